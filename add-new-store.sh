@@ -18,6 +18,7 @@ STORE_NUMBER=$1
 STORE_NAME=${2:-"Store S${STORE_NUMBER}"}
 STORE_ID="store-s${STORE_NUMBER}"
 API_KEY="store-s${STORE_NUMBER}-key"
+CENTRAL_KEY="store-s${STORE_NUMBER}-central-key" # Ligada a la tienda en la API central
 
 # Calcular puertos (incrementales)
 BACKEND_PORT=$((8080 + STORE_NUMBER + 2))  # 8083, 8087, 8088, etc.
@@ -54,18 +55,19 @@ BACKEND_CONFIG="
   store-s${STORE_NUMBER}:
     build:
       context: ./packages/backend
-      dockerfile: ./services/store-s1/Dockerfile  # Reutiliza el mismo Dockerfile
+      dockerfile: ./services/store/Dockerfile  # Reutiliza el mismo Dockerfile
     container_name: store-s${STORE_NUMBER}
     restart: unless-stopped
     ports:
       - \"${BACKEND_PORT}:8083\"
     environment:
+      - STORE_ID=${STORE_ID}
       - PORT=8083
       - LOG_LEVEL=debug
       - ENVIRONMENT=development
       - API_KEYS=${API_KEY},demo
       - CENTRAL_API_URL=http://inventory-management-system:8081
-      - CENTRAL_API_KEY=demo
+      - CENTRAL_API_KEY=${CENTRAL_KEY}
       - DATA_DIR=/app/data
       # Legacy full sync interval (fallback)
       - SYNC_INTERVAL_MINUTES=5
@@ -130,6 +132,11 @@ echo "$BACKEND_CONFIG" >> temp_compose.yml
 tail -n +$((BACKEND_INSERT_LINE + 1)) docker-compose.yml >> temp_compose.yml
 mv temp_compose.yml docker-compose.yml
 
+echo "🔧 Registrando la clave de la tienda en la API central..."
+# La clave debe ser válida (API_KEYS) y estar ligada a la tienda (API_KEY_STORES)
+sed -i "s/^\(      - API_KEYS=demo,central-api-key.*\)$/\1,${CENTRAL_KEY}/" docker-compose.yml
+sed -i "s/^\(      - API_KEY_STORES=.*\)$/\1,${CENTRAL_KEY}:${STORE_ID}/" docker-compose.yml
+
 echo "🔧 Agregando configuración del frontend..."
 # Recalcular línea del frontend (el archivo cambió)
 FRONTEND_INSERT_LINE=$(grep -n "# OpenTelemetry Collector" docker-compose.yml | head -1 | cut -d: -f1)
@@ -155,8 +162,8 @@ echo ""
 echo "# Construir ambos:"
 echo "docker-compose build store-s${STORE_NUMBER} frontend-s${STORE_NUMBER}"
 echo ""
-echo "# Ejecutar la nueva tienda:"
-echo "docker-compose up -d store-s${STORE_NUMBER} frontend-s${STORE_NUMBER}"
+echo "# Ejecutar la nueva tienda (la API central se recrea para cargar su clave):"
+echo "docker-compose up -d inventory-management-system store-s${STORE_NUMBER} frontend-s${STORE_NUMBER}"
 echo ""
 echo "🌐 URLs de acceso:"
 echo "=================="
@@ -169,5 +176,6 @@ echo "============================"
 echo "Store ID: ${STORE_ID}"
 echo "Store Name: ${STORE_NAME}"
 echo "API Key: ${API_KEY}"
+echo "Central API Key: ${CENTRAL_KEY}"
 echo ""
 echo "💾 Backup guardado en: docker-compose.yml.backup"
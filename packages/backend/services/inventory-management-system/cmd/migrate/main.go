@@ -0,0 +1,128 @@
+// Command migrate copies the central API's persisted state (products and
+// their metadata, idempotency entries, events and event consumers) between
+// storage backends and verifies the copy by counts and checksums. With
+// -follow it keeps copying while the source changes, so a cutover only waits
+// for the last pass: put the central API in maintenance mode, wait for a pass
+// that reports no changes, then restart it on the target.
+//
+//	go run ./cmd/migrate -from json:data=./data/inventory.json,events=./data/events.json \
+//	    -to json:data=/mnt/new/inventory.json,events=/mnt/new/events.json
+//	go run ./cmd/migrate -from json -to json:data=/mnt/new/inventory.json -follow -interval 2s
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"inventory-management-api/internal/migrate"
+)
+
+func main() {
+	from := flag.String("from", "", "source backend, e.g. json:data=PATH,events=PATH (kinds: "+strings.Join(migrate.Kinds(), ", ")+")")
+	to := flag.String("to", "", "target backend, same form as -from")
+	overwrite := flag.Bool("overwrite", false, "replace a target that already holds data")
+	dryRun := flag.Bool("dry-run", false, "summarize the source without writing the target")
+	follow := flag.Bool("follow", false, "keep copying while the source changes, until interrupted")
+	interval := flag.Duration("interval", 5*time.Second, "how often -follow checks the source for changes")
+	jsonOutput := flag.Bool("json", false, "print each pass as JSON")
+	flag.Parse()
+
+	if *from == "" || (*to == "" && !*dryRun) {
+		fail("-from and -to are required")
+	}
+	if *follow && *dryRun {
+		fail("-follow cannot be combined with -dry-run")
+	}
+	if *interval <= 0 {
+		fail("-interval must be positive")
+	}
+
+	source, err := migrate.Open(*from)
+	if err != nil {
+		fail(err.Error())
+	}
+	target := source
+	if *to != "" {
+		if target, err = migrate.Open(*to); err != nil {
+			fail(err.Error())
+		}
+		if target.Name() == source.Name() {
+			fail("-from and -to are the same backend")
+		}
+	}
+
+	opts := migrate.Options{Overwrite: *overwrite, DryRun: *dryRun}
+	report := func(result migrate.Result) {
+		printResult(result, *dryRun, *jsonOutput)
+	}
+
+	if *follow {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		slog.Info("Following source", "source", source.Name(), "target", target.Name(), "interval", *interval)
+		if err := migrate.Follow(ctx, source, target, opts, *interval, report); err != nil {
+			exitOnError(err)
+		}
+		slog.Info("Stopped following source")
+		return
+	}
+
+	result, err := migrate.Migrate(source, target, opts)
+	if err != nil {
+		exitOnError(err)
+	}
+	report(result)
+	if !result.Verified() {
+		os.Exit(1)
+	}
+}
+
+// printResult prints a pass as a short report or as JSON
+func printResult(result migrate.Result, dryRun, jsonOutput bool) {
+	if jsonOutput {
+		encoded, _ := json.Marshal(result)
+		fmt.Println(string(encoded))
+		return
+	}
+
+	source := result.Source
+	fmt.Printf("source: %d products, %d idempotency entries, %d events (next offset %d), %d consumers\n",
+		source.Products, source.Idempotency, source.Events, source.NextOffset, source.Consumers)
+	fmt.Printf("        products %s, events %s\n", source.ProductsChecksum[:12], source.EventsChecksum[:12])
+	for _, skipped := range result.Skipped {
+		fmt.Printf("skipped: %s, the target cannot keep them\n", skipped)
+	}
+	switch {
+	case dryRun:
+		fmt.Println("dry run, target not written")
+	case result.Verified():
+		fmt.Printf("verified: target matches source (%s)\n", result.Duration)
+	default:
+		fmt.Println("MISMATCH after copy:")
+		for _, mismatch := range result.Mismatches {
+			fmt.Println("  " + mismatch)
+		}
+	}
+}
+
+func exitOnError(err error) {
+	if errors.Is(err, migrate.ErrTargetNotEmpty) {
+		fail(err.Error() + "; pass -overwrite to replace it")
+	}
+	fail(err.Error())
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, "migrate:", message)
+	os.Exit(2)
+}
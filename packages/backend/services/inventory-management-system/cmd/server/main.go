@@ -2,22 +2,42 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/archive"
 	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/currency"
+	"inventory-management-api/internal/eventfilters"
 	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/featureflags"
 	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/leader"
 	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/openapi"
+	"inventory-management-api/internal/orders"
+	"inventory-management-api/internal/partitions"
+	"inventory-management-api/internal/reports"
+	"inventory-management-api/internal/runtimeconfig"
 	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/snapshots"
+	"inventory-management-api/internal/stocktakes"
 	"inventory-management-api/internal/telemetry"
+	"inventory-management-api/internal/tlsconfig"
+	"inventory-management-api/internal/transfers"
 
 	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
 )
 
 func main() {
@@ -30,6 +50,7 @@ func main() {
 	ctx := context.Background()
 	otelTelemetry := &telemetry.Telemetry{}
 	otelTelemetry.InitMetrics("inventory-management-api", &ctx)
+	otelTelemetry.InitTracing("inventory-management-api")
 	slog.Info("OpenTelemetry telemetry initialized")
 
 	// Initialize Inventory API telemetry
@@ -40,6 +61,36 @@ func main() {
 	}
 	slog.Info("Inventory API telemetry initialized successfully")
 
+	// Error budgets per endpoint group, exposed as burn-rate metrics
+	sloObjectives, err := telemetry.ParseSLOObjectives(cfg.SLOObjectives)
+	if err != nil {
+		slog.Error("Invalid SLO objectives", "error", err)
+		return
+	}
+	sloTracker := telemetry.NewSLOTracker(sloObjectives)
+	if err := apiTelemetry.SetSLOTracker(sloTracker); err != nil {
+		slog.Warn("Failed to register SLO metrics", "error", err)
+	}
+
+	// A catalog split by product ID hash runs one instance per partition and
+	// a router in front of them
+	partitionMap, partition, err := parsePartitions(cfg)
+	if err != nil {
+		slog.Error("Invalid partition configuration", "error", err)
+		return
+	}
+	if routerMode, _ := strconv.ParseBool(cfg.PartitionRouter); routerMode {
+		if partitionMap == nil {
+			slog.Error("Invalid partition configuration", "error", "PARTITION_ROUTER needs PARTITION_MAP")
+			return
+		}
+		if err := runPartitionRouter(cfg, partitionMap, apiTelemetry); err != nil {
+			slog.Error("Partition router failed", "error", err)
+		}
+		otelTelemetry.Close()
+		return
+	}
+
 	r := mux.NewRouter()
 
 	// Initialize services
@@ -50,77 +101,444 @@ func main() {
 	}
 	slog.Info("Inventory service initialized successfully")
 
-	// Initialize event queue
-	maxEvents, _ := strconv.Atoi(cfg.MaxEventsInQueue)
-	if maxEvents <= 0 {
-		maxEvents = 10000
+	// Instances sharing the data files elect one leader that writes them; the
+	// others follow, serving reads from the files and forwarding writes
+	var elector *leader.Elector
+	if cfg.LeaderLockFile != "" {
+		retryInterval, err := time.ParseDuration(cfg.LeaderRetryInterval)
+		if err != nil || retryInterval <= 0 {
+			slog.Warn("Invalid leader retry interval, using default", "provided", cfg.LeaderRetryInterval, "error", err)
+			retryInterval = time.Second
+		}
+		elector = leader.New(leader.Config{
+			LockFile:      cfg.LeaderLockFile,
+			AdvertiseURL:  cfg.LeaderAdvertiseURL,
+			InstanceID:    cfg.LeaderInstanceID,
+			RetryInterval: retryInterval,
+		})
+		elected, err := elector.Campaign()
+		if err != nil {
+			slog.Error("Failed to take part in leader election", "error", err)
+			return
+		}
+		if !elected {
+			inventoryService.EnterStandby()
+		}
 	}
+	following := elector != nil && inventoryService.IsStandby()
 
-	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
-		FilePath:  cfg.EventsFilePath,
-		MaxEvents: maxEvents,
-		Logger:    slog.Default(),
-	})
+	// Initialize event queue
+	eventQueue, err := events.NewEventQueue(eventQueueConfig(cfg, cfg.EventsFilePath))
 	if err != nil {
 		slog.Error("Failed to initialize event queue", "error", err)
 		return
 	}
 	slog.Info("Event queue initialized successfully")
+	if following {
+		eventQueue.SetStandby(true)
+	}
+
+	// Expose the oldest retained event and what rotation dropped
+	if err := eventQueue.RegisterMetrics(); err != nil {
+		slog.Warn("Failed to register event queue metrics", "error", err)
+	}
 
 	// Set event queue in inventory service for event publishing
 	inventoryService.SetEventQueue(eventQueue)
 
+	// Expose per-shard update queue depth
+	if err := apiTelemetry.RegisterQueueDepthGauge(inventoryService.ShardQueueDepths, inventoryService.QueueHighWaterMark()); err != nil {
+		slog.Warn("Failed to register queue depth metric", "error", err)
+	}
+
+	// Expose stock levels and units sold
+	if err := inventoryService.RegisterBusinessMetrics(); err != nil {
+		slog.Warn("Failed to register business metrics", "error", err)
+	}
+
+	// Initialize currency rates provider for multi-currency pricing
+	inventoryService.SetRatesProvider(currency.NewStaticRatesProviderFromConfig(cfg.BaseCurrency, cfg.CurrencyRates))
+
+	// Feature flags gating experimental behaviors per environment and store
+	featureFlags := featureflags.New(cfg.FeatureFlags, cfg.FeatureFlagOverrides)
+	inventoryService.SetFeatureFlags(featureFlags)
+
+	// Point-in-time inventory snapshots for end-of-day accounting
+	snapshotStore, err := snapshots.NewStore(cfg.SnapshotsDir)
+	if err != nil {
+		slog.Error("Failed to initialize snapshot store", "error", err)
+		return
+	}
+
+	// Named event filters stores subscribe to instead of the whole stream
+	eventFilters, err := eventfilters.NewStore(cfg.EventFiltersFilePath)
+	if err != nil {
+		slog.Error("Failed to initialize event filters", "error", err)
+		return
+	}
+
+	// History of stock transfers between stores
+	maxTransferHistory, err := strconv.Atoi(cfg.MaxTransferHistory)
+	if err != nil || maxTransferHistory < 0 {
+		slog.Warn("Invalid max transfer history, using default", "configured", cfg.MaxTransferHistory, "default", 10000)
+		maxTransferHistory = 10000
+	}
+	transferLog, err := transfers.NewStore(cfg.TransfersFilePath, maxTransferHistory)
+	if err != nil {
+		slog.Error("Failed to initialize transfer history", "error", err)
+		return
+	}
+	inventoryService.SetTransferLog(transferLog)
+
+	// Cycle count sessions; open ones freeze decrements of their products
+	stocktakeStore, err := stocktakes.NewStore(cfg.StocktakesFilePath)
+	if err != nil {
+		slog.Error("Failed to initialize stocktakes", "error", err)
+		return
+	}
+	inventoryService.SetStocktakes(stocktakeStore)
+
+	// Orders and the stock they hold until committed or cancelled
+	orderStore, err := orders.NewStore(cfg.OrdersFilePath)
+	if err != nil {
+		slog.Error("Failed to initialize orders", "error", err)
+		return
+	}
+	inventoryService.SetOrders(orderStore)
+
+	// Daily sales reports aggregated from the event stream
+	reportStore, err := reports.NewStore(cfg.ReportsDir)
+	if err != nil {
+		slog.Error("Failed to initialize sales reports", "error", err)
+		return
+	}
+	reportAggregator := reports.NewAggregator(eventQueue, reportStore)
+	if !following {
+		reportAggregator.Start()
+	}
+
+	// Archive events the queue rotates or compacts away
+	archiveSink, err := archive.NewSink(archive.Config{
+		Sink:            cfg.ArchiveSink,
+		Dir:             cfg.ArchiveDir,
+		Bucket:          cfg.ArchiveBucket,
+		Endpoint:        cfg.ArchiveEndpoint,
+		Region:          cfg.ArchiveRegion,
+		AccessKeyID:     cfg.ArchiveAccessKeyID,
+		SecretAccessKey: cfg.ArchiveSecretAccessKey,
+		SessionToken:    cfg.ArchiveSessionToken,
+	})
+	if err != nil {
+		slog.Error("Failed to initialize event archive", "error", err)
+		return
+	}
+	var archiver *archive.Archiver
+	if archiveSink != nil {
+		archiver = archive.NewArchiver(archiveSink, cfg.ArchivePrefix)
+		archiver.Start()
+		eventQueue.SetRotatedCallback(archiver.Enqueue)
+		slog.Info("Event archival enabled", "sink", archiveSink.Name(), "prefix", cfg.ArchivePrefix)
+	}
+
+	// Background admin jobs; modules register their job types on the manager
+	jobManager, err := jobs.NewManager(jobs.ParseConfig(cfg))
+	if err != nil {
+		slog.Error("Failed to initialize background jobs", "error", err)
+		return
+	}
+
 	// Initialize handlers
 	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
 	eventsHandler := handlers.NewEventsHandler(eventQueue, slog.Default())
+	eventsHandler.SetFilters(eventFilters)
+	eventsHandler.SetCategoryExpansion(inventoryService.CategoriesWithDescendants)
 	healthHandler := handlers.NewHealthHandler()
+	if elector != nil {
+		healthHandler.SetElector(elector)
+	}
 	adminHandler := handlers.NewAdminHandler(inventoryService)
+	featureFlagsHandler := handlers.NewFeatureFlagsHandler(featureFlags)
+	snapshotsHandler := handlers.NewSnapshotsHandler(inventoryService, snapshotStore)
+	restoreHandler := handlers.NewRestoreHandler(inventoryService, snapshotStore, eventQueue)
+	eventFiltersHandler := handlers.NewEventFiltersHandler(eventFilters)
+	categoriesHandler := handlers.NewCategoriesHandler(inventoryService)
+	forecastHandler := handlers.NewForecastHandler(inventoryService, eventQueue)
+	reportsHandler := handlers.NewReportsHandler(reportStore)
+	dashboardHandler := handlers.NewDashboardHandler(inventoryService, eventQueue)
+	transfersHandler := handlers.NewTransfersHandler(inventoryService)
+	stocktakesHandler := handlers.NewStocktakesHandler(inventoryService)
+	ordersHandler := handlers.NewOrdersHandler(inventoryService)
+	archiveHandler := handlers.NewArchiveHandler(archiver)
+	jobsHandler := handlers.NewJobsHandler(jobManager)
 	slog.Debug("HTTP handlers initialized")
 
 	// Create telemetry middleware
 	telemetryMiddleware := telemetry.NewTelemetryMiddleware(apiTelemetry)
 
-	// Apply telemetry middleware to all routes first
+	// Assign request IDs first so every later log line and error response carries one
+	r.Use(middleware.RequestIDMiddleware)
+
+	// Restrict route prefixes to allowed networks before any other work is done
+	ipFilterConfig, err := middleware.ParseIPFilterConfig(cfg)
+	if err != nil {
+		slog.Error("Invalid IP filter configuration", "error", err)
+		return
+	}
+	if ipFilterConfig.Enabled() {
+		r.Use(middleware.IPFilterMiddleware(ipFilterConfig))
+	}
+
+	// Bind verified store client certificates to X-Store-ID before anything reads it
+	r.Use(middleware.ClientCertMiddleware)
+
+	// Inject faults for integration tests when enabled; outside tracing so a
+	// reset connection can be hijacked from the raw writer
+	chaosConfig := middleware.ParseChaosConfig(cfg)
+	if chaosConfig.Enabled {
+		r.Use(middleware.ChaosMiddleware(chaosConfig))
+	}
+
+	// Apply tracing and telemetry middleware to all routes
+	r.Use(telemetry.TracingMiddleware)
 	r.Use(telemetryMiddleware.Middleware)
 
-	// Setup rate limiting middleware
+	// Deadlines per endpoint group; inside telemetry so timeouts count as 504s
+	r.Use(middleware.TimeoutMiddleware(middleware.ParseTimeoutConfig(cfg)))
+
+	// Followers forward what they cannot serve from the leader's files
+	if elector != nil {
+		r.Use(middleware.LeaderMiddleware(elector, followerServesLocally))
+	}
+
+	// Setup rate limiting; versioned routes apply it after authentication so
+	// callers are counted by principal, not by headers they set themselves
 	rateLimitConfig := middleware.ParseRateLimitConfig(cfg)
 	var rateLimiter *middleware.RateLimiter
 	if rateLimitConfig.Enabled {
 		rateLimiter = middleware.NewRateLimiter(rateLimitConfig)
-		r.Use(middleware.RateLimitMiddleware(rateLimiter))
 		slog.Info("Rate limiting middleware enabled")
 	} else {
 		slog.Info("Rate limiting middleware disabled")
 	}
 
+	// Compress large responses such as full listings for clients that accept gzip
+	compressionConfig := middleware.ParseCompressionConfig(cfg)
+	if compressionConfig.Enabled {
+		r.Use(middleware.CompressionMiddleware(compressionConfig.MinBytes))
+	}
+
 	// Initialize rate limiting status handler
 	rateLimitStatusHandler := handlers.NewRateLimitStatusHandler(rateLimiter)
 
-	// Apply auth middleware to v1 API routes
-	v1 := r.PathPrefix("/v1").Subrouter()
-	v1.Use(middleware.AuthMiddleware)
+	// Lock out IP addresses and API keys after repeated authentication failures
+	authLockoutConfig := middleware.ParseAuthLockoutConfig(cfg)
+	var authLockout *middleware.AuthLockout
+	if authLockoutConfig.Enabled {
+		authLockout = middleware.NewAuthLockout(authLockoutConfig)
+		defer authLockout.Stop()
+		if err := authLockout.RegisterMetrics(); err != nil {
+			slog.Warn("Failed to register auth lockout metrics", "error", err)
+		}
+	}
+	authLockoutsHandler := handlers.NewAuthLockoutsHandler(authLockout)
 
-	// Central Inventory API routes (v1) - specific routes first
-	v1.HandleFunc("/inventory/updates", inventoryHandler.UpdateInventory).Methods("POST") // Not Use PATCH because it's not a partial update
-	v1.HandleFunc("/inventory/events", eventsHandler.GetEvents).Methods("GET")
-	v1.HandleFunc("/inventory/{productId}", inventoryHandler.GetProduct).Methods("GET")
-	v1.HandleFunc("/inventory", inventoryHandler.ListProducts).Methods("GET")
+	// Rate limits, worker count and event retention can change without a restart
+	runtimeConfig := runtimeconfig.NewManager(cfg, rateLimiter, inventoryService, eventQueue)
+	runtimeConfigHandler := handlers.NewRuntimeConfigHandler(runtimeConfig)
 
-	// Admin API routes (v1) - require admin authentication
-	adminV1 := r.PathPrefix("/v1/admin").Subrouter()
-	adminV1.Use(middleware.AdminAuthMiddleware)
-	adminV1.HandleFunc("/products/set", adminHandler.SetProducts).Methods("PUT") // Not Use PATCH because it's not a partial update
-	adminV1.HandleFunc("/products/create", adminHandler.CreateProducts).Methods("POST")
-	adminV1.HandleFunc("/products/delete", adminHandler.DeleteProducts).Methods("DELETE")
+	// A partition refuses writes for products another partition owns
+	if partition != nil {
+		inventoryHandler.SetPartition(*partition)
+		adminHandler.SetPartition(*partition)
+		slog.Info("Serving partition", "partition", partition.ID, "hash_start", partition.Start, "hash_end", partition.End)
+	}
 
-	// Rate limiting status endpoints (admin only)
-	adminV1.HandleFunc("/rate-limit/status", rateLimitStatusHandler.GetRateLimitStatus).Methods("GET")
-	adminV1.HandleFunc("/rate-limit/reset", rateLimitStatusHandler.ResetRateLimits).Methods("POST")
+	// Request payload limits per route group
+	bodyLimitConfig := middleware.ParseBodyLimitConfig(cfg)
+	inventoryHandler.SetMaxBatchItems(bodyLimitConfig.MaxBatchItems)
+	adminHandler.SetMaxItems(bodyLimitConfig.MaxAdminItems)
+	adminHandler.SetJobs(jobManager, bodyLimitConfig.MaxJobItems)
+	restoreHandler.SetJobs(jobManager)
+
+	// Request models may only use rules the validator knows
+	if err := handlers.CheckRequestModels(); err != nil {
+		slog.Error("Invalid request validation rules", "error", err)
+		return
+	}
+
+	// Every v1 route requires the permission annotated on it in the OpenAPI routes
+	if err := middleware.ValidateAuthConfig(); err != nil {
+		slog.Error("Invalid authorization configuration", "error", err)
+		return
+	}
+	apiRoutes := openapi.Routes()
+	authorize := middleware.AuthorizeMiddleware(openapi.RoutePermissions(apiRoutes))
+
+	// Read-only maintenance refuses writes, except the routes marked KeepOpen
+	maintenance := middleware.NewMaintenance(cfg)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenance)
+	sloHandler := handlers.NewSLOHandler(sloTracker)
+	readOnly := middleware.MaintenanceMiddleware(maintenance, openapi.MaintenanceRoutes(apiRoutes))
+
+	// Deprecation and Sunset headers announce /v1 routes slated for removal
+	deprecations, err := openapi.RouteDeprecations(apiRoutes)
+	if err != nil {
+		slog.Error("Invalid route deprecation", "error", err)
+		return
+	}
+	deprecate := middleware.DeprecationMiddleware(deprecations)
+
+	// apiChain applies the middleware every versioned route group shares
+	apiChain := func(sub *mux.Router, version string, maxBodyBytes int64) *mux.Router {
+		sub.Use(middleware.APIVersionMiddleware(version))
+		sub.Use(deprecate)
+		if authLockout != nil {
+			sub.Use(middleware.AuthLockoutMiddleware(authLockout, ipFilterConfig.ClientIP))
+		}
+		sub.Use(middleware.AuthMiddleware)
+		if rateLimiter != nil {
+			sub.Use(middleware.RateLimitMiddleware(rateLimiter, ipFilterConfig.ClientIP))
+		}
+		sub.Use(authorize)
+		sub.Use(readOnly)
+		sub.Use(middleware.BodyLimitMiddleware(maxBodyBytes))
+		return sub
+	}
+
+	// Every version serves the same handlers; they branch on
+	// apiversion.FromContext where a version changes the contract
+	for _, version := range apiversion.Versions {
+		// Central Inventory API routes - specific routes first
+		api := apiChain(r.PathPrefix("/"+version).Subrouter(), version, bodyLimitConfig.MaxBodyBytes)
+		api.HandleFunc("/inventory/updates", inventoryHandler.UpdateInventory).Methods("POST") // Not Use PATCH because it's not a partial update
+		api.HandleFunc("/inventory/batch-get", inventoryHandler.BatchGetProducts).Methods("POST")
+		api.HandleFunc("/inventory/versions", inventoryHandler.GetProductVersions).Methods("GET")
+		api.HandleFunc("/inventory/snapshot", inventoryHandler.GetCatalogSnapshot).Methods("GET")
+		api.HandleFunc("/inventory/events", eventsHandler.GetEvents).Methods("GET")
+		api.HandleFunc("/inventory/events/commit", eventsHandler.CommitEventOffset).Methods("POST")
+		api.HandleFunc("/inventory/transfers", transfersHandler.CreateTransfer).Methods("POST")
+		api.HandleFunc("/inventory/transfers", transfersHandler.ListTransfers).Methods("GET")
+		api.HandleFunc("/inventory/{productId}/price", inventoryHandler.GetProductPrice).Methods("GET")
+		api.HandleFunc("/inventory/{productId}/forecast", forecastHandler.GetProductForecast).Methods("GET")
+		api.HandleFunc("/inventory/{productId}/backorders", inventoryHandler.GetProductBackorders).Methods("GET")
+		api.HandleFunc("/inventory/{productId}", inventoryHandler.GetProduct).Methods("GET")
+		api.HandleFunc("/inventory", inventoryHandler.ListProducts).Methods("GET")
+		api.HandleFunc("/categories", categoriesHandler.ListCategories).Methods("GET")
+		api.HandleFunc("/orders", ordersHandler.CreateOrder).Methods("POST")
+		api.HandleFunc("/orders/{id}", ordersHandler.GetOrder).Methods("GET")
+		api.HandleFunc("/orders/{id}/commit", ordersHandler.CommitOrder).Methods("POST")
+		api.HandleFunc("/orders/{id}/cancel", ordersHandler.CancelOrder).Methods("POST")
+
+		// Set jobs take payloads far above the admin limit, so they get their own
+		// subrouter with the same authentication
+		adminJobs := apiChain(r.PathPrefix("/"+version+"/admin/products/set/jobs").Subrouter(), version, bodyLimitConfig.MaxJobBodyBytes)
+		adminJobs.HandleFunc("", adminHandler.StartSetProductsJob).Methods("POST")
+
+		// Admin API routes - require a role granting each route's permission
+		admin := apiChain(r.PathPrefix("/"+version+"/admin").Subrouter(), version, bodyLimitConfig.MaxAdminBodyBytes)
+		admin.HandleFunc("/products/set", adminHandler.SetProducts).Methods("PUT") // Not Use PATCH because it's not a partial update
+		admin.HandleFunc("/products/create", adminHandler.CreateProducts).Methods("POST")
+		admin.HandleFunc("/products/delete", adminHandler.DeleteProducts).Methods("DELETE")
+		admin.HandleFunc("/products/{productId}/assets", adminHandler.GetProductAssets).Methods("GET")
+		admin.HandleFunc("/products/{productId}/assets", adminHandler.SetProductAssets).Methods("PUT")
+		admin.HandleFunc("/products/{productId}/assets", adminHandler.PutProductAsset).Methods("POST")
+		admin.HandleFunc("/products/{productId}/assets", adminHandler.RemoveProductAsset).Methods("DELETE")
+
+		// Inventory snapshots (admin only)
+		admin.HandleFunc("/snapshots", snapshotsHandler.CreateSnapshot).Methods("POST")
+		admin.HandleFunc("/snapshots", snapshotsHandler.ListSnapshots).Methods("GET")
+		admin.HandleFunc("/snapshots/{id}", snapshotsHandler.GetSnapshot).Methods("GET")
+		admin.HandleFunc("/restore", restoreHandler.Restore).Methods("POST")
+
+		// Sales velocity and stock-out forecast for purchasing (admin only)
+		admin.HandleFunc("/forecast", forecastHandler.GetForecastReport).Methods("GET")
+
+		// Aggregated numbers for the admin dashboard (admin only)
+		admin.HandleFunc("/dashboard", dashboardHandler.GetDashboard).Methods("GET")
+
+		// Daily sales reports, JSON or CSV (admin only)
+		admin.HandleFunc("/reports/sales", reportsHandler.GetSalesReport).Methods("GET")
+
+		// Event queue inspection and compaction (admin only)
+		admin.HandleFunc("/events/stats", eventsHandler.GetEventStats).Methods("GET")
+		admin.HandleFunc("/events/compact", eventsHandler.CompactEvents).Methods("POST")
+		admin.HandleFunc("/stores/{storeId}/replay", eventsHandler.ReplayStore).Methods("POST")
+
+		// Product lock contention, to debug stuck updates (admin only)
+		admin.HandleFunc("/locks", adminHandler.GetLockStats).Methods("GET")
+
+		// Archived event segments for offline analytics and store rebuilds (admin only)
+		admin.HandleFunc("/archive/segments", archiveHandler.ListSegments).Methods("GET")
+		admin.HandleFunc("/archive/segments/{id}", archiveHandler.GetSegment).Methods("GET")
+		admin.HandleFunc("/archive/events", archiveHandler.GetEvents).Methods("GET")
+
+		// Cycle counts that freeze decrements and apply variances on close (admin only)
+		admin.HandleFunc("/stocktakes", stocktakesHandler.StartStocktake).Methods("POST")
+		admin.HandleFunc("/stocktakes", stocktakesHandler.ListStocktakes).Methods("GET")
+		admin.HandleFunc("/stocktakes/{id}", stocktakesHandler.GetStocktake).Methods("GET")
+		admin.HandleFunc("/stocktakes/{id}/counts", stocktakesHandler.RecordCounts).Methods("POST")
+		admin.HandleFunc("/stocktakes/{id}/close", stocktakesHandler.CloseStocktake).Methods("POST")
+		admin.HandleFunc("/stocktakes/{id}/cancel", stocktakesHandler.CancelStocktake).Methods("POST")
+
+		// Named event filters and their store allocation (admin only)
+		admin.HandleFunc("/event-filters", eventFiltersHandler.ListFilters).Methods("GET")
+		admin.HandleFunc("/event-filters/{id}", eventFiltersHandler.GetFilter).Methods("GET")
+		admin.HandleFunc("/event-filters/{id}", eventFiltersHandler.PutFilter).Methods("PUT")
+		admin.HandleFunc("/event-filters/{id}", eventFiltersHandler.DeleteFilter).Methods("DELETE")
+
+		// Product category tree (admin only); changes reach stores as events
+		admin.HandleFunc("/categories/{id}", categoriesHandler.PutCategory).Methods("PUT")
+		admin.HandleFunc("/categories/{id}", categoriesHandler.DeleteCategory).Methods("DELETE")
+
+		// Rate limiting status endpoints (admin only)
+		admin.HandleFunc("/rate-limit/status", rateLimitStatusHandler.GetRateLimitStatus).Methods("GET")
+		admin.HandleFunc("/rate-limit/reset", rateLimitStatusHandler.ResetRateLimits).Methods("POST")
+		admin.HandleFunc("/rate-limit/lockouts", authLockoutsHandler.ListLockouts).Methods("GET")
+		admin.HandleFunc("/rate-limit/lockouts/{id}", authLockoutsHandler.Unblock).Methods("DELETE")
+
+		// Runtime configuration (admin only)
+		admin.HandleFunc("/config", runtimeConfigHandler.GetConfig).Methods("GET")
+		admin.HandleFunc("/config", runtimeConfigHandler.PatchConfig).Methods("PATCH")
+
+		// Feature flags (admin only)
+		admin.HandleFunc("/feature-flags", featureFlagsHandler.ListFlags).Methods("GET")
+		admin.HandleFunc("/feature-flags/{flag}", featureFlagsHandler.SetFlag).Methods("PUT")
+		admin.HandleFunc("/feature-flags/{flag}", featureFlagsHandler.ClearFlag).Methods("DELETE")
+
+		// Read-only maintenance mode
+		admin.HandleFunc("/maintenance", maintenanceHandler.GetMaintenance).Methods("GET")
+		admin.HandleFunc("/maintenance", maintenanceHandler.SetMaintenance).Methods("PUT")
+		admin.HandleFunc("/maintenance/recount", adminHandler.RecountMetadata).Methods("POST")
+
+		// Error budgets and burn-rate alerts per endpoint group
+		admin.HandleFunc("/slo", sloHandler.GetSLOStatus).Methods("GET")
+
+		// Background jobs of every registered type
+		admin.HandleFunc("/jobs", jobsHandler.StartJob).Methods("POST")
+		admin.HandleFunc("/jobs", jobsHandler.ListJobs).Methods("GET")
+		admin.HandleFunc("/jobs/{id}", jobsHandler.GetJob).Methods("GET")
+		admin.HandleFunc("/jobs/{id}/cancel", jobsHandler.CancelJob).Methods("POST")
+	}
 
 	// Health check endpoint (no auth required)
 	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
 
+	// API documentation (no auth required)
+	docsHandler, err := handlers.NewDocsHandler(openapi.Build("1.0.0", apiRoutes))
+	if err != nil {
+		slog.Error("Failed to build OpenAPI document", "error", err)
+		return
+	}
+	docs := r.NewRoute().Subrouter()
+	if rateLimiter != nil {
+		docs.Use(middleware.RateLimitMiddleware(rateLimiter, ipFilterConfig.ClientIP)) // By client IP
+	}
+	docs.HandleFunc("/openapi.json", docsHandler.OpenAPISpec).Methods("GET")
+	docs.HandleFunc("/docs", docsHandler.SwaggerUI).Methods("GET")
+	if missing := openapi.UndocumentedRoutes(r, apiRoutes, "GET /openapi.json", "GET /docs"); len(missing) > 0 {
+		slog.Warn("Routes missing from OpenAPI document", "routes", missing)
+	}
+
 	slog.Info("Starting HTTP server",
 		"port", cfg.Port,
 		"environment", cfg.Environment)
@@ -129,6 +547,7 @@ func main() {
 		"v1_endpoints", []string{
 			"POST /v1/inventory/updates (single & batch)",
 			"GET /v1/inventory/{productId}",
+			"GET /v1/inventory/{productId}/price?currency=<ISO code>",
 			"GET /v1/inventory (with replication support)",
 			"GET /v1/inventory/events (event streaming)",
 		},
@@ -144,22 +563,88 @@ func main() {
 		},
 		"system_endpoints", []string{
 			"GET /health",
+			"GET /openapi.json",
+			"GET /docs",
 		})
 
+	// Serve HTTPS, optionally verifying store client certificates, when a certificate is configured
+	tlsConfig, err := tlsconfig.ServerConfig(cfg)
+	if err != nil {
+		slog.Error("Failed to configure TLS", "error", err)
+		os.Exit(1)
+	}
+
 	// Create HTTP server
 	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: r,
+		Addr:      ":" + cfg.Port,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+	}
+
+	// A follower keeps its reads current until it wins the election, then
+	// takes over the files from the previous leader
+	if elector != nil {
+		reloadInterval, err := time.ParseDuration(cfg.FollowerReloadInterval)
+		if err != nil || reloadInterval <= 0 {
+			slog.Warn("Invalid follower reload interval, using default", "provided", cfg.FollowerReloadInterval, "error", err)
+			reloadInterval = time.Second
+		}
+		elected := make(chan struct{})
+		promoted := make(chan struct{})
+		if following {
+			go func() {
+				defer close(promoted)
+				ticker := time.NewTicker(reloadInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-elected:
+						promoteToLeader(eventQueue, inventoryService, reportAggregator, []reloader{
+							{"transfer history", transferLog.Reload},
+							{"stocktakes", stocktakeStore.Reload},
+							{"orders", orderStore.Reload},
+							{"event filters", eventFilters.Reload},
+							{"jobs", jobManager.Reload},
+							{"sales reports", reportStore.Reload},
+						})
+						return
+					case <-ticker.C:
+						followLeader(eventQueue, inventoryService, eventFilters)
+					}
+				}
+			}()
+		}
+		elector.Start(func() {
+			close(elected)
+			<-promoted
+		})
+		defer elector.Stop()
 	}
 
 	// Start server in a goroutine
 	go func() {
-		slog.Info("Server ready to accept connections", "address", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Info("Server ready to accept connections", "address", server.Addr, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			// Certificates come from TLSConfig.GetCertificate so they can be rotated
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("Server failed to start", "error", err)
 		}
 	}()
 
+	// SIGHUP re-reads .env and applies the runtime-tunable settings it contains
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			reloadRuntimeConfig(runtimeConfig)
+		}
+	}()
+
 	// Wait for interrupt signal to gracefully shutdown the server
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -170,19 +655,167 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	// Shutdown event queue first
+	// Stop background jobs; a running job stops after its current chunk
+	jobManager.Stop()
+
+	// Stop accepting updates (503) and drain the worker pool; requests already
+	// queued get their results so in-flight handlers can finish
+	if err := inventoryService.Shutdown(ctx); err != nil {
+		slog.Error("Inventory service drain incomplete", "error", err)
+	}
+
+	// Shutdown HTTP server once in-flight requests complete
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Server forced to shutdown", "error", err)
+	}
+
+	// Save the sales report batch in progress before the queue closes
+	reportAggregator.Stop()
+
+	// Close the event queue after the workers that publish to it
 	if err := eventQueue.Close(); err != nil {
 		slog.Error("Error closing event queue", "error", err)
 	}
 
-	// Shutdown telemetry
+	// Upload the segments rotated before the queue closed
+	if archiver != nil {
+		if err := archiver.Stop(ctx); err != nil {
+			slog.Error("Event archive upload incomplete", "error", err)
+		}
+	}
+
+	// Shutdown telemetry last so shutdown work is still recorded
 	otelTelemetry.Close()
 	slog.Info("Telemetry shutdown completed")
 
-	// Shutdown HTTP server
-	if err := server.Shutdown(ctx); err != nil {
-		slog.Error("Server forced to shutdown", "error", err)
+	slog.Info("Server exited")
+}
+
+// eventQueueConfig reads the event retention settings for a queue kept in filePath
+func eventQueueConfig(cfg *config.Config, filePath string) events.EventQueueConfig {
+	maxEvents, _ := strconv.Atoi(cfg.MaxEventsInQueue)
+	if maxEvents <= 0 {
+		maxEvents = 10000
 	}
+	// 0 or invalid keeps the default cap of 10x maxEvents
+	maxRetainedEvents, _ := strconv.Atoi(cfg.MaxRetainedEvents)
+	// 0 disables age and size based retention
+	maxEventAge, err := time.ParseDuration(cfg.EventRetentionMaxAge)
+	if err != nil {
+		slog.Warn("Invalid event retention max age, age retention disabled", "provided", cfg.EventRetentionMaxAge, "error", err)
+		maxEventAge = 0
+	}
+	maxEventsFileBytes, _ := strconv.ParseInt(cfg.EventsFileMaxBytes, 10, 64)
+
+	return events.EventQueueConfig{
+		FilePath:          filePath,
+		MaxEvents:         maxEvents,
+		MaxRetainedEvents: maxRetainedEvents,
+		MaxEventAge:       maxEventAge,
+		MaxFileBytes:      maxEventsFileBytes,
+		Logger:            slog.Default(),
+	}
+}
 
-	slog.Info("Server exited")
+// parsePartitions reads the partition map and, on a partition, the partition
+// this instance serves; both are nil when the catalog is not partitioned
+func parsePartitions(cfg *config.Config) (*partitions.Map, *partitions.Partition, error) {
+	if cfg.PartitionMap == "" {
+		if cfg.PartitionID != "" {
+			return nil, nil, errors.New("PARTITION_ID needs PARTITION_MAP")
+		}
+		return nil, nil, nil
+	}
+	partitionMap, err := partitions.ParseMap(cfg.PartitionMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.PartitionID == "" {
+		return partitionMap, nil, nil
+	}
+	partition, ok := partitionMap.Get(cfg.PartitionID)
+	if !ok {
+		return nil, nil, fmt.Errorf("PARTITION_ID %q is not in PARTITION_MAP", cfg.PartitionID)
+	}
+	return partitionMap, &partition, nil
+}
+
+// followerServesLocally tells which requests a follower answers itself: the
+// product and event reads it keeps current from the leader's files, and the
+// unversioned system routes. Everything else goes to the leader.
+func followerServesLocally(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, version := range apiversion.Versions {
+		prefix := "/" + version + "/"
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			path := strings.TrimPrefix(r.URL.Path, prefix)
+			return (path == "inventory" || strings.HasPrefix(path, "inventory/")) && path != "inventory/transfers"
+		}
+	}
+	return true
+}
+
+// followLeader reloads the files a follower serves reads from when the leader
+// changed them
+func followLeader(eventQueue *events.EventQueue, inventoryService *services.InventoryService, eventFilters *eventfilters.Store) {
+	if _, err := eventQueue.Reload(); err != nil {
+		slog.Warn("Failed to reload the leader's events file", "error", err)
+	}
+	if _, err := inventoryService.ReloadData(); err != nil {
+		slog.Warn("Failed to reload the leader's data file", "error", err)
+	}
+	if err := eventFilters.Reload(); err != nil {
+		slog.Warn("Failed to reload the leader's event filters", "error", err)
+	}
+}
+
+// reloader re-reads one file-backed store
+type reloader struct {
+	name   string
+	reload func() error
+}
+
+// promoteToLeader takes over the files from the previous leader: everything is
+// read once more, then this instance starts writing. An instance that cannot
+// take over exits, releasing the lock for another one.
+func promoteToLeader(eventQueue *events.EventQueue, inventoryService *services.InventoryService, reportAggregator *reports.Aggregator, stores []reloader) {
+	slog.Info("Won leader election, taking over from the previous leader")
+	for _, store := range stores {
+		if err := store.reload(); err != nil {
+			slog.Error("Failed to take over as leader", "store", store.name, "error", err)
+			os.Exit(1)
+		}
+	}
+	if err := eventQueue.SetStandby(false); err != nil {
+		slog.Error("Failed to take over as leader", "store", "events", "error", err)
+		os.Exit(1)
+	}
+	if err := inventoryService.Promote(); err != nil {
+		slog.Error("Failed to take over as leader", "store", "inventory", "error", err)
+		os.Exit(1)
+	}
+	reportAggregator.Start()
+}
+
+// reloadRuntimeConfig applies the runtime-tunable settings found in .env
+func reloadRuntimeConfig(runtimeConfig *runtimeconfig.Manager) {
+	values, err := godotenv.Read()
+	if err != nil {
+		slog.Error("Failed to re-read .env on SIGHUP", "error", err)
+		return
+	}
+
+	applied, err := runtimeConfig.Apply(runtimeconfig.EnvChanges(values), "SIGHUP", ".env", "")
+	if err != nil {
+		var validationErr *runtimeconfig.ValidationError
+		if errors.As(err, &validationErr) {
+			slog.Error("Rejected .env reload, nothing applied", "errors", validationErr.Details)
+			return
+		}
+		slog.Error("Failed to apply .env reload", "error", err)
+		return
+	}
+	slog.Info("Reloaded runtime configuration from .env", "changed_settings", len(applied))
 }
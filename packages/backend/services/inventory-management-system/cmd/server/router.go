@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/openapi"
+	"inventory-management-api/internal/partitions"
+	"inventory-management-api/internal/telemetry"
+	"inventory-management-api/internal/tlsconfig"
+
+	"github.com/gorilla/mux"
+)
+
+// runPartitionRouter serves the partition router until the process is told to
+// stop. The router owns no products: it sends each request to the partitions
+// owning its products and serves the event stream it merges from all of them.
+func runPartitionRouter(cfg *config.Config, partitionMap *partitions.Map, apiTelemetry *telemetry.InventoryApiTelemetry) error {
+	slog.Info("Starting partition router", "partitions", len(partitionMap.Partitions()))
+
+	pollWait, err := time.ParseDuration(cfg.PartitionPollWait)
+	if err != nil || pollWait <= 0 || pollWait > time.Minute {
+		slog.Warn("Invalid partition poll wait, using default", "provided", cfg.PartitionPollWait, "error", err)
+		pollWait = 10 * time.Second
+	}
+	proxyTimeout, err := time.ParseDuration(cfg.PartitionProxyTimeout)
+	if err != nil || proxyTimeout <= 0 {
+		slog.Warn("Invalid partition proxy timeout, using default", "provided", cfg.PartitionProxyTimeout, "error", err)
+		proxyTimeout = 10 * time.Second
+	}
+
+	// The merged stream stores poll, with its own offsets and consumers
+	eventQueue, err := events.NewEventQueue(eventQueueConfig(cfg, cfg.PartitionEventsFile))
+	if err != nil {
+		return err
+	}
+	merger, err := partitions.NewMerger(partitions.MergerConfig{
+		Map:       partitionMap,
+		Queue:     eventQueue,
+		StateFile: cfg.PartitionStateFile,
+		APIKey:    cfg.PartitionAPIKey,
+		PollWait:  pollWait,
+	})
+	if err != nil {
+		eventQueue.Close()
+		return err
+	}
+	merger.Start()
+
+	eventsHandler := handlers.NewEventsHandler(eventQueue, slog.Default())
+	eventsHandler.SetPartitionOffsets(merger.OffsetsAt)
+	routerHandler := handlers.NewPartitionRouterHandler(partitionMap, eventsHandler, eventQueue, proxyTimeout)
+	healthHandler := handlers.NewHealthHandler()
+
+	bodyLimitConfig := middleware.ParseBodyLimitConfig(cfg)
+	routerHandler.SetMaxBatchItems(bodyLimitConfig.MaxBatchItems)
+
+	if err := middleware.ValidateAuthConfig(); err != nil {
+		return err
+	}
+	authorize := middleware.AuthorizeMiddleware(openapi.RoutePermissions(openapi.Routes()))
+
+	r := mux.NewRouter()
+	r.Use(middleware.RequestIDMiddleware)
+	r.Use(middleware.ClientCertMiddleware)
+	r.Use(telemetry.TracingMiddleware)
+	r.Use(telemetry.NewTelemetryMiddleware(apiTelemetry).Middleware)
+	r.Use(middleware.TimeoutMiddleware(middleware.ParseTimeoutConfig(cfg)))
+
+	// Only the routes that map to partitions are served; the rest, admin
+	// routes included, go to the partitions directly
+	for _, version := range apiversion.Versions {
+		api := r.PathPrefix("/" + version).Subrouter()
+		api.Use(middleware.APIVersionMiddleware(version))
+		api.Use(middleware.AuthMiddleware)
+		api.Use(authorize)
+		api.Use(middleware.BodyLimitMiddleware(bodyLimitConfig.MaxBodyBytes))
+		api.HandleFunc("/inventory/updates", routerHandler.UpdateInventory).Methods("POST")
+		api.HandleFunc("/inventory/batch-get", routerHandler.BatchGetProducts).Methods("POST")
+		api.HandleFunc("/inventory/versions", routerHandler.GetProductVersions).Methods("GET")
+		api.HandleFunc("/inventory/snapshot", routerHandler.GetCatalogSnapshot).Methods("GET")
+		api.HandleFunc("/inventory/events", routerHandler.GetEvents).Methods("GET")
+		api.HandleFunc("/inventory/events/commit", eventsHandler.CommitEventOffset).Methods("POST")
+		api.HandleFunc("/inventory/{productId}/price", routerHandler.ProxyProduct).Methods("GET")
+		api.HandleFunc("/inventory/{productId}/forecast", routerHandler.ProxyProduct).Methods("GET")
+		api.HandleFunc("/inventory/{productId}/backorders", routerHandler.ProxyProduct).Methods("GET")
+		api.HandleFunc("/inventory/{productId}", routerHandler.ProxyProduct).Methods("GET")
+	}
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET")
+
+	tlsConfig, err := tlsconfig.ServerConfig(cfg)
+	if err != nil {
+		merger.Stop()
+		eventQueue.Close()
+		return err
+	}
+	server := &http.Server{
+		Addr:      ":" + cfg.Port,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+	}
+	go func() {
+		slog.Info("Partition router ready to accept connections", "address", server.Addr, "tls", tlsConfig != nil)
+		var err error
+		if tlsConfig != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Server failed to start", "error", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	slog.Info("Shutting down partition router...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Server forced to shutdown", "error", err)
+	}
+
+	// Stop merging before the queue it appends to closes
+	merger.Stop()
+	if err := eventQueue.Close(); err != nil {
+		slog.Error("Error closing event queue", "error", err)
+	}
+	slog.Info("Partition router exited", "partition_offsets", merger.Offsets())
+	return nil
+}
@@ -0,0 +1,78 @@
+// Package apiversion names the REST API versions the central API serves and
+// carries the version of a request through its context, so one handler can
+// serve every version and branch only where a version changes the contract.
+package apiversion
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// API versions, each served under its own path prefix ("/v1", "/v2")
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Versions lists the served versions, oldest first
+var Versions = []string{V1, V2}
+
+// Latest is the newest served version, the successor of deprecated routes
+const Latest = V2
+
+// Header echoes the version that served a request
+const Header = "API-Version"
+
+type contextKey struct{}
+
+// WithVersion returns a copy of ctx carrying version
+func WithVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, contextKey{}, version)
+}
+
+// FromContext returns the version in ctx, or V1 when there is none
+func FromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(contextKey{}).(string); ok {
+		return version
+	}
+	return V1
+}
+
+// Path moves a /v1 path to version, e.g. Path(V2, "/v1/admin/jobs/7") is
+// "/v2/admin/jobs/7". Paths outside /v1 are returned unchanged.
+func Path(version, v1Path string) string {
+	if from, ok := FromPath(v1Path); ok && from == V1 {
+		return "/" + version + strings.TrimPrefix(v1Path, "/"+V1)
+	}
+	return v1Path
+}
+
+// FromPath returns the version whose prefix path is under, or false for
+// unversioned paths such as /health
+func FromPath(path string) (string, bool) {
+	for _, version := range Versions {
+		if path == "/"+version || strings.HasPrefix(path, "/"+version+"/") {
+			return version, true
+		}
+	}
+	return "", false
+}
+
+// Canonical moves a path under any served version to its /v1 form, so rules
+// written for /v1 paths (IP filters, admin rate limits, endpoint metrics)
+// cover every version
+func Canonical(path string) string {
+	version, ok := FromPath(path)
+	if !ok || version == V1 {
+		return path
+	}
+	return "/" + V1 + strings.TrimPrefix(path, "/"+version)
+}
+
+// Deprecation marks a route slated for removal. Since and Sunset are sent as
+// the Deprecation (RFC 9745) and Sunset (RFC 8594) response headers.
+type Deprecation struct {
+	Since  time.Time
+	Sunset time.Time // Zero when no removal date is set yet
+}
@@ -0,0 +1,343 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+const (
+	segmentPrefix = "events-"
+	segmentSuffix = ".json.gz"
+	// queueSize bounds the rotated batches waiting for upload
+	queueSize   = 256
+	maxAttempts = 5
+	retryDelay  = time.Second
+)
+
+// segmentFile is the JSON document stored gzip-compressed in each segment
+type segmentFile struct {
+	FirstOffset int64          `json:"firstOffset"`
+	LastOffset  int64          `json:"lastOffset"`
+	ArchivedAt  string         `json:"archivedAt"`
+	Events      []models.Event `json:"events"`
+}
+
+// Archiver writes batches of rotated events to a sink as segments
+type Archiver struct {
+	sink    Sink
+	prefix  string
+	pending chan []models.Event
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+
+	archived atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewArchiver creates an archiver storing segments under prefix; call Start
+// to run it
+func NewArchiver(sink Sink, prefix string) *Archiver {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &Archiver{
+		sink:    sink,
+		prefix:  prefix,
+		pending: make(chan []models.Event, queueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start uploads enqueued batches in the background
+func (a *Archiver) Start() {
+	go a.run()
+}
+
+// Enqueue hands a batch of rotated events to the archiver without blocking;
+// it is meant to be the queue's rotated callback. A batch is dropped, and
+// logged, when the upload backlog is full.
+func (a *Archiver) Enqueue(events []models.Event) {
+	if len(events) == 0 {
+		return
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.closed {
+		a.drop(events, "archiver stopped")
+		return
+	}
+	select {
+	case a.pending <- events:
+	default:
+		a.drop(events, "archive backlog full")
+	}
+}
+
+func (a *Archiver) drop(events []models.Event, reason string) {
+	a.dropped.Add(int64(len(events)))
+	first, last := offsetRange(events)
+	slog.Error("Dropping rotated events without archiving them",
+		"reason", reason,
+		"first_offset", first,
+		"last_offset", last,
+		"count", len(events))
+}
+
+// Stop stops accepting batches and waits until the pending ones are uploaded
+// or ctx is done
+func (a *Archiver) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.pending)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats reports the archiver counters
+func (a *Archiver) Stats() models.ArchiveStats {
+	return models.ArchiveStats{
+		Sink:           a.sink.Name(),
+		PendingBatches: len(a.pending),
+		ArchivedEvents: a.archived.Load(),
+		DroppedEvents:  a.dropped.Load(),
+		FailedEvents:   a.failed.Load(),
+	}
+}
+
+func (a *Archiver) run() {
+	defer close(a.done)
+	for events := range a.pending {
+		a.archive(events)
+	}
+}
+
+func (a *Archiver) archive(events []models.Event) {
+	sort.Slice(events, func(i, j int) bool { return events[i].Offset < events[j].Offset })
+	first, last := offsetRange(events)
+
+	data, err := encodeSegment(segmentFile{
+		FirstOffset: first,
+		LastOffset:  last,
+		ArchivedAt:  clock.Timestamp(),
+		Events:      events,
+	})
+	if err != nil {
+		a.failed.Add(int64(len(events)))
+		slog.Error("Failed to encode archive segment", "first_offset", first, "last_offset", last, "error", err)
+		return
+	}
+
+	key := a.prefix + segmentPrefix + SegmentID(first, last) + segmentSuffix
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		err = a.sink.Put(ctx, key, data)
+		cancel()
+		if err == nil {
+			break
+		}
+		if attempt == maxAttempts {
+			a.failed.Add(int64(len(events)))
+			slog.Error("Failed to archive rotated events, giving up",
+				"key", key,
+				"count", len(events),
+				"attempts", attempt,
+				"error", err)
+			return
+		}
+		slog.Warn("Failed to archive rotated events, retrying",
+			"key", key,
+			"attempt", attempt,
+			"retry_in", delay,
+			"error", err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	a.archived.Add(int64(len(events)))
+	slog.Info("Archived rotated events",
+		"sink", a.sink.Name(),
+		"key", key,
+		"first_offset", first,
+		"last_offset", last,
+		"count", len(events),
+		"bytes", len(data))
+}
+
+// SegmentID names the segment holding offsets first through last
+func SegmentID(first, last int64) string {
+	return fmt.Sprintf("%020d-%020d", first, last)
+}
+
+// parseSegmentID returns the offset range of a segment ID
+func parseSegmentID(id string) (int64, int64, bool) {
+	firstPart, lastPart, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(firstPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	last, err := strconv.ParseInt(lastPart, 10, 64)
+	if err != nil || last < first {
+		return 0, 0, false
+	}
+	return first, last, true
+}
+
+// Segments lists the archived segments ordered by offset
+func (a *Archiver) Segments(ctx context.Context) ([]models.ArchiveSegment, error) {
+	objects, err := a.sink.List(ctx, a.prefix+segmentPrefix)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]models.ArchiveSegment, 0, len(objects))
+	for _, object := range objects {
+		name := strings.TrimPrefix(object.Key, a.prefix+segmentPrefix)
+		if !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(name, segmentSuffix)
+		first, last, ok := parseSegmentID(id)
+		if !ok {
+			continue
+		}
+		segments = append(segments, models.ArchiveSegment{
+			ID:          id,
+			Key:         object.Key,
+			FirstOffset: first,
+			LastOffset:  last,
+			SizeBytes:   object.SizeBytes,
+		})
+	}
+	sort.Slice(segments, func(i, j int) bool { return segments[i].FirstOffset < segments[j].FirstOffset })
+	return segments, nil
+}
+
+// ReadSegment returns one segment and its events
+func (a *Archiver) ReadSegment(ctx context.Context, id string) (models.ArchiveSegment, []models.Event, error) {
+	first, last, ok := parseSegmentID(id)
+	if !ok {
+		return models.ArchiveSegment{}, nil, ErrNotFound
+	}
+	key := a.prefix + segmentPrefix + id + segmentSuffix
+	data, err := a.sink.Get(ctx, key)
+	if err != nil {
+		return models.ArchiveSegment{}, nil, err
+	}
+	file, err := decodeSegment(data)
+	if err != nil {
+		return models.ArchiveSegment{}, nil, fmt.Errorf("failed to decode segment %s: %w", id, err)
+	}
+	segment := models.ArchiveSegment{
+		ID:          id,
+		Key:         key,
+		FirstOffset: first,
+		LastOffset:  last,
+		SizeBytes:   int64(len(data)),
+	}
+	return segment, file.Events, nil
+}
+
+// ReadEvents re-streams archived events from offset in pages like the events
+// endpoint. Offsets can skip where a segment was never archived.
+func (a *Archiver) ReadEvents(ctx context.Context, from int64, limit int) ([]models.Event, int64, bool, error) {
+	segments, err := a.Segments(ctx)
+	if err != nil {
+		return nil, from, false, err
+	}
+
+	result := make([]models.Event, 0, limit)
+	next := from
+	for i, segment := range segments {
+		if segment.LastOffset < from {
+			continue
+		}
+		_, events, err := a.ReadSegment(ctx, segment.ID)
+		if err != nil {
+			return nil, from, false, err
+		}
+		for j, event := range events {
+			if event.Offset < from {
+				continue
+			}
+			if len(result) == limit {
+				return result, next, true, nil
+			}
+			result = append(result, event)
+			next = event.Offset + 1
+			if len(result) == limit && j == len(events)-1 && i == len(segments)-1 {
+				return result, next, false, nil
+			}
+		}
+	}
+	return result, next, false, nil
+}
+
+func offsetRange(events []models.Event) (int64, int64) {
+	first, last := events[0].Offset, events[0].Offset
+	for _, event := range events[1:] {
+		first = min(first, event.Offset)
+		last = max(last, event.Offset)
+	}
+	return first, last
+}
+
+func encodeSegment(segment segmentFile) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(writer).Encode(segment); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeSegment(data []byte) (segmentFile, error) {
+	var segment segmentFile
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return segment, err
+	}
+	defer reader.Close()
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return segment, err
+	}
+	if err := json.Unmarshal(body, &segment); err != nil {
+		return segment, err
+	}
+	if segment.Events == nil {
+		return segment, errors.New("segment has no events")
+	}
+	return segment, nil
+}
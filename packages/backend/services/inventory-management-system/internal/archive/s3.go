@@ -0,0 +1,272 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Credentials are the HMAC keys used to sign object store requests
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// S3Sink stores objects through the S3 REST API with path-style URLs
+// (endpoint/bucket/key), which AWS S3, Google Cloud Storage and MinIO accept
+type S3Sink struct {
+	name     string
+	endpoint *url.URL
+	region   string
+	bucket   string
+	creds    Credentials
+	client   *http.Client
+}
+
+// NewS3Sink creates an S3-compatible sink
+func NewS3Sink(name, endpoint, region, bucket string, creds Credentials) (*S3Sink, error) {
+	if bucket == "" {
+		return nil, errors.New("archive bucket is required for the " + name + " sink")
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errors.New("archive access key id and secret are required for the " + name + " sink")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid archive endpoint %q", endpoint)
+	}
+	return &S3Sink{
+		name:     name,
+		endpoint: u,
+		region:   region,
+		bucket:   bucket,
+		creds:    creds,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Name implements Sink
+func (s *S3Sink) Name() string {
+	return s.name
+}
+
+// Put uploads an object
+func (s *S3Sink) Put(ctx context.Context, key string, data []byte) error {
+	resp, err := s.do(ctx, http.MethodPut, key, nil, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError("upload "+key, resp)
+	}
+	return nil
+}
+
+// Get downloads an object
+func (s *S3Sink) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, responseError("download "+key, resp)
+	}
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2
+func (s *S3Sink) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := responseError("list "+prefix, resp)
+			resp.Body.Close()
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode bucket listing: %w", err)
+		}
+		for _, content := range result.Contents {
+			objects = append(objects, Object{Key: content.Key, SizeBytes: content.Size})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *S3Sink) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodPut {
+		req.Header.Set("Content-Type", "application/gzip")
+	}
+	sum := sha256.Sum256(body)
+	SignV4(req, hex.EncodeToString(sum[:]), s.creds, s.region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request to archive failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+func responseError(action string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("failed to %s: status %d: %s", action, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// SignV4 signs req with AWS Signature Version 4 for the s3 service. The
+// payload hash is the hex SHA-256 of the body (or UNSIGNED-PAYLOAD).
+func SignV4(req *http.Request, payloadHash string, creds Credentials, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for name, values := range req.Header {
+		trimmed := make([]string, len(values))
+		for i, value := range values {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := day + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name, as SigV4 requires
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes everything but unreserved characters, and slashes
+// unless encodeSlash is set
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+// Package archive keeps events the queue rotates or compacts away. Removed
+// events are written as gzip-compressed segments to a sink: a local directory
+// or an S3-compatible object store (AWS S3, Google Cloud Storage through its
+// XML API with HMAC keys, MinIO). Archived segments can be listed and read
+// back for offline analytics or to rebuild a store.
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotFound is returned for a missing object or segment
+var ErrNotFound = errors.New("archived object not found")
+
+// Object is a stored object
+type Object struct {
+	Key       string
+	SizeBytes int64
+}
+
+// Sink stores archived segments
+type Sink interface {
+	// Name identifies the sink in logs and the admin API
+	Name() string
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	// List returns the objects whose key starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// Config selects and configures a sink
+type Config struct {
+	Sink            string // none, local, s3 or gcs
+	Dir             string // Directory of the local sink
+	Bucket          string
+	Endpoint        string // Defaults to AWS S3 in Region, or storage.googleapis.com for gcs
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// NewSink builds the configured sink; it returns nil when archival is disabled
+func NewSink(cfg Config) (Sink, error) {
+	switch strings.ToLower(cfg.Sink) {
+	case "", "none":
+		return nil, nil
+	case "local":
+		if cfg.Dir == "" {
+			return nil, errors.New("archive directory is required for the local sink")
+		}
+		return &LocalSink{dir: cfg.Dir}, nil
+	case "s3":
+		region := cfg.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://s3." + region + ".amazonaws.com"
+		}
+		return NewS3Sink("s3", endpoint, region, cfg.Bucket, Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+		})
+	case "gcs":
+		region := cfg.Region
+		if region == "" {
+			region = "auto"
+		}
+		endpoint := cfg.Endpoint
+		if endpoint == "" {
+			endpoint = "https://storage.googleapis.com"
+		}
+		return NewS3Sink("gcs", endpoint, region, cfg.Bucket, Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown archive sink %q, expected none, local, s3 or gcs", cfg.Sink)
+	}
+}
+
+// LocalSink stores objects as files under a directory
+type LocalSink struct {
+	dir string
+}
+
+// NewLocalSink creates a sink writing under dir
+func NewLocalSink(dir string) *LocalSink {
+	return &LocalSink{dir: dir}
+}
+
+// Name implements Sink
+func (s *LocalSink) Name() string {
+	return "local"
+}
+
+// Put writes the object atomically
+func (s *LocalSink) Put(ctx context.Context, key string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write archived object: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store archived object: %w", err)
+	}
+	return nil
+}
+
+// Get reads an object
+func (s *LocalSink) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// List walks the directory for keys starting with prefix
+func (s *LocalSink) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path == s.dir {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, SizeBytes: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archive: %w", err)
+	}
+	return objects, nil
+}
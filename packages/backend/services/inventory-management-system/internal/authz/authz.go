@@ -0,0 +1,175 @@
+// Package authz holds the role and permission model. A principal (API key,
+// JWT subject or client certificate store) has roles, each role grants a set
+// of permissions and every route requires one permission.
+package authz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Permission is what a route requires of the caller
+type Permission string
+
+// Permissions required by the routes of the central inventory API
+const (
+	PermInventoryRead  Permission = "inventory:read"  // Product reads, listings, snapshots and forecasts
+	PermInventoryWrite Permission = "inventory:write" // Stock updates and transfers
+	PermEventsConsume  Permission = "events:consume"  // Event polling and offset commits
+	PermProductsCreate Permission = "products:create"
+	PermProductsUpdate Permission = "products:update"
+	PermProductsDelete Permission = "products:delete"
+	PermAdminRead      Permission = "admin:read"  // Reports, stats, dashboard and admin listings
+	PermAdminWrite     Permission = "admin:write" // Snapshots, restore, stocktakes, filters, flags and runtime config
+)
+
+// Role is a named set of permissions
+type Role string
+
+// Built-in roles
+const (
+	RoleAdmin          Role = "admin"
+	RoleCatalogManager Role = "catalog-manager"
+	RoleAnalyst        Role = "analyst"
+	RoleStore          Role = "store"
+)
+
+// rolePermissions lists what each role may do. Admin may do everything.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {
+		PermInventoryRead, PermInventoryWrite, PermEventsConsume,
+		PermProductsCreate, PermProductsUpdate, PermProductsDelete,
+		PermAdminRead, PermAdminWrite,
+	},
+	RoleCatalogManager: {PermInventoryRead, PermProductsCreate, PermProductsUpdate},
+	RoleAnalyst:        {PermInventoryRead, PermAdminRead},
+	RoleStore:          {PermInventoryRead, PermInventoryWrite, PermEventsConsume},
+}
+
+// RolePermissions returns the permissions a role grants, nil for an unknown role
+func RolePermissions(role Role) []Permission {
+	return rolePermissions[role]
+}
+
+// ParseRole validates a role name
+func ParseRole(value string) (Role, error) {
+	role := Role(strings.TrimSpace(value))
+	if _, ok := rolePermissions[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", value)
+	}
+	return role, nil
+}
+
+// ParseKeyRoles parses "key:role|role,key:role" into the roles of each API key
+func ParseKeyRoles(value string) (map[string][]Role, error) {
+	assignments := make(map[string][]Role)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, roleList, ok := strings.Cut(part, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.TrimSpace(roleList) == "" {
+			return nil, fmt.Errorf("invalid role assignment %q, expected key:role", part)
+		}
+		for _, name := range strings.Split(roleList, "|") {
+			role, err := ParseRole(name)
+			if err != nil {
+				return nil, err
+			}
+			assignments[key] = append(assignments[key], role)
+		}
+	}
+	return assignments, nil
+}
+
+// ParseKeyStores parses "key:store-id,key:store-id" into the store each API
+// key is bound to
+func ParseKeyStores(value string) (map[string]string, error) {
+	bindings := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, storeID, ok := strings.Cut(part, ":")
+		key, storeID = strings.TrimSpace(key), strings.TrimSpace(storeID)
+		if !ok || key == "" || storeID == "" {
+			return nil, fmt.Errorf("invalid store binding %q, expected key:store-id", part)
+		}
+		if bound, dup := bindings[key]; dup && bound != storeID {
+			return nil, fmt.Errorf("API key bound to both %s and %s", bound, storeID)
+		}
+		bindings[key] = storeID
+	}
+	return bindings, nil
+}
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	ID      string // "key:<api key>", "jwt:<subject>" or "store:<store id>"
+	Source  string // api_key, jwt or client_cert
+	Roles   []Role
+	StoreID string // Store the caller is bound to, if any: its certificate's, or the one of its key or token
+}
+
+// Principal sources
+const (
+	SourceAPIKey     = "api_key"
+	SourceJWT        = "jwt"
+	SourceClientCert = "client_cert"
+)
+
+// Can reports whether any of the principal's roles grants perm
+func (p Principal) Can(perm Permission) bool {
+	for _, role := range p.Roles {
+		for _, granted := range rolePermissions[role] {
+			if granted == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal has role
+func (p Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions returns every permission the principal holds, sorted
+func (p Principal) Permissions() []Permission {
+	seen := make(map[Permission]bool)
+	var perms []Permission
+	for _, role := range p.Roles {
+		for _, perm := range rolePermissions[role] {
+			if !seen[perm] {
+				seen[perm] = true
+				perms = append(perms, perm)
+			}
+		}
+	}
+	sort.Slice(perms, func(i, j int) bool { return perms[i] < perms[j] })
+	return perms
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns ctx carrying the request's principal
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal AuthMiddleware resolved, if any
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
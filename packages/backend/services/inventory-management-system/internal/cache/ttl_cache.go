@@ -4,6 +4,8 @@ import (
 	"log/slog"
 	"sync"
 	"time"
+
+	"inventory-management-api/internal/clock"
 )
 
 // CacheEntry represents a cached item with expiration time
@@ -19,6 +21,7 @@ type TTLCache struct {
 	ttl         time.Duration
 	cleanupTicker *time.Ticker
 	stopCleanup chan bool
+	clock       clock.Clock // Expirations are measured on it; the wall clock by default
 }
 
 // NewTTLCache creates a new TTL cache with specified TTL and cleanup interval
@@ -27,6 +30,7 @@ func NewTTLCache(ttl, cleanupInterval time.Duration) *TTLCache {
 		items:       make(map[string]*CacheEntry),
 		ttl:         ttl,
 		stopCleanup: make(chan bool),
+		clock:       clock.System{},
 	}
 
 	// Start cleanup goroutine
@@ -40,12 +44,20 @@ func NewTTLCache(ttl, cleanupInterval time.Duration) *TTLCache {
 	return cache
 }
 
+// SetClock makes the cache measure expirations on c, e.g. a manual clock in
+// tests
+func (c *TTLCache) SetClock(clk clock.Clock) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.clock = clk
+}
+
 // Set stores a value in the cache with TTL
 func (c *TTLCache) Set(key string, value interface{}) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	expiresAt := time.Now().Add(c.ttl)
+	expiresAt := c.clock.Now().Add(c.ttl)
 	c.items[key] = &CacheEntry{
 		Value:     value,
 		ExpiresAt: expiresAt,
@@ -67,7 +79,7 @@ func (c *TTLCache) Get(key string) (interface{}, bool) {
 	}
 
 	// Check if entry has expired
-	if time.Now().After(entry.ExpiresAt) {
+	if c.clock.Now().After(entry.ExpiresAt) {
 		slog.Debug("Cache entry expired", "key", key)
 		return nil, false
 	}
@@ -97,7 +109,7 @@ func (c *TTLCache) ActiveSize() int {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	now := time.Now()
+	now := c.clock.Now()
 	activeCount := 0
 	for _, entry := range c.items {
 		if now.Before(entry.ExpiresAt) {
@@ -144,7 +156,7 @@ func (c *TTLCache) performCleanup() {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	now := time.Now()
+	now := c.clock.Now()
 	expiredKeys := make([]string, 0)
 
 	// Find expired keys
@@ -171,7 +183,7 @@ func (c *TTLCache) GetStats() map[string]interface{} {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
 
-	now := time.Now()
+	now := c.clock.Now()
 	activeCount := 0
 	expiredCount := 0
 
@@ -0,0 +1,162 @@
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Layout is the format of every timestamp the service persists or emits:
+// RFC3339 in UTC with whole seconds, so timestamps from every write path order
+// the same as strings and parse alike in the stores
+const Layout = time.RFC3339
+
+// Clock tells the current time and when a duration has passed. Expirations,
+// rate limit windows and waits take it so tests can move time without
+// sleeping.
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the returned channel once d has passed
+	After(d time.Duration) <-chan time.Time
+}
+
+// System is the wall clock
+type System struct{}
+
+// Now returns the wall clock time
+func (System) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for d on the wall clock
+func (System) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Fixed is a clock stopped at one instant, for tests
+type Fixed time.Time
+
+// Now returns the fixed instant
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
+
+// After fires only for durations of zero or less, as time never passes
+func (f Fixed) After(d time.Duration) <-chan time.Time {
+	fired := make(chan time.Time, 1)
+	if d <= 0 {
+		fired <- time.Time(f)
+	}
+	return fired
+}
+
+// Manual is a clock that only moves when advanced, for tests of expirations
+// and waits
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+// manualWaiter is a pending After on a manual clock
+type manualWaiter struct {
+	at    time.Time
+	fired chan time.Time
+}
+
+// NewManual returns a manual clock reading start
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start}
+}
+
+// Now returns the clock's current time
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+// After fires once the clock was advanced by d
+func (m *Manual) After(d time.Duration) <-chan time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fired := make(chan time.Time, 1)
+	if d <= 0 {
+		fired <- m.now
+		return fired
+	}
+	m.waiters = append(m.waiters, manualWaiter{at: m.now.Add(d), fired: fired})
+	return fired
+}
+
+// Advance moves the clock forward by d and fires the waits that are due
+func (m *Manual) Advance(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = m.now.Add(d)
+	pending := m.waiters[:0]
+	for _, waiter := range m.waiters {
+		if waiter.at.After(m.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.fired <- m.now
+	}
+	m.waiters = pending
+}
+
+// Waiters returns how many Afters have not fired yet, so a test can wait for
+// a goroutine to start waiting before it advances the clock
+func (m *Manual) Waiters() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.waiters)
+}
+
+// holder wraps the clock so any implementation fits one atomic pointer
+type holder struct {
+	Clock
+}
+
+var current atomic.Pointer[holder]
+
+func init() {
+	current.Store(&holder{System{}})
+}
+
+// Set makes c the clock timestamps are taken from and returns a function
+// restoring the previous one, e.g. to defer in a test
+func Set(c Clock) (restore func()) {
+	previous := current.Swap(&holder{c})
+	return func() {
+		current.Store(previous)
+	}
+}
+
+// Now returns the current time in UTC
+func Now() time.Time {
+	return current.Load().Now().UTC()
+}
+
+// Timestamp returns the current time formatted for persisting or emitting
+func Timestamp() string {
+	return Format(Now())
+}
+
+// Format formats t as a timestamp: in UTC, truncated to whole seconds
+func Format(t time.Time) string {
+	return t.UTC().Format(Layout)
+}
+
+// Normalize rewrites a timestamp written elsewhere or by an older version,
+// e.g. in local time or with fractional seconds, into the service's format.
+// Values that do not parse are returned unchanged.
+func Normalize(timestamp string) string {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		return timestamp
+	}
+	return Format(t)
+}
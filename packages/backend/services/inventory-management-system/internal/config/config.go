@@ -5,6 +5,9 @@ import (
 	"os"
 	"strings"
 
+	"inventory-management-api/internal/requestid"
+	"inventory-management-api/internal/telemetry"
+
 	"github.com/joho/godotenv"
 )
 
@@ -12,15 +15,50 @@ import (
 type Config struct {
 	Port                            string
 	DataPath                        string
+	SeedDataPath                    string // Data file loaded at startup; changes are saved to DataPath
 	LogLevel                        string
 	Environment                     string
 	IdempotencyCacheTTL             string
 	IdempotencyCacheCleanupInterval string
 	EnableJSONPersistence           string
+	PersistenceFlushInterval        string
+	PersistenceFlushMaxUpdates      string
 	InventoryWorkerCount            string
 	InventoryQueueBufferSize        string
+	InventoryQueueHighWaterMark     string
+	InventoryQueueLaneQuotas        string
+	ReadCacheEnabled                string
+	NotFoundCacheTTL                string
+	NotFoundCacheMaxEntries         string
+	LockWatchdogThreshold           string
+	UpdateTimeout                   string
 	MaxEventsInQueue                string
+	MaxRetainedEvents               string
+	EventRetentionMaxAge            string
+	EventsFileMaxBytes              string
 	EventsFilePath                  string
+	SnapshotsDir                    string
+	EventFiltersFilePath            string
+	TransfersFilePath               string
+	MaxTransferHistory              string
+	StocktakesFilePath              string
+	OrdersFilePath                  string
+	ReportsDir                      string
+
+	// Archival of rotated events to a local directory or object storage
+	ArchiveSink            string
+	ArchiveDir             string
+	ArchiveBucket          string
+	ArchivePrefix          string
+	ArchiveEndpoint        string
+	ArchiveRegion          string
+	ArchiveAccessKeyID     string
+	ArchiveSecretAccessKey string
+	ArchiveSessionToken    string
+
+	// Currency configuration
+	BaseCurrency  string
+	CurrencyRates string
 
 	// Rate limiting configuration
 	RateLimitEnabled                string
@@ -28,6 +66,94 @@ type Config struct {
 	RateLimitRequestsPerMinute      string
 	RateLimitWindowMinutes          string
 	RateLimitAdminRequestsPerMinute string
+	RateLimitAlgorithm              string
+	RateLimitBurstSize              string
+	RateLimitTiers                  string
+	RateLimitPrincipalTiers         string
+
+	// Request payload limits
+	MaxRequestBodyBytes      string
+	MaxAdminRequestBodyBytes string
+	MaxBatchUpdateItems      string
+	MaxAdminItemsPerRequest  string
+	MaxAdminJobBodyBytes     string
+	MaxAdminJobItems         string
+
+	// Response compression
+	CompressionEnabled  string
+	CompressionMinBytes string
+
+	// Client IP filtering per route prefix
+	IPAllowlist    string
+	IPDenylist     string
+	TrustedProxies string
+
+	// Lockout after repeated authentication failures
+	AuthLockoutEnabled      string
+	AuthLockoutThreshold    string
+	AuthLockoutWindow       string
+	AuthLockoutBaseDuration string
+	AuthLockoutMaxDuration  string
+
+	// Background admin jobs
+	AdminJobWorkers   string
+	AdminJobQueueSize string
+	AdminJobChunkSize string
+	JobsFilePath      string
+
+	// Read-only maintenance mode at startup; admins toggle it at runtime
+	MaintenanceMode       string
+	MaintenanceMessage    string
+	MaintenanceRetryAfter string
+
+	// Leader election between instances sharing the data files
+	LeaderLockFile         string
+	LeaderAdvertiseURL     string
+	LeaderInstanceID       string
+	LeaderRetryInterval    string
+	FollowerReloadInterval string
+
+	// Hash range partitioning of the catalog across instances
+	PartitionMap          string
+	PartitionID           string
+	PartitionRouter       string
+	PartitionAPIKey       string
+	PartitionEventsFile   string
+	PartitionStateFile    string
+	PartitionPollWait     string
+	PartitionProxyTimeout string
+
+	// Availability and latency objectives per endpoint group
+	SLOObjectives string
+
+	// Request deadlines per endpoint group and route prefix
+	RequestTimeoutRead        string
+	RequestTimeoutWrite       string
+	RequestTimeoutAdmin       string
+	RequestTimeoutStream      string
+	RequestTimeoutEventsGrace string
+	RequestTimeoutRoutes      string
+
+	// TLS and mutual TLS for store connections
+	TLSCertFile       string
+	TLSKeyFile        string
+	TLSClientCAFile   string
+	TLSClientAuth     string
+	TLSReloadInterval string
+
+	// Feature flags for experimental behaviors
+	FeatureFlags         string
+	FeatureFlagOverrides string
+
+	// Chaos fault injection for integration testing
+	ChaosEnabled       string
+	ChaosRoutes        string
+	ChaosLatency       string
+	ChaosLatencyRate   string
+	ChaosErrorRate     string
+	ChaosResetRate     string
+	ChaosDuplicateRate string
+	ChaosSeed          string
 }
 
 // LoadConfig loads configuration from .env file and environment variables
@@ -44,15 +170,50 @@ func LoadConfig() *Config {
 	config := &Config{
 		Port:                            getEnvWithDefault("PORT", "8080"),
 		DataPath:                        getEnvWithDefault("DATA_PATH", "data/inventory_test_data.json"),
+		SeedDataPath:                    getEnvWithDefault("SEED_DATA_PATH", "data/inventory_test_data.json"),
 		LogLevel:                        getEnvWithDefault("LOG_LEVEL", "info"),
 		Environment:                     getEnvWithDefault("ENVIRONMENT", "development"),
 		IdempotencyCacheTTL:             getEnvWithDefault("IDEMPOTENCY_CACHE_TTL", "2m"),
 		IdempotencyCacheCleanupInterval: getEnvWithDefault("IDEMPOTENCY_CACHE_CLEANUP_INTERVAL", "30s"),
 		EnableJSONPersistence:           getEnvWithDefault("ENABLE_JSON_PERSISTENCE", "true"),
+		PersistenceFlushInterval:        getEnvWithDefault("PERSISTENCE_FLUSH_INTERVAL", "500ms"),
+		PersistenceFlushMaxUpdates:      getEnvWithDefault("PERSISTENCE_FLUSH_MAX_UPDATES", "100"),
 		InventoryWorkerCount:            getEnvWithDefault("INVENTORY_WORKER_COUNT", "1"),
 		InventoryQueueBufferSize:        getEnvWithDefault("INVENTORY_QUEUE_BUFFER_SIZE", "100"),
+		InventoryQueueHighWaterMark:     getEnvWithDefault("INVENTORY_QUEUE_HIGH_WATER_MARK", "0"),
+		InventoryQueueLaneQuotas:        getEnvWithDefault("INVENTORY_QUEUE_LANE_QUOTAS", "checkout:100,sync:75,bulk:50"),
+		ReadCacheEnabled:                getEnvWithDefault("READ_CACHE_ENABLED", "false"),
+		NotFoundCacheTTL:                getEnvWithDefault("NOT_FOUND_CACHE_TTL", "5s"),
+		NotFoundCacheMaxEntries:         getEnvWithDefault("NOT_FOUND_CACHE_MAX_ENTRIES", "10000"),
+		LockWatchdogThreshold:           getEnvWithDefault("LOCK_WATCHDOG_THRESHOLD", "5s"),
+		UpdateTimeout:                   getEnvWithDefault("UPDATE_TIMEOUT", "20s"),
 		MaxEventsInQueue:                getEnvWithDefault("MAX_EVENTS_IN_QUEUE", "10000"),
+		MaxRetainedEvents:               getEnvWithDefault("MAX_RETAINED_EVENTS", "0"),
+		EventRetentionMaxAge:            getEnvWithDefault("EVENT_RETENTION_MAX_AGE", "0"),
+		EventsFileMaxBytes:              getEnvWithDefault("EVENTS_FILE_MAX_BYTES", "0"),
 		EventsFilePath:                  getEnvWithDefault("EVENTS_FILE_PATH", "./data/events.json"),
+		SnapshotsDir:                    getEnvWithDefault("SNAPSHOTS_DIR", "./data/snapshots"),
+		EventFiltersFilePath:            getEnvWithDefault("EVENT_FILTERS_FILE_PATH", "./data/event_filters.json"),
+		TransfersFilePath:               getEnvWithDefault("TRANSFERS_FILE_PATH", "./data/transfers.json"),
+		MaxTransferHistory:              getEnvWithDefault("MAX_TRANSFER_HISTORY", "10000"),
+		StocktakesFilePath:              getEnvWithDefault("STOCKTAKES_FILE_PATH", "./data/stocktakes.json"),
+		OrdersFilePath:                  getEnvWithDefault("ORDERS_FILE_PATH", "./data/orders.json"),
+		ReportsDir:                      getEnvWithDefault("REPORTS_DIR", "./data/reports"),
+
+		// Archival of rotated events; credentials fall back to the standard AWS variables
+		ArchiveSink:            getEnvWithDefault("ARCHIVE_SINK", "none"),
+		ArchiveDir:             getEnvWithDefault("ARCHIVE_DIR", "./data/archive"),
+		ArchiveBucket:          getEnvWithDefault("ARCHIVE_BUCKET", ""),
+		ArchivePrefix:          getEnvWithDefault("ARCHIVE_PREFIX", "inventory-events/"),
+		ArchiveEndpoint:        getEnvWithDefault("ARCHIVE_ENDPOINT", ""),
+		ArchiveRegion:          getEnvWithDefault("ARCHIVE_REGION", os.Getenv("AWS_REGION")),
+		ArchiveAccessKeyID:     getEnvWithDefault("ARCHIVE_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID")),
+		ArchiveSecretAccessKey: getEnvWithDefault("ARCHIVE_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY")),
+		ArchiveSessionToken:    getEnvWithDefault("ARCHIVE_SESSION_TOKEN", os.Getenv("AWS_SESSION_TOKEN")),
+
+		// Currency configuration
+		BaseCurrency:  getEnvWithDefault("BASE_CURRENCY", "USD"),
+		CurrencyRates: getEnvWithDefault("CURRENCY_RATES", "EUR:0.92,MXN:17.10,COP:3950,ARS:350,BRL:4.95"),
 
 		// Rate limiting configuration
 		RateLimitEnabled:                getEnvWithDefault("RATE_LIMIT_ENABLED", "true"),
@@ -60,6 +221,94 @@ func LoadConfig() *Config {
 		RateLimitRequestsPerMinute:      getEnvWithDefault("RATE_LIMIT_REQUESTS_PER_MINUTE", "100"),
 		RateLimitWindowMinutes:          getEnvWithDefault("RATE_LIMIT_WINDOW_MINUTES", "1"),
 		RateLimitAdminRequestsPerMinute: getEnvWithDefault("RATE_LIMIT_ADMIN_REQUESTS_PER_MINUTE", "50"),
+		RateLimitAlgorithm:              getEnvWithDefault("RATE_LIMIT_ALGORITHM", "fixed_window"),
+		RateLimitBurstSize:              getEnvWithDefault("RATE_LIMIT_BURST_SIZE", "0"),
+		RateLimitTiers:                  getEnvWithDefault("RATE_LIMIT_TIERS", "store:600,readonly:12000"),
+		RateLimitPrincipalTiers:         getEnvWithDefault("RATE_LIMIT_PRINCIPAL_TIERS", ""),
+
+		// Request payload limits
+		MaxRequestBodyBytes:      getEnvWithDefault("MAX_REQUEST_BODY_BYTES", "1048576"),
+		MaxAdminRequestBodyBytes: getEnvWithDefault("MAX_ADMIN_REQUEST_BODY_BYTES", "10485760"),
+		MaxBatchUpdateItems:      getEnvWithDefault("MAX_BATCH_UPDATE_ITEMS", "100"),
+		MaxAdminItemsPerRequest:  getEnvWithDefault("MAX_ADMIN_ITEMS_PER_REQUEST", "1000"),
+		MaxAdminJobBodyBytes:     getEnvWithDefault("MAX_ADMIN_JOB_BODY_BYTES", "104857600"),
+		MaxAdminJobItems:         getEnvWithDefault("MAX_ADMIN_JOB_ITEMS", "100000"),
+
+		// Response compression
+		CompressionEnabled:  getEnvWithDefault("COMPRESSION_ENABLED", "true"),
+		CompressionMinBytes: getEnvWithDefault("COMPRESSION_MIN_BYTES", "1024"),
+
+		// Client IP filtering per route prefix
+		IPAllowlist:    getEnvWithDefault("IP_ALLOWLIST", ""),
+		IPDenylist:     getEnvWithDefault("IP_DENYLIST", ""),
+		TrustedProxies: getEnvWithDefault("TRUSTED_PROXIES", ""),
+
+		// Lockout after repeated authentication failures
+		AuthLockoutEnabled:      getEnvWithDefault("AUTH_LOCKOUT_ENABLED", "true"),
+		AuthLockoutThreshold:    getEnvWithDefault("AUTH_LOCKOUT_THRESHOLD", "5"),
+		AuthLockoutWindow:       getEnvWithDefault("AUTH_LOCKOUT_WINDOW", "5m"),
+		AuthLockoutBaseDuration: getEnvWithDefault("AUTH_LOCKOUT_BASE_DURATION", "1m"),
+		AuthLockoutMaxDuration:  getEnvWithDefault("AUTH_LOCKOUT_MAX_DURATION", "1h"),
+
+		// Background admin jobs
+		AdminJobWorkers:   getEnvWithDefault("ADMIN_JOB_WORKERS", "2"),
+		AdminJobQueueSize: getEnvWithDefault("ADMIN_JOB_QUEUE_SIZE", "16"),
+		AdminJobChunkSize: getEnvWithDefault("ADMIN_JOB_CHUNK_SIZE", "500"),
+		JobsFilePath:      getEnvWithDefault("JOBS_FILE_PATH", "./data/jobs.json"),
+
+		// Read-only maintenance mode at startup; admins toggle it at runtime
+		MaintenanceMode:       getEnvWithDefault("MAINTENANCE_MODE", "false"),
+		MaintenanceMessage:    getEnvWithDefault("MAINTENANCE_MESSAGE", ""),
+		MaintenanceRetryAfter: getEnvWithDefault("MAINTENANCE_RETRY_AFTER", "60"),
+
+		// Leader election between instances sharing the data files
+		LeaderLockFile:         getEnvWithDefault("LEADER_LOCK_FILE", ""),
+		LeaderAdvertiseURL:     getEnvWithDefault("LEADER_ADVERTISE_URL", ""),
+		LeaderInstanceID:       getEnvWithDefault("LEADER_INSTANCE_ID", ""),
+		LeaderRetryInterval:    getEnvWithDefault("LEADER_RETRY_INTERVAL", "1s"),
+		FollowerReloadInterval: getEnvWithDefault("FOLLOWER_RELOAD_INTERVAL", "1s"),
+
+		// Hash range partitioning of the catalog across instances
+		PartitionMap:          getEnvWithDefault("PARTITION_MAP", ""),
+		PartitionID:           getEnvWithDefault("PARTITION_ID", ""),
+		PartitionRouter:       getEnvWithDefault("PARTITION_ROUTER", "false"),
+		PartitionAPIKey:       getEnvWithDefault("PARTITION_API_KEY", ""),
+		PartitionEventsFile:   getEnvWithDefault("PARTITION_EVENTS_FILE", "data/router-events.json"),
+		PartitionStateFile:    getEnvWithDefault("PARTITION_STATE_FILE", "data/router-offsets.json"),
+		PartitionPollWait:     getEnvWithDefault("PARTITION_POLL_WAIT", "10s"),
+		PartitionProxyTimeout: getEnvWithDefault("PARTITION_PROXY_TIMEOUT", "10s"),
+
+		// Availability and latency objectives per endpoint group
+		SLOObjectives: getEnvWithDefault("SLO_OBJECTIVES", "writes:99.9:500ms,reads:99.9:250ms,events:99.5,admin:99:2s"),
+
+		// Request deadlines per endpoint group and route prefix
+		RequestTimeoutRead:        getEnvWithDefault("REQUEST_TIMEOUT_READ", "2s"),
+		RequestTimeoutWrite:       getEnvWithDefault("REQUEST_TIMEOUT_WRITE", "10s"),
+		RequestTimeoutAdmin:       getEnvWithDefault("REQUEST_TIMEOUT_ADMIN", "30s"),
+		RequestTimeoutStream:      getEnvWithDefault("REQUEST_TIMEOUT_STREAM", "2m"),
+		RequestTimeoutEventsGrace: getEnvWithDefault("REQUEST_TIMEOUT_EVENTS_GRACE", "5s"),
+		RequestTimeoutRoutes:      getEnvWithDefault("REQUEST_TIMEOUT_ROUTES", "/v1/admin/restore=5m"),
+
+		// TLS and mutual TLS for store connections
+		TLSCertFile:       getEnvWithDefault("TLS_CERT_FILE", ""),
+		TLSKeyFile:        getEnvWithDefault("TLS_KEY_FILE", ""),
+		TLSClientCAFile:   getEnvWithDefault("TLS_CLIENT_CA_FILE", ""),
+		TLSClientAuth:     getEnvWithDefault("TLS_CLIENT_AUTH", "optional"),
+		TLSReloadInterval: getEnvWithDefault("TLS_RELOAD_INTERVAL", "1m"),
+
+		// Feature flags for experimental behaviors
+		FeatureFlags:         getEnvWithDefault("FEATURE_FLAGS", ""),
+		FeatureFlagOverrides: getEnvWithDefault("FEATURE_FLAG_OVERRIDES", ""),
+
+		// Chaos fault injection for integration testing
+		ChaosEnabled:       getEnvWithDefault("CHAOS_ENABLED", "false"),
+		ChaosRoutes:        getEnvWithDefault("CHAOS_ROUTES", "/v1/inventory"),
+		ChaosLatency:       getEnvWithDefault("CHAOS_LATENCY", "0s"),
+		ChaosLatencyRate:   getEnvWithDefault("CHAOS_LATENCY_RATE", "0"),
+		ChaosErrorRate:     getEnvWithDefault("CHAOS_ERROR_RATE", "0"),
+		ChaosResetRate:     getEnvWithDefault("CHAOS_RESET_RATE", "0"),
+		ChaosDuplicateRate: getEnvWithDefault("CHAOS_DUPLICATE_RATE", "0"),
+		ChaosSeed:          getEnvWithDefault("CHAOS_SEED", ""),
 	}
 
 	// Configure slog based on log level
@@ -70,18 +319,86 @@ func LoadConfig() *Config {
 		"environment", config.Environment,
 		"logLevel", config.LogLevel,
 		"dataPath", config.DataPath,
+		"seedDataPath", config.SeedDataPath,
 		"idempotencyCacheTTL", config.IdempotencyCacheTTL,
 		"idempotencyCacheCleanupInterval", config.IdempotencyCacheCleanupInterval,
 		"enableJSONPersistence", config.EnableJSONPersistence,
+		"persistenceFlushInterval", config.PersistenceFlushInterval,
+		"persistenceFlushMaxUpdates", config.PersistenceFlushMaxUpdates,
 		"inventoryWorkerCount", config.InventoryWorkerCount,
 		"inventoryQueueBufferSize", config.InventoryQueueBufferSize,
+		"inventoryQueueHighWaterMark", config.InventoryQueueHighWaterMark,
+		"inventoryQueueLaneQuotas", config.InventoryQueueLaneQuotas,
+		"readCacheEnabled", config.ReadCacheEnabled,
+		"notFoundCacheTTL", config.NotFoundCacheTTL,
+		"notFoundCacheMaxEntries", config.NotFoundCacheMaxEntries,
+		"lockWatchdogThreshold", config.LockWatchdogThreshold,
+		"updateTimeout", config.UpdateTimeout,
 		"maxEventsInQueue", config.MaxEventsInQueue,
+		"maxRetainedEvents", config.MaxRetainedEvents,
+		"eventRetentionMaxAge", config.EventRetentionMaxAge,
+		"eventsFileMaxBytes", config.EventsFileMaxBytes,
 		"eventsFilePath", config.EventsFilePath,
+		"snapshotsDir", config.SnapshotsDir,
+		"eventFiltersFilePath", config.EventFiltersFilePath,
+		"transfersFilePath", config.TransfersFilePath,
+		"maxTransferHistory", config.MaxTransferHistory,
+		"stocktakesFilePath", config.StocktakesFilePath,
+		"ordersFilePath", config.OrdersFilePath,
+		"reportsDir", config.ReportsDir,
+		"archiveSink", config.ArchiveSink,
+		"archiveDir", config.ArchiveDir,
+		"archiveBucket", config.ArchiveBucket,
+		"archivePrefix", config.ArchivePrefix,
+		"archiveEndpoint", config.ArchiveEndpoint,
+		"baseCurrency", config.BaseCurrency,
+		"currencyRates", config.CurrencyRates,
 		"rateLimitEnabled", config.RateLimitEnabled,
 		"rateLimitType", config.RateLimitType,
 		"rateLimitRequestsPerMinute", config.RateLimitRequestsPerMinute,
 		"rateLimitWindowMinutes", config.RateLimitWindowMinutes,
-		"rateLimitAdminRequestsPerMinute", config.RateLimitAdminRequestsPerMinute)
+		"rateLimitAdminRequestsPerMinute", config.RateLimitAdminRequestsPerMinute,
+		"rateLimitAlgorithm", config.RateLimitAlgorithm,
+		"rateLimitBurstSize", config.RateLimitBurstSize,
+		"rateLimitTiers", config.RateLimitTiers,
+		"maxRequestBodyBytes", config.MaxRequestBodyBytes,
+		"maxAdminRequestBodyBytes", config.MaxAdminRequestBodyBytes,
+		"maxBatchUpdateItems", config.MaxBatchUpdateItems,
+		"maxAdminItemsPerRequest", config.MaxAdminItemsPerRequest,
+		"maxAdminJobBodyBytes", config.MaxAdminJobBodyBytes,
+		"maxAdminJobItems", config.MaxAdminJobItems,
+		"adminJobWorkers", config.AdminJobWorkers,
+		"adminJobChunkSize", config.AdminJobChunkSize,
+		"jobsFilePath", config.JobsFilePath,
+		"maintenanceMode", config.MaintenanceMode,
+		"leaderLockFile", config.LeaderLockFile,
+		"leaderAdvertiseURL", config.LeaderAdvertiseURL,
+		"leaderInstanceID", config.LeaderInstanceID,
+		"leaderRetryInterval", config.LeaderRetryInterval,
+		"followerReloadInterval", config.FollowerReloadInterval,
+		"partitionMap", config.PartitionMap,
+		"partitionID", config.PartitionID,
+		"partitionRouter", config.PartitionRouter,
+		"partitionEventsFile", config.PartitionEventsFile,
+		"partitionStateFile", config.PartitionStateFile,
+		"partitionPollWait", config.PartitionPollWait,
+		"partitionProxyTimeout", config.PartitionProxyTimeout,
+		"sloObjectives", config.SLOObjectives,
+		"requestTimeoutRead", config.RequestTimeoutRead,
+		"requestTimeoutWrite", config.RequestTimeoutWrite,
+		"requestTimeoutAdmin", config.RequestTimeoutAdmin,
+		"requestTimeoutStream", config.RequestTimeoutStream,
+		"requestTimeoutEventsGrace", config.RequestTimeoutEventsGrace,
+		"requestTimeoutRoutes", config.RequestTimeoutRoutes,
+		"compressionEnabled", config.CompressionEnabled,
+		"compressionMinBytes", config.CompressionMinBytes,
+		"tlsCertFile", config.TLSCertFile,
+		"tlsClientCAFile", config.TLSClientCAFile,
+		"tlsClientAuth", config.TLSClientAuth,
+		"tlsReloadInterval", config.TLSReloadInterval,
+		"featureFlags", config.FeatureFlags,
+		"featureFlagOverrides", config.FeatureFlagOverrides,
+		"chaosEnabled", config.ChaosEnabled)
 
 	return config
 }
@@ -103,10 +420,12 @@ func setupLogging(logLevel string) {
 		level = slog.LevelInfo
 	}
 
-	// Create a text handler with the specified log level
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+	// Create a text handler with the specified log level; records logged with a
+	// request context also get its request_id, and its trace_id and span_id
+	// when the request is traced
+	handler := telemetry.NewLogHandler(requestid.NewLogHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: level,
-	})
+	})))
 
 	// Set the default logger for the entire application
 	slog.SetDefault(slog.New(handler))
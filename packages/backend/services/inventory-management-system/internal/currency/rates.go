@@ -0,0 +1,202 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"inventory-management-api/internal/models"
+)
+
+// ErrUnsupportedCurrency is returned for a currency there is no rate for.
+// Providers wrap it so callers can tell it apart from a failure to get rates.
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// RatesProvider supplies exchange rates between ISO 4217 currencies
+type RatesProvider interface {
+	// Rate returns how many units of "to" one unit of "from" is worth, or an
+	// error wrapping ErrUnsupportedCurrency when either has no rate
+	Rate(from, to string) (float64, error)
+	// BaseCurrency returns the currency all rates are expressed against
+	BaseCurrency() string
+}
+
+// minorUnitExponents lists currencies whose minor unit is not 2 decimal places
+var minorUnitExponents = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"CLP": 0,
+	"BHD": 3,
+	"KWD": 3,
+}
+
+// MinorUnitExponent returns the number of decimal places used by the currency
+func MinorUnitExponent(currencyCode string) int {
+	if exp, ok := minorUnitExponents[strings.ToUpper(currencyCode)]; ok {
+		return exp
+	}
+	return 2
+}
+
+// ToMinorUnits converts a decimal amount into integer minor units for the currency
+func ToMinorUnits(amount float64, currencyCode string) int64 {
+	factor := math.Pow10(MinorUnitExponent(currencyCode))
+	return int64(math.Round(amount * factor))
+}
+
+// FromMinorUnits converts integer minor units back into a decimal amount
+func FromMinorUnits(amount int64, currencyCode string) float64 {
+	factor := math.Pow10(MinorUnitExponent(currencyCode))
+	return float64(amount) / factor
+}
+
+// IsValidCode reports whether the code looks like an ISO 4217 currency code
+func IsValidCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// StaticRatesProvider serves rates from a fixed table relative to a base currency
+type StaticRatesProvider struct {
+	mu    sync.RWMutex
+	base  string
+	rates map[string]float64 // units of currency per one unit of base
+}
+
+// NewStaticRatesProvider creates a rates provider from a base currency and rate table
+func NewStaticRatesProvider(base string, rates map[string]float64) *StaticRatesProvider {
+	base = strings.ToUpper(base)
+	table := make(map[string]float64, len(rates)+1)
+	for code, rate := range rates {
+		table[strings.ToUpper(code)] = rate
+	}
+	table[base] = 1
+
+	return &StaticRatesProvider{
+		base:  base,
+		rates: table,
+	}
+}
+
+// ParseRates parses a rate table in the form "EUR:0.92,MXN:17.1"
+func ParseRates(value string) (map[string]float64, error) {
+	rates := make(map[string]float64)
+	if strings.TrimSpace(value) == "" {
+		return rates, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid rate entry: %q", pair)
+		}
+
+		code := strings.ToUpper(strings.TrimSpace(parts[0]))
+		if !IsValidCode(code) {
+			return nil, fmt.Errorf("invalid currency code: %q", parts[0])
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s: %q", code, parts[1])
+		}
+		rates[code] = rate
+	}
+
+	return rates, nil
+}
+
+// NewStaticRatesProviderFromConfig builds a provider from raw configuration values,
+// falling back to an identity table for the base currency if rates are invalid
+func NewStaticRatesProviderFromConfig(base, rates string) *StaticRatesProvider {
+	if !IsValidCode(strings.ToUpper(base)) {
+		slog.Warn("Invalid base currency, using default", "provided", base, "default", "USD")
+		base = "USD"
+	}
+
+	table, err := ParseRates(rates)
+	if err != nil {
+		slog.Warn("Invalid currency rates, only base currency will be available", "provided", rates, "error", err)
+		table = map[string]float64{}
+	}
+
+	slog.Info("Currency rates provider initialized",
+		"base_currency", strings.ToUpper(base),
+		"currency_count", len(table)+1)
+
+	return NewStaticRatesProvider(base, table)
+}
+
+// BaseCurrency returns the base currency of the rate table
+func (p *StaticRatesProvider) BaseCurrency() string {
+	return p.base
+}
+
+// Rate returns the exchange rate between two currencies
+func (p *StaticRatesProvider) Rate(from, to string) (float64, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	from = strings.ToUpper(from)
+	to = strings.ToUpper(to)
+
+	fromRate, ok := p.rates[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+	toRate, ok := p.rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+
+	return toRate / fromRate, nil
+}
+
+// SetRate updates the rate of a currency relative to the base currency
+func (p *StaticRatesProvider) SetRate(code string, rate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rates[strings.ToUpper(code)] = rate
+}
+
+// Currencies returns the list of supported currency codes
+func (p *StaticRatesProvider) Currencies() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	codes := make([]string, 0, len(p.rates))
+	for code := range p.rates {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// Convert converts money into the target currency using the provider's rates
+func Convert(provider RatesProvider, amount models.Money, to string) (models.Money, error) {
+	to = strings.ToUpper(to)
+	if strings.EqualFold(amount.Currency, to) {
+		return models.Money{Amount: amount.Amount, Currency: to}, nil
+	}
+
+	rate, err := provider.Rate(amount.Currency, to)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	decimal := FromMinorUnits(amount.Amount, amount.Currency) * rate
+	return models.Money{
+		Amount:   ToMinorUnits(decimal, to),
+		Currency: to,
+	}, nil
+}
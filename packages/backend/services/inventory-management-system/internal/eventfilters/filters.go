@@ -0,0 +1,327 @@
+// Package eventfilters narrows the event stream to the part of the catalog a
+// store carries. Filters are given ad hoc on a poll or stored by name in a JSON
+// file, and a stored filter can be allocated to stores so their polls use it
+// without naming it.
+package eventfilters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+var (
+	// ErrNotFound is returned for an unknown filter ID
+	ErrNotFound = errors.New("event filter not found")
+	// ErrInvalidID is returned for filter IDs that are not lowercase slugs
+	ErrInvalidID = errors.New("filter ID must be 1-64 lowercase letters, digits, '-' or '_'")
+	// ErrEmptyFilter is returned for a filter without products or categories
+	ErrEmptyFilter = errors.New("filter needs at least one product ID or category")
+)
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// StoreAllocatedError is returned when a store is already allocated to another filter
+type StoreAllocatedError struct {
+	StoreID  string
+	FilterID string
+}
+
+func (e *StoreAllocatedError) Error() string {
+	return fmt.Sprintf("store %s is already allocated to filter %s", e.StoreID, e.FilterID)
+}
+
+// Matcher decides which events a filter lets through. A nil Matcher matches
+// every event.
+type Matcher struct {
+	products   map[string]bool
+	categories map[string]bool
+}
+
+// NewMatcher builds a matcher for the union of the given products and
+// categories; it returns nil when both are empty
+func NewMatcher(productIDs, categories []string) *Matcher {
+	m := &Matcher{products: make(map[string]bool), categories: make(map[string]bool)}
+	for _, productID := range productIDs {
+		if productID = strings.TrimSpace(productID); productID != "" {
+			m.products[productID] = true
+		}
+	}
+	for _, category := range categories {
+		if category = normalizeCategory(category); category != "" {
+			m.categories[category] = true
+		}
+	}
+	if len(m.products) == 0 && len(m.categories) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Merge returns a matcher for the union of m and other
+func (m *Matcher) Merge(other *Matcher) *Matcher {
+	switch {
+	case m == nil:
+		return other
+	case other == nil:
+		return m
+	}
+	merged := &Matcher{products: make(map[string]bool), categories: make(map[string]bool)}
+	for _, source := range []*Matcher{m, other} {
+		for productID := range source.products {
+			merged.products[productID] = true
+		}
+		for category := range source.categories {
+			merged.categories[category] = true
+		}
+	}
+	return merged
+}
+
+// Matches reports whether the event concerns a product in the filter, or a
+// product the event moves out of it. Events about no product, like
+// system_restored, always match.
+func (m *Matcher) Matches(event models.Event) bool {
+	if m == nil || event.ProductID == "" {
+		return true
+	}
+	return m.covers(event.ProductID, event.Data.Category) || m.Leaves(event)
+}
+
+// Leaves reports whether the event moves a product out of the filter: its
+// previous category was in the filter and neither its ID nor its new
+// category is
+func (m *Matcher) Leaves(event models.Event) bool {
+	if m == nil || event.PreviousCategory == "" {
+		return false
+	}
+	return m.categories[normalizeCategory(event.PreviousCategory)] && !m.covers(event.ProductID, event.Data.Category)
+}
+
+// covers reports whether the filter holds the product in its category
+func (m *Matcher) covers(productID, category string) bool {
+	return m.products[productID] || m.categories[normalizeCategory(category)]
+}
+
+// Filter returns the events that match, keeping their order. An event moving
+// a product out of the filter is passed on as a product_deleted event, so
+// stores drop the product instead of keeping it with stock that no longer
+// updates.
+func (m *Matcher) Filter(events []models.Event) []models.Event {
+	if m == nil {
+		return events
+	}
+	matched := make([]models.Event, 0, len(events))
+	for _, event := range events {
+		switch {
+		case m.Leaves(event):
+			removal := event
+			removal.EventType = models.EventTypeProductDeleted
+			matched = append(matched, removal)
+		case m.Matches(event):
+			matched = append(matched, event)
+		}
+	}
+	return matched
+}
+
+// Store keeps named filters in a JSON file
+type Store struct {
+	path    string
+	mutex   sync.RWMutex
+	filters map[string]models.EventFilter
+}
+
+// NewStore loads the filters saved at path; a missing file means no filters
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, filters: make(map[string]models.EventFilter)}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	slog.Info("Event filters loaded", "path", path, "count", len(s.filters))
+	return s, nil
+}
+
+// Reload replaces the filters with the ones saved at the path, for an
+// instance following or taking over from another that writes the file
+func (s *Store) Reload() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read event filters: %w", err)
+	}
+
+	var filters []models.EventFilter
+	if err := json.Unmarshal(data, &filters); err != nil {
+		return fmt.Errorf("failed to decode event filters: %w", err)
+	}
+
+	loaded := make(map[string]models.EventFilter, len(filters))
+	for _, filter := range filters {
+		loaded[filter.ID] = filter
+	}
+	s.mutex.Lock()
+	s.filters = loaded
+	s.mutex.Unlock()
+	return nil
+}
+
+// List returns every filter sorted by ID
+func (s *Store) List() []models.EventFilter {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.sortedLocked()
+}
+
+// Get returns one filter
+func (s *Store) Get(id string) (models.EventFilter, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	filter, ok := s.filters[id]
+	if !ok {
+		return models.EventFilter{}, ErrNotFound
+	}
+	return filter, nil
+}
+
+// ForStore returns the filter allocated to storeID, if any
+func (s *Store) ForStore(storeID string) (models.EventFilter, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, filter := range s.filters {
+		for _, allocated := range filter.StoreIDs {
+			if allocated == storeID {
+				return filter, true
+			}
+		}
+	}
+	return models.EventFilter{}, false
+}
+
+// Put creates or replaces the filter id and saves the file. A store can be
+// allocated to one filter only.
+func (s *Store) Put(id string, req models.EventFilterRequest) (models.EventFilter, error) {
+	if !validID.MatchString(id) {
+		return models.EventFilter{}, ErrInvalidID
+	}
+
+	filter := models.EventFilter{
+		ID:         id,
+		ProductIDs: cleanList(req.ProductIDs, strings.TrimSpace),
+		Categories: cleanList(req.Categories, normalizeCategory),
+		StoreIDs:   cleanList(req.StoreIDs, strings.TrimSpace),
+		UpdatedAt:  clock.Timestamp(),
+	}
+	if len(filter.ProductIDs) == 0 && len(filter.Categories) == 0 {
+		return models.EventFilter{}, ErrEmptyFilter
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, other := range s.filters {
+		if other.ID == id {
+			continue
+		}
+		for _, storeID := range filter.StoreIDs {
+			for _, allocated := range other.StoreIDs {
+				if allocated == storeID {
+					return models.EventFilter{}, &StoreAllocatedError{StoreID: storeID, FilterID: other.ID}
+				}
+			}
+		}
+	}
+
+	previous, existed := s.filters[id]
+	s.filters[id] = filter
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.filters[id] = previous
+		} else {
+			delete(s.filters, id)
+		}
+		return models.EventFilter{}, err
+	}
+	return filter, nil
+}
+
+// Delete removes the filter id and saves the file
+func (s *Store) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, ok := s.filters[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(s.filters, id)
+	if err := s.saveLocked(); err != nil {
+		s.filters[id] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *Store) sortedLocked() []models.EventFilter {
+	filters := make([]models.EventFilter, 0, len(s.filters))
+	for _, filter := range s.filters {
+		filters = append(filters, filter)
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].ID < filters[j].ID })
+	return filters
+}
+
+// saveLocked writes the filters atomically (caller holds the write lock)
+func (s *Store) saveLocked() error {
+	data, err := json.MarshalIndent(s.sortedLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode event filters: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create event filters directory: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write event filters: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store event filters: %w", err)
+	}
+	return nil
+}
+
+// cleanList normalizes, deduplicates and sorts values, dropping blanks
+func cleanList(values []string, normalize func(string) string) []string {
+	seen := make(map[string]bool, len(values))
+	cleaned := make([]string, 0, len(values))
+	for _, value := range values {
+		value = normalize(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		cleaned = append(cleaned, value)
+	}
+	sort.Strings(cleaned)
+	return cleaned
+}
+
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RegisterMetrics exposes the queue's retention as observable metrics: the
+// oldest retained event, the events file size and the events rotation dropped
+func (eq *EventQueue) RegisterMetrics() error {
+	meter := otel.Meter("inventory-management-api")
+
+	oldestOffset, err := meter.Int64ObservableGauge(
+		"inventory_event_queue_oldest_offset",
+		metric.WithDescription("Offset of the oldest event still retained in the queue"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create oldest offset gauge: %w", err)
+	}
+
+	oldestAge, err := meter.Int64ObservableGauge(
+		"inventory_event_queue_oldest_age",
+		metric.WithDescription("Age of the oldest event still retained in the queue"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create oldest age gauge: %w", err)
+	}
+
+	fileSize, err := meter.Int64ObservableGauge(
+		"inventory_event_queue_file_size",
+		metric.WithDescription("Size of the events file at its last save"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create events file size gauge: %w", err)
+	}
+
+	rotated, err := meter.Int64ObservableCounter(
+		"inventory_event_queue_rotated_events_total",
+		metric.WithDescription("Events dropped by rotation, by the limit that dropped them"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create rotated events counter: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		stats := eq.Stats()
+		observer.ObserveInt64(oldestOffset, eq.OldestAvailableOffset())
+		if stats.OldestTimestamp != "" {
+			if timestamp, err := time.Parse(time.RFC3339, stats.OldestTimestamp); err == nil {
+				observer.ObserveInt64(oldestAge, int64(time.Since(timestamp).Seconds()))
+			}
+		} else {
+			observer.ObserveInt64(oldestAge, 0)
+		}
+		observer.ObserveInt64(fileSize, eq.fileSize.Load())
+		for reason, count := range stats.RotatedEvents {
+			observer.ObserveInt64(rotated, count, metric.WithAttributes(attribute.String("reason", reason)))
+		}
+		return nil
+	}, oldestOffset, oldestAge, fileSize, rotated)
+	if err != nil {
+		return fmt.Errorf("failed to register event queue metrics callback: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// AppendMerged appends an event a partition router read from a partition's
+// stream, keeping its timestamp and partition fields, and returns its offset
+// in this queue. Timestamps written in another format are normalized.
+func (eq *EventQueue) AppendMerged(event models.Event) int64 {
+	if event.Timestamp == "" {
+		event.Timestamp = clock.Format(eq.clock.Now())
+	} else {
+		event.Timestamp = clock.Normalize(event.Timestamp)
+	}
+
+	eq.mu.Lock()
+	event.Offset = eq.nextOffset
+	eq.nextOffset++
+	eq.appendLocked(event)
+	eq.mu.Unlock()
+
+	eq.published(event)
+	return event.Offset
+}
+
+// PartitionOffsetsFrom returns, for each partition with merged events at or
+// after offset, the lowest partition offset among them: where that partition's
+// stream stood when this queue reached offset
+func (eq *EventQueue) PartitionOffsetsFrom(offset int64) map[string]int64 {
+	eq.mu.RLock()
+	defer eq.mu.RUnlock()
+
+	offsets := make(map[string]int64)
+	for i := len(eq.events) - 1; i >= 0 && eq.events[i].Offset >= offset; i-- {
+		if partition := eq.events[i].Partition; partition != "" {
+			offsets[partition] = eq.events[i].PartitionOffset
+		}
+	}
+	return offsets
+}
+
+// MergedPartitionOffsets returns, for each partition with merged events in the
+// queue, the partition offset following the newest of them
+func (eq *EventQueue) MergedPartitionOffsets() map[string]int64 {
+	eq.mu.RLock()
+	defer eq.mu.RUnlock()
+
+	offsets := make(map[string]int64)
+	for _, event := range eq.events {
+		if event.Partition != "" {
+			offsets[event.Partition] = event.PartitionOffset + 1
+		}
+	}
+	return offsets
+}
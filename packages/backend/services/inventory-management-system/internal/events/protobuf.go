@@ -0,0 +1,475 @@
+package events
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"inventory-management-api/internal/models"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ProtobufContentType is the media type of protobuf-encoded events responses,
+// laid out as described in events.proto
+const ProtobufContentType = "application/x-protobuf"
+
+// MarshalProtobuf encodes an events response in the events.proto wire format.
+// Zero values are omitted, as proto3 does.
+func MarshalProtobuf(response models.EventsResponse) []byte {
+	var b []byte
+	for _, event := range response.Events {
+		b = appendMessage(b, 1, marshalEvent(event))
+	}
+	b = appendInt(b, 2, response.NextOffset)
+	if response.HasMore {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendInt(b, 4, int64(response.Count))
+	if response.Filtered {
+		b = protowire.AppendTag(b, 5, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendInt(b, 6, response.CurrentOffset)
+	return b
+}
+
+// UnmarshalProtobuf decodes an events response encoded by MarshalProtobuf,
+// skipping fields it does not know
+func UnmarshalProtobuf(b []byte) (models.EventsResponse, error) {
+	var response models.EventsResponse
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			event, err := unmarshalEvent(value)
+			if err != nil {
+				return 0, err
+			}
+			response.Events = append(response.Events, event)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			return consumeInt(b, &response.NextOffset)
+		case num == 3 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.HasMore = value != 0
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			var count int64
+			n, err := consumeInt(b, &count)
+			response.Count = int(count)
+			return n, err
+		case num == 5 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.Filtered = value != 0
+			return n, nil
+		case num == 6 && typ == protowire.VarintType:
+			return consumeInt(b, &response.CurrentOffset)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if response.Events == nil {
+		response.Events = []models.Event{}
+	}
+	return response, err
+}
+
+func marshalEvent(event models.Event) []byte {
+	var b []byte
+	b = appendInt(b, 1, event.Offset)
+	b = appendString(b, 2, event.Timestamp)
+	b = appendString(b, 3, event.EventType)
+	b = appendString(b, 4, event.ProductID)
+	b = appendMessage(b, 5, marshalProduct(event.Data))
+	b = appendInt(b, 6, int64(event.Version))
+	b = appendString(b, 7, event.StoreID)
+	b = appendInt(b, 8, int64(event.Delta))
+	b = appendString(b, 9, event.ToStoreID)
+	b = appendInt(b, 10, int64(event.Quantity))
+	b = appendString(b, 11, event.Reason)
+	b = appendString(b, 12, event.OrderID)
+	if event.Category != nil {
+		var category []byte
+		category = appendString(category, 1, event.Category.ID)
+		category = appendString(category, 2, event.Category.Name)
+		category = appendString(category, 3, event.Category.ParentID)
+		category = appendString(category, 4, event.Category.UpdatedAt)
+		if event.Category.StockPolicy != nil {
+			category = appendMessage(category, 5, marshalStockPolicy(*event.Category.StockPolicy))
+		}
+		b = appendMessage(b, 13, category)
+	}
+	if event.Backorder != nil {
+		var backorder []byte
+		backorder = appendString(backorder, 1, event.Backorder.StoreID)
+		backorder = appendString(backorder, 2, event.Backorder.IdempotencyKey)
+		backorder = appendInt(backorder, 3, int64(event.Backorder.Version))
+		backorder = appendInt(backorder, 4, int64(event.Backorder.Units))
+		backorder = appendInt(backorder, 5, int64(event.Backorder.Remaining))
+		backorder = appendString(backorder, 6, event.Backorder.CreatedAt)
+		b = appendMessage(b, 14, backorder)
+	}
+	b = appendString(b, 15, event.PreviousCategory)
+	return b
+}
+
+func unmarshalEvent(b []byte) (models.Event, error) {
+	var event models.Event
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			return consumeInt(b, &event.Offset)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(b, &event.Timestamp)
+		case num == 3 && typ == protowire.BytesType:
+			return consumeString(b, &event.EventType)
+		case num == 4 && typ == protowire.BytesType:
+			return consumeString(b, &event.ProductID)
+		case num == 5 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			product, err := unmarshalProduct(value)
+			event.Data = product
+			return n, err
+		case num == 6 && typ == protowire.VarintType:
+			var version int64
+			n, err := consumeInt(b, &version)
+			event.Version = int(version)
+			return n, err
+		case num == 7 && typ == protowire.BytesType:
+			return consumeString(b, &event.StoreID)
+		case num == 8 && typ == protowire.VarintType:
+			var delta int64
+			n, err := consumeInt(b, &delta)
+			event.Delta = int(delta)
+			return n, err
+		case num == 9 && typ == protowire.BytesType:
+			return consumeString(b, &event.ToStoreID)
+		case num == 10 && typ == protowire.VarintType:
+			var quantity int64
+			n, err := consumeInt(b, &quantity)
+			event.Quantity = int(quantity)
+			return n, err
+		case num == 11 && typ == protowire.BytesType:
+			return consumeString(b, &event.Reason)
+		case num == 12 && typ == protowire.BytesType:
+			return consumeString(b, &event.OrderID)
+		case num == 13 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var category models.Category
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &category.ID)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &category.Name)
+				case num == 3 && typ == protowire.BytesType:
+					return consumeString(b, &category.ParentID)
+				case num == 4 && typ == protowire.BytesType:
+					return consumeString(b, &category.UpdatedAt)
+				case num == 5 && typ == protowire.BytesType:
+					return consumeStockPolicy(b, &category.StockPolicy)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			event.Category = &category
+			return n, err
+		case num == 14 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var backorder models.Backorder
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				var value int64
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &backorder.StoreID)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &backorder.IdempotencyKey)
+				case num == 3 && typ == protowire.VarintType:
+					n, err := consumeInt(b, &value)
+					backorder.Version = int(value)
+					return n, err
+				case num == 4 && typ == protowire.VarintType:
+					n, err := consumeInt(b, &value)
+					backorder.Units = int(value)
+					return n, err
+				case num == 5 && typ == protowire.VarintType:
+					n, err := consumeInt(b, &value)
+					backorder.Remaining = int(value)
+					return n, err
+				case num == 6 && typ == protowire.BytesType:
+					return consumeString(b, &backorder.CreatedAt)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			event.Backorder = &backorder
+			return n, err
+		case num == 15 && typ == protowire.BytesType:
+			return consumeString(b, &event.PreviousCategory)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	return event, err
+}
+
+func marshalProduct(product models.ProductResponse) []byte {
+	var b []byte
+	b = appendString(b, 1, product.ProductID)
+	b = appendString(b, 2, product.Name)
+	b = appendInt(b, 3, int64(product.Available))
+	b = appendInt(b, 4, int64(product.Version))
+	b = appendString(b, 5, product.LastUpdated)
+	if product.Price != 0 {
+		b = protowire.AppendTag(b, 6, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(product.Price))
+	}
+	for _, price := range product.Prices {
+		var money []byte
+		money = appendInt(money, 1, price.Amount)
+		money = appendString(money, 2, price.Currency)
+		b = appendMessage(b, 7, money)
+	}
+	b = appendString(b, 8, product.Category)
+	b = appendString(b, 9, product.Barcode)
+	for _, alias := range product.BarcodeAliases {
+		b = protowire.AppendTag(b, 10, protowire.BytesType)
+		b = protowire.AppendString(b, alias)
+	}
+	for _, asset := range product.Assets {
+		var message []byte
+		message = appendString(message, 1, asset.Type)
+		message = appendString(message, 2, asset.URL)
+		message = appendString(message, 3, asset.Checksum)
+		b = appendMessage(b, 11, message)
+	}
+	if product.StockPolicy != nil {
+		b = appendMessage(b, 12, marshalStockPolicy(*product.StockPolicy))
+	}
+	// Map entries in store order, so the same product always encodes the same
+	for _, storeID := range slices.Sorted(maps.Keys(product.Allocations)) {
+		var entry []byte
+		entry = appendString(entry, 1, storeID)
+		entry = appendInt(entry, 2, int64(product.Allocations[storeID]))
+		b = appendMessage(b, 13, entry)
+	}
+	return b
+}
+
+// marshalStockPolicy always encodes set bounds, as a minimum or maximum of
+// zero differs from none
+func marshalStockPolicy(policy models.StockPolicy) []byte {
+	var b []byte
+	if policy.MinStock != nil {
+		b = protowire.AppendTag(b, 1, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(*policy.MinStock)))
+	}
+	if policy.MaxStock != nil {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(int64(*policy.MaxStock)))
+	}
+	if policy.AllowBackorder {
+		b = protowire.AppendTag(b, 3, protowire.VarintType)
+		b = protowire.AppendVarint(b, 1)
+	}
+	b = appendInt(b, 4, int64(policy.MaxBackorder))
+	return b
+}
+
+func consumeStockPolicy(b []byte, target **models.StockPolicy) (int, error) {
+	value, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+	var policy models.StockPolicy
+	err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case (num == 1 || num == 2) && typ == protowire.VarintType:
+			var bound int64
+			n, err := consumeInt(b, &bound)
+			stock := int(bound)
+			if num == 1 {
+				policy.MinStock = &stock
+			} else {
+				policy.MaxStock = &stock
+			}
+			return n, err
+		case num == 3 && typ == protowire.VarintType:
+			allow, n := protowire.ConsumeVarint(b)
+			policy.AllowBackorder = allow != 0
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			var maxBackorder int64
+			n, err := consumeInt(b, &maxBackorder)
+			policy.MaxBackorder = int(maxBackorder)
+			return n, err
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	*target = &policy
+	return n, err
+}
+
+func unmarshalProduct(b []byte) (models.ProductResponse, error) {
+	var product models.ProductResponse
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &product.ProductID)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(b, &product.Name)
+		case num == 3 && typ == protowire.VarintType:
+			var available int64
+			n, err := consumeInt(b, &available)
+			product.Available = int(available)
+			return n, err
+		case num == 4 && typ == protowire.VarintType:
+			var version int64
+			n, err := consumeInt(b, &version)
+			product.Version = int(version)
+			return n, err
+		case num == 5 && typ == protowire.BytesType:
+			return consumeString(b, &product.LastUpdated)
+		case num == 6 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			product.Price = math.Float64frombits(bits)
+			return n, nil
+		case num == 7 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var money models.Money
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.VarintType:
+					return consumeInt(b, &money.Amount)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &money.Currency)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			product.Prices = append(product.Prices, money)
+			return n, err
+		case num == 8 && typ == protowire.BytesType:
+			return consumeString(b, &product.Category)
+		case num == 9 && typ == protowire.BytesType:
+			return consumeString(b, &product.Barcode)
+		case num == 10 && typ == protowire.BytesType:
+			var alias string
+			n, err := consumeString(b, &alias)
+			product.BarcodeAliases = append(product.BarcodeAliases, alias)
+			return n, err
+		case num == 11 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var asset models.ProductAsset
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &asset.Type)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &asset.URL)
+				case num == 3 && typ == protowire.BytesType:
+					return consumeString(b, &asset.Checksum)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			product.Assets = append(product.Assets, asset)
+			return n, err
+		case num == 12 && typ == protowire.BytesType:
+			return consumeStockPolicy(b, &product.StockPolicy)
+		case num == 13 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var storeID string
+			var units int64
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &storeID)
+				case num == 2 && typ == protowire.VarintType:
+					return consumeInt(b, &units)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			if product.Allocations == nil {
+				product.Allocations = make(map[string]int)
+			}
+			product.Allocations[storeID] = int(units)
+			return n, err
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	return product, err
+}
+
+func appendInt(b []byte, num protowire.Number, value int64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+// consumeFields walks the fields of one message. field consumes the value of
+// each field and returns its length, or a negative protowire error code.
+func consumeFields(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeInt(b []byte, target *int64) (int, error) {
+	value, n := protowire.ConsumeVarint(b)
+	*target = int64(value)
+	return n, nil
+}
+
+func consumeString(b []byte, target *string) (int, error) {
+	value, n := protowire.ConsumeString(b)
+	*target = value
+	return n, nil
+}
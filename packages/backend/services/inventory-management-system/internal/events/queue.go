@@ -2,13 +2,17 @@ package events
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"inventory-management-api/internal/clock"
 	"inventory-management-api/internal/models"
 )
 
@@ -17,33 +21,115 @@ type EventQueue struct {
 	mu            sync.RWMutex
 	events        []models.Event
 	nextOffset    int64
+	oldestOffset  int64 // Lowest offset still served; older ones were rotated or compacted away
 	filePath      string
 	maxEvents     int
+	maxRetained   int
+	maxAge        time.Duration    // Events older than this are rotated out (0 disables)
+	maxFileBytes  int64            // The queue rotates once the events file grows past this (0 disables)
+	clock         clock.Clock      // Ages events and times out waits
+	fileSize      atomic.Int64     // Size of the events file at the last save, scaled down by rotation
+	rotated       map[string]int64 // Events removed by rotation, by reason
 	logger        *slog.Logger
-	writeChan     chan models.Event
+	saveChan      chan struct{} // Signals the file writer that events were appended
 	stopChan      chan struct{}
+	writerDone    chan struct{}
+	saveMutex     sync.Mutex // Serializes writes of the events file
 	waiters       map[int64][]chan struct{}
 	waitersMutex  sync.RWMutex
 	resetCallback func(reason string) // Callback to notify when queue is reset due to file load failure
+	// rotatedCallback receives events removed by rotation or compaction, e.g. for archival
+	rotatedCallback func(removed []models.Event)
+	consumers       map[string]models.EventConsumer
+	// outboxSeq is the sequence number of the newest event appended from the
+	// inventory outbox; savedOutboxSeq is the newest one in the events file
+	outboxSeq      int64
+	savedOutboxSeq atomic.Int64
+	// standby is set on a follower, which only reads the events file the
+	// leader writes; fileModTime and fileBytes identify the file last reloaded
+	standby     atomic.Bool
+	fileModTime time.Time
+	fileBytes   int64
+}
+
+// ErrCommitOffsetOutOfRange is returned when a consumer commits an offset the
+// queue has not assigned yet
+var ErrCommitOffsetOutOfRange = errors.New("committed offset is beyond the next event offset")
+
+// ErrCompactOffsetOutOfRange is returned when compacting past the newest stored event
+var ErrCompactOffsetOutOfRange = errors.New("compaction offset is beyond the newest stored event")
+
+// ConsumersBehindError is returned by Compact when registered consumers have
+// not yet read past the requested offset
+type ConsumersBehindError struct {
+	BeforeOffset int64
+	Consumers    []models.EventConsumer
+}
+
+func (e *ConsumersBehindError) Error() string {
+	return fmt.Sprintf("%d consumer(s) have not read past offset %d", len(e.Consumers), e.BeforeOffset)
+}
+
+// eventsFile is the on-disk layout of the events file
+type eventsFile struct {
+	Events     []models.Event                  `json:"events"`
+	NextOffset int64                           `json:"nextOffset"`
+	Consumers  map[string]models.EventConsumer `json:"consumers,omitempty"`
+	OutboxSeq  int64                           `json:"outboxSeq,omitempty"`
 }
 
 // EventQueueConfig holds configuration for the event queue
 type EventQueueConfig struct {
 	FilePath  string
 	MaxEvents int
-	Logger    *slog.Logger
+	// MaxRetainedEvents caps the queue even when registered consumers have not
+	// committed past the oldest events (0 means 10x MaxEvents)
+	MaxRetainedEvents int
+	// MaxEventAge rotates out events older than this (0 disables)
+	MaxEventAge time.Duration
+	// MaxFileBytes rotates the queue once the events file grows past this
+	// size (0 disables)
+	MaxFileBytes int64
+	Logger       *slog.Logger
+	// Clock ages events and times out waits (nil means the wall clock)
+	Clock clock.Clock
 }
 
+// Rotation reasons, the limit that made the queue drop its oldest events
+const (
+	RotationReasonCount  = "count"
+	RotationReasonAge    = "age"
+	RotationReasonSize   = "size"
+	RotationReasonForced = "forced"
+)
+
 // NewEventQueue creates a new event queue
 func NewEventQueue(config EventQueueConfig) (*EventQueue, error) {
+	maxRetained := config.MaxRetainedEvents
+	if maxRetained <= 0 {
+		maxRetained = config.MaxEvents * 10
+	}
+
+	eventClock := config.Clock
+	if eventClock == nil {
+		eventClock = clock.System{}
+	}
+
 	eq := &EventQueue{
-		events:    make([]models.Event, 0),
-		filePath:  config.FilePath,
-		maxEvents: config.MaxEvents,
-		logger:    config.Logger,
-		writeChan: make(chan models.Event, 1000), // Buffer for async writes
-		stopChan:  make(chan struct{}),
-		waiters:   make(map[int64][]chan struct{}),
+		events:       make([]models.Event, 0),
+		filePath:     config.FilePath,
+		maxEvents:    config.MaxEvents,
+		maxRetained:  maxRetained,
+		maxAge:       config.MaxEventAge,
+		maxFileBytes: config.MaxFileBytes,
+		clock:        eventClock,
+		rotated:      make(map[string]int64),
+		logger:       config.Logger,
+		saveChan:     make(chan struct{}, 1),
+		stopChan:     make(chan struct{}),
+		writerDone:   make(chan struct{}),
+		waiters:      make(map[int64][]chan struct{}),
+		consumers:    make(map[string]models.EventConsumer),
 	}
 
 	// Create directory if it doesn't exist
@@ -65,12 +151,15 @@ func NewEventQueue(config EventQueueConfig) (*EventQueue, error) {
 		}
 	}
 
-	// Start async writer goroutine
-	go eq.asyncWriter()
+	// Start the file writer goroutine
+	go eq.fileWriter()
 
 	eq.logger.Info("Event queue initialized",
 		"file_path", config.FilePath,
 		"max_events", config.MaxEvents,
+		"max_retained_events", maxRetained,
+		"max_event_age", config.MaxEventAge,
+		"max_file_bytes", config.MaxFileBytes,
 		"loaded_events", len(eq.events),
 		"next_offset", eq.nextOffset,
 	)
@@ -78,6 +167,12 @@ func NewEventQueue(config EventQueueConfig) (*EventQueue, error) {
 	return eq, nil
 }
 
+// Clock returns the clock the queue ages events and times out waits on, for
+// callers that wait with it
+func (eq *EventQueue) Clock() clock.Clock {
+	return eq.clock
+}
+
 // SetResetCallback sets a callback function that will be called when the event queue is reset due to file load failure
 func (eq *EventQueue) SetResetCallback(callback func(reason string)) {
 	eq.mu.Lock()
@@ -88,6 +183,42 @@ func (eq *EventQueue) SetResetCallback(callback func(reason string)) {
 	eq.checkForMissingFileReset()
 }
 
+// SetRotatedCallback sets a callback that receives every batch of events
+// removed by rotation or compaction. It is called with the queue locked, so it
+// must hand the events off without blocking.
+func (eq *EventQueue) SetRotatedCallback(callback func(removed []models.Event)) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.rotatedCallback = callback
+}
+
+// notifyRotatedLocked passes a copy of removed events to the rotated callback;
+// the caller must hold eq.mu
+func (eq *EventQueue) notifyRotatedLocked(removed []models.Event) {
+	if eq.rotatedCallback == nil || len(removed) == 0 {
+		return
+	}
+	eq.rotatedCallback(append([]models.Event(nil), removed...))
+}
+
+// SetRetention changes the rotation thresholds at runtime (maxRetained 0 means
+// 10x maxEvents). The new limits apply from the next published event.
+func (eq *EventQueue) SetRetention(maxEvents, maxRetained int) {
+	if maxRetained <= 0 {
+		maxRetained = maxEvents * 10
+	}
+
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+	eq.maxEvents = maxEvents
+	eq.maxRetained = maxRetained
+
+	eq.logger.Info("Event queue retention updated",
+		"max_events", maxEvents,
+		"max_retained_events", maxRetained,
+	)
+}
+
 // checkForMissingFileReset checks if the event queue started fresh due to missing file and triggers callback
 func (eq *EventQueue) checkForMissingFileReset() {
 	// Use read lock to safely access events and nextOffset
@@ -110,32 +241,165 @@ func (eq *EventQueue) checkForMissingFileReset() {
 	}
 }
 
-// PublishEvent adds a new event to the queue
-func (eq *EventQueue) PublishEvent(eventType, productID string, data models.ProductResponse, version int) {
-	event := models.Event{
-		Offset:    eq.getNextOffset(),
-		Timestamp: time.Now().Format(time.RFC3339),
+// PublishEvent adds a new event to the queue and returns its offset
+func (eq *EventQueue) PublishEvent(eventType, productID string, data models.ProductResponse, version int) int64 {
+	return eq.publish(ProductEvent(eventType, productID, data, version))
+}
+
+// ProductEvent builds an eventType event carrying a product's full state
+func ProductEvent(eventType, productID string, data models.ProductResponse, version int) models.Event {
+	return models.Event{
 		EventType: eventType,
 		ProductID: productID,
 		Data:      data,
 		Version:   version,
 	}
+}
 
-	// Send to async writer (non-blocking)
-	select {
-	case eq.writeChan <- event:
-		eq.logger.Debug("Event queued for writing",
-			"offset", event.Offset,
-			"event_type", event.EventType,
-			"product_id", event.ProductID,
-		)
-	default:
-		eq.logger.Error("Event write channel full, dropping event",
-			"offset", event.Offset,
-			"event_type", event.EventType,
-			"product_id", event.ProductID,
-		)
+// PublishStoreUpdate publishes a product_updated event for a stock change sent
+// by a store, recording the store, the applied delta and the optional reason
+func (eq *EventQueue) PublishStoreUpdate(productID string, data models.ProductResponse, version int, storeID string, delta int, reason string) int64 {
+	return eq.publish(StoreUpdateEvent(productID, data, version, storeID, delta, reason))
+}
+
+// StoreUpdateEvent builds the event PublishStoreUpdate publishes
+func StoreUpdateEvent(productID string, data models.ProductResponse, version int, storeID string, delta int, reason string) models.Event {
+	return models.Event{
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Data:      data,
+		Version:   version,
+		StoreID:   storeID,
+		Delta:     delta,
+		Reason:    reason,
+	}
+}
+
+// PublishTransfer publishes a stock_transferred event moving quantity units
+// from one store's allocation to another's
+func (eq *EventQueue) PublishTransfer(productID string, data models.ProductResponse, version int, fromStoreID, toStoreID string, quantity int) int64 {
+	return eq.publish(TransferEvent(productID, data, version, fromStoreID, toStoreID, quantity))
+}
+
+// TransferEvent builds the event PublishTransfer publishes
+func TransferEvent(productID string, data models.ProductResponse, version int, fromStoreID, toStoreID string, quantity int) models.Event {
+	return models.Event{
+		EventType: models.EventTypeStockTransferred,
+		ProductID: productID,
+		Data:      data,
+		Version:   version,
+		StoreID:   fromStoreID,
+		ToStoreID: toStoreID,
+		Quantity:  quantity,
+	}
+}
+
+// PublishOrder publishes one line of an order as an order event of eventType,
+// recording the store and the order's units of the product
+func (eq *EventQueue) PublishOrder(eventType, productID string, data models.ProductResponse, version int, orderID, storeID string, delta, quantity int) int64 {
+	return eq.publish(OrderEvent(eventType, productID, data, version, orderID, storeID, delta, quantity))
+}
+
+// OrderEvent builds the event PublishOrder publishes
+func OrderEvent(eventType, productID string, data models.ProductResponse, version int, orderID, storeID string, delta, quantity int) models.Event {
+	return models.Event{
+		EventType: eventType,
+		ProductID: productID,
+		Data:      data,
+		Version:   version,
+		StoreID:   storeID,
+		Delta:     delta,
+		Quantity:  quantity,
+		OrderID:   orderID,
+	}
+}
+
+// CategoryEvent builds an eventType event carrying a category of the tree
+func CategoryEvent(eventType string, category models.Category) models.Event {
+	return models.Event{
+		EventType: eventType,
+		Category:  &category,
+	}
+}
+
+// BackorderFulfilledEvent builds the event reporting quantity restocked units
+// filling backorder, which holds the units still waiting after them
+func BackorderFulfilledEvent(productID string, data models.ProductResponse, version int, backorder models.Backorder, quantity int) models.Event {
+	return models.Event{
+		EventType: models.EventTypeBackorderFulfilled,
+		ProductID: productID,
+		Data:      data,
+		Version:   version,
+		StoreID:   backorder.StoreID,
+		Quantity:  quantity,
+		Backorder: &backorder,
+	}
+}
+
+// publish appends the event to the queue before returning, assigning its
+// offset and storing it in one step, so events are stored in offset order and
+// in the order they were published. Only the file write happens later.
+func (eq *EventQueue) publish(event models.Event) int64 {
+	event.Timestamp = clock.Format(eq.clock.Now())
+
+	eq.mu.Lock()
+	event.Offset = eq.nextOffset
+	eq.nextOffset++
+	eq.appendLocked(event)
+	eq.mu.Unlock()
+
+	eq.published(event)
+	return event.Offset
+}
+
+// AppendFromOutbox appends the event recorded under seq in the inventory
+// outbox, keeping its timestamp. Outbox events must be appended in seq order;
+// an event at or below the newest seq appended is already in the queue and
+// is skipped, so redelivering the outbox after a crash adds no duplicates.
+func (eq *EventQueue) AppendFromOutbox(seq int64, event models.Event) (int64, bool) {
+	if event.Timestamp == "" {
+		event.Timestamp = clock.Format(eq.clock.Now())
 	}
+
+	eq.mu.Lock()
+	if seq <= eq.outboxSeq {
+		eq.mu.Unlock()
+		return 0, false
+	}
+	event.Offset = eq.nextOffset
+	eq.nextOffset++
+	eq.outboxSeq = seq
+	eq.appendLocked(event)
+	eq.mu.Unlock()
+
+	eq.published(event)
+	return event.Offset, true
+}
+
+// OutboxSeq returns the seq of the newest event appended from the outbox
+func (eq *EventQueue) OutboxSeq() int64 {
+	eq.mu.RLock()
+	defer eq.mu.RUnlock()
+	return eq.outboxSeq
+}
+
+// SavedOutboxSeq returns the seq of the newest outbox event in the events
+// file; outbox entries up to it are durable and may be dropped
+func (eq *EventQueue) SavedOutboxSeq() int64 {
+	return eq.savedOutboxSeq.Load()
+}
+
+// published wakes the event's waiters and schedules the file save
+func (eq *EventQueue) published(event models.Event) {
+
+	eq.notifyWaiters(event.Offset)
+	eq.requestSave()
+
+	eq.logger.Debug("Event appended",
+		"offset", event.Offset,
+		"event_type", event.EventType,
+		"product_id", event.ProductID,
+	)
 }
 
 // GetEvents retrieves events starting from the given offset
@@ -210,8 +474,9 @@ func (eq *EventQueue) WaitForEvents(fromOffset int64, timeout time.Duration) <-c
 	eq.waiters[fromOffset] = append(eq.waiters[fromOffset], notifyChan)
 
 	// Set timeout
+	timedOut := eq.clock.After(timeout)
 	go func() {
-		time.Sleep(timeout)
+		<-timedOut
 		select {
 		case <-notifyChan:
 			// Already notified
@@ -223,6 +488,156 @@ func (eq *EventQueue) WaitForEvents(fromOffset int64, timeout time.Duration) <-c
 	return notifyChan
 }
 
+// CommitOffset registers storeID as an event consumer that has applied every
+// event before offset. Committed offsets only move forward; the consumer's
+// current state is returned.
+func (eq *EventQueue) CommitOffset(storeID string, offset int64) (models.EventConsumer, error) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	if offset < 0 || offset > eq.nextOffset {
+		return eq.consumers[storeID], ErrCommitOffsetOutOfRange
+	}
+
+	consumer := eq.consumers[storeID]
+	consumer.StoreID = storeID
+	if offset > consumer.Offset {
+		consumer.Offset = offset
+	}
+	consumer.LastSeen = clock.Format(eq.clock.Now())
+	completeReplay(&consumer)
+	eq.consumers[storeID] = consumer
+
+	return consumer, nil
+}
+
+// consumedCountLocked returns how many of the oldest stored events every
+// registered consumer has committed past (all of them when there are no
+// consumers); the caller must hold eq.mu
+func (eq *EventQueue) consumedCountLocked() int {
+	if len(eq.consumers) == 0 {
+		return len(eq.events)
+	}
+
+	minOffset := int64(-1)
+	for _, consumer := range eq.consumers {
+		if minOffset < 0 || consumer.Offset < minOffset {
+			minOffset = consumer.Offset
+		}
+	}
+
+	return sort.Search(len(eq.events), func(i int) bool {
+		return eq.events[i].Offset >= minOffset
+	})
+}
+
+// Stats returns the queue size, offsets, file size, rotated event counts and
+// registered consumers
+func (eq *EventQueue) Stats() models.EventQueueStats {
+	eq.mu.RLock()
+	defer eq.mu.RUnlock()
+	return eq.statsLocked()
+}
+
+// statsLocked builds the queue stats; the caller must hold eq.mu
+func (eq *EventQueue) statsLocked() models.EventQueueStats {
+	stats := models.EventQueueStats{
+		EventCount: len(eq.events),
+		NextOffset: eq.nextOffset,
+		Consumers:  eq.consumersLocked(),
+	}
+
+	if len(eq.events) > 0 {
+		oldest := eq.events[0].Offset
+		newest := eq.events[len(eq.events)-1].Offset
+		stats.OldestOffset = &oldest
+		stats.NewestOffset = &newest
+		stats.OldestTimestamp = eq.events[0].Timestamp
+	}
+
+	if len(eq.rotated) > 0 {
+		stats.RotatedEvents = make(map[string]int64, len(eq.rotated))
+		for reason, count := range eq.rotated {
+			stats.RotatedEvents[reason] = count
+		}
+	}
+
+	if info, err := os.Stat(eq.filePath); err == nil {
+		stats.FileSizeBytes = info.Size()
+	}
+
+	return stats
+}
+
+// consumersLocked returns the registered consumers sorted by store ID; the
+// caller must hold eq.mu
+func (eq *EventQueue) consumersLocked() []models.EventConsumer {
+	consumers := make([]models.EventConsumer, 0, len(eq.consumers))
+	for _, consumer := range eq.consumers {
+		consumers = append(consumers, consumer)
+	}
+	sort.Slice(consumers, func(i, j int) bool {
+		return consumers[i].StoreID < consumers[j].StoreID
+	})
+	return consumers
+}
+
+// Compact removes every event with an offset below beforeOffset, provided all
+// registered consumers have already read past it. beforeOffset may be at most
+// one past the newest stored event. Returns the number of removed events.
+func (eq *EventQueue) Compact(beforeOffset int64) (int, error) {
+	eq.mu.Lock()
+
+	limit := eq.nextOffset
+	if len(eq.events) > 0 {
+		limit = eq.events[len(eq.events)-1].Offset + 1
+	}
+	if beforeOffset < 0 || beforeOffset > limit {
+		eq.mu.Unlock()
+		return 0, ErrCompactOffsetOutOfRange
+	}
+
+	var behind []models.EventConsumer
+	for _, consumer := range eq.consumersLocked() {
+		if consumer.Offset < beforeOffset {
+			behind = append(behind, consumer)
+		}
+	}
+	if len(behind) > 0 {
+		eq.mu.Unlock()
+		return 0, &ConsumersBehindError{BeforeOffset: beforeOffset, Consumers: behind}
+	}
+
+	removed := 0
+	for removed < len(eq.events) && eq.events[removed].Offset < beforeOffset {
+		removed++
+	}
+	eq.notifyRotatedLocked(eq.events[:removed])
+	eq.events = append([]models.Event(nil), eq.events[removed:]...)
+	if beforeOffset > eq.oldestOffset {
+		eq.oldestOffset = beforeOffset
+	}
+	eq.mu.Unlock()
+
+	eq.logger.Info("Event queue compacted",
+		"before_offset", beforeOffset,
+		"removed_events", removed,
+	)
+
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, eq.saveToFile()
+}
+
+// OldestAvailableOffset returns the lowest offset GetEvents can still serve.
+// Requests below it have lost events to rotation or compaction.
+func (eq *EventQueue) OldestAvailableOffset() int64 {
+	eq.mu.RLock()
+	defer eq.mu.RUnlock()
+	return eq.oldestOffset
+}
+
 // GetCurrentOffset returns the current event offset (next offset to be assigned)
 func (eq *EventQueue) GetCurrentOffset() int64 {
 	eq.mu.RLock()
@@ -235,67 +650,163 @@ func (eq *EventQueue) Close() error {
 	eq.logger.Info("Shutting down event queue")
 
 	close(eq.stopChan)
+	<-eq.writerDone
 
-	// Wait a bit for async writer to finish
-	time.Sleep(100 * time.Millisecond)
+	if eq.standby.Load() {
+		// The leader owns the events file
+		return nil
+	}
 
 	// Final save
 	return eq.saveToFile()
 }
 
-// getNextOffset returns the next available offset (thread-safe)
-func (eq *EventQueue) getNextOffset() int64 {
-	eq.mu.Lock()
-	defer eq.mu.Unlock()
-
-	offset := eq.nextOffset
-	eq.nextOffset++
-	return offset
-}
+// fileWriter saves the events file whenever events were appended since the
+// last save; a burst of appends is written once
+func (eq *EventQueue) fileWriter() {
+	defer close(eq.writerDone)
+
+	// Events age out even while nothing is published
+	var ageCheck <-chan time.Time
+	if eq.maxAge > 0 {
+		ticker := time.NewTicker(ageCheckInterval(eq.maxAge))
+		defer ticker.Stop()
+		ageCheck = ticker.C
+	}
 
-// asyncWriter handles writing events to memory and file asynchronously
-func (eq *EventQueue) asyncWriter() {
 	for {
 		select {
-		case event := <-eq.writeChan:
-			eq.addEventToMemory(event)
-			eq.notifyWaiters(event.Offset)
+		case <-eq.saveChan:
+			if eq.standby.Load() {
+				continue
+			}
+			if err := eq.saveToFile(); err != nil {
+				eq.logger.Error("Failed to save events to file", "error", err)
+			}
+
+		case <-ageCheck:
+			if eq.standby.Load() {
+				// The leader rotates the file; a reload brings its result
+				continue
+			}
+			eq.mu.Lock()
+			removed := eq.rotateLocked(eq.clock.Now())
+			eq.mu.Unlock()
+			if removed > 0 {
+				if err := eq.saveToFile(); err != nil {
+					eq.logger.Error("Failed to save events to file", "error", err)
+				}
+			}
 
 		case <-eq.stopChan:
-			eq.logger.Info("Event queue async writer stopping")
+			eq.logger.Info("Event queue file writer stopping")
 			return
 		}
 	}
 }
 
-// addEventToMemory adds an event to the in-memory queue and manages rotation
-func (eq *EventQueue) addEventToMemory(event models.Event) {
-	eq.mu.Lock()
-	defer eq.mu.Unlock()
+// requestSave asks the file writer to save; a save already pending covers it
+func (eq *EventQueue) requestSave() {
+	select {
+	case eq.saveChan <- struct{}{}:
+	default:
+	}
+}
+
+// ageCheckInterval is how often events are checked against maxAge: a tenth
+// of it, between a second and a minute
+func ageCheckInterval(maxAge time.Duration) time.Duration {
+	interval := maxAge / 10
+	if interval < time.Second {
+		return time.Second
+	}
+	if interval > time.Minute {
+		return time.Minute
+	}
+	return interval
+}
 
-	// Add event to memory
+// appendLocked adds an event to the in-memory queue and manages rotation;
+// the caller must hold eq.mu
+func (eq *EventQueue) appendLocked(event models.Event) {
 	eq.events = append(eq.events, event)
+	eq.rotateLocked(eq.clock.Now())
+}
 
-	// Rotate if necessary
+// rotateLocked drops the oldest events once the queue is over its event
+// count or the events file over its size, rotating down to 75% of the limit,
+// and drops every event older than the maximum age. Only events every registered
+// consumer has committed past are dropped, unless the queue has grown beyond
+// the retention cap. Returns the number of dropped events; the caller must
+// hold eq.mu.
+func (eq *EventQueue) rotateLocked(now time.Time) int {
+	removeCount, reason := 0, ""
 	if len(eq.events) > eq.maxEvents {
-		// Remove oldest events, keep recent ones
-		keepCount := eq.maxEvents * 3 / 4 // Keep 75% of max events
-		eq.events = eq.events[len(eq.events)-keepCount:]
+		removeCount, reason = len(eq.events)-eq.maxEvents*3/4, RotationReasonCount
+	}
+	if eq.maxAge > 0 {
+		if expired := eq.expiredCountLocked(now.Add(-eq.maxAge)); expired > removeCount {
+			removeCount, reason = expired, RotationReasonAge
+		}
+	}
+	if fileSize := eq.fileSize.Load(); eq.maxFileBytes > 0 && fileSize > eq.maxFileBytes && len(eq.events) > 0 {
+		keep := int(int64(len(eq.events)) * (eq.maxFileBytes * 3 / 4) / fileSize)
+		if oversize := len(eq.events) - keep; oversize > removeCount {
+			removeCount, reason = oversize, RotationReasonSize
+		}
+	}
+	if removeCount == 0 {
+		return 0
+	}
 
-		eq.logger.Info("Event queue rotated",
-			"removed_events", len(eq.events)+keepCount-len(eq.events),
-			"remaining_events", len(eq.events),
-		)
+	// Only discard events every registered consumer has committed past,
+	// unless the queue has grown beyond the retention cap
+	if consumed := eq.consumedCountLocked(); consumed < removeCount {
+		if len(eq.events) > eq.maxRetained {
+			forced := len(eq.events) - eq.maxRetained*3/4
+			if forced > consumed {
+				consumed, reason = forced, RotationReasonForced
+			}
+			eq.logger.Warn("Event queue over retention cap, discarding unconsumed events",
+				"max_retained_events", eq.maxRetained,
+				"removed_events", consumed,
+				"oldest_remaining_offset", eq.events[consumed].Offset,
+			)
+		}
+		removeCount = consumed
+	}
+	if removeCount == 0 {
+		return 0
 	}
 
-	// Save to file immediately but asynchronously to prevent blocking
-	go func() {
-		if err := eq.saveToFile(); err != nil {
-			eq.logger.Error("Failed to save events to file immediately", "error", err)
-		} else {
-			eq.logger.Debug("Event saved to file immediately", "offset", event.Offset)
+	eq.notifyRotatedLocked(eq.events[:removeCount])
+	eq.oldestOffset = eq.events[removeCount-1].Offset + 1
+	// Estimate the smaller file until the next save measures it
+	eq.fileSize.Store(eq.fileSize.Load() * int64(len(eq.events)-removeCount) / int64(len(eq.events)))
+	eq.events = eq.events[removeCount:]
+	eq.rotated[reason] += int64(removeCount)
+
+	eq.logger.Info("Event queue rotated",
+		"reason", reason,
+		"removed_events", removeCount,
+		"remaining_events", len(eq.events),
+	)
+	return removeCount
+}
+
+// expiredCountLocked returns how many of the oldest events were published
+// before cutoff. Events are stored in publish order, so the scan stops at the
+// first newer one; the caller must hold eq.mu.
+func (eq *EventQueue) expiredCountLocked(cutoff time.Time) int {
+	expired := 0
+	for _, event := range eq.events {
+		timestamp, err := time.Parse(time.RFC3339, event.Timestamp)
+		if err != nil || !timestamp.Before(cutoff) {
+			break
 		}
-	}()
+		expired++
+	}
+	return expired
 }
 
 // notifyWaiters notifies all waiters waiting for events at or after the given offset
@@ -328,32 +839,49 @@ func (eq *EventQueue) loadFromFile() error {
 		return fmt.Errorf("failed to read events file: %w", err)
 	}
 
-	var fileData struct {
-		Events     []models.Event `json:"events"`
-		NextOffset int64          `json:"nextOffset"`
-	}
+	var fileData eventsFile
 
 	if err := json.Unmarshal(data, &fileData); err != nil {
 		return fmt.Errorf("failed to unmarshal events: %w", err)
 	}
 
+	eq.applyFileLocked(fileData, int64(len(data)))
+	return nil
+}
+
+// applyFileLocked replaces the queue's contents with the events file's; the
+// caller holds eq.mu or has not shared the queue yet. Timestamps older
+// versions wrote in local time are normalized to UTC.
+func (eq *EventQueue) applyFileLocked(fileData eventsFile, size int64) {
+	for i := range fileData.Events {
+		fileData.Events[i].Timestamp = clock.Normalize(fileData.Events[i].Timestamp)
+	}
 	eq.events = fileData.Events
 	eq.nextOffset = fileData.NextOffset
-
-	return nil
+	eq.oldestOffset = fileData.NextOffset
+	if len(eq.events) > 0 {
+		eq.oldestOffset = eq.events[0].Offset
+	}
+	if fileData.Consumers != nil {
+		eq.consumers = fileData.Consumers
+	}
+	eq.outboxSeq = fileData.OutboxSeq
+	eq.savedOutboxSeq.Store(fileData.OutboxSeq)
+	eq.fileSize.Store(size)
 }
 
 // saveToFile saves events to the persistent file
 func (eq *EventQueue) saveToFile() error {
+	eq.saveMutex.Lock()
+	defer eq.saveMutex.Unlock()
 	eq.mu.RLock()
 	defer eq.mu.RUnlock()
 
-	fileData := struct {
-		Events     []models.Event `json:"events"`
-		NextOffset int64          `json:"nextOffset"`
-	}{
+	fileData := eventsFile{
 		Events:     eq.events,
 		NextOffset: eq.nextOffset,
+		Consumers:  eq.consumers,
+		OutboxSeq:  eq.outboxSeq,
 	}
 
 	data, err := json.MarshalIndent(fileData, "", "  ")
@@ -370,6 +898,8 @@ func (eq *EventQueue) saveToFile() error {
 	if err := os.Rename(tempFile, eq.filePath); err != nil {
 		return fmt.Errorf("failed to rename temp events file: %w", err)
 	}
+	eq.savedOutboxSeq.Store(fileData.OutboxSeq)
+	eq.fileSize.Store(int64(len(data)))
 
 	return nil
 }
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"errors"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// ErrUnknownConsumer is returned when replaying a store that never committed
+// an offset
+var ErrUnknownConsumer = errors.New("store has never consumed events")
+
+// RequestReplay rewinds storeID's committed offset to the oldest stored event
+// and marks a replay pending, so the store's next events poll is told to
+// rebuild from a snapshot whatever offset it asks for. Other stores are not
+// affected. Compaction waits for the store until it has caught up again.
+func (eq *EventQueue) RequestReplay(storeID, requestedBy string) (models.EventConsumer, error) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	consumer, ok := eq.consumers[storeID]
+	if !ok {
+		return models.EventConsumer{}, ErrUnknownConsumer
+	}
+	consumer.Offset = eq.oldestOffset
+	consumer.Replay = &models.StoreReplay{
+		Status:      models.StoreReplayPending,
+		RequestedBy: requestedBy,
+		RequestedAt: clock.Timestamp(),
+	}
+	eq.consumers[storeID] = consumer
+
+	eq.logger.Info("Store replay requested",
+		"store_id", storeID,
+		"requested_by", requestedBy,
+		"rewound_to_offset", consumer.Offset)
+	return consumer, nil
+}
+
+// StartReplay hands a pending replay to storeID: it marks the replay
+// resyncing and returns the offset the store resumes polling from after its
+// snapshot. Reports false when no replay is pending for the store.
+func (eq *EventQueue) StartReplay(storeID string) (int64, bool) {
+	eq.mu.Lock()
+	defer eq.mu.Unlock()
+
+	consumer, ok := eq.consumers[storeID]
+	if !ok || consumer.Replay == nil || consumer.Replay.Status != models.StoreReplayPending {
+		return 0, false
+	}
+	replay := *consumer.Replay
+	replay.Status = models.StoreReplayResyncing
+	replay.StartedAt = clock.Timestamp()
+	replay.SnapshotOffset = eq.nextOffset
+	consumer.Replay = &replay
+	eq.consumers[storeID] = consumer
+
+	eq.logger.Info("Store replay started",
+		"store_id", storeID,
+		"snapshot_offset", replay.SnapshotOffset)
+	return replay.SnapshotOffset, true
+}
+
+// completeReplay marks a resyncing replay completed once the consumer has
+// committed the snapshot offset. The replay is copied, never changed in
+// place, since consumers handed out earlier share it.
+func completeReplay(consumer *models.EventConsumer) {
+	if consumer.Replay == nil || consumer.Replay.Status != models.StoreReplayResyncing ||
+		consumer.Offset < consumer.Replay.SnapshotOffset {
+		return
+	}
+	replay := *consumer.Replay
+	replay.Status = models.StoreReplayCompleted
+	replay.CompletedAt = clock.Timestamp()
+	consumer.Replay = &replay
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"inventory-management-api/internal/models"
+)
+
+// SetStandby switches the queue between following another instance and
+// owning the events file. In standby the file is never saved or rotated and
+// Reload keeps the queue current; leaving standby reloads the file one last
+// time so this instance continues from what the leader wrote.
+func (eq *EventQueue) SetStandby(standby bool) error {
+	if standby {
+		eq.standby.Store(true)
+		eq.logger.Info("Event queue in standby, serving events from the leader's events file",
+			"file_path", eq.filePath)
+		return nil
+	}
+	if !eq.standby.Load() {
+		return nil
+	}
+	if _, err := eq.Reload(); err != nil {
+		return err
+	}
+	eq.standby.Store(false)
+	eq.logger.Info("Event queue promoted, now writing the events file",
+		"next_offset", eq.GetCurrentOffset())
+	return nil
+}
+
+// Reload replaces the queue's events, consumers and offsets with the events
+// file's when it changed since the last reload, and reports whether it did.
+// Long polls waiting for the new events are woken.
+func (eq *EventQueue) Reload() (bool, error) {
+	eq.saveMutex.Lock()
+	defer eq.saveMutex.Unlock()
+
+	info, err := os.Stat(eq.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil // The leader has not published yet
+		}
+		return false, fmt.Errorf("failed to stat events file: %w", err)
+	}
+	if info.ModTime().Equal(eq.fileModTime) && info.Size() == eq.fileBytes {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(eq.filePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read events file: %w", err)
+	}
+	var fileData eventsFile
+	if err := json.Unmarshal(data, &fileData); err != nil {
+		return false, fmt.Errorf("failed to unmarshal events: %w", err)
+	}
+	if fileData.Consumers == nil {
+		fileData.Consumers = make(map[string]models.EventConsumer)
+	}
+
+	eq.mu.Lock()
+	previousOffset := eq.nextOffset
+	eq.applyFileLocked(fileData, int64(len(data)))
+	nextOffset := eq.nextOffset
+	eq.mu.Unlock()
+
+	eq.fileModTime = info.ModTime()
+	eq.fileBytes = info.Size()
+	if nextOffset > previousOffset {
+		eq.notifyWaiters(nextOffset - 1)
+	}
+	eq.logger.Debug("Reloaded events from the leader's events file",
+		"events", len(fileData.Events),
+		"next_offset", nextOffset)
+	return true, nil
+}
@@ -0,0 +1,225 @@
+// Package featureflags gates experimental behaviors per environment and per
+// tenant (store ID). Values come from configuration and can be changed at
+// runtime through the admin API; runtime changes are not persisted.
+package featureflags
+
+import (
+	"errors"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Known flags
+const (
+	// PositiveDeltas lets stores send positive deltas (restocks) on
+	// /v1/inventory/updates; without it only decrements are accepted
+	PositiveDeltas = "positive_deltas"
+	// ReturnRestocks accepts positive deltas labelled with reason "return",
+	// so stores can put returned units back on sale without positive_deltas
+	ReturnRestocks = "return_restocks"
+)
+
+// Definition describes a known flag
+type Definition struct {
+	Name        string
+	Description string
+	Default     bool
+}
+
+var definitions = []Definition{
+	{Name: PositiveDeltas, Description: "Accept positive deltas (restocks) on inventory updates", Default: false},
+	{Name: ReturnRestocks, Description: "Accept positive deltas with reason \"return\" on inventory updates", Default: true},
+}
+
+// ErrUnknownFlag is returned when toggling a flag that is not defined
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Definitions returns every known flag, sorted by name
+func Definitions() []Definition {
+	defs := append([]Definition(nil), definitions...)
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+func definition(name string) (Definition, bool) {
+	for _, def := range definitions {
+		if def.Name == name {
+			return def, true
+		}
+	}
+	return Definition{}, false
+}
+
+// Flags holds the environment-wide values and per-tenant overrides
+type Flags struct {
+	mutex     sync.RWMutex
+	values    map[string]bool
+	overrides map[string]map[string]bool // flag -> tenant -> value
+}
+
+// New builds flags from FEATURE_FLAGS ("flag=true,flag=false") and
+// FEATURE_FLAG_OVERRIDES ("tenant:flag=true,..."). Malformed entries and
+// unknown flags are logged and skipped.
+func New(flags, overrides string) *Flags {
+	f := &Flags{
+		values:    make(map[string]bool),
+		overrides: make(map[string]map[string]bool),
+	}
+
+	for _, part := range splitList(flags) {
+		name, enabled, ok := parseAssignment(part)
+		if !ok {
+			slog.Warn("Invalid feature flag, expected flag=true|false", "value", part)
+			continue
+		}
+		if _, known := definition(name); !known {
+			slog.Warn("Unknown feature flag in configuration", "flag", name)
+			continue
+		}
+		f.values[name] = enabled
+	}
+
+	for _, part := range splitList(overrides) {
+		tenant, assignment, ok := strings.Cut(part, ":")
+		name, enabled, valid := parseAssignment(assignment)
+		if !ok || strings.TrimSpace(tenant) == "" || !valid {
+			slog.Warn("Invalid feature flag override, expected tenant:flag=true|false", "value", part)
+			continue
+		}
+		if _, known := definition(name); !known {
+			slog.Warn("Unknown feature flag in overrides", "flag", name)
+			continue
+		}
+		f.setOverride(name, strings.TrimSpace(tenant), enabled)
+	}
+
+	slog.Info("Feature flags configured",
+		"flags", f.values,
+		"overridden_flags", len(f.overrides))
+	return f
+}
+
+func splitList(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+func parseAssignment(value string) (string, bool, bool) {
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok {
+		return "", false, false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return "", false, false
+	}
+	return strings.TrimSpace(name), enabled, true
+}
+
+// Enabled reports whether a flag is on for a tenant: a tenant override wins,
+// then the environment-wide value, then the flag's default. A nil *Flags
+// reports defaults.
+func (f *Flags) Enabled(name, tenant string) bool {
+	def, known := definition(name)
+	if !known {
+		return false
+	}
+	if f == nil {
+		return def.Default
+	}
+
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	if tenant != "" {
+		if enabled, ok := f.overrides[name][tenant]; ok {
+			return enabled
+		}
+	}
+	if enabled, ok := f.values[name]; ok {
+		return enabled
+	}
+	return def.Default
+}
+
+// Set changes a flag for the environment, or for one tenant when tenant is set
+func (f *Flags) Set(name, tenant string, enabled bool) error {
+	if _, known := definition(name); !known {
+		return ErrUnknownFlag
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if tenant == "" {
+		f.values[name] = enabled
+	} else {
+		f.setOverride(name, tenant, enabled)
+	}
+	return nil
+}
+
+// Clear drops a tenant override, or the environment-wide value (back to the
+// default) when tenant is empty
+func (f *Flags) Clear(name, tenant string) error {
+	if _, known := definition(name); !known {
+		return ErrUnknownFlag
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if tenant == "" {
+		delete(f.values, name)
+		return nil
+	}
+	delete(f.overrides[name], tenant)
+	if len(f.overrides[name]) == 0 {
+		delete(f.overrides, name)
+	}
+	return nil
+}
+
+// setOverride records a tenant override (caller must hold f.mutex or own f)
+func (f *Flags) setOverride(name, tenant string, enabled bool) {
+	if f.overrides[name] == nil {
+		f.overrides[name] = make(map[string]bool)
+	}
+	f.overrides[name][tenant] = enabled
+}
+
+// State is the current configuration of one flag
+type State struct {
+	Definition
+	Enabled   bool
+	Overrides map[string]bool
+}
+
+// States returns every known flag with its environment-wide value and
+// tenant overrides, sorted by name
+func (f *Flags) States() []State {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	states := make([]State, 0, len(definitions))
+	for _, def := range Definitions() {
+		enabled, ok := f.values[def.Name]
+		if !ok {
+			enabled = def.Default
+		}
+		overrides := make(map[string]bool, len(f.overrides[def.Name]))
+		for tenant, value := range f.overrides[def.Name] {
+			overrides[tenant] = value
+		}
+		states = append(states, State{Definition: def, Enabled: enabled, Overrides: overrides})
+	}
+	return states
+}
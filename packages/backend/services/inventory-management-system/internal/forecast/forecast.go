@@ -0,0 +1,198 @@
+// Package forecast derives sales velocity and projected stock-out dates from
+// the event history. Sales are the decreases in available stock between two
+// consecutive events of a product, so restocks are ignored and the history
+// reaches back only as far as the event queue retains events.
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"inventory-management-api/internal/models"
+)
+
+// DefaultWindows are the trailing windows, in days, used when none are given
+var DefaultWindows = []int{1, 7, 30}
+
+const (
+	maxWindows    = 5
+	maxWindowDays = 365
+
+	// minCoverage keeps a history of a few minutes from being extrapolated
+	// into an extreme daily velocity
+	minCoverage = time.Hour
+
+	dateLayout = "2006-01-02"
+)
+
+// ParseWindows parses a comma-separated list of window lengths in days
+func ParseWindows(value string) ([]int, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultWindows, nil
+	}
+
+	seen := make(map[int]bool)
+	windows := make([]int, 0, maxWindows)
+	for _, part := range strings.Split(value, ",") {
+		days, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || days < 1 || days > maxWindowDays {
+			return nil, fmt.Errorf("window %q must be a number of days between 1 and %d", part, maxWindowDays)
+		}
+		if seen[days] {
+			continue
+		}
+		seen[days] = true
+		windows = append(windows, days)
+	}
+	if len(windows) > maxWindows {
+		return nil, fmt.Errorf("at most %d windows are allowed", maxWindows)
+	}
+	sort.Ints(windows)
+	return windows, nil
+}
+
+type sale struct {
+	at    time.Time
+	units int
+}
+
+// History holds the sales found in a run of events
+type History struct {
+	from  time.Time
+	sales map[string][]sale
+}
+
+// NewHistory extracts sales from events in offset order. History starts at
+// the oldest event, or at now when there are none.
+func NewHistory(events []models.Event, now time.Time) *History {
+	h := &History{from: now, sales: make(map[string][]sale)}
+	if len(events) > 0 {
+		if from, err := time.Parse(time.RFC3339, events[0].Timestamp); err == nil {
+			h.from = from
+		}
+	}
+
+	available := make(map[string]int)
+	for _, event := range events {
+		switch event.EventType {
+		case models.EventTypeProductCreated:
+			available[event.ProductID] = event.Data.Available
+		case models.EventTypeProductUpdated:
+			previous, known := available[event.ProductID]
+			available[event.ProductID] = event.Data.Available
+			// Stocktake corrections are shrinkage, not sales
+			if !known || event.Data.Available >= previous || event.Reason == models.UpdateReasonStocktake {
+				continue
+			}
+			at, err := time.Parse(time.RFC3339, event.Timestamp)
+			if err != nil {
+				continue
+			}
+			h.sales[event.ProductID] = append(h.sales[event.ProductID], sale{at: at, units: previous - event.Data.Available})
+		case models.EventTypeOrderReserved, models.EventTypeOrderCancelled:
+			// Held or released units are not sales; the commit is
+			available[event.ProductID] = event.Data.Available
+		case models.EventTypeOrderCommitted:
+			available[event.ProductID] = event.Data.Available
+			at, err := time.Parse(time.RFC3339, event.Timestamp)
+			if err != nil {
+				continue
+			}
+			h.sales[event.ProductID] = append(h.sales[event.ProductID], sale{at: at, units: event.Quantity})
+		case models.EventTypeProductDeleted:
+			delete(available, event.ProductID)
+		case models.EventTypeSystemRestored:
+			// Stock after a restore is not comparable with stock before it
+			available = make(map[string]int)
+		}
+	}
+	return h
+}
+
+// From returns the start of the history
+func (h *History) From() time.Time {
+	return h.from
+}
+
+// Forecast computes the velocity of product over each window ending at now
+func (h *History) Forecast(product models.ProductResponse, windows []int, now time.Time) models.ProductForecast {
+	forecast := models.ProductForecast{
+		ProductID: product.ProductID,
+		Name:      product.Name,
+		Category:  product.Category,
+		Available: product.Available,
+		Windows:   make([]models.ForecastWindow, 0, len(windows)),
+	}
+
+	for _, days := range windows {
+		since := now.Add(-time.Duration(days) * 24 * time.Hour)
+		window := models.ForecastWindow{Days: days, Partial: h.from.After(since)}
+
+		for _, s := range h.sales[product.ProductID] {
+			if !s.at.Before(since) {
+				window.UnitsSold += s.units
+			}
+		}
+
+		coverage := now.Sub(since)
+		if window.Partial {
+			coverage = now.Sub(h.from)
+		}
+		coverage = max(coverage, minCoverage)
+		velocity := float64(window.UnitsSold) / (coverage.Hours() / 24)
+		window.VelocityPerDay = round(velocity, 2)
+
+		if window.UnitsSold > 0 {
+			daysOfStock := 0.0
+			if product.Available > 0 {
+				daysOfStock = float64(product.Available) / velocity
+			}
+			stockout := now.AddDate(0, 0, int(daysOfStock)).Format(dateLayout)
+			daysOfStock = round(daysOfStock, 1)
+			window.DaysOfStock = &daysOfStock
+			window.ProjectedStockoutDate = &stockout
+
+			if forecast.DaysOfStock == nil || daysOfStock < *forecast.DaysOfStock {
+				forecast.DaysOfStock = window.DaysOfStock
+				forecast.ProjectedStockoutDate = window.ProjectedStockoutDate
+			}
+		}
+		forecast.Windows = append(forecast.Windows, window)
+	}
+	return forecast
+}
+
+// Report forecasts every product, soonest stock-out first. Products that sold
+// nothing come last. With withinDays > 0 only products projected to run out
+// within that many days are kept.
+func (h *History) Report(products []models.ProductResponse, windows []int, now time.Time, withinDays int) []models.ProductForecast {
+	report := make([]models.ProductForecast, 0, len(products))
+	for _, product := range products {
+		forecast := h.Forecast(product, windows, now)
+		if withinDays > 0 && (forecast.DaysOfStock == nil || *forecast.DaysOfStock > float64(withinDays)) {
+			continue
+		}
+		report = append(report, forecast)
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i].DaysOfStock, report[j].DaysOfStock
+		switch {
+		case a != nil && b != nil && *a != *b:
+			return *a < *b
+		case (a == nil) != (b == nil):
+			return a != nil
+		}
+		return report[i].ProductID < report[j].ProductID
+	})
+	return report
+}
+
+func round(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
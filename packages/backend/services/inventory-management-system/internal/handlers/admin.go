@@ -2,100 +2,117 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 
+	"inventory-management-api/internal/jobs"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
 	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
 )
 
 // AdminHandler handles admin-only endpoints
 type AdminHandler struct {
 	inventoryService *services.InventoryService
+	maxItems         int
+	jobs             *jobs.Manager // Runs set jobs; nil refuses them
+	maxJobItems      int
+	partition        *partitions.Partition // Set on a partition, which refuses to create products it does not own
 }
 
 // NewAdminHandler creates a new admin handler
 func NewAdminHandler(inventoryService *services.InventoryService) *AdminHandler {
 	return &AdminHandler{
 		inventoryService: inventoryService,
+		maxItems:         1000,
 	}
 }
 
+// SetMaxItems sets the maximum number of products or IDs accepted per request
+func (h *AdminHandler) SetMaxItems(maxItems int) {
+	h.maxItems = maxItems
+}
+
+// SetJobs enables background set jobs of up to maxItems products and
+// registers them with manager as the products_set job type
+func (h *AdminHandler) SetJobs(manager *jobs.Manager, maxItems int) {
+	h.jobs = manager
+	h.maxJobItems = maxItems
+	manager.Register(models.JobTypeProductsSet, h.startSetProductsJob)
+}
+
+// SetPartition makes the handler refuse to create products partition does not own
+func (h *AdminHandler) SetPartition(partition partitions.Partition) {
+	h.partition = &partition
+}
+
+// writeTooManyItems writes the standard response for oversized admin requests
+func (h *AdminHandler) writeTooManyItems(w http.ResponseWriter, field string, count int) {
+	writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many items in request", []models.ErrorDetail{
+		{
+			Field: field,
+			Issue: fmt.Sprintf("Request contains %d items, maximum is %d", count, h.maxItems),
+		},
+	})
+}
+
 // SetProducts handles POST /api/v1/admin/products/set - Admin product update endpoint
 func (h *AdminHandler) SetProducts(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Admin set products request received",
+	slog.InfoContext(r.Context(), "Admin set products request received",
 		"remote_addr", r.RemoteAddr,
 		"user_agent", r.Header.Get("User-Agent"))
 
 	// Parse request body
 	var req models.AdminSetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("Failed to parse admin set request body",
+		slog.WarnContext(r.Context(), "Failed to parse admin set request body",
 			"error", err,
 			"remote_addr", r.RemoteAddr)
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Invalid JSON in request body", nil)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
 		return
 	}
 
 	// Validate request
 	if len(req.Products) == 0 {
-		slog.Warn("Admin set request with no products",
+		slog.WarnContext(r.Context(), "Admin set request with no products",
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "No products specified", nil)
 		return
 	}
 
-	// Validate individual products
-	var validationErrors []models.ErrorDetail
-	for i, product := range req.Products {
-		if product.ProductID == "" {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].productId",
-				Issue: "Product ID is required",
-			})
-		}
-
-		// Check that at least one field is being updated
-		if product.Name == nil && product.Available == nil && product.Price == nil {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].fields",
-				Issue: "At least one field (name, available, price) must be specified",
-			})
-		}
-
-		// Validate available quantity if provided
-		if product.Available != nil && *product.Available < 0 {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].available",
-				Issue: "Available quantity cannot be negative",
-			})
-		}
-
-		// Validate price if provided
-		if product.Price != nil && *product.Price < 0 {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].price",
-				Issue: "Price cannot be negative",
-			})
-		}
+	if len(req.Products) > h.maxItems {
+		slog.WarnContext(r.Context(), "Admin request exceeds item limit",
+			"count", len(req.Products),
+			"max_items", h.maxItems,
+			"remote_addr", r.RemoteAddr)
+		h.writeTooManyItems(w, "products", len(req.Products))
+		return
 	}
 
-	if len(validationErrors) > 0 {
-		slog.Warn("Admin set request validation failed",
+	// Validate request fields against the model rules
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		slog.WarnContext(r.Context(), "Admin set request validation failed",
 			"validation_errors", len(validationErrors),
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
 		return
 	}
 
-	slog.Info("Processing admin set request",
+	slog.InfoContext(r.Context(), "Processing admin set request",
 		"product_count", len(req.Products),
 		"remote_addr", r.RemoteAddr)
 
 	// Process the admin set request
 	response, err := h.inventoryService.AdminSetProducts(req.Products)
+	if errors.Is(err, services.ErrServiceRestoring) {
+		writeRestoringError(w)
+		return
+	}
 	if err != nil {
-		slog.Error("Failed to process admin set request",
+		slog.ErrorContext(r.Context(), "Failed to process admin set request",
 			"error", err,
 			"product_count", len(req.Products),
 			"remote_addr", r.RemoteAddr)
@@ -104,7 +121,7 @@ func (h *AdminHandler) SetProducts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the results
-	slog.Info("Admin set request completed",
+	slog.InfoContext(r.Context(), "Admin set request completed",
 		"total_requests", response.Summary.TotalRequests,
 		"successful_updates", response.Summary.SuccessfulUpdates,
 		"failed_updates", response.Summary.FailedUpdates,
@@ -116,76 +133,67 @@ func (h *AdminHandler) SetProducts(w http.ResponseWriter, r *http.Request) {
 
 // CreateProducts handles POST /api/v1/admin/products/create - Admin product creation endpoint
 func (h *AdminHandler) CreateProducts(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Admin create products request received",
+	slog.InfoContext(r.Context(), "Admin create products request received",
 		"remote_addr", r.RemoteAddr,
 		"user_agent", r.Header.Get("User-Agent"))
 
 	// Parse request body
 	var req models.AdminCreateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("Failed to parse admin create request body",
+		slog.WarnContext(r.Context(), "Failed to parse admin create request body",
 			"error", err,
 			"remote_addr", r.RemoteAddr)
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Invalid JSON in request body", nil)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
 		return
 	}
 
 	// Validate request
 	if len(req.Products) == 0 {
-		slog.Warn("Admin create request with no products",
+		slog.WarnContext(r.Context(), "Admin create request with no products",
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "No products specified", nil)
 		return
 	}
 
-	// Validate individual products
-	var validationErrors []models.ErrorDetail
-	for i, product := range req.Products {
-		if product.ProductID == "" {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].productId",
-				Issue: "Product ID is required",
-			})
-		}
-
-		if product.Name == "" {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].name",
-				Issue: "Product name is required",
-			})
-		}
-
-		if product.Available < 0 {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].available",
-				Issue: "Available quantity cannot be negative",
-			})
-		}
-
-		if product.Price < 0 {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "products[" + string(rune(i)) + "].price",
-				Issue: "Price cannot be negative",
-			})
-		}
+	if len(req.Products) > h.maxItems {
+		slog.WarnContext(r.Context(), "Admin request exceeds item limit",
+			"count", len(req.Products),
+			"max_items", h.maxItems,
+			"remote_addr", r.RemoteAddr)
+		h.writeTooManyItems(w, "products", len(req.Products))
+		return
 	}
 
-	if len(validationErrors) > 0 {
-		slog.Warn("Admin create request validation failed",
+	// Validate request fields against the model rules
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		slog.WarnContext(r.Context(), "Admin create request validation failed",
 			"validation_errors", len(validationErrors),
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
 		return
 	}
 
-	slog.Info("Processing admin create request",
+	productIDs := make([]string, len(req.Products))
+	for i, product := range req.Products {
+		productIDs[i] = product.ProductID
+	}
+	if misrouted := misroutedProducts(h.partition, productIDs); len(misrouted) > 0 {
+		writeWrongPartition(w, h.partition, misrouted)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Processing admin create request",
 		"product_count", len(req.Products),
 		"remote_addr", r.RemoteAddr)
 
 	// Process the admin create request
 	response, err := h.inventoryService.AdminCreateProducts(req.Products)
+	if errors.Is(err, services.ErrServiceRestoring) {
+		writeRestoringError(w)
+		return
+	}
 	if err != nil {
-		slog.Error("Failed to process admin create request",
+		slog.ErrorContext(r.Context(), "Failed to process admin create request",
 			"error", err,
 			"product_count", len(req.Products),
 			"remote_addr", r.RemoteAddr)
@@ -194,7 +202,7 @@ func (h *AdminHandler) CreateProducts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the results
-	slog.Info("Admin create request completed",
+	slog.InfoContext(r.Context(), "Admin create request completed",
 		"total_requests", response.Summary.TotalRequests,
 		"successful_creations", response.Summary.SuccessfulCreations,
 		"failed_creations", response.Summary.FailedCreations,
@@ -206,55 +214,58 @@ func (h *AdminHandler) CreateProducts(w http.ResponseWriter, r *http.Request) {
 
 // DeleteProducts handles DELETE /api/v1/admin/products/delete - Admin product deletion endpoint
 func (h *AdminHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Admin delete products request received",
+	slog.InfoContext(r.Context(), "Admin delete products request received",
 		"remote_addr", r.RemoteAddr,
 		"user_agent", r.Header.Get("User-Agent"))
 
 	// Parse request body
 	var req models.AdminDeleteRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("Failed to parse admin delete request body",
+		slog.WarnContext(r.Context(), "Failed to parse admin delete request body",
 			"error", err,
 			"remote_addr", r.RemoteAddr)
-		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "Invalid JSON in request body", nil)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
 		return
 	}
 
 	// Validate request
 	if len(req.ProductIDs) == 0 {
-		slog.Warn("Admin delete request with no product IDs",
+		slog.WarnContext(r.Context(), "Admin delete request with no product IDs",
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "No product IDs specified", nil)
 		return
 	}
 
-	// Validate individual product IDs
-	var validationErrors []models.ErrorDetail
-	for i, productID := range req.ProductIDs {
-		if productID == "" {
-			validationErrors = append(validationErrors, models.ErrorDetail{
-				Field: "productIds[" + string(rune(i)) + "]",
-				Issue: "Product ID cannot be empty",
-			})
-		}
+	if len(req.ProductIDs) > h.maxItems {
+		slog.WarnContext(r.Context(), "Admin request exceeds item limit",
+			"count", len(req.ProductIDs),
+			"max_items", h.maxItems,
+			"remote_addr", r.RemoteAddr)
+		h.writeTooManyItems(w, "productIds", len(req.ProductIDs))
+		return
 	}
 
-	if len(validationErrors) > 0 {
-		slog.Warn("Admin delete request validation failed",
+	// Validate request fields against the model rules
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		slog.WarnContext(r.Context(), "Admin delete request validation failed",
 			"validation_errors", len(validationErrors),
 			"remote_addr", r.RemoteAddr)
 		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
 		return
 	}
 
-	slog.Info("Processing admin delete request",
+	slog.InfoContext(r.Context(), "Processing admin delete request",
 		"product_count", len(req.ProductIDs),
 		"remote_addr", r.RemoteAddr)
 
 	// Process the admin delete request
 	response, err := h.inventoryService.AdminDeleteProducts(req.ProductIDs)
+	if errors.Is(err, services.ErrServiceRestoring) {
+		writeRestoringError(w)
+		return
+	}
 	if err != nil {
-		slog.Error("Failed to process admin delete request",
+		slog.ErrorContext(r.Context(), "Failed to process admin delete request",
 			"error", err,
 			"product_count", len(req.ProductIDs),
 			"remote_addr", r.RemoteAddr)
@@ -263,7 +274,7 @@ func (h *AdminHandler) DeleteProducts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Log the results
-	slog.Info("Admin delete request completed",
+	slog.InfoContext(r.Context(), "Admin delete request completed",
 		"total_requests", response.Summary.TotalRequests,
 		"successful_deletions", response.Summary.SuccessfulDeletions,
 		"failed_deletions", response.Summary.FailedDeletions,
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// GetProductAssets handles GET /v1/admin/products/{productId}/assets
+func (h *AdminHandler) GetProductAssets(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+	response, err := h.inventoryService.ProductAssets(productID)
+	if err != nil {
+		h.writeAssetError(w, r, productID, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// SetProductAssets handles PUT /v1/admin/products/{productId}/assets,
+// replacing the product's whole asset list
+func (h *AdminHandler) SetProductAssets(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+
+	var req models.ProductAssetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	response, err := h.inventoryService.SetProductAssets(productID, req.Assets)
+	if err != nil {
+		h.writeAssetError(w, r, productID, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Product assets replaced",
+		"product_id", productID,
+		"assets", len(response.Assets),
+		"new_version", response.Version,
+		"remote_addr", r.RemoteAddr)
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// PutProductAsset handles POST /v1/admin/products/{productId}/assets, adding
+// one asset or replacing the one with the same URL
+func (h *AdminHandler) PutProductAsset(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+
+	var asset models.ProductAsset
+	if err := json.NewDecoder(r.Body).Decode(&asset); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(asset); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	response, err := h.inventoryService.PutProductAsset(productID, asset)
+	if err != nil {
+		h.writeAssetError(w, r, productID, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Product asset saved",
+		"product_id", productID,
+		"asset_type", asset.Type,
+		"new_version", response.Version,
+		"remote_addr", r.RemoteAddr)
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// RemoveProductAsset handles DELETE /v1/admin/products/{productId}/assets?url=
+func (h *AdminHandler) RemoveProductAsset(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_request", "The url query parameter is required", []models.ErrorDetail{
+			{Field: "url", Issue: "url is required"},
+		})
+		return
+	}
+
+	response, err := h.inventoryService.RemoveProductAsset(productID, url)
+	if err != nil {
+		h.writeAssetError(w, r, productID, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Product asset removed",
+		"product_id", productID,
+		"new_version", response.Version,
+		"remote_addr", r.RemoteAddr)
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// writeAssetError maps an asset service error to its response
+func (h *AdminHandler) writeAssetError(w http.ResponseWriter, r *http.Request, productID string, err error) {
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		writeErrorResponse(w, http.StatusNotFound, services.ErrTypeProductNotFound, "Product not found", nil)
+	case errors.Is(err, services.ErrAssetNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "asset_not_found", "Product has no asset with that URL", nil)
+	case errors.Is(err, services.ErrTooManyAssets):
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "assets", Issue: fmt.Sprintf("A product has at most %d assets", services.MaxProductAssets)},
+		})
+	case errors.Is(err, services.ErrServiceRestoring):
+		writeRestoringError(w)
+	default:
+		slog.ErrorContext(r.Context(), "Failed to change product assets",
+			"error", err,
+			"product_id", productID)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to change product assets", nil)
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+)
+
+// errTooManyProducts stops decoding once a set job exceeds its item limit
+var errTooManyProducts = errors.New("too many products")
+
+// StartSetProductsJob handles POST /v1/admin/products/set/jobs - applies the
+// same product updates as SetProducts in a background job and answers 202
+// with the job, which GET /v1/admin/jobs/{id} reports on
+func (h *AdminHandler) StartSetProductsJob(w http.ResponseWriter, r *http.Request) {
+	if h.jobs == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "jobs_disabled", "Background jobs are not enabled", nil)
+		return
+	}
+	if h.inventoryService.IsRestoring() {
+		writeRestoringError(w)
+		return
+	}
+
+	products, err := h.decodeSetProducts(r.Body)
+	var paramsErr *jobs.ParamsError
+	switch {
+	case errors.As(err, &paramsErr):
+		writeJobError(w, r, err)
+		return
+	case err != nil:
+		slog.WarnContext(r.Context(), "Failed to parse admin set job body",
+			"error", err,
+			"remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	job, err := h.jobs.Submit(models.JobTypeProductsSet, h.setProductsWork(products))
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Admin set job queued",
+		"job_id", job.ID,
+		"product_count", len(products),
+		"remote_addr", r.RemoteAddr)
+	writeJobAccepted(w, r, job)
+}
+
+// startSetProductsJob is the products_set StartFunc for POST /v1/admin/jobs;
+// params take the body of PUT /v1/admin/products/set
+func (h *AdminHandler) startSetProductsJob(params json.RawMessage) (jobs.Work, error) {
+	if h.inventoryService.IsRestoring() {
+		return jobs.Work{}, services.ErrServiceRestoring
+	}
+	products, err := h.decodeSetProducts(bytes.NewReader(params))
+	var paramsErr *jobs.ParamsError
+	switch {
+	case errors.As(err, &paramsErr):
+		return jobs.Work{}, err
+	case err != nil:
+		return jobs.Work{}, &jobs.ParamsError{Code: "invalid_request", Message: "params must be an object with a products array"}
+	}
+	return h.setProductsWork(products), nil
+}
+
+// setProductsWork applies products a chunk at a time, saving after each chunk
+func (h *AdminHandler) setProductsWork(products []models.AdminProductUpdate) jobs.Work {
+	chunkSize := h.jobs.ChunkSize()
+	return jobs.Work{
+		Total: len(products),
+		Run: func(ctx context.Context, progress *jobs.Progress) error {
+			return h.inventoryService.AdminSetProductsInChunks(ctx, products, chunkSize, progress.Record)
+		},
+	}
+}
+
+// decodeSetProducts decodes and validates a set job's products. Invalid
+// products are reported as a *jobs.ParamsError, malformed JSON as the
+// decoder's error.
+func (h *AdminHandler) decodeSetProducts(body io.Reader) ([]models.AdminProductUpdate, error) {
+	products, err := decodeProductsStream(body, h.maxJobItems)
+	if errors.Is(err, errTooManyProducts) {
+		return nil, &jobs.ParamsError{
+			Code:    "batch_too_large",
+			Message: "Too many items in request",
+			Details: []models.ErrorDetail{
+				{
+					Field: "products",
+					Issue: fmt.Sprintf("Request contains more than %d items", h.maxJobItems),
+				},
+			},
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, &jobs.ParamsError{Code: "invalid_request", Message: "No products specified"}
+	}
+	if validationErrors := validation.Validate(models.AdminSetRequest{Products: products}); len(validationErrors) > 0 {
+		return nil, &jobs.ParamsError{Code: "validation_error", Message: "Request validation failed", Details: validationErrors}
+	}
+	return products, nil
+}
+
+// decodeProductsStream decodes {"products": [...]} one product at a time, so
+// an oversized job is refused once it passes maxItems products rather than
+// after the whole list was decoded
+func decodeProductsStream(body io.Reader, maxItems int) ([]models.AdminProductUpdate, error) {
+	decoder := json.NewDecoder(body)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	var products []models.AdminProductUpdate
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		if key != "products" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return nil, err
+		}
+		for decoder.More() {
+			if len(products) == maxItems {
+				return nil, errTooManyProducts
+			}
+			var product models.AdminProductUpdate
+			if err := decoder.Decode(&product); err != nil {
+				return nil, err
+			}
+			products = append(products, product)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return nil, err
+		}
+	}
+	if err := expectDelim(decoder, '}'); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, got %v", delim, token)
+	}
+	return nil
+}
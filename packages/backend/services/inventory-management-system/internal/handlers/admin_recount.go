@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+// RecountMetadata handles POST /v1/admin/maintenance/recount - recomputes the
+// metadata counters and repairs the ones that drifted, or with ?dryRun=true
+// only reports them
+func (h *AdminHandler) RecountMetadata(w http.ResponseWriter, r *http.Request) {
+	dryRun := false
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid dryRun", []models.ErrorDetail{
+				{Field: "dryRun", Issue: "must be true or false"},
+			})
+			return
+		}
+		dryRun = parsed
+	}
+
+	response, err := h.inventoryService.RecountMetadata(dryRun)
+	if err != nil {
+		if errors.Is(err, services.ErrServiceRestoring) {
+			writeRestoringError(w)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to recount metadata", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to recount metadata", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Metadata recounted",
+		"discrepancies", response.Discrepancies,
+		"repaired", response.Repaired,
+		"dry_run", dryRun)
+	writeJSONResponse(w, http.StatusOK, response)
+}
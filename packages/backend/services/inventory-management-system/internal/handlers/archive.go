@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/archive"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ArchiveHandler lists and re-streams the events archived on rotation
+type ArchiveHandler struct {
+	archiver *archive.Archiver
+}
+
+// NewArchiveHandler creates a new archive handler; archiver is nil when
+// archival is disabled
+func NewArchiveHandler(archiver *archive.Archiver) *ArchiveHandler {
+	return &ArchiveHandler{archiver: archiver}
+}
+
+// configured answers 404 when no archive sink is configured
+func (h *ArchiveHandler) configured(w http.ResponseWriter) bool {
+	if h.archiver == nil {
+		writeErrorResponse(w, http.StatusNotFound, "archive_not_configured", "Event archival is disabled, set ARCHIVE_SINK to enable it", nil)
+		return false
+	}
+	return true
+}
+
+// ListSegments handles GET /v1/admin/archive/segments
+func (h *ArchiveHandler) ListSegments(w http.ResponseWriter, r *http.Request) {
+	if !h.configured(w) {
+		return
+	}
+
+	segments, err := h.archiver.Segments(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list archived segments", "error", err)
+		writeErrorResponse(w, http.StatusBadGateway, "archive_unavailable", "Failed to list archived segments", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.ArchiveSegmentListResponse{
+		Segments: segments,
+		Stats:    h.archiver.Stats(),
+	})
+}
+
+// GetSegment handles GET /v1/admin/archive/segments/{id} - the events of one segment
+func (h *ArchiveHandler) GetSegment(w http.ResponseWriter, r *http.Request) {
+	if !h.configured(w) {
+		return
+	}
+	id := mux.Vars(r)["id"]
+
+	segment, events, err := h.archiver.ReadSegment(r.Context(), id)
+	if errors.Is(err, archive.ErrNotFound) {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Archived segment not found", nil)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read archived segment", "segment", id, "error", err)
+		writeErrorResponse(w, http.StatusBadGateway, "archive_unavailable", "Failed to read archived segment", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.ArchiveSegmentResponse{Segment: segment, Events: events})
+}
+
+// GetEvents handles GET /v1/admin/archive/events - re-streams archived events
+// from offset (default 0) in pages like GET /v1/inventory/events
+func (h *ArchiveHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	if !h.configured(w) {
+		return
+	}
+	query := r.URL.Query()
+
+	var offset int64
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid offset", []models.ErrorDetail{
+				{Field: "offset", Issue: "must be a non-negative integer"},
+			})
+			return
+		}
+		offset = parsed
+	}
+
+	limit := 100
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid limit", []models.ErrorDetail{
+				{Field: "limit", Issue: "must be between 1 and 1000"},
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	events, nextOffset, hasMore, err := h.archiver.ReadEvents(r.Context(), offset, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to read archived events", "offset", offset, "error", err)
+		writeErrorResponse(w, http.StatusBadGateway, "archive_unavailable", "Failed to read archived events", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.EventsResponse{
+		Events:     events,
+		NextOffset: nextOffset,
+		HasMore:    hasMore,
+		Count:      len(events),
+	})
+}
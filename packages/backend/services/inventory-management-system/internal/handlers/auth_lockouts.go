@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// AuthLockoutsHandler lets admins see and lift authentication failure lockouts
+type AuthLockoutsHandler struct {
+	lockout *middleware.AuthLockout
+}
+
+// NewAuthLockoutsHandler creates a new auth lockouts handler. lockout is nil
+// when lockout is disabled.
+func NewAuthLockoutsHandler(lockout *middleware.AuthLockout) *AuthLockoutsHandler {
+	return &AuthLockoutsHandler{lockout: lockout}
+}
+
+// ListLockouts handles GET /v1/admin/rate-limit/lockouts - IP addresses and
+// API keys with recent authentication failures, locked ones first
+func (h *AuthLockoutsHandler) ListLockouts(w http.ResponseWriter, r *http.Request) {
+	if h.lockout == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "auth_lockout_disabled", "Authentication failure lockout is disabled", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, h.lockout.Entries())
+}
+
+// Unblock handles DELETE /v1/admin/rate-limit/lockouts/{id} - lifts a lockout
+// and forgets the principal's failures and strikes
+func (h *AuthLockoutsHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	if h.lockout == nil {
+		writeErrorResponse(w, http.StatusServiceUnavailable, "auth_lockout_disabled", "Authentication failure lockout is disabled", nil)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	principal, ok := h.lockout.Unblock(id)
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, "lockout_not_found", "No tracked principal with this ID", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Auth lockout lifted",
+		"audit", true,
+		"principal", principal,
+		"actor", middleware.RequestActor(r))
+
+	w.WriteHeader(http.StatusNoContent)
+}
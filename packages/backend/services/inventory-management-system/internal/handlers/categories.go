@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// CategoriesHandler serves and manages the product category tree
+type CategoriesHandler struct {
+	inventoryService *services.InventoryService
+}
+
+// NewCategoriesHandler creates a new categories handler
+func NewCategoriesHandler(inventoryService *services.InventoryService) *CategoriesHandler {
+	return &CategoriesHandler{inventoryService: inventoryService}
+}
+
+// ListCategories handles GET /v1/categories
+func (h *CategoriesHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, models.CategoryListResponse{Categories: h.inventoryService.ListCategories()})
+}
+
+// PutCategory handles PUT /v1/admin/categories/{id} - creates the category or
+// renames and moves it
+func (h *CategoriesHandler) PutCategory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req models.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in category request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	category, err := h.inventoryService.PutCategory(id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCategoryID):
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid category ID", []models.ErrorDetail{
+				{Field: "id", Issue: err.Error()},
+			})
+		case errors.Is(err, services.ErrUnknownParentCategory):
+			writeErrorResponse(w, http.StatusBadRequest, services.ErrTypeUnknownCategory, "Parent category not found: "+req.ParentID, []models.ErrorDetail{
+				{Field: "parentId", Issue: err.Error()},
+			})
+		case errors.Is(err, services.ErrCategoryCycle):
+			writeErrorResponse(w, http.StatusConflict, "category_cycle", err.Error(), []models.ErrorDetail{
+				{Field: "parentId", Issue: err.Error()},
+			})
+		case errors.Is(err, services.ErrServiceRestoring):
+			writeRestoringError(w)
+		default:
+			slog.ErrorContext(r.Context(), "Failed to save category", "category_id", id, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to save category", nil)
+		}
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, category)
+}
+
+// DeleteCategory handles DELETE /v1/admin/categories/{id}. Only categories
+// without subcategories or products can be deleted.
+func (h *CategoriesHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := h.inventoryService.DeleteCategory(id); err != nil {
+		var inUse *services.CategoryInUseError
+		switch {
+		case errors.Is(err, services.ErrCategoryNotFound):
+			writeErrorResponse(w, http.StatusNotFound, "category_not_found", "Category not found: "+id, nil)
+		case errors.As(err, &inUse):
+			writeErrorResponse(w, http.StatusConflict, "category_in_use", "Category still has subcategories or products", []models.ErrorDetail{
+				{Field: "subcategories", Issue: fmt.Sprintf("%d subcategories", inUse.Subcategories)},
+				{Field: "products", Issue: fmt.Sprintf("%d products", inUse.Products)},
+			})
+		case errors.Is(err, services.ErrServiceRestoring):
+			writeRestoringError(w)
+		default:
+			slog.ErrorContext(r.Context(), "Failed to delete category", "category_id", id, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to delete category", nil)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+	"inventory-management-api/internal/services"
+)
+
+// Number of low stock products the dashboard lists by default and at most
+const (
+	defaultDashboardLowStock = 10
+	maxDashboardLowStock     = 100
+)
+
+// DashboardHandler serves the admin dashboard's aggregated payload
+type DashboardHandler struct {
+	inventoryService *services.InventoryService
+	eventQueue       *events.EventQueue
+}
+
+// NewDashboardHandler creates a new dashboard handler
+func NewDashboardHandler(inventoryService *services.InventoryService, eventQueue *events.EventQueue) *DashboardHandler {
+	return &DashboardHandler{
+		inventoryService: inventoryService,
+		eventQueue:       eventQueue,
+	}
+}
+
+// GetDashboard handles GET /v1/admin/dashboard - stock totals, the lowStock
+// (default 10, at most 100) in-stock products with the fewest units, the update
+// rate over the last hour, the event queue depth and how far each store lags
+func (h *DashboardHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	lowStock := defaultDashboardLowStock
+	if value := r.URL.Query().Get("lowStock"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxDashboardLowStock {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid lowStock", []models.ErrorDetail{
+				{Field: "lowStock", Issue: "must be between 1 and " + strconv.Itoa(maxDashboardLowStock)},
+			})
+			return
+		}
+		lowStock = parsed
+	}
+
+	levels := h.inventoryService.StockLevels()
+	updatesLastHour := h.inventoryService.UpdatesLastHour()
+	queueStats := h.eventQueue.Stats()
+
+	writeJSONResponse(w, http.StatusOK, models.AdminDashboardResponse{
+		TotalSKUs:        levels.TotalProducts,
+		TotalUnits:       levels.TotalUnits,
+		OutOfStockCount:  levels.OutOfStockProducts,
+		LowStock:         h.inventoryService.LowStockProducts(lowStock),
+		UpdatesLastHour:  updatesLastHour,
+		UpdatesPerMinute: float64(updatesLastHour) / 60,
+		EventQueue: models.DashboardEventQueue{
+			Depth:        queueStats.EventCount,
+			OldestOffset: queueStats.OldestOffset,
+			NextOffset:   queueStats.NextOffset,
+		},
+		StoreLag:    storeLagSummary(queueStats),
+		GeneratedAt: clock.Timestamp(),
+	})
+}
+
+// storeLagSummary measures each store's committed offset against the head of
+// the queue. The sales report aggregator also commits offsets but is no store.
+func storeLagSummary(stats models.EventQueueStats) models.StoreLagSummary {
+	summary := models.StoreLagSummary{Stores: []models.StoreLag{}}
+	for _, consumer := range stats.Consumers {
+		if consumer.StoreID == reports.ConsumerID {
+			continue
+		}
+		// A store may be ahead of a queue that was reset
+		lag := max(stats.NextOffset-consumer.Offset, 0)
+		summary.Stores = append(summary.Stores, models.StoreLag{
+			StoreID:   consumer.StoreID,
+			Offset:    consumer.Offset,
+			LagEvents: lag,
+			LastSeen:  consumer.LastSeen,
+			Replay:    consumer.Replay,
+		})
+		summary.TotalLagEvents += lag
+	}
+
+	sort.SliceStable(summary.Stores, func(i, j int) bool {
+		return summary.Stores[i].LagEvents > summary.Stores[j].LagEvents
+	})
+	summary.StoreCount = len(summary.Stores)
+	if summary.StoreCount > 0 {
+		summary.MaxLagEvents = summary.Stores[0].LagEvents
+		summary.MaxLagStoreID = summary.Stores[0].StoreID
+	}
+	return summary
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/openapi"
+)
+
+// swaggerUIPage renders the spec with Swagger UI loaded from a CDN
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Inventory Management API - Docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// DocsHandler serves the OpenAPI document and the Swagger UI
+type DocsHandler struct {
+	spec []byte
+}
+
+// NewDocsHandler creates a docs handler, encoding the document once up front
+func NewDocsHandler(doc *openapi.Document) (*DocsHandler, error) {
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{spec: spec}, nil
+}
+
+// OpenAPISpec handles GET /openapi.json
+func (h *DocsHandler) OpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(h.spec); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write OpenAPI document", "error", err)
+	}
+}
+
+// SwaggerUI handles GET /docs
+func (h *DocsHandler) SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(swaggerUIPage)); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to write Swagger UI page", "error", err)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/eventfilters"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// EventFiltersHandler manages the named event filters stores subscribe to
+type EventFiltersHandler struct {
+	store *eventfilters.Store
+}
+
+// NewEventFiltersHandler creates a new event filters handler
+func NewEventFiltersHandler(store *eventfilters.Store) *EventFiltersHandler {
+	return &EventFiltersHandler{store: store}
+}
+
+// ListFilters handles GET /v1/admin/event-filters
+func (h *EventFiltersHandler) ListFilters(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, models.EventFilterListResponse{Filters: h.store.List()})
+}
+
+// GetFilter handles GET /v1/admin/event-filters/{id}
+func (h *EventFiltersHandler) GetFilter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	filter, err := h.store.Get(id)
+	if err != nil {
+		writeErrorResponse(w, http.StatusNotFound, "filter_not_found", "Event filter not found: "+id, nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, filter)
+}
+
+// PutFilter handles PUT /v1/admin/event-filters/{id} - creates or replaces the
+// filter and its store allocation
+func (h *EventFiltersHandler) PutFilter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req models.EventFilterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in event filter request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	filter, err := h.store.Put(id, req)
+	if err != nil {
+		var allocated *eventfilters.StoreAllocatedError
+		switch {
+		case errors.Is(err, eventfilters.ErrInvalidID):
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid filter ID", []models.ErrorDetail{
+				{Field: "id", Issue: err.Error()},
+			})
+		case errors.Is(err, eventfilters.ErrEmptyFilter):
+			writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+				{Field: "productIds", Issue: err.Error()},
+			})
+		case errors.As(err, &allocated):
+			writeErrorResponse(w, http.StatusConflict, "store_already_allocated", err.Error(), []models.ErrorDetail{
+				{Field: "storeIds", Issue: "already allocated to filter " + allocated.FilterID},
+			})
+		default:
+			slog.ErrorContext(r.Context(), "Failed to save event filter", "filter_id", id, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to save event filter", nil)
+		}
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Event filter saved",
+		"filter_id", id,
+		"products", len(filter.ProductIDs),
+		"categories", len(filter.Categories),
+		"stores", len(filter.StoreIDs),
+	)
+	writeJSONResponse(w, http.StatusOK, filter)
+}
+
+// DeleteFilter handles DELETE /v1/admin/event-filters/{id}. Stores polling
+// with the deleted filter get 404 until they drop it.
+func (h *EventFiltersHandler) DeleteFilter(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	if err := h.store.Delete(id); err != nil {
+		if errors.Is(err, eventfilters.ErrNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "filter_not_found", "Event filter not found: "+id, nil)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to delete event filter", "filter_id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to delete event filter", nil)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Event filter deleted", "filter_id", id)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,20 +2,42 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"inventory-management-api/internal/eventfilters"
 	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/middleware"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+	"inventory-management-api/internal/requestid"
 	"inventory-management-api/internal/telemetry"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
 )
 
+// CommittedOffsetHeader lets a store commit the offset it has applied while
+// polling; without it the requested offset is committed
+const CommittedOffsetHeader = "X-Committed-Offset"
+
 // EventsHandler handles event streaming requests
 type EventsHandler struct {
 	eventQueue *events.EventQueue
+	filters    *eventfilters.Store // Nil disables named filters and store allocation
 	logger     *slog.Logger
+	// partitionOffsets maps a next offset to the partition offset vector; set
+	// on a partition router
+	partitionOffsets func(nextOffset int64) map[string]int64
+	// expandCategories adds the subcategories of filtered categories; nil
+	// matches the categories as given
+	expandCategories func(categories []string) []string
 }
 
 // NewEventsHandler creates a new events handler
@@ -26,6 +48,23 @@ func NewEventsHandler(eventQueue *events.EventQueue, logger *slog.Logger) *Event
 	}
 }
 
+// SetFilters enables named event filters and their store allocation
+func (h *EventsHandler) SetFilters(filters *eventfilters.Store) {
+	h.filters = filters
+}
+
+// SetPartitionOffsets adds the partition offset vector matching each page's
+// next offset to events responses
+func (h *EventsHandler) SetPartitionOffsets(offsetsAt func(nextOffset int64) map[string]int64) {
+	h.partitionOffsets = offsetsAt
+}
+
+// SetCategoryExpansion makes category filters cover subcategories, expanded
+// by expand on every poll so categories added later are included
+func (h *EventsHandler) SetCategoryExpansion(expand func(categories []string) []string) {
+	h.expandCategories = expand
+}
+
 // GetEvents handles GET /v1/inventory/events
 func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -59,40 +98,93 @@ func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	h.logger.Info("Events request received",
+	h.logger.InfoContext(ctx, "Events request received",
 		"offset", offset,
 		"limit", limit,
 		"wait", waitSeconds,
 		"remote_addr", r.RemoteAddr,
 	)
 
+	// The consumer is the store the caller is authenticated as
+	storeID, ok := requestStoreID(w, r)
+	if !ok {
+		return
+	}
+
+	matcher, ok := h.resolveFilter(w, r, storeID)
+	if !ok {
+		return
+	}
+
+	// An operator asked this store to rebuild; send it through the same full
+	// resync as a lost offset, whatever offset it polls from
+	if storeID != "" {
+		if snapshotOffset, ok := h.eventQueue.StartReplay(storeID); ok {
+			h.writeReplayRequestedResponse(w, r, storeID, offset, snapshotOffset)
+			return
+		}
+	}
+
+	// Events below the oldest available offset are gone; tell the store where
+	// to resume after a full resync instead of returning an empty page
+	if oldest := h.eventQueue.OldestAvailableOffset(); offset < oldest {
+		h.writeOffsetGoneResponse(w, r, storeID, offset, oldest)
+		return
+	}
+
+	committedOffset := offset
+	if committedStr := r.Header.Get(CommittedOffsetHeader); committedStr != "" {
+		committedOffset, err = strconv.ParseInt(committedStr, 10, 64)
+		if err != nil {
+			h.writeErrorResponse(w, "invalid "+CommittedOffsetHeader+" header", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Requesting an offset means every earlier event was consumed
+	if storeID != "" {
+		if _, err := h.eventQueue.CommitOffset(storeID, committedOffset); err != nil {
+			h.logger.DebugContext(ctx, "Ignoring committed offset from events poll",
+				"store_id", storeID,
+				"committed_offset", committedOffset,
+				"error", err,
+			)
+		}
+	}
+
 	// Try to get events immediately
-	events, nextOffset, hasMore := h.eventQueue.GetEvents(offset, limit)
+	events, nextOffset, hasMore := h.readEvents(offset, limit, matcher)
 
 	// If no events and wait > 0, use long polling
 	if len(events) == 0 && waitSeconds > 0 {
-		h.logger.Debug("No events available, starting long polling",
+		h.logger.DebugContext(ctx, "No events available, starting long polling",
 			"offset", offset,
 			"wait_seconds", waitSeconds,
 		)
 
-		// Wait for new events or timeout
-		waitChan := h.eventQueue.WaitForEvents(offset, time.Duration(waitSeconds)*time.Second)
+		// Wait for new events or timeout; with a filter, events that do not
+		// match are skipped and the wait continues after them
+		waitClock := h.eventQueue.Clock()
+		deadline := waitClock.Now().Add(time.Duration(waitSeconds) * time.Second)
+		for len(events) == 0 && waitClock.Now().Before(deadline) {
+			waitFrom := max(offset, nextOffset)
+			waitChan := h.eventQueue.WaitForEvents(waitFrom, deadline.Sub(waitClock.Now()))
 
-		select {
-		case <-waitChan:
-			// New events might be available, try again
-			events, nextOffset, hasMore = h.eventQueue.GetEvents(offset, limit)
-			h.logger.Debug("Long polling completed with events",
-				"offset", offset,
-				"events_count", len(events),
-			)
-		case <-r.Context().Done():
-			// Client disconnected
-			h.logger.Debug("Client disconnected during long polling",
-				"offset", offset,
-			)
-			return
+			select {
+			case <-waitChan:
+				// New events might be available, try again
+				events, nextOffset, hasMore = h.readEvents(waitFrom, limit, matcher)
+				h.logger.DebugContext(ctx, "Long polling completed with events",
+					"offset", waitFrom,
+					"events_count", len(events),
+				)
+			case <-r.Context().Done():
+				// Client disconnected
+				h.logger.DebugContext(ctx, "Client disconnected during long polling",
+					"offset", offset,
+				)
+				return
+			}
 		}
 	}
 
@@ -105,17 +197,321 @@ func (h *EventsHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
 		NextOffset: nextOffset,
 		HasMore:    hasMore,
 		Count:      len(events),
+		Filtered:   matcher != nil,
+		// Read after the page so it is never behind nextOffset
+		CurrentOffset: h.eventQueue.GetCurrentOffset(),
+	}
+	if h.partitionOffsets != nil {
+		response.PartitionOffsets = h.partitionOffsets(nextOffset)
 	}
 
-	h.logger.Info("Events response sent",
+	h.logger.InfoContext(ctx, "Events response sent",
 		"offset", offset,
 		"events_count", len(events),
 		"next_offset", nextOffset,
 		"has_more", hasMore,
 	)
 
+	writeEventsResponse(w, r, response)
+}
+
+// readEvents returns up to limit events from offset that match the filter.
+// Pages without a match are skipped, so a narrow filter does not answer with
+// empty pages while matching events are still queued further on.
+func (h *EventsHandler) readEvents(offset int64, limit int, matcher *eventfilters.Matcher) ([]models.Event, int64, bool) {
+	for {
+		page, nextOffset, hasMore := h.eventQueue.GetEvents(offset, limit)
+		matched := matcher.Filter(page)
+		if len(matched) > 0 || !hasMore {
+			return matched, nextOffset, hasMore
+		}
+		offset = nextOffset
+	}
+}
+
+// resolveFilter builds the matcher for a poll from the productIds, category
+// and filter query parameters, or from the filter allocated to the polling
+// store when none is given. It answers the request itself on error.
+func (h *EventsHandler) resolveFilter(w http.ResponseWriter, r *http.Request, storeID string) (*eventfilters.Matcher, bool) {
+	query := r.URL.Query()
+	matcher := eventfilters.NewMatcher(splitList(query.Get("productIds")), h.withSubcategories(splitList(query.Get("category"))))
+
+	filterID := strings.TrimSpace(query.Get("filter"))
+	if filterID != "" {
+		if h.filters == nil {
+			writeErrorResponse(w, http.StatusNotFound, "filter_not_found", "Event filter not found: "+filterID, nil)
+			return nil, false
+		}
+		filter, err := h.filters.Get(filterID)
+		if err != nil {
+			writeErrorResponse(w, http.StatusNotFound, "filter_not_found", "Event filter not found: "+filterID, nil)
+			return nil, false
+		}
+		return matcher.Merge(eventfilters.NewMatcher(filter.ProductIDs, h.withSubcategories(filter.Categories))), true
+	}
+
+	if matcher == nil && h.filters != nil {
+		if storeID != "" {
+			if filter, ok := h.filters.ForStore(storeID); ok {
+				return eventfilters.NewMatcher(filter.ProductIDs, h.withSubcategories(filter.Categories)), true
+			}
+		}
+	}
+	return matcher, true
+}
+
+// withSubcategories expands categories when category expansion is set
+func (h *EventsHandler) withSubcategories(categories []string) []string {
+	if h.expandCategories == nil || len(categories) == 0 {
+		return categories
+	}
+	return h.expandCategories(categories)
+}
+
+// splitList splits a comma-separated query value
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// writeEventsResponse encodes an events page as protobuf when the client's
+// Accept header prefers it, and as JSON otherwise
+func writeEventsResponse(w http.ResponseWriter, r *http.Request, response models.EventsResponse) {
+	// The encoding depends on Accept, so caches must key on it
+	w.Header().Add("Vary", "Accept")
+	if prefersProtobuf(r.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", events.ProtobufContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write(events.MarshalProtobuf(response))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// prefersProtobuf reports whether an Accept header ranks protobuf above JSON.
+// Ties go to JSON, which stays the default for clients that send no preference.
+func prefersProtobuf(accept string) bool {
+	return prefersMediaType(accept, events.ProtobufContentType)
+}
+
+// prefersMediaType reports whether an Accept header ranks mediaType above JSON,
+// with ties going to JSON
+func prefersMediaType(accept, mediaType string) bool {
+	preferredQ, jsonQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		accepted := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch accepted {
+		case mediaType:
+			preferredQ = math.Max(preferredQ, q)
+		case "application/json", "application/*", "*/*":
+			jsonQ = math.Max(jsonQ, q)
+		}
+	}
+	return preferredQ > 0 && preferredQ > jsonQ
+}
+
+// CommitEventOffset handles POST /v1/inventory/events/commit - records that the
+// calling store has applied every event before the given offset. The store is
+// the one the caller is authenticated as (see middleware.RequestStoreID).
+func (h *EventsHandler) CommitEventOffset(w http.ResponseWriter, r *http.Request) {
+	storeID, ok := requestStoreID(w, r)
+	if !ok {
+		return
+	}
+	if storeID == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "missing_store_id", middleware.StoreIDHeader+" header is required", nil)
+		return
+	}
+
+	var req models.EventCommitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WarnContext(r.Context(), "Invalid JSON in event commit request", "error", err, "store_id", storeID)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	consumer, err := h.eventQueue.CommitOffset(storeID, req.Offset)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid_offset", "offset is beyond the next event offset", nil)
+		return
+	}
+
+	h.logger.DebugContext(r.Context(), "Event offset committed",
+		"store_id", storeID,
+		"offset", consumer.Offset,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(consumer)
+}
+
+// GetEventStats handles GET /v1/admin/events/stats
+func (h *EventsHandler) GetEventStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.eventQueue.Stats()
+
+	h.logger.DebugContext(r.Context(), "Event queue stats retrieved",
+		"event_count", stats.EventCount,
+		"next_offset", stats.NextOffset,
+		"consumers", len(stats.Consumers),
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// CompactEvents handles POST /v1/admin/events/compact - removes events below
+// beforeOffset once every registered store has read past it
+func (h *EventsHandler) CompactEvents(w http.ResponseWriter, r *http.Request) {
+	var req models.EventCompactRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.WarnContext(r.Context(), "Invalid JSON in event compact request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	removed, err := h.eventQueue.Compact(req.BeforeOffset)
+	if err != nil {
+		var behindErr *events.ConsumersBehindError
+		switch {
+		case errors.As(err, &behindErr):
+			details := make([]models.ErrorDetail, 0, len(behindErr.Consumers))
+			for _, consumer := range behindErr.Consumers {
+				details = append(details, models.ErrorDetail{
+					Field: consumer.StoreID,
+					Issue: fmt.Sprintf("consumed up to offset %d", consumer.Offset),
+				})
+			}
+			h.logger.WarnContext(r.Context(), "Event compaction refused, consumers behind",
+				"before_offset", req.BeforeOffset,
+				"consumers_behind", len(behindErr.Consumers),
+			)
+			writeErrorResponse(w, http.StatusConflict, "consumers_behind", "Registered stores have not consumed events up to the requested offset", details)
+		case errors.Is(err, events.ErrCompactOffsetOutOfRange):
+			writeErrorResponse(w, http.StatusBadRequest, "invalid_offset", "beforeOffset is beyond the newest stored event", nil)
+		default:
+			h.logger.ErrorContext(r.Context(), "Failed to persist compacted event queue", "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to persist compacted event queue", nil)
+		}
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "Event queue compacted by admin",
+		"before_offset", req.BeforeOffset,
+		"removed_events", removed,
+		"remote_addr", r.RemoteAddr,
+	)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(models.EventCompactResponse{
+		RemovedEvents: removed,
+		Stats:         h.eventQueue.Stats(),
+	})
+}
+
+// ReplayStore handles POST /v1/admin/stores/{storeId}/replay - rewinds one
+// store's committed offset so its next poll rebuilds its cache from a
+// snapshot and the events after it
+func (h *EventsHandler) ReplayStore(w http.ResponseWriter, r *http.Request) {
+	storeID := mux.Vars(r)["storeId"]
+	if storeID == reports.ConsumerID {
+		writeErrorResponse(w, http.StatusNotFound, "store_not_found", "Store has never consumed events", nil)
+		return
+	}
+
+	consumer, err := h.eventQueue.RequestReplay(storeID, adminActor(r))
+	if errors.Is(err, events.ErrUnknownConsumer) {
+		writeErrorResponse(w, http.StatusNotFound, "store_not_found", "Store has never consumed events", nil)
+		return
+	}
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "Failed to request store replay", "store_id", storeID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to request store replay", nil)
+		return
+	}
+
+	h.logger.InfoContext(r.Context(), "Store replay requested by admin",
+		"store_id", storeID,
+		"actor", adminActor(r),
+		"remote_addr", r.RemoteAddr,
+	)
+	writeJSONResponse(w, http.StatusAccepted, consumer)
+}
+
+// writeReplayRequestedResponse answers a store with a pending replay like a
+// lost offset, so stores that predate replays resync as well
+func (h *EventsHandler) writeReplayRequestedResponse(w http.ResponseWriter, r *http.Request, storeID string, offset, snapshotOffset int64) {
+	response := models.OffsetGoneResponse{
+		Code:                  "offset_gone",
+		Message:               "Replay requested by an operator, run a full resync",
+		RequestedOffset:       offset,
+		OldestAvailableOffset: h.eventQueue.OldestAvailableOffset(),
+		SnapshotOffset:        snapshotOffset,
+		Reason:                "replay_requested",
+		RequestID:             w.Header().Get(requestid.Header),
+	}
+
+	h.logger.InfoContext(r.Context(), "Store told to resync for a requested replay",
+		"store_id", storeID,
+		"offset", offset,
+		"snapshot_offset", snapshotOffset,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusGone)
+	json.NewEncoder(w).Encode(response)
+}
+
+// writeOffsetGoneResponse answers 410 Gone with the oldest available offset
+// and the current snapshot offset
+func (h *EventsHandler) writeOffsetGoneResponse(w http.ResponseWriter, r *http.Request, storeID string, offset, oldest int64) {
+	response := models.OffsetGoneResponse{
+		Code:                  "offset_gone",
+		Message:               "Requested offset is no longer available, run a full resync",
+		RequestedOffset:       offset,
+		OldestAvailableOffset: oldest,
+		SnapshotOffset:        h.eventQueue.GetCurrentOffset(),
+		RequestID:             w.Header().Get(requestid.Header),
+	}
+
+	h.logger.WarnContext(r.Context(), "Events requested from an offset that is gone",
+		"offset", offset,
+		"oldest_available_offset", oldest,
+		"snapshot_offset", response.SnapshotOffset,
+		"store_id", storeID,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusGone)
 	json.NewEncoder(w).Encode(response)
 }
 
@@ -125,8 +521,9 @@ func (h *EventsHandler) writeErrorResponse(w http.ResponseWriter, message string
 	w.WriteHeader(statusCode)
 
 	errorResp := models.ErrorResponse{
-		Code:    "EVENTS_ERROR",
-		Message: message,
+		Code:      "EVENTS_ERROR",
+		Message:   message,
+		RequestID: w.Header().Get(requestid.Header),
 	}
 
 	json.NewEncoder(w).Encode(errorResp)
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// FeatureFlagsHandler lets admins inspect and toggle feature flags
+type FeatureFlagsHandler struct {
+	flags *featureflags.Flags
+}
+
+// NewFeatureFlagsHandler creates a new feature flags handler
+func NewFeatureFlagsHandler(flags *featureflags.Flags) *FeatureFlagsHandler {
+	return &FeatureFlagsHandler{flags: flags}
+}
+
+// ListFlags handles GET /v1/admin/feature-flags
+func (h *FeatureFlagsHandler) ListFlags(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, h.response())
+}
+
+// SetFlag handles PUT /v1/admin/feature-flags/{flag} - turns a flag on or off
+// for the environment or for one tenant
+func (h *FeatureFlagsHandler) SetFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["flag"]
+
+	var req models.FeatureFlagUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in feature flag request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	tenant := strings.TrimSpace(req.Tenant)
+	if err := h.flags.Set(name, tenant, *req.Enabled); err != nil {
+		h.writeFlagError(w, name, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Feature flag changed",
+		"flag", name,
+		"tenant", tenant,
+		"enabled", *req.Enabled,
+		"actor", middleware.RequestActor(r))
+
+	writeJSONResponse(w, http.StatusOK, h.response())
+}
+
+// ClearFlag handles DELETE /v1/admin/feature-flags/{flag}?tenant= - drops a
+// tenant override, or resets the environment-wide value to the default
+func (h *FeatureFlagsHandler) ClearFlag(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["flag"]
+	tenant := strings.TrimSpace(r.URL.Query().Get("tenant"))
+
+	if err := h.flags.Clear(name, tenant); err != nil {
+		h.writeFlagError(w, name, err)
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Feature flag cleared",
+		"flag", name,
+		"tenant", tenant,
+		"actor", middleware.RequestActor(r))
+
+	writeJSONResponse(w, http.StatusOK, h.response())
+}
+
+func (h *FeatureFlagsHandler) writeFlagError(w http.ResponseWriter, name string, err error) {
+	if errors.Is(err, featureflags.ErrUnknownFlag) {
+		writeErrorResponse(w, http.StatusNotFound, "flag_not_found", "Unknown feature flag: "+name, nil)
+		return
+	}
+	writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to update feature flag", nil)
+}
+
+func (h *FeatureFlagsHandler) response() models.FeatureFlagsResponse {
+	states := h.flags.States()
+	flags := make([]models.FeatureFlagState, 0, len(states))
+	for _, state := range states {
+		flags = append(flags, models.FeatureFlagState{
+			Name:        state.Name,
+			Description: state.Description,
+			Default:     state.Default,
+			Enabled:     state.Enabled,
+			Overrides:   state.Overrides,
+		})
+	}
+	return models.FeatureFlagsResponse{Flags: flags}
+}
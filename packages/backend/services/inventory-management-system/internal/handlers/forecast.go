@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/forecast"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// historyPageSize is the number of events read per page when loading history
+const historyPageSize = 1000
+
+// ForecastHandler projects stock-outs from the sales recorded in the event history
+type ForecastHandler struct {
+	inventoryService *services.InventoryService
+	eventQueue       *events.EventQueue
+}
+
+// NewForecastHandler creates a new forecast handler
+func NewForecastHandler(inventoryService *services.InventoryService, eventQueue *events.EventQueue) *ForecastHandler {
+	return &ForecastHandler{
+		inventoryService: inventoryService,
+		eventQueue:       eventQueue,
+	}
+}
+
+// GetProductForecast handles GET /v1/inventory/{productId}/forecast
+func (h *ForecastHandler) GetProductForecast(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+
+	windows, ok := parseForecastWindows(w, r)
+	if !ok {
+		return
+	}
+
+	product, err := h.inventoryService.GetProduct(productID)
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", fmt.Sprintf("Product not found: %s", productID), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to read product for forecast", "product_id", productID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to read product", nil)
+		return
+	}
+
+	now := clock.Now()
+	history := h.loadHistory(now)
+	writeJSONResponse(w, http.StatusOK, models.ProductForecastResponse{
+		Forecast:    history.Forecast(*product, windows, now),
+		HistoryFrom: clock.Format(history.From()),
+		GeneratedAt: clock.Format(now),
+	})
+}
+
+// GetForecastReport handles GET /v1/admin/forecast - every product, soonest
+// stock-out first, optionally only those running out within withinDays
+func (h *ForecastHandler) GetForecastReport(w http.ResponseWriter, r *http.Request) {
+	windows, ok := parseForecastWindows(w, r)
+	if !ok {
+		return
+	}
+
+	withinDays := 0
+	if value := r.URL.Query().Get("withinDays"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid withinDays", []models.ErrorDetail{
+				{Field: "withinDays", Issue: "must be a positive number of days"},
+			})
+			return
+		}
+		withinDays = parsed
+	}
+
+	products, _ := h.inventoryService.SnapshotProducts()
+	now := clock.Now()
+	history := h.loadHistory(now)
+	report := history.Report(products, windows, now, withinDays)
+
+	writeJSONResponse(w, http.StatusOK, models.ForecastReport{
+		Products:    report,
+		Count:       len(report),
+		HistoryFrom: clock.Format(history.From()),
+		GeneratedAt: clock.Format(now),
+	})
+}
+
+// loadHistory reads every retained event
+func (h *ForecastHandler) loadHistory(now time.Time) *forecast.History {
+	var collected []models.Event
+	next := h.eventQueue.OldestAvailableOffset()
+	for {
+		page, nextOffset, hasMore := h.eventQueue.GetEvents(next, historyPageSize)
+		collected = append(collected, page...)
+		if !hasMore || len(page) == 0 {
+			break
+		}
+		next = nextOffset
+	}
+	return forecast.NewHistory(collected, now)
+}
+
+func parseForecastWindows(w http.ResponseWriter, r *http.Request) ([]int, bool) {
+	windows, err := forecast.ParseWindows(r.URL.Query().Get("windows"))
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid windows", []models.ErrorDetail{
+			{Field: "windows", Issue: err.Error()},
+		})
+		return nil, false
+	}
+	return windows, true
+}
@@ -2,17 +2,32 @@ package handlers
 
 import (
 	"net/http"
+
+	"inventory-management-api/internal/leader"
+	"inventory-management-api/internal/models"
 )
 
 // HealthHandler handles health check requests
-type HealthHandler struct{}
+type HealthHandler struct {
+	elector *leader.Elector // Nil unless leader election is on
+}
 
 // NewHealthHandler creates a new health handler
 func NewHealthHandler() *HealthHandler {
 	return &HealthHandler{}
 }
 
+// SetElector adds this instance's leader election role to health checks
+func (h *HealthHandler) SetElector(elector *leader.Elector) {
+	h.elector = elector
+}
+
 // Health handles GET /health - Health check endpoint
 func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
-	writeJSONResponse(w, http.StatusOK, map[string]string{"status": "healthy"})
+	response := models.HealthResponse{Status: "healthy"}
+	if h.elector != nil {
+		status := h.elector.Status()
+		response.Leader = &status
+	}
+	writeJSONResponse(w, http.StatusOK, response)
 }
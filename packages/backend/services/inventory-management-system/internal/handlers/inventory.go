@@ -1,16 +1,27 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/idempotency"
+	"inventory-management-api/internal/middleware"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
+	"inventory-management-api/internal/requestid"
 	"inventory-management-api/internal/services"
 	"inventory-management-api/internal/telemetry"
+	"inventory-management-api/internal/validation"
 
 	"github.com/gorilla/mux"
 )
@@ -18,15 +29,28 @@ import (
 // InventoryHandler handles inventory-related HTTP requests
 type InventoryHandler struct {
 	inventoryService *services.InventoryService
+	maxBatchItems    int
+	partition        *partitions.Partition // Set on a partition, which refuses updates of products it does not own
 }
 
 // NewInventoryHandler creates a new inventory handler
 func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
 	return &InventoryHandler{
 		inventoryService: inventoryService,
+		maxBatchItems:    100,
 	}
 }
 
+// SetMaxBatchItems sets the maximum number of updates accepted in one batch
+func (h *InventoryHandler) SetMaxBatchItems(maxItems int) {
+	h.maxBatchItems = maxItems
+}
+
+// SetPartition makes the handler refuse updates of products partition does not own
+func (h *InventoryHandler) SetPartition(partition partitions.Partition) {
+	h.partition = &partition
+}
+
 // writeJSONResponse is a helper function to write JSON responses
 func writeJSONResponse(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -39,90 +63,387 @@ func writeErrorResponse(w http.ResponseWriter, statusCode int, code, message str
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(models.ErrorResponse{
-		Code:    code,
-		Message: message,
-		Details: details,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: w.Header().Get(requestid.Header),
 	})
 }
 
+// requestStoreID resolves the store a request acts for from its principal
+// (see middleware.RequestStoreID), answering 403 when X-Store-ID names a store
+// the caller is not authenticated as
+func requestStoreID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	storeID, err := middleware.RequestStoreID(r)
+	switch {
+	case errors.Is(err, middleware.ErrStoreIDMismatch):
+		writeErrorResponse(w, http.StatusForbidden, "store_id_mismatch", err.Error(), nil)
+		return "", false
+	case err != nil:
+		writeErrorResponse(w, http.StatusForbidden, "store_not_bound", err.Error(), nil)
+		return "", false
+	}
+	return storeID, true
+}
+
+// writeDecodeError answers a failed body decode with 413 when the body hit the
+// size limit and with the given 400 error otherwise
+func writeDecodeError(w http.ResponseWriter, err error, code, message string) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		middleware.WritePayloadTooLargeResponse(w, maxBytesErr.Limit)
+		return
+	}
+	writeErrorResponse(w, http.StatusBadRequest, code, message, nil)
+}
+
 // UpdateInventory handles POST /v1/inventory/updates - Mutate stock (single or batch)
 func (h *InventoryHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var req models.UpdateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		slog.Warn("Invalid JSON in update request", "error", err, "remote_addr", r.RemoteAddr)
-		writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON", nil)
+		slog.WarnContext(r.Context(), "Invalid JSON in update request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+
+	// Idempotency keys are deduplicated per store; stores identify themselves
+	// with X-Store-ID when the body does not name one
+	if req.StoreID == "" {
+		req.StoreID = r.Header.Get(middleware.StoreIDHeader)
+	}
+
+	// Feature flags apply to the store the caller is authenticated as. A
+	// caller bound to a store updates as that store; one bound to none keeps
+	// the store it names for idempotency and events, with the default flags.
+	tenantID, err := middleware.StoreIDFor(r, req.StoreID)
+	switch {
+	case errors.Is(err, middleware.ErrStoreIDMismatch):
+		slog.WarnContext(r.Context(), "Rejecting update for another store", "store_id", req.StoreID, "remote_addr", r.RemoteAddr)
+		writeErrorResponse(w, http.StatusForbidden, "store_id_mismatch", err.Error(), nil)
+		return
+	case err == nil && tenantID != "":
+		req.StoreID = tenantID
+	}
+	ctx = services.WithTenant(ctx, tenantID)
+
+	if h.inventoryService.IsDraining() {
+		slog.WarnContext(r.Context(), "Rejecting inventory update during shutdown", "store_id", req.StoreID, "remote_addr", r.RemoteAddr)
+		w.Header().Set("Retry-After", "5")
+		writeErrorResponse(w, http.StatusServiceUnavailable, services.ErrTypeServiceUnavailable, "Service is shutting down, retry shortly", nil)
+		return
+	}
+
+	if h.inventoryService.IsRestoring() {
+		slog.WarnContext(r.Context(), "Rejecting inventory update during restore", "store_id", req.StoreID, "remote_addr", r.RemoteAddr)
+		writeRestoringError(w)
 		return
 	}
 
+	if len(req.Updates) > h.maxBatchItems {
+		slog.WarnContext(r.Context(), "Batch update exceeds item limit",
+			"store_id", req.StoreID,
+			"update_count", len(req.Updates),
+			"max_items", h.maxBatchItems)
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many updates in batch", []models.ErrorDetail{
+			{
+				Field: "updates",
+				Issue: fmt.Sprintf("Batch contains %d updates, maximum is %d", len(req.Updates), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	productIDs := []string{req.ProductID}
+	if len(req.Updates) > 0 {
+		productIDs = make([]string, len(req.Updates))
+		for i, update := range req.Updates {
+			productIDs[i] = update.ProductID
+		}
+	}
+	if misrouted := misroutedProducts(h.partition, productIDs); len(misrouted) > 0 {
+		slog.WarnContext(r.Context(), "Rejecting update of products owned by another partition",
+			"store_id", req.StoreID,
+			"misrouted", len(misrouted))
+		writeWrongPartition(w, h.partition, misrouted)
+		return
+	}
+
+	priority, err := updatePriority(r)
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: middleware.UpdatePriorityHeader, Issue: err.Error()},
+		})
+		return
+	}
+	ctx = services.WithUpdatePriority(ctx, priority)
+
+	if len(req.Reason) > maxUpdateReasonLength {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "reason", Issue: fmt.Sprintf("must be at most %d characters", maxUpdateReasonLength)},
+		})
+		return
+	}
+	if req.Reason != "" {
+		ctx = services.WithUpdateReason(ctx, req.Reason)
+	}
+
 	// Set telemetry context data for the middleware to pick up
 	ctx = telemetry.SetStoreID(ctx, req.StoreID)
+	telemetry.AnnotateStore(ctx, req.StoreID)
 
 	// Determine if this is a single update or batch update
 	if len(req.Updates) > 0 {
 		// Batch update operation
-		slog.Info("Processing batch inventory update",
+		slog.InfoContext(r.Context(), "Processing batch inventory update",
 			"store_id", req.StoreID,
 			"update_count", len(req.Updates),
 			"remote_addr", r.RemoteAddr)
 
-		response := h.processBatchUpdate(req)
+		response := h.processBatchUpdate(ctx, req)
 
 		// For batch updates, return 200 even if some items failed
 		// The client can check individual results
+		if hasBackpressureResult(response.Results) {
+			w.Header().Set("Retry-After", queueSaturatedRetryAfter)
+		}
+		if allReplayed(response.Results) {
+			w.Header().Set(IdempotencyReplayedHeader, "true")
+		}
 		writeJSONResponse(w, http.StatusOK, response)
 	} else {
 		// Single update operation
-		slog.Info("Processing single inventory update",
+		slog.InfoContext(r.Context(), "Processing single inventory update",
 			"store_id", req.StoreID,
 			"product_id", req.ProductID,
 			"delta", req.Delta,
 			"remote_addr", r.RemoteAddr)
 
-		response := h.processSingleUpdate(req)
+		telemetry.AnnotateProduct(ctx, req.ProductID)
+		response := h.processSingleUpdate(ctx, req)
+		if isBackpressureError(response.ErrorType) {
+			w.Header().Set("Retry-After", queueSaturatedRetryAfter)
+		}
+		if response.ErrorType == services.ErrTypeStocktakeHold {
+			w.Header().Set("Retry-After", stocktakeRetryAfter)
+		}
+		if response.Replayed {
+			w.Header().Set(IdempotencyReplayedHeader, "true")
+		}
 
-		// For single updates, return appropriate HTTP status based on result
-		if !response.Applied {
-			// Check if it's a version conflict or other error
-			if req.Version >= 0 {
-				// Likely a version conflict or business logic error
-				writeJSONResponse(w, http.StatusConflict, response)
-			} else {
-				// Bad request (missing fields, etc.)
-				writeJSONResponse(w, http.StatusBadRequest, response)
-			}
-		} else {
-			writeJSONResponse(w, http.StatusOK, response)
+		// Single updates always return the UpdateResponse envelope; the status
+		// code is derived from its error type
+		writeJSONResponse(w, statusForUpdateError(response.ErrorType), response)
+	}
+}
+
+// updatePriority classifies the update lane. Updates by an admin principal,
+// whatever it authenticated with, are bulk work and everything else is a
+// checkout decrement. The X-Update-Priority header can move a request to a
+// lower lane, never above its default.
+func updatePriority(r *http.Request) (services.UpdatePriority, error) {
+	priority := services.PriorityCheckout
+	if principal, ok := authz.PrincipalFromContext(r.Context()); ok && principal.HasRole(authz.RoleAdmin) {
+		priority = services.PriorityBulk
+	}
+
+	value := r.Header.Get(middleware.UpdatePriorityHeader)
+	if value == "" {
+		return priority, nil
+	}
+	requested, err := services.ParseUpdatePriority(value)
+	if err != nil {
+		return 0, err
+	}
+	if requested < priority {
+		return 0, fmt.Errorf("cannot raise the priority above %s", priority)
+	}
+	return requested, nil
+}
+
+// statusForUpdateError maps a single-update error type to its HTTP status:
+// 200 applied, 409 version_conflict, 404 product_not_found,
+// 412 condition_failed, 422 insufficient_inventory and the stock policy's
+// backorder_limit_exceeded and max_stock_exceeded, 423 stocktake_frozen,
+// 429 load_shed and stocktake_hold, 400 for malformed requests
+func statusForUpdateError(errorType string) int {
+	switch errorType {
+	case "":
+		return http.StatusOK
+	case services.ErrTypeLoadShed, services.ErrTypeStocktakeHold:
+		return http.StatusTooManyRequests
+	case services.ErrTypeStocktakeFrozen:
+		return http.StatusLocked
+	case services.ErrTypeVersionConflict:
+		return http.StatusConflict
+	case services.ErrTypeConditionFailed:
+		return http.StatusPreconditionFailed
+	case services.ErrTypeProductNotFound, services.ErrTypeNotFound:
+		return http.StatusNotFound
+	case services.ErrTypeInsufficientInventory, services.ErrTypeBackorderLimit, services.ErrTypeMaxStockExceeded:
+		return http.StatusUnprocessableEntity
+	case services.ErrTypeInvalidRequest, services.ErrTypeInvalidDelta, services.ErrTypeMissingProductID,
+		services.ErrTypeInvalidIdempotencyKey, services.ErrTypeValidation:
+		return http.StatusBadRequest
+	case services.ErrTypeTimeout, services.ErrTypeServiceUnavailable, services.ErrTypeQueueSaturated:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// validateUpdate checks an update item against the model rules and returns the
+// error type and message for the first violation, or empty strings when valid
+func validateUpdate(update models.ProductUpdate) (string, string) {
+	validationErrors := validation.Validate(update)
+	if len(validationErrors) == 0 {
+		return "", ""
+	}
+
+	first := validationErrors[0]
+	switch first.Field {
+	case "productId":
+		return services.ErrTypeMissingProductID, first.Issue
+	case "idempotencyKey":
+		return services.ErrTypeInvalidIdempotencyKey, first.Issue
+	}
+	return services.ErrTypeInvalidRequest, first.Issue
+}
+
+// withUpdateCondition carries the update's stock condition, if any, to the
+// worker that applies it
+func withUpdateCondition(ctx context.Context, update models.ProductUpdate) context.Context {
+	condition := services.UpdateCondition{MinAvailable: update.MinAvailable, ExpectedAvailable: update.ExpectedAvailable}
+	if !condition.IsSet() {
+		return ctx
+	}
+	return services.WithUpdateCondition(ctx, condition)
+}
+
+// maxUpdateReasonLength caps the optional reason label stored on update events
+const maxUpdateReasonLength = 64
+
+// queueSaturatedRetryAfter is the Retry-After value, in seconds, sent when an
+// update was rejected because its worker shard was saturated or its lane shed
+const queueSaturatedRetryAfter = "1"
+
+// stocktakeRetryAfter is the Retry-After value, in seconds, sent when a
+// decrement is held until a stocktake in queue mode closes
+const stocktakeRetryAfter = "30"
+
+// IdempotencyReplayedHeader is set to "true" on update and transfer responses
+// answered from the idempotency cache instead of being applied again
+const IdempotencyReplayedHeader = "Idempotency-Replayed"
+
+// restoreRetryAfter is the Retry-After value, in seconds, sent while a
+// point-in-time restore pauses writes
+const restoreRetryAfter = "5"
+
+// errorTypeForSubmitError classifies an error returned while submitting an update
+func errorTypeForSubmitError(err error) string {
+	switch {
+	case errors.Is(err, services.ErrServiceDraining), errors.Is(err, services.ErrServiceRestoring):
+		return services.ErrTypeServiceUnavailable
+	case errors.Is(err, services.ErrQueueSaturated):
+		return services.ErrTypeQueueSaturated
+	case errors.Is(err, services.ErrLoadShed):
+		return services.ErrTypeLoadShed
+	case errors.Is(err, services.ErrUpdateAbandoned):
+		return services.ErrTypeTimeout
+	default:
+		return services.ErrTypeInternalError
+	}
+}
+
+// isBackpressureError reports whether an update was rejected because its
+// shard was saturated or its priority lane was shed
+func isBackpressureError(errorType string) bool {
+	return errorType == services.ErrTypeQueueSaturated || errorType == services.ErrTypeLoadShed
+}
+
+// allReplayed reports whether every batch item was answered from the
+// idempotency cache, i.e. the whole batch was a retry
+func allReplayed(results []models.ProductUpdateResult) bool {
+	for _, result := range results {
+		if !result.Replayed {
+			return false
 		}
 	}
+	return len(results) > 0
+}
+
+// hasBackpressureResult reports whether any batch item was rejected by backpressure
+func hasBackpressureResult(results []models.ProductUpdateResult) bool {
+	for _, result := range results {
+		if isBackpressureError(result.ErrorType) {
+			return true
+		}
+	}
+	return false
+}
+
+// replayedUpdate returns the cached outcome of an update this store already
+// sent with the same key, so retries are answered without validating or
+// queueing them again
+func (h *InventoryHandler) replayedUpdate(storeID, idempotencyKey string) (*services.UpdateResult, bool) {
+	if !idempotency.Valid(idempotencyKey) {
+		return nil, false
+	}
+	return h.inventoryService.ReplayedUpdate(storeID, idempotencyKey)
+}
+
+// rejectUpdate caches a validation failure under the update's key, when the key
+// itself is valid, so retries of the same bad request are replayed
+func (h *InventoryHandler) rejectUpdate(storeID string, update models.ProductUpdate, errorType, message string) {
+	if errorType != services.ErrTypeInvalidIdempotencyKey {
+		h.inventoryService.CacheRejectedUpdate(storeID, update.IdempotencyKey, errorType, message)
+	}
 }
 
 // processSingleUpdate handles single product updates with OCC and idempotency
-func (h *InventoryHandler) processSingleUpdate(req models.UpdateRequest) models.UpdateResponse {
-	// Validate single update request
-	if req.ProductID == "" {
-		slog.Warn("Missing product ID in single update")
+func (h *InventoryHandler) processSingleUpdate(ctx context.Context, req models.UpdateRequest) models.UpdateResponse {
+	if result, replayed := h.replayedUpdate(req.StoreID, req.IdempotencyKey); replayed {
+		slog.InfoContext(ctx, "Replaying cached update result",
+			"product_id", req.ProductID,
+			"idempotency_key", req.IdempotencyKey)
 		return models.UpdateResponse{
-			ProductID:    req.ProductID,
-			ErrorType:    services.ErrTypeMissingProductID,
-			ErrorMessage: "Missing product ID",
-			Applied:      false,
+			ProductID:     req.ProductID,
+			NewQuantity:   result.NewQuantity,
+			NewVersion:    result.NewVersion,
+			Applied:       result.Applied,
+			LastUpdated:   result.LastUpdated,
+			ErrorType:     result.ErrorType,
+			ErrorMessage:  result.ErrorMessage,
+			Replayed:      true,
+			BelowMinStock: result.BelowMinStock,
+			Backordered:   result.Backordered,
 		}
 	}
 
-	if req.IdempotencyKey == "" {
-		slog.Warn("Missing idempotency key in single update", "product_id", req.ProductID)
+	// Validate single update request
+	update := models.ProductUpdate{
+		ProductID:         req.ProductID,
+		Delta:             req.Delta,
+		Version:           req.Version,
+		IdempotencyKey:    req.IdempotencyKey,
+		MinAvailable:      req.MinAvailable,
+		ExpectedAvailable: req.ExpectedAvailable,
+	}
+	if errorType, message := validateUpdate(update); errorType != "" {
+		slog.WarnContext(ctx, "Invalid single update", "product_id", req.ProductID, "error", message)
+		h.rejectUpdate(req.StoreID, update, errorType, message)
 		return models.UpdateResponse{
 			ProductID:    req.ProductID,
-			ErrorType:    services.ErrTypeInvalidRequest,
-			ErrorMessage: "Missing idempotency key",
+			ErrorType:    errorType,
+			ErrorMessage: message,
 			Applied:      false,
 		}
 	}
 
 	// Submit update to queue-based service
-	result, err := h.inventoryService.UpdateInventory(
+	result, err := h.inventoryService.UpdateInventoryCtx(
+		withUpdateCondition(ctx, update),
 		req.ProductID,
 		req.Delta,
 		req.Version,
@@ -131,7 +452,7 @@ func (h *InventoryHandler) processSingleUpdate(req models.UpdateRequest) models.
 	)
 
 	if err != nil {
-		slog.Error("Failed to process single update",
+		slog.ErrorContext(ctx, "Failed to process single update",
 			"product_id", req.ProductID,
 			"error", err)
 		return models.UpdateResponse{
@@ -139,31 +460,34 @@ func (h *InventoryHandler) processSingleUpdate(req models.UpdateRequest) models.
 			Applied:      false,
 			NewQuantity:  0,
 			NewVersion:   0,
-			ErrorType:    services.ErrTypeInternalError,
+			ErrorType:    errorTypeForSubmitError(err),
 			ErrorMessage: err.Error(),
 			LastUpdated:  "",
 		}
 	}
 
 	response := models.UpdateResponse{
-		ProductID:    req.ProductID,
-		NewQuantity:  result.NewQuantity,
-		NewVersion:   result.NewVersion,
-		Applied:      result.Applied,
-		LastUpdated:  result.LastUpdated,
-		ErrorType:    result.ErrorType,
-		ErrorMessage: result.ErrorMessage,
+		ProductID:     req.ProductID,
+		NewQuantity:   result.NewQuantity,
+		NewVersion:    result.NewVersion,
+		Applied:       result.Applied,
+		LastUpdated:   result.LastUpdated,
+		ErrorType:     result.ErrorType,
+		ErrorMessage:  result.ErrorMessage,
+		Replayed:      result.Replayed,
+		BelowMinStock: result.BelowMinStock,
+		Backordered:   result.Backordered,
 	}
 
 	if result.Success {
-		slog.Info("Single update processed successfully",
+		slog.InfoContext(ctx, "Single update processed successfully",
 			"product_id", req.ProductID,
 			"new_quantity", response.NewQuantity,
 			"new_version", response.NewVersion,
 			"delta", req.Delta,
 			"idempotency_key", req.IdempotencyKey)
 	} else {
-		slog.Warn("Single update failed",
+		slog.WarnContext(ctx, "Single update failed",
 			"product_id", req.ProductID,
 			"error_type", result.ErrorType,
 			"error_message", result.ErrorMessage,
@@ -174,38 +498,49 @@ func (h *InventoryHandler) processSingleUpdate(req models.UpdateRequest) models.
 }
 
 // processBatchUpdate handles batch product updates with OCC and idempotency
-func (h *InventoryHandler) processBatchUpdate(req models.UpdateRequest) models.UpdateResponse {
+func (h *InventoryHandler) processBatchUpdate(ctx context.Context, req models.UpdateRequest) models.UpdateResponse {
 	results := make([]models.ProductUpdateResult, 0, len(req.Updates))
 	succeeded := 0
 	failed := 0
 
 	for _, update := range req.Updates {
-		if update.ProductID == "" {
-			slog.Warn("Missing product ID in batch update item")
+		if cached, replayed := h.replayedUpdate(req.StoreID, update.IdempotencyKey); replayed {
 			results = append(results, models.ProductUpdateResult{
-				ProductID:    update.ProductID,
-				Applied:      false,
-				ErrorType:    services.ErrTypeMissingProductID,
-				ErrorMessage: "Missing product ID",
+				ProductID:     update.ProductID,
+				NewQuantity:   cached.NewQuantity,
+				NewVersion:    cached.NewVersion,
+				Applied:       cached.Applied,
+				LastUpdated:   cached.LastUpdated,
+				ErrorType:     cached.ErrorType,
+				ErrorMessage:  cached.ErrorMessage,
+				Replayed:      true,
+				BelowMinStock: cached.BelowMinStock,
+				Backordered:   cached.Backordered,
 			})
-			failed++
+			if cached.Success {
+				succeeded++
+			} else {
+				failed++
+			}
 			continue
 		}
 
-		if update.IdempotencyKey == "" {
-			slog.Warn("Missing idempotency key in batch update item", "product_id", update.ProductID)
+		if errorType, message := validateUpdate(update); errorType != "" {
+			slog.WarnContext(ctx, "Invalid batch update item", "product_id", update.ProductID, "error", message)
+			h.rejectUpdate(req.StoreID, update, errorType, message)
 			results = append(results, models.ProductUpdateResult{
 				ProductID:    update.ProductID,
 				Applied:      false,
-				ErrorType:    services.ErrTypeInvalidRequest,
-				ErrorMessage: "Missing idempotency key",
+				ErrorType:    errorType,
+				ErrorMessage: message,
 			})
 			failed++
 			continue
 		}
 
 		// Submit update to queue-based service
-		serviceResult, err := h.inventoryService.UpdateInventory(
+		serviceResult, err := h.inventoryService.UpdateInventoryCtx(
+			withUpdateCondition(ctx, update),
 			update.ProductID,
 			update.Delta,
 			update.Version,
@@ -215,13 +550,13 @@ func (h *InventoryHandler) processBatchUpdate(req models.UpdateRequest) models.U
 
 		var result models.ProductUpdateResult
 		if err != nil {
-			slog.Error("Failed to process batch update item",
+			slog.ErrorContext(ctx, "Failed to process batch update item",
 				"product_id", update.ProductID,
 				"error", err)
 			result = models.ProductUpdateResult{
 				ProductID:    update.ProductID,
 				Applied:      false,
-				ErrorType:    services.ErrTypeInternalError,
+				ErrorType:    errorTypeForSubmitError(err),
 				ErrorMessage: err.Error(),
 			}
 			failed++
@@ -234,22 +569,26 @@ func (h *InventoryHandler) processBatchUpdate(req models.UpdateRequest) models.U
 				LastUpdated:  serviceResult.LastUpdated,
 				ErrorType:    serviceResult.ErrorType,
 				ErrorMessage: serviceResult.ErrorMessage,
+				Replayed:     serviceResult.Replayed,
 			}
 			failed++
 		} else {
 			result = models.ProductUpdateResult{
-				ProductID:   update.ProductID,
-				NewQuantity: serviceResult.NewQuantity,
-				NewVersion:  serviceResult.NewVersion,
-				Applied:     true,
-				LastUpdated: serviceResult.LastUpdated,
+				ProductID:     update.ProductID,
+				NewQuantity:   serviceResult.NewQuantity,
+				NewVersion:    serviceResult.NewVersion,
+				Applied:       true,
+				LastUpdated:   serviceResult.LastUpdated,
+				Replayed:      serviceResult.Replayed,
+				BelowMinStock: serviceResult.BelowMinStock,
+				Backordered:   serviceResult.Backordered,
 			}
 			succeeded++
 		}
 
 		results = append(results, result)
 
-		slog.Debug("Batch update item processed",
+		slog.DebugContext(ctx, "Batch update item processed",
 			"product_id", update.ProductID,
 			"new_quantity", result.NewQuantity,
 			"applied", result.Applied,
@@ -265,7 +604,7 @@ func (h *InventoryHandler) processBatchUpdate(req models.UpdateRequest) models.U
 		},
 	}
 
-	slog.Info("Batch update completed",
+	slog.InfoContext(ctx, "Batch update completed",
 		"total", response.Summary.Total,
 		"succeeded", response.Summary.Succeeded,
 		"failed", response.Summary.Failed)
@@ -288,18 +627,172 @@ func (h *InventoryHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Get the product from the service
 	product, err := h.inventoryService.GetProduct(productID)
-	if err != nil {
-		// If product doesn't exist, return 404
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
 		writeErrorResponse(w, http.StatusNotFound, "not_found", fmt.Sprintf("Product not found: %s", productID), nil)
 		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to read product", "product_id", productID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to read product", nil)
+		return
 	}
 
 	// Return successful response
 	writeJSONResponse(w, http.StatusOK, product)
 }
 
+// BatchGetProducts handles POST /v1/inventory/batch-get - Read several products in one round trip
+func (h *InventoryHandler) BatchGetProducts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req models.BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(ctx, "Invalid JSON in batch get request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	if len(req.ProductIDs) > h.maxBatchItems {
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many product IDs in batch", []models.ErrorDetail{
+			{
+				Field: "productIds",
+				Issue: fmt.Sprintf("Batch contains %d product IDs, maximum is %d", len(req.ProductIDs), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	products, missing := h.inventoryService.GetProducts(req.ProductIDs)
+	ctx = telemetry.SetProductCount(ctx, len(products))
+
+	slog.DebugContext(ctx, "Batch get completed",
+		"requested", len(req.ProductIDs),
+		"found", len(products),
+		"missing", len(missing),
+		"remote_addr", r.RemoteAddr)
+
+	writeJSONResponse(w, http.StatusOK, models.BatchGetResponse{
+		Products: products,
+		Missing:  missing,
+	})
+}
+
+// GetProductVersions handles GET /v1/inventory/versions?ids=... - Read the
+// current version and stock of several products ahead of OCC updates
+func (h *InventoryHandler) GetProductVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// IDs come comma-separated, repeated, or both; blanks and duplicates are dropped
+	var productIDs []string
+	seen := make(map[string]bool)
+	for _, value := range r.URL.Query()["ids"] {
+		for _, productID := range strings.Split(value, ",") {
+			productID = strings.TrimSpace(productID)
+			if productID == "" || seen[productID] {
+				continue
+			}
+			seen[productID] = true
+			productIDs = append(productIDs, productID)
+		}
+	}
+
+	if len(productIDs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "ids", Issue: "at least one product ID is required"},
+		})
+		return
+	}
+
+	if len(productIDs) > h.maxBatchItems {
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many product IDs in batch", []models.ErrorDetail{
+			{
+				Field: "ids",
+				Issue: fmt.Sprintf("Batch contains %d product IDs, maximum is %d", len(productIDs), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	versions, missing := h.inventoryService.GetProductVersions(productIDs)
+	ctx = telemetry.SetProductCount(ctx, len(versions))
+
+	slog.DebugContext(ctx, "Product versions read",
+		"requested", len(productIDs),
+		"found", len(versions),
+		"missing", len(missing),
+		"remote_addr", r.RemoteAddr)
+
+	writeJSONResponse(w, http.StatusOK, models.VersionsResponse{
+		Versions: versions,
+		Missing:  missing,
+	})
+}
+
+// GetProductPrice handles GET /v1/inventory/{productId}/price - Read price in a given currency
+func (h *InventoryHandler) GetProductPrice(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+	currencyCode := r.URL.Query().Get("currency")
+
+	price, err := h.inventoryService.GetProductPrice(productID, currencyCode)
+	switch {
+	case errors.Is(err, services.ErrUnsupportedCurrency):
+		writeErrorResponse(w, http.StatusBadRequest, services.ErrTypeUnsupportedCurrency, err.Error(), []models.ErrorDetail{
+			{Field: "currency", Issue: "unsupported or invalid currency code"},
+		})
+		return
+	case errors.Is(err, services.ErrProductNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", fmt.Sprintf("Product not found: %s", productID), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to resolve product price",
+			"product_id", productID,
+			"currency", currencyCode,
+			"error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to resolve product price", nil)
+		return
+	}
+
+	slog.DebugContext(r.Context(), "Product price resolved",
+		"product_id", productID,
+		"currency", price.Currency,
+		"amount", price.Amount,
+		"source", price.Source)
+
+	writeJSONResponse(w, http.StatusOK, price)
+}
+
+// GetProductBackorders handles GET /v1/inventory/{productId}/backorders
+func (h *InventoryHandler) GetProductBackorders(w http.ResponseWriter, r *http.Request) {
+	productID := mux.Vars(r)["productId"]
+
+	response, err := h.inventoryService.ProductBackorders(productID)
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", fmt.Sprintf("Product not found: %s", productID), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to read backorders", "product_id", productID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to read backorders", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
 // ListProducts handles GET /v1/inventory - List products with offset-based pagination
 func (h *InventoryHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	// The encoding depends on Accept, so caches must key on it
+	w.Header().Add("Vary", "Accept")
+	if prefersMediaType(r.Header.Get("Accept"), NDJSONContentType) {
+		h.streamProducts(w, r)
+		return
+	}
+
 	ctx := r.Context()
 
 	// Parse query parameters
@@ -326,25 +819,57 @@ func (h *InventoryHandler) ListProducts(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
-	slog.Debug("Listing products with pagination",
+	slog.DebugContext(r.Context(), "Listing products with pagination",
 		"offset", offset,
 		"limit", limit,
 		"remote_addr", r.RemoteAddr)
 
-	// Get all products from inventory service using existing method
-	// We'll get all products and then apply our own pagination for deterministic results
-	productList, err := h.inventoryService.ListProducts("", 0) // Get all products (limit 0 = no limit)
-	if err != nil {
-		slog.Error("Failed to get products from inventory service", "error", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+	var allProducts []models.ProductResponse
+	var deletedProductIDs []string
+	asOf := ""
+	if updatedSinceStr := r.URL.Query().Get("updatedSince"); updatedSinceStr != "" {
+		// Delta query: only products changed at or after updatedSince, oldest change first
+		updatedSince, err := time.Parse(time.RFC3339, updatedSinceStr)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid updatedSince", []models.ErrorDetail{
+				{Field: "updatedSince", Issue: "must be an RFC3339 timestamp"},
+			})
+			return
+		}
+
+		// asOf is taken with the read and rounded down to the second, and
+		// updatedSince is inclusive, so passing asOf back as the next
+		// updatedSince never misses a change
+		var readAt time.Time
+		allProducts, deletedProductIDs, readAt, err = h.inventoryService.ProductsUpdatedSince(updatedSince)
+		if errors.Is(err, services.ErrUpdatedSinceTooOld) {
+			writeErrorResponse(w, http.StatusGone, "updated_since_gone", "Deletions since updatedSince are no longer kept, list the full catalog", []models.ErrorDetail{
+				{Field: "updatedSince", Issue: "must be within the deletion retention window"},
+			})
+			return
+		}
+		asOf = clock.Format(readAt)
+	} else {
+		// Get all products from inventory service using existing method
+		// We'll get all products and then apply our own pagination for deterministic results
+		productList, err := h.inventoryService.ListProducts("", 0) // Get all products (limit 0 = no limit)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to get products from inventory service", "error", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// Convert to slice and sort by product_id for deterministic pagination
+		allProducts = productList.Items
+		sort.Slice(allProducts, func(i, j int) bool {
+			return allProducts[i].ProductID < allProducts[j].ProductID
+		})
 	}
 
-	// Convert to slice and sort by product_id for deterministic pagination
-	allProducts := productList.Items
-	sort.Slice(allProducts, func(i, j int) bool {
-		return allProducts[i].ProductID < allProducts[j].ProductID
-	})
+	// A category covers the products of its subcategories
+	if category := r.URL.Query().Get("category"); category != "" {
+		allProducts = filterByCategories(allProducts, h.inventoryService.CategoriesWithDescendants(splitList(category)))
+	}
 
 	// Calculate total count
 	totalCount := len(allProducts)
@@ -375,17 +900,37 @@ func (h *InventoryHandler) ListProducts(w http.ResponseWriter, r *http.Request)
 			"has_more":    offset+limit < totalCount,
 		},
 	}
+	if asOf != "" {
+		response["deletedProductIds"] = deletedProductIDs
+		response["asOf"] = asOf
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		slog.Error("Failed to encode response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to encode response", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	slog.Debug("Successfully returned products",
+	slog.DebugContext(r.Context(), "Successfully returned products",
 		"returned_count", len(paginatedProducts),
 		"total_count", totalCount,
 		"offset", offset,
 		"limit", limit)
 }
+
+// filterByCategories keeps the products in one of categories, compared
+// case-insensitively
+func filterByCategories(products []models.ProductResponse, categories []string) []models.ProductResponse {
+	wanted := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		wanted[category] = true
+	}
+	filtered := make([]models.ProductResponse, 0, len(products))
+	for _, product := range products {
+		if wanted[strings.ToLower(strings.TrimSpace(product.Category))] {
+			filtered = append(filtered, product)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/models"
+)
+
+// NDJSONContentType is the media type of streamed product listings, one
+// product per line
+const NDJSONContentType = "application/x-ndjson"
+
+// Streamed listing headers. ListTotalCountHeader is sent before the body;
+// ListProductCountHeader is a trailer, so a stream cut short arrives without it.
+const (
+	ListTotalCountHeader   = "X-Total-Count"
+	ListProductCountHeader = "X-Product-Count"
+)
+
+// ndjsonChunkSize is how many products are read and written between flushes
+const ndjsonChunkSize = 200
+
+// streamProducts answers GET /v1/inventory with one product per line, in
+// product ID order, for clients that Accept application/x-ndjson. Only the
+// product IDs are copied up front; products are read a chunk at a time and
+// each chunk is flushed before the next is read, so a slow reader slows the
+// stream instead of growing server memory. offset applies as in the JSON
+// listing; limit is optional and not capped, and without it the stream runs
+// to the last product. Products deleted while streaming are skipped.
+func (h *InventoryHandler) streamProducts(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("updatedSince") != "" {
+		writeErrorResponse(w, http.StatusBadRequest, "bad_request", "updatedSince is not supported with "+NDJSONContentType, []models.ErrorDetail{
+			{Field: "updatedSince", Issue: "request application/json for delta listings"},
+		})
+		return
+	}
+
+	offset, limit := 0, 0
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && parsed >= 0 {
+		offset = parsed
+	}
+	if parsed, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+
+	var ids []string
+	if category := r.URL.Query().Get("category"); category != "" {
+		ids = h.inventoryService.ProductIDsInCategories(h.inventoryService.CategoriesWithDescendants(splitList(category)))
+	} else {
+		ids = h.inventoryService.ProductIDs()
+	}
+	totalCount := len(ids)
+	if offset > len(ids) {
+		offset = len(ids)
+	}
+	ids = ids[offset:]
+	if limit > 0 && limit < len(ids) {
+		ids = ids[:limit]
+	}
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.Header().Set(ListTotalCountHeader, strconv.Itoa(totalCount))
+	w.Header().Set("Trailer", ListProductCountHeader)
+	w.WriteHeader(http.StatusOK)
+
+	ctx := r.Context()
+	// The controller reaches the connection through the middleware's writers
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	streamed := 0
+	for start := 0; start < len(ids); start += ndjsonChunkSize {
+		if err := ctx.Err(); err != nil {
+			slog.DebugContext(ctx, "Product stream abandoned by client", "streamed", streamed, "error", err)
+			return
+		}
+
+		end := min(start+ndjsonChunkSize, len(ids))
+		products, _ := h.inventoryService.GetProducts(ids[start:end])
+		for _, product := range products {
+			if err := encoder.Encode(product); err != nil {
+				// Headers are out; the missing trailer tells the client the stream is short
+				slog.WarnContext(ctx, "Failed to stream products", "streamed", streamed, "error", err)
+				return
+			}
+			streamed++
+		}
+		controller.Flush()
+	}
+	w.Header().Set(ListProductCountHeader, strconv.Itoa(streamed))
+
+	slog.DebugContext(ctx, "Products streamed",
+		"streamed", streamed,
+		"total_count", totalCount,
+		"offset", offset)
+}
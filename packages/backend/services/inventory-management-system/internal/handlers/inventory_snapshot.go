@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/telemetry"
+)
+
+// Catalog snapshot response headers, sent before the body starts streaming
+const (
+	SnapshotOffsetHeader       = "X-Snapshot-Offset"
+	SnapshotProductCountHeader = "X-Snapshot-Product-Count"
+)
+
+// snapshotFlushEvery is how many products are written between flushes
+const snapshotFlushEvery = 500
+
+// GetCatalogSnapshot handles GET /v1/inventory/snapshot. It streams the whole
+// catalog, copied at a single point in time, as
+// {"eventOffset": N, "productCount": M, "products": [...]}. Stores load it and
+// poll events from eventOffset. The body is chunked, so a response cut short
+// is detected by counting products against productCount.
+func (h *InventoryHandler) GetCatalogSnapshot(w http.ResponseWriter, r *http.Request) {
+	if h.inventoryService.IsRestoring() {
+		writeRestoringError(w)
+		return
+	}
+
+	products, eventOffset := h.inventoryService.CatalogSnapshot()
+	writeCatalogSnapshot(w, r, products, eventOffset)
+}
+
+// writeCatalogSnapshot streams products, sorted by ID, as a catalog snapshot
+// taken at eventOffset
+func writeCatalogSnapshot(w http.ResponseWriter, r *http.Request, products []models.ProductResponse, eventOffset int64) {
+	ctx := telemetry.SetProductCount(r.Context(), len(products))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(SnapshotOffsetHeader, strconv.FormatInt(eventOffset, 10))
+	w.Header().Set(SnapshotProductCountHeader, strconv.Itoa(len(products)))
+	w.WriteHeader(http.StatusOK)
+
+	// The controller reaches the connection through the middleware's writers
+	controller := http.NewResponseController(w)
+	encoder := json.NewEncoder(w)
+	write := func(s string) error {
+		_, err := w.Write([]byte(s))
+		return err
+	}
+
+	err := write(`{"eventOffset":` + strconv.FormatInt(eventOffset, 10) +
+		`,"productCount":` + strconv.Itoa(len(products)) + `,"products":[`)
+	for i := 0; err == nil && i < len(products); i++ {
+		if i > 0 {
+			err = write(",")
+		}
+		if err == nil {
+			err = encoder.Encode(products[i])
+		}
+		if (i+1)%snapshotFlushEvery == 0 {
+			controller.Flush()
+		}
+	}
+	if err == nil {
+		err = write("]}\n")
+	}
+	if err != nil {
+		// Headers are out, so the client sees a short body and retries
+		slog.WarnContext(ctx, "Failed to stream catalog snapshot", "event_offset", eventOffset, "error", err)
+		return
+	}
+
+	slog.DebugContext(ctx, "Catalog snapshot streamed",
+		"product_count", len(products),
+		"event_offset", eventOffset)
+}
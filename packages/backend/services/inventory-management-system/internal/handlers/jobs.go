@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// jobQueueRetryAfter is the Retry-After value, in seconds, sent when the job
+// queue is full
+const jobQueueRetryAfter = "30"
+
+// JobsHandler starts, lists and cancels background admin jobs of every
+// registered type
+type JobsHandler struct {
+	manager *jobs.Manager
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(manager *jobs.Manager) *JobsHandler {
+	return &JobsHandler{manager: manager}
+}
+
+// StartJob handles POST /v1/admin/jobs - queues a job of a registered type
+func (h *JobsHandler) StartJob(w http.ResponseWriter, r *http.Request) {
+	var req models.JobStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	job, err := h.manager.Start(req.Type, req.Params)
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Job started by admin",
+		"job_id", job.ID,
+		"type", job.Type,
+		"actor", adminActor(r))
+	writeJobAccepted(w, r, job)
+}
+
+// ListJobs handles GET /v1/admin/jobs?type=&status= - jobs newest first,
+// without their per-item results
+func (h *JobsHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	list := h.manager.List(r.URL.Query().Get("type"), r.URL.Query().Get("status"))
+	writeJSONResponse(w, http.StatusOK, models.JobsResponse{
+		Jobs:  list,
+		Count: len(list),
+		Types: h.manager.Types(),
+	})
+}
+
+// GetJob handles GET /v1/admin/jobs/{id} - the job's progress and the results
+// of the items processed so far
+func (h *JobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.manager.Get(mux.Vars(r)["id"])
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Job not found", nil)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, job)
+}
+
+// CancelJob handles POST /v1/admin/jobs/{id}/cancel. A running job stops at
+// its next checkpoint, so the response may still show it running.
+func (h *JobsHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.manager.Cancel(mux.Vars(r)["id"])
+	if err != nil {
+		writeJobError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Job cancelled by admin",
+		"job_id", job.ID,
+		"type", job.Type,
+		"actor", adminActor(r))
+	writeJSONResponse(w, http.StatusAccepted, job)
+}
+
+// writeJobAccepted answers 202 with a queued job and where to poll it, under
+// the API version the job was started through
+func writeJobAccepted(w http.ResponseWriter, r *http.Request, job models.Job) {
+	w.Header().Set("Location", apiversion.Path(apiversion.FromContext(r.Context()), "/v1/admin/jobs/"+job.ID))
+	writeJSONResponse(w, http.StatusAccepted, job)
+}
+
+// writeJobError maps a job manager error to its response
+func writeJobError(w http.ResponseWriter, r *http.Request, err error) {
+	var paramsErr *jobs.ParamsError
+	switch {
+	case errors.As(err, &paramsErr):
+		writeErrorResponse(w, http.StatusBadRequest, paramsErr.Code, paramsErr.Message, paramsErr.Details)
+	case errors.Is(err, jobs.ErrUnknownType):
+		writeErrorResponse(w, http.StatusBadRequest, "unknown_job_type", "Unknown job type", []models.ErrorDetail{
+			{Field: "type", Issue: err.Error()},
+		})
+	case errors.Is(err, jobs.ErrNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Job not found", nil)
+	case errors.Is(err, jobs.ErrFinished):
+		writeErrorResponse(w, http.StatusConflict, "job_finished", "Job already finished", nil)
+	case errors.Is(err, jobs.ErrQueueFull):
+		w.Header().Set("Retry-After", jobQueueRetryAfter)
+		writeErrorResponse(w, http.StatusServiceUnavailable, "job_queue_full", "Too many background jobs, retry later", nil)
+	case errors.Is(err, jobs.ErrStopped):
+		writeErrorResponse(w, http.StatusServiceUnavailable, "jobs_disabled", "Background jobs are shutting down", nil)
+	case errors.Is(err, services.ErrServiceRestoring):
+		writeRestoringError(w)
+	default:
+		slog.ErrorContext(r.Context(), "Failed to start job", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to start job", nil)
+	}
+}
@@ -0,0 +1,9 @@
+package handlers
+
+import "net/http"
+
+// GetLockStats handles GET /v1/admin/locks - product locks held or awaited
+// right now, to find stuck or contended products
+func (h *AdminHandler) GetLockStats(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, h.inventoryService.GetLockStats())
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/validation"
+)
+
+// MaintenanceHandler lets admins put the central API in read-only maintenance
+// mode, for migrations and restores
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(maintenance *middleware.Maintenance) *MaintenanceHandler {
+	return &MaintenanceHandler{maintenance: maintenance}
+}
+
+// GetMaintenance handles GET /v1/admin/maintenance
+func (h *MaintenanceHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, h.maintenance.Status())
+}
+
+// SetMaintenance handles PUT /v1/admin/maintenance - turns read-only
+// maintenance mode on or off
+func (h *MaintenanceHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req models.MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	status := h.maintenance.Set(*req.Enabled, strings.TrimSpace(req.Message), req.RetryAfterSeconds, adminActor(r))
+
+	slog.WarnContext(r.Context(), "Maintenance mode changed",
+		"audit", true,
+		"enabled", status.Enabled,
+		"message", status.Message,
+		"retry_after_seconds", status.RetryAfterSeconds,
+		"actor", status.ChangedBy)
+	writeJSONResponse(w, http.StatusOK, status)
+}
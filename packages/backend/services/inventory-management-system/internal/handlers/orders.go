@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/orders"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// OrdersHandler reserves stock for orders and commits or releases it
+type OrdersHandler struct {
+	inventoryService *services.InventoryService
+}
+
+// NewOrdersHandler creates a new orders handler
+func NewOrdersHandler(inventoryService *services.InventoryService) *OrdersHandler {
+	return &OrdersHandler{inventoryService: inventoryService}
+}
+
+// CreateOrder handles POST /v1/orders - reserve every line of an order at once
+func (h *OrdersHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req models.OrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in order request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	order, err := h.inventoryService.CreateOrder(r.Context(), req)
+	if err != nil {
+		var orderErr *services.OrderError
+		if errors.As(err, &orderErr) {
+			if orderErr.Replayed {
+				w.Header().Set(IdempotencyReplayedHeader, "true")
+			}
+			if orderErr.Type == services.ErrTypeStocktakeHold {
+				w.Header().Set("Retry-After", stocktakeRetryAfter)
+			}
+			var details []models.ErrorDetail
+			if orderErr.ProductID != "" {
+				details = append(details, models.ErrorDetail{Field: "productId", Issue: orderErr.ProductID})
+			}
+			if orderErr.Type == services.ErrTypeInsufficientInventory {
+				details = append(details,
+					models.ErrorDetail{Field: "version", Issue: fmt.Sprintf("current version is %d", orderErr.CurrentVersion)},
+					models.ErrorDetail{Field: "quantity", Issue: fmt.Sprintf("%d units available", orderErr.Available)})
+			}
+			writeErrorResponse(w, statusForUpdateError(orderErr.Type), orderErr.Type, orderErr.Message, details)
+			return
+		}
+		h.writeOrderError(w, r, err)
+		return
+	}
+
+	if order.Replayed {
+		w.Header().Set(IdempotencyReplayedHeader, "true")
+	}
+	writeJSONResponse(w, http.StatusCreated, order)
+}
+
+// GetOrder handles GET /v1/orders/{id}
+func (h *OrdersHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.inventoryService.GetOrder(mux.Vars(r)["id"])
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Order not found", nil)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, order)
+}
+
+// CommitOrder handles POST /v1/orders/{id}/commit - the reserved units are sold
+func (h *OrdersHandler) CommitOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.inventoryService.CommitOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.writeOrderError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, order)
+}
+
+// CancelOrder handles POST /v1/orders/{id}/cancel - the reserved units return to stock
+func (h *OrdersHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	order, err := h.inventoryService.CancelOrder(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		h.writeOrderError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, order)
+}
+
+// writeOrderError maps an order service error to its response
+func (h *OrdersHandler) writeOrderError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, orders.ErrNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Order not found", nil)
+	case errors.Is(err, services.ErrOrderFinished):
+		writeErrorResponse(w, http.StatusConflict, "order_finished", "Order was already committed or cancelled", nil)
+	case errors.Is(err, services.ErrServiceRestoring):
+		writeRestoringError(w)
+	case errors.Is(err, services.ErrServiceDraining):
+		w.Header().Set("Retry-After", "5")
+		writeErrorResponse(w, http.StatusServiceUnavailable, services.ErrTypeServiceUnavailable, "Service is shutting down, retry shortly", nil)
+	default:
+		slog.ErrorContext(r.Context(), "Order request failed", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, services.ErrTypeInternalError, "Order request failed", nil)
+	}
+}
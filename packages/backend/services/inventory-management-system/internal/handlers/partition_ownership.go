@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
+)
+
+// misroutedProducts returns the IDs among productIDs that partition does not
+// own; none when the instance is not a partition
+func misroutedProducts(partition *partitions.Partition, productIDs []string) []string {
+	if partition == nil {
+		return nil
+	}
+	var misrouted []string
+	for _, productID := range productIDs {
+		if !partition.Owns(productID) {
+			misrouted = append(misrouted, productID)
+		}
+	}
+	return misrouted
+}
+
+// writeWrongPartition answers 421 wrong_partition, listing the products
+// another partition owns
+func writeWrongPartition(w http.ResponseWriter, partition *partitions.Partition, misrouted []string) {
+	details := make([]models.ErrorDetail, len(misrouted))
+	for i, productID := range misrouted {
+		details[i] = models.ErrorDetail{Field: "productId", Issue: productID + " is owned by another partition"}
+	}
+	writeErrorResponse(w, http.StatusMisdirectedRequest, "wrong_partition", "Partition "+partition.ID+" does not own these products, send them through the partition router", details)
+}
@@ -0,0 +1,482 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// PartitionHeader names the partition that served a request the router
+// forwarded whole
+const PartitionHeader = "X-Partition"
+
+// PartitionRouterHandler serves the routes of a partition router. Requests for
+// one product go to the partition owning it, batch requests are split by
+// partition and their results merged in request order, and the events routes
+// serve the stream the router merged from every partition.
+type PartitionRouterHandler struct {
+	partitions    *partitions.Map
+	eventsHandler *EventsHandler
+	eventQueue    *events.EventQueue // The merged stream
+	client        *http.Client
+	proxies       map[string]*httputil.ReverseProxy // By partition ID
+	maxBatchItems int
+}
+
+// NewPartitionRouterHandler creates a router over partitionMap; timeout bounds
+// each request to a partition
+func NewPartitionRouterHandler(partitionMap *partitions.Map, eventsHandler *EventsHandler, eventQueue *events.EventQueue, timeout time.Duration) *PartitionRouterHandler {
+	h := &PartitionRouterHandler{
+		partitions:    partitionMap,
+		eventsHandler: eventsHandler,
+		eventQueue:    eventQueue,
+		client:        &http.Client{Timeout: timeout},
+		proxies:       make(map[string]*httputil.ReverseProxy),
+		maxBatchItems: 100,
+	}
+	for _, partition := range partitionMap.Partitions() {
+		h.proxies[partition.ID] = newPartitionProxy(partition)
+	}
+	return h
+}
+
+// SetMaxBatchItems sets the maximum number of items accepted in one batch
+func (h *PartitionRouterHandler) SetMaxBatchItems(maxItems int) {
+	h.maxBatchItems = maxItems
+}
+
+// newPartitionProxy forwards whole requests to partition
+func newPartitionProxy(partition partitions.Partition) *httputil.ReverseProxy {
+	// ParseMap validated the URL
+	target, _ := url.Parse(partition.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		resp.Header.Set(PartitionHeader, partition.ID)
+		return nil
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		slog.WarnContext(r.Context(), "Failed to forward request to partition",
+			"partition", partition.ID,
+			"url", partition.URL,
+			"error", err)
+		writePartitionUnavailable(w, partition.ID)
+	}
+	return proxy
+}
+
+// writePartitionUnavailable answers 503 partition_unavailable
+func writePartitionUnavailable(w http.ResponseWriter, partitionID string) {
+	writeErrorResponse(w, http.StatusServiceUnavailable, "partition_unavailable", "A partition is unreachable, retry later", []models.ErrorDetail{
+		{Field: "partition", Issue: partitionID},
+	})
+}
+
+// ProxyProduct handles the GET routes of one product, such as
+// GET /v1/inventory/{productId}, on the partition owning it
+func (h *PartitionRouterHandler) ProxyProduct(w http.ResponseWriter, r *http.Request) {
+	partition := h.partitions.For(mux.Vars(r)["productId"])
+	h.proxies[partition.ID].ServeHTTP(w, r)
+}
+
+// UpdateInventory handles POST /v1/inventory/updates. A single update, or a
+// batch owned by one partition, is forwarded whole; any other batch is split
+// and the results come back in request order.
+func (h *PartitionRouterHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+	var req models.UpdateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		slog.WarnContext(ctx, "Invalid JSON in update request", "error", err, "remote_addr", r.RemoteAddr)
+		writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON", nil)
+		return
+	}
+
+	if len(req.Updates) > h.maxBatchItems {
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many updates in batch", []models.ErrorDetail{
+			{
+				Field: "updates",
+				Issue: fmt.Sprintf("Batch contains %d updates, maximum is %d", len(req.Updates), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	productIDs := []string{req.ProductID}
+	if len(req.Updates) > 0 {
+		productIDs = make([]string, len(req.Updates))
+		for i, update := range req.Updates {
+			productIDs[i] = update.ProductID
+		}
+	}
+	groups := h.partitions.Group(productIDs)
+	if len(groups) == 1 {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.proxies[groups[0].Partition.ID].ServeHTTP(w, r)
+		return
+	}
+
+	results := make([]models.ProductUpdateResult, len(req.Updates))
+	h.fanOut(groups, func(group partitions.Group) {
+		part := req
+		part.Updates = make([]models.ProductUpdate, len(group.Indexes))
+		for i, index := range group.Indexes {
+			part.Updates[i] = req.Updates[index]
+		}
+
+		var response models.UpdateResponse
+		err := h.call(r, group.Partition, http.MethodPost, r.URL.Path, part, &response)
+		if err == nil && len(response.Results) != len(group.Indexes) {
+			err = fmt.Errorf("partition returned %d results for %d updates", len(response.Results), len(group.Indexes))
+		}
+		for i, index := range group.Indexes {
+			if err != nil {
+				results[index] = failedUpdateResult(req.Updates[index].ProductID, err)
+				continue
+			}
+			results[index] = response.Results[i]
+		}
+	})
+
+	summary := &models.BatchSummary{Total: len(results)}
+	for _, result := range results {
+		if result.ErrorType == "" {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+
+	slog.InfoContext(ctx, "Partitioned batch update completed",
+		"partitions", len(groups),
+		"total", summary.Total,
+		"succeeded", summary.Succeeded,
+		"failed", summary.Failed)
+
+	if hasBackpressureResult(results) {
+		w.Header().Set("Retry-After", queueSaturatedRetryAfter)
+	}
+	if allReplayed(results) {
+		w.Header().Set(IdempotencyReplayedHeader, "true")
+	}
+	writeJSONResponse(w, http.StatusOK, models.UpdateResponse{Results: results, Summary: summary})
+}
+
+// failedUpdateResult reports a batch item whose partition did not answer
+// with results
+func failedUpdateResult(productID string, err error) models.ProductUpdateResult {
+	result := models.ProductUpdateResult{
+		ProductID:    productID,
+		ErrorType:    services.ErrTypeServiceUnavailable,
+		ErrorMessage: err.Error(),
+	}
+	if partitionErr, ok := err.(*partitionError); ok && partitionErr.code != "" {
+		result.ErrorType = partitionErr.code
+	}
+	return result
+}
+
+// BatchGetProducts handles POST /v1/inventory/batch-get across partitions
+func (h *PartitionRouterHandler) BatchGetProducts(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+	if len(req.ProductIDs) > h.maxBatchItems {
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many product IDs in batch", []models.ErrorDetail{
+			{
+				Field: "productIds",
+				Issue: fmt.Sprintf("Batch contains %d product IDs, maximum is %d", len(req.ProductIDs), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	found := make(map[string]models.ProductResponse)
+	var mu sync.Mutex
+	failed := h.fanOutReads(h.partitions.Group(req.ProductIDs), func(group partitions.Group) error {
+		part := models.BatchGetRequest{ProductIDs: make([]string, len(group.Indexes))}
+		for i, index := range group.Indexes {
+			part.ProductIDs[i] = req.ProductIDs[index]
+		}
+		var response models.BatchGetResponse
+		if err := h.call(r, group.Partition, http.MethodPost, r.URL.Path, part, &response); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, product := range response.Products {
+			found[product.ProductID] = product
+		}
+		return nil
+	})
+	if failed != "" {
+		writePartitionUnavailable(w, failed)
+		return
+	}
+
+	response := models.BatchGetResponse{Products: []models.ProductResponse{}, Missing: []string{}}
+	for _, productID := range uniqueIDs(req.ProductIDs) {
+		if product, ok := found[productID]; ok {
+			response.Products = append(response.Products, product)
+		} else {
+			response.Missing = append(response.Missing, productID)
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// GetProductVersions handles GET /v1/inventory/versions?ids=... across partitions
+func (h *PartitionRouterHandler) GetProductVersions(w http.ResponseWriter, r *http.Request) {
+	var productIDs []string
+	for _, value := range r.URL.Query()["ids"] {
+		for _, productID := range strings.Split(value, ",") {
+			if productID = strings.TrimSpace(productID); productID != "" {
+				productIDs = append(productIDs, productID)
+			}
+		}
+	}
+	productIDs = uniqueIDs(productIDs)
+
+	if len(productIDs) == 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "ids", Issue: "at least one product ID is required"},
+		})
+		return
+	}
+	if len(productIDs) > h.maxBatchItems {
+		writeErrorResponse(w, http.StatusBadRequest, "batch_too_large", "Too many product IDs in batch", []models.ErrorDetail{
+			{
+				Field: "ids",
+				Issue: fmt.Sprintf("Batch contains %d product IDs, maximum is %d", len(productIDs), h.maxBatchItems),
+			},
+		})
+		return
+	}
+
+	versions := make(map[string]models.ProductVersion)
+	var mu sync.Mutex
+	failed := h.fanOutReads(h.partitions.Group(productIDs), func(group partitions.Group) error {
+		ids := make([]string, len(group.Indexes))
+		for i, index := range group.Indexes {
+			ids[i] = productIDs[index]
+		}
+		var response models.VersionsResponse
+		path := r.URL.Path + "?ids=" + url.QueryEscape(strings.Join(ids, ","))
+		if err := h.call(r, group.Partition, http.MethodGet, path, nil, &response); err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for productID, version := range response.Versions {
+			versions[productID] = version
+		}
+		return nil
+	})
+	if failed != "" {
+		writePartitionUnavailable(w, failed)
+		return
+	}
+
+	response := models.VersionsResponse{Versions: versions, Missing: []string{}}
+	for _, productID := range productIDs {
+		if _, ok := versions[productID]; !ok {
+			response.Missing = append(response.Missing, productID)
+		}
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// partitionSnapshot is the catalog snapshot a partition streams
+type partitionSnapshot struct {
+	EventOffset  int64                    `json:"eventOffset"`
+	ProductCount int                      `json:"productCount"`
+	Products     []models.ProductResponse `json:"products"`
+}
+
+// GetCatalogSnapshot handles GET /v1/inventory/snapshot: the partitions'
+// snapshots merged, at an offset of the merged stream read before any of them
+// was taken. Every event a partition snapshot misses was merged after that
+// offset, so stores polling from it miss nothing.
+func (h *PartitionRouterHandler) GetCatalogSnapshot(w http.ResponseWriter, r *http.Request) {
+	eventOffset := h.eventQueue.GetCurrentOffset()
+
+	var products []models.ProductResponse
+	var mu sync.Mutex
+	failed := h.fanOutReads(groupsOfAll(h.partitions), func(group partitions.Group) error {
+		var snapshot partitionSnapshot
+		if err := h.call(r, group.Partition, http.MethodGet, r.URL.Path, nil, &snapshot); err != nil {
+			return err
+		}
+		if len(snapshot.Products) != snapshot.ProductCount {
+			return fmt.Errorf("snapshot cut short at %d of %d products", len(snapshot.Products), snapshot.ProductCount)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		products = append(products, snapshot.Products...)
+		return nil
+	})
+	if failed != "" {
+		writePartitionUnavailable(w, failed)
+		return
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].ProductID < products[j].ProductID })
+	writeCatalogSnapshot(w, r, products, eventOffset)
+}
+
+// GetEvents handles GET /v1/inventory/events: the merged stream, or with
+// ?partition=<id> that partition's own stream, with its own offsets
+func (h *PartitionRouterHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	partitionID := r.URL.Query().Get("partition")
+	if partitionID == "" {
+		h.eventsHandler.GetEvents(w, r)
+		return
+	}
+
+	if _, ok := h.partitions.Get(partitionID); !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "partition", Issue: "unknown partition " + partitionID},
+		})
+		return
+	}
+	h.proxies[partitionID].ServeHTTP(w, r)
+}
+
+// partitionError is a partition's answer other than 200
+type partitionError struct {
+	partitionID string
+	status      int
+	code        string
+	message     string
+}
+
+func (e *partitionError) Error() string {
+	if e.message != "" {
+		return fmt.Sprintf("partition %s answered %d: %s", e.partitionID, e.status, e.message)
+	}
+	return fmt.Sprintf("partition %s answered %d", e.partitionID, e.status)
+}
+
+// call sends body, when not nil, to path on partition with the caller's
+// headers and decodes a 200 answer into out. Other answers return a
+// partitionError carrying the partition's error code.
+func (h *PartitionRouterHandler) call(r *http.Request, partition partitions.Partition, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), method, partition.URL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header = r.Header.Clone()
+	// The client negotiates its own encoding and length with the partition
+	req.Header.Del("Accept-Encoding")
+	req.Header.Del("Content-Length")
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errorResponse models.ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&errorResponse)
+		return &partitionError{
+			partitionID: partition.ID,
+			status:      resp.StatusCode,
+			code:        errorResponse.Code,
+			message:     errorResponse.Message,
+		}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// fanOut runs send for every group concurrently
+func (h *PartitionRouterHandler) fanOut(groups []partitions.Group, send func(partitions.Group)) {
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(group)
+		}()
+	}
+	wg.Wait()
+}
+
+// fanOutReads runs read for every group concurrently and returns the ID of a
+// partition that failed, or "" when every read succeeded. A read is whole or
+// not at all, so one failed partition fails the request.
+func (h *PartitionRouterHandler) fanOutReads(groups []partitions.Group, read func(partitions.Group) error) string {
+	var mu sync.Mutex
+	failed := ""
+	h.fanOut(groups, func(group partitions.Group) {
+		if err := read(group); err != nil {
+			slog.Warn("Failed to read from partition", "partition", group.Partition.ID, "error", err)
+			mu.Lock()
+			failed = group.Partition.ID
+			mu.Unlock()
+		}
+	})
+	return failed
+}
+
+// groupsOfAll returns one group per partition, for requests every partition answers
+func groupsOfAll(partitionMap *partitions.Map) []partitions.Group {
+	var groups []partitions.Group
+	for _, partition := range partitionMap.Partitions() {
+		groups = append(groups, partitions.Group{Partition: partition})
+	}
+	return groups
+}
+
+// uniqueIDs drops repeated IDs, keeping the first occurrence of each
+func uniqueIDs(productIDs []string) []string {
+	seen := make(map[string]bool, len(productIDs))
+	unique := make([]string, 0, len(productIDs))
+	for _, productID := range productIDs {
+		if !seen[productID] {
+			seen[productID] = true
+			unique = append(unique, productID)
+		}
+	}
+	return unique
+}
@@ -22,7 +22,7 @@ func NewRateLimitStatusHandler(rateLimiter *middleware.RateLimiter) *RateLimitSt
 
 // GetRateLimitStatus returns current rate limiting statistics
 func (h *RateLimitStatusHandler) GetRateLimitStatus(w http.ResponseWriter, r *http.Request) {
-	slog.Debug("Getting rate limit status", "remote_addr", r.RemoteAddr)
+	slog.DebugContext(r.Context(), "Getting rate limit status", "remote_addr", r.RemoteAddr)
 
 	if h.rateLimiter == nil {
 		writeErrorResponse(w, http.StatusServiceUnavailable, "rate_limiter_unavailable", "Rate limiter not available", nil)
@@ -35,17 +35,17 @@ func (h *RateLimitStatusHandler) GetRateLimitStatus(w http.ResponseWriter, r *ht
 	w.WriteHeader(http.StatusOK)
 	
 	if err := json.NewEncoder(w).Encode(stats); err != nil {
-		slog.Error("Failed to encode rate limit status response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to encode rate limit status response", "error", err)
 		writeErrorResponse(w, http.StatusInternalServerError, "encoding_error", "Failed to encode response", nil)
 		return
 	}
 
-	slog.Debug("Rate limit status retrieved successfully", "active_ip_limits", stats["active_ip_limits"])
+	slog.DebugContext(r.Context(), "Rate limit status retrieved successfully", "active_ip_limits", stats["active_ip_limits"])
 }
 
 // ResetRateLimits resets all rate limiting counters (admin only)
 func (h *RateLimitStatusHandler) ResetRateLimits(w http.ResponseWriter, r *http.Request) {
-	slog.Info("Resetting rate limits", "remote_addr", r.RemoteAddr)
+	slog.InfoContext(r.Context(), "Resetting rate limits", "remote_addr", r.RemoteAddr)
 
 	if h.rateLimiter == nil {
 		writeErrorResponse(w, http.StatusServiceUnavailable, "rate_limiter_unavailable", "Rate limiter not available", nil)
@@ -63,10 +63,10 @@ func (h *RateLimitStatusHandler) ResetRateLimits(w http.ResponseWriter, r *http.
 	w.WriteHeader(http.StatusOK)
 	
 	if err := json.NewEncoder(w).Encode(response); err != nil {
-		slog.Error("Failed to encode rate limit reset response", "error", err)
+		slog.ErrorContext(r.Context(), "Failed to encode rate limit reset response", "error", err)
 		writeErrorResponse(w, http.StatusInternalServerError, "encoding_error", "Failed to encode response", nil)
 		return
 	}
 
-	slog.Info("Rate limits reset successfully")
+	slog.InfoContext(r.Context(), "Rate limits reset successfully")
 }
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+)
+
+// csvContentType is the media type of CSV sales reports
+const csvContentType = "text/csv"
+
+// ReportsHandler serves the daily sales reports built from the event stream
+type ReportsHandler struct {
+	store *reports.Store
+}
+
+// NewReportsHandler creates a new reports handler
+func NewReportsHandler(store *reports.Store) *ReportsHandler {
+	return &ReportsHandler{store: store}
+}
+
+// GetSalesReport handles GET /v1/admin/reports/sales - units sold per product
+// and store on one UTC day (default today), as JSON or with format=csv (or
+// Accept: text/csv) as CSV
+func (h *ReportsHandler) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	date := clock.Now().Format(reports.DateLayout)
+	if value := query.Get("date"); value != "" {
+		parsed, err := reports.ParseDate(value)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid date", []models.ErrorDetail{
+				{Field: "date", Issue: err.Error()},
+			})
+			return
+		}
+		date = parsed
+	}
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = "json"
+		if strings.Contains(r.Header.Get("Accept"), csvContentType) {
+			format = "csv"
+		}
+	case "json", "csv":
+	default:
+		writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid format", []models.ErrorDetail{
+			{Field: "format", Issue: "must be json or csv"},
+		})
+		return
+	}
+
+	report := h.store.Report(date)
+	report.GeneratedAt = clock.Timestamp()
+
+	if format == "json" {
+		writeJSONResponse(w, http.StatusOK, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", csvContentType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="sales-`+date+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"date", "product_id", "store_id", "product_name", "category", "units_sold", "transactions"})
+	for _, row := range report.Rows {
+		writer.Write([]string{
+			row.Date,
+			row.ProductID,
+			row.StoreID,
+			row.ProductName,
+			row.Category,
+			strconv.Itoa(row.UnitsSold),
+			strconv.Itoa(row.Transactions),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		slog.WarnContext(r.Context(), "Failed to write sales report CSV", "date", date, "error", err)
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/validation"
+)
+
+// requestModels are the request bodies handlers validate; add new ones here
+// so their validate tags are checked at startup
+var requestModels = []interface{}{
+	models.UpdateRequest{},
+	models.ProductUpdate{},
+	models.BatchGetRequest{},
+	models.OrderRequest{},
+	models.TransferRequest{},
+	models.StocktakeStartRequest{},
+	models.StocktakeCountsRequest{},
+	models.AdminSetRequest{},
+	models.AdminCreateRequest{},
+	models.AdminDeleteRequest{},
+	models.ProductAssetsRequest{},
+	models.ProductAsset{},
+	models.CategoryRequest{},
+	models.EventFilterRequest{},
+	models.EventCommitRequest{},
+	models.EventCompactRequest{},
+	models.FeatureFlagUpdateRequest{},
+	models.RuntimeConfigPatchRequest{},
+	models.MaintenanceRequest{},
+	models.SnapshotCreateRequest{},
+	models.RestoreRequest{},
+	models.JobStartRequest{},
+}
+
+// CheckRequestModels reports an unknown rule or malformed parameter in the
+// validate tags of any request model, so the server refuses to start rather
+// than rejecting the requests that use it
+func CheckRequestModels() error {
+	return validation.CheckRules(requestModels...)
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/snapshots"
+	"inventory-management-api/internal/validation"
+)
+
+const (
+	// replayPageSize is the number of events read from the queue per page
+	replayPageSize = 1000
+	// replayEventWait bounds the wait for events whose offset was assigned but
+	// that the queue's async writer has not stored yet
+	replayEventWait = 2 * time.Second
+)
+
+// errEventsUnavailable is returned when events needed for a replay were
+// rotated or compacted away
+var errEventsUnavailable = errors.New("events needed for the replay are no longer available")
+
+// RestoreHandler rebuilds the inventory from a snapshot plus the event log
+type RestoreHandler struct {
+	inventoryService *services.InventoryService
+	store            *snapshots.Store
+	eventQueue       *events.EventQueue
+}
+
+// NewRestoreHandler creates a new restore handler
+func NewRestoreHandler(inventoryService *services.InventoryService, store *snapshots.Store, eventQueue *events.EventQueue) *RestoreHandler {
+	return &RestoreHandler{
+		inventoryService: inventoryService,
+		store:            store,
+		eventQueue:       eventQueue,
+	}
+}
+
+// SetJobs registers restores with manager as the restore job type, so a
+// restore can also run in the background through POST /v1/admin/jobs
+func (h *RestoreHandler) SetJobs(manager *jobs.Manager) {
+	manager.Register(models.JobTypeRestore, h.startRestoreJob)
+}
+
+// restorePlan is a validated restore: the snapshot to start from and the
+// offset to replay its events to
+type restorePlan struct {
+	info         models.SnapshotInfo
+	products     []models.ProductResponse
+	targetOffset int
+	reason       string
+}
+
+// targetOffsetError is returned by planRestore for a target offset outside
+// the snapshot offset and the current offset
+type targetOffsetError struct {
+	snapshotOffset int
+	currentOffset  int
+}
+
+func (e *targetOffsetError) Error() string {
+	return fmt.Sprintf("must be between the snapshot offset %d and the current offset %d", e.snapshotOffset, e.currentOffset)
+}
+
+// planRestore validates req and loads its snapshot. It fails with
+// snapshots.ErrNotFound, a *targetOffsetError or errEventsUnavailable when the
+// restore cannot run.
+func (h *RestoreHandler) planRestore(req models.RestoreRequest) (restorePlan, error) {
+	info, products, err := h.store.Get(req.SnapshotID)
+	if err != nil {
+		return restorePlan{}, err
+	}
+
+	targetOffset := info.LastOffset
+	if req.TargetOffset != nil {
+		targetOffset = *req.TargetOffset
+	}
+	currentOffset := int(h.eventQueue.GetCurrentOffset())
+	if targetOffset < info.LastOffset || targetOffset > currentOffset {
+		return restorePlan{}, &targetOffsetError{snapshotOffset: info.LastOffset, currentOffset: currentOffset}
+	}
+	if targetOffset > info.LastOffset && h.eventQueue.OldestAvailableOffset() > int64(info.LastOffset) {
+		return restorePlan{}, errEventsUnavailable
+	}
+	return restorePlan{info: info, products: products, targetOffset: targetOffset, reason: strings.TrimSpace(req.Reason)}, nil
+}
+
+// runRestore replays plan and swaps the inventory for the result. A cancelled
+// ctx stops the replay before anything is replaced.
+func (h *RestoreHandler) runRestore(ctx context.Context, plan restorePlan, actor string) (models.RestoreResponse, error) {
+	slog.WarnContext(ctx, "Restoring inventory from snapshot",
+		"snapshot_id", plan.info.ID,
+		"snapshot_offset", plan.info.LastOffset,
+		"target_offset", plan.targetOffset,
+		"actor", actor,
+		"reason", plan.reason)
+
+	var restored []models.ProductResponse
+	eventsReplayed := 0
+	restoreOffset, err := h.inventoryService.Restore(func() ([]models.ProductResponse, error) {
+		replay, err := h.collectEvents(int64(plan.info.LastOffset), int64(plan.targetOffset))
+		if err != nil {
+			return nil, err
+		}
+		restored, err = snapshots.Replay(plan.products, replay)
+		eventsReplayed = len(replay)
+		if err == nil {
+			err = ctx.Err()
+		}
+		return restored, err
+	})
+	if err != nil {
+		return models.RestoreResponse{}, err
+	}
+
+	response := models.RestoreResponse{
+		SnapshotID:     plan.info.ID,
+		TargetOffset:   plan.targetOffset,
+		EventsReplayed: eventsReplayed,
+		ProductCount:   len(restored),
+		RestoreOffset:  restoreOffset,
+		RestoredAt:     clock.Timestamp(),
+	}
+	for _, product := range restored {
+		response.TotalAvailable += product.Available
+	}
+	return response, nil
+}
+
+// Restore handles POST /v1/admin/restore - restores the inventory to the state
+// at targetOffset by replaying the events after a snapshot
+func (h *RestoreHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	var req models.RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in restore request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	plan, err := h.planRestore(req)
+	var offsetErr *targetOffsetError
+	switch {
+	case errors.Is(err, snapshots.ErrNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "snapshot_not_found", "Snapshot not found: "+req.SnapshotID, nil)
+		return
+	case errors.As(err, &offsetErr):
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", []models.ErrorDetail{
+			{Field: "targetOffset", Issue: offsetErr.Error()},
+		})
+		return
+	case errors.Is(err, errEventsUnavailable):
+		writeErrorResponse(w, http.StatusConflict, "events_unavailable", errEventsUnavailable.Error(), nil)
+		return
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to load inventory snapshot", "snapshot_id", req.SnapshotID, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to load snapshot", nil)
+		return
+	}
+
+	// A client that hangs up does not abort the restore
+	response, err := h.runRestore(context.WithoutCancel(r.Context()), plan, "admin "+middleware.RequestActor(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrServiceDraining):
+			writeErrorResponse(w, http.StatusServiceUnavailable, "service_draining", "Service is shutting down", nil)
+		case errors.Is(err, services.ErrRestoreInProgress):
+			writeErrorResponse(w, http.StatusConflict, "restore_in_progress", "Another restore is in progress", nil)
+		case errors.Is(err, errEventsUnavailable):
+			writeErrorResponse(w, http.StatusConflict, "events_unavailable", err.Error(), nil)
+		case errors.Is(err, snapshots.ErrRestoreBoundary):
+			writeErrorResponse(w, http.StatusConflict, "restore_boundary", "Cannot replay across an earlier restore: "+err.Error(), nil)
+		default:
+			slog.ErrorContext(r.Context(), "Failed to restore inventory", "snapshot_id", plan.info.ID, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to restore inventory", nil)
+		}
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, response)
+}
+
+// startRestoreJob is the restore StartFunc for POST /v1/admin/jobs; params
+// take the body of POST /v1/admin/restore. The job has a single item and
+// reports the RestoreResponse as its output. Cancelling it stops the replay
+// until the inventory is swapped.
+func (h *RestoreHandler) startRestoreJob(params json.RawMessage) (jobs.Work, error) {
+	var req models.RestoreRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		return jobs.Work{}, &jobs.ParamsError{Code: "invalid_request", Message: "params must be a restore request object"}
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		return jobs.Work{}, &jobs.ParamsError{Code: "validation_error", Message: "Request validation failed", Details: validationErrors}
+	}
+
+	plan, err := h.planRestore(req)
+	var offsetErr *targetOffsetError
+	switch {
+	case errors.Is(err, snapshots.ErrNotFound):
+		return jobs.Work{}, &jobs.ParamsError{Code: "snapshot_not_found", Message: "Snapshot not found: " + req.SnapshotID}
+	case errors.As(err, &offsetErr):
+		return jobs.Work{}, &jobs.ParamsError{Code: "validation_error", Message: "Request validation failed", Details: []models.ErrorDetail{
+			{Field: "targetOffset", Issue: offsetErr.Error()},
+		}}
+	case errors.Is(err, errEventsUnavailable):
+		return jobs.Work{}, &jobs.ParamsError{Code: "events_unavailable", Message: errEventsUnavailable.Error()}
+	case err != nil:
+		return jobs.Work{}, err
+	}
+
+	return jobs.Work{
+		Total: 1,
+		Run: func(ctx context.Context, progress *jobs.Progress) error {
+			response, err := h.runRestore(ctx, plan, "admin job")
+			if err != nil {
+				return err
+			}
+			progress.Advance(1, 0)
+			return progress.SetOutput(response)
+		},
+	}, nil
+}
+
+// collectEvents reads every event in [from, to) from the queue
+func (h *RestoreHandler) collectEvents(from, to int64) ([]models.Event, error) {
+	if from >= to {
+		return nil, nil
+	}
+	if h.eventQueue.OldestAvailableOffset() > from {
+		return nil, errEventsUnavailable
+	}
+
+	collected := make([]models.Event, 0, to-from)
+	next := from
+	for next < to {
+		page, _, _ := h.eventQueue.GetEvents(next, replayPageSize)
+		if len(page) == 0 || page[0].Offset != next {
+			// The offset is assigned but the event may not be stored yet
+			<-h.eventQueue.WaitForEvents(next, replayEventWait)
+			page, _, _ = h.eventQueue.GetEvents(next, replayPageSize)
+			if len(page) == 0 || page[0].Offset != next {
+				return nil, fmt.Errorf("%w: offset %d is missing", errEventsUnavailable, next)
+			}
+		}
+
+		for _, event := range page {
+			if event.Offset >= to {
+				break
+			}
+			if event.Offset != next {
+				return nil, fmt.Errorf("%w: offset %d is missing", errEventsUnavailable, next)
+			}
+			collected = append(collected, event)
+			next++
+		}
+	}
+	return collected, nil
+}
+
+// writeRestoringError answers a write rejected while a restore pauses writes
+func writeRestoringError(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", restoreRetryAfter)
+	writeErrorResponse(w, http.StatusServiceUnavailable, services.ErrTypeServiceUnavailable, "Inventory restore in progress, retry shortly", nil)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/runtimeconfig"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+)
+
+// RuntimeConfigHandler exposes the runtime-tunable settings to admins
+type RuntimeConfigHandler struct {
+	manager *runtimeconfig.Manager
+}
+
+// NewRuntimeConfigHandler creates a new runtime configuration handler
+func NewRuntimeConfigHandler(manager *runtimeconfig.Manager) *RuntimeConfigHandler {
+	return &RuntimeConfigHandler{manager: manager}
+}
+
+// GetConfig handles GET /v1/admin/config - current settings and recent changes
+func (h *RuntimeConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, models.RuntimeConfigResponse{
+		Settings: h.manager.Settings(),
+		Audit:    h.manager.Audit(),
+	})
+}
+
+// PatchConfig handles PATCH /v1/admin/config - applies all changes or none
+func (h *RuntimeConfigHandler) PatchConfig(w http.ResponseWriter, r *http.Request) {
+	var req models.RuntimeConfigPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in runtime config request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	changes := make(map[string]string, len(req.Settings))
+	var details []models.ErrorDetail
+	for key, raw := range req.Settings {
+		value, ok := settingValue(raw)
+		if !ok {
+			details = append(details, models.ErrorDetail{Field: "settings." + key, Issue: "must be a string or a number"})
+			continue
+		}
+		changes[key] = value
+	}
+	if len(details) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", details)
+		return
+	}
+
+	actor := "admin " + middleware.RequestActor(r)
+	applied, err := h.manager.Apply(changes, actor, "api", strings.TrimSpace(req.Reason))
+	if err != nil {
+		var validationErr *runtimeconfig.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			for i := range validationErr.Details {
+				validationErr.Details[i].Field = "settings." + validationErr.Details[i].Field
+			}
+			writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErr.Details)
+		case errors.Is(err, services.ErrServiceDraining):
+			writeErrorResponse(w, http.StatusServiceUnavailable, "service_draining", "Service is shutting down", nil)
+		default:
+			slog.ErrorContext(r.Context(), "Failed to apply runtime configuration", "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to apply runtime configuration", nil)
+		}
+		return
+	}
+
+	if applied == nil {
+		applied = []models.ConfigAuditEntry{}
+	}
+	writeJSONResponse(w, http.StatusOK, models.RuntimeConfigPatchResponse{
+		Applied:  applied,
+		Settings: h.manager.Settings(),
+	})
+}
+
+// settingValue accepts a JSON string or number as a setting value
+func settingValue(raw json.RawMessage) (string, bool) {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, true
+	}
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number.String(), true
+	}
+	return "", false
+}
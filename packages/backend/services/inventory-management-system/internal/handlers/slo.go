@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"net/http"
+
+	"inventory-management-api/internal/telemetry"
+)
+
+// SLOHandler shows the error budget of each endpoint group
+type SLOHandler struct {
+	tracker *telemetry.SLOTracker
+}
+
+// NewSLOHandler creates a new SLO handler
+func NewSLOHandler(tracker *telemetry.SLOTracker) *SLOHandler {
+	return &SLOHandler{tracker: tracker}
+}
+
+// GetSLOStatus handles GET /v1/admin/slo - the budget left, burn rates and
+// firing alerts of every objective
+func (h *SLOHandler) GetSLOStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSONResponse(w, http.StatusOK, h.tracker.Status())
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/snapshots"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// SnapshotsHandler captures and serves point-in-time inventory snapshots
+type SnapshotsHandler struct {
+	inventoryService *services.InventoryService
+	store            *snapshots.Store
+}
+
+// NewSnapshotsHandler creates a new snapshots handler
+func NewSnapshotsHandler(inventoryService *services.InventoryService, store *snapshots.Store) *SnapshotsHandler {
+	return &SnapshotsHandler{
+		inventoryService: inventoryService,
+		store:            store,
+	}
+}
+
+// CreateSnapshot handles POST /v1/admin/snapshots - captures the full inventory
+func (h *SnapshotsHandler) CreateSnapshot(w http.ResponseWriter, r *http.Request) {
+	var req models.SnapshotCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in snapshot request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	products, lastOffset := h.inventoryService.SnapshotProducts()
+	info, err := h.store.Create(strings.TrimSpace(req.Name), products, lastOffset)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create inventory snapshot", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to store snapshot", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusCreated, info)
+}
+
+// ListSnapshots handles GET /v1/admin/snapshots
+func (h *SnapshotsHandler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	infos, err := h.store.List()
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list inventory snapshots", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to list snapshots", nil)
+		return
+	}
+
+	writeJSONResponse(w, http.StatusOK, models.SnapshotListResponse{Snapshots: infos})
+}
+
+// GetSnapshot handles GET /v1/admin/snapshots/{id} - downloads the snapshot, or
+// with ?diff=true compares it with the current inventory
+func (h *SnapshotsHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	diff := false
+	if value := r.URL.Query().Get("diff"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid diff", []models.ErrorDetail{
+				{Field: "diff", Issue: "must be true or false"},
+			})
+			return
+		}
+		diff = parsed
+	}
+
+	info, products, err := h.store.Get(id)
+	if err != nil {
+		if errors.Is(err, snapshots.ErrNotFound) {
+			writeErrorResponse(w, http.StatusNotFound, "snapshot_not_found", "Snapshot not found: "+id, nil)
+			return
+		}
+		slog.ErrorContext(r.Context(), "Failed to load inventory snapshot", "snapshot_id", id, "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Failed to load snapshot", nil)
+		return
+	}
+
+	if !diff {
+		writeJSONResponse(w, http.StatusOK, models.SnapshotResponse{Snapshot: info, Products: products})
+		return
+	}
+
+	current, currentOffset := h.inventoryService.SnapshotProducts()
+	writeJSONResponse(w, http.StatusOK, snapshots.Diff(info, products, current, currentOffset))
+}
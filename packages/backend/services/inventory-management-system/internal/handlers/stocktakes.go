@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/stocktakes"
+	"inventory-management-api/internal/validation"
+
+	"github.com/gorilla/mux"
+)
+
+// StocktakesHandler runs cycle counts: it freezes decrements of the counted
+// products, records counts and applies the variances when a count closes
+type StocktakesHandler struct {
+	inventoryService *services.InventoryService
+}
+
+// NewStocktakesHandler creates a new stocktakes handler
+func NewStocktakesHandler(inventoryService *services.InventoryService) *StocktakesHandler {
+	return &StocktakesHandler{inventoryService: inventoryService}
+}
+
+// StartStocktake handles POST /v1/admin/stocktakes
+func (h *StocktakesHandler) StartStocktake(w http.ResponseWriter, r *http.Request) {
+	var req models.StocktakeStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	session, err := h.inventoryService.StartStocktake(req, adminActor(r))
+	if err != nil {
+		h.writeStocktakeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusCreated, session)
+}
+
+// ListStocktakes handles GET /v1/admin/stocktakes
+func (h *StocktakesHandler) ListStocktakes(w http.ResponseWriter, r *http.Request) {
+	sessions := h.inventoryService.ListStocktakes()
+	writeJSONResponse(w, http.StatusOK, models.StocktakesResponse{
+		Stocktakes: sessions,
+		Count:      len(sessions),
+	})
+}
+
+// GetStocktake handles GET /v1/admin/stocktakes/{id} - the session with its variance report
+func (h *StocktakesHandler) GetStocktake(w http.ResponseWriter, r *http.Request) {
+	report, ok := h.inventoryService.GetStocktake(mux.Vars(r)["id"])
+	if !ok {
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Stocktake not found", nil)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, report)
+}
+
+// RecordCounts handles POST /v1/admin/stocktakes/{id}/counts
+func (h *StocktakesHandler) RecordCounts(w http.ResponseWriter, r *http.Request) {
+	var req models.StocktakeCountsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDecodeError(w, err, "invalid_request", "Invalid JSON in request body")
+		return
+	}
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+	for _, count := range req.Counts {
+		if validationErrors := validation.Validate(count); len(validationErrors) > 0 {
+			writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+			return
+		}
+	}
+
+	report, err := h.inventoryService.RecordStocktakeCounts(mux.Vars(r)["id"], req.Counts)
+	if err != nil {
+		h.writeStocktakeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, report)
+}
+
+// CloseStocktake handles POST /v1/admin/stocktakes/{id}/close - applies the
+// variances as adjustments and lifts the freeze
+func (h *StocktakesHandler) CloseStocktake(w http.ResponseWriter, r *http.Request) {
+	report, err := h.inventoryService.CloseStocktake(r.Context(), mux.Vars(r)["id"], adminActor(r))
+	if err != nil {
+		h.writeStocktakeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, report)
+}
+
+// CancelStocktake handles POST /v1/admin/stocktakes/{id}/cancel - lifts the
+// freeze without applying any count
+func (h *StocktakesHandler) CancelStocktake(w http.ResponseWriter, r *http.Request) {
+	report, err := h.inventoryService.CancelStocktake(mux.Vars(r)["id"], adminActor(r))
+	if err != nil {
+		h.writeStocktakeError(w, r, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, report)
+}
+
+// writeStocktakeError maps a stocktake service error to its response
+func (h *StocktakesHandler) writeStocktakeError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *services.StocktakeValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErr.Details)
+	case errors.Is(err, stocktakes.ErrNotFound):
+		writeErrorResponse(w, http.StatusNotFound, "not_found", "Stocktake not found", nil)
+	case errors.Is(err, stocktakes.ErrOverlap):
+		writeErrorResponse(w, http.StatusConflict, "stocktake_conflict", err.Error(), nil)
+	case errors.Is(err, services.ErrStocktakeNotOpen):
+		writeErrorResponse(w, http.StatusConflict, "stocktake_not_open", "Stocktake is already closed or cancelled", nil)
+	case errors.Is(err, services.ErrServiceRestoring):
+		writeRestoringError(w)
+	default:
+		slog.ErrorContext(r.Context(), "Stocktake request failed", "error", err)
+		writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Stocktake request failed", nil)
+	}
+}
+
+// adminActor identifies who made the admin request in audit records
+func adminActor(r *http.Request) string {
+	return "admin " + middleware.RequestActor(r)
+}
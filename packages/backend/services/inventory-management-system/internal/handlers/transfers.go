@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/validation"
+)
+
+// TransfersHandler moves stock between stores and lists past transfers
+type TransfersHandler struct {
+	inventoryService *services.InventoryService
+}
+
+// NewTransfersHandler creates a new transfers handler
+func NewTransfersHandler(inventoryService *services.InventoryService) *TransfersHandler {
+	return &TransfersHandler{inventoryService: inventoryService}
+}
+
+// CreateTransfer handles POST /v1/inventory/transfers - move units of a product
+// from one store's allocation to another's as one operation
+func (h *TransfersHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	var req models.TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.WarnContext(r.Context(), "Invalid JSON in transfer request", "error", err, "remote_addr", r.RemoteAddr)
+		writeDecodeError(w, err, "bad_request", "Invalid JSON")
+		return
+	}
+
+	if validationErrors := validation.Validate(req); len(validationErrors) > 0 {
+		writeErrorResponse(w, http.StatusBadRequest, "validation_error", "Request validation failed", validationErrors)
+		return
+	}
+
+	transfer, err := h.inventoryService.TransferStock(r.Context(), req)
+	if err != nil {
+		var transferErr *services.TransferError
+		switch {
+		case errors.As(err, &transferErr):
+			if transferErr.Replayed {
+				w.Header().Set(IdempotencyReplayedHeader, "true")
+			}
+			var details []models.ErrorDetail
+			if transferErr.Type == services.ErrTypeVersionConflict || transferErr.Type == services.ErrTypeInsufficientInventory {
+				details = []models.ErrorDetail{
+					{Field: "version", Issue: fmt.Sprintf("current version is %d", transferErr.CurrentVersion)},
+					{Field: "quantity", Issue: fmt.Sprintf("%d units available", transferErr.Available)},
+				}
+			}
+			writeErrorResponse(w, statusForUpdateError(transferErr.Type), transferErr.Type, transferErr.Message, details)
+		case errors.Is(err, services.ErrServiceRestoring):
+			writeRestoringError(w)
+		case errors.Is(err, services.ErrServiceDraining):
+			w.Header().Set("Retry-After", "5")
+			writeErrorResponse(w, http.StatusServiceUnavailable, services.ErrTypeServiceUnavailable, "Service is shutting down, retry shortly", nil)
+		default:
+			slog.ErrorContext(r.Context(), "Failed to transfer stock", "product_id", req.ProductID, "error", err)
+			writeErrorResponse(w, http.StatusInternalServerError, services.ErrTypeInternalError, "Failed to transfer stock", nil)
+		}
+		return
+	}
+
+	if transfer.Replayed {
+		w.Header().Set(IdempotencyReplayedHeader, "true")
+	}
+	writeJSONResponse(w, http.StatusCreated, transfer)
+}
+
+// ListTransfers handles GET /v1/inventory/transfers - transfer history, newest
+// first, optionally narrowed by productId and by storeId (either side)
+func (h *TransfersHandler) ListTransfers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 100
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 1000 {
+			writeErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid limit", []models.ErrorDetail{
+				{Field: "limit", Issue: "must be between 1 and 1000"},
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	transfers := h.inventoryService.ListTransfers(query.Get("productId"), query.Get("storeId"), limit)
+	writeJSONResponse(w, http.StatusOK, models.TransfersResponse{
+		Transfers: transfers,
+		Count:     len(transfers),
+	})
+}
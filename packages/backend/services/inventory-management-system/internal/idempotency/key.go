@@ -0,0 +1,77 @@
+// Package idempotency defines the format of client idempotency keys and how
+// they are namespaced per store before deduplication.
+//
+// A key is either a UUIDv4 in canonical form
+// ("3b241101-e2bb-4255-8caf-4136c566a962") or a ULID
+// ("01ARZ3NDEKTSV4RRFFQ69G5FAV"). Both are matched case-insensitively.
+package idempotency
+
+import "strings"
+
+// Valid reports whether key is a UUIDv4 or a ULID
+func Valid(key string) bool {
+	return isUUIDv4(key) || isULID(key)
+}
+
+// Scoped returns the deduplication key for key sent on behalf of storeID, so
+// two stores reusing the same key never see each other's results. Updates
+// without a store share the unscoped namespace.
+func Scoped(storeID, key string) string {
+	if storeID == "" {
+		return key
+	}
+	return storeID + ":" + key
+}
+
+// isUUIDv4 checks the canonical 8-4-4-4-12 form with version 4 and the RFC
+// 4122 variant
+func isUUIDv4(key string) bool {
+	if len(key) != 36 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if key[i] != '-' {
+				return false
+			}
+		default:
+			if !isHex(key[i]) {
+				return false
+			}
+		}
+	}
+	return key[14] == '4' && strings.IndexByte("89abAB", key[19]) >= 0
+}
+
+// isULID checks 26 Crockford base32 characters whose first one keeps the
+// timestamp within 48 bits
+func isULID(key string) bool {
+	if len(key) != 26 || key[0] < '0' || key[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if !isCrockford(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHex(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// isCrockford accepts Crockford base32 digits, which leave out I, L, O and U
+func isCrockford(c byte) bool {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	switch {
+	case c >= '0' && c <= '9':
+		return true
+	case c >= 'A' && c <= 'Z':
+		return c != 'I' && c != 'L' && c != 'O' && c != 'U'
+	}
+	return false
+}
@@ -0,0 +1,518 @@
+// Package jobs runs long admin operations in the background on a small worker
+// pool. Other modules register job types; the manager keeps each job's
+// progress and per-item results in memory and saves the records to a JSON
+// file, so finished jobs survive a restart.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/models"
+)
+
+// maxFinishedJobs bounds how many succeeded, failed or cancelled jobs are kept
+const maxFinishedJobs = 100
+
+var (
+	// ErrQueueFull is returned when every worker is busy and the queue is full
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrStopped is returned for jobs submitted after Stop
+	ErrStopped = errors.New("job manager stopped")
+	// ErrUnknownType is returned by Start for a type nobody registered
+	ErrUnknownType = errors.New("unknown job type")
+	// ErrNotFound is returned for an unknown job ID
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when cancelling a job that already ended
+	ErrFinished = errors.New("job already finished")
+
+	// errCancelled is the cause of a job's context when Cancel stops it
+	errCancelled = errors.New("job cancelled")
+)
+
+// Config sizes the worker pool and names the file job records are saved in
+type Config struct {
+	Workers   int    // Jobs running at once
+	QueueSize int    // Jobs waiting for a worker
+	ChunkSize int    // Items a job processes between progress updates
+	FilePath  string // Empty keeps job records in memory only
+}
+
+// ParseConfig reads the ADMIN_JOB_* settings, falling back to the defaults
+func ParseConfig(cfg *config.Config) Config {
+	return Config{
+		Workers:   parsePositive("ADMIN_JOB_WORKERS", cfg.AdminJobWorkers, 2),
+		QueueSize: parsePositive("ADMIN_JOB_QUEUE_SIZE", cfg.AdminJobQueueSize, 16),
+		ChunkSize: parsePositive("ADMIN_JOB_CHUNK_SIZE", cfg.AdminJobChunkSize, 500),
+		FilePath:  cfg.JobsFilePath,
+	}
+}
+
+func parsePositive(name, value string, defaultValue int) int {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Invalid job setting, using default", "setting", name, "configured", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// RunFunc does a job's work, reporting results through progress. ctx is
+// cancelled when the job is cancelled or the manager stops.
+type RunFunc func(ctx context.Context, progress *Progress) error
+
+// Work is a job ready to queue: how many items it has and how to run it
+type Work struct {
+	Total int
+	Run   RunFunc
+}
+
+// StartFunc turns the params of a job request into its work. It returns a
+// *ParamsError when the params are invalid.
+type StartFunc func(params json.RawMessage) (Work, error)
+
+// ParamsError reports job params a StartFunc refused
+type ParamsError struct {
+	Code    string
+	Message string
+	Details []models.ErrorDetail
+}
+
+func (e *ParamsError) Error() string {
+	return e.Message
+}
+
+// task is a queued job waiting for a worker
+type task struct {
+	id  string
+	run RunFunc
+}
+
+// Manager queues jobs and keeps their records, oldest first
+type Manager struct {
+	config  Config
+	mutex   sync.RWMutex
+	jobs    []*models.Job
+	types   map[string]StartFunc
+	cancels map[string]context.CancelCauseFunc // Running jobs by ID
+	nextID  int64
+	stopped bool
+	queue   chan task
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// fileData is the on-disk layout
+type fileData struct {
+	NextID int64         `json:"nextId"`
+	Jobs   []*models.Job `json:"jobs"`
+}
+
+// NewManager loads the job records saved at config.FilePath and starts
+// config.Workers workers. Jobs that were queued or running when the process
+// stopped are recorded as failed.
+func NewManager(config Config) (*Manager, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{
+		config:  config,
+		types:   make(map[string]StartFunc),
+		cancels: make(map[string]context.CancelCauseFunc),
+		nextID:  1,
+		queue:   make(chan task, config.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	if err := m.load(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	for i := 0; i < config.Workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	slog.Info("Job manager started", "workers", config.Workers, "queue_size", config.QueueSize,
+		"chunk_size", config.ChunkSize, "saved_jobs", len(m.jobs))
+	return m, nil
+}
+
+// Reload replaces the job records with the ones saved at config.FilePath, for
+// an instance taking over the file from another; jobs that instance left
+// queued or running are recorded as failed
+func (m *Manager) Reload() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.load()
+}
+
+func (m *Manager) load() error {
+	if m.config.FilePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(m.config.FilePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read jobs: %w", err)
+	}
+
+	var saved fileData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode jobs: %w", err)
+	}
+	now := clock.Timestamp()
+	for _, job := range saved.Jobs {
+		if !isFinished(job.Status) {
+			job.Status = models.JobStatusFailed
+			job.Error = "interrupted by restart"
+			job.FinishedAt = now
+		}
+	}
+	m.jobs = saved.Jobs
+	if saved.NextID > m.nextID {
+		m.nextID = saved.NextID
+	}
+	return nil
+}
+
+// Register makes jobs of jobType startable through Start
+func (m *Manager) Register(jobType string, start StartFunc) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.types[jobType] = start
+}
+
+// Types returns the registered job types, sorted
+func (m *Manager) Types() []string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	types := make([]string, 0, len(m.types))
+	for jobType := range m.types {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// ChunkSize is the number of items a job should process between progress updates
+func (m *Manager) ChunkSize() int {
+	return m.config.ChunkSize
+}
+
+// Start queues a job of a registered type from its request params
+func (m *Manager) Start(jobType string, params json.RawMessage) (models.Job, error) {
+	m.mutex.RLock()
+	start, ok := m.types[jobType]
+	m.mutex.RUnlock()
+	if !ok {
+		return models.Job{}, fmt.Errorf("%w: %s", ErrUnknownType, jobType)
+	}
+
+	work, err := start(params)
+	if err != nil {
+		return models.Job{}, err
+	}
+	return m.Submit(jobType, work)
+}
+
+// Submit records a queued job and hands its work to the next free worker
+func (m *Manager) Submit(jobType string, work Work) (models.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.stopped {
+		return models.Job{}, ErrStopped
+	}
+	job := &models.Job{
+		ID:        fmt.Sprintf("job-%06d", m.nextID),
+		Type:      jobType,
+		Status:    models.JobStatusQueued,
+		Total:     work.Total,
+		CreatedAt: clock.Timestamp(),
+	}
+	select {
+	case m.queue <- task{id: job.ID, run: work.Run}:
+	default:
+		return models.Job{}, ErrQueueFull
+	}
+	m.nextID++
+	m.jobs = append(m.jobs, job)
+	m.saveOrLogLocked()
+	slog.Info("Job queued", "job_id", job.ID, "type", jobType, "total", work.Total)
+	return copyJob(job), nil
+}
+
+// Get returns a job by ID with its per-item results
+func (m *Manager) Get(id string) (models.Job, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if job := m.findLocked(id); job != nil {
+		return copyJob(job), true
+	}
+	return models.Job{}, false
+}
+
+// List returns the jobs of jobType and status, newest first and without their
+// per-item results; empty filters match every job
+func (m *Manager) List(jobType, status string) []models.Job {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	result := make([]models.Job, 0, len(m.jobs))
+	for i := len(m.jobs) - 1; i >= 0; i-- {
+		job := *m.jobs[i]
+		if (jobType != "" && job.Type != jobType) || (status != "" && job.Status != status) {
+			continue
+		}
+		job.Results = nil
+		result = append(result, job)
+	}
+	return result
+}
+
+// Cancel stops a job: a queued job is cancelled at once, a running job once
+// its work notices the cancelled context, keeping the items already done
+func (m *Manager) Cancel(id string) (models.Job, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job := m.findLocked(id)
+	if job == nil {
+		return models.Job{}, ErrNotFound
+	}
+	switch {
+	case isFinished(job.Status):
+		return copyJob(job), ErrFinished
+	case job.Status == models.JobStatusQueued:
+		job.Status = models.JobStatusCancelled
+		job.FinishedAt = clock.Timestamp()
+		m.saveOrLogLocked()
+		slog.Info("Job cancelled before it started", "job_id", id, "type", job.Type)
+	default:
+		m.cancels[id](errCancelled)
+		slog.Info("Job cancellation requested", "job_id", id, "type", job.Type, "processed", job.Processed)
+	}
+	return copyJob(job), nil
+}
+
+// Stop cancels running jobs, fails the queued ones and waits for the workers
+func (m *Manager) Stop() {
+	m.mutex.Lock()
+	if m.stopped {
+		m.mutex.Unlock()
+		return
+	}
+	m.stopped = true
+	close(m.queue)
+	m.mutex.Unlock()
+
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Manager) worker() {
+	defer m.wg.Done()
+	for t := range m.queue {
+		ctx, ok := m.begin(t.id)
+		if !ok {
+			continue
+		}
+		m.finish(t.id, ctx, t.run(ctx, &Progress{manager: m, id: t.id}))
+	}
+}
+
+// begin marks a queued job running and returns its context; it reports false
+// for a job cancelled while queued. Jobs still queued at Stop fail.
+func (m *Manager) begin(id string) (context.Context, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	job := m.findLocked(id)
+	if job == nil || job.Status != models.JobStatusQueued {
+		return nil, false
+	}
+	if m.ctx.Err() != nil {
+		job.Status = models.JobStatusFailed
+		job.Error = "interrupted by shutdown"
+		job.FinishedAt = clock.Timestamp()
+		m.saveOrLogLocked()
+		return nil, false
+	}
+
+	ctx, cancel := context.WithCancelCause(m.ctx)
+	m.cancels[id] = cancel
+	job.Status = models.JobStatusRunning
+	job.StartedAt = clock.Timestamp()
+	m.saveOrLogLocked()
+	return ctx, true
+}
+
+// finish records how a job ended, saves the records and drops the oldest
+// finished jobs
+func (m *Manager) finish(id string, ctx context.Context, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.cancels[id](nil)
+	delete(m.cancels, id)
+	job := m.findLocked(id)
+	if job == nil {
+		return
+	}
+
+	job.FinishedAt = clock.Timestamp()
+	switch {
+	case errors.Is(context.Cause(ctx), errCancelled):
+		job.Status = models.JobStatusCancelled
+	case err != nil && m.ctx.Err() != nil:
+		job.Status = models.JobStatusFailed
+		job.Error = "interrupted by shutdown"
+	case err != nil:
+		job.Status = models.JobStatusFailed
+		job.Error = err.Error()
+	default:
+		job.Status = models.JobStatusSucceeded
+	}
+	slog.Info("Job finished", "job_id", id, "type", job.Type, "status", job.Status,
+		"processed", job.Processed, "succeeded", job.Succeeded, "failed", job.Failed, "error", job.Error)
+
+	m.pruneLocked()
+	m.saveOrLogLocked()
+}
+
+func (m *Manager) update(id string, fn func(*models.Job)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if job := m.findLocked(id); job != nil {
+		fn(job)
+	}
+}
+
+func (m *Manager) findLocked(id string) *models.Job {
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// pruneLocked keeps at most maxFinishedJobs finished jobs, dropping the oldest
+func (m *Manager) pruneLocked() {
+	finished := 0
+	for _, job := range m.jobs {
+		if isFinished(job.Status) {
+			finished++
+		}
+	}
+	if finished <= maxFinishedJobs {
+		return
+	}
+
+	kept := m.jobs[:0]
+	for _, job := range m.jobs {
+		if finished > maxFinishedJobs && isFinished(job.Status) {
+			finished--
+			continue
+		}
+		kept = append(kept, job)
+	}
+	m.jobs = kept
+}
+
+// saveOrLogLocked saves the job records; a failed save only loses them on restart
+func (m *Manager) saveOrLogLocked() {
+	if err := m.saveLocked(); err != nil {
+		slog.Error("Failed to save jobs", "path", m.config.FilePath, "error", err)
+	}
+}
+
+func (m *Manager) saveLocked() error {
+	if m.config.FilePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(fileData{NextID: m.nextID, Jobs: m.jobs})
+	if err != nil {
+		return fmt.Errorf("failed to encode jobs: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.config.FilePath), 0755); err != nil {
+		return fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+
+	tempPath := m.config.FilePath + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write jobs: %w", err)
+	}
+	if err := os.Rename(tempPath, m.config.FilePath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store jobs: %w", err)
+	}
+	return nil
+}
+
+func isFinished(status string) bool {
+	return status == models.JobStatusSucceeded || status == models.JobStatusFailed || status == models.JobStatusCancelled
+}
+
+func copyJob(job *models.Job) models.Job {
+	result := *job
+	result.Results = append(make([]models.AdminProductResult, 0, len(job.Results)), job.Results...)
+	return result
+}
+
+// Progress is how a running job reports its per-item results
+type Progress struct {
+	manager *Manager
+	id      string
+}
+
+// Record appends results to the job and counts them as processed
+func (p *Progress) Record(results []models.AdminProductResult) {
+	p.manager.update(p.id, func(job *models.Job) {
+		for _, result := range results {
+			if result.Success {
+				job.Succeeded++
+			} else {
+				job.Failed++
+			}
+		}
+		job.Processed += len(results)
+		job.Results = append(job.Results, results...)
+	})
+}
+
+// Advance counts processed items that have no per-item result
+func (p *Progress) Advance(succeeded, failed int) {
+	p.manager.update(p.id, func(job *models.Job) {
+		job.Succeeded += succeeded
+		job.Failed += failed
+		job.Processed += succeeded + failed
+	})
+}
+
+// SetOutput records the job's overall result, such as a report or a file name
+func (p *Progress) SetOutput(output interface{}) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to encode job output: %w", err)
+	}
+	p.manager.update(p.id, func(job *models.Job) {
+		job.Output = data
+	})
+	return nil
+}
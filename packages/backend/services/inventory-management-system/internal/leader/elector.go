@@ -0,0 +1,238 @@
+// Package leader picks one instance of a multi-instance deployment sharing
+// the same data files as the leader, through an exclusive lock on a file on
+// that shared storage. Only the leader writes the data and events files;
+// followers serve reads and send writes to the URL the leader recorded in the
+// lock file. The lock is released when the leader's process exits, so a
+// follower takes over within one retry interval.
+package leader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// ErrUnsupported is returned on platforms without file locks
+var ErrUnsupported = errors.New("leader election needs file locks, which this platform lacks")
+
+// Config configures the elector
+type Config struct {
+	LockFile      string        // On the storage every instance shares
+	AdvertiseURL  string        // Where the other instances reach this one
+	InstanceID    string        // Names this instance in the lock file and logs
+	RetryInterval time.Duration // How often a follower retries the lock and rereads the leader
+}
+
+// Roles reported in LeaderStatus
+const (
+	RoleLeader    = "leader"
+	RolePromoting = "promoting" // Won the lock, still taking over from the previous leader
+	RoleFollower  = "follower"
+)
+
+// Elector campaigns for the lock and tracks the current leader
+type Elector struct {
+	config  Config
+	mu      sync.RWMutex
+	file    *os.File             // Open and locked once this instance won
+	leading bool                 // Set once the instance that won is ready to lead
+	leader  *models.LeaderRecord // Last leader read from the lock file; nil until known
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// New creates an elector; Campaign takes part in the election
+func New(config Config) *Elector {
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+	if config.InstanceID == "" {
+		config.InstanceID, _ = os.Hostname()
+	}
+	return &Elector{
+		config: config,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// Campaign tries to take the lock once and reports whether this instance won
+// it. The instance leads once Start returns or its onElected callback did. A
+// follower learns the current leader from the lock file.
+func (e *Elector) Campaign() (bool, error) {
+	if e.holdsLock() {
+		return true, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(e.config.LockFile), 0755); err != nil {
+		return false, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	file, err := os.OpenFile(e.config.LockFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	acquired, err := tryLock(file)
+	if err != nil || !acquired {
+		file.Close()
+		e.readLeader()
+		return false, err
+	}
+
+	record := models.LeaderRecord{
+		InstanceID: e.config.InstanceID,
+		URL:        e.config.AdvertiseURL,
+		Since:      clock.Timestamp(),
+	}
+	if err := writeRecord(file, record); err != nil {
+		// Closing the file releases the lock for another instance
+		file.Close()
+		return false, fmt.Errorf("failed to record leader: %w", err)
+	}
+
+	e.mu.Lock()
+	e.file = file
+	e.leader = &record
+	e.mu.Unlock()
+	slog.Info("Elected leader", "instance_id", record.InstanceID, "url", record.URL, "lock_file", e.config.LockFile)
+	return true, nil
+}
+
+// Start keeps a follower campaigning in the background every RetryInterval,
+// rereading the leader in between, and calls onElected once it wins; it leads
+// when onElected returns. An instance that won before Start leads right away
+// and onElected is not called.
+func (e *Elector) Start(onElected func()) {
+	if e.holdsLock() {
+		e.setLeading()
+		close(e.done)
+		return
+	}
+	go func() {
+		defer close(e.done)
+		ticker := time.NewTicker(e.config.RetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.stop:
+				return
+			case <-ticker.C:
+				elected, err := e.Campaign()
+				if err != nil {
+					slog.Warn("Leader election attempt failed", "error", err)
+					continue
+				}
+				if elected {
+					onElected()
+					e.setLeading()
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stop stops campaigning and releases the lock if this instance holds it
+func (e *Elector) Stop() {
+	close(e.stop)
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil {
+		e.file.Close()
+		e.file = nil
+		e.leading = false
+		slog.Info("Released leadership", "instance_id", e.config.InstanceID)
+	}
+}
+
+// IsLeader reports whether this instance leads
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leading
+}
+
+// holdsLock reports whether this instance won the lock
+func (e *Elector) holdsLock() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.file != nil
+}
+
+// setLeading marks the instance that won as ready to lead
+func (e *Elector) setLeading() {
+	e.mu.Lock()
+	e.leading = true
+	e.mu.Unlock()
+	slog.Info("Leading", "instance_id", e.config.InstanceID)
+}
+
+// Leader returns the current leader, or false while none is known. While this
+// instance is taking over there is no leader to forward to.
+func (e *Elector) Leader() (models.LeaderRecord, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.leader == nil || (e.file != nil && !e.leading) {
+		return models.LeaderRecord{}, false
+	}
+	return *e.leader, true
+}
+
+// Status returns this instance's role and the leader it knows of
+func (e *Elector) Status() models.LeaderStatus {
+	status := models.LeaderStatus{InstanceID: e.config.InstanceID, Role: RoleFollower}
+	if e.IsLeader() {
+		status.Role = RoleLeader
+	} else if e.holdsLock() {
+		status.Role = RolePromoting
+	}
+	if leader, ok := e.Leader(); ok {
+		status.Leader = &leader
+	}
+	return status
+}
+
+// readLeader refreshes the leader from the lock file. A file being rewritten
+// by a new leader reads as empty; the previous leader is kept until the next
+// read.
+func (e *Elector) readLeader() {
+	data, err := os.ReadFile(e.config.LockFile)
+	if err != nil || len(data) == 0 {
+		return
+	}
+	var record models.LeaderRecord
+	if err := json.Unmarshal(data, &record); err != nil || record.InstanceID == "" {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leader == nil || *e.leader != record {
+		slog.Info("Following leader", "leader_id", record.InstanceID, "leader_url", record.URL, "since", record.Since)
+	}
+	e.leader = &record
+}
+
+// writeRecord replaces the lock file's contents with record
+func writeRecord(file *os.File, record models.LeaderRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return file.Sync()
+}
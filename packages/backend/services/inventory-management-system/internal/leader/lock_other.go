@@ -0,0 +1,10 @@
+//go:build !unix
+
+package leader
+
+import "os"
+
+// tryLock fails where flock is not available
+func tryLock(file *os.File) (bool, error) {
+	return false, ErrUnsupported
+}
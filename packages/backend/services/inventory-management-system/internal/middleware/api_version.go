@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"inventory-management-api/internal/apiversion"
+
+	"github.com/gorilla/mux"
+)
+
+// APIVersionMiddleware stores the version a subrouter serves in the request
+// context for handlers (apiversion.FromContext) and echoes it in API-Version
+func APIVersionMiddleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(apiversion.Header, version)
+			next.ServeHTTP(w, r.WithContext(apiversion.WithVersion(r.Context(), version)))
+		})
+	}
+}
+
+// DeprecationMiddleware announces routes slated for removal, looked up by
+// method and route template in deprecations (see openapi.RouteDeprecations):
+// it sets Deprecation, Sunset when a removal date is set, and a Link to the
+// same path in the latest version. Responses are otherwise unchanged.
+func DeprecationMiddleware(deprecations map[string]apiversion.Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routeKey := r.Method + " " + r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					routeKey = r.Method + " " + template
+				}
+			}
+
+			if deprecation, ok := deprecations[routeKey]; ok {
+				w.Header().Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+				if !deprecation.Sunset.IsZero() {
+					w.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+				}
+				if successor := apiversion.Path(apiversion.Latest, r.URL.Path); successor != r.URL.Path {
+					w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
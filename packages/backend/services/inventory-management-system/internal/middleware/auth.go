@@ -2,36 +2,216 @@ package middleware
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"inventory-management-api/internal/authz"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/requestid"
+
+	"github.com/gorilla/mux"
 )
 
-// AuthMiddleware provides API key authentication
+// AuthMiddleware authenticates the caller and puts its principal in the
+// request context for AuthorizeMiddleware. Stores authenticated by a client
+// certificate (see ClientCertMiddleware) need no API key and get the store
+// role. Otherwise a bearer JWT (when JWT_SECRET is set) or an API key is
+// required; see principalForAPIKey for the roles of a key.
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if storeID := ClientCertStoreID(r.Context()); storeID != "" {
+			slog.DebugContext(r.Context(), "Authentication successful", "remote_addr", r.RemoteAddr, "client_cert_store_id", storeID)
+			principal := authz.Principal{ID: "store:" + storeID, Source: authz.SourceClientCert, Roles: []authz.Role{authz.RoleStore}, StoreID: storeID}
+			next.ServeHTTP(w, r.WithContext(authz.WithPrincipal(r.Context(), principal)))
+			return
+		}
+
+		if token, ok := bearerToken(r); ok {
+			principal, err := verifyBearerToken(token)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Authentication failed: invalid bearer token", "remote_addr", r.RemoteAddr, "error", err)
+				writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Invalid bearer token", nil)
+				return
+			}
+			slog.DebugContext(r.Context(), "Authentication successful", "remote_addr", r.RemoteAddr, "principal", principal.ID, "roles", principal.Roles)
+			next.ServeHTTP(w, r.WithContext(authz.WithPrincipal(r.Context(), principal)))
+			return
+		}
+
 		apiKey := r.Header.Get("X-API-Key")
 		if apiKey == "" {
-			slog.Warn("Authentication failed: missing API key", "remote_addr", r.RemoteAddr)
+			slog.WarnContext(r.Context(), "Authentication failed: missing API key", "remote_addr", r.RemoteAddr)
 			writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "API key required", nil)
 			return
 		}
 
-		// Validate API key against environment variable
-		if !isValidAPIKey(apiKey) {
-			slog.Warn("Authentication failed: invalid API key", "remote_addr", r.RemoteAddr, "provided_key", apiKey)
+		principal, ok := principalForAPIKey(apiKey)
+		if !ok {
+			slog.WarnContext(r.Context(), "Authentication failed: invalid API key", "remote_addr", r.RemoteAddr, "provided_key", MaskAPIKey(apiKey))
 			writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Invalid API key", nil)
 			return
 		}
 
-		slog.Debug("Authentication successful", "remote_addr", r.RemoteAddr, "api_key", apiKey)
-		next.ServeHTTP(w, r)
+		slog.DebugContext(r.Context(), "Authentication successful", "remote_addr", r.RemoteAddr, "api_key", MaskAPIKey(apiKey), "roles", principal.Roles)
+		next.ServeHTTP(w, r.WithContext(authz.WithPrincipal(r.Context(), principal)))
 	})
 }
 
+// principalForAPIKey resolves the roles of an API key: those assigned in
+// API_KEY_ROLES ("key:role|role,..."), else admin for admin keys and store for
+// the other keys in API_KEYS. A key listed in API_KEY_ROLES needs no other
+// entry. API_KEY_STORES ("key:store-id,...") binds a key to a store.
+func principalForAPIKey(apiKey string) (authz.Principal, bool) {
+	principal := authz.Principal{ID: "key:" + apiKey, Source: authz.SourceAPIKey}
+
+	// ValidateAuthConfig rejects a malformed API_KEY_STORES at startup
+	if bindings, err := authz.ParseKeyStores(os.Getenv("API_KEY_STORES")); err == nil {
+		principal.StoreID = bindings[apiKey]
+	}
+
+	// ValidateAuthConfig rejects a malformed API_KEY_ROLES at startup
+	if assignments, err := authz.ParseKeyRoles(os.Getenv("API_KEY_ROLES")); err == nil {
+		if roles, ok := assignments[apiKey]; ok {
+			principal.Roles = roles
+			return principal, true
+		}
+	}
+
+	switch {
+	case isValidAdminAPIKey(apiKey):
+		principal.Roles = []authz.Role{authz.RoleAdmin}
+	case isValidAPIKey(apiKey):
+		principal.Roles = []authz.Role{authz.RoleStore}
+	default:
+		return authz.Principal{}, false
+	}
+	return principal, true
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// verifyBearerToken checks a JWT against JWT_SECRET and, if set, JWT_ISSUER
+func verifyBearerToken(token string) (authz.Principal, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return authz.Principal{}, fmt.Errorf("%w: bearer tokens are not enabled", authz.ErrInvalidToken)
+	}
+	return authz.NewJWTVerifier(secret, os.Getenv("JWT_ISSUER")).Verify(token, time.Now())
+}
+
+// ValidateAuthConfig checks the role assignments in API_KEY_ROLES and the
+// store bindings in API_KEY_STORES, so a typo fails startup instead of
+// silently leaving keys with their default roles or without their store
+func ValidateAuthConfig() error {
+	if _, err := authz.ParseKeyRoles(os.Getenv("API_KEY_ROLES")); err != nil {
+		return fmt.Errorf("invalid API_KEY_ROLES: %w", err)
+	}
+	if _, err := authz.ParseKeyStores(os.Getenv("API_KEY_STORES")); err != nil {
+		return fmt.Errorf("invalid API_KEY_STORES: %w", err)
+	}
+	return nil
+}
+
+// Errors of RequestStoreID and StoreIDFor
+var (
+	ErrStoreIDMismatch = errors.New("store ID does not match the store the caller is authenticated as")
+	ErrStoreNotBound   = errors.New("acting for a store needs a client certificate, API key or token bound to the store")
+)
+
+// RequestStoreID returns the store a request acts for, e.g. as an event
+// consumer, taken from the authenticated principal rather than X-Store-ID
+// (see StoreIDFor)
+func RequestStoreID(r *http.Request) (string, error) {
+	return StoreIDFor(r, r.Header.Get(StoreIDHeader))
+}
+
+// StoreIDFor returns the store a request claiming to act for claimed acts
+// for: a principal bound to a store acts for that store, and a claim naming
+// another is rejected. Admins, such as operators and the partition router,
+// may act for the claimed store; other principals may not claim one. It
+// returns "" when the request acts for no store.
+func StoreIDFor(r *http.Request, claimed string) (string, error) {
+	claimed = strings.TrimSpace(claimed)
+	principal, ok := authz.PrincipalFromContext(r.Context())
+	switch {
+	case ok && principal.StoreID != "":
+		if claimed != "" && claimed != principal.StoreID {
+			return "", ErrStoreIDMismatch
+		}
+		return principal.StoreID, nil
+	case claimed == "":
+		return "", nil
+	case ok && principal.HasRole(authz.RoleAdmin):
+		return claimed, nil
+	}
+	return "", ErrStoreNotBound
+}
+
+// AuthorizeMiddleware enforces the permission each route requires, looked up
+// by method and route template in permissions (see openapi.RoutePermissions).
+// It runs after AuthMiddleware. Routes without a required permission are
+// denied, so a route added without one fails closed.
+func AuthorizeMiddleware(permissions map[string]authz.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routeKey := r.Method + " " + r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					routeKey = r.Method + " " + template
+				}
+			}
+
+			permission, ok := permissions[routeKey]
+			if !ok {
+				slog.ErrorContext(r.Context(), "Route has no required permission, denying", "route", routeKey)
+				writeErrorResponse(w, http.StatusForbidden, "forbidden", "Route is not authorized for any role", nil)
+				return
+			}
+
+			principal, ok := authz.PrincipalFromContext(r.Context())
+			if !ok {
+				writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Authentication required", nil)
+				return
+			}
+
+			if !principal.Can(permission) {
+				slog.WarnContext(r.Context(), "Authorization failed: missing permission",
+					"principal", maskPrincipal(principal.ID),
+					"roles", principal.Roles,
+					"route", routeKey,
+					"permission", permission)
+				writeErrorResponse(w, http.StatusForbidden, "forbidden", "Permission "+string(permission)+" required", []models.ErrorDetail{
+					{Field: "permission", Issue: string(permission)},
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestActor names the caller of a request for audit records, with API keys
+// masked. Without a resolved principal it falls back to the API key header.
+func RequestActor(r *http.Request) string {
+	if principal, ok := authz.PrincipalFromContext(r.Context()); ok {
+		return maskPrincipal(principal.ID)
+	}
+	return MaskAPIKey(r.Header.Get(APIKeyHeader))
+}
+
 // isValidAPIKey checks if the provided API key is valid
 func isValidAPIKey(apiKey string) bool {
 	// Get valid API keys from environment variable
@@ -49,28 +229,6 @@ func isValidAPIKey(apiKey string) bool {
 	return false
 }
 
-// AdminAuthMiddleware provides admin-only API key authentication
-func AdminAuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		apiKey := r.Header.Get("X-API-Key")
-		if apiKey == "" {
-			slog.Warn("Admin authentication failed: missing API key", "remote_addr", r.RemoteAddr)
-			writeErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Admin API key required", nil)
-			return
-		}
-
-		// Validate API key against admin keys
-		if !isValidAdminAPIKey(apiKey) {
-			slog.Warn("Admin authentication failed: invalid admin API key", "remote_addr", r.RemoteAddr, "provided_key", apiKey)
-			writeErrorResponse(w, http.StatusForbidden, "forbidden", "Admin access required", nil)
-			return
-		}
-
-		slog.Debug("Admin authentication successful", "remote_addr", r.RemoteAddr, "api_key", apiKey)
-		next.ServeHTTP(w, r)
-	})
-}
-
 // isValidAdminAPIKey checks if the provided API key has admin privileges
 func isValidAdminAPIKey(apiKey string) bool {
 	// Get admin API keys from environment variable
@@ -97,8 +255,9 @@ func writeErrorResponse(w http.ResponseWriter, statusCode int, code, message str
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(models.ErrorResponse{
-		Code:    code,
-		Message: message,
-		Details: details,
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: w.Header().Get(requestid.Header),
 	})
 }
@@ -0,0 +1,392 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// AuthLockoutConfig holds the brute-force protection settings
+type AuthLockoutConfig struct {
+	Enabled      bool
+	Threshold    int           // Failures within Window that lock a principal out
+	Window       time.Duration // How long failures are counted
+	BaseDuration time.Duration // First lockout; each further one doubles
+	MaxDuration  time.Duration // Longest lockout, and how long strikes are remembered
+}
+
+// ParseAuthLockoutConfig parses lockout settings from the config struct
+func ParseAuthLockoutConfig(cfg *config.Config) AuthLockoutConfig {
+	lockoutConfig := AuthLockoutConfig{
+		Enabled:      parseBool(cfg.AuthLockoutEnabled, true),
+		Threshold:    parseInt(cfg.AuthLockoutThreshold, 5),
+		Window:       parseDuration(cfg.AuthLockoutWindow, 5*time.Minute),
+		BaseDuration: parseDuration(cfg.AuthLockoutBaseDuration, time.Minute),
+		MaxDuration:  parseDuration(cfg.AuthLockoutMaxDuration, time.Hour),
+	}
+
+	if lockoutConfig.Threshold <= 0 {
+		slog.Warn("Invalid auth lockout threshold, using default",
+			"configured", cfg.AuthLockoutThreshold, "default", 5)
+		lockoutConfig.Threshold = 5
+	}
+	if lockoutConfig.MaxDuration < lockoutConfig.BaseDuration {
+		slog.Warn("Auth lockout max duration below base duration, using base duration",
+			"max_duration", lockoutConfig.MaxDuration, "base_duration", lockoutConfig.BaseDuration)
+		lockoutConfig.MaxDuration = lockoutConfig.BaseDuration
+	}
+
+	slog.Info("Auth failure lockout configured",
+		"enabled", lockoutConfig.Enabled,
+		"threshold", lockoutConfig.Threshold,
+		"window", lockoutConfig.Window,
+		"base_duration", lockoutConfig.BaseDuration,
+		"max_duration", lockoutConfig.MaxDuration)
+
+	return lockoutConfig
+}
+
+// parseDuration parses a Go duration, falling back to defaultValue when the
+// value is empty, malformed or not positive
+func parseDuration(value string, defaultValue time.Duration) time.Duration {
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		if value != "" {
+			slog.Warn("Invalid duration, using default", "value", value, "default", defaultValue)
+		}
+		return defaultValue
+	}
+	return parsed
+}
+
+// lockoutEntry tracks the failures of one IP address or API key
+type lockoutEntry struct {
+	failures    int
+	windowStart time.Time
+	strikes     int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// AuthLockout locks out client IPs and API keys after repeated authentication
+// failures. Each lockout of the same principal lasts twice as long as the one
+// before, up to MaxDuration; strikes are forgotten after MaxDuration without a
+// failure.
+type AuthLockout struct {
+	config  AuthLockoutConfig
+	mu      sync.Mutex
+	entries map[string]*lockoutEntry // Keyed by "ip:<address>" or "key:<api key>"
+
+	failureCounter metric.Int64Counter // Nil until RegisterMetrics
+	lockoutCounter metric.Int64Counter
+
+	cleanupTicker *time.Ticker
+	stopCleanup   chan struct{}
+}
+
+// NewAuthLockout creates a lockout tracker
+func NewAuthLockout(config AuthLockoutConfig) *AuthLockout {
+	l := &AuthLockout{
+		config:      config,
+		entries:     make(map[string]*lockoutEntry),
+		stopCleanup: make(chan struct{}),
+	}
+
+	// Forget principals whose failures and strikes have expired
+	l.cleanupTicker = time.NewTicker(time.Minute)
+	go l.cleanupExpiredEntries()
+
+	return l
+}
+
+// Stop stops the cleanup goroutine
+func (l *AuthLockout) Stop() {
+	l.cleanupTicker.Stop()
+	close(l.stopCleanup)
+}
+
+// cleanupExpiredEntries prunes expired entries so failures from many
+// addresses do not accumulate
+func (l *AuthLockout) cleanupExpiredEntries() {
+	for {
+		select {
+		case <-l.cleanupTicker.C:
+			l.mu.Lock()
+			l.pruneLocked(time.Now())
+			l.mu.Unlock()
+		case <-l.stopCleanup:
+			return
+		}
+	}
+}
+
+// RegisterMetrics exposes auth_failures_total, auth_lockouts_total and the
+// auth_locked_principals gauge
+func (l *AuthLockout) RegisterMetrics() error {
+	meter := otel.Meter("inventory-management-api")
+
+	failureCounter, err := meter.Int64Counter(
+		"auth_failures_total",
+		metric.WithDescription("Requests rejected for a missing or invalid API key or token"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create auth failure counter: %w", err)
+	}
+
+	lockoutCounter, err := meter.Int64Counter(
+		"auth_lockouts_total",
+		metric.WithDescription("IP addresses and API keys locked out after repeated authentication failures"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create auth lockout counter: %w", err)
+	}
+
+	lockedGauge, err := meter.Int64ObservableGauge(
+		"auth_locked_principals",
+		metric.WithDescription("IP addresses and API keys currently locked out"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create locked principals gauge: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		observer.ObserveInt64(lockedGauge, int64(l.LockedCount()))
+		return nil
+	}, lockedGauge)
+	if err != nil {
+		return fmt.Errorf("failed to register locked principals callback: %w", err)
+	}
+
+	l.mu.Lock()
+	l.failureCounter = failureCounter
+	l.lockoutCounter = lockoutCounter
+	l.mu.Unlock()
+	return nil
+}
+
+// Locked returns the first of principals that is locked out and until when
+func (l *AuthLockout) Locked(principals ...string) (string, time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for _, principal := range principals {
+		if entry, ok := l.entries[principal]; ok && now.Before(entry.lockedUntil) {
+			return principal, entry.lockedUntil, true
+		}
+	}
+	return "", time.Time{}, false
+}
+
+// RecordFailure counts a failed authentication against each principal and
+// locks out those that reach the threshold
+func (l *AuthLockout) RecordFailure(ctx context.Context, principals ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.failureCounter != nil {
+		l.failureCounter.Add(ctx, 1)
+	}
+	for _, principal := range principals {
+		entry, ok := l.entries[principal]
+		if !ok {
+			entry = &lockoutEntry{}
+			l.entries[principal] = entry
+		}
+		if now.Sub(entry.lastFailure) > l.config.MaxDuration && now.After(entry.lockedUntil) {
+			entry.strikes = 0
+		}
+		if now.Sub(entry.windowStart) > l.config.Window {
+			entry.windowStart = now
+			entry.failures = 0
+		}
+		entry.failures++
+		entry.lastFailure = now
+
+		if entry.failures < l.config.Threshold {
+			continue
+		}
+		entry.strikes++
+		entry.failures = 0
+		duration := l.lockoutDuration(entry.strikes)
+		entry.lockedUntil = now.Add(duration)
+		if l.lockoutCounter != nil {
+			l.lockoutCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", principalKind(principal))))
+		}
+		slog.WarnContext(ctx, "Principal locked out after repeated authentication failures",
+			"audit", true,
+			"principal", maskPrincipal(principal),
+			"strikes", entry.strikes,
+			"locked_for", duration,
+			"locked_until", entry.lockedUntil.UTC().Format(time.RFC3339))
+	}
+}
+
+// RecordSuccess clears the failure count of a principal that authenticated.
+// Its strikes stay, so a lockout after the next run of failures is longer.
+func (l *AuthLockout) RecordSuccess(principals ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, principal := range principals {
+		if entry, ok := l.entries[principal]; ok {
+			entry.failures = 0
+		}
+	}
+}
+
+// lockoutDuration doubles BaseDuration for every earlier strike, up to MaxDuration
+func (l *AuthLockout) lockoutDuration(strikes int) time.Duration {
+	duration := float64(l.config.BaseDuration) * math.Pow(2, float64(strikes-1))
+	if duration > float64(l.config.MaxDuration) {
+		return l.config.MaxDuration
+	}
+	return time.Duration(duration)
+}
+
+// Entries lists tracked principals, locked ones first, and drops those whose
+// failures and strikes have expired
+func (l *AuthLockout) Entries() models.AuthLockoutListResponse {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.pruneLocked(now)
+	response := models.AuthLockoutListResponse{Lockouts: make([]models.AuthLockoutEntry, 0, len(l.entries))}
+	for principal, entry := range l.entries {
+		listed := models.AuthLockoutEntry{
+			ID:          lockoutID(principal),
+			Principal:   maskPrincipal(principal),
+			Failures:    entry.failures,
+			Strikes:     entry.strikes,
+			LastFailure: entry.lastFailure.UTC().Format(time.RFC3339),
+		}
+		if now.Before(entry.lockedUntil) {
+			listed.LockedUntil = entry.lockedUntil.UTC().Format(time.RFC3339)
+			response.Locked++
+		}
+		response.Lockouts = append(response.Lockouts, listed)
+	}
+
+	sort.Slice(response.Lockouts, func(i, j int) bool {
+		a, b := response.Lockouts[i], response.Lockouts[j]
+		if (a.LockedUntil != "") != (b.LockedUntil != "") {
+			return a.LockedUntil != ""
+		}
+		return a.Principal < b.Principal
+	})
+	return response
+}
+
+// LockedCount returns how many principals are locked out now
+func (l *AuthLockout) LockedCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	locked := 0
+	for _, entry := range l.entries {
+		if now.Before(entry.lockedUntil) {
+			locked++
+		}
+	}
+	return locked
+}
+
+// Unblock forgets the principal with the given ID (see Entries), lifting its
+// lockout and strikes. It reports whether the ID was known.
+func (l *AuthLockout) Unblock(id string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for principal := range l.entries {
+		if lockoutID(principal) == id {
+			delete(l.entries, principal)
+			return maskPrincipal(principal), true
+		}
+	}
+	return "", false
+}
+
+// pruneLocked drops entries with nothing left to remember; the caller holds l.mu
+func (l *AuthLockout) pruneLocked(now time.Time) {
+	for principal, entry := range l.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.lastFailure) > max(l.config.Window, l.config.MaxDuration) {
+			delete(l.entries, principal)
+		}
+	}
+}
+
+// lockoutID is a stable handle for a principal that does not reveal an API key
+func lockoutID(principal string) string {
+	sum := sha256.Sum256([]byte(principal))
+	return hex.EncodeToString(sum[:8])
+}
+
+func principalKind(principal string) string {
+	if len(principal) > 3 && principal[:3] == "ip:" {
+		return "ip"
+	}
+	return "key"
+}
+
+// AuthLockoutMiddleware wraps AuthMiddleware. Requests from a locked-out IP
+// or with a locked-out API key are answered 429 auth_locked_out with
+// Retry-After, like rate-limited requests, without checking the key. Every 401
+// from the wrapped handler counts as a failure for the client IP and the
+// presented key; other responses clear their failure counts.
+func AuthLockoutMiddleware(lockout *AuthLockout, clientIP func(*http.Request) net.IP) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principals := make([]string, 0, 2)
+			if ip := clientIP(r); ip != nil {
+				principals = append(principals, "ip:"+ip.String())
+			}
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+				principals = append(principals, "key:"+apiKey)
+			}
+
+			if principal, until, locked := lockout.Locked(principals...); locked {
+				retryAfter := int(math.Ceil(time.Until(until).Seconds()))
+				slog.WarnContext(r.Context(), "Request rejected: principal locked out",
+					"principal", maskPrincipal(principal),
+					"path", r.URL.Path,
+					"retry_after_seconds", retryAfter)
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				writeErrorResponse(w, http.StatusTooManyRequests, "auth_locked_out",
+					"Too many failed authentication attempts. Please try again later.", []models.ErrorDetail{
+						{Field: "retry_after", Issue: fmt.Sprintf("Retry after %d seconds", retryAfter)},
+					})
+				return
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			if recorder.statusCode == http.StatusUnauthorized {
+				lockout.RecordFailure(r.Context(), principals...)
+			} else {
+				lockout.RecordSuccess(principals...)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/models"
+)
+
+// BodyLimitConfig holds request payload limits
+type BodyLimitConfig struct {
+	MaxBodyBytes      int64 // Limit for regular v1 routes
+	MaxAdminBodyBytes int64 // Limit for admin routes (bulk imports)
+	MaxBatchItems     int   // Max updates in one batch update request
+	MaxAdminItems     int   // Max products/IDs in one admin request
+	MaxJobBodyBytes   int64 // Limit for admin routes that start background jobs
+	MaxJobItems       int   // Max products in one background job
+}
+
+// ParseBodyLimitConfig parses payload limits from the config struct
+func ParseBodyLimitConfig(cfg *config.Config) BodyLimitConfig {
+	bodyLimitConfig := BodyLimitConfig{
+		MaxBodyBytes:      int64(parseInt(cfg.MaxRequestBodyBytes, 1<<20)),
+		MaxAdminBodyBytes: int64(parseInt(cfg.MaxAdminRequestBodyBytes, 10<<20)),
+		MaxBatchItems:     parseInt(cfg.MaxBatchUpdateItems, 100),
+		MaxAdminItems:     parseInt(cfg.MaxAdminItemsPerRequest, 1000),
+		MaxJobBodyBytes:   int64(parseInt(cfg.MaxAdminJobBodyBytes, 100<<20)),
+		MaxJobItems:       parseInt(cfg.MaxAdminJobItems, 100000),
+	}
+
+	if bodyLimitConfig.MaxBodyBytes <= 0 {
+		slog.Warn("Invalid max request body size, using default",
+			"configured", cfg.MaxRequestBodyBytes, "default", 1<<20)
+		bodyLimitConfig.MaxBodyBytes = 1 << 20
+	}
+
+	if bodyLimitConfig.MaxAdminBodyBytes <= 0 {
+		slog.Warn("Invalid max admin request body size, using default",
+			"configured", cfg.MaxAdminRequestBodyBytes, "default", 10<<20)
+		bodyLimitConfig.MaxAdminBodyBytes = 10 << 20
+	}
+
+	if bodyLimitConfig.MaxBatchItems <= 0 {
+		slog.Warn("Invalid max batch update items, using default",
+			"configured", cfg.MaxBatchUpdateItems, "default", 100)
+		bodyLimitConfig.MaxBatchItems = 100
+	}
+
+	if bodyLimitConfig.MaxAdminItems <= 0 {
+		slog.Warn("Invalid max admin items per request, using default",
+			"configured", cfg.MaxAdminItemsPerRequest, "default", 1000)
+		bodyLimitConfig.MaxAdminItems = 1000
+	}
+
+	if bodyLimitConfig.MaxJobBodyBytes <= 0 {
+		slog.Warn("Invalid max admin job body size, using default",
+			"configured", cfg.MaxAdminJobBodyBytes, "default", 100<<20)
+		bodyLimitConfig.MaxJobBodyBytes = 100 << 20
+	}
+
+	if bodyLimitConfig.MaxJobItems <= 0 {
+		slog.Warn("Invalid max admin job items, using default",
+			"configured", cfg.MaxAdminJobItems, "default", 100000)
+		bodyLimitConfig.MaxJobItems = 100000
+	}
+
+	slog.Info("Request body limits configured",
+		"max_body_bytes", bodyLimitConfig.MaxBodyBytes,
+		"max_admin_body_bytes", bodyLimitConfig.MaxAdminBodyBytes,
+		"max_batch_items", bodyLimitConfig.MaxBatchItems,
+		"max_admin_items", bodyLimitConfig.MaxAdminItems,
+		"max_job_body_bytes", bodyLimitConfig.MaxJobBodyBytes,
+		"max_job_items", bodyLimitConfig.MaxJobItems)
+
+	return bodyLimitConfig
+}
+
+// BodyLimitMiddleware rejects requests whose declared Content-Length exceeds
+// maxBytes and caps the body reader for chunked or understated requests, so
+// handlers decoding the body get an error instead of buffering it all
+func BodyLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				slog.WarnContext(r.Context(), "Request body too large",
+					"path", r.URL.Path,
+					"content_length", r.ContentLength,
+					"max_bytes", maxBytes,
+					"remote_addr", r.RemoteAddr)
+				WritePayloadTooLargeResponse(w, maxBytes)
+				return
+			}
+
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IsBodyTooLarge reports whether a body read failed because of the size cap
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// WritePayloadTooLargeResponse writes the standard 413 error response
+func WritePayloadTooLargeResponse(w http.ResponseWriter, maxBytes int64) {
+	writeErrorResponse(w, http.StatusRequestEntityTooLarge, "payload_too_large", "Request body too large", []models.ErrorDetail{
+		{
+			Field: "body",
+			Issue: fmt.Sprintf("Request body must not exceed %d bytes", maxBytes),
+		},
+	})
+}
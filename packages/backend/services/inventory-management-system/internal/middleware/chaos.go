@@ -0,0 +1,265 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+)
+
+// chaosEventsPath is the route whose responses can carry duplicated events
+const chaosEventsPath = "/v1/inventory/events"
+
+// ChaosConfig holds fault injection settings for integration testing. Rates
+// are probabilities between 0 and 1, rolled independently per request.
+type ChaosConfig struct {
+	Enabled       bool
+	Routes        []string      // Path prefixes that receive faults
+	Latency       time.Duration // Delay added when the latency roll hits
+	LatencyRate   float64
+	ErrorRate     float64 // Requests answered with 500 instead of reaching the handler
+	ResetRate     float64 // Requests whose connection is reset without a response
+	DuplicateRate float64 // Per-event chance of a duplicate in event poll responses
+	Seed          int64   // Fixed seed for reproducible runs; 0 seeds from the clock
+}
+
+// ParseChaosConfig parses fault injection settings from the config struct.
+// Chaos is never enabled in production, whatever the configuration says.
+func ParseChaosConfig(cfg *config.Config) ChaosConfig {
+	chaosConfig := ChaosConfig{
+		Enabled:       parseBool(cfg.ChaosEnabled, false),
+		Latency:       parseChaosLatency(cfg.ChaosLatency),
+		LatencyRate:   parseChaosRate("latency", cfg.ChaosLatencyRate),
+		ErrorRate:     parseChaosRate("error", cfg.ChaosErrorRate),
+		ResetRate:     parseChaosRate("reset", cfg.ChaosResetRate),
+		DuplicateRate: parseChaosRate("duplicate", cfg.ChaosDuplicateRate),
+	}
+
+	for _, route := range strings.Split(cfg.ChaosRoutes, ",") {
+		if route = strings.TrimSpace(route); route != "" {
+			chaosConfig.Routes = append(chaosConfig.Routes, route)
+		}
+	}
+
+	if seed, err := strconv.ParseInt(cfg.ChaosSeed, 10, 64); err == nil {
+		chaosConfig.Seed = seed
+	} else if cfg.ChaosSeed != "" {
+		slog.Warn("Invalid chaos seed, seeding from the clock", "configured", cfg.ChaosSeed)
+	}
+
+	if chaosConfig.Enabled && cfg.IsProduction() {
+		slog.Error("Chaos fault injection requested in production, leaving it disabled")
+		chaosConfig.Enabled = false
+	}
+
+	if chaosConfig.Enabled {
+		slog.Warn("Chaos fault injection enabled, for testing only",
+			"routes", chaosConfig.Routes,
+			"latency", chaosConfig.Latency,
+			"latency_rate", chaosConfig.LatencyRate,
+			"error_rate", chaosConfig.ErrorRate,
+			"reset_rate", chaosConfig.ResetRate,
+			"duplicate_rate", chaosConfig.DuplicateRate,
+			"seed", chaosConfig.Seed)
+	}
+
+	return chaosConfig
+}
+
+func parseChaosLatency(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	latency, err := time.ParseDuration(value)
+	if err != nil || latency < 0 {
+		slog.Warn("Invalid chaos latency, using default", "configured", value, "default", "0s")
+		return 0
+	}
+	return latency
+}
+
+func parseChaosRate(name, value string) float64 {
+	if value == "" {
+		return 0
+	}
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate < 0 || rate > 1 {
+		slog.Warn("Invalid chaos rate, using default", "fault", name, "configured", value, "default", 0)
+		return 0
+	}
+	return rate
+}
+
+// chaos rolls the dice for every faulted request; math/rand sources are not
+// safe for concurrent use, so rolls are serialized
+type chaos struct {
+	config ChaosConfig
+	mu     sync.Mutex
+	rng    *rand.Rand
+}
+
+func (c *chaos) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rng.Float64() < rate
+}
+
+func (c *chaos) matches(path string) bool {
+	if len(c.config.Routes) == 0 {
+		return true
+	}
+	path = apiversion.Canonical(path)
+	for _, route := range c.config.Routes {
+		if strings.HasPrefix(path, apiversion.Canonical(route)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ChaosMiddleware injects latency, 500s, connection resets and duplicated
+// event deliveries on the configured routes, so store sync fallbacks and
+// client retry policies can be exercised against a real server
+func ChaosMiddleware(chaosConfig ChaosConfig) func(http.Handler) http.Handler {
+	seed := chaosConfig.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	c := &chaos{config: chaosConfig, rng: rand.New(rand.NewSource(seed))}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !c.matches(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if c.roll(c.config.LatencyRate) {
+				select {
+				case <-time.After(c.config.Latency):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			if c.roll(c.config.ResetRate) {
+				slog.DebugContext(r.Context(), "Chaos: resetting connection", "path", r.URL.Path)
+				resetConnection(w)
+				return
+			}
+
+			if c.roll(c.config.ErrorRate) {
+				slog.DebugContext(r.Context(), "Chaos: injecting server error", "path", r.URL.Path)
+				writeErrorResponse(w, http.StatusInternalServerError, "internal_error", "Injected fault", nil)
+				return
+			}
+
+			if c.config.DuplicateRate > 0 && r.Method == http.MethodGet && apiversion.Canonical(r.URL.Path) == chaosEventsPath {
+				c.duplicateEvents(w, r, next)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// resetConnection drops the client connection without a response. Linger 0
+// makes the kernel send RST instead of a clean FIN, like a crashed peer.
+func resetConnection(w http.ResponseWriter) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		// Writers that cannot be hijacked still get an aborted response
+		panic(http.ErrAbortHandler)
+	}
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetLinger(0)
+	}
+	conn.Close()
+}
+
+// duplicateEvents buffers an event poll response and repeats some of its
+// events in place, as an at-least-once delivery would
+func (c *chaos) duplicateEvents(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	// Compression runs inside this middleware; ask for an identity body to rewrite
+	r.Header.Del("Accept-Encoding")
+
+	buffered := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	next.ServeHTTP(buffered, r)
+
+	body := buffered.body.Bytes()
+	contentType := buffered.header.Get("Content-Type")
+	if buffered.status == http.StatusOK {
+		body = c.rewriteEvents(r, body, contentType)
+	}
+
+	for key, values := range buffered.header {
+		w.Header()[key] = values
+	}
+	w.Header().Del("Content-Length")
+	w.WriteHeader(buffered.status)
+	w.Write(body)
+}
+
+func (c *chaos) rewriteEvents(r *http.Request, body []byte, contentType string) []byte {
+	var response models.EventsResponse
+	protobuf := strings.HasPrefix(contentType, events.ProtobufContentType)
+	var err error
+	if protobuf {
+		response, err = events.UnmarshalProtobuf(body)
+	} else {
+		err = json.Unmarshal(body, &response)
+	}
+	if err != nil {
+		return body
+	}
+
+	duplicated := make([]models.Event, 0, len(response.Events))
+	for _, event := range response.Events {
+		duplicated = append(duplicated, event)
+		if c.roll(c.config.DuplicateRate) {
+			duplicated = append(duplicated, event)
+		}
+	}
+	if len(duplicated) == len(response.Events) {
+		return body
+	}
+	slog.DebugContext(r.Context(), "Chaos: duplicating events",
+		"events", len(response.Events), "duplicates", len(duplicated)-len(response.Events))
+	response.Events = duplicated
+	response.Count = len(duplicated)
+
+	if protobuf {
+		return events.MarshalProtobuf(response)
+	}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(response)
+	return buf.Bytes()
+}
+
+// bufferedResponse holds a complete response so it can be rewritten
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(status int) { b.status = status }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
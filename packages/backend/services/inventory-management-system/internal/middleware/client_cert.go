@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type clientCertStoreKey struct{}
+
+// ClientCertMiddleware binds a verified TLS client certificate to a store: its
+// Common Name becomes the request's X-Store-ID and AuthMiddleware accepts the
+// request without an API key, as a principal bound to that store. A
+// conflicting X-Store-ID is rejected.
+func ClientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		storeID := strings.TrimSpace(r.TLS.VerifiedChains[0][0].Subject.CommonName)
+		if storeID == "" {
+			slog.WarnContext(r.Context(), "Client certificate has no Common Name, falling back to API key authentication",
+				"remote_addr", r.RemoteAddr)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if claimed := strings.TrimSpace(r.Header.Get(StoreIDHeader)); claimed != "" && claimed != storeID {
+			slog.WarnContext(r.Context(), "Store ID does not match client certificate",
+				"remote_addr", r.RemoteAddr,
+				"certificate_store_id", storeID,
+				"claimed_store_id", claimed)
+			writeErrorResponse(w, http.StatusForbidden, "store_id_mismatch", StoreIDHeader+" does not match the client certificate", nil)
+			return
+		}
+
+		r.Header.Set(StoreIDHeader, storeID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientCertStoreKey{}, storeID)))
+	})
+}
+
+// ClientCertStoreID returns the store authenticated by a client certificate, if any
+func ClientCertStoreID(ctx context.Context) string {
+	storeID, _ := ctx.Value(clientCertStoreKey{}).(string)
+	return storeID
+}
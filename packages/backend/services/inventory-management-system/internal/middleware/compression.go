@@ -0,0 +1,363 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"inventory-management-api/internal/config"
+
+	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// CompressionConfig holds response compression settings
+type CompressionConfig struct {
+	Enabled  bool
+	MinBytes int // Responses smaller than this are sent uncompressed
+}
+
+// ParseCompressionConfig parses response compression settings from the config struct
+func ParseCompressionConfig(cfg *config.Config) CompressionConfig {
+	compressionConfig := CompressionConfig{
+		Enabled:  parseBool(cfg.CompressionEnabled, true),
+		MinBytes: parseInt(cfg.CompressionMinBytes, 1024),
+	}
+
+	if compressionConfig.MinBytes < 0 {
+		slog.Warn("Invalid compression threshold, using default",
+			"configured", cfg.CompressionMinBytes, "default", 1024)
+		compressionConfig.MinBytes = 1024
+	}
+
+	slog.Info("Response compression configured",
+		"enabled", compressionConfig.Enabled,
+		"min_bytes", compressionConfig.MinBytes)
+
+	return compressionConfig
+}
+
+// Content codings the middleware can produce
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+// encoder is the part of gzip.Writer and zstd.Encoder the middleware uses
+type encoder interface {
+	io.Writer
+	Flush() error
+	Close() error
+	Reset(w io.Writer)
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// zstd encoders are kept to one goroutine and a 1 MiB window so pooled
+// encoders stay small and clients decode with little memory
+var zstdWriterPool = sync.Pool{
+	New: func() interface{} {
+		enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+		return enc
+	},
+}
+
+func getEncoder(encoding string, w io.Writer) encoder {
+	var enc encoder
+	if encoding == encodingZstd {
+		enc = zstdWriterPool.Get().(*zstd.Encoder)
+	} else {
+		enc = gzipWriterPool.Get().(*gzip.Writer)
+	}
+	enc.Reset(w)
+	return enc
+}
+
+func putEncoder(enc encoder) {
+	switch enc := enc.(type) {
+	case *zstd.Encoder:
+		zstdWriterPool.Put(enc)
+	case *gzip.Writer:
+		gzipWriterPool.Put(enc)
+	}
+}
+
+// compressionMetrics counts the bytes of compressed responses before and after
+// compression, by route and encoding
+type compressionMetrics struct {
+	uncompressed metric.Int64Counter
+	compressed   metric.Int64Counter
+}
+
+func newCompressionMetrics() *compressionMetrics {
+	meter := otel.Meter("inventory-management-api")
+
+	uncompressed, err := meter.Int64Counter(
+		"http_response_uncompressed_bytes_total",
+		metric.WithDescription("Body bytes of compressed responses before compression"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		slog.Warn("Failed to create uncompressed bytes counter", "error", err)
+		return nil
+	}
+	compressed, err := meter.Int64Counter(
+		"http_response_compressed_bytes_total",
+		metric.WithDescription("Body bytes of compressed responses as sent"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		slog.Warn("Failed to create compressed bytes counter", "error", err)
+		return nil
+	}
+	return &compressionMetrics{uncompressed: uncompressed, compressed: compressed}
+}
+
+// CompressionMiddleware compresses responses for clients that accept it, with
+// zstd when the client lists it and gzip otherwise. The body is buffered until
+// minBytes are written, so small responses such as single products and errors
+// go out unchanged, while large listings and event pages are compressed.
+func CompressionMiddleware(minBytes int) func(http.Handler) http.Handler {
+	metrics := newCompressionMetrics()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, minBytes: minBytes, encoding: encoding}
+			defer func() {
+				cw.finish()
+				cw.record(r, metrics)
+			}()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter holds back the status and the first minBytes of the body,
+// then decides once whether the response is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	minBytes int
+	encoding string
+	status   int
+	buf      []byte
+	started  bool
+	enc      encoder
+
+	// Body bytes written by the handler and, once compressing, sent
+	rawBytes  int64
+	sentBytes *countingWriter
+}
+
+// countingWriter counts the bytes the encoder writes to the response
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.started || cw.status != 0 {
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.rawBytes += int64(len(p))
+	if !cw.started {
+		if len(cw.buf)+len(p) < cw.minBytes {
+			cw.buf = append(cw.buf, p...)
+			return len(p), nil
+		}
+		cw.buf = append(cw.buf, p...)
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what is buffered; a response flushed before reaching the
+// threshold stays uncompressed
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		cw.start(false)
+	}
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start writes the header, compressed if allowed and worthwhile, followed by
+// the buffered body
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	header := cw.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Sniff before compressing, net/http would otherwise sniff gzip bytes
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if compress && cw.compressible(header) {
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		cw.sentBytes = &countingWriter{w: cw.ResponseWriter}
+		cw.enc = getEncoder(cw.encoding, cw.sentBytes)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buffered := cw.buf
+	cw.buf = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	if cw.enc != nil {
+		_, err := cw.enc.Write(buffered)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buffered)
+	return err
+}
+
+// compressible reports whether the response may carry a compressed body
+func (cw *compressWriter) compressible(header http.Header) bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(header.Get("Content-Type"), ";")[0]))
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		strings.HasSuffix(mediaType, "javascript") ||
+		strings.HasSuffix(mediaType, "protobuf")
+}
+
+// finish flushes a response that never reached the threshold and closes the
+// compressed stream
+func (cw *compressWriter) finish() {
+	if !cw.started {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return // Nothing written, let net/http send its default response
+		}
+		cw.start(false)
+	}
+	if cw.enc != nil {
+		cw.enc.Close()
+		putEncoder(cw.enc)
+		cw.enc = nil
+	}
+}
+
+// record logs and counts the size of a compressed response before and after
+// compression
+func (cw *compressWriter) record(r *http.Request, metrics *compressionMetrics) {
+	if cw.sentBytes == nil {
+		return
+	}
+
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+	slog.Debug("Response compressed",
+		"route", route,
+		"encoding", cw.encoding,
+		"uncompressed_bytes", cw.rawBytes,
+		"compressed_bytes", cw.sentBytes.n)
+
+	if metrics != nil {
+		attrs := metric.WithAttributes(
+			attribute.String("http.route", route),
+			attribute.String("encoding", cw.encoding),
+		)
+		metrics.uncompressed.Add(r.Context(), cw.rawBytes, attrs)
+		metrics.compressed.Add(r.Context(), cw.sentBytes.n, attrs)
+	}
+}
+
+// negotiateEncoding picks the coding for an Accept-Encoding header: zstd when
+// listed and weighted at least as high as gzip, then gzip, honoring q=0 and
+// the * wildcard. The wildcard alone selects gzip, which every client decodes.
+// An empty result sends the response uncompressed.
+func negotiateEncoding(acceptEncoding string) string {
+	gzipQ, zstdQ, wildcardQ := -1.0, -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch coding {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "zstd":
+			zstdQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+
+	if gzipQ < 0 {
+		gzipQ = wildcardQ
+	}
+	if zstdQ > 0 && zstdQ >= gzipQ {
+		return encodingZstd
+	}
+	if gzipQ > 0 {
+		return encodingGzip
+	}
+	return ""
+}
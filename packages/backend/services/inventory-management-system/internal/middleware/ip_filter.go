@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/config"
+)
+
+// IPRule lists the networks of one route prefix
+type IPRule struct {
+	Prefix   string
+	Networks []*net.IPNet
+}
+
+// IPFilterConfig holds the client IP allow and deny lists per route prefix
+// and the proxies whose X-Forwarded-For is believed
+type IPFilterConfig struct {
+	Allow          []IPRule // Longest prefix first
+	Deny           []IPRule
+	TrustedProxies []*net.IPNet
+}
+
+// Enabled reports whether any allow or deny list is configured
+func (c IPFilterConfig) Enabled() bool {
+	return len(c.Allow) > 0 || len(c.Deny) > 0
+}
+
+// ParseIPFilterConfig parses IP_ALLOWLIST and IP_DENYLIST, both
+// "prefix=cidr|cidr,prefix=cidr", and TRUSTED_PROXIES, a comma-separated CIDR
+// list. A bare IP is a single-address network. Unlike other settings a
+// malformed list is an error, so a typo never opens the admin API.
+func ParseIPFilterConfig(cfg *config.Config) (IPFilterConfig, error) {
+	var filterConfig IPFilterConfig
+	var err error
+	if filterConfig.Allow, err = parseIPRules(cfg.IPAllowlist); err != nil {
+		return IPFilterConfig{}, fmt.Errorf("invalid IP_ALLOWLIST: %w", err)
+	}
+	if filterConfig.Deny, err = parseIPRules(cfg.IPDenylist); err != nil {
+		return IPFilterConfig{}, fmt.Errorf("invalid IP_DENYLIST: %w", err)
+	}
+	if filterConfig.TrustedProxies, err = parseNetworks(strings.Split(cfg.TrustedProxies, ",")); err != nil {
+		return IPFilterConfig{}, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	if filterConfig.Enabled() {
+		slog.Info("IP filtering configured",
+			"allowlisted_prefixes", rulePrefixes(filterConfig.Allow),
+			"denylisted_prefixes", rulePrefixes(filterConfig.Deny),
+			"trusted_proxies", len(filterConfig.TrustedProxies))
+	}
+	return filterConfig, nil
+}
+
+// parseIPRules parses "prefix=cidr|cidr,..." sorted by longest prefix first
+func parseIPRules(value string) ([]IPRule, error) {
+	var rules []IPRule
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		prefix, cidrs, ok := strings.Cut(part, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("%q: expected /route-prefix=cidr|cidr", part)
+		}
+		// "/" is kept as the empty prefix, which matches every path
+		prefix = strings.TrimRight(prefix, "/")
+		networks, err := parseNetworks(strings.Split(cidrs, "|"))
+		if err != nil {
+			return nil, err
+		}
+		if len(networks) == 0 {
+			return nil, fmt.Errorf("%q: no networks", part)
+		}
+		rules = append(rules, IPRule{Prefix: prefix, Networks: networks})
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].Prefix) > len(rules[j].Prefix)
+	})
+	return rules, nil
+}
+
+// parseNetworks parses CIDRs and bare IPs, skipping blanks
+func parseNetworks(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP or CIDR", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an IP or CIDR", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func rulePrefixes(rules []IPRule) []string {
+	prefixes := make([]string, len(rules))
+	for i, rule := range rules {
+		prefixes[i] = rule.Prefix
+		if prefixes[i] == "" {
+			prefixes[i] = "/"
+		}
+	}
+	return prefixes
+}
+
+// matchesPrefix reports whether path is prefix or below it. Both are compared
+// in their /v1 form, so a rule for a /v1 prefix covers the route in every
+// API version.
+func matchesPrefix(path, prefix string) bool {
+	path, prefix = apiversion.Canonical(path), apiversion.Canonical(prefix)
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address the request came from. X-Forwarded-For is only
+// believed when the connection comes from a trusted proxy; it is then read
+// from the right, skipping trusted proxies, since a client can put anything at
+// the left of the header.
+func (c IPFilterConfig) ClientIP(r *http.Request) net.IP {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !containsIP(c.TrustedProxies, peer) {
+		return peer
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// Garbage in the chain; the last proxy we trust is the best we know
+			return peer
+		}
+		if !containsIP(c.TrustedProxies, hop) {
+			return hop
+		}
+		peer = hop
+	}
+	return peer
+}
+
+// check returns why ip may not reach path and the prefix of the rule that
+// decided it, or an empty reason when the request may pass. A denylisted
+// network is blocked on every prefix that lists it; the allowlist of the
+// longest matching prefix is the only one that applies.
+func (c IPFilterConfig) check(path string, ip net.IP) (reason, prefix string) {
+	for _, rule := range c.Deny {
+		if matchesPrefix(path, rule.Prefix) && (ip == nil || containsIP(rule.Networks, ip)) {
+			return "denylisted", rule.Prefix
+		}
+	}
+	for _, rule := range c.Allow {
+		if matchesPrefix(path, rule.Prefix) {
+			if ip == nil || !containsIP(rule.Networks, ip) {
+				return "not_allowlisted", rule.Prefix
+			}
+			return "", ""
+		}
+	}
+	return "", ""
+}
+
+// IPFilterMiddleware blocks requests whose client IP a route prefix denies or
+// does not allow, with 403 ip_forbidden, and logs every blocked attempt for
+// audit. It runs before authentication and rate limiting so blocked clients
+// neither learn whether their key is valid nor use up quota.
+func IPFilterMiddleware(filterConfig IPFilterConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := filterConfig.ClientIP(r)
+			reason, prefix := filterConfig.check(r.URL.Path, ip)
+			if reason == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if prefix == "" {
+				prefix = "/"
+			}
+
+			slog.WarnContext(r.Context(), "Request blocked by IP filter",
+				"audit", true,
+				"client_ip", ip.String(),
+				"remote_addr", r.RemoteAddr,
+				"forwarded_for", r.Header.Get("X-Forwarded-For"),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"rule_prefix", prefix,
+				"reason", reason,
+				"api_key", MaskAPIKey(r.Header.Get(APIKeyHeader)),
+				"store_id", r.Header.Get(StoreIDHeader),
+				"user_agent", r.UserAgent())
+			writeErrorResponse(w, http.StatusForbidden, "ip_forbidden", "Access from this address is not allowed", nil)
+		})
+	}
+}
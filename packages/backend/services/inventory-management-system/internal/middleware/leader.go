@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+
+	"inventory-management-api/internal/leader"
+	"inventory-management-api/internal/models"
+)
+
+// LeaderHeader names the instance that served a request a follower forwarded
+const LeaderHeader = "X-Served-By-Leader"
+
+// LeaderMiddleware forwards to the leader every request a follower may not
+// serve itself, that is every request servesLocally rejects. While no leader
+// is known, or the leader cannot be reached, those requests get 503
+// no_leader. On the leader every request is served.
+func LeaderMiddleware(elector *leader.Elector, servesLocally func(*http.Request) bool) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	proxies := make(map[string]*httputil.ReverseProxy) // By leader URL
+
+	proxyFor := func(record models.LeaderRecord) (*httputil.ReverseProxy, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if proxy, ok := proxies[record.URL]; ok {
+			return proxy, nil
+		}
+		target, err := url.Parse(record.URL)
+		if err != nil {
+			return nil, err
+		}
+		proxy := httputil.NewSingleHostReverseProxy(target)
+		proxy.ModifyResponse = func(resp *http.Response) error {
+			resp.Header.Set(LeaderHeader, record.InstanceID)
+			return nil
+		}
+		proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			slog.WarnContext(r.Context(), "Failed to forward request to leader",
+				"leader_url", record.URL,
+				"error", err)
+			writeErrorResponse(w, http.StatusServiceUnavailable, "no_leader", "The leader instance is unreachable, retry later", nil)
+		}
+		proxies[record.URL] = proxy
+		return proxy, nil
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if elector.IsLeader() || servesLocally(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			record, ok := elector.Leader()
+			if !ok || record.URL == "" {
+				writeErrorResponse(w, http.StatusServiceUnavailable, "no_leader", "No leader instance is known, retry later", nil)
+				return
+			}
+			proxy, err := proxyFor(record)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Invalid leader URL", "leader_url", record.URL, "error", err)
+				writeErrorResponse(w, http.StatusServiceUnavailable, "no_leader", "The leader instance is unreachable, retry later", nil)
+				return
+			}
+			slog.DebugContext(r.Context(), "Forwarding request to leader",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"leader_id", record.InstanceID)
+			proxy.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// MaintenanceHeader is set on every response while maintenance mode is on, so
+// stores notice it on their reads and event polls, not only on refused writes
+const MaintenanceHeader = "X-Maintenance-Mode"
+
+// maintenanceHeaderValue is the only maintenance mode there is
+const maintenanceHeaderValue = "read-only"
+
+// defaultMaintenanceMessage is sent when maintenance was turned on without one
+const defaultMaintenanceMessage = "Central API is in read-only maintenance, retry later"
+
+// Maintenance holds the read-only maintenance switch. It starts from the
+// configuration and is flipped by admins at runtime; the switch is not kept
+// across restarts.
+type Maintenance struct {
+	mu     sync.RWMutex
+	status models.MaintenanceStatus
+}
+
+// NewMaintenance creates the maintenance switch from MAINTENANCE_MODE,
+// MAINTENANCE_MESSAGE and MAINTENANCE_RETRY_AFTER
+func NewMaintenance(cfg *config.Config) *Maintenance {
+	status := models.MaintenanceStatus{
+		Enabled:           parseBool(cfg.MaintenanceMode, false),
+		Message:           cfg.MaintenanceMessage,
+		RetryAfterSeconds: parseInt(cfg.MaintenanceRetryAfter, 60),
+	}
+	if status.RetryAfterSeconds <= 0 {
+		slog.Warn("Invalid maintenance Retry-After, using default",
+			"configured", cfg.MaintenanceRetryAfter, "default", 60)
+		status.RetryAfterSeconds = 60
+	}
+	if status.Enabled {
+		status.Since = clock.Timestamp()
+		slog.Warn("Starting in read-only maintenance mode, writes are refused",
+			"message", status.Message,
+			"retry_after_seconds", status.RetryAfterSeconds)
+	}
+	return &Maintenance{status: status}
+}
+
+// Status returns the current maintenance state
+func (m *Maintenance) Status() models.MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Set turns maintenance mode on or off on behalf of actor. A retryAfterSeconds
+// of 0 keeps the current value.
+func (m *Maintenance) Set(enabled bool, message string, retryAfterSeconds int, actor string) models.MaintenanceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if enabled != m.status.Enabled {
+		m.status.Since = clock.Timestamp()
+	}
+	m.status.Enabled = enabled
+	m.status.Message = message
+	if retryAfterSeconds > 0 {
+		m.status.RetryAfterSeconds = retryAfterSeconds
+	}
+	m.status.ChangedBy = actor
+	return m.status
+}
+
+// MaintenanceMiddleware refuses requests with 503 maintenance_mode and
+// Retry-After while maintenance mode is on, unless their route is in served
+// (method and route template, see openapi.MaintenanceRoutes). Reads are
+// always in served, so only writes are refused.
+func MaintenanceMiddleware(maintenance *Maintenance, served map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := maintenance.Status()
+			if !status.Enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set(MaintenanceHeader, maintenanceHeaderValue)
+
+			routeKey := r.Method + " " + r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					routeKey = r.Method + " " + template
+				}
+			}
+			if served[routeKey] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			message := status.Message
+			if message == "" {
+				message = defaultMaintenanceMessage
+			}
+			slog.InfoContext(r.Context(), "Write refused during maintenance",
+				"route", routeKey,
+				"principal", RequestActor(r))
+			w.Header().Set("Retry-After", strconv.Itoa(status.RetryAfterSeconds))
+			writeErrorResponse(w, http.StatusServiceUnavailable, "maintenance_mode", message, nil)
+		})
+	}
+}
@@ -4,14 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"math"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/clock"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/requestid"
 )
 
 // RateLimitType defines the type of rate limiting
@@ -21,8 +27,19 @@ const (
 	RateLimitTypeIP     RateLimitType = "ip"
 	RateLimitTypeGlobal RateLimitType = "global"
 	RateLimitTypeBoth   RateLimitType = "both"
+	// RateLimitTypePrincipal limits each API key or store separately using tiers
+	RateLimitTypePrincipal RateLimitType = "principal"
 )
 
+// Headers used to identify the principal of a request
+const (
+	APIKeyHeader  = "X-API-Key"
+	StoreIDHeader = "X-Store-ID"
+)
+
+// UpdatePriorityHeader classifies an inventory update as checkout, sync or bulk
+const UpdatePriorityHeader = "X-Update-Priority"
+
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
 	Enabled                bool
@@ -30,33 +47,53 @@ type RateLimitConfig struct {
 	RequestsPerMinute      int
 	WindowMinutes          int
 	AdminRequestsPerMinute int
+	Algorithm              RateLimitAlgorithm // fixed_window (default) or token_bucket
+	BurstSize              int                // Token bucket capacity; 0 means the per-window limit
+	Tiers                  map[string]int     // Tier name -> requests per window
+	PrincipalTiers         map[string]string  // API key, store ID or JWT subject -> tier name
 }
 
+// RateLimitAlgorithm selects how requests are counted
+type RateLimitAlgorithm string
+
+const (
+	RateLimitAlgorithmFixedWindow RateLimitAlgorithm = "fixed_window"
+	RateLimitAlgorithmTokenBucket RateLimitAlgorithm = "token_bucket"
+)
+
 // RateLimitEntry represents a rate limit entry
 type RateLimitEntry struct {
 	Count     int
 	ResetTime time.Time
 	mutex     sync.RWMutex
+
+	// Token bucket state
+	Tokens     float64
+	LastRefill time.Time
 }
 
 // RateLimiter manages rate limiting
 type RateLimiter struct {
-	config        RateLimitConfig
+	config        atomic.Pointer[RateLimitConfig] // Replaced whole by UpdateLimits
 	ipLimits      map[string]*RateLimitEntry
+	principals    map[string]*principalEntry
 	globalLimit   *RateLimitEntry
 	mutex         sync.RWMutex
 	cleanupTicker *time.Ticker
 	stopCleanup   chan struct{}
+	clock         clock.Clock // Windows and refills are measured on it; the wall clock by default
 }
 
 // NewRateLimiter creates a new rate limiter
 func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 	rl := &RateLimiter{
-		config:      config,
 		ipLimits:    make(map[string]*RateLimitEntry),
+		principals:  make(map[string]*principalEntry),
 		globalLimit: &RateLimitEntry{},
 		stopCleanup: make(chan struct{}),
+		clock:       clock.System{},
 	}
+	rl.config.Store(&config)
 
 	// Start cleanup goroutine to remove expired entries
 	rl.cleanupTicker = time.NewTicker(time.Minute)
@@ -67,11 +104,24 @@ func NewRateLimiter(config RateLimitConfig) *RateLimiter {
 		"type", config.Type,
 		"requests_per_minute", config.RequestsPerMinute,
 		"window_minutes", config.WindowMinutes,
-		"admin_requests_per_minute", config.AdminRequestsPerMinute)
+		"admin_requests_per_minute", config.AdminRequestsPerMinute,
+		"algorithm", config.Algorithm,
+		"burst_size", config.BurstSize)
 
 	return rl
 }
 
+// SetClock makes the limiter measure windows and token refills on c, e.g. a
+// manual clock in tests. Call it before the limiter is used.
+func (rl *RateLimiter) SetClock(c clock.Clock) {
+	rl.clock = c
+}
+
+// Now returns the current time on the limiter's clock
+func (rl *RateLimiter) Now() time.Time {
+	return rl.clock.Now()
+}
+
 // Stop stops the rate limiter and cleanup goroutine
 func (rl *RateLimiter) Stop() {
 	if rl.cleanupTicker != nil {
@@ -86,7 +136,7 @@ func (rl *RateLimiter) cleanupExpiredEntries() {
 		select {
 		case <-rl.cleanupTicker.C:
 			rl.mutex.Lock()
-			now := time.Now()
+			now := rl.clock.Now()
 			for ip, entry := range rl.ipLimits {
 				entry.mutex.RLock()
 				expired := now.After(entry.ResetTime)
@@ -97,6 +147,16 @@ func (rl *RateLimiter) cleanupExpiredEntries() {
 				}
 			}
 
+			for principal, entry := range rl.principals {
+				entry.mutex.RLock()
+				expired := now.After(entry.ResetTime)
+				entry.mutex.RUnlock()
+
+				if expired {
+					delete(rl.principals, principal)
+				}
+			}
+
 			// Reset global limit if expired
 			rl.globalLimit.mutex.RLock()
 			globalExpired := now.After(rl.globalLimit.ResetTime)
@@ -104,8 +164,7 @@ func (rl *RateLimiter) cleanupExpiredEntries() {
 
 			if globalExpired {
 				rl.globalLimit.mutex.Lock()
-				rl.globalLimit.Count = 0
-				rl.globalLimit.ResetTime = time.Time{}
+				rl.globalLimit.reset()
 				rl.globalLimit.mutex.Unlock()
 			}
 
@@ -118,7 +177,14 @@ func (rl *RateLimiter) cleanupExpiredEntries() {
 
 // IsAllowed checks if a request is allowed based on rate limiting rules
 func (rl *RateLimiter) IsAllowed(clientIP string, isAdmin bool) (bool, *RateLimitInfo) {
-	if !rl.config.Enabled {
+	return rl.IsAllowedForPrincipal(clientIP, "", isAdmin)
+}
+
+// IsAllowedForPrincipal checks a request identified by a principal (API key or
+// store ID). Principal limiting falls back to the client IP when principal is empty.
+func (rl *RateLimiter) IsAllowedForPrincipal(clientIP, principal string, isAdmin bool) (bool, *RateLimitInfo) {
+	config := rl.config.Load()
+	if !config.Enabled {
 		return true, &RateLimitInfo{
 			Limit:     -1, // Unlimited
 			Remaining: -1,
@@ -126,30 +192,38 @@ func (rl *RateLimiter) IsAllowed(clientIP string, isAdmin bool) (bool, *RateLimi
 		}
 	}
 
-	now := time.Now()
-	windowDuration := time.Duration(rl.config.WindowMinutes) * time.Minute
+	now := rl.clock.Now()
+	windowDuration := time.Duration(config.WindowMinutes) * time.Minute
 
 	// Determine the limit based on whether it's an admin request
-	limit := rl.config.RequestsPerMinute
-	if isAdmin && rl.config.AdminRequestsPerMinute > 0 {
-		limit = rl.config.AdminRequestsPerMinute
+	limit := config.RequestsPerMinute
+	if isAdmin && config.AdminRequestsPerMinute > 0 {
+		limit = config.AdminRequestsPerMinute
 	}
 
 	var ipAllowed, globalAllowed bool = true, true
 	var ipInfo, globalInfo *RateLimitInfo
 
+	// Check per-principal tiered limiting
+	if config.Type == RateLimitTypePrincipal {
+		if principal == "" {
+			principal = "ip:" + clientIP
+		}
+		return rl.checkPrincipalLimit(principal, isAdmin, windowDuration, now)
+	}
+
 	// Check IP-based rate limiting
-	if rl.config.Type == RateLimitTypeIP || rl.config.Type == RateLimitTypeBoth {
+	if config.Type == RateLimitTypeIP || config.Type == RateLimitTypeBoth {
 		ipAllowed, ipInfo = rl.checkIPLimit(clientIP, limit, windowDuration, now)
 	}
 
 	// Check global rate limiting
-	if rl.config.Type == RateLimitTypeGlobal || rl.config.Type == RateLimitTypeBoth {
+	if config.Type == RateLimitTypeGlobal || config.Type == RateLimitTypeBoth {
 		globalAllowed, globalInfo = rl.checkGlobalLimit(limit, windowDuration, now)
 	}
 
 	// For "both" type, use the most restrictive limit
-	if rl.config.Type == RateLimitTypeBoth {
+	if config.Type == RateLimitTypeBoth {
 		allowed := ipAllowed && globalAllowed
 
 		// Return the most restrictive info
@@ -162,7 +236,7 @@ func (rl *RateLimiter) IsAllowed(clientIP string, isAdmin bool) (bool, *RateLimi
 	}
 
 	// Return the appropriate result based on type
-	if rl.config.Type == RateLimitTypeIP {
+	if config.Type == RateLimitTypeIP {
 		return ipAllowed, ipInfo
 	}
 
@@ -183,25 +257,61 @@ func (rl *RateLimiter) checkIPLimit(clientIP string, limit int, windowDuration t
 	entry.mutex.Lock()
 	defer entry.mutex.Unlock()
 
-	// Reset if window has expired
-	if now.After(entry.ResetTime) {
-		entry.Count = 0
-		entry.ResetTime = now.Add(windowDuration)
+	return rl.consume(entry, limit, windowDuration, now)
+}
+
+// principalEntry tracks consumption for one API key or store
+type principalEntry struct {
+	RateLimitEntry
+	Tier  string
+	Limit int
+}
+
+// TierForPrincipal returns the tier name and limit for a principal. Unmapped
+// principals get the default tier, i.e. RequestsPerMinute.
+func (rl *RateLimiter) TierForPrincipal(principal string, isAdmin bool) (string, int) {
+	config := rl.config.Load()
+	if isAdmin && config.AdminRequestsPerMinute > 0 {
+		return "admin", config.AdminRequestsPerMinute
 	}
 
-	info := &RateLimitInfo{
-		Limit:     limit,
-		Remaining: limit - entry.Count - 1, // -1 for current request
-		ResetTime: entry.ResetTime,
+	name := principal
+	if idx := strings.Index(principal, ":"); idx >= 0 {
+		name = principal[idx+1:]
+	}
+	if tier, ok := config.PrincipalTiers[name]; ok {
+		if limit, ok := config.Tiers[tier]; ok {
+			return tier, limit
+		}
 	}
 
-	if entry.Count >= limit {
-		return false, info
+	return "default", config.RequestsPerMinute
+}
+
+// checkPrincipalLimit checks per-principal rate limiting. Admin requests are
+// counted separately so admin traffic does not eat into a store's budget.
+func (rl *RateLimiter) checkPrincipalLimit(principal string, isAdmin bool, windowDuration time.Duration, now time.Time) (bool, *RateLimitInfo) {
+	tier, limit := rl.TierForPrincipal(principal, isAdmin)
+	key := principal
+	if isAdmin {
+		key = "admin|" + principal
 	}
 
-	entry.Count++
-	info.Remaining = limit - entry.Count
-	return true, info
+	rl.mutex.Lock()
+	entry, exists := rl.principals[key]
+	if !exists {
+		entry = &principalEntry{}
+		rl.principals[key] = entry
+	}
+	rl.mutex.Unlock()
+
+	entry.mutex.Lock()
+	defer entry.mutex.Unlock()
+
+	entry.Tier = tier
+	entry.Limit = limit
+
+	return rl.consume(&entry.RateLimitEntry, limit, windowDuration, now)
 }
 
 // checkGlobalLimit checks global rate limiting
@@ -209,24 +319,38 @@ func (rl *RateLimiter) checkGlobalLimit(limit int, windowDuration time.Duration,
 	rl.globalLimit.mutex.Lock()
 	defer rl.globalLimit.mutex.Unlock()
 
+	return rl.consume(rl.globalLimit, limit, windowDuration, now)
+}
+
+// consume takes one request from the entry using the configured algorithm (caller must hold entry.mutex)
+func (rl *RateLimiter) consume(entry *RateLimitEntry, limit int, windowDuration time.Duration, now time.Time) (bool, *RateLimitInfo) {
+	config := rl.config.Load()
+	if config.Algorithm == RateLimitAlgorithmTokenBucket {
+		return consumeTokenBucket(entry, limit, config.BurstSize, windowDuration, now)
+	}
+	return consumeFixedWindow(entry, limit, windowDuration, now)
+}
+
+// consumeFixedWindow counts requests in fixed windows that reset at ResetTime
+func consumeFixedWindow(entry *RateLimitEntry, limit int, windowDuration time.Duration, now time.Time) (bool, *RateLimitInfo) {
 	// Reset if window has expired
-	if now.After(rl.globalLimit.ResetTime) {
-		rl.globalLimit.Count = 0
-		rl.globalLimit.ResetTime = now.Add(windowDuration)
+	if now.After(entry.ResetTime) {
+		entry.Count = 0
+		entry.ResetTime = now.Add(windowDuration)
 	}
 
 	info := &RateLimitInfo{
 		Limit:     limit,
-		Remaining: limit - rl.globalLimit.Count - 1, // -1 for current request
-		ResetTime: rl.globalLimit.ResetTime,
+		Remaining: limit - entry.Count - 1, // -1 for current request
+		ResetTime: entry.ResetTime,
 	}
 
-	if rl.globalLimit.Count >= limit {
+	if entry.Count >= limit {
 		return false, info
 	}
 
-	rl.globalLimit.Count++
-	info.Remaining = limit - rl.globalLimit.Count
+	entry.Count++
+	info.Remaining = limit - entry.Count
 	return true, info
 }
 
@@ -237,8 +361,12 @@ type RateLimitInfo struct {
 	ResetTime time.Time
 }
 
-// RateLimitMiddleware creates a rate limiting middleware using an existing rate limiter
-func RateLimitMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handler {
+// RateLimitMiddleware creates a rate limiting middleware using an existing rate
+// limiter. It goes after AuthMiddleware, so principal limiting counts each
+// authenticated caller. clientIP resolves the caller's address, normally
+// IPFilterConfig.ClientIP so forwarding headers are only believed from
+// trusted proxies.
+func RateLimitMiddleware(rateLimiter *RateLimiter, clientIP func(*http.Request) net.IP) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip rate limiting for health check
@@ -247,17 +375,19 @@ func RateLimitMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handl
 				return
 			}
 
-			clientIP := getClientIP(r)
-			isAdmin := strings.HasPrefix(r.URL.Path, "/v1/admin")
+			ip := requestIP(r, clientIP)
+			isAdmin := strings.HasPrefix(apiversion.Canonical(r.URL.Path), "/v1/admin")
+			principal := getPrincipal(r)
 
-			allowed, info := rateLimiter.IsAllowed(clientIP, isAdmin)
+			allowed, info := rateLimiter.IsAllowedForPrincipal(ip, principal, isAdmin)
 
 			// Set rate limit headers
 			setRateLimitHeaders(w, info)
 
 			if !allowed {
-				slog.Warn("Rate limit exceeded",
-					"client_ip", clientIP,
+				slog.WarnContext(r.Context(), "Rate limit exceeded",
+					"client_ip", ip,
+					"principal", maskPrincipal(principal),
 					"path", r.URL.Path,
 					"method", r.Method,
 					"is_admin", isAdmin,
@@ -265,12 +395,12 @@ func RateLimitMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handl
 					"remaining", info.Remaining,
 					"reset_time", info.ResetTime.Format(time.RFC3339))
 
-				writeRateLimitErrorResponse(w, info)
+				writeRateLimitErrorResponse(w, info, rateLimiter.Now())
 				return
 			}
 
-			slog.Debug("Rate limit check passed",
-				"client_ip", clientIP,
+			slog.DebugContext(r.Context(), "Rate limit check passed",
+				"client_ip", ip,
 				"path", r.URL.Path,
 				"remaining", info.Remaining)
 
@@ -279,34 +409,40 @@ func RateLimitMiddleware(rateLimiter *RateLimiter) func(http.Handler) http.Handl
 	}
 }
 
-// getClientIP extracts the client IP address from the request
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first (for load balancers/proxies)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// X-Forwarded-For can contain multiple IPs, take the first one
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if net.ParseIP(ip) != nil {
-				return ip
-			}
-		}
+// getPrincipal identifies who is calling by the principal AuthMiddleware
+// resolved, never by headers a client can set freely. Requests without one are
+// limited by client IP.
+func getPrincipal(r *http.Request) string {
+	if principal, ok := authz.PrincipalFromContext(r.Context()); ok {
+		return principal.ID
 	}
+	return ""
+}
 
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		if net.ParseIP(xri) != nil {
-			return xri
-		}
-	}
+// MaskAPIKey hides most of an API key so it can be logged or reported
+func MaskAPIKey(apiKey string) string {
+	return maskPrincipal("key:" + apiKey)
+}
 
-	// Fall back to RemoteAddr
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
+// maskPrincipal hides most of an API key so it can be logged or reported
+func maskPrincipal(principal string) string {
+	apiKey, ok := strings.CutPrefix(principal, "key:")
+	if !ok {
+		return principal
+	}
+	if len(apiKey) <= 4 {
+		return "key:****"
 	}
+	return "key:" + apiKey[:4] + "****"
+}
 
-	return host
+// requestIP returns the address to limit r by, falling back to RemoteAddr
+// when clientIP cannot parse one
+func requestIP(r *http.Request, clientIP func(*http.Request) net.IP) string {
+	if ip := clientIP(r); ip != nil {
+		return ip.String()
+	}
+	return r.RemoteAddr
 }
 
 // setRateLimitHeaders sets rate limit headers in the response
@@ -321,20 +457,22 @@ func setRateLimitHeaders(w http.ResponseWriter, info *RateLimitInfo) {
 	}
 }
 
-// writeRateLimitErrorResponse writes a rate limit exceeded error response
-func writeRateLimitErrorResponse(w http.ResponseWriter, info *RateLimitInfo) {
+// writeRateLimitErrorResponse writes a rate limit exceeded error response;
+// Retry-After counts from now on the limiter's clock
+func writeRateLimitErrorResponse(w http.ResponseWriter, info *RateLimitInfo, now time.Time) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusTooManyRequests)
 
 	retryAfter := ""
 	if !info.ResetTime.IsZero() {
-		retryAfter = fmt.Sprintf("%.0f", time.Until(info.ResetTime).Seconds())
+		retryAfter = fmt.Sprintf("%.0f", math.Ceil(info.ResetTime.Sub(now).Seconds()))
 		w.Header().Set("Retry-After", retryAfter)
 	}
 
 	errorResp := models.ErrorResponse{
-		Code:    "rate_limit_exceeded",
-		Message: "Rate limit exceeded. Please try again later.",
+		Code:      "rate_limit_exceeded",
+		Message:   "Rate limit exceeded. Please try again later.",
+		RequestID: w.Header().Get(requestid.Header),
 		Details: []models.ErrorDetail{
 			{
 				Field: "rate_limit",
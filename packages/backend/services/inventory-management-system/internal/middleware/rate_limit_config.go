@@ -2,9 +2,9 @@ package middleware
 
 import (
 	"log/slog"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"inventory-management-api/internal/config"
 )
@@ -17,6 +17,17 @@ func ParseRateLimitConfig(cfg *config.Config) RateLimitConfig {
 		RequestsPerMinute:      parseInt(cfg.RateLimitRequestsPerMinute, 100),
 		WindowMinutes:          parseInt(cfg.RateLimitWindowMinutes, 1),
 		AdminRequestsPerMinute: parseInt(cfg.RateLimitAdminRequestsPerMinute, 50),
+		Algorithm:              parseRateLimitAlgorithm(cfg.RateLimitAlgorithm),
+		BurstSize:              parseInt(cfg.RateLimitBurstSize, 0),
+		Tiers:                  parseTiers(cfg.RateLimitTiers),
+		PrincipalTiers:         parsePrincipalTiers(cfg.RateLimitPrincipalTiers),
+	}
+
+	for principal, tier := range rateLimitConfig.PrincipalTiers {
+		if _, ok := rateLimitConfig.Tiers[tier]; !ok {
+			slog.Warn("Principal mapped to unknown rate limit tier, using default limit",
+				"principal", maskPrincipal("key:"+principal), "tier", tier)
+		}
 	}
 
 	// Validate configuration
@@ -38,13 +49,23 @@ func ParseRateLimitConfig(cfg *config.Config) RateLimitConfig {
 		rateLimitConfig.AdminRequestsPerMinute = 50
 	}
 
+	if rateLimitConfig.BurstSize < 0 {
+		slog.Warn("Invalid rate limit burst size, using the per-window limit",
+			"configured", cfg.RateLimitBurstSize)
+		rateLimitConfig.BurstSize = 0
+	}
+
 	// Log the final configuration
 	slog.Info("Rate limiting configuration parsed",
 		"enabled", rateLimitConfig.Enabled,
 		"type", rateLimitConfig.Type,
 		"requests_per_minute", rateLimitConfig.RequestsPerMinute,
 		"window_minutes", rateLimitConfig.WindowMinutes,
-		"admin_requests_per_minute", rateLimitConfig.AdminRequestsPerMinute)
+		"admin_requests_per_minute", rateLimitConfig.AdminRequestsPerMinute,
+		"algorithm", rateLimitConfig.Algorithm,
+		"burst_size", rateLimitConfig.BurstSize,
+		"tiers", rateLimitConfig.Tiers,
+		"mapped_principals", len(rateLimitConfig.PrincipalTiers))
 
 	return rateLimitConfig
 }
@@ -96,6 +117,8 @@ func parseRateLimitType(value string) RateLimitType {
 		return RateLimitTypeGlobal
 	case "both":
 		return RateLimitTypeBoth
+	case "principal":
+		return RateLimitTypePrincipal
 	default:
 		slog.Warn("Invalid rate limit type, using default",
 			"value", value, "default", "ip")
@@ -103,22 +126,93 @@ func parseRateLimitType(value string) RateLimitType {
 	}
 }
 
+// parseRateLimitAlgorithm parses the rate limit algorithm with validation
+func parseRateLimitAlgorithm(value string) RateLimitAlgorithm {
+	switch strings.ToLower(value) {
+	case "", "fixed_window":
+		return RateLimitAlgorithmFixedWindow
+	case "token_bucket":
+		return RateLimitAlgorithmTokenBucket
+	default:
+		slog.Warn("Invalid rate limit algorithm, using default",
+			"value", value, "default", "fixed_window")
+		return RateLimitAlgorithmFixedWindow
+	}
+}
+
+// parseTiers parses "name:limit,name:limit" into a tier map
+func parseTiers(value string) map[string]int {
+	tiers := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		name, limitStr, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			if part != "" {
+				slog.Warn("Invalid rate limit tier, expected name:limit", "value", part)
+			}
+			continue
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit <= 0 {
+			slog.Warn("Invalid rate limit tier limit", "tier", name, "value", limitStr)
+			continue
+		}
+		tiers[strings.TrimSpace(name)] = limit
+	}
+	return tiers
+}
+
+// parsePrincipalTiers parses "principal:tier,principal:tier" where principal
+// is an API key or a store ID
+func parsePrincipalTiers(value string) map[string]string {
+	mapping := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		principal, tier, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || principal == "" || tier == "" {
+			if part != "" {
+				slog.Warn("Invalid rate limit principal mapping, expected principal:tier")
+			}
+			continue
+		}
+		mapping[strings.TrimSpace(principal)] = strings.TrimSpace(tier)
+	}
+	return mapping
+}
+
+// PrincipalUsage reports the current consumption of one principal
+type PrincipalUsage struct {
+	Principal string `json:"principal"`
+	Tier      string `json:"tier"`
+	Limit     int    `json:"limit"`
+	Used      int    `json:"used"`
+	Remaining int    `json:"remaining"`
+	ResetTime string `json:"reset_time"`
+}
+
 // GetRateLimitStats returns current rate limiting statistics
 func (rl *RateLimiter) GetRateLimitStats() map[string]interface{} {
 	rl.mutex.RLock()
 	defer rl.mutex.RUnlock()
 
+	current := rl.config.Load()
 	stats := map[string]interface{}{
-		"enabled":                   rl.config.Enabled,
-		"type":                      string(rl.config.Type),
-		"requests_per_minute":       rl.config.RequestsPerMinute,
-		"window_minutes":            rl.config.WindowMinutes,
-		"admin_requests_per_minute": rl.config.AdminRequestsPerMinute,
+		"enabled":                   current.Enabled,
+		"type":                      string(current.Type),
+		"requests_per_minute":       current.RequestsPerMinute,
+		"window_minutes":            current.WindowMinutes,
+		"algorithm":                 string(current.Algorithm),
+		"burst_size":                current.BurstSize,
+		"admin_requests_per_minute": current.AdminRequestsPerMinute,
 		"active_ip_limits":          len(rl.ipLimits),
 	}
 
+	if current.Type == RateLimitTypePrincipal {
+		stats["tiers"] = current.Tiers
+		stats["principals"] = rl.principalUsage()
+	}
+
 	// Add global limit stats if applicable
-	if rl.config.Type == RateLimitTypeGlobal || rl.config.Type == RateLimitTypeBoth {
+	if current.Type == RateLimitTypeGlobal || current.Type == RateLimitTypeBoth {
 		rl.globalLimit.mutex.RLock()
 		stats["global_count"] = rl.globalLimit.Count
 		stats["global_reset_time"] = rl.globalLimit.ResetTime.Format("2006-01-02T15:04:05Z07:00")
@@ -128,6 +222,34 @@ func (rl *RateLimiter) GetRateLimitStats() map[string]interface{} {
 	return stats
 }
 
+// Config returns the limits currently in force
+func (rl *RateLimiter) Config() RateLimitConfig {
+	return *rl.config.Load()
+}
+
+// UpdateLimits applies new request limits, window, burst size and tiers at
+// runtime. Enabled, Type and Algorithm keep their startup values because the
+// counters already tracked depend on them. Existing counters are kept, so a
+// lower limit takes effect immediately for callers that already used it up.
+func (rl *RateLimiter) UpdateLimits(limits RateLimitConfig) {
+	updated := *rl.config.Load()
+	updated.RequestsPerMinute = limits.RequestsPerMinute
+	updated.WindowMinutes = limits.WindowMinutes
+	updated.AdminRequestsPerMinute = limits.AdminRequestsPerMinute
+	updated.BurstSize = limits.BurstSize
+	updated.Tiers = limits.Tiers
+	updated.PrincipalTiers = limits.PrincipalTiers
+	rl.config.Store(&updated)
+
+	slog.Info("Rate limits updated",
+		"requests_per_minute", updated.RequestsPerMinute,
+		"window_minutes", updated.WindowMinutes,
+		"admin_requests_per_minute", updated.AdminRequestsPerMinute,
+		"burst_size", updated.BurstSize,
+		"tiers", len(updated.Tiers),
+		"mapped_principals", len(updated.PrincipalTiers))
+}
+
 // ResetRateLimits resets all rate limiting counters (useful for testing)
 func (rl *RateLimiter) ResetRateLimits() {
 	rl.mutex.Lock()
@@ -135,12 +257,42 @@ func (rl *RateLimiter) ResetRateLimits() {
 
 	// Clear all IP limits
 	rl.ipLimits = make(map[string]*RateLimitEntry)
+	rl.principals = make(map[string]*principalEntry)
 
 	// Reset global limit
 	rl.globalLimit.mutex.Lock()
-	rl.globalLimit.Count = 0
-	rl.globalLimit.ResetTime = time.Time{}
+	rl.globalLimit.reset()
 	rl.globalLimit.mutex.Unlock()
 
 	slog.Info("Rate limits reset")
 }
+
+// principalUsage snapshots per-principal consumption, masking API keys (caller must hold rl.mutex)
+func (rl *RateLimiter) principalUsage() []PrincipalUsage {
+	now := rl.clock.Now()
+	usage := make([]PrincipalUsage, 0, len(rl.principals))
+	for key, entry := range rl.principals {
+		entry.mutex.RLock()
+		used := entry.Count
+		if now.After(entry.ResetTime) {
+			used = 0
+		}
+		usage = append(usage, PrincipalUsage{
+			Principal: maskPrincipal(strings.TrimPrefix(key, "admin|")),
+			Tier:      entry.Tier,
+			Limit:     entry.Limit,
+			Used:      used,
+			Remaining: entry.Limit - used,
+			ResetTime: entry.ResetTime.Format("2006-01-02T15:04:05Z07:00"),
+		})
+		entry.mutex.RUnlock()
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Principal == usage[j].Principal {
+			return usage[i].Tier < usage[j].Tier
+		}
+		return usage[i].Principal < usage[j].Principal
+	})
+	return usage
+}
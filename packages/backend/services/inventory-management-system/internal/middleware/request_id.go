@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"inventory-management-api/internal/requestid"
+)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID (or generates one),
+// echoes it in the response, stores it in the request context for contextual
+// log lines and logs one structured line per completed request
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		ctx := requestid.WithRequestID(r.Context(), id)
+
+		recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		slog.InfoContext(ctx, "HTTP request completed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", recorder.statusCode,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+			"store_id", r.Header.Get("X-Store-ID"))
+	})
+}
+
+// statusRecorder captures the response status code for request logging
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Flush passes flushes of streamed responses through to the client
+func (w *statusRecorder) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,261 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/telemetry"
+)
+
+// RouteTimeout overrides the deadline of the routes below a prefix
+type RouteTimeout struct {
+	Prefix  string
+	Timeout time.Duration
+}
+
+// TimeoutConfig holds the deadline of each endpoint group
+type TimeoutConfig struct {
+	Read        time.Duration
+	Write       time.Duration
+	Admin       time.Duration
+	Stream      time.Duration  // NDJSON product listings and catalog snapshots
+	EventsGrace time.Duration  // Added to the wait of an events long poll
+	Routes      []RouteTimeout // Longest prefix first
+}
+
+// ParseTimeoutConfig parses the request deadlines from the config struct.
+// REQUEST_TIMEOUT_ROUTES is "prefix=duration,..."; malformed entries are
+// skipped with a warning.
+func ParseTimeoutConfig(cfg *config.Config) TimeoutConfig {
+	timeoutConfig := TimeoutConfig{
+		Read:        parseDuration(cfg.RequestTimeoutRead, 2*time.Second),
+		Write:       parseDuration(cfg.RequestTimeoutWrite, 10*time.Second),
+		Admin:       parseDuration(cfg.RequestTimeoutAdmin, 30*time.Second),
+		Stream:      parseDuration(cfg.RequestTimeoutStream, 2*time.Minute),
+		EventsGrace: parseDuration(cfg.RequestTimeoutEventsGrace, 5*time.Second),
+	}
+
+	for _, part := range strings.Split(cfg.RequestTimeoutRoutes, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		prefix, value, ok := strings.Cut(part, "=")
+		prefix = strings.TrimRight(strings.TrimSpace(prefix), "/")
+		timeout, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || !strings.HasPrefix(prefix, "/") || err != nil || timeout <= 0 {
+			slog.Warn("Invalid route timeout, skipping", "value", part)
+			continue
+		}
+		timeoutConfig.Routes = append(timeoutConfig.Routes, RouteTimeout{Prefix: prefix, Timeout: timeout})
+	}
+	sort.SliceStable(timeoutConfig.Routes, func(i, j int) bool {
+		return len(timeoutConfig.Routes[i].Prefix) > len(timeoutConfig.Routes[j].Prefix)
+	})
+
+	slog.Info("Request timeouts configured",
+		"read", timeoutConfig.Read,
+		"write", timeoutConfig.Write,
+		"admin", timeoutConfig.Admin,
+		"stream", timeoutConfig.Stream,
+		"events_grace", timeoutConfig.EventsGrace,
+		"route_overrides", len(timeoutConfig.Routes))
+
+	return timeoutConfig
+}
+
+// For returns the deadline of r: its route override when one matches, the
+// stream budget for streamed downloads, the requested wait plus the grace for
+// event long polls, and otherwise the budget of its endpoint group
+func (c TimeoutConfig) For(r *http.Request) time.Duration {
+	path := apiversion.Canonical(r.URL.Path)
+	for _, route := range c.Routes {
+		if matchesPrefix(path, route.Prefix) {
+			return route.Timeout
+		}
+	}
+	if isStreamed(r, path) {
+		return c.Stream
+	}
+
+	switch telemetry.SLOGroup(r.Method, r.URL.Path) {
+	case telemetry.SLOGroupAdmin:
+		return c.Admin
+	case telemetry.SLOGroupEvents:
+		if r.Method == http.MethodGet {
+			// The events handler ignores waits outside 0-60 seconds
+			wait, err := strconv.Atoi(r.URL.Query().Get("wait"))
+			if err != nil || wait < 0 || wait > 60 {
+				wait = 0
+			}
+			return time.Duration(wait)*time.Second + c.EventsGrace
+		}
+		return c.Write
+	case telemetry.SLOGroupReads:
+		return c.Read
+	default:
+		return c.Write
+	}
+}
+
+// isStreamed reports whether r downloads a stream that grows with the catalog:
+// the catalog snapshot, or a product listing that accepts NDJSON. A listing
+// that names NDJSON below JSON gets the stream budget too, which only
+// lengthens its deadline.
+func isStreamed(r *http.Request, path string) bool {
+	if r.Method != http.MethodGet {
+		return false
+	}
+	switch path {
+	case "/v1/inventory/snapshot":
+		return true
+	case "/v1/inventory":
+		return strings.Contains(strings.ToLower(r.Header.Get("Accept")), "application/x-ndjson")
+	}
+	return false
+}
+
+// TimeoutMiddleware cancels the request context once the route's deadline
+// passes and answers 504 request_timeout. A handler that has already started
+// its response, such as a product stream, keeps it and is expected to stop
+// at the cancelled context; the deadline cannot change its status anymore.
+func TimeoutMiddleware(timeoutConfig TimeoutConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := timeoutConfig.For(r)
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			tw := &timeoutWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan interface{}, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+						return
+					}
+					close(done)
+				}()
+				next.ServeHTTP(tw, r.WithContext(ctx))
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+				tw.finish()
+				return
+			case <-ctx.Done():
+			}
+
+			if !tw.abandon() {
+				// The response is under way; let the handler end it
+				select {
+				case p := <-panicked:
+					panic(p)
+				case <-done:
+				}
+				return
+			}
+			if r.Context().Err() != nil {
+				// The client went away; there is nobody to answer
+				return
+			}
+
+			slog.WarnContext(r.Context(), "Request deadline exceeded",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"timeout", timeout)
+			writeErrorResponse(w, http.StatusGatewayTimeout, "request_timeout",
+				fmt.Sprintf("Request did not complete within %s", timeout), nil)
+		})
+	}
+}
+
+// timeoutWriter holds back the handler's headers until it writes, so the
+// middleware can still answer 504 on its own up to then. Once abandoned,
+// the handler's writes are dropped.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	w           http.ResponseWriter
+	header      http.Header
+	wroteHeader bool
+	abandoned   bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		// Trailers are set after the headers are out
+		return tw.w.Header()
+	}
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(statusCode)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(statusCode int) {
+	if tw.abandoned || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	for key, values := range tw.header {
+		tw.w.Header()[key] = values
+	}
+	tw.w.WriteHeader(statusCode)
+}
+
+func (tw *timeoutWriter) Write(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	return tw.w.Write(data)
+}
+
+// Flush keeps streamed responses flowing through the deadline
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.abandoned {
+		return
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	http.NewResponseController(tw.w).Flush()
+}
+
+// finish sends the headers of a handler that returned without writing
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(http.StatusOK)
+}
+
+// abandon stops the handler's writes from reaching the client, unless it
+// has already started the response
+func (tw *timeoutWriter) abandon() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return false
+	}
+	tw.abandoned = true
+	return true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"math"
+	"time"
+)
+
+// consumeTokenBucket takes one token from the entry's bucket. The bucket holds
+// up to burst tokens (the per-window limit when burst is 0) and refills
+// continuously at limit tokens per window, so there is no 2x burst at window
+// boundaries. Count mirrors the tokens in use so stats keep working, and
+// ResetTime is when the bucket is full again, or when the next token arrives
+// for a rejected request, which gives a precise Retry-After.
+func consumeTokenBucket(entry *RateLimitEntry, limit, burst int, windowDuration time.Duration, now time.Time) (bool, *RateLimitInfo) {
+	capacity := float64(limit)
+	if burst > 0 {
+		capacity = float64(burst)
+	}
+	refillPerSecond := float64(limit) / windowDuration.Seconds()
+
+	if entry.LastRefill.IsZero() {
+		entry.Tokens = capacity
+	} else {
+		elapsed := now.Sub(entry.LastRefill).Seconds()
+		entry.Tokens = math.Min(capacity, entry.Tokens+elapsed*refillPerSecond)
+	}
+	entry.LastRefill = now
+
+	allowed := entry.Tokens >= 1
+	if allowed {
+		entry.Tokens--
+	}
+
+	entry.Count = int(math.Ceil(capacity - entry.Tokens))
+	entry.ResetTime = now.Add(secondsToDuration((capacity - entry.Tokens) / refillPerSecond))
+
+	info := &RateLimitInfo{
+		Limit:     limit,
+		Remaining: int(math.Floor(entry.Tokens)),
+		ResetTime: entry.ResetTime,
+	}
+	if !allowed {
+		info.ResetTime = now.Add(secondsToDuration((1 - entry.Tokens) / refillPerSecond))
+	}
+
+	return allowed, info
+}
+
+// reset clears fixed-window and token bucket state (caller must hold the mutex)
+func (e *RateLimitEntry) reset() {
+	e.Count = 0
+	e.ResetTime = time.Time{}
+	e.Tokens = 0
+	e.LastRefill = time.Time{}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
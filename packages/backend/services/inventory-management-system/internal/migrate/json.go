@@ -0,0 +1,150 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+// Default JSON backend files, as used by the server
+const (
+	defaultDataPath   = "data/inventory_test_data.json"
+	defaultEventsPath = "./data/events.json"
+)
+
+// jsonEventsFile has the layout of the event queue's events file
+type jsonEventsFile struct {
+	Events     []models.Event                  `json:"events"`
+	NextOffset int64                           `json:"nextOffset"`
+	Consumers  map[string]models.EventConsumer `json:"consumers,omitempty"`
+}
+
+// jsonBackend is the server's file persistence: the inventory data file and
+// the events file
+type jsonBackend struct {
+	dataPath   string
+	eventsPath string
+}
+
+// openJSON opens the JSON files named by the data and events options, or by
+// DATA_PATH and EVENTS_FILE_PATH
+func openJSON(options map[string]string) (Backend, error) {
+	backend := &jsonBackend{
+		dataPath:   envOrDefault("DATA_PATH", defaultDataPath),
+		eventsPath: envOrDefault("EVENTS_FILE_PATH", defaultEventsPath),
+	}
+	for key, value := range options {
+		switch key {
+		case "data":
+			backend.dataPath = value
+		case "events":
+			backend.eventsPath = value
+		default:
+			return nil, fmt.Errorf("unknown json backend option %q (known: data, events)", key)
+		}
+	}
+	return backend, nil
+}
+
+func (b *jsonBackend) Name() string {
+	return fmt.Sprintf("json(data=%s, events=%s)", b.dataPath, b.eventsPath)
+}
+
+func (b *jsonBackend) KeepsIdempotency() bool {
+	return false
+}
+
+// Load reads both files; a missing file loads as empty
+func (b *jsonBackend) Load() (*Dataset, error) {
+	var inventory services.InventoryData
+	if err := readJSONFile(b.dataPath, &inventory); err != nil {
+		return nil, err
+	}
+	var eventsFile jsonEventsFile
+	if err := readJSONFile(b.eventsPath, &eventsFile); err != nil {
+		return nil, err
+	}
+
+	return &Dataset{
+		Products:   inventory.Products,
+		Metadata:   inventory.Metadata,
+		Events:     eventsFile.Events,
+		NextOffset: eventsFile.NextOffset,
+		Consumers:  eventsFile.Consumers,
+	}, nil
+}
+
+// Store writes the events file first and the data file last, each atomically,
+// so a server started on a half-written target resyncs its stores instead of
+// serving products ahead of the events
+func (b *jsonBackend) Store(dataset *Dataset) error {
+	events := dataset.Events
+	if events == nil {
+		events = []models.Event{}
+	}
+	if err := writeJSONFile(b.eventsPath, jsonEventsFile{
+		Events:     events,
+		NextOffset: dataset.NextOffset,
+		Consumers:  dataset.Consumers,
+	}); err != nil {
+		return err
+	}
+
+	products := dataset.Products
+	if products == nil {
+		products = map[string]services.ProductData{}
+	}
+	metadata := dataset.Metadata
+	metadata.TotalProducts = len(products)
+	if metadata.LastUpdated == "" {
+		metadata.LastUpdated = clock.Timestamp()
+	}
+	return writeJSONFile(b.dataPath, services.InventoryData{Products: products, Metadata: metadata})
+}
+
+func readJSONFile(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSONFile writes v to a temp file and renames it over path
+func writeJSONFile(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory for %s: %w", path, err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("write %s: %w", tempPath, err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("replace %s: %w", path, err)
+	}
+	return nil
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,289 @@
+// Package migrate copies the central API's persisted state (products with
+// their metadata, idempotency entries, events and event consumers) from one
+// storage backend to another and verifies the copy by counts and checksums.
+// Backends are opened from specs such as "json:data=./data/inventory.json".
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+// ErrUnknownBackend is returned for a backend kind that has no driver
+var ErrUnknownBackend = errors.New("unknown storage backend")
+
+// ErrTargetNotEmpty is returned when the target already holds data and the
+// migration was not allowed to overwrite it
+var ErrTargetNotEmpty = errors.New("target backend is not empty")
+
+// IdempotencyEntry is a cached update result, keyed by store and idempotency key
+type IdempotencyEntry struct {
+	Key       string          `json:"key"`
+	Result    json.RawMessage `json:"result"`
+	ExpiresAt string          `json:"expiresAt"`
+}
+
+// Dataset is everything a backend persists
+type Dataset struct {
+	Products    map[string]services.ProductData
+	Metadata    services.MetadataData
+	Idempotency []IdempotencyEntry
+	Events      []models.Event
+	NextOffset  int64
+	Consumers   map[string]models.EventConsumer
+}
+
+// Empty reports whether the dataset holds no products and no events
+func (d *Dataset) Empty() bool {
+	return len(d.Products) == 0 && len(d.Events) == 0 && d.NextOffset == 0
+}
+
+// Backend loads and stores a whole dataset. Store replaces what the backend
+// held.
+type Backend interface {
+	Name() string
+	Load() (*Dataset, error)
+	Store(dataset *Dataset) error
+	// KeepsIdempotency reports whether idempotency entries survive a store;
+	// the JSON files keep none, the cache lives in memory
+	KeepsIdempotency() bool
+}
+
+// opener opens a backend from the options of its spec
+type opener func(options map[string]string) (Backend, error)
+
+// backends are the known backend kinds. New persistence backends register
+// their driver here.
+var backends = map[string]opener{
+	"json": openJSON,
+}
+
+// Kinds lists the known backend kinds
+func Kinds() []string {
+	kinds := make([]string, 0, len(backends))
+	for kind := range backends {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Open opens a backend from a spec: its kind, optionally followed by a colon
+// and comma-separated key=value options, e.g.
+// "json:data=./data/inventory.json,events=./data/events.json"
+func Open(spec string) (Backend, error) {
+	kind, rawOptions, _ := strings.Cut(spec, ":")
+	open, ok := backends[kind]
+	if !ok {
+		return nil, fmt.Errorf("%w %q (known: %s)", ErrUnknownBackend, kind, strings.Join(Kinds(), ", "))
+	}
+
+	options := make(map[string]string)
+	for _, option := range strings.Split(rawOptions, ",") {
+		if option = strings.TrimSpace(option); option == "" {
+			continue
+		}
+		key, value, found := strings.Cut(option, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid %s backend option %q, want key=value", kind, option)
+		}
+		options[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return open(options)
+}
+
+// Summary counts a dataset and checksums its products and events, so copies
+// can be compared without diffing them
+type Summary struct {
+	Products         int    `json:"products"`
+	Idempotency      int    `json:"idempotency"`
+	Events           int    `json:"events"`
+	Consumers        int    `json:"consumers"`
+	NextOffset       int64  `json:"nextOffset"`
+	ProductsChecksum string `json:"productsChecksum"`
+	EventsChecksum   string `json:"eventsChecksum"`
+}
+
+// Summarize counts and checksums a dataset. Products are hashed in product ID
+// order and events in offset order, so the checksums do not depend on how a
+// backend orders them.
+func Summarize(dataset *Dataset) Summary {
+	ids := make([]string, 0, len(dataset.Products))
+	for id := range dataset.Products {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	productsHash := sha256.New()
+	for _, id := range ids {
+		line, _ := json.Marshal(dataset.Products[id])
+		productsHash.Write(line)
+		productsHash.Write([]byte{'\n'})
+	}
+
+	events := append([]models.Event(nil), dataset.Events...)
+	sort.Slice(events, func(i, j int) bool { return events[i].Offset < events[j].Offset })
+	eventsHash := sha256.New()
+	for _, event := range events {
+		line, _ := json.Marshal(event)
+		eventsHash.Write(line)
+		eventsHash.Write([]byte{'\n'})
+	}
+
+	return Summary{
+		Products:         len(dataset.Products),
+		Idempotency:      len(dataset.Idempotency),
+		Events:           len(dataset.Events),
+		Consumers:        len(dataset.Consumers),
+		NextOffset:       dataset.NextOffset,
+		ProductsChecksum: hex.EncodeToString(productsHash.Sum(nil)),
+		EventsChecksum:   hex.EncodeToString(eventsHash.Sum(nil)),
+	}
+}
+
+// Mismatches lists how target differs from source. Idempotency entries are
+// only compared when the target keeps them.
+func Mismatches(source, target Summary, keepsIdempotency bool) []string {
+	var mismatches []string
+	check := func(what string, want, got interface{}) {
+		if want != got {
+			mismatches = append(mismatches, fmt.Sprintf("%s: source %v, target %v", what, want, got))
+		}
+	}
+	check("products", source.Products, target.Products)
+	check("products checksum", source.ProductsChecksum, target.ProductsChecksum)
+	check("events", source.Events, target.Events)
+	check("events checksum", source.EventsChecksum, target.EventsChecksum)
+	check("next offset", source.NextOffset, target.NextOffset)
+	check("consumers", source.Consumers, target.Consumers)
+	if keepsIdempotency {
+		check("idempotency entries", source.Idempotency, target.Idempotency)
+	}
+	return mismatches
+}
+
+// Options tunes a migration
+type Options struct {
+	// Overwrite allows replacing a target that already holds data
+	Overwrite bool
+	// DryRun loads and summarizes the source without writing the target
+	DryRun bool
+}
+
+// Result reports one copy pass
+type Result struct {
+	Source     Summary  `json:"source"`
+	Target     Summary  `json:"target"`
+	Mismatches []string `json:"mismatches,omitempty"`
+	Skipped    []string `json:"skipped,omitempty"` // Parts of the dataset the target cannot keep
+	Duration   string   `json:"duration"`
+}
+
+// Verified reports whether the target matched the source after the copy
+func (r Result) Verified() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Migrate copies source into target once and verifies the copy by reading
+// the target back
+func Migrate(source, target Backend, opts Options) (Result, error) {
+	start := time.Now()
+
+	dataset, err := source.Load()
+	if err != nil {
+		return Result{}, fmt.Errorf("load %s: %w", source.Name(), err)
+	}
+	result := Result{Source: Summarize(dataset)}
+	if !target.KeepsIdempotency() && len(dataset.Idempotency) > 0 {
+		result.Skipped = append(result.Skipped, fmt.Sprintf("%d idempotency entries", len(dataset.Idempotency)))
+	}
+	if opts.DryRun {
+		result.Duration = time.Since(start).String()
+		return result, nil
+	}
+
+	if !opts.Overwrite {
+		existing, err := target.Load()
+		if err != nil {
+			return result, fmt.Errorf("inspect %s: %w", target.Name(), err)
+		}
+		if !existing.Empty() {
+			return result, fmt.Errorf("%w: %s holds %d products and %d events", ErrTargetNotEmpty,
+				target.Name(), len(existing.Products), len(existing.Events))
+		}
+	}
+
+	if err := copyDataset(dataset, target, &result); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(start).String()
+	return result, nil
+}
+
+// copyDataset stores dataset in target, reads it back and records the
+// verification in result
+func copyDataset(dataset *Dataset, target Backend, result *Result) error {
+	if err := target.Store(dataset); err != nil {
+		return fmt.Errorf("store %s: %w", target.Name(), err)
+	}
+	copied, err := target.Load()
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", target.Name(), err)
+	}
+	result.Target = Summarize(copied)
+	result.Mismatches = Mismatches(result.Source, result.Target, target.KeepsIdempotency())
+	return nil
+}
+
+// Follow copies source into target every interval while the source changes,
+// until ctx is done, so a cutover only waits for the last pass. The first
+// pass is a full Migrate; later passes run only when the source summary
+// changed. report is called after every pass that copied.
+func Follow(ctx context.Context, source, target Backend, opts Options, interval time.Duration, report func(Result)) error {
+	result, err := Migrate(source, target, opts)
+	if err != nil {
+		return err
+	}
+	report(result)
+	last := result.Source
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		dataset, err := source.Load()
+		if err != nil {
+			// The source may be mid-write; the next pass tries again
+			slog.Warn("Failed to load migration source, retrying next pass", "source", source.Name(), "error", err)
+			continue
+		}
+		summary := Summarize(dataset)
+		if summary == last {
+			continue
+		}
+
+		result := Result{Source: summary}
+		if err := copyDataset(dataset, target, &result); err != nil {
+			return err
+		}
+		result.Duration = time.Since(start).String()
+		report(result)
+		last = summary
+	}
+}
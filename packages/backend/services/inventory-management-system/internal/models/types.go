@@ -1,10 +1,19 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
 // ErrorResponse represents the standard error response format
 type ErrorResponse struct {
-	Code    string        `json:"code"`
-	Message string        `json:"message"`
-	Details []ErrorDetail `json:"details,omitempty"`
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+	RequestID string        `json:"requestId,omitempty"` // Matches the X-Request-ID response header
 }
 
 type ErrorDetail struct {
@@ -21,16 +30,28 @@ type UpdateRequest struct {
 	Version        int    `json:"version,omitempty"`
 	IdempotencyKey string `json:"idempotencyKey,omitempty"`
 
+	// Optional label recorded on the published events, e.g. "return"; applies
+	// to every item of a batch
+	Reason string `json:"reason,omitempty"`
+
+	// Optional compare-and-set on the product's stock, checked atomically with
+	// the update; with either set, version may be omitted
+	MinAvailable      *int `json:"minAvailable,omitempty"`
+	ExpectedAvailable *int `json:"expectedAvailable,omitempty"`
+
 	// Batch update fields
 	Updates []ProductUpdate `json:"updates,omitempty"`
 }
 
 // ProductUpdate represents a single product update in a batch operation
 type ProductUpdate struct {
-	ProductID      string `json:"productId"`
+	ProductID      string `json:"productId" validate:"required"`
 	Delta          int    `json:"delta"`
 	Version        int    `json:"version"`
-	IdempotencyKey string `json:"idempotencyKey"`
+	IdempotencyKey string `json:"idempotencyKey" validate:"required,idempotencykey"` // UUIDv4 or ULID, deduplicated per store
+	// Optional compare-and-set on the product's stock, as on UpdateRequest
+	MinAvailable      *int `json:"minAvailable,omitempty" validate:"min=0"`
+	ExpectedAvailable *int `json:"expectedAvailable,omitempty" validate:"min=0"`
 }
 
 type UpdateResponse struct {
@@ -38,8 +59,13 @@ type UpdateResponse struct {
 	ProductID   string `json:"productId,omitempty"`
 	NewQuantity int    `json:"newQuantity,omitempty"`
 	NewVersion  int    `json:"newVersion,omitempty"`
-	Applied     bool   `json:"applied,omitempty"`
+	Applied     bool   `json:"applied"` // Always present so failed single updates keep the envelope
 	LastUpdated string `json:"lastUpdated,omitempty"`
+	Replayed    bool   `json:"replayed,omitempty"` // Answered from the idempotency cache
+	// The update left fewer units than the product's minStock policy
+	BelowMinStock bool `json:"belowMinStock,omitempty"`
+	// Units of the sale taken beyond the stock and queued as a backorder
+	Backordered int `json:"backordered,omitempty"`
 
 	// Batch response fields
 	Results      []ProductUpdateResult `json:"results,omitempty"`
@@ -57,6 +83,11 @@ type ProductUpdateResult struct {
 	LastUpdated  string `json:"lastUpdated"`
 	ErrorType    string `json:"errorType,omitempty"`
 	ErrorMessage string `json:"errorMessage,omitempty"`
+	Replayed     bool   `json:"replayed,omitempty"` // Answered from the idempotency cache
+	// The update left fewer units than the product's minStock policy
+	BelowMinStock bool `json:"belowMinStock,omitempty"`
+	// Units of the sale taken beyond the stock and queued as a backorder
+	Backordered int `json:"backordered,omitempty"`
 }
 
 // BatchSummary provides summary statistics for batch operations
@@ -73,6 +104,132 @@ type ProductResponse struct {
 	Version     int     `json:"version"`
 	LastUpdated string  `json:"lastUpdated"`
 	Price       float64 `json:"price"`
+	Prices      []Money `json:"prices,omitempty"`   // Per-currency price list
+	Category    string  `json:"category,omitempty"` // Product category, used for stock reporting
+	// Barcode and BarcodeAliases are the codes POS scanners read for the product
+	Barcode        string   `json:"barcode,omitempty"`
+	BarcodeAliases []string `json:"barcodeAliases,omitempty"`
+	// Images and other files store displays show with the product
+	Assets []ProductAsset `json:"assets,omitempty"`
+	// The product's own stock policy; without one its category's applies
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"`
+	// Units of the stock set aside for each store, by store ID
+	Allocations map[string]int `json:"allocations,omitempty"`
+}
+
+// StockPolicy bounds a product's stock on store updates. MinStock only flags
+// updates leaving fewer units, for restock alerts; MaxStock caps restocks;
+// AllowBackorder lets sales take available below zero, down to -MaxBackorder.
+type StockPolicy struct {
+	MinStock       *int `json:"minStock,omitempty" validate:"min=0"`
+	MaxStock       *int `json:"maxStock,omitempty" validate:"min=0"`
+	AllowBackorder bool `json:"allowBackorder,omitempty"`
+	MaxBackorder   int  `json:"maxBackorder,omitempty" validate:"min=0"`
+}
+
+// ValidateStruct checks the bounds against each other
+func (p StockPolicy) ValidateStruct() []ErrorDetail {
+	var details []ErrorDetail
+	if p.MinStock != nil && p.MaxStock != nil && *p.MaxStock < *p.MinStock {
+		details = append(details, ErrorDetail{Field: "maxStock", Issue: "maxStock cannot be below minStock"})
+	}
+	if p.AllowBackorder && p.MaxBackorder < 1 {
+		details = append(details, ErrorDetail{Field: "maxBackorder", Issue: "maxBackorder must be at least 1 when allowBackorder is set"})
+	}
+	if !p.AllowBackorder && p.MaxBackorder != 0 {
+		details = append(details, ErrorDetail{Field: "maxBackorder", Issue: "maxBackorder needs allowBackorder"})
+	}
+	return details
+}
+
+// IsZero reports whether the policy sets nothing
+func (p StockPolicy) IsZero() bool {
+	return p.MinStock == nil && p.MaxStock == nil && !p.AllowBackorder && p.MaxBackorder == 0
+}
+
+// ProductAsset is a typed URL to an image or other file for the product.
+// Checksum lets displays verify a downloaded or cached copy.
+type ProductAsset struct {
+	Type     string `json:"type" validate:"required,oneof=image thumbnail video document"`
+	URL      string `json:"url" validate:"required,max=2048,url"`
+	Checksum string `json:"checksum" validate:"required,checksum"` // sha256:<64 hex digits>
+}
+
+// ProductAssetsRequest replaces a product's assets; an empty list clears them
+type ProductAssetsRequest struct {
+	Assets []ProductAsset `json:"assets" validate:"max=20,unique=URL"`
+}
+
+// ProductAssetsResponse is a product's assets and the version that has them
+type ProductAssetsResponse struct {
+	ProductID   string         `json:"productId"`
+	Version     int            `json:"version"`
+	LastUpdated string         `json:"lastUpdated"`
+	Assets      []ProductAsset `json:"assets"`
+}
+
+// Money represents a currency-aware amount stored as integer minor units
+type Money struct {
+	Amount   int64  `json:"amount" validate:"min=0"`     // Minor units (e.g. cents)
+	Currency string `json:"currency" validate:"iso4217"` // ISO 4217 currency code
+}
+
+// Backorder is the part of a store's sale taken beyond the stock, waiting in
+// the product's queue until restocks fill it. Version is the product version
+// the sale produced.
+type Backorder struct {
+	StoreID        string `json:"storeId,omitempty"`
+	IdempotencyKey string `json:"idempotencyKey,omitempty"` // Of the update that made the sale
+	Version        int    `json:"version"`
+	Units          int    `json:"units"`     // Units backordered by the sale
+	Remaining      int    `json:"remaining"` // Units still waiting for stock
+	CreatedAt      string `json:"createdAt"`
+}
+
+// BackordersResponse lists a product's backorder queue, oldest first
+type BackordersResponse struct {
+	ProductID  string      `json:"productId"`
+	Available  int         `json:"available"`
+	Version    int         `json:"version"`
+	Units      int         `json:"units"` // Units waiting across the queue
+	Backorders []Backorder `json:"backorders"`
+}
+
+// PriceResponse represents a product price resolved in a requested currency
+type PriceResponse struct {
+	ProductID    string  `json:"productId"`
+	Currency     string  `json:"currency"`
+	Amount       int64   `json:"amount"`
+	Display      float64 `json:"display"`
+	Source       string  `json:"source"` // "price_list" or "converted"
+	BaseCurrency string  `json:"baseCurrency"`
+	Rate         float64 `json:"rate,omitempty"`
+}
+
+// BatchGetRequest asks for several products in one round trip
+type BatchGetRequest struct {
+	ProductIDs []string `json:"productIds" validate:"required,dive,required"`
+}
+
+// BatchGetResponse lists the found products in request order and the
+// requested IDs that do not exist
+type BatchGetResponse struct {
+	Products []ProductResponse `json:"products"`
+	Missing  []string          `json:"missing"`
+}
+
+// ProductVersion is the OCC state of a product: what a client needs to
+// build an update without the full product payload
+type ProductVersion struct {
+	Version   int `json:"version"`
+	Available int `json:"available"`
+}
+
+// VersionsResponse maps each found product ID to its current version and
+// stock and lists the requested IDs that do not exist
+type VersionsResponse struct {
+	Versions map[string]ProductVersion `json:"versions"`
+	Missing  []string                  `json:"missing"`
 }
 
 type ListResponse struct {
@@ -88,6 +245,22 @@ type Event struct {
 	ProductID string          `json:"productId"`
 	Data      ProductResponse `json:"data"`
 	Version   int             `json:"version"`
+	StoreID   string          `json:"storeId,omitempty"`   // Store that sent the update, or the source store of a transfer
+	Delta     int             `json:"delta,omitempty"`     // Applied stock change, for store updates
+	ToStoreID string          `json:"toStoreId,omitempty"` // Destination store, for stock transfers
+	Quantity  int             `json:"quantity,omitempty"`  // Units moved, for stock transfers
+	Reason    string          `json:"reason,omitempty"`    // Why the store changed stock, e.g. "return"
+	OrderID   string          `json:"orderId,omitempty"`   // Order that reserved, sold or released the units
+	Category  *Category       `json:"category,omitempty"`  // The category changed, for category events
+	Backorder *Backorder      `json:"backorder,omitempty"` // Queued by a store update, or filled, for backorder_fulfilled events
+	// The category an admin moved the product out of, for product_updated
+	// events changing it; filtered polls turn the event into a removal for
+	// stores whose filter the product leaves
+	PreviousCategory string `json:"previousCategory,omitempty"`
+	// Set by a partition router on the events it merged: the partition that
+	// published the event and the event's offset in that partition's stream
+	Partition       string `json:"partition,omitempty"`
+	PartitionOffset int64  `json:"partitionOffset,omitempty"`
 }
 
 // Admin SET endpoint models
@@ -96,10 +269,46 @@ type AdminSetRequest struct {
 }
 
 type AdminProductUpdate struct {
-	ProductID string   `json:"productId"`
-	Name      *string  `json:"name,omitempty"`      // Pointer for optional field
-	Available *int     `json:"available,omitempty"` // Pointer for optional field
-	Price     *float64 `json:"price,omitempty"`     // Pointer for optional field
+	ProductID string   `json:"productId" validate:"required"`
+	Name      *string  `json:"name,omitempty"`                              // Pointer for optional field
+	Available *int     `json:"available,omitempty" validate:"min=0"`        // Pointer for optional field
+	Price     *float64 `json:"price,omitempty" validate:"min=0"`            // Pointer for optional field
+	Prices    []Money  `json:"prices,omitempty" validate:"unique=Currency"` // Replaces the price list when provided
+	Category  *string  `json:"category,omitempty"`                          // Pointer for optional field
+	Barcode   *string  `json:"barcode,omitempty" validate:"max=64"`         // Empty string clears it
+	// Replaces the aliases when provided; an empty list clears them
+	BarcodeAliases []string `json:"barcodeAliases,omitempty" validate:"max=20,unique,dive,required,max=64"`
+	// Replaces the assets when provided; an empty list clears them
+	Assets []ProductAsset `json:"assets,omitempty" validate:"max=20,unique=URL"`
+	// Replaces the product's stock policy when provided; {} clears it
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"`
+	// Replaces the units set aside per store ID when provided; {} clears them.
+	// Together they may not exceed the product's stock.
+	Allocations map[string]int `json:"allocations,omitempty" validate:"max=1000"`
+}
+
+// ValidateStruct requires at least one field to be updated and checks the
+// allocations, which the tag rules cannot reach inside a map
+func (p AdminProductUpdate) ValidateStruct() []ErrorDetail {
+	if p.Name == nil && p.Available == nil && p.Price == nil && p.Prices == nil && p.Category == nil &&
+		p.Barcode == nil && p.BarcodeAliases == nil && p.Assets == nil && p.StockPolicy == nil && p.Allocations == nil {
+		return []ErrorDetail{{
+			Field: "fields",
+			Issue: "At least one field (name, available, price, prices, category, barcode, barcodeAliases, assets, stockPolicy, allocations) must be specified",
+		}}
+	}
+
+	var details []ErrorDetail
+	for _, storeID := range slices.Sorted(maps.Keys(p.Allocations)) {
+		units := p.Allocations[storeID]
+		switch {
+		case strings.TrimSpace(storeID) == "" || len(storeID) > 64:
+			details = append(details, ErrorDetail{Field: "allocations", Issue: fmt.Sprintf("Invalid store ID %q", storeID)})
+		case units < 0:
+			details = append(details, ErrorDetail{Field: "allocations." + storeID, Issue: "allocation cannot be negative"})
+		}
+	}
+	return details
 }
 
 type AdminSetResponse struct {
@@ -128,10 +337,17 @@ type AdminCreateRequest struct {
 }
 
 type AdminProductCreate struct {
-	ProductID string  `json:"productId"`
-	Name      string  `json:"name"`
-	Available int     `json:"available"`
-	Price     float64 `json:"price"`
+	ProductID string  `json:"productId" validate:"required"`
+	Name      string  `json:"name" validate:"required"`
+	Available int     `json:"available" validate:"min=0"`
+	Price     float64 `json:"price" validate:"min=0"`
+	Prices    []Money `json:"prices,omitempty" validate:"unique=Currency"`
+	Category  string  `json:"category,omitempty"`
+	Barcode   string  `json:"barcode,omitempty" validate:"max=64"`
+	// Further codes the product is scanned by, e.g. a UPC next to the EAN
+	BarcodeAliases []string       `json:"barcodeAliases,omitempty" validate:"max=20,unique,dive,required,max=64"`
+	Assets         []ProductAsset `json:"assets,omitempty" validate:"max=20,unique=URL"`
+	StockPolicy    *StockPolicy   `json:"stockPolicy,omitempty"`
 }
 
 type AdminCreateResponse struct {
@@ -147,7 +363,7 @@ type AdminCreateSummary struct {
 
 // Admin DELETE endpoint models
 type AdminDeleteRequest struct {
-	ProductIDs []string `json:"productIds"`
+	ProductIDs []string `json:"productIds" validate:"dive,required"`
 }
 
 type AdminDeleteResponse struct {
@@ -161,12 +377,137 @@ type AdminDeleteSummary struct {
 	FailedDeletions     int `json:"failedDeletions"`
 }
 
+// Background job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// Background job types
+const (
+	JobTypeProductsSet = "products_set"
+	JobTypeRestore     = "restore"
+)
+
+// Job is a background admin operation with its progress and per-item results
+type Job struct {
+	ID         string               `json:"id"`
+	Type       string               `json:"type"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`     // Items the job will process
+	Processed  int                  `json:"processed"` // Items processed so far
+	Succeeded  int                  `json:"succeeded"`
+	Failed     int                  `json:"failed"`
+	Error      string               `json:"error,omitempty"` // Why a failed job stopped early
+	CreatedAt  string               `json:"createdAt"`
+	StartedAt  string               `json:"startedAt,omitempty"`
+	FinishedAt string               `json:"finishedAt,omitempty"`
+	Results    []AdminProductResult `json:"results"`
+	Output     json.RawMessage      `json:"output,omitempty"` // Overall result of job types that produce one
+}
+
+// JobStartRequest starts a job of a registered type
+type JobStartRequest struct {
+	Type   string          `json:"type" validate:"required"`
+	Params json.RawMessage `json:"params,omitempty"` // Decoded by the job type
+}
+
+// JobsResponse lists background jobs, newest first, without per-item results
+type JobsResponse struct {
+	Jobs  []Job    `json:"jobs"`
+	Count int      `json:"count"`
+	Types []string `json:"types"` // Job types POST /v1/admin/jobs accepts
+}
+
 // EventsResponse represents the response for the events endpoint
 type EventsResponse struct {
 	Events     []Event `json:"events"`
 	NextOffset int64   `json:"nextOffset"`
 	HasMore    bool    `json:"hasMore"`
 	Count      int     `json:"count"`
+	// Filtered is set when an event filter applied: offsets may skip, and
+	// NextOffset moves past the events that did not match
+	Filtered bool `json:"filtered,omitempty"`
+	// PartitionOffsets is set by a partition router: for each partition, the
+	// offset in its own stream that NextOffset corresponds to
+	PartitionOffsets map[string]int64 `json:"partitionOffsets,omitempty"`
+	// CurrentOffset is the next offset the queue will assign, so readers can
+	// tell how many events they trail by
+	CurrentOffset int64 `json:"currentOffset,omitempty"`
+}
+
+// OffsetGoneResponse is returned with 410 Gone when the requested event offset
+// was rotated or compacted away. Stores should run a full resync and resume
+// polling from SnapshotOffset, the next offset at the time of the response.
+type OffsetGoneResponse struct {
+	Code                  string `json:"code"`
+	Message               string `json:"message"`
+	RequestedOffset       int64  `json:"requestedOffset"`
+	OldestAvailableOffset int64  `json:"oldestAvailableOffset"`
+	SnapshotOffset        int64  `json:"snapshotOffset"`
+	Reason                string `json:"reason,omitempty"` // replay_requested when an operator asked for the resync
+	RequestID             string `json:"requestId,omitempty"`
+}
+
+// EventConsumer is a store that reads the event stream. Offset is the next
+// offset it asked for, so every earlier event has been consumed.
+type EventConsumer struct {
+	StoreID  string       `json:"storeId"`
+	Offset   int64        `json:"offset"`
+	LastSeen string       `json:"lastSeen"`
+	Replay   *StoreReplay `json:"replay,omitempty"` // Latest operator-requested rebuild
+}
+
+// Store replay statuses
+const (
+	StoreReplayPending   = "pending"   // Waiting for the store's next events poll
+	StoreReplayResyncing = "resyncing" // Told to resync; waiting for it to poll past the snapshot offset
+	StoreReplayCompleted = "completed"
+)
+
+// StoreReplay tracks an operator-requested rebuild of one store's cache from
+// a snapshot followed by the events after it
+type StoreReplay struct {
+	Status         string `json:"status"`
+	RequestedBy    string `json:"requestedBy,omitempty"`
+	RequestedAt    string `json:"requestedAt"`
+	StartedAt      string `json:"startedAt,omitempty"`      // When the store was told to resync
+	SnapshotOffset int64  `json:"snapshotOffset,omitempty"` // Where the store resumes polling after the snapshot
+	CompletedAt    string `json:"completedAt,omitempty"`
+}
+
+// EventQueueStats represents the response for the admin event stats endpoint.
+// OldestOffset, NewestOffset and OldestTimestamp are omitted while the queue
+// is empty. RotatedEvents counts the events rotation dropped since startup by
+// the limit that dropped them (count, age, size or forced).
+type EventQueueStats struct {
+	EventCount      int              `json:"eventCount"`
+	OldestOffset    *int64           `json:"oldestOffset,omitempty"`
+	NewestOffset    *int64           `json:"newestOffset,omitempty"`
+	NextOffset      int64            `json:"nextOffset"`
+	OldestTimestamp string           `json:"oldestTimestamp,omitempty"`
+	FileSizeBytes   int64            `json:"fileSizeBytes"`
+	RotatedEvents   map[string]int64 `json:"rotatedEvents,omitempty"`
+	Consumers       []EventConsumer  `json:"consumers"`
+}
+
+// EventCommitRequest commits that the calling store applied every event before Offset
+type EventCommitRequest struct {
+	Offset int64 `json:"offset" validate:"min=0"`
+}
+
+// EventCompactRequest asks to remove every event with an offset below BeforeOffset
+type EventCompactRequest struct {
+	BeforeOffset int64 `json:"beforeOffset" validate:"min=0"`
+}
+
+// EventCompactResponse represents the response for the admin compact endpoint
+type EventCompactResponse struct {
+	RemovedEvents int             `json:"removedEvents"`
+	Stats         EventQueueStats `json:"stats"`
 }
 
 // EventType constants
@@ -174,4 +515,623 @@ const (
 	EventTypeProductUpdated = "product_updated"
 	EventTypeProductCreated = "product_created"
 	EventTypeProductDeleted = "product_deleted"
+	EventTypeSystemRestored = "system_restored" // The whole inventory was replaced; stores resync fully
+	// EventTypeStockTransferred moves units between two stores' allocations;
+	// the product's available stock is unchanged but its version advances
+	EventTypeStockTransferred = "stock_transferred"
+	// Order events carry the order's units in Quantity. A reservation takes
+	// them out of available stock and a cancellation puts them back; a commit
+	// turns the reservation into a sale, leaving stock unchanged.
+	EventTypeOrderReserved  = "order_reserved"
+	EventTypeOrderCommitted = "order_committed"
+	EventTypeOrderCancelled = "order_cancelled"
+	// Category events carry the category in Category and no product, so they
+	// pass every event filter and stores keep the whole tree
+	EventTypeCategoryUpdated = "category_updated"
+	EventTypeCategoryDeleted = "category_deleted"
+	// EventTypeBackorderFulfilled reports restocked units filling a queued
+	// backorder: Quantity units went to Backorder, whose Remaining says what
+	// still waits. Stock is unchanged by the event itself; the restock that
+	// filled it was published just before, at the same version.
+	EventTypeBackorderFulfilled = "backorder_fulfilled"
+)
+
+// Update reasons with a meaning to the central API; other reasons are only recorded
+const (
+	UpdateReasonReturn    = "return"    // Restock of units a customer returned
+	UpdateReasonStocktake = "stocktake" // Correction applied when a stocktake closed
+)
+
+// TransferRequest moves Quantity units of a product from one store's
+// allocation to another's as a single operation
+type TransferRequest struct {
+	ProductID      string `json:"productId" validate:"required"`
+	FromStoreID    string `json:"fromStoreId" validate:"required"`
+	ToStoreID      string `json:"toStoreId" validate:"required"`
+	Quantity       int    `json:"quantity" validate:"min=1"`
+	Version        int    `json:"version,omitempty"`                                 // Optional OCC check; 0 skips it
+	IdempotencyKey string `json:"idempotencyKey" validate:"required,idempotencykey"` // UUIDv4 or ULID, deduplicated per source store
+}
+
+// StockTransfer is a completed transfer, as returned by the transfer endpoint
+// and kept in the transfer history
+type StockTransfer struct {
+	TransferID     string `json:"transferId"`
+	ProductID      string `json:"productId"`
+	FromStoreID    string `json:"fromStoreId"`
+	ToStoreID      string `json:"toStoreId"`
+	Quantity       int    `json:"quantity"`
+	NewVersion     int    `json:"newVersion"`
+	Available      int    `json:"available"`      // Product stock, unchanged by the transfer
+	FromAllocation int    `json:"fromAllocation"` // Units left allocated to the source store
+	ToAllocation   int    `json:"toAllocation"`   // Units now allocated to the destination store
+	IdempotencyKey string `json:"idempotencyKey"`
+	EventOffset    int64  `json:"eventOffset"` // Offset of the stock_transferred event
+	CreatedAt      string `json:"createdAt"`
+	Replayed       bool   `json:"replayed,omitempty"` // Answered from the idempotency cache
+}
+
+// TransfersResponse lists transfer history, newest first
+type TransfersResponse struct {
+	Transfers []StockTransfer `json:"transfers"`
+	Count     int             `json:"count"`
+}
+
+// LockHold is a product lock held right now
+type LockHold struct {
+	ProductID string `json:"productId"`
+	Mode      string `json:"mode"`              // write or read
+	Readers   int    `json:"readers,omitempty"` // Read holders, for read holds
+	HeldMs    int64  `json:"heldMs"`
+	Waiters   int    `json:"waiters"` // Goroutines blocked acquiring it
+}
+
+// LockStats is a point-in-time view of the product lock manager
+type LockStats struct {
+	TotalLocks    int        `json:"totalLocks"` // Products with a lock allocated
+	Held          int        `json:"held"`
+	Waiters       int        `json:"waiters"`
+	LongestHoldMs int64      `json:"longestHoldMs"`
+	Stalls        int64      `json:"stalls"` // Holds the watchdog logged as past its threshold
+	Holds         []LockHold `json:"holds"`  // Longest first, at most 10
+}
+
+// Order statuses
+const (
+	OrderStatusReserved  = "reserved"  // Units are held; commit or cancel the order
+	OrderStatusCommitted = "committed" // The held units were sold
+	OrderStatusCancelled = "cancelled" // The held units were returned to stock
+)
+
+// OrderLine is one product of an order
+type OrderLine struct {
+	ProductID string `json:"productId" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"min=1"`
+}
+
+// OrderRequest reserves every line of an order, or none of them
+type OrderRequest struct {
+	StoreID        string      `json:"storeId" validate:"required"`
+	Lines          []OrderLine `json:"lines" validate:"required,max=100,unique=ProductID"`
+	IdempotencyKey string      `json:"idempotencyKey" validate:"required,idempotencykey"` // UUIDv4 or ULID, deduplicated per store
+}
+
+// OrderItem is a line of a recorded order with the product state after its
+// last step
+type OrderItem struct {
+	ProductID   string `json:"productId"`
+	Quantity    int    `json:"quantity"`
+	NewVersion  int    `json:"newVersion"`
+	Available   int    `json:"available"`
+	EventOffset int64  `json:"eventOffset"` // Offset of the line's last order event
+}
+
+// Order is a reservation of stock for a store, as returned by the order
+// endpoints and kept in the order history
+type Order struct {
+	OrderID        string      `json:"orderId"`
+	StoreID        string      `json:"storeId"`
+	Status         string      `json:"status"`
+	Items          []OrderItem `json:"items"`
+	IdempotencyKey string      `json:"idempotencyKey"`
+	CreatedAt      string      `json:"createdAt"`
+	CommittedAt    string      `json:"committedAt,omitempty"`
+	CancelledAt    string      `json:"cancelledAt,omitempty"`
+	Replayed       bool        `json:"replayed,omitempty"` // Answered from the idempotency cache
+}
+
+// Stocktake modes decide what happens to store decrements of frozen products
+const (
+	StocktakeModeReject = "reject" // Answered 423 stocktake_frozen
+	StocktakeModeQueue  = "queue"  // Answered 429 stocktake_hold so stores buffer and replay them
+)
+
+// Stocktake session statuses
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusClosed    = "closed"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+// StocktakeStartRequest starts a cycle count of a store's or a category's
+// products; with neither, every product is counted
+type StocktakeStartRequest struct {
+	StoreID  string `json:"storeId,omitempty"`  // Only freeze decrements from this store
+	Category string `json:"category,omitempty"` // Only count products in this category
+	Mode     string `json:"mode,omitempty"`     // reject (default) or queue
+	Reason   string `json:"reason,omitempty" validate:"max=500"`
+}
+
+// StocktakeCount is one counted quantity
+type StocktakeCount struct {
+	ProductID string `json:"productId" validate:"required"`
+	Counted   int    `json:"counted" validate:"min=0"`
+}
+
+// StocktakeCountsRequest submits counted quantities; a product counted again
+// replaces its earlier count
+type StocktakeCountsRequest struct {
+	Counts []StocktakeCount `json:"counts" validate:"required,min=1"`
+}
+
+// StocktakeLine is a counted product and its variance against the system
+// stock at the time of the count
+type StocktakeLine struct {
+	ProductID      string `json:"productId"`
+	SystemQuantity int    `json:"systemQuantity"`
+	Counted        int    `json:"counted"`
+	Variance       int    `json:"variance"` // counted - systemQuantity
+	CountedAt      string `json:"countedAt"`
+}
+
+// StocktakeAdjustment is a correction applied when a session closed
+type StocktakeAdjustment struct {
+	ProductID   string `json:"productId"`
+	Variance    int    `json:"variance"`
+	OldQuantity int    `json:"oldQuantity"`
+	NewQuantity int    `json:"newQuantity"`
+	NewVersion  int    `json:"newVersion"`
+	EventOffset int64  `json:"eventOffset"`
+}
+
+// StocktakeSession is a cycle count. Decrements of its products are frozen
+// while it is open; closing it applies the variances as audited adjustments.
+type StocktakeSession struct {
+	ID          string                `json:"id"`
+	StoreID     string                `json:"storeId,omitempty"`
+	Category    string                `json:"category,omitempty"`
+	Mode        string                `json:"mode"`
+	Status      string                `json:"status"`
+	Reason      string                `json:"reason,omitempty"`
+	ProductIDs  []string              `json:"productIds"`
+	Lines       []StocktakeLine       `json:"lines"`
+	Adjustments []StocktakeAdjustment `json:"adjustments,omitempty"`
+	OpenedBy    string                `json:"openedBy"`
+	OpenedAt    string                `json:"openedAt"`
+	ClosedBy    string                `json:"closedBy,omitempty"`
+	ClosedAt    string                `json:"closedAt,omitempty"`
+}
+
+// StocktakeReport is a session with its variance summary
+type StocktakeReport struct {
+	StocktakeSession
+	Uncounted   []string `json:"uncounted"`   // Products in scope without a count
+	NetVariance int      `json:"netVariance"` // Sum of all variances
+	UnitsOver   int      `json:"unitsOver"`   // Units counted above system stock
+	UnitsShort  int      `json:"unitsShort"`  // Units missing against system stock
+}
+
+// StocktakesResponse lists stocktake sessions, newest first
+type StocktakesResponse struct {
+	Stocktakes []StocktakeSession `json:"stocktakes"`
+	Count      int                `json:"count"`
+}
+
+// ConfigAuditEntry records one runtime configuration change
+type ConfigAuditEntry struct {
+	Timestamp string `json:"timestamp"`
+	Actor     string `json:"actor"`
+	Source    string `json:"source"`
+	Key       string `json:"key"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// RuntimeConfigResponse lists the runtime-tunable settings and recent changes
+type RuntimeConfigResponse struct {
+	Settings map[string]string  `json:"settings"`
+	Audit    []ConfigAuditEntry `json:"audit"`
+}
+
+// RuntimeConfigPatchRequest changes runtime-tunable settings; values may be
+// JSON strings or numbers
+type RuntimeConfigPatchRequest struct {
+	Settings map[string]json.RawMessage `json:"settings" validate:"required"`
+	Reason   string                     `json:"reason" validate:"max=500"`
+}
+
+// RuntimeConfigPatchResponse reports the changes applied and the resulting settings
+type RuntimeConfigPatchResponse struct {
+	Applied  []ConfigAuditEntry `json:"applied"`
+	Settings map[string]string  `json:"settings"`
+}
+
+// FeatureFlagState describes one feature flag and where it is enabled
+type FeatureFlagState struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Default     bool            `json:"default"`
+	Enabled     bool            `json:"enabled"`             // Environment-wide value
+	Overrides   map[string]bool `json:"overrides,omitempty"` // Tenant (store ID) -> value
+}
+
+// FeatureFlagsResponse lists every known feature flag
+type FeatureFlagsResponse struct {
+	Flags []FeatureFlagState `json:"flags"`
+}
+
+// FeatureFlagUpdateRequest turns a flag on or off for the environment, or for
+// one tenant when Tenant is set
+type FeatureFlagUpdateRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	Tenant  string `json:"tenant,omitempty" validate:"max=100"`
+}
+
+// SnapshotCreateRequest names a new inventory snapshot
+type SnapshotCreateRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// SnapshotInfo describes a stored inventory snapshot
+type SnapshotInfo struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	CreatedAt      string `json:"createdAt"`
+	LastOffset     int    `json:"lastOffset"` // Event offset the snapshot corresponds to
+	ProductCount   int    `json:"productCount"`
+	TotalAvailable int    `json:"totalAvailable"`
+	SizeBytes      int64  `json:"sizeBytes"` // Compressed size on disk
+}
+
+// SnapshotListResponse lists stored snapshots, newest first
+type SnapshotListResponse struct {
+	Snapshots []SnapshotInfo `json:"snapshots"`
+}
+
+// SnapshotResponse is a full snapshot download
+type SnapshotResponse struct {
+	Snapshot SnapshotInfo      `json:"snapshot"`
+	Products []ProductResponse `json:"products"`
+}
+
+// ProductChange is one product whose state differs from a snapshot
+type ProductChange struct {
+	ProductID         string  `json:"productId"`
+	SnapshotAvailable int     `json:"snapshotAvailable"`
+	CurrentAvailable  int     `json:"currentAvailable"`
+	AvailableDelta    int     `json:"availableDelta"`
+	SnapshotVersion   int     `json:"snapshotVersion"`
+	CurrentVersion    int     `json:"currentVersion"`
+	SnapshotPrice     float64 `json:"snapshotPrice"`
+	CurrentPrice      float64 `json:"currentPrice"`
+}
+
+// SnapshotDiff compares a snapshot with the current inventory
+type SnapshotDiff struct {
+	SnapshotID     string          `json:"snapshotId"`
+	SnapshotOffset int             `json:"snapshotOffset"`
+	CurrentOffset  int             `json:"currentOffset"`
+	AvailableDelta int             `json:"availableDelta"` // Net change in units across all products
+	Changed        []ProductChange `json:"changed"`
+	Added          []string        `json:"added"`   // Products created since the snapshot
+	Removed        []string        `json:"removed"` // Products deleted since the snapshot
+	Unchanged      int             `json:"unchanged"`
+}
+
+// RestoreRequest rebuilds the inventory from a snapshot plus the events after it
+type RestoreRequest struct {
+	SnapshotID   string `json:"snapshotId" validate:"required"`
+	TargetOffset *int   `json:"targetOffset,omitempty" validate:"min=0"` // Defaults to the snapshot's offset
+	Reason       string `json:"reason,omitempty" validate:"max=200"`
+}
+
+// RestoreResponse describes a completed point-in-time restore
+type RestoreResponse struct {
+	SnapshotID     string `json:"snapshotId"`
+	TargetOffset   int    `json:"targetOffset"`
+	EventsReplayed int    `json:"eventsReplayed"`
+	ProductCount   int    `json:"productCount"`
+	TotalAvailable int    `json:"totalAvailable"`
+	RestoreOffset  int    `json:"restoreOffset"` // Offset of the system_restored event
+	RestoredAt     string `json:"restoredAt"`
+}
+
+// EventFilter is a named subset of the catalog a store subscribes to. An event
+// matches when its product is listed or belongs to one of the categories.
+type EventFilter struct {
+	ID         string   `json:"id"`
+	ProductIDs []string `json:"productIds"`
+	Categories []string `json:"categories"`
+	StoreIDs   []string `json:"storeIds"` // Stores whose polls use this filter by default
+	UpdatedAt  string   `json:"updatedAt"`
+}
+
+// EventFilterRequest creates or replaces a named event filter
+type EventFilterRequest struct {
+	ProductIDs []string `json:"productIds" validate:"max=10000"`
+	Categories []string `json:"categories" validate:"max=100"`
+	StoreIDs   []string `json:"storeIds" validate:"max=1000"`
+}
+
+// EventFilterListResponse lists the named event filters
+type EventFilterListResponse struct {
+	Filters []EventFilter `json:"filters"`
+}
+
+// Category is a node of the product category tree. Products carry the ID in
+// their category field; a category covers the products of its subcategories.
+type Category struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ParentID  string `json:"parentId,omitempty"` // Empty for a top-level category
+	UpdatedAt string `json:"updatedAt"`
+	// Applies to the category's products without a policy of their own,
+	// and to its subcategories without one
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"`
+}
+
+// CategoryRequest creates a category or renames and moves an existing one
+type CategoryRequest struct {
+	Name        string       `json:"name" validate:"required,max=100"`
+	ParentID    string       `json:"parentId,omitempty"`
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"` // Omitted or {} for none
+}
+
+// CategoryListResponse lists every category, sorted by ID
+type CategoryListResponse struct {
+	Categories []Category `json:"categories"`
+}
+
+// ForecastWindow is the sales velocity over one trailing window. Sales are the
+// decreases in available stock recorded by product events.
+type ForecastWindow struct {
+	Days                  int      `json:"days"`
+	UnitsSold             int      `json:"unitsSold"`
+	VelocityPerDay        float64  `json:"velocityPerDay"`
+	DaysOfStock           *float64 `json:"daysOfStock"`           // Nil when nothing sold in the window
+	ProjectedStockoutDate *string  `json:"projectedStockoutDate"` // YYYY-MM-DD, nil when nothing sold
+	Partial               bool     `json:"partial,omitempty"`     // Event history covers less than the window
+}
+
+// ProductForecast projects when a product runs out at its recent sales velocity
+type ProductForecast struct {
+	ProductID             string           `json:"productId"`
+	Name                  string           `json:"name"`
+	Category              string           `json:"category,omitempty"`
+	Available             int              `json:"available"`
+	DaysOfStock           *float64         `json:"daysOfStock"`           // Lowest across windows
+	ProjectedStockoutDate *string          `json:"projectedStockoutDate"` // Earliest across windows
+	Windows               []ForecastWindow `json:"windows"`
+}
+
+// ProductForecastResponse is the forecast for one product
+type ProductForecastResponse struct {
+	Forecast    ProductForecast `json:"forecast"`
+	HistoryFrom string          `json:"historyFrom"` // Timestamp of the oldest retained event
+	GeneratedAt string          `json:"generatedAt"`
+}
+
+// ForecastReport lists product forecasts, soonest stock-out first
+type ForecastReport struct {
+	Products    []ProductForecast `json:"products"`
+	Count       int               `json:"count"`
+	HistoryFrom string            `json:"historyFrom"`
+	GeneratedAt string            `json:"generatedAt"`
+}
+
+// SalesReportRow is the units one store sold of one product on one day
+type SalesReportRow struct {
+	Date         string `json:"date"`
+	ProductID    string `json:"productId"`
+	StoreID      string `json:"storeId"`
+	ProductName  string `json:"productName"`
+	Category     string `json:"category,omitempty"`
+	UnitsSold    int    `json:"unitsSold"`
+	Transactions int    `json:"transactions"` // Applied updates that removed stock
+}
+
+// SalesReport aggregates the sales of one UTC day
+type SalesReport struct {
+	Date              string           `json:"date"`
+	Rows              []SalesReportRow `json:"rows"`
+	TotalUnits        int              `json:"totalUnits"`
+	TotalTransactions int              `json:"totalTransactions"`
+	ProcessedOffset   int64            `json:"processedOffset"` // Events below this offset are included
+	GeneratedAt       string           `json:"generatedAt"`
+}
+
+// ArchiveSegment is one gzip-compressed batch of rotated events in the archive
+type ArchiveSegment struct {
+	ID          string `json:"id"`
+	Key         string `json:"key"`
+	FirstOffset int64  `json:"firstOffset"`
+	LastOffset  int64  `json:"lastOffset"`
+	SizeBytes   int64  `json:"sizeBytes"`
+}
+
+// ArchiveStats counts the rotated events handed to the archiver since startup
+type ArchiveStats struct {
+	Sink           string `json:"sink"`
+	PendingBatches int    `json:"pendingBatches"`
+	ArchivedEvents int64  `json:"archivedEvents"`
+	DroppedEvents  int64  `json:"droppedEvents"`
+	FailedEvents   int64  `json:"failedEvents"`
+}
+
+// ArchiveSegmentListResponse lists archived segments ordered by offset
+type ArchiveSegmentListResponse struct {
+	Segments []ArchiveSegment `json:"segments"`
+	Stats    ArchiveStats     `json:"stats"`
+}
+
+// ArchiveSegmentResponse is one archived segment with its events
+type ArchiveSegmentResponse struct {
+	Segment ArchiveSegment `json:"segment"`
+	Events  []Event        `json:"events"`
+}
+
+// AdminDashboardResponse aggregates what the admin dashboard shows in one payload
+type AdminDashboardResponse struct {
+	TotalSKUs        int                 `json:"totalSkus"`
+	TotalUnits       int64               `json:"totalUnits"`
+	OutOfStockCount  int                 `json:"outOfStockCount"`
+	LowStock         []LowStockProduct   `json:"lowStock"` // In-stock products with the fewest units
+	UpdatesLastHour  int64               `json:"updatesLastHour"`
+	UpdatesPerMinute float64             `json:"updatesPerMinute"` // Average over the last hour
+	EventQueue       DashboardEventQueue `json:"eventQueue"`
+	StoreLag         StoreLagSummary     `json:"storeLag"`
+	GeneratedAt      string              `json:"generatedAt"`
+}
+
+// LowStockProduct is a product listed in the dashboard's low stock table
+type LowStockProduct struct {
+	ProductID string `json:"productId"`
+	Name      string `json:"name"`
+	Available int    `json:"available"`
+	Category  string `json:"category,omitempty"`
+}
+
+// DashboardEventQueue summarizes the event queue for the dashboard
+type DashboardEventQueue struct {
+	Depth        int    `json:"depth"` // Events currently retained
+	OldestOffset *int64 `json:"oldestOffset,omitempty"`
+	NextOffset   int64  `json:"nextOffset"`
+}
+
+// StoreLagSummary reports how far behind the event stream the stores are,
+// from the offsets they last committed
+type StoreLagSummary struct {
+	StoreCount     int        `json:"storeCount"`
+	MaxLagEvents   int64      `json:"maxLagEvents"`
+	MaxLagStoreID  string     `json:"maxLagStoreId,omitempty"`
+	TotalLagEvents int64      `json:"totalLagEvents"`
+	Stores         []StoreLag `json:"stores"` // Most behind first
+}
+
+// StoreLag is one store's distance from the head of the event stream
+type StoreLag struct {
+	StoreID   string       `json:"storeId"`
+	Offset    int64        `json:"offset"`
+	LagEvents int64        `json:"lagEvents"`
+	LastSeen  string       `json:"lastSeen"`
+	Replay    *StoreReplay `json:"replay,omitempty"` // Latest operator-requested rebuild
+}
+
+// AuthLockoutEntry is an IP address or API key with recent authentication
+// failures. LockedUntil is set while it is locked out.
+type AuthLockoutEntry struct {
+	ID          string `json:"id"`        // Opaque handle for unblocking; API keys are never shown in full
+	Principal   string `json:"principal"` // "ip:<address>" or a masked "key:<prefix>****"
+	Failures    int    `json:"failures"`  // Failures in the current window
+	Strikes     int    `json:"strikes"`   // Lockouts so far; each one doubles the next
+	LockedUntil string `json:"lockedUntil,omitempty"`
+	LastFailure string `json:"lastFailure"`
+}
+
+// AuthLockoutListResponse lists tracked principals, locked ones first
+type AuthLockoutListResponse struct {
+	Lockouts []AuthLockoutEntry `json:"lockouts"`
+	Locked   int                `json:"locked"`
+}
+
+// MaintenanceStatus is the central API's read-only maintenance mode. While it
+// is enabled, writes are refused with 503; reads and event consumption go on.
+type MaintenanceStatus struct {
+	Enabled           bool   `json:"enabled"`
+	Message           string `json:"message,omitempty"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds"`
+	Since             string `json:"since,omitempty"`     // When maintenance was last turned on or off
+	ChangedBy         string `json:"changedBy,omitempty"` // Admin who last changed it; empty when set by configuration
+}
+
+// MaintenanceRequest turns maintenance mode on or off. RetryAfterSeconds
+// keeps its current value when omitted.
+type MaintenanceRequest struct {
+	Enabled           *bool  `json:"enabled" validate:"required"`
+	Message           string `json:"message,omitempty" validate:"max=200"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds,omitempty" validate:"omitempty,min=1,max=3600"`
+}
+
+// MetadataCounters are the counters the data file keeps beside the products
+type MetadataCounters struct {
+	TotalProducts int    `json:"totalProducts"`
+	LastOffset    int    `json:"lastOffset"` // Next event offset
+	LastUpdated   string `json:"lastUpdated"`
+	OutboxSeq     int64  `json:"outboxSeq"`
+}
+
+// MetadataRecountResponse compares the recorded metadata counters with the
+// ones recomputed from the products and the event queue
+type MetadataRecountResponse struct {
+	Recorded      MetadataCounters `json:"recorded"`
+	Actual        MetadataCounters `json:"actual"`
+	Discrepancies []string         `json:"discrepancies"` // Counters that differed
+	Repaired      bool             `json:"repaired"`      // The recorded counters were replaced by the actual ones
+	DryRun        bool             `json:"dryRun,omitempty"`
+}
+
+// LeaderRecord names the instance that writes the shared data files; the
+// leader keeps it in the lock file for followers to find
+type LeaderRecord struct {
+	InstanceID string `json:"instanceId"`
+	URL        string `json:"url"`             // Where followers forward writes
+	Since      string `json:"since,omitempty"` // When it became leader
+}
+
+// LeaderStatus is one instance's view of leader election
+type LeaderStatus struct {
+	InstanceID string        `json:"instanceId"`
+	Role       string        `json:"role"` // leader or follower
+	Leader     *LeaderRecord `json:"leader,omitempty"`
+}
+
+// HealthResponse is the health check result. Leader is only set when leader
+// election is on.
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Leader *LeaderStatus `json:"leader,omitempty"`
+}
+
+// SLO kinds
+const (
+	SLOAvailability = "availability" // Requests that did not fail with a 5xx
+	SLOLatency      = "latency"      // Requests answered within the p99 target
 )
+
+// SLO alert severities, from multiwindow burn-rate rules over a 30-day budget
+const (
+	SLOAlertPage   = "page"   // Budget burning fast: 2% in an hour or 5% in six
+	SLOAlertTicket = "ticket" // Budget burning slowly: 10% in a day or three
+)
+
+// SLOObjectiveStatus is the error budget of one objective of an endpoint
+// group. Counts, budget and burn rates only cover what this instance served
+// since it started.
+type SLOObjectiveStatus struct {
+	Group                string             `json:"group"` // writes, reads, events or admin
+	SLO                  string             `json:"slo"`   // availability or latency
+	Target               float64            `json:"target"`
+	LatencyThresholdMs   int64              `json:"latencyThresholdMs,omitempty"` // p99 target of latency objectives
+	Requests             int64              `json:"requests"`                     // Over the budget window
+	BadRequests          int64              `json:"badRequests"`
+	ErrorBudgetRemaining float64            `json:"errorBudgetRemaining"` // 1 untouched, 0 spent, negative overspent
+	BurnRates            map[string]float64 `json:"burnRates"`            // By window, e.g. "1h"; 1 spends the budget exactly over the budget window
+	Alert                string             `json:"alert,omitempty"`      // page or ticket while a burn-rate rule fires
+}
+
+// SLOStatus is the state of every configured objective
+type SLOStatus struct {
+	BudgetWindow string               `json:"budgetWindow"`
+	Objectives   []SLOObjectiveStatus `json:"objectives"`
+}
@@ -0,0 +1,42 @@
+package openapi
+
+import (
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// UndocumentedRoutes walks the router and returns "METHOD path" keys of routes
+// missing from the documented set, so drift shows up at startup and in tests
+func UndocumentedRoutes(router *mux.Router, routes []Route, ignore ...string) []string {
+	documented := make(map[string]bool)
+	for _, key := range RouteKeys(routes) {
+		documented[key] = true
+	}
+	for _, key := range ignore {
+		documented[key] = true
+	}
+
+	var missing []string
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Subrouter prefixes carry no methods
+			return nil
+		}
+		for _, method := range methods {
+			key := method + " " + path
+			if !documented[key] {
+				missing = append(missing, key)
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(missing)
+	return missing
+}
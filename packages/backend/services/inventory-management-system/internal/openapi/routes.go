@@ -0,0 +1,1422 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/models"
+)
+
+// Security requirements used by routes. A bearer JWT is accepted wherever an
+// API key is.
+const (
+	SecurityNone   = ""
+	SecurityAPI    = "ApiKeyAuth"
+	SecurityAdmin  = "AdminApiKeyAuth"
+	SecurityBearer = "BearerAuth"
+)
+
+// Route documents one HTTP route. Request and response bodies are Go values
+// (usually zero values of model types) or *Schema for ad-hoc payloads.
+type Route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Security    string
+	Permission  authz.Permission // Required of the caller's roles by AuthorizeMiddleware
+	KeepOpen    bool             // Write still served in read-only maintenance mode
+	Deprecated  string           // Date (2006-01-02) the route was deprecated, sent in the Deprecation header
+	Sunset      string           // Date (2006-01-02) the route is removed, sent in the Sunset header
+	Parameters  []Parameter
+	Request     interface{}
+	Responses   map[int]interface{}
+}
+
+// freeFormObject is used for responses built from maps
+var freeFormObject = &Schema{Type: "object", AdditionalProperties: &Schema{}}
+
+func pathParam(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "string"}}
+}
+
+func queryParam(name, schemaType, description string, required bool) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Required: required, Schema: &Schema{Type: schemaType}}
+}
+
+// Routes returns the documented routes of the central inventory API: the /v1
+// routes and their copies under every later version. Keep it in step with the
+// router in cmd/server; UndocumentedRoutes reports drift.
+func Routes() []Route {
+	routes := v1Routes()
+	for _, version := range apiversion.Versions[1:] {
+		routes = append(routes, Versioned(routes, version)...)
+	}
+	return routes
+}
+
+// v1Routes returns the /v1 routes and the unversioned ones (/health)
+func v1Routes() []Route {
+	errorResponse := models.ErrorResponse{}
+
+	return []Route{
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/inventory/updates",
+			OperationID: "updateInventory",
+			Summary:     "Apply a single or batch inventory update",
+			Description: "Send productId/delta/version/idempotencyKey for a single update, or an updates array for a batch. idempotencyKey must be a UUIDv4 or a ULID (400 invalid_idempotency_key) and is deduplicated per storeId, or X-Store-ID when the body has none; retries are answered from the cache with replayed=true and the Idempotency-Replayed header. Versions use optimistic concurrency. minAvailable and expectedAvailable make an update (or batch item) compare-and-set on the product's stock, checked under the product lock; with either set, version may be 0 to skip the version check. Single updates answer 200 applied, 409 version_conflict, 404 product_not_found, 412 condition_failed, 422 insufficient_inventory or a stock policy's backorder_limit_exceeded or max_stock_exceeded, 423 stocktake_frozen, 429 load_shed or stocktake_hold or 503 queue_saturated (all but 423 with Retry-After), always with the UpdateResponse envelope; batches answer 200 with per-item results. Applied updates leaving less than the policy's minStock carry belowMinStock=true, and sales taken beyond the stock under allowBackorder report the queued units in backordered. Under pressure, sync and bulk updates are shed before checkout decrements (INVENTORY_QUEUE_LANE_QUOTAS). A partition (PARTITION_ID) answers 421 wrong_partition for products another partition owns; a partition router splits batches across partitions and merges the results in request order.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryWrite,
+			Parameters: []Parameter{
+				{Name: "X-Update-Priority", In: "header", Description: "Update lane: checkout (default), sync for replayed offline writes, or bulk (default for admin keys)", Schema: &Schema{Type: "string", Enum: []string{"checkout", "sync", "bulk"}}},
+			},
+			Request: models.UpdateRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.UpdateResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusNotFound:              models.UpdateResponse{},
+				http.StatusConflict:              models.UpdateResponse{},
+				http.StatusPreconditionFailed:    models.UpdateResponse{},
+				http.StatusRequestEntityTooLarge: errorResponse,
+				http.StatusUnprocessableEntity:   models.UpdateResponse{},
+				http.StatusLocked:                models.UpdateResponse{},
+				http.StatusMisdirectedRequest:    errorResponse,
+				http.StatusTooManyRequests:       models.UpdateResponse{},
+				http.StatusServiceUnavailable:    errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/inventory/batch-get",
+			OperationID: "batchGetProducts",
+			Summary:     "Read several products in one round trip",
+			Description: "Returns the found products in request order and lists the IDs that do not exist under missing. Duplicate IDs are returned once; the batch size limit is MAX_BATCH_UPDATE_ITEMS.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			KeepOpen:    true,
+			Request:     models.BatchGetRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.BatchGetResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/versions",
+			OperationID: "getProductVersions",
+			Summary:     "Read current versions and stock for several products",
+			Description: "Returns only version and available per product, keyed by product ID, so clients can prepare OCC updates cheaply. Unknown IDs are listed under missing; the batch size limit is MAX_BATCH_UPDATE_ITEMS.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters: []Parameter{
+				queryParam("ids", "string", "Comma-separated product IDs; the parameter may also be repeated", true),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.VersionsResponse{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/events",
+			OperationID: "getEvents",
+			Summary:     "Read inventory change events from an offset",
+			Description: "Answers 410 offset_gone when the offset was rotated or compacted away, or with reason=replay_requested once after an operator asked for the store's replay; the body carries oldestAvailableOffset and the snapshotOffset to resume from after a full resync. With Accept: application/x-protobuf the 200 body is protobuf-encoded as described in internal/events/events.proto; errors stay JSON. productIds, category and filter narrow the stream server-side (their union is delivered); without them a store gets the filter allocated to its X-Store-ID, if any. Filtered pages set filtered=true and their offsets have gaps; nextOffset skips the events left out. On a partition router the stream merges every partition's events, each tagged with its partition and partitionOffset, and partitionOffsets gives each partition's offset matching nextOffset; partition=<id> reads that partition's own stream instead.",
+			Tag:         "events",
+			Security:    SecurityAPI,
+			Permission:  authz.PermEventsConsume,
+			Parameters: []Parameter{
+				queryParam("offset", "integer", "Starting event offset", true),
+				queryParam("limit", "integer", "Maximum events to return (default 100, max 1000)", false),
+				queryParam("wait", "integer", "Long-poll seconds when no events are available (max 60)", false),
+				queryParam("productIds", "string", "Comma-separated product IDs to receive events for", false),
+				queryParam("category", "string", "Comma-separated categories to receive events for (case-insensitive); each covers its subcategories", false),
+				queryParam("filter", "string", "ID of a named event filter", false),
+				queryParam("partition", "string", "On a partition router, the partition whose own stream to read", false),
+				{Name: "X-Committed-Offset", In: "header", Description: "Offset the store (X-Store-ID) has applied; defaults to the requested offset", Schema: &Schema{Type: "integer"}},
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.EventsResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+				http.StatusGone:       models.OffsetGoneResponse{},
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/inventory/events/commit",
+			OperationID: "commitEventOffset",
+			Summary:     "Commit the event offset applied by a store",
+			Description: "Requires X-Store-ID. Records that the store applied every event before offset; committed offsets only move forward. Events are only rotated once every registered store has committed past them (up to MAX_RETAINED_EVENTS).",
+			Tag:         "events",
+			Security:    SecurityAPI,
+			Permission:  authz.PermEventsConsume,
+			KeepOpen:    true,
+			Request:     models.EventCommitRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.EventConsumer{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/inventory/transfers",
+			OperationID: "createTransfer",
+			Summary:     "Move stock between two stores' allocations",
+			Description: "Moves quantity units of a product from fromStoreId to toStoreId in one step. Available stock is unchanged; the version advances once and a single stock_transferred event is published. version is an optional OCC check. Answers 201 with the transfer, 409 version_conflict, 404 product_not_found or 422 insufficient_inventory when quantity exceeds the stock. Replays with the same idempotencyKey return the first outcome with replayed=true and the Idempotency-Replayed header.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryWrite,
+			Request:     models.TransferRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:             models.StockTransfer{},
+				http.StatusBadRequest:          errorResponse,
+				http.StatusNotFound:            errorResponse,
+				http.StatusConflict:            errorResponse,
+				http.StatusUnprocessableEntity: errorResponse,
+				http.StatusServiceUnavailable:  errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/transfers",
+			OperationID: "listTransfers",
+			Summary:     "List past stock transfers",
+			Description: "Transfer history, newest first. storeId matches transfers into or out of the store.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters: []Parameter{
+				queryParam("productId", "string", "Only transfers of this product", false),
+				queryParam("storeId", "string", "Only transfers from or to this store", false),
+				queryParam("limit", "integer", "Maximum transfers to return (1-1000, default 100)", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.TransfersResponse{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/orders",
+			OperationID: "createOrder",
+			Summary:     "Reserve stock for every line of an order",
+			Description: "Reserves all lines or none: each product's available stock drops by its quantity, its version advances and an order_reserved event is published per line. The order stays reserved until committed or cancelled. Answers 201 with the order, 404 product_not_found, 422 insufficient_inventory, 423 stocktake_frozen or 429 stocktake_hold; errors name the failing line in details. Replays with the same idempotencyKey return the first outcome with replayed=true and the Idempotency-Replayed header.",
+			Tag:         "orders",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryWrite,
+			Request:     models.OrderRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:             models.Order{},
+				http.StatusBadRequest:          errorResponse,
+				http.StatusNotFound:            errorResponse,
+				http.StatusUnprocessableEntity: errorResponse,
+				http.StatusLocked:              errorResponse,
+				http.StatusTooManyRequests:     errorResponse,
+				http.StatusServiceUnavailable:  errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/orders/{id}",
+			OperationID: "getOrder",
+			Summary:     "Get an order",
+			Tag:         "orders",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters:  []Parameter{pathParam("id", "Order ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.Order{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/orders/{id}/commit",
+			OperationID: "commitOrder",
+			Summary:     "Sell an order's reserved stock",
+			Description: "Stock is unchanged; each product's version advances and an order_committed event is published per line. Committing a committed order returns it unchanged; a cancelled order answers 409 order_finished.",
+			Tag:         "orders",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryWrite,
+			Parameters:  []Parameter{pathParam("id", "Order ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.Order{},
+				http.StatusNotFound:           errorResponse,
+				http.StatusConflict:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/orders/{id}/cancel",
+			OperationID: "cancelOrder",
+			Summary:     "Release an order's reserved stock",
+			Description: "The reserved units return to available stock and an order_cancelled event is published per line. Cancelling a cancelled order returns it unchanged; a committed order answers 409 order_finished.",
+			Tag:         "orders",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryWrite,
+			Parameters:  []Parameter{pathParam("id", "Order ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.Order{},
+				http.StatusNotFound:           errorResponse,
+				http.StatusConflict:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/{productId}/price",
+			OperationID: "getProductPrice",
+			Summary:     "Get a product price in a given currency",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters: []Parameter{
+				pathParam("productId", "Product identifier"),
+				queryParam("currency", "string", "ISO 4217 currency code (defaults to the base currency)", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.PriceResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/{productId}/forecast",
+			OperationID: "getProductForecast",
+			Summary:     "Get a product's sales velocity and projected stock-out date",
+			Description: "Sales are the decreases in available stock recorded by the retained event history, with orders counted when committed rather than when reserved. Each window reports units sold, units per day and the projected stock-out date at that pace; the top-level projection is the earliest. Windows longer than the history are marked partial and averaged over the history instead.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters: []Parameter{
+				pathParam("productId", "Product identifier"),
+				queryParam("windows", "string", "Comma-separated trailing windows in days, 1-365, at most 5 (default 1,7,30)", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.ProductForecastResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/{productId}/backorders",
+			OperationID: "getProductBackorders",
+			Summary:     "Get a product's backorder queue",
+			Description: "Sales of products whose stock policy allows backorders may take available stock below zero; the units beyond the stock are queued per product, oldest first. Restocks fill the queue in that order and publish a backorder_fulfilled event per backorder they fill, with the units filled in quantity and the units still waiting in backorder.remaining.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters:  []Parameter{pathParam("productId", "Product identifier")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.BackordersResponse{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/{productId}",
+			OperationID: "getProduct",
+			Summary:     "Get a product",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters:  []Parameter{pathParam("productId", "Product identifier")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.ProductResponse{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory",
+			OperationID: "listProducts",
+			Summary:     "List products with offset pagination",
+			Description: "With updatedSince only products changed at or after that time are listed, oldest change first, read from a lastUpdated index instead of a full scan. The response then also carries deletedProductIds and asOf, the updatedSince to use for the next poll. Deletions are kept for 7 days; an older updatedSince answers 410 updated_since_gone, after which the consumer lists the full catalog. With Accept: application/x-ndjson the products are streamed one per line in product ID order instead, read and flushed a chunk at a time; limit is then optional and uncapped, updatedSince is rejected, X-Total-Count carries the catalog size and the X-Product-Count trailer the number of lines sent, missing when the stream was cut short.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Parameters: []Parameter{
+				queryParam("offset", "integer", "Number of products to skip (default 0)", false),
+				queryParam("limit", "integer", "Page size (default 50, max 200; uncapped and optional for NDJSON)", false),
+				{Name: "updatedSince", In: "query", Description: "Only list products changed at or after this time (inclusive)", Schema: &Schema{Type: "string", Format: "date-time"}},
+				queryParam("category", "string", "Comma-separated categories to list (case-insensitive); each covers its subcategories", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusBadRequest: errorResponse,
+				http.StatusGone:       errorResponse,
+				http.StatusOK: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"products": {Type: "array", Items: &Schema{Ref: "#/components/schemas/ProductResponse"}},
+						"pagination": {
+							Type: "object",
+							Properties: map[string]*Schema{
+								"offset":      {Type: "integer"},
+								"limit":       {Type: "integer"},
+								"total_count": {Type: "integer"},
+								"has_more":    {Type: "boolean"},
+							},
+						},
+						"deletedProductIds": {Type: "array", Items: &Schema{Type: "string"}},
+						"asOf":              {Type: "string", Format: "date-time"},
+					},
+				},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/categories",
+			OperationID: "listCategories",
+			Summary:     "List the category tree",
+			Description: "Categories are listed flat, sorted by ID; parentId links each to its parent.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.CategoryListResponse{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/inventory/snapshot",
+			OperationID: "getCatalogSnapshot",
+			Summary:     "Stream the whole catalog as of one event offset",
+			Description: "The catalog is copied at a single point in time and streamed with chunked transfer encoding. Poll events from eventOffset to catch up: changes missing from the snapshot are all at or after it. The X-Snapshot-Offset and X-Snapshot-Product-Count headers repeat the metadata; a body with fewer products than productCount was cut short and should be fetched again.",
+			Tag:         "inventory",
+			Security:    SecurityAPI,
+			Permission:  authz.PermInventoryRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"eventOffset":  {Type: "integer"},
+						"productCount": {Type: "integer"},
+						"products":     {Type: "array", Items: &Schema{Ref: "#/components/schemas/ProductResponse"}},
+					},
+				},
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/products/set",
+			OperationID: "adminSetProducts",
+			Summary:     "Update product fields",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Request:     models.AdminSetRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.AdminSetResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/products/create",
+			OperationID: "adminCreateProducts",
+			Summary:     "Create products",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsCreate,
+			Description: "A partition (PARTITION_ID) answers 421 wrong_partition when it does not own every product; create them on the partition owning their hash range.",
+			Request:     models.AdminCreateRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.AdminCreateResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+				http.StatusMisdirectedRequest:    errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/products/delete",
+			OperationID: "adminDeleteProducts",
+			Summary:     "Delete products",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsDelete,
+			Request:     models.AdminDeleteRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.AdminDeleteResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/products/{productId}/assets",
+			OperationID: "getProductAssets",
+			Summary:     "List a product's image and asset references",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermInventoryRead,
+			Parameters:  []Parameter{pathParam("productId", "Product ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.ProductAssetsResponse{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/products/{productId}/assets",
+			OperationID: "setProductAssets",
+			Summary:     "Replace a product's assets",
+			Description: "Replaces the whole list; an empty list clears it. Each asset is an image, thumbnail, video or document with an absolute http(s) URL and a sha256:<hex> checksum; URLs must be unique and at most 20 assets are kept. The change bumps the product version and reaches stores as a product_updated event.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Parameters:  []Parameter{pathParam("productId", "Product ID")},
+			Request:     models.ProductAssetsRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.ProductAssetsResponse{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusNotFound:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/products/{productId}/assets",
+			OperationID: "putProductAsset",
+			Summary:     "Add an asset, or replace the one with the same URL",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Parameters:  []Parameter{pathParam("productId", "Product ID")},
+			Request:     models.ProductAsset{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.ProductAssetsResponse{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusNotFound:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/products/{productId}/assets",
+			OperationID: "removeProductAsset",
+			Summary:     "Remove the asset with a URL",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Parameters: []Parameter{
+				pathParam("productId", "Product ID"),
+				queryParam("url", "string", "URL of the asset to remove", true),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.ProductAssetsResponse{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusNotFound:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/products/set/jobs",
+			OperationID: "startAdminSetProductsJob",
+			Summary:     "Update product fields in a background job",
+			Description: "Takes the same body as PUT /v1/admin/products/set for up to MAX_ADMIN_JOB_ITEMS products and MAX_ADMIN_JOB_BODY_BYTES, and answers 202 with the queued job and its Location. The job applies ADMIN_JOB_CHUNK_SIZE products at a time, persisting after each chunk; GET /v1/admin/jobs/{id} reports progress and per-item results. Answers 503 job_queue_full with Retry-After when ADMIN_JOB_QUEUE_SIZE jobs are already waiting.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Request:     models.AdminSetRequest{},
+			Responses: map[int]interface{}{
+				http.StatusAccepted:              models.Job{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+				http.StatusServiceUnavailable:    errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/jobs",
+			OperationID: "startJob",
+			Summary:     "Start a background job of a registered type",
+			Description: "params are decoded by the job type; products_set takes the body of PUT /v1/admin/products/set and restore the body of POST /v1/admin/restore, reporting the restore response as the job's output. Answers 202 with the queued job and its Location, 400 unknown_job_type or the job type's validation error, and 503 job_queue_full with Retry-After when ADMIN_JOB_QUEUE_SIZE jobs are already waiting. GET /v1/admin/jobs lists the registered types.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			Request:     models.JobStartRequest{},
+			Responses: map[int]interface{}{
+				http.StatusAccepted:           models.Job{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/jobs",
+			OperationID: "listJobs",
+			Summary:     "List background jobs, newest first, without per-item results",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				queryParam("type", "string", "Only jobs of this type", false),
+				queryParam("status", "string", "Only jobs in this status: queued, running, succeeded, failed or cancelled", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK: models.JobsResponse{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/jobs/{id}",
+			OperationID: "getJob",
+			Summary:     "Get a background job's progress and per-item results",
+			Description: "A job is queued, running, succeeded, failed or cancelled. A failed or cancelled job stopped early and keeps the results of the items it processed. Job records are saved in JOBS_FILE_PATH; jobs interrupted by a restart are recorded as failed. The last 100 finished jobs are kept.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters:  []Parameter{pathParam("id", "Job ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.Job{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/jobs/{id}/cancel",
+			OperationID: "cancelJob",
+			Summary:     "Cancel a queued or running job",
+			Description: "A queued job is cancelled at once. A running job stops at its next checkpoint, after the current chunk for products_set or before the inventory is swapped for restore, keeping the items already applied, so the 202 response may still show it running. Answers 409 job_finished for a job that already ended.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("id", "Job ID")},
+			Responses: map[int]interface{}{
+				http.StatusAccepted: models.Job{},
+				http.StatusNotFound: errorResponse,
+				http.StatusConflict: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/snapshots",
+			OperationID: "createSnapshot",
+			Summary:     "Capture a named snapshot of the full inventory",
+			Description: "Copies every product at a single point in time, with the event offset it corresponds to, and stores it gzip-compressed under SNAPSHOTS_DIR.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Request:     models.SnapshotCreateRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:               models.SnapshotInfo{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/snapshots",
+			OperationID: "listSnapshots",
+			Summary:     "List stored snapshots, newest first",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.SnapshotListResponse{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/snapshots/{id}",
+			OperationID: "getSnapshot",
+			Summary:     "Download a snapshot or diff it against the current inventory",
+			Description: "Returns the snapshot's products. With diff=true returns a SnapshotDiff instead: per-product stock, version and price changes, products added and removed since, and the net change in units.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				pathParam("id", "Snapshot ID"),
+				queryParam("diff", "boolean", "Compare with the current inventory instead of downloading", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.SnapshotResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/restore",
+			OperationID: "restoreInventory",
+			Summary:     "Restore the inventory to a point in time",
+			Description: "Loads a snapshot and replays the events between its offset and targetOffset (default: the snapshot's offset). Inventory writes answer 503 with Retry-After while the restore runs. Publishes a system_restored event so stores perform a forced full resync.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Request:     models.RestoreRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.RestoreResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusNotFound:              errorResponse,
+				http.StatusConflict:              errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+				http.StatusServiceUnavailable:    errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/forecast",
+			OperationID: "getForecastReport",
+			Summary:     "Forecast stock-outs for every product",
+			Description: "Same computation as getProductForecast for the whole catalog, sorted by days of stock (soonest first); products that sold nothing come last.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				queryParam("windows", "string", "Comma-separated trailing windows in days, 1-365, at most 5 (default 1,7,30)", false),
+				queryParam("withinDays", "integer", "Only list products projected to run out within this many days", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.ForecastReport{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/dashboard",
+			OperationID: "getAdminDashboard",
+			Summary:     "Get the admin dashboard",
+			Description: "Everything the admin dashboard shows in one call: SKU, unit and out-of-stock counts, the in-stock products with the fewest units, inventory updates applied in the last hour, the event queue depth and each store's lag behind the event stream, measured from the offset it last committed (most behind first).",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				queryParam("lowStock", "integer", "Number of low stock products to list, 1-100 (default 10)", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.AdminDashboardResponse{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/reports/sales",
+			OperationID: "getSalesReport",
+			Summary:     "Get the daily sales report",
+			Description: "Units sold per product and store on one UTC day, aggregated in the background from the negative deltas of store updates and from committed orders in the event stream. Events up to processedOffset are included. With format=csv or Accept: text/csv the report is returned as CSV with the columns date, product_id, store_id, product_name, category, units_sold and transactions.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				queryParam("date", "string", "Report day as YYYY-MM-DD, in UTC (default today)", false),
+				queryParam("format", "string", "json (default) or csv", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.SalesReport{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/stocktakes",
+			OperationID: "startStocktake",
+			Summary:     "Start a cycle count",
+			Description: "Opens a stocktake over the products of a category, or every product, for one store or all stores. While it is open, decrements of those products from the counted store are refused with 423 stocktake_frozen (mode reject) or 429 stocktake_hold with Retry-After so stores queue and replay them (mode queue). Answers 409 stocktake_conflict when an open stocktake already holds one of the products for the same store.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			Request:     models.StocktakeStartRequest{},
+			Responses: map[int]interface{}{
+				http.StatusCreated:    models.StocktakeSession{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusConflict:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/stocktakes",
+			OperationID: "listStocktakes",
+			Summary:     "List stocktakes, newest first",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.StocktakesResponse{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/stocktakes/{id}",
+			OperationID: "getStocktake",
+			Summary:     "Get a stocktake and its variance report",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters:  []Parameter{pathParam("id", "Stocktake ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.StocktakeReport{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/stocktakes/{id}/counts",
+			OperationID: "recordStocktakeCounts",
+			Summary:     "Record counted quantities",
+			Description: "Each count replaces an earlier count of the same product. Variance is the counted quantity minus the system stock when the count was recorded.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			Parameters:  []Parameter{pathParam("id", "Stocktake ID")},
+			Request:     models.StocktakeCountsRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.StocktakeReport{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+				http.StatusConflict:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/stocktakes/{id}/close",
+			OperationID: "closeStocktake",
+			Summary:     "Close a stocktake and apply its variances",
+			Description: "Applies every non-zero variance as an adjustment event with reason stocktake, which sales reports and forecasts ignore, then lifts the freeze.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			Parameters:  []Parameter{pathParam("id", "Stocktake ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.StocktakeReport{},
+				http.StatusNotFound: errorResponse,
+				http.StatusConflict: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/stocktakes/{id}/cancel",
+			OperationID: "cancelStocktake",
+			Summary:     "Cancel a stocktake without applying counts",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			Parameters:  []Parameter{pathParam("id", "Stocktake ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.StocktakeReport{},
+				http.StatusNotFound: errorResponse,
+				http.StatusConflict: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/event-filters",
+			OperationID: "listEventFilters",
+			Summary:     "List named event filters",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.EventFilterListResponse{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/event-filters/{id}",
+			OperationID: "getEventFilter",
+			Summary:     "Get a named event filter",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters:  []Parameter{pathParam("id", "Filter ID")},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.EventFilter{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/event-filters/{id}",
+			OperationID: "putEventFilter",
+			Summary:     "Create or replace a named event filter",
+			Description: "The filter delivers events for any of its productIds or categories. Stores listed in storeIds get it on polls that name no filter; a store can be allocated to one filter only (409 store_already_allocated). IDs are 1-64 lowercase letters, digits, '-' or '_'.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("id", "Filter ID")},
+			Request:     models.EventFilterRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.EventFilter{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusConflict:              errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/event-filters/{id}",
+			OperationID: "deleteEventFilter",
+			Summary:     "Delete a named event filter",
+			Description: "Polls naming the deleted filter answer 404 filter_not_found.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("id", "Filter ID")},
+			Responses: map[int]interface{}{
+				http.StatusNoContent: nil,
+				http.StatusNotFound:  errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/categories/{id}",
+			OperationID: "putCategory",
+			Summary:     "Create, rename or move a category",
+			Description: "parentId places the category under another one; moving a category takes its subcategories along, and a category cannot be moved under itself or its subcategories (409 category_cycle). IDs are 1-64 lowercase letters, digits, '-' or '_'. stockPolicy applies to products in the category and its subcategories without a policy of their own; {} clears it. Once a category exists, products can only be created or set in a category of the tree (unknown_category). Stores receive the change as a category_updated event.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Parameters:  []Parameter{pathParam("id", "Category ID")},
+			Request:     models.CategoryRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.Category{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusConflict:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/categories/{id}",
+			OperationID: "deleteCategory",
+			Summary:     "Delete a category",
+			Description: "Only categories without subcategories or products can be deleted (409 category_in_use). Stores receive the change as a category_deleted event.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermProductsUpdate,
+			Parameters:  []Parameter{pathParam("id", "Category ID")},
+			Responses: map[int]interface{}{
+				http.StatusNoContent:          nil,
+				http.StatusNotFound:           errorResponse,
+				http.StatusConflict:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/events/stats",
+			OperationID: "getEventQueueStats",
+			Summary:     "Get event queue size, offsets and registered stores",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.EventQueueStats{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/locks",
+			OperationID: "getLockStats",
+			Summary:     "Get the product locks held or awaited right now",
+			Description: "Counts held locks and blocked waiters and lists the longest current holds, longest first. stalls counts the holds the lock watchdog logged with goroutine stacks for lasting longer than LOCK_WATCHDOG_THRESHOLD.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.LockStats{},
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/events/compact",
+			OperationID: "compactEventQueue",
+			Summary:     "Remove events below an offset",
+			Description: "Stores register by polling events with X-Store-ID. Compaction is refused with 409 consumers_behind while any registered store has not read past beforeOffset.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Request:     models.EventCompactRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.EventCompactResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusConflict:              errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/stores/{storeId}/replay",
+			OperationID: "replayStore",
+			Summary:     "Rebuild one store's cache from a snapshot and replayed events",
+			Description: "Rewinds the store's committed offset to the oldest stored event and marks a replay pending. The store's next events poll answers 410 offset_gone with reason=replay_requested, whatever offset it asks for, so it reloads the catalog and resumes from snapshotOffset. Other stores are not affected. Progress (pending, resyncing, completed) shows on the store's consumer in the event stats and the dashboard's storeLag. Answers 404 store_not_found for a store that never polled events with X-Store-ID.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("storeId", "Store ID the store sends as X-Store-ID")},
+			Responses: map[int]interface{}{
+				http.StatusAccepted: models.EventConsumer{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/archive/segments",
+			OperationID: "listArchiveSegments",
+			Summary:     "List archived event segments",
+			Description: "Events removed from the queue by rotation or compaction are written to the configured archive sink (ARCHIVE_SINK) as gzip-compressed segments. Stats counts the events archived, dropped or failed since startup. Returns 404 archive_not_configured when archival is disabled.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.ArchiveSegmentListResponse{},
+				http.StatusNotFound:   errorResponse,
+				http.StatusBadGateway: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/archive/segments/{id}",
+			OperationID: "getArchiveSegment",
+			Summary:     "Get the events of an archived segment",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				pathParam("id", "Segment ID, the first and last offset as <first>-<last>"),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.ArchiveSegmentResponse{},
+				http.StatusNotFound:   errorResponse,
+				http.StatusBadGateway: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/archive/events",
+			OperationID: "getArchivedEvents",
+			Summary:     "Re-stream archived events",
+			Description: "Pages through archived events from offset like GET /v1/inventory/events, for offline analytics or to rebuild a store from before the oldest queued event. Offsets skip where a segment failed to archive.",
+			Tag:         "events",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Parameters: []Parameter{
+				queryParam("offset", "integer", "First offset to return (default 0)", false),
+				queryParam("limit", "integer", "Maximum events per page, 1-1000 (default 100)", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.EventsResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+				http.StatusBadGateway: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/rate-limit/status",
+			OperationID: "getRateLimitStatus",
+			Summary:     "Get rate limiter statistics",
+			Tag:         "rate-limit",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK:                 freeFormObject,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/rate-limit/reset",
+			OperationID: "resetRateLimits",
+			Summary:     "Reset all rate limit counters",
+			Tag:         "rate-limit",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Responses: map[int]interface{}{
+				http.StatusOK:                 freeFormObject,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/rate-limit/lockouts",
+			OperationID: "listAuthLockouts",
+			Summary:     "List IP addresses and API keys with recent authentication failures",
+			Description: "Locked principals come first. API keys are masked; use the returned id to unblock.",
+			Tag:         "rate-limit",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.AuthLockoutListResponse{},
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/rate-limit/lockouts/{id}",
+			OperationID: "unblockAuthLockout",
+			Summary:     "Lift a lockout and forget the principal's failures",
+			Tag:         "rate-limit",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("id", "Lockout ID from the listing")},
+			Responses: map[int]interface{}{
+				http.StatusNoContent:          nil,
+				http.StatusNotFound:           errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/config",
+			OperationID: "getRuntimeConfig",
+			Summary:     "Get runtime-tunable settings and recent changes",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.RuntimeConfigResponse{},
+			},
+		},
+		{
+			Method:      http.MethodPatch,
+			Path:        "/v1/admin/config",
+			OperationID: "patchRuntimeConfig",
+			Summary:     "Change runtime-tunable settings without a restart",
+			Description: "Settings: rateLimitRequestsPerMinute, rateLimitAdminRequestsPerMinute, rateLimitWindowMinutes, rateLimitBurstSize, rateLimitTiers, rateLimitPrincipalTiers, inventoryWorkerCount, maxEventsInQueue, maxRetainedEvents. Values are strings or numbers. All changes are validated first and applied together, or none with 400; each applied change is recorded in the audit trail with the masked admin key and reason.",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Request:     models.RuntimeConfigPatchRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:                    models.RuntimeConfigPatchResponse{},
+				http.StatusBadRequest:            errorResponse,
+				http.StatusRequestEntityTooLarge: errorResponse,
+				http.StatusServiceUnavailable:    errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/feature-flags",
+			OperationID: "listFeatureFlags",
+			Summary:     "List feature flags with their environment-wide values and store overrides",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.FeatureFlagsResponse{},
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/feature-flags/{flag}",
+			OperationID: "setFeatureFlag",
+			Summary:     "Turn a feature flag on or off",
+			Description: "Without tenant the value applies to the whole environment; with tenant (a store ID) it overrides the environment-wide value for that store. Changes are not persisted across restarts.",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters:  []Parameter{pathParam("flag", "Feature flag name")},
+			Request:     models.FeatureFlagUpdateRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.FeatureFlagsResponse{},
+				http.StatusBadRequest: errorResponse,
+				http.StatusNotFound:   errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/v1/admin/feature-flags/{flag}",
+			OperationID: "clearFeatureFlag",
+			Summary:     "Remove a store override, or reset a flag to its default",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters: []Parameter{
+				pathParam("flag", "Feature flag name"),
+				queryParam("tenant", "string", "Store ID whose override to remove; omit to reset the environment-wide value", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:       models.FeatureFlagsResponse{},
+				http.StatusNotFound: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/maintenance",
+			OperationID: "getMaintenance",
+			Summary:     "Read-only maintenance mode",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.MaintenanceStatus{},
+			},
+		},
+		{
+			Method:      http.MethodPut,
+			Path:        "/v1/admin/maintenance",
+			OperationID: "setMaintenance",
+			Summary:     "Turn read-only maintenance mode on or off",
+			Description: "While enabled, writes answer 503 maintenance_mode with Retry-After and every /v1 response carries X-Maintenance-Mode: read-only. Reads, event polling and commits, and the admin writes marked x-served-in-maintenance keep working. Not kept across restarts; see MAINTENANCE_MODE.",
+			Tag:         "config",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Request:     models.MaintenanceRequest{},
+			Responses: map[int]interface{}{
+				http.StatusOK:         models.MaintenanceStatus{},
+				http.StatusBadRequest: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodPost,
+			Path:        "/v1/admin/maintenance/recount",
+			OperationID: "recountMetadata",
+			Summary:     "Recompute and repair the metadata counters",
+			Description: "totalProducts, lastOffset, lastUpdated and outboxSeq drift when an operation fails halfway, e.g. a delete applied but not saved. They are recomputed from the products and the event queue while commits wait: totalProducts counts the products, lastOffset is the queue's next offset, and lastUpdated and outboxSeq are moved forward to the newest change and the newest outbox seq. Counters that differed are listed in discrepancies and replaced, and the data file is saved; with dryRun=true they are only reported. Served in maintenance mode.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminWrite,
+			KeepOpen:    true,
+			Parameters: []Parameter{
+				queryParam("dryRun", "boolean", "Only report the discrepancies", false),
+			},
+			Responses: map[int]interface{}{
+				http.StatusOK:                 models.MetadataRecountResponse{},
+				http.StatusBadRequest:         errorResponse,
+				http.StatusServiceUnavailable: errorResponse,
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/v1/admin/slo",
+			OperationID: "getSLOStatus",
+			Summary:     "Error budgets and burn-rate alerts per endpoint group",
+			Description: "One objective per availability target of SLO_OBJECTIVES and one per p99 latency target. Burn rates are reported over 5m, 30m, 1h, 2h, 6h, 1d and 3d; alert is page or ticket while a multiwindow burn-rate rule fires against the 30-day budget. Counts are this instance's since it started. The same values are exported as inventory_slo_* metrics.",
+			Tag:         "admin",
+			Security:    SecurityAdmin,
+			Permission:  authz.PermAdminRead,
+			Responses: map[int]interface{}{
+				http.StatusOK: models.SLOStatus{},
+			},
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/health",
+			OperationID: "health",
+			Summary:     "Health check",
+			Tag:         "system",
+			Responses: map[int]interface{}{
+				http.StatusOK: models.HealthResponse{},
+			},
+		},
+	}
+}
+
+// Build generates the OpenAPI document for the given routes
+func Build(version string, routes []Route) *Document {
+	reg := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Inventory Management API",
+			Description: "Central inventory service for store replication, updates, events and administration.",
+			Version:     version,
+		},
+		Tags: []Tag{
+			{Name: "inventory", Description: "Product reads and stock updates"},
+			{Name: "events", Description: "Change event stream for store replication"},
+			{Name: "orders", Description: "Stock reservations for orders, committed or cancelled later"},
+			{Name: "admin", Description: "Product administration (admin API key)"},
+			{Name: "rate-limit", Description: "Rate limiter inspection (admin API key)"},
+			{Name: "config", Description: "Runtime configuration and feature flags (admin API key)"},
+			{Name: "system", Description: "Health and documentation"},
+		},
+		Paths: make(map[string]PathItem),
+		Components: Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				SecurityAPI:    {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "Store or client API key (API_KEYS)"},
+				SecurityAdmin:  {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "Admin API key (ADMIN_API_KEYS)"},
+				SecurityBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "HS256 token signed with JWT_SECRET; its roles claim grants permissions"},
+			},
+		},
+	}
+
+	for _, route := range routes {
+		op := &Operation{
+			OperationID: route.OperationID,
+			Summary:     route.Summary,
+			Description: route.Description,
+			Parameters:  route.Parameters,
+			Responses:   make(map[string]Response),
+		}
+		if route.Tag != "" {
+			op.Tags = []string{route.Tag}
+		}
+		if route.Security != SecurityNone {
+			op.Security = []map[string][]string{{route.Security: {}}, {SecurityBearer: {}}}
+		}
+		op.RequiredPermission = string(route.Permission)
+		op.ServedInMaintenance = route.KeepOpen
+		op.Deprecated = route.Deprecated != ""
+		if route.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: reg.schemaFor(route.Request)}},
+			}
+		}
+		for status, body := range route.Responses {
+			response := Response{Description: http.StatusText(status)}
+			if body != nil {
+				response.Content = map[string]MediaType{"application/json": {Schema: reg.schemaFor(body)}}
+			}
+			op.Responses[strconv.Itoa(status)] = response
+		}
+		if route.Security != SecurityNone {
+			if _, exists := op.Responses["401"]; !exists {
+				op.Responses["401"] = Response{
+					Description: http.StatusText(http.StatusUnauthorized),
+					Content:     map[string]MediaType{"application/json": {Schema: reg.schemaFor(models.ErrorResponse{})}},
+				}
+			}
+		}
+		if route.Permission != "" {
+			if _, exists := op.Responses["403"]; !exists {
+				op.Responses["403"] = Response{
+					Description: http.StatusText(http.StatusForbidden),
+					Content:     map[string]MediaType{"application/json": {Schema: reg.schemaFor(models.ErrorResponse{})}},
+				}
+			}
+		}
+
+		// TimeoutMiddleware covers every versioned route
+		if strings.HasPrefix(route.Path, "/v1/") {
+			if _, exists := op.Responses["504"]; !exists {
+				op.Responses["504"] = Response{
+					Description: http.StatusText(http.StatusGatewayTimeout),
+					Content:     map[string]MediaType{"application/json": {Schema: reg.schemaFor(models.ErrorResponse{})}},
+				}
+			}
+		}
+
+		item, exists := doc.Paths[route.Path]
+		if !exists {
+			item = make(PathItem)
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	// ProductResponse is referenced by hand from the list schema
+	reg.schemaFor(models.ProductResponse{})
+	doc.Components.Schemas = reg.schemas
+
+	return doc
+}
+
+// RouteKeys returns "METHOD path" keys for the documented routes, sorted
+func RouteKeys(routes []Route) []string {
+	keys := make([]string, 0, len(routes))
+	for _, route := range routes {
+		keys = append(keys, route.Method+" "+route.Path)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RoutePermissions maps "METHOD path" keys to the permission each route
+// requires, for AuthorizeMiddleware
+func RoutePermissions(routes []Route) map[string]authz.Permission {
+	permissions := make(map[string]authz.Permission, len(routes))
+	for _, route := range routes {
+		if route.Permission != "" {
+			permissions[route.Method+" "+route.Path] = route.Permission
+		}
+	}
+	return permissions
+}
+
+// MaintenanceRoutes returns the "METHOD path" keys still served in read-only
+// maintenance mode, for MaintenanceMiddleware: every GET, plus the writes
+// marked KeepOpen
+func MaintenanceRoutes(routes []Route) map[string]bool {
+	served := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		if route.Method == http.MethodGet || route.KeepOpen {
+			served[route.Method+" "+route.Path] = true
+		}
+	}
+	return served
+}
+
+// Versioned copies the /v1 routes under version: paths and the paths quoted in
+// descriptions move to the version's prefix, operation IDs gain its suffix
+// ("listJobsV2") and deprecations are dropped, since they are announced for
+// /v1 only. Unversioned routes are skipped.
+func Versioned(routes []Route, version string) []Route {
+	suffix := strings.ToUpper(version[:1]) + version[1:]
+	var versioned []Route
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/"+apiversion.V1+"/") {
+			continue
+		}
+		route.Path = apiversion.Path(version, route.Path)
+		route.OperationID += suffix
+		route.Description = strings.ReplaceAll(route.Description, "/"+apiversion.V1+"/", "/"+version+"/")
+		route.Deprecated = ""
+		route.Sunset = ""
+		versioned = append(versioned, route)
+	}
+	return versioned
+}
+
+// RouteDeprecations maps "METHOD path" keys of deprecated routes to their
+// deprecation, for DeprecationMiddleware. Dates that do not parse are
+// reported as an error, so a typo fails startup and tests.
+func RouteDeprecations(routes []Route) (map[string]apiversion.Deprecation, error) {
+	deprecations := make(map[string]apiversion.Deprecation)
+	for _, route := range routes {
+		if route.Deprecated == "" {
+			if route.Sunset != "" {
+				return nil, fmt.Errorf("%s %s: sunset without a deprecation date", route.Method, route.Path)
+			}
+			continue
+		}
+		var deprecation apiversion.Deprecation
+		var err error
+		if deprecation.Since, err = time.Parse(time.DateOnly, route.Deprecated); err != nil {
+			return nil, fmt.Errorf("%s %s: deprecation date: %w", route.Method, route.Path, err)
+		}
+		if route.Sunset != "" {
+			if deprecation.Sunset, err = time.Parse(time.DateOnly, route.Sunset); err != nil {
+				return nil, fmt.Errorf("%s %s: sunset date: %w", route.Method, route.Path, err)
+			}
+		}
+		deprecations[route.Method+" "+route.Path] = deprecation
+	}
+	return deprecations, nil
+}
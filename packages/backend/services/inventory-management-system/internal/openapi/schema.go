@@ -0,0 +1,127 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaRegistry turns Go model types into component schemas by reflecting on
+// their json tags, so the spec follows the models without manual upkeep
+type schemaRegistry struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{schemas: make(map[string]*Schema)}
+}
+
+// schemaFor returns the schema for v; named structs are registered as
+// components and referenced, everything else is inlined
+func (reg *schemaRegistry) schemaFor(v interface{}) *Schema {
+	if schema, ok := v.(*Schema); ok {
+		return schema
+	}
+	return reg.schemaForType(reflect.TypeOf(v))
+}
+
+func (reg *schemaRegistry) schemaForType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == rawMessageType {
+		// Any JSON value
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: reg.schemaForType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reg.schemaForType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return reg.structSchema(t)
+		}
+		if _, exists := reg.schemas[t.Name()]; !exists {
+			// Reserve the name first so self-referencing types terminate
+			reg.schemas[t.Name()] = &Schema{}
+			*reg.schemas[t.Name()] = *reg.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	return &Schema{}
+}
+
+// structSchema builds an object schema from exported fields and json tags.
+// Fields without omitempty are listed as required.
+func (reg *schemaRegistry) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty, skip := parseJSONTag(field)
+		if skip {
+			continue
+		}
+
+		fieldSchema := reg.schemaForType(field.Type)
+		if field.Type.Kind() == reflect.Ptr && fieldSchema.Ref == "" {
+			fieldSchema.Nullable = true
+		}
+		schema.Properties[name] = fieldSchema
+
+		if !omitEmpty {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	return schema
+}
+
+// parseJSONTag returns the wire name of a field and whether it is optional
+func parseJSONTag(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
@@ -0,0 +1,177 @@
+// Package orders keeps orders and their reservations in a JSON file, so held
+// stock can still be committed or released after a restart.
+package orders
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"inventory-management-api/internal/models"
+)
+
+// maxFinishedOrders bounds how many committed or cancelled orders are kept;
+// reserved orders are never dropped
+const maxFinishedOrders = 10000
+
+// ErrNotFound is returned for an unknown order ID
+var ErrNotFound = errors.New("order not found")
+
+// Store holds orders, oldest first
+type Store struct {
+	path   string
+	mutex  sync.RWMutex
+	orders []models.Order
+	nextID int64
+}
+
+// fileData is the on-disk layout; NextID keeps IDs unique after old orders
+// were dropped
+type fileData struct {
+	NextID int64          `json:"nextId"`
+	Orders []models.Order `json:"orders"`
+}
+
+// NewStore loads the orders saved at path; a missing file means none, and an
+// empty path keeps orders in memory only
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, nextID: 1}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload replaces the orders with the ones saved at the path, for an
+// instance taking over the file from another
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read orders: %w", err)
+	}
+
+	var saved fileData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode orders: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.orders = saved.Orders
+	if saved.NextID > s.nextID {
+		s.nextID = saved.NextID
+	}
+	slog.Info("Orders loaded", "path", s.path, "count", len(s.orders))
+	return nil
+}
+
+// NewID allocates the next order ID, for an order about to be created
+func (s *Store) NewID() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := fmt.Sprintf("ord-%06d", s.nextID)
+	s.nextID++
+	return id
+}
+
+// Create records the order, with an ID from NewID, and saves the file. The
+// stock is already reserved, so the order stays recorded even when the save
+// fails; the error is returned for logging and the next save includes it.
+func (s *Store) Create(order models.Order) (models.Order, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.orders = append(s.orders, order)
+	return order, s.saveLocked()
+}
+
+// Get returns an order by ID
+func (s *Store) Get(id string) (models.Order, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, order := range s.orders {
+		if order.OrderID == id {
+			return order, true
+		}
+	}
+	return models.Order{}, false
+}
+
+// Update changes an order with fn and saves the file. The change is kept when
+// fn succeeds even if the save fails; the error is returned for logging.
+func (s *Store) Update(id string, fn func(*models.Order) error) (models.Order, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.orders {
+		if s.orders[i].OrderID != id {
+			continue
+		}
+		order := s.orders[i]
+		order.Items = append([]models.OrderItem(nil), order.Items...)
+		if err := fn(&order); err != nil {
+			return s.orders[i], err
+		}
+		s.orders[i] = order
+		s.trimLocked()
+		return order, s.saveLocked()
+	}
+	return models.Order{}, ErrNotFound
+}
+
+// trimLocked drops the oldest finished orders past maxFinishedOrders
+func (s *Store) trimLocked() {
+	finished := 0
+	for _, order := range s.orders {
+		if order.Status != models.OrderStatusReserved {
+			finished++
+		}
+	}
+
+	kept := s.orders[:0]
+	for _, order := range s.orders {
+		if order.Status != models.OrderStatusReserved && finished > maxFinishedOrders {
+			finished--
+			continue
+		}
+		kept = append(kept, order)
+	}
+	s.orders = kept
+}
+
+// saveLocked writes the orders atomically (caller holds the write lock)
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(fileData{NextID: s.nextID, Orders: s.orders}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode orders: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create orders directory: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write orders: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store orders: %w", err)
+	}
+	return nil
+}
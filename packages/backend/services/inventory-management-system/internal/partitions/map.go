@@ -0,0 +1,171 @@
+// Package partitions splits a large catalog across several central instances
+// by product ID hash. Each partition owns a contiguous range of the hash space
+// and runs as an ordinary instance with its own data and events files; a
+// router instance maps requests to the partitions owning their products and
+// merges the partitions' event streams into one stream for the stores.
+package partitions
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HashSpace is the number of hash values partitions divide between them
+const HashSpace = 1 << 16
+
+// ErrEmptyMap is returned when PARTITION_MAP lists no partitions
+var ErrEmptyMap = errors.New("partition map is empty")
+
+// Partition is one instance and the hash range it owns
+type Partition struct {
+	ID    string
+	Start uint16 // First hash owned
+	End   uint16 // Last hash owned, inclusive
+	URL   string // Base URL of the instance
+}
+
+// Owns reports whether the partition owns productID
+func (p Partition) Owns(productID string) bool {
+	hash := Hash(productID)
+	return hash >= p.Start && hash <= p.End
+}
+
+// Map assigns every hash to exactly one partition
+type Map struct {
+	partitions []Partition // Sorted by Start
+}
+
+// Hash returns the position of productID in the hash space: the FNV-1a hash
+// of the ID folded into 16 bits. Stores may compute it to find the partition
+// of a product.
+func Hash(productID string) uint16 {
+	h := fnv.New32a()
+	h.Write([]byte(productID))
+	sum := h.Sum32()
+	return uint16(sum>>16) ^ uint16(sum)
+}
+
+// ParseMap parses PARTITION_MAP: comma-separated "id:start-end=url" entries,
+// e.g. "p1:0-32767=http://central-1:8081,p2:32768-65535=http://central-2:8081".
+// The ranges must cover the hash space 0-65535 without gaps or overlaps.
+func ParseMap(value string) (*Map, error) {
+	var partitions []Partition
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		partition, err := parseEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		if seen[partition.ID] {
+			return nil, fmt.Errorf("partition %q is listed twice", partition.ID)
+		}
+		seen[partition.ID] = true
+		partitions = append(partitions, partition)
+	}
+	if len(partitions) == 0 {
+		return nil, ErrEmptyMap
+	}
+
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].Start < partitions[j].Start })
+	next := 0 // First hash not yet owned
+	for _, partition := range partitions {
+		switch {
+		case int(partition.Start) > next:
+			return nil, fmt.Errorf("hashes %d-%d belong to no partition", next, partition.Start-1)
+		case int(partition.Start) < next:
+			return nil, fmt.Errorf("partition %q overlaps the range of another partition", partition.ID)
+		}
+		next = int(partition.End) + 1
+	}
+	if next < HashSpace {
+		return nil, fmt.Errorf("hashes %d-%d belong to no partition", next, HashSpace-1)
+	}
+	return &Map{partitions: partitions}, nil
+}
+
+// parseEntry parses one "id:start-end=url" entry
+func parseEntry(entry string) (Partition, error) {
+	id, rest, ok := strings.Cut(entry, ":")
+	hashRange, rawURL, ok2 := strings.Cut(rest, "=")
+	start, end, ok3 := strings.Cut(hashRange, "-")
+	id = strings.TrimSpace(id)
+	if !ok || !ok2 || !ok3 || id == "" {
+		return Partition{}, fmt.Errorf("invalid partition %q, expected id:start-end=url", entry)
+	}
+
+	first, err := strconv.ParseUint(strings.TrimSpace(start), 10, 16)
+	if err != nil {
+		return Partition{}, fmt.Errorf("invalid range start of partition %q: %w", id, err)
+	}
+	last, err := strconv.ParseUint(strings.TrimSpace(end), 10, 16)
+	if err != nil {
+		return Partition{}, fmt.Errorf("invalid range end of partition %q: %w", id, err)
+	}
+	if first > last {
+		return Partition{}, fmt.Errorf("partition %q range starts after it ends", id)
+	}
+
+	rawURL = strings.TrimRight(strings.TrimSpace(rawURL), "/")
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return Partition{}, fmt.Errorf("invalid URL %q for partition %q", rawURL, id)
+	}
+	return Partition{ID: id, Start: uint16(first), End: uint16(last), URL: rawURL}, nil
+}
+
+// Partitions returns the partitions in hash order
+func (m *Map) Partitions() []Partition {
+	return append([]Partition(nil), m.partitions...)
+}
+
+// Get returns the partition named id
+func (m *Map) Get(id string) (Partition, bool) {
+	for _, partition := range m.partitions {
+		if partition.ID == id {
+			return partition, true
+		}
+	}
+	return Partition{}, false
+}
+
+// For returns the partition owning productID
+func (m *Map) For(productID string) Partition {
+	hash := Hash(productID)
+	i := sort.Search(len(m.partitions), func(i int) bool { return m.partitions[i].End >= hash })
+	return m.partitions[i]
+}
+
+// Group splits productIDs by the partition owning them. Each group keeps the
+// positions of its IDs in productIDs, so results can be merged back in
+// request order. Groups come in hash order.
+func (m *Map) Group(productIDs []string) []Group {
+	byPartition := make(map[string]int) // Index into groups
+	var groups []Group
+	for i, productID := range productIDs {
+		partition := m.For(productID)
+		index, ok := byPartition[partition.ID]
+		if !ok {
+			index = len(groups)
+			byPartition[partition.ID] = index
+			groups = append(groups, Group{Partition: partition})
+		}
+		groups[index].Indexes = append(groups[index].Indexes, i)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Partition.Start < groups[j].Partition.Start })
+	return groups
+}
+
+// Group is the part of a request owned by one partition
+type Group struct {
+	Partition Partition
+	Indexes   []int // Positions in the request, ascending
+}
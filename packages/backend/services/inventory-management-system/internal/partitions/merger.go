@@ -0,0 +1,242 @@
+package partitions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+)
+
+// ConsumerID is the store ID the router polls partitions as, so each
+// partition retains its events until the router has merged them
+const ConsumerID = "partition-router"
+
+// mergePageSize is how many events the router reads from a partition at once
+const mergePageSize = 1000
+
+// MergerConfig configures the merger
+type MergerConfig struct {
+	Map           *Map
+	Queue         *events.EventQueue // The router's queue, which stores poll
+	StateFile     string             // Keeps the partition offsets merged so far
+	APIKey        string             // Sent to the partitions as X-API-Key
+	PollWait      time.Duration      // Long poll wait on each partition
+	RetryInterval time.Duration      // Pause after a failed poll
+}
+
+// Merger follows the event stream of every partition and appends the events
+// to the router's queue, tagged with their partition and partition offset.
+// The partition offsets merged so far form the router's offset vector.
+type Merger struct {
+	config MergerConfig
+	client *http.Client
+	// mu serializes appends with reads of the vector, so a vector read
+	// matches the events in the queue
+	mu      sync.Mutex
+	offsets map[string]int64 // Next offset to read, by partition
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// mergerState is the on-disk layout of the state file
+type mergerState struct {
+	Offsets map[string]int64 `json:"offsets"`
+}
+
+// NewMerger creates a merger that resumes where the last one stopped. The
+// events in the queue are saved with their partition offsets, so they decide;
+// the state file covers partitions whose merged events were all rotated out.
+func NewMerger(config MergerConfig) (*Merger, error) {
+	if config.PollWait <= 0 {
+		config.PollWait = 10 * time.Second
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = time.Second
+	}
+
+	offsets := make(map[string]int64)
+	data, err := os.ReadFile(config.StateFile)
+	switch {
+	case err == nil:
+		var state mergerState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("failed to parse partition offsets: %w", err)
+		}
+		maps.Copy(offsets, state.Offsets)
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read partition offsets: %w", err)
+	}
+	maps.Copy(offsets, config.Queue.MergedPartitionOffsets())
+
+	return &Merger{
+		config:  config,
+		client:  &http.Client{Timeout: config.PollWait + 10*time.Second},
+		offsets: offsets,
+	}, nil
+}
+
+// Start follows every partition in the background
+func (m *Merger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	for _, partition := range m.config.Map.Partitions() {
+		m.wg.Add(1)
+		go m.follow(ctx, partition)
+	}
+	slog.Info("Merging partition event streams", "partitions", len(m.config.Map.Partitions()), "offsets", m.Offsets())
+}
+
+// Stop stops following the partitions, abandoning polls in flight
+func (m *Merger) Stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Offsets returns the next offset to merge from each partition
+func (m *Merger) Offsets() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.offsets)
+}
+
+// OffsetsAt returns the offset vector matching offset nextOffset of the
+// router's queue: for each partition, the offset of its first event the
+// router merged at or after nextOffset, or the next offset to merge when
+// there is none. A store that read the merged stream up to nextOffset has
+// read every partition up to these offsets.
+func (m *Merger) OffsetsAt(nextOffset int64) map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	offsets := maps.Clone(m.offsets)
+	maps.Copy(offsets, m.config.Queue.PartitionOffsetsFrom(nextOffset))
+	return offsets
+}
+
+// follow merges partition's events until ctx is cancelled
+func (m *Merger) follow(ctx context.Context, partition Partition) {
+	defer m.wg.Done()
+	for ctx.Err() == nil {
+		if err := m.poll(ctx, partition); err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to read partition events", "partition", partition.ID, "url", partition.URL, "error", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(m.config.RetryInterval):
+			}
+		}
+	}
+}
+
+// poll reads one page of partition's events and merges it
+func (m *Merger) poll(ctx context.Context, partition Partition) error {
+	m.mu.Lock()
+	offset := m.offsets[partition.ID]
+	m.mu.Unlock()
+
+	url := fmt.Sprintf("%s/%s/inventory/events?offset=%d&limit=%d&wait=%d",
+		partition.URL, apiversion.V1, offset, mergePageSize, int(m.config.PollWait.Seconds()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(middleware.StoreIDHeader, ConsumerID)
+	if m.config.APIKey != "" {
+		req.Header.Set(middleware.APIKeyHeader, m.config.APIKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var page models.EventsResponse
+		if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+			return fmt.Errorf("invalid events response: %w", err)
+		}
+		return m.merge(partition.ID, offset, page)
+	case http.StatusGone:
+		var gone models.OffsetGoneResponse
+		if err := json.NewDecoder(resp.Body).Decode(&gone); err != nil {
+			return fmt.Errorf("invalid offset gone response: %w", err)
+		}
+		return m.restart(partition.ID, offset, gone.SnapshotOffset)
+	default:
+		return fmt.Errorf("partition answered %d", resp.StatusCode)
+	}
+}
+
+// merge appends a page read from offset of partition's stream
+func (m *Merger) merge(partitionID string, offset int64, page models.EventsResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if page.NextOffset <= offset && len(page.Events) == 0 {
+		return nil
+	}
+
+	for _, event := range page.Events {
+		event.Partition = partitionID
+		event.PartitionOffset = event.Offset
+		m.config.Queue.AppendMerged(event)
+	}
+	m.offsets[partitionID] = max(page.NextOffset, offset)
+	slog.Debug("Merged partition events", "partition", partitionID, "events", len(page.Events), "next_offset", page.NextOffset)
+	return m.saveLocked()
+}
+
+// restart resumes a partition whose stream no longer holds offset. The events
+// in between are lost to the merged stream, so a system_restored event sends
+// every store through a full resync, which reads the partitions' snapshots.
+// Its partition offset is set so the event counts as the one before resumeAt.
+func (m *Merger) restart(partitionID string, offset, resumeAt int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	slog.Warn("Partition no longer holds the merged offset, stores will resync",
+		"partition", partitionID,
+		"offset", offset,
+		"resume_offset", resumeAt)
+	event := events.ProductEvent(models.EventTypeSystemRestored, "", models.ProductResponse{}, 0)
+	event.Partition = partitionID
+	event.PartitionOffset = resumeAt - 1
+	m.config.Queue.AppendMerged(event)
+	m.offsets[partitionID] = resumeAt
+	return m.saveLocked()
+}
+
+// saveLocked writes the offsets to the state file. The caller holds mu.
+func (m *Merger) saveLocked() error {
+	data, err := json.Marshal(mergerState{Offsets: m.offsets})
+	if err != nil {
+		return fmt.Errorf("failed to encode partition offsets: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.config.StateFile), 0755); err != nil {
+		return fmt.Errorf("failed to create partition offsets directory: %w", err)
+	}
+
+	tempPath := m.config.StateFile + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write partition offsets: %w", err)
+	}
+	if err := os.Rename(tempPath, m.config.StateFile); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store partition offsets: %w", err)
+	}
+	return nil
+}
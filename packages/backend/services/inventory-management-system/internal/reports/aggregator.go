@@ -0,0 +1,146 @@
+package reports
+
+import (
+	"log/slog"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+)
+
+// ConsumerID is the event consumer the aggregator commits its offset as, so
+// queue rotation keeps events until they are in a report
+const ConsumerID = "sales-reports"
+
+const (
+	pageSize = 1000
+	// idleWait is how long to wait for new events between polls
+	idleWait = 30 * time.Second
+	// gapWait is how long an assigned offset may stay missing from the queue
+	// before it is treated as a dropped event and skipped
+	gapWait      = 5 * time.Second
+	gapRetry     = 100 * time.Millisecond
+	failureRetry = 5 * time.Second
+)
+
+// Aggregator tails the event queue into a Store
+type Aggregator struct {
+	queue   *events.EventQueue
+	store   *Store
+	started atomic.Bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewAggregator creates an aggregator; call Start to run it
+func NewAggregator(queue *events.EventQueue, store *Store) *Aggregator {
+	return &Aggregator{
+		queue: queue,
+		store: store,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Start runs the aggregator in the background
+func (a *Aggregator) Start() {
+	a.started.Store(true)
+	go a.run()
+}
+
+// Stop stops the aggregator and waits for the batch in progress to be saved;
+// an aggregator that was never started just stays stopped
+func (a *Aggregator) Stop() {
+	close(a.stop)
+	if a.started.Load() {
+		<-a.done
+	}
+}
+
+func (a *Aggregator) run() {
+	defer close(a.done)
+
+	next := a.store.NextOffset()
+	var gapSince time.Time
+	for {
+		if current := a.queue.GetCurrentOffset(); next > current {
+			// The queue was reset and numbers events from a lower offset again
+			slog.Warn("Event queue offsets went backwards, rebasing sales reports",
+				"next_offset", next,
+				"current_offset", current)
+			if err := a.store.Rebase(current); err != nil {
+				slog.Error("Failed to rebase sales reports", "error", err)
+			} else {
+				next = current
+			}
+		}
+		if oldest := a.queue.OldestAvailableOffset(); next < oldest {
+			slog.Warn("Sales report events were rotated away before aggregation, reports are incomplete",
+				"next_offset", next,
+				"oldest_available_offset", oldest)
+			next = oldest
+		}
+
+		// Stored order can differ slightly from offset order, since events
+		// are published concurrently
+		page, _, _ := a.queue.GetEvents(next, pageSize)
+		sort.Slice(page, func(i, j int) bool { return page[i].Offset < page[j].Offset })
+
+		batch := contiguous(page, next)
+		wait := idleWait
+		switch {
+		case len(batch) > 0:
+			end := batch[len(batch)-1].Offset + 1
+			if err := a.store.Apply(batch, end); err != nil {
+				slog.Error("Failed to save sales reports", "error", err)
+				wait = failureRetry
+				break
+			}
+			next = end
+			gapSince = time.Time{}
+			if _, err := a.queue.CommitOffset(ConsumerID, next); err != nil {
+				slog.Warn("Failed to commit sales report offset", "offset", next, "error", err)
+			}
+			continue
+		case len(page) > 0:
+			// next is assigned but not stored yet, or was dropped
+			if gapSince.IsZero() {
+				gapSince = time.Now()
+			}
+			if time.Since(gapSince) < gapWait {
+				wait = gapRetry
+				break
+			}
+			slog.Warn("Skipping missing events in sales reports", "from_offset", next, "to_offset", page[0].Offset)
+			next = page[0].Offset
+			gapSince = time.Time{}
+			continue
+		}
+
+		if wait == idleWait {
+			select {
+			case <-a.stop:
+				return
+			case <-a.queue.WaitForEvents(next, idleWait):
+			}
+			continue
+		}
+		select {
+		case <-a.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// contiguous returns the leading events of page that follow from without gaps
+func contiguous(page []models.Event, from int64) []models.Event {
+	for i, event := range page {
+		if event.Offset != from+int64(i) {
+			return page[:i]
+		}
+	}
+	return page
+}
@@ -0,0 +1,285 @@
+// Package reports aggregates the event stream into daily sales reports. A
+// background aggregator tails the event queue and adds every applied negative
+// store delta to the report of its UTC day, keyed by product and store. Each
+// day is saved to its own JSON file in the reports directory.
+package reports
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/models"
+)
+
+// DateLayout is the format of report dates
+const DateLayout = "2006-01-02"
+
+const checkpointFile = "checkpoint.json"
+
+// day is the saved form of one day's report. NextOffset is the offset after
+// the last event applied to it, so events replayed after a crash between
+// saving a day and saving the checkpoint are not counted twice.
+type day struct {
+	Date       string                   `json:"date"`
+	NextOffset int64                    `json:"nextOffset"`
+	Rows       []*models.SalesReportRow `json:"rows"`
+
+	index map[string]*models.SalesReportRow
+}
+
+type checkpoint struct {
+	NextOffset int64 `json:"nextOffset"`
+}
+
+// Store keeps the daily sales reports
+type Store struct {
+	dir        string
+	mutex      sync.RWMutex
+	days       map[string]*day
+	nextOffset int64
+}
+
+// NewStore loads the reports saved in dir, creating it if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create reports directory: %w", err)
+	}
+	s := &Store{dir: dir, days: make(map[string]*day)}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload replaces the reports with the ones saved in the directory, for an
+// instance taking over the directory from another
+func (s *Store) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "sales-*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list sales reports: %w", err)
+	}
+	days := make(map[string]*day, len(paths))
+	for _, path := range paths {
+		var d day
+		if err := readJSON(path, &d); err != nil {
+			return err
+		}
+		d.index = make(map[string]*models.SalesReportRow, len(d.Rows))
+		for _, row := range d.Rows {
+			d.index[rowKey(row.ProductID, row.StoreID)] = row
+		}
+		days[d.Date] = &d
+	}
+
+	var cp checkpoint
+	if err := readJSON(filepath.Join(s.dir, checkpointFile), &cp); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.days = days
+	s.nextOffset = cp.NextOffset
+	slog.Info("Sales reports loaded", "dir", s.dir, "days", len(s.days), "next_offset", s.nextOffset)
+	return nil
+}
+
+// NextOffset returns the offset aggregation resumes from
+func (s *Store) NextOffset() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.nextOffset
+}
+
+// Apply adds the sales in events, which must be in offset order and end
+// before nextOffset, then saves the days they touched and the checkpoint
+func (s *Store) Apply(events []models.Event, nextOffset int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// The state before the batch, restored if saving fails so a retry does
+	// not count the batch twice (nil for days the batch creates)
+	previous := make(map[string]*day)
+	touched := make(map[string]*day)
+	for _, event := range events {
+		units := unitsSold(event)
+		if units == 0 {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, event.Timestamp)
+		if err != nil {
+			slog.Warn("Skipping sale with invalid timestamp", "offset", event.Offset, "timestamp", event.Timestamp)
+			continue
+		}
+
+		date := at.UTC().Format(DateLayout)
+		d, ok := s.days[date]
+		if _, saved := previous[date]; !saved {
+			previous[date] = d.clone()
+		}
+		if !ok {
+			d = &day{Date: date, index: make(map[string]*models.SalesReportRow)}
+			s.days[date] = d
+		}
+		if event.Offset < d.NextOffset {
+			continue
+		}
+
+		key := rowKey(event.ProductID, event.StoreID)
+		row, ok := d.index[key]
+		if !ok {
+			row = &models.SalesReportRow{Date: date, ProductID: event.ProductID, StoreID: event.StoreID}
+			d.index[key] = row
+			d.Rows = append(d.Rows, row)
+		}
+		row.ProductName = event.Data.Name
+		row.Category = event.Data.Category
+		row.UnitsSold += units
+		row.Transactions++
+		touched[date] = d
+	}
+
+	err := s.saveLocked(touched, nextOffset)
+	if err != nil {
+		for date, d := range previous {
+			if d == nil {
+				delete(s.days, date)
+			} else {
+				s.days[date] = d
+			}
+		}
+		return err
+	}
+	s.nextOffset = nextOffset
+	return nil
+}
+
+func (s *Store) saveLocked(touched map[string]*day, nextOffset int64) error {
+	for _, d := range touched {
+		d.NextOffset = nextOffset
+		if err := writeJSON(filepath.Join(s.dir, "sales-"+d.Date+".json"), d); err != nil {
+			return err
+		}
+	}
+	return writeJSON(filepath.Join(s.dir, checkpointFile), checkpoint{NextOffset: nextOffset})
+}
+
+// Rebase restarts aggregation at offset after the event queue was reset. The
+// per-day offsets refer to the old numbering, so they are cleared.
+func (s *Store) Rebase(offset int64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, d := range s.days {
+		if d.NextOffset == 0 {
+			continue
+		}
+		d.NextOffset = 0
+		if err := writeJSON(filepath.Join(s.dir, "sales-"+d.Date+".json"), d); err != nil {
+			return err
+		}
+	}
+	if err := writeJSON(filepath.Join(s.dir, checkpointFile), checkpoint{NextOffset: offset}); err != nil {
+		return err
+	}
+	s.nextOffset = offset
+	return nil
+}
+
+// clone deep-copies the day; a nil day clones to nil
+func (d *day) clone() *day {
+	if d == nil {
+		return nil
+	}
+	copied := &day{Date: d.Date, NextOffset: d.NextOffset, index: make(map[string]*models.SalesReportRow, len(d.Rows))}
+	for _, row := range d.Rows {
+		rowCopy := *row
+		copied.Rows = append(copied.Rows, &rowCopy)
+		copied.index[rowKey(row.ProductID, row.StoreID)] = &rowCopy
+	}
+	return copied
+}
+
+// Report returns the sales of date sorted by product and store
+func (s *Store) Report(date string) models.SalesReport {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	report := models.SalesReport{Date: date, Rows: []models.SalesReportRow{}, ProcessedOffset: s.nextOffset}
+	if d, ok := s.days[date]; ok {
+		for _, row := range d.Rows {
+			report.Rows = append(report.Rows, *row)
+			report.TotalUnits += row.UnitsSold
+			report.TotalTransactions += row.Transactions
+		}
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		if report.Rows[i].ProductID != report.Rows[j].ProductID {
+			return report.Rows[i].ProductID < report.Rows[j].ProductID
+		}
+		return report.Rows[i].StoreID < report.Rows[j].StoreID
+	})
+	return report
+}
+
+func rowKey(productID, storeID string) string {
+	return productID + "\x00" + storeID
+}
+
+func readJSON(path string, target interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// writeJSON replaces the file atomically
+func writeJSON(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// ParseDate validates a report date (YYYY-MM-DD)
+func ParseDate(value string) (string, error) {
+	date, err := time.Parse(DateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("date must be formatted as YYYY-MM-DD")
+	}
+	return date.Format(DateLayout), nil
+}
+
+// unitsSold returns the units an event sold: store decrements other than
+// stocktake corrections, which are shrinkage, and committed orders. Order
+// reservations and cancellations only hold and release stock.
+func unitsSold(event models.Event) int {
+	switch {
+	case event.EventType == models.EventTypeOrderCommitted:
+		return event.Quantity
+	case event.EventType == models.EventTypeProductUpdated && event.Delta < 0 && event.Reason != models.UpdateReasonStocktake:
+		return -event.Delta
+	}
+	return 0
+}
@@ -0,0 +1,276 @@
+// Package runtimeconfig applies changes to the settings that can be tuned
+// without a restart (rate limits, update worker count, event retention) and
+// keeps an audit trail of who changed what.
+package runtimeconfig
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+// maxAuditEntries bounds the in-memory audit trail
+const maxAuditEntries = 100
+
+type group int
+
+const (
+	groupRateLimit group = iota
+	groupWorkers
+	groupEvents
+)
+
+// setting describes one runtime-tunable value
+type setting struct {
+	key      string
+	env      string
+	group    group
+	field    func(cfg *config.Config) *string
+	validate func(value string) error
+}
+
+var settings = []setting{
+	{"rateLimitRequestsPerMinute", "RATE_LIMIT_REQUESTS_PER_MINUTE", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitRequestsPerMinute }, positiveInt},
+	{"rateLimitAdminRequestsPerMinute", "RATE_LIMIT_ADMIN_REQUESTS_PER_MINUTE", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitAdminRequestsPerMinute }, positiveInt},
+	{"rateLimitWindowMinutes", "RATE_LIMIT_WINDOW_MINUTES", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitWindowMinutes }, positiveInt},
+	{"rateLimitBurstSize", "RATE_LIMIT_BURST_SIZE", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitBurstSize }, nonNegativeInt},
+	{"rateLimitTiers", "RATE_LIMIT_TIERS", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitTiers }, tierList},
+	{"rateLimitPrincipalTiers", "RATE_LIMIT_PRINCIPAL_TIERS", groupRateLimit,
+		func(cfg *config.Config) *string { return &cfg.RateLimitPrincipalTiers }, principalTierList},
+	{"inventoryWorkerCount", "INVENTORY_WORKER_COUNT", groupWorkers,
+		func(cfg *config.Config) *string { return &cfg.InventoryWorkerCount }, positiveInt},
+	{"maxEventsInQueue", "MAX_EVENTS_IN_QUEUE", groupEvents,
+		func(cfg *config.Config) *string { return &cfg.MaxEventsInQueue }, positiveInt},
+	{"maxRetainedEvents", "MAX_RETAINED_EVENTS", groupEvents,
+		func(cfg *config.Config) *string { return &cfg.MaxRetainedEvents }, nonNegativeInt},
+}
+
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// ValidationError lists the rejected settings of a change request; nothing
+// was applied
+type ValidationError struct {
+	Details []models.ErrorDetail
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d invalid runtime setting(s)", len(e.Details))
+}
+
+// Manager owns the current values of the runtime-tunable settings
+type Manager struct {
+	mutex            sync.Mutex
+	cfg              config.Config
+	rateLimiter      *middleware.RateLimiter
+	inventoryService *services.InventoryService
+	eventQueue       *events.EventQueue
+	audit            []models.ConfigAuditEntry
+}
+
+// NewManager starts from the loaded configuration. rateLimiter is nil when
+// rate limiting is disabled, in which case its settings are rejected.
+func NewManager(cfg *config.Config, rateLimiter *middleware.RateLimiter, inventoryService *services.InventoryService, eventQueue *events.EventQueue) *Manager {
+	m := &Manager{
+		cfg:              *cfg,
+		rateLimiter:      rateLimiter,
+		inventoryService: inventoryService,
+		eventQueue:       eventQueue,
+	}
+	// Report the worker count actually running, which may differ from an
+	// invalid configured value
+	if inventoryService != nil {
+		m.cfg.InventoryWorkerCount = strconv.Itoa(inventoryService.WorkerCount())
+	}
+	return m
+}
+
+// Settings returns the current value of every runtime-tunable setting
+func (m *Manager) Settings() map[string]string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	values := make(map[string]string, len(settings))
+	for _, s := range settings {
+		values[s.key] = *s.field(&m.cfg)
+	}
+	return values
+}
+
+// Audit returns the recorded changes, oldest first
+func (m *Manager) Audit() []models.ConfigAuditEntry {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]models.ConfigAuditEntry(nil), m.audit...)
+}
+
+// EnvChanges picks the runtime-tunable variables out of an environment map
+// (such as a re-read .env file) and keys them by setting name
+func EnvChanges(env map[string]string) map[string]string {
+	changes := make(map[string]string)
+	for _, s := range settings {
+		if value, ok := env[s.env]; ok {
+			changes[s.key] = value
+		}
+	}
+	return changes
+}
+
+// Apply validates every change first and applies them only if all are valid.
+// Unchanged values are ignored; the audit entries of the applied changes are
+// returned.
+func (m *Manager) Apply(changes map[string]string, actor, source, reason string) ([]models.ConfigAuditEntry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var details []models.ErrorDetail
+	candidate := m.cfg
+	changedGroups := make(map[group]bool)
+	var applied []models.ConfigAuditEntry
+	now := clock.Timestamp()
+
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := strings.TrimSpace(changes[key])
+		s, ok := lookup(key)
+		if !ok {
+			details = append(details, models.ErrorDetail{Field: key, Issue: "is not a runtime-tunable setting"})
+			continue
+		}
+		if s.group == groupRateLimit && m.rateLimiter == nil {
+			details = append(details, models.ErrorDetail{Field: key, Issue: "rate limiting is disabled; enabling it requires a restart"})
+			continue
+		}
+		if err := s.validate(value); err != nil {
+			details = append(details, models.ErrorDetail{Field: key, Issue: err.Error()})
+			continue
+		}
+
+		field := s.field(&candidate)
+		if *field == value {
+			continue
+		}
+		applied = append(applied, models.ConfigAuditEntry{
+			Timestamp: now,
+			Actor:     actor,
+			Source:    source,
+			Key:       key,
+			OldValue:  *field,
+			NewValue:  value,
+			Reason:    reason,
+		})
+		*field = value
+		changedGroups[s.group] = true
+	}
+
+	if len(details) > 0 {
+		return nil, &ValidationError{Details: details}
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	// Resizing is the only step that can fail, so it goes first and nothing
+	// else is touched when it does
+	if changedGroups[groupWorkers] {
+		workerCount, _ := strconv.Atoi(candidate.InventoryWorkerCount)
+		if err := m.inventoryService.ResizeWorkerPool(workerCount); err != nil {
+			return nil, err
+		}
+	}
+	if changedGroups[groupRateLimit] {
+		m.rateLimiter.UpdateLimits(middleware.ParseRateLimitConfig(&candidate))
+	}
+	if changedGroups[groupEvents] {
+		maxEvents, _ := strconv.Atoi(candidate.MaxEventsInQueue)
+		maxRetained, _ := strconv.Atoi(candidate.MaxRetainedEvents)
+		m.eventQueue.SetRetention(maxEvents, maxRetained)
+	}
+
+	m.cfg = candidate
+	for _, entry := range applied {
+		slog.Info("Runtime configuration changed",
+			"actor", entry.Actor,
+			"source", entry.Source,
+			"key", entry.Key,
+			"old_value", entry.OldValue,
+			"new_value", entry.NewValue,
+			"reason", entry.Reason)
+	}
+	m.audit = append(m.audit, applied...)
+	if len(m.audit) > maxAuditEntries {
+		m.audit = append([]models.ConfigAuditEntry(nil), m.audit[len(m.audit)-maxAuditEntries:]...)
+	}
+
+	return applied, nil
+}
+
+func positiveInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func nonNegativeInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("must be a non-negative integer")
+	}
+	return nil
+}
+
+// tierList accepts "name:limit,name:limit" with positive limits, or empty
+func tierList(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		name, limit, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || strings.TrimSpace(name) == "" || positiveInt(strings.TrimSpace(limit)) != nil {
+			return fmt.Errorf("must be a comma-separated list of name:limit with positive limits")
+		}
+	}
+	return nil
+}
+
+// principalTierList accepts "principal:tier,principal:tier", or empty
+func principalTierList(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, part := range strings.Split(value, ",") {
+		principal, tier, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || strings.TrimSpace(principal) == "" || strings.TrimSpace(tier) == "" {
+			return fmt.Errorf("must be a comma-separated list of principal:tier")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// allocatedUnits returns the units allocated to all stores together
+func allocatedUnits(allocations map[string]int) int {
+	total := 0
+	for _, units := range allocations {
+		total += units
+	}
+	return total
+}
+
+// allocationsViolation returns why allocations cannot be set on a product
+// holding available units, or "" when they fit in its stock
+func allocationsViolation(available int, allocations map[string]int) string {
+	if total := allocatedUnits(allocations); total > max(available, 0) {
+		return fmt.Sprintf("allocations of %d units exceed the available stock of %d", total, available)
+	}
+	return ""
+}
+
+// ownAllocations copies allocations set by an admin, dropping stores given
+// none; an empty map clears them
+func ownAllocations(allocations map[string]int) map[string]int {
+	owned := make(map[string]int, len(allocations))
+	for storeID, units := range allocations {
+		if units > 0 {
+			owned[storeID] = units
+		}
+	}
+	if len(owned) == 0 {
+		return nil
+	}
+	return owned
+}
+
+// moveAllocation moves units from one store's allocation to another's. The
+// map is replaced, never modified, as product copies share it.
+func moveAllocation(product *ProductData, fromStoreID, toStoreID string, units int) {
+	allocations := maps.Clone(product.Allocations)
+	allocations[fromStoreID] -= units
+	if allocations[fromStoreID] == 0 {
+		delete(allocations, fromStoreID)
+	}
+	allocations[toStoreID] += units
+	product.Allocations = allocations
+}
+
+// drawAllocation takes the units a store sold out of its own allocation, as
+// far as it has any
+func drawAllocation(product *ProductData, storeID string, units int) {
+	allocated := product.Allocations[storeID]
+	if storeID == "" || allocated == 0 {
+		return
+	}
+	allocations := maps.Clone(product.Allocations)
+	if allocated <= units {
+		delete(allocations, storeID)
+	} else {
+		allocations[storeID] = allocated - units
+	}
+	product.Allocations = allocations
+}
+
+// trimAllocations shrinks the allocations once the stock no longer covers
+// them, e.g. after sales beyond a store's allocation or an admin correction,
+// taking from the largest allocations first. Reports whether any changed.
+func trimAllocations(product *ProductData) bool {
+	excess := allocatedUnits(product.Allocations) - max(product.Available, 0)
+	if excess <= 0 {
+		return false
+	}
+
+	storeIDs := make([]string, 0, len(product.Allocations))
+	for storeID := range product.Allocations {
+		storeIDs = append(storeIDs, storeID)
+	}
+	sort.Slice(storeIDs, func(i, j int) bool {
+		a, b := product.Allocations[storeIDs[i]], product.Allocations[storeIDs[j]]
+		return a > b || (a == b && storeIDs[i] < storeIDs[j])
+	})
+
+	allocations := maps.Clone(product.Allocations)
+	for _, storeID := range storeIDs {
+		if excess == 0 {
+			break
+		}
+		taken := min(excess, allocations[storeID])
+		excess -= taken
+		if allocations[storeID] -= taken; allocations[storeID] == 0 {
+			delete(allocations, storeID)
+		}
+	}
+	product.Allocations = allocations
+	return true
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// ErrAssetNotFound is returned when removing an asset URL the product does not have
+var ErrAssetNotFound = errors.New("asset not found")
+
+// ErrTooManyAssets is returned when adding an asset to a product that already
+// has MaxProductAssets
+var ErrTooManyAssets = errors.New("product has too many assets")
+
+// MaxProductAssets is the most assets a product keeps, matching the max rule
+// on ProductAssetsRequest
+const MaxProductAssets = 20
+
+// ProductAssets returns a product's assets and its current version
+func (s *InventoryService) ProductAssets(productID string) (*models.ProductAssetsResponse, error) {
+	product, exists := s.readProduct(productID)
+	if !exists {
+		return nil, ErrProductNotFound
+	}
+	return assetsResponse(product.ProductID, product.Version, product.LastUpdated, product.Assets), nil
+}
+
+// SetProductAssets replaces a product's assets; an empty list clears them
+func (s *InventoryService) SetProductAssets(productID string, assets []models.ProductAsset) (*models.ProductAssetsResponse, error) {
+	return s.changeProductAssets(productID, func([]models.ProductAsset) ([]models.ProductAsset, error) {
+		return assets, nil
+	})
+}
+
+// PutProductAsset adds an asset, replacing the one with the same URL if any
+func (s *InventoryService) PutProductAsset(productID string, asset models.ProductAsset) (*models.ProductAssetsResponse, error) {
+	return s.changeProductAssets(productID, func(current []models.ProductAsset) ([]models.ProductAsset, error) {
+		assets := make([]models.ProductAsset, 0, len(current)+1)
+		replaced := false
+		for _, existing := range current {
+			if existing.URL == asset.URL {
+				existing = asset
+				replaced = true
+			}
+			assets = append(assets, existing)
+		}
+		if !replaced {
+			if len(assets) >= MaxProductAssets {
+				return nil, ErrTooManyAssets
+			}
+			assets = append(assets, asset)
+		}
+		return assets, nil
+	})
+}
+
+// RemoveProductAsset removes the asset with the given URL
+func (s *InventoryService) RemoveProductAsset(productID, url string) (*models.ProductAssetsResponse, error) {
+	return s.changeProductAssets(productID, func(current []models.ProductAsset) ([]models.ProductAsset, error) {
+		assets := make([]models.ProductAsset, 0, len(current))
+		for _, existing := range current {
+			if existing.URL != url {
+				assets = append(assets, existing)
+			}
+		}
+		if len(assets) == len(current) {
+			return nil, ErrAssetNotFound
+		}
+		return assets, nil
+	})
+}
+
+// changeProductAssets applies change to a product's assets under its write
+// lock and publishes the result as a product_updated event, so stores pick
+// the assets up through replication
+func (s *InventoryService) changeProductAssets(productID string, change func([]models.ProductAsset) ([]models.ProductAsset, error)) (*models.ProductAssetsResponse, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	var response *models.ProductAssetsResponse
+	s.productLockManager.WithProductWriteLock(productID, func() {
+		product, exists := s.lookupProduct(productID)
+		if !exists {
+			err = ErrProductNotFound
+			return
+		}
+
+		var assets []models.ProductAsset
+		if assets, err = change(product.Assets); err != nil {
+			return
+		}
+
+		product.Assets = assets
+		product.Version++
+		product.LastUpdated = clock.Timestamp()
+		s.commitProductEvent(models.EventTypeProductUpdated, product)
+		response = assetsResponse(product.ProductID, product.Version, product.LastUpdated, product.Assets)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if saveErr := s.persister.FlushNow(); saveErr != nil {
+		// The in-memory state is still consistent, as for admin set
+		slog.Error("Failed to persist inventory data after asset change",
+			"error", saveErr,
+			"product_id", productID)
+	}
+
+	slog.Debug("Product assets updated",
+		"product_id", productID,
+		"new_version", response.Version,
+		"assets", len(response.Assets))
+	return response, nil
+}
+
+// assetsResponse builds a ProductAssetsResponse, with an empty rather than
+// null asset list
+func assetsResponse(productID string, version int, lastUpdated string, assets []models.ProductAsset) *models.ProductAssetsResponse {
+	return &models.ProductAssetsResponse{
+		ProductID:   productID,
+		Version:     version,
+		LastUpdated: lastUpdated,
+		Assets:      append([]models.ProductAsset{}, assets...),
+	}
+}
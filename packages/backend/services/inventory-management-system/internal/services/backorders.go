@@ -0,0 +1,135 @@
+package services
+
+import (
+	"log/slog"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+)
+
+// ProductBackorders returns a product's backorder queue, oldest first
+func (s *InventoryService) ProductBackorders(productID string) (*models.BackordersResponse, error) {
+	var response *models.BackordersResponse
+	s.productLockManager.WithProductReadLock(productID, func() {
+		product, exists := s.lookupProduct(productID)
+		if !exists {
+			return
+		}
+		response = &models.BackordersResponse{
+			ProductID:  product.ProductID,
+			Available:  product.Available,
+			Version:    product.Version,
+			Units:      backorderedUnits(product.Backorders),
+			Backorders: append([]models.Backorder{}, product.Backorders...),
+		}
+	})
+	if response == nil {
+		return nil, ErrProductNotFound
+	}
+	return response, nil
+}
+
+// backorderShortfall returns how many units of a sale taking available from
+// before to after went beyond the stock
+func backorderShortfall(before, after int) int {
+	return max(0, -after) - max(0, -before)
+}
+
+// queueBackorder appends the shortfall of a store's sale to the product's
+// queue; product already holds the sale's version and timestamp
+func queueBackorder(product *ProductData, req *UpdateRequest, shortfall int) {
+	product.Backorders = append(append([]models.Backorder(nil), product.Backorders...), models.Backorder{
+		StoreID:        req.StoreID,
+		IdempotencyKey: req.IdempotencyKey,
+		Version:        product.Version,
+		Units:          shortfall,
+		Remaining:      shortfall,
+		CreatedAt:      product.LastUpdated,
+	})
+
+	slog.Info("Backorder queued",
+		"product_id", product.ProductID,
+		"store_id", req.StoreID,
+		"units", shortfall,
+		"available", product.Available,
+		"queued_units", backorderedUnits(product.Backorders))
+}
+
+// fulfillBackorders fills queued backorders, oldest first, with the units a
+// restock brought: the queue keeps only the units the stock is below zero.
+// It returns a backorder_fulfilled event per backorder filled in part or in
+// whole; product is about to be committed at its current version.
+func fulfillBackorders(product *ProductData) []models.Event {
+	filled := backorderedUnits(product.Backorders) - max(0, -product.Available)
+	if filled <= 0 {
+		return nil
+	}
+
+	// The stored queue is shared with readers, so the filled one is a copy
+	queue := make([]models.Backorder, 0, len(product.Backorders))
+	var fulfilled []models.Event
+	for _, backorder := range product.Backorders {
+		if filled > 0 {
+			units := min(filled, backorder.Remaining)
+			filled -= units
+			backorder.Remaining -= units
+			fulfilled = append(fulfilled, events.BackorderFulfilledEvent(product.ProductID, toProductResponse(*product),
+				product.Version, backorder, units))
+
+			slog.Info("Backorder fulfilled",
+				"product_id", product.ProductID,
+				"store_id", backorder.StoreID,
+				"backorder_version", backorder.Version,
+				"units", units,
+				"remaining", backorder.Remaining)
+		}
+		if backorder.Remaining > 0 {
+			queue = append(queue, backorder)
+		}
+	}
+	if len(queue) == 0 {
+		queue = nil
+	}
+	product.Backorders = queue
+	return fulfilled
+}
+
+// backorderedUnits sums the units still waiting in a backorder queue
+func backorderedUnits(backorders []models.Backorder) int {
+	units := 0
+	for _, backorder := range backorders {
+		units += backorder.Remaining
+	}
+	return units
+}
+
+// applyBackorderEvent returns backorders with the backorder an event queued or
+// filled, when it carries one; for rolling the queue forward from events.
+// Backorders are matched by the version of their sale, so applying an event
+// the queue already reflects changes nothing.
+func applyBackorderEvent(backorders []models.Backorder, event models.Event) []models.Backorder {
+	if event.Backorder == nil {
+		return backorders
+	}
+
+	applied := make([]models.Backorder, 0, len(backorders)+1)
+	found := false
+	for _, backorder := range backorders {
+		if backorder.Version == event.Backorder.Version {
+			found = true
+			if event.EventType == models.EventTypeBackorderFulfilled {
+				backorder = *event.Backorder
+			}
+		}
+		if backorder.Remaining > 0 {
+			applied = append(applied, backorder)
+		}
+	}
+	if !found && event.EventType == models.EventTypeProductUpdated {
+		applied = append(applied, *event.Backorder)
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	return applied
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"inventory-management-api/internal/models"
+)
+
+// trimBarcodes trims every code and drops blank ones. A non-nil list stays
+// non-nil so an empty update still clears the aliases.
+func trimBarcodes(codes []string) []string {
+	if codes == nil {
+		return nil
+	}
+	trimmed := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code = strings.TrimSpace(code); code != "" {
+			trimmed = append(trimmed, code)
+		}
+	}
+	return trimmed
+}
+
+// productCodes returns every code a product is scanned by
+func productCodes(product ProductData) []string {
+	codes := make([]string, 0, len(product.BarcodeAliases)+1)
+	if product.Barcode != "" {
+		codes = append(codes, product.Barcode)
+	}
+	return append(codes, product.BarcodeAliases...)
+}
+
+// barcodeOwner returns a product other than product.ProductID that is already
+// scanned by one of product's codes, so a scan never resolves to two products
+func (s *InventoryService) barcodeOwner(product ProductData) (code, ownerID string, found bool) {
+	codes := productCodes(product)
+	if len(codes) == 0 {
+		return "", "", false
+	}
+
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	for _, other := range s.data.Products {
+		if other.ProductID == product.ProductID {
+			continue
+		}
+		for _, otherCode := range productCodes(other) {
+			for _, code := range codes {
+				if code == otherCode {
+					return code, other.ProductID, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+// barcodeConflictResult rejects an admin create or set whose barcode is taken
+func barcodeConflictResult(productID, code, ownerID string) models.AdminProductResult {
+	return models.AdminProductResult{
+		ProductID:    productID,
+		Success:      false,
+		ErrorType:    ErrTypeBarcodeConflict,
+		ErrorMessage: fmt.Sprintf("Barcode %s already belongs to product %s", code, ownerID),
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// UncategorizedCategory labels stock of products without a category
+const UncategorizedCategory = "uncategorized"
+
+// StockLevels summarizes current inventory for dashboards
+type StockLevels struct {
+	TotalProducts      int
+	TotalUnits         int64
+	OutOfStockProducts int
+	UnitsByCategory    map[string]int64
+}
+
+// StockLevels computes unit totals from a consistent snapshot of all products
+func (s *InventoryService) StockLevels() StockLevels {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	levels := StockLevels{
+		TotalProducts:   len(s.data.Products),
+		UnitsByCategory: make(map[string]int64),
+	}
+	for _, productData := range s.data.Products {
+		category := productData.Category
+		if category == "" {
+			category = UncategorizedCategory
+		}
+
+		levels.TotalUnits += int64(productData.Available)
+		levels.UnitsByCategory[category] += int64(productData.Available)
+		if productData.Available == 0 {
+			levels.OutOfStockProducts++
+		}
+	}
+	return levels
+}
+
+// RegisterBusinessMetrics exposes stock levels as observable gauges and starts
+// counting units sold. Gauges are computed once per collection, not per update.
+func (s *InventoryService) RegisterBusinessMetrics() error {
+	meter := otel.Meter("inventory-management-api")
+
+	unitsGauge, err := meter.Int64ObservableGauge(
+		"inventory_stock_units",
+		metric.WithDescription("Total units available across all products"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create stock units gauge: %w", err)
+	}
+
+	categoryGauge, err := meter.Int64ObservableGauge(
+		"inventory_stock_units_by_category",
+		metric.WithDescription("Units available per product category"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create category stock gauge: %w", err)
+	}
+
+	outOfStockGauge, err := meter.Int64ObservableGauge(
+		"inventory_products_out_of_stock",
+		metric.WithDescription("Number of products with zero units available"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create out of stock gauge: %w", err)
+	}
+
+	unitsSoldCounter, err := meter.Int64Counter(
+		"inventory_units_sold_total",
+		metric.WithDescription("Units sold through applied negative inventory updates"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create units sold counter: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		levels := s.StockLevels()
+		observer.ObserveInt64(unitsGauge, levels.TotalUnits)
+		observer.ObserveInt64(outOfStockGauge, int64(levels.OutOfStockProducts))
+		for category, units := range levels.UnitsByCategory {
+			observer.ObserveInt64(categoryGauge, units, metric.WithAttributes(attribute.String("category", category)))
+		}
+		return nil
+	}, unitsGauge, categoryGauge, outOfStockGauge)
+	if err != nil {
+		return fmt.Errorf("failed to register stock level callback: %w", err)
+	}
+
+	if err := s.registerReadCacheMetrics(meter); err != nil {
+		return err
+	}
+	if err := s.registerNotFoundCacheMetrics(meter); err != nil {
+		return err
+	}
+	if err := s.registerLockMetrics(meter); err != nil {
+		return err
+	}
+
+	s.globalMutex.Lock()
+	s.unitsSoldCounter = unitsSoldCounter
+	s.globalMutex.Unlock()
+
+	slog.Info("Inventory business metrics registered")
+	return nil
+}
+
+// recordUnitsSold counts the units removed by an applied update
+func (s *InventoryService) recordUnitsSold(req *UpdateRequest) {
+	if req.Delta >= 0 {
+		return
+	}
+
+	s.globalMutex.RLock()
+	counter := s.unitsSoldCounter
+	s.globalMutex.RUnlock()
+	if counter == nil {
+		return
+	}
+
+	counter.Add(req.context(), int64(-req.Delta), metric.WithAttributes(attribute.String("store_id", req.StoreID)))
+}
+
+// registerLockMetrics exposes product lock contention, so stuck or hot
+// products show up before updates time out
+func (s *InventoryService) registerLockMetrics(meter metric.Meter) error {
+	held, err := meter.Int64ObservableGauge(
+		"inventory_product_locks_held",
+		metric.WithDescription("Product locks held for reading or writing"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create held locks gauge: %w", err)
+	}
+
+	waiters, err := meter.Int64ObservableGauge(
+		"inventory_product_lock_waiters",
+		metric.WithDescription("Goroutines blocked acquiring a product lock"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create lock waiters gauge: %w", err)
+	}
+
+	longestHold, err := meter.Int64ObservableGauge(
+		"inventory_product_lock_longest_hold",
+		metric.WithDescription("How long the longest current product lock hold has lasted"),
+		metric.WithUnit("ms"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create longest lock hold gauge: %w", err)
+	}
+
+	stalls, err := meter.Int64ObservableCounter(
+		"inventory_product_lock_stalls_total",
+		metric.WithDescription("Product lock holds the watchdog logged as past its threshold"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create lock stalls counter: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		stats := s.GetLockStats()
+		observer.ObserveInt64(held, int64(stats.Held))
+		observer.ObserveInt64(waiters, int64(stats.Waiters))
+		observer.ObserveInt64(longestHold, stats.LongestHoldMs)
+		observer.ObserveInt64(stalls, stats.Stalls)
+		return nil
+	}, held, waiters, longestHold, stalls)
+	if err != nil {
+		return fmt.Errorf("failed to register lock metrics callback: %w", err)
+	}
+	return nil
+}
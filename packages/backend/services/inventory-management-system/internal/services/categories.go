@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+)
+
+var (
+	// ErrCategoryNotFound is returned for an unknown category ID
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrInvalidCategoryID is returned for category IDs that are not lowercase slugs
+	ErrInvalidCategoryID = errors.New("category ID must be 1-64 lowercase letters, digits, '-' or '_'")
+	// ErrUnknownParentCategory is returned when a category's parent does not exist
+	ErrUnknownParentCategory = errors.New("parent category not found")
+	// ErrCategoryCycle is returned when a category would become its own ancestor
+	ErrCategoryCycle = errors.New("category cannot be moved under itself or its subcategories")
+)
+
+// CategoryInUseError is returned when deleting a category that still has
+// subcategories or products
+type CategoryInUseError struct {
+	CategoryID    string
+	Subcategories int
+	Products      int
+}
+
+func (e *CategoryInUseError) Error() string {
+	return fmt.Sprintf("category %s still has %d subcategories and %d products", e.CategoryID, e.Subcategories, e.Products)
+}
+
+var validCategoryID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// normalizeCategory matches category references case-insensitively, as event
+// filters and stocktakes do
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// ListCategories returns every category sorted by ID
+func (s *InventoryService) ListCategories() []models.Category {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	categories := make([]models.Category, 0, len(s.data.Categories))
+	for _, category := range s.data.Categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories
+}
+
+// PutCategory creates the category id or renames and moves it, and publishes
+// a category_updated event. Moving a category takes its subcategories along.
+func (s *InventoryService) PutCategory(id string, req models.CategoryRequest) (models.Category, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return models.Category{}, err
+	}
+	defer release()
+
+	if !validCategoryID.MatchString(id) {
+		return models.Category{}, ErrInvalidCategoryID
+	}
+	category := models.Category{
+		ID:          id,
+		Name:        strings.TrimSpace(req.Name),
+		ParentID:    normalizeCategory(req.ParentID),
+		StockPolicy: ownStockPolicy(req.StockPolicy),
+		UpdatedAt:   clock.Timestamp(),
+	}
+
+	s.categoryMutex.Lock()
+	defer s.categoryMutex.Unlock()
+
+	s.globalMutex.RLock()
+	err = s.checkParentLocked(category)
+	s.globalMutex.RUnlock()
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	s.commitChange(category.UpdatedAt, events.CategoryEvent(models.EventTypeCategoryUpdated, category), func() {
+		s.storeCategoryLocked(category)
+	})
+	s.persistCategoryChange(id)
+
+	slog.Info("Category saved", "category_id", id, "parent_id", category.ParentID, "name", category.Name)
+	return category, nil
+}
+
+// DeleteCategory removes a category without subcategories or products and
+// publishes a category_deleted event
+func (s *InventoryService) DeleteCategory(id string) error {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	s.categoryMutex.Lock()
+	defer s.categoryMutex.Unlock()
+
+	s.globalMutex.RLock()
+	category, exists := s.data.Categories[id]
+	inUse := &CategoryInUseError{CategoryID: id}
+	if exists {
+		for _, other := range s.data.Categories {
+			if other.ParentID == id {
+				inUse.Subcategories++
+			}
+		}
+		for _, product := range s.data.Products {
+			if normalizeCategory(product.Category) == id {
+				inUse.Products++
+			}
+		}
+	}
+	s.globalMutex.RUnlock()
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+	if inUse.Subcategories > 0 || inUse.Products > 0 {
+		return inUse
+	}
+
+	category.UpdatedAt = clock.Timestamp()
+	s.commitChange(category.UpdatedAt, events.CategoryEvent(models.EventTypeCategoryDeleted, category), func() {
+		delete(s.data.Categories, id)
+	})
+	s.persistCategoryChange(id)
+
+	slog.Info("Category deleted", "category_id", id)
+	return nil
+}
+
+// CategoriesWithDescendants expands categories to include every subcategory,
+// so filtering by a category covers its whole subtree. Categories outside the
+// tree are kept as given, for products with free-form categories.
+func (s *InventoryService) CategoriesWithDescendants(categories []string) []string {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	children := make(map[string][]string, len(s.data.Categories))
+	for _, category := range s.data.Categories {
+		if category.ParentID != "" {
+			children[category.ParentID] = append(children[category.ParentID], category.ID)
+		}
+	}
+
+	seen := make(map[string]bool, len(categories))
+	expanded := make([]string, 0, len(categories))
+	pending := make([]string, 0, len(categories))
+	for _, category := range categories {
+		if category = normalizeCategory(category); category != "" {
+			pending = append(pending, category)
+		}
+	}
+	for len(pending) > 0 {
+		category := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if seen[category] {
+			continue
+		}
+		seen[category] = true
+		expanded = append(expanded, category)
+		pending = append(pending, children[category]...)
+	}
+	sort.Strings(expanded)
+	return expanded
+}
+
+// resolveCategory returns the category a product is assigned to. While no
+// category is defined, categories stay free-form as before the tree existed;
+// once one is, a product can only be put in a category of the tree. The
+// caller holds categoryMutex for reading until the product is committed.
+func (s *InventoryService) resolveCategory(category string) (string, bool) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		return "", true
+	}
+
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+	if len(s.data.Categories) == 0 {
+		return category, true
+	}
+	id := normalizeCategory(category)
+	_, exists := s.data.Categories[id]
+	return id, exists
+}
+
+// unknownCategoryResult rejects an admin create or set naming a category
+// outside the tree
+func unknownCategoryResult(productID, category string) models.AdminProductResult {
+	return models.AdminProductResult{
+		ProductID:    productID,
+		Success:      false,
+		ErrorType:    ErrTypeUnknownCategory,
+		ErrorMessage: fmt.Sprintf("Category %s does not exist", category),
+	}
+}
+
+// checkParentLocked verifies category's parent exists and is not category or
+// one of its subcategories (caller holds the global lock for reading)
+func (s *InventoryService) checkParentLocked(category models.Category) error {
+	for parentID := category.ParentID; parentID != ""; {
+		if parentID == category.ID {
+			return ErrCategoryCycle
+		}
+		parent, exists := s.data.Categories[parentID]
+		if !exists {
+			if parentID == category.ParentID {
+				return ErrUnknownParentCategory
+			}
+			return nil
+		}
+		parentID = parent.ParentID
+	}
+	return nil
+}
+
+// storeCategoryLocked saves a category (caller holds the global write lock)
+func (s *InventoryService) storeCategoryLocked(category models.Category) {
+	if s.data.Categories == nil {
+		s.data.Categories = make(map[string]models.Category)
+	}
+	s.data.Categories[category.ID] = category
+}
+
+// persistCategoryChange saves the data file after a category change; as for
+// admin product changes, a failed save leaves the in-memory state in place
+func (s *InventoryService) persistCategoryChange(id string) {
+	if err := s.persister.FlushNow(); err != nil {
+		slog.Error("Failed to persist inventory data after category change",
+			"error", err,
+			"category_id", id)
+	}
+}
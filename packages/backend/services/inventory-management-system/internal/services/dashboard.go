@@ -0,0 +1,88 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/models"
+)
+
+// updateRateMinutes is how many one-minute buckets the update rate keeps
+const updateRateMinutes = 60
+
+// updateRate counts applied inventory updates per minute over the last hour.
+// The zero value is ready to use.
+type updateRate struct {
+	mu      sync.Mutex
+	buckets [updateRateMinutes]updateRateBucket
+}
+
+type updateRateBucket struct {
+	minute int64 // Unix minute the count belongs to
+	count  int64
+}
+
+// record counts one applied update at now
+func (r *updateRate) record(now time.Time) {
+	minute := now.Unix() / 60
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	bucket := &r.buckets[minute%updateRateMinutes]
+	if bucket.minute != minute {
+		bucket.minute = minute
+		bucket.count = 0
+	}
+	bucket.count++
+}
+
+// total returns the updates applied in the hour before now
+func (r *updateRate) total(now time.Time) int64 {
+	minute := now.Unix() / 60
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total int64
+	for _, bucket := range r.buckets {
+		if minute-bucket.minute < updateRateMinutes {
+			total += bucket.count
+		}
+	}
+	return total
+}
+
+// UpdatesLastHour returns how many inventory updates were applied in the last hour
+func (s *InventoryService) UpdatesLastHour() int64 {
+	return s.updateRate.total(time.Now())
+}
+
+// LowStockProducts returns up to limit products that are still in stock, fewest
+// units first. Out-of-stock products are counted by StockLevels instead.
+func (s *InventoryService) LowStockProducts(limit int) []models.LowStockProduct {
+	s.globalMutex.RLock()
+	products := make([]models.LowStockProduct, 0, len(s.data.Products))
+	for _, productData := range s.data.Products {
+		if productData.Available <= 0 {
+			continue
+		}
+		products = append(products, models.LowStockProduct{
+			ProductID: productData.ProductID,
+			Name:      productData.Name,
+			Available: productData.Available,
+			Category:  productData.Category,
+		})
+	}
+	s.globalMutex.RUnlock()
+
+	sort.Slice(products, func(i, j int) bool {
+		if products[i].Available != products[j].Available {
+			return products[i].Available < products[j].Available
+		}
+		return products[i].ProductID < products[j].ProductID
+	})
+	if len(products) > limit {
+		products = products[:limit]
+	}
+	return products
+}
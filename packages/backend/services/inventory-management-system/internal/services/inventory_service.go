@@ -1,35 +1,80 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"inventory-management-api/internal/cache"
+	"inventory-management-api/internal/clock"
 	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/currency"
 	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/idempotency"
 	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/orders"
+	"inventory-management-api/internal/stocktakes"
+	"inventory-management-api/internal/telemetry"
+	"inventory-management-api/internal/transfers"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // InventoryService handles inventory business logic
 type InventoryService struct {
 	data                  *InventoryData
-	globalMutex           sync.RWMutex // Only for global operations like file saves
+	globalMutex           sync.RWMutex  // Only for global operations like file saves
+	updatedIndex          *updatedIndex // Products by lastUpdated, guarded by globalMutex
 	productLockManager    *ProductLockManager
-	updateQueue           chan *UpdateRequest
+	stopLockWatchdog      func()                // Nil when the watchdog is off
+	updateShards          []chan *UpdateRequest // One queue per worker, keyed by product ID; guarded by drainMutex
 	idempotencyCache      *cache.TTLCache
 	dataFilePath          string
 	enableJSONPersistence bool
 	workerCount           int
+	workersDone           <-chan struct{} // Closed once the current worker generation exits
 	queueBufferSize       int
+	queueHighWaterMark    int
+	laneQuotas            [priorityCount]int // Percent of the high-water mark each priority may fill
+	laneCounters          laneCounters
+	updateTimeout         time.Duration // Result wait of updates submitted without a deadline
 	stopWorkers           chan bool
 	workersWaitGroup      sync.WaitGroup
+	drainMutex            sync.RWMutex // Guards draining against queue submissions
+	draining              bool
+	restoring             bool         // Set while Restore replaces the inventory; guarded by drainMutex
+	restoreMutex          sync.RWMutex // Admin writes hold it for reading so Restore can wait them out
 	eventQueue            *events.EventQueue
+	persister             *writeBehindPersister
+	ratesProvider         currency.RatesProvider
+	featureFlags          *featureflags.Flags // Nil means every flag at its default
+	unitsSoldCounter      metric.Int64Counter // Nil until RegisterBusinessMetrics
+	readCache             *productReadCache   // Nil unless READ_CACHE_ENABLED
+	notFoundCache         *notFoundCache      // Nil when NOT_FOUND_CACHE_TTL is 0
+	transferLog           *transfers.Store    // In memory until SetTransferLog
+	stocktakes            *stocktakes.Store   // In memory until SetStocktakes
+	stocktakeMutex        sync.Mutex          // Serializes count, close and cancel
+	orders                *orders.Store       // In memory until SetOrders
+	orderMutex            sync.Mutex          // Serializes order commits and cancellations
+	outboxMutex           sync.Mutex          // Keeps outbox entries and queue appends in the same order
+	categoryMutex         sync.RWMutex        // Category changes hold it; product category assignments read it
+	updateRate            updateRate          // Applied updates per minute, for the admin dashboard
+	standby               atomic.Bool         // Set on a follower, which only reads the data file the leader writes
+	standbyMutex          sync.Mutex          // Serializes standby reloads and promotion
+	dataFileModTime       time.Time           // Data file modification time and size at the last standby reload
+	dataFileSize          int64
 }
 
 // UpdateRequest represents an internal update request for queue processing
@@ -39,7 +84,64 @@ type UpdateRequest struct {
 	Version        int
 	IdempotencyKey string
 	StoreID        string
+	TenantID       string // Store whose feature flags apply: the one the caller is authenticated as
+	Reason         string // Recorded on the published event
+	Condition      UpdateCondition
+	Priority       UpdatePriority
 	ResponseChan   chan *UpdateResult
+	Ctx            context.Context // Caller's trace context, carried across the queue
+	EnqueuedAt     time.Time
+}
+
+// dedupeKey is the idempotency cache key, scoped to the store that sent the update
+func (r *UpdateRequest) dedupeKey() string {
+	return idempotency.Scoped(r.StoreID, r.IdempotencyKey)
+}
+
+type reasonKey struct{}
+
+// WithUpdateReason returns a context whose updates record reason on their events
+func WithUpdateReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+// UpdateReasonFromContext returns the update reason set on ctx, if any
+func UpdateReasonFromContext(ctx context.Context) string {
+	reason, _ := ctx.Value(reasonKey{}).(string)
+	return reason
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a context whose updates are checked against the feature
+// flags of storeID, the store the caller is authenticated as
+func WithTenant(ctx context.Context, storeID string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, storeID)
+}
+
+// TenantFromContext returns the tenant set on ctx, "" when none is
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// positiveDeltaAllowed reports whether the tenant may restock with this
+// update: any restock with positive_deltas, or a customer return with
+// return_restocks. The store the update names does not count, as callers can
+// name any.
+func (s *InventoryService) positiveDeltaAllowed(req *UpdateRequest) bool {
+	if s.featureFlags.Enabled(featureflags.PositiveDeltas, req.TenantID) {
+		return true
+	}
+	return req.Reason == models.UpdateReasonReturn && s.featureFlags.Enabled(featureflags.ReturnRestocks, req.TenantID)
+}
+
+// context returns the caller's context, or a background context when unset
+func (r *UpdateRequest) context() context.Context {
+	if r.Ctx == nil {
+		return context.Background()
+	}
+	return r.Ctx
 }
 
 // UpdateResult represents the result of an update operation
@@ -51,22 +153,43 @@ type UpdateResult struct {
 	ErrorMessage string
 	Applied      bool
 	LastUpdated  string
+	Replayed     bool // Answered from the idempotency cache
+	// The update left fewer units than the product's minStock policy
+	BelowMinStock bool
+	// Units of the sale taken beyond the stock and queued as a backorder
+	Backordered int
+
+	err error // Set on updates answered without being attempted
 }
 
 // InventoryData represents the complete inventory data structure
 type InventoryData struct {
-	Products map[string]ProductData `json:"products"`
-	Metadata MetadataData           `json:"metadata"`
+	Products   map[string]ProductData     `json:"products"`
+	Categories map[string]models.Category `json:"categories,omitempty"` // Category tree by ID
+	Metadata   MetadataData               `json:"metadata"`
+	Outbox     []OutboxEntry              `json:"outbox,omitempty"` // Events not yet in the events file
 }
 
 // ProductData represents complete product data
 type ProductData struct {
-	ProductID   string  `json:"productId"`
-	Name        string  `json:"name"`
-	Available   int     `json:"available"`
-	Version     int     `json:"version"`
-	LastUpdated string  `json:"lastUpdated"`
-	Price       float64 `json:"price"`
+	ProductID      string                `json:"productId"`
+	Name           string                `json:"name"`
+	Available      int                   `json:"available"`
+	Version        int                   `json:"version"`
+	LastUpdated    string                `json:"lastUpdated"`
+	Price          float64               `json:"price"`
+	Prices         []models.Money        `json:"prices,omitempty"`
+	Category       string                `json:"category,omitempty"`
+	Barcode        string                `json:"barcode,omitempty"`
+	BarcodeAliases []string              `json:"barcodeAliases,omitempty"`
+	Assets         []models.ProductAsset `json:"assets,omitempty"`
+	StockPolicy    *models.StockPolicy   `json:"stockPolicy,omitempty"`
+	// Units of the stock set aside for each store, by store ID. Together they
+	// never exceed the stock; transfers move units between stores.
+	Allocations map[string]int `json:"allocations,omitempty"`
+	// Sales taken beyond the stock, oldest first, waiting for restocks; kept
+	// in the data file only, not in product responses or events
+	Backorders []models.Backorder `json:"backorders,omitempty"`
 }
 
 // MetadataData represents system metadata for replication and caching
@@ -74,6 +197,7 @@ type MetadataData struct {
 	LastOffset    int    `json:"lastOffset"`    // Last event sequence number for replication
 	TotalProducts int    `json:"totalProducts"` // Quick count of total products
 	LastUpdated   string `json:"lastUpdated"`   // System-wide last update timestamp
+	OutboxSeq     int64  `json:"outboxSeq"`     // Seq of the newest outbox entry
 }
 
 const (
@@ -90,8 +214,47 @@ const (
 	ErrTypeMissingProductID      = "missing_product_id"
 	ErrTypeNotFound              = "not_found"
 	ErrTypeValidation            = "validation_error"
+	ErrTypeUnsupportedCurrency   = "unsupported_currency"
+	ErrTypeServiceUnavailable    = "service_unavailable"
+	ErrTypeQueueSaturated        = "queue_saturated"
+	ErrTypeLoadShed              = "load_shed"
+	ErrTypeStocktakeFrozen       = "stocktake_frozen"         // Decrement rejected by a stocktake in reject mode
+	ErrTypeStocktakeHold         = "stocktake_hold"           // Decrement held back by a stocktake in queue mode; retry later
+	ErrTypeBarcodeConflict       = "barcode_conflict"         // Barcode already scans as another product
+	ErrTypeConditionFailed       = "condition_failed"         // Stock did not meet minAvailable or expectedAvailable
+	ErrTypeUnknownCategory       = "unknown_category"         // Category is not in the category tree
+	ErrTypeMaxStockExceeded      = "max_stock_exceeded"       // Restock would exceed the stock policy's maxStock
+	ErrTypeBackorderLimit        = "backorder_limit_exceeded" // Sale would exceed the stock policy's maxBackorder
 )
 
+// ErrProductNotFound is wrapped by every error about a product that does not
+// exist
+var ErrProductNotFound = errors.New("product not found")
+
+// ErrUnsupportedCurrency is returned for a price in a currency that is invalid
+// or has no exchange rate
+var ErrUnsupportedCurrency = currency.ErrUnsupportedCurrency
+
+// ErrServiceDraining is returned for updates submitted after shutdown began
+var ErrServiceDraining = errors.New("inventory service is shutting down")
+
+// ErrServiceRestoring is returned for writes submitted while a restore runs
+var ErrServiceRestoring = errors.New("inventory service is restoring")
+
+// ErrQueueSaturated is returned when the target shard is at its high-water mark
+var ErrQueueSaturated = errors.New("inventory update queue is saturated")
+
+// ErrUpdateAbandoned is returned when the caller's context ends before its
+// update has a result; it wraps the context's error
+var ErrUpdateAbandoned = errors.New("inventory update abandoned by its caller")
+
+// defaultUpdateTimeout bounds updates submitted without a deadline
+const defaultUpdateTimeout = 20 * time.Second
+
+// updateProcessingTimeout is how long a worker waits on one update before
+// moving on to the rest of its shard
+const updateProcessingTimeout = 15 * time.Second
+
 // NewInventoryService creates a new inventory service instance
 func NewInventoryService(cfg *config.Config) (*InventoryService, error) {
 	// Parse cache TTL
@@ -129,40 +292,135 @@ func NewInventoryService(cfg *config.Config) (*InventoryService, error) {
 		queueBufferSize = 100
 	}
 
+	// Parse queue high-water mark (0 = reject only when the shard buffer is full)
+	queueHighWaterMark, err := strconv.Atoi(cfg.InventoryQueueHighWaterMark)
+	if err != nil || queueHighWaterMark < 0 {
+		slog.Warn("Invalid queue high-water mark, using default", "provided", cfg.InventoryQueueHighWaterMark, "error", err)
+		queueHighWaterMark = 0
+	}
+	if queueHighWaterMark == 0 || queueHighWaterMark > queueBufferSize {
+		queueHighWaterMark = queueBufferSize
+	}
+
+	// Parse per-priority lane quotas (percent of the high-water mark)
+	laneQuotas, err := ParseLaneQuotas(cfg.InventoryQueueLaneQuotas)
+	if err != nil {
+		slog.Warn("Invalid queue lane quotas, using defaults", "provided", cfg.InventoryQueueLaneQuotas, "error", err)
+	}
+
+	// Parse write-behind persistence settings (0 flush interval = save every update)
+	flushInterval, err := time.ParseDuration(cfg.PersistenceFlushInterval)
+	if err != nil || flushInterval < 0 {
+		slog.Warn("Invalid persistence flush interval, using default", "provided", cfg.PersistenceFlushInterval, "error", err)
+		flushInterval = 500 * time.Millisecond
+	}
+
+	flushMaxUpdates, err := strconv.Atoi(cfg.PersistenceFlushMaxUpdates)
+	if err != nil || flushMaxUpdates < 1 {
+		slog.Warn("Invalid persistence flush max updates, using default", "provided", cfg.PersistenceFlushMaxUpdates, "error", err)
+		flushMaxUpdates = 100
+	}
+
+	// Parse read cache setting (off unless enabled)
+	readCacheEnabled := false
+	if cfg.ReadCacheEnabled != "" {
+		readCacheEnabled, err = strconv.ParseBool(cfg.ReadCacheEnabled)
+		if err != nil {
+			slog.Warn("Invalid read cache setting, using default", "provided", cfg.ReadCacheEnabled, "error", err)
+			readCacheEnabled = false
+		}
+	}
+
+	// Parse the lock watchdog threshold (0 turns the watchdog off)
+	lockWatchdogThreshold, err := time.ParseDuration(cfg.LockWatchdogThreshold)
+	if err != nil || lockWatchdogThreshold < 0 {
+		slog.Warn("Invalid lock watchdog threshold, using default", "provided", cfg.LockWatchdogThreshold, "error", err)
+		lockWatchdogThreshold = 5 * time.Second
+	}
+
+	// Parse the not-found cache settings (0 TTL turns the cache off)
+	notFoundCacheTTL, err := time.ParseDuration(cfg.NotFoundCacheTTL)
+	if err != nil || notFoundCacheTTL < 0 {
+		slog.Warn("Invalid not-found cache TTL, using default", "provided", cfg.NotFoundCacheTTL, "error", err)
+		notFoundCacheTTL = 5 * time.Second
+	}
+	notFoundCacheMaxEntries, err := strconv.Atoi(cfg.NotFoundCacheMaxEntries)
+	if err != nil || notFoundCacheMaxEntries < 1 {
+		slog.Warn("Invalid not-found cache size, using default", "provided", cfg.NotFoundCacheMaxEntries, "error", err)
+		notFoundCacheMaxEntries = 10000
+	}
+
+	// Parse the result wait of updates whose caller set no deadline
+	updateTimeout, err := time.ParseDuration(cfg.UpdateTimeout)
+	if err != nil || updateTimeout <= 0 {
+		slog.Warn("Invalid update timeout, using default", "provided", cfg.UpdateTimeout, "error", err)
+		updateTimeout = defaultUpdateTimeout
+	}
+
 	service := &InventoryService{
-		updateQueue:           make(chan *UpdateRequest, queueBufferSize),
+		updateShards:          newUpdateShards(workerCount, queueBufferSize),
 		idempotencyCache:      cache.NewTTLCache(cacheTTL, cleanupInterval),
 		productLockManager:    NewProductLockManager(),
 		dataFilePath:          cfg.DataPath,
 		enableJSONPersistence: enablePersistence,
 		workerCount:           workerCount,
 		queueBufferSize:       queueBufferSize,
+		queueHighWaterMark:    queueHighWaterMark,
+		laneQuotas:            laneQuotas,
+		updateTimeout:         updateTimeout,
 		stopWorkers:           make(chan bool),
 	}
+	if readCacheEnabled {
+		service.readCache = newProductReadCache()
+	}
+	if notFoundCacheTTL > 0 {
+		service.notFoundCache = newNotFoundCache(notFoundCacheTTL, notFoundCacheMaxEntries)
+	}
+	service.transferLog, _ = transfers.NewStore("", 0)
+	service.stocktakes, _ = stocktakes.NewStore("")
+	service.orders, _ = orders.NewStore("")
 
-	err = service.loadTestData()
+	seedDataPath := cfg.SeedDataPath
+	if seedDataPath == "" {
+		seedDataPath = defaultSeedDataPath
+	}
+	err = service.loadTestData(seedDataPath)
 	if err != nil {
 		return nil, fmt.Errorf("error loading test data: %w", err)
 	}
 
+	service.persister = newWriteBehindPersister(service.saveDataToFile, flushInterval, flushMaxUpdates)
+
 	// Start the worker pool
 	service.startWorkerPool()
+	if lockWatchdogThreshold > 0 {
+		service.stopLockWatchdog = service.productLockManager.StartWatchdog(lockWatchdogThreshold)
+	}
 
 	slog.Info("Inventory service initialized with queue processing",
 		"worker_count", workerCount,
 		"queue_buffer_size", queueBufferSize,
+		"queue_high_water_mark", queueHighWaterMark,
+		"queue_lane_quotas", laneQuotas,
 		"cache_ttl", cacheTTL.String(),
 		"cleanup_interval", cleanupInterval.String(),
-		"json_persistence", enablePersistence)
+		"json_persistence", enablePersistence,
+		"persistence_flush_interval", flushInterval.String(),
+		"persistence_flush_max_updates", flushMaxUpdates,
+		"read_cache", readCacheEnabled,
+		"not_found_cache_ttl", notFoundCacheTTL.String(),
+		"lock_watchdog_threshold", lockWatchdogThreshold.String(),
+		"update_timeout", updateTimeout.String())
 
 	return service, nil
 }
 
-// loadTestData loads test data from JSON file
-func (s *InventoryService) loadTestData() error {
-	// Get the test data file path
-	dataPath := filepath.Join("data", "inventory_test_data.json")
+// defaultSeedDataPath is the data file loaded at startup when the
+// configuration names none, relative to the working directory
+const defaultSeedDataPath = "data/inventory_test_data.json"
 
+// loadTestData loads test data from the JSON file at dataPath
+func (s *InventoryService) loadTestData(dataPath string) error {
 	slog.Debug("Loading test data", "path", dataPath)
 
 	// Read the file
@@ -179,6 +437,12 @@ func (s *InventoryService) loadTestData() error {
 		slog.Error("Failed to parse test data JSON", "path", dataPath, "error", err)
 		return fmt.Errorf("error parsing test data JSON: %w", err)
 	}
+	normalizeTimestamps(s.data)
+
+	s.updatedIndex = newUpdatedIndex()
+	for productID, productData := range s.data.Products {
+		s.updatedIndex.record(productID, parseUpdatedAt(productData.LastUpdated), false)
+	}
 
 	slog.Info("Test data loaded successfully",
 		"path", dataPath,
@@ -188,42 +452,105 @@ func (s *InventoryService) loadTestData() error {
 	return nil
 }
 
-// GetProduct retrieves a product by its ID using product-level read lock
+// normalizeTimestamps rewrites the timestamps of a loaded data file in UTC.
+// Older versions wrote admin changes in local time, which broke the ordering
+// of lastUpdated across products and delta queries.
+func normalizeTimestamps(data *InventoryData) {
+	for productID, productData := range data.Products {
+		productData.LastUpdated = clock.Normalize(productData.LastUpdated)
+		for i := range productData.Backorders {
+			productData.Backorders[i].CreatedAt = clock.Normalize(productData.Backorders[i].CreatedAt)
+		}
+		data.Products[productID] = productData
+	}
+	for categoryID, category := range data.Categories {
+		category.UpdatedAt = clock.Normalize(category.UpdatedAt)
+		data.Categories[categoryID] = category
+	}
+	for i := range data.Outbox {
+		data.Outbox[i].Event.Timestamp = clock.Normalize(data.Outbox[i].Event.Timestamp)
+	}
+	data.Metadata.LastUpdated = clock.Normalize(data.Metadata.LastUpdated)
+}
+
+// GetProduct retrieves a product by its ID from the read cache, or under its
+// product-level read lock
 func (s *InventoryService) GetProduct(productID string) (*models.ProductResponse, error) {
 	slog.Debug("Retrieving product", "product_id", productID)
 
 	var response *models.ProductResponse
 	var err error
 
-	// Use product-level read lock for concurrent access
-	s.productLockManager.WithProductReadLock(productID, func() {
-		// Search for the product in the data
-		productData, exists := s.data.Products[productID]
-		if !exists {
-			slog.Warn("Product not found", "product_id", productID)
-			err = fmt.Errorf("product not found: %s", productID)
-			return
-		}
-
-		// Convert to response structure
-		response = &models.ProductResponse{
-			ProductID:   productData.ProductID,
-			Name:        productData.Name,
-			Available:   productData.Available,
-			Version:     productData.Version,
-			LastUpdated: productData.LastUpdated,
-			Price:       productData.Price,
-		}
-
+	// Served from the read cache when enabled, otherwise under the product read lock
+	product, exists := s.readProduct(productID)
+	if !exists {
+		slog.Warn("Product not found", "product_id", productID)
+		err = fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	} else {
+		response = &product
 		slog.Debug("Product retrieved successfully",
 			"product_id", productID,
 			"available", response.Available,
 			"version", response.Version)
-	})
+	}
 
 	return response, err
 }
 
+// GetProducts retrieves several products, each under its product-level read
+// lock, and returns the IDs that do not exist. Duplicate IDs are returned once.
+func (s *InventoryService) GetProducts(productIDs []string) ([]models.ProductResponse, []string) {
+	products := make([]models.ProductResponse, 0, len(productIDs))
+	missing := []string{}
+	seen := make(map[string]bool, len(productIDs))
+
+	for _, productID := range productIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+
+		product, exists := s.readProduct(productID)
+		if !exists {
+			missing = append(missing, productID)
+			continue
+		}
+		products = append(products, product)
+	}
+
+	slog.Debug("Products retrieved in batch",
+		"requested", len(productIDs),
+		"found", len(products),
+		"missing", len(missing))
+
+	return products, missing
+}
+
+// GetProductVersions reads the current version and stock of several products,
+// each under its product-level read lock, and returns the IDs that do not exist
+func (s *InventoryService) GetProductVersions(productIDs []string) (map[string]models.ProductVersion, []string) {
+	versions := make(map[string]models.ProductVersion, len(productIDs))
+	missing := []string{}
+
+	for _, productID := range productIDs {
+		if _, seen := versions[productID]; seen {
+			continue
+		}
+
+		product, exists := s.readProduct(productID)
+		if !exists {
+			missing = append(missing, productID)
+			continue
+		}
+		versions[productID] = models.ProductVersion{
+			Version:   product.Version,
+			Available: product.Available,
+		}
+	}
+
+	return versions, missing
+}
+
 // ListProducts retrieves a list of products with pagination
 func (s *InventoryService) ListProducts(cursor string, limit int) (*models.ListResponse, error) {
 	// Use global read lock for multi-product operations
@@ -237,12 +564,19 @@ func (s *InventoryService) ListProducts(cursor string, limit int) (*models.ListR
 
 	for _, productData := range s.data.Products {
 		item := models.ProductResponse{
-			ProductID:   productData.ProductID,
-			Name:        productData.Name,
-			Available:   productData.Available,
-			Version:     productData.Version,
-			LastUpdated: productData.LastUpdated,
-			Price:       productData.Price,
+			ProductID:      productData.ProductID,
+			Name:           productData.Name,
+			Available:      productData.Available,
+			Version:        productData.Version,
+			LastUpdated:    productData.LastUpdated,
+			Price:          productData.Price,
+			Prices:         productData.Prices,
+			Category:       productData.Category,
+			Barcode:        productData.Barcode,
+			BarcodeAliases: productData.BarcodeAliases,
+			Assets:         productData.Assets,
+			StockPolicy:    productData.StockPolicy,
+			Allocations:    productData.Allocations,
 		}
 		items = append(items, item)
 
@@ -260,9 +594,103 @@ func (s *InventoryService) ListProducts(cursor string, limit int) (*models.ListR
 	return response, nil
 }
 
+// ProductsUpdatedSince returns the products changed at or after since, oldest
+// change first, the IDs of products deleted in that window and the time the
+// read was taken at. It reads the lastUpdated index, so the cost follows the
+// number of changes, not the catalog. Returns ErrUpdatedSinceTooOld when
+// deletions after since were already pruned.
+func (s *InventoryService) ProductsUpdatedSince(since time.Time) ([]models.ProductResponse, []string, time.Time, error) {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	// Changes are indexed under the write lock, so none visible after this
+	// read is indexed before asOf
+	asOf := clock.Now()
+	changed, deleted, err := s.updatedIndex.since(since)
+	if err != nil {
+		return nil, nil, asOf, err
+	}
+	products := make([]models.ProductResponse, 0, len(changed))
+	for _, productID := range changed {
+		productData, exists := s.data.Products[productID]
+		if !exists {
+			continue
+		}
+		products = append(products, models.ProductResponse{
+			ProductID:      productData.ProductID,
+			Name:           productData.Name,
+			Available:      productData.Available,
+			Version:        productData.Version,
+			LastUpdated:    productData.LastUpdated,
+			Price:          productData.Price,
+			Prices:         productData.Prices,
+			Category:       productData.Category,
+			Barcode:        productData.Barcode,
+			BarcodeAliases: productData.BarcodeAliases,
+			Assets:         productData.Assets,
+			StockPolicy:    productData.StockPolicy,
+			Allocations:    productData.Allocations,
+		})
+	}
+	if deleted == nil {
+		deleted = []string{}
+	}
+
+	return products, deleted, asOf, nil
+}
+
+// lookupProduct reads a product from the shared map. Callers hold the product
+// lock for ordering; the global read lock guards the map itself because
+// workers update different products in parallel.
+func (s *InventoryService) lookupProduct(productID string) (ProductData, bool) {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+	productData, exists := s.data.Products[productID]
+	return productData, exists
+}
+
+// storeProduct writes a product to the shared map
+func (s *InventoryService) storeProduct(productID string, productData ProductData) {
+	s.globalMutex.Lock()
+	defer s.globalMutex.Unlock()
+	s.storeProductLocked(productID, productData)
+}
+
+// storeProductLocked writes a product to the shared map; the caller holds the
+// global write lock. The change is indexed at the time it becomes visible, not
+// at its lastUpdated stamp taken earlier, so a delta query never hands out an
+// asOf past a change it could not see.
+func (s *InventoryService) storeProductLocked(productID string, productData ProductData) {
+	s.data.Products[productID] = productData
+	s.updatedIndex.record(productID, clock.Now(), false)
+	if s.readCache != nil {
+		s.readCache.put(toProductResponse(productData))
+	}
+	if s.notFoundCache != nil {
+		s.notFoundCache.remove(productID)
+	}
+}
+
+// removeProduct deletes a product from the shared map
+func (s *InventoryService) removeProduct(productID string) {
+	s.globalMutex.Lock()
+	defer s.globalMutex.Unlock()
+	s.removeProductLocked(productID)
+}
+
+// removeProductLocked deletes a product from the shared map; the caller holds
+// the global write lock
+func (s *InventoryService) removeProductLocked(productID string) {
+	delete(s.data.Products, productID)
+	s.updatedIndex.record(productID, clock.Now(), true)
+	if s.readCache != nil {
+		s.readCache.remove(productID)
+	}
+}
+
 // ProductExists checks if a product exists
 func (s *InventoryService) ProductExists(productID string) bool {
-	_, exists := s.data.Products[productID]
+	_, exists := s.lookupProduct(productID)
 	return exists
 }
 
@@ -281,25 +709,89 @@ func (s *InventoryService) GetLastOffset() int {
 	return s.data.Metadata.LastOffset
 }
 
-// startWorkerPool starts the configured number of worker goroutines
+// startWorkerPool starts one worker goroutine per update shard
 func (s *InventoryService) startWorkerPool() {
-	slog.Info("Starting inventory update worker pool", "worker_count", s.workerCount)
+	slog.Info("Starting inventory update worker pool",
+		"worker_count", s.workerCount,
+		"shard_buffer_size", s.queueBufferSize)
+
+	s.workersDone = s.startWorkers(s.updateShards, nil)
+}
 
-	for i := 0; i < s.workerCount; i++ {
+// startWorkers starts one worker per shard. Workers wait for start to close
+// before consuming, and the returned channel closes once all of them exited.
+func (s *InventoryService) startWorkers(shards []chan *UpdateRequest, start <-chan struct{}) <-chan struct{} {
+	var generation sync.WaitGroup
+	for i, shard := range shards {
+		generation.Add(1)
 		s.workersWaitGroup.Add(1)
-		go s.processUpdateWorker(i + 1)
+		go func(workerID int, queue <-chan *UpdateRequest) {
+			defer generation.Done()
+			s.processUpdateWorker(workerID, queue, start)
+		}(i+1, shard)
 	}
+
+	done := make(chan struct{})
+	go func() {
+		generation.Wait()
+		close(done)
+	}()
+	return done
 }
 
-// processUpdateWorker processes inventory updates from the queue
-func (s *InventoryService) processUpdateWorker(workerID int) {
+// processUpdateWorker processes inventory updates from its shard queue
+func (s *InventoryService) processUpdateWorker(workerID int, queue <-chan *UpdateRequest, start <-chan struct{}) {
 	defer s.workersWaitGroup.Done()
 
+	if start != nil {
+		select {
+		case <-start:
+		case <-s.stopWorkers:
+			return
+		}
+	}
+
 	slog.Debug("Starting inventory update worker", "worker_id", workerID)
 
 	for {
+		// A stop wins over the updates still queued; Shutdown answers those
 		select {
-		case updateReq := <-s.updateQueue:
+		case <-s.stopWorkers:
+			slog.Debug("Stopping inventory update worker", "worker_id", workerID)
+			return
+		default:
+		}
+
+		select {
+		case updateReq, ok := <-queue:
+			if !ok {
+				slog.Debug("Update queue drained, stopping worker", "worker_id", workerID)
+				return
+			}
+
+			// Record the time the update spent waiting in the shard queue
+			_, waitSpan := telemetry.Tracer().Start(updateReq.context(), "inventory.queue_wait",
+				trace.WithTimestamp(updateReq.EnqueuedAt),
+				trace.WithAttributes(
+					attribute.String("product.id", updateReq.ProductID),
+					attribute.Int("worker.id", workerID),
+				))
+			waitSpan.End()
+
+			// The caller stopped waiting while the update was queued; drop it
+			if err := updateReq.context().Err(); err != nil {
+				s.laneCounters.abandoned[updateReq.Priority].Add(1)
+				slog.Warn("Dropping update abandoned in the queue",
+					"worker_id", workerID,
+					"product_id", updateReq.ProductID,
+					"priority", updateReq.Priority.String(),
+					"idempotency_key", updateReq.IdempotencyKey,
+					"queued_for", time.Since(updateReq.EnqueuedAt).String(),
+					"error", err)
+				updateReq.ResponseChan <- abandonedUpdateResult(err)
+				continue
+			}
+
 			// Process update with timeout protection
 			resultChan := make(chan *UpdateResult, 1)
 			go func() {
@@ -311,7 +803,7 @@ func (s *InventoryService) processUpdateWorker(workerID int) {
 			select {
 			case result = <-resultChan:
 				// Update completed successfully
-			case <-time.After(15 * time.Second):
+			case <-time.After(updateProcessingTimeout):
 				// Update processing timed out
 				slog.Error("Update processing timed out",
 					"worker_id", workerID,
@@ -349,13 +841,24 @@ func (s *InventoryService) processUpdateWorker(workerID int) {
 	}
 }
 
-// SetEventQueue sets the event queue for publishing events and synchronizes metadata
+// SetEventQueue sets the event queue for publishing events and synchronizes
+// metadata. In standby the synchronization waits until Promote.
 func (s *InventoryService) SetEventQueue(eventQueue *events.EventQueue) {
 	s.eventQueue = eventQueue
+	if s.standby.Load() {
+		return
+	}
+	s.syncEventQueue()
+}
+
+// syncEventQueue reconciles the data with the events file and aligns the
+// metadata offset with the queue
+func (s *InventoryService) syncEventQueue() {
+	s.recoverOutbox()
 
 	// Synchronize metadata with event queue's current offset for perfect consistency
 	s.globalMutex.Lock()
-	currentOffset := eventQueue.GetCurrentOffset()
+	currentOffset := s.eventQueue.GetCurrentOffset()
 
 	slog.Debug("SetEventQueue synchronization check",
 		"db_offset", s.data.Metadata.LastOffset,
@@ -383,6 +886,77 @@ func (s *InventoryService) SetEventQueue(eventQueue *events.EventQueue) {
 	s.globalMutex.Unlock()
 }
 
+// SetRatesProvider sets the exchange rates provider used for price conversion
+func (s *InventoryService) SetRatesProvider(provider currency.RatesProvider) {
+	s.ratesProvider = provider
+}
+
+// SetFeatureFlags sets the flags gating experimental update behaviors
+func (s *InventoryService) SetFeatureFlags(flags *featureflags.Flags) {
+	s.featureFlags = flags
+}
+
+// GetProductPrice resolves a product's price in the requested currency.
+// An explicit entry in the product's price list wins; otherwise the base price
+// is converted using the configured rates provider.
+func (s *InventoryService) GetProductPrice(productID, currencyCode string) (*models.PriceResponse, error) {
+	if s.ratesProvider == nil {
+		return nil, fmt.Errorf("%w: no rates provider configured", ErrUnsupportedCurrency)
+	}
+
+	currencyCode = strings.ToUpper(currencyCode)
+	baseCurrency := s.ratesProvider.BaseCurrency()
+	if currencyCode == "" {
+		currencyCode = baseCurrency
+	}
+	if !currency.IsValidCode(currencyCode) {
+		return nil, fmt.Errorf("%w: invalid currency code %q", ErrUnsupportedCurrency, currencyCode)
+	}
+
+	var productData ProductData
+	var exists bool
+	s.productLockManager.WithProductReadLock(productID, func() {
+		productData, exists = s.lookupProduct(productID)
+	})
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, productID)
+	}
+
+	response := &models.PriceResponse{
+		ProductID:    productID,
+		Currency:     currencyCode,
+		BaseCurrency: baseCurrency,
+	}
+
+	for _, price := range productData.Prices {
+		if strings.EqualFold(price.Currency, currencyCode) {
+			response.Amount = price.Amount
+			response.Display = currency.FromMinorUnits(price.Amount, currencyCode)
+			response.Source = "price_list"
+			return response, nil
+		}
+	}
+
+	base := models.Money{
+		Amount:   currency.ToMinorUnits(productData.Price, baseCurrency),
+		Currency: baseCurrency,
+	}
+	converted, err := currency.Convert(s.ratesProvider, base, currencyCode)
+	if err != nil {
+		// Unsupported currencies already wrap ErrUnsupportedCurrency; anything
+		// else is the rates provider failing
+		return nil, fmt.Errorf("failed to convert price to %s: %w", currencyCode, err)
+	}
+	rate, _ := s.ratesProvider.Rate(baseCurrency, currencyCode)
+
+	response.Amount = converted.Amount
+	response.Display = currency.FromMinorUnits(converted.Amount, currencyCode)
+	response.Source = "converted"
+	response.Rate = rate
+
+	return response, nil
+}
+
 // ResetDatabaseOffset resets the database offset to 0 to maintain consistency with event queue reset
 func (s *InventoryService) ResetDatabaseOffset() {
 	s.ResetDatabaseOffsetWithReason("event_queue_reset")
@@ -402,7 +976,7 @@ func (s *InventoryService) ResetDatabaseOffsetWithReason(reason string) {
 func (s *InventoryService) resetDatabaseOffsetInternal(reason string) {
 	oldOffset := s.data.Metadata.LastOffset
 	s.data.Metadata.LastOffset = 0
-	s.data.Metadata.LastUpdated = time.Now().UTC().Format(time.RFC3339)
+	s.data.Metadata.LastUpdated = clock.Timestamp()
 
 	var logMessage string
 	switch reason {
@@ -433,22 +1007,43 @@ func (s *InventoryService) processUpdateInternal(req *UpdateRequest) *UpdateResu
 		"version", req.Version,
 		"idempotency_key", req.IdempotencyKey)
 
-	// Check idempotency first using TTL cache (no locking needed for cache check)
-	if cachedResult, exists := s.idempotencyCache.Get(req.IdempotencyKey); exists {
-		if result, ok := cachedResult.(*UpdateResult); ok {
-			slog.Info("Idempotent request detected, returning cached result",
-				"idempotency_key", req.IdempotencyKey,
-				"product_id", req.ProductID)
-			return result
-		}
+	// Check idempotency again; an identical update may have been queued
+	// alongside this one before either was cached
+	if result, exists := s.cachedUpdateResult(req.dedupeKey()); exists {
+		slog.Info("Idempotent request detected, returning cached result",
+			"idempotency_key", req.IdempotencyKey,
+			"product_id", req.ProductID)
+		return result
 	}
 
 	var result *UpdateResult
 
 	// Use product-level write lock for OCC-compliant update
+	lockRequested := time.Now()
 	s.productLockManager.WithProductWriteLock(req.ProductID, func() {
+		// The span covers lock hold time; the wait to acquire it is an attribute
+		_, lockSpan := telemetry.Tracer().Start(req.context(), "inventory.product_lock",
+			trace.WithAttributes(
+				attribute.String("product.id", req.ProductID),
+				attribute.Int64("lock.wait_ms", time.Since(lockRequested).Milliseconds()),
+			))
+		defer lockSpan.End()
+
+		// The caller may have gone while the update waited for the lock.
+		// Nothing is applied or cached, so a retry starts over.
+		if err := req.context().Err(); err != nil {
+			s.laneCounters.abandoned[req.Priority].Add(1)
+			slog.Warn("Dropping update abandoned while waiting for the product lock",
+				"product_id", req.ProductID,
+				"priority", req.Priority.String(),
+				"idempotency_key", req.IdempotencyKey,
+				"error", err)
+			result = abandonedUpdateResult(err)
+			return
+		}
+
 		// Get current product data
-		productData, exists := s.data.Products[req.ProductID]
+		productData, exists := s.lookupProduct(req.ProductID)
 		if !exists {
 			result = &UpdateResult{
 				Success:      false,
@@ -459,12 +1054,21 @@ func (s *InventoryService) processUpdateInternal(req *UpdateRequest) *UpdateResu
 				NewVersion:   0,
 				LastUpdated:  "",
 			}
-			s.cacheIdempotencyResult(req.IdempotencyKey, result)
+			s.cacheIdempotencyResult(req.dedupeKey(), result)
 			return
 		}
 
-		// Check version for OCC
-		if productData.Version != req.Version {
+		// Decrements of products being counted wait until the stocktake closes.
+		// Not cached, so the same update succeeds once the session closed.
+		if req.Delta < 0 {
+			if frozen := s.stocktakeFreeze(req.ProductID, req.StoreID, productData); frozen != nil {
+				result = frozen
+				return
+			}
+		}
+
+		// Check version for OCC; conditional updates may skip it with version 0
+		if productData.Version != req.Version && (req.Version != 0 || !req.Condition.IsSet()) {
 			result = &UpdateResult{
 				Success:      false,
 				ErrorMessage: fmt.Sprintf("version conflict: expected %d, got %d", productData.Version, req.Version),
@@ -474,7 +1078,7 @@ func (s *InventoryService) processUpdateInternal(req *UpdateRequest) *UpdateResu
 				NewVersion:   productData.Version,   // Return current version
 				LastUpdated:  productData.LastUpdated,
 			}
-			s.cacheIdempotencyResult(req.IdempotencyKey, result)
+			s.cacheIdempotencyResult(req.dedupeKey(), result)
 
 			slog.Warn("Version conflict detected",
 				"product_id", req.ProductID,
@@ -485,142 +1089,210 @@ func (s *InventoryService) processUpdateInternal(req *UpdateRequest) *UpdateResu
 			return
 		}
 
-		// stores only negative quantities
-		if req.Delta > 0 {
+		// Compare-and-set on stock, against the same read the delta applies to
+		if message := req.Condition.failure(productData.Available); message != "" {
 			result = &UpdateResult{
 				Success:      false,
-				ErrorMessage: fmt.Sprintf("invalid delta: %d - only negative quantities are allowed", req.Delta),
-				ErrorType:    ErrTypeInvalidRequest,
+				ErrorMessage: message,
+				ErrorType:    ErrTypeConditionFailed,
 				Applied:      false,
+				NewQuantity:  productData.Available, // Return current quantity
+				NewVersion:   productData.Version,   // Return current version
+				LastUpdated:  productData.LastUpdated,
 			}
-			s.cacheIdempotencyResult(req.IdempotencyKey, result)
+			s.cacheIdempotencyResult(req.dedupeKey(), result)
+
+			slog.Warn("Update condition failed",
+				"product_id", req.ProductID,
+				"available", productData.Available,
+				"idempotency_key", req.IdempotencyKey)
+
 			return
 		}
 
-		// Calculate new quantity
-		newQuantity := productData.Available + req.Delta
-		if newQuantity < 0 {
+		// stores only send negative quantities unless restocks are enabled for them
+		if req.Delta > 0 && !s.positiveDeltaAllowed(req) {
 			result = &UpdateResult{
 				Success:      false,
-				ErrorMessage: fmt.Sprintf("insufficient inventory: current %d, delta %d", productData.Available, req.Delta),
-				ErrorType:    ErrTypeInsufficientInventory,
+				ErrorMessage: fmt.Sprintf("invalid delta: %d - only negative quantities are allowed", req.Delta),
+				ErrorType:    ErrTypeInvalidRequest,
 				Applied:      false,
 			}
-			s.cacheIdempotencyResult(req.IdempotencyKey, result)
+			s.cacheIdempotencyResult(req.dedupeKey(), result)
 			return
 		}
 
+		// Check the stock policy: no negative stock beyond the backorder
+		// allowance, no restock above the maximum
+		policy := s.stockPolicyFor(productData)
+		if violation := stockPolicyViolation(productData, req.Delta, policy); violation != nil {
+			result = violation
+			s.cacheIdempotencyResult(req.dedupeKey(), result)
+
+			slog.Warn("Update refused by stock policy",
+				"product_id", req.ProductID,
+				"available", productData.Available,
+				"delta", req.Delta,
+				"error_type", violation.ErrorType,
+				"idempotency_key", req.IdempotencyKey)
+
+			return
+		}
+
+		// Calculate new quantity
+		newQuantity := productData.Available + req.Delta
+
 		// Apply the update
 		newVersion := productData.Version + 1
-		lastUpdated := time.Now().UTC().Format(time.RFC3339)
+		lastUpdated := clock.Timestamp()
 
 		productData.Available = newQuantity
 		productData.Version = newVersion
 		productData.LastUpdated = lastUpdated
-		s.data.Products[req.ProductID] = productData
 
-		// Update global metadata (requires brief global lock)
-		s.globalMutex.Lock()
-		s.data.Metadata.LastOffset++
-		s.data.Metadata.LastUpdated = lastUpdated
-		s.globalMutex.Unlock()
+		// Units sold beyond the stock wait in the backorder queue; restocks
+		// fill the queue as they are committed. A store's sales come out of
+		// its own allocation first.
+		backordered := 0
+		if req.Delta < 0 {
+			drawAllocation(&productData, req.StoreID, -req.Delta)
+			if backordered = backorderShortfall(newQuantity-req.Delta, newQuantity); backordered > 0 {
+				queueBackorder(&productData, req, backordered)
+			}
+		}
+
+		// The product and its event are stored together, before the product
+		// lock is released, so the product's events follow its updates
+		_, publishSpan := telemetry.Tracer().Start(req.context(), "events.publish",
+			trace.WithAttributes(
+				attribute.String("event.type", models.EventTypeProductUpdated),
+				attribute.String("product.id", req.ProductID),
+			))
+		updateEvent := events.StoreUpdateEvent(req.ProductID, toProductResponse(productData),
+			newVersion, req.StoreID, req.Delta, req.Reason)
+		if backordered > 0 {
+			queued := productData.Backorders[len(productData.Backorders)-1]
+			updateEvent.Backorder = &queued
+		}
+		offset := s.commitProduct(productData, updateEvent)
+		publishSpan.SetAttributes(attribute.Int64("event.offset", offset))
+		publishSpan.End()
 
 		result = &UpdateResult{
-			Success:     true,
-			NewQuantity: newQuantity,
-			NewVersion:  newVersion,
-			Applied:     true,
-			LastUpdated: lastUpdated,
+			Success:       true,
+			NewQuantity:   newQuantity,
+			NewVersion:    newVersion,
+			Applied:       true,
+			LastUpdated:   lastUpdated,
+			BelowMinStock: belowMinStock(newQuantity, policy),
+			Backordered:   backordered,
 		}
 
 		// Cache the result for idempotency
-		s.cacheIdempotencyResult(req.IdempotencyKey, result)
+		s.cacheIdempotencyResult(req.dedupeKey(), result)
+
+		if result.BelowMinStock {
+			logBelowMinStock(req.ProductID, req.StoreID, newQuantity, policy)
+		}
 
 		slog.Info("Inventory update applied successfully",
 			"product_id", req.ProductID,
 			"old_quantity", productData.Available-req.Delta,
 			"new_quantity", newQuantity,
-			"old_version", req.Version,
+			"old_version", newVersion-1,
 			"new_version", newVersion,
 			"delta", req.Delta,
 			"idempotency_key", req.IdempotencyKey)
 	})
 
-	// Publish event if update was successful and event queue is available
-	// Do this asynchronously to prevent blocking the response
-	if result.Success && s.eventQueue != nil {
-		go func() {
-			// Get the complete product data to include in the event
-			var productName string
-			var productPrice float64
-			s.productLockManager.WithProductReadLock(req.ProductID, func() {
-				if productData, exists := s.data.Products[req.ProductID]; exists {
-					productName = productData.Name
-					productPrice = productData.Price
-				}
-			})
-
-			// Create event data with complete product information
-			eventData := models.ProductResponse{
-				ProductID:   req.ProductID,
-				Name:        productName,
-				Available:   result.NewQuantity,
-				Version:     result.NewVersion,
-				LastUpdated: result.LastUpdated,
-				Price:       productPrice,
-			}
-
-			s.eventQueue.PublishEvent(
-				models.EventTypeProductUpdated,
-				req.ProductID,
-				eventData,
-				result.NewVersion,
-			)
-
-			// Update metadata with current event offset for snapshot synchronization
-			s.globalMutex.Lock()
-			currentOffset := s.eventQueue.GetCurrentOffset()
-			s.data.Metadata.LastOffset = int(currentOffset)
-			s.data.Metadata.LastUpdated = result.LastUpdated
-			s.globalMutex.Unlock()
-
-			slog.Debug("Event published for inventory update",
-				"product_id", req.ProductID,
-				"product_name", productName,
-				"event_type", models.EventTypeProductUpdated,
-				"new_version", result.NewVersion,
-				"new_quantity", result.NewQuantity,
-				"current_offset", currentOffset)
-		}()
-	}
-
-	// Persist changes to JSON file if enabled (outside of product lock)
+	// Queue the change for the write-behind persister (outside of product lock);
+	// it is flushed with other updates on the next interval or full batch
 	if result.Success {
-		if saveErr := s.saveDataToFile(); saveErr != nil {
-			slog.Error("Failed to persist inventory data to file",
-				"error", saveErr,
-				"product_id", req.ProductID)
-			// Note: We don't fail the update operation if file save fails
-			// The in-memory state is still consistent
-		}
+		s.persister.MarkDirty()
+		s.recordUnitsSold(req)
+		s.updateRate.record(time.Now())
 	}
 
 	return result
 }
 
+// commitProductEvent stores an admin change of product with its event; the
+// caller holds the product's write lock
+func (s *InventoryService) commitProductEvent(eventType string, product ProductData) {
+	offset := s.commitProduct(product, events.ProductEvent(eventType, product.ProductID, toProductResponse(product), product.Version))
+
+	slog.Debug("Event published for admin product change",
+		"product_id", product.ProductID,
+		"event_type", eventType,
+		"version", product.Version,
+		"offset", offset)
+}
+
 // cacheIdempotencyResult stores the result for future idempotent requests
 func (s *InventoryService) cacheIdempotencyResult(key string, result *UpdateResult) {
 	s.idempotencyCache.Set(key, result)
 }
 
+// cachedUpdateResult returns a copy of the result cached under key, marked as
+// replayed; the cached result itself is shared and never modified
+func (s *InventoryService) cachedUpdateResult(key string) (*UpdateResult, bool) {
+	cached, exists := s.idempotencyCache.Get(key)
+	if !exists {
+		return nil, false
+	}
+	result, ok := cached.(*UpdateResult)
+	if !ok {
+		return nil, false
+	}
+	replayed := *result
+	replayed.Replayed = true
+	return &replayed, true
+}
+
+// ReplayedUpdate returns the cached outcome of an update storeID already sent
+// with idempotencyKey, marked as replayed
+func (s *InventoryService) ReplayedUpdate(storeID, idempotencyKey string) (*UpdateResult, bool) {
+	return s.cachedUpdateResult(idempotency.Scoped(storeID, idempotencyKey))
+}
+
+// CacheRejectedUpdate remembers an update that was rejected before reaching
+// the queue, so retries with the same key are answered from the cache
+func (s *InventoryService) CacheRejectedUpdate(storeID, idempotencyKey, errorType, message string) {
+	s.cacheIdempotencyResult(idempotency.Scoped(storeID, idempotencyKey), &UpdateResult{
+		Success:      false,
+		ErrorType:    errorType,
+		ErrorMessage: message,
+	})
+}
+
 // saveDataToFile persists the current inventory data to the JSON file
 func (s *InventoryService) saveDataToFile() error {
 	slog.Debug("saveDataToFile called", "enableJSONPersistence", s.enableJSONPersistence)
+	if s.standby.Load() {
+		// The leader owns the data file
+		return nil
+	}
+	s.trimOutbox()
 	if !s.enableJSONPersistence {
 		slog.Debug("JSON persistence disabled, skipping file save")
 		return nil
 	}
 
+	// Flushes batch many updates, so each one is its own trace
+	_, span := telemetry.Tracer().Start(context.Background(), "inventory.persist",
+		trace.WithAttributes(attribute.String("file.path", s.dataFilePath)))
+	defer span.End()
+
+	if err := s.writeDataFile(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+// writeDataFile snapshots the data under the global read lock and writes it atomically
+func (s *InventoryService) writeDataFile() error {
 	slog.Debug("Saving inventory data to file", "path", s.dataFilePath)
 
 	// Use global read lock to get consistent snapshot of data
@@ -662,6 +1334,9 @@ func (s *InventoryService) saveDataToFile() error {
 
 // saveDataToFileInternal saves data to file without acquiring mutex (internal use only)
 func (s *InventoryService) saveDataToFileInternal() error {
+	if s.standby.Load() {
+		return nil
+	}
 	if !s.enableJSONPersistence {
 		slog.Debug("JSON persistence disabled, skipping file save")
 		return nil
@@ -707,45 +1382,161 @@ func (s *InventoryService) GetCacheStats() map[string]interface{} {
 	return s.idempotencyCache.GetStats()
 }
 
-// GetLockStats returns statistics about the product lock manager
-func (s *InventoryService) GetLockStats() map[string]interface{} {
+// GetLockStats returns the product locks held or awaited right now
+func (s *InventoryService) GetLockStats() models.LockStats {
 	return s.productLockManager.GetLockStats()
 }
 
 // Stop gracefully shuts down the inventory service
 func (s *InventoryService) Stop() {
-	slog.Info("Stopping inventory service", "worker_count", s.workerCount)
+	if err := s.Shutdown(context.Background()); err != nil {
+		slog.Error("Inventory service stopped with errors", "error", err)
+	}
+}
 
-	// Signal all workers to stop
-	close(s.stopWorkers)
+// Shutdown stops accepting updates, lets the workers finish everything already
+// queued and persists the data. If ctx expires first the workers are told to
+// stop; once they exited, the updates still queued are answered with
+// ErrServiceDraining and the data is persisted.
+func (s *InventoryService) Shutdown(ctx context.Context) error {
+	s.drainMutex.Lock()
+	if s.draining {
+		s.drainMutex.Unlock()
+		return nil
+	}
+	s.draining = true
+	// No submitter holds the read lock now, so closing the queues is safe
+	for _, shard := range s.updateShards {
+		close(shard)
+	}
+	s.drainMutex.Unlock()
 
-	// Wait for all workers to finish processing current requests
-	s.workersWaitGroup.Wait()
+	slog.Info("Draining inventory update queue",
+		"worker_count", s.workerCount,
+		"queued_updates", s.queuedUpdates())
 
-	// Close the update queue
-	close(s.updateQueue)
+	drained := make(chan struct{})
+	go func() {
+		s.workersWaitGroup.Wait()
+		close(drained)
+	}()
+
+	var drainErr error
+	select {
+	case <-drained:
+		slog.Info("Inventory update queue drained")
+	case <-ctx.Done():
+		close(s.stopWorkers)
+		drainErr = fmt.Errorf("drain deadline exceeded with %d updates still queued: %w", s.queuedUpdates(), ctx.Err())
+		slog.Error("Inventory update queue drain timed out", "queued_updates", s.queuedUpdates())
+
+		// Updates being applied finish within the processing timeout; the
+		// persister must not stop under them
+		<-drained
+		s.rejectQueuedUpdates()
+	}
+
+	// Flush pending changes and stop the persister before exiting
+	if err := s.persister.Stop(); err != nil {
+		slog.Error("Failed to persist inventory data on shutdown", "error", err)
+		if drainErr == nil {
+			drainErr = err
+		}
+	}
 
 	// Stop the idempotency cache
 	if s.idempotencyCache != nil {
 		s.idempotencyCache.Stop()
 	}
+	if s.stopLockWatchdog != nil {
+		s.stopLockWatchdog()
+	}
 
 	slog.Info("Inventory service stopped successfully")
+	return drainErr
 }
 
-// UpdateInventory submits an inventory update request to the queue and waits for the result
+// IsDraining reports whether the service has stopped accepting updates
+func (s *InventoryService) IsDraining() bool {
+	s.drainMutex.RLock()
+	defer s.drainMutex.RUnlock()
+	return s.draining
+}
+
+// UpdateInventory submits an inventory update request to the queue and waits
+// for the result, at most UPDATE_TIMEOUT. Request handlers use
+// UpdateInventoryCtx so a client disconnect or deadline drops the update.
 func (s *InventoryService) UpdateInventory(productID string, delta, version int, idempotencyKey, storeID string) (*UpdateResult, error) {
+	return s.UpdateInventoryCtx(context.Background(), productID, delta, version, idempotencyKey, storeID)
+}
+
+// UpdateInventoryCtx submits an inventory update and waits for the result. The
+// trace in ctx follows the update through the queue, product lock and event publish.
+func (s *InventoryService) UpdateInventoryCtx(ctx context.Context, productID string, delta, version int, idempotencyKey, storeID string) (*UpdateResult, error) {
+	ctx, span := telemetry.Tracer().Start(ctx, "inventory.update",
+		trace.WithAttributes(
+			attribute.String("product.id", productID),
+			attribute.String("store.id", storeID),
+			attribute.Int("update.delta", delta),
+		))
+	defer span.End()
+
+	result, err := s.submitUpdate(ctx, productID, delta, version, idempotencyKey, storeID)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	case !result.Success:
+		span.SetAttributes(attribute.String("update.error_type", result.ErrorType))
+	}
+	return result, err
+}
+
+// submitUpdate enqueues the update on its product's shard and waits for the worker's result
+func (s *InventoryService) submitUpdate(ctx context.Context, productID string, delta, version int, idempotencyKey, storeID string) (*UpdateResult, error) {
+	// Callers without a deadline still get a bounded wait; the worker
+	// drops the update once it passes
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := s.updateTimeout
+		if timeout <= 0 {
+			timeout = defaultUpdateTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
 	// Create response channel
 	responseChan := make(chan *UpdateResult, 1)
 
 	// Create update request
+	priority := UpdatePriorityFromContext(ctx)
 	updateReq := &UpdateRequest{
 		ProductID:      productID,
 		Delta:          delta,
 		Version:        version,
 		IdempotencyKey: idempotencyKey,
 		StoreID:        storeID,
+		TenantID:       TenantFromContext(ctx),
+		Reason:         UpdateReasonFromContext(ctx),
+		Condition:      UpdateConditionFromContext(ctx),
+		Priority:       priority,
 		ResponseChan:   responseChan,
+		Ctx:            ctx,
+		EnqueuedAt:     time.Now(),
+	}
+
+	// Retries of an update already answered never reach the queue
+	if result, exists := s.cachedUpdateResult(updateReq.dedupeKey()); exists {
+		slog.Info("Idempotent request detected, returning cached result",
+			"idempotency_key", idempotencyKey,
+			"product_id", productID)
+		return result, nil
+	}
+
+	// Don't queue work nobody is waiting for
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUpdateAbandoned, err)
 	}
 
 	slog.Debug("Submitting update to queue",
@@ -754,24 +1545,105 @@ func (s *InventoryService) UpdateInventory(productID string, delta, version int,
 		"version", version,
 		"idempotency_key", idempotencyKey)
 
-	// Submit to queue
+	// Submit to queue; the read lock keeps Shutdown from closing the queue mid-send
+	s.drainMutex.RLock()
+	if s.draining {
+		s.drainMutex.RUnlock()
+		return nil, ErrServiceDraining
+	}
+	if s.restoring {
+		s.drainMutex.RUnlock()
+		return nil, ErrServiceRestoring
+	}
+
+	// Reject immediately instead of blocking when the shard is saturated.
+	// Lower-priority lanes are shed first, once the shard passes their quota,
+	// so checkout decrements keep the remaining headroom.
+	shard := s.shardFor(productID)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("update.shard", shard),
+		attribute.String("update.priority", priority.String()))
+	depth := len(s.updateShards[shard])
+	if depth >= s.queueHighWaterMark {
+		s.drainMutex.RUnlock()
+		s.laneCounters.shed[priority].Add(1)
+		slog.Warn("Update queue saturated, rejecting update",
+			"product_id", productID,
+			"shard", shard,
+			"priority", priority.String(),
+			"queue_depth", depth,
+			"high_water_mark", s.queueHighWaterMark)
+		return nil, ErrQueueSaturated
+	}
+	if laneLimit := s.laneLimit(priority); depth >= laneLimit {
+		s.drainMutex.RUnlock()
+		s.laneCounters.shed[priority].Add(1)
+		slog.Warn("Update queue under pressure, shedding lower-priority update",
+			"product_id", productID,
+			"shard", shard,
+			"priority", priority.String(),
+			"queue_depth", depth,
+			"lane_limit", laneLimit)
+		return nil, ErrLoadShed
+	}
 	select {
-	case s.updateQueue <- updateReq:
+	case s.updateShards[shard] <- updateReq:
 		// Successfully queued
-	case <-time.After(5 * time.Second):
-		return nil, fmt.Errorf("timeout submitting update to queue")
+		s.drainMutex.RUnlock()
+		s.laneCounters.admitted[priority].Add(1)
+	default:
+		s.drainMutex.RUnlock()
+		s.laneCounters.shed[priority].Add(1)
+		return nil, ErrQueueSaturated
 	}
 
-	// Wait for result with extended timeout to account for file I/O
+	// Wait for the result until the caller's context ends. A worker that
+	// dequeues the update after that drops it; one already applying it
+	// finishes and caches the result for retries.
 	select {
 	case result := <-responseChan:
+		if result.err != nil {
+			return nil, result.err
+		}
 		return result, nil
-	case <-time.After(20 * time.Second):
-		slog.Error("Timeout waiting for update result",
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "Stopped waiting for update result",
 			"product_id", productID,
 			"idempotency_key", idempotencyKey,
-			"timeout", "20s")
-		return nil, fmt.Errorf("timeout waiting for update result after 20 seconds")
+			"queued_for", time.Since(updateReq.EnqueuedAt).String(),
+			"error", ctx.Err())
+		return nil, fmt.Errorf("%w: %w", ErrUpdateAbandoned, ctx.Err())
+	}
+}
+
+// rejectQueuedUpdates answers the updates left in the closed shard queues of
+// stopped workers with ErrServiceDraining, so their callers stop waiting
+func (s *InventoryService) rejectQueuedUpdates() {
+	rejected := 0
+	for _, shard := range s.updateShards {
+		for updateReq := range shard {
+			// The response channel holds one result and nothing else writes it
+			updateReq.ResponseChan <- &UpdateResult{
+				ErrorType:    ErrTypeServiceUnavailable,
+				ErrorMessage: ErrServiceDraining.Error(),
+				err:          ErrServiceDraining,
+			}
+			rejected++
+		}
+	}
+	if rejected > 0 {
+		slog.Warn("Rejected updates still queued at shutdown", "rejected_updates", rejected)
+	}
+}
+
+// abandonedUpdateResult answers an update dropped because its caller's
+// context ended; nobody is usually left to read it
+func abandonedUpdateResult(err error) *UpdateResult {
+	return &UpdateResult{
+		Success:      false,
+		ErrorType:    ErrTypeTimeout,
+		ErrorMessage: fmt.Sprintf("update abandoned by its caller: %v", err),
+		Applied:      false,
 	}
 }
 
@@ -779,9 +1651,64 @@ func (s *InventoryService) UpdateInventory(productID string, delta, version int,
 func (s *InventoryService) AdminSetProducts(products []models.AdminProductUpdate) (*models.AdminSetResponse, error) {
 	slog.Info("Processing admin set request", "product_count", len(products))
 
+	results, err := s.adminSetChunk(products)
+	if err != nil {
+		return nil, err
+	}
+
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+	failureCount := len(results) - successCount
+
+	response := &models.AdminSetResponse{
+		Results: results,
+		Summary: models.AdminSetSummary{
+			TotalRequests:     len(products),
+			SuccessfulUpdates: successCount,
+			FailedUpdates:     failureCount,
+		},
+	}
+
+	slog.Info("Admin set request completed",
+		"total", len(products),
+		"successful", successCount,
+		"failed", failureCount)
+
+	return response, nil
+}
+
+// AdminSetProductsInChunks applies products like AdminSetProducts, chunkSize
+// at a time: each chunk is persisted and handed to record before the next one
+// starts, so a restore can run between chunks. Stops early with ctx's error or
+// ErrServiceRestoring, keeping the chunks already applied.
+func (s *InventoryService) AdminSetProductsInChunks(ctx context.Context, products []models.AdminProductUpdate, chunkSize int, record func([]models.AdminProductResult)) error {
+	for start := 0; start < len(products); start += chunkSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		results, err := s.adminSetChunk(products[start:min(start+chunkSize, len(products))])
+		if err != nil {
+			return err
+		}
+		record(results)
+	}
+	return nil
+}
+
+// adminSetChunk applies products in order and persists them once
+func (s *InventoryService) adminSetChunk(products []models.AdminProductUpdate) ([]models.AdminProductResult, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	results := make([]models.AdminProductResult, 0, len(products))
 	successCount := 0
-	failureCount := 0
 
 	for _, productUpdate := range products {
 		result := s.processAdminProductUpdate(productUpdate)
@@ -789,15 +1716,13 @@ func (s *InventoryService) AdminSetProducts(products []models.AdminProductUpdate
 
 		if result.Success {
 			successCount++
-		} else {
-			failureCount++
 		}
 	}
 
 	// Persist changes to JSON file if any update was successful
 	if successCount > 0 {
 		slog.Debug("Attempting to persist admin set changes to file", "successful_updates", successCount)
-		if saveErr := s.saveDataToFile(); saveErr != nil {
+		if saveErr := s.persister.FlushNow(); saveErr != nil {
 			slog.Error("Failed to persist inventory data to file after admin set",
 				"error", saveErr,
 				"successful_updates", successCount)
@@ -808,21 +1733,7 @@ func (s *InventoryService) AdminSetProducts(products []models.AdminProductUpdate
 		}
 	}
 
-	response := &models.AdminSetResponse{
-		Results: results,
-		Summary: models.AdminSetSummary{
-			TotalRequests:     len(products),
-			SuccessfulUpdates: successCount,
-			FailedUpdates:     failureCount,
-		},
-	}
-
-	slog.Info("Admin set request completed",
-		"total", len(products),
-		"successful", successCount,
-		"failed", failureCount)
-
-	return response, nil
+	return results, nil
 }
 
 // processAdminProductUpdate handles a single admin product update with OCC
@@ -836,7 +1747,7 @@ func (s *InventoryService) processAdminProductUpdate(update models.AdminProductU
 
 	s.productLockManager.WithProductWriteLock(update.ProductID, func() {
 		// Check if product exists
-		productData, exists = s.data.Products[update.ProductID]
+		productData, exists = s.lookupProduct(update.ProductID)
 		if !exists {
 			result = models.AdminProductResult{
 				ProductID:    update.ProductID,
@@ -864,6 +1775,51 @@ func (s *InventoryService) processAdminProductUpdate(update models.AdminProductU
 			updatedProduct.Price = *update.Price
 			hasChanges = true
 		}
+		if update.Prices != nil {
+			updatedProduct.Prices = update.Prices
+			hasChanges = true
+		}
+		if update.Category != nil {
+			// Held until the product is committed, so the category cannot be deleted meanwhile
+			s.categoryMutex.RLock()
+			defer s.categoryMutex.RUnlock()
+			category, ok := s.resolveCategory(*update.Category)
+			if !ok {
+				result = unknownCategoryResult(update.ProductID, *update.Category)
+				return
+			}
+			updatedProduct.Category = category
+			hasChanges = true
+		}
+		if update.Barcode != nil {
+			updatedProduct.Barcode = strings.TrimSpace(*update.Barcode)
+			hasChanges = true
+		}
+		if update.BarcodeAliases != nil {
+			updatedProduct.BarcodeAliases = trimBarcodes(update.BarcodeAliases)
+			hasChanges = true
+		}
+		if update.Assets != nil {
+			updatedProduct.Assets = update.Assets
+			hasChanges = true
+		}
+		if update.StockPolicy != nil {
+			updatedProduct.StockPolicy = ownStockPolicy(update.StockPolicy)
+			hasChanges = true
+		}
+		if update.Allocations != nil {
+			if violation := allocationsViolation(updatedProduct.Available, update.Allocations); violation != "" {
+				result = models.AdminProductResult{
+					ProductID:    update.ProductID,
+					Success:      false,
+					ErrorType:    ErrTypeValidation,
+					ErrorMessage: violation,
+				}
+				return
+			}
+			updatedProduct.Allocations = ownAllocations(update.Allocations)
+			hasChanges = true
+		}
 
 		if !hasChanges {
 			result = models.AdminProductResult{
@@ -874,13 +1830,25 @@ func (s *InventoryService) processAdminProductUpdate(update models.AdminProductU
 			}
 			return
 		}
+		if update.Barcode != nil || update.BarcodeAliases != nil {
+			if code, ownerID, found := s.barcodeOwner(updatedProduct); found {
+				result = barcodeConflictResult(update.ProductID, code, ownerID)
+				return
+			}
+		}
 
 		// Update version and timestamp (OCC)
 		updatedProduct.Version++
-		updatedProduct.LastUpdated = time.Now().Format(time.RFC3339)
-
-		// Apply the update
-		s.data.Products[update.ProductID] = updatedProduct
+		updatedProduct.LastUpdated = clock.Timestamp()
+
+		// Apply the update; a category change names the category the product
+		// left, so stores filtering on it learn that it is gone
+		event := events.ProductEvent(models.EventTypeProductUpdated, updatedProduct.ProductID,
+			toProductResponse(updatedProduct), updatedProduct.Version)
+		if normalizeCategory(productData.Category) != normalizeCategory(updatedProduct.Category) {
+			event.PreviousCategory = productData.Category
+		}
+		s.commitProduct(updatedProduct, event)
 
 		result = models.AdminProductResult{
 			ProductID:   update.ProductID,
@@ -894,53 +1862,25 @@ func (s *InventoryService) processAdminProductUpdate(update models.AdminProductU
 			"new_version", updatedProduct.Version,
 			"name_updated", update.Name != nil,
 			"available_updated", update.Available != nil,
-			"price_updated", update.Price != nil)
+			"price_updated", update.Price != nil,
+			"prices_updated", update.Prices != nil,
+			"category_updated", update.Category != nil,
+			"assets_updated", update.Assets != nil,
+			"stock_policy_updated", update.StockPolicy != nil,
+			"allocations_updated", update.Allocations != nil)
 	})
 
-	// Publish event if update was successful
-	if result.Success && s.eventQueue != nil {
-		go func() {
-			// Get the complete updated product data
-			s.productLockManager.WithProductReadLock(update.ProductID, func() {
-				if updatedProductData, exists := s.data.Products[update.ProductID]; exists {
-					eventData := models.ProductResponse{
-						ProductID:   update.ProductID,
-						Name:        updatedProductData.Name,
-						Available:   updatedProductData.Available,
-						Version:     updatedProductData.Version,
-						LastUpdated: updatedProductData.LastUpdated,
-						Price:       updatedProductData.Price,
-					}
-
-					s.eventQueue.PublishEvent(
-						models.EventTypeProductUpdated,
-						update.ProductID,
-						eventData,
-						updatedProductData.Version,
-					)
-
-					// Update metadata with current event offset
-					s.globalMutex.Lock()
-					currentOffset := s.eventQueue.GetCurrentOffset()
-					s.data.Metadata.LastOffset = int(currentOffset)
-					s.data.Metadata.LastUpdated = updatedProductData.LastUpdated
-					s.globalMutex.Unlock()
-
-					slog.Debug("Event published for admin product update",
-						"product_id", update.ProductID,
-						"event_type", models.EventTypeProductUpdated,
-						"new_version", updatedProductData.Version,
-						"current_offset", currentOffset)
-				}
-			})
-		}()
-	}
-
 	return result
 }
 
 // AdminCreateProducts performs admin-level product creation with OCC
 func (s *InventoryService) AdminCreateProducts(products []models.AdminProductCreate) (*models.AdminCreateResponse, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	slog.Info("Processing admin create request", "product_count", len(products))
 
 	results := make([]models.AdminProductResult, 0, len(products))
@@ -961,7 +1901,7 @@ func (s *InventoryService) AdminCreateProducts(products []models.AdminProductCre
 	// Persist changes to JSON file if any creation was successful
 	if successCount > 0 {
 		slog.Debug("Attempting to persist admin create changes to file", "successful_creations", successCount)
-		if saveErr := s.saveDataToFile(); saveErr != nil {
+		if saveErr := s.persister.FlushNow(); saveErr != nil {
 			slog.Error("Failed to persist inventory data to file after admin create",
 				"error", saveErr,
 				"successful_creations", successCount)
@@ -998,7 +1938,7 @@ func (s *InventoryService) processAdminProductCreate(create models.AdminProductC
 
 	s.productLockManager.WithProductWriteLock(create.ProductID, func() {
 		// Check if product already exists
-		if _, exists := s.data.Products[create.ProductID]; exists {
+		if _, exists := s.lookupProduct(create.ProductID); exists {
 			result = models.AdminProductResult{
 				ProductID:    create.ProductID,
 				Success:      false,
@@ -1008,22 +1948,41 @@ func (s *InventoryService) processAdminProductCreate(create models.AdminProductC
 			return
 		}
 
+		// Held until the product is committed, so the category cannot be deleted meanwhile
+		s.categoryMutex.RLock()
+		defer s.categoryMutex.RUnlock()
+		category, ok := s.resolveCategory(create.Category)
+		if !ok {
+			result = unknownCategoryResult(create.ProductID, create.Category)
+			return
+		}
+
 		// Create new product data
 		newProduct := ProductData{
-			ProductID:   create.ProductID,
-			Name:        create.Name,
-			Available:   create.Available,
-			Price:       create.Price,
-			Version:     1, // Start with version 1
-			LastUpdated: time.Now().Format(time.RFC3339),
+			ProductID:      create.ProductID,
+			Name:           create.Name,
+			Available:      create.Available,
+			Price:          create.Price,
+			Prices:         create.Prices,
+			Category:       category,
+			Barcode:        strings.TrimSpace(create.Barcode),
+			BarcodeAliases: trimBarcodes(create.BarcodeAliases),
+			Assets:         create.Assets,
+			StockPolicy:    ownStockPolicy(create.StockPolicy),
+			Version:        1, // Start with version 1
+			LastUpdated:    clock.Timestamp(),
+		}
+		if code, ownerID, found := s.barcodeOwner(newProduct); found {
+			result = barcodeConflictResult(create.ProductID, code, ownerID)
+			return
 		}
 
-		// Add the product
-		s.data.Products[create.ProductID] = newProduct
-
-		// Update metadata
-		s.data.Metadata.TotalProducts++
-		s.data.Metadata.LastUpdated = newProduct.LastUpdated
+		// Add the product, counting it in the same step as its event
+		s.commitChange(newProduct.LastUpdated, events.ProductEvent(models.EventTypeProductCreated, create.ProductID,
+			toProductResponse(newProduct), newProduct.Version), func() {
+			s.storeProductLocked(create.ProductID, newProduct)
+			s.data.Metadata.TotalProducts++
+		})
 
 		result = models.AdminProductResult{
 			ProductID:   create.ProductID,
@@ -1039,50 +1998,17 @@ func (s *InventoryService) processAdminProductCreate(create models.AdminProductC
 			"price", create.Price)
 	})
 
-	// Publish event if creation was successful
-	if result.Success && s.eventQueue != nil {
-		go func() {
-			// Get the complete created product data
-			s.productLockManager.WithProductReadLock(create.ProductID, func() {
-				if createdProductData, exists := s.data.Products[create.ProductID]; exists {
-					eventData := models.ProductResponse{
-						ProductID:   create.ProductID,
-						Name:        createdProductData.Name,
-						Available:   createdProductData.Available,
-						Version:     createdProductData.Version,
-						LastUpdated: createdProductData.LastUpdated,
-						Price:       createdProductData.Price,
-					}
-
-					s.eventQueue.PublishEvent(
-						models.EventTypeProductCreated,
-						create.ProductID,
-						eventData,
-						createdProductData.Version,
-					)
-
-					// Update metadata with current event offset
-					s.globalMutex.Lock()
-					currentOffset := s.eventQueue.GetCurrentOffset()
-					s.data.Metadata.LastOffset = int(currentOffset)
-					s.data.Metadata.LastUpdated = createdProductData.LastUpdated
-					s.globalMutex.Unlock()
-
-					slog.Debug("Event published for admin product creation",
-						"product_id", create.ProductID,
-						"event_type", models.EventTypeProductCreated,
-						"version", createdProductData.Version,
-						"current_offset", currentOffset)
-				}
-			})
-		}()
-	}
-
 	return result
 }
 
 // AdminDeleteProducts performs admin-level product deletion with OCC
 func (s *InventoryService) AdminDeleteProducts(productIDs []string) (*models.AdminDeleteResponse, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	slog.Info("Processing admin delete request", "product_count", len(productIDs))
 
 	results := make([]models.AdminProductResult, 0, len(productIDs))
@@ -1103,7 +2029,7 @@ func (s *InventoryService) AdminDeleteProducts(productIDs []string) (*models.Adm
 	// Persist changes to JSON file if any deletion was successful
 	if successCount > 0 {
 		slog.Debug("Attempting to persist admin delete changes to file", "successful_deletions", successCount)
-		if saveErr := s.saveDataToFile(); saveErr != nil {
+		if saveErr := s.persister.FlushNow(); saveErr != nil {
 			slog.Error("Failed to persist inventory data to file after admin delete",
 				"error", saveErr,
 				"successful_deletions", successCount)
@@ -1142,7 +2068,7 @@ func (s *InventoryService) processAdminProductDelete(productID string) models.Ad
 
 	s.productLockManager.WithProductWriteLock(productID, func() {
 		// Check if product exists and get its data before deletion
-		deletedProduct, existed = s.data.Products[productID]
+		deletedProduct, existed = s.lookupProduct(productID)
 		if !existed {
 			result = models.AdminProductResult{
 				ProductID:    productID,
@@ -1153,59 +2079,27 @@ func (s *InventoryService) processAdminProductDelete(productID string) models.Ad
 			return
 		}
 
-		// Delete the product
-		delete(s.data.Products, productID)
-
-		// Update metadata
-		s.data.Metadata.TotalProducts--
-		s.data.Metadata.LastUpdated = time.Now().Format(time.RFC3339)
-
 		result = models.AdminProductResult{
 			ProductID:   productID,
 			Success:     true,
 			NewVersion:  deletedProduct.Version + 1, // Increment version for deletion event
-			LastUpdated: s.data.Metadata.LastUpdated,
+			LastUpdated: clock.Timestamp(),
 		}
 
+		// The event carries the deleted product at its deletion version
+		tombstone := deletedProduct
+		tombstone.Version = result.NewVersion
+		tombstone.LastUpdated = result.LastUpdated
+		s.commitChange(result.LastUpdated, events.ProductEvent(models.EventTypeProductDeleted, productID,
+			toProductResponse(tombstone), tombstone.Version), func() {
+			s.removeProductLocked(productID)
+			s.data.Metadata.TotalProducts--
+		})
+
 		slog.Debug("Admin product deletion successful",
 			"product_id", productID,
 			"name", deletedProduct.Name)
 	})
 
-	// Publish event if deletion was successful
-	if result.Success && s.eventQueue != nil {
-		go func() {
-			// Create event data with the deleted product information
-			eventData := models.ProductResponse{
-				ProductID:   productID,
-				Name:        deletedProduct.Name,
-				Available:   deletedProduct.Available,
-				Version:     result.NewVersion,
-				LastUpdated: result.LastUpdated,
-				Price:       deletedProduct.Price,
-			}
-
-			s.eventQueue.PublishEvent(
-				models.EventTypeProductDeleted,
-				productID,
-				eventData,
-				result.NewVersion,
-			)
-
-			// Update metadata with current event offset
-			s.globalMutex.Lock()
-			currentOffset := s.eventQueue.GetCurrentOffset()
-			s.data.Metadata.LastOffset = int(currentOffset)
-			s.data.Metadata.LastUpdated = result.LastUpdated
-			s.globalMutex.Unlock()
-
-			slog.Debug("Event published for admin product deletion",
-				"product_id", productID,
-				"event_type", models.EventTypeProductDeleted,
-				"version", result.NewVersion,
-				"current_offset", currentOffset)
-		}()
-	}
-
 	return result
 }
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"maps"
+	"sort"
+
+	"inventory-management-api/internal/models"
+)
+
+// SnapshotProducts copies every product, sorted by ID, together with the last
+// event offset, under the global read lock so the copy is a single point in time
+func (s *InventoryService) SnapshotProducts() ([]models.ProductResponse, int) {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	return s.copyProductsLocked(), s.data.Metadata.LastOffset
+}
+
+// CatalogSnapshot copies every product, sorted by ID, together with the next
+// event offset, read under the same lock. Updates publish their event after
+// storing the product, so every change missing from the copy has an event at
+// or after that offset; changes already in the copy may be replayed from it,
+// and stores skip them as stale by version.
+func (s *InventoryService) CatalogSnapshot() ([]models.ProductResponse, int64) {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	var nextOffset int64
+	if s.eventQueue != nil {
+		nextOffset = s.eventQueue.GetCurrentOffset()
+	}
+	return s.copyProductsLocked(), nextOffset
+}
+
+// copyProductsLocked copies every product sorted by ID. The caller holds globalMutex.
+func (s *InventoryService) copyProductsLocked() []models.ProductResponse {
+	products := make([]models.ProductResponse, 0, len(s.data.Products))
+	for _, productData := range s.data.Products {
+		products = append(products, models.ProductResponse{
+			ProductID:      productData.ProductID,
+			Name:           productData.Name,
+			Available:      productData.Available,
+			Version:        productData.Version,
+			LastUpdated:    productData.LastUpdated,
+			Price:          productData.Price,
+			Prices:         append([]models.Money(nil), productData.Prices...),
+			Category:       productData.Category,
+			Barcode:        productData.Barcode,
+			BarcodeAliases: append([]string(nil), productData.BarcodeAliases...),
+			Assets:         append([]models.ProductAsset(nil), productData.Assets...),
+			StockPolicy:    productData.StockPolicy,
+			Allocations:    maps.Clone(productData.Allocations),
+		})
+	}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].ProductID < products[j].ProductID })
+	return products
+}
+
+// ProductIDs returns every product ID, sorted. Streaming listings read the
+// products behind them a chunk at a time, so the catalog is never copied whole.
+func (s *InventoryService) ProductIDs() []string {
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	ids := make([]string, 0, len(s.data.Products))
+	for productID := range s.data.Products {
+		ids = append(ids, productID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ProductIDsInCategories returns the IDs of the products in one of
+// categories, sorted; categories are compared case-insensitively
+func (s *InventoryService) ProductIDsInCategories(categories []string) []string {
+	wanted := make(map[string]bool, len(categories))
+	for _, category := range categories {
+		wanted[normalizeCategory(category)] = true
+	}
+
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	ids := make([]string, 0)
+	for productID, productData := range s.data.Products {
+		if wanted[normalizeCategory(productData.Category)] {
+			ids = append(ids, productID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"log/slog"
+	"time"
+
+	"inventory-management-api/internal/models"
+)
+
+// RecountMetadata recomputes the metadata counters from the products and the
+// event queue, which they drift from when an operation fails halfway, and
+// saves the recomputed ones unless dryRun is set. Commits wait meanwhile, so
+// the counters are compared at a single point.
+func (s *InventoryService) RecountMetadata(dryRun bool) (*models.MetadataRecountResponse, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	s.outboxMutex.Lock()
+	s.globalMutex.Lock()
+	recorded := metadataCounters(s.data.Metadata)
+	actual := s.recountMetadataLocked()
+	discrepancies := metadataDiscrepancies(recorded, actual)
+	repaired := !dryRun && len(discrepancies) > 0
+	if repaired {
+		s.data.Metadata.TotalProducts = actual.TotalProducts
+		s.data.Metadata.LastOffset = actual.LastOffset
+		s.data.Metadata.LastUpdated = actual.LastUpdated
+		s.data.Metadata.OutboxSeq = actual.OutboxSeq
+	}
+	s.globalMutex.Unlock()
+	s.outboxMutex.Unlock()
+
+	// As for admin changes, a failed save leaves the repair in memory, to be
+	// saved with the next change
+	if repaired {
+		if err := s.persister.FlushNow(); err != nil {
+			slog.Error("Failed to persist inventory data after metadata recount", "error", err)
+		}
+	}
+	if len(discrepancies) > 0 {
+		slog.Warn("Metadata counters drifted",
+			"discrepancies", discrepancies,
+			"recorded", recorded,
+			"actual", actual,
+			"repaired", repaired)
+	}
+
+	return &models.MetadataRecountResponse{
+		Recorded:      recorded,
+		Actual:        actual,
+		Discrepancies: discrepancies,
+		Repaired:      repaired,
+		DryRun:        dryRun,
+	}, nil
+}
+
+// recountMetadataLocked recomputes the counters; the caller holds the outbox
+// and global locks. Without an event queue the offset only counts changes
+// and is kept. The timestamp and outbox seq are only ever moved forward: a
+// deletion may have changed the data after every remaining product, and an
+// outbox seq below the queue's would have new events skipped as duplicates.
+func (s *InventoryService) recountMetadataLocked() models.MetadataCounters {
+	actual := metadataCounters(s.data.Metadata)
+	actual.TotalProducts = len(s.data.Products)
+
+	if s.eventQueue != nil {
+		actual.LastOffset = int(s.eventQueue.GetCurrentOffset())
+		actual.OutboxSeq = max(actual.OutboxSeq, s.eventQueue.OutboxSeq())
+	}
+	if len(s.data.Outbox) > 0 {
+		actual.OutboxSeq = max(actual.OutboxSeq, s.data.Outbox[len(s.data.Outbox)-1].Seq)
+	}
+
+	latest, _ := time.Parse(time.RFC3339, actual.LastUpdated)
+	newer := func(lastUpdated string) {
+		if at, err := time.Parse(time.RFC3339, lastUpdated); err == nil && at.After(latest) {
+			latest = at
+			actual.LastUpdated = lastUpdated
+		}
+	}
+	for _, product := range s.data.Products {
+		newer(product.LastUpdated)
+	}
+	for _, category := range s.data.Categories {
+		newer(category.UpdatedAt)
+	}
+	return actual
+}
+
+func metadataCounters(metadata MetadataData) models.MetadataCounters {
+	return models.MetadataCounters{
+		TotalProducts: metadata.TotalProducts,
+		LastOffset:    metadata.LastOffset,
+		LastUpdated:   metadata.LastUpdated,
+		OutboxSeq:     metadata.OutboxSeq,
+	}
+}
+
+// metadataDiscrepancies names the counters that differ, by their JSON names
+func metadataDiscrepancies(recorded, actual models.MetadataCounters) []string {
+	discrepancies := []string{}
+	if recorded.TotalProducts != actual.TotalProducts {
+		discrepancies = append(discrepancies, "totalProducts")
+	}
+	if recorded.LastOffset != actual.LastOffset {
+		discrepancies = append(discrepancies, "lastOffset")
+	}
+	if recorded.LastUpdated != actual.LastUpdated {
+		discrepancies = append(discrepancies, "lastUpdated")
+	}
+	if recorded.OutboxSeq != actual.OutboxSeq {
+		discrepancies = append(discrepancies, "outboxSeq")
+	}
+	return discrepancies
+}
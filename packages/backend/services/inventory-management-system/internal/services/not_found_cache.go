@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// notFoundCache remembers product IDs that were looked up and did not exist,
+// so clients polling unknown SKUs skip the product lock and the map lookup.
+// Entries are filled under the global read lock and dropped under the global
+// write lock whenever a product is stored, so a created product is never
+// hidden. Once full, new IDs are not remembered until entries expire.
+type notFoundCache struct {
+	mutex      sync.Mutex
+	entries    map[string]time.Time // Product ID -> expiry
+	ttl        time.Duration
+	maxEntries int
+	hits       atomic.Int64
+	rejected   atomic.Int64 // IDs not remembered because the cache was full
+}
+
+func newNotFoundCache(ttl time.Duration, maxEntries int) *notFoundCache {
+	return &notFoundCache{
+		entries:    make(map[string]time.Time),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// contains reports whether productID is known not to exist and counts the hit
+func (c *notFoundCache) contains(productID string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	expiresAt, ok := c.entries[productID]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(expiresAt) {
+		delete(c.entries, productID)
+		return false
+	}
+	c.hits.Add(1)
+	return true
+}
+
+// add remembers a product ID that was not found. Callers hold the global
+// read lock, so a concurrent store of the product removes the entry after it.
+func (c *notFoundCache) add(productID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	if _, ok := c.entries[productID]; !ok && len(c.entries) >= c.maxEntries {
+		for id, expiresAt := range c.entries {
+			if !now.Before(expiresAt) {
+				delete(c.entries, id)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.rejected.Add(1)
+			return
+		}
+	}
+	c.entries[productID] = now.Add(c.ttl)
+}
+
+// remove forgets a product ID once the product exists
+func (c *notFoundCache) remove(productID string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, productID)
+}
+
+// reset forgets every ID after the whole inventory was replaced
+func (c *notFoundCache) reset() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]time.Time)
+}
+
+// size returns the remembered IDs, including expired ones not swept yet
+func (c *notFoundCache) size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// NotFoundCacheStats returns the not-found cache counters; enabled is false
+// when the cache is off
+func (s *InventoryService) NotFoundCacheStats() map[string]interface{} {
+	if s.notFoundCache == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	return map[string]interface{}{
+		"enabled":     true,
+		"entries":     s.notFoundCache.size(),
+		"max_entries": s.notFoundCache.maxEntries,
+		"ttl":         s.notFoundCache.ttl.String(),
+		"hits":        s.notFoundCache.hits.Load(),
+		"rejected":    s.notFoundCache.rejected.Load(),
+	}
+}
+
+// registerNotFoundCacheMetrics exposes how many unknown product lookups the
+// not-found cache answered
+func (s *InventoryService) registerNotFoundCacheMetrics(meter metric.Meter) error {
+	if s.notFoundCache == nil {
+		return nil
+	}
+
+	hits, err := meter.Int64ObservableCounter(
+		"inventory_not_found_cache_hits_total",
+		metric.WithDescription("Lookups of unknown products answered by the not-found cache"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create not-found cache hits counter: %w", err)
+	}
+
+	entries, err := meter.Int64ObservableGauge(
+		"inventory_not_found_cache_entries",
+		metric.WithDescription("Unknown product IDs held in the not-found cache"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create not-found cache entries gauge: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		observer.ObserveInt64(hits, s.notFoundCache.hits.Load())
+		observer.ObserveInt64(entries, int64(s.notFoundCache.size()))
+		return nil
+	}, hits, entries)
+	if err != nil {
+		return fmt.Errorf("failed to register not-found cache callback: %w", err)
+	}
+	return nil
+}
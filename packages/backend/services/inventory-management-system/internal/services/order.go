@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/idempotency"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/orders"
+)
+
+// orderIdempotencyPrefix keeps order keys apart from update and transfer keys
+// in the shared idempotency cache
+const orderIdempotencyPrefix = "order:"
+
+// ErrOrderFinished is returned when committing a cancelled order or cancelling
+// a committed one
+var ErrOrderFinished = errors.New("order is already finished")
+
+// OrderError is returned when an order cannot reserve its lines; Type is one
+// of the ErrType constants. ProductID names the line that failed, with the
+// product's current version and stock.
+type OrderError struct {
+	Type           string
+	Message        string
+	ProductID      string
+	CurrentVersion int
+	Available      int
+	Replayed       bool  // Answered from the idempotency cache
+	Err            error // Sentinel cause, such as ErrProductNotFound, if any
+}
+
+func (e *OrderError) Error() string {
+	return e.Message
+}
+
+func (e *OrderError) Unwrap() error {
+	return e.Err
+}
+
+// SetOrders replaces the in-memory orders, e.g. with ones saved to a file
+func (s *InventoryService) SetOrders(store *orders.Store) {
+	s.orders = store
+}
+
+// CreateOrder reserves every line of req or none of them: the units leave
+// available stock, each product's version advances and an order_reserved
+// event is published per line. Replays with the same idempotency key return
+// the first outcome.
+func (s *InventoryService) CreateOrder(ctx context.Context, req models.OrderRequest) (*models.Order, error) {
+	if s.IsDraining() {
+		return nil, ErrServiceDraining
+	}
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cacheKey := orderIdempotencyPrefix + idempotency.Scoped(req.StoreID, req.IdempotencyKey)
+	if cached, exists := s.idempotencyCache.Get(cacheKey); exists {
+		slog.InfoContext(ctx, "Idempotent order detected, returning cached result",
+			"idempotency_key", req.IdempotencyKey,
+			"store_id", req.StoreID)
+		// Copies, so the cached outcome is never marked as replayed
+		switch cached := cached.(type) {
+		case *models.Order:
+			replayed := *cached
+			replayed.Replayed = true
+			return &replayed, nil
+		case *OrderError:
+			replayed := *cached
+			replayed.Replayed = true
+			return nil, &replayed
+		}
+	}
+
+	order, orderErr := s.reserveOrderLines(req)
+	if orderErr != nil {
+		// Stocktake freezes are not cached, so the order succeeds once the count closed
+		if orderErr.Type != ErrTypeStocktakeFrozen && orderErr.Type != ErrTypeStocktakeHold {
+			s.idempotencyCache.Set(cacheKey, orderErr)
+		}
+		slog.WarnContext(ctx, "Order rejected",
+			"store_id", req.StoreID,
+			"product_id", orderErr.ProductID,
+			"error_type", orderErr.Type,
+			"error", orderErr.Message)
+		return nil, orderErr
+	}
+
+	s.persister.MarkDirty()
+
+	order, err = s.orders.Create(order)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save orders", "order_id", order.OrderID, "error", err)
+	}
+	s.idempotencyCache.Set(cacheKey, &order)
+
+	slog.InfoContext(ctx, "Order reserved",
+		"order_id", order.OrderID,
+		"store_id", order.StoreID,
+		"lines", len(order.Items))
+	return &order, nil
+}
+
+// reserveOrderLines holds every product's write lock, in product ID order so
+// concurrent orders cannot deadlock, checks all lines and only then takes the
+// units out of stock. The order gets its ID once every line passed, so its
+// events are published before the locks are released.
+func (s *InventoryService) reserveOrderLines(req models.OrderRequest) (models.Order, *OrderError) {
+	lines := append([]models.OrderLine(nil), req.Lines...)
+	sort.Slice(lines, func(i, j int) bool { return lines[i].ProductID < lines[j].ProductID })
+	for _, line := range lines {
+		lock := s.productLockManager.LockProductForWrite(line.ProductID)
+		defer s.productLockManager.UnlockProductWrite(line.ProductID, lock)
+	}
+
+	products := make([]ProductData, len(lines))
+	for i, line := range lines {
+		productData, exists := s.lookupProduct(line.ProductID)
+		if !exists {
+			err := fmt.Errorf("%w: %s", ErrProductNotFound, line.ProductID)
+			return models.Order{}, &OrderError{
+				Type:      ErrTypeProductNotFound,
+				Message:   err.Error(),
+				ProductID: line.ProductID,
+				Err:       err,
+			}
+		}
+		if frozen := s.stocktakeFreeze(line.ProductID, req.StoreID, productData); frozen != nil {
+			return models.Order{}, &OrderError{
+				Type:           frozen.ErrorType,
+				Message:        frozen.ErrorMessage,
+				ProductID:      line.ProductID,
+				CurrentVersion: productData.Version,
+				Available:      productData.Available,
+			}
+		}
+		if line.Quantity > productData.Available {
+			return models.Order{}, &OrderError{
+				Type:           ErrTypeInsufficientInventory,
+				Message:        fmt.Sprintf("insufficient inventory for %s: current %d, ordered %d", line.ProductID, productData.Available, line.Quantity),
+				ProductID:      line.ProductID,
+				CurrentVersion: productData.Version,
+				Available:      productData.Available,
+			}
+		}
+		products[i] = productData
+	}
+
+	now := clock.Timestamp()
+	order := models.Order{
+		OrderID:        s.orders.NewID(),
+		StoreID:        req.StoreID,
+		Status:         models.OrderStatusReserved,
+		Items:          make([]models.OrderItem, len(lines)),
+		IdempotencyKey: req.IdempotencyKey,
+		CreatedAt:      now,
+	}
+	for i, line := range lines {
+		productData := products[i]
+		productData.Available -= line.Quantity
+		productData.Version++
+		productData.LastUpdated = now
+		order.Items[i] = s.applyOrderLine(models.EventTypeOrderReserved, order, productData, line.Quantity, -line.Quantity)
+	}
+	return order, nil
+}
+
+// CommitOrder turns a reservation into a sale. Stock is unchanged; each
+// product's version advances and an order_committed event is published per
+// line. Committing a committed order returns it unchanged.
+func (s *InventoryService) CommitOrder(ctx context.Context, id string) (models.Order, error) {
+	return s.finishOrder(ctx, id, models.OrderStatusCommitted, models.EventTypeOrderCommitted, 0)
+}
+
+// CancelOrder releases a reservation: the units return to available stock and
+// an order_cancelled event is published per line. Cancelling a cancelled
+// order returns it unchanged.
+func (s *InventoryService) CancelOrder(ctx context.Context, id string) (models.Order, error) {
+	return s.finishOrder(ctx, id, models.OrderStatusCancelled, models.EventTypeOrderCancelled, 1)
+}
+
+// GetOrder returns an order by ID
+func (s *InventoryService) GetOrder(id string) (models.Order, bool) {
+	return s.orders.Get(id)
+}
+
+// finishOrder moves a reserved order to status, adding sign times each line's
+// quantity back to stock. Products deleted since the reservation are skipped.
+func (s *InventoryService) finishOrder(ctx context.Context, id, status, eventType string, sign int) (models.Order, error) {
+	s.orderMutex.Lock()
+	defer s.orderMutex.Unlock()
+
+	order, ok := s.orders.Get(id)
+	if !ok {
+		return models.Order{}, orders.ErrNotFound
+	}
+	if order.Status == status {
+		return order, nil
+	}
+	if order.Status != models.OrderStatusReserved {
+		return order, ErrOrderFinished
+	}
+	if s.IsDraining() {
+		return models.Order{}, ErrServiceDraining
+	}
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return models.Order{}, err
+	}
+	defer release()
+
+	now := clock.Timestamp()
+	var published []models.OrderItem
+	for _, item := range order.Items {
+		applied := false
+		s.productLockManager.WithProductWriteLock(item.ProductID, func() {
+			productData, exists := s.lookupProduct(item.ProductID)
+			if !exists {
+				return
+			}
+			productData.Available += sign * item.Quantity
+			productData.Version++
+			productData.LastUpdated = now
+			published = append(published, s.applyOrderLine(eventType, order, productData, item.Quantity, sign*item.Quantity))
+			applied = true
+		})
+		if !applied {
+			slog.WarnContext(ctx, "Skipping order line for a deleted product",
+				"order_id", id,
+				"product_id", item.ProductID)
+		}
+	}
+	if len(published) > 0 {
+		s.persister.MarkDirty()
+	}
+
+	order, err = s.orders.Update(id, func(o *models.Order) error {
+		for i := range o.Items {
+			for _, item := range published {
+				if item.ProductID == o.Items[i].ProductID {
+					o.Items[i] = item
+				}
+			}
+		}
+		o.Status = status
+		if status == models.OrderStatusCommitted {
+			o.CommittedAt = now
+		} else {
+			o.CancelledAt = now
+		}
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save orders", "order_id", id, "error", err)
+	}
+
+	slog.InfoContext(ctx, "Order finished",
+		"order_id", id,
+		"store_id", order.StoreID,
+		"status", status,
+		"lines", len(published))
+	return order, nil
+}
+
+// applyOrderLine stores productData, changed by one line of order, with its
+// eventType event; the caller holds the product's write lock
+func (s *InventoryService) applyOrderLine(eventType string, order models.Order, productData ProductData, quantity, delta int) models.OrderItem {
+	item := models.OrderItem{
+		ProductID:  productData.ProductID,
+		Quantity:   quantity,
+		NewVersion: productData.Version,
+		Available:  productData.Available,
+	}
+	item.EventOffset = s.commitProduct(productData, events.OrderEvent(eventType, productData.ProductID,
+		toProductResponse(productData), productData.Version, order.OrderID, order.StoreID, delta, quantity))
+	return item
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"log/slog"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// OutboxEntry is an event recorded in the data file together with the change
+// it describes. Seq numbers the entries in the order the changes were applied.
+type OutboxEntry struct {
+	Seq   int64        `json:"seq"`
+	Event models.Event `json:"event"`
+}
+
+// outboxReplayBatch bounds the events read at a time when rolling forward
+const outboxReplayBatch = 1000
+
+// commitProduct stores productData and records event for it, followed by
+// the events of the backorders a restock filled; the caller holds the
+// product's write lock. Allocations the stock no longer covers are trimmed
+// first. Returns the offset of event.
+func (s *InventoryService) commitProduct(productData ProductData, event models.Event) int64 {
+	if trimAllocations(&productData) {
+		event.Data.Allocations = productData.Allocations
+	}
+	changeEvents := append([]models.Event{event}, fulfillBackorders(&productData)...)
+	return s.commitEvents(productData.LastUpdated, changeEvents, func() {
+		s.storeProductLocked(productData.ProductID, productData)
+	})[0]
+}
+
+// commitChange applies a change under the global lock and records its event
+// in the outbox in the same step, so every save of the data file holds both
+// or neither. The event is then moved into the queue, in outbox order; the
+// entry stays in the outbox until the events file holds it, so a crash
+// between the two files loses no event. Returns the event's offset.
+func (s *InventoryService) commitChange(lastUpdated string, event models.Event, apply func()) int64 {
+	return s.commitEvents(lastUpdated, []models.Event{event}, apply)[0]
+}
+
+// commitEvents is commitChange for a change described by several events,
+// recorded and queued in order. Returns their offsets.
+func (s *InventoryService) commitEvents(lastUpdated string, changeEvents []models.Event, apply func()) []int64 {
+	s.outboxMutex.Lock()
+	defer s.outboxMutex.Unlock()
+
+	offsets := make([]int64, len(changeEvents))
+	s.globalMutex.Lock()
+	apply()
+	s.data.Metadata.LastUpdated = lastUpdated
+	if s.eventQueue == nil {
+		// Without a queue the offset just counts the changes
+		s.data.Metadata.LastOffset += len(changeEvents)
+		s.globalMutex.Unlock()
+		return offsets
+	}
+	timestamp := clock.Timestamp()
+	entries := make([]OutboxEntry, len(changeEvents))
+	for i, event := range changeEvents {
+		s.data.Metadata.OutboxSeq++
+		entries[i] = OutboxEntry{Seq: s.data.Metadata.OutboxSeq, Event: event}
+		entries[i].Event.Timestamp = timestamp
+	}
+	s.data.Outbox = append(s.data.Outbox, entries...)
+	s.globalMutex.Unlock()
+
+	for i, entry := range entries {
+		offsets[i], _ = s.eventQueue.AppendFromOutbox(entry.Seq, entry.Event)
+	}
+
+	s.globalMutex.Lock()
+	s.data.Metadata.LastOffset = int(offsets[len(offsets)-1]) + 1
+	s.globalMutex.Unlock()
+	return offsets
+}
+
+// trimOutbox drops the entries the events file already holds; called before
+// every save of the data file
+func (s *InventoryService) trimOutbox() {
+	if s.eventQueue == nil {
+		return
+	}
+	saved := s.eventQueue.SavedOutboxSeq()
+
+	s.globalMutex.Lock()
+	defer s.globalMutex.Unlock()
+	kept := 0
+	for kept < len(s.data.Outbox) && s.data.Outbox[kept].Seq <= saved {
+		kept++
+	}
+	if kept > 0 {
+		s.data.Outbox = append([]OutboxEntry(nil), s.data.Outbox[kept:]...)
+	}
+}
+
+// recoverOutbox reconciles the data file with the events file after a crash
+// left one ahead of the other. Events the data file is missing are applied to
+// the products; outbox entries the events file is missing are appended to the
+// queue. Called from SetEventQueue before any change is made.
+func (s *InventoryService) recoverOutbox() {
+	s.outboxMutex.Lock()
+	defer s.outboxMutex.Unlock()
+	s.globalMutex.Lock()
+	defer s.globalMutex.Unlock()
+
+	queueSeq := s.eventQueue.OutboxSeq()
+	if queueSeq > s.data.Metadata.OutboxSeq {
+		applied := s.rollForwardLocked(int64(s.data.Metadata.LastOffset))
+		slog.Warn("Events file was ahead of the data file, applied the missing changes",
+			"data_outbox_seq", s.data.Metadata.OutboxSeq,
+			"queue_outbox_seq", queueSeq,
+			"applied_events", applied)
+		s.data.Metadata.OutboxSeq = queueSeq
+		s.data.Outbox = nil
+		return
+	}
+
+	redelivered := 0
+	for _, entry := range s.data.Outbox {
+		if _, appended := s.eventQueue.AppendFromOutbox(entry.Seq, entry.Event); appended {
+			redelivered++
+		}
+	}
+	if redelivered > 0 {
+		slog.Warn("Data file was ahead of the events file, appended the missing events",
+			"redelivered_events", redelivered,
+			"outbox_seq", s.data.Metadata.OutboxSeq)
+	}
+}
+
+// rollForwardLocked applies the queue's events from offset on to the
+// products. Events carry the product's full state, so applying one the data
+// already reflects is harmless. Stops at a restore, whose products are not
+// in the events. The caller holds the global lock.
+func (s *InventoryService) rollForwardLocked(offset int64) int {
+	applied := 0
+	for {
+		batch, next, hasMore := s.eventQueue.GetEvents(offset, outboxReplayBatch)
+		for _, event := range batch {
+			switch event.EventType {
+			case models.EventTypeProductCreated, models.EventTypeProductUpdated, models.EventTypeStockTransferred,
+				models.EventTypeOrderReserved, models.EventTypeOrderCommitted, models.EventTypeOrderCancelled:
+				// The backorder queue is not in the product's state, only in the
+				// events that change it
+				product := fromProductResponse(event.Data)
+				product.Backorders = applyBackorderEvent(s.data.Products[event.ProductID].Backorders, event)
+				s.storeProductLocked(event.ProductID, product)
+			case models.EventTypeBackorderFulfilled:
+				if product, exists := s.data.Products[event.ProductID]; exists {
+					product.Backorders = applyBackorderEvent(product.Backorders, event)
+					s.storeProductLocked(event.ProductID, product)
+				}
+			case models.EventTypeProductDeleted:
+				s.removeProductLocked(event.ProductID)
+			case models.EventTypeCategoryUpdated:
+				if event.Category != nil {
+					s.storeCategoryLocked(*event.Category)
+				}
+			case models.EventTypeCategoryDeleted:
+				if event.Category != nil {
+					delete(s.data.Categories, event.Category.ID)
+				}
+			case models.EventTypeSystemRestored:
+				slog.Error("Cannot roll forward past a restore, its products are not in the events",
+					"offset", event.Offset)
+				return applied
+			}
+			applied++
+		}
+		offset = next
+		if !hasMore {
+			break
+		}
+	}
+	s.data.Metadata.TotalProducts = len(s.data.Products)
+	s.data.Metadata.LastOffset = int(offset)
+	return applied
+}
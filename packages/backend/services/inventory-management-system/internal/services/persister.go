@@ -0,0 +1,141 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// writeBehindPersister batches inventory changes and saves the data file on an
+// interval or once enough updates are pending, instead of after every update.
+// All saves go through it so concurrent writers never race on the temp file.
+type writeBehindPersister struct {
+	save          func() error
+	flushInterval time.Duration
+	maxPending    int
+
+	mu          sync.Mutex // Guards pending
+	pending     int
+	saveMutex   sync.Mutex // Serializes saves
+	flushSignal chan struct{}
+	stop        chan struct{}
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+// newWriteBehindPersister creates a persister; a non-positive flushInterval
+// disables batching and every change is saved immediately
+func newWriteBehindPersister(save func() error, flushInterval time.Duration, maxPending int) *writeBehindPersister {
+	if maxPending < 1 {
+		maxPending = 1
+	}
+
+	p := &writeBehindPersister{
+		save:          save,
+		flushInterval: flushInterval,
+		maxPending:    maxPending,
+		flushSignal:   make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+
+	if flushInterval > 0 {
+		go p.run()
+	} else {
+		close(p.done)
+	}
+	return p
+}
+
+// MarkDirty records a change that needs to reach disk
+func (p *writeBehindPersister) MarkDirty() {
+	if p.flushInterval <= 0 {
+		if err := p.Flush(); err != nil {
+			slog.Error("Failed to persist inventory data to file", "error", err)
+		}
+		return
+	}
+
+	p.mu.Lock()
+	p.pending++
+	full := p.pending >= p.maxPending
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.flushSignal <- struct{}{}:
+		default:
+			// A flush is already requested
+		}
+	}
+}
+
+// Flush saves the data now if anything changed since the last save
+func (p *writeBehindPersister) Flush() error {
+	p.saveMutex.Lock()
+	defer p.saveMutex.Unlock()
+
+	p.mu.Lock()
+	pending := p.pending
+	p.pending = 0
+	p.mu.Unlock()
+
+	if pending == 0 && p.flushInterval > 0 {
+		return nil
+	}
+
+	start := time.Now()
+	if err := p.save(); err != nil {
+		// Keep the changes pending so the next flush retries
+		p.mu.Lock()
+		p.pending += pending
+		p.mu.Unlock()
+		return err
+	}
+
+	slog.Debug("Flushed inventory changes to file",
+		"batched_changes", pending,
+		"duration", time.Since(start))
+	return nil
+}
+
+// FlushNow saves the data immediately regardless of pending changes, for
+// operations that must be on disk before they return
+func (p *writeBehindPersister) FlushNow() error {
+	p.mu.Lock()
+	p.pending++
+	p.mu.Unlock()
+	return p.Flush()
+}
+
+// Stop stops the background loop after a final flush
+func (p *writeBehindPersister) Stop() error {
+	p.stopOnce.Do(func() {
+		if p.flushInterval > 0 {
+			close(p.stop)
+		}
+	})
+	<-p.done
+	return p.Flush()
+}
+
+// run flushes pending changes every interval or when the batch is full
+func (p *writeBehindPersister) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.flushSignal:
+		case <-p.stop:
+			return
+		}
+
+		if err := p.Flush(); err != nil {
+			slog.Error("Failed to persist inventory data to file", "error", err)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSave stands in for the data file save, counting calls and failing
+// while failing is set
+type countingSave struct {
+	mu      sync.Mutex
+	calls   int
+	failing bool
+}
+
+func (c *countingSave) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if c.failing {
+		return errors.New("disk full")
+	}
+	return nil
+}
+
+func (c *countingSave) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *countingSave) setFailing(failing bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failing = failing
+}
+
+// pendingChanges returns the changes the persister has not saved yet
+func pendingChanges(p *writeBehindPersister) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pending
+}
+
+// waitForSaves waits until save has been called want times
+func waitForSaves(t *testing.T, save *countingSave, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for save.count() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d saves, got %d", want, save.count())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestWriteBehindPersister_MarkDirtyBatches(t *testing.T) {
+	save := &countingSave{}
+	p := newWriteBehindPersister(save.save, time.Hour, 3)
+	defer p.Stop()
+
+	p.MarkDirty()
+	p.MarkDirty()
+	if got := save.count(); got != 0 {
+		t.Errorf("Expected a partial batch to wait for the interval, got %d saves", got)
+	}
+	if got := pendingChanges(p); got != 2 {
+		t.Errorf("Expected 2 pending changes, got %d", got)
+	}
+
+	p.MarkDirty()
+	waitForSaves(t, save, 1)
+	if got := pendingChanges(p); got != 0 {
+		t.Errorf("Expected the full batch to be saved, %d changes still pending", got)
+	}
+}
+
+func TestWriteBehindPersister_SavesEveryChangeWithoutInterval(t *testing.T) {
+	save := &countingSave{}
+	p := newWriteBehindPersister(save.save, 0, 10)
+
+	p.MarkDirty()
+	p.MarkDirty()
+	if got := save.count(); got != 2 {
+		t.Errorf("Expected every change saved at once, got %d saves", got)
+	}
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+}
+
+func TestWriteBehindPersister_FlushRetriesFailedSave(t *testing.T) {
+	save := &countingSave{failing: true}
+	p := newWriteBehindPersister(save.save, time.Hour, 100)
+	defer p.Stop()
+
+	p.MarkDirty()
+	p.MarkDirty()
+	if err := p.Flush(); err == nil {
+		t.Fatal("Expected the failed save to be returned")
+	}
+	if got := pendingChanges(p); got != 2 {
+		t.Errorf("Expected the failed batch to stay pending, got %d pending changes", got)
+	}
+
+	save.setFailing(false)
+	p.MarkDirty()
+	if err := p.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := save.count(); got != 2 {
+		t.Errorf("Expected the retry to save once, got %d saves", got)
+	}
+	if got := pendingChanges(p); got != 0 {
+		t.Errorf("Expected the retry to save the failed batch, %d changes still pending", got)
+	}
+
+	// Nothing changed since, so there is nothing to save
+	if err := p.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	if got := save.count(); got != 2 {
+		t.Errorf("Expected no save without changes, got %d saves", got)
+	}
+}
+
+func TestWriteBehindPersister_StopFlushesPending(t *testing.T) {
+	save := &countingSave{}
+	p := newWriteBehindPersister(save.save, time.Hour, 100)
+
+	p.MarkDirty()
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if got := save.count(); got != 1 {
+		t.Errorf("Expected Stop to save the pending change, got %d saves", got)
+	}
+
+	// A second stop has nothing left to save
+	if err := p.Stop(); err != nil {
+		t.Fatalf("Second Stop failed: %v", err)
+	}
+	if got := save.count(); got != 1 {
+		t.Errorf("Expected no further save, got %d saves", got)
+	}
+}
+
+func TestWriteBehindPersister_StopReturnsFailedSave(t *testing.T) {
+	save := &countingSave{failing: true}
+	p := newWriteBehindPersister(save.save, time.Hour, 100)
+
+	p.MarkDirty()
+	if err := p.Stop(); err == nil {
+		t.Fatal("Expected Stop to return the failed final save")
+	}
+	if got := pendingChanges(p); got != 1 {
+		t.Errorf("Expected the change to stay pending, got %d", got)
+	}
+}
@@ -2,26 +2,51 @@ package services
 
 import (
 	"log/slog"
+	"runtime"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"inventory-management-api/internal/models"
 )
 
+// maxReportedHolds bounds the longest holds listed in the lock stats
+const maxReportedHolds = 10
+
+// maxStackDumpBytes bounds the goroutine dump the lock watchdog logs
+const maxStackDumpBytes = 1 << 20
+
+// ProductLock is a product's mutex with the hold state the stats and the
+// watchdog read. The state has its own mutex so reading it never waits for
+// the product lock itself.
+type ProductLock struct {
+	sync.RWMutex
+	mutex    sync.Mutex // Guards the fields below
+	waiters  int        // Goroutines blocked acquiring the lock
+	writer   bool
+	readers  int
+	since    time.Time // When the current hold began; zero while free
+	reported bool      // The watchdog already logged the current hold
+}
+
 // ProductLockManager manages fine-grained locks per product ID
 type ProductLockManager struct {
-	locks    map[string]*sync.RWMutex
+	locks    map[string]*ProductLock
 	locksMux sync.RWMutex
+	stalls   atomic.Int64 // Holds the watchdog found past its threshold
 }
 
 // NewProductLockManager creates a new product lock manager
 func NewProductLockManager() *ProductLockManager {
 	return &ProductLockManager{
-		locks: make(map[string]*sync.RWMutex),
+		locks: make(map[string]*ProductLock),
 	}
 }
 
-// GetProductLock returns a mutex for the specified product ID
-// Creates a new mutex if one doesn't exist for this product
-func (plm *ProductLockManager) GetProductLock(productID string) *sync.RWMutex {
+// getProductLock returns the lock for the specified product ID
+// Creates a new lock if one doesn't exist for this product
+func (plm *ProductLockManager) getProductLock(productID string) *ProductLock {
 	plm.locksMux.RLock()
 	if lock, exists := plm.locks[productID]; exists {
 		plm.locksMux.RUnlock()
@@ -39,52 +64,85 @@ func (plm *ProductLockManager) GetProductLock(productID string) *sync.RWMutex {
 	}
 
 	// Create new lock for this product
-	newLock := &sync.RWMutex{}
+	newLock := &ProductLock{}
 	plm.locks[productID] = newLock
-	
+
 	slog.Debug("Created new product lock", "product_id", productID)
 	return newLock
 }
 
 // LockProductForWrite acquires a write lock for the specified product
-func (plm *ProductLockManager) LockProductForWrite(productID string) *sync.RWMutex {
-	lock := plm.GetProductLock(productID)
+func (plm *ProductLockManager) LockProductForWrite(productID string) *ProductLock {
+	lock := plm.getProductLock(productID)
+	lock.wait()
 	lock.Lock()
+	lock.mutex.Lock()
+	lock.waiters--
+	lock.writer = true
+	lock.since = time.Now()
+	lock.reported = false
+	lock.mutex.Unlock()
 	slog.Debug("Acquired write lock for product", "product_id", productID)
 	return lock
 }
 
 // LockProductForRead acquires a read lock for the specified product
-func (plm *ProductLockManager) LockProductForRead(productID string) *sync.RWMutex {
-	lock := plm.GetProductLock(productID)
+func (plm *ProductLockManager) LockProductForRead(productID string) *ProductLock {
+	lock := plm.getProductLock(productID)
+	lock.wait()
 	lock.RLock()
+	lock.mutex.Lock()
+	lock.waiters--
+	lock.readers++
+	if lock.readers == 1 {
+		lock.since = time.Now()
+		lock.reported = false
+	}
+	lock.mutex.Unlock()
 	slog.Debug("Acquired read lock for product", "product_id", productID)
 	return lock
 }
 
 // UnlockProductWrite releases a write lock for the specified product
-func (plm *ProductLockManager) UnlockProductWrite(productID string, lock *sync.RWMutex) {
+func (plm *ProductLockManager) UnlockProductWrite(productID string, lock *ProductLock) {
+	lock.mutex.Lock()
+	lock.writer = false
+	lock.since = time.Time{}
+	lock.mutex.Unlock()
 	lock.Unlock()
 	slog.Debug("Released write lock for product", "product_id", productID)
 }
 
 // UnlockProductRead releases a read lock for the specified product
-func (plm *ProductLockManager) UnlockProductRead(productID string, lock *sync.RWMutex) {
+func (plm *ProductLockManager) UnlockProductRead(productID string, lock *ProductLock) {
+	lock.mutex.Lock()
+	lock.readers--
+	if lock.readers == 0 {
+		lock.since = time.Time{}
+	}
+	lock.mutex.Unlock()
 	lock.RUnlock()
 	slog.Debug("Released read lock for product", "product_id", productID)
 }
 
+// wait counts the caller as a waiter until it holds the lock
+func (l *ProductLock) wait() {
+	l.mutex.Lock()
+	l.waiters++
+	l.mutex.Unlock()
+}
+
 // WithProductWriteLock executes a function while holding a write lock for the product
 func (plm *ProductLockManager) WithProductWriteLock(productID string, fn func()) {
 	start := time.Now()
 	lock := plm.LockProductForWrite(productID)
 	defer plm.UnlockProductWrite(productID, lock)
-	
+
 	fn()
-	
+
 	duration := time.Since(start)
-	slog.Debug("Product write operation completed", 
-		"product_id", productID, 
+	slog.Debug("Product write operation completed",
+		"product_id", productID,
 		"duration", duration.String())
 }
 
@@ -93,23 +151,131 @@ func (plm *ProductLockManager) WithProductReadLock(productID string, fn func())
 	start := time.Now()
 	lock := plm.LockProductForRead(productID)
 	defer plm.UnlockProductRead(productID, lock)
-	
+
 	fn()
-	
+
 	duration := time.Since(start)
-	slog.Debug("Product read operation completed", 
-		"product_id", productID, 
+	slog.Debug("Product read operation completed",
+		"product_id", productID,
 		"duration", duration.String())
 }
 
-// GetLockStats returns statistics about the lock manager
-func (plm *ProductLockManager) GetLockStats() map[string]interface{} {
+// GetLockStats returns how many product locks are held or awaited right now
+// and the longest current holds, longest first
+func (plm *ProductLockManager) GetLockStats() models.LockStats {
+	now := time.Now()
+	stats := models.LockStats{Holds: []models.LockHold{}, Stalls: plm.stalls.Load()}
+
 	plm.locksMux.RLock()
-	defer plm.locksMux.RUnlock()
-	
-	return map[string]interface{}{
-		"total_product_locks": len(plm.locks),
-		"lock_manager_type":   "fine_grained_per_product",
+	stats.TotalLocks = len(plm.locks)
+	for productID, lock := range plm.locks {
+		hold, held := lock.hold(productID, now)
+		stats.Waiters += hold.Waiters
+		if !held {
+			continue
+		}
+		stats.Held++
+		stats.Holds = append(stats.Holds, hold)
+	}
+	plm.locksMux.RUnlock()
+
+	sort.Slice(stats.Holds, func(i, j int) bool { return stats.Holds[i].HeldMs > stats.Holds[j].HeldMs })
+	if len(stats.Holds) > 0 {
+		stats.LongestHoldMs = stats.Holds[0].HeldMs
+	}
+	if len(stats.Holds) > maxReportedHolds {
+		stats.Holds = stats.Holds[:maxReportedHolds]
+	}
+	return stats
+}
+
+// hold describes the lock's current hold; held is false while it is free
+func (l *ProductLock) hold(productID string, now time.Time) (models.LockHold, bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	hold := models.LockHold{ProductID: productID, Waiters: l.waiters, Readers: l.readers}
+	if l.since.IsZero() {
+		return hold, false
+	}
+	hold.Mode = "read"
+	if l.writer {
+		hold.Mode = "write"
+	}
+	hold.HeldMs = now.Sub(l.since).Milliseconds()
+	return hold, true
+}
+
+// StartWatchdog checks the held locks every threshold/2 and logs, once per
+// hold, every lock held longer than threshold together with the stacks of
+// all goroutines, so the stuck holder can be found. The returned function
+// stops the watchdog.
+func (plm *ProductLockManager) StartWatchdog(threshold time.Duration) func() {
+	interval := threshold / 2
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				plm.checkHolds(threshold)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stop) }) }
+}
+
+// checkHolds logs the holds that passed threshold since the last check
+func (plm *ProductLockManager) checkHolds(threshold time.Duration) {
+	now := time.Now()
+	var stuck []models.LockHold
+
+	plm.locksMux.RLock()
+	for productID, lock := range plm.locks {
+		lock.mutex.Lock()
+		if !lock.since.IsZero() && !lock.reported && now.Sub(lock.since) >= threshold {
+			lock.reported = true
+			lock.mutex.Unlock()
+			hold, _ := lock.hold(productID, now)
+			stuck = append(stuck, hold)
+			continue
+		}
+		lock.mutex.Unlock()
+	}
+	plm.locksMux.RUnlock()
+
+	if len(stuck) == 0 {
+		return
+	}
+	plm.stalls.Add(int64(len(stuck)))
+
+	// One dump serves every lock found stuck in this pass
+	stacks := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(stacks, true)
+		if n < len(stacks) || len(stacks) >= maxStackDumpBytes {
+			stacks = stacks[:n]
+			break
+		}
+		stacks = make([]byte, 2*len(stacks))
+	}
+	for _, hold := range stuck {
+		slog.Error("Product lock held past the watchdog threshold",
+			"product_id", hold.ProductID,
+			"mode", hold.Mode,
+			"readers", hold.Readers,
+			"held_ms", hold.HeldMs,
+			"waiters", hold.Waiters,
+			"threshold", threshold.String(),
+			"goroutines", string(stacks))
 	}
 }
 
@@ -118,7 +284,7 @@ func (plm *ProductLockManager) GetLockStats() map[string]interface{} {
 func (plm *ProductLockManager) CleanupUnusedLocks(activeProductIDs map[string]bool) {
 	plm.locksMux.Lock()
 	defer plm.locksMux.Unlock()
-	
+
 	removedCount := 0
 	for productID := range plm.locks {
 		if !activeProductIDs[productID] {
@@ -126,9 +292,9 @@ func (plm *ProductLockManager) CleanupUnusedLocks(activeProductIDs map[string]bo
 			removedCount++
 		}
 	}
-	
+
 	if removedCount > 0 {
-		slog.Info("Cleaned up unused product locks", 
+		slog.Info("Cleaned up unused product locks",
 			"removed_locks", removedCount,
 			"remaining_locks", len(plm.locks))
 	}
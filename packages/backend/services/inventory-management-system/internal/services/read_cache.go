@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"sync/atomic"
+
+	"inventory-management-api/internal/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// readCacheVerifyEvery is how often a cache hit is re-read under the locks to
+// measure how many versions the cache lags behind the store
+const readCacheVerifyEvery = 100
+
+// productReadCache serves product reads without taking product or global
+// locks. Writers refresh entries while holding the global write lock and
+// misses fill them under the global read lock, so an entry is never older
+// than the last completed write. A restore swaps in an empty map.
+type productReadCache struct {
+	entries  atomic.Pointer[sync.Map] // Product ID -> models.ProductResponse
+	size     atomic.Int64
+	hits     atomic.Int64
+	misses   atomic.Int64
+	verified atomic.Int64
+	stale    atomic.Int64
+	maxLag   atomic.Int64 // Largest version lag seen by verification
+}
+
+func newProductReadCache() *productReadCache {
+	c := &productReadCache{}
+	c.entries.Store(&sync.Map{})
+	return c
+}
+
+// get returns the cached product and counts the hit or miss
+func (c *productReadCache) get(productID string) (models.ProductResponse, bool) {
+	if value, ok := c.entries.Load().Load(productID); ok {
+		c.hits.Add(1)
+		return value.(models.ProductResponse), true
+	}
+	c.misses.Add(1)
+	return models.ProductResponse{}, false
+}
+
+// fill adds a product read on a miss unless a writer stored a newer one first.
+// Callers hold the global read lock.
+func (c *productReadCache) fill(product models.ProductResponse) {
+	if _, loaded := c.entries.Load().LoadOrStore(product.ProductID, product); !loaded {
+		c.size.Add(1)
+	}
+}
+
+// put replaces a product after a write or a stale verification. Callers hold
+// the global mutex, for writing unless they also hold the product lock.
+func (c *productReadCache) put(product models.ProductResponse) {
+	if _, loaded := c.entries.Load().Swap(product.ProductID, product); !loaded {
+		c.size.Add(1)
+	}
+}
+
+// remove drops a deleted product. Callers hold the global mutex, as for put.
+func (c *productReadCache) remove(productID string) {
+	if _, loaded := c.entries.Load().LoadAndDelete(productID); loaded {
+		c.size.Add(-1)
+	}
+}
+
+// reset empties the cache after the whole inventory was replaced. Callers
+// hold the global write lock.
+func (c *productReadCache) reset() {
+	c.entries.Store(&sync.Map{})
+	c.size.Store(0)
+}
+
+// shouldVerify samples one hit in readCacheVerifyEvery
+func (c *productReadCache) shouldVerify() bool {
+	return c.hits.Load()%readCacheVerifyEvery == 0
+}
+
+// recordVerification counts a verified hit and how many versions it lagged
+func (c *productReadCache) recordVerification(lag int64) {
+	c.verified.Add(1)
+	if lag == 0 {
+		return
+	}
+	c.stale.Add(1)
+	for {
+		current := c.maxLag.Load()
+		if lag <= current || c.maxLag.CompareAndSwap(current, lag) {
+			return
+		}
+	}
+}
+
+// readProduct returns a product for the read paths. With the read cache
+// enabled a hit takes no locks; otherwise, and on a miss, the product is read
+// under its product read lock. IDs recently found missing are answered from
+// the not-found cache without locks.
+func (s *InventoryService) readProduct(productID string) (models.ProductResponse, bool) {
+	if s.readCache != nil {
+		if product, ok := s.readCache.get(productID); ok {
+			if s.readCache.shouldVerify() {
+				s.verifyCachedProduct(product)
+			}
+			return product, true
+		}
+	}
+	if s.notFoundCache != nil && s.notFoundCache.contains(productID) {
+		return models.ProductResponse{}, false
+	}
+
+	var product models.ProductResponse
+	var exists bool
+	s.productLockManager.WithProductReadLock(productID, func() {
+		s.globalMutex.RLock()
+		defer s.globalMutex.RUnlock()
+
+		productData, found := s.data.Products[productID]
+		if !found {
+			if s.notFoundCache != nil {
+				s.notFoundCache.add(productID)
+			}
+			return
+		}
+		product, exists = toProductResponse(productData), true
+		if s.readCache != nil {
+			s.readCache.fill(product)
+		}
+	})
+	return product, exists
+}
+
+// verifyCachedProduct compares a cached product with the store, records the
+// version lag and repairs the entry when it is stale
+func (s *InventoryService) verifyCachedProduct(cached models.ProductResponse) {
+	s.productLockManager.WithProductReadLock(cached.ProductID, func() {
+		s.globalMutex.RLock()
+		defer s.globalMutex.RUnlock()
+
+		productData, exists := s.data.Products[cached.ProductID]
+		if !exists {
+			s.readCache.recordVerification(1)
+			s.readCache.remove(cached.ProductID)
+			return
+		}
+		lag := int64(productData.Version - cached.Version)
+		if lag < 0 {
+			lag = -lag // A restore can move versions back
+		}
+		s.readCache.recordVerification(lag)
+		if lag > 0 {
+			s.readCache.put(toProductResponse(productData))
+		}
+	})
+}
+
+// ReadCacheStats returns the read cache counters; enabled is false when the
+// cache is off
+func (s *InventoryService) ReadCacheStats() map[string]interface{} {
+	if s.readCache == nil {
+		return map[string]interface{}{"enabled": false}
+	}
+	hits, misses := s.readCache.hits.Load(), s.readCache.misses.Load()
+	hitRate := 0.0
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	return map[string]interface{}{
+		"enabled":         true,
+		"entries":         s.readCache.size.Load(),
+		"hits":            hits,
+		"misses":          misses,
+		"hit_rate":        hitRate,
+		"verified_reads":  s.readCache.verified.Load(),
+		"stale_reads":     s.readCache.stale.Load(),
+		"max_version_lag": s.readCache.maxLag.Load(),
+		"verify_one_in":   readCacheVerifyEvery,
+	}
+}
+
+// registerReadCacheMetrics exposes hit rate and staleness of the read cache
+func (s *InventoryService) registerReadCacheMetrics(meter metric.Meter) error {
+	if s.readCache == nil {
+		return nil
+	}
+
+	requests, err := meter.Int64ObservableCounter(
+		"inventory_read_cache_requests_total",
+		metric.WithDescription("Product reads served by the read cache, by result (hit or miss)"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create read cache requests counter: %w", err)
+	}
+
+	entries, err := meter.Int64ObservableGauge(
+		"inventory_read_cache_entries",
+		metric.WithDescription("Products held in the read cache"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create read cache entries gauge: %w", err)
+	}
+
+	stale, err := meter.Int64ObservableCounter(
+		"inventory_read_cache_stale_reads_total",
+		metric.WithDescription("Sampled cache hits whose version differed from the store"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create read cache stale reads counter: %w", err)
+	}
+
+	maxLag, err := meter.Int64ObservableGauge(
+		"inventory_read_cache_max_version_lag",
+		metric.WithDescription("Largest number of versions a sampled cache hit lagged behind the store"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create read cache version lag gauge: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		observer.ObserveInt64(requests, s.readCache.hits.Load(), metric.WithAttributes(attribute.String("result", "hit")))
+		observer.ObserveInt64(requests, s.readCache.misses.Load(), metric.WithAttributes(attribute.String("result", "miss")))
+		observer.ObserveInt64(entries, s.readCache.size.Load())
+		observer.ObserveInt64(stale, s.readCache.stale.Load())
+		observer.ObserveInt64(maxLag, s.readCache.maxLag.Load())
+		return nil
+	}, requests, entries, stale, maxLag)
+	if err != nil {
+		return fmt.Errorf("failed to register read cache callback: %w", err)
+	}
+	return nil
+}
+
+// fromProductResponse converts a product's API representation, e.g. from an
+// event or a snapshot, to stored product data
+func fromProductResponse(product models.ProductResponse) ProductData {
+	return ProductData{
+		ProductID:      product.ProductID,
+		Name:           product.Name,
+		Available:      product.Available,
+		Version:        product.Version,
+		LastUpdated:    product.LastUpdated,
+		Price:          product.Price,
+		Prices:         append([]models.Money(nil), product.Prices...),
+		Category:       product.Category,
+		Barcode:        product.Barcode,
+		BarcodeAliases: append([]string(nil), product.BarcodeAliases...),
+		Assets:         append([]models.ProductAsset(nil), product.Assets...),
+		StockPolicy:    product.StockPolicy,
+		Allocations:    maps.Clone(product.Allocations),
+	}
+}
+
+// toProductResponse converts stored product data to its API representation
+func toProductResponse(productData ProductData) models.ProductResponse {
+	return models.ProductResponse{
+		ProductID:      productData.ProductID,
+		Name:           productData.Name,
+		Available:      productData.Available,
+		Version:        productData.Version,
+		LastUpdated:    productData.LastUpdated,
+		Price:          productData.Price,
+		Prices:         productData.Prices,
+		Category:       productData.Category,
+		Barcode:        productData.Barcode,
+		BarcodeAliases: productData.BarcodeAliases,
+		Assets:         productData.Assets,
+		StockPolicy:    productData.StockPolicy,
+		Allocations:    productData.Allocations,
+	}
+}
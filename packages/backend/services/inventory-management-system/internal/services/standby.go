@@ -0,0 +1,107 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// EnterStandby makes the service a follower of another instance sharing its
+// data file: the file is never saved and the outbox is not reconciled, since
+// the leader owns both. Call before SetEventQueue; ReloadData then keeps the
+// products current and Promote ends the standby.
+func (s *InventoryService) EnterStandby() {
+	s.standby.Store(true)
+	slog.Info("Inventory service in standby, serving reads from the leader's data file",
+		"path", s.dataFilePath)
+}
+
+// IsStandby reports whether the service is following another instance
+func (s *InventoryService) IsStandby() bool {
+	return s.standby.Load()
+}
+
+// ReloadData replaces the products with the data file's when it changed since
+// the last reload, and reports whether it did. Reads see either the old or the
+// new data, never a mix.
+func (s *InventoryService) ReloadData() (bool, error) {
+	s.standbyMutex.Lock()
+	defer s.standbyMutex.Unlock()
+	return s.reloadDataLocked()
+}
+
+// reloadDataLocked does ReloadData; the caller holds standbyMutex
+func (s *InventoryService) reloadDataLocked() (bool, error) {
+	info, err := os.Stat(s.dataFilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat data file: %w", err)
+	}
+	if info.ModTime().Equal(s.dataFileModTime) && info.Size() == s.dataFileSize {
+		return false, nil
+	}
+
+	raw, err := os.ReadFile(s.dataFilePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read data file: %w", err)
+	}
+	data := &InventoryData{}
+	if err := json.Unmarshal(raw, data); err != nil {
+		return false, fmt.Errorf("failed to parse data file: %w", err)
+	}
+	normalizeTimestamps(data)
+	index := newUpdatedIndex()
+	for productID, productData := range data.Products {
+		index.record(productID, parseUpdatedAt(productData.LastUpdated), false)
+	}
+
+	s.outboxMutex.Lock()
+	s.globalMutex.Lock()
+	s.data = data
+	s.updatedIndex = index
+	if s.readCache != nil {
+		s.readCache.reset()
+	}
+	if s.notFoundCache != nil {
+		s.notFoundCache.reset()
+	}
+	s.globalMutex.Unlock()
+	s.outboxMutex.Unlock()
+
+	s.dataFileModTime = info.ModTime()
+	s.dataFileSize = info.Size()
+	slog.Debug("Reloaded inventory data from the leader's data file",
+		"products_count", len(data.Products),
+		"last_offset", data.Metadata.LastOffset)
+	return true, nil
+}
+
+// Promote ends the standby once this instance became the leader: the data
+// file is read one last time, reconciled with the events file as on startup,
+// and from then on saved by this instance. The event queue must be reloaded
+// and out of standby first.
+func (s *InventoryService) Promote() error {
+	s.standbyMutex.Lock()
+	defer s.standbyMutex.Unlock()
+
+	if !s.standby.Load() {
+		return nil
+	}
+	if _, err := s.reloadDataLocked(); err != nil {
+		return err
+	}
+	s.standby.Store(false)
+
+	if s.eventQueue != nil {
+		s.syncEventQueue()
+	}
+
+	s.globalMutex.RLock()
+	productsCount := len(s.data.Products)
+	lastOffset := s.data.Metadata.LastOffset
+	s.globalMutex.RUnlock()
+	slog.Info("Inventory service promoted to leader",
+		"products_count", productsCount,
+		"last_offset", lastOffset)
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+
+	"inventory-management-api/internal/models"
+)
+
+// stockPolicyFor returns the policy governing a product: its own, or else the
+// policy of its category or the nearest ancestor category with one. Products
+// without any get the zero policy, which only forbids negative stock.
+func (s *InventoryService) stockPolicyFor(product ProductData) models.StockPolicy {
+	if product.StockPolicy != nil {
+		return *product.StockPolicy
+	}
+
+	s.globalMutex.RLock()
+	defer s.globalMutex.RUnlock()
+
+	// The depth bound guards against a cycle in a hand-edited data file
+	categoryID := normalizeCategory(product.Category)
+	for depth := 0; categoryID != "" && depth <= len(s.data.Categories); depth++ {
+		category, exists := s.data.Categories[categoryID]
+		if !exists {
+			break
+		}
+		if category.StockPolicy != nil {
+			return *category.StockPolicy
+		}
+		categoryID = category.ParentID
+	}
+	return models.StockPolicy{}
+}
+
+// stockPolicyViolation returns the failed result for an update whose delta
+// breaks the policy, or nil when the update may be applied. Sales may take
+// the stock below zero only with allowBackorder, and restocks may not exceed
+// maxStock. Stock already outside the bounds, e.g. after the policy changed,
+// can always move back towards them.
+func stockPolicyViolation(product ProductData, delta int, policy models.StockPolicy) *UpdateResult {
+	newQuantity := product.Available + delta
+
+	var errorType, message string
+	switch {
+	case delta < 0 && newQuantity < 0 && !policy.AllowBackorder:
+		errorType = ErrTypeInsufficientInventory
+		message = fmt.Sprintf("insufficient inventory: current %d, delta %d", product.Available, delta)
+	case delta < 0 && newQuantity < -policy.MaxBackorder:
+		errorType = ErrTypeBackorderLimit
+		message = fmt.Sprintf("backorder limit exceeded: current %d, delta %d, maxBackorder %d", product.Available, delta, policy.MaxBackorder)
+	case delta > 0 && policy.MaxStock != nil && newQuantity > *policy.MaxStock:
+		errorType = ErrTypeMaxStockExceeded
+		message = fmt.Sprintf("max stock exceeded: current %d, delta %d, maxStock %d", product.Available, delta, *policy.MaxStock)
+	default:
+		return nil
+	}
+
+	return &UpdateResult{
+		Success:      false,
+		ErrorMessage: message,
+		ErrorType:    errorType,
+		Applied:      false,
+		NewQuantity:  product.Available, // Return current quantity
+		NewVersion:   product.Version,   // Return current version
+		LastUpdated:  product.LastUpdated,
+	}
+}
+
+// belowMinStock reports whether available is under the policy's minStock
+func belowMinStock(available int, policy models.StockPolicy) bool {
+	return policy.MinStock != nil && available < *policy.MinStock
+}
+
+// ownStockPolicy returns the policy to store from a request: nil for none or
+// an empty one, otherwise a copy not shared with the request
+func ownStockPolicy(policy *models.StockPolicy) *models.StockPolicy {
+	if policy == nil || policy.IsZero() {
+		return nil
+	}
+	owned := *policy
+	if policy.MinStock != nil {
+		minStock := *policy.MinStock
+		owned.MinStock = &minStock
+	}
+	if policy.MaxStock != nil {
+		maxStock := *policy.MaxStock
+		owned.MaxStock = &maxStock
+	}
+	return &owned
+}
+
+// logBelowMinStock records a product falling under its minimum for alerting
+func logBelowMinStock(productID, storeID string, available int, policy models.StockPolicy) {
+	slog.Warn("Stock below minimum",
+		"product_id", productID,
+		"store_id", storeID,
+		"available", available,
+		"min_stock", *policy.MinStock)
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/idempotency"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/transfers"
+)
+
+// transferIdempotencyPrefix keeps transfer keys apart from update keys in the
+// shared idempotency cache
+const transferIdempotencyPrefix = "transfer:"
+
+// TransferError is returned when a transfer is rejected; Type is one of the
+// ErrType constants. Conflicts carry the product's current version and stock.
+type TransferError struct {
+	Type           string
+	Message        string
+	CurrentVersion int
+	Available      int
+	Replayed       bool  // Answered from the idempotency cache
+	Err            error // Sentinel cause, such as ErrProductNotFound, if any
+}
+
+func (e *TransferError) Error() string {
+	return e.Message
+}
+
+func (e *TransferError) Unwrap() error {
+	return e.Err
+}
+
+// SetTransferLog replaces the in-memory transfer history, e.g. with one saved to a file
+func (s *InventoryService) SetTransferLog(log *transfers.Store) {
+	s.transferLog = log
+}
+
+// TransferStock moves req.Quantity units of a product from one store's
+// allocation to another's in one step: the source store must hold that many
+// allocated units, the product's stock is unchanged, its version advances
+// once and a single stock_transferred event is published. Replays with the
+// same idempotency key return the first outcome.
+func (s *InventoryService) TransferStock(ctx context.Context, req models.TransferRequest) (*models.StockTransfer, error) {
+	if s.IsDraining() {
+		return nil, ErrServiceDraining
+	}
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	cacheKey := transferIdempotencyPrefix + idempotency.Scoped(req.FromStoreID, req.IdempotencyKey)
+	if cached, exists := s.idempotencyCache.Get(cacheKey); exists {
+		slog.InfoContext(ctx, "Idempotent transfer detected, returning cached result",
+			"idempotency_key", req.IdempotencyKey,
+			"product_id", req.ProductID)
+		// Copies, so the cached outcome is never marked as replayed
+		switch cached := cached.(type) {
+		case *models.StockTransfer:
+			replayed := *cached
+			replayed.Replayed = true
+			return &replayed, nil
+		case *TransferError:
+			replayed := *cached
+			replayed.Replayed = true
+			return nil, &replayed
+		}
+	}
+
+	if req.FromStoreID == req.ToStoreID {
+		transferErr := &TransferError{Type: ErrTypeInvalidRequest, Message: "source and destination stores must differ"}
+		s.idempotencyCache.Set(cacheKey, transferErr)
+		return nil, transferErr
+	}
+
+	var transfer *models.StockTransfer
+	var transferErr *TransferError
+
+	s.productLockManager.WithProductWriteLock(req.ProductID, func() {
+		productData, exists := s.lookupProduct(req.ProductID)
+		if !exists {
+			err := fmt.Errorf("%w: %s", ErrProductNotFound, req.ProductID)
+			transferErr = &TransferError{
+				Type:    ErrTypeProductNotFound,
+				Message: err.Error(),
+				Err:     err,
+			}
+			return
+		}
+
+		if req.Version != 0 && productData.Version != req.Version {
+			transferErr = &TransferError{
+				Type:           ErrTypeVersionConflict,
+				Message:        fmt.Sprintf("version conflict: expected %d, got %d", productData.Version, req.Version),
+				CurrentVersion: productData.Version,
+				Available:      productData.Available,
+			}
+			return
+		}
+
+		if allocated := productData.Allocations[req.FromStoreID]; req.Quantity > allocated {
+			transferErr = &TransferError{
+				Type: ErrTypeInsufficientInventory,
+				Message: fmt.Sprintf("insufficient allocation: store %s has %d units allocated, transfer %d",
+					req.FromStoreID, allocated, req.Quantity),
+				CurrentVersion: productData.Version,
+				Available:      productData.Available,
+			}
+			return
+		}
+
+		moveAllocation(&productData, req.FromStoreID, req.ToStoreID, req.Quantity)
+		productData.Version++
+		productData.LastUpdated = clock.Timestamp()
+
+		transfer = &models.StockTransfer{
+			ProductID:      req.ProductID,
+			FromStoreID:    req.FromStoreID,
+			ToStoreID:      req.ToStoreID,
+			Quantity:       req.Quantity,
+			NewVersion:     productData.Version,
+			Available:      productData.Available,
+			FromAllocation: productData.Allocations[req.FromStoreID],
+			ToAllocation:   productData.Allocations[req.ToStoreID],
+			IdempotencyKey: req.IdempotencyKey,
+			CreatedAt:      productData.LastUpdated,
+		}
+		// Stored with its event before the lock is released, so the transfer
+		// record carries its offset and the product's events stay in order
+		transfer.EventOffset = s.commitProduct(productData, events.TransferEvent(req.ProductID, toProductResponse(productData),
+			productData.Version, req.FromStoreID, req.ToStoreID, req.Quantity))
+	})
+
+	if transferErr != nil {
+		s.idempotencyCache.Set(cacheKey, transferErr)
+		slog.WarnContext(ctx, "Stock transfer rejected",
+			"product_id", req.ProductID,
+			"from_store_id", req.FromStoreID,
+			"to_store_id", req.ToStoreID,
+			"quantity", req.Quantity,
+			"error_type", transferErr.Type,
+			"error", transferErr.Message)
+		return nil, transferErr
+	}
+
+	s.persister.MarkDirty()
+
+	recorded, err := s.transferLog.Append(*transfer)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save transfer history", "transfer_id", recorded.TransferID, "error", err)
+	}
+	transfer = &recorded
+	s.idempotencyCache.Set(cacheKey, transfer)
+
+	slog.InfoContext(ctx, "Stock transferred between stores",
+		"transfer_id", transfer.TransferID,
+		"product_id", req.ProductID,
+		"from_store_id", req.FromStoreID,
+		"to_store_id", req.ToStoreID,
+		"quantity", req.Quantity,
+		"new_version", transfer.NewVersion,
+		"event_offset", transfer.EventOffset)
+
+	return transfer, nil
+}
+
+// ListTransfers returns up to limit recorded transfers, newest first,
+// optionally narrowed to a product and to transfers into or out of a store
+func (s *InventoryService) ListTransfers(productID, storeID string, limit int) []models.StockTransfer {
+	return s.transferLog.List(productID, storeID, limit)
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/stocktakes"
+)
+
+// ErrStocktakeNotOpen is returned when counting, closing or cancelling a
+// stocktake that already closed or was cancelled
+var ErrStocktakeNotOpen = errors.New("stocktake is not open")
+
+// StocktakeValidationError lists the request fields a stocktake call rejected
+type StocktakeValidationError struct {
+	Details []models.ErrorDetail
+}
+
+func (e *StocktakeValidationError) Error() string {
+	return fmt.Sprintf("invalid stocktake request: %d issues", len(e.Details))
+}
+
+// SetStocktakes replaces the in-memory stocktake sessions, e.g. with ones saved to a file
+func (s *InventoryService) SetStocktakes(store *stocktakes.Store) {
+	s.stocktakes = store
+}
+
+// StartStocktake opens a cycle count of the products in req's category, or of
+// every product. Until it closes, decrements of those products sent by
+// req.StoreID (or by any store without one) are rejected or held.
+func (s *InventoryService) StartStocktake(req models.StocktakeStartRequest, actor string) (models.StocktakeSession, error) {
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return models.StocktakeSession{}, err
+	}
+	defer release()
+
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = models.StocktakeModeReject
+	}
+	if mode != models.StocktakeModeReject && mode != models.StocktakeModeQueue {
+		return models.StocktakeSession{}, &StocktakeValidationError{Details: []models.ErrorDetail{
+			{Field: "mode", Issue: "must be reject or queue"},
+		}}
+	}
+
+	products, _ := s.SnapshotProducts()
+	productIDs := make([]string, 0, len(products))
+	for _, product := range products {
+		if req.Category == "" || strings.EqualFold(product.Category, req.Category) {
+			productIDs = append(productIDs, product.ProductID)
+		}
+	}
+	if len(productIDs) == 0 {
+		return models.StocktakeSession{}, &StocktakeValidationError{Details: []models.ErrorDetail{
+			{Field: "category", Issue: "no products to count"},
+		}}
+	}
+
+	session, err := s.stocktakes.Open(models.StocktakeSession{
+		StoreID:    req.StoreID,
+		Category:   req.Category,
+		Mode:       mode,
+		Reason:     strings.TrimSpace(req.Reason),
+		ProductIDs: productIDs,
+		Lines:      []models.StocktakeLine{},
+		OpenedBy:   actor,
+		OpenedAt:   clock.Timestamp(),
+	})
+	if errors.Is(err, stocktakes.ErrOverlap) {
+		return models.StocktakeSession{}, err
+	}
+	if err != nil {
+		slog.Error("Failed to save stocktakes", "stocktake_id", session.ID, "error", err)
+	}
+
+	slog.Info("Stocktake started",
+		"stocktake_id", session.ID,
+		"store_id", session.StoreID,
+		"category", session.Category,
+		"mode", session.Mode,
+		"products", len(session.ProductIDs),
+		"actor", actor)
+	return session, nil
+}
+
+// RecordStocktakeCounts records counted quantities against the system stock
+// at the moment of the count. A product counted again replaces its count.
+func (s *InventoryService) RecordStocktakeCounts(id string, counts []models.StocktakeCount) (models.StocktakeReport, error) {
+	s.stocktakeMutex.Lock()
+	defer s.stocktakeMutex.Unlock()
+
+	session, err := s.openStocktake(id)
+	if err != nil {
+		return models.StocktakeReport{}, err
+	}
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return models.StocktakeReport{}, err
+	}
+	defer release()
+
+	inScope := make(map[string]bool, len(session.ProductIDs))
+	for _, productID := range session.ProductIDs {
+		inScope[productID] = true
+	}
+	var details []models.ErrorDetail
+	for i, count := range counts {
+		if !inScope[count.ProductID] {
+			details = append(details, models.ErrorDetail{
+				Field: fmt.Sprintf("counts[%d].productId", i),
+				Issue: fmt.Sprintf("%s is not part of this stocktake", count.ProductID),
+			})
+		}
+	}
+	if len(details) > 0 {
+		return models.StocktakeReport{}, &StocktakeValidationError{Details: details}
+	}
+
+	now := clock.Timestamp()
+	lines := make(map[string]models.StocktakeLine, len(counts))
+	for _, count := range counts {
+		systemQuantity := 0
+		s.productLockManager.WithProductReadLock(count.ProductID, func() {
+			if productData, exists := s.lookupProduct(count.ProductID); exists {
+				systemQuantity = productData.Available
+			}
+		})
+		lines[count.ProductID] = models.StocktakeLine{
+			ProductID:      count.ProductID,
+			SystemQuantity: systemQuantity,
+			Counted:        count.Counted,
+			Variance:       count.Counted - systemQuantity,
+			CountedAt:      now,
+		}
+	}
+
+	session, err = s.stocktakes.Update(id, func(session *models.StocktakeSession) error {
+		kept := session.Lines[:0]
+		for _, line := range session.Lines {
+			if _, recounted := lines[line.ProductID]; !recounted {
+				kept = append(kept, line)
+			}
+		}
+		for _, count := range counts {
+			if line, pending := lines[count.ProductID]; pending {
+				kept = append(kept, line)
+				delete(lines, count.ProductID)
+			}
+		}
+		session.Lines = kept
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to save stocktakes", "stocktake_id", id, "error", err)
+	}
+	return buildStocktakeReport(session), nil
+}
+
+// CloseStocktake applies every counted variance as an audited adjustment and
+// lifts the freeze. The variance is added to the current stock, so changes
+// allowed during the count, like restocks, are kept.
+func (s *InventoryService) CloseStocktake(ctx context.Context, id, actor string) (models.StocktakeReport, error) {
+	s.stocktakeMutex.Lock()
+	defer s.stocktakeMutex.Unlock()
+
+	session, err := s.openStocktake(id)
+	if err != nil {
+		return models.StocktakeReport{}, err
+	}
+	release, err := s.beginAdminWrite()
+	if err != nil {
+		return models.StocktakeReport{}, err
+	}
+	defer release()
+
+	var adjustments []models.StocktakeAdjustment
+	for _, line := range session.Lines {
+		if line.Variance == 0 {
+			continue
+		}
+		if adjustment, ok := s.applyStocktakeAdjustment(ctx, session, line); ok {
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	if len(adjustments) > 0 {
+		s.persister.MarkDirty()
+	}
+
+	session, err = s.stocktakes.Update(id, func(session *models.StocktakeSession) error {
+		session.Status = models.StocktakeStatusClosed
+		session.Adjustments = adjustments
+		session.ClosedBy = actor
+		session.ClosedAt = clock.Timestamp()
+		return nil
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to save stocktakes", "stocktake_id", id, "error", err)
+	}
+
+	report := buildStocktakeReport(session)
+	slog.InfoContext(ctx, "Stocktake closed",
+		"stocktake_id", id,
+		"counted", len(session.Lines),
+		"uncounted", len(report.Uncounted),
+		"adjustments", len(adjustments),
+		"net_variance", report.NetVariance,
+		"actor", actor)
+	return report, nil
+}
+
+// applyStocktakeAdjustment adds one line's variance to the product's stock and
+// publishes it as a product_updated event with reason "stocktake"
+func (s *InventoryService) applyStocktakeAdjustment(ctx context.Context, session models.StocktakeSession, line models.StocktakeLine) (models.StocktakeAdjustment, bool) {
+	adjustment := models.StocktakeAdjustment{ProductID: line.ProductID, Variance: line.Variance}
+	applied := false
+
+	s.productLockManager.WithProductWriteLock(line.ProductID, func() {
+		productData, exists := s.lookupProduct(line.ProductID)
+		if !exists {
+			return
+		}
+		adjustment.OldQuantity = productData.Available
+		adjustment.NewQuantity = productData.Available + line.Variance
+		if adjustment.NewQuantity < 0 {
+			adjustment.NewQuantity = 0
+		}
+
+		productData.Available = adjustment.NewQuantity
+		productData.Version++
+		productData.LastUpdated = clock.Timestamp()
+
+		adjustment.NewVersion = productData.Version
+		adjustment.EventOffset = s.commitProduct(productData, events.StoreUpdateEvent(line.ProductID, toProductResponse(productData),
+			productData.Version, session.StoreID, adjustment.NewQuantity-adjustment.OldQuantity, models.UpdateReasonStocktake))
+		applied = true
+	})
+	if !applied {
+		slog.WarnContext(ctx, "Skipping stocktake adjustment for a deleted product",
+			"stocktake_id", session.ID,
+			"product_id", line.ProductID)
+		return adjustment, false
+	}
+
+	slog.InfoContext(ctx, "Stocktake adjustment applied",
+		"stocktake_id", session.ID,
+		"product_id", line.ProductID,
+		"variance", line.Variance,
+		"old_quantity", adjustment.OldQuantity,
+		"new_quantity", adjustment.NewQuantity,
+		"new_version", adjustment.NewVersion)
+	return adjustment, true
+}
+
+// CancelStocktake lifts the freeze without applying any count
+func (s *InventoryService) CancelStocktake(id, actor string) (models.StocktakeReport, error) {
+	s.stocktakeMutex.Lock()
+	defer s.stocktakeMutex.Unlock()
+
+	if _, err := s.openStocktake(id); err != nil {
+		return models.StocktakeReport{}, err
+	}
+	session, err := s.stocktakes.Update(id, func(session *models.StocktakeSession) error {
+		session.Status = models.StocktakeStatusCancelled
+		session.ClosedBy = actor
+		session.ClosedAt = clock.Timestamp()
+		return nil
+	})
+	if err != nil {
+		slog.Error("Failed to save stocktakes", "stocktake_id", id, "error", err)
+	}
+
+	slog.Info("Stocktake cancelled", "stocktake_id", id, "actor", actor)
+	return buildStocktakeReport(session), nil
+}
+
+// GetStocktake returns a session with its variance report
+func (s *InventoryService) GetStocktake(id string) (models.StocktakeReport, bool) {
+	session, ok := s.stocktakes.Get(id)
+	if !ok {
+		return models.StocktakeReport{}, false
+	}
+	return buildStocktakeReport(session), true
+}
+
+// ListStocktakes returns every kept session, newest first
+func (s *InventoryService) ListStocktakes() []models.StocktakeSession {
+	return s.stocktakes.List()
+}
+
+// openStocktake returns the session if it exists and is still open
+func (s *InventoryService) openStocktake(id string) (models.StocktakeSession, error) {
+	session, ok := s.stocktakes.Get(id)
+	if !ok {
+		return models.StocktakeSession{}, stocktakes.ErrNotFound
+	}
+	if session.Status != models.StocktakeStatusOpen {
+		return models.StocktakeSession{}, ErrStocktakeNotOpen
+	}
+	return session, nil
+}
+
+// stocktakeFreeze returns the result for a decrement of a product an open
+// stocktake holds, or nil when the decrement may proceed
+func (s *InventoryService) stocktakeFreeze(productID, storeID string, productData ProductData) *UpdateResult {
+	sessionID, mode, frozen := s.stocktakes.Frozen(productID, storeID)
+	if !frozen {
+		return nil
+	}
+
+	errorType := ErrTypeStocktakeFrozen
+	if mode == models.StocktakeModeQueue {
+		errorType = ErrTypeStocktakeHold
+	}
+	slog.Info("Decrement blocked by stocktake",
+		"product_id", productID,
+		"store_id", storeID,
+		"stocktake_id", sessionID,
+		"mode", mode)
+	return &UpdateResult{
+		Success:      false,
+		ErrorType:    errorType,
+		ErrorMessage: fmt.Sprintf("product %s is being counted by stocktake %s", productID, sessionID),
+		NewQuantity:  productData.Available,
+		NewVersion:   productData.Version,
+		LastUpdated:  productData.LastUpdated,
+	}
+}
+
+// buildStocktakeReport summarizes a session's variances
+func buildStocktakeReport(session models.StocktakeSession) models.StocktakeReport {
+	report := models.StocktakeReport{StocktakeSession: session, Uncounted: []string{}}
+	counted := make(map[string]bool, len(session.Lines))
+	for _, line := range session.Lines {
+		counted[line.ProductID] = true
+		report.NetVariance += line.Variance
+		if line.Variance > 0 {
+			report.UnitsOver += line.Variance
+		} else {
+			report.UnitsShort -= line.Variance
+		}
+	}
+	for _, productID := range session.ProductIDs {
+		if !counted[productID] {
+			report.Uncounted = append(report.Uncounted, productID)
+		}
+	}
+	return report
+}
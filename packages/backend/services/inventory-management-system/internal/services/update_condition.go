@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"fmt"
+)
+
+// UpdateCondition makes an update compare-and-set on the product's stock. It
+// is checked under the product write lock, after the version, so the stock it
+// saw is the stock the delta applies to. An update with a condition may leave
+// its version at 0 to skip the version check.
+type UpdateCondition struct {
+	MinAvailable      *int // Apply only while available >= MinAvailable
+	ExpectedAvailable *int // Apply only while available == ExpectedAvailable
+}
+
+// IsSet reports whether the condition constrains anything
+func (c UpdateCondition) IsSet() bool {
+	return c.MinAvailable != nil || c.ExpectedAvailable != nil
+}
+
+// failure returns why available does not meet the condition, or "" when it does
+func (c UpdateCondition) failure(available int) string {
+	if c.ExpectedAvailable != nil && available != *c.ExpectedAvailable {
+		return fmt.Sprintf("condition failed: expected available %d, got %d", *c.ExpectedAvailable, available)
+	}
+	if c.MinAvailable != nil && available < *c.MinAvailable {
+		return fmt.Sprintf("condition failed: available %d is below minAvailable %d", available, *c.MinAvailable)
+	}
+	return ""
+}
+
+type conditionKey struct{}
+
+// WithUpdateCondition returns a context whose updates apply only while the
+// product's stock meets condition
+func WithUpdateCondition(ctx context.Context, condition UpdateCondition) context.Context {
+	return context.WithValue(ctx, conditionKey{}, condition)
+}
+
+// UpdateConditionFromContext returns the condition set on ctx, if any
+func UpdateConditionFromContext(ctx context.Context) UpdateCondition {
+	condition, _ := ctx.Value(conditionKey{}).(UpdateCondition)
+	return condition
+}
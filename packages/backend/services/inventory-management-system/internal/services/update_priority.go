@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// UpdatePriority classifies update traffic so checkout decrements keep flowing
+// while bulk work competes for the same worker shards
+type UpdatePriority int
+
+const (
+	// PriorityCheckout is a live sale decrement sent by a store
+	PriorityCheckout UpdatePriority = iota
+	// PrioritySync is a store replaying writes buffered while it was offline
+	PrioritySync
+	// PriorityBulk is an admin or back-office bulk adjustment
+	PriorityBulk
+
+	priorityCount = 3
+)
+
+// defaultLaneQuotas is the share of the shard high-water mark, in percent, each
+// priority lane may fill before its updates are shed
+var defaultLaneQuotas = [priorityCount]int{100, 75, 50}
+
+// ErrLoadShed is returned when a lower-priority update arrives while its
+// shard is already past that priority's quota
+var ErrLoadShed = errors.New("inventory update shed under load")
+
+var priorityNames = [priorityCount]string{"checkout", "sync", "bulk"}
+
+// String returns the name used in the X-Update-Priority header and in config
+func (p UpdatePriority) String() string {
+	if p < 0 || int(p) >= priorityCount {
+		return "unknown"
+	}
+	return priorityNames[p]
+}
+
+// ParseUpdatePriority parses a priority name (checkout, sync or bulk)
+func ParseUpdatePriority(value string) (UpdatePriority, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	for i, priorityName := range priorityNames {
+		if name == priorityName {
+			return UpdatePriority(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown update priority %q, expected checkout, sync or bulk", value)
+}
+
+type priorityKey struct{}
+
+// WithUpdatePriority returns a context whose updates are queued in the given lane
+func WithUpdatePriority(ctx context.Context, priority UpdatePriority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// UpdatePriorityFromContext returns the lane set on ctx, checkout by default
+func UpdatePriorityFromContext(ctx context.Context) UpdatePriority {
+	if priority, ok := ctx.Value(priorityKey{}).(UpdatePriority); ok {
+		return priority
+	}
+	return PriorityCheckout
+}
+
+// ParseLaneQuotas parses "checkout:100,sync:75,bulk:50" into per-lane
+// percentages of the shard high-water mark. Lanes left out keep their
+// defaults; checkout always keeps 100 so it is never shed before saturation.
+func ParseLaneQuotas(value string) ([priorityCount]int, error) {
+	quotas := defaultLaneQuotas
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, percent, found := strings.Cut(entry, ":")
+		if !found {
+			return defaultLaneQuotas, fmt.Errorf("invalid lane quota %q, expected lane:percent", entry)
+		}
+		priority, err := ParseUpdatePriority(name)
+		if err != nil {
+			return defaultLaneQuotas, err
+		}
+		quota, err := strconv.Atoi(strings.TrimSpace(percent))
+		if err != nil || quota < 1 || quota > 100 {
+			return defaultLaneQuotas, fmt.Errorf("invalid quota %q for lane %s, expected 1-100", percent, priority)
+		}
+		quotas[priority] = quota
+	}
+	quotas[PriorityCheckout] = 100
+	return quotas, nil
+}
+
+// laneCounters counts admitted, shed and abandoned updates per priority lane
+type laneCounters struct {
+	admitted  [priorityCount]atomic.Int64
+	shed      [priorityCount]atomic.Int64
+	abandoned [priorityCount]atomic.Int64 // Dropped after their caller's context ended
+}
+
+// laneLimit returns the shard depth at which updates of the given priority are
+// shed (caller holds drainMutex)
+func (s *InventoryService) laneLimit(priority UpdatePriority) int {
+	limit := s.queueHighWaterMark * s.laneQuotas[priority] / 100
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// LoadSheddingStats returns each lane's depth limit and how many of its updates
+// were admitted, shed or abandoned
+func (s *InventoryService) LoadSheddingStats() map[string]interface{} {
+	s.drainMutex.RLock()
+	defer s.drainMutex.RUnlock()
+
+	lanes := make(map[string]interface{}, priorityCount)
+	for i := 0; i < priorityCount; i++ {
+		priority := UpdatePriority(i)
+		lanes[priority.String()] = map[string]interface{}{
+			"quota_percent": s.laneQuotas[priority],
+			"depth_limit":   s.laneLimit(priority),
+			"admitted":      s.laneCounters.admitted[priority].Load(),
+			"shed":          s.laneCounters.shed[priority].Load(),
+			"abandoned":     s.laneCounters.abandoned[priority].Load(),
+		}
+	}
+	return map[string]interface{}{
+		"high_water_mark": s.queueHighWaterMark,
+		"lanes":           lanes,
+	}
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+)
+
+// newUpdateShards creates one buffered update queue per worker
+func newUpdateShards(count, bufferSize int) []chan *UpdateRequest {
+	shards := make([]chan *UpdateRequest, count)
+	for i := range shards {
+		shards[i] = make(chan *UpdateRequest, bufferSize)
+	}
+	return shards
+}
+
+// shardFor routes a product to a fixed shard, so updates to one product stay
+// ordered on one worker while unrelated products proceed in parallel (caller
+// holds drainMutex)
+func (s *InventoryService) shardFor(productID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(productID))
+	return int(hash.Sum32() % uint32(len(s.updateShards)))
+}
+
+// ShardQueueDepths returns the number of queued updates in each shard
+func (s *InventoryService) ShardQueueDepths() []int {
+	s.drainMutex.RLock()
+	defer s.drainMutex.RUnlock()
+
+	depths := make([]int, len(s.updateShards))
+	for i, shard := range s.updateShards {
+		depths[i] = len(shard)
+	}
+	return depths
+}
+
+// QueueHighWaterMark returns the per-shard depth at which new updates are rejected
+func (s *InventoryService) QueueHighWaterMark() int {
+	return s.queueHighWaterMark
+}
+
+// queuedUpdates returns the number of queued updates across all shards
+func (s *InventoryService) queuedUpdates() int {
+	total := 0
+	for _, depth := range s.ShardQueueDepths() {
+		total += depth
+	}
+	return total
+}
+
+// WorkerCount returns the current number of update workers
+func (s *InventoryService) WorkerCount() int {
+	s.drainMutex.RLock()
+	defer s.drainMutex.RUnlock()
+	return s.workerCount
+}
+
+// ResizeWorkerPool changes the number of update workers without downtime.
+// Resizing remaps products to shards, so the new workers only start once the
+// previous ones finished every update already queued; new submissions wait in
+// the new shards meanwhile and no product is updated by two workers at once.
+func (s *InventoryService) ResizeWorkerPool(workerCount int) error {
+	if workerCount < 1 {
+		return fmt.Errorf("worker count must be at least 1, got %d", workerCount)
+	}
+
+	s.drainMutex.Lock()
+	defer s.drainMutex.Unlock()
+
+	if s.draining {
+		return ErrServiceDraining
+	}
+	if workerCount == s.workerCount {
+		return nil
+	}
+
+	previous := s.updateShards
+	previousCount := s.workerCount
+	s.updateShards = newUpdateShards(workerCount, s.queueBufferSize)
+	s.workerCount = workerCount
+	s.workersDone = s.startWorkers(s.updateShards, s.workersDone)
+
+	// No submitter holds the read lock, so closing the old queues is safe;
+	// their workers exit once the updates still queued are processed
+	for _, shard := range previous {
+		close(shard)
+	}
+
+	slog.Info("Inventory update worker pool resized",
+		"previous_worker_count", previousCount,
+		"worker_count", workerCount)
+	return nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"inventory-management-api/internal/clock"
+)
+
+// updatedIndex orders product changes by their lastUpdated time so delta
+// queries read only the changes after a timestamp instead of scanning every
+// product. Each change appends an entry; entries superseded by a newer change
+// to the same product are skipped on read and dropped once they outnumber the
+// live ones. Deletions are kept as tombstones for tombstoneRetention so
+// consumers learn about them. Callers serialize access with the service's
+// global mutex.
+type updatedIndex struct {
+	entries   []updatedEntry   // Sorted by at, then by seq
+	latest    map[string]int64 // Product ID -> seq of its newest entry
+	seq       int64
+	horizon   time.Time // Deletions before it were pruned
+	nextPrune time.Time
+}
+
+// tombstoneRetention is how long a deletion stays in the index. Delta queries
+// from before a pruned deletion are refused, as they would miss it.
+const tombstoneRetention = 7 * 24 * time.Hour
+
+// tombstonePruneInterval is how often recording a change also prunes expired
+// tombstones
+const tombstonePruneInterval = time.Hour
+
+// ErrUpdatedSinceTooOld is returned for delta queries from before the oldest
+// deletion the index still knows about
+var ErrUpdatedSinceTooOld = errors.New("updatedSince is older than the deletions kept")
+
+type updatedEntry struct {
+	at        time.Time
+	productID string
+	seq       int64
+	deleted   bool
+}
+
+func newUpdatedIndex() *updatedIndex {
+	return &updatedIndex{latest: make(map[string]int64)}
+}
+
+// record registers a change to productID at the given time
+func (x *updatedIndex) record(productID string, at time.Time, deleted bool) {
+	x.seq++
+	entry := updatedEntry{at: at, productID: productID, seq: x.seq, deleted: deleted}
+
+	// Changes arrive almost in time order, so the insert point is nearly always the end
+	i := sort.Search(len(x.entries), func(i int) bool { return x.entries[i].at.After(at) })
+	x.entries = append(x.entries, updatedEntry{})
+	copy(x.entries[i+1:], x.entries[i:])
+	x.entries[i] = entry
+	x.latest[productID] = x.seq
+
+	if len(x.entries) > 2*len(x.latest)+64 || !clock.Now().Before(x.nextPrune) {
+		x.compact()
+	}
+}
+
+// since returns the IDs of products changed and deleted at or after t, oldest
+// change first. It fails with ErrUpdatedSinceTooOld when deletions after t may
+// have been pruned.
+func (x *updatedIndex) since(t time.Time) (changed []string, deleted []string, err error) {
+	if t.Before(x.horizon) {
+		return nil, nil, ErrUpdatedSinceTooOld
+	}
+	i := sort.Search(len(x.entries), func(i int) bool { return !x.entries[i].at.Before(t) })
+	for _, entry := range x.entries[i:] {
+		if x.latest[entry.productID] != entry.seq {
+			continue
+		}
+		if entry.deleted {
+			deleted = append(deleted, entry.productID)
+		} else {
+			changed = append(changed, entry.productID)
+		}
+	}
+	return changed, deleted, nil
+}
+
+// compact drops superseded entries and tombstones older than tombstoneRetention
+func (x *updatedIndex) compact() {
+	now := clock.Now()
+	cutoff := now.Add(-tombstoneRetention)
+	live := x.entries[:0]
+	for _, entry := range x.entries {
+		if x.latest[entry.productID] != entry.seq {
+			continue
+		}
+		if entry.deleted && entry.at.Before(cutoff) {
+			delete(x.latest, entry.productID)
+			x.horizon = cutoff
+			continue
+		}
+		live = append(live, entry)
+	}
+	x.entries = live
+	x.nextPrune = now.Add(tombstonePruneInterval)
+}
+
+// parseUpdatedAt reads a product's lastUpdated value; unparseable values count
+// as changed now so they are never hidden from delta queries
+func parseUpdatedAt(lastUpdated string) time.Time {
+	at, err := time.Parse(time.RFC3339, lastUpdated)
+	if err != nil {
+		return clock.Now()
+	}
+	return at
+}
@@ -0,0 +1,46 @@
+package snapshots
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"inventory-management-api/internal/models"
+)
+
+// ErrRestoreBoundary is returned when the events to replay span an earlier
+// restore, which replaced the whole inventory and cannot be replayed
+var ErrRestoreBoundary = errors.New("events span an earlier restore")
+
+// Replay applies events in order on top of a snapshot's products and returns
+// the resulting products sorted by ID. Events carry the full product state, so
+// replaying an event already reflected in the snapshot is harmless.
+func Replay(products []models.ProductResponse, events []models.Event) ([]models.ProductResponse, error) {
+	state := make(map[string]models.ProductResponse, len(products))
+	for _, product := range products {
+		state[product.ProductID] = product
+	}
+
+	for _, event := range events {
+		switch event.EventType {
+		case models.EventTypeProductCreated, models.EventTypeProductUpdated, models.EventTypeStockTransferred,
+			models.EventTypeOrderReserved, models.EventTypeOrderCommitted, models.EventTypeOrderCancelled:
+			state[event.ProductID] = event.Data
+		case models.EventTypeProductDeleted:
+			delete(state, event.ProductID)
+		case models.EventTypeSystemRestored:
+			return nil, fmt.Errorf("%w at offset %d", ErrRestoreBoundary, event.Offset)
+		case models.EventTypeCategoryUpdated, models.EventTypeCategoryDeleted, models.EventTypeBackorderFulfilled:
+			// Neither the category tree nor backorder queues are part of a snapshot
+		default:
+			return nil, fmt.Errorf("unknown event type %q at offset %d", event.EventType, event.Offset)
+		}
+	}
+
+	restored := make([]models.ProductResponse, 0, len(state))
+	for _, product := range state {
+		restored = append(restored, product)
+	}
+	sort.Slice(restored, func(i, j int) bool { return restored[i].ProductID < restored[j].ProductID })
+	return restored, nil
+}
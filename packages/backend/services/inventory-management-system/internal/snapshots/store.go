@@ -0,0 +1,255 @@
+// Package snapshots stores named point-in-time copies of the full inventory as
+// gzip-compressed JSON files and compares them with the current state.
+package snapshots
+
+import (
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// ErrNotFound is returned for an unknown or malformed snapshot ID
+var ErrNotFound = errors.New("snapshot not found")
+
+// validID matches the IDs generated by Create, which keeps lookups inside dir
+var validID = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}Z-[0-9a-f]{8}$`)
+
+const (
+	dataSuffix = ".json.gz"
+	metaSuffix = ".meta.json"
+)
+
+// snapshotFile is the compressed on-disk layout of one snapshot
+type snapshotFile struct {
+	Snapshot models.SnapshotInfo      `json:"snapshot"`
+	Products []models.ProductResponse `json:"products"`
+}
+
+// Store keeps snapshots in a directory: <id>.json.gz holds the products and
+// <id>.meta.json a small copy of the metadata so listing needs no decompression
+type Store struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+// NewStore creates the snapshot directory if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshots directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Create writes a snapshot of products taken at lastOffset
+func (s *Store) Create(name string, products []models.ProductResponse, lastOffset int) (models.SnapshotInfo, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return models.SnapshotInfo{}, fmt.Errorf("failed to generate snapshot ID: %w", err)
+	}
+	now := clock.Now()
+
+	info := models.SnapshotInfo{
+		ID:           now.Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix),
+		Name:         name,
+		CreatedAt:    clock.Format(now),
+		LastOffset:   lastOffset,
+		ProductCount: len(products),
+	}
+	for _, product := range products {
+		info.TotalAvailable += product.Available
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dataPath := filepath.Join(s.dir, info.ID+dataSuffix)
+	size, err := writeCompressed(dataPath, snapshotFile{Snapshot: info, Products: products})
+	if err != nil {
+		return models.SnapshotInfo{}, err
+	}
+	info.SizeBytes = size
+
+	meta, err := json.Marshal(info)
+	if err != nil {
+		os.Remove(dataPath)
+		return models.SnapshotInfo{}, fmt.Errorf("failed to encode snapshot metadata: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, info.ID+metaSuffix), meta); err != nil {
+		os.Remove(dataPath)
+		return models.SnapshotInfo{}, err
+	}
+
+	slog.Info("Inventory snapshot created",
+		"snapshot_id", info.ID,
+		"name", info.Name,
+		"products", info.ProductCount,
+		"last_offset", info.LastOffset,
+		"size_bytes", info.SizeBytes)
+	return info, nil
+}
+
+// List returns every snapshot's metadata, newest first
+func (s *Store) List() ([]models.SnapshotInfo, error) {
+	matches, err := filepath.Glob(filepath.Join(s.dir, "*"+metaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	infos := make([]models.SnapshotInfo, 0, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			slog.Warn("Failed to read snapshot metadata", "path", path, "error", err)
+			continue
+		}
+		var info models.SnapshotInfo
+		if err := json.Unmarshal(data, &info); err != nil {
+			slog.Warn("Invalid snapshot metadata", "path", path, "error", err)
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	// IDs start with the creation time, so they sort chronologically
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID > infos[j].ID })
+	return infos, nil
+}
+
+// Get loads a snapshot with its products
+func (s *Store) Get(id string) (models.SnapshotInfo, []models.ProductResponse, error) {
+	if !validID.MatchString(id) {
+		return models.SnapshotInfo{}, nil, ErrNotFound
+	}
+
+	file, err := os.Open(filepath.Join(s.dir, id+dataSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return models.SnapshotInfo{}, nil, ErrNotFound
+	}
+	if err != nil {
+		return models.SnapshotInfo{}, nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return models.SnapshotInfo{}, nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+	}
+	defer reader.Close()
+
+	var snapshot snapshotFile
+	if err := json.NewDecoder(reader).Decode(&snapshot); err != nil {
+		return models.SnapshotInfo{}, nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if info, err := os.Stat(file.Name()); err == nil {
+		snapshot.Snapshot.SizeBytes = info.Size()
+	}
+	return snapshot.Snapshot, snapshot.Products, nil
+}
+
+// Diff compares a snapshot's products with the current ones. Products whose
+// stock, version, price or name differ are listed as changed.
+func Diff(info models.SnapshotInfo, snapshot, current []models.ProductResponse, currentOffset int) models.SnapshotDiff {
+	diff := models.SnapshotDiff{
+		SnapshotID:     info.ID,
+		SnapshotOffset: info.LastOffset,
+		CurrentOffset:  currentOffset,
+		Changed:        []models.ProductChange{},
+		Added:          []string{},
+		Removed:        []string{},
+	}
+
+	before := make(map[string]models.ProductResponse, len(snapshot))
+	for _, product := range snapshot {
+		before[product.ProductID] = product
+	}
+
+	for _, now := range current {
+		then, existed := before[now.ProductID]
+		if !existed {
+			diff.Added = append(diff.Added, now.ProductID)
+			diff.AvailableDelta += now.Available
+			continue
+		}
+		delete(before, now.ProductID)
+
+		if then.Available == now.Available && then.Version == now.Version && then.Price == now.Price && then.Name == now.Name {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, models.ProductChange{
+			ProductID:         now.ProductID,
+			SnapshotAvailable: then.Available,
+			CurrentAvailable:  now.Available,
+			AvailableDelta:    now.Available - then.Available,
+			SnapshotVersion:   then.Version,
+			CurrentVersion:    now.Version,
+			SnapshotPrice:     then.Price,
+			CurrentPrice:      now.Price,
+		})
+		diff.AvailableDelta += now.Available - then.Available
+	}
+
+	for productID, then := range before {
+		diff.Removed = append(diff.Removed, productID)
+		diff.AvailableDelta -= then.Available
+	}
+
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].ProductID < diff.Changed[j].ProductID })
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+// writeCompressed gzips value as JSON into path atomically and returns the file size
+func writeCompressed(path string, value interface{}) (int64, error) {
+	tempPath := path + ".tmp"
+	file, err := os.Create(tempPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	writer := gzip.NewWriter(file)
+	encodeErr := json.NewEncoder(writer).Encode(value)
+	closeErr := writer.Close()
+	syncErr := file.Sync()
+	fileErr := file.Close()
+	if err := errors.Join(encodeErr, closeErr, syncErr, fileErr); err != nil {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	info, err := os.Stat(tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return 0, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return info.Size(), nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot metadata: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store snapshot metadata: %w", err)
+	}
+	return nil
+}
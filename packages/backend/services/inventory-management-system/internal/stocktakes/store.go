@@ -0,0 +1,234 @@
+// Package stocktakes keeps cycle count sessions in a JSON file and answers,
+// for every store decrement, whether an open session has frozen the product.
+package stocktakes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"inventory-management-api/internal/models"
+)
+
+// maxFinishedSessions bounds how many closed or cancelled sessions are kept
+const maxFinishedSessions = 100
+
+var (
+	// ErrNotFound is returned for an unknown session ID
+	ErrNotFound = errors.New("stocktake not found")
+	// ErrOverlap is returned when a new session would freeze products an open
+	// session already freezes for the same store
+	ErrOverlap = errors.New("stocktake overlaps an open stocktake")
+)
+
+// freeze is an open session's hold on one product
+type freeze struct {
+	sessionID string
+	storeID   string // Empty freezes decrements from every store
+	mode      string
+}
+
+// Store holds stocktake sessions, oldest first
+type Store struct {
+	path     string
+	mutex    sync.RWMutex
+	sessions []models.StocktakeSession
+	nextID   int64
+	frozen   map[string][]freeze // Product ID -> open sessions holding it
+}
+
+// fileData is the on-disk layout
+type fileData struct {
+	NextID   int64                     `json:"nextId"`
+	Sessions []models.StocktakeSession `json:"sessions"`
+}
+
+// NewStore loads the sessions saved at path; a missing file means none, and
+// an empty path keeps sessions in memory only
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, nextID: 1}
+	s.reindexLocked()
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload replaces the sessions with the ones saved at the path, for an
+// instance taking over the file from another
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stocktakes: %w", err)
+	}
+
+	var saved fileData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode stocktakes: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sessions = saved.Sessions
+	if saved.NextID > s.nextID {
+		s.nextID = saved.NextID
+	}
+	s.reindexLocked()
+	slog.Info("Stocktakes loaded", "path", s.path, "count", len(s.sessions))
+	return nil
+}
+
+// Open assigns the session an ID, records it as open and saves the file
+func (s *Store) Open(session models.StocktakeSession) (models.StocktakeSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, productID := range session.ProductIDs {
+		for _, held := range s.frozen[productID] {
+			if held.storeID == "" || session.StoreID == "" || held.storeID == session.StoreID {
+				return models.StocktakeSession{}, fmt.Errorf("%w: %s holds %s", ErrOverlap, held.sessionID, productID)
+			}
+		}
+	}
+
+	session.ID = fmt.Sprintf("st-%06d", s.nextID)
+	session.Status = models.StocktakeStatusOpen
+	s.nextID++
+	s.sessions = append(s.sessions, session)
+	s.reindexLocked()
+	return session, s.saveLocked()
+}
+
+// Get returns a session by ID
+func (s *Store) Get(id string) (models.StocktakeSession, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.ID == id {
+			return session, true
+		}
+	}
+	return models.StocktakeSession{}, false
+}
+
+// List returns every session, newest first
+func (s *Store) List() []models.StocktakeSession {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]models.StocktakeSession, 0, len(s.sessions))
+	for i := len(s.sessions) - 1; i >= 0; i-- {
+		result = append(result, s.sessions[i])
+	}
+	return result
+}
+
+// Update changes a session with fn and saves the file. The change is kept when
+// fn succeeds even if the save fails; the error is returned for logging.
+func (s *Store) Update(id string, fn func(*models.StocktakeSession) error) (models.StocktakeSession, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i := range s.sessions {
+		if s.sessions[i].ID != id {
+			continue
+		}
+		session := s.sessions[i]
+		session.Lines = append([]models.StocktakeLine(nil), session.Lines...)
+		if err := fn(&session); err != nil {
+			return s.sessions[i], err
+		}
+		s.sessions[i] = session
+		s.trimLocked()
+		s.reindexLocked()
+		return session, s.saveLocked()
+	}
+	return models.StocktakeSession{}, ErrNotFound
+}
+
+// Frozen reports whether an open session holds decrements of productID sent by
+// storeID, and with which session and mode
+func (s *Store) Frozen(productID, storeID string) (sessionID, mode string, frozen bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, held := range s.frozen[productID] {
+		if held.storeID == "" || held.storeID == storeID {
+			return held.sessionID, held.mode, true
+		}
+	}
+	return "", "", false
+}
+
+// trimLocked drops the oldest finished sessions past maxFinishedSessions
+func (s *Store) trimLocked() {
+	finished := 0
+	for _, session := range s.sessions {
+		if session.Status != models.StocktakeStatusOpen {
+			finished++
+		}
+	}
+
+	kept := s.sessions[:0]
+	for _, session := range s.sessions {
+		if session.Status != models.StocktakeStatusOpen && finished > maxFinishedSessions {
+			finished--
+			continue
+		}
+		kept = append(kept, session)
+	}
+	s.sessions = kept
+}
+
+// reindexLocked rebuilds the frozen product index from the open sessions
+func (s *Store) reindexLocked() {
+	s.frozen = make(map[string][]freeze)
+	for _, session := range s.sessions {
+		if session.Status != models.StocktakeStatusOpen {
+			continue
+		}
+		for _, productID := range session.ProductIDs {
+			s.frozen[productID] = append(s.frozen[productID], freeze{
+				sessionID: session.ID,
+				storeID:   session.StoreID,
+				mode:      session.Mode,
+			})
+		}
+	}
+}
+
+// saveLocked writes the sessions atomically (caller holds the write lock)
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(fileData{NextID: s.nextID, Sessions: s.sessions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stocktakes: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create stocktakes directory: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write stocktakes: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store stocktakes: %w", err)
+	}
+	return nil
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"inventory-management-api/internal/apiversion"
+
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -30,6 +32,9 @@ type InventoryApiTelemetry struct {
 	inventoryUpdateCounter metric.Int64Counter
 	eventRetrievalCounter  metric.Int64Counter
 	productQueryCounter    metric.Int64Counter
+
+	// Request counts per endpoint group for error budgets; nil when off
+	sloTracker *SLOTracker
 }
 
 // InventoryApiMetrics contains the telemetry data for a request
@@ -46,6 +51,10 @@ type InventoryApiMetrics struct {
 	StoreID      string // Keep if store count is manageable
 	EventCount   int
 	ProductCount int
+	// Correlation details, set for sampled requests only and recorded on
+	// exemplars rather than as series attributes
+	ProductID      string
+	RequestStoreID string
 }
 
 // NewInventoryApiTelemetry creates a new instance of InventoryApiTelemetry
@@ -130,6 +139,49 @@ func (t *InventoryApiTelemetry) InitializeTelemetry(ctx context.Context) error {
 	return nil
 }
 
+// RegisterQueueDepthGauge reports the depth of each update worker shard as
+// inventory_update_queue_depth{shard="N"}, read from depths at collection time,
+// and the per-shard rejection threshold as inventory_update_queue_high_water_mark
+func (t *InventoryApiTelemetry) RegisterQueueDepthGauge(depths func() []int, highWaterMark int) error {
+	if t.meter == nil {
+		return fmt.Errorf("telemetry not initialized")
+	}
+
+	queueDepthGauge, err := t.meter.Int64ObservableGauge(
+		"inventory_update_queue_depth",
+		metric.WithDescription("Number of inventory updates waiting in each worker shard queue"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Error("Failed to create queue depth gauge", "error", err)
+		return fmt.Errorf("failed to create queue depth gauge: %w", err)
+	}
+
+	highWaterMarkGauge, err := t.meter.Int64ObservableGauge(
+		"inventory_update_queue_high_water_mark",
+		metric.WithDescription("Queue depth per worker shard at which new inventory updates are rejected"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Error("Failed to create queue high-water mark gauge", "error", err)
+		return fmt.Errorf("failed to create queue high-water mark gauge: %w", err)
+	}
+
+	_, err = t.meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		for shard, depth := range depths() {
+			observer.ObserveInt64(queueDepthGauge, int64(depth), metric.WithAttributes(attribute.Int("shard", shard)))
+		}
+		observer.ObserveInt64(highWaterMarkGauge, int64(highWaterMark))
+		return nil
+	}, queueDepthGauge, highWaterMarkGauge)
+	if err != nil {
+		slog.Error("Failed to register queue depth callback", "error", err)
+		return fmt.Errorf("failed to register queue depth callback: %w", err)
+	}
+
+	return nil
+}
+
 // RegisterRequestReceived records a successful API request
 func (t *InventoryApiTelemetry) RegisterRequestReceived(ctx context.Context, metrics InventoryApiMetrics) {
 	if t.requestCounter == nil {
@@ -240,6 +292,14 @@ func (t *InventoryApiTelemetry) RegisterRequestDuration(ctx context.Context, met
 		attrs = append(attrs, attribute.String("store_id", metrics.StoreID))
 	}
 
+	// Exemplar-only attributes, dropped from the series by the duration view
+	if metrics.ProductID != "" {
+		attrs = append(attrs, attribute.String("product_id", metrics.ProductID))
+	}
+	if metrics.RequestStoreID != "" {
+		attrs = append(attrs, attribute.String("request_store_id", metrics.RequestStoreID))
+	}
+
 	// Record duration in seconds
 	durationSeconds := metrics.Duration.Seconds()
 	t.durationHistogram.Record(ctx, durationSeconds, metric.WithAttributes(attrs...))
@@ -334,8 +394,13 @@ func categorizeError(errorMessage string) string {
 	}
 }
 
-// GetEndpointFromPath normalizes the endpoint path for telemetry
+// GetEndpointFromPath normalizes the endpoint path for telemetry. Paths under
+// later API versions keep their version and are normalized like /v1 ones.
 func GetEndpointFromPath(path string) string {
+	if version, ok := apiversion.FromPath(path); ok && version != apiversion.V1 {
+		return apiversion.Path(version, GetEndpointFromPath(apiversion.Canonical(path)))
+	}
+
 	// Normalize paths with parameters to template format
 	switch {
 	case path == "/v1/inventory":
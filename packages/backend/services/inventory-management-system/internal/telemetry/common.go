@@ -8,12 +8,14 @@ import (
 	"os"
 	"sync"
 
+	promclient "github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	api "go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 // Structure for Open Telemetry variables
@@ -22,6 +24,8 @@ type Telemetry struct {
 	Provider *metric.MeterProvider // If not scraper use gRPC.
 	meter    api.Meter             // meter to create metrics.
 	ctx      *context.Context
+
+	TracerProvider *sdktrace.TracerProvider // Nil when TRACES_EXPORTER=none.
 }
 
 var (
@@ -34,6 +38,7 @@ func (t *Telemetry) InitMetrics(meterName string, ctx *context.Context) *Telemet
 	t.ctx = ctx
 
 	once.Do(func() {
+		initCorrelation()
 		if metricsExporter == "scraper" {
 			slog.Info("Starting metrics with scraper exporter")
 			t.initScrapeMetrics(meterName) // Serves a page on http://localhost:9080/metrics .
@@ -43,10 +48,11 @@ func (t *Telemetry) InitMetrics(meterName string, ctx *context.Context) *Telemet
 		}
 	})
 	return &Telemetry{
-		server:   t.server,
-		Provider: t.Provider,
-		meter:    t.meter,
-		ctx:      t.ctx,
+		server:         t.server,
+		Provider:       t.Provider,
+		meter:          t.meter,
+		ctx:            t.ctx,
+		TracerProvider: t.TracerProvider,
 	}
 }
 
@@ -62,6 +68,7 @@ func (t *Telemetry) Close() {
 	if t.Provider != nil {
 		t.Provider.ForceFlush(*t.ctx)
 	}
+	t.shutdownTracing(*t.ctx)
 }
 
 // Initialize GRPC metrics exporter. https://opentelemetry.io/docs/languages/go/exporters/#otlp-metrics-over-grpc.
@@ -75,7 +82,8 @@ func (t *Telemetry) initGRPCMetrics(meterName string) {
 		return
 	}
 
-	t.Provider = metric.NewMeterProvider(metric.WithReader(metric.NewPeriodicReader(exporter)))
+	options := append(meterProviderOptions(), metric.WithReader(metric.NewPeriodicReader(exporter)))
+	t.Provider = metric.NewMeterProvider(options...)
 	otel.SetMeterProvider(t.Provider)
 	t.meter = t.Provider.Meter(meterName)
 }
@@ -92,7 +100,8 @@ func (t *Telemetry) initScrapeMetrics(meterName string) {
 		return
 	}
 
-	t.Provider = metric.NewMeterProvider(metric.WithReader(exporter))
+	options := append(meterProviderOptions(), metric.WithReader(exporter))
+	t.Provider = metric.NewMeterProvider(options...)
 	otel.SetMeterProvider(t.Provider)
 	t.meter = t.Provider.Meter(meterName)
 
@@ -104,7 +113,11 @@ func (t *Telemetry) serveMetrics() {
 	slog.Info("Serving metrics at localhost:9080/metrics")
 
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", promhttp.Handler())
+	// Exemplars are only exposed in the OpenMetrics format, which Prometheus
+	// negotiates when exemplar storage is enabled
+	mux.Handle("/metrics", promhttp.HandlerFor(promclient.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	}))
 
 	t.server = &http.Server{
 		Addr:    ":9080",
@@ -0,0 +1,169 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultCorrelationSampleRatio is the share of traced requests correlated
+// when CORRELATION_SAMPLE_RATIO is not set
+const DefaultCorrelationSampleRatio = 0.01
+
+// correlationSampleRatio holds the ratio as float64 bits so it can be raised
+// during an incident without a lock on the request path
+var correlationSampleRatio atomic.Uint64
+
+func init() {
+	correlationSampleRatio.Store(math.Float64bits(DefaultCorrelationSampleRatio))
+}
+
+// initCorrelation reads CORRELATION_SAMPLE_RATIO, falling back to the default
+// when it is not a ratio between 0 and 1
+func initCorrelation() {
+	value := getEnvWithDefault("CORRELATION_SAMPLE_RATIO", "")
+	if value == "" {
+		return
+	}
+	ratio, err := strconv.ParseFloat(value, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		slog.Warn("Invalid correlation sample ratio, using default", "provided", value, "default", DefaultCorrelationSampleRatio)
+		return
+	}
+	SetCorrelationSampleRatio(ratio)
+	slog.Info("Request correlation sampling configured", "ratio", ratio)
+}
+
+// SetCorrelationSampleRatio sets the share of traced requests whose duration
+// is kept as an exemplar and whose completion is logged with their trace ID.
+// 0 turns correlation off, 1 correlates every traced request.
+func SetCorrelationSampleRatio(ratio float64) {
+	correlationSampleRatio.Store(math.Float64bits(min(max(ratio, 0), 1)))
+}
+
+// CorrelationSampleRatio returns the current correlation sample ratio
+func CorrelationSampleRatio() float64 {
+	return math.Float64frombits(correlationSampleRatio.Load())
+}
+
+// correlation collects the high-cardinality details of a sampled request.
+// Handlers fill it in through the request context; the telemetry middleware
+// reads it once the handler returns.
+type correlation struct {
+	mu        sync.Mutex
+	productID string
+	storeID   string
+}
+
+type correlationKey struct{}
+
+// startCorrelation decides whether the request in ctx is correlated. Only
+// requests with a recording span are, so every exemplar and log line written
+// for them carries a trace ID that can be looked up.
+func startCorrelation(ctx context.Context) (context.Context, *correlation) {
+	if !trace.SpanFromContext(ctx).SpanContext().IsSampled() {
+		return ctx, nil
+	}
+	ratio := CorrelationSampleRatio()
+	if ratio <= 0 || (ratio < 1 && rand.Float64() >= ratio) {
+		return ctx, nil
+	}
+	c := &correlation{}
+	return context.WithValue(ctx, correlationKey{}, c), c
+}
+
+// correlationFromContext returns the correlation of a sampled request, or nil
+func correlationFromContext(ctx context.Context) *correlation {
+	c, _ := ctx.Value(correlationKey{}).(*correlation)
+	return c
+}
+
+// AnnotateProduct records the product a request acts on. It is kept only for
+// correlated requests, on their exemplar and completion log, never as a
+// metric attribute.
+func AnnotateProduct(ctx context.Context, productID string) {
+	if c := correlationFromContext(ctx); c != nil && productID != "" {
+		c.mu.Lock()
+		c.productID = productID
+		c.mu.Unlock()
+	}
+}
+
+// AnnotateStore records the store a correlated request came from
+func AnnotateStore(ctx context.Context, storeID string) {
+	if c := correlationFromContext(ctx); c != nil && storeID != "" {
+		c.mu.Lock()
+		c.storeID = storeID
+		c.mu.Unlock()
+	}
+}
+
+// values returns the recorded product and store
+func (c *correlation) values() (productID, storeID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.productID, c.storeID
+}
+
+// exemplarFilter keeps exemplars for correlated requests only, so the
+// sampling switch bounds how many measurements carry their trace
+func exemplarFilter(ctx context.Context) bool {
+	return correlationFromContext(ctx) != nil
+}
+
+// exemplarAttributes are recorded with the request duration of correlated
+// requests but dropped from its series, so they appear on exemplars only
+var exemplarAttributes = []attribute.Key{"product_id", "request_store_id"}
+
+// meterProviderOptions returns the views and exemplar filter every meter
+// provider is created with
+func meterProviderOptions() []metric.Option {
+	return []metric.Option{
+		metric.WithExemplarFilter(exemplarFilter),
+		metric.WithView(metric.NewView(
+			metric.Instrument{Name: "inventory_api_request_duration_seconds"},
+			metric.Stream{AttributeFilter: attribute.NewDenyKeysFilter(exemplarAttributes...)},
+		)),
+	}
+}
+
+// LogHandler adds the trace_id and span_id of the current span to records
+// logged with a context (slog.InfoContext and friends), so log lines can be
+// joined with traces and exemplars
+type LogHandler struct {
+	slog.Handler
+}
+
+// NewLogHandler wraps next so trace IDs are added to every contextual record
+func NewLogHandler(next slog.Handler) *LogHandler {
+	return &LogHandler{Handler: next}
+}
+
+// Handle implements slog.Handler
+func (h *LogHandler) Handle(ctx context.Context, record slog.Record) error {
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
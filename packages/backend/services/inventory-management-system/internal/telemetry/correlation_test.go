@@ -0,0 +1,149 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serveCorrelated sends one traced product request through the tracing and
+// telemetry middleware at the given correlation ratio and returns the
+// request duration data point and the request's trace ID
+func serveCorrelated(t *testing.T, ratio float64) (metricdata.HistogramDataPoint[float64], trace.TraceID) {
+	t.Helper()
+
+	reader := metric.NewManualReader()
+	meterProvider := metric.NewMeterProvider(append(meterProviderOptions(), metric.WithReader(reader))...)
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.AlwaysSample()))
+
+	previousMeterProvider := otel.GetMeterProvider()
+	previousTracerProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	previousRatio := CorrelationSampleRatio()
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	SetCorrelationSampleRatio(ratio)
+	defer func() {
+		otel.SetMeterProvider(previousMeterProvider)
+		otel.SetTracerProvider(previousTracerProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		SetCorrelationSampleRatio(previousRatio)
+	}()
+
+	apiTelemetry := NewInventoryApiTelemetry()
+	if err := apiTelemetry.InitializeTelemetry(context.Background()); err != nil {
+		t.Fatalf("Failed to initialize telemetry: %v", err)
+	}
+
+	var traceID trace.TraceID
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.Use(NewTelemetryMiddleware(apiTelemetry).Middleware)
+	router.HandleFunc("/v1/inventory/{productId}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanContextFromContext(r.Context()).TraceID()
+		w.WriteHeader(http.StatusOK)
+	}).Methods("GET")
+
+	req := httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil)
+	req.Header.Set("X-Store-ID", "store-7")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("Failed to collect metrics: %v", err)
+	}
+	for _, scope := range data.ScopeMetrics {
+		for _, m := range scope.Metrics {
+			if m.Name != "inventory_api_request_duration_seconds" {
+				continue
+			}
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || len(histogram.DataPoints) != 1 {
+				t.Fatalf("Expected one duration data point, got %+v", m.Data)
+			}
+			return histogram.DataPoints[0], traceID
+		}
+	}
+	t.Fatal("Request duration was not recorded")
+	return metricdata.HistogramDataPoint[float64]{}, traceID
+}
+
+// TestTelemetryMiddleware_CorrelatedRequestExemplar verifies that a sampled
+// request leaves an exemplar with its trace, product and store, while the
+// series keeps only low-cardinality attributes
+func TestTelemetryMiddleware_CorrelatedRequestExemplar(t *testing.T) {
+	point, traceID := serveCorrelated(t, 1)
+
+	for _, key := range exemplarAttributes {
+		if _, ok := point.Attributes.Value(key); ok {
+			t.Errorf("Expected %s dropped from the series, got %v", key, point.Attributes.ToSlice())
+		}
+	}
+	if len(point.Exemplars) != 1 {
+		t.Fatalf("Expected 1 exemplar, got %d", len(point.Exemplars))
+	}
+
+	exemplar := point.Exemplars[0]
+	if trace.TraceID(exemplar.TraceID) != traceID {
+		t.Errorf("Expected exemplar of trace %s, got %x", traceID, exemplar.TraceID)
+	}
+	filtered := attribute.NewSet(exemplar.FilteredAttributes...)
+	if value, _ := filtered.Value("product_id"); value.AsString() != "SKU-001" {
+		t.Errorf("Expected product_id SKU-001 on the exemplar, got %v", exemplar.FilteredAttributes)
+	}
+	if value, _ := filtered.Value("request_store_id"); value.AsString() != "store-7" {
+		t.Errorf("Expected request_store_id store-7 on the exemplar, got %v", exemplar.FilteredAttributes)
+	}
+}
+
+// TestTelemetryMiddleware_CorrelationDisabled verifies that a ratio of 0
+// keeps exemplars off even for traced requests
+func TestTelemetryMiddleware_CorrelationDisabled(t *testing.T) {
+	point, _ := serveCorrelated(t, 0)
+	if len(point.Exemplars) != 0 {
+		t.Errorf("Expected no exemplars, got %+v", point.Exemplars)
+	}
+}
+
+// TestLogHandler_AddsTraceContext verifies that records logged with a traced
+// context carry its trace and span IDs and that others are left alone
+func TestLogHandler_AddsTraceContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewLogHandler(slog.NewTextHandler(&buf, nil)))
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	logger.InfoContext(ctx, "traced")
+	logger.Info("untraced")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "trace_id=4bf92f3577b34da6a3ce929d0e0e4736") || !strings.Contains(lines[0], "span_id=00f067aa0ba902b7") {
+		t.Errorf("Expected trace context on the traced record, got %q", lines[0])
+	}
+	if strings.Contains(lines[1], "trace_id") {
+		t.Errorf("Expected no trace context on the untraced record, got %q", lines[1])
+	}
+}
@@ -2,11 +2,14 @@ package telemetry
 
 import (
 	"context"
+	"log/slog"
 	"net"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 // TelemetryMiddleware wraps HTTP handlers to automatically collect telemetry
@@ -65,6 +68,15 @@ func (tm *TelemetryMiddleware) Middleware(next http.Handler) http.Handler {
 		// Extract telemetry data from request
 		metrics := tm.extractMetricsFromRequest(r)
 
+		// Sampled requests also carry their product and store to exemplars
+		// and to the completion log
+		ctx, sampled := startCorrelation(r.Context())
+		if sampled != nil {
+			AnnotateStore(ctx, r.Header.Get("X-Store-ID"))
+			AnnotateProduct(ctx, mux.Vars(r)["productId"])
+			r = r.WithContext(ctx)
+		}
+
 		// Call the next handler
 		next.ServeHTTP(wrapper, r)
 
@@ -73,8 +85,10 @@ func (tm *TelemetryMiddleware) Middleware(next http.Handler) http.Handler {
 		metrics.Duration = time.Since(start)
 
 		// Extract additional telemetry data from context
-		ctx := r.Context()
 		UpdateMetricsFromContext(ctx, &metrics)
+		if sampled != nil {
+			metrics.ProductID, metrics.RequestStoreID = sampled.values()
+		}
 
 		// Record telemetry based on success/failure
 		if wrapper.statusCode >= 400 {
@@ -88,6 +102,19 @@ func (tm *TelemetryMiddleware) Middleware(next http.Handler) http.Handler {
 
 		// Always record duration
 		tm.telemetry.RegisterRequestDuration(ctx, metrics)
+		if tm.telemetry.sloTracker != nil {
+			tm.telemetry.sloTracker.Record(r.Method, r.URL.Path, metrics.StatusCode, metrics.Duration)
+		}
+
+		if sampled != nil {
+			slog.InfoContext(ctx, "Request sampled for correlation",
+				"method", metrics.Method,
+				"endpoint", metrics.Endpoint,
+				"status_code", metrics.StatusCode,
+				"duration_ms", metrics.Duration.Milliseconds(),
+				"store_id", metrics.RequestStoreID,
+				"product_id", metrics.ProductID)
+		}
 	})
 }
 
@@ -106,6 +133,16 @@ func (w *responseWriterWrapper) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
+// Flush passes flushes of streamed responses through to the client
+func (w *responseWriterWrapper) Flush() {
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // extractMetricsFromRequest extracts telemetry data from the HTTP request
 func (tm *TelemetryMiddleware) extractMetricsFromRequest(r *http.Request) InventoryApiMetrics {
 	// Extract client IP and normalize it for low cardinality
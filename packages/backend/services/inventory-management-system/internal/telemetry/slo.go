@@ -0,0 +1,371 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/models"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// SLO endpoint groups
+const (
+	SLOGroupWrites = "writes" // Stock updates, reservations, transfers and other /v1 writes
+	SLOGroupReads  = "reads"  // Product reads, batch-get, versions and snapshots
+	SLOGroupEvents = "events" // Event polling and commits; long polls make latency meaningless
+	SLOGroupAdmin  = "admin"  // Everything under /v1/admin
+)
+
+// SLOBudgetWindow is the period error budgets are spent over. The burn-rate
+// alert thresholds assume it.
+const SLOBudgetWindow = 30 * 24 * time.Hour
+
+// sloLatencyTarget is the share of requests that must finish within a latency
+// objective's threshold, which makes the threshold a p99 target
+const sloLatencyTarget = 0.99
+
+// sloWindow is a window burn rates are reported over
+type sloWindow struct {
+	name     string
+	duration time.Duration
+}
+
+var sloWindows = []sloWindow{
+	{"5m", 5 * time.Minute},
+	{"30m", 30 * time.Minute},
+	{"1h", time.Hour},
+	{"2h", 2 * time.Hour},
+	{"6h", 6 * time.Hour},
+	{"1d", 24 * time.Hour},
+	{"3d", 72 * time.Hour},
+}
+
+// sloAlertRule fires when the budget burns faster than threshold over both a
+// long window and a short one; the short window makes it stop soon after
+// the burn does
+type sloAlertRule struct {
+	severity    string
+	long, short string
+	threshold   float64
+}
+
+var sloAlertRules = []sloAlertRule{
+	{models.SLOAlertPage, "1h", "5m", 14.4},
+	{models.SLOAlertPage, "6h", "30m", 6},
+	{models.SLOAlertTicket, "1d", "2h", 3},
+	{models.SLOAlertTicket, "3d", "6h", 1},
+}
+
+// SLOObjective is the availability target of an endpoint group and, when
+// Latency is set, its p99 latency target
+type SLOObjective struct {
+	Group        string
+	Availability float64 // Share of requests that must not fail with a 5xx, e.g. 0.999
+	Latency      time.Duration
+}
+
+// ParseSLOObjectives parses a comma-separated list of
+// group:availability%[:p99latency], e.g. "writes:99.9:500ms,events:99.5"
+func ParseSLOObjectives(spec string) ([]SLOObjective, error) {
+	var objectives []SLOObjective
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.Split(entry, ":")
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("objective %q: want group:availability%%[:p99latency]", entry)
+		}
+
+		objective := SLOObjective{Group: strings.TrimSpace(fields[0])}
+		switch objective.Group {
+		case SLOGroupWrites, SLOGroupReads, SLOGroupEvents, SLOGroupAdmin:
+		default:
+			return nil, fmt.Errorf("objective %q: unknown group %q", entry, objective.Group)
+		}
+		if seen[objective.Group] {
+			return nil, fmt.Errorf("objective %q: group %s is listed twice", entry, objective.Group)
+		}
+		seen[objective.Group] = true
+
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(fields[1]), "%"), 64)
+		if err != nil || percent <= 0 || percent >= 100 {
+			return nil, fmt.Errorf("objective %q: availability must be a percentage between 0 and 100", entry)
+		}
+		objective.Availability = percent / 100
+
+		if len(fields) == 3 {
+			objective.Latency, err = time.ParseDuration(strings.TrimSpace(fields[2]))
+			if err != nil || objective.Latency <= 0 {
+				return nil, fmt.Errorf("objective %q: invalid latency %q", entry, fields[2])
+			}
+		}
+		objectives = append(objectives, objective)
+	}
+	if len(objectives) == 0 {
+		return nil, fmt.Errorf("no objectives")
+	}
+	return objectives, nil
+}
+
+// SLOGroup returns the endpoint group of a request, or "" for requests no
+// objective covers (health checks, docs)
+func SLOGroup(method, path string) string {
+	path = apiversion.Canonical(path)
+	switch {
+	case path == "/v1/admin" || strings.HasPrefix(path, "/v1/admin/"):
+		return SLOGroupAdmin
+	case strings.HasPrefix(path, "/v1/inventory/events"):
+		return SLOGroupEvents
+	case !strings.HasPrefix(path, "/v1/"):
+		return ""
+	case method == http.MethodGet || method == http.MethodHead || path == "/v1/inventory/batch-get":
+		return SLOGroupReads
+	default:
+		return SLOGroupWrites
+	}
+}
+
+// sloCounts are the requests of one bucket
+type sloCounts struct {
+	total  int64
+	failed int64 // Answered with a 5xx
+	slow   int64 // Slower than the latency threshold
+}
+
+func (c *sloCounts) add(other sloCounts) {
+	c.total += other.total
+	c.failed += other.failed
+	c.slow += other.slow
+}
+
+// sloRing keeps counts in fixed-width time buckets, reusing a bucket once it
+// falls out of the span the ring covers
+type sloRing struct {
+	width   time.Duration
+	buckets []sloCounts
+	epochs  []int64 // Bucket number each slot currently holds
+}
+
+func newSLORing(width, span time.Duration) *sloRing {
+	n := int(span / width)
+	return &sloRing{width: width, buckets: make([]sloCounts, n), epochs: make([]int64, n)}
+}
+
+func (r *sloRing) record(now time.Time, counts sloCounts) {
+	epoch := now.UnixNano() / int64(r.width)
+	slot := int(epoch % int64(len(r.buckets)))
+	if r.epochs[slot] != epoch {
+		r.epochs[slot] = epoch
+		r.buckets[slot] = sloCounts{}
+	}
+	r.buckets[slot].add(counts)
+}
+
+// sum adds up the buckets of the window ending now
+func (r *sloRing) sum(now time.Time, window time.Duration) sloCounts {
+	var total sloCounts
+	epoch := now.UnixNano() / int64(r.width)
+	n := min(int64(window/r.width), int64(len(r.buckets)))
+	for i := int64(0); i < n; i++ {
+		slot := int((epoch - i) % int64(len(r.buckets)))
+		if r.epochs[slot] == epoch-i {
+			total.add(r.buckets[slot])
+		}
+	}
+	return total
+}
+
+// sloGroupState holds the counts of one group. Minute buckets cover the burn
+// rate windows, hour buckets the budget window.
+type sloGroupState struct {
+	objective SLOObjective
+	minutes   *sloRing
+	hours     *sloRing
+}
+
+// SLOTracker counts good and bad requests per endpoint group and derives
+// error budgets, burn rates and alerts from them, so alerting rules only
+// need to look at inventory_slo_alert
+type SLOTracker struct {
+	mu     sync.Mutex
+	groups map[string]*sloGroupState
+	order  []string // Groups in configuration order
+	now    func() time.Time
+}
+
+// NewSLOTracker creates a tracker for objectives
+func NewSLOTracker(objectives []SLOObjective) *SLOTracker {
+	t := &SLOTracker{groups: make(map[string]*sloGroupState), now: time.Now}
+	longest := sloWindows[len(sloWindows)-1].duration
+	for _, objective := range objectives {
+		t.groups[objective.Group] = &sloGroupState{
+			objective: objective,
+			minutes:   newSLORing(time.Minute, longest),
+			hours:     newSLORing(time.Hour, SLOBudgetWindow),
+		}
+		t.order = append(t.order, objective.Group)
+	}
+	return t
+}
+
+// Record counts one request against the objectives of its group
+func (t *SLOTracker) Record(method, path string, statusCode int, duration time.Duration) {
+	state, ok := t.groups[SLOGroup(method, path)]
+	if !ok {
+		return
+	}
+	counts := sloCounts{total: 1}
+	if statusCode >= http.StatusInternalServerError {
+		counts.failed = 1
+	}
+	if state.objective.Latency > 0 && duration > state.objective.Latency {
+		counts.slow = 1
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	state.minutes.record(now, counts)
+	state.hours.record(now, counts)
+}
+
+// Status returns the budget, burn rates and alert of every objective
+func (t *SLOTracker) Status() models.SLOStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+
+	status := models.SLOStatus{BudgetWindow: SLOBudgetWindow.String(), Objectives: []models.SLOObjectiveStatus{}}
+	for _, group := range t.order {
+		state := t.groups[group]
+		status.Objectives = append(status.Objectives, state.status(now, models.SLOAvailability))
+		if state.objective.Latency > 0 {
+			status.Objectives = append(status.Objectives, state.status(now, models.SLOLatency))
+		}
+	}
+	return status
+}
+
+// status computes one objective of the group
+func (s *sloGroupState) status(now time.Time, slo string) models.SLOObjectiveStatus {
+	target := s.objective.Availability
+	bad := func(c sloCounts) int64 { return c.failed }
+	if slo == models.SLOLatency {
+		target = sloLatencyTarget
+		bad = func(c sloCounts) int64 { return c.slow }
+	}
+	allowed := 1 - target
+
+	budget := s.hours.sum(now, SLOBudgetWindow)
+	objective := models.SLOObjectiveStatus{
+		Group:                s.objective.Group,
+		SLO:                  slo,
+		Target:               target,
+		Requests:             budget.total,
+		BadRequests:          bad(budget),
+		ErrorBudgetRemaining: 1,
+		BurnRates:            make(map[string]float64, len(sloWindows)),
+	}
+	if slo == models.SLOLatency {
+		objective.LatencyThresholdMs = s.objective.Latency.Milliseconds()
+	}
+	if budget.total > 0 {
+		objective.ErrorBudgetRemaining = 1 - float64(bad(budget))/float64(budget.total)/allowed
+	}
+
+	for _, window := range sloWindows {
+		counts := s.minutes.sum(now, window.duration)
+		if counts.total > 0 {
+			objective.BurnRates[window.name] = float64(bad(counts)) / float64(counts.total) / allowed
+		} else {
+			objective.BurnRates[window.name] = 0
+		}
+	}
+	for _, rule := range sloAlertRules {
+		if objective.BurnRates[rule.long] > rule.threshold && objective.BurnRates[rule.short] > rule.threshold {
+			objective.Alert = rule.severity
+			break // Page rules come first
+		}
+	}
+	return objective
+}
+
+// SetSLOTracker makes the telemetry middleware count requests against
+// tracker and exposes its state as inventory_slo_burn_rate{group,slo,window},
+// inventory_slo_error_budget_remaining{group,slo} and
+// inventory_slo_alert{group,slo,severity}
+func (t *InventoryApiTelemetry) SetSLOTracker(tracker *SLOTracker) error {
+	if t.meter == nil {
+		return fmt.Errorf("telemetry not initialized")
+	}
+	t.sloTracker = tracker
+
+	burnRateGauge, err := t.meter.Float64ObservableGauge(
+		"inventory_slo_burn_rate",
+		metric.WithDescription("Rate the error budget is spent at over each window; 1 spends it exactly over the budget window"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Error("Failed to create SLO burn rate gauge", "error", err)
+		return fmt.Errorf("failed to create SLO burn rate gauge: %w", err)
+	}
+
+	budgetGauge, err := t.meter.Float64ObservableGauge(
+		"inventory_slo_error_budget_remaining",
+		metric.WithDescription("Share of the error budget left over the budget window; negative once overspent"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Error("Failed to create SLO error budget gauge", "error", err)
+		return fmt.Errorf("failed to create SLO error budget gauge: %w", err)
+	}
+
+	alertGauge, err := t.meter.Int64ObservableGauge(
+		"inventory_slo_alert",
+		metric.WithDescription("1 while a burn-rate alert of the severity fires for the objective"),
+		metric.WithUnit("1"),
+	)
+	if err != nil {
+		slog.Error("Failed to create SLO alert gauge", "error", err)
+		return fmt.Errorf("failed to create SLO alert gauge: %w", err)
+	}
+
+	_, err = t.meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		for _, objective := range tracker.Status().Objectives {
+			group := attribute.String("group", objective.Group)
+			slo := attribute.String("slo", objective.SLO)
+			for _, window := range sloWindows {
+				observer.ObserveFloat64(burnRateGauge, objective.BurnRates[window.name],
+					metric.WithAttributes(group, slo, attribute.String("window", window.name)))
+			}
+			observer.ObserveFloat64(budgetGauge, objective.ErrorBudgetRemaining, metric.WithAttributes(group, slo))
+			for _, severity := range []string{models.SLOAlertPage, models.SLOAlertTicket} {
+				var firing int64
+				if objective.Alert == severity {
+					firing = 1
+				}
+				observer.ObserveInt64(alertGauge, firing,
+					metric.WithAttributes(group, slo, attribute.String("severity", severity)))
+			}
+		}
+		return nil
+	}, burnRateGauge, budgetGauge, alertGauge)
+	if err != nil {
+		slog.Error("Failed to register SLO callback", "error", err)
+		return fmt.Errorf("failed to register SLO callback: %w", err)
+	}
+
+	return nil
+}
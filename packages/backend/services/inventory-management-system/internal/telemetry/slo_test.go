@@ -0,0 +1,153 @@
+package telemetry
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/models"
+)
+
+// newTestSLOTracker returns a tracker for writes at 99% and 100ms and for
+// events at 99.5%, with a clock the test moves
+func newTestSLOTracker(t *testing.T) (*SLOTracker, *time.Time) {
+	t.Helper()
+	objectives, err := ParseSLOObjectives("writes:99%:100ms, events:99.5")
+	if err != nil {
+		t.Fatalf("Failed to parse objectives: %v", err)
+	}
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	tracker := NewSLOTracker(objectives)
+	tracker.now = func() time.Time { return now }
+	return tracker, &now
+}
+
+// objectiveStatus finds one objective in status
+func objectiveStatus(t *testing.T, status models.SLOStatus, group, slo string) models.SLOObjectiveStatus {
+	t.Helper()
+	for _, objective := range status.Objectives {
+		if objective.Group == group && objective.SLO == slo {
+			return objective
+		}
+	}
+	t.Fatalf("No %s objective for %s in %+v", slo, group, status.Objectives)
+	return models.SLOObjectiveStatus{}
+}
+
+func TestParseSLOObjectives_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":          " , ",
+		"unknown group":  "checkout:99.9",
+		"duplicate":      "writes:99.9,writes:99",
+		"missing target": "writes",
+		"target of 100":  "writes:100",
+		"bad latency":    "writes:99.9:fast",
+		"extra field":    "writes:99.9:100ms:1",
+	}
+	for name, spec := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseSLOObjectives(spec); err == nil {
+				t.Errorf("Expected %q to be rejected", spec)
+			}
+		})
+	}
+}
+
+func TestSLOGroup(t *testing.T) {
+	tests := []struct {
+		method, path, group string
+	}{
+		{"POST", "/v1/inventory/updates", SLOGroupWrites},
+		{"POST", "/v2/orders", SLOGroupWrites},
+		{"GET", "/v1/inventory/SKU-001", SLOGroupReads},
+		{"POST", "/v1/inventory/batch-get", SLOGroupReads},
+		{"GET", "/v1/inventory/events", SLOGroupEvents},
+		{"POST", "/v1/inventory/events/commit", SLOGroupEvents},
+		{"PUT", "/v1/admin/maintenance", SLOGroupAdmin},
+		{"GET", "/health", ""},
+	}
+	for _, tt := range tests {
+		if got := SLOGroup(tt.method, tt.path); got != tt.group {
+			t.Errorf("SLOGroup(%s %s) = %q, want %q", tt.method, tt.path, got, tt.group)
+		}
+	}
+}
+
+func TestSLOTracker_BurnRatesAndBudget(t *testing.T) {
+	tracker, now := newTestSLOTracker(t)
+
+	// Two hours ago: 100 good writes. Now: 98 good, one failed, one slow.
+	*now = now.Add(-2 * time.Hour)
+	for i := 0; i < 100; i++ {
+		tracker.Record("POST", "/v1/inventory/updates", http.StatusOK, 10*time.Millisecond)
+	}
+	*now = now.Add(2 * time.Hour)
+	for i := 0; i < 98; i++ {
+		tracker.Record("POST", "/v1/inventory/updates", http.StatusOK, 10*time.Millisecond)
+	}
+	tracker.Record("POST", "/v1/inventory/updates", http.StatusServiceUnavailable, 10*time.Millisecond)
+	tracker.Record("POST", "/v1/inventory/updates", http.StatusOK, time.Second)
+	tracker.Record("GET", "/health", http.StatusInternalServerError, time.Second)
+
+	status := tracker.Status()
+	if status.BudgetWindow != SLOBudgetWindow.String() || len(status.Objectives) != 3 {
+		t.Fatalf("Expected writes availability and latency and events availability, got %+v", status)
+	}
+
+	availability := objectiveStatus(t, status, SLOGroupWrites, models.SLOAvailability)
+	if availability.Requests != 200 || availability.BadRequests != 1 {
+		t.Errorf("Expected 1 of 200 writes failed, got %d of %d", availability.BadRequests, availability.Requests)
+	}
+	// 1 failed of 100 in the last hour against a 1% budget burns at 1
+	if math.Abs(availability.BurnRates["1h"]-1) > 1e-9 {
+		t.Errorf("Expected a 1h burn rate of 1, got %v", availability.BurnRates["1h"])
+	}
+	// 1 failed of 200 over the budget window leaves half of the budget
+	if math.Abs(availability.ErrorBudgetRemaining-0.5) > 1e-9 {
+		t.Errorf("Expected half the budget left, got %v", availability.ErrorBudgetRemaining)
+	}
+
+	latency := objectiveStatus(t, status, SLOGroupWrites, models.SLOLatency)
+	if latency.Target != 0.99 || latency.LatencyThresholdMs != 100 || latency.BadRequests != 1 {
+		t.Errorf("Expected 1 write slower than the 100ms p99 target, got %+v", latency)
+	}
+
+	events := objectiveStatus(t, status, SLOGroupEvents, models.SLOAvailability)
+	if events.Requests != 0 || events.ErrorBudgetRemaining != 1 || events.BurnRates["5m"] != 0 {
+		t.Errorf("Expected an untouched events budget, got %+v", events)
+	}
+}
+
+func TestSLOTracker_Alerts(t *testing.T) {
+	tracker, now := newTestSLOTracker(t)
+
+	// A 20% failure rate for the last hour burns at 20 over 1h and 5m
+	start := now.Add(-time.Hour)
+	for minute := 0; minute < 60; minute++ {
+		*now = start.Add(time.Duration(minute+1) * time.Minute)
+		for i := 0; i < 5; i++ {
+			code := http.StatusOK
+			if i == 0 {
+				code = http.StatusInternalServerError
+			}
+			tracker.Record("POST", "/v1/inventory/updates", code, time.Millisecond)
+		}
+	}
+	if alert := objectiveStatus(t, tracker.Status(), SLOGroupWrites, models.SLOAvailability).Alert; alert != models.SLOAlertPage {
+		t.Errorf("Expected a page while failures continue, got %q", alert)
+	}
+
+	// Once the 5m and 30m windows are quiet the page stops; the burn over
+	// the longer windows still opens a ticket
+	for minute := 0; minute < 35; minute++ {
+		*now = now.Add(time.Minute)
+		tracker.Record("POST", "/v1/inventory/updates", http.StatusOK, time.Millisecond)
+	}
+	if alert := objectiveStatus(t, tracker.Status(), SLOGroupWrites, models.SLOAvailability).Alert; alert != models.SLOAlertTicket {
+		t.Errorf("Expected a ticket once the failures stop, got %q", alert)
+	}
+	if alert := objectiveStatus(t, tracker.Status(), SLOGroupWrites, models.SLOLatency).Alert; alert != "" {
+		t.Errorf("Expected no latency alert, got %q", alert)
+	}
+}
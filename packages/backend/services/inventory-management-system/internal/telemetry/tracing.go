@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"inventory-management-api/internal/requestid"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for all central API spans
+const TracerName = "inventory-management-api"
+
+// Initialize tracing depending on the TRACES_EXPORTER value ("grpc" or "none").
+// W3C trace context is always propagated so store requests join the same trace.
+func (t *Telemetry) InitTracing(serviceName string) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	tracesExporter := getEnvWithDefault("TRACES_EXPORTER", "grpc")
+	if tracesExporter == "none" {
+		slog.Info("Tracing export disabled")
+		return
+	}
+
+	// The URL to export is set via environment variable
+	// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT and if not set it is "localhost:4317".
+	// Sampling follows OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG.
+	exporter, err := otlptracegrpc.New(*t.ctx)
+	if err != nil {
+		slog.Error("Creating GRPC trace exporter", "error", err)
+
+		return
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the default name
+	res, err := resource.New(*t.ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("Creating trace resource, using default", "error", err)
+		res = resource.Default()
+	}
+
+	t.TracerProvider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(t.TracerProvider)
+	slog.Info("Starting tracing with grpc exporter", "service", serviceName)
+}
+
+// Tracer returns the central API tracer. It resolves through the global
+// provider, so spans are dropped until InitTracing has run.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// TracingMiddleware starts a server span for each request, continuing the
+// trace from the caller's traceparent header when present
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := GetEndpointFromPath(r.URL.Path)
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		ctx, span := Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		if storeID := r.Header.Get("X-Store-ID"); storeID != "" {
+			span.SetAttributes(attribute.String("store.id", storeID))
+		}
+		if requestID := requestid.FromContext(ctx); requestID != "" {
+			span.SetAttributes(attribute.String("http.request_id", requestID))
+		}
+
+		wrapper := &responseWriterWrapper{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+		}
+
+		next.ServeHTTP(wrapper, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", wrapper.statusCode))
+		if wrapper.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapper.statusCode))
+		}
+	})
+}
+
+// shutdownTracing flushes buffered spans and stops the tracer provider
+func (t *Telemetry) shutdownTracing(ctx context.Context) {
+	if t.TracerProvider == nil {
+		return
+	}
+	if err := t.TracerProvider.Shutdown(ctx); err != nil {
+		slog.Error("Shutting down tracer provider", "error", err)
+	}
+}
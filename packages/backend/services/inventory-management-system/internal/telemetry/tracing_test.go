@@ -0,0 +1,73 @@
+package telemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TestTracingMiddleware_ContinuesCallerTrace verifies that a request carrying a
+// traceparent header produces a server span in the caller's trace
+func TestTracingMiddleware_ContinuesCallerTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	var handlerSpan trace.SpanContext
+	router := mux.NewRouter()
+	router.Use(TracingMiddleware)
+	router.HandleFunc("/v1/inventory/{productId}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "GET /v1/inventory/{productId}" {
+		t.Errorf("Expected span named by route template, got %q", span.Name())
+	}
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("Expected server span, got %v", span.SpanKind())
+	}
+	if got := span.SpanContext().TraceID().String(); got != traceID {
+		t.Errorf("Expected trace ID %s from traceparent, got %s", traceID, got)
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("Expected handler context to carry the server span")
+	}
+
+	var status attribute.Value
+	for _, attr := range span.Attributes() {
+		if attr.Key == "http.response.status_code" {
+			status = attr.Value
+		}
+	}
+	if status.AsInt64() != http.StatusNotFound {
+		t.Errorf("Expected status code attribute 404, got %v", status.AsInt64())
+	}
+}
@@ -0,0 +1,178 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"inventory-management-api/internal/config"
+)
+
+// CertReloader serves a certificate/key pair from disk and picks up rotated
+// files without a restart. Files are checked for changes at most once per
+// interval, on the next handshake; a pair that fails to load is logged and the
+// previous certificate stays in use.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader loads the pair once; interval 0 disables reloading
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	reloader := &CertReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current(), nil
+}
+
+// current returns the certificate, reloading it first when the files changed
+func (r *CertReloader) current() *tls.Certificate {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.interval > 0 && time.Since(r.lastCheck) >= r.interval {
+		r.lastCheck = time.Now()
+		if r.changed() {
+			if err := r.loadLocked(); err != nil {
+				slog.Warn("Failed to reload TLS certificate, keeping the previous one",
+					"cert_file", r.certFile, "error", err)
+			} else {
+				slog.Info("Reloaded rotated TLS certificate", "cert_file", r.certFile)
+			}
+		}
+	}
+	return r.cert
+}
+
+// changed reports whether either file was modified since the last load
+func (r *CertReloader) changed() bool {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(r.certMod) || !keyInfo.ModTime().Equal(r.keyMod)
+}
+
+func (r *CertReloader) load() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastCheck = time.Now()
+	return r.loadLocked()
+}
+
+func (r *CertReloader) loadLocked() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat key: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate pair: %w", err)
+	}
+
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	return nil
+}
+
+// Client certificate modes for TLS_CLIENT_AUTH
+const (
+	ClientAuthOptional = "optional" // Verify certificates when presented, API keys still accepted
+	ClientAuthRequire  = "require"  // Every connection must present a valid certificate
+)
+
+// ServerConfig returns the TLS configuration for serving HTTPS, or nil when
+// no certificate is configured. With a client CA, store certificates signed
+// by it are verified and can authenticate requests instead of an API key.
+func ServerConfig(cfg *config.Config) (*tls.Config, error) {
+	if cfg.TLSCertFile == "" && cfg.TLSKeyFile == "" {
+		if cfg.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil, nil
+	}
+	if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+		return nil, fmt.Errorf("both TLS_CERT_FILE and TLS_KEY_FILE are required")
+	}
+
+	reloadInterval, err := time.ParseDuration(cfg.TLSReloadInterval)
+	if err != nil || reloadInterval < 0 {
+		slog.Warn("Invalid TLS reload interval, using default", "provided", cfg.TLSReloadInterval, "default", "1m")
+		reloadInterval = time.Minute
+	}
+
+	reloader, err := NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile, reloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pool, err := LoadCertPool(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+
+		switch cfg.TLSClientAuth {
+		case ClientAuthRequire:
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		case ClientAuthOptional, "":
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, fmt.Errorf("invalid TLS_CLIENT_AUTH %q, expected optional or require", cfg.TLSClientAuth)
+		}
+	}
+
+	slog.Info("TLS configured",
+		"cert_file", cfg.TLSCertFile,
+		"client_ca_file", cfg.TLSClientCAFile,
+		"client_auth", tlsConfig.ClientAuth.String(),
+		"reload_interval", reloadInterval)
+
+	return tlsConfig, nil
+}
+
+// LoadCertPool reads PEM certificates into a pool
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+	return pool, nil
+}
@@ -0,0 +1,136 @@
+// Package transfers keeps the history of stock transfers between stores in a
+// JSON file, so it survives restarts and event rotation.
+package transfers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"inventory-management-api/internal/models"
+)
+
+// Store is an append-only transfer history capped at maxEntries; the oldest
+// transfers are dropped first
+type Store struct {
+	path       string
+	maxEntries int
+	mutex      sync.RWMutex
+	transfers  []models.StockTransfer // Oldest first
+	nextID     int64
+}
+
+// fileData is the on-disk layout; NextID keeps IDs unique after old entries
+// were dropped
+type fileData struct {
+	NextID    int64                  `json:"nextId"`
+	Transfers []models.StockTransfer `json:"transfers"`
+}
+
+// NewStore loads the history saved at path; a missing file means no
+// transfers, and an empty path keeps the history in memory only
+func NewStore(path string, maxEntries int) (*Store, error) {
+	s := &Store{path: path, maxEntries: maxEntries, nextID: 1}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload replaces the history with the one saved at the path, for an
+// instance taking over the file from another
+func (s *Store) Reload() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read transfer history: %w", err)
+	}
+
+	var saved fileData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("failed to decode transfer history: %w", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.transfers = saved.Transfers
+	if saved.NextID > s.nextID {
+		s.nextID = saved.NextID
+	}
+	slog.Info("Transfer history loaded", "path", s.path, "count", len(s.transfers))
+	return nil
+}
+
+// Append assigns the transfer an ID, records it and saves the file. The
+// transfer already happened, so it stays in the history even when the save
+// fails; the error is returned for logging and the next save includes it.
+func (s *Store) Append(transfer models.StockTransfer) (models.StockTransfer, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	transfer.TransferID = fmt.Sprintf("tr-%06d", s.nextID)
+	s.nextID++
+	s.transfers = append(s.transfers, transfer)
+	if s.maxEntries > 0 && len(s.transfers) > s.maxEntries {
+		s.transfers = append([]models.StockTransfer(nil), s.transfers[len(s.transfers)-s.maxEntries:]...)
+	}
+
+	return transfer, s.saveLocked()
+}
+
+// List returns up to limit transfers, newest first, optionally narrowed to one
+// product and to transfers into or out of one store
+func (s *Store) List(productID, storeID string, limit int) []models.StockTransfer {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	result := make([]models.StockTransfer, 0)
+	for i := len(s.transfers) - 1; i >= 0; i-- {
+		transfer := s.transfers[i]
+		if productID != "" && transfer.ProductID != productID {
+			continue
+		}
+		if storeID != "" && transfer.FromStoreID != storeID && transfer.ToStoreID != storeID {
+			continue
+		}
+		result = append(result, transfer)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result
+}
+
+// saveLocked writes the history atomically (caller holds the write lock)
+func (s *Store) saveLocked() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(fileData{NextID: s.nextID, Transfers: s.transfers}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transfer history: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create transfer history directory: %w", err)
+	}
+
+	tempPath := s.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write transfer history: %w", err)
+	}
+	if err := os.Rename(tempPath, s.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store transfer history: %w", err)
+	}
+	return nil
+}
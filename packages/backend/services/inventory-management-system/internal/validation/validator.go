@@ -0,0 +1,424 @@
+// Package validation checks request models against their `validate` struct
+// tags and returns problems as ErrorDetail lists for the standard error envelope.
+//
+// Supported rules (comma separated, go-playground style):
+//
+//	required      strings non-blank, slices non-empty, pointers non-nil
+//	omitempty     skip the remaining rules when the value is the zero value
+//	min=N, max=N  numeric bounds, or length bounds for strings and slices
+//	iso4217       ISO 4217 currency code (case-insensitive)
+//	idempotencykey  UUIDv4 or ULID (see package idempotency)
+//	oneof=A B     one of the space-separated values
+//	url           absolute http or https URL with a host and no credentials
+//	checksum      sha256: followed by 64 hex digits
+//	unique=Field  no two slice elements share the same Field value
+//	dive          apply the remaining rules to each slice element
+//
+// Nested structs and slices of structs are always validated. Rules on pointer
+// fields other than required only run when the pointer is set. Types that need
+// cross-field checks implement StructValidator.
+//
+// The tags of a type are checked the first time it is validated; servers call
+// CheckRules at startup so a malformed tag stops the start instead.
+package validation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"inventory-management-api/internal/currency"
+	"inventory-management-api/internal/idempotency"
+	"inventory-management-api/internal/models"
+)
+
+// StructValidator is implemented by models with rules spanning several fields.
+// Returned field names are relative to the struct and get prefixed by the caller.
+type StructValidator interface {
+	ValidateStruct() []models.ErrorDetail
+}
+
+// Validate checks v and returns every violation found, or nil when v is valid
+func Validate(v interface{}) []models.ErrorDetail {
+	return ValidateAt("", v)
+}
+
+// ValidateAt validates v, prefixing field paths with path
+func ValidateAt(path string, v interface{}) []models.ErrorDetail {
+	var details []models.ErrorDetail
+	validateValue(path, reflect.ValueOf(v), &details)
+	return details
+}
+
+func validateValue(path string, value reflect.Value, details *[]models.ErrorDetail) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		validateStruct(path, value, details)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(indexPath(path, i), value.Index(i), details)
+		}
+	}
+}
+
+func validateStruct(path string, value reflect.Value, details *[]models.ErrorDetail) {
+	t := value.Type()
+	if err := checkType(t); err != nil {
+		slog.Error("Request model has invalid validation rules", "type", t.String(), "error", err)
+		addDetail(details, path, "Request cannot be validated")
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldPath := joinPath(path, jsonName(field))
+		fieldValue := value.Field(i)
+
+		rules, elemRules := splitDive(field.Tag.Get("validate"))
+		if applyRules(fieldPath, fieldValue, rules, details) {
+			if elemRules != nil {
+				for j := 0; j < fieldValue.Len(); j++ {
+					applyRules(indexPath(fieldPath, j), fieldValue.Index(j), elemRules, details)
+				}
+			}
+			validateValue(fieldPath, fieldValue, details)
+		}
+	}
+
+	if value.CanAddr() {
+		if sv, ok := value.Addr().Interface().(StructValidator); ok {
+			appendPrefixed(path, sv.ValidateStruct(), details)
+			return
+		}
+	}
+	if sv, ok := value.Interface().(StructValidator); ok {
+		appendPrefixed(path, sv.ValidateStruct(), details)
+	}
+}
+
+// applyRules checks value against rules; it returns false when a required
+// value is missing so nested checks are skipped for that field
+func applyRules(path string, value reflect.Value, rules []string, details *[]models.ErrorDetail) bool {
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "" {
+			continue
+		}
+
+		if name == "required" {
+			if isMissing(value) {
+				addDetail(details, path, fmt.Sprintf("%s is required", fieldLabel(path)))
+				return false
+			}
+			continue
+		}
+		if name == "omitempty" {
+			if value.IsZero() {
+				return true
+			}
+			continue
+		}
+
+		target := value
+		if target.Kind() == reflect.Ptr {
+			if target.IsNil() {
+				continue
+			}
+			target = target.Elem()
+		}
+
+		switch name {
+		case "min", "max":
+			checkBound(path, target, name, param, details)
+		case "iso4217":
+			code := strings.ToUpper(target.String())
+			if !currency.IsValidCode(code) {
+				addDetail(details, path, fmt.Sprintf("Invalid ISO 4217 currency code: %q", target.String()))
+			}
+		case "idempotencykey":
+			if !idempotency.Valid(target.String()) {
+				addDetail(details, path, fmt.Sprintf("%s must be a UUIDv4 or a ULID", fieldLabel(path)))
+			}
+		case "oneof":
+			if !slices.Contains(strings.Fields(param), target.String()) {
+				addDetail(details, path, fmt.Sprintf("%s must be one of: %s", fieldLabel(path), strings.Join(strings.Fields(param), ", ")))
+			}
+		case "url":
+			if !isHTTPURL(target.String()) {
+				addDetail(details, path, fmt.Sprintf("%s must be an absolute http or https URL without credentials", fieldLabel(path)))
+			}
+		case "checksum":
+			if !isSHA256Checksum(target.String()) {
+				addDetail(details, path, fmt.Sprintf("%s must be sha256: followed by 64 hex digits", fieldLabel(path)))
+			}
+		case "unique":
+			checkUnique(path, target, param, details)
+		}
+	}
+	return true
+}
+
+func checkBound(path string, value reflect.Value, rule, param string, details *[]models.ErrorDetail) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return // Refused by checkType
+	}
+
+	var actual float64
+	isLength := false
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(value.Len())
+		isLength = true
+	default:
+		return
+	}
+
+	label := fieldLabel(path)
+	switch {
+	case rule == "min" && actual < limit && isLength:
+		addDetail(details, path, fmt.Sprintf("%s must have at least %s items", label, param))
+	case rule == "min" && actual < limit && limit == 0:
+		addDetail(details, path, fmt.Sprintf("%s cannot be negative", label))
+	case rule == "min" && actual < limit:
+		addDetail(details, path, fmt.Sprintf("%s must be at least %s", label, param))
+	case rule == "max" && actual > limit && isLength:
+		addDetail(details, path, fmt.Sprintf("%s must have at most %s items", label, param))
+	case rule == "max" && actual > limit:
+		addDetail(details, path, fmt.Sprintf("%s must be at most %s", label, param))
+	}
+}
+
+// checkedTypes caches checkType results: reflect.Type -> error, nil when valid
+var checkedTypes sync.Map
+
+// CheckRules reports the first unknown rule or malformed rule parameter in the
+// validate tags of the types of values and the types nested in them
+func CheckRules(values ...interface{}) error {
+	for _, v := range values {
+		if err := checkType(reflect.TypeOf(v)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkType checks the validate tags of t and the types nested in it, once per type
+func checkType(t reflect.Type) error {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	if cached, ok := checkedTypes.Load(t); ok {
+		err, _ := cached.(error)
+		return err
+	}
+
+	// Mark the type first so a type nesting itself ends the recursion
+	checkedTypes.Store(t, nil)
+	err := checkStructRules(t)
+	checkedTypes.Store(t, err)
+	return err
+}
+
+func checkStructRules(t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		where := t.String() + "." + field.Name
+		rules, elemRules := splitDive(field.Tag.Get("validate"))
+		if err := checkFieldRules(where, field.Type, rules); err != nil {
+			return err
+		}
+		if elemRules != nil {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+				return fmt.Errorf("validation: dive on %s, which is not a slice", where)
+			}
+			if err := checkFieldRules(where+"[]", fieldType.Elem(), elemRules); err != nil {
+				return err
+			}
+		}
+		if err := checkType(field.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkFieldRules(where string, fieldType reflect.Type, rules []string) error {
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "", "required", "omitempty", "iso4217", "idempotencykey", "url", "checksum":
+		case "min", "max":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				return fmt.Errorf("validation: invalid %s parameter %q on %s", name, param, where)
+			}
+		case "oneof":
+			if len(strings.Fields(param)) == 0 {
+				return fmt.Errorf("validation: oneof without values on %s", where)
+			}
+		case "unique":
+			if param == "" {
+				continue
+			}
+			elemType := fieldType
+			for elemType.Kind() == reflect.Ptr || elemType.Kind() == reflect.Slice || elemType.Kind() == reflect.Array {
+				elemType = elemType.Elem()
+			}
+			if elemType.Kind() != reflect.Struct {
+				return fmt.Errorf("validation: unique=%s on %s, whose elements are not structs", param, where)
+			}
+			if _, ok := elemType.FieldByName(param); !ok {
+				return fmt.Errorf("validation: unique=%s on %s names no field of %s", param, where, elemType)
+			}
+		default:
+			return fmt.Errorf("validation: unknown rule %q on %s", name, where)
+		}
+	}
+	return nil
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL with a host.
+// Credentials are refused so they never end up in events sent to stores.
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" || u.User != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// isSHA256Checksum reports whether s is "sha256:" and a hex SHA-256 digest
+func isSHA256Checksum(s string) bool {
+	digest, ok := strings.CutPrefix(s, "sha256:")
+	if !ok || len(digest) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(digest)
+	return err == nil
+}
+
+func checkUnique(path string, value reflect.Value, fieldName string, details *[]models.ErrorDetail) {
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return
+	}
+
+	seen := make(map[string]bool, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		elem := reflect.Indirect(value.Index(i))
+		if fieldName != "" {
+			elem = elem.FieldByName(fieldName)
+		}
+		key := strings.ToUpper(fmt.Sprint(elem.Interface()))
+		if seen[key] {
+			addDetail(details, indexPath(path, i), fmt.Sprintf("Duplicate %s: %s", fieldLabel(path), key))
+		}
+		seen[key] = true
+	}
+}
+
+func isMissing(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return value.IsNil()
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+// splitDive separates field rules from the element rules following "dive"
+func splitDive(tag string) (rules []string, elemRules []string) {
+	if tag == "" {
+		return nil, nil
+	}
+	parts := strings.Split(tag, ",")
+	for i, part := range parts {
+		if part == "dive" {
+			return parts[:i], append([]string{}, parts[i+1:]...)
+		}
+	}
+	return parts, nil
+}
+
+func appendPrefixed(path string, found []models.ErrorDetail, details *[]models.ErrorDetail) {
+	for _, detail := range found {
+		detail.Field = joinPath(path, detail.Field)
+		*details = append(*details, detail)
+	}
+}
+
+func addDetail(details *[]models.ErrorDetail, path, issue string) {
+	*details = append(*details, models.ErrorDetail{Field: path, Issue: issue})
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "" {
+		return path
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, index int) string {
+	return path + "[" + strconv.Itoa(index) + "]"
+}
+
+// fieldLabel returns the last path segment without indexes, e.g.
+// "products[2].available" -> "available"
+func fieldLabel(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		path = path[i+1:]
+	}
+	if i := strings.Index(path, "["); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		return "value"
+	}
+	return path
+}
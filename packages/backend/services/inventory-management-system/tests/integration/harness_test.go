@@ -0,0 +1,165 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/testutil"
+)
+
+// API keys every service started by the harness accepts
+const (
+	apiKey   = testutil.TestAPIKey
+	adminKey = testutil.TestAdminKey
+)
+
+// harnessStores are the stores startStore may boot; central binds each one
+// its own key, as event consumers must authenticate as their store
+var harnessStores = []string{"store-s1", "store-s2", "store-s3", "store-s4"}
+
+// product is the part of a product both APIs return that convergence compares
+type product struct {
+	ProductID string `json:"productId"`
+	Available int    `json:"available"`
+	Version   int    `json:"version"`
+}
+
+// startCentral boots a central API seeded with products
+func startCentral(t *testing.T, products ...models.Product) *testutil.TestCentralAPI {
+	t.Helper()
+	return testutil.StartTestCentralAPI(t, testutil.WithProducts(products...), testutil.WithStores(harnessStores...))
+}
+
+// startStore boots a store service syncing from central
+func startStore(t *testing.T, central *testutil.TestCentralAPI, storeID string) *testutil.TestStoreAPI {
+	t.Helper()
+	return testutil.StartTestStore(t, central, storeID)
+}
+
+// call sends a request with key and decodes a JSON response into out; it
+// returns 0 when the service could not be reached
+func call(t *testing.T, service *testutil.Process, method, path, key string, body, out interface{}) (int, []byte) {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Failed to encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, service.BaseURL+path, reader)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			t.Fatalf("Failed to decode %s %s response %q: %v", method, path, data, err)
+		}
+	}
+	return resp.StatusCode, data
+}
+
+// centralProduct reads a product from the central API
+func centralProduct(t *testing.T, central *testutil.TestCentralAPI, productID string) (product, bool) {
+	t.Helper()
+
+	var p product
+	status, _ := call(t, central.Process, "GET", "/v1/inventory/"+productID, apiKey, nil, &p)
+	return p, status == http.StatusOK
+}
+
+// storeProduct reads a product from a store's cache
+func storeProduct(t *testing.T, store *testutil.TestStoreAPI, productID string) (product, bool) {
+	t.Helper()
+
+	var p product
+	status, _ := call(t, store.Process, "GET", "/v1/store/inventory/"+productID, store.APIKey, nil, &p)
+	return p, status == http.StatusOK
+}
+
+// sell sells units of a product through a store at the version it last saw
+func sell(t *testing.T, store *testutil.TestStoreAPI, productID string, units int) product {
+	t.Helper()
+
+	current, ok := storeProduct(t, store, productID)
+	if !ok {
+		t.Fatalf("%s has no %s", store.StoreID, productID)
+	}
+	var resp struct {
+		Applied     bool `json:"applied"`
+		NewQuantity int  `json:"newQuantity"`
+		NewVersion  int  `json:"newVersion"`
+	}
+	status, body := call(t, store.Process, "POST", "/v1/store/inventory/updates", store.APIKey, map[string]interface{}{
+		"productId":      productID,
+		"delta":          -units,
+		"version":        current.Version,
+		"idempotencyKey": idempotency.NewKey(),
+	}, &resp)
+	if status != http.StatusOK || !resp.Applied {
+		t.Fatalf("Expected %s to sell %d of %s, got %d: %s", store.StoreID, units, productID, status, body)
+	}
+	return product{ProductID: productID, Available: resp.NewQuantity, Version: resp.NewVersion}
+}
+
+// awaitConvergence waits until every store holds the central API's stock and
+// version of each product
+func awaitConvergence(t *testing.T, central *testutil.TestCentralAPI, productIDs []string, stores ...*testutil.TestStoreAPI) {
+	t.Helper()
+
+	eventually(t, 30*time.Second, func() (bool, string) {
+		for _, productID := range productIDs {
+			want, ok := centralProduct(t, central, productID)
+			if !ok {
+				return false, "central API has no " + productID
+			}
+			for _, store := range stores {
+				got, ok := storeProduct(t, store, productID)
+				if !ok || got != want {
+					return false, fmt.Sprintf("%s has %s at %+v, central API at %+v", store.StoreID, productID, got, want)
+				}
+			}
+		}
+		return true, ""
+	})
+}
+
+// eventually polls condition until it holds or timeout passes
+func eventually(t *testing.T, timeout time.Duration, condition func() (bool, string)) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, message := condition()
+		if ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out after %v: %s", timeout, message)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
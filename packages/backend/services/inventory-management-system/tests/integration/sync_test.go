@@ -0,0 +1,79 @@
+//go:build integration
+
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// seedCatalog is the inventory every end-to-end test starts from
+var seedCatalog = []models.Product{
+	{ProductID: "SKU-001", Name: "Phone", Available: 50, Version: 1, LastUpdated: seedTime, Price: 99.99},
+	{ProductID: "SKU-002", Name: "Laptop", Available: 20, Version: 1, LastUpdated: seedTime, Price: 1299.99},
+}
+
+var seedTime = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var seedProductIDs = []string{"SKU-001", "SKU-002"}
+
+// TestE2E_UpdateReachesEveryStore tests that a sale through one store is
+// applied centrally, published as an event and applied by the other store
+func TestE2E_UpdateReachesEveryStore(t *testing.T) {
+	central := startCentral(t, seedCatalog...)
+	store1 := startStore(t, central, "store-s1")
+	store2 := startStore(t, central, "store-s2")
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+
+	sold := sell(t, store1, "SKU-001", 3)
+	if sold.Available != 47 || sold.Version != 2 {
+		t.Fatalf("Expected SKU-001 at 47 units, version 2, got %+v", sold)
+	}
+	if got, _ := centralProduct(t, central, "SKU-001"); got != sold {
+		t.Errorf("Expected the central API to hold %+v, got %+v", sold, got)
+	}
+
+	events := central.Events(t, 0)
+	if len(events) != 1 || events[0].ProductID != "SKU-001" || events[0].StoreID != "store-s1" || events[0].Version != 2 {
+		t.Errorf("Expected one SKU-001 event from store-s1 at version 2, got %+v", events)
+	}
+
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+}
+
+// TestE2E_StoreConvergesAfterRestart tests that a store killed while other
+// stores and admins change the inventory catches up once restarted
+func TestE2E_StoreConvergesAfterRestart(t *testing.T) {
+	central := startCentral(t, seedCatalog...)
+	store1 := startStore(t, central, "store-s1")
+	store2 := startStore(t, central, "store-s2")
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+
+	sell(t, store2, "SKU-002", 2)
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+
+	store2.Crash(t)
+
+	sell(t, store1, "SKU-001", 5)
+	sell(t, store1, "SKU-002", 1)
+	available := 100
+	status, body := call(t, central.Process, "PUT", "/v1/admin/products/set", adminKey, map[string]interface{}{
+		"products": []map[string]interface{}{{"productId": "SKU-001", "available": available}},
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected the admin restock to succeed, got %d: %s", status, body)
+	}
+
+	store2.Start(t)
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+
+	// The restarted store sells at the versions it caught up to
+	sold := sell(t, store2, "SKU-001", 1)
+	if sold.Available != 99 {
+		t.Errorf("Expected SKU-001 at 99 units after the restock, got %+v", sold)
+	}
+	awaitConvergence(t, central, seedProductIDs, store1, store2)
+}
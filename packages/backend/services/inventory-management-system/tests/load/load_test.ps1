@@ -57,8 +57,8 @@ function Generate-DynamicPayload {
         return $null
     }
 
-    $randomId = [DateTimeOffset]::Now.ToUnixTimeMilliseconds()
-    $idempotencyKey = "$TestName-$ProductId-$randomId"
+    # The API accepts UUIDv4 or ULID idempotency keys
+    $idempotencyKey = [guid]::NewGuid().ToString()
 
     $payload = @{
         storeId = "store-1"
@@ -75,8 +75,6 @@ function Generate-DynamicPayload {
 function Generate-BatchPayload {
     param($TestName)
 
-    $randomId = [DateTimeOffset]::Now.ToUnixTimeMilliseconds()
-
     $sku001Version = Get-ProductVersion -ProductId "SKU-001"
     $sku002Version = Get-ProductVersion -ProductId "SKU-002"
     $sku003Version = Get-ProductVersion -ProductId "SKU-003"
@@ -93,19 +91,19 @@ function Generate-BatchPayload {
                 productId = "SKU-001"
                 delta = 1
                 version = $sku001Version
-                idempotencyKey = "$TestName-sku001-$randomId"
+                idempotencyKey = [guid]::NewGuid().ToString()
             },
             @{
                 productId = "SKU-002"
                 delta = 1
                 version = $sku002Version
-                idempotencyKey = "$TestName-sku002-$randomId"
+                idempotencyKey = [guid]::NewGuid().ToString()
             },
             @{
                 productId = "SKU-003"
                 delta = 1
                 version = $sku003Version
-                idempotencyKey = "$TestName-sku003-$randomId"
+                idempotencyKey = [guid]::NewGuid().ToString()
             }
         )
     }
@@ -163,13 +161,12 @@ for ($i = 1; $i -le $ConcurrentClients; $i++) {
     $job = Start-Job -ScriptBlock {
         param($ServerUrl, $ApiKey, $Version, $RequestId)
 
-        $randomId = [DateTimeOffset]::Now.ToUnixTimeMilliseconds()
         $payload = @{
             storeId = "store-1"
             productId = "SKU-002"
             delta = 1
             version = $Version
-            idempotencyKey = "conflict-test-$RequestId-$randomId"
+            idempotencyKey = [guid]::NewGuid().ToString()
         } | ConvertTo-Json -Compress
 
         try {
@@ -44,6 +44,15 @@ get_product_version() {
     fi
 }
 
+# Function to generate an idempotency key (the API accepts UUIDv4 or ULID)
+new_idempotency_key() {
+    if [ -r /proc/sys/kernel/random/uuid ]; then
+        cat /proc/sys/kernel/random/uuid
+    else
+        uuidgen | tr '[:upper:]' '[:lower:]'
+    fi
+}
+
 # Function to generate dynamic payload
 generate_dynamic_payload() {
     local product_id=$1
@@ -56,13 +65,12 @@ generate_dynamic_payload() {
         return 1
     fi
 
-    local random_id=$(date +%s%N | cut -b1-13)
-    local idempotency_key="${test_name}-${product_id}-${random_id}"
+    local idempotency_key=$(new_idempotency_key)
 
     echo "{\"storeId\":\"store-1\",\"productId\":\"$product_id\",\"delta\":$delta,\"version\":$version,\"idempotencyKey\":\"$idempotency_key\"}"
 }
 
-# Function to generate conflict test payload with placeholder for unique idempotency keys
+# Function to generate conflict test payload
 generate_conflict_payload_template() {
     local product_id=$1
     local delta=$2
@@ -74,14 +82,13 @@ generate_conflict_payload_template() {
         return 1
     fi
 
-    # Use placeholder that will be replaced by hey with unique values
-    echo "{\"storeId\":\"store-1\",\"productId\":\"$product_id\",\"delta\":$delta,\"version\":$version,\"idempotencyKey\":\"$test_name-$product_id-{{.RequestNumber}}\"}"
+    # hey sends the same body every time, so all requests share one key
+    echo "{\"storeId\":\"store-1\",\"productId\":\"$product_id\",\"delta\":$delta,\"version\":$version,\"idempotencyKey\":\"$(new_idempotency_key)\"}"
 }
 
 # Function to generate dynamic batch payload
 generate_batch_payload() {
     local test_name=$1
-    local random_id=$(date +%s%N | cut -b1-13)
 
     local sku001_version=$(get_product_version "SKU-001")
     local sku002_version=$(get_product_version "SKU-002")
@@ -100,19 +107,19 @@ generate_batch_payload() {
       "productId": "SKU-001",
       "delta": 1,
       "version": $sku001_version,
-      "idempotencyKey": "${test_name}-sku001-${random_id}"
+      "idempotencyKey": "$(new_idempotency_key)"
     },
     {
       "productId": "SKU-002",
       "delta": 1,
       "version": $sku002_version,
-      "idempotencyKey": "${test_name}-sku002-${random_id}"
+      "idempotencyKey": "$(new_idempotency_key)"
     },
     {
       "productId": "SKU-003",
       "delta": 1,
       "version": $sku003_version,
-      "idempotencyKey": "${test_name}-sku003-${random_id}"
+      "idempotencyKey": "$(new_idempotency_key)"
     }
   ]
 }
@@ -165,11 +172,10 @@ echo "Starting $CONCURRENT_CLIENTS concurrent requests..."
 
 for i in $(seq 1 $CONCURRENT_CLIENTS); do
     {
-        local_random=$(date +%s%N | tail -c 6)
         curl -s -X POST \
             -H "X-API-Key: $API_KEY" \
             -H "Content-Type: application/json" \
-            -d "{\"storeId\":\"store-1\",\"productId\":\"SKU-002\",\"delta\":1,\"version\":$SKU002_VERSION,\"idempotencyKey\":\"conflict-test-$i-$local_random\"}" \
+            -d "{\"storeId\":\"store-1\",\"productId\":\"SKU-002\",\"delta\":1,\"version\":$SKU002_VERSION,\"idempotencyKey\":\"$(new_idempotency_key)\"}" \
             "$SERVER_URL/v1/inventory/updates" \
             -w "Request $i: HTTP %{http_code} - %{json}\n"
     } &
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"inventory-management-api/internal/cache"
+	"inventory-management-api/internal/clock"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -149,41 +150,34 @@ func TestTTLCache_Size(t *testing.T) {
 // TestTTLCache_Expiration tests that items expire after TTL
 func TestTTLCache_Expiration(t *testing.T) {
 	// Arrange
-	shortTTL := 100 * time.Millisecond
-	ttlCache := cache.NewTTLCache(shortTTL, 50*time.Millisecond)
+	ttl := time.Minute
+	ttlCache := cache.NewTTLCache(ttl, time.Hour)
 	defer ttlCache.Stop()
-	
+	manual := clock.NewManual(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	ttlCache.SetClock(manual)
+
 	key := "expiring-key"
 	value := "expiring-value"
-	
+
 	// Act
 	ttlCache.Set(key, value)
-	
-	// Verify item exists immediately
+
+	// Verify item exists immediately and until the TTL has passed
 	retrievedValue, exists := ttlCache.Get(key)
 	assert.True(t, exists, "Key should exist immediately after setting")
 	assert.Equal(t, value, retrievedValue, "Value should match immediately after setting")
-	
-	// Wait for expiration
-	time.Sleep(shortTTL + 100*time.Millisecond)
-	
-	// Assert - Item should eventually be cleaned up
-	// Note: We give it some time for the cleanup goroutine to run
-	eventually := func() bool {
-		_, exists := ttlCache.Get(key)
-		return !exists
-	}
-	
-	// Wait up to 1 second for cleanup
-	for i := 0; i < 10; i++ {
-		if eventually() {
-			break
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	
+
+	manual.Advance(ttl - time.Second)
+	_, exists = ttlCache.Get(key)
+	assert.True(t, exists, "Key should exist until its TTL has passed")
+	assert.Equal(t, 1, ttlCache.ActiveSize())
+
+	// Assert - Item expires once the clock moves past the TTL
+	manual.Advance(2 * time.Second)
 	_, exists = ttlCache.Get(key)
 	assert.False(t, exists, "Key should expire after TTL")
+	assert.Equal(t, 0, ttlCache.ActiveSize())
+	assert.Equal(t, 1, ttlCache.GetStats()["expired_entries"])
 }
 
 // TestTTLCache_GetStats tests cache statistics
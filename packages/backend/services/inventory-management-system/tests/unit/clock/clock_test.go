@@ -0,0 +1,73 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/clock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestTimestamp_UTC tests that timestamps are UTC with whole seconds whatever
+// the zone of the clock
+func TestTimestamp_UTC(t *testing.T) {
+	zone := time.FixedZone("UTC-5", -5*60*60)
+	restore := clock.Set(clock.Fixed(time.Date(2026, 3, 1, 4, 30, 0, 987654321, zone)))
+	defer restore()
+
+	assert.Equal(t, "2026-03-01T09:30:00Z", clock.Timestamp())
+	assert.Equal(t, time.UTC, clock.Now().Location())
+
+	restore()
+	assert.WithinDuration(t, time.Now(), clock.Now(), time.Second, "Restoring should bring back the wall clock")
+}
+
+// TestNormalize tests rewriting timestamps written in other formats
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "2024-01-15T15:00:00Z", clock.Normalize("2024-01-15T10:00:00-05:00"))
+	assert.Equal(t, "2024-01-15T10:00:00Z", clock.Normalize("2024-01-15T10:00:00.123456Z"))
+	assert.Equal(t, "2024-01-15T10:00:00Z", clock.Normalize("2024-01-15T10:00:00Z"))
+	assert.Equal(t, "not a time", clock.Normalize("not a time"), "Unparseable values should be kept")
+	assert.Equal(t, "", clock.Normalize(""))
+}
+
+// TestManual_Advance tests that a manual clock fires waits only once advanced
+// past them
+func TestManual_Advance(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	manual := clock.NewManual(start)
+
+	soon := manual.After(time.Second)
+	later := manual.After(time.Minute)
+	assert.Equal(t, 2, manual.Waiters())
+
+	manual.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), manual.Now())
+	select {
+	case at := <-soon:
+		assert.Equal(t, start.Add(time.Second), at)
+	default:
+		t.Fatal("Expected the one second wait to fire")
+	}
+	select {
+	case <-later:
+		t.Fatal("Expected the one minute wait to be pending")
+	default:
+	}
+	assert.Equal(t, 1, manual.Waiters())
+
+	manual.Advance(time.Hour)
+	select {
+	case <-later:
+	default:
+		t.Fatal("Expected the one minute wait to fire")
+	}
+	assert.Equal(t, 0, manual.Waiters())
+
+	select {
+	case <-manual.After(0):
+	default:
+		t.Fatal("Expected a zero wait to fire at once")
+	}
+}
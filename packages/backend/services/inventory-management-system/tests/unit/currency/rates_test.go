@@ -0,0 +1,57 @@
+package currency
+
+import (
+	"testing"
+
+	"inventory-management-api/internal/currency"
+	"inventory-management-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMinorUnits_RoundTrip tests conversion between decimal amounts and minor units
+func TestMinorUnits_RoundTrip(t *testing.T) {
+	assert.Equal(t, int64(89999), currency.ToMinorUnits(899.99, "USD"))
+	assert.Equal(t, int64(1500), currency.ToMinorUnits(1500, "JPY"))
+	assert.Equal(t, int64(1250), currency.ToMinorUnits(1.25, "KWD"))
+
+	assert.InDelta(t, 899.99, currency.FromMinorUnits(89999, "USD"), 0.0001)
+	assert.InDelta(t, 1500.0, currency.FromMinorUnits(1500, "JPY"), 0.0001)
+}
+
+// TestParseRates tests parsing of the rates configuration string
+func TestParseRates(t *testing.T) {
+	rates, err := currency.ParseRates("EUR:0.92, mxn:17.1")
+	require.NoError(t, err)
+	assert.Equal(t, 0.92, rates["EUR"])
+	assert.Equal(t, 17.1, rates["MXN"])
+
+	_, err = currency.ParseRates("EUR=0.92")
+	assert.Error(t, err, "Malformed entries should be rejected")
+
+	_, err = currency.ParseRates("EURO:0.92")
+	assert.Error(t, err, "Non ISO codes should be rejected")
+
+	_, err = currency.ParseRates("EUR:-1")
+	assert.Error(t, err, "Non-positive rates should be rejected")
+}
+
+// TestConvert tests conversion through the base currency
+func TestConvert(t *testing.T) {
+	provider := currency.NewStaticRatesProvider("USD", map[string]float64{
+		"EUR": 0.5,
+		"JPY": 100,
+	})
+
+	converted, err := currency.Convert(provider, models.Money{Amount: 1000, Currency: "USD"}, "EUR")
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{Amount: 500, Currency: "EUR"}, converted)
+
+	converted, err = currency.Convert(provider, models.Money{Amount: 500, Currency: "EUR"}, "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, models.Money{Amount: 1000, Currency: "JPY"}, converted)
+
+	_, err = currency.Convert(provider, models.Money{Amount: 100, Currency: "USD"}, "GBP")
+	assert.ErrorIs(t, err, currency.ErrUnsupportedCurrency, "Unsupported target currency should fail")
+}
@@ -0,0 +1,141 @@
+package eventfilters
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"inventory-management-api/internal/eventfilters"
+	"inventory-management-api/internal/models"
+)
+
+func event(productID, category string) models.Event {
+	return models.Event{
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Data:      models.ProductResponse{ProductID: productID, Category: category},
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	if eventfilters.NewMatcher([]string{" "}, nil) != nil {
+		t.Error("Expected a nil matcher for blank filters")
+	}
+
+	matcher := eventfilters.NewMatcher([]string{"SKU-1"}, nil).
+		Merge(eventfilters.NewMatcher(nil, []string{" Phones "}))
+
+	tests := []struct {
+		name     string
+		event    models.Event
+		expected bool
+	}{
+		{"listed product", event("SKU-1", "tv"), true},
+		{"category case-insensitive", event("SKU-2", "PHONES"), true},
+		{"other product", event("SKU-3", "tv"), false},
+		{"system event", models.Event{EventType: models.EventTypeSystemRestored}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matcher.Matches(tt.event); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	var all *eventfilters.Matcher
+	if len(all.Filter([]models.Event{event("SKU-3", "tv")})) != 1 {
+		t.Error("Expected a nil matcher to keep every event")
+	}
+}
+
+// TestMatcher_ProductLeavingFilter tests that a product moved out of a
+// filtered category reaches the store as a removal
+func TestMatcher_ProductLeavingFilter(t *testing.T) {
+	matcher := eventfilters.NewMatcher([]string{"SKU-1"}, []string{"phones", "tablets"})
+	moved := func(productID, from, to string) models.Event {
+		e := event(productID, to)
+		e.PreviousCategory = from
+		return e
+	}
+
+	tests := []struct {
+		name     string
+		event    models.Event
+		expected string // Event type passed on, "" when filtered out
+	}{
+		{"left the filter", moved("SKU-2", "Phones", "tv"), models.EventTypeProductDeleted},
+		{"moved within the filter", moved("SKU-2", "phones", "tablets"), models.EventTypeProductUpdated},
+		{"moved into the filter", moved("SKU-2", "tv", "phones"), models.EventTypeProductUpdated},
+		{"listed product", moved("SKU-1", "phones", "tv"), models.EventTypeProductUpdated},
+		{"moved outside the filter", moved("SKU-2", "tv", "radio"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := matcher.Filter([]models.Event{tt.event})
+			switch {
+			case tt.expected == "" && len(filtered) != 0:
+				t.Errorf("Expected the event to be filtered out, got %+v", filtered)
+			case tt.expected != "" && (len(filtered) != 1 || filtered[0].EventType != tt.expected):
+				t.Errorf("Expected a %s event, got %+v", tt.expected, filtered)
+			}
+		})
+	}
+}
+
+func TestStore_PersistsAndAllocates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event_filters.json")
+	store, err := eventfilters.NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	filter, err := store.Put("north", models.EventFilterRequest{
+		ProductIDs: []string{"SKU-2", " SKU-1", "SKU-2", ""},
+		Categories: []string{"Phones"},
+		StoreIDs:   []string{"store-001"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to put filter: %v", err)
+	}
+	if !reflect.DeepEqual(filter.ProductIDs, []string{"SKU-1", "SKU-2"}) || filter.Categories[0] != "phones" {
+		t.Errorf("Expected cleaned lists, got %+v", filter)
+	}
+
+	var allocated *eventfilters.StoreAllocatedError
+	_, err = store.Put("south", models.EventFilterRequest{ProductIDs: []string{"SKU-9"}, StoreIDs: []string{"store-001"}})
+	if !errors.As(err, &allocated) || allocated.FilterID != "north" {
+		t.Errorf("Expected a store allocation conflict with north, got %v", err)
+	}
+
+	// Filters survive a restart
+	reloaded, err := eventfilters.NewStore(path)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if got, ok := reloaded.ForStore("store-001"); !ok || got.ID != "north" {
+		t.Errorf("Expected store-001 allocated to north after reload, got %+v", got)
+	}
+
+	if err := reloaded.Delete("north"); err != nil {
+		t.Fatalf("Failed to delete filter: %v", err)
+	}
+	if err := reloaded.Delete("north"); !errors.Is(err, eventfilters.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, ok := reloaded.ForStore("store-001"); ok {
+		t.Error("Expected no allocation after delete")
+	}
+}
+
+func TestStore_RejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event_filters.json")
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := eventfilters.NewStore(path); err == nil {
+		t.Error("Expected an error for a corrupt filters file")
+	}
+}
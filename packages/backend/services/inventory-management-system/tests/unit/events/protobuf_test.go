@@ -0,0 +1,155 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// storeClientFixture is the page the store client's decoder is tested
+// against, in the shared module next to the decoder
+var storeClientFixture = filepath.Join("..", "..", "..", "..", "..", "shared", "client", "testdata", "events.pb")
+
+var update = flag.Bool("update", false, "rewrite the store client's protobuf fixture")
+
+func sampleEventsResponse() models.EventsResponse {
+	events := make([]models.Event, 0, 50)
+	for i := 0; i < 50; i++ {
+		events = append(events, models.Event{
+			Offset:    int64(1000 + i),
+			Timestamp: "2025-11-28T23:00:00Z",
+			EventType: models.EventTypeProductUpdated,
+			ProductID: "SKU-001",
+			Data: models.ProductResponse{
+				ProductID:      "SKU-001",
+				Name:           "iPhone 15 Pro",
+				Available:      40 - i,
+				Version:        20 + i,
+				LastUpdated:    "2025-11-28T23:00:00Z",
+				Price:          1299.99,
+				Prices:         []models.Money{{Amount: 129999, Currency: "USD"}, {Amount: 119999, Currency: "EUR"}},
+				Category:       "phones",
+				Barcode:        "0194253401234",
+				BarcodeAliases: []string{"194253401234"},
+			},
+			Version: 20 + i,
+			StoreID: "store-001",
+			Delta:   -1,
+			Reason:  "sale",
+		})
+	}
+	events[0].Data.Assets = []models.ProductAsset{{
+		Type:     "image",
+		URL:      "https://cdn.example.com/products/iphone.jpg",
+		Checksum: "sha256:" + strings.Repeat("ab", 32),
+	}}
+	// A minimum of zero differs from none, so set bounds are always encoded
+	minStock, maxStock := 0, 200
+	events[0].Data.StockPolicy = &models.StockPolicy{MinStock: &minStock, MaxStock: &maxStock}
+	events[1].PreviousCategory = "tablets"
+	events[1].Data.Allocations = map[string]int{"store-1": 3, "store-2": 1}
+	events = append(events, models.Event{Offset: 1050, EventType: models.EventTypeSystemRestored})
+	events = append(events, models.Event{
+		Offset:    1051,
+		EventType: models.EventTypeStockTransferred,
+		ProductID: "SKU-001",
+		Version:   71,
+		StoreID:   "store-001",
+		ToStoreID: "store-002",
+		Quantity:  5,
+	})
+	events = append(events, models.Event{
+		Offset:    1052,
+		EventType: models.EventTypeCategoryUpdated,
+		Category: &models.Category{ID: "smartphones", Name: "Smartphones", ParentID: "phones", UpdatedAt: "2025-11-28T23:00:00Z",
+			StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 5}},
+	})
+	events = append(events, models.Event{
+		Offset:    1053,
+		EventType: models.EventTypeBackorderFulfilled,
+		ProductID: "SKU-001",
+		Version:   72,
+		StoreID:   "store-001",
+		Quantity:  2,
+		Backorder: &models.Backorder{StoreID: "store-001", IdempotencyKey: "01J000000000000000000000B1", Version: 60, Units: 2, CreatedAt: "2025-11-28T23:00:00Z"},
+	})
+	return models.EventsResponse{Events: events, NextOffset: 1054, HasMore: true, Count: len(events), Filtered: true, CurrentOffset: 1060}
+}
+
+func TestProtobuf_RoundTrip(t *testing.T) {
+	response := sampleEventsResponse()
+	// A sold-out product going negative must survive the varint encoding
+	response.Events[0].Data.Available = -3
+
+	decoded, err := events.UnmarshalProtobuf(events.MarshalProtobuf(response))
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, response) {
+		t.Errorf("Round trip changed the response:\n got %+v\nwant %+v", decoded, response)
+	}
+}
+
+// TestProtobuf_MatchesStoreClientFixture tests that the store client's
+// decoder fixture is what this encoder writes for the sample page. After a
+// change to events.proto, run with -update and extend the client's decoder
+// test to match.
+func TestProtobuf_MatchesStoreClientFixture(t *testing.T) {
+	response := sampleEventsResponse()
+	response.Events[0].Data.Available = -3
+	encoded := events.MarshalProtobuf(response)
+
+	if *update {
+		if err := os.WriteFile(storeClientFixture, encoded, 0o644); err != nil {
+			t.Fatalf("Failed to write the fixture: %v", err)
+		}
+	}
+	fixture, err := os.ReadFile(storeClientFixture)
+	if err != nil {
+		t.Fatalf("Failed to read the fixture: %v", err)
+	}
+	if !bytes.Equal(fixture, encoded) {
+		t.Errorf("%s is out of date; run go test ./tests/unit/events -run StoreClientFixture -update", storeClientFixture)
+	}
+}
+
+func TestProtobuf_SmallerThanJSON(t *testing.T) {
+	response := sampleEventsResponse()
+	encoded := events.MarshalProtobuf(response)
+	jsonEncoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	if len(encoded)*2 > len(jsonEncoded) {
+		t.Errorf("Expected protobuf to be under half the JSON size, got %d vs %d bytes", len(encoded), len(jsonEncoded))
+	}
+}
+
+func TestProtobuf_SkipsUnknownFields(t *testing.T) {
+	encoded := events.MarshalProtobuf(models.EventsResponse{NextOffset: 7, Count: 0})
+	// A field added by a newer central API
+	encoded = protowire.AppendTag(encoded, 99, protowire.BytesType)
+	encoded = protowire.AppendString(encoded, "future")
+
+	decoded, err := events.UnmarshalProtobuf(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if decoded.NextOffset != 7 || len(decoded.Events) != 0 {
+		t.Errorf("Unexpected response: %+v", decoded)
+	}
+
+	if _, err := events.UnmarshalProtobuf([]byte{0x0a, 0x05, 0x01}); err == nil {
+		t.Error("Expected an error for a truncated message")
+	}
+}
@@ -0,0 +1,491 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+)
+
+// newTestQueue creates a queue backed by a temp file with count published events
+func newTestQueue(t *testing.T, count int) (*events.EventQueue, string) {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	eq := openQueue(t, filePath, 1000, 0)
+	publishEvents(t, eq, count)
+
+	return eq, filePath
+}
+
+// openQueue opens the queue at filePath with the given rotation limits
+func openQueue(t *testing.T, filePath string, maxEvents, maxRetained int) *events.EventQueue {
+	t.Helper()
+
+	eq, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:          filePath,
+		MaxEvents:         maxEvents,
+		MaxRetainedEvents: maxRetained,
+		Logger:            slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	return eq
+}
+
+// publishEvents publishes count events and waits until the newest is stored
+func publishEvents(t *testing.T, eq *events.EventQueue, count int) {
+	t.Helper()
+
+	for i := 0; i < count; i++ {
+		eq.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001"}, i+1)
+	}
+
+	want := eq.GetCurrentOffset() - 1
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		stats := eq.Stats()
+		if stats.NewestOffset != nil && *stats.NewestOffset == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for event offset %d", want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventQueue_Stats(t *testing.T) {
+	eq, _ := newTestQueue(t, 3)
+	defer eq.Close()
+
+	eq.CommitOffset("store-1", 2)
+	eq.CommitOffset("store-1", 1) // Offsets never move backwards
+
+	stats := eq.Stats()
+	if stats.EventCount != 3 {
+		t.Errorf("Expected 3 events, got %d", stats.EventCount)
+	}
+	if stats.OldestOffset == nil || *stats.OldestOffset != 0 {
+		t.Errorf("Expected oldest offset 0, got %v", stats.OldestOffset)
+	}
+	if stats.NewestOffset == nil || *stats.NewestOffset != 2 {
+		t.Errorf("Expected newest offset 2, got %v", stats.NewestOffset)
+	}
+	if stats.NextOffset != 3 {
+		t.Errorf("Expected next offset 3, got %d", stats.NextOffset)
+	}
+	if len(stats.Consumers) != 1 || stats.Consumers[0].Offset != 2 {
+		t.Errorf("Expected store-1 at offset 2, got %+v", stats.Consumers)
+	}
+}
+
+func TestEventQueue_CompactRefusedWhileConsumerBehind(t *testing.T) {
+	eq, _ := newTestQueue(t, 5)
+	defer eq.Close()
+
+	eq.CommitOffset("store-1", 4)
+	eq.CommitOffset("store-2", 1)
+
+	removed, err := eq.Compact(3)
+
+	var behindErr *events.ConsumersBehindError
+	if !errors.As(err, &behindErr) {
+		t.Fatalf("Expected ConsumersBehindError, got %v", err)
+	}
+	if len(behindErr.Consumers) != 1 || behindErr.Consumers[0].StoreID != "store-2" {
+		t.Errorf("Expected only store-2 to be behind, got %+v", behindErr.Consumers)
+	}
+	if removed != 0 || eq.Stats().EventCount != 5 {
+		t.Errorf("Expected no events removed, removed %d", removed)
+	}
+}
+
+func TestEventQueue_CompactRemovesConsumedEvents(t *testing.T) {
+	eq, filePath := newTestQueue(t, 5)
+	defer eq.Close()
+
+	eq.CommitOffset("store-1", 4)
+
+	removed, err := eq.Compact(3)
+	if err != nil {
+		t.Fatalf("Expected compaction to succeed, got %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("Expected 3 removed events, got %d", removed)
+	}
+
+	stats := eq.Stats()
+	if stats.OldestOffset == nil || *stats.OldestOffset != 3 {
+		t.Errorf("Expected oldest offset 3, got %v", stats.OldestOffset)
+	}
+	if stats.NextOffset != 5 {
+		t.Errorf("Expected next offset to stay 5, got %d", stats.NextOffset)
+	}
+
+	// Compaction and registered consumers survive a restart
+	reloaded := openQueue(t, filePath, 1000, 0)
+	defer reloaded.Close()
+
+	reloadedStats := reloaded.Stats()
+	if reloadedStats.EventCount != 2 {
+		t.Errorf("Expected 2 events after reload, got %d", reloadedStats.EventCount)
+	}
+	if len(reloadedStats.Consumers) != 1 || reloadedStats.Consumers[0].StoreID != "store-1" {
+		t.Errorf("Expected store-1 to stay registered, got %+v", reloadedStats.Consumers)
+	}
+}
+
+func TestEventQueue_CompactRejectsOffsetBeyondNewest(t *testing.T) {
+	eq, _ := newTestQueue(t, 2)
+	defer eq.Close()
+
+	if _, err := eq.Compact(10); !errors.Is(err, events.ErrCompactOffsetOutOfRange) {
+		t.Errorf("Expected ErrCompactOffsetOutOfRange, got %v", err)
+	}
+}
+
+func TestEventQueue_CommitOffsetRejectsUnassignedOffset(t *testing.T) {
+	eq, _ := newTestQueue(t, 2)
+	defer eq.Close()
+
+	if _, err := eq.CommitOffset("store-1", 3); !errors.Is(err, events.ErrCommitOffsetOutOfRange) {
+		t.Errorf("Expected ErrCommitOffsetOutOfRange, got %v", err)
+	}
+	if consumer, err := eq.CommitOffset("store-1", 2); err != nil || consumer.Offset != 2 {
+		t.Errorf("Expected commit at offset 2, got %+v (%v)", consumer, err)
+	}
+}
+
+func TestEventQueue_RotationKeepsUnconsumedEvents(t *testing.T) {
+	eq := openQueue(t, filepath.Join(t.TempDir(), "events.json"), 8, 100)
+	defer eq.Close()
+
+	eq.CommitOffset("store-1", 0)
+	publishEvents(t, eq, 5)
+	eq.CommitOffset("store-1", 3)
+	publishEvents(t, eq, 5)
+
+	// 10 events exceed MaxEvents, but store-1 has only committed past offsets 0-2
+	stats := eq.Stats()
+	if stats.OldestOffset == nil || *stats.OldestOffset != 3 {
+		t.Errorf("Expected rotation to stop at committed offset 3, got %v", stats.OldestOffset)
+	}
+	if stats.EventCount != 7 {
+		t.Errorf("Expected 7 retained events, got %d", stats.EventCount)
+	}
+}
+
+func TestEventQueue_RotationWithoutConsumers(t *testing.T) {
+	eq := openQueue(t, filepath.Join(t.TempDir(), "events.json"), 8, 100)
+	defer eq.Close()
+
+	publishEvents(t, eq, 9)
+
+	// Without registered consumers the queue rotates down to 75% of MaxEvents
+	if stats := eq.Stats(); stats.EventCount != 6 {
+		t.Errorf("Expected 6 events after rotation, got %d", stats.EventCount)
+	}
+}
+
+func TestEventQueue_RetentionCapOverridesLaggingConsumer(t *testing.T) {
+	eq := openQueue(t, filepath.Join(t.TempDir(), "events.json"), 4, 8)
+	defer eq.Close()
+
+	eq.CommitOffset("store-1", 0)
+	publishEvents(t, eq, 9)
+
+	// store-1 never committed, so only the cap (rotating to 75% of 8) applies
+	stats := eq.Stats()
+	if stats.EventCount != 6 {
+		t.Errorf("Expected 6 events after forced rotation, got %d", stats.EventCount)
+	}
+	if stats.OldestOffset == nil || *stats.OldestOffset != 3 {
+		t.Errorf("Expected oldest offset 3, got %v", stats.OldestOffset)
+	}
+}
+
+// writeEventsFile writes count events published at timestamp, with the given
+// consumers, as the events file at filePath
+func writeEventsFile(t *testing.T, filePath string, count int, timestamp time.Time, consumers map[string]models.EventConsumer) {
+	t.Helper()
+
+	stored := make([]models.Event, count)
+	for i := range stored {
+		stored[i] = models.Event{
+			Offset:    int64(i),
+			EventType: models.EventTypeProductUpdated,
+			ProductID: "SKU-001",
+			Data:      models.ProductResponse{ProductID: "SKU-001"},
+			Version:   i + 1,
+			Timestamp: timestamp.Format(time.RFC3339),
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"events":     stored,
+		"nextOffset": count,
+		"consumers":  consumers,
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal events file: %v", err)
+	}
+	if err := os.WriteFile(filePath, data, 0o644); err != nil {
+		t.Fatalf("Failed to write events file: %v", err)
+	}
+}
+
+// openRetentionQueue opens the queue at filePath with age and size retention
+func openRetentionQueue(t *testing.T, filePath string, maxAge time.Duration, maxFileBytes int64) *events.EventQueue {
+	t.Helper()
+
+	eq, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:     filePath,
+		MaxEvents:    1000,
+		MaxEventAge:  maxAge,
+		MaxFileBytes: maxFileBytes,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	return eq
+}
+
+func TestEventQueue_AgeRetentionDropsExpiredEvents(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	writeEventsFile(t, filePath, 5, time.Now().Add(-48*time.Hour), nil)
+	eq := openRetentionQueue(t, filePath, 24*time.Hour, 0)
+	defer eq.Close()
+
+	publishEvents(t, eq, 1)
+
+	stats := eq.Stats()
+	if stats.EventCount != 1 {
+		t.Errorf("Expected only the new event to be retained, got %d", stats.EventCount)
+	}
+	if stats.OldestOffset == nil || *stats.OldestOffset != 5 {
+		t.Errorf("Expected oldest offset 5, got %v", stats.OldestOffset)
+	}
+	if stats.RotatedEvents[events.RotationReasonAge] != 5 {
+		t.Errorf("Expected 5 events rotated by age, got %v", stats.RotatedEvents)
+	}
+}
+
+func TestEventQueue_AgeRetentionKeepsUnconsumedEvents(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	writeEventsFile(t, filePath, 5, time.Now().Add(-48*time.Hour), map[string]models.EventConsumer{
+		"store-1": {StoreID: "store-1", Offset: 2},
+	})
+	eq := openRetentionQueue(t, filePath, 24*time.Hour, 0)
+	defer eq.Close()
+
+	publishEvents(t, eq, 1)
+
+	// Every event expired, but store-1 has only committed past offsets 0-1
+	stats := eq.Stats()
+	if stats.OldestOffset == nil || *stats.OldestOffset != 2 {
+		t.Errorf("Expected rotation to stop at committed offset 2, got %v", stats.OldestOffset)
+	}
+	if stats.EventCount != 4 {
+		t.Errorf("Expected 4 retained events, got %d", stats.EventCount)
+	}
+}
+
+func TestEventQueue_SizeRetentionRotatesOversizedFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	writeEventsFile(t, filePath, 20, time.Now(), nil)
+	info, err := os.Stat(filePath)
+	if err != nil {
+		t.Fatalf("Failed to stat events file: %v", err)
+	}
+	eq := openRetentionQueue(t, filePath, 0, info.Size()/2)
+	defer eq.Close()
+
+	publishEvents(t, eq, 1)
+
+	// The file is twice the limit, so the queue keeps 75% of half its events
+	stats := eq.Stats()
+	if stats.EventCount != 7 {
+		t.Errorf("Expected 7 retained events, got %d", stats.EventCount)
+	}
+	if stats.RotatedEvents[events.RotationReasonSize] != 14 {
+		t.Errorf("Expected 14 events rotated by size, got %v", stats.RotatedEvents)
+	}
+
+	// The next publish is measured against the smaller file and rotates nothing
+	publishEvents(t, eq, 1)
+	if stats := eq.Stats(); stats.EventCount != 8 {
+		t.Errorf("Expected 8 retained events, got %d", stats.EventCount)
+	}
+}
+
+func TestEventQueue_StandbyReloadsWithoutWriting(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	writeEventsFile(t, filePath, 2, time.Now(), nil)
+	eq := openQueue(t, filePath, 1000, 0)
+	if err := eq.SetStandby(true); err != nil {
+		t.Fatalf("Failed to enter standby: %v", err)
+	}
+	waiting := eq.WaitForEvents(2, 2*time.Second)
+
+	// The leader appends three events
+	writeEventsFile(t, filePath, 5, time.Now(), map[string]models.EventConsumer{
+		"store-1": {StoreID: "store-1", Offset: 3},
+	})
+	leaderFile, _ := os.ReadFile(filePath)
+
+	reloaded, err := eq.Reload()
+	if err != nil || !reloaded {
+		t.Fatalf("Expected the changed file to be reloaded, got %v, %v", reloaded, err)
+	}
+	if offset := eq.GetCurrentOffset(); offset != 5 {
+		t.Errorf("Expected next offset 5 after reload, got %d", offset)
+	}
+	if consumers := eq.Stats().Consumers; len(consumers) != 1 || consumers[0].Offset != 3 {
+		t.Errorf("Expected the leader's consumer at offset 3, got %v", consumers)
+	}
+	select {
+	case <-waiting:
+	default:
+		t.Error("Expected the long poll to wake for the reloaded events")
+	}
+	if reloaded, _ := eq.Reload(); reloaded {
+		t.Error("Expected an unchanged file not to be reloaded")
+	}
+
+	if err := eq.Close(); err != nil {
+		t.Fatalf("Failed to close queue: %v", err)
+	}
+	if after, _ := os.ReadFile(filePath); string(after) != string(leaderFile) {
+		t.Error("Expected a queue in standby to leave the leader's events file alone")
+	}
+}
+
+func TestEventQueue_OldestAvailableOffset(t *testing.T) {
+	eq := openQueue(t, filepath.Join(t.TempDir(), "events.json"), 8, 100)
+	defer eq.Close()
+
+	if got := eq.OldestAvailableOffset(); got != 0 {
+		t.Errorf("Expected oldest available offset 0 on a fresh queue, got %d", got)
+	}
+
+	// Rotation at the 9th event drops offsets 0-2
+	publishEvents(t, eq, 9)
+	if got := eq.OldestAvailableOffset(); got != 3 {
+		t.Errorf("Expected oldest available offset 3 after rotation, got %d", got)
+	}
+
+	// Compacting everything keeps the boundary even with no events left
+	if _, err := eq.Compact(9); err != nil {
+		t.Fatalf("Expected compaction to succeed, got %v", err)
+	}
+	if got := eq.OldestAvailableOffset(); got != 9 {
+		t.Errorf("Expected oldest available offset 9 after compaction, got %d", got)
+	}
+}
+
+func TestEventQueue_ConcurrentPublishesStoredInOffsetOrder(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "events.json")
+	eq := openQueue(t, filePath, 1000, 0)
+
+	var wg sync.WaitGroup
+	for p := 0; p < 8; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				eq.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001"}, i+1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every publish is stored by the time it returns, in offset order
+	stored, _, _ := eq.GetEvents(0, 1000)
+	if len(stored) != 400 {
+		t.Fatalf("Expected 400 stored events, got %d", len(stored))
+	}
+	for i, event := range stored {
+		if event.Offset != int64(i) {
+			t.Fatalf("Expected offset %d at position %d, got %d", i, i, event.Offset)
+		}
+	}
+
+	if err := eq.Close(); err != nil {
+		t.Fatalf("Failed to close queue: %v", err)
+	}
+	reopened := openQueue(t, filePath, 1000, 0)
+	defer reopened.Close()
+	if stats := reopened.Stats(); stats.EventCount != 400 || stats.NextOffset != 400 {
+		t.Errorf("Expected the saved file to hold all 400 events, got %+v", stats)
+	}
+}
+
+// openManualClockQueue opens a queue in a temp dir whose time only moves when
+// the returned clock is advanced
+func openManualClockQueue(t *testing.T, maxAge time.Duration) (*events.EventQueue, *clock.Manual) {
+	t.Helper()
+
+	manual := clock.NewManual(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	eq, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:    filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents:   1000,
+		MaxEventAge: maxAge,
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Clock:       manual,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	return eq, manual
+}
+
+func TestEventQueue_WaitForEventsTimesOutOnClock(t *testing.T) {
+	eq, manual := openManualClockQueue(t, 0)
+	defer eq.Close()
+
+	wait := eq.WaitForEvents(0, 30*time.Second)
+
+	manual.Advance(29 * time.Second)
+	select {
+	case <-wait:
+		t.Fatal("Wait should not end before its timeout")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	manual.Advance(time.Second)
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("Wait should end once the clock passed its timeout")
+	}
+}
+
+func TestEventQueue_AgeRetentionOnClock(t *testing.T) {
+	eq, manual := openManualClockQueue(t, 24*time.Hour)
+	defer eq.Close()
+
+	publishEvents(t, eq, 3)
+	stored, _, _ := eq.GetEvents(0, 1)
+	if len(stored) != 1 || stored[0].Timestamp != "2026-03-01T09:30:00Z" {
+		t.Fatalf("Expected events stamped by the queue's clock, got %+v", stored)
+	}
+
+	manual.Advance(25 * time.Hour)
+	publishEvents(t, eq, 1)
+
+	stats := eq.Stats()
+	if stats.EventCount != 1 || stats.RotatedEvents[events.RotationReasonAge] != 3 {
+		t.Errorf("Expected the 3 older events rotated by age, got %d events and %v", stats.EventCount, stats.RotatedEvents)
+	}
+}
@@ -0,0 +1,69 @@
+package featureflags
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-management-api/internal/featureflags"
+)
+
+func TestFlags_Precedence(t *testing.T) {
+	flags := featureflags.New("positive_deltas=true", "store-2:positive_deltas=false")
+
+	tests := []struct {
+		tenant string
+		want   bool
+	}{
+		{tenant: "", want: true},
+		{tenant: "store-1", want: true},
+		{tenant: "store-2", want: false},
+	}
+	for _, tt := range tests {
+		if got := flags.Enabled(featureflags.PositiveDeltas, tt.tenant); got != tt.want {
+			t.Errorf("Enabled for %q = %v, want %v", tt.tenant, got, tt.want)
+		}
+	}
+}
+
+func TestFlags_Defaults(t *testing.T) {
+	var nilFlags *featureflags.Flags
+	if nilFlags.Enabled(featureflags.PositiveDeltas, "store-1") {
+		t.Error("Expected nil flags to report the default (off)")
+	}
+
+	// Malformed and unknown entries are skipped
+	flags := featureflags.New("positive_deltas=maybe,unknown_flag=true,novalue", "store-1positive_deltas=true,store-1:unknown=true")
+	if flags.Enabled(featureflags.PositiveDeltas, "store-1") {
+		t.Error("Expected malformed configuration to leave the default in place")
+	}
+	if flags.Enabled("unknown_flag", "") {
+		t.Error("Expected unknown flags to be off")
+	}
+}
+
+func TestFlags_SetAndClear(t *testing.T) {
+	flags := featureflags.New("", "")
+
+	if err := flags.Set(featureflags.PositiveDeltas, "store-1", true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !flags.Enabled(featureflags.PositiveDeltas, "store-1") || flags.Enabled(featureflags.PositiveDeltas, "store-2") {
+		t.Error("Expected the override to apply to store-1 only")
+	}
+
+	states := flags.States()
+	if len(states) != 2 || states[0].Name != featureflags.PositiveDeltas || states[0].Enabled || !states[0].Overrides["store-1"] {
+		t.Errorf("Unexpected states: %+v", states)
+	}
+
+	if err := flags.Clear(featureflags.PositiveDeltas, "store-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if flags.Enabled(featureflags.PositiveDeltas, "store-1") {
+		t.Error("Expected the cleared override to fall back to the default")
+	}
+
+	if err := flags.Set("unknown_flag", "", true); !errors.Is(err, featureflags.ErrUnknownFlag) {
+		t.Errorf("Expected ErrUnknownFlag, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/forecast"
+	"inventory-management-api/internal/models"
+)
+
+var now = time.Date(2025, 11, 30, 12, 0, 0, 0, time.UTC)
+
+func stockEvent(eventType, productID string, available int, age time.Duration) models.Event {
+	return models.Event{
+		Timestamp: now.Add(-age).Format(time.RFC3339),
+		EventType: eventType,
+		ProductID: productID,
+		Data:      models.ProductResponse{ProductID: productID, Available: available},
+	}
+}
+
+func day(n float64) time.Duration {
+	return time.Duration(n * 24 * float64(time.Hour))
+}
+
+func TestParseWindows(t *testing.T) {
+	windows, err := forecast.ParseWindows("30, 7,7")
+	if err != nil || len(windows) != 2 || windows[0] != 7 || windows[1] != 30 {
+		t.Errorf("Expected [7 30], got %v (%v)", windows, err)
+	}
+	if windows, _ := forecast.ParseWindows(""); len(windows) != len(forecast.DefaultWindows) {
+		t.Errorf("Expected the default windows, got %v", windows)
+	}
+	for _, invalid := range []string{"0", "366", "x", "1,2,3,4,5,6"} {
+		if _, err := forecast.ParseWindows(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestHistory_Forecast(t *testing.T) {
+	history := forecast.NewHistory([]models.Event{
+		stockEvent(models.EventTypeProductCreated, "SKU-1", 100, day(20)),
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 80, day(10)),  // 20 sold
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 90, day(6)),   // restock, ignored
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 76, day(5)),   // 14 sold
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 70, day(0.5)), // 6 sold
+	}, now)
+
+	result := history.Forecast(models.ProductResponse{ProductID: "SKU-1", Available: 70}, []int{1, 7, 30}, now)
+	if len(result.Windows) != 3 {
+		t.Fatalf("Expected 3 windows, got %d", len(result.Windows))
+	}
+
+	oneDay, week, month := result.Windows[0], result.Windows[1], result.Windows[2]
+	if oneDay.UnitsSold != 6 || oneDay.VelocityPerDay != 6 || oneDay.Partial {
+		t.Errorf("Unexpected 1-day window: %+v", oneDay)
+	}
+	if week.UnitsSold != 20 || week.VelocityPerDay != 2.86 {
+		t.Errorf("Unexpected 7-day window: %+v", week)
+	}
+	// The history covers 20 of the 30 days, so it is averaged over 20
+	if month.UnitsSold != 40 || month.VelocityPerDay != 2 || !month.Partial {
+		t.Errorf("Unexpected 30-day window: %+v", month)
+	}
+
+	// The fastest window gives the earliest stock-out: 70 units at 6/day
+	if result.DaysOfStock == nil || *result.DaysOfStock != 11.7 {
+		t.Fatalf("Expected 11.7 days of stock, got %v", result.DaysOfStock)
+	}
+	if *result.ProjectedStockoutDate != "2025-12-11" {
+		t.Errorf("Expected stock-out on 2025-12-11, got %s", *result.ProjectedStockoutDate)
+	}
+}
+
+func TestHistory_ResetsAcrossRestoreAndDelete(t *testing.T) {
+	history := forecast.NewHistory([]models.Event{
+		stockEvent(models.EventTypeProductCreated, "SKU-1", 100, day(3)),
+		{Timestamp: now.Add(-day(2)).Format(time.RFC3339), EventType: models.EventTypeSystemRestored},
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 10, day(1.5)), // first after restore
+		stockEvent(models.EventTypeProductDeleted, "SKU-1", 0, day(1.2)),
+		stockEvent(models.EventTypeProductCreated, "SKU-1", 50, day(1.1)),
+		stockEvent(models.EventTypeProductUpdated, "SKU-1", 45, day(1)),
+	}, now)
+
+	result := history.Forecast(models.ProductResponse{ProductID: "SKU-1", Available: 45}, []int{7}, now)
+	if result.Windows[0].UnitsSold != 5 {
+		t.Errorf("Expected only the 5 units sold after re-creation, got %d", result.Windows[0].UnitsSold)
+	}
+}
+
+func TestHistory_Report(t *testing.T) {
+	history := forecast.NewHistory([]models.Event{
+		stockEvent(models.EventTypeProductCreated, "SKU-FAST", 100, day(7)),
+		stockEvent(models.EventTypeProductUpdated, "SKU-FAST", 30, day(1)),
+		stockEvent(models.EventTypeProductCreated, "SKU-SLOW", 100, day(7)),
+		stockEvent(models.EventTypeProductUpdated, "SKU-SLOW", 93, day(1)),
+	}, now)
+	products := []models.ProductResponse{
+		{ProductID: "SKU-IDLE", Available: 5},
+		{ProductID: "SKU-SLOW", Available: 93},
+		{ProductID: "SKU-FAST", Available: 30},
+	}
+
+	report := history.Report(products, []int{7}, now, 0)
+	order := []string{"SKU-FAST", "SKU-SLOW", "SKU-IDLE"}
+	for i, productID := range order {
+		if report[i].ProductID != productID {
+			t.Fatalf("Expected order %v, got %+v", order, report)
+		}
+	}
+	if report[2].DaysOfStock != nil || report[2].Windows[0].VelocityPerDay != 0 {
+		t.Errorf("Expected no projection for a product without sales, got %+v", report[2])
+	}
+
+	report = history.Report(products, []int{7}, now, 30)
+	if len(report) != 1 || report[0].ProductID != "SKU-FAST" {
+		t.Errorf("Expected only SKU-FAST within 30 days, got %+v", report)
+	}
+}
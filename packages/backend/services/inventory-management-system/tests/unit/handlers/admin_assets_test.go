@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAdminHandler_ProductAssets(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	adminHandler := handlers.NewAdminHandler(inventoryService)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/products/{productId}/assets", adminHandler.GetProductAssets).Methods("GET")
+	router.HandleFunc("/v1/admin/products/{productId}/assets", adminHandler.SetProductAssets).Methods("PUT")
+	router.HandleFunc("/v1/admin/products/{productId}/assets", adminHandler.PutProductAsset).Methods("POST")
+	router.HandleFunc("/v1/admin/products/{productId}/assets", adminHandler.RemoveProductAsset).Methods("DELETE")
+
+	send := func(method, path, body string) (*httptest.ResponseRecorder, models.ProductAssetsResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		var response models.ProductAssetsResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+	checksum := "sha256:" + strings.Repeat("0f", 32)
+	image := `{"type": "image", "url": "https://cdn.example.com/sku-001.jpg", "checksum": "` + checksum + `"}`
+
+	rr, response := send("PUT", "/v1/admin/products/SKU-001/assets", `{"assets": [`+image+`]}`)
+	if rr.Code != http.StatusOK || len(response.Assets) != 1 || response.Version != 4 {
+		t.Fatalf("Expected the assets to be set at version 4, got %d: %s", rr.Code, rr.Body.String())
+	}
+	product, err := inventoryService.GetProduct("SKU-001")
+	if err != nil || len(product.Assets) != 1 || product.Assets[0].Checksum != checksum {
+		t.Fatalf("Expected the asset on the product, got %+v (%v)", product, err)
+	}
+
+	// Posting the same URL replaces the asset, another URL adds one
+	rr, response = send("POST", "/v1/admin/products/SKU-001/assets", strings.Replace(image, `"image"`, `"thumbnail"`, 1))
+	if rr.Code != http.StatusOK || len(response.Assets) != 1 || response.Assets[0].Type != "thumbnail" {
+		t.Fatalf("Expected the asset to be replaced, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr, response = send("POST", "/v1/admin/products/SKU-001/assets", `{"type": "document", "url": "https://cdn.example.com/sku-001.pdf", "checksum": "`+checksum+`"}`)
+	if rr.Code != http.StatusOK || len(response.Assets) != 2 {
+		t.Fatalf("Expected a second asset, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Bad URLs, types and checksums are refused
+	for _, body := range []string{
+		`{"type": "image", "url": "ftp://cdn.example.com/a.jpg", "checksum": "` + checksum + `"}`,
+		`{"type": "image", "url": "/a.jpg", "checksum": "` + checksum + `"}`,
+		`{"type": "banner", "url": "https://cdn.example.com/a.jpg", "checksum": "` + checksum + `"}`,
+		`{"type": "image", "url": "https://cdn.example.com/a.jpg", "checksum": "md5:abc"}`,
+	} {
+		if rr, _ := send("POST", "/v1/admin/products/SKU-001/assets", body); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, rr.Code)
+		}
+	}
+	if rr, _ := send("PUT", "/v1/admin/products/SKU-001/assets", `{"assets": [`+image+`, `+image+`]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected duplicate URLs to be refused, got %d", rr.Code)
+	}
+
+	rr, response = send("DELETE", "/v1/admin/products/SKU-001/assets?url="+url.QueryEscape("https://cdn.example.com/sku-001.pdf"), "")
+	if rr.Code != http.StatusOK || len(response.Assets) != 1 {
+		t.Fatalf("Expected the document to be removed, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr, _ := send("DELETE", "/v1/admin/products/SKU-001/assets?url="+url.QueryEscape("https://cdn.example.com/sku-001.pdf"), ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 removing a missing asset, got %d", rr.Code)
+	}
+	if rr, _ := send("GET", "/v1/admin/products/SKU-404/assets", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown product, got %d", rr.Code)
+	}
+
+	rr, response = send("GET", "/v1/admin/products/SKU-002/assets", "")
+	if rr.Code != http.StatusOK || response.Assets == nil || len(response.Assets) != 0 {
+		t.Errorf("Expected an empty asset list, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAdminHandler_SetProductsJob(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	jobManager, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 4, ChunkSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to create job manager: %v", err)
+	}
+	t.Cleanup(jobManager.Stop)
+
+	adminHandler := handlers.NewAdminHandler(inventoryService)
+	adminHandler.SetJobs(jobManager, 3)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/products/set/jobs", adminHandler.StartSetProductsJob).Methods("POST")
+	jobsHandler := handlers.NewJobsHandler(jobManager)
+	router.HandleFunc("/v1/admin/jobs", jobsHandler.StartJob).Methods("POST")
+	router.HandleFunc("/v1/admin/jobs", jobsHandler.ListJobs).Methods("GET")
+	router.HandleFunc("/v1/admin/jobs/{id}", jobsHandler.GetJob).Methods("GET")
+
+	startJob := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/v1/admin/products/set/jobs", bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("applies products and reports per-item results", func(t *testing.T) {
+		rr := startJob(`{"products": [
+			{"productId": "SKU-001", "available": 42},
+			{"productId": "SKU-404", "available": 1},
+			{"productId": "SKU-002", "name": "Laptop Pro"}
+		]}`)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var queued models.Job
+		if err := json.Unmarshal(rr.Body.Bytes(), &queued); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+		if queued.Type != models.JobTypeProductsSet || queued.Total != 3 {
+			t.Errorf("Expected a products_set job of 3 items, got %+v", queued)
+		}
+		if location := rr.Header().Get("Location"); location != "/v1/admin/jobs/"+queued.ID {
+			t.Errorf("Expected Location of the job, got %q", location)
+		}
+
+		job := waitForJob(t, router, queued.ID)
+		if job.Status != models.JobStatusSucceeded {
+			t.Fatalf("Expected job to succeed, got %+v", job)
+		}
+		if job.Processed != 3 || job.Succeeded != 2 || job.Failed != 1 || len(job.Results) != 3 {
+			t.Errorf("Expected 3 processed items, 2 succeeded and 1 failed, got %+v", job)
+		}
+		if job.Results[1].ProductID != "SKU-404" || job.Results[1].ErrorType == "" {
+			t.Errorf("Expected the unknown product to fail in order, got %+v", job.Results[1])
+		}
+		if product, _ := inventoryService.GetProduct("SKU-001"); product.Available != 42 {
+			t.Errorf("Expected SKU-001 available 42, got %d", product.Available)
+		}
+	})
+
+	t.Run("products_set through the jobs API", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/v1/admin/jobs", bytes.NewBufferString(
+			`{"type": "products_set", "params": {"products": [{"productId": "SKU-002", "available": 5}]}}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var queued models.Job
+		json.Unmarshal(rr.Body.Bytes(), &queued)
+		if job := waitForJob(t, router, queued.ID); job.Status != models.JobStatusSucceeded || job.Succeeded != 1 {
+			t.Errorf("Expected the job to succeed, got %+v", job)
+		}
+
+		req = httptest.NewRequest("GET", "/v1/admin/jobs?type=products_set", nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var list models.JobsResponse
+		json.Unmarshal(rr.Body.Bytes(), &list)
+		if list.Count != 2 || list.Jobs[0].ID != queued.ID || list.Jobs[0].Results != nil {
+			t.Errorf("Expected both jobs newest first without results, got %+v", list)
+		}
+		if len(list.Types) != 1 || list.Types[0] != models.JobTypeProductsSet {
+			t.Errorf("Expected products_set to be registered, got %v", list.Types)
+		}
+	})
+
+	t.Run("jobs API rejects bad requests", func(t *testing.T) {
+		for _, tt := range []struct {
+			body         string
+			expectedCode string
+		}{
+			{`{"type": "reindex"}`, "unknown_job_type"},
+			{`{"type": "products_set", "params": {"products": [{"productId": "SKU-001"}]}}`, "validation_error"},
+			{`{"type": "products_set", "params": [1]}`, "invalid_request"},
+			{`{"params": {}}`, "validation_error"},
+		} {
+			req := httptest.NewRequest("POST", "/v1/admin/jobs", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			var errResp models.ErrorResponse
+			json.Unmarshal(rr.Body.Bytes(), &errResp)
+			if rr.Code != http.StatusBadRequest || errResp.Code != tt.expectedCode {
+				t.Errorf("Expected 400 %s for %s, got %d %s", tt.expectedCode, tt.body, rr.Code, errResp.Code)
+			}
+		}
+	})
+
+	t.Run("refuses more products than the job limit", func(t *testing.T) {
+		rr := startJob(`{"products": [{"productId": "A"}, {"productId": "B"}, {"productId": "C"}, {"productId": "D"}]}`)
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", rr.Code)
+		}
+		var errResp models.ErrorResponse
+		json.Unmarshal(rr.Body.Bytes(), &errResp)
+		if errResp.Code != "batch_too_large" {
+			t.Errorf("Expected batch_too_large, got %q", errResp.Code)
+		}
+	})
+
+	t.Run("rejects invalid bodies", func(t *testing.T) {
+		for _, body := range []string{`{"products": []}`, `{"products": {}}`, `[]`, `{"products": [{"available": 1}]}`} {
+			if rr := startJob(body); rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %s, got %d", body, rr.Code)
+			}
+		}
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v1/admin/jobs/job-999999", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", rr.Code)
+		}
+	})
+}
+
+// waitForJob polls a job until it finishes or five seconds pass
+func waitForJob(t *testing.T, router http.Handler, id string) models.Job {
+	t.Helper()
+
+	var job models.Job
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		req := httptest.NewRequest("GET", "/v1/admin/jobs/"+id, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", rr.Code)
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+		if job.FinishedAt != "" || time.Now().After(deadline) {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+)
+
+func TestAdminHandler_RecountMetadata(t *testing.T) {
+	f := newRestoreFixture(t)
+	handler := handlers.NewAdminHandler(f.service)
+	f.sell(t, "SKU-001", 1, 3)
+
+	recount := func(query string) (int, models.MetadataRecountResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.RecountMetadata(rr, httptest.NewRequest("POST", "/v1/admin/maintenance/recount"+query, nil))
+		var response models.MetadataRecountResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response
+	}
+
+	// The fixture's data file records no products
+	code, response := recount("?dryRun=true")
+	if code != http.StatusOK || response.Repaired || !response.DryRun ||
+		!reflect.DeepEqual(response.Discrepancies, []string{"totalProducts"}) || response.Actual.TotalProducts != 2 {
+		t.Fatalf("Expected totalProducts to be reported, got %d: %+v", code, response)
+	}
+	if f.service.GetSystemMetadata().TotalProducts != 0 {
+		t.Fatal("Expected a dry run to change nothing")
+	}
+
+	code, response = recount("")
+	if code != http.StatusOK || !response.Repaired || response.Actual.LastOffset != 1 || f.service.GetSystemMetadata().TotalProducts != 2 {
+		t.Fatalf("Expected totalProducts to be repaired, got %d: %+v", code, response)
+	}
+
+	code, response = recount("")
+	if code != http.StatusOK || response.Repaired || len(response.Discrepancies) != 0 {
+		t.Errorf("Expected nothing left to repair, got %d: %+v", code, response)
+	}
+	if code, _ := recount("?dryRun=maybe"); code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid dryRun, got %d", code)
+	}
+}
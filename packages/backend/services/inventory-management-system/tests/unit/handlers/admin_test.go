@@ -591,6 +591,45 @@ func intPtr(i int) *int {
 	return &i
 }
 
+func TestAdminHandler_Barcodes(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	adminHandler := handlers.NewAdminHandler(inventoryService)
+
+	send := func(handle http.HandlerFunc, method, body string) models.AdminProductResult {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handle(rr, httptest.NewRequest(method, "/v1/admin/products", bytes.NewBufferString(body)))
+		var response models.AdminSetResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || len(response.Results) != 1 {
+			t.Fatalf("Unexpected response %d: %s", rr.Code, rr.Body.String())
+		}
+		return response.Results[0]
+	}
+
+	result := send(adminHandler.CreateProducts, "POST", `{"products": [{"productId": "SKU-003", "name": "Scanned", "barcode": " 4006381333931 ", "barcodeAliases": ["006381333931"]}]}`)
+	if !result.Success {
+		t.Fatalf("Expected the create to succeed, got %+v", result)
+	}
+	product, err := inventoryService.GetProduct("SKU-003")
+	if err != nil || product.Barcode != "4006381333931" || len(product.BarcodeAliases) != 1 {
+		t.Fatalf("Expected trimmed barcodes on the product, got %+v (%v)", product, err)
+	}
+
+	// A code that already scans as another product is refused
+	result = send(adminHandler.SetProducts, "PUT", `{"products": [{"productId": "SKU-001", "barcodeAliases": ["006381333931"]}]}`)
+	if result.Success || result.ErrorType != services.ErrTypeBarcodeConflict {
+		t.Errorf("Expected barcode_conflict, got %+v", result)
+	}
+	result = send(adminHandler.SetProducts, "PUT", `{"products": [{"productId": "SKU-003", "barcodeAliases": []}]}`)
+	if !result.Success {
+		t.Fatalf("Expected clearing the aliases to succeed, got %+v", result)
+	}
+	result = send(adminHandler.SetProducts, "PUT", `{"products": [{"productId": "SKU-001", "barcodeAliases": ["006381333931"]}]}`)
+	if !result.Success {
+		t.Errorf("Expected the freed alias to be assignable, got %+v", result)
+	}
+}
+
 func float64Ptr(f float64) *float64 {
 	return &f
 }
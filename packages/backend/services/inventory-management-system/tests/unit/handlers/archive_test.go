@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/archive"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func newArchiveRouter(archiver *archive.Archiver) *mux.Router {
+	archiveHandler := handlers.NewArchiveHandler(archiver)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/archive/segments", archiveHandler.ListSegments).Methods("GET")
+	router.HandleFunc("/v1/admin/archive/segments/{id}", archiveHandler.GetSegment).Methods("GET")
+	router.HandleFunc("/v1/admin/archive/events", archiveHandler.GetEvents).Methods("GET")
+	return router
+}
+
+func TestArchiveHandler_NotConfigured(t *testing.T) {
+	router := newArchiveRouter(nil)
+	for _, path := range []string{"/v1/admin/archive/segments", "/v1/admin/archive/segments/1-2", "/v1/admin/archive/events"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var resp models.ErrorResponse
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if rr.Code != http.StatusNotFound || resp.Code != "archive_not_configured" {
+			t.Errorf("Expected 404 archive_not_configured for %s, got %d %+v", path, rr.Code, resp)
+		}
+	}
+}
+
+func TestArchiveHandler_ListsAndStreamsSegments(t *testing.T) {
+	archiver := archive.NewArchiver(archive.NewLocalSink(t.TempDir()), "")
+	archiver.Start()
+	archiver.Enqueue([]models.Event{
+		{Offset: 0, EventType: models.EventTypeProductCreated, ProductID: "SKU-1"},
+		{Offset: 1, EventType: models.EventTypeProductUpdated, ProductID: "SKU-1"},
+	})
+	archiver.Enqueue([]models.Event{{Offset: 2, EventType: models.EventTypeProductDeleted, ProductID: "SKU-1"}})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := archiver.Stop(ctx); err != nil {
+		t.Fatalf("Failed to stop archiver: %v", err)
+	}
+	router := newArchiveRouter(archiver)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	var list models.ArchiveSegmentListResponse
+	rr := get("/v1/admin/archive/segments")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if rr.Code != http.StatusOK || len(list.Segments) != 2 || list.Stats.ArchivedEvents != 3 {
+		t.Fatalf("Unexpected segment list (%d): %+v", rr.Code, list)
+	}
+
+	var segment models.ArchiveSegmentResponse
+	rr = get("/v1/admin/archive/segments/" + list.Segments[0].ID)
+	json.Unmarshal(rr.Body.Bytes(), &segment)
+	if rr.Code != http.StatusOK || len(segment.Events) != 2 || segment.Segment.LastOffset != 1 {
+		t.Errorf("Unexpected segment (%d): %+v", rr.Code, segment)
+	}
+	if rr := get("/v1/admin/archive/segments/" + archive.SegmentID(5, 9)); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a missing segment, got %d", rr.Code)
+	}
+
+	var page models.EventsResponse
+	rr = get("/v1/admin/archive/events?offset=1&limit=1")
+	json.Unmarshal(rr.Body.Bytes(), &page)
+	if rr.Code != http.StatusOK || page.Count != 1 || page.Events[0].Offset != 1 || page.NextOffset != 2 || !page.HasMore {
+		t.Errorf("Unexpected events page (%d): %+v", rr.Code, page)
+	}
+
+	for _, query := range []string{"offset=-1", "offset=x", "limit=0", "limit=1001"} {
+		if rr := get("/v1/admin/archive/events?" + query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
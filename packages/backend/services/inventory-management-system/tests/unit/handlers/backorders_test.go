@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestInventoryHandler_Backorders(t *testing.T) {
+	f := newRestoreFixture(t)
+	flags := featureflags.New("", "")
+	flags.Set(featureflags.PositiveDeltas, "", true)
+	f.service.SetFeatureFlags(flags)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/inventory/{productId}/backorders", handlers.NewInventoryHandler(f.service).GetProductBackorders).Methods("GET")
+	backorders := func() models.BackordersResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/SKU-002/backorders", nil))
+		var response models.BackordersResponse
+		if rr.Code != http.StatusOK || json.Unmarshal(rr.Body.Bytes(), &response) != nil {
+			t.Fatalf("Expected the backorders, got %d: %s", rr.Code, rr.Body.String())
+		}
+		return response
+	}
+	update := func(delta, version int, key, storeID string) {
+		t.Helper()
+		result, err := f.service.UpdateInventory("SKU-002", delta, version, key, storeID)
+		if err != nil || !result.Success {
+			t.Fatalf("Update %s failed: %v %+v", key, err, result)
+		}
+	}
+
+	// SKU-002 is sold out and takes up to 10 units on backorder
+	if response, err := f.service.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-002",
+		StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 10}}}); err != nil || !response.Results[0].Success {
+		t.Fatalf("Failed to set the policy: %+v (%v)", response, err)
+	}
+	result, err := f.service.UpdateInventory("SKU-002", -2, 8, "sale-1", "store-1")
+	if err != nil || !result.Success || result.Backordered != 2 || result.NewQuantity != -2 {
+		t.Fatalf("Expected 2 units on backorder, got %+v (%v)", result, err)
+	}
+	update(-3, 9, "sale-2", "store-2")
+
+	queue := backorders()
+	if queue.Units != 5 || len(queue.Backorders) != 2 || queue.Backorders[0].IdempotencyKey != "sale-1" || queue.Backorders[1].Remaining != 3 {
+		t.Fatalf("Expected both sales queued in order, got %+v", queue)
+	}
+
+	// A restock of 3 fills the first backorder and part of the second
+	update(3, 10, "restock-1", "store-1")
+	queue = backorders()
+	if queue.Available != -2 || queue.Units != 2 || len(queue.Backorders) != 1 || queue.Backorders[0].StoreID != "store-2" {
+		t.Fatalf("Expected 2 units left for store-2, got %+v", queue)
+	}
+
+	// Setting the stock fills the rest
+	available := 4
+	if response, err := f.service.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-002", Available: &available}}); err != nil || !response.Results[0].Success {
+		t.Fatalf("Failed to set the stock: %+v (%v)", response, err)
+	}
+	if queue = backorders(); queue.Units != 0 || len(queue.Backorders) != 0 {
+		t.Errorf("Expected an empty queue, got %+v", queue)
+	}
+
+	stored, _, _ := f.eventQueue.GetEvents(0, 100)
+	var fulfilled []models.Event
+	for _, event := range stored {
+		if event.EventType == models.EventTypeBackorderFulfilled {
+			fulfilled = append(fulfilled, event)
+		}
+	}
+	if len(fulfilled) != 3 {
+		t.Fatalf("Expected 3 backorder_fulfilled events, got %+v", fulfilled)
+	}
+	for i, want := range []struct {
+		storeID             string
+		quantity, remaining int
+	}{{"store-1", 2, 0}, {"store-2", 1, 2}, {"store-2", 2, 0}} {
+		event := fulfilled[i]
+		if event.StoreID != want.storeID || event.Quantity != want.quantity || event.Backorder == nil || event.Backorder.Remaining != want.remaining {
+			t.Errorf("Event %d: expected %+v, got %+v", i, want, event)
+		}
+	}
+	if stored[1].Backorder == nil || stored[1].Backorder.Units != 2 {
+		t.Errorf("Expected the first sale's event to carry its backorder, got %+v", stored[1])
+	}
+}
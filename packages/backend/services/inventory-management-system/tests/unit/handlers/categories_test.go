@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCategoriesHandler_Tree(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	categoriesHandler := handlers.NewCategoriesHandler(inventoryService)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/categories", categoriesHandler.ListCategories).Methods("GET")
+	router.HandleFunc("/v1/admin/categories/{id}", categoriesHandler.PutCategory).Methods("PUT")
+	router.HandleFunc("/v1/admin/categories/{id}", categoriesHandler.DeleteCategory).Methods("DELETE")
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rr
+	}
+
+	for _, step := range []struct{ id, body string }{
+		{"electronics", `{"name": "Electronics"}`},
+		{"phones", `{"name": "Phones", "parentId": "electronics"}`},
+		{"smartphones", `{"name": "Smartphones", "parentId": "phones"}`},
+	} {
+		if rr := send("PUT", "/v1/admin/categories/"+step.id, step.body); rr.Code != http.StatusOK {
+			t.Fatalf("Expected %s to be saved, got %d: %s", step.id, rr.Code, rr.Body.String())
+		}
+	}
+
+	rr := send("GET", "/v1/categories", "")
+	var list models.CategoryListResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || len(list.Categories) != 3 || list.Categories[0].ID != "electronics" {
+		t.Fatalf("Expected three categories sorted by ID, got %s", rr.Body.String())
+	}
+
+	// Moving a category under its own subtree is refused
+	if rr := send("PUT", "/v1/admin/categories/electronics", `{"name": "Electronics", "parentId": "smartphones"}`); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a cycle, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("PUT", "/v1/admin/categories/tablets", `{"name": "Tablets", "parentId": "computers"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown parent, got %d", rr.Code)
+	}
+	if rr := send("PUT", "/v1/admin/categories/Bad%20ID", `{"name": "Bad"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid ID, got %d", rr.Code)
+	}
+	if rr := send("PUT", "/v1/admin/categories/tablets", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without a name, got %d", rr.Code)
+	}
+
+	expanded := inventoryService.CategoriesWithDescendants([]string{"Phones", "garden"})
+	if len(expanded) != 3 || expanded[0] != "garden" || expanded[1] != "phones" || expanded[2] != "smartphones" {
+		t.Errorf("Expected phones, its subcategory and the free-form garden, got %v", expanded)
+	}
+
+	// A category with subcategories cannot be deleted
+	if rr := send("DELETE", "/v1/admin/categories/phones", ""); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deleting a category in use, got %d", rr.Code)
+	}
+	if rr := send("DELETE", "/v1/admin/categories/smartphones", ""); rr.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 deleting a leaf, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("DELETE", "/v1/admin/categories/smartphones", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting it again, got %d", rr.Code)
+	}
+}
+
+func TestCategoriesHandler_ProductAssignmentAndListing(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+
+	// Before the tree exists, categories stay free-form
+	phones, laptops := "Phones", "laptops"
+	response, err := inventoryService.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-001", Category: &phones}})
+	if err != nil || !response.Results[0].Success {
+		t.Fatalf("Expected a free-form category to be accepted, got %+v (%v)", response, err)
+	}
+
+	for _, category := range []struct{ id, parentID string }{{"electronics", ""}, {"phones", "electronics"}, {"laptops", "electronics"}} {
+		if _, err := inventoryService.PutCategory(category.id, models.CategoryRequest{Name: category.id, ParentID: category.parentID}); err != nil {
+			t.Fatalf("Failed to save category %s: %v", category.id, err)
+		}
+	}
+	if err := inventoryService.DeleteCategory("phones"); err == nil {
+		t.Error("Expected phones to be in use by SKU-001")
+	}
+
+	response, err = inventoryService.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-002", Category: &laptops}})
+	if err != nil || !response.Results[0].Success {
+		t.Fatalf("Expected laptops to be assigned, got %+v (%v)", response, err)
+	}
+	created, err := inventoryService.AdminCreateProducts([]models.AdminProductCreate{{ProductID: "SKU-003", Name: "Rake", Category: "garden"}})
+	if err != nil || created.Results[0].Success || created.Results[0].ErrorType != services.ErrTypeUnknownCategory {
+		t.Fatalf("Expected unknown_category once the tree exists, got %+v (%v)", created, err)
+	}
+
+	list := func(query string) []models.ProductResponse {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		inventoryHandler.ListProducts(rr, httptest.NewRequest("GET", "/v1/inventory?"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Products []models.ProductResponse `json:"products"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Products
+	}
+
+	if products := list("category=electronics"); len(products) != 2 {
+		t.Errorf("Expected the parent to cover both products, got %+v", products)
+	}
+	if products := list("category=laptops"); len(products) != 1 || products[0].ProductID != "SKU-002" {
+		t.Errorf("Expected only SKU-002 under laptops, got %+v", products)
+	}
+}
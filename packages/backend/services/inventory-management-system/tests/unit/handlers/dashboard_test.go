@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+)
+
+func TestDashboardHandler_GetDashboard(t *testing.T) {
+	f := newRestoreFixture(t)
+	f.sell(t, "SKU-001", 2, 3)
+	f.sell(t, "SKU-001", 1, 4)
+
+	if _, err := f.eventQueue.CommitOffset("store-1", 1); err != nil {
+		t.Fatalf("Failed to commit store offset: %v", err)
+	}
+	if _, err := f.eventQueue.CommitOffset(reports.ConsumerID, 0); err != nil {
+		t.Fatalf("Failed to commit report offset: %v", err)
+	}
+
+	handler := handlers.NewDashboardHandler(f.service, f.eventQueue)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.GetDashboard(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/v1/admin/dashboard")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var dashboard models.AdminDashboardResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &dashboard); err != nil {
+		t.Fatalf("Failed to decode dashboard: %v", err)
+	}
+
+	if dashboard.TotalSKUs != 2 || dashboard.TotalUnits != 7 || dashboard.OutOfStockCount != 1 {
+		t.Errorf("Expected 2 SKUs, 7 units and 1 out of stock, got %+v", dashboard)
+	}
+	if len(dashboard.LowStock) != 1 || dashboard.LowStock[0].ProductID != "SKU-001" || dashboard.LowStock[0].Available != 7 {
+		t.Errorf("Expected only SKU-001 with 7 units in low stock, got %+v", dashboard.LowStock)
+	}
+	if dashboard.UpdatesLastHour != 2 {
+		t.Errorf("Expected 2 updates in the last hour, got %d", dashboard.UpdatesLastHour)
+	}
+	if dashboard.EventQueue.Depth != 2 || dashboard.EventQueue.NextOffset != 2 {
+		t.Errorf("Expected 2 queued events, got %+v", dashboard.EventQueue)
+	}
+
+	lag := dashboard.StoreLag
+	if lag.StoreCount != 1 || lag.MaxLagStoreID != "store-1" || lag.MaxLagEvents != 1 {
+		t.Errorf("Expected store-1 one event behind and the report consumer left out, got %+v", lag)
+	}
+
+	if rr := get("/v1/admin/dashboard?lowStock=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid lowStock, got %d", rr.Code)
+	}
+}
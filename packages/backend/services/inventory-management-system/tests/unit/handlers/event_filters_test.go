@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/eventfilters"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+type eventFiltersFixture struct {
+	queue   *events.EventQueue
+	filters *eventfilters.Store
+	events  *handlers.EventsHandler
+	router  *mux.Router
+}
+
+// newEventFiltersFixture publishes ten phone events followed by one TV event
+func newEventFiltersFixture(t *testing.T) *eventFiltersFixture {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    logger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	for i := 0; i < 10; i++ {
+		queue.PublishEvent(models.EventTypeProductUpdated, "SKU-PHONE",
+			models.ProductResponse{ProductID: "SKU-PHONE", Category: "Phones"}, i+1)
+	}
+	queue.PublishEvent(models.EventTypeProductUpdated, "SKU-TV",
+		models.ProductResponse{ProductID: "SKU-TV", Category: "tv"}, 1)
+	deadline := time.Now().Add(2 * time.Second)
+	for queue.Stats().EventCount < 11 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	filters, err := eventfilters.NewStore(filepath.Join(t.TempDir(), "event_filters.json"))
+	if err != nil {
+		t.Fatalf("Failed to create filter store: %v", err)
+	}
+
+	eventsHandler := handlers.NewEventsHandler(queue, logger)
+	eventsHandler.SetFilters(filters)
+	filtersHandler := handlers.NewEventFiltersHandler(filters)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/event-filters", filtersHandler.ListFilters).Methods("GET")
+	router.HandleFunc("/v1/admin/event-filters/{id}", filtersHandler.GetFilter).Methods("GET")
+	router.HandleFunc("/v1/admin/event-filters/{id}", filtersHandler.PutFilter).Methods("PUT")
+	router.HandleFunc("/v1/admin/event-filters/{id}", filtersHandler.DeleteFilter).Methods("DELETE")
+
+	return &eventFiltersFixture{queue: queue, filters: filters, events: eventsHandler, router: router}
+}
+
+func (f *eventFiltersFixture) poll(t *testing.T, query, storeID string) (int, models.EventsResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/v1/inventory/events?"+query, nil)
+	if storeID != "" {
+		req = asStore(req, storeID)
+	}
+	rr := httptest.NewRecorder()
+	f.events.GetEvents(rr, req)
+
+	var response models.EventsResponse
+	if rr.Code == http.StatusOK {
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode events response: %v", err)
+		}
+	}
+	return rr.Code, response
+}
+
+func (f *eventFiltersFixture) put(t *testing.T, id, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	f.router.ServeHTTP(rr, httptest.NewRequest("PUT", "/v1/admin/event-filters/"+id, strings.NewReader(body)))
+	return rr
+}
+
+func TestEventsHandler_GetEvents_FilterSkipsNonMatchingPages(t *testing.T) {
+	f := newEventFiltersFixture(t)
+
+	// The TV event sits behind several pages of phone events
+	code, response := f.poll(t, "offset=0&limit=3&productIds=SKU-TV", "")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", code)
+	}
+	if len(response.Events) != 1 || response.Events[0].ProductID != "SKU-TV" {
+		t.Fatalf("Expected only the TV event, got %+v", response.Events)
+	}
+	if !response.Filtered {
+		t.Error("Expected the response to be marked as filtered")
+	}
+	if response.NextOffset != response.Events[0].Offset+1 {
+		t.Errorf("Expected nextOffset after the TV event, got %d", response.NextOffset)
+	}
+
+	// Categories match case-insensitively
+	_, response = f.poll(t, "offset=0&limit=100&category=phones", "")
+	if len(response.Events) != 10 {
+		t.Errorf("Expected 10 phone events, got %d", len(response.Events))
+	}
+
+	// Without a filter every event is returned and the response is not marked
+	_, response = f.poll(t, "offset=0&limit=100", "")
+	if len(response.Events) != 11 || response.Filtered {
+		t.Errorf("Expected 11 unfiltered events, got %d (filtered=%v)", len(response.Events), response.Filtered)
+	}
+}
+
+func TestEventsHandler_GetEvents_FilterLongPollSkipsNonMatching(t *testing.T) {
+	f := newEventFiltersFixture(t)
+	next := f.queue.GetCurrentOffset()
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		f.queue.PublishEvent(models.EventTypeProductUpdated, "SKU-PHONE",
+			models.ProductResponse{ProductID: "SKU-PHONE", Category: "phones"}, 11)
+		time.Sleep(50 * time.Millisecond)
+		f.queue.PublishEvent(models.EventTypeProductUpdated, "SKU-TV",
+			models.ProductResponse{ProductID: "SKU-TV", Category: "tv"}, 2)
+	}()
+
+	_, response := f.poll(t, "limit=10&wait=5&productIds=SKU-TV&offset="+strconv.FormatInt(next, 10), "")
+	if len(response.Events) != 1 || response.Events[0].Version != 2 {
+		t.Fatalf("Expected the second TV event, got %+v", response.Events)
+	}
+}
+
+func TestEventsHandler_GetEvents_NamedFilterAndStoreAllocation(t *testing.T) {
+	f := newEventFiltersFixture(t)
+
+	if rr := f.put(t, "tv-only", `{"productIds":["SKU-TV"],"storeIds":["store-001"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 creating the filter, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	_, response := f.poll(t, "offset=0&filter=tv-only", "")
+	if len(response.Events) != 1 || !response.Filtered {
+		t.Errorf("Expected one filtered event for the named filter, got %+v", response)
+	}
+
+	// The allocated store gets the filter without naming it; others do not
+	_, response = f.poll(t, "offset=0", "store-001")
+	if len(response.Events) != 1 {
+		t.Errorf("Expected the allocated filter for store-001, got %d events", len(response.Events))
+	}
+	_, response = f.poll(t, "offset=0", "store-002")
+	if len(response.Events) != 11 {
+		t.Errorf("Expected all events for store-002, got %d", len(response.Events))
+	}
+
+	if code, _ := f.poll(t, "offset=0&filter=missing", ""); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown filter, got %d", code)
+	}
+}
+
+func TestEventFiltersHandler_PutFilter_Errors(t *testing.T) {
+	f := newEventFiltersFixture(t)
+	f.put(t, "tv-only", `{"productIds":["SKU-TV"],"storeIds":["store-001"]}`)
+
+	tests := []struct {
+		name     string
+		id       string
+		body     string
+		expected int
+		code     string
+	}{
+		{"store already allocated", "phones", `{"categories":["phones"],"storeIds":["store-001"]}`, http.StatusConflict, "store_already_allocated"},
+		{"empty filter", "empty", `{"storeIds":["store-002"]}`, http.StatusBadRequest, "validation_error"},
+		{"invalid id", "Bad.ID", `{"productIds":["SKU-TV"]}`, http.StatusBadRequest, "bad_request"},
+		{"invalid json", "broken", `{`, http.StatusBadRequest, "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := f.put(t, tt.id, tt.body)
+			if rr.Code != tt.expected {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expected, rr.Code, rr.Body.String())
+			}
+			var response models.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode error: %v", err)
+			}
+			if response.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, response.Code)
+			}
+		})
+	}
+
+	// Re-allocating a store within the same filter is allowed
+	if rr := f.put(t, "tv-only", `{"productIds":["SKU-TV","SKU-TV2"],"storeIds":["store-001"]}`); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 replacing the filter, got %d", rr.Code)
+	}
+
+	rr := httptest.NewRecorder()
+	f.router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/v1/admin/event-filters/tv-only", nil))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 deleting the filter, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	f.router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/admin/event-filters/tv-only", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", rr.Code)
+	}
+}
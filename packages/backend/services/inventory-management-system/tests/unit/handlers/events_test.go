@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// asStore authenticates req as a principal bound to storeID, which it also
+// names in X-Store-ID as store services do
+func asStore(req *http.Request, storeID string) *http.Request {
+	req.Header.Set("X-Store-ID", storeID)
+	principal := authz.Principal{ID: "key:" + storeID + "-key", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleStore}, StoreID: storeID}
+	return req.WithContext(authz.WithPrincipal(req.Context(), principal))
+}
+
+func TestEventsHandler_GetEvents_OffsetGone(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    logger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	defer eventQueue.Close()
+
+	for i := 0; i < 5; i++ {
+		eventQueue.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001"}, i+1)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for eventQueue.Stats().EventCount < 5 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := eventQueue.Compact(3); err != nil {
+		t.Fatalf("Failed to compact event queue: %v", err)
+	}
+
+	handler := handlers.NewEventsHandler(eventQueue, logger)
+
+	// Offsets still in the queue are served as usual
+	rr := httptest.NewRecorder()
+	handler.GetEvents(rr, httptest.NewRequest("GET", "/v1/inventory/events?offset=3", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for available offset, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.GetEvents(rr, httptest.NewRequest("GET", "/v1/inventory/events?offset=1", nil))
+	if rr.Code != http.StatusGone {
+		t.Fatalf("Expected status 410 for compacted offset, got %d", rr.Code)
+	}
+
+	var response models.OffsetGoneResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode 410 body: %v", err)
+	}
+	if response.Code != "offset_gone" {
+		t.Errorf("Expected code offset_gone, got %q", response.Code)
+	}
+	if response.RequestedOffset != 1 || response.OldestAvailableOffset != 3 || response.SnapshotOffset != 5 {
+		t.Errorf("Expected requested 1, oldest 3, snapshot 5, got %+v", response)
+	}
+}
+
+func TestEventsHandler_GetEvents_Protobuf(t *testing.T) {
+	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	defer eventQueue.Close()
+
+	eventQueue.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001", Available: 4}, 2)
+	deadline := time.Now().Add(2 * time.Second)
+	for eventQueue.Stats().EventCount < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	handler := handlers.NewEventsHandler(eventQueue, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	tests := []struct {
+		accept      string
+		contentType string
+	}{
+		{accept: "", contentType: "application/json"},
+		{accept: "application/json", contentType: "application/json"},
+		{accept: "application/x-protobuf", contentType: events.ProtobufContentType},
+		{accept: "application/x-protobuf, application/json;q=0.5", contentType: events.ProtobufContentType},
+		{accept: "application/x-protobuf;q=0.5, */*", contentType: "application/json"},
+		{accept: "application/x-protobuf;q=0", contentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/v1/inventory/events?offset=0", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		rr := httptest.NewRecorder()
+		handler.GetEvents(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Accept %q: expected status 200, got %d", tt.accept, rr.Code)
+		}
+		if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+			t.Errorf("Accept %q: expected %s, got %s", tt.accept, tt.contentType, got)
+			continue
+		}
+		if tt.contentType != events.ProtobufContentType {
+			continue
+		}
+
+		response, err := events.UnmarshalProtobuf(rr.Body.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to decode protobuf body: %v", err)
+		}
+		if len(response.Events) != 1 || response.Events[0].Data.Available != 4 || response.NextOffset != 1 {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	}
+}
+
+func TestEventsHandler_ReplayStore(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    logger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	defer eventQueue.Close()
+
+	for i := 0; i < 3; i++ {
+		eventQueue.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001"}, i+1)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for eventQueue.Stats().EventCount < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for events")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	handler := handlers.NewEventsHandler(eventQueue, logger)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/inventory/events", handler.GetEvents).Methods("GET")
+	router.HandleFunc("/v1/admin/stores/{storeId}/replay", handler.ReplayStore).Methods("POST")
+
+	poll := func(storeID, offset string) *httptest.ResponseRecorder {
+		req := asStore(httptest.NewRequest("GET", "/v1/inventory/events?offset="+offset, nil), storeID)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	consumer := func(storeID string) models.EventConsumer {
+		for _, c := range eventQueue.Stats().Consumers {
+			if c.StoreID == storeID {
+				return c
+			}
+		}
+		t.Fatalf("Expected %s to be a registered consumer", storeID)
+		return models.EventConsumer{}
+	}
+
+	// Both stores are caught up
+	current := strconv.FormatInt(eventQueue.GetCurrentOffset(), 10)
+	for _, storeID := range []string{"store-a", "store-b"} {
+		if rr := poll(storeID, current); rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %s, got %d", storeID, rr.Code)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/admin/stores/store-a/replay", nil))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var requested models.EventConsumer
+	if err := json.Unmarshal(rr.Body.Bytes(), &requested); err != nil {
+		t.Fatalf("Failed to decode consumer: %v", err)
+	}
+	if requested.Replay == nil || requested.Replay.Status != models.StoreReplayPending ||
+		requested.Offset != eventQueue.OldestAvailableOffset() {
+		t.Errorf("Expected a pending replay rewound to the oldest offset, got %+v", requested)
+	}
+
+	// The replayed store is told to resync whatever offset it polls from
+	rr = poll("store-a", current)
+	if rr.Code != http.StatusGone {
+		t.Fatalf("Expected status 410 for the replayed store, got %d", rr.Code)
+	}
+	var gone models.OffsetGoneResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &gone); err != nil {
+		t.Fatalf("Failed to decode 410 body: %v", err)
+	}
+	if gone.Code != "offset_gone" || gone.Reason != "replay_requested" || gone.SnapshotOffset != eventQueue.GetCurrentOffset() {
+		t.Errorf("Expected offset_gone for a requested replay, got %+v", gone)
+	}
+	if replay := consumer("store-a").Replay; replay == nil || replay.Status != models.StoreReplayResyncing {
+		t.Errorf("Expected the replay to be resyncing, got %+v", replay)
+	}
+
+	// Polling from the snapshot offset completes the replay
+	if rr := poll("store-a", strconv.FormatInt(gone.SnapshotOffset, 10)); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 after the resync, got %d", rr.Code)
+	}
+	if replay := consumer("store-a").Replay; replay == nil || replay.Status != models.StoreReplayCompleted {
+		t.Errorf("Expected the replay to be completed, got %+v", replay)
+	}
+
+	if rr := poll("store-b", current); rr.Code != http.StatusOK {
+		t.Errorf("Expected the other store to be unaffected, got %d", rr.Code)
+	}
+	if replay := consumer("store-b").Replay; replay != nil {
+		t.Errorf("Expected no replay for the other store, got %+v", replay)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/admin/stores/store-z/replay", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown store, got %d", rr.Code)
+	}
+}
+
+// TestEventsHandler_ConsumerFromPrincipal tests that polls and commits count
+// for the store the caller is authenticated as, not whichever X-Store-ID it
+// sends
+func TestEventsHandler_ConsumerFromPrincipal(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    logger,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	defer eventQueue.Close()
+	for i := 0; i < 3; i++ {
+		eventQueue.PublishEvent(models.EventTypeProductUpdated, "SKU-001", models.ProductResponse{ProductID: "SKU-001"}, i+1)
+	}
+	handler := handlers.NewEventsHandler(eventQueue, logger)
+
+	bound := authz.Principal{ID: "key:store-a-key", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleStore}, StoreID: "store-a"}
+	unbound := authz.Principal{ID: "key:demo", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleStore}}
+	admin := authz.Principal{ID: "key:admin-key", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleAdmin}}
+	send := func(principal authz.Principal, method, header string) *httptest.ResponseRecorder {
+		var req *http.Request
+		if method == "GET" {
+			req = httptest.NewRequest("GET", "/v1/inventory/events?offset=1", nil)
+		} else {
+			req = httptest.NewRequest("POST", "/v1/inventory/events/commit", strings.NewReader(`{"offset": 2}`))
+		}
+		if header != "" {
+			req.Header.Set("X-Store-ID", header)
+		}
+		req = req.WithContext(authz.WithPrincipal(req.Context(), principal))
+		rr := httptest.NewRecorder()
+		if method == "GET" {
+			handler.GetEvents(rr, req)
+		} else {
+			handler.CommitEventOffset(rr, req)
+		}
+		return rr
+	}
+	errorCode := func(rr *httptest.ResponseRecorder) string {
+		var body models.ErrorResponse
+		json.Unmarshal(rr.Body.Bytes(), &body)
+		return body.Code
+	}
+
+	tests := []struct {
+		name      string
+		principal authz.Principal
+		method    string
+		header    string
+		status    int
+		code      string
+		consumer  string // Store registered as a consumer, "" for none
+	}{
+		{"bound key polls", bound, "GET", "", http.StatusOK, "", "store-a"},
+		{"bound key commits", bound, "POST", "store-a", http.StatusOK, "", "store-a"},
+		{"bound key names another store", bound, "GET", "store-b", http.StatusForbidden, "store_id_mismatch", ""},
+		{"bound key commits for another store", bound, "POST", "store-b", http.StatusForbidden, "store_id_mismatch", ""},
+		{"unbound key names a store", unbound, "GET", "store-c", http.StatusForbidden, "store_not_bound", ""},
+		{"unbound key commits", unbound, "POST", "store-c", http.StatusForbidden, "store_not_bound", ""},
+		{"unbound key polls anonymously", unbound, "GET", "", http.StatusOK, "", ""},
+		{"admin acts for a store", admin, "POST", "store-d", http.StatusOK, "", "store-d"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := send(tt.principal, tt.method, tt.header)
+			if rr.Code != tt.status || errorCode(rr) != tt.code {
+				t.Fatalf("Expected %d %q, got %d: %s", tt.status, tt.code, rr.Code, rr.Body.String())
+			}
+			if tt.consumer == "" {
+				return
+			}
+			for _, c := range eventQueue.Stats().Consumers {
+				if c.StoreID == tt.consumer {
+					return
+				}
+			}
+			t.Errorf("Expected %s to be a registered consumer", tt.consumer)
+		})
+	}
+	for _, c := range eventQueue.Stats().Consumers {
+		if c.StoreID == "store-b" || c.StoreID == "store-c" {
+			t.Errorf("Expected no consumer for %s, whose requests were rejected", c.StoreID)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func featureFlagsRouter(flags *featureflags.Flags) *mux.Router {
+	handler := handlers.NewFeatureFlagsHandler(flags)
+	r := mux.NewRouter()
+	r.HandleFunc("/v1/admin/feature-flags", handler.ListFlags).Methods("GET")
+	r.HandleFunc("/v1/admin/feature-flags/{flag}", handler.SetFlag).Methods("PUT")
+	r.HandleFunc("/v1/admin/feature-flags/{flag}", handler.ClearFlag).Methods("DELETE")
+	return r
+}
+
+func TestFeatureFlagsHandler_SetAndClear(t *testing.T) {
+	flags := featureflags.New("", "")
+	router := featureFlagsRouter(flags)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("PUT", "/v1/admin/feature-flags/positive_deltas",
+		strings.NewReader(`{"enabled": true, "tenant": "store-1"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.FeatureFlagsResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Flags) != 2 || response.Flags[0].Name != featureflags.PositiveDeltas || response.Flags[0].Enabled || !response.Flags[0].Overrides["store-1"] {
+		t.Errorf("Unexpected flags: %+v", response.Flags)
+	}
+	if !flags.Enabled(featureflags.PositiveDeltas, "store-1") {
+		t.Error("Expected positive deltas to be enabled for store-1")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("DELETE", "/v1/admin/feature-flags/positive_deltas?tenant=store-1", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if flags.Enabled(featureflags.PositiveDeltas, "store-1") {
+		t.Error("Expected the override to be removed")
+	}
+}
+
+func TestFeatureFlagsHandler_Errors(t *testing.T) {
+	router := featureFlagsRouter(featureflags.New("", ""))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "unknown flag", method: "PUT", path: "/v1/admin/feature-flags/teleport", body: `{"enabled": true}`, status: http.StatusNotFound},
+		{name: "missing enabled", method: "PUT", path: "/v1/admin/feature-flags/positive_deltas", body: `{"tenant": "store-1"}`, status: http.StatusBadRequest},
+		{name: "malformed JSON", method: "PUT", path: "/v1/admin/feature-flags/positive_deltas", body: `{"enabled":`, status: http.StatusBadRequest},
+		{name: "clear unknown flag", method: "DELETE", path: "/v1/admin/feature-flags/teleport", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
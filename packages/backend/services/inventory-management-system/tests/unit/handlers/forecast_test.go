@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+func TestForecastHandler(t *testing.T) {
+	f := newRestoreFixture(t)
+	// The first event only records the stock level, the second is a sale
+	f.sell(t, "SKU-001", 1, 3)
+	f.sell(t, "SKU-001", 2, 4)
+
+	forecastHandler := handlers.NewForecastHandler(f.service, f.eventQueue)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/inventory/{productId}/forecast", forecastHandler.GetProductForecast).Methods("GET")
+	router.HandleFunc("/v1/admin/forecast", forecastHandler.GetForecastReport).Methods("GET")
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/v1/inventory/SKU-001/forecast?windows=7")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response models.ProductForecastResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode forecast: %v", err)
+	}
+	window := response.Forecast.Windows[0]
+	if window.Days != 7 || window.UnitsSold != 2 || !window.Partial {
+		t.Errorf("Expected 2 units sold in a partial 7-day window, got %+v", window)
+	}
+	if response.Forecast.Available != 7 || response.Forecast.ProjectedStockoutDate == nil {
+		t.Errorf("Expected a stock-out projection for 7 units, got %+v", response.Forecast)
+	}
+
+	if rr := get("/v1/inventory/SKU-404/forecast"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown product, got %d", rr.Code)
+	}
+	if rr := get("/v1/inventory/SKU-001/forecast?windows=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid window, got %d", rr.Code)
+	}
+
+	rr = get("/v1/admin/forecast?withinDays=30")
+	var report models.ForecastReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Count != 1 || report.Products[0].ProductID != "SKU-001" {
+		t.Errorf("Expected only SKU-001 to run out within 30 days, got %+v", report)
+	}
+	if rr := get("/v1/admin/forecast?withinDays=-1"); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid withinDays, got %d", rr.Code)
+	}
+}
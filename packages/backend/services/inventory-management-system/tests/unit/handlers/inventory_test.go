@@ -0,0 +1,637 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/currency"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// newBatchTestService starts an inventory service on a two-product fixture
+func newBatchTestService(t *testing.T) *services.InventoryService {
+	t.Helper()
+
+	seedPath := filepath.Join(t.TempDir(), "inventory_test_data.json")
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3, "price": 99.99, "lastUpdated": "2024-01-15T10:00:00Z"},
+		"SKU-002": {"productId": "SKU-002", "name": "Laptop", "available": 0, "version": 7, "price": 999.99, "lastUpdated": "2024-01-15T11:00:00Z"}
+	}, "metadata": {}}`
+	if err := os.WriteFile(seedPath, []byte(fixture), 0o644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+
+	inventoryService, err := services.NewInventoryService(&config.Config{
+		SeedDataPath:                    seedPath,
+		Port:                            "8080",
+		LogLevel:                        "info",
+		Environment:                     "test",
+		IdempotencyCacheTTL:             "2m",
+		IdempotencyCacheCleanupInterval: "30s",
+		EnableJSONPersistence:           "false",
+		InventoryWorkerCount:            "1",
+		InventoryQueueBufferSize:        "100",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create inventory service: %v", err)
+	}
+	t.Cleanup(inventoryService.Stop)
+	return inventoryService
+}
+
+func postBatchGet(handler *handlers.InventoryHandler, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.BatchGetProducts(rr, httptest.NewRequest("POST", "/v1/inventory/batch-get", bytes.NewBufferString(body)))
+	return rr
+}
+
+func TestInventoryHandler_BatchGetProducts(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	rr := postBatchGet(handler, `{"productIds": ["SKU-002", "SKU-404", "SKU-001", "SKU-002"]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.BatchGetResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	// Found products keep request order and duplicates are returned once
+	if len(response.Products) != 2 || response.Products[0].ProductID != "SKU-002" || response.Products[1].ProductID != "SKU-001" {
+		t.Fatalf("Expected SKU-002 then SKU-001, got %+v", response.Products)
+	}
+	if response.Products[0].Version != 7 || response.Products[1].Available != 10 {
+		t.Errorf("Expected product data from the service, got %+v", response.Products)
+	}
+	if len(response.Missing) != 1 || response.Missing[0] != "SKU-404" {
+		t.Errorf("Expected SKU-404 missing, got %v", response.Missing)
+	}
+}
+
+func TestInventoryHandler_BatchGetProducts_Rejected(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+	handler.SetMaxBatchItems(2)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{name: "empty list", body: `{"productIds": []}`, code: "validation_error"},
+		{name: "blank ID", body: `{"productIds": ["SKU-001", " "]}`, code: "validation_error"},
+		{name: "too many IDs", body: `{"productIds": ["SKU-001", "SKU-002", "SKU-003"]}`, code: "batch_too_large"},
+		{name: "invalid JSON", body: `{"productIds": `, code: "bad_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postBatchGet(handler, tt.body)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", rr.Code)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode error: %v", err)
+			}
+			if response.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, response.Code)
+			}
+		})
+	}
+}
+
+type deltaResponse struct {
+	Products          []models.ProductResponse `json:"products"`
+	DeletedProductIDs []string                 `json:"deletedProductIds"`
+	AsOf              string                   `json:"asOf"`
+}
+
+func getUpdatedSince(t *testing.T, handler *handlers.InventoryHandler, updatedSince string) deltaResponse {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	handler.ListProducts(rr, httptest.NewRequest("GET", "/v1/inventory?updatedSince="+updatedSince, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response deltaResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return response
+}
+
+func TestInventoryHandler_ListProducts_UpdatedSince(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	handler := handlers.NewInventoryHandler(inventoryService)
+
+	// Changes are listed oldest first and the bound is inclusive
+	response := getUpdatedSince(t, handler, "2024-01-15T10:00:00Z")
+	if len(response.Products) != 2 || response.Products[0].ProductID != "SKU-001" || response.Products[1].ProductID != "SKU-002" {
+		t.Fatalf("Expected SKU-001 then SKU-002, got %+v", response.Products)
+	}
+	if len(response.DeletedProductIDs) != 0 {
+		t.Errorf("Expected no deletions, got %v", response.DeletedProductIDs)
+	}
+
+	response = getUpdatedSince(t, handler, "2024-01-15T10:30:00Z")
+	if len(response.Products) != 1 || response.Products[0].ProductID != "SKU-002" {
+		t.Fatalf("Expected only SKU-002, got %+v", response.Products)
+	}
+
+	// asOf from a poll picks up every later change, including deletions
+	asOf := getUpdatedSince(t, handler, "2030-01-01T00:00:00Z").AsOf
+	if _, err := time.Parse(time.RFC3339, asOf); err != nil {
+		t.Fatalf("Expected RFC3339 asOf, got %q", asOf)
+	}
+	if _, err := inventoryService.UpdateInventory("SKU-001", -1, 3, "delta-test", "store-1"); err != nil {
+		t.Fatalf("Failed to update inventory: %v", err)
+	}
+	if _, err := inventoryService.AdminDeleteProducts([]string{"SKU-002"}); err != nil {
+		t.Fatalf("Failed to delete product: %v", err)
+	}
+
+	response = getUpdatedSince(t, handler, asOf)
+	if len(response.Products) != 1 || response.Products[0].ProductID != "SKU-001" || response.Products[0].Version != 4 {
+		t.Errorf("Expected SKU-001 at version 4, got %+v", response.Products)
+	}
+	if len(response.DeletedProductIDs) != 1 || response.DeletedProductIDs[0] != "SKU-002" {
+		t.Errorf("Expected SKU-002 deleted, got %v", response.DeletedProductIDs)
+	}
+}
+
+func TestInventoryHandler_ListProducts_UpdatedSincePrunedDeletions(t *testing.T) {
+	now := clock.NewManual(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	defer clock.Set(now)()
+	inventoryService := newBatchTestService(t)
+	handler := handlers.NewInventoryHandler(inventoryService)
+
+	before := getUpdatedSince(t, handler, "2026-03-01T00:00:00Z").AsOf
+	if _, err := inventoryService.AdminDeleteProducts([]string{"SKU-002"}); err != nil {
+		t.Fatalf("Failed to delete product: %v", err)
+	}
+
+	// Once the deletion is past the retention window, the next change prunes it
+	now.Advance(8 * 24 * time.Hour)
+	if _, err := inventoryService.UpdateInventory("SKU-001", -1, 3, "prune-test", "store-1"); err != nil {
+		t.Fatalf("Failed to update inventory: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ListProducts(rr, httptest.NewRequest("GET", "/v1/inventory?updatedSince="+before, nil))
+	if rr.Code != http.StatusGone {
+		t.Fatalf("Expected status 410 for a poll that could miss the deletion, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var errResponse models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &errResponse); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	if errResponse.Code != "updated_since_gone" {
+		t.Errorf("Expected code updated_since_gone, got %s", errResponse.Code)
+	}
+
+	response := getUpdatedSince(t, handler, "2026-03-09T00:00:00Z")
+	if len(response.Products) != 1 || response.Products[0].ProductID != "SKU-001" || len(response.DeletedProductIDs) != 0 {
+		t.Errorf("Expected only SKU-001 changed within the window, got %+v", response)
+	}
+}
+
+func TestInventoryHandler_ListProducts_InvalidUpdatedSince(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	rr := httptest.NewRecorder()
+	handler.ListProducts(rr, httptest.NewRequest("GET", "/v1/inventory?updatedSince=yesterday", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", rr.Code)
+	}
+
+	var response models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	if response.Code != "bad_request" {
+		t.Errorf("Expected code bad_request, got %s", response.Code)
+	}
+}
+
+func TestInventoryHandler_ListProducts_NDJSON(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	stream := func(query string) *http.Response {
+		req := httptest.NewRequest("GET", "/v1/inventory"+query, nil)
+		req.Header.Set("Accept", "application/x-ndjson")
+		rr := httptest.NewRecorder()
+		handler.ListProducts(rr, req)
+		return rr.Result()
+	}
+
+	resp := stream("")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != handlers.NDJSONContentType {
+		t.Fatalf("Expected a 200 NDJSON stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get(handlers.ListTotalCountHeader) != "2" || resp.Trailer.Get(handlers.ListProductCountHeader) != "2" {
+		t.Errorf("Expected total 2 and a product count trailer of 2, got %q and %q",
+			resp.Header.Get(handlers.ListTotalCountHeader), resp.Trailer.Get(handlers.ListProductCountHeader))
+	}
+	var ids []string
+	decoder := json.NewDecoder(resp.Body)
+	for decoder.More() {
+		var product models.ProductResponse
+		if err := decoder.Decode(&product); err != nil {
+			t.Fatalf("Failed to decode product line: %v", err)
+		}
+		ids = append(ids, product.ProductID)
+	}
+	if strings.Join(ids, ",") != "SKU-001,SKU-002" {
+		t.Errorf("Expected SKU-001 then SKU-002, got %v", ids)
+	}
+
+	// offset resumes a stream; limit is optional and uncapped
+	resp = stream("?offset=1&limit=500")
+	if resp.Trailer.Get(handlers.ListProductCountHeader) != "1" {
+		t.Errorf("Expected one product after offset 1, got %q", resp.Trailer.Get(handlers.ListProductCountHeader))
+	}
+	if resp := stream("?updatedSince=2024-01-15T10:00:00Z"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected delta listings to stay JSON only, got %d", resp.StatusCode)
+	}
+
+	// JSON stays the default and wins ties
+	req := httptest.NewRequest("GET", "/v1/inventory", nil)
+	req.Header.Set("Accept", "application/json, application/x-ndjson")
+	rr := httptest.NewRecorder()
+	handler.ListProducts(rr, req)
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "application/json") || rr.Header().Get("Vary") != "Accept" {
+		t.Errorf("Expected a JSON page varying on Accept, got %s (Vary %q)", rr.Header().Get("Content-Type"), rr.Header().Get("Vary"))
+	}
+}
+
+func getVersions(handler *handlers.InventoryHandler, query string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.GetProductVersions(rr, httptest.NewRequest("GET", "/v1/inventory/versions?"+query, nil))
+	return rr
+}
+
+func TestInventoryHandler_GetProductVersions(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	rr := getVersions(handler, "ids=SKU-001,SKU-404&ids=SKU-002,SKU-001")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.VersionsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	expected := map[string]models.ProductVersion{
+		"SKU-001": {Version: 3, Available: 10},
+		"SKU-002": {Version: 7, Available: 0},
+	}
+	if len(response.Versions) != len(expected) {
+		t.Fatalf("Expected %d versions, got %+v", len(expected), response.Versions)
+	}
+	for productID, want := range expected {
+		if got := response.Versions[productID]; got != want {
+			t.Errorf("Expected %s at %+v, got %+v", productID, want, got)
+		}
+	}
+	if len(response.Missing) != 1 || response.Missing[0] != "SKU-404" {
+		t.Errorf("Expected SKU-404 missing, got %v", response.Missing)
+	}
+}
+
+func TestInventoryHandler_GetProductVersions_Rejected(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+	handler.SetMaxBatchItems(2)
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{name: "no ids", query: "", code: "validation_error"},
+		{name: "blank ids", query: "ids=,%20", code: "validation_error"},
+		{name: "too many IDs", query: "ids=SKU-001,SKU-002,SKU-003", code: "batch_too_large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := getVersions(handler, tt.query)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", rr.Code)
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to decode error: %v", err)
+			}
+			if response.Code != tt.code {
+				t.Errorf("Expected code %s, got %s", tt.code, response.Code)
+			}
+		})
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_Priority(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	body := `{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "01J000000000000000000000P1"}`
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+	req.Header.Set("X-Update-Priority", "sync")
+	rr := httptest.NewRecorder()
+	handler.UpdateInventory(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for a sync update, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+	req.Header.Set("X-Update-Priority", "urgent")
+	rr = httptest.NewRecorder()
+	handler.UpdateInventory(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an unknown priority, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// Admins default to the bulk lane, however they authenticated, and may
+	// not ask for a higher one
+	admin := authz.Principal{ID: "jwt:ops", Source: authz.SourceJWT, Roles: []authz.Role{authz.RoleAdmin}}
+	req = httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+	req = req.WithContext(authz.WithPrincipal(req.Context(), admin))
+	req.Header.Set("X-Update-Priority", "checkout")
+	rr = httptest.NewRecorder()
+	handler.UpdateInventory(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400 for an admin raising its priority, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_Reason(t *testing.T) {
+	f := newRestoreFixture(t)
+	handler := handlers.NewInventoryHandler(f.service)
+
+	body := `{"storeId": "store-1", "productId": "SKU-001", "delta": 2, "version": 3, "idempotencyKey": "01J000000000000000000000R1", "reason": "return"}`
+	rr := httptest.NewRecorder()
+	handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	f.requireOffset(t, 1)
+	stored, _, _ := f.eventQueue.GetEvents(0, 10)
+	if len(stored) != 1 || stored[0].Reason != "return" || stored[0].Delta != 2 {
+		t.Fatalf("Expected the reason on the update event, got %+v", stored)
+	}
+
+	body = `{"storeId": "store-1", "productId": "SKU-001", "delta": 1, "version": 4, "idempotencyKey": "01J000000000000000000000R2", "reason": "` + strings.Repeat("x", 65) + `"}`
+	rr = httptest.NewRecorder()
+	handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an over-long reason, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_Conditions(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	update := func(body string) (int, models.UpdateResponse) {
+		rr := httptest.NewRecorder()
+		handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body)))
+		var response models.UpdateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return rr.Code, response
+	}
+
+	// A failed condition reports the current stock so the caller can retry
+	status, response := update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "minAvailable": 20, "idempotencyKey": "01J000000000000000000000C1"}`)
+	if status != http.StatusPreconditionFailed || response.ErrorType != "condition_failed" || response.NewQuantity != 10 || response.NewVersion != 3 {
+		t.Fatalf("Expected 412 condition_failed with the current stock, got %d %+v", status, response)
+	}
+
+	// With a condition the version may be left out
+	status, response = update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "minAvailable": 5, "idempotencyKey": "01J000000000000000000000C2"}`)
+	if status != http.StatusOK || response.NewQuantity != 9 || response.NewVersion != 4 {
+		t.Fatalf("Expected the conditional update to apply, got %d %+v", status, response)
+	}
+
+	status, response = update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "expectedAvailable": 10, "idempotencyKey": "01J000000000000000000000C3"}`)
+	if status != http.StatusPreconditionFailed || response.ErrorType != "condition_failed" {
+		t.Fatalf("Expected 412 for a stale expectedAvailable, got %d %+v", status, response)
+	}
+
+	// Without a condition the version is still required
+	status, response = update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "idempotencyKey": "01J000000000000000000000C4"}`)
+	if status == http.StatusOK {
+		t.Fatalf("Expected an unconditional update without a version to fail, got %+v", response)
+	}
+
+	status, _ = update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "minAvailable": -1, "idempotencyKey": "01J000000000000000000000C5"}`)
+	if status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a negative minAvailable, got %d", status)
+	}
+
+	batch := `{"storeId": "store-1", "updates": [
+		{"productId": "SKU-001", "delta": -1, "expectedAvailable": 9, "idempotencyKey": "01J000000000000000000000C6"},
+		{"productId": "SKU-002", "delta": -1, "minAvailable": 1, "idempotencyKey": "01J000000000000000000000C7"}]}`
+	_, response = update(batch)
+	if len(response.Results) != 2 || !response.Results[0].Applied || response.Results[0].NewQuantity != 8 {
+		t.Fatalf("Expected the first batch item to apply, got %+v", response.Results)
+	}
+	if response.Results[1].Applied || response.Results[1].ErrorType != "condition_failed" {
+		t.Errorf("Expected the second batch item to fail its condition, got %+v", response.Results[1])
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_IdempotencyKeys(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	update := func(body, storeHeader string) (int, models.UpdateResponse) {
+		req := httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+		if storeHeader != "" {
+			req.Header.Set("X-Store-ID", storeHeader)
+		}
+		rr := httptest.NewRecorder()
+		handler.UpdateInventory(rr, req)
+		var response models.UpdateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return rr.Code, response
+	}
+
+	status, response := update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "store-1-checkout-1"}`, "")
+	if status != http.StatusBadRequest || response.ErrorType != "invalid_idempotency_key" {
+		t.Fatalf("Expected 400 invalid_idempotency_key, got %d %+v", status, response)
+	}
+
+	// A replay from the same store returns the cached result
+	const key = "3b241101-e2bb-4255-8caf-4136c566a962"
+	for i := 0; i < 2; i++ {
+		status, response = update(`{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "`+key+`"}`, "")
+		if status != http.StatusOK || response.NewVersion != 4 {
+			t.Fatalf("Attempt %d: expected version 4, got %d %+v", i+1, status, response)
+		}
+	}
+
+	// The same key from another store is a different update
+	status, response = update(`{"storeId": "store-2", "productId": "SKU-001", "delta": -1, "version": 4, "idempotencyKey": "`+key+`"}`, "")
+	if status != http.StatusOK || response.NewVersion != 5 || response.NewQuantity != 8 {
+		t.Fatalf("Expected store-2's update to apply, got %d %+v", status, response)
+	}
+
+	// Without storeId in the body the key is scoped by X-Store-ID
+	status, response = update(`{"productId": "SKU-001", "delta": -1, "version": 5, "idempotencyKey": "`+key+`"}`, "store-3")
+	if status != http.StatusOK || response.NewVersion != 6 {
+		t.Fatalf("Expected store-3's update to apply, got %d %+v", status, response)
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_Replayed(t *testing.T) {
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+
+	update := func(body string) (*httptest.ResponseRecorder, models.UpdateResponse) {
+		rr := httptest.NewRecorder()
+		handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body)))
+		var response models.UpdateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return rr, response
+	}
+
+	body := `{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "01J0000000000000000000RP01"}`
+	rr, response := update(body)
+	if rr.Code != http.StatusOK || response.Replayed || rr.Header().Get("Idempotency-Replayed") != "" {
+		t.Fatalf("Expected a fresh update, got %d %+v", rr.Code, response)
+	}
+	rr, response = update(body)
+	if rr.Code != http.StatusOK || !response.Replayed || response.NewVersion != 4 || rr.Header().Get("Idempotency-Replayed") != "true" {
+		t.Fatalf("Expected the retry to be replayed, got %d %+v (header %q)", rr.Code, response, rr.Header().Get("Idempotency-Replayed"))
+	}
+
+	// Rejected requests are cached too, so retries never reach the queue
+	body = `{"storeId": "store-1", "delta": -1, "version": 4, "idempotencyKey": "01J0000000000000000000RP02"}`
+	rr, response = update(body)
+	if rr.Code != http.StatusBadRequest || response.Replayed {
+		t.Fatalf("Expected 400 for a missing product ID, got %d %+v", rr.Code, response)
+	}
+	rr, response = update(body)
+	if rr.Code != http.StatusBadRequest || response.ErrorType != "missing_product_id" || !response.Replayed || rr.Header().Get("Idempotency-Replayed") != "true" {
+		t.Fatalf("Expected the rejected retry to be replayed, got %d %+v", rr.Code, response)
+	}
+
+	// A batch is marked replayed only when every item was
+	batch := `{"storeId": "store-1", "updates": [
+		{"productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "01J0000000000000000000RP01"},
+		{"productId": "SKU-001", "delta": -1, "version": 4, "idempotencyKey": "01J0000000000000000000RP03"}]}`
+	rr, response = update(batch)
+	if rr.Header().Get("Idempotency-Replayed") != "" || !response.Results[0].Replayed || response.Results[1].Replayed || !response.Results[1].Applied {
+		t.Fatalf("Expected only the first item to be replayed, got %+v", response.Results)
+	}
+	rr, _ = update(batch)
+	if rr.Header().Get("Idempotency-Replayed") != "true" {
+		t.Errorf("Expected a fully replayed batch to set Idempotency-Replayed")
+	}
+}
+
+func TestInventoryHandler_GetCatalogSnapshot(t *testing.T) {
+	f := newRestoreFixture(t)
+	f.sell(t, "SKU-001", 2, 3)
+	handler := handlers.NewInventoryHandler(f.service)
+
+	rr := httptest.NewRecorder()
+	handler.GetCatalogSnapshot(rr, httptest.NewRequest("GET", "/v1/inventory/snapshot", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get(handlers.SnapshotOffsetHeader) != "1" || rr.Header().Get(handlers.SnapshotProductCountHeader) != "2" {
+		t.Errorf("Unexpected snapshot headers: %v", rr.Header())
+	}
+
+	var snapshot struct {
+		EventOffset  int64                    `json:"eventOffset"`
+		ProductCount int                      `json:"productCount"`
+		Products     []models.ProductResponse `json:"products"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	// The offset is the next event to poll, after the sale already in the copy
+	if snapshot.EventOffset != 1 || snapshot.ProductCount != 2 || len(snapshot.Products) != 2 {
+		t.Fatalf("Unexpected snapshot: %+v", snapshot)
+	}
+	if snapshot.Products[0].ProductID != "SKU-001" || snapshot.Products[0].Available != 8 || snapshot.Products[0].Version != 4 {
+		t.Errorf("Expected SKU-001 at version 4 with 8 units, got %+v", snapshot.Products[0])
+	}
+}
+
+// failingRates is a rates provider whose source is down
+type failingRates struct{}
+
+func (failingRates) Rate(from, to string) (float64, error) {
+	return 0, errors.New("rates source unreachable")
+}
+
+func (failingRates) BaseCurrency() string { return "USD" }
+
+func TestInventoryHandler_GetProductPrice_Errors(t *testing.T) {
+	service := newBatchTestService(t)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/inventory/{productId}/price", handlers.NewInventoryHandler(service).GetProductPrice).Methods("GET")
+	getPrice := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	service.SetRatesProvider(currency.NewStaticRatesProvider("USD", map[string]float64{"EUR": 0.5}))
+	tests := []struct {
+		name      string
+		path      string
+		status    int
+		errorType string
+	}{
+		{"converted", "/v1/inventory/SKU-001/price?currency=EUR", http.StatusOK, ""},
+		{"no rate", "/v1/inventory/SKU-001/price?currency=GBP", http.StatusBadRequest, services.ErrTypeUnsupportedCurrency},
+		{"invalid code", "/v1/inventory/SKU-001/price?currency=euro", http.StatusBadRequest, services.ErrTypeUnsupportedCurrency},
+		{"unknown product", "/v1/inventory/SKU-404/price?currency=EUR", http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := getPrice(tt.path)
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.errorType != "" && !strings.Contains(rr.Body.String(), `"`+tt.errorType+`"`) {
+				t.Errorf("Expected error type %s, got %s", tt.errorType, rr.Body.String())
+			}
+		})
+	}
+
+	// A failing rates provider is a server error, not a missing product
+	service.SetRatesProvider(failingRates{})
+	if rr := getPrice("/v1/inventory/SKU-001/price?currency=EUR"); rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500 when rates are unavailable, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
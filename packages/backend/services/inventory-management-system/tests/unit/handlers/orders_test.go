@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/orders"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOrdersHandler(t *testing.T) {
+	f := newRestoreFixture(t)
+	orderPath := filepath.Join(t.TempDir(), "orders.json")
+	orderStore, err := orders.NewStore(orderPath)
+	if err != nil {
+		t.Fatalf("Failed to create order store: %v", err)
+	}
+	f.service.SetOrders(orderStore)
+
+	ordersHandler := handlers.NewOrdersHandler(f.service)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/orders", ordersHandler.CreateOrder).Methods("POST")
+	router.HandleFunc("/v1/orders/{id}", ordersHandler.GetOrder).Methods("GET")
+	router.HandleFunc("/v1/orders/{id}/commit", ordersHandler.CommitOrder).Methods("POST")
+	router.HandleFunc("/v1/orders/{id}/cancel", ordersHandler.CancelOrder).Methods("POST")
+
+	post := func(path string, req interface{}) (*httptest.ResponseRecorder, models.Order) {
+		var body []byte
+		if req != nil {
+			body, _ = json.Marshal(req)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", path, bytes.NewReader(body)))
+		var order models.Order
+		json.Unmarshal(rr.Body.Bytes(), &order)
+		return rr, order
+	}
+	available := func(productID string) int {
+		product, err := f.service.GetProduct(productID)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", productID, err)
+		}
+		return product.Available
+	}
+
+	// SKU-002 has no stock, so nothing is reserved
+	request := models.OrderRequest{
+		StoreID: "store-1",
+		Lines: []models.OrderLine{
+			{ProductID: "SKU-001", Quantity: 3},
+			{ProductID: "SKU-002", Quantity: 1},
+		},
+		IdempotencyKey: "01J0000000000000000000RD01",
+	}
+	rr, _ := post("/v1/orders", request)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if available("SKU-001") != 10 {
+		t.Fatalf("Expected a rejected order to reserve nothing, SKU-001 has %d", available("SKU-001"))
+	}
+
+	request.Lines = request.Lines[:1]
+	request.IdempotencyKey = "01J0000000000000000000RD02"
+	rr, order := post("/v1/orders", request)
+	if rr.Code != http.StatusCreated || order.OrderID == "" || order.Status != models.OrderStatusReserved {
+		t.Fatalf("Expected a reserved order, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(order.Items) != 1 || order.Items[0].NewVersion != 4 || order.Items[0].Available != 7 || available("SKU-001") != 7 {
+		t.Fatalf("Expected 3 units of SKU-001 to be held, got %+v", order.Items)
+	}
+
+	// A replay returns the same order without reserving again
+	rr, replayed := post("/v1/orders", request)
+	if replayed.OrderID != order.OrderID || !replayed.Replayed || rr.Header().Get("Idempotency-Replayed") != "true" || available("SKU-001") != 7 {
+		t.Errorf("Expected the replay to return %s, got %s", order.OrderID, rr.Body.String())
+	}
+
+	rr, committed := post("/v1/orders/"+order.OrderID+"/commit", nil)
+	if rr.Code != http.StatusOK || committed.Status != models.OrderStatusCommitted || committed.CommittedAt == "" {
+		t.Fatalf("Expected the order to commit, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if committed.Items[0].NewVersion != 5 || available("SKU-001") != 7 {
+		t.Errorf("Expected a commit to keep the stock and advance the version, got %+v", committed.Items)
+	}
+	if rr, again := post("/v1/orders/"+order.OrderID+"/commit", nil); rr.Code != http.StatusOK || again.Items[0].NewVersion != 5 {
+		t.Errorf("Expected committing twice to return the order unchanged, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr, _ := post("/v1/orders/"+order.OrderID+"/cancel", nil); rr.Code != http.StatusConflict {
+		t.Errorf("Expected 409 when cancelling a committed order, got %d", rr.Code)
+	}
+
+	request.IdempotencyKey = "01J0000000000000000000RD03"
+	_, second := post("/v1/orders", request)
+	rr, cancelled := post("/v1/orders/"+second.OrderID+"/cancel", nil)
+	if rr.Code != http.StatusOK || cancelled.Status != models.OrderStatusCancelled || available("SKU-001") != 7 {
+		t.Fatalf("Expected the cancelled units back in stock, got %d with %d available: %s", rr.Code, available("SKU-001"), rr.Body.String())
+	}
+
+	f.requireOffset(t, 4)
+	stored, _, _ := f.eventQueue.GetEvents(0, 10)
+	expected := []string{models.EventTypeOrderReserved, models.EventTypeOrderCommitted, models.EventTypeOrderReserved, models.EventTypeOrderCancelled}
+	if len(stored) != len(expected) {
+		t.Fatalf("Expected %d order events, got %+v", len(expected), stored)
+	}
+	for i, event := range stored {
+		if event.EventType != expected[i] || event.Quantity != 3 || event.StoreID != "store-1" || event.OrderID == "" {
+			t.Errorf("Event %d: expected %s for 3 units, got %+v", i, expected[i], event)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/orders/ord-999999", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown order, got %d", rr.Code)
+	}
+
+	// Orders survive a restart
+	reloaded, err := orders.NewStore(orderPath)
+	if err != nil {
+		t.Fatalf("Failed to reload orders: %v", err)
+	}
+	if saved, ok := reloaded.Get(second.OrderID); !ok || saved.Status != models.OrderStatusCancelled {
+		t.Errorf("Expected the cancelled order to be saved, got %+v", saved)
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
+)
+
+// fakePartitionServer answers updates, version reads and snapshots for the
+// products it is given, the way a partition instance would
+func fakePartitionServer(t *testing.T, products map[string]models.ProductResponse) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "store-key" {
+			t.Errorf("Expected the caller's API key to reach the partition, got %q", r.Header.Get("X-API-Key"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/v1/inventory/updates":
+			var req models.UpdateRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			response := models.UpdateResponse{Summary: &models.BatchSummary{Total: len(req.Updates)}}
+			for _, update := range req.Updates {
+				result := models.ProductUpdateResult{ProductID: update.ProductID, ErrorType: "product_not_found"}
+				if product, ok := products[update.ProductID]; ok {
+					result = models.ProductUpdateResult{ProductID: update.ProductID, Applied: true, NewVersion: product.Version + 1, NewQuantity: product.Available + update.Delta}
+				}
+				response.Results = append(response.Results, result)
+			}
+			json.NewEncoder(w).Encode(response)
+		case "/v1/inventory/versions":
+			response := models.VersionsResponse{Versions: map[string]models.ProductVersion{}, Missing: []string{}}
+			for _, productID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+				if product, ok := products[productID]; ok {
+					response.Versions[productID] = models.ProductVersion{Version: product.Version, Available: product.Available}
+				} else {
+					response.Missing = append(response.Missing, productID)
+				}
+			}
+			json.NewEncoder(w).Encode(response)
+		case "/v1/inventory/snapshot":
+			snapshot := struct {
+				EventOffset  int64                    `json:"eventOffset"`
+				ProductCount int                      `json:"productCount"`
+				Products     []models.ProductResponse `json:"products"`
+			}{EventOffset: 42, ProductCount: len(products)}
+			for _, product := range products {
+				snapshot.Products = append(snapshot.Products, product)
+			}
+			json.NewEncoder(w).Encode(snapshot)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newPartitionRouter routes between p1, owning SKU-001 and SKU-004, and p2,
+// owning SKU-002
+func newPartitionRouter(t *testing.T, p1URL, p2URL string) (*handlers.PartitionRouterHandler, *events.EventQueue) {
+	t.Helper()
+	partitionMap, err := partitions.ParseMap("p1:0-4999=" + p1URL + ",p2:5000-65535=" + p2URL)
+	if err != nil {
+		t.Fatalf("Failed to parse partition map: %v", err)
+	}
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "router-events.json"),
+		MaxEvents: 100,
+		Logger:    slog.Default(),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+	return handlers.NewPartitionRouterHandler(partitionMap, handlers.NewEventsHandler(queue, slog.Default()), queue, time.Second), queue
+}
+
+// partitionProducts returns the fixture products of one partition
+func partitionProducts(productIDs ...string) map[string]models.ProductResponse {
+	products := make(map[string]models.ProductResponse)
+	for i, productID := range productIDs {
+		products[productID] = models.ProductResponse{ProductID: productID, Available: 10 * (i + 1), Version: i + 1}
+	}
+	return products
+}
+
+func TestPartitionRouter_SplitsBatchUpdates(t *testing.T) {
+	p1 := fakePartitionServer(t, partitionProducts("SKU-001", "SKU-004"))
+	p2 := fakePartitionServer(t, partitionProducts("SKU-002"))
+	router, _ := newPartitionRouter(t, p1.URL, p2.URL)
+
+	body := `{"updates": [
+		{"productId": "SKU-002", "delta": -1},
+		{"productId": "SKU-001", "delta": -2},
+		{"productId": "SKU-004", "delta": 5}
+	]}`
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+	req.Header.Set("X-API-Key", "store-key")
+	rr := httptest.NewRecorder()
+	router.UpdateInventory(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.UpdateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	want := []models.ProductUpdateResult{
+		{ProductID: "SKU-002", Applied: true, NewVersion: 2, NewQuantity: 9},
+		{ProductID: "SKU-001", Applied: true, NewVersion: 2, NewQuantity: 8},
+		{ProductID: "SKU-004", Applied: true, NewVersion: 3, NewQuantity: 25},
+	}
+	if len(response.Results) != len(want) {
+		t.Fatalf("Expected %d results, got %+v", len(want), response.Results)
+	}
+	for i := range want {
+		if response.Results[i] != want[i] {
+			t.Errorf("Result %d: expected %+v, got %+v", i, want[i], response.Results[i])
+		}
+	}
+	if *response.Summary != (models.BatchSummary{Total: 3, Succeeded: 3}) {
+		t.Errorf("Unexpected summary %+v", *response.Summary)
+	}
+}
+
+func TestPartitionRouter_UnreachablePartitionFailsItsItems(t *testing.T) {
+	p1 := fakePartitionServer(t, partitionProducts("SKU-001"))
+	p2 := httptest.NewServer(http.NotFoundHandler())
+	p2.Close()
+	router, _ := newPartitionRouter(t, p1.URL, p2.URL)
+
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(`{"updates": [
+		{"productId": "SKU-001", "delta": -1},
+		{"productId": "SKU-002", "delta": -1}
+	]}`))
+	req.Header.Set("X-API-Key", "store-key")
+	rr := httptest.NewRecorder()
+	router.UpdateInventory(rr, req)
+
+	var response models.UpdateResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Results) != 2 || !response.Results[0].Applied || response.Results[1].ErrorType != "service_unavailable" {
+		t.Errorf("Expected SKU-001 applied and SKU-002 unavailable, got %+v", response.Results)
+	}
+	if *response.Summary != (models.BatchSummary{Total: 2, Succeeded: 1, Failed: 1}) {
+		t.Errorf("Unexpected summary %+v", *response.Summary)
+	}
+}
+
+func TestPartitionRouter_GetProductVersions(t *testing.T) {
+	p1 := fakePartitionServer(t, partitionProducts("SKU-001"))
+	p2 := fakePartitionServer(t, partitionProducts("SKU-002"))
+	router, _ := newPartitionRouter(t, p1.URL, p2.URL)
+
+	req := httptest.NewRequest("GET", "/v1/inventory/versions?ids=SKU-004,SKU-002,SKU-001,SKU-002", nil)
+	req.Header.Set("X-API-Key", "store-key")
+	rr := httptest.NewRecorder()
+	router.GetProductVersions(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.VersionsResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Versions) != 2 || response.Versions["SKU-001"].Available != 10 || response.Versions["SKU-002"].Available != 10 {
+		t.Errorf("Expected versions from both partitions, got %+v", response.Versions)
+	}
+	if len(response.Missing) != 1 || response.Missing[0] != "SKU-004" {
+		t.Errorf("Expected SKU-004 missing, got %v", response.Missing)
+	}
+}
+
+func TestPartitionRouter_GetCatalogSnapshot(t *testing.T) {
+	p1 := fakePartitionServer(t, partitionProducts("SKU-004", "SKU-001"))
+	p2 := fakePartitionServer(t, partitionProducts("SKU-002"))
+	router, queue := newPartitionRouter(t, p1.URL, p2.URL)
+	queue.AppendMerged(models.Event{EventType: models.EventTypeProductUpdated, ProductID: "SKU-001", Partition: "p1"})
+
+	req := httptest.NewRequest("GET", "/v1/inventory/snapshot", nil)
+	req.Header.Set("X-API-Key", "store-key")
+	rr := httptest.NewRecorder()
+	router.GetCatalogSnapshot(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var snapshot struct {
+		EventOffset  int64                    `json:"eventOffset"`
+		ProductCount int                      `json:"productCount"`
+		Products     []models.ProductResponse `json:"products"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &snapshot); err != nil {
+		t.Fatalf("Failed to decode snapshot: %v", err)
+	}
+	if snapshot.EventOffset != 1 || rr.Header().Get(handlers.SnapshotOffsetHeader) != "1" {
+		t.Errorf("Expected the snapshot at the router's offset 1, got %d", snapshot.EventOffset)
+	}
+	var productIDs []string
+	for _, product := range snapshot.Products {
+		productIDs = append(productIDs, product.ProductID)
+	}
+	if snapshot.ProductCount != 3 || strings.Join(productIDs, ",") != "SKU-001,SKU-002,SKU-004" {
+		t.Errorf("Expected every partition's products sorted by ID, got %d %v", snapshot.ProductCount, productIDs)
+	}
+}
+
+func TestInventoryHandler_UpdateInventory_WrongPartition(t *testing.T) {
+	partitionMap, err := partitions.ParseMap("p1:0-4999=http://central-1:8081,p2:5000-65535=http://central-2:8081")
+	if err != nil {
+		t.Fatalf("Failed to parse partition map: %v", err)
+	}
+	p1, _ := partitionMap.Get("p1")
+	handler := handlers.NewInventoryHandler(newBatchTestService(t))
+	handler.SetPartition(p1)
+
+	rr := httptest.NewRecorder()
+	handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(`{"updates": [
+		{"productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		{"productId": "SKU-002", "delta": 1, "version": 7, "idempotencyKey": "7c9e6679-7425-40de-944b-e07fc1f90ae7"}
+	]}`)))
+	if rr.Code != http.StatusMisdirectedRequest {
+		t.Fatalf("Expected status 421, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != "wrong_partition" || len(response.Details) != 1 || !strings.HasPrefix(response.Details[0].Issue, "SKU-002") {
+		t.Errorf("Expected SKU-002 reported as owned elsewhere, got %+v", response)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+)
+
+func TestReportsHandler_GetSalesReport(t *testing.T) {
+	store, err := reports.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create report store: %v", err)
+	}
+	err = store.Apply([]models.Event{{
+		Offset:    0,
+		Timestamp: "2025-11-28T10:00:00Z",
+		EventType: models.EventTypeProductUpdated,
+		ProductID: "SKU-1",
+		Data:      models.ProductResponse{ProductID: "SKU-1", Name: "Phone, 128GB"},
+		StoreID:   "store-1",
+		Delta:     -2,
+	}}, 1)
+	if err != nil {
+		t.Fatalf("Failed to apply sales: %v", err)
+	}
+	handler := handlers.NewReportsHandler(store)
+
+	get := func(query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/v1/admin/reports/sales?"+query, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rr := httptest.NewRecorder()
+		handler.GetSalesReport(rr, req)
+		return rr
+	}
+
+	rr := get("date=2025-11-28", "")
+	var report models.SalesReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode JSON report: %v", err)
+	}
+	if rr.Code != http.StatusOK || report.TotalUnits != 2 || report.GeneratedAt == "" {
+		t.Errorf("Unexpected JSON report (%d): %+v", rr.Code, report)
+	}
+
+	for _, rr := range []*httptest.ResponseRecorder{get("date=2025-11-28&format=csv", ""), get("date=2025-11-28", "text/csv")} {
+		if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+			t.Errorf("Expected a CSV response, got %q", rr.Header().Get("Content-Type"))
+		}
+		expected := "date,product_id,store_id,product_name,category,units_sold,transactions\n" +
+			"2025-11-28,SKU-1,store-1,\"Phone, 128GB\",,2,1\n"
+		if rr.Body.String() != expected {
+			t.Errorf("Unexpected CSV:\n%s", rr.Body.String())
+		}
+	}
+
+	for _, query := range []string{"date=28-11-2025", "format=xml"} {
+		if rr := get(query, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+)
+
+func TestCheckRequestModels(t *testing.T) {
+	if err := handlers.CheckRequestModels(); err != nil {
+		t.Fatalf("Request model has invalid validation rules: %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+	"inventory-management-api/internal/snapshots"
+
+	"github.com/gorilla/mux"
+)
+
+type restoreFixture struct {
+	service    *services.InventoryService
+	eventQueue *events.EventQueue
+	store      *snapshots.Store
+	handler    *handlers.RestoreHandler
+	published  int64 // Events published so far
+}
+
+func newRestoreFixture(t *testing.T) *restoreFixture {
+	t.Helper()
+
+	service := newBatchTestService(t)
+	eventQueue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	t.Cleanup(func() { eventQueue.Close() })
+	service.SetEventQueue(eventQueue)
+
+	store, err := snapshots.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create snapshot store: %v", err)
+	}
+	return &restoreFixture{
+		service:    service,
+		eventQueue: eventQueue,
+		store:      store,
+		handler:    handlers.NewRestoreHandler(service, store, eventQueue),
+	}
+}
+
+// sell applies a sale of one product and checks its event was stored and
+// recorded in the metadata before the update returned
+func (f *restoreFixture) sell(t *testing.T, productID string, units, version int) {
+	t.Helper()
+
+	result, err := f.service.UpdateInventory(productID, -units, version, fmt.Sprintf("%s-v%d", productID, version), "store-1")
+	if err != nil || !result.Success {
+		t.Fatalf("Update failed: %v %+v", err, result)
+	}
+	f.published++
+	f.requireOffset(t, f.published)
+}
+
+// requireOffset checks that offset events are stored and recorded in the
+// metadata; changes publish their events before returning
+func (f *restoreFixture) requireOffset(t *testing.T, offset int64) {
+	t.Helper()
+
+	_, lastOffset := f.service.SnapshotProducts()
+	if count := f.eventQueue.Stats().EventCount; count < int(offset) || lastOffset < int(offset) {
+		t.Fatalf("Expected offset %d, got %d events and metadata offset %d", offset, count, lastOffset)
+	}
+}
+
+func postRestore(handler *handlers.RestoreHandler, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	handler.Restore(rr, httptest.NewRequest("POST", "/v1/admin/restore", strings.NewReader(body)))
+	return rr
+}
+
+func TestRestoreHandler_RestoresToTargetOffset(t *testing.T) {
+	f := newRestoreFixture(t)
+
+	f.sell(t, "SKU-001", 1, 3) // offset 0: 9 left, version 4
+	products, lastOffset := f.service.SnapshotProducts()
+	info, err := f.store.Create("before sales", products, lastOffset)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	f.sell(t, "SKU-001", 2, 4) // offset 1: 7 left, version 5
+	f.sell(t, "SKU-001", 3, 5) // offset 2: 4 left, version 6
+
+	rr := postRestore(f.handler, fmt.Sprintf(`{"snapshotId": %q, "targetOffset": 2, "reason": "undo last sale"}`, info.ID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.RestoreResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.EventsReplayed != 1 || response.ProductCount != 2 || response.TotalAvailable != 7 || response.RestoreOffset != 3 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+
+	product, err := f.service.GetProduct("SKU-001")
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if product.Available != 7 || product.Version != 5 {
+		t.Errorf("Expected 7 available at version 5, got %d at version %d", product.Available, product.Version)
+	}
+
+	// Stores learn about the restore from the event log
+	f.published++
+	f.requireOffset(t, f.published)
+	restoreEvents, _, _ := f.eventQueue.GetEvents(3, 10)
+	if len(restoreEvents) != 1 || restoreEvents[0].EventType != models.EventTypeSystemRestored {
+		t.Errorf("Expected a system_restored event, got %+v", restoreEvents)
+	}
+
+	// Writes resume on the restored versions
+	f.sell(t, "SKU-001", 1, 5)
+}
+
+func TestRestoreHandler_DefaultsToSnapshotOffset(t *testing.T) {
+	f := newRestoreFixture(t)
+
+	products, lastOffset := f.service.SnapshotProducts()
+	info, err := f.store.Create("initial", products, lastOffset)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	f.sell(t, "SKU-001", 4, 3)
+
+	rr := postRestore(f.handler, fmt.Sprintf(`{"snapshotId": %q}`, info.ID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	product, err := f.service.GetProduct("SKU-001")
+	if err != nil {
+		t.Fatalf("Failed to get product: %v", err)
+	}
+	if product.Available != 10 || product.Version != 3 {
+		t.Errorf("Expected the snapshot state, got %d at version %d", product.Available, product.Version)
+	}
+}
+
+func TestRestoreHandler_Errors(t *testing.T) {
+	f := newRestoreFixture(t)
+
+	products, lastOffset := f.service.SnapshotProducts()
+	info, err := f.store.Create("initial", products, lastOffset)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "missing snapshot ID", body: `{}`, status: http.StatusBadRequest},
+		{name: "malformed JSON", body: `{"snapshotId":`, status: http.StatusBadRequest},
+		{name: "unknown snapshot", body: `{"snapshotId": "20250101T000000Z-deadbeef"}`, status: http.StatusNotFound},
+		{name: "target beyond current offset", body: fmt.Sprintf(`{"snapshotId": %q, "targetOffset": 99}`, info.ID), status: http.StatusBadRequest},
+		{name: "negative target", body: fmt.Sprintf(`{"snapshotId": %q, "targetOffset": -1}`, info.ID), status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := postRestore(f.handler, tt.body)
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRestoreHandler_RestoreJob(t *testing.T) {
+	f := newRestoreFixture(t)
+	jobManager, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 4, ChunkSize: 1})
+	if err != nil {
+		t.Fatalf("Failed to create job manager: %v", err)
+	}
+	t.Cleanup(jobManager.Stop)
+	f.handler.SetJobs(jobManager)
+	jobsHandler := handlers.NewJobsHandler(jobManager)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/jobs", jobsHandler.StartJob).Methods("POST")
+	router.HandleFunc("/v1/admin/jobs/{id}", jobsHandler.GetJob).Methods("GET")
+
+	startJob := func(params string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"type": %q, "params": %s}`, models.JobTypeRestore, params)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/admin/jobs", strings.NewReader(body)))
+		return rr
+	}
+
+	products, lastOffset := f.service.SnapshotProducts()
+	info, err := f.store.Create("initial", products, lastOffset)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	f.sell(t, "SKU-001", 4, 3)
+
+	t.Run("refuses invalid params before queueing", func(t *testing.T) {
+		for params, code := range map[string]string{
+			`{}`: "validation_error",
+			`{"snapshotId": "20250101T000000Z-deadbeef"}`:                  "snapshot_not_found",
+			fmt.Sprintf(`{"snapshotId": %q, "targetOffset": 99}`, info.ID): "validation_error",
+		} {
+			rr := startJob(params)
+			var response models.ErrorResponse
+			json.Unmarshal(rr.Body.Bytes(), &response)
+			if rr.Code != http.StatusBadRequest || response.Code != code {
+				t.Errorf("Expected 400 %s for %s, got %d: %s", code, params, rr.Code, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("restores in the background", func(t *testing.T) {
+		rr := startJob(fmt.Sprintf(`{"snapshotId": %q}`, info.ID))
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var queued models.Job
+		if err := json.Unmarshal(rr.Body.Bytes(), &queued); err != nil {
+			t.Fatalf("Failed to decode job: %v", err)
+		}
+
+		job := waitForJob(t, router, queued.ID)
+		if job.Status != models.JobStatusSucceeded || job.Succeeded != 1 {
+			t.Fatalf("Expected the restore job to succeed, got %+v", job)
+		}
+		var output models.RestoreResponse
+		if err := json.Unmarshal(job.Output, &output); err != nil {
+			t.Fatalf("Failed to decode job output: %v", err)
+		}
+		if output.SnapshotID != info.ID || output.ProductCount != 2 || output.RestoreOffset != 1 {
+			t.Errorf("Unexpected job output: %+v", output)
+		}
+		product, err := f.service.GetProduct("SKU-001")
+		if err != nil {
+			t.Fatalf("Failed to get product: %v", err)
+		}
+		if product.Available != 10 || product.Version != 3 {
+			t.Errorf("Expected the snapshot state, got %d at version %d", product.Available, product.Version)
+		}
+	})
+}
+
+func TestInventoryService_EventsFollowUpdateOrder(t *testing.T) {
+	f := newRestoreFixture(t)
+
+	// Concurrent sellers retry on version conflicts, so every sale applies
+	var wg sync.WaitGroup
+	for seller := 0; seller < 4; seller++ {
+		wg.Add(1)
+		go func(seller int) {
+			defer wg.Done()
+			for sale := 0; sale < 2; sale++ {
+				for attempt := 0; ; attempt++ {
+					product, _ := f.service.GetProduct("SKU-001")
+					key := fmt.Sprintf("seller-%d-sale-%d-attempt-%d", seller, sale, attempt)
+					result, err := f.service.UpdateInventory("SKU-001", -1, product.Version, key, "store-1")
+					if err != nil {
+						t.Errorf("Update failed: %v", err)
+						return
+					}
+					if result.Success {
+						break
+					}
+				}
+			}
+		}(seller)
+	}
+	wg.Wait()
+
+	f.requireOffset(t, 8)
+	stored, _, _ := f.eventQueue.GetEvents(0, 100)
+	if len(stored) != 8 {
+		t.Fatalf("Expected 8 events, got %d", len(stored))
+	}
+	for i, event := range stored {
+		if event.Version != 4+i || event.Data.Available != 9-i {
+			t.Errorf("Event %d: expected version %d with %d available, got version %d with %d", i, 4+i, 9-i, event.Version, event.Data.Available)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/runtimeconfig"
+)
+
+func newRuntimeConfigHandler() (*handlers.RuntimeConfigHandler, *middleware.RateLimiter) {
+	cfg := &config.Config{
+		RateLimitEnabled:                "true",
+		RateLimitType:                   "ip",
+		RateLimitRequestsPerMinute:      "100",
+		RateLimitWindowMinutes:          "1",
+		RateLimitAdminRequestsPerMinute: "50",
+	}
+	rateLimiter := middleware.NewRateLimiter(middleware.ParseRateLimitConfig(cfg))
+	return handlers.NewRuntimeConfigHandler(runtimeconfig.NewManager(cfg, rateLimiter, nil, nil)), rateLimiter
+}
+
+func patchConfig(handler *handlers.RuntimeConfigHandler, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("PATCH", "/v1/admin/config", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "admin-secret")
+	rr := httptest.NewRecorder()
+	handler.PatchConfig(rr, req)
+	return rr
+}
+
+func TestRuntimeConfigHandler_PatchConfig(t *testing.T) {
+	handler, rateLimiter := newRuntimeConfigHandler()
+
+	rr := patchConfig(handler, `{"settings": {"rateLimitRequestsPerMinute": 300, "rateLimitTiers": "store:900"}, "reason": "sale"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	var response models.RuntimeConfigPatchResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Applied) != 2 {
+		t.Fatalf("Expected 2 applied changes, got %+v", response.Applied)
+	}
+	for _, entry := range response.Applied {
+		if entry.Actor != "admin key:admi****" || entry.Source != "api" || entry.Reason != "sale" {
+			t.Errorf("Unexpected audit entry: %+v", entry)
+		}
+	}
+	if limit := rateLimiter.Config().RequestsPerMinute; limit != 300 {
+		t.Errorf("Expected limit 300, got %d", limit)
+	}
+
+	// The GET view includes the audit trail
+	rr = httptest.NewRecorder()
+	handler.GetConfig(rr, httptest.NewRequest("GET", "/v1/admin/config", nil))
+	var view models.RuntimeConfigResponse
+	if err := json.NewDecoder(rr.Body).Decode(&view); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if view.Settings["rateLimitTiers"] != "store:900" || len(view.Audit) != 2 {
+		t.Errorf("Unexpected config view: %+v", view)
+	}
+}
+
+func TestRuntimeConfigHandler_PatchConfig_Invalid(t *testing.T) {
+	handler, rateLimiter := newRuntimeConfigHandler()
+
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{name: "malformed JSON", body: `{"settings":`},
+		{name: "no settings", body: `{"settings": {}}`, field: "settings"},
+		{name: "object value", body: `{"settings": {"rateLimitRequestsPerMinute": {"value": 5}}}`, field: "settings.rateLimitRequestsPerMinute"},
+		{name: "restart-only setting", body: `{"settings": {"rateLimitRequestsPerMinute": 5, "rateLimitAlgorithm": "token_bucket"}}`, field: "settings.rateLimitAlgorithm"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := patchConfig(handler, tt.body)
+			if rr.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d: %s", rr.Code, rr.Body.String())
+			}
+			if tt.field != "" {
+				var response models.ErrorResponse
+				json.NewDecoder(rr.Body).Decode(&response)
+				if len(response.Details) != 1 || response.Details[0].Field != tt.field {
+					t.Errorf("Expected one detail for %s, got %+v", tt.field, response.Details)
+				}
+			}
+		})
+	}
+	if limit := rateLimiter.Config().RequestsPerMinute; limit != 100 {
+		t.Errorf("Expected the limit to stay 100, got %d", limit)
+	}
+}
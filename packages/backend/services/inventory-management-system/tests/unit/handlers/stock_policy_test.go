@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+func TestInventoryHandler_UpdateInventory_StockPolicies(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	flags := featureflags.New("", "")
+	flags.Set(featureflags.PositiveDeltas, "", true)
+	inventoryService.SetFeatureFlags(flags)
+	handler := handlers.NewInventoryHandler(inventoryService)
+
+	keys := 0
+	update := func(productID string, delta, version int) (int, models.UpdateResponse) {
+		t.Helper()
+		keys++
+		body := fmt.Sprintf(`{"storeId": "store-1", "productId": %q, "delta": %d, "version": %d, "idempotencyKey": "01J0000000000000000000SP%02d"}`, productID, delta, version, keys)
+		rr := httptest.NewRecorder()
+		handler.UpdateInventory(rr, httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body)))
+		var response models.UpdateResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return rr.Code, response
+	}
+
+	// SKU-001 follows the policy of its parent category
+	minStock, maxStock := 5, 5
+	if _, err := inventoryService.PutCategory("electronics", models.CategoryRequest{Name: "Electronics",
+		StockPolicy: &models.StockPolicy{MinStock: &minStock, AllowBackorder: true, MaxBackorder: 3}}); err != nil {
+		t.Fatalf("Failed to save electronics: %v", err)
+	}
+	if _, err := inventoryService.PutCategory("phones", models.CategoryRequest{Name: "Phones", ParentID: "electronics"}); err != nil {
+		t.Fatalf("Failed to save phones: %v", err)
+	}
+	phones := "phones"
+	if response, err := inventoryService.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-001", Category: &phones}}); err != nil || !response.Results[0].Success {
+		t.Fatalf("Failed to assign SKU-001 to phones: %+v (%v)", response, err)
+	}
+
+	if code, response := update("SKU-001", -6, 4); code != http.StatusOK || response.NewQuantity != 4 || !response.BelowMinStock {
+		t.Errorf("Expected the sale to apply below the minimum, got %d: %+v", code, response)
+	}
+	if code, response := update("SKU-001", -6, 5); code != http.StatusOK || response.NewQuantity != -2 {
+		t.Errorf("Expected a backorder of 2, got %d: %+v", code, response)
+	}
+	if code, response := update("SKU-001", -2, 6); code != http.StatusUnprocessableEntity ||
+		response.ErrorType != services.ErrTypeBackorderLimit || response.NewQuantity != -2 {
+		t.Errorf("Expected backorder_limit_exceeded, got %d: %+v", code, response)
+	}
+
+	// SKU-002 has its own policy, capping restocks
+	if response, err := inventoryService.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-002",
+		StockPolicy: &models.StockPolicy{MaxStock: &maxStock}}}); err != nil || !response.Results[0].Success {
+		t.Fatalf("Failed to set the SKU-002 policy: %+v (%v)", response, err)
+	}
+	if code, response := update("SKU-002", 6, 8); code != http.StatusUnprocessableEntity || response.ErrorType != services.ErrTypeMaxStockExceeded {
+		t.Errorf("Expected max_stock_exceeded, got %d: %+v", code, response)
+	}
+	if code, response := update("SKU-002", 5, 8); code != http.StatusOK || response.NewQuantity != 5 || response.BelowMinStock {
+		t.Errorf("Expected the restock up to the maximum, got %d: %+v", code, response)
+	}
+	if code, response := update("SKU-002", -6, 9); code != http.StatusUnprocessableEntity || response.ErrorType != services.ErrTypeInsufficientInventory {
+		t.Errorf("Expected insufficient_inventory without backorders, got %d: %+v", code, response)
+	}
+
+	// An empty policy clears it
+	if response, err := inventoryService.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-002",
+		StockPolicy: &models.StockPolicy{}}}); err != nil || !response.Results[0].Success {
+		t.Fatalf("Failed to clear the SKU-002 policy: %+v (%v)", response, err)
+	}
+	if product, err := inventoryService.GetProduct("SKU-002"); err != nil || product.StockPolicy != nil {
+		t.Errorf("Expected no policy on SKU-002, got %+v (%v)", product, err)
+	}
+	if code, response := update("SKU-002", 1, 10); code != http.StatusOK || response.NewQuantity != 6 {
+		t.Errorf("Expected restocks to be uncapped, got %d: %+v", code, response)
+	}
+}
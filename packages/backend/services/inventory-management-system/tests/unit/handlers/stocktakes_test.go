@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/stocktakes"
+
+	"github.com/gorilla/mux"
+)
+
+func TestStocktakesHandler(t *testing.T) {
+	f := newRestoreFixture(t)
+	sessionsPath := filepath.Join(t.TempDir(), "stocktakes.json")
+	store, err := stocktakes.NewStore(sessionsPath)
+	if err != nil {
+		t.Fatalf("Failed to create stocktake store: %v", err)
+	}
+	f.service.SetStocktakes(store)
+
+	stocktakesHandler := handlers.NewStocktakesHandler(f.service)
+	inventoryHandler := handlers.NewInventoryHandler(f.service)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/admin/stocktakes", stocktakesHandler.StartStocktake).Methods("POST")
+	router.HandleFunc("/v1/admin/stocktakes", stocktakesHandler.ListStocktakes).Methods("GET")
+	router.HandleFunc("/v1/admin/stocktakes/{id}", stocktakesHandler.GetStocktake).Methods("GET")
+	router.HandleFunc("/v1/admin/stocktakes/{id}/counts", stocktakesHandler.RecordCounts).Methods("POST")
+	router.HandleFunc("/v1/admin/stocktakes/{id}/close", stocktakesHandler.CloseStocktake).Methods("POST")
+	router.HandleFunc("/v1/admin/stocktakes/{id}/cancel", stocktakesHandler.CancelStocktake).Methods("POST")
+	router.HandleFunc("/v1/inventory/updates", inventoryHandler.UpdateInventory).Methods("POST")
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		return rr
+	}
+	start := func(body string) models.StocktakeSession {
+		t.Helper()
+		rr := send("POST", "/v1/admin/stocktakes", body)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var session models.StocktakeSession
+		if err := json.Unmarshal(rr.Body.Bytes(), &session); err != nil {
+			t.Fatalf("Failed to decode stocktake: %v", err)
+		}
+		return session
+	}
+
+	session := start(`{"storeId": "store-1", "reason": "monthly count"}`)
+	if session.Status != models.StocktakeStatusOpen || session.Mode != models.StocktakeModeReject || len(session.ProductIDs) != 2 {
+		t.Fatalf("Unexpected stocktake: %+v", session)
+	}
+
+	// Decrements from the counted store are refused; other stores still sell
+	rr := send("POST", "/v1/inventory/updates", `{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "01J000000000000000000000F1"}`)
+	if rr.Code != http.StatusLocked {
+		t.Errorf("Expected status 423 for a frozen decrement, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = send("POST", "/v1/inventory/updates", `{"storeId": "store-2", "productId": "SKU-001", "delta": -1, "version": 3, "idempotencyKey": "01J00000000000000000000051"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for another store, got %d: %s", rr.Code, rr.Body.String())
+	}
+	f.requireOffset(t, 1)
+
+	if rr := send("POST", "/v1/admin/stocktakes", `{"storeId": "store-1"}`); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 for an overlapping stocktake, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("POST", "/v1/admin/stocktakes", `{"storeId": "store-1", "mode": "pause"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown mode, got %d", rr.Code)
+	}
+
+	// System stock is 9 after the other store's sale
+	rr = send("POST", "/v1/admin/stocktakes/"+session.ID+"/counts", `{"counts": [{"productId": "SKU-001", "counted": 7}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var report models.StocktakeReport
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if len(report.Lines) != 1 || report.Lines[0].SystemQuantity != 9 || report.Lines[0].Variance != -2 ||
+		report.UnitsShort != 2 || len(report.Uncounted) != 1 || report.Uncounted[0] != "SKU-002" {
+		t.Errorf("Unexpected variance report: %+v", report)
+	}
+	if rr := send("POST", "/v1/admin/stocktakes/"+session.ID+"/counts", `{"counts": [{"productId": "SKU-404", "counted": 1}]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a product outside the stocktake, got %d", rr.Code)
+	}
+
+	rr = send("POST", "/v1/admin/stocktakes/"+session.ID+"/close", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	report = models.StocktakeReport{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Failed to decode report: %v", err)
+	}
+	if report.Status != models.StocktakeStatusClosed || len(report.Adjustments) != 1 ||
+		report.Adjustments[0].NewQuantity != 7 || report.Adjustments[0].NewVersion != 5 {
+		t.Fatalf("Unexpected closed stocktake: %+v", report)
+	}
+
+	// The correction is published as an audited adjustment
+	f.requireOffset(t, 2)
+	stored, _, _ := f.eventQueue.GetEvents(1, 10)
+	if len(stored) != 1 || stored[0].Reason != models.UpdateReasonStocktake || stored[0].Delta != -2 || stored[0].StoreID != "store-1" {
+		t.Fatalf("Expected a stocktake adjustment event, got %+v", stored)
+	}
+
+	// Closing lifts the freeze
+	rr = send("POST", "/v1/inventory/updates", `{"storeId": "store-1", "productId": "SKU-001", "delta": -1, "version": 5, "idempotencyKey": "01J00000000000000000000AC1"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 after close, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("POST", "/v1/admin/stocktakes/"+session.ID+"/close", ""); rr.Code != http.StatusConflict {
+		t.Errorf("Expected status 409 closing twice, got %d", rr.Code)
+	}
+
+	// Queue mode holds decrements with Retry-After so stores replay them later
+	queued := start(`{"mode": "queue"}`)
+	rr = send("POST", "/v1/inventory/updates", `{"storeId": "store-3", "productId": "SKU-001", "delta": -1, "version": 6, "idempotencyKey": "01J000000000000000000000H1"}`)
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected status 429 with Retry-After for a held decrement, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("POST", "/v1/admin/stocktakes/"+queued.ID+"/cancel", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200 cancelling, got %d", rr.Code)
+	}
+	rr = send("POST", "/v1/inventory/updates", `{"storeId": "store-3", "productId": "SKU-001", "delta": -1, "version": 6, "idempotencyKey": "01J000000000000000000000H1"}`)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the held decrement to apply after cancel, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := send("GET", "/v1/admin/stocktakes/st-999999", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unknown stocktake, got %d", rr.Code)
+	}
+
+	// Sessions survive a restart
+	reloaded, err := stocktakes.NewStore(sessionsPath)
+	if err != nil {
+		t.Fatalf("Failed to reload stocktake store: %v", err)
+	}
+	if sessions := reloaded.List(); len(sessions) != 2 || sessions[0].Status != models.StocktakeStatusCancelled {
+		t.Errorf("Expected two sessions after reload, got %+v", sessions)
+	}
+}
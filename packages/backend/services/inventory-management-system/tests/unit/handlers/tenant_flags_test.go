@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/featureflags"
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+)
+
+// TestInventoryHandler_UpdateInventory_TenantFlags tests that restocks are
+// allowed by the flags of the store the caller is authenticated as, not of the
+// store the body or X-Store-ID names
+func TestInventoryHandler_UpdateInventory_TenantFlags(t *testing.T) {
+	inventoryService := newBatchTestService(t)
+	flags := featureflags.New("", "")
+	flags.Set(featureflags.PositiveDeltas, "store-1", true)
+	inventoryService.SetFeatureFlags(flags)
+	handler := handlers.NewInventoryHandler(inventoryService)
+
+	unbound := authz.Principal{ID: "key:demo", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleStore}}
+	admin := authz.Principal{ID: "key:admin-key", Source: authz.SourceAPIKey, Roles: []authz.Role{authz.RoleAdmin}}
+	keys := 0
+	restock := func(req *http.Request) (int, models.UpdateResponse) {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		handler.UpdateInventory(rr, req)
+		var response models.UpdateResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response
+	}
+	request := func(bodyStoreID string) *http.Request {
+		t.Helper()
+		product, err := inventoryService.GetProduct("SKU-001")
+		if err != nil {
+			t.Fatalf("Failed to get SKU-001: %v", err)
+		}
+		keys++
+		body := fmt.Sprintf(`{"storeId": %q, "productId": "SKU-001", "delta": 1, "version": %d, "idempotencyKey": "01J0000000000000000000TF%02d"}`, bodyStoreID, product.Version, keys)
+		return httptest.NewRequest("POST", "/v1/inventory/updates", bytes.NewBufferString(body))
+	}
+	as := func(req *http.Request, principal authz.Principal) *http.Request {
+		return req.WithContext(authz.WithPrincipal(req.Context(), principal))
+	}
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"unbound key naming the flagged store", func() *http.Request { return as(request("store-1"), unbound) }, http.StatusBadRequest},
+		{"flagged store", func() *http.Request { return asStore(request(""), "store-1") }, http.StatusOK},
+		{"other store naming the flagged store", func() *http.Request { return asStore(request("store-1"), "store-2") }, http.StatusForbidden},
+		{"other store", func() *http.Request { return asStore(request(""), "store-2") }, http.StatusBadRequest},
+		{"admin acting for the flagged store", func() *http.Request { return as(request("store-1"), admin) }, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, response := restock(tt.req()); code != tt.status {
+				t.Errorf("Expected status %d, got %d: %+v", tt.status, code, response)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/models"
+)
+
+// TestAdminSetProducts_UTCTimestamp tests that admin writes record lastUpdated
+// in UTC like store updates, even when the clock runs in another zone
+func TestAdminSetProducts_UTCTimestamp(t *testing.T) {
+	service := newBatchTestService(t)
+	zone := time.FixedZone("UTC+9", 9*60*60)
+	defer clock.Set(clock.Fixed(time.Date(2026, 3, 1, 18, 30, 0, 0, zone)))()
+
+	name := "Phone X"
+	if _, err := service.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-001", Name: &name}}); err != nil {
+		t.Fatalf("Expected the update to succeed, got %v", err)
+	}
+
+	product, err := service.GetProduct("SKU-001")
+	if err != nil {
+		t.Fatalf("Expected SKU-001, got %v", err)
+	}
+	if product.LastUpdated != "2026-03-01T09:30:00Z" {
+		t.Errorf("Expected lastUpdated in UTC, got %s", product.LastUpdated)
+	}
+}
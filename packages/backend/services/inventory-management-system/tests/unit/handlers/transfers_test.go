@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"inventory-management-api/internal/handlers"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/transfers"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTransfersHandler(t *testing.T) {
+	f := newRestoreFixture(t)
+	historyPath := filepath.Join(t.TempDir(), "transfers.json")
+	transferLog, err := transfers.NewStore(historyPath, 100)
+	if err != nil {
+		t.Fatalf("Failed to create transfer store: %v", err)
+	}
+	f.service.SetTransferLog(transferLog)
+
+	transfersHandler := handlers.NewTransfersHandler(f.service)
+	router := mux.NewRouter()
+	router.HandleFunc("/v1/inventory/transfers", transfersHandler.CreateTransfer).Methods("POST")
+	router.HandleFunc("/v1/inventory/transfers", transfersHandler.ListTransfers).Methods("GET")
+
+	post := func(req models.TransferRequest) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/inventory/transfers", bytes.NewReader(body)))
+		return rr
+	}
+
+	// Offset 0: six of SKU-001's ten units go to store-1, one to store-3
+	allocated, err := f.service.AdminSetProducts([]models.AdminProductUpdate{
+		{ProductID: "SKU-001", Allocations: map[string]int{"store-1": 6, "store-3": 1}},
+	})
+	if err != nil || !allocated.Results[0].Success {
+		t.Fatalf("Failed to allocate stock: %v %+v", err, allocated)
+	}
+
+	request := models.TransferRequest{
+		ProductID:      "SKU-001",
+		FromStoreID:    "store-1",
+		ToStoreID:      "store-2",
+		Quantity:       4,
+		Version:        4,
+		IdempotencyKey: "01J00000000000000000000001",
+	}
+	rr := post(request)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var transfer models.StockTransfer
+	if err := json.Unmarshal(rr.Body.Bytes(), &transfer); err != nil {
+		t.Fatalf("Failed to decode transfer: %v", err)
+	}
+	if transfer.TransferID == "" || transfer.NewVersion != 5 || transfer.Available != 10 || transfer.EventOffset != 1 ||
+		transfer.FromAllocation != 2 || transfer.ToAllocation != 4 {
+		t.Errorf("Unexpected transfer: %+v", transfer)
+	}
+
+	// The units moved between the allocations; the stock is unchanged
+	product, err := f.service.GetProduct("SKU-001")
+	if err != nil {
+		t.Fatalf("GetProduct failed: %v", err)
+	}
+	expected := map[string]int{"store-1": 2, "store-2": 4, "store-3": 1}
+	if product.Available != 10 || !reflect.DeepEqual(product.Allocations, expected) {
+		t.Errorf("Expected 10 units allocated as %v, got %d allocated as %v", expected, product.Available, product.Allocations)
+	}
+
+	// One event carries both sides of the transfer and the new allocations
+	f.requireOffset(t, 2)
+	stored, _, _ := f.eventQueue.GetEvents(1, 10)
+	if len(stored) != 1 || stored[0].EventType != models.EventTypeStockTransferred ||
+		stored[0].StoreID != "store-1" || stored[0].ToStoreID != "store-2" || stored[0].Quantity != 4 ||
+		!reflect.DeepEqual(stored[0].Data.Allocations, expected) {
+		t.Fatalf("Expected a single stock_transferred event, got %+v", stored)
+	}
+
+	// A replay returns the first transfer without moving stock again
+	rr = post(request)
+	var replayed models.StockTransfer
+	if err := json.Unmarshal(rr.Body.Bytes(), &replayed); err != nil || replayed.TransferID != transfer.TransferID {
+		t.Errorf("Expected the replay to return %s, got %s (%v)", transfer.TransferID, rr.Body.String(), err)
+	}
+	if !replayed.Replayed || rr.Header().Get("Idempotency-Replayed") != "true" {
+		t.Errorf("Expected the replay to be marked as replayed, got %s", rr.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*models.TransferRequest)
+		status int
+	}{
+		{"stale version", func(r *models.TransferRequest) { r.Version = 4 }, http.StatusConflict},
+		{"more than allocated", func(r *models.TransferRequest) { r.Version = 0; r.Quantity = 3 }, http.StatusUnprocessableEntity},
+		{"nothing allocated", func(r *models.TransferRequest) { r.Version = 0; r.FromStoreID = "store-9"; r.Quantity = 1 }, http.StatusUnprocessableEntity},
+		{"same store", func(r *models.TransferRequest) { r.ToStoreID = "store-1" }, http.StatusBadRequest},
+		{"unknown product", func(r *models.TransferRequest) { r.ProductID = "SKU-404"; r.Version = 0 }, http.StatusNotFound},
+		{"zero quantity", func(r *models.TransferRequest) { r.Quantity = 0 }, http.StatusBadRequest},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := request
+			req.IdempotencyKey = "01J0000000000000000000000" + string(rune('A'+i))
+			tt.modify(&req)
+			if rr := post(req); rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	second := request
+	second.FromStoreID = "store-3"
+	second.Quantity = 1
+	second.Version = 0
+	second.IdempotencyKey = "01J00000000000000000000002"
+	if rr := post(second); rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	list := func(query string) models.TransfersResponse {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/transfers"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200 for %q, got %d", query, rr.Code)
+		}
+		var response models.TransfersResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode history: %v", err)
+		}
+		return response
+	}
+
+	if history := list(""); history.Count != 2 || history.Transfers[0].FromStoreID != "store-3" {
+		t.Errorf("Expected two transfers, newest first, got %+v", history)
+	}
+	if history := list("?storeId=store-1"); history.Count != 1 || history.Transfers[0].TransferID != transfer.TransferID {
+		t.Errorf("Expected only the store-1 transfer, got %+v", history)
+	}
+	if history := list("?productId=SKU-002"); history.Count != 0 {
+		t.Errorf("Expected no SKU-002 transfers, got %+v", history)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/transfers?limit=0", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid limit, got %d", rr.Code)
+	}
+
+	// The history survives a restart
+	reloaded, err := transfers.NewStore(historyPath, 100)
+	if err != nil {
+		t.Fatalf("Failed to reload transfer store: %v", err)
+	}
+	if history := reloaded.List("", "", 0); len(history) != 2 {
+		t.Errorf("Expected two transfers after reload, got %d", len(history))
+	}
+}
@@ -0,0 +1,150 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/jobs"
+	"inventory-management-api/internal/models"
+)
+
+// waitForStatus polls a job until it reaches status or a second passes
+func waitForStatus(t *testing.T, manager *jobs.Manager, id, status string) models.Job {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		job, _ := manager.Get(id)
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected job %s to become %s, got %+v", id, status, job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// blockingWork records one item and then waits for its context or release
+func blockingWork(release <-chan struct{}) jobs.Work {
+	return jobs.Work{
+		Total: 2,
+		Run: func(ctx context.Context, progress *jobs.Progress) error {
+			progress.Advance(1, 0)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-release:
+				progress.Advance(1, 0)
+				return nil
+			}
+		},
+	}
+}
+
+func TestManager_RegisteredTypes(t *testing.T) {
+	manager, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 2, ChunkSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create job manager: %v", err)
+	}
+	defer manager.Stop()
+
+	manager.Register("export", func(params json.RawMessage) (jobs.Work, error) {
+		var req struct {
+			Format string `json:"format"`
+		}
+		if err := json.Unmarshal(params, &req); err != nil || req.Format == "" {
+			return jobs.Work{}, &jobs.ParamsError{Code: "validation_error", Message: "format is required"}
+		}
+		return jobs.Work{Run: func(ctx context.Context, progress *jobs.Progress) error {
+			return progress.SetOutput(map[string]string{"file": "export." + req.Format})
+		}}, nil
+	})
+
+	job, err := manager.Start("export", json.RawMessage(`{"format": "csv"}`))
+	if err != nil {
+		t.Fatalf("Failed to start job: %v", err)
+	}
+	done := waitForStatus(t, manager, job.ID, models.JobStatusSucceeded)
+	if string(done.Output) != `{"file":"export.csv"}` {
+		t.Errorf("Expected the job output, got %s", done.Output)
+	}
+
+	var paramsErr *jobs.ParamsError
+	if _, err := manager.Start("export", json.RawMessage(`{}`)); !errors.As(err, &paramsErr) {
+		t.Errorf("Expected a params error, got %v", err)
+	}
+	if _, err := manager.Start("import", nil); !errors.Is(err, jobs.ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
+
+func TestManager_Cancel(t *testing.T) {
+	manager, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 2, ChunkSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to create job manager: %v", err)
+	}
+	defer manager.Stop()
+
+	release := make(chan struct{})
+	running, _ := manager.Submit("test", blockingWork(release))
+	queued, _ := manager.Submit("test", blockingWork(release))
+	waitForStatus(t, manager, running.ID, models.JobStatusRunning)
+
+	if job, err := manager.Cancel(queued.ID); err != nil || job.Status != models.JobStatusCancelled {
+		t.Errorf("Expected the queued job to be cancelled at once, got %+v, %v", job, err)
+	}
+	if _, err := manager.Cancel(running.ID); err != nil {
+		t.Fatalf("Failed to cancel running job: %v", err)
+	}
+	job := waitForStatus(t, manager, running.ID, models.JobStatusCancelled)
+	if job.Processed != 1 {
+		t.Errorf("Expected the cancelled job to keep its progress, got %+v", job)
+	}
+
+	if _, err := manager.Cancel(running.ID); !errors.Is(err, jobs.ErrFinished) {
+		t.Errorf("Expected ErrFinished, got %v", err)
+	}
+	if _, err := manager.Cancel("job-999999"); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	close(release)
+}
+
+func TestManager_PersistsJobs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	manager, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 2, ChunkSize: 10, FilePath: path})
+	if err != nil {
+		t.Fatalf("Failed to create job manager: %v", err)
+	}
+
+	finished, _ := manager.Submit("test", jobs.Work{Total: 1, Run: func(ctx context.Context, progress *jobs.Progress) error {
+		progress.Record([]models.AdminProductResult{{ProductID: "SKU-001", Success: true}})
+		return nil
+	}})
+	waitForStatus(t, manager, finished.ID, models.JobStatusSucceeded)
+	interrupted, _ := manager.Submit("test", blockingWork(make(chan struct{})))
+	waitForStatus(t, manager, interrupted.ID, models.JobStatusRunning)
+	manager.Stop()
+
+	reloaded, err := jobs.NewManager(jobs.Config{Workers: 1, QueueSize: 2, ChunkSize: 10, FilePath: path})
+	if err != nil {
+		t.Fatalf("Failed to reload job manager: %v", err)
+	}
+	defer reloaded.Stop()
+
+	if job, ok := reloaded.Get(finished.ID); !ok || job.Status != models.JobStatusSucceeded || len(job.Results) != 1 {
+		t.Errorf("Expected the finished job with its results, got %+v", job)
+	}
+	if job, _ := reloaded.Get(interrupted.ID); job.Status != models.JobStatusFailed || job.Processed != 1 {
+		t.Errorf("Expected the interrupted job to be failed with its progress, got %+v", job)
+	}
+	next, _ := reloaded.Submit("test", jobs.Work{Run: func(ctx context.Context, progress *jobs.Progress) error { return nil }})
+	if next.ID == finished.ID || next.ID == interrupted.ID {
+		t.Errorf("Expected a new job ID after reload, got %s", next.ID)
+	}
+}
@@ -0,0 +1,89 @@
+package leader
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/leader"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newElector creates an elector on lockFile that retries quickly
+func newElector(lockFile, id string) *leader.Elector {
+	return leader.New(leader.Config{
+		LockFile:      lockFile,
+		AdvertiseURL:  "http://" + id + ":8081",
+		InstanceID:    id,
+		RetryInterval: 10 * time.Millisecond,
+	})
+}
+
+func TestElector_OneLeaderAndFollowersFindIt(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "leader.lock")
+	first := newElector(lockFile, "central-a")
+	second := newElector(lockFile, "central-b")
+
+	elected, err := first.Campaign()
+	require.NoError(t, err)
+	assert.True(t, elected)
+	assert.Equal(t, leader.RolePromoting, first.Status().Role, "the winner leads once started")
+	first.Start(func() { t.Error("a leader that won before Start is not promoted again") })
+	defer first.Stop()
+	assert.True(t, first.IsLeader())
+
+	elected, err = second.Campaign()
+	require.NoError(t, err)
+	assert.False(t, elected)
+	assert.False(t, second.IsLeader())
+
+	record, ok := second.Leader()
+	require.True(t, ok)
+	assert.Equal(t, "central-a", record.InstanceID)
+	assert.Equal(t, "http://central-a:8081", record.URL)
+
+	status := second.Status()
+	assert.Equal(t, leader.RoleFollower, status.Role)
+	require.NotNil(t, status.Leader)
+	assert.Equal(t, "central-a", status.Leader.InstanceID)
+}
+
+func TestElector_FollowerTakesOverWhenLeaderStops(t *testing.T) {
+	lockFile := filepath.Join(t.TempDir(), "leader.lock")
+	first := newElector(lockFile, "central-a")
+	second := newElector(lockFile, "central-b")
+
+	_, err := first.Campaign()
+	require.NoError(t, err)
+	first.Start(func() {})
+	_, err = second.Campaign()
+	require.NoError(t, err)
+
+	promoted := make(chan struct{})
+	promoting := make(chan struct{})
+	second.Start(func() {
+		// Nothing is forwarded while the new leader takes over
+		_, ok := second.Leader()
+		assert.False(t, ok)
+		assert.Equal(t, leader.RolePromoting, second.Status().Role)
+		close(promoting)
+		<-promoted
+	})
+	defer second.Stop()
+
+	first.Stop()
+	select {
+	case <-promoting:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the follower to win the election")
+	}
+	assert.False(t, second.IsLeader(), "the new leader leads once promoted")
+	close(promoted)
+
+	require.Eventually(t, second.IsLeader, time.Second, 5*time.Millisecond)
+	record, ok := second.Leader()
+	require.True(t, ok)
+	assert.Equal(t, "central-b", record.InstanceID)
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/apiversion"
+	"inventory-management-api/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAPIVersionMiddleware_StoresVersionForHandlers(t *testing.T) {
+	var seen []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, apiversion.FromContext(r.Context()))
+	}
+
+	r := mux.NewRouter()
+	for _, version := range apiversion.Versions {
+		sub := r.PathPrefix("/" + version).Subrouter()
+		sub.Use(middleware.APIVersionMiddleware(version))
+		sub.HandleFunc("/inventory", handler).Methods("GET")
+	}
+
+	for _, version := range apiversion.Versions {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/"+version+"/inventory", nil))
+		if rr.Header().Get(apiversion.Header) != version {
+			t.Errorf("Expected API-Version %s, got %q", version, rr.Header().Get(apiversion.Header))
+		}
+	}
+	if len(seen) != 2 || seen[0] != apiversion.V1 || seen[1] != apiversion.V2 {
+		t.Errorf("Expected handlers to see v1 then v2, got %v", seen)
+	}
+}
+
+func TestDeprecationMiddleware_AnnouncesDeprecatedRoutes(t *testing.T) {
+	deprecations := map[string]apiversion.Deprecation{
+		"GET /v1/inventory/{productId}": {
+			Since:  time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC),
+			Sunset: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"GET /v1/inventory/events": {Since: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r := mux.NewRouter()
+	r.Use(middleware.DeprecationMiddleware(deprecations))
+	r.HandleFunc("/v1/inventory/events", noop).Methods("GET")
+	r.HandleFunc("/v1/inventory/{productId}", noop).Methods("GET")
+	r.HandleFunc("/v2/inventory/{productId}", noop).Methods("GET")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil))
+	if got := rr.Header().Get("Deprecation"); got != "@1768435200" {
+		t.Errorf("Expected Deprecation @1768435200, got %q", got)
+	}
+	if got := rr.Header().Get("Sunset"); got != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("Expected Sunset as an HTTP date, got %q", got)
+	}
+	if got := rr.Header().Get("Link"); got != `</v2/inventory/SKU-001>; rel="successor-version"` {
+		t.Errorf("Expected a successor-version link, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/events", nil))
+	if rr.Header().Get("Deprecation") == "" || rr.Header().Get("Sunset") != "" {
+		t.Errorf("Expected a deprecation without a sunset date, got %v", rr.Header())
+	}
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest("GET", "/v2/inventory/SKU-001", nil))
+	if rr.Header().Get("Deprecation") != "" || rr.Header().Get("Sunset") != "" || rr.Header().Get("Link") != "" {
+		t.Errorf("Expected no deprecation headers on the successor, got %v", rr.Header())
+	}
+}
+
+func TestAPIVersion_PathsAcrossVersions(t *testing.T) {
+	tests := []struct {
+		name, got, expected string
+	}{
+		{"canonical v2", apiversion.Canonical("/v2/admin/jobs/7"), "/v1/admin/jobs/7"},
+		{"canonical v2 root", apiversion.Canonical("/v2"), "/v1"},
+		{"canonical v1", apiversion.Canonical("/v1/inventory"), "/v1/inventory"},
+		{"canonical unversioned", apiversion.Canonical("/health"), "/health"},
+		{"canonical lookalike", apiversion.Canonical("/v2x/inventory"), "/v2x/inventory"},
+		{"path to v2", apiversion.Path(apiversion.V2, "/v1/admin/jobs/7"), "/v2/admin/jobs/7"},
+		{"path unversioned", apiversion.Path(apiversion.V2, "/health"), "/health"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, tt.got)
+		}
+	}
+}
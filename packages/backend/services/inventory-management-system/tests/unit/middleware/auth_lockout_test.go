@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/middleware"
+)
+
+func newLockoutHandler(t *testing.T, lockoutConfig middleware.AuthLockoutConfig) (*middleware.AuthLockout, http.Handler) {
+	t.Helper()
+	t.Setenv("API_KEYS", "valid-key")
+	t.Setenv("ADMIN_API_KEYS", "")
+	t.Setenv("API_KEY_ROLES", "")
+
+	lockout := middleware.NewAuthLockout(lockoutConfig)
+	t.Cleanup(lockout.Stop)
+	clientIP := middleware.IPFilterConfig{}.ClientIP
+	inner := middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	return lockout, middleware.AuthLockoutMiddleware(lockout, clientIP)(inner)
+}
+
+func sendWithKey(handler http.Handler, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", "/v1/inventory", nil)
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("X-API-Key", apiKey)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthLockout_LocksIPAfterThreshold(t *testing.T) {
+	_, handler := newLockoutHandler(t, middleware.AuthLockoutConfig{
+		Enabled: true, Threshold: 3, Window: time.Minute, BaseDuration: time.Minute, MaxDuration: time.Hour,
+	})
+
+	for i := 0; i < 3; i++ {
+		if rr := sendWithKey(handler, "203.0.113.7:5000", "guess"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Attempt %d: expected 401, got %d", i+1, rr.Code)
+		}
+	}
+
+	// Even the valid key is refused from the locked-out address
+	rr := sendWithKey(handler, "203.0.113.7:5000", "valid-key")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429 while locked out, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After on a locked-out response")
+	}
+
+	if rr := sendWithKey(handler, "198.51.100.2:5000", "valid-key"); rr.Code != http.StatusOK {
+		t.Errorf("Expected other addresses to be unaffected, got %d", rr.Code)
+	}
+}
+
+func TestAuthLockout_LocksKeyAcrossAddresses(t *testing.T) {
+	_, handler := newLockoutHandler(t, middleware.AuthLockoutConfig{
+		Enabled: true, Threshold: 2, Window: time.Minute, BaseDuration: time.Minute, MaxDuration: time.Hour,
+	})
+
+	sendWithKey(handler, "203.0.113.7:5000", "stolen-guess")
+	sendWithKey(handler, "203.0.113.8:5000", "stolen-guess")
+
+	if rr := sendWithKey(handler, "203.0.113.9:5000", "stolen-guess"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected the key to be locked out from a new address, got %d", rr.Code)
+	}
+}
+
+func TestAuthLockout_SuccessResetsFailures(t *testing.T) {
+	_, handler := newLockoutHandler(t, middleware.AuthLockoutConfig{
+		Enabled: true, Threshold: 3, Window: time.Minute, BaseDuration: time.Minute, MaxDuration: time.Hour,
+	})
+
+	for i := 0; i < 5; i++ {
+		sendWithKey(handler, "203.0.113.7:5000", "typo")
+		sendWithKey(handler, "203.0.113.7:5000", "typo2")
+		if rr := sendWithKey(handler, "203.0.113.7:5000", "valid-key"); rr.Code != http.StatusOK {
+			t.Fatalf("Round %d: expected 200 after fewer failures than the threshold, got %d", i+1, rr.Code)
+		}
+	}
+}
+
+func TestAuthLockout_EscalatesAndUnblocks(t *testing.T) {
+	lockout, handler := newLockoutHandler(t, middleware.AuthLockoutConfig{
+		Enabled: true, Threshold: 1, Window: time.Minute, BaseDuration: 100 * time.Millisecond, MaxDuration: time.Hour,
+	})
+
+	sendWithKey(handler, "203.0.113.7:5000", "guess")
+	time.Sleep(120 * time.Millisecond)
+	if rr := sendWithKey(handler, "203.0.113.7:5000", "guess"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected the first lockout to have expired, got %d", rr.Code)
+	}
+
+	// The second lockout lasts twice as long as the first
+	time.Sleep(120 * time.Millisecond)
+	if rr := sendWithKey(handler, "203.0.113.7:5000", "valid-key"); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the second lockout to still hold, got %d", rr.Code)
+	}
+
+	entries := lockout.Entries()
+	if entries.Locked != 2 {
+		t.Fatalf("Expected the address and the key to be locked, got %+v", entries)
+	}
+	for _, entry := range entries.Lockouts {
+		if entry.Principal == "key:guess" {
+			t.Errorf("Expected API keys to be masked, got %q", entry.Principal)
+		}
+		if entry.Principal == "ip:203.0.113.7" && entry.Strikes != 2 {
+			t.Errorf("Expected 2 strikes for the address, got %d", entry.Strikes)
+		}
+		if _, ok := lockout.Unblock(entry.ID); !ok {
+			t.Errorf("Expected to unblock %s", entry.Principal)
+		}
+	}
+
+	if rr := sendWithKey(handler, "203.0.113.7:5000", "valid-key"); rr.Code != http.StatusOK {
+		t.Errorf("Expected 200 after unblocking, got %d", rr.Code)
+	}
+	if _, ok := lockout.Unblock("unknown"); ok {
+		t.Error("Expected unknown IDs to be rejected")
+	}
+}
@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/authz"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// newAuthorizedRouter serves a few routes behind AuthMiddleware and
+// AuthorizeMiddleware with the given permissions
+func newAuthorizedRouter(permissions map[string]authz.Permission) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := mux.NewRouter()
+	v1 := r.PathPrefix("/v1").Subrouter()
+	v1.Use(middleware.AuthMiddleware)
+	v1.Use(middleware.AuthorizeMiddleware(permissions))
+	v1.HandleFunc("/inventory/{productId}", ok).Methods("GET")
+	v1.HandleFunc("/admin/products/create", ok).Methods("POST")
+	v1.HandleFunc("/admin/products/delete", ok).Methods("DELETE")
+	v1.HandleFunc("/admin/unannotated", ok).Methods("GET")
+	return r
+}
+
+func TestAuthorizeMiddleware_EnforcesRolePermissions(t *testing.T) {
+	t.Setenv("API_KEYS", "store-key")
+	t.Setenv("ADMIN_API_KEYS", "admin-key")
+	t.Setenv("API_KEY_ROLES", "cat-key:catalog-manager,bi-key:analyst")
+
+	router := newAuthorizedRouter(map[string]authz.Permission{
+		"GET /v1/inventory/{productId}":    authz.PermInventoryRead,
+		"POST /v1/admin/products/create":   authz.PermProductsCreate,
+		"DELETE /v1/admin/products/delete": authz.PermProductsDelete,
+	})
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		want   int
+	}{
+		{"store reads", "store-key", "GET", "/v1/inventory/SKU-001", http.StatusOK},
+		{"store cannot create", "store-key", "POST", "/v1/admin/products/create", http.StatusForbidden},
+		{"catalog manager creates", "cat-key", "POST", "/v1/admin/products/create", http.StatusOK},
+		{"catalog manager cannot delete", "cat-key", "DELETE", "/v1/admin/products/delete", http.StatusForbidden},
+		{"analyst reads", "bi-key", "GET", "/v1/inventory/SKU-001", http.StatusOK},
+		{"analyst cannot create", "bi-key", "POST", "/v1/admin/products/create", http.StatusForbidden},
+		{"admin deletes", "admin-key", "DELETE", "/v1/admin/products/delete", http.StatusOK},
+		{"unannotated route is denied", "admin-key", "GET", "/v1/admin/unannotated", http.StatusForbidden},
+		{"unknown key", "nope", "GET", "/v1/inventory/SKU-001", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(middleware.APIKeyHeader, tt.key)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Fatalf("Expected status %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	req := httptest.NewRequest("DELETE", "/v1/admin/products/delete", nil)
+	req.Header.Set(middleware.APIKeyHeader, "cat-key")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var errResp models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &errResp); err != nil {
+		t.Fatalf("Failed to decode error: %v", err)
+	}
+	if errResp.Code != "forbidden" || len(errResp.Details) != 1 || errResp.Details[0].Issue != string(authz.PermProductsDelete) {
+		t.Errorf("Expected a forbidden error naming products:delete, got %+v", errResp)
+	}
+}
+
+func TestAuthMiddleware_BearerToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_ISSUER", "identity")
+
+	router := newAuthorizedRouter(map[string]authz.Permission{
+		"POST /v1/admin/products/create": authz.PermProductsCreate,
+	})
+	create := func(token string) int {
+		req := httptest.NewRequest("POST", "/v1/admin/products/create", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	sign := func(claims authz.Claims, secret string) string {
+		token, err := authz.SignHS256(claims, secret)
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		return token
+	}
+	expires := time.Now().Add(time.Hour).Unix()
+
+	if code := create(sign(authz.Claims{Subject: "alice", Issuer: "identity", Roles: []string{"catalog-manager"}, ExpiresAt: expires}, "test-secret")); code != http.StatusOK {
+		t.Errorf("Expected a catalog manager token to create products, got %d", code)
+	}
+	if code := create(sign(authz.Claims{Subject: "bob", Issuer: "identity", Roles: []string{"analyst"}, ExpiresAt: expires}, "test-secret")); code != http.StatusForbidden {
+		t.Errorf("Expected an analyst token to be forbidden, got %d", code)
+	}
+	if code := create(sign(authz.Claims{Subject: "alice", Issuer: "identity", Roles: []string{"admin"}, ExpiresAt: expires}, "wrong-secret")); code != http.StatusUnauthorized {
+		t.Errorf("Expected a token with a bad signature to be rejected, got %d", code)
+	}
+	if code := create(sign(authz.Claims{Subject: "alice", Issuer: "identity", Roles: []string{"admin"}, ExpiresAt: time.Now().Add(-time.Minute).Unix()}, "test-secret")); code != http.StatusUnauthorized {
+		t.Errorf("Expected an expired token to be rejected, got %d", code)
+	}
+	if code := create(sign(authz.Claims{Subject: "alice", Issuer: "elsewhere", Roles: []string{"admin"}, ExpiresAt: expires}, "test-secret")); code != http.StatusUnauthorized {
+		t.Errorf("Expected a token from another issuer to be rejected, got %d", code)
+	}
+}
+
+func TestValidateAuthConfig_RejectsUnknownRole(t *testing.T) {
+	t.Setenv("API_KEY_ROLES", "key:superuser")
+	if err := middleware.ValidateAuthConfig(); err == nil {
+		t.Error("Expected an unknown role to be rejected")
+	}
+
+	t.Setenv("API_KEY_ROLES", "key:analyst|catalog-manager")
+	if err := middleware.ValidateAuthConfig(); err != nil {
+		t.Errorf("Expected combined roles to be accepted, got %v", err)
+	}
+}
+
+func TestValidateAuthConfig_RejectsBadStoreBinding(t *testing.T) {
+	t.Setenv("API_KEY_ROLES", "")
+	for _, value := range []string{"store-key", "store-key:", "store-key:store-1,store-key:store-2"} {
+		t.Setenv("API_KEY_STORES", value)
+		if err := middleware.ValidateAuthConfig(); err == nil {
+			t.Errorf("Expected API_KEY_STORES=%q to be rejected", value)
+		}
+	}
+
+	t.Setenv("API_KEY_STORES", "store-key:store-1, other-key:store-2")
+	if err := middleware.ValidateAuthConfig(); err != nil {
+		t.Errorf("Expected store bindings to be accepted, got %v", err)
+	}
+}
+
+// TestAuthMiddleware_BindsStores tests that API keys in API_KEY_STORES and
+// tokens with a store_id claim act for their store only
+func TestAuthMiddleware_BindsStores(t *testing.T) {
+	t.Setenv("API_KEYS", "store-key,shared-key")
+	t.Setenv("ADMIN_API_KEYS", "admin-key")
+	t.Setenv("API_KEY_ROLES", "")
+	t.Setenv("API_KEY_STORES", "store-key:store-1")
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("JWT_ISSUER", "")
+
+	handler := middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeID, err := middleware.RequestStoreID(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(storeID))
+	}))
+	token, err := authz.SignHS256(authz.Claims{Subject: "pos-7", Roles: []string{"store"}, StoreID: "store-7"}, "test-secret")
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		bearer  string
+		header  string
+		status  int
+		storeID string
+	}{
+		{"bound key", "store-key", "", "", http.StatusOK, "store-1"},
+		{"bound key naming its store", "store-key", "", "store-1", http.StatusOK, "store-1"},
+		{"bound key naming another store", "store-key", "", "store-2", http.StatusForbidden, ""},
+		{"unbound key", "shared-key", "", "", http.StatusOK, ""},
+		{"unbound key naming a store", "shared-key", "", "store-2", http.StatusForbidden, ""},
+		{"admin key naming a store", "admin-key", "", "store-2", http.StatusOK, "store-2"},
+		{"bound token", "", token, "", http.StatusOK, "store-7"},
+		{"bound token naming another store", "", token, "store-1", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/inventory/events?offset=0", nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Store-ID", tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, rr.Code, rr.Body.String())
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != tt.storeID {
+				t.Errorf("Expected store %q, got %q", tt.storeID, rr.Body.String())
+			}
+		})
+	}
+}
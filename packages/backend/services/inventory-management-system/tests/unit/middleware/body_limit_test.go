@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/middleware"
+)
+
+func TestBodyLimitMiddleware_RejectsDeclaredOversizedBody(t *testing.T) {
+	called := false
+	handler := middleware.BodyLimitMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", strings.NewReader(strings.Repeat("x", 32)))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", rr.Code)
+	}
+	if called {
+		t.Error("Handler should not be called for oversized body")
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body: %v", err)
+	}
+	if body["code"] != "payload_too_large" {
+		t.Errorf("Expected error code payload_too_large, got %v", body["code"])
+	}
+}
+
+func TestBodyLimitMiddleware_CapsUndeclaredBody(t *testing.T) {
+	var readErr error
+	handler := middleware.BodyLimitMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", strings.NewReader(strings.Repeat("x", 32)))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if !middleware.IsBodyTooLarge(readErr) {
+		t.Errorf("Expected body too large error, got %v", readErr)
+	}
+}
+
+func TestBodyLimitMiddleware_AllowsSmallBody(t *testing.T) {
+	handler := middleware.BodyLimitMiddleware(64)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			t.Errorf("Unexpected read error: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", strings.NewReader(`{"storeId":"store-s1"}`))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", rr.Code)
+	}
+}
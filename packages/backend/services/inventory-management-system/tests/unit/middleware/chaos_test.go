@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+)
+
+func eventsBody(w http.ResponseWriter, r *http.Request) {
+	response := models.EventsResponse{
+		Events: []models.Event{
+			{Offset: 1, EventType: "product_updated", ProductID: "SKU-001", Version: 2},
+			{Offset: 2, EventType: "product_updated", ProductID: "SKU-002", Version: 5},
+		},
+		NextOffset: 3,
+		Count:      2,
+	}
+	if r.Header.Get("Accept") == events.ProtobufContentType {
+		w.Header().Set("Content-Type", events.ProtobufContentType)
+		w.Write(events.MarshalProtobuf(response))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func serveChaos(chaosConfig middleware.ChaosConfig, req *http.Request, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	middleware.ChaosMiddleware(chaosConfig)(handler).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestChaosMiddleware_InjectsServerErrors(t *testing.T) {
+	reached := false
+	handler := func(w http.ResponseWriter, r *http.Request) { reached = true }
+
+	rr := serveChaos(middleware.ChaosConfig{Enabled: true, ErrorRate: 1, Seed: 1},
+		httptest.NewRequest("POST", "/v1/inventory/updates", nil), handler)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500, got %d", rr.Code)
+	}
+	if reached {
+		t.Error("Expected the handler not to run for an injected error")
+	}
+	var errResp models.ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&errResp); err != nil || errResp.Code != "internal_error" {
+		t.Errorf("Expected an internal_error body, got %+v (%v)", errResp, err)
+	}
+}
+
+func TestChaosMiddleware_OnlyFaultsConfiguredRoutes(t *testing.T) {
+	chaosConfig := middleware.ChaosConfig{Enabled: true, Routes: []string{"/v1/inventory"}, ErrorRate: 1, Seed: 1}
+	handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	rr := serveChaos(chaosConfig, httptest.NewRequest("GET", "/health", nil), handler)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected /health untouched, got %d", rr.Code)
+	}
+
+	rr = serveChaos(chaosConfig, httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil), handler)
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected an injected 500 on /v1/inventory/SKU-001, got %d", rr.Code)
+	}
+}
+
+func TestChaosMiddleware_AddsLatency(t *testing.T) {
+	chaosConfig := middleware.ChaosConfig{Enabled: true, Latency: 50 * time.Millisecond, LatencyRate: 1, Seed: 1}
+	handler := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	start := time.Now()
+	rr := serveChaos(chaosConfig, httptest.NewRequest("GET", "/v1/inventory", nil), handler)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Expected at least 50ms of injected latency, got %s", elapsed)
+	}
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the request to succeed after the delay, got %d", rr.Code)
+	}
+}
+
+func TestChaosMiddleware_ResetsConnections(t *testing.T) {
+	chaosConfig := middleware.ChaosConfig{Enabled: true, ResetRate: 1, Seed: 1}
+	server := httptest.NewServer(middleware.ChaosMiddleware(chaosConfig)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/v1/inventory")
+	if err == nil {
+		resp.Body.Close()
+		t.Fatalf("Expected a connection error, got status %d", resp.StatusCode)
+	}
+}
+
+func TestChaosMiddleware_DuplicatesEvents(t *testing.T) {
+	chaosConfig := middleware.ChaosConfig{Enabled: true, DuplicateRate: 1, Seed: 1}
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v1/inventory/events?offset=0", nil)
+		rr := serveChaos(chaosConfig, req, eventsBody)
+
+		var response models.EventsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode events: %v", err)
+		}
+		if len(response.Events) != 4 || response.Count != 4 {
+			t.Fatalf("Expected every event delivered twice, got %d events (count %d)", len(response.Events), response.Count)
+		}
+		if response.Events[0].Offset != response.Events[1].Offset || response.NextOffset != 3 {
+			t.Errorf("Expected duplicates in place and nextOffset unchanged, got %+v", response)
+		}
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/v1/inventory/events?offset=0", nil)
+		req.Header.Set("Accept", events.ProtobufContentType)
+		rr := serveChaos(chaosConfig, req, eventsBody)
+
+		if rr.Header().Get("Content-Type") != events.ProtobufContentType {
+			t.Fatalf("Expected a protobuf response, got %q", rr.Header().Get("Content-Type"))
+		}
+		response, err := events.UnmarshalProtobuf(rr.Body.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to decode events: %v", err)
+		}
+		if len(response.Events) != 4 {
+			t.Errorf("Expected every event delivered twice, got %d events", len(response.Events))
+		}
+	})
+}
+
+func TestParseChaosConfig(t *testing.T) {
+	cfg := &config.Config{
+		Environment:        "development",
+		ChaosEnabled:       "true",
+		ChaosRoutes:        "/v1/inventory/events, /v1/inventory/updates",
+		ChaosLatency:       "250ms",
+		ChaosLatencyRate:   "0.5",
+		ChaosErrorRate:     "1.5", // Out of range, falls back to 0
+		ChaosDuplicateRate: "0.1",
+		ChaosSeed:          "42",
+	}
+
+	chaosConfig := middleware.ParseChaosConfig(cfg)
+	if !chaosConfig.Enabled || chaosConfig.Seed != 42 || chaosConfig.Latency != 250*time.Millisecond {
+		t.Fatalf("Unexpected chaos config: %+v", chaosConfig)
+	}
+	if len(chaosConfig.Routes) != 2 || chaosConfig.Routes[1] != "/v1/inventory/updates" {
+		t.Errorf("Expected two trimmed routes, got %v", chaosConfig.Routes)
+	}
+	if chaosConfig.LatencyRate != 0.5 || chaosConfig.ErrorRate != 0 || chaosConfig.DuplicateRate != 0.1 {
+		t.Errorf("Unexpected rates: %+v", chaosConfig)
+	}
+
+	cfg.Environment = "production"
+	if middleware.ParseChaosConfig(cfg).Enabled {
+		t.Error("Expected chaos to stay disabled in production")
+	}
+}
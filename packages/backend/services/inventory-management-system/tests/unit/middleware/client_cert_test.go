@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/middleware"
+)
+
+// requestWithClientCert builds a request as if the TLS handshake verified a
+// client certificate with the given Common Name
+func requestWithClientCert(commonName string) *http.Request {
+	req := httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: commonName}},
+		}},
+	}
+	return req
+}
+
+func TestClientCertMiddleware_AuthenticatesStore(t *testing.T) {
+	var storeID, header string
+	handler := middleware.ClientCertMiddleware(middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeID = middleware.ClientCertStoreID(r.Context())
+		header = r.Header.Get(middleware.StoreIDHeader)
+	})))
+
+	// No API key: the certificate alone authenticates the store
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, requestWithClientCert("store-7"))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if storeID != "store-7" || header != "store-7" {
+		t.Errorf("Expected store-7 in context and header, got %q and %q", storeID, header)
+	}
+}
+
+func TestClientCertMiddleware_RejectsMismatchedStoreID(t *testing.T) {
+	called := false
+	handler := middleware.ClientCertMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	req := requestWithClientCert("store-7")
+	req.Header.Set(middleware.StoreIDHeader, "store-8")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if called {
+		t.Error("Expected handler not to be called")
+	}
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d", rr.Code)
+	}
+}
+
+func TestClientCertMiddleware_FallsBackToAPIKey(t *testing.T) {
+	handler := middleware.ClientCertMiddleware(middleware.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	tests := []struct {
+		name string
+		req  *http.Request
+	}{
+		{name: "plain HTTP", req: httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil)},
+		{name: "TLS without client certificate", req: func() *http.Request {
+			req := httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil)
+			req.TLS = &tls.ConnectionState{}
+			return req
+		}()},
+		{name: "certificate without Common Name", req: requestWithClientCert("")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, tt.req)
+			if rr.Code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401 without an API key, got %d", rr.Code)
+			}
+		})
+	}
+}
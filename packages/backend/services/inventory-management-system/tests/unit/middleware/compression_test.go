@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/middleware"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func serveCompressed(t *testing.T, minBytes int, acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/v1/inventory", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	rr := httptest.NewRecorder()
+	middleware.CompressionMiddleware(minBytes)(handler).ServeHTTP(rr, req)
+	return rr
+}
+
+func jsonBody(body string, status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		// Write in small chunks like an encoder streaming a listing
+		for i := 0; i < len(body); i += 100 {
+			end := i + 100
+			if end > len(body) {
+				end = len(body)
+			}
+			w.Write([]byte(body[i:end]))
+		}
+	}
+}
+
+func TestCompressionMiddleware_CompressesLargeResponses(t *testing.T) {
+	body := `{"products": [` + strings.Repeat(`{"productId": "SKU-001", "available": 10},`, 200) + `{}]}`
+
+	rr := serveCompressed(t, 1024, "br;q=1.0, gzip;q=0.8", jsonBody(body, http.StatusOK))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip encoding, got %q", rr.Header().Get("Content-Encoding"))
+	}
+	if rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Expected Vary: Accept-Encoding, got %q", rr.Header().Get("Vary"))
+	}
+	if rr.Body.Len() >= len(body) {
+		t.Errorf("Expected compressed body smaller than %d bytes, got %d", len(body), rr.Body.Len())
+	}
+
+	reader, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("Failed to open gzip body: %v", err)
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to read gzip body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Errorf("Decompressed body does not match the original")
+	}
+}
+
+func TestCompressionMiddleware_NegotiatesZstd(t *testing.T) {
+	body := `{"events": [` + strings.Repeat(`{"offset": 1, "productId": "SKU-001", "eventType": "inventory_updated"},`, 100) + `{}]}`
+
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{acceptEncoding: "zstd, gzip", expected: "zstd"},
+		{acceptEncoding: "gzip, deflate, br, zstd", expected: "zstd"},
+		{acceptEncoding: "zstd;q=0.5, gzip", expected: "gzip"},
+		{acceptEncoding: "zstd;q=0, *", expected: "gzip"},
+		{acceptEncoding: "*", expected: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			rr := serveCompressed(t, 1024, tt.acceptEncoding, jsonBody(body, http.StatusOK))
+			if encoding := rr.Header().Get("Content-Encoding"); encoding != tt.expected {
+				t.Fatalf("Expected %s encoding, got %q", tt.expected, encoding)
+			}
+			if tt.expected != "zstd" {
+				return
+			}
+
+			decoder, err := zstd.NewReader(rr.Body)
+			if err != nil {
+				t.Fatalf("Failed to open zstd body: %v", err)
+			}
+			defer decoder.Close()
+			decoded, err := io.ReadAll(decoder)
+			if err != nil {
+				t.Fatalf("Failed to read zstd body: %v", err)
+			}
+			if string(decoded) != body {
+				t.Errorf("Decompressed body does not match the original")
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_LeavesResponsesUncompressed(t *testing.T) {
+	largeBody := `{"products": [` + strings.Repeat(`{"productId": "SKU-001"},`, 100) + `{}]}`
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		status         int
+	}{
+		{name: "below threshold", acceptEncoding: "gzip", body: `{"productId": "SKU-001"}`, status: http.StatusOK},
+		{name: "small error", acceptEncoding: "gzip", body: `{"code": "not_found"}`, status: http.StatusNotFound},
+		{name: "no Accept-Encoding", acceptEncoding: "", body: largeBody, status: http.StatusOK},
+		{name: "gzip refused", acceptEncoding: "gzip;q=0, *", body: largeBody, status: http.StatusOK},
+		{name: "unsupported coding only", acceptEncoding: "br", body: largeBody, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveCompressed(t, 1024, tt.acceptEncoding, jsonBody(tt.body, tt.status))
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if encoding := rr.Header().Get("Content-Encoding"); encoding != "" {
+				t.Errorf("Expected no Content-Encoding, got %q", encoding)
+			}
+			if rr.Body.String() != tt.body {
+				t.Errorf("Expected body to pass through unchanged")
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_SkipsBinaryContent(t *testing.T) {
+	body := strings.Repeat("x", 4096)
+
+	rr := serveCompressed(t, 1024, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(body))
+	})
+	if encoding := rr.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("Expected no Content-Encoding for image/png, got %q", encoding)
+	}
+	if rr.Body.String() != body {
+		t.Errorf("Expected body to pass through unchanged")
+	}
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/middleware"
+)
+
+func TestIPFilterMiddleware_PerPrefixLists(t *testing.T) {
+	filterConfig, err := middleware.ParseIPFilterConfig(&config.Config{
+		IPAllowlist:    "/v1/admin=10.0.0.0/8|192.168.1.7,/v1/admin/reports=172.16.0.0/12",
+		IPDenylist:     "/v1=10.66.0.0/16",
+		TrustedProxies: "10.0.0.1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse IP filter config: %v", err)
+	}
+	handler := middleware.IPFilterMiddleware(filterConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		path         string
+		expectedCode int
+	}{
+		{"corporate address reaches admin", "10.1.2.3:5000", "", "/v1/admin/dashboard", http.StatusOK},
+		{"single allowed address", "192.168.1.7:5000", "", "/v1/admin/dashboard", http.StatusOK},
+		{"outside address blocked from admin", "203.0.113.9:5000", "", "/v1/admin/dashboard", http.StatusForbidden},
+		{"prefix matches whole segments", "203.0.113.9:5000", "", "/v1/administrator", http.StatusOK},
+		{"longest prefix allowlist wins", "10.1.2.3:5000", "", "/v1/admin/reports/sales", http.StatusForbidden},
+		{"denylist applies below its prefix", "10.66.1.1:5000", "", "/v1/inventory", http.StatusForbidden},
+		{"unlisted route is open", "203.0.113.9:5000", "", "/v1/inventory", http.StatusOK},
+		{"forwarded for from a trusted proxy", "10.0.0.1:5000", "203.0.113.9", "/v1/admin/dashboard", http.StatusForbidden},
+		{"forwarded for from an untrusted peer is ignored", "10.1.2.3:5000", "203.0.113.9", "/v1/admin/dashboard", http.StatusOK},
+		{"spoofed leftmost hop is skipped", "10.0.0.1:5000", "10.1.2.3, 203.0.113.9", "/v1/admin/dashboard", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, rr.Code)
+			}
+		})
+	}
+}
+
+func TestParseIPFilterConfig_RejectsMalformedLists(t *testing.T) {
+	for _, cfg := range []*config.Config{
+		{IPAllowlist: "/v1/admin=10.0.0.0/33"},
+		{IPAllowlist: "v1/admin=10.0.0.0/8"},
+		{IPDenylist: "/v1="},
+		{TrustedProxies: "proxy.internal"},
+	} {
+		if _, err := middleware.ParseIPFilterConfig(cfg); err == nil {
+			t.Errorf("Expected %+v to be rejected", cfg)
+		}
+	}
+}
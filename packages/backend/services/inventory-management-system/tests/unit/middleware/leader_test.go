@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/leader"
+	"inventory-management-api/internal/middleware"
+)
+
+// servesReads lets followers answer GET requests themselves
+func servesReads(r *http.Request) bool {
+	return r.Method == http.MethodGet
+}
+
+// newFollowerHandler wraps a handler answering "local" in the leader
+// middleware of a follower of the instance at leaderURL
+func newFollowerHandler(t *testing.T, leaderURL string) http.Handler {
+	t.Helper()
+
+	lockFile := filepath.Join(t.TempDir(), "leader.lock")
+	current := leader.New(leader.Config{LockFile: lockFile, AdvertiseURL: leaderURL, InstanceID: "central-a", RetryInterval: time.Hour})
+	if elected, err := current.Campaign(); err != nil || !elected {
+		t.Fatalf("Expected the first instance to win, got %v, %v", elected, err)
+	}
+	current.Start(func() {})
+	t.Cleanup(current.Stop)
+
+	follower := leader.New(leader.Config{LockFile: lockFile, InstanceID: "central-b", RetryInterval: time.Hour})
+	if elected, _ := follower.Campaign(); elected {
+		t.Fatal("Expected the second instance to follow")
+	}
+
+	local := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("local"))
+	})
+	return middleware.LeaderMiddleware(follower, servesReads)(local)
+}
+
+func TestLeaderMiddleware_FollowerForwardsWrites(t *testing.T) {
+	leaderServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("leader " + r.Method + " " + r.URL.Path))
+	}))
+	defer leaderServer.Close()
+	handler := newFollowerHandler(t, leaderServer.URL)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/inventory/SKU-001", nil))
+	if rr.Body.String() != "local" {
+		t.Errorf("Expected the follower to serve reads, got %q", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/inventory/updates", nil))
+	if rr.Code != http.StatusAccepted || rr.Body.String() != "leader POST /v1/inventory/updates" {
+		t.Errorf("Expected the write to reach the leader, got %d %q", rr.Code, rr.Body.String())
+	}
+	if served := rr.Header().Get(middleware.LeaderHeader); served != "central-a" {
+		t.Errorf("Expected %s central-a, got %q", middleware.LeaderHeader, served)
+	}
+}
+
+func TestLeaderMiddleware_UnreachableLeader(t *testing.T) {
+	leaderServer := httptest.NewServer(http.NotFoundHandler())
+	leaderURL := leaderServer.URL
+	leaderServer.Close()
+	handler := newFollowerHandler(t, leaderURL)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/v1/inventory/updates", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the leader is unreachable, got %d", rr.Code)
+	}
+}
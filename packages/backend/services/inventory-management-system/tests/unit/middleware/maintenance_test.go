@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/openapi"
+
+	"github.com/gorilla/mux"
+)
+
+func newMaintenanceRouter(maintenance *middleware.Maintenance) *mux.Router {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r := mux.NewRouter()
+	r.Use(middleware.MaintenanceMiddleware(maintenance, openapi.MaintenanceRoutes(openapi.Routes())))
+	r.HandleFunc("/v1/inventory/updates", noop).Methods("POST")
+	r.HandleFunc("/v1/inventory/batch-get", noop).Methods("POST")
+	r.HandleFunc("/v1/inventory/events", noop).Methods("GET")
+	r.HandleFunc("/v1/inventory/events/commit", noop).Methods("POST")
+	r.HandleFunc("/v1/admin/products/delete", noop).Methods("DELETE")
+	r.HandleFunc("/v1/admin/restore", noop).Methods("POST")
+	r.HandleFunc("/v1/admin/maintenance", noop).Methods("PUT")
+	return r
+}
+
+func TestMaintenanceMiddleware_RefusesWritesOnly(t *testing.T) {
+	maintenance := middleware.NewMaintenance(&config.Config{MaintenanceRetryAfter: "120"})
+	router := newMaintenanceRouter(maintenance)
+
+	send := func(method, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	if rr := send("POST", "/v1/inventory/updates"); rr.Code != http.StatusOK || rr.Header().Get(middleware.MaintenanceHeader) != "" {
+		t.Fatalf("Expected writes to pass outside maintenance, got %d", rr.Code)
+	}
+
+	status := maintenance.Set(true, "Migrating storage", 0, "admin test")
+	if !status.Enabled || status.RetryAfterSeconds != 120 || status.Since == "" || status.ChangedBy != "admin test" {
+		t.Errorf("Expected maintenance on with the configured Retry-After, got %+v", status)
+	}
+
+	for _, tt := range []struct {
+		method, path string
+		expected     int
+	}{
+		{"POST", "/v1/inventory/updates", http.StatusServiceUnavailable},
+		{"DELETE", "/v1/admin/products/delete", http.StatusServiceUnavailable},
+		{"GET", "/v1/inventory/events", http.StatusOK},
+		{"POST", "/v1/inventory/events/commit", http.StatusOK},
+		{"POST", "/v1/inventory/batch-get", http.StatusOK},
+		{"POST", "/v1/admin/restore", http.StatusOK},
+		{"PUT", "/v1/admin/maintenance", http.StatusOK},
+	} {
+		rr := send(tt.method, tt.path)
+		if rr.Code != tt.expected {
+			t.Errorf("%s %s: expected %d, got %d", tt.method, tt.path, tt.expected, rr.Code)
+		}
+		if rr.Header().Get(middleware.MaintenanceHeader) != "read-only" {
+			t.Errorf("%s %s: expected the maintenance header", tt.method, tt.path)
+		}
+		if tt.expected == http.StatusServiceUnavailable {
+			var errResp models.ErrorResponse
+			json.Unmarshal(rr.Body.Bytes(), &errResp)
+			if errResp.Code != "maintenance_mode" || errResp.Message != "Migrating storage" {
+				t.Errorf("%s %s: expected maintenance_mode with the message, got %+v", tt.method, tt.path, errResp)
+			}
+			if rr.Header().Get("Retry-After") != "120" {
+				t.Errorf("%s %s: expected Retry-After 120, got %q", tt.method, tt.path, rr.Header().Get("Retry-After"))
+			}
+		}
+	}
+
+	maintenance.Set(false, "", 0, "admin test")
+	if rr := send("POST", "/v1/inventory/updates"); rr.Code != http.StatusOK || rr.Header().Get(middleware.MaintenanceHeader) != "" {
+		t.Errorf("Expected writes to pass after maintenance, got %d", rr.Code)
+	}
+}
+
+func TestNewMaintenance_StartsFromConfig(t *testing.T) {
+	status := middleware.NewMaintenance(&config.Config{MaintenanceMode: "true", MaintenanceRetryAfter: "-5"}).Status()
+	if !status.Enabled || status.RetryAfterSeconds != 60 || status.Since == "" {
+		t.Errorf("Expected maintenance on with the default Retry-After, got %+v", status)
+	}
+}
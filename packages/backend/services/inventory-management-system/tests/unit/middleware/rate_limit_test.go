@@ -4,7 +4,10 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"inventory-management-api/internal/clock"
+	"inventory-management-api/internal/config"
 	"inventory-management-api/internal/middleware"
 )
 
@@ -195,7 +198,7 @@ func TestRateLimitMiddleware_Integration(t *testing.T) {
 	// Create rate limiter and wrap with middleware
 	rateLimiter := middleware.NewRateLimiter(config)
 	defer rateLimiter.Stop()
-	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter)(testHandler)
+	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter, middleware.IPFilterConfig{}.ClientIP)(testHandler)
 
 	// Test regular endpoint
 	req1 := httptest.NewRequest("GET", "/v1/inventory", nil)
@@ -259,7 +262,7 @@ func TestRateLimitMiddleware_HealthCheckExemption(t *testing.T) {
 	// Create rate limiter and wrap with middleware
 	rateLimiter := middleware.NewRateLimiter(config)
 	defer rateLimiter.Stop()
-	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter)(testHandler)
+	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter, middleware.IPFilterConfig{}.ClientIP)(testHandler)
 
 	// Health check requests should not be rate limited
 	for i := 0; i < 5; i++ {
@@ -297,7 +300,7 @@ func TestRateLimitMiddleware_AdminEndpoints(t *testing.T) {
 	// Create rate limiter and wrap with middleware
 	rateLimiter := middleware.NewRateLimiter(config)
 	defer rateLimiter.Stop()
-	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter)(testHandler)
+	rateLimitedHandler := middleware.RateLimitMiddleware(rateLimiter, middleware.IPFilterConfig{}.ClientIP)(testHandler)
 
 	// First admin request should succeed
 	req1 := httptest.NewRequest("POST", "/v1/admin/products/create", nil)
@@ -324,3 +327,273 @@ func TestRateLimitMiddleware_AdminEndpoints(t *testing.T) {
 		t.Errorf("Second admin request should be rate limited, got status %d", rr2.Code)
 	}
 }
+
+func TestRateLimiter_PrincipalTiers(t *testing.T) {
+	config := middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypePrincipal,
+		RequestsPerMinute:      1,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 1,
+		Tiers:                  map[string]int{"store": 3},
+		PrincipalTiers:         map[string]string{"store-s1": "store"},
+	}
+
+	rateLimiter := middleware.NewRateLimiter(config)
+	defer rateLimiter.Stop()
+
+	// Mapped store gets its tier limit
+	for i := 0; i < 3; i++ {
+		allowed, info := rateLimiter.IsAllowedForPrincipal("10.0.0.1", "store:store-s1", false)
+		if !allowed {
+			t.Errorf("Request %d for store-s1 should be allowed", i+1)
+		}
+		if info.Limit != 3 {
+			t.Errorf("Expected store tier limit 3, got %d", info.Limit)
+		}
+	}
+	if allowed, _ := rateLimiter.IsAllowedForPrincipal("10.0.0.1", "store:store-s1", false); allowed {
+		t.Error("4th request for store-s1 should be denied")
+	}
+
+	// Another principal from the same IP has its own budget at the default limit
+	allowed, info := rateLimiter.IsAllowedForPrincipal("10.0.0.1", "key:other-key", false)
+	if !allowed {
+		t.Error("First request for another principal should be allowed")
+	}
+	if info.Limit != 1 {
+		t.Errorf("Expected default limit 1, got %d", info.Limit)
+	}
+
+	stats := rateLimiter.GetRateLimitStats()
+	usage, ok := stats["principals"].([]middleware.PrincipalUsage)
+	if !ok || len(usage) != 2 {
+		t.Fatalf("Expected usage for 2 principals, got %v", stats["principals"])
+	}
+	for _, u := range usage {
+		if u.Principal == "key:other-key" {
+			t.Error("API keys should be masked in rate limit status")
+		}
+	}
+}
+
+// TestRateLimitMiddleware_PrincipalFromAuth tests that principal limiting
+// counts the caller AuthMiddleware authenticated, whatever X-Store-ID claims,
+// and falls back to the client IP for requests without a principal
+func TestRateLimitMiddleware_PrincipalFromAuth(t *testing.T) {
+	t.Setenv("API_KEYS", "key-a,key-b")
+	t.Setenv("ADMIN_API_KEYS", "")
+	t.Setenv("API_KEY_ROLES", "")
+	config := middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypePrincipal,
+		RequestsPerMinute:      1,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 1,
+	}
+
+	testHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rateLimiter := middleware.NewRateLimiter(config)
+	defer rateLimiter.Stop()
+	limited := middleware.RateLimitMiddleware(rateLimiter, middleware.IPFilterConfig{}.ClientIP)(testHandler)
+	authenticated := middleware.AuthMiddleware(limited)
+
+	send := func(handler http.Handler, remoteAddr, apiKey, storeID string) int {
+		req := httptest.NewRequest("GET", "/v1/inventory", nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		if storeID != "" {
+			req.Header.Set("X-Store-ID", storeID)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := send(authenticated, "192.168.1.1:12345", "key-a", "store-1"); code != http.StatusOK {
+		t.Errorf("First request for key-a should succeed, got %d", code)
+	}
+	if code := send(authenticated, "192.168.1.1:12345", "key-a", "store-2"); code != http.StatusTooManyRequests {
+		t.Errorf("Another X-Store-ID must not give key-a a fresh budget, got %d", code)
+	}
+	if code := send(authenticated, "192.168.1.1:12345", "key-b", ""); code != http.StatusOK {
+		t.Errorf("key-b shares the IP but not the budget, got %d", code)
+	}
+
+	// Without authentication the client IP is the principal
+	if code := send(limited, "192.168.1.2:12345", "key-c", "store-3"); code != http.StatusOK {
+		t.Errorf("First unauthenticated request should succeed, got %d", code)
+	}
+	if code := send(limited, "192.168.1.2:12345", "key-d", "store-4"); code != http.StatusTooManyRequests {
+		t.Errorf("Unauthenticated requests from one IP share a budget, got %d", code)
+	}
+}
+
+func TestRateLimiter_TokenBucketBurst(t *testing.T) {
+	config := middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypeIP,
+		Algorithm:              middleware.RateLimitAlgorithmTokenBucket,
+		RequestsPerMinute:      60,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 60,
+		BurstSize:              5,
+	}
+
+	rateLimiter := middleware.NewRateLimiter(config)
+	defer rateLimiter.Stop()
+
+	// The bucket allows a burst of 5 even though the rate is 60 per minute
+	for i := 0; i < 5; i++ {
+		allowed, info := rateLimiter.IsAllowed("10.0.0.1", false)
+		if !allowed {
+			t.Fatalf("Burst request %d should be allowed", i+1)
+		}
+		if info.Remaining != 5-i-1 {
+			t.Errorf("Expected remaining %d, got %d", 5-i-1, info.Remaining)
+		}
+	}
+
+	allowed, info := rateLimiter.IsAllowed("10.0.0.1", false)
+	if allowed {
+		t.Error("Request beyond the burst should be denied")
+	}
+
+	// One token refills every second at 60 per minute
+	if wait := time.Until(info.ResetTime); wait <= 0 || wait > time.Second {
+		t.Errorf("Expected next token within a second, got %v", wait)
+	}
+}
+
+func TestRateLimitMiddleware_WindowOnManualClock(t *testing.T) {
+	config := middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypeIP,
+		RequestsPerMinute:      1,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 1,
+	}
+
+	manual := clock.NewManual(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	rateLimiter := middleware.NewRateLimiter(config)
+	defer rateLimiter.Stop()
+	rateLimiter.SetClock(manual)
+	handler := middleware.RateLimitMiddleware(rateLimiter, middleware.IPFilterConfig{}.ClientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/v1/inventory", nil)
+		req.RemoteAddr = "192.168.1.1:12345"
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Fatalf("First request should succeed, got status %d", rr.Code)
+	}
+
+	manual.Advance(20 * time.Second)
+	rr := serve()
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Second request in the window should be rate limited, got status %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "40" {
+		t.Errorf("Expected Retry-After: 40, got %s", rr.Header().Get("Retry-After"))
+	}
+
+	manual.Advance(41 * time.Second)
+	if rr := serve(); rr.Code != http.StatusOK {
+		t.Errorf("Request after the window should succeed, got status %d", rr.Code)
+	}
+}
+
+func TestRateLimiter_TokenBucketRefillOnManualClock(t *testing.T) {
+	config := middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypeIP,
+		Algorithm:              middleware.RateLimitAlgorithmTokenBucket,
+		RequestsPerMinute:      60,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 60,
+		BurstSize:              2,
+	}
+
+	manual := clock.NewManual(time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC))
+	rateLimiter := middleware.NewRateLimiter(config)
+	defer rateLimiter.Stop()
+	rateLimiter.SetClock(manual)
+
+	for i := 0; i < 2; i++ {
+		if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); !allowed {
+			t.Fatalf("Burst request %d should be allowed", i+1)
+		}
+	}
+	if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); allowed {
+		t.Fatal("Request beyond the burst should be denied")
+	}
+
+	// One token refills every second at 60 per minute
+	manual.Advance(time.Second)
+	if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); !allowed {
+		t.Error("Request after a refill should be allowed")
+	}
+	if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); allowed {
+		t.Error("Only one token should have refilled")
+	}
+}
+
+func TestRateLimitMiddleware_ClientIPFromTrustedProxiesOnly(t *testing.T) {
+	filterConfig, err := middleware.ParseIPFilterConfig(&config.Config{TrustedProxies: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("Failed to parse IP filter config: %v", err)
+	}
+	rateLimiter := middleware.NewRateLimiter(middleware.RateLimitConfig{
+		Enabled:                true,
+		Type:                   middleware.RateLimitTypeIP,
+		RequestsPerMinute:      1,
+		WindowMinutes:          1,
+		AdminRequestsPerMinute: 1,
+	})
+	defer rateLimiter.Stop()
+	handler := middleware.RateLimitMiddleware(rateLimiter, filterConfig.ClientIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(remoteAddr string, headers map[string]string) int {
+		req := httptest.NewRequest("GET", "/v1/inventory", nil)
+		req.RemoteAddr = remoteAddr
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := send("203.0.113.9:5000", nil); code != http.StatusOK {
+		t.Fatalf("First request should succeed, got %d", code)
+	}
+	// A direct client cannot get a fresh bucket by naming another address
+	if code := send("203.0.113.9:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}); code != http.StatusTooManyRequests {
+		t.Errorf("Spoofed X-Forwarded-For should be ignored, got %d", code)
+	}
+	if code := send("203.0.113.9:5000", map[string]string{"X-Real-IP": "198.51.100.2"}); code != http.StatusTooManyRequests {
+		t.Errorf("Spoofed X-Real-IP should be ignored, got %d", code)
+	}
+
+	// Behind a trusted proxy each forwarded client has its own bucket
+	if code := send("10.0.0.1:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}); code != http.StatusOK {
+		t.Errorf("First request forwarded by a trusted proxy should succeed, got %d", code)
+	}
+	if code := send("10.0.0.1:5000", map[string]string{"X-Forwarded-For": "198.51.100.3"}); code != http.StatusOK {
+		t.Errorf("Another client behind the proxy should have its own limit, got %d", code)
+	}
+	if code := send("10.0.0.1:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}); code != http.StatusTooManyRequests {
+		t.Errorf("Forwarded client should be limited by its own address, got %d", code)
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/requestid"
+)
+
+func TestRequestIDMiddleware_ReusesValidIncomingID(t *testing.T) {
+	var contextID string
+	handler := middleware.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contextID = requestid.FromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/v1/inventory", nil)
+	req.Header.Set(requestid.Header, "store-1-abc123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if got := rr.Header().Get(requestid.Header); got != "store-1-abc123" {
+		t.Errorf("Expected response header store-1-abc123, got %q", got)
+	}
+	if contextID != "store-1-abc123" {
+		t.Errorf("Expected context request ID store-1-abc123, got %q", contextID)
+	}
+}
+
+func TestRequestIDMiddleware_GeneratesIDWhenMissingOrInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "missing", header: ""},
+		{name: "contains spaces", header: "not a valid id"},
+		{name: "too long", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var contextID string
+			handler := middleware.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				contextID = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/v1/inventory", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			got := rr.Header().Get(requestid.Header)
+			if len(got) != 32 {
+				t.Errorf("Expected generated 32-character ID, got %q", got)
+			}
+			if contextID != got {
+				t.Errorf("Expected context ID %q to match response header %q", contextID, got)
+			}
+		})
+	}
+}
+
+func TestRequestIDMiddleware_ErrorResponseIncludesID(t *testing.T) {
+	handler := middleware.RequestIDMiddleware(middleware.BodyLimitMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	req := httptest.NewRequest("POST", "/v1/inventory/updates", strings.NewReader(strings.Repeat("x", 32)))
+	req.Header.Set(requestid.Header, "req-42")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected JSON error body: %v", err)
+	}
+	if body["requestId"] != "req-42" {
+		t.Errorf("Expected requestId req-42 in error body, got %v", body["requestId"])
+	}
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/telemetry"
+)
+
+// TestMiddlewareChain_FlushReachesClient tests that a flush from a streaming
+// handler passes through every writer of the server chain, so a chunk reaches
+// the client before the handler ends the response
+func TestMiddlewareChain_FlushReachesClient(t *testing.T) {
+	// Signalled by the client once it has read the first chunk
+	received := make(chan struct{}, 1)
+	stream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"productId":"SKU-001"}` + "\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush failed: %v", err)
+		}
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte(`{"productId":"SKU-002"}` + "\n"))
+	})
+
+	var handler http.Handler = stream
+	handler = middleware.CompressionMiddleware(1)(handler)
+	handler = middleware.TimeoutMiddleware(middleware.TimeoutConfig{Read: 5 * time.Second, Write: 5 * time.Second, Admin: 5 * time.Second})(handler)
+	handler = telemetry.NewTelemetryMiddleware(telemetry.NewInventoryApiTelemetry()).Middleware(handler)
+	handler = middleware.RequestIDMiddleware(handler)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for _, encoding := range []string{"identity", "gzip"} {
+		t.Run(encoding, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", server.URL+"/v1/inventory", nil)
+			if encoding == "identity" {
+				req.Header.Set("Accept-Encoding", "identity")
+			}
+			// The clock starts before the request: without flushes even the
+			// headers wait for the handler to finish
+			lines := make(chan string)
+			go func() {
+				defer close(lines)
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Errorf("Request failed: %v", err)
+					return
+				}
+				defer resp.Body.Close()
+				scanner := bufio.NewScanner(resp.Body)
+				for scanner.Scan() {
+					lines <- scanner.Text()
+				}
+			}()
+
+			select {
+			case line := <-lines:
+				if line != `{"productId":"SKU-001"}` {
+					t.Errorf("Unexpected first line %q", line)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("The first chunk did not reach the client before the handler finished")
+			}
+			received <- struct{}{}
+			if line := <-lines; line != `{"productId":"SKU-002"}` {
+				t.Errorf("Unexpected second line %q", line)
+			}
+		})
+	}
+}
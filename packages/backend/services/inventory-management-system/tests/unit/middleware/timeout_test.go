@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/models"
+)
+
+func TestTimeoutConfig_For(t *testing.T) {
+	timeoutConfig := middleware.ParseTimeoutConfig(&config.Config{
+		RequestTimeoutRead:        "2s",
+		RequestTimeoutWrite:       "10s",
+		RequestTimeoutAdmin:       "30s",
+		RequestTimeoutStream:      "2m",
+		RequestTimeoutEventsGrace: "5s",
+		RequestTimeoutRoutes:      "/v1/inventory/snapshot=30s, /v1/admin=1m, /v1/admin/restore=5m, bogus=1s",
+	})
+
+	tests := []struct {
+		method, target, accept string
+		expected               time.Duration
+	}{
+		{"GET", "/v1/inventory/SKU-001", "", 2 * time.Second},
+		{"POST", "/v1/inventory/batch-get", "", 2 * time.Second},
+		{"POST", "/v1/inventory/updates", "", 10 * time.Second},
+		{"GET", "/v1/inventory/events?offset=0&wait=20", "", 25 * time.Second},
+		{"GET", "/v1/inventory/events?offset=0&wait=600", "", 5 * time.Second},
+		{"POST", "/v1/inventory/events/commit", "", 10 * time.Second},
+		{"GET", "/v1/inventory?page=2", "", 2 * time.Second},
+		{"GET", "/v1/inventory", "application/x-ndjson", 2 * time.Minute},
+		{"GET", "/v2/inventory", "application/json, application/x-ndjson;q=0.5", 2 * time.Minute},
+		{"GET", "/v2/inventory/snapshot", "", 30 * time.Second},
+		{"GET", "/v1/admin/dashboard", "", time.Minute},
+		{"POST", "/v1/admin/restore", "", 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		if got := timeoutConfig.For(req); got != tt.expected {
+			t.Errorf("%s %s (Accept %q): expected %s, got %s", tt.method, tt.target, tt.accept, tt.expected, got)
+		}
+	}
+
+	// Without an override the snapshot streams on the stream budget
+	timeoutConfig.Routes = nil
+	if got := timeoutConfig.For(httptest.NewRequest("GET", "/v1/inventory/snapshot", nil)); got != 2*time.Minute {
+		t.Errorf("Expected the snapshot to get the stream budget, got %s", got)
+	}
+}
+
+func TestTimeoutMiddleware_AnswersGatewayTimeout(t *testing.T) {
+	timeoutConfig := middleware.TimeoutConfig{Read: 20 * time.Millisecond}
+	cancelled := make(chan error, 1)
+	handler := middleware.TimeoutMiddleware(timeoutConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond) // Stuck without looking at its context
+		cancelled <- r.Context().Err()
+		w.Write([]byte("too late"))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory/SKU-001", nil))
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("Expected status 504, got %d", rr.Code)
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Code != "request_timeout" {
+		t.Errorf("Expected code request_timeout, got %q", response.Code)
+	}
+
+	if err := <-cancelled; err != context.DeadlineExceeded {
+		t.Errorf("Expected the handler's context to be past its deadline, got %v", err)
+	}
+	if strings.Contains(rr.Body.String(), "too late") {
+		t.Errorf("Expected the late write to be dropped, got %q", rr.Body.String())
+	}
+}
+
+func TestTimeoutMiddleware_PassesFastResponses(t *testing.T) {
+	handler := middleware.TimeoutMiddleware(middleware.TimeoutConfig{Write: time.Second})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Expected the request context to carry a deadline")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/v1/orders", nil))
+	if rr.Code != http.StatusCreated || rr.Body.String() != `{"ok":true}` || rr.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("Expected the handler's response unchanged, got %d %q %v", rr.Code, rr.Body.String(), rr.Header())
+	}
+}
+
+func TestTimeoutMiddleware_StartedStreamKeepsItsStatus(t *testing.T) {
+	handler := middleware.TimeoutMiddleware(middleware.TimeoutConfig{Read: 20 * time.Millisecond})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		w.Header().Set("X-Product-Count", "1") // Trailer values are set once the stream ends
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/inventory", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "first\n" {
+		t.Errorf("Expected the started stream to keep status 200, got %d %q", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("X-Product-Count") != "1" {
+		t.Error("Expected headers set after the response started to reach the writer")
+	}
+}
@@ -0,0 +1,130 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/migrate"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+)
+
+// openJSON opens a JSON backend in dir
+func openJSON(t *testing.T, dir string) migrate.Backend {
+	t.Helper()
+	backend, err := migrate.Open("json:data=" + filepath.Join(dir, "inventory.json") + ",events=" + filepath.Join(dir, "events.json"))
+	if err != nil {
+		t.Fatalf("Failed to open backend: %v", err)
+	}
+	return backend
+}
+
+func seedDataset() *migrate.Dataset {
+	return &migrate.Dataset{
+		Products: map[string]services.ProductData{
+			"SKU-001": {ProductID: "SKU-001", Name: "Laptop", Available: 10, Version: 3},
+			"SKU-002": {ProductID: "SKU-002", Name: "Mouse", Available: 0, Version: 7},
+		},
+		Metadata: services.MetadataData{LastOffset: 2},
+		Events: []models.Event{
+			{Offset: 0, EventType: models.EventTypeProductUpdated, ProductID: "SKU-001"},
+			{Offset: 1, EventType: models.EventTypeProductUpdated, ProductID: "SKU-002"},
+		},
+		NextOffset: 2,
+		Consumers:  map[string]models.EventConsumer{"store-s1": {StoreID: "store-s1", Offset: 2}},
+	}
+}
+
+func TestMigrate_CopiesAndVerifies(t *testing.T) {
+	source := openJSON(t, t.TempDir())
+	if err := source.Store(seedDataset()); err != nil {
+		t.Fatalf("Failed to seed source: %v", err)
+	}
+	target := openJSON(t, t.TempDir())
+
+	result, err := migrate.Migrate(source, target, migrate.Options{})
+	if err != nil {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	if !result.Verified() || result.Target.Products != 2 || result.Target.Events != 2 || result.Target.Consumers != 1 {
+		t.Errorf("Expected a verified copy of 2 products, 2 events and 1 consumer, got %+v", result)
+	}
+
+	copied, _ := target.Load()
+	if copied.Products["SKU-002"].Version != 7 || copied.NextOffset != 2 {
+		t.Errorf("Expected the products and next offset to be copied, got %+v", copied)
+	}
+
+	if _, err := migrate.Migrate(source, target, migrate.Options{}); !errors.Is(err, migrate.ErrTargetNotEmpty) {
+		t.Errorf("Expected ErrTargetNotEmpty, got %v", err)
+	}
+	if _, err := migrate.Migrate(source, target, migrate.Options{Overwrite: true}); err != nil {
+		t.Errorf("Expected -overwrite to replace the target, got %v", err)
+	}
+}
+
+func TestSummarize_DetectsChanges(t *testing.T) {
+	dataset := seedDataset()
+	before := migrate.Summarize(dataset)
+
+	product := dataset.Products["SKU-001"]
+	product.Available = 9
+	dataset.Products["SKU-001"] = product
+	after := migrate.Summarize(dataset)
+
+	mismatches := migrate.Mismatches(before, after, false)
+	if len(mismatches) != 1 || before.EventsChecksum != after.EventsChecksum {
+		t.Errorf("Expected only the products checksum to differ, got %v", mismatches)
+	}
+}
+
+func TestFollow_CopiesSourceChanges(t *testing.T) {
+	source := openJSON(t, t.TempDir())
+	if err := source.Store(seedDataset()); err != nil {
+		t.Fatalf("Failed to seed source: %v", err)
+	}
+	target := openJSON(t, t.TempDir())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	passes := make(chan migrate.Result, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- migrate.Follow(ctx, source, target, migrate.Options{}, 10*time.Millisecond, func(result migrate.Result) {
+			passes <- result
+		})
+	}()
+
+	if first := <-passes; !first.Verified() {
+		t.Fatalf("Expected the first pass to verify, got %+v", first)
+	}
+
+	dataset := seedDataset()
+	dataset.Events = append(dataset.Events, models.Event{Offset: 2, EventType: models.EventTypeProductUpdated, ProductID: "SKU-001"})
+	dataset.NextOffset = 3
+	if err := source.Store(dataset); err != nil {
+		t.Fatalf("Failed to update source: %v", err)
+	}
+
+	select {
+	case second := <-passes:
+		if !second.Verified() || second.Target.Events != 3 {
+			t.Errorf("Expected the new event to be copied, got %+v", second)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the follow pass")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected follow to stop cleanly, got %v", err)
+	}
+}
+
+func TestOpen_UnknownBackend(t *testing.T) {
+	if _, err := migrate.Open("postgres:dsn=postgres://localhost/inventory"); !errors.Is(err, migrate.ErrUnknownBackend) {
+		t.Errorf("Expected ErrUnknownBackend, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/openapi"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild_DocumentsAllRoutes(t *testing.T) {
+	doc := openapi.Build("test", openapi.Routes())
+
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	for _, path := range []string{
+		"/v1/inventory/updates",
+		"/v1/inventory/events",
+		"/v1/inventory/{productId}",
+		"/v1/admin/products/create",
+		"/v1/admin/rate-limit/status",
+		"/health",
+	} {
+		assert.Contains(t, doc.Paths, path)
+	}
+
+	updates := doc.Paths["/v1/inventory/updates"]["post"]
+	require.NotNil(t, updates)
+	assert.Equal(t, "#/components/schemas/UpdateRequest", updates.RequestBody.Content["application/json"].Schema.Ref)
+	assert.Contains(t, updates.Responses, "401")
+	assert.Contains(t, updates.Responses, "413")
+}
+
+func TestBuild_SchemasFollowModelTags(t *testing.T) {
+	doc := openapi.Build("test", openapi.Routes())
+
+	product, ok := doc.Components.Schemas["ProductResponse"]
+	require.True(t, ok)
+	assert.Equal(t, "integer", product.Properties["available"].Type)
+	assert.Equal(t, "array", product.Properties["prices"].Type)
+	assert.Equal(t, "#/components/schemas/Money", product.Properties["prices"].Items.Ref)
+	assert.Contains(t, product.Required, "productId")
+	assert.NotContains(t, product.Required, "prices")
+
+	update, ok := doc.Components.Schemas["AdminProductUpdate"]
+	require.True(t, ok)
+	assert.True(t, update.Properties["name"].Nullable)
+
+	_, err := json.Marshal(doc)
+	assert.NoError(t, err)
+}
+
+func TestUndocumentedRoutes_ReportsDrift(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	r := mux.NewRouter()
+	r.HandleFunc("/health", noop).Methods("GET")
+	r.HandleFunc("/v1/inventory/new-thing", noop).Methods("POST")
+
+	missing := openapi.UndocumentedRoutes(r, openapi.Routes())
+	assert.Equal(t, []string{"POST /v1/inventory/new-thing"}, missing)
+}
+
+func TestRoutes_SecuredRoutesRequireAPermission(t *testing.T) {
+	permissions := openapi.RoutePermissions(openapi.Routes())
+	for _, route := range openapi.Routes() {
+		if route.Security == openapi.SecurityNone {
+			continue
+		}
+		assert.NotEmpty(t, permissions[route.Method+" "+route.Path], "%s %s has no required permission", route.Method, route.Path)
+	}
+
+	doc := openapi.Build("test", openapi.Routes())
+	deleteOp := doc.Paths["/v1/admin/products/delete"]["delete"]
+	require.NotNil(t, deleteOp)
+	assert.Equal(t, "products:delete", deleteOp.RequiredPermission)
+	assert.Contains(t, deleteOp.Responses, "403")
+}
+
+func TestRoutes_EveryV1RouteIsServedUnderV2(t *testing.T) {
+	doc := openapi.Build("test", openapi.Routes())
+
+	operationIDs := make(map[string]bool)
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			assert.False(t, operationIDs[op.OperationID], "duplicate operationId %s", op.OperationID)
+			operationIDs[op.OperationID] = true
+
+			v1Path, found := strings.CutPrefix(path, "/v2/")
+			if !found {
+				continue
+			}
+			v1 := doc.Paths["/v1/"+v1Path][method]
+			require.NotNil(t, v1, "%s %s has no /v1 route", method, path)
+			assert.Equal(t, v1.RequiredPermission, op.RequiredPermission)
+		}
+	}
+
+	jobs := doc.Paths["/v2/admin/jobs/{id}"]["get"]
+	require.NotNil(t, jobs)
+	assert.Equal(t, "getJobV2", jobs.OperationID)
+	assert.NotContains(t, doc.Paths, "/v2/health")
+}
+
+func TestRouteDeprecations(t *testing.T) {
+	routes := []openapi.Route{
+		{Method: http.MethodGet, Path: "/v1/inventory/old", Deprecated: "2026-01-15", Sunset: "2026-07-01"},
+		{Method: http.MethodGet, Path: "/v1/inventory/current"},
+	}
+
+	deprecations, err := openapi.RouteDeprecations(routes)
+	require.NoError(t, err)
+	require.Len(t, deprecations, 1)
+	old := deprecations["GET /v1/inventory/old"]
+	assert.Equal(t, time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), old.Since)
+	assert.Equal(t, time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC), old.Sunset)
+
+	versioned := openapi.Versioned(routes, "v2")
+	require.Len(t, versioned, 2)
+	assert.Equal(t, "/v2/inventory/old", versioned[0].Path)
+	assert.Empty(t, versioned[0].Deprecated, "deprecations are announced for /v1 only")
+
+	doc := openapi.Build("test", routes)
+	assert.True(t, doc.Paths["/v1/inventory/old"]["get"].Deprecated)
+	assert.False(t, doc.Paths["/v1/inventory/current"]["get"].Deprecated)
+
+	_, err = openapi.RouteDeprecations([]openapi.Route{{Method: http.MethodGet, Path: "/v1/x", Deprecated: "15/01/2026"}})
+	assert.Error(t, err)
+	_, err = openapi.RouteDeprecations([]openapi.Route{{Method: http.MethodGet, Path: "/v1/x", Sunset: "2026-07-01"}})
+	assert.Error(t, err)
+
+	_, err = openapi.RouteDeprecations(openapi.Routes())
+	assert.NoError(t, err)
+}
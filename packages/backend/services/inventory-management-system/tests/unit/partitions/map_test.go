@@ -0,0 +1,71 @@
+package partitions
+
+import (
+	"testing"
+
+	"inventory-management-api/internal/partitions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMap puts SKU-001 (hash 1571) and SKU-004 (hash 4076) on p1 and SKU-002
+// (hash 6234) on p2
+const testMap = "p2:5000-65535=http://central-2:8081, p1:0-4999=http://central-1:8081/"
+
+func TestParseMap(t *testing.T) {
+	partitionMap, err := partitions.ParseMap(testMap)
+	require.NoError(t, err)
+
+	all := partitionMap.Partitions()
+	require.Len(t, all, 2)
+	assert.Equal(t, partitions.Partition{ID: "p1", Start: 0, End: 4999, URL: "http://central-1:8081"}, all[0], "partitions come in hash order, URLs without the trailing slash")
+	assert.Equal(t, "p2", all[1].ID)
+
+	partition, ok := partitionMap.Get("p2")
+	require.True(t, ok)
+	assert.Equal(t, "http://central-2:8081", partition.URL)
+	_, ok = partitionMap.Get("p3")
+	assert.False(t, ok)
+}
+
+func TestParseMap_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"empty":         " , ",
+		"gap at start":  "p1:1-65535=http://a:8081",
+		"gap between":   "p1:0-99=http://a:8081,p2:101-65535=http://b:8081",
+		"gap at end":    "p1:0-65534=http://a:8081",
+		"overlap":       "p1:0-100=http://a:8081,p2:100-65535=http://b:8081",
+		"duplicate id":  "p1:0-100=http://a:8081,p1:101-65535=http://b:8081",
+		"reversed":      "p1:65535-0=http://a:8081",
+		"out of space":  "p1:0-65536=http://a:8081",
+		"missing range": "p1=http://a:8081",
+		"missing url":   "p1:0-65535",
+		"relative url":  "p1:0-65535=central-1:8081",
+	}
+	for name, value := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := partitions.ParseMap(value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMap_ForAndGroup(t *testing.T) {
+	partitionMap, err := partitions.ParseMap(testMap)
+	require.NoError(t, err)
+
+	assert.Equal(t, "p1", partitionMap.For("SKU-001").ID)
+	assert.Equal(t, "p2", partitionMap.For("SKU-002").ID)
+	for _, productID := range []string{"SKU-001", "SKU-002", "SKU-004", "anything"} {
+		partition := partitionMap.For(productID)
+		assert.True(t, partition.Owns(productID), "the partition found owns %s", productID)
+	}
+
+	groups := partitionMap.Group([]string{"SKU-002", "SKU-001", "SKU-004", "SKU-002"})
+	require.Len(t, groups, 2)
+	assert.Equal(t, "p1", groups[0].Partition.ID)
+	assert.Equal(t, []int{1, 2}, groups[0].Indexes)
+	assert.Equal(t, "p2", groups[1].Partition.ID)
+	assert.Equal(t, []int{0, 3}, groups[1].Indexes)
+}
@@ -0,0 +1,151 @@
+package partitions
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/partitions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePartition serves a fixed event stream that starts at oldest and ends
+// before next, the way a partition's events endpoint does
+func fakePartition(t *testing.T, stream []models.Event, oldest, next int64) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, partitions.ConsumerID, r.Header.Get("X-Store-ID"))
+		assert.Equal(t, "partition-key", r.Header.Get("X-API-Key"))
+
+		offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+		w.Header().Set("Content-Type", "application/json")
+		if offset < oldest {
+			w.WriteHeader(http.StatusGone)
+			json.NewEncoder(w).Encode(models.OffsetGoneResponse{Code: "offset_gone", RequestedOffset: offset, OldestAvailableOffset: oldest, SnapshotOffset: next})
+			return
+		}
+
+		page := models.EventsResponse{Events: []models.Event{}, NextOffset: next}
+		for _, event := range stream {
+			if event.Offset >= offset {
+				page.Events = append(page.Events, event)
+			}
+		}
+		if len(page.Events) == 0 {
+			time.Sleep(20 * time.Millisecond) // Stands in for the long poll
+		}
+		page.Count = len(page.Events)
+		json.NewEncoder(w).Encode(page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// productEvent returns a stock event of productID at a partition offset
+func productEvent(offset int64, productID string, version int) models.Event {
+	return models.Event{
+		Offset:    offset,
+		Timestamp: "2024-01-15T10:00:00Z",
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Version:   version,
+		Data:      models.ProductResponse{ProductID: productID, Version: version},
+	}
+}
+
+// newRouterQueue opens the router's merged queue in dir
+func newRouterQueue(t *testing.T, dir string) *events.EventQueue {
+	t.Helper()
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(dir, "router-events.json"),
+		MaxEvents: 100,
+		Logger:    slog.Default(),
+	})
+	require.NoError(t, err)
+	return queue
+}
+
+// newMerger creates a merger over partitionMap that retries quickly
+func newMerger(t *testing.T, partitionMap *partitions.Map, queue *events.EventQueue, dir string) *partitions.Merger {
+	t.Helper()
+	merger, err := partitions.NewMerger(partitions.MergerConfig{
+		Map:           partitionMap,
+		Queue:         queue,
+		StateFile:     filepath.Join(dir, "router-offsets.json"),
+		APIKey:        "partition-key",
+		PollWait:      time.Second,
+		RetryInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return merger
+}
+
+func TestMerger_MergesPartitionStreams(t *testing.T) {
+	p1 := fakePartition(t, []models.Event{productEvent(0, "SKU-001", 2), productEvent(1, "SKU-001", 3)}, 0, 2)
+	p2 := fakePartition(t, []models.Event{productEvent(0, "SKU-002", 8)}, 0, 1)
+	partitionMap, err := partitions.ParseMap("p1:0-4999=" + p1.URL + ",p2:5000-65535=" + p2.URL)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	queue := newRouterQueue(t, dir)
+	defer queue.Close()
+	merger := newMerger(t, partitionMap, queue, dir)
+	merger.Start()
+	require.Eventually(t, func() bool { return queue.GetCurrentOffset() == 3 }, 2*time.Second, 10*time.Millisecond)
+	merger.Stop()
+
+	merged, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, merged, 3)
+	var p1Offsets []int64
+	for i, event := range merged {
+		assert.Equal(t, int64(i), event.Offset, "merged events get offsets of the router's stream")
+		assert.Equal(t, "2024-01-15T10:00:00Z", event.Timestamp, "merged events keep their timestamp")
+		switch event.ProductID {
+		case "SKU-001":
+			assert.Equal(t, "p1", event.Partition)
+			p1Offsets = append(p1Offsets, event.PartitionOffset)
+		case "SKU-002":
+			assert.Equal(t, "p2", event.Partition)
+			assert.Equal(t, int64(0), event.PartitionOffset)
+		}
+	}
+	assert.Equal(t, []int64{0, 1}, p1Offsets, "a partition's events keep their order")
+
+	assert.Equal(t, map[string]int64{"p1": 2, "p2": 1}, merger.Offsets())
+	assert.Equal(t, map[string]int64{"p1": 0, "p2": 0}, merger.OffsetsAt(0))
+	assert.Equal(t, map[string]int64{"p1": 2, "p2": 1}, merger.OffsetsAt(3))
+
+	// A restarted router resumes where this one stopped
+	resumed := newMerger(t, partitionMap, queue, dir)
+	assert.Equal(t, map[string]int64{"p1": 2, "p2": 1}, resumed.Offsets())
+}
+
+func TestMerger_PartitionOffsetGone(t *testing.T) {
+	// The partition rotated away everything the router has not merged yet
+	p1 := fakePartition(t, nil, 5, 7)
+	partitionMap, err := partitions.ParseMap("p1:0-65535=" + p1.URL)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	queue := newRouterQueue(t, dir)
+	defer queue.Close()
+	merger := newMerger(t, partitionMap, queue, dir)
+	merger.Start()
+	require.Eventually(t, func() bool { return merger.Offsets()["p1"] == 7 }, 2*time.Second, 10*time.Millisecond)
+	merger.Stop()
+
+	merged, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, merged, 1)
+	assert.Equal(t, models.EventTypeSystemRestored, merged[0].EventType, "stores resync fully after the gap")
+	assert.Equal(t, "p1", merged[0].Partition)
+	assert.Equal(t, map[string]int64{"p1": 7}, queue.MergedPartitionOffsets())
+}
@@ -0,0 +1,157 @@
+package reports
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/reports"
+)
+
+func sale(offset int64, timestamp, productID, storeID string, delta int) models.Event {
+	return models.Event{
+		Offset:    offset,
+		Timestamp: timestamp,
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Data:      models.ProductResponse{ProductID: productID, Name: "Phone", Category: "phones"},
+		StoreID:   storeID,
+		Delta:     delta,
+	}
+}
+
+func TestStore_AggregatesPerDayProductAndStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := reports.NewStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	batch := []models.Event{
+		sale(0, "2025-11-28T10:00:00Z", "SKU-1", "store-1", -2),
+		sale(1, "2025-11-28T11:00:00Z", "SKU-1", "store-1", -1),
+		sale(2, "2025-11-28T12:00:00Z", "SKU-1", "store-2", -4),
+		sale(3, "2025-11-28T12:30:00Z", "SKU-1", "store-2", 5), // restock, not a sale
+		{Offset: 4, Timestamp: "2025-11-28T13:00:00Z", EventType: models.EventTypeProductCreated, ProductID: "SKU-2"},
+		// 23:30 at UTC-3 is the next UTC day
+		sale(5, "2025-11-28T23:30:00-03:00", "SKU-1", "store-1", -7),
+	}
+	if err := store.Apply(batch, 6); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+
+	report := store.Report("2025-11-28")
+	if len(report.Rows) != 2 || report.TotalUnits != 7 || report.TotalTransactions != 3 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if row := report.Rows[0]; row.StoreID != "store-1" || row.UnitsSold != 3 || row.Transactions != 2 || row.ProductName != "Phone" {
+		t.Errorf("Unexpected store-1 row: %+v", row)
+	}
+	if next := store.Report("2025-11-29"); next.TotalUnits != 7 {
+		t.Errorf("Expected the late sale on 2025-11-29, got %+v", next)
+	}
+	if empty := store.Report("2025-11-27"); len(empty.Rows) != 0 || empty.ProcessedOffset != 6 {
+		t.Errorf("Expected an empty report processed to 6, got %+v", empty)
+	}
+
+	// Simulate a crash after the day was saved but before the checkpoint:
+	// replaying the batch must not count it twice
+	if err := os.WriteFile(filepath.Join(dir, "checkpoint.json"), []byte(`{"nextOffset":0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := reports.NewStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reload store: %v", err)
+	}
+	if reloaded.NextOffset() != 0 {
+		t.Fatalf("Expected to resume from 0, got %d", reloaded.NextOffset())
+	}
+	if err := reloaded.Apply(append(batch, sale(6, "2025-11-28T14:00:00Z", "SKU-1", "store-1", -1)), 7); err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if report := reloaded.Report("2025-11-28"); report.TotalUnits != 8 {
+		t.Errorf("Expected 8 units after replay plus one new sale, got %d", report.TotalUnits)
+	}
+}
+
+func TestStore_CountsOrdersWhenCommitted(t *testing.T) {
+	store, err := reports.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	order := func(offset int64, eventType string, delta int) models.Event {
+		event := sale(offset, "2025-11-28T10:00:00Z", "SKU-1", "store-1", delta)
+		event.EventType = eventType
+		event.Quantity = 3
+		event.OrderID = "ord-000001"
+		return event
+	}
+	batch := []models.Event{
+		order(0, models.EventTypeOrderReserved, -3),
+		order(1, models.EventTypeOrderCommitted, 0),
+		order(2, models.EventTypeOrderReserved, -3),
+		order(3, models.EventTypeOrderCancelled, 3),
+	}
+	if err := store.Apply(batch, 4); err != nil {
+		t.Fatalf("Failed to apply: %v", err)
+	}
+
+	if report := store.Report("2025-11-28"); report.TotalUnits != 3 || report.TotalTransactions != 1 {
+		t.Errorf("Expected only the committed order as a sale, got %+v", report)
+	}
+}
+
+func TestAggregator_TailsEventQueue(t *testing.T) {
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  filepath.Join(t.TempDir(), "events.json"),
+		MaxEvents: 1000,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	defer queue.Close()
+
+	store, err := reports.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+	aggregator := reports.NewAggregator(queue, store)
+	aggregator.Start()
+	defer aggregator.Stop()
+
+	product := models.ProductResponse{ProductID: "SKU-1", Name: "Phone"}
+	queue.PublishStoreUpdate("SKU-1", product, 2, "store-1", -3, "")
+	queue.PublishEvent(models.EventTypeProductUpdated, "SKU-1", product, 3) // admin change
+	queue.PublishStoreUpdate("SKU-1", product, 4, "store-1", -2, "")
+
+	today := time.Now().UTC().Format(reports.DateLayout)
+	deadline := time.Now().Add(2 * time.Second)
+	for store.NextOffset() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for aggregation, next offset %d", store.NextOffset())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	report := store.Report(today)
+	if report.TotalUnits != 5 || report.TotalTransactions != 2 {
+		t.Errorf("Expected 5 units in 2 transactions, got %+v", report)
+	}
+
+	var consumer models.EventConsumer
+	for _, c := range queue.Stats().Consumers {
+		if c.StoreID == reports.ConsumerID {
+			consumer = c
+		}
+	}
+	if consumer.Offset != 3 {
+		t.Errorf("Expected the aggregator to commit offset 3, got %+v", consumer)
+	}
+}
@@ -0,0 +1,226 @@
+package runtimeconfig
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/middleware"
+	"inventory-management-api/internal/runtimeconfig"
+	"inventory-management-api/internal/services"
+)
+
+const testInventory = `{
+  "products": {
+    "SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 1000, "version": 1, "lastUpdated": "2025-09-04T23:06:38-05:00", "price": 10},
+    "SKU-002": {"productId": "SKU-002", "name": "Laptop", "available": 1000, "version": 1, "lastUpdated": "2025-09-04T23:06:38-05:00", "price": 10}
+  },
+  "metadata": {"lastOffset": 0}
+}`
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	dataPath := filepath.Join(dir, "data", "inventory_test_data.json")
+	if err := os.MkdirAll(filepath.Dir(dataPath), 0o755); err != nil {
+		t.Fatalf("Failed to create data directory: %v", err)
+	}
+	if err := os.WriteFile(dataPath, []byte(testInventory), 0o600); err != nil {
+		t.Fatalf("Failed to write inventory data: %v", err)
+	}
+
+	return &config.Config{
+		DataPath:                        dataPath,
+		SeedDataPath:                    dataPath,
+		EnableJSONPersistence:           "false",
+		InventoryWorkerCount:            "2",
+		InventoryQueueBufferSize:        "10",
+		IdempotencyCacheTTL:             "1m",
+		IdempotencyCacheCleanupInterval: "30s",
+		MaxEventsInQueue:                "1000",
+		MaxRetainedEvents:               "0",
+		EventsFilePath:                  filepath.Join(dir, "events.json"),
+		RateLimitEnabled:                "true",
+		RateLimitType:                   "ip",
+		RateLimitRequestsPerMinute:      "2",
+		RateLimitWindowMinutes:          "1",
+		RateLimitAdminRequestsPerMinute: "50",
+		RateLimitBurstSize:              "0",
+		RateLimitTiers:                  "store:600",
+	}
+}
+
+func newManager(t *testing.T, rateLimiting bool) (*runtimeconfig.Manager, *middleware.RateLimiter, *services.InventoryService) {
+	t.Helper()
+
+	cfg := testConfig(t)
+	service, err := services.NewInventoryService(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create inventory service: %v", err)
+	}
+	t.Cleanup(service.Stop)
+
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  cfg.EventsFilePath,
+		MaxEvents: 1000,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create event queue: %v", err)
+	}
+	t.Cleanup(func() { queue.Close() })
+
+	var rateLimiter *middleware.RateLimiter
+	if rateLimiting {
+		rateLimiter = middleware.NewRateLimiter(middleware.ParseRateLimitConfig(cfg))
+	}
+	return runtimeconfig.NewManager(cfg, rateLimiter, service, queue), rateLimiter, service
+}
+
+func TestManager_AppliesRateLimitsAndAudits(t *testing.T) {
+	manager, rateLimiter, _ := newManager(t, true)
+
+	for i := 0; i < 2; i++ {
+		rateLimiter.IsAllowed("10.0.0.1", false)
+	}
+	if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); allowed {
+		t.Fatal("Expected the third request to be limited")
+	}
+
+	applied, err := manager.Apply(map[string]string{
+		"rateLimitRequestsPerMinute": "5",
+		"rateLimitWindowMinutes":     "1", // Unchanged, not audited
+	}, "admin key:admi****", "api", "holiday traffic")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Fatalf("Expected 1 applied change, got %d", len(applied))
+	}
+	entry := applied[0]
+	if entry.Key != "rateLimitRequestsPerMinute" || entry.OldValue != "2" || entry.NewValue != "5" ||
+		entry.Actor != "admin key:admi****" || entry.Reason != "holiday traffic" {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+
+	// Counters survive the change, so the raised limit applies to the same window
+	if allowed, _ := rateLimiter.IsAllowed("10.0.0.1", false); !allowed {
+		t.Error("Expected the raised limit to allow the request")
+	}
+	if got := manager.Settings()["rateLimitRequestsPerMinute"]; got != "5" {
+		t.Errorf("Expected setting 5, got %s", got)
+	}
+	if audit := manager.Audit(); len(audit) != 1 {
+		t.Errorf("Expected 1 audit entry, got %d", len(audit))
+	}
+}
+
+func TestManager_RejectsInvalidChangesAtomically(t *testing.T) {
+	manager, rateLimiter, _ := newManager(t, true)
+
+	tests := []struct {
+		name    string
+		changes map[string]string
+	}{
+		{name: "unknown setting", changes: map[string]string{"rateLimitRequestsPerMinute": "5", "rateLimitEnabled": "false"}},
+		{name: "non-numeric", changes: map[string]string{"rateLimitRequestsPerMinute": "5", "inventoryWorkerCount": "many"}},
+		{name: "zero workers", changes: map[string]string{"rateLimitRequestsPerMinute": "5", "inventoryWorkerCount": "0"}},
+		{name: "bad tier", changes: map[string]string{"rateLimitRequestsPerMinute": "5", "rateLimitTiers": "store"}},
+		{name: "negative burst", changes: map[string]string{"rateLimitRequestsPerMinute": "5", "rateLimitBurstSize": "-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := manager.Apply(tt.changes, "test", "api", "")
+			var validationErr *runtimeconfig.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a validation error, got %v", err)
+			}
+			if len(validationErr.Details) != 1 {
+				t.Errorf("Expected 1 detail, got %+v", validationErr.Details)
+			}
+			if limit := rateLimiter.Config().RequestsPerMinute; limit != 2 {
+				t.Errorf("Expected no change to be applied, limit is %d", limit)
+			}
+		})
+	}
+	if audit := manager.Audit(); len(audit) != 0 {
+		t.Errorf("Expected an empty audit trail, got %d entries", len(audit))
+	}
+}
+
+func TestManager_RejectsRateLimitsWhenDisabled(t *testing.T) {
+	manager, _, _ := newManager(t, false)
+
+	_, err := manager.Apply(map[string]string{"rateLimitRequestsPerMinute": "5"}, "test", "api", "")
+	var validationErr *runtimeconfig.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected a validation error, got %v", err)
+	}
+
+	if _, err := manager.Apply(map[string]string{"maxEventsInQueue": "500", "maxRetainedEvents": "2000"}, "test", "api", ""); err != nil {
+		t.Errorf("Expected event retention to change without a rate limiter: %v", err)
+	}
+}
+
+func TestManager_ResizesWorkerPoolWithoutLosingUpdates(t *testing.T) {
+	manager, _, service := newManager(t, true)
+
+	const updatesPerProduct = 50
+	var wg sync.WaitGroup
+	for _, productID := range []string{"SKU-001", "SKU-002"} {
+		wg.Add(1)
+		go func(productID string) {
+			defer wg.Done()
+			version := 1
+			for i := 0; i < updatesPerProduct; i++ {
+				result, err := service.UpdateInventory(productID, -1, version, fmt.Sprintf("%s-%d", productID, i), "store-1")
+				if err != nil || !result.Success {
+					t.Errorf("Update %d of %s failed: %v %+v", i, productID, err, result)
+					return
+				}
+				version = result.NewVersion
+			}
+		}(productID)
+	}
+
+	for _, count := range []string{"4", "1", "3"} {
+		if _, err := manager.Apply(map[string]string{"inventoryWorkerCount": count}, "test", "api", ""); err != nil {
+			t.Fatalf("Failed to resize to %s workers: %v", count, err)
+		}
+	}
+	wg.Wait()
+
+	if service.WorkerCount() != 3 {
+		t.Errorf("Expected 3 workers, got %d", service.WorkerCount())
+	}
+	for _, productID := range []string{"SKU-001", "SKU-002"} {
+		product, err := service.GetProduct(productID)
+		if err != nil {
+			t.Fatalf("Failed to get %s: %v", productID, err)
+		}
+		if product.Available != 1000-updatesPerProduct {
+			t.Errorf("Expected %s to have %d available, got %d", productID, 1000-updatesPerProduct, product.Available)
+		}
+	}
+}
+
+func TestEnvChanges(t *testing.T) {
+	changes := runtimeconfig.EnvChanges(map[string]string{
+		"RATE_LIMIT_REQUESTS_PER_MINUTE": "300",
+		"INVENTORY_WORKER_COUNT":         "4",
+		"PORT":                           "9090",
+	})
+
+	if len(changes) != 2 || changes["rateLimitRequestsPerMinute"] != "300" || changes["inventoryWorkerCount"] != "4" {
+		t.Errorf("Unexpected changes: %v", changes)
+	}
+}
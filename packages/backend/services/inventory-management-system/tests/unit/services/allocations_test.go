@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// allocate sets SKU-001's allocations through an admin update
+func allocate(t *testing.T, service *services.InventoryService, allocations map[string]int) models.AdminProductResult {
+	t.Helper()
+
+	response, err := service.AdminSetProducts([]models.AdminProductUpdate{{ProductID: "SKU-001", Allocations: allocations}})
+	require.NoError(t, err)
+	return response.Results[0]
+}
+
+// TestAllocations_AdminSet tests that admins set allocations within the stock
+func TestAllocations_AdminSet(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	result := allocate(t, service, map[string]int{"store-1": 8, "store-2": 3})
+	assert.False(t, result.Success, "eleven units allocated out of ten")
+	assert.Equal(t, services.ErrTypeValidation, result.ErrorType)
+
+	result = allocate(t, service, map[string]int{"store-1": 6, "store-2": 4, "store-3": 0})
+	require.True(t, result.Success)
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"store-1": 6, "store-2": 4}, product.Allocations)
+
+	require.True(t, allocate(t, service, map[string]int{}).Success)
+	product, err = service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Empty(t, product.Allocations)
+}
+
+// TestAllocations_Sales tests that a store's sales use up its own allocation
+// first and that allocations shrink, largest first, once the stock no longer
+// covers them
+func TestAllocations_Sales(t *testing.T) {
+	service := newReadCacheService(t, "false")
+	require.True(t, allocate(t, service, map[string]int{"store-1": 3, "store-2": 5, "store-3": 2}).Success)
+
+	// store-1 sells within its allocation; the others keep theirs
+	result, err := service.UpdateInventory("SKU-001", -2, 4, "alloc-1", "store-1")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 8, product.Available)
+	assert.Equal(t, map[string]int{"store-1": 1, "store-2": 5, "store-3": 2}, product.Allocations)
+
+	// store-4 has none, so its sale eats into the others once the unallocated
+	// units run out: 3 units left for 8 allocated
+	result, err = service.UpdateInventory("SKU-001", -5, 5, "alloc-2", "store-4")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	product, err = service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 3, product.Available)
+	assert.Equal(t, map[string]int{"store-1": 1, "store-3": 2}, product.Allocations)
+
+	// A transfer moves allocated units without touching the stock
+	transfer, err := service.TransferStock(context.Background(), models.TransferRequest{
+		ProductID: "SKU-001", FromStoreID: "store-3", ToStoreID: "store-1", Quantity: 2,
+		IdempotencyKey: "01J00000000000000000000003",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, transfer.FromAllocation)
+	assert.Equal(t, 3, transfer.ToAllocation)
+	product, err = service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 3, product.Available)
+	assert.Equal(t, map[string]int{"store-1": 3}, product.Allocations)
+
+	_, err = service.TransferStock(context.Background(), models.TransferRequest{
+		ProductID: "SKU-001", FromStoreID: "store-3", ToStoreID: "store-1", Quantity: 1,
+		IdempotencyKey: "01J00000000000000000000004",
+	})
+	var transferErr *services.TransferError
+	require.ErrorAs(t, err, &transferErr)
+	assert.Equal(t, services.ErrTypeInsufficientInventory, transferErr.Type)
+}
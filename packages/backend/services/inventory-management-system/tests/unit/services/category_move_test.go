@@ -0,0 +1,42 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+
+	"inventory-management-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdminSet_RecordsPreviousCategory tests that the event of an admin
+// category change names the category the product left, so stores filtering
+// on it can drop the product
+func TestAdminSet_RecordsPreviousCategory(t *testing.T) {
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3, "category": "phones", "lastUpdated": "2024-01-15T10:00:00Z"}
+	}, "metadata": {}}`
+	queue := openOutboxQueue(t, filepath.Join(t.TempDir(), "events.json"))
+	service := newOutboxService(t, t.TempDir(), fixture)
+	service.SetEventQueue(queue)
+
+	set := func(update models.AdminProductUpdate) {
+		t.Helper()
+		update.ProductID = "SKU-001"
+		response, err := service.AdminSetProducts([]models.AdminProductUpdate{update})
+		require.NoError(t, err)
+		require.True(t, response.Results[0].Success, response.Results[0].ErrorMessage)
+	}
+	name, sameCategory, newCategory := "Phone X", "Phones", "tv"
+	set(models.AdminProductUpdate{Name: &name})
+	set(models.AdminProductUpdate{Category: &sameCategory})
+	set(models.AdminProductUpdate{Category: &newCategory})
+
+	stored, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, stored, 3)
+	assert.Empty(t, stored[0].PreviousCategory, "the category did not change")
+	assert.Empty(t, stored[1].PreviousCategory, "categories compare case-insensitively")
+	assert.Equal(t, "Phones", stored[2].PreviousCategory)
+	assert.Equal(t, "tv", stored[2].Data.Category)
+}
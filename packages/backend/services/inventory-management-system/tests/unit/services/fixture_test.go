@@ -0,0 +1,31 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newFixtureService creates a service with the settings of cfg that loads the
+// data file fixture, written to dir. The idempotency cache settings default
+// to the ones of a real deployment. rt reports failures; stopping the service
+// is up to the caller.
+func newFixtureService(rt require.TestingT, dir, fixture string, cfg config.Config) *services.InventoryService {
+	cfg.SeedDataPath = filepath.Join(dir, "inventory_test_data.json")
+	require.NoError(rt, os.WriteFile(cfg.SeedDataPath, []byte(fixture), 0o644))
+
+	if cfg.IdempotencyCacheTTL == "" {
+		cfg.IdempotencyCacheTTL = "2m"
+	}
+	if cfg.IdempotencyCacheCleanupInterval == "" {
+		cfg.IdempotencyCacheCleanupInterval = "30s"
+	}
+
+	service, err := services.NewInventoryService(&cfg)
+	require.NoError(rt, err)
+	return service
+}
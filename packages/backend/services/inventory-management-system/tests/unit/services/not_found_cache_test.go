@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+
+	"inventory-management-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNotFoundCache_AnswersRepeatedMisses tests that a second lookup of an
+// unknown product is answered from the not-found cache
+func TestNotFoundCache_AnswersRepeatedMisses(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	_, err := service.GetProduct("SKU-404")
+	require.Error(t, err)
+	_, missing := service.GetProducts([]string{"SKU-001", "SKU-404"})
+	assert.Equal(t, []string{"SKU-404"}, missing)
+
+	stats := service.NotFoundCacheStats()
+	assert.Equal(t, true, stats["enabled"])
+	assert.Equal(t, 1, stats["entries"])
+	assert.Equal(t, int64(1), stats["hits"])
+}
+
+// TestNotFoundCache_CreationInvalidates tests that creating a product makes it
+// readable right away even though it was cached as missing
+func TestNotFoundCache_CreationInvalidates(t *testing.T) {
+	service := newReadCacheService(t, "true")
+
+	_, err := service.GetProduct("SKU-NEW")
+	require.Error(t, err)
+
+	response, err := service.AdminCreateProducts([]models.AdminProductCreate{
+		{ProductID: "SKU-NEW", Name: "Tablet", Available: 4, Price: 299.99},
+	})
+	require.NoError(t, err)
+	require.True(t, response.Results[0].Success)
+
+	product, err := service.GetProduct("SKU-NEW")
+	require.NoError(t, err)
+	assert.Equal(t, 4, product.Available)
+	assert.Equal(t, 0, service.NotFoundCacheStats()["entries"])
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/require"
+	"pgregory.net/rapid"
+)
+
+// occProducts are the products the property tests update
+var occProducts = []string{"SKU-001", "SKU-002"}
+
+// newOCCService creates a service in a fresh directory of t over the given
+// stock, each product at version 1. rt reports failures of the current run.
+func newOCCService(t *testing.T, rt require.TestingT, stock map[string]int) *services.InventoryService {
+	products := map[string]map[string]interface{}{}
+	for productID, available := range stock {
+		products[productID] = map[string]interface{}{
+			"productId": productID, "name": productID, "available": available, "version": 1,
+			"price": 9.99, "lastUpdated": "2026-01-01T00:00:00Z",
+		}
+	}
+	fixture, err := json.Marshal(map[string]interface{}{"products": products, "metadata": map[string]interface{}{}})
+	require.NoError(rt, err)
+
+	return newFixtureService(rt, t.TempDir(), string(fixture), config.Config{
+		EnableJSONPersistence:    "false",
+		InventoryWorkerCount:     "4",
+		InventoryQueueBufferSize: "100",
+	})
+}
+
+// drawStock draws the starting stock of every product
+func drawStock(rt *rapid.T) map[string]int {
+	stock := map[string]int{}
+	for _, productID := range occProducts {
+		stock[productID] = rapid.IntRange(0, 20).Draw(rt, productID+" stock")
+	}
+	return stock
+}
+
+// occState is what the model expects of a product
+type occState struct {
+	available int
+	version   int
+}
+
+// TestOCC_MatchesModel checks sequential updates with stale and future
+// versions, restocks stores may not send and retries against a model: only
+// an update at the current version that keeps the stock at or above zero is
+// applied, bumping the version by one, and a retry returns the first answer
+func TestOCC_MatchesModel(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		stock := drawStock(rt)
+		service := newOCCService(t, rt, stock)
+		defer service.Stop()
+
+		model := map[string]*occState{}
+		for productID, available := range stock {
+			model[productID] = &occState{available: available, version: 1}
+		}
+		answered := map[string]*modelAnswer{} // By idempotency key
+		var keys []string
+
+		steps := rapid.IntRange(1, 40).Draw(rt, "steps")
+		for step := 0; step < steps; step++ {
+			if len(keys) > 0 && rapid.IntRange(0, 4).Draw(rt, "retry") == 0 {
+				key := rapid.SampledFrom(keys).Draw(rt, "retried key")
+				first := answered[key]
+				result, err := service.UpdateInventory(first.productID(), first.delta(), first.version(), key, "store-1")
+				require.NoError(rt, err)
+				if !result.Replayed || result.Success != first.Success || result.NewQuantity != first.NewQuantity || result.NewVersion != first.NewVersion {
+					rt.Fatalf("Retry of %s answered %+v, first answer %+v", key, result, first.UpdateResult)
+				}
+				continue
+			}
+
+			productID := rapid.SampledFrom(occProducts).Draw(rt, "product")
+			delta := rapid.IntRange(-8, 2).Filter(func(d int) bool { return d != 0 }).Draw(rt, "delta")
+			state := model[productID]
+			version := state.version + rapid.SampledFrom([]int{0, 0, 0, -1, 1}).Draw(rt, "version skew")
+			key := fmt.Sprintf("key-%d", step)
+
+			result, err := service.UpdateInventory(productID, delta, version, key, "store-1")
+			require.NoError(rt, err)
+
+			// The checks run in the service's order; only an invalid request
+			// answers without the product's state
+			expectedError := ""
+			switch {
+			case version != state.version:
+				expectedError = services.ErrTypeVersionConflict
+			case delta > 0:
+				expectedError = services.ErrTypeInvalidRequest
+			case state.available+delta < 0:
+				expectedError = services.ErrTypeInsufficientInventory
+			default:
+				state.available += delta
+				state.version++
+			}
+			if result.Success != (expectedError == "") || result.ErrorType != expectedError || result.Replayed {
+				rt.Fatalf("Update of %s by %d at version %d answered %+v, expected error %q", productID, delta, version, result, expectedError)
+			}
+			if expectedError != services.ErrTypeInvalidRequest && (result.NewQuantity != state.available || result.NewVersion != state.version) {
+				rt.Fatalf("Expected %s at %+v after the update, answer was %+v", productID, *state, result)
+			}
+
+			answered[key] = &modelAnswer{UpdateResult: result, product: productID, change: delta, at: version}
+			keys = append(keys, key)
+		}
+
+		for productID, state := range model {
+			product, err := service.GetProduct(productID)
+			require.NoError(rt, err)
+			if product.Available != state.available || product.Version != state.version {
+				rt.Fatalf("Expected %s to end at %+v, got available %d, version %d", productID, *state, product.Available, product.Version)
+			}
+		}
+	})
+}
+
+// TestOCC_ConcurrentInvariants runs random sales and retries from concurrent
+// stores and checks the invariants of the result: stock never goes below
+// zero, every applied update got the next version of its product, the
+// applied deltas add up to the change in stock and no retry applied twice
+func TestOCC_ConcurrentInvariants(t *testing.T) {
+	rapid.Check(t, func(rt *rapid.T) {
+		stock := drawStock(rt)
+		service := newOCCService(t, rt, stock)
+		defer service.Stop()
+
+		// Draw every store's script up front; rapid cannot draw concurrently
+		type sale struct {
+			productID string
+			units     int
+			retry     bool
+		}
+		stores := rapid.IntRange(2, 4).Draw(rt, "stores")
+		scripts := make([][]sale, stores)
+		for i := range scripts {
+			scripts[i] = rapid.SliceOfN(rapid.Custom(func(rt *rapid.T) sale {
+				return sale{
+					productID: rapid.SampledFrom(occProducts).Draw(rt, "product"),
+					units:     rapid.IntRange(1, 6).Draw(rt, "units"),
+					retry:     rapid.IntRange(0, 3).Draw(rt, "retry") == 0,
+				}
+			}), 1, 15).Draw(rt, fmt.Sprintf("store %d sales", i))
+		}
+
+		var mu sync.Mutex
+		var answers []*modelAnswer
+		var failures []error
+		var wg sync.WaitGroup
+		for i, script := range scripts {
+			wg.Add(1)
+			go func(storeID string, script []sale) {
+				defer wg.Done()
+				for n, s := range script {
+					product, err := service.GetProduct(s.productID)
+					if err != nil {
+						mu.Lock()
+						failures = append(failures, err)
+						mu.Unlock()
+						return
+					}
+					key := fmt.Sprintf("%s-%d", storeID, n)
+					attempts := 1
+					if s.retry {
+						attempts = 2
+					}
+					for attempt := 0; attempt < attempts; attempt++ {
+						result, err := service.UpdateInventory(s.productID, -s.units, product.Version, key, storeID)
+						mu.Lock()
+						if err != nil {
+							failures = append(failures, err)
+						} else {
+							answers = append(answers, &modelAnswer{UpdateResult: result, product: s.productID, change: -s.units, at: product.Version, key: storeID + "/" + key})
+						}
+						mu.Unlock()
+					}
+				}
+			}(fmt.Sprintf("store-%d", i+1), script)
+		}
+		wg.Wait()
+		for _, err := range failures {
+			rt.Fatalf("Update failed: %v", err)
+		}
+
+		// Retries answer exactly like the update they repeat
+		first := map[string]*modelAnswer{}
+		for _, answer := range answers {
+			if !answer.Replayed {
+				if _, seen := first[answer.key]; seen {
+					rt.Fatalf("Update %s was answered without replay twice", answer.key)
+				}
+				first[answer.key] = answer
+			}
+		}
+		for _, answer := range answers {
+			original := first[answer.key]
+			if answer.Replayed && (original == nil || answer.Success != original.Success ||
+				answer.NewQuantity != original.NewQuantity || answer.NewVersion != original.NewVersion) {
+				rt.Fatalf("Retry of %s answered %+v, first answer %+v", answer.key, answer.UpdateResult, original)
+			}
+		}
+
+		for _, productID := range occProducts {
+			var applied []*modelAnswer
+			for _, answer := range first {
+				if answer.product == productID && answer.Success {
+					applied = append(applied, answer)
+				}
+			}
+			sort.Slice(applied, func(i, j int) bool { return applied[i].NewVersion < applied[j].NewVersion })
+
+			// Replaying the applied updates in version order reproduces every answer
+			available, version := stock[productID], 1
+			for _, answer := range applied {
+				if answer.at != version || answer.NewVersion != version+1 {
+					rt.Fatalf("%s: update %s read version %d and got %d, expected %d to %d", productID, answer.key, answer.at, answer.NewVersion, version, version+1)
+				}
+				available += answer.change
+				version++
+				if available < 0 || answer.NewQuantity != available {
+					rt.Fatalf("%s: update %s left %d units, replay gives %d", productID, answer.key, answer.NewQuantity, available)
+				}
+			}
+
+			product, err := service.GetProduct(productID)
+			require.NoError(rt, err)
+			if product.Available != available || product.Version != version {
+				rt.Fatalf("%s: expected %d units at version %d from %d applied updates, got %d at %d",
+					productID, available, version, len(applied), product.Available, product.Version)
+			}
+		}
+	})
+}
+
+// modelAnswer is an update's answer with the request that produced it
+type modelAnswer struct {
+	*services.UpdateResult
+	product string
+	change  int
+	at      int    // Version the update was sent at
+	key     string // Store and idempotency key
+}
+
+func (a *modelAnswer) productID() string { return a.product }
+func (a *modelAnswer) delta() int        { return a.change }
+func (a *modelAnswer) version() int      { return a.at }
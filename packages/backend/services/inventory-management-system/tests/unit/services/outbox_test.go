@@ -0,0 +1,163 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/events"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// soldEvent is the product_updated event of selling one of SKU-001's 10 units at version 3
+var soldEvent = models.Event{
+	EventType: models.EventTypeProductUpdated,
+	ProductID: "SKU-001",
+	Data:      models.ProductResponse{ProductID: "SKU-001", Name: "Phone", Available: 9, Version: 4, LastUpdated: "2024-01-15T12:00:00Z"},
+	Version:   4,
+	StoreID:   "store-1",
+	Delta:     -1,
+	Timestamp: "2024-01-15T12:00:00Z",
+}
+
+// newOutboxService creates a service over the data file fixture, saving it
+// to dir/data/inventory.json after every change
+func newOutboxService(t *testing.T, dir, fixture string) *services.InventoryService {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "data"), 0o755))
+	service := newFixtureService(t, dir, fixture, config.Config{
+		DataPath:                 filepath.Join(dir, "data", "inventory.json"),
+		EnableJSONPersistence:    "true",
+		PersistenceFlushInterval: "0",
+		InventoryWorkerCount:     "1",
+		InventoryQueueBufferSize: "100",
+	})
+	t.Cleanup(service.Stop)
+	return service
+}
+
+// openOutboxQueue opens the events file at path
+func openOutboxQueue(t *testing.T, path string) *events.EventQueue {
+	t.Helper()
+
+	queue, err := events.NewEventQueue(events.EventQueueConfig{
+		FilePath:  path,
+		MaxEvents: 1000,
+		Logger:    slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { queue.Close() })
+	return queue
+}
+
+// TestOutbox_RedeliversEventsMissingFromEventsFile tests that a change saved
+// with its outbox entry reaches the queue when the events file lost it, and
+// only once
+func TestOutbox_RedeliversEventsMissingFromEventsFile(t *testing.T) {
+	entry, err := json.Marshal(services.OutboxEntry{Seq: 1, Event: soldEvent})
+	require.NoError(t, err)
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 9, "version": 4, "lastUpdated": "2024-01-15T12:00:00Z"}
+	}, "metadata": {"outboxSeq": 1}, "outbox": [` + string(entry) + `]}`
+
+	eventsPath := filepath.Join(t.TempDir(), "events.json")
+	queue := openOutboxQueue(t, eventsPath)
+	newOutboxService(t, t.TempDir(), fixture).SetEventQueue(queue)
+
+	stored, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 4, stored[0].Version)
+	assert.Equal(t, soldEvent.Timestamp, stored[0].Timestamp, "the event keeps the time of the change")
+	assert.Equal(t, int64(1), queue.OutboxSeq())
+
+	// Once the events file holds the entry, delivering it again adds nothing
+	require.Eventually(t, func() bool { return queue.SavedOutboxSeq() == 1 }, time.Second, 5*time.Millisecond)
+	reopened := openOutboxQueue(t, eventsPath)
+	newOutboxService(t, t.TempDir(), fixture).SetEventQueue(reopened)
+	assert.Equal(t, 1, reopened.Stats().EventCount)
+}
+
+// TestOutbox_RollsDataForwardToEventsFile tests that a change whose event
+// reached the events file but not the data file is applied on startup
+func TestOutbox_RollsDataForwardToEventsFile(t *testing.T) {
+	file, err := json.Marshal(map[string]interface{}{
+		"events":     []models.Event{soldEvent},
+		"nextOffset": 1,
+		"outboxSeq":  1,
+	})
+	require.NoError(t, err)
+	eventsPath := filepath.Join(t.TempDir(), "events.json")
+	require.NoError(t, os.WriteFile(eventsPath, file, 0o644))
+
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3, "lastUpdated": "2024-01-15T10:00:00Z"}
+	}, "metadata": {}}`
+	queue := openOutboxQueue(t, eventsPath)
+	service := newOutboxService(t, t.TempDir(), fixture)
+	service.SetEventQueue(queue)
+
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 4, product.Version)
+	assert.Equal(t, 9, product.Available)
+
+	// The next change continues after the recovered one
+	result, err := service.UpdateInventory("SKU-001", -1, 4, "outbox-roll-forward", "store-1")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	stored, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, stored, 2)
+	assert.Equal(t, 5, stored[1].Version)
+	assert.Equal(t, int64(2), queue.OutboxSeq())
+}
+
+// TestOutbox_TrimsEntriesOnceEventsFileHoldsThem tests that the data file
+// keeps each change's entry only until the events file is saved with it
+func TestOutbox_TrimsEntriesOnceEventsFileHoldsThem(t *testing.T) {
+	dir := t.TempDir()
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3, "lastUpdated": "2024-01-15T10:00:00Z"}
+	}, "metadata": {}}`
+	queue := openOutboxQueue(t, filepath.Join(t.TempDir(), "events.json"))
+	service := newOutboxService(t, dir, fixture)
+	service.SetEventQueue(queue)
+
+	for version := 3; version < 5; version++ {
+		result, err := service.UpdateInventory("SKU-001", -1, version, fmt.Sprintf("outbox-trim-%d", version), "store-1")
+		require.NoError(t, err)
+		require.True(t, result.Success)
+	}
+	require.Eventually(t, func() bool { return queue.SavedOutboxSeq() == 2 }, time.Second, 5*time.Millisecond)
+
+	// The next save drops the entries the events file holds
+	result, err := service.UpdateInventory("SKU-001", -1, 5, "outbox-trim-5", "store-1")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	var saved struct {
+		Metadata struct {
+			OutboxSeq int64 `json:"outboxSeq"`
+		} `json:"metadata"`
+		Outbox []services.OutboxEntry `json:"outbox"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "data", "inventory.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, int64(3), saved.Metadata.OutboxSeq)
+	// Only the newest change may still be missing from the events file
+	for _, entry := range saved.Outbox {
+		assert.Equal(t, int64(3), entry.Seq)
+		assert.Equal(t, 6, entry.Event.Version)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"bytes"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer safe to share with a background logger
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.String()
+}
+
+// TestProductLockManager_Stats tests that held locks, waiters and the longest
+// hold are reported while a product lock is contended
+func TestProductLockManager_Stats(t *testing.T) {
+	plm := services.NewProductLockManager()
+	plm.WithProductReadLock("SKU-002", func() {})
+
+	lock := plm.LockProductForWrite("SKU-001")
+	waited := make(chan struct{})
+	go func() {
+		plm.WithProductWriteLock("SKU-001", func() {})
+		close(waited)
+	}()
+
+	require.Eventually(t, func() bool { return plm.GetLockStats().Waiters == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stats := plm.GetLockStats()
+	assert.Equal(t, 2, stats.TotalLocks)
+	assert.Equal(t, 1, stats.Held)
+	require.Len(t, stats.Holds, 1)
+	assert.Equal(t, "SKU-001", stats.Holds[0].ProductID)
+	assert.Equal(t, "write", stats.Holds[0].Mode)
+	assert.Equal(t, 1, stats.Holds[0].Waiters)
+	assert.GreaterOrEqual(t, stats.LongestHoldMs, int64(20))
+
+	plm.UnlockProductWrite("SKU-001", lock)
+	<-waited
+	stats = plm.GetLockStats()
+	assert.Equal(t, 0, stats.Held)
+	assert.Equal(t, 0, stats.Waiters)
+	assert.Empty(t, stats.Holds)
+}
+
+// TestProductLockManager_Watchdog tests that a hold past the threshold is
+// logged once with the goroutine stacks
+func TestProductLockManager_Watchdog(t *testing.T) {
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelError})))
+	defer slog.SetDefault(previous)
+
+	plm := services.NewProductLockManager()
+	stop := plm.StartWatchdog(20 * time.Millisecond)
+	defer stop()
+
+	lock := plm.LockProductForWrite("SKU-001")
+	require.Eventually(t, func() bool { return plm.GetLockStats().Stalls == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	plm.UnlockProductWrite("SKU-001", lock)
+
+	assert.Equal(t, int64(1), plm.GetLockStats().Stalls, "a hold is reported once")
+	output := logs.String()
+	assert.Contains(t, output, "product_id=SKU-001")
+	assert.Contains(t, output, "TestProductLockManager_Watchdog", "the dump includes the holder's stack")
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/currency"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestErrProductNotFound_Wrapped tests that every read and write of an unknown
+// product reports ErrProductNotFound, so callers need not match messages
+func TestErrProductNotFound_Wrapped(t *testing.T) {
+	service := newFixtureService(t, t.TempDir(), `{"products": {}, "metadata": {}}`, config.Config{
+		EnableJSONPersistence:    "false",
+		InventoryWorkerCount:     "1",
+		InventoryQueueBufferSize: "10",
+	})
+	t.Cleanup(service.Stop)
+	service.SetRatesProvider(currency.NewStaticRatesProvider("USD", nil))
+	ctx := context.Background()
+
+	_, err := service.GetProduct("SKU-404")
+	assert.ErrorIs(t, err, services.ErrProductNotFound, "GetProduct")
+	assert.ErrorContains(t, err, "SKU-404")
+
+	_, err = service.GetProductPrice("SKU-404", "USD")
+	assert.ErrorIs(t, err, services.ErrProductNotFound, "GetProductPrice")
+
+	_, err = service.ProductBackorders("SKU-404")
+	assert.ErrorIs(t, err, services.ErrProductNotFound, "ProductBackorders")
+
+	_, err = service.TransferStock(ctx, models.TransferRequest{
+		ProductID: "SKU-404", FromStoreID: "store-1", ToStoreID: "store-2", Quantity: 1,
+		IdempotencyKey: "01J00000000000000000000001",
+	})
+	assert.ErrorIs(t, err, services.ErrProductNotFound, "TransferStock")
+
+	_, err = service.CreateOrder(ctx, models.OrderRequest{
+		StoreID: "store-1", Lines: []models.OrderLine{{ProductID: "SKU-404", Quantity: 1}},
+		IdempotencyKey: "01J00000000000000000000002",
+	})
+	assert.ErrorIs(t, err, services.ErrProductNotFound, "CreateOrder")
+}
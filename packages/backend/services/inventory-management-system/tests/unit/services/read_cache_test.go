@@ -0,0 +1,133 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReadCacheService creates a service over a two-product fixture with the
+// read cache set as given
+func newReadCacheService(t *testing.T, readCache string) *services.InventoryService {
+	t.Helper()
+
+	fixture := `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3, "price": 99.99, "lastUpdated": "2024-01-15T10:00:00Z"},
+		"SKU-002": {"productId": "SKU-002", "name": "Laptop", "available": 5, "version": 7, "price": 999.99, "lastUpdated": "2024-01-15T11:00:00Z"}
+	}, "metadata": {}}`
+	service := newFixtureService(t, t.TempDir(), fixture, config.Config{
+		EnableJSONPersistence:    "false",
+		InventoryWorkerCount:     "2",
+		InventoryQueueBufferSize: "100",
+		ReadCacheEnabled:         readCache,
+	})
+	t.Cleanup(service.Stop)
+	return service
+}
+
+// TestReadCache_Disabled tests that reads work without the cache
+func TestReadCache_Disabled(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 3, product.Version)
+	assert.Equal(t, false, service.ReadCacheStats()["enabled"])
+}
+
+// TestReadCache_RefreshedOnWrites tests that cached reads follow updates,
+// admin changes, deletions and restores
+func TestReadCache_RefreshedOnWrites(t *testing.T) {
+	service := newReadCacheService(t, "true")
+
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 3, product.Version)
+	product, err = service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 3, product.Version)
+
+	stats := service.ReadCacheStats()
+	assert.Equal(t, int64(1), stats["hits"])
+	assert.Equal(t, int64(1), stats["misses"])
+	assert.Equal(t, int64(1), stats["entries"])
+
+	// A store update refreshes the cached product
+	result, err := service.UpdateInventory("SKU-001", -2, 3, "read-cache-1", "store-1")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+	product, err = service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 4, product.Version)
+	assert.Equal(t, 8, product.Available)
+
+	versions, missing := service.GetProductVersions([]string{"SKU-001", "SKU-404"})
+	assert.Equal(t, models.ProductVersion{Version: 4, Available: 8}, versions["SKU-001"])
+	assert.Equal(t, []string{"SKU-404"}, missing)
+
+	// Deleting a product removes it from the cache
+	_, err = service.AdminDeleteProducts([]string{"SKU-001"})
+	require.NoError(t, err)
+	_, err = service.GetProduct("SKU-001")
+	assert.Error(t, err)
+
+	// A restore replaces every cached product
+	_, err = service.GetProduct("SKU-002")
+	require.NoError(t, err)
+	_, err = service.Restore(func() ([]models.ProductResponse, error) {
+		return []models.ProductResponse{{ProductID: "SKU-002", Name: "Laptop", Available: 1, Version: 2}}, nil
+	})
+	require.NoError(t, err)
+	products, missing := service.GetProducts([]string{"SKU-002", "SKU-001"})
+	require.Len(t, products, 1)
+	assert.Equal(t, 2, products[0].Version)
+	assert.Equal(t, []string{"SKU-001"}, missing)
+	assert.Equal(t, int64(0), service.ReadCacheStats()["stale_reads"])
+}
+
+// TestReadCache_ConcurrentReadsAndWrites tests that cached reads never go
+// back to an older version while updates are applied
+func TestReadCache_ConcurrentReadsAndWrites(t *testing.T) {
+	service := newReadCacheService(t, "true")
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for version := 7; version < 12; version++ {
+			result, err := service.UpdateInventory("SKU-002", -1, version, fmt.Sprintf("read-cache-concurrent-%d", version), "store-1")
+			assert.NoError(t, err)
+			assert.True(t, result.Success)
+		}
+	}()
+
+	for reader := 0; reader < 4; reader++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lastVersion := 0
+			for i := 0; i < 500; i++ {
+				product, err := service.GetProduct("SKU-002")
+				if !assert.NoError(t, err) {
+					return
+				}
+				assert.GreaterOrEqual(t, product.Version, lastVersion)
+				assert.Equal(t, 5-(product.Version-7), product.Available)
+				lastVersion = product.Version
+			}
+		}()
+	}
+	wg.Wait()
+
+	product, err := service.GetProduct("SKU-002")
+	require.NoError(t, err)
+	assert.Equal(t, 12, product.Version)
+	assert.Equal(t, int64(0), service.ReadCacheStats()["max_version_lag"])
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStandby_ReloadsDataWithoutWriting tests that a follower serves the
+// products the leader saved and never saves the data file itself
+func TestStandby_ReloadsDataWithoutWriting(t *testing.T) {
+	dir := t.TempDir()
+	service := newOutboxService(t, dir, `{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 10, "version": 3}
+	}, "metadata": {}}`)
+	service.EnterStandby()
+	service.SetEventQueue(openOutboxQueue(t, filepath.Join(t.TempDir(), "events.json")))
+
+	// The leader sells three units and saves
+	leaderData := []byte(`{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 7, "version": 4}
+	}, "metadata": {"lastOffset": 1}}`)
+	dataPath := filepath.Join(dir, "data", "inventory.json")
+	require.NoError(t, os.WriteFile(dataPath, leaderData, 0o644))
+
+	reloaded, err := service.ReloadData()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 7, product.Available)
+	assert.Equal(t, 4, product.Version)
+
+	reloaded, err = service.ReloadData()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "an unchanged file is not reloaded")
+
+	require.NoError(t, service.Shutdown(context.Background()))
+	saved, err := os.ReadFile(dataPath)
+	require.NoError(t, err)
+	assert.Equal(t, string(leaderData), string(saved), "a follower leaves the leader's data file alone")
+}
+
+// TestStandby_PromoteRecoversOutbox tests that the outbox the previous leader
+// left behind is delivered once the follower takes over, not before
+func TestStandby_PromoteRecoversOutbox(t *testing.T) {
+	entry, err := json.Marshal(services.OutboxEntry{Seq: 1, Event: soldEvent})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	service := newOutboxService(t, dir, `{"products": {}, "metadata": {}}`)
+	service.EnterStandby()
+	queue := openOutboxQueue(t, filepath.Join(t.TempDir(), "events.json"))
+	service.SetEventQueue(queue)
+
+	// The leader saved a sale but crashed before its event reached the events file
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data", "inventory.json"), []byte(`{"products": {
+		"SKU-001": {"productId": "SKU-001", "name": "Phone", "available": 9, "version": 4}
+	}, "metadata": {"outboxSeq": 1}, "outbox": [`+string(entry)+`]}`), 0o644))
+	_, err = service.ReloadData()
+	require.NoError(t, err)
+	assert.Equal(t, 0, queue.Stats().EventCount, "a follower does not deliver the leader's outbox")
+
+	require.NoError(t, service.Promote())
+	assert.False(t, service.IsStandby())
+	stored, _, _ := queue.GetEvents(0, 10)
+	require.Len(t, stored, 1)
+	assert.Equal(t, 4, stored[0].Version)
+}
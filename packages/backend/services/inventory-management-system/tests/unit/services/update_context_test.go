@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpdateInventoryCtx_CancelledCallerIsNotQueued tests that an update whose
+// caller already went away is neither applied nor cached, so a retry with the
+// same idempotency key is applied
+func TestUpdateInventoryCtx_CancelledCallerIsNotQueued(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.UpdateInventoryCtx(ctx, "SKU-001", -1, 3, "cancelled-1", "store-1")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, services.ErrUpdateAbandoned))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	product, err := service.GetProduct("SKU-001")
+	require.NoError(t, err)
+	assert.Equal(t, 10, product.Available)
+	assert.Equal(t, 3, product.Version)
+
+	result, err := service.UpdateInventoryCtx(context.Background(), "SKU-001", -1, 3, "cancelled-1", "store-1")
+	require.NoError(t, err)
+	assert.True(t, result.Applied)
+	assert.False(t, result.Replayed)
+	assert.Equal(t, 9, result.NewQuantity)
+}
+
+// TestUpdateInventoryCtx_ExpiredDeadline tests that a passed deadline ends the
+// wait with the context's error instead of the fallback timeout
+func TestUpdateInventoryCtx_ExpiredDeadline(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	start := time.Now()
+	_, err := service.UpdateInventoryCtx(ctx, "SKU-002", 1, 7, "expired-1", "store-1")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, services.ErrUpdateAbandoned))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+
+	product, err := service.GetProduct("SKU-002")
+	require.NoError(t, err)
+	assert.Equal(t, 7, product.Version)
+}
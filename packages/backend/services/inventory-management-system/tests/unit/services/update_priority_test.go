@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseUpdatePriority tests lane names and rejection of unknown ones
+func TestParseUpdatePriority(t *testing.T) {
+	for name, expected := range map[string]services.UpdatePriority{
+		"checkout": services.PriorityCheckout,
+		" Sync ":   services.PrioritySync,
+		"BULK":     services.PriorityBulk,
+	} {
+		priority, err := services.ParseUpdatePriority(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, priority, name)
+	}
+
+	_, err := services.ParseUpdatePriority("urgent")
+	assert.Error(t, err)
+}
+
+// TestUpdatePriorityFromContext tests that updates default to the checkout lane
+func TestUpdatePriorityFromContext(t *testing.T) {
+	assert.Equal(t, services.PriorityCheckout, services.UpdatePriorityFromContext(context.Background()))
+
+	ctx := services.WithUpdatePriority(context.Background(), services.PriorityBulk)
+	assert.Equal(t, services.PriorityBulk, services.UpdatePriorityFromContext(ctx))
+}
+
+// TestParseLaneQuotas tests quota parsing, defaults for omitted lanes and that
+// checkout is never limited below the high-water mark
+func TestParseLaneQuotas(t *testing.T) {
+	quotas, err := services.ParseLaneQuotas("sync:90,bulk:20")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{100, 90, 20}, quotas)
+
+	quotas, err = services.ParseLaneQuotas("checkout:10,bulk:40")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{100, 75, 40}, quotas)
+
+	quotas, err = services.ParseLaneQuotas("")
+	require.NoError(t, err)
+	assert.Equal(t, [3]int{100, 75, 50}, quotas)
+
+	for _, invalid := range []string{"bulk", "bulk:0", "bulk:101", "bulk:half", "urgent:10"} {
+		quotas, err = services.ParseLaneQuotas(invalid)
+		assert.Error(t, err, invalid)
+		assert.Equal(t, [3]int{100, 75, 50}, quotas, invalid)
+	}
+}
+
+// TestLoadSheddingStats tests lane limits and that admitted updates are counted
+// in their lane
+func TestLoadSheddingStats(t *testing.T) {
+	service := newReadCacheService(t, "false")
+
+	ctx := services.WithUpdatePriority(context.Background(), services.PrioritySync)
+	result, err := service.UpdateInventoryCtx(ctx, "SKU-001", -1, 3, "priority-1", "store-1")
+	require.NoError(t, err)
+	require.True(t, result.Success)
+
+	stats := service.LoadSheddingStats()
+	assert.Equal(t, 100, stats["high_water_mark"])
+
+	lanes := stats["lanes"].(map[string]interface{})
+	sync := lanes["sync"].(map[string]interface{})
+	assert.Equal(t, 75, sync["depth_limit"])
+	assert.Equal(t, int64(1), sync["admitted"])
+	assert.Equal(t, int64(0), sync["shed"])
+
+	bulk := lanes["bulk"].(map[string]interface{})
+	assert.Equal(t, 50, bulk["depth_limit"])
+	assert.Equal(t, int64(0), bulk["admitted"])
+}
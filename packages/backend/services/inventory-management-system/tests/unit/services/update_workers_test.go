@@ -0,0 +1,318 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// workerPoolStock is the stock of every product in the worker pool fixture
+const workerPoolStock = 100000
+
+// newWorkerPoolService creates a service over products SKU-0000 onwards with
+// the queue and persistence settings of cfg. Every save rewrites the whole
+// catalog, so the larger it is the longer updates saved one by one take.
+// It returns the service and the path of its data file.
+func newWorkerPoolService(t *testing.T, productCount int, cfg config.Config) (*services.InventoryService, string) {
+	t.Helper()
+
+	products := make([]string, productCount)
+	for i := range products {
+		products[i] = fmt.Sprintf(`"SKU-%04d": {"productId": "SKU-%04d", "name": "Product %d", "available": %d, "version": 1, "lastUpdated": "2024-01-15T10:00:00Z"}`,
+			i, i, i, workerPoolStock)
+	}
+	fixture := `{"products": {` + strings.Join(products, ",") + `}, "metadata": {}}`
+
+	dir := t.TempDir()
+	cfg.DataPath = filepath.Join(dir, "inventory.json")
+	cfg.EnableJSONPersistence = "true"
+	service := newFixtureService(t, dir, fixture, cfg)
+	t.Cleanup(service.Stop)
+	return service, cfg.DataPath
+}
+
+// sell submits a one-unit sale of productID that skips the version check
+func sell(ctx context.Context, service *services.InventoryService, productID, key string) (*services.UpdateResult, error) {
+	zero := 0
+	ctx = services.WithUpdateCondition(ctx, services.UpdateCondition{MinAvailable: &zero})
+	return service.UpdateInventoryCtx(ctx, productID, -1, 0, key, "store-1")
+}
+
+// salesTimeout bounds every sale in these tests. It is well past the
+// service's default update timeout, so a slow run under -race still has
+// every sale answered by the worker rather than timed out by the caller.
+const salesTimeout = 2 * time.Minute
+
+// sellConcurrently submits count sales of productID at once and returns
+// their errors as they finish
+func sellConcurrently(ctx context.Context, service *services.InventoryService, productID string, count int) <-chan error {
+	errs := make(chan error, count)
+	for i := 0; i < count; i++ {
+		go func(i int) {
+			_, err := sell(ctx, service, productID, fmt.Sprintf("%s-%d", productID, i))
+			errs <- err
+		}(i)
+	}
+	return errs
+}
+
+// queued returns the number of updates waiting in every shard
+func queued(service *services.InventoryService) int {
+	total := 0
+	for _, depth := range service.ShardQueueDepths() {
+		total += depth
+	}
+	return total
+}
+
+// savedStock returns the stock of productID in the data file at path
+func savedStock(t *testing.T, path, productID string) int {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return workerPoolStock
+	}
+	require.NoError(t, err)
+	var data services.InventoryData
+	require.NoError(t, json.Unmarshal(content, &data))
+	return data.Products[productID].Available
+}
+
+// TestShutdown_RejectsUpdatesQueuedPastDeadline tests that a shutdown whose
+// deadline passes lets the workers finish the update in hand, answers every
+// update still queued with ErrServiceDraining and persists what was applied
+func TestShutdown_RejectsUpdatesQueuedPastDeadline(t *testing.T) {
+	service, dataPath := newWorkerPoolService(t, 200, config.Config{
+		PersistenceFlushInterval: "0",
+		InventoryWorkerCount:     "1",
+		InventoryQueueBufferSize: "1000",
+	})
+	ctx, cancelSales := context.WithTimeout(context.Background(), salesTimeout)
+	defer cancelSales()
+
+	const sales = 300
+	errs := sellConcurrently(ctx, service, "SKU-0000", sales)
+	require.Eventually(t, func() bool { return queued(service) >= 20 }, 5*time.Second, time.Millisecond)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	err := service.Shutdown(expired)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	applied, draining := 0, 0
+	for i := 0; i < sales; i++ {
+		select {
+		case err := <-errs:
+			switch {
+			case err == nil:
+				applied++
+			case errors.Is(err, services.ErrServiceDraining):
+				draining++
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		case <-time.After(30 * time.Second):
+			t.Fatalf("%d of %d sales are still waiting after shutdown", sales-i, sales)
+		}
+	}
+	assert.Positive(t, draining, "the updates still queued are rejected")
+	assert.Zero(t, queued(service))
+
+	product, err := service.GetProduct("SKU-0000")
+	require.NoError(t, err)
+	assert.Equal(t, workerPoolStock-applied, product.Available)
+	assert.Equal(t, product.Available, savedStock(t, dataPath, "SKU-0000"), "every applied sale reached the data file")
+}
+
+// TestWriteBehindPersister_BatchesSaves tests that applied updates reach the
+// data file once a batch is full, and that shutdown flushes a partial batch
+func TestWriteBehindPersister_BatchesSaves(t *testing.T) {
+	service, dataPath := newWorkerPoolService(t, 1, config.Config{
+		PersistenceFlushInterval:   "1h",
+		PersistenceFlushMaxUpdates: "3",
+		InventoryWorkerCount:       "1",
+		InventoryQueueBufferSize:   "10",
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := sell(context.Background(), service, "SKU-0000", fmt.Sprintf("batch-%d", i))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, workerPoolStock, savedStock(t, dataPath, "SKU-0000"), "a partial batch waits for the interval")
+
+	_, err := sell(context.Background(), service, "SKU-0000", "batch-2")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return savedStock(t, dataPath, "SKU-0000") == workerPoolStock-3
+	}, 5*time.Second, 10*time.Millisecond, "a full batch is saved")
+
+	_, err = sell(context.Background(), service, "SKU-0000", "batch-3")
+	require.NoError(t, err)
+	require.NoError(t, service.Shutdown(context.Background()))
+	assert.Equal(t, workerPoolStock-4, savedStock(t, dataPath, "SKU-0000"), "shutdown flushes the partial batch")
+}
+
+// TestUpdateShards_HotProductDoesNotBlockOthers tests that a product's
+// updates queue on a single shard and that products on other shards are
+// applied while that backlog remains
+func TestUpdateShards_HotProductDoesNotBlockOthers(t *testing.T) {
+	service, _ := newWorkerPoolService(t, 8, config.Config{
+		PersistenceFlushInterval: "0",
+		InventoryWorkerCount:     "4",
+		InventoryQueueBufferSize: "1000",
+	})
+	require.Len(t, service.ShardQueueDepths(), 4)
+	ctx, cancelSales := context.WithTimeout(context.Background(), salesTimeout)
+	defer cancelSales()
+
+	// Record every shard seen holding updates while only the hot product is
+	// sold. Sampling runs for the whole burst, so it does not matter how
+	// quickly the backlog builds or drains.
+	var mu sync.Mutex
+	backlogged := map[int]bool{}
+	sampling := true
+	sample := func() int {
+		depths := service.ShardQueueDepths()
+		mu.Lock()
+		defer mu.Unlock()
+		total := 0
+		for shard, depth := range depths {
+			if sampling && depth > 0 {
+				backlogged[shard] = true
+			}
+			total += depth
+		}
+		return total
+	}
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				sample()
+			}
+		}
+	}()
+
+	const hotSales = 300
+	var hotPending atomic.Int64
+	hotPending.Store(hotSales)
+	hotErrs := make(chan error, hotSales)
+	go func() {
+		errs := sellConcurrently(ctx, service, "SKU-0000", hotSales)
+		for i := 0; i < hotSales; i++ {
+			err := <-errs
+			hotPending.Add(-1)
+			hotErrs <- err
+		}
+	}()
+	require.Eventually(t, func() bool { return sample() >= 10 }, 30*time.Second, time.Millisecond)
+
+	mu.Lock()
+	sampling = false
+	assert.Len(t, backlogged, 1, "one product's updates share a shard")
+	mu.Unlock()
+
+	// Sold together, the products on idle shards finish while the hot
+	// product's sales are still outstanding
+	const others = 7
+	passed := make(chan bool, others)
+	for i := 1; i <= others; i++ {
+		go func(i int) {
+			result, err := sell(ctx, service, fmt.Sprintf("SKU-%04d", i), fmt.Sprintf("other-%d", i))
+			assert.NoError(t, err)
+			assert.True(t, err == nil && result.Applied)
+			passed <- hotPending.Load() > 0
+		}(i)
+	}
+	passedBacklog := false
+	for i := 0; i < others; i++ {
+		passedBacklog = <-passed || passedBacklog
+	}
+	assert.True(t, passedBacklog, "updates to other products are applied while the hot product's backlog remains")
+
+	for i := 0; i < hotSales; i++ {
+		require.NoError(t, <-hotErrs)
+	}
+	close(stop)
+	<-sampled
+}
+
+// TestUpdateQueue_RejectsPastHighWaterMark tests that updates arriving while
+// their shard holds the high-water mark are rejected at once with
+// ErrQueueSaturated, and the shard never grows past it
+func TestUpdateQueue_RejectsPastHighWaterMark(t *testing.T) {
+	service, _ := newWorkerPoolService(t, 2000, config.Config{
+		PersistenceFlushInterval:    "0",
+		InventoryWorkerCount:        "1",
+		InventoryQueueBufferSize:    "100",
+		InventoryQueueHighWaterMark: "5",
+	})
+	require.Equal(t, 5, service.QueueHighWaterMark())
+
+	ctx, cancelSales := context.WithTimeout(context.Background(), salesTimeout)
+	defer cancelSales()
+
+	const sales = 100
+	errs := sellConcurrently(ctx, service, "SKU-0000", sales)
+
+	var mu sync.Mutex
+	deepest := 0
+	stop := make(chan struct{})
+	sampled := make(chan struct{})
+	go func() {
+		defer close(sampled)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			depth := queued(service)
+			mu.Lock()
+			deepest = max(deepest, depth)
+			mu.Unlock()
+		}
+	}()
+
+	// Once the backlog fills the shard, a new update is turned away
+	require.Eventually(t, func() bool { return queued(service) >= 5 }, 5*time.Second, time.Millisecond)
+	_, err := sell(ctx, service, "SKU-0000", "past-mark")
+	saturated := 0
+	if errors.Is(err, services.ErrQueueSaturated) {
+		saturated++
+	}
+	for i := 0; i < sales; i++ {
+		if err := <-errs; err != nil {
+			require.True(t, errors.Is(err, services.ErrQueueSaturated), "unexpected error: %v", err)
+			saturated++
+		}
+	}
+	close(stop)
+	<-sampled
+
+	assert.Positive(t, saturated, "updates past the high-water mark are rejected")
+	mu.Lock()
+	defer mu.Unlock()
+	assert.LessOrEqual(t, deepest, 5)
+}
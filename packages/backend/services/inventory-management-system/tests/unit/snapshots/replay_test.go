@@ -0,0 +1,53 @@
+package snapshots
+
+import (
+	"errors"
+	"testing"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/snapshots"
+)
+
+func event(offset int64, eventType, productID string, data models.ProductResponse) models.Event {
+	return models.Event{Offset: offset, EventType: eventType, ProductID: productID, Data: data}
+}
+
+func TestReplay(t *testing.T) {
+	snapshot := []models.ProductResponse{product("SKU-001", 10, 1, 5), product("SKU-002", 7, 3, 8)}
+	events := []models.Event{
+		event(10, models.EventTypeProductUpdated, "SKU-001", product("SKU-001", 8, 2, 5)),
+		event(11, models.EventTypeProductCreated, "SKU-003", product("SKU-003", 4, 1, 2)),
+		event(12, models.EventTypeProductDeleted, "SKU-002", product("SKU-002", 7, 3, 8)),
+		event(13, models.EventTypeProductUpdated, "SKU-001", product("SKU-001", 6, 3, 5)),
+	}
+
+	restored, err := snapshots.Replay(snapshot, events)
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Fatalf("Expected 2 products, got %+v", restored)
+	}
+	if restored[0].ProductID != "SKU-001" || restored[0].Available != 6 || restored[0].Version != 3 {
+		t.Errorf("Unexpected SKU-001: %+v", restored[0])
+	}
+	if restored[1].ProductID != "SKU-003" || restored[1].Available != 4 {
+		t.Errorf("Unexpected SKU-003: %+v", restored[1])
+	}
+
+	// The snapshot itself is left untouched
+	if snapshot[0].Available != 10 {
+		t.Errorf("Replay modified the snapshot: %+v", snapshot[0])
+	}
+}
+
+func TestReplay_StopsAtEarlierRestore(t *testing.T) {
+	events := []models.Event{
+		event(10, models.EventTypeProductUpdated, "SKU-001", product("SKU-001", 8, 2, 5)),
+		event(11, models.EventTypeSystemRestored, "", models.ProductResponse{}),
+	}
+
+	if _, err := snapshots.Replay(nil, events); !errors.Is(err, snapshots.ErrRestoreBoundary) {
+		t.Errorf("Expected ErrRestoreBoundary, got %v", err)
+	}
+}
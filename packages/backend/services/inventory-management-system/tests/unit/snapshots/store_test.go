@@ -0,0 +1,114 @@
+package snapshots
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/snapshots"
+)
+
+func product(id string, available, version int, price float64) models.ProductResponse {
+	return models.ProductResponse{ProductID: id, Name: "Product " + id, Available: available, Version: version, Price: price}
+}
+
+func TestStore_CreateListGet(t *testing.T) {
+	dir := t.TempDir()
+	store, err := snapshots.NewStore(filepath.Join(dir, "snapshots"))
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	products := []models.ProductResponse{product("SKU-001", 10, 1, 5), product("SKU-002", 7, 3, 8)}
+	first, err := store.Create("end of day", products, 42)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+	if first.ProductCount != 2 || first.TotalAvailable != 17 || first.LastOffset != 42 || first.SizeBytes == 0 {
+		t.Errorf("Unexpected snapshot info: %+v", first)
+	}
+	second, err := store.Create("later", products[:1], 43)
+	if err != nil {
+		t.Fatalf("Failed to create snapshot: %v", err)
+	}
+
+	infos, err := store.List()
+	if err != nil {
+		t.Fatalf("Failed to list snapshots: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("Expected 2 snapshots, got %d", len(infos))
+	}
+	if infos[0].ID < infos[1].ID {
+		t.Errorf("Expected newest first, got %s before %s", infos[0].ID, infos[1].ID)
+	}
+	if infos[0].ID != second.ID && infos[1].ID != second.ID {
+		t.Errorf("Expected %s to be listed", second.ID)
+	}
+
+	info, loaded, err := store.Get(first.ID)
+	if err != nil {
+		t.Fatalf("Failed to get snapshot: %v", err)
+	}
+	if info.Name != "end of day" || len(loaded) != 2 || loaded[1].Available != 7 {
+		t.Errorf("Unexpected snapshot: %+v %+v", info, loaded)
+	}
+
+	// The products are stored compressed
+	raw, err := os.ReadFile(filepath.Join(dir, "snapshots", first.ID+".json.gz"))
+	if err != nil {
+		t.Fatalf("Failed to read snapshot file: %v", err)
+	}
+	if len(raw) < 2 || raw[0] != 0x1f || raw[1] != 0x8b {
+		t.Error("Expected a gzip snapshot file")
+	}
+}
+
+func TestStore_GetUnknown(t *testing.T) {
+	store, err := snapshots.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	for _, id := range []string{"20250101T000000Z-deadbeef", "../events", ""} {
+		if _, _, err := store.Get(id); !errors.Is(err, snapshots.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	info := models.SnapshotInfo{ID: "20250101T000000Z-deadbeef", LastOffset: 10}
+	snapshot := []models.ProductResponse{
+		product("SKU-001", 10, 1, 5),
+		product("SKU-002", 7, 3, 8),
+		product("SKU-003", 4, 1, 2),
+	}
+	current := []models.ProductResponse{
+		product("SKU-001", 10, 1, 5), // unchanged
+		product("SKU-002", 2, 5, 8),  // sold 5
+		product("SKU-004", 6, 1, 3),  // created
+	}
+
+	diff := snapshots.Diff(info, snapshot, current, 15)
+
+	if diff.Unchanged != 1 || len(diff.Changed) != 1 {
+		t.Fatalf("Unexpected diff: %+v", diff)
+	}
+	change := diff.Changed[0]
+	if change.ProductID != "SKU-002" || change.AvailableDelta != -5 || change.SnapshotVersion != 3 || change.CurrentVersion != 5 {
+		t.Errorf("Unexpected change: %+v", change)
+	}
+	if len(diff.Added) != 1 || diff.Added[0] != "SKU-004" || len(diff.Removed) != 1 || diff.Removed[0] != "SKU-003" {
+		t.Errorf("Unexpected added/removed: %v %v", diff.Added, diff.Removed)
+	}
+	// -5 sold, +6 created, -4 removed
+	if diff.AvailableDelta != -3 {
+		t.Errorf("Expected net change -3, got %d", diff.AvailableDelta)
+	}
+	if diff.SnapshotOffset != 10 || diff.CurrentOffset != 15 {
+		t.Errorf("Unexpected offsets: %d %d", diff.SnapshotOffset, diff.CurrentOffset)
+	}
+}
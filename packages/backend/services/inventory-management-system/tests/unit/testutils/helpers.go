@@ -1,6 +1,7 @@
 package testutils
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -74,9 +75,13 @@ func CreateTestUpdateRequest(storeID, productID string, delta, version int) *Tes
 	}
 }
 
-// GenerateTestIdempotencyKey generates a unique idempotency key for testing
+// GenerateTestIdempotencyKey generates a unique UUIDv4 idempotency key for testing
 func GenerateTestIdempotencyKey() string {
-	return fmt.Sprintf("test-key-%d", time.Now().UnixNano())
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // AssertHTTPResponse asserts HTTP response status and content type
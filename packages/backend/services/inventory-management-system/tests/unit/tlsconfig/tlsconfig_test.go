@@ -0,0 +1,164 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inventory-management-api/internal/config"
+	"inventory-management-api/internal/tlsconfig"
+)
+
+// writeSelfSigned writes a self-signed certificate and key for commonName and
+// returns their paths
+func writeSelfSigned(t *testing.T, dir, name, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func servedCommonName(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+
+	cert, err := tlsConfig.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("Failed to get certificate: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "server", "localhost")
+	caFile, _ := writeSelfSigned(t, dir, "ca", "store-ca")
+
+	tests := []struct {
+		name       string
+		cfg        config.Config
+		wantErr    bool
+		wantNil    bool
+		clientAuth tls.ClientAuthType
+	}{
+		{name: "disabled", cfg: config.Config{}, wantNil: true},
+		{name: "server only", cfg: config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile}, clientAuth: tls.NoClientCert},
+		{name: "optional client certificates", cfg: config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile, TLSClientAuth: "optional"}, clientAuth: tls.VerifyClientCertIfGiven},
+		{name: "required client certificates", cfg: config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile, TLSClientAuth: "require"}, clientAuth: tls.RequireAndVerifyClientCert},
+		{name: "missing key", cfg: config.Config{TLSCertFile: certFile}, wantErr: true},
+		{name: "client CA without certificate", cfg: config.Config{TLSClientCAFile: caFile}, wantErr: true},
+		{name: "unknown client auth mode", cfg: config.Config{TLSCertFile: certFile, TLSKeyFile: keyFile, TLSClientCAFile: caFile, TLSClientAuth: "sometimes"}, wantErr: true},
+		{name: "unreadable certificate", cfg: config.Config{TLSCertFile: filepath.Join(dir, "missing.crt"), TLSKeyFile: keyFile}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tlsConfig, err := tlsconfig.ServerConfig(&tt.cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantNil {
+				if tlsConfig != nil {
+					t.Error("Expected no TLS configuration")
+				}
+				return
+			}
+			if tlsConfig.ClientAuth != tt.clientAuth {
+				t.Errorf("Expected client auth %v, got %v", tt.clientAuth, tlsConfig.ClientAuth)
+			}
+			if servedCommonName(t, tlsConfig) != "localhost" {
+				t.Error("Expected the configured certificate to be served")
+			}
+		})
+	}
+}
+
+func TestServerConfig_ReloadsRotatedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSigned(t, dir, "server", "before-rotation")
+
+	tlsConfig, err := tlsconfig.ServerConfig(&config.Config{
+		TLSCertFile:       certFile,
+		TLSKeyFile:        keyFile,
+		TLSReloadInterval: "1ms",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if name := servedCommonName(t, tlsConfig); name != "before-rotation" {
+		t.Fatalf("Expected before-rotation, got %s", name)
+	}
+
+	// Rotate the pair in place; move the modification time forward so the
+	// change is seen even on filesystems with coarse timestamps
+	writeSelfSigned(t, dir, "server", "after-rotation")
+	later := time.Now().Add(time.Minute)
+	for _, file := range []string{certFile, keyFile} {
+		if err := os.Chtimes(file, later, later); err != nil {
+			t.Fatalf("Failed to touch %s: %v", file, err)
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if name := servedCommonName(t, tlsConfig); name != "after-rotation" {
+		t.Errorf("Expected after-rotation, got %s", name)
+	}
+
+	// A broken rotation keeps serving the last good certificate
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("Failed to corrupt certificate: %v", err)
+	}
+	evenLater := later.Add(time.Minute)
+	os.Chtimes(certFile, evenLater, evenLater)
+	time.Sleep(5 * time.Millisecond)
+
+	if name := servedCommonName(t, tlsConfig); name != "after-rotation" {
+		t.Errorf("Expected the previous certificate to stay in use, got %s", name)
+	}
+}
@@ -0,0 +1,248 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"inventory-management-api/internal/models"
+	"inventory-management-api/internal/validation"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate_ValidCreateRequest(t *testing.T) {
+	req := models.AdminCreateRequest{
+		Products: []models.AdminProductCreate{
+			{
+				ProductID: "SKU-1",
+				Name:      "Widget",
+				Available: 5,
+				Price:     9.99,
+				Prices:    []models.Money{{Amount: 999, Currency: "USD"}, {Amount: 899, Currency: "eur"}},
+			},
+		},
+	}
+
+	assert.Empty(t, validation.Validate(req))
+}
+
+func TestValidate_IndexedFieldPaths(t *testing.T) {
+	products := make([]models.AdminProductCreate, 12)
+	for i := range products {
+		products[i] = models.AdminProductCreate{ProductID: "SKU", Name: "Widget"}
+	}
+	products[11].Available = -1
+
+	details := validation.Validate(models.AdminCreateRequest{Products: products})
+
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "products[11].available", Issue: "available cannot be negative"},
+	}, details)
+}
+
+func TestValidate_RequiredAndPriceRules(t *testing.T) {
+	req := models.AdminCreateRequest{
+		Products: []models.AdminProductCreate{
+			{
+				ProductID: " ",
+				Prices: []models.Money{
+					{Amount: 100, Currency: "USD"},
+					{Amount: -5, Currency: "XX"},
+					{Amount: 200, Currency: "usd"},
+				},
+			},
+		},
+	}
+
+	details := validation.Validate(req)
+
+	fields := make([]string, 0, len(details))
+	for _, detail := range details {
+		fields = append(fields, detail.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		"products[0].productId",
+		"products[0].name",
+		"products[0].prices[2]",
+		"products[0].prices[1].amount",
+		"products[0].prices[1].currency",
+	}, fields)
+}
+
+func TestValidate_StructLevelRules(t *testing.T) {
+	req := models.AdminSetRequest{
+		Products: []models.AdminProductUpdate{{ProductID: "SKU-1"}},
+	}
+
+	details := validation.Validate(req)
+
+	assert.Len(t, details, 1)
+	assert.Equal(t, "products[0].fields", details[0].Field)
+}
+
+func TestValidate_Allocations(t *testing.T) {
+	assert.Empty(t, validation.Validate(models.AdminProductUpdate{ProductID: "SKU-1", Allocations: map[string]int{}}))
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "allocations", Issue: `Invalid store ID " "`},
+		{Field: "allocations.store-2", Issue: "allocation cannot be negative"},
+	}, validation.Validate(models.AdminProductUpdate{ProductID: "SKU-1", Allocations: map[string]int{"store-1": 2, "store-2": -1, " ": 1}}))
+}
+
+func TestValidate_PointerRulesOnlyWhenSet(t *testing.T) {
+	negative := -1
+	name := "Widget"
+	category := "electronics"
+
+	assert.Empty(t, validation.Validate(models.AdminProductUpdate{ProductID: "SKU-1", Name: &name}))
+	assert.Empty(t, validation.Validate(models.AdminProductUpdate{ProductID: "SKU-1", Category: &category}))
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "available", Issue: "available cannot be negative"},
+	}, validation.Validate(models.AdminProductUpdate{ProductID: "SKU-1", Available: &negative}))
+}
+
+func TestValidate_DiveRules(t *testing.T) {
+	details := validation.Validate(models.AdminDeleteRequest{ProductIDs: []string{"SKU-1", ""}})
+
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "productIds[1]", Issue: "productIds is required"},
+	}, details)
+}
+
+func TestValidate_IdempotencyKeyFormat(t *testing.T) {
+	tests := []struct {
+		key   string
+		valid bool
+	}{
+		{"3b241101-e2bb-4255-8caf-4136c566a962", true},
+		{"3B241101-E2BB-4255-8CAF-4136C566A962", true},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", true},
+		{"01arz3ndektsv4rrffq69g5fav", true},
+		{"3b241101-e2bb-1255-8caf-4136c566a962", false}, // UUIDv1
+		{"3b241101-e2bb-4255-ccaf-4136c566a962", false}, // Wrong variant
+		{"3b241101e2bb42558caf4136c566a962", false},     // No dashes
+		{"81ARZ3NDEKTSV4RRFFQ69G5FAV", false},           // Timestamp overflows 48 bits
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", false},           // U is not Crockford base32
+		{"store-1-checkout-42", false},
+	}
+	for _, tt := range tests {
+		update := models.ProductUpdate{ProductID: "SKU-1", IdempotencyKey: tt.key}
+		details := validation.Validate(update)
+		if tt.valid {
+			assert.Empty(t, details, tt.key)
+		} else {
+			assert.Equal(t, []models.ErrorDetail{
+				{Field: "idempotencyKey", Issue: "idempotencyKey must be a UUIDv4 or a ULID"},
+			}, details, tt.key)
+		}
+	}
+}
+
+func TestValidate_AssetRules(t *testing.T) {
+	checksum := "sha256:" + strings.Repeat("a1", 32)
+	valid := models.ProductAsset{Type: "image", URL: "https://cdn.example.com/a.jpg", Checksum: checksum}
+	assert.Empty(t, validation.Validate(valid))
+
+	tests := []struct {
+		name  string
+		asset models.ProductAsset
+		field string
+	}{
+		{"unknown type", models.ProductAsset{Type: "banner", URL: valid.URL, Checksum: checksum}, "type"},
+		{"relative url", models.ProductAsset{Type: "image", URL: "/a.jpg", Checksum: checksum}, "url"},
+		{"ftp url", models.ProductAsset{Type: "image", URL: "ftp://cdn.example.com/a.jpg", Checksum: checksum}, "url"},
+		{"url with credentials", models.ProductAsset{Type: "image", URL: "https://user:pw@cdn.example.com/a.jpg", Checksum: checksum}, "url"},
+		{"short checksum", models.ProductAsset{Type: "image", URL: valid.URL, Checksum: "sha256:abc"}, "checksum"},
+		{"other algorithm", models.ProductAsset{Type: "image", URL: valid.URL, Checksum: "md5:" + strings.Repeat("a1", 16)}, "checksum"},
+	}
+	for _, tt := range tests {
+		details := validation.Validate(tt.asset)
+		if assert.Len(t, details, 1, tt.name) {
+			assert.Equal(t, tt.field, details[0].Field, tt.name)
+		}
+	}
+
+	details := validation.Validate(models.ProductAssetsRequest{Assets: []models.ProductAsset{valid, valid}})
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, "assets[1]", details[0].Field)
+	}
+}
+
+func TestValidate_StockPolicyRules(t *testing.T) {
+	low, high, negative := 5, 10, -1
+	valid := models.StockPolicy{MinStock: &low, MaxStock: &high, AllowBackorder: true, MaxBackorder: 3}
+	assert.Empty(t, validation.Validate(valid))
+
+	tests := []struct {
+		name   string
+		policy models.StockPolicy
+		field  string
+	}{
+		{"negative minimum", models.StockPolicy{MinStock: &negative}, "minStock"},
+		{"max below min", models.StockPolicy{MinStock: &high, MaxStock: &low}, "maxStock"},
+		{"backorder without bound", models.StockPolicy{AllowBackorder: true}, "maxBackorder"},
+		{"bound without backorder", models.StockPolicy{MaxBackorder: 2}, "maxBackorder"},
+	}
+	for _, tt := range tests {
+		details := validation.Validate(tt.policy)
+		if assert.Len(t, details, 1, tt.name) {
+			assert.Equal(t, tt.field, details[0].Field, tt.name)
+		}
+	}
+
+	details := validation.Validate(models.CategoryRequest{Name: "Phones", StockPolicy: &models.StockPolicy{AllowBackorder: true}})
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, "stockPolicy.maxBackorder", details[0].Field)
+	}
+}
+
+type badRuleLine struct {
+	SKU string `json:"sku" validate:"requird"`
+}
+
+type badRuleRequest struct {
+	Name  string        `json:"name" validate:"required"`
+	Lines []badRuleLine `json:"lines"`
+}
+
+func TestCheckRules(t *testing.T) {
+	type badBound struct {
+		Count int `validate:"min=one"`
+	}
+	type badUnique struct {
+		Items []models.Money `validate:"unique=Code"`
+	}
+	type badDive struct {
+		Name string `validate:"dive,required"`
+	}
+	type selfNested struct {
+		Name     string        `validate:"max=10"`
+		Children []*selfNested `validate:"max=5"`
+	}
+
+	assert.NoError(t, validation.CheckRules(models.AdminCreateRequest{}, selfNested{}))
+	assert.ErrorContains(t, validation.CheckRules(badBound{}), `invalid min parameter "one"`)
+	assert.ErrorContains(t, validation.CheckRules(badUnique{}), "unique=Code")
+	assert.ErrorContains(t, validation.CheckRules(badDive{}), "not a slice")
+	assert.ErrorContains(t, validation.CheckRules(&badRuleRequest{}), `unknown rule "requird" on validation.badRuleLine.SKU`)
+}
+
+func TestValidate_InvalidRulesAreReportedNotPanicked(t *testing.T) {
+	var details []models.ErrorDetail
+	assert.NotPanics(t, func() {
+		details = validation.Validate(badRuleRequest{Name: "x", Lines: []badRuleLine{{SKU: "SKU-1"}}})
+	})
+
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "", Issue: "Request cannot be validated"},
+	}, details)
+}
+
+func TestValidate_OmitEmptySkipsZeroValues(t *testing.T) {
+	enabled := true
+	assert.Empty(t, validation.Validate(models.MaintenanceRequest{Enabled: &enabled}))
+	assert.Empty(t, validation.Validate(models.MaintenanceRequest{Enabled: &enabled, RetryAfterSeconds: 30}))
+
+	assert.Equal(t, []models.ErrorDetail{
+		{Field: "retryAfterSeconds", Issue: "retryAfterSeconds must be at most 3600"},
+	}, validation.Validate(models.MaintenanceRequest{Enabled: &enabled, RetryAfterSeconds: 7200}))
+}
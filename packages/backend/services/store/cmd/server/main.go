@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/joho/godotenv"
+	"github.com/melibackend/shared/client"
+	sharedmiddleware "github.com/melibackend/shared/middleware"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/sync"
+	"github.com/melibackend/shared/tlsconfig"
+	"github.com/melibackend/shared/tracing"
+	"github.com/melibackend/store/internal/config"
+	"github.com/melibackend/store/internal/handlers"
+	"github.com/melibackend/store/internal/returns"
+)
+
+const version = "1.0.0"
+
+func main() {
+	// Load .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		// .env file is optional, so we just log if it's not found
+		fmt.Printf("No .env file found or error loading it: %v\n", err)
+	}
+
+	// Load configuration (setupLogging is called automatically inside)
+	cfg := config.Load()
+	serviceName := fmt.Sprintf("%s-api", cfg.StoreID)
+
+	slog.Info("Starting Store API",
+		"store_id", cfg.StoreID,
+		"service", serviceName,
+		"version", version,
+		"port", cfg.Port,
+		"environment", cfg.Environment,
+		"central_api_url", cfg.CentralAPIURL,
+		"central_api_urls", cfg.CentralAPIURLs,
+		"sync_interval_seconds", cfg.SyncIntervalSeconds,
+		"event_wait_timeout_seconds", cfg.EventWaitTimeoutSeconds,
+		"event_batch_limit", cfg.EventBatchLimit,
+		"event_encoding", cfg.EventEncoding,
+		"event_compression", cfg.EventCompression,
+		"event_filter", cfg.EventFilter,
+		"full_resync_interval_minutes", cfg.FullResyncIntervalMinutes,
+		"full_resync_at", cfg.FullResyncAt,
+		"circuit_breaker_failure_threshold", cfg.CircuitBreakerFailureThreshold,
+		"circuit_breaker_open_seconds", cfg.CircuitBreakerOpenSeconds,
+		"rate_limit_honor_headers", cfg.RateLimitHonorHeaders,
+		"sync_rate_limit_low_percent", cfg.SyncRateLimitLowPercent,
+		"sync_catch_up_max_events_per_second", cfg.SyncCatchUpMaxEventsPerSecond,
+		"sync_catch_up_max_cpu_percent", cfg.SyncCatchUpMaxCPUPercent,
+	)
+
+	// Initialize tracing; the inventory client propagates trace context to the central API
+	shutdownTracing, err := tracing.Init(context.Background(), serviceName)
+	if err != nil {
+		slog.Warn("Failed to initialize tracing, continuing without trace export", "error", err)
+	}
+
+	// Initialize inventory client
+	inventoryClient := client.NewInventoryClient(cfg.CentralAPIURL, cfg.CentralAPIKey)
+	inventoryClient.SetStoreID(cfg.StoreID)
+	if err := inventoryClient.SetEventEncoding(cfg.EventEncoding); err != nil {
+		slog.Error("Invalid event encoding", "error", err)
+		os.Exit(1)
+	}
+	if err := inventoryClient.SetEventCompression(cfg.EventCompression); err != nil {
+		slog.Error("Invalid event compression", "error", err)
+		os.Exit(1)
+	}
+	inventoryClient.SetEventFilter(cfg.EventFilter)
+
+	// A private CA and a client certificate for central APIs served over (mutual) TLS
+	tlsReloadInterval := time.Duration(cfg.TLSReloadIntervalSeconds) * time.Second
+	centralTLSConfig, err := tlsconfig.ClientConfig(cfg.CentralTLSCAFile, cfg.CentralTLSCertFile, cfg.CentralTLSKeyFile, tlsReloadInterval)
+	if err != nil {
+		slog.Error("Failed to configure TLS for the central API", "error", err)
+		os.Exit(1)
+	}
+	if centralTLSConfig != nil {
+		inventoryClient.SetTLSConfig(centralTLSConfig)
+		slog.Info("Central API TLS configured",
+			"ca_file", cfg.CentralTLSCAFile,
+			"client_cert_file", cfg.CentralTLSCertFile)
+	}
+
+	// Configure circuit breaker and retries for central API calls
+	breakerConfig := client.DefaultCircuitBreakerConfig()
+	breakerConfig.FailureThreshold = cfg.CircuitBreakerFailureThreshold
+	breakerConfig.OpenTimeout = time.Duration(cfg.CircuitBreakerOpenSeconds) * time.Second
+	breaker := client.NewCircuitBreaker(breakerConfig)
+	breaker.OnStateChange(func(from, to client.BreakerState) {
+		if to == client.BreakerOpen {
+			slog.Warn("Central API circuit opened, failing fast", "from", from)
+		}
+	})
+	inventoryClient.SetCircuitBreaker(breaker)
+
+	retryPolicy := client.DefaultIdempotentRetryPolicy()
+	retryPolicy.MaxAttempts = cfg.ClientRetryMaxAttempts
+	inventoryClient.SetRetryPolicy(retryPolicy)
+
+	inventoryClient.SetRateLimitOptions(client.RateLimitOptions{
+		HonorRetryAfter:   cfg.RateLimitHonorHeaders,
+		WaitWhenExhausted: cfg.RateLimitHonorHeaders,
+		MaxWait:           time.Duration(cfg.RateLimitMaxWaitSeconds) * time.Second,
+	})
+
+	// With standby central instances, calls follow the most preferred healthy one
+	failoverCtx, stopFailover := context.WithCancel(context.Background())
+	defer stopFailover()
+	if cfg.CentralAPIURLs != "" {
+		inventoryClient.SetEndpoints(strings.Split(cfg.CentralAPIURLs, ","))
+		inventoryClient.StartFailover(failoverCtx, client.FailoverOptions{
+			HealthInterval:   time.Duration(cfg.CentralHealthIntervalSeconds) * time.Second,
+			FailureThreshold: cfg.CentralFailoverThreshold,
+			FailbackAfter:    cfg.CentralFailbackAfter,
+		})
+		slog.Info("Central API failover configured",
+			"endpoints", cfg.CentralAPIURLs,
+			"active", inventoryClient.ActiveEndpoint())
+	}
+
+	// Test connection to central API
+	startupCtx, startupCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	_, err = inventoryClient.HealthCheckCtx(startupCtx)
+	startupCancel()
+	if err != nil {
+		slog.Error("Failed to connect to central inventory API", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Successfully connected to central inventory API")
+
+	// Initialize local storage
+	localStorage, err := newLocalStorage(cfg)
+	if err != nil {
+		slog.Error("Failed to create local storage", "backend", cfg.StorageBackend, "error", err)
+		os.Exit(1)
+	}
+	if err := localStorage.Initialize(); err != nil {
+		slog.Error("Failed to initialize local storage", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Local storage initialized", "backend", cfg.StorageBackend, "data_dir", cfg.DataDir)
+
+	// Initialize event-driven sync manager
+	eventSyncConfig := sync.EventSyncConfig{
+		SyncIntervalSeconds:     cfg.SyncIntervalSeconds,
+		EventWaitTimeoutSeconds: cfg.EventWaitTimeoutSeconds,
+		EventBatchLimit:         cfg.EventBatchLimit,
+		MaxConsecutiveFailures:  5, // Allow 5 consecutive failures before fallback
+		FullResyncInterval:      time.Duration(cfg.FullResyncIntervalMinutes) * time.Minute,
+		FullResyncAt:            cfg.FullResyncAt,
+		FullResyncJitter:        time.Duration(cfg.FullResyncJitterMinutes) * time.Minute,
+		RateLimitLowPercent:     cfg.SyncRateLimitLowPercent,
+
+		CatchUpMaxEventsPerSecond: cfg.SyncCatchUpMaxEventsPerSecond,
+		CatchUpMaxCPUPercent:      cfg.SyncCatchUpMaxCPUPercent,
+	}
+	syncManager := sync.NewEventSyncManager(inventoryClient, localStorage, eventSyncConfig)
+
+	// The offline write queue is created before the sync manager starts so the
+	// scheduled full resync can skip products with pending writes
+	var writeQueue *sync.WriteQueue
+	if cfg.OfflineQueueEnabled {
+		writeQueue = sync.NewWriteQueue(inventoryClient, localStorage, cfg.DataDir,
+			time.Duration(cfg.OfflineReplayIntervalSeconds)*time.Second)
+		syncManager.SetWriteQueue(writeQueue)
+	}
+
+	// Unknown product IDs are forgotten once the sync applies an event for them
+	notFoundCache := handlers.NewNotFoundCache(time.Duration(cfg.NotFoundCacheTTLSeconds)*time.Second, cfg.NotFoundCacheMaxEntries)
+	syncManager.SetChangeListener(notFoundCache.Invalidate)
+
+	// Start sync manager with initial sync
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Sync lag is always served on /metrics; METRICS_EXPORTER=otlp also pushes it
+	meter, shutdownMetrics, err := tracing.InitMetrics(ctx, serviceName)
+	if err != nil {
+		slog.Warn("Metrics export disabled", "error", err)
+	}
+	if meter != nil {
+		if err := syncManager.RegisterMetrics(meter); err != nil {
+			slog.Warn("Failed to register sync metrics", "error", err)
+		}
+	}
+
+	if err := syncManager.Start(ctx); err != nil {
+		slog.Error("Failed to start sync manager", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Sync manager started successfully")
+
+	// Start offline write queue for buffering updates during central outages
+	if writeQueue != nil {
+		if err := writeQueue.Start(ctx); err != nil {
+			slog.Error("Failed to start offline write queue", "error", err)
+			os.Exit(1)
+		}
+		slog.Info("Offline write queue started", "replay_interval_seconds", cfg.OfflineReplayIntervalSeconds)
+	}
+
+	// Initialize cache verification; the scheduled job only runs with an interval
+	reconciler := sync.NewReconciler(inventoryClient, localStorage, sync.ReconcilerConfig{
+		Interval:   time.Duration(cfg.ReconcileIntervalMinutes) * time.Minute,
+		SampleSize: cfg.ReconcileSampleSize,
+		AutoHeal:   cfg.ReconcileAutoHeal,
+	})
+	if writeQueue != nil {
+		reconciler.SetWriteQueue(writeQueue)
+	}
+	reconciler.Start(ctx)
+
+	// Initialize handlers with local storage
+	healthHandler := handlers.NewHealthHandler(inventoryClient, serviceName, version)
+	inventoryHandler := handlers.NewInventoryHandler(cfg.StoreID, inventoryClient, localStorage, syncManager)
+	if writeQueue != nil {
+		inventoryHandler.SetWriteQueue(writeQueue)
+	}
+	inventoryHandler.SetReconciler(reconciler)
+
+	returnsLedger, err := returns.NewLedger(cfg.DataDir)
+	if err != nil {
+		slog.Error("Failed to load returns ledger", "error", err)
+		os.Exit(1)
+	}
+	inventoryHandler.SetReturnsLedger(returnsLedger)
+	inventoryHandler.SetStockCheckMode(cfg.LocalStockCheckMode)
+	inventoryHandler.SetNotFoundCache(notFoundCache)
+
+	// Setup router
+	r := chi.NewRouter()
+
+	// Middleware
+	// The request ID comes first so chi's RequestID, the logger and calls to the
+	// central API all see the same X-Request-ID
+	r.Use(sharedmiddleware.RequestIDMiddleware)
+	r.Use(sharedmiddleware.TracingMiddleware(func(r *http.Request) string {
+		return chi.RouteContext(r.Context()).RoutePattern()
+	}))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Timeout(60 * time.Second))
+	if cfg.CompressionEnabled {
+		r.Use(sharedmiddleware.CompressionMiddleware(cfg.CompressionMinBytes))
+	}
+
+	// API Key authentication for protected routes
+	apiKeys := strings.Split(cfg.APIKeys, ",")
+	authMiddleware := sharedmiddleware.AuthMiddleware(apiKeys)
+
+	// Routes
+	r.Get("/health", healthHandler.HealthCheck)
+	r.Get("/metrics", inventoryHandler.GetMetrics)
+
+	// Protected routes
+	r.Route("/v1", func(r chi.Router) {
+		r.Use(authMiddleware)
+
+		// Store-specific inventory endpoints (now using local cache)
+		r.Get("/store/inventory", inventoryHandler.GetAllProducts)
+		r.Get("/store/inventory/by-barcode/{code}", inventoryHandler.GetProductByBarcode)
+		r.Get("/store/inventory/{productId}", inventoryHandler.GetProduct)
+		r.Post("/store/inventory/batch-get", inventoryHandler.BatchGetProducts)
+		r.Get("/store/categories", inventoryHandler.GetCategories)
+		r.Post("/store/inventory/updates", inventoryHandler.UpdateInventory)
+		r.Post("/store/inventory/batch-updates", inventoryHandler.BatchUpdateInventory)
+		r.Post("/store/inventory/returns", inventoryHandler.ProcessReturn)
+		r.Get("/store/inventory/returns/stats", inventoryHandler.GetReturnStats)
+
+		// Sync management endpoints
+		r.Get("/store/sync/status", inventoryHandler.GetSyncStatus)
+		r.Post("/store/sync/force", inventoryHandler.ForceSync)
+		r.Post("/store/sync/pause", inventoryHandler.PauseSync)
+		r.Post("/store/sync/resume", inventoryHandler.ResumeSync)
+		r.Get("/store/sync/verify", inventoryHandler.GetLastVerification)
+		r.Post("/store/sync/verify", inventoryHandler.VerifySync)
+		r.Get("/store/cache/stats", inventoryHandler.GetCacheStats)
+		r.Get("/store/offline-queue", inventoryHandler.GetOfflineQueueStatus)
+		r.Post("/store/offline-queue/replay", inventoryHandler.ReplayOfflineQueue)
+	})
+
+	// Cache inspection for support engineers, only with admin keys configured
+	if cfg.AdminAPIKeys != "" {
+		adminAuthMiddleware := sharedmiddleware.AuthMiddleware(strings.Split(cfg.AdminAPIKeys, ","))
+		r.With(adminAuthMiddleware).Get("/v1/store/cache/products/{productId}/raw", inventoryHandler.GetRawCachedProduct)
+		r.With(adminAuthMiddleware).Get("/v1/store/cache/dump", inventoryHandler.DumpCache)
+	}
+
+	// Serve HTTPS when a certificate is configured
+	serverTLSConfig, err := tlsconfig.ServerConfig(cfg.TLSCertFile, cfg.TLSKeyFile, tlsReloadInterval)
+	if err != nil {
+		slog.Error("Failed to configure TLS", "error", err)
+		os.Exit(1)
+	}
+
+	// Start server
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%d", cfg.Port),
+		Handler:   r,
+		TLSConfig: serverTLSConfig,
+	}
+
+	// Graceful shutdown
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		<-sigChan
+
+		slog.Info("Shutting down server...")
+
+		// Stop sync manager and scheduled verification
+		syncManager.Stop()
+		stopFailover()
+		reconciler.Stop()
+
+		// Stop offline replay; pending writes stay in the journal
+		if writeQueue != nil {
+			writeQueue.Stop()
+		}
+
+		// Close local storage
+		if err := localStorage.Close(); err != nil {
+			slog.Error("Failed to close local storage", "error", err)
+		}
+
+		// Cancel context
+		cancel()
+
+		// Shutdown server
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer shutdownCancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Server shutdown error", "error", err)
+		}
+
+		// Flush buffered spans last so shutdown work is still traced
+		if err := shutdownMetrics(shutdownCtx); err != nil {
+			slog.Error("Metrics shutdown error", "error", err)
+		}
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("Tracing shutdown error", "error", err)
+		}
+	}()
+
+	slog.Info("Server ready to accept connections", "address", server.Addr, "tls", serverTLSConfig != nil)
+
+	if serverTLSConfig != nil {
+		// Certificates come from TLSConfig.GetCertificate so they can be rotated
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		slog.Error("Server failed to start", "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// newLocalStorage creates the storage backend selected by STORAGE_BACKEND
+func newLocalStorage(cfg *config.Config) (storage.LocalStorage, error) {
+	switch cfg.StorageBackend {
+	case "memory", "":
+		return storage.NewMemoryStorage(cfg.DataDir), nil
+	case "redis":
+		return storage.NewRedisStorage(cfg.RedisURL, cfg.RedisKeyPrefix)
+	default:
+		return nil, fmt.Errorf("unknown storage backend: %s", cfg.StorageBackend)
+	}
+}
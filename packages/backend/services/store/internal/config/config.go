@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/melibackend/shared/utils"
+)
+
+// Config holds the application configuration
+type Config struct {
+	StoreID                 string `json:"storeId"`
+	Port                    int    `json:"port"`
+	Environment             string `json:"environment"`
+	LogLevel                string `json:"logLevel"`
+	APIKeys                 string `json:"apiKeys"`
+	AdminAPIKeys            string `json:"adminApiKeys"` // Keys for cache inspection, empty disables it
+	CentralAPIURL           string `json:"centralApiUrl"`
+	CentralAPIKey           string `json:"centralApiKey"`
+	CentralAPIURLs          string `json:"centralApiUrls"` // Prioritized central endpoints, overrides CentralAPIURL
+	DataDir                 string `json:"dataDir"`
+	StorageBackend          string `json:"storageBackend"` // memory or redis
+	RedisURL                string `json:"redisUrl"`
+	RedisKeyPrefix          string `json:"redisKeyPrefix"`
+	SyncInterval            int    `json:"syncIntervalMinutes"`     // Legacy full sync interval in minutes
+	SyncIntervalSeconds     int    `json:"syncIntervalSeconds"`     // Event polling interval in seconds
+	EventWaitTimeoutSeconds int    `json:"eventWaitTimeoutSeconds"` // Long polling timeout in seconds
+	EventBatchLimit         int    `json:"eventBatchLimit"`         // Max events per request
+	EventEncoding           string `json:"eventEncoding"`           // json or protobuf
+	EventCompression        string `json:"eventCompression"`        // zstd, gzip or none
+	EventFilter             string `json:"eventFilter"`             // Named central event filter, empty for all events
+
+	// Scheduled full resync to correct drift the event stream missed
+	FullResyncIntervalMinutes int    `json:"fullResyncIntervalMinutes"` // 0 disables unless FullResyncAt is set
+	FullResyncAt              string `json:"fullResyncAt"`              // Daily UTC time (HH:MM), overrides the interval
+	FullResyncJitterMinutes   int    `json:"fullResyncJitterMinutes"`   // Random extra delay per run
+
+	// Central API resilience
+	CircuitBreakerFailureThreshold int `json:"circuitBreakerFailureThreshold"` // Consecutive failures before opening
+	CircuitBreakerOpenSeconds      int `json:"circuitBreakerOpenSeconds"`      // Time to stay open before probing
+	ClientRetryMaxAttempts         int `json:"clientRetryMaxAttempts"`         // Attempts for idempotent reads
+
+	// Central endpoint failover, with more than one CentralAPIURLs
+	CentralHealthIntervalSeconds int `json:"centralHealthIntervalSeconds"` // How often every endpoint is probed
+	CentralFailoverThreshold     int `json:"centralFailoverThreshold"`     // Failed probes before leaving the active endpoint
+	CentralFailbackAfter         int `json:"centralFailbackAfter"`         // Healthy probes before returning to a preferred endpoint
+
+	// Central API rate limiting
+	RateLimitHonorHeaders   bool `json:"rateLimitHonorHeaders"`   // Wait on Retry-After and exhausted quotas
+	RateLimitMaxWaitSeconds int  `json:"rateLimitMaxWaitSeconds"` // Longest single rate limit wait
+	SyncRateLimitLowPercent int  `json:"syncRateLimitLowPercent"` // Slow polling below this share of quota, 0 disables
+
+	// Pacing of the polls that drain an event backlog after downtime
+	SyncCatchUpMaxEventsPerSecond int `json:"syncCatchUpMaxEventsPerSecond"` // 0 disables the rate limit
+	SyncCatchUpMaxCPUPercent      int `json:"syncCatchUpMaxCpuPercent"`      // Share of the CPUs catch-up may push the process to, 0 disables
+
+	// Offline write buffering
+	OfflineQueueEnabled          bool `json:"offlineQueueEnabled"`          // Accept updates while the central API is down
+	OfflineReplayIntervalSeconds int  `json:"offlineReplayIntervalSeconds"` // How often pending writes are replayed
+
+	// Local stock pre-check before forwarding updates: strict, lenient or off
+	LocalStockCheckMode string `json:"localStockCheckMode"`
+
+	// Unknown product IDs remembered to spare storage and central lookups
+	NotFoundCacheTTLSeconds int `json:"notFoundCacheTtlSeconds"` // 0 disables the cache
+	NotFoundCacheMaxEntries int `json:"notFoundCacheMaxEntries"` // IDs remembered at most
+
+	// Scheduled cache verification against the central API
+	ReconcileIntervalMinutes int  `json:"reconcileIntervalMinutes"` // 0 disables the scheduled job
+	ReconcileSampleSize      int  `json:"reconcileSampleSize"`      // Products per run, 0 compares all
+	ReconcileAutoHeal        bool `json:"reconcileAutoHeal"`        // Replace diverged products with central state
+
+	// Response compression
+	CompressionEnabled  bool `json:"compressionEnabled"`  // Gzip responses for clients that accept it
+	CompressionMinBytes int  `json:"compressionMinBytes"` // Smaller responses are sent uncompressed
+
+	// TLS for the store API and mutual TLS towards the central API
+	TLSCertFile              string `json:"tlsCertFile"`              // Serve HTTPS when set with TLSKeyFile
+	TLSKeyFile               string `json:"tlsKeyFile"`               // Private key for TLSCertFile
+	CentralTLSCAFile         string `json:"centralTlsCaFile"`         // CA that signed the central API certificate
+	CentralTLSCertFile       string `json:"centralTlsCertFile"`       // Client certificate (CN = store ID) for mutual TLS
+	CentralTLSKeyFile        string `json:"centralTlsKeyFile"`        // Private key for CentralTLSCertFile
+	TLSReloadIntervalSeconds int    `json:"tlsReloadIntervalSeconds"` // How often rotated certificate files are picked up, 0 disables
+}
+
+// Load loads configuration from environment variables with defaults
+func Load() *Config {
+	storeID := getEnv("STORE_ID", "store-s1")
+
+	cfg := &Config{
+		StoreID:                 storeID,
+		Port:                    getEnvAsInt("PORT", 8083),
+		Environment:             getEnv("ENVIRONMENT", "development"),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		APIKeys:                 getEnv("API_KEYS", fmt.Sprintf("%s-key,demo", storeID)),
+		AdminAPIKeys:            getEnv("ADMIN_API_KEYS", ""),
+		CentralAPIURL:           getEnv("CENTRAL_API_URL", "http://inventory-management-system:8081"),
+		CentralAPIKey:           getEnv("CENTRAL_API_KEY", "demo"),
+		CentralAPIURLs:          getEnv("CENTRAL_API_URLS", ""),
+		DataDir:                 getEnv("DATA_DIR", "/app/data"),
+		StorageBackend:          getEnv("STORAGE_BACKEND", "memory"),
+		RedisURL:                getEnv("REDIS_URL", "redis://redis:6379/0"),
+		RedisKeyPrefix:          getEnv("REDIS_KEY_PREFIX", fmt.Sprintf("inventory:%s:", storeID)),
+		SyncInterval:            getEnvAsInt("SYNC_INTERVAL_MINUTES", 5),
+		SyncIntervalSeconds:     getEnvAsInt("SYNC_INTERVAL_SECONDS", 30),
+		EventWaitTimeoutSeconds: getEnvAsInt("EVENT_WAIT_TIMEOUT_SECONDS", 20),
+		EventBatchLimit:         getEnvAsInt("EVENT_BATCH_LIMIT", 100),
+		EventEncoding:           getEnv("EVENT_ENCODING", "json"),
+		EventCompression:        getEnv("EVENT_COMPRESSION", "zstd"),
+		EventFilter:             getEnv("EVENT_FILTER", ""),
+
+		FullResyncIntervalMinutes: getEnvAsInt("FULL_RESYNC_INTERVAL_MINUTES", 0),
+		FullResyncAt:              getEnv("FULL_RESYNC_AT", ""),
+		FullResyncJitterMinutes:   getEnvAsInt("FULL_RESYNC_JITTER_MINUTES", 30),
+
+		CircuitBreakerFailureThreshold: getEnvAsInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5),
+		CircuitBreakerOpenSeconds:      getEnvAsInt("CIRCUIT_BREAKER_OPEN_SECONDS", 30),
+		ClientRetryMaxAttempts:         getEnvAsInt("CLIENT_RETRY_MAX_ATTEMPTS", 3),
+
+		CentralHealthIntervalSeconds: getEnvAsInt("CENTRAL_HEALTH_INTERVAL_SECONDS", 5),
+		CentralFailoverThreshold:     getEnvAsInt("CENTRAL_FAILOVER_THRESHOLD", 2),
+		CentralFailbackAfter:         getEnvAsInt("CENTRAL_FAILBACK_AFTER", 3),
+
+		RateLimitHonorHeaders:   getEnvAsBool("CENTRAL_RATE_LIMIT_HONOR_HEADERS", true),
+		RateLimitMaxWaitSeconds: getEnvAsInt("CENTRAL_RATE_LIMIT_MAX_WAIT_SECONDS", 10),
+		SyncRateLimitLowPercent: getEnvAsInt("SYNC_RATE_LIMIT_LOW_PERCENT", 10),
+
+		SyncCatchUpMaxEventsPerSecond: getEnvAsInt("SYNC_CATCH_UP_MAX_EVENTS_PER_SECOND", 500),
+		SyncCatchUpMaxCPUPercent:      getEnvAsInt("SYNC_CATCH_UP_MAX_CPU_PERCENT", 50),
+
+		OfflineQueueEnabled:          getEnvAsBool("OFFLINE_QUEUE_ENABLED", true),
+		OfflineReplayIntervalSeconds: getEnvAsInt("OFFLINE_REPLAY_INTERVAL_SECONDS", 10),
+
+		LocalStockCheckMode: getEnv("LOCAL_STOCK_CHECK_MODE", "off"),
+
+		NotFoundCacheTTLSeconds: getEnvAsInt("NOT_FOUND_CACHE_TTL_SECONDS", 5),
+		NotFoundCacheMaxEntries: getEnvAsInt("NOT_FOUND_CACHE_MAX_ENTRIES", 10000),
+
+		ReconcileIntervalMinutes: getEnvAsInt("RECONCILE_INTERVAL_MINUTES", 0),
+		ReconcileSampleSize:      getEnvAsInt("RECONCILE_SAMPLE_SIZE", 50),
+		ReconcileAutoHeal:        getEnvAsBool("RECONCILE_AUTO_HEAL", false),
+
+		CompressionEnabled:  getEnvAsBool("COMPRESSION_ENABLED", true),
+		CompressionMinBytes: getEnvAsInt("COMPRESSION_MIN_BYTES", 1024),
+
+		TLSCertFile:              getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:               getEnv("TLS_KEY_FILE", ""),
+		CentralTLSCAFile:         getEnv("CENTRAL_TLS_CA_FILE", ""),
+		CentralTLSCertFile:       getEnv("CENTRAL_TLS_CERT_FILE", ""),
+		CentralTLSKeyFile:        getEnv("CENTRAL_TLS_KEY_FILE", ""),
+		TLSReloadIntervalSeconds: getEnvAsInt("TLS_RELOAD_INTERVAL_SECONDS", 60),
+	}
+
+	// Configure slog based on log level using shared utils
+	utils.SetupLogging(cfg.LogLevel)
+
+	return cfg
+}
+
+// getEnv gets an environment variable with a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsInt gets an environment variable as integer with a default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean with a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
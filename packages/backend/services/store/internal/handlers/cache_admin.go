@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+)
+
+// Page size limits for the cache dump
+const (
+	defaultCacheDumpLimit = 100
+	maxCacheDumpLimit     = 1000
+)
+
+// CacheDump is one page of the local cache as stored, with the sync position
+// the cache reflects
+type CacheDump struct {
+	StoreID         string           `json:"storeId"`
+	LastEventOffset int64            `json:"lastEventOffset"`
+	LastSyncTime    time.Time        `json:"lastSyncTime"`
+	Products        []models.Product `json:"products"`
+	Offset          int              `json:"offset"`
+	Limit           int              `json:"limit"`
+	TotalCount      int              `json:"totalCount"`
+	HasMore         bool             `json:"hasMore"`
+}
+
+// GetRawCachedProduct handles GET /v1/store/cache/products/{productId}/raw -
+// returns the product exactly as the storage backend holds it
+func (h *InventoryHandler) GetRawCachedProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productId")
+
+	raw, err := h.localStorage.GetRawProduct(productID)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.writeErrorResponse(w, "product_not_found", "Product not in the local cache", http.StatusNotFound, map[string]string{"productId": productID})
+			return
+		}
+		slog.Error("Failed to read raw product from local storage", "product_id", productID, "error", err)
+		h.writeErrorResponse(w, "storage_error", "Failed to read the local cache", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	slog.Info("Raw cached product inspected", "product_id", productID, "backend", raw.Backend, "remote_addr", r.RemoteAddr)
+	h.writeJSON(w, http.StatusOK, raw)
+}
+
+// DumpCache handles GET /v1/store/cache/dump - pages through every cached
+// product in product ID order, without the formatting of the inventory
+// endpoints
+func (h *InventoryHandler) DumpCache(w http.ResponseWriter, r *http.Request) {
+	offset, limit, ok := parseCacheDumpPage(r)
+	if !ok {
+		h.writeErrorResponse(w, "invalid_request", "offset must be >= 0 and limit between 1 and 1000", http.StatusBadRequest, nil)
+		return
+	}
+
+	products, err := h.localStorage.GetAllProducts()
+	if err != nil {
+		slog.Error("Failed to get products from local storage", "error", err)
+		h.writeErrorResponse(w, "storage_error", "Failed to read the local cache", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ProductID < products[j].ProductID
+	})
+
+	dump := CacheDump{
+		StoreID:    h.storeID,
+		Products:   []models.Product{},
+		Offset:     offset,
+		Limit:      limit,
+		TotalCount: len(products),
+		HasMore:    offset+limit < len(products),
+	}
+	if offset < len(products) {
+		end := offset + limit
+		if end > len(products) {
+			end = len(products)
+		}
+		dump.Products = products[offset:end]
+	}
+
+	// The sync position is informational; a dump without it is still useful
+	if lastOffset, err := h.localStorage.GetLastEventOffset(); err == nil {
+		dump.LastEventOffset = lastOffset
+	}
+	if lastSync, err := h.localStorage.GetLastSyncTime(); err == nil {
+		dump.LastSyncTime = lastSync
+	}
+
+	slog.Info("Local cache dumped",
+		"offset", offset,
+		"returned_count", len(dump.Products),
+		"total_count", dump.TotalCount,
+		"remote_addr", r.RemoteAddr)
+	h.writeJSON(w, http.StatusOK, dump)
+}
+
+// parseCacheDumpPage reads the offset and limit query parameters
+func parseCacheDumpPage(r *http.Request) (int, int, bool) {
+	offset, limit := 0, defaultCacheDumpLimit
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxCacheDumpLimit {
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	return offset, limit, true
+}
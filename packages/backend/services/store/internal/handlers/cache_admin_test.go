@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/testutil"
+)
+
+func TestGetRawCachedProduct(t *testing.T) {
+	handler, localStorage := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+	cacheProducts(t, localStorage, models.Product{ProductID: "SKU-001", Available: 3, Version: 7})
+
+	rr := serve(handler, "GET", "/v1/store/cache/products/SKU-001/raw", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var raw struct {
+		Backend string         `json:"backend"`
+		Key     string         `json:"key"`
+		Value   models.Product `json:"value"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("Failed to decode raw product: %v", err)
+	}
+	if raw.Backend != "memory" || raw.Key != "SKU-001" || raw.Value.Version != 7 {
+		t.Errorf("Expected SKU-001 at version 7 from memory, got %s", rr.Body.String())
+	}
+
+	rr = serve(handler, "GET", "/v1/store/cache/products/SKU-404/raw", "")
+	if rr.Code != http.StatusNotFound || errorCode(rr) != "product_not_found" {
+		t.Errorf("Expected 404 product_not_found, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestDumpCache_Pages(t *testing.T) {
+	handler, localStorage := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+	for _, id := range []string{"SKU-003", "SKU-001", "SKU-005", "SKU-002", "SKU-004"} {
+		cacheProducts(t, localStorage, models.Product{ProductID: id, Available: 1, Version: 1})
+	}
+	if err := localStorage.SetLastEventOffset(42); err != nil {
+		t.Fatalf("Failed to set event offset: %v", err)
+	}
+
+	var ids []string
+	for offset := 0; ; offset += 2 {
+		rr := serve(handler, "GET", fmt.Sprintf("/v1/store/cache/dump?offset=%d&limit=2", offset), "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+		var dump CacheDump
+		if err := json.Unmarshal(rr.Body.Bytes(), &dump); err != nil {
+			t.Fatalf("Failed to decode dump: %v", err)
+		}
+		if dump.StoreID != "store-001" || dump.LastEventOffset != 42 || dump.TotalCount != 5 {
+			t.Fatalf("Unexpected dump header: %+v", dump)
+		}
+		for _, product := range dump.Products {
+			ids = append(ids, product.ProductID)
+		}
+		if !dump.HasMore {
+			break
+		}
+	}
+
+	if fmt.Sprint(ids) != "[SKU-001 SKU-002 SKU-003 SKU-004 SKU-005]" {
+		t.Errorf("Expected every product once in ID order, got %v", ids)
+	}
+}
+
+func TestDumpCache_InvalidPage(t *testing.T) {
+	handler, _ := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+
+	for _, query := range []string{"limit=0", "limit=1001", "offset=-1", "limit=many"} {
+		rr := serve(handler, "GET", "/v1/store/cache/dump?"+query, "")
+		if rr.Code != http.StatusBadRequest || errorCode(rr) != "invalid_request" {
+			t.Errorf("%s: expected 400 invalid_request, got %d: %s", query, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/melibackend/shared/models"
+)
+
+// GetCategories handles GET /v1/store/categories - lists the category tree
+// replicated from the central API
+func (h *InventoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.localStorage.GetCategories()
+	if err != nil {
+		slog.Error("Failed to get categories from local storage", "error", err)
+		h.writeErrorResponse(w, "storage_error", "Failed to retrieve categories", http.StatusInternalServerError, nil)
+		return
+	}
+	h.writeJSON(w, http.StatusOK, models.CategoryListResponse{Categories: categories})
+}
+
+// categoryFilter returns the comma-separated categories requested together
+// with all their subcategories, compared case-insensitively as on the central
+// API. Categories outside the tree are kept for free-form product categories.
+func (h *InventoryHandler) categoryFilter(requested string) (map[string]bool, error) {
+	categories, err := h.localStorage.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[string][]string, len(categories))
+	for _, category := range categories {
+		if category.ParentID != "" {
+			children[category.ParentID] = append(children[category.ParentID], category.ID)
+		}
+	}
+
+	wanted := make(map[string]bool)
+	var pending []string
+	for _, category := range strings.Split(requested, ",") {
+		if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+			pending = append(pending, category)
+		}
+	}
+	for len(pending) > 0 {
+		category := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if wanted[category] {
+			continue
+		}
+		wanted[category] = true
+		pending = append(pending, children[category]...)
+	}
+	return wanted, nil
+}
@@ -30,16 +30,26 @@ func NewHealthHandler(inventoryClient *client.InventoryClient, serviceName, vers
 func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	slog.Debug("Health check requested", "remote_addr", r.RemoteAddr)
 
+	checks := map[string]string{}
+	if breaker := h.inventoryClient.CircuitBreaker(); breaker != nil {
+		checks["centralApiCircuit"] = string(breaker.State())
+	}
+	if len(h.inventoryClient.Endpoints()) > 1 {
+		checks["centralApiEndpoint"] = h.inventoryClient.ActiveEndpoint()
+	}
+
 	// Check central API health
-	centralHealth, err := h.inventoryClient.HealthCheck()
+	centralHealth, err := h.inventoryClient.HealthCheckCtx(r.Context())
 	if err != nil {
 		slog.Error("Central API health check failed", "error", err)
+		checks["centralApi"] = "unreachable"
 
 		response := models.HealthResponse{
 			Status:    "unhealthy",
 			Service:   h.serviceName,
 			Version:   h.version,
 			Timestamp: time.Now(),
+			Checks:    checks,
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -50,11 +60,21 @@ func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	slog.Debug("Central API health check successful", "central_status", centralHealth.Status)
 
+	checks["centralApi"] = centralHealth.Status
+
+	// The central API answers again but the breaker has not closed yet, so
+	// inventory calls are still failing fast
+	status := "healthy"
+	if checks["centralApiCircuit"] == string(client.BreakerOpen) {
+		status = "degraded"
+	}
+
 	response := models.HealthResponse{
-		Status:    "healthy",
+		Status:    status,
 		Service:   h.serviceName,
 		Version:   h.version,
 		Timestamp: time.Now(),
+		Checks:    checks,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
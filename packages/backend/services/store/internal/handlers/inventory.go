@@ -0,0 +1,1028 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/sync"
+	"github.com/melibackend/store/internal/returns"
+)
+
+// InventoryHandler handles inventory-related requests
+type InventoryHandler struct {
+	storeID         string
+	inventoryClient *client.InventoryClient
+	localStorage    storage.LocalStorage
+	syncManager     sync.SyncManager
+	writeQueue      *sync.WriteQueue
+	reconciler      *sync.Reconciler
+	returnsLedger   *returns.Ledger
+	stockCheckMode  string
+	notFoundCache   *NotFoundCache // Nil when off
+}
+
+// maxBatchGetItems caps the product IDs accepted by one batch get, matching
+// the central API default
+const maxBatchGetItems = 100
+
+// Local stock check modes applied before forwarding updates to the central API
+const (
+	// StockCheckStrict rejects updates whose delta exceeds the cached stock
+	StockCheckStrict = "strict"
+	// StockCheckLenient rejects sales only when the cache shows no stock at all,
+	// tolerating a slightly stale cache
+	StockCheckLenient = "lenient"
+	// StockCheckOff forwards every update unchecked; the default
+	StockCheckOff = "off"
+)
+
+// NewInventoryHandler creates a new inventory handler
+func NewInventoryHandler(storeID string, inventoryClient *client.InventoryClient, localStorage storage.LocalStorage, syncManager sync.SyncManager) *InventoryHandler {
+	return &InventoryHandler{
+		storeID:         storeID,
+		inventoryClient: inventoryClient,
+		localStorage:    localStorage,
+		syncManager:     syncManager,
+		stockCheckMode:  StockCheckOff,
+	}
+}
+
+// SetWriteQueue enables offline buffering of updates while the central API is down
+func (h *InventoryHandler) SetWriteQueue(writeQueue *sync.WriteQueue) {
+	h.writeQueue = writeQueue
+}
+
+// SetReconciler enables cache verification against the central API
+func (h *InventoryHandler) SetReconciler(reconciler *sync.Reconciler) {
+	h.reconciler = reconciler
+}
+
+// SetNotFoundCache makes product reads remember unknown product IDs
+func (h *InventoryHandler) SetNotFoundCache(notFoundCache *NotFoundCache) {
+	h.notFoundCache = notFoundCache
+}
+
+// SetStockCheckMode sets the local stock pre-check mode; unknown modes fall back to off
+func (h *InventoryHandler) SetStockCheckMode(mode string) {
+	switch strings.ToLower(mode) {
+	case StockCheckStrict, StockCheckLenient:
+		h.stockCheckMode = strings.ToLower(mode)
+	default:
+		if mode != "" && mode != StockCheckOff {
+			slog.Warn("Unknown local stock check mode, using off", "mode", mode)
+		}
+		h.stockCheckMode = StockCheckOff
+	}
+}
+
+// GetAllProducts handles GET /v1/store/inventory with pagination support (using local cache)
+func (h *InventoryHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Getting all products for store from local cache", "remote_addr", r.RemoteAddr)
+
+	// Parse query parameters for pagination
+	offsetStr := r.URL.Query().Get("offset")
+	limitStr := r.URL.Query().Get("limit")
+
+	// Parse offset (default 0)
+	offset := 0
+	if offsetStr != "" {
+		if parsedOffset, err := strconv.Atoi(offsetStr); err == nil && parsedOffset >= 0 {
+			offset = parsedOffset
+		}
+	}
+
+	// Parse limit (default 50, max 200)
+	limit := 50
+	if limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+			// Cap the limit to prevent excessive resource usage
+			if limit > 200 {
+				limit = 200
+			}
+		}
+	}
+
+	// Get all products from local storage
+	allProducts, err := h.localStorage.GetAllProducts()
+	if err != nil {
+		slog.Error("Failed to get products from local storage", "error", err)
+		h.writeErrorResponse(w, "storage_error", "Failed to retrieve products", http.StatusInternalServerError, nil)
+		return
+	}
+
+	// A category covers the products of its subcategories
+	var categories map[string]bool
+	if category := r.URL.Query().Get("category"); category != "" {
+		if categories, err = h.categoryFilter(category); err != nil {
+			slog.Error("Failed to get categories from local storage", "error", err)
+			h.writeErrorResponse(w, "storage_error", "Failed to retrieve categories", http.StatusInternalServerError, nil)
+			return
+		}
+	}
+
+	// Convert models.Product to response format with consistent field names
+	var productResponses []map[string]interface{}
+	for _, product := range allProducts {
+		if categories != nil && !categories[strings.ToLower(product.Category)] {
+			continue
+		}
+		productResponses = append(productResponses, localProductResponse(product))
+	}
+
+	// Sort products by productId for deterministic pagination
+	sort.Slice(productResponses, func(i, j int) bool {
+		return productResponses[i]["productId"].(string) < productResponses[j]["productId"].(string)
+	})
+
+	// Calculate total count
+	totalCount := len(productResponses)
+
+	// Apply pagination
+	var paginatedProducts []map[string]interface{}
+	if offset < totalCount {
+		end := offset + limit
+		if end > totalCount {
+			end = totalCount
+		}
+		paginatedProducts = productResponses[offset:end]
+	} else {
+		// Offset beyond available products
+		paginatedProducts = []map[string]interface{}{}
+	}
+
+	// Create response with pagination metadata (matching Central API format)
+	response := map[string]interface{}{
+		"products": paginatedProducts,
+		"pagination": map[string]interface{}{
+			"offset":      offset,
+			"limit":       limit,
+			"total_count": totalCount,
+			"has_more":    offset+limit < totalCount,
+		},
+	}
+
+	slog.Info("Successfully retrieved products from local cache",
+		"total_count", totalCount,
+		"returned_count", len(paginatedProducts),
+		"offset", offset,
+		"limit", limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetProduct handles GET /v1/store/inventory/{productId} (now using local cache)
+func (h *InventoryHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	productID := chi.URLParam(r, "productId")
+
+	slog.Info("Getting product for store from local cache", "product_id", productID, "remote_addr", r.RemoteAddr)
+
+	if h.notFoundCache.Contains(productID) {
+		slog.Debug("Product recently not found, skipping local storage", "product_id", productID)
+		h.writeErrorResponse(w, "product_not_found", "Product not found", http.StatusNotFound, map[string]string{"productId": productID})
+		return
+	}
+
+	product, err := h.localStorage.GetProduct(productID)
+	if err != nil {
+		slog.Error("Failed to get product from local storage", "product_id", productID, "error", err)
+
+		if errors.Is(err, storage.ErrNotFound) {
+			h.notFoundCache.Add(productID)
+			h.writeErrorResponse(w, "product_not_found", "Product not found", http.StatusNotFound, map[string]string{"productId": productID})
+			return
+		}
+
+		h.writeErrorResponse(w, "storage_error", "Failed to retrieve product", http.StatusInternalServerError, nil)
+		return
+	}
+
+	// Convert to consistent response format
+	productResponse := localProductResponse(*product)
+
+	slog.Info("Successfully retrieved product from local cache",
+		"product_id", productID,
+		"name", product.Name,
+		"available", product.Available,
+		"version", product.Version)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(productResponse)
+}
+
+// GetProductByBarcode handles GET /v1/store/inventory/by-barcode/{code} - looks
+// up a scanned barcode or alias in the local cache
+func (h *InventoryHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+
+	product, err := h.localStorage.GetProductByBarcode(code)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			slog.Info("No product for barcode in local cache", "barcode", code)
+			h.writeErrorResponse(w, "product_not_found", "No product has this barcode", http.StatusNotFound, map[string]string{"barcode": code})
+			return
+		}
+
+		slog.Error("Failed to look up barcode in local storage", "barcode", code, "error", err)
+		h.writeErrorResponse(w, "storage_error", "Failed to retrieve product", http.StatusInternalServerError, nil)
+		return
+	}
+
+	slog.Info("Successfully resolved barcode from local cache",
+		"barcode", code,
+		"product_id", product.ProductID,
+		"available", product.Available)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(localProductResponse(*product))
+}
+
+// localProductResponse converts a cached product to the response format shared
+// by the store's read endpoints
+func localProductResponse(product models.Product) map[string]interface{} {
+	productResponse := map[string]interface{}{
+		"productId":   product.ProductID,
+		"name":        product.Name,
+		"available":   product.Available,
+		"version":     product.Version,
+		"lastUpdated": product.LastUpdated.Format("2006-01-02T15:04:05Z07:00"),
+		"price":       product.Price,
+	}
+	if len(product.Prices) > 0 {
+		productResponse["prices"] = product.Prices
+	}
+	if product.Category != "" {
+		productResponse["category"] = product.Category
+	}
+	if product.Barcode != "" {
+		productResponse["barcode"] = product.Barcode
+	}
+	if len(product.BarcodeAliases) > 0 {
+		productResponse["barcodeAliases"] = product.BarcodeAliases
+	}
+	if len(product.Assets) > 0 {
+		productResponse["assets"] = product.Assets
+	}
+	return productResponse
+}
+
+// BatchGetProducts handles POST /v1/store/inventory/batch-get - reads several
+// products from the local cache and looks up cache misses in the central API
+// with a single batch request
+func (h *InventoryHandler) BatchGetProducts(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeErrorResponse(w, "invalid_request", "Invalid JSON in request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(req.ProductIDs) == 0 {
+		h.writeErrorResponse(w, "invalid_request", "productIds is required", http.StatusBadRequest, nil)
+		return
+	}
+	if len(req.ProductIDs) > maxBatchGetItems {
+		h.writeErrorResponse(w, "batch_too_large", "Too many product IDs in batch", http.StatusBadRequest, map[string]int{
+			"count":   len(req.ProductIDs),
+			"maximum": maxBatchGetItems,
+		})
+		return
+	}
+
+	slog.Info("Batch get for store from local cache", "count", len(req.ProductIDs), "remote_addr", r.RemoteAddr)
+
+	// Cache hits are answered locally; misses keep their request position so
+	// centrally found products can be slotted back in order
+	found := make(map[string]models.Product, len(req.ProductIDs))
+	var misses, knownMissing []string
+	seen := make(map[string]bool, len(req.ProductIDs))
+	for _, productID := range req.ProductIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+
+		// IDs recently unknown to the central API are not asked for again
+		if h.notFoundCache.Contains(productID) {
+			knownMissing = append(knownMissing, productID)
+			continue
+		}
+
+		product, err := h.localStorage.GetProduct(productID)
+		if err != nil {
+			misses = append(misses, productID)
+			continue
+		}
+		found[productID] = *product
+	}
+
+	response := models.BatchGetResponse{Products: []models.Product{}, Missing: append([]string{}, knownMissing...)}
+	if len(misses) > 0 {
+		centralResp, err := h.inventoryClient.BatchGetProductsCtx(r.Context(), misses)
+		if err != nil {
+			slog.Warn("Central batch get failed, answering from local cache only",
+				"cache_misses", len(misses),
+				"error", err)
+			response.Unavailable = misses
+		} else {
+			for _, product := range centralResp.Products {
+				found[product.ProductID] = product
+				// Fill the cache unless an event added the product meanwhile
+				if _, err := h.localStorage.GetProduct(product.ProductID); err == nil {
+					continue
+				}
+				if err := h.localStorage.UpsertProduct(product); err != nil {
+					slog.Warn("Failed to cache product from central batch get", "product_id", product.ProductID, "error", err)
+				}
+			}
+			response.Missing = append(response.Missing, centralResp.Missing...)
+			h.notFoundCache.Add(centralResp.Missing...)
+		}
+	}
+
+	for _, productID := range req.ProductIDs {
+		if product, ok := found[productID]; ok {
+			response.Products = append(response.Products, product)
+			delete(found, productID)
+		}
+	}
+
+	slog.Info("Batch get completed",
+		"requested", len(req.ProductIDs),
+		"found", len(response.Products),
+		"cache_misses", len(misses),
+		"missing", len(response.Missing),
+		"unavailable", len(response.Unavailable))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateInventory handles POST /v1/store/inventory/updates
+func (h *InventoryHandler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
+	var updateReq models.UpdateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&updateReq); err != nil {
+		slog.Error("Failed to decode update request", "error", err)
+		h.writeErrorResponse(w, "invalid_request", "Invalid request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if updateReq.StoreID == "" {
+		updateReq.StoreID = h.storeID
+	}
+	// The central API rejects malformed keys; a queued offline update would
+	// never replay
+	if !idempotency.Valid(updateReq.IdempotencyKey) {
+		h.writeErrorResponse(w, "invalid_idempotency_key", "idempotencyKey must be a UUIDv4 or a ULID", http.StatusBadRequest,
+			map[string]string{"field": "idempotencyKey"})
+		return
+	}
+
+	slog.Info("Processing inventory update for store",
+		"store_id", updateReq.StoreID,
+		"product_id", updateReq.ProductID,
+		"delta", updateReq.Delta,
+		"version", updateReq.Version,
+		"remote_addr", r.RemoteAddr,
+	)
+
+	// Reject obviously impossible sales without a round trip to the central API
+	if stdErr := h.checkLocalStock(updateReq); stdErr != nil {
+		h.writeStandardizedErrorResponse(w, stdErr, updateReq.ProductID)
+		return
+	}
+
+	updateResp, err := h.inventoryClient.UpdateInventoryCtx(r.Context(), updateReq)
+	if err != nil {
+		slog.Error("Failed to update inventory via central API",
+			"product_id", updateReq.ProductID,
+			"error", err,
+		)
+
+		// Central API unreachable: accept the write locally and replay it later
+		if h.writeQueue != nil && sync.IsOfflineError(err) && r.Context().Err() == nil {
+			h.queueOfflineUpdate(w, updateReq)
+			return
+		}
+
+		// Enhanced error handling with proper HTTP status codes
+		h.handleInventoryUpdateError(w, updateReq, err)
+		return
+	}
+
+	// Update local cache if the central update was successful and applied
+	if updateResp.Applied {
+		if err := h.syncManager.UpdateLocalProduct(
+			updateResp.ProductID,
+			updateResp.NewQuantity,
+			updateResp.NewVersion,
+			time.Now(),
+		); err != nil {
+			slog.Warn("Failed to update local cache after successful central update",
+				"product_id", updateResp.ProductID,
+				"error", err,
+			)
+		} else {
+			slog.Debug("Local cache updated after successful central update",
+				"product_id", updateResp.ProductID,
+				"new_quantity", updateResp.NewQuantity,
+				"new_version", updateResp.NewVersion,
+			)
+		}
+	}
+
+	slog.Info("Successfully updated inventory",
+		"product_id", updateReq.ProductID,
+		"new_quantity", updateResp.NewQuantity,
+		"new_version", updateResp.NewVersion,
+		"applied", updateResp.Applied,
+		"replayed", updateResp.Replayed,
+	)
+
+	if updateResp.Replayed {
+		w.Header().Set(idempotency.ReplayedHeader, "true")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updateResp)
+}
+
+// BatchUpdateInventory handles POST /v1/store/inventory/batch-updates
+func (h *InventoryHandler) BatchUpdateInventory(w http.ResponseWriter, r *http.Request) {
+	var batchReq models.BatchUpdateRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&batchReq); err != nil {
+		slog.Error("Failed to decode batch update request", "error", err)
+		h.writeErrorResponse(w, "invalid_request", "Invalid request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if batchReq.StoreID == "" {
+		batchReq.StoreID = h.storeID
+	}
+
+	slog.Info("Processing batch inventory update for store",
+		"store_id", batchReq.StoreID,
+		"update_count", len(batchReq.Updates),
+		"remote_addr", r.RemoteAddr,
+	)
+
+	// The central API rejects malformed keys; a queued offline update would
+	// never replay
+	for i, update := range batchReq.Updates {
+		if !idempotency.Valid(update.IdempotencyKey) {
+			h.writeErrorResponse(w, "invalid_idempotency_key", "idempotencyKey must be a UUIDv4 or a ULID", http.StatusBadRequest,
+				map[string]string{"field": fmt.Sprintf("updates[%d].idempotencyKey", i)})
+			return
+		}
+	}
+
+	batchResp, err := h.inventoryClient.BatchUpdateInventoryCtx(r.Context(), batchReq)
+	if err != nil {
+		slog.Error("Failed to batch update inventory via central API",
+			"store_id", batchReq.StoreID,
+			"error", err,
+		)
+		statusCode := http.StatusInternalServerError
+		if apiErr, ok := client.AsAPIError(err); ok {
+			statusCode = statusCodeForErrorType(apiErr.ErrorType)
+		}
+		h.writeErrorResponse(w, "batch_update_failed", "Failed to batch update inventory", statusCode, map[string]interface{}{
+			"storeId":     batchReq.StoreID,
+			"updateCount": len(batchReq.Updates),
+			"error":       err.Error(),
+		})
+		return
+	}
+
+	slog.Info("Successfully processed batch inventory update",
+		"store_id", batchReq.StoreID,
+		"total_count", batchResp.TotalCount,
+		"success_count", batchResp.SuccessCount,
+		"failure_count", batchResp.FailureCount,
+	)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(batchResp)
+}
+
+// checkLocalStock returns an insufficient_inventory error when the local cache
+// shows the sale cannot succeed. Products missing from the cache are forwarded
+// so the central API stays the source of truth. Products whose stock policy
+// allows backorders may sell down to their backorder limit instead of zero.
+func (h *InventoryHandler) checkLocalStock(updateReq models.UpdateRequest) *StandardizedError {
+	if h.stockCheckMode == StockCheckOff || updateReq.Delta >= 0 {
+		return nil
+	}
+
+	product, err := h.localStorage.GetProduct(updateReq.ProductID)
+	if err != nil {
+		return nil
+	}
+
+	// The policy is only read for sales that would take the stock below zero
+	floor := 0
+	if product.Available+updateReq.Delta < 0 {
+		floor = storage.StockPolicyFor(h.localStorage, product).StockFloor()
+	}
+	impossible := product.Available <= floor
+	if h.stockCheckMode == StockCheckStrict {
+		impossible = product.Available+updateReq.Delta < floor
+	}
+	if !impossible {
+		return nil
+	}
+
+	slog.Info("Rejected update by local stock check",
+		"product_id", updateReq.ProductID,
+		"delta", updateReq.Delta,
+		"local_available", product.Available,
+		"mode", h.stockCheckMode)
+
+	return &StandardizedError{
+		ErrorType:    client.ErrorTypeInsufficientInventory,
+		ErrorMessage: fmt.Sprintf("insufficient inventory: local stock %d, delta %d", product.Available, updateReq.Delta),
+		NewVersion:   product.Version,
+		NewQuantity:  product.Available,
+		StatusCode:   http.StatusUnprocessableEntity,
+	}
+}
+
+// queueOfflineUpdate buffers an update in the offline write queue and answers 202 Accepted
+func (h *InventoryHandler) queueOfflineUpdate(w http.ResponseWriter, updateReq models.UpdateRequest) {
+	product, err := h.writeQueue.Enqueue(updateReq)
+	if err != nil {
+		if errors.Is(err, sync.ErrInsufficientLocalStock) {
+			h.writeStandardizedErrorResponse(w, &StandardizedError{
+				ErrorType:    client.ErrorTypeInsufficientInventory,
+				ErrorMessage: err.Error(),
+				NewVersion:   product.Version,
+				NewQuantity:  product.Available,
+				StatusCode:   http.StatusUnprocessableEntity,
+			}, updateReq.ProductID)
+			return
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			h.writeStandardizedErrorResponse(w, &StandardizedError{
+				ErrorType:    client.ErrorTypeProductNotFound,
+				ErrorMessage: err.Error(),
+				NewQuantity:  -1,
+				StatusCode:   http.StatusNotFound,
+			}, updateReq.ProductID)
+			return
+		}
+
+		slog.Error("Failed to queue offline inventory update",
+			"product_id", updateReq.ProductID,
+			"error", err,
+		)
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "server_error",
+			ErrorMessage: "Central API unavailable and update could not be queued",
+			NewQuantity:  -1,
+			StatusCode:   http.StatusServiceUnavailable,
+		}, updateReq.ProductID)
+		return
+	}
+
+	response := map[string]interface{}{
+		"productId":      updateReq.ProductID,
+		"applied":        false,
+		"queued":         true,
+		"newQuantity":    product.Available,
+		"newVersion":     product.Version,
+		"idempotencyKey": updateReq.IdempotencyKey,
+		"lastUpdated":    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetOfflineQueueStatus handles GET /v1/store/offline-queue
+func (h *InventoryHandler) GetOfflineQueueStatus(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Getting offline queue status", "remote_addr", r.RemoteAddr)
+
+	if h.writeQueue == nil {
+		h.writeErrorResponse(w, "offline_queue_disabled", "Offline write queue is disabled", http.StatusNotFound, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.writeQueue.Status())
+}
+
+// ReplayOfflineQueue handles POST /v1/store/offline-queue/replay
+func (h *InventoryHandler) ReplayOfflineQueue(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Offline queue replay requested", "remote_addr", r.RemoteAddr)
+
+	if h.writeQueue == nil {
+		h.writeErrorResponse(w, "offline_queue_disabled", "Offline write queue is disabled", http.StatusNotFound, nil)
+		return
+	}
+
+	if err := h.writeQueue.Replay(r.Context()); err != nil {
+		h.writeErrorResponse(w, "replay_incomplete", "Central API unavailable, pending writes kept", http.StatusServiceUnavailable, map[string]interface{}{
+			"error":   err.Error(),
+			"pending": h.writeQueue.PendingCount(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(h.writeQueue.Status())
+}
+
+// GetSyncStatus handles GET /v1/store/sync/status
+func (h *InventoryHandler) GetSyncStatus(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Getting sync status", "remote_addr", r.RemoteAddr)
+
+	status := h.syncManager.GetSyncStatus()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(status)
+}
+
+// ForceSync handles POST /v1/store/sync/force - refused with 409 while sync
+// is paused unless ?force=true is given
+func (h *InventoryHandler) ForceSync(w http.ResponseWriter, r *http.Request) {
+	overridePause := r.URL.Query().Get("force") == "true"
+	slog.Info("Force sync requested", "remote_addr", r.RemoteAddr, "override_pause", overridePause)
+
+	ctx := r.Context()
+	if err := h.syncManager.ForceSync(ctx, overridePause); err != nil {
+		if errors.Is(err, sync.ErrSyncPaused) {
+			h.writeErrorResponse(w, "sync_paused", "Sync is paused; add ?force=true to sync anyway", http.StatusConflict, h.syncManager.GetSyncStatus().Paused)
+			return
+		}
+		slog.Error("Force sync failed", "error", err)
+		h.writeErrorResponse(w, "sync_failed", "Force sync failed", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	response := map[string]interface{}{
+		"message": "Force sync completed successfully",
+		"status":  h.syncManager.GetSyncStatus(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// VerifySync handles POST /v1/store/sync/verify - compares the local cache
+// against the central API and returns the divergence report. An empty body
+// runs a full comparison without healing.
+func (h *InventoryHandler) VerifySync(w http.ResponseWriter, r *http.Request) {
+	slog.Info("Sync verification requested", "remote_addr", r.RemoteAddr)
+
+	if h.reconciler == nil {
+		h.writeErrorResponse(w, "verification_disabled", "Sync verification is not configured", http.StatusNotFound, nil)
+		return
+	}
+
+	var options sync.ReconcileOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		h.writeErrorResponse(w, "invalid_request", "Invalid JSON in request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if options.SampleSize < 0 {
+		h.writeErrorResponse(w, "invalid_request", "sampleSize must be zero (full comparison) or positive", http.StatusBadRequest, nil)
+		return
+	}
+
+	report, err := h.reconciler.Verify(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, sync.ErrReconcileInProgress) {
+			h.writeErrorResponse(w, "verification_in_progress", "A sync verification is already running", http.StatusConflict, nil)
+			return
+		}
+		slog.Error("Sync verification failed", "error", err)
+		h.writeErrorResponse(w, "verification_failed", "Sync verification failed", http.StatusBadGateway, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetLastVerification handles GET /v1/store/sync/verify - returns the report
+// of the most recent on-demand or scheduled verification
+func (h *InventoryHandler) GetLastVerification(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Getting last sync verification", "remote_addr", r.RemoteAddr)
+
+	var report *sync.ReconcileReport
+	if h.reconciler != nil {
+		report = h.reconciler.LastReport()
+	}
+	if report == nil {
+		h.writeErrorResponse(w, "no_verification", "No sync verification has run yet", http.StatusNotFound, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetCacheStats handles GET /v1/store/cache/stats
+func (h *InventoryHandler) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	slog.Debug("Getting cache stats", "remote_addr", r.RemoteAddr)
+
+	stats, err := h.localStorage.GetStorageStats()
+	if err != nil {
+		slog.Error("Failed to get storage stats", "error", err)
+		h.writeErrorResponse(w, "stats_error", "Failed to get cache stats", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// ErrorResponse represents the standard error response format
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+type ErrorDetail struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// handleInventoryUpdateError handles errors from inventory updates with proper HTTP status codes
+func (h *InventoryHandler) handleInventoryUpdateError(w http.ResponseWriter, updateReq models.UpdateRequest, err error) {
+	errorStr := err.Error()
+
+	slog.Info("Enhanced error handling called",
+		"product_id", updateReq.ProductID,
+		"error_string", errorStr)
+
+	// Typed errors from the shared client carry the decoded central API response
+	if apiErr, ok := client.AsAPIError(err); ok {
+		h.writeStandardizedErrorResponse(w, standardizedErrorFromAPIError(apiErr), updateReq.ProductID)
+		return
+	}
+
+	if errors.Is(err, storage.ErrNotFound) {
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    client.ErrorTypeProductNotFound,
+			ErrorMessage: errorStr,
+			StatusCode:   http.StatusNotFound,
+		}, updateReq.ProductID)
+		return
+	}
+
+	// Compatibility: try to parse a structured error embedded in the error string
+	if centralAPIError := h.parseCentralAPIError(errorStr); centralAPIError != nil {
+		slog.Info("Successfully parsed central API error, returning standardized response",
+			"error_type", centralAPIError.ErrorType,
+			"status_code", centralAPIError.StatusCode)
+		// Return the error in the standardized format expected by frontend
+		h.writeStandardizedErrorResponse(w, centralAPIError, updateReq.ProductID)
+		return
+	}
+
+	// Fallback: parse error string for known patterns
+	switch {
+	case strings.Contains(errorStr, "version conflict"):
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "version_conflict",
+			ErrorMessage: errorStr,
+			StatusCode:   http.StatusConflict,
+		}, updateReq.ProductID)
+
+	case strings.Contains(errorStr, "insufficient inventory"):
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "insufficient_inventory",
+			ErrorMessage: errorStr,
+			StatusCode:   http.StatusUnprocessableEntity,
+		}, updateReq.ProductID)
+
+	case strings.Contains(errorStr, "product not found"):
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "product_not_found",
+			ErrorMessage: errorStr,
+			StatusCode:   http.StatusNotFound,
+		}, updateReq.ProductID)
+
+	case strings.Contains(errorStr, "invalid"):
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "invalid_request",
+			ErrorMessage: errorStr,
+			StatusCode:   http.StatusBadRequest,
+		}, updateReq.ProductID)
+
+	default:
+		// Actual server error
+		h.writeStandardizedErrorResponse(w, &StandardizedError{
+			ErrorType:    "server_error",
+			ErrorMessage: "Internal server error occurred",
+			StatusCode:   http.StatusInternalServerError,
+		}, updateReq.ProductID)
+	}
+}
+
+// StandardizedError represents a parsed error with proper categorization
+type StandardizedError struct {
+	ErrorType    string `json:"errorType"`
+	ErrorMessage string `json:"errorMessage"`
+	ProductID    string `json:"productId,omitempty"`
+	NewVersion   int    `json:"newVersion,omitempty"`
+	NewQuantity  int    `json:"newQuantity,omitempty"`
+	LastUpdated  string `json:"lastUpdated,omitempty"`
+	StatusCode   int    `json:"-"` // Not included in JSON response
+}
+
+// standardizedErrorFromAPIError maps a typed central API error to the store error format
+func standardizedErrorFromAPIError(apiErr *client.APIError) *StandardizedError {
+	return &StandardizedError{
+		ErrorType:    apiErr.ErrorType,
+		ErrorMessage: apiErr.ErrorMessage,
+		ProductID:    apiErr.ProductID,
+		NewVersion:   apiErr.NewVersion,
+		NewQuantity:  apiErr.NewQuantity,
+		LastUpdated:  apiErr.LastUpdated,
+		StatusCode:   statusCodeForErrorType(apiErr.ErrorType),
+	}
+}
+
+// statusCodeForErrorType maps central API error types to store HTTP status codes
+func statusCodeForErrorType(errorType string) int {
+	switch errorType {
+	case client.ErrorTypeVersionConflict:
+		return http.StatusConflict
+	case client.ErrorTypeConditionFailed:
+		return http.StatusPreconditionFailed
+	case client.ErrorTypeInsufficientInventory:
+		return http.StatusUnprocessableEntity
+	case client.ErrorTypeProductNotFound, "not_found":
+		return http.StatusNotFound
+	case client.ErrorTypeInvalidRequest, "invalid_delta", "missing_product_id":
+		return http.StatusBadRequest
+	case client.ErrorTypeRateLimited, client.ErrorTypeStocktakeHold:
+		return http.StatusTooManyRequests
+	case client.ErrorTypeStocktakeFrozen:
+		return http.StatusLocked
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// parseCentralAPIError attempts to parse structured error responses from central API.
+// Deprecated: kept as a compatibility shim for errors that are not *client.APIError.
+func (h *InventoryHandler) parseCentralAPIError(errorStr string) *StandardizedError {
+	slog.Debug("Parsing central API error", "error_string", errorStr)
+
+	// Look for the pattern "request failed with status XXX: {JSON}"
+	statusPattern := "request failed with status "
+	statusIndex := strings.Index(errorStr, statusPattern)
+	if statusIndex == -1 {
+		slog.Debug("No status pattern found in error string")
+		return nil
+	}
+
+	// Find the JSON part after the status
+	jsonStart := strings.Index(errorStr[statusIndex:], "{")
+	if jsonStart == -1 {
+		slog.Debug("No JSON start found after status pattern")
+		return nil
+	}
+	jsonStart += statusIndex
+
+	// Find the end of JSON (look for the last } before \n)
+	jsonEnd := strings.Index(errorStr[jsonStart:], "\\n")
+	if jsonEnd == -1 {
+		jsonEnd = len(errorStr)
+	} else {
+		jsonEnd += jsonStart
+	}
+
+	// Find the actual end of JSON
+	jsonEndBrace := strings.LastIndex(errorStr[jsonStart:jsonEnd], "}")
+	if jsonEndBrace == -1 {
+		slog.Debug("No JSON end found")
+		return nil
+	}
+	jsonEnd = jsonStart + jsonEndBrace + 1
+
+	jsonStr := errorStr[jsonStart:jsonEnd]
+	slog.Debug("Extracted JSON from error", "json", jsonStr)
+
+	// Try to parse as UpdateResponse (which contains error information)
+	var updateResp struct {
+		ProductID    string `json:"productId"`
+		Applied      bool   `json:"applied"`
+		NewQuantity  int    `json:"newQuantity"`
+		NewVersion   int    `json:"newVersion"`
+		ErrorType    string `json:"errorType"`
+		ErrorMessage string `json:"errorMessage"`
+		LastUpdated  string `json:"lastUpdated"`
+	}
+
+	if err := json.Unmarshal([]byte(jsonStr), &updateResp); err != nil {
+		slog.Debug("Failed to parse JSON as UpdateResponse", "error", err, "json", jsonStr)
+		return nil
+	}
+
+	if updateResp.ErrorType == "" {
+		slog.Debug("No errorType found in parsed response")
+		return nil
+	}
+
+	// Map error types to proper HTTP status codes
+	statusCode := statusCodeForErrorType(updateResp.ErrorType)
+
+	slog.Info("Successfully parsed central API error",
+		"error_type", updateResp.ErrorType,
+		"status_code", statusCode,
+		"product_id", updateResp.ProductID,
+		"new_version", updateResp.NewVersion)
+
+	return &StandardizedError{
+		ErrorType:    updateResp.ErrorType,
+		ErrorMessage: updateResp.ErrorMessage,
+		ProductID:    updateResp.ProductID,
+		NewVersion:   updateResp.NewVersion,
+		NewQuantity:  updateResp.NewQuantity,
+		LastUpdated:  updateResp.LastUpdated,
+		StatusCode:   statusCode,
+	}
+}
+
+// writeStandardizedErrorResponse writes error response in the format expected by frontend
+func (h *InventoryHandler) writeStandardizedErrorResponse(w http.ResponseWriter, stdErr *StandardizedError, productID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(stdErr.StatusCode)
+
+	// Create response in the format expected by frontend
+	response := map[string]interface{}{
+		"productId":    productID,
+		"errorType":    stdErr.ErrorType,
+		"errorMessage": stdErr.ErrorMessage,
+	}
+
+	// Include additional fields if available
+	if stdErr.NewVersion > 0 {
+		response["newVersion"] = stdErr.NewVersion
+	}
+	if stdErr.NewQuantity >= 0 {
+		response["newQuantity"] = stdErr.NewQuantity
+	}
+	if stdErr.LastUpdated != "" {
+		response["lastUpdated"] = stdErr.LastUpdated
+	}
+
+	json.NewEncoder(w).Encode(response)
+
+	slog.Info("Returned standardized error response",
+		"product_id", productID,
+		"error_type", stdErr.ErrorType,
+		"status_code", stdErr.StatusCode,
+		"has_version", stdErr.NewVersion > 0,
+		"has_quantity", stdErr.NewQuantity >= 0)
+}
+
+// writeErrorResponse writes a structured JSON error response (legacy format)
+func (h *InventoryHandler) writeErrorResponse(w http.ResponseWriter, code, message string, statusCode int, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	errorResponse := ErrorResponse{
+		Error: ErrorDetail{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	}
+
+	json.NewEncoder(w).Encode(errorResponse)
+}
+
+// writeSimpleErrorResponse writes a simple error response (for backward compatibility)
+func (h *InventoryHandler) writeSimpleErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+	h.writeErrorResponse(w, "internal_error", message, statusCode, nil)
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/sync"
+	"github.com/melibackend/shared/testutil"
+	"github.com/melibackend/store/internal/returns"
+)
+
+// newTestHandler returns a handler for store-001 of api with an empty local
+// cache and a returns ledger, both under a temp dir
+func newTestHandler(t *testing.T, api *testutil.FakeCentralAPI) (*InventoryHandler, *storage.MemoryStorage) {
+	t.Helper()
+	dir := t.TempDir()
+	localStorage := storage.NewMemoryStorage(dir)
+	if err := localStorage.Initialize(); err != nil {
+		t.Fatalf("Failed to initialize local storage: %v", err)
+	}
+
+	inventoryClient := api.Client()
+	inventoryClient.SetStoreID("store-001")
+	handler := NewInventoryHandler("store-001", inventoryClient, localStorage,
+		sync.NewEventSyncManager(inventoryClient, localStorage, sync.EventSyncConfig{}))
+
+	ledger, err := returns.NewLedger(dir)
+	if err != nil {
+		t.Fatalf("Failed to create returns ledger: %v", err)
+	}
+	handler.SetReturnsLedger(ledger)
+	return handler, localStorage
+}
+
+// cacheProducts puts products in the local cache
+func cacheProducts(t *testing.T, localStorage *storage.MemoryStorage, products ...models.Product) {
+	t.Helper()
+	for _, product := range products {
+		if err := localStorage.UpsertProduct(product); err != nil {
+			t.Fatalf("Failed to cache %s: %v", product.ProductID, err)
+		}
+	}
+}
+
+// serve sends a request through a router with the routes of the server that
+// take URL parameters
+func serve(handler *InventoryHandler, method, target, body string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Get("/v1/store/inventory/by-barcode/{code}", handler.GetProductByBarcode)
+	r.Get("/v1/store/inventory/{productId}", handler.GetProduct)
+	r.Post("/v1/store/inventory/updates", handler.UpdateInventory)
+	r.Post("/v1/store/sync/force", handler.ForceSync)
+	r.Post("/v1/store/sync/pause", handler.PauseSync)
+	r.Post("/v1/store/sync/resume", handler.ResumeSync)
+	r.Get("/v1/store/cache/products/{productId}/raw", handler.GetRawCachedProduct)
+	r.Get("/v1/store/cache/dump", handler.DumpCache)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rr
+}
+
+// errorCode returns the code of an error response
+func errorCode(rr *httptest.ResponseRecorder) string {
+	var response ErrorResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	return response.Error.Code
+}
+
+func TestCheckLocalStock(t *testing.T) {
+	backorder := &models.StockPolicy{AllowBackorder: true, MaxBackorder: 5}
+	products := []models.Product{
+		{ProductID: "SKU-LOW", Available: 2, Version: 1},
+		{ProductID: "SKU-OUT", Available: 0, Version: 1},
+		{ProductID: "SKU-BACKORDER", Available: 0, Version: 1, StockPolicy: backorder},
+		{ProductID: "SKU-CATEGORY", Available: -5, Version: 1, Category: "preorders"},
+	}
+
+	tests := []struct {
+		name      string
+		mode      string
+		productID string
+		delta     int
+		rejected  bool
+	}{
+		{"off forwards a sale of an empty product", "off", "SKU-OUT", -1, false},
+		{"lenient allows a sale beyond stock", "lenient", "SKU-LOW", -3, false},
+		{"lenient rejects a sale of an empty product", "lenient", "SKU-OUT", -1, true},
+		{"strict allows a sale within stock", "strict", "SKU-LOW", -2, false},
+		{"strict rejects a sale beyond stock", "strict", "SKU-LOW", -3, true},
+		{"restocks are never checked", "strict", "SKU-OUT", 4, false},
+		{"uncached products are left to central", "strict", "SKU-404", -1, false},
+		{"lenient allows a backorder", "lenient", "SKU-BACKORDER", -3, false},
+		{"strict allows a backorder within the limit", "strict", "SKU-BACKORDER", -5, false},
+		{"strict rejects a backorder beyond the limit", "strict", "SKU-BACKORDER", -6, true},
+		{"lenient rejects at the category backorder limit", "lenient", "SKU-CATEGORY", -1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, localStorage := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+			cacheProducts(t, localStorage, products...)
+			if err := localStorage.SyncCategories([]models.Category{{ID: "preorders", Name: "Preorders", StockPolicy: backorder}}); err != nil {
+				t.Fatalf("Failed to cache categories: %v", err)
+			}
+			handler.SetStockCheckMode(tt.mode)
+
+			stdErr := handler.checkLocalStock(models.UpdateRequest{ProductID: tt.productID, Delta: tt.delta, Version: 1})
+			if rejected := stdErr != nil; rejected != tt.rejected {
+				t.Fatalf("Expected rejected=%v, got %+v", tt.rejected, stdErr)
+			}
+			if stdErr != nil && stdErr.StatusCode != http.StatusUnprocessableEntity {
+				t.Errorf("Expected status 422, got %d", stdErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestUpdateInventory_LocalStockCheckOffByDefault(t *testing.T) {
+	// The cache lags central: it still has the product sold out
+	api := testutil.StartFakeCentralAPI(t, models.Product{ProductID: "SKU-001", Available: 5, Version: 2})
+	handler, localStorage := newTestHandler(t, api)
+	cacheProducts(t, localStorage, models.Product{ProductID: "SKU-001", Available: 0, Version: 1})
+
+	body := `{"productId":"SKU-001","delta":-1,"version":2,"idempotencyKey":"01J00000000000000000000001"}`
+	rr := serve(handler, "POST", "/v1/store/inventory/updates", body)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected the sale forwarded to central, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if product, _ := api.Product("SKU-001"); product.Available != 4 {
+		t.Errorf("Expected central at 4 units, got %d", product.Available)
+	}
+
+	// Opting in rejects the sale on the stale cache without asking central
+	handler.SetStockCheckMode("lenient")
+	cacheProducts(t, localStorage, models.Product{ProductID: "SKU-001", Available: 0, Version: 3})
+	body = `{"productId":"SKU-001","delta":-1,"version":3,"idempotencyKey":"01J00000000000000000000002"}`
+	rr = serve(handler, "POST", "/v1/store/inventory/updates", body)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response StandardizedError
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.ErrorType != "insufficient_inventory" {
+		t.Errorf("Expected insufficient_inventory, got %s", rr.Body.String())
+	}
+	if got := api.Requests("/v1/inventory/updates"); got != 1 {
+		t.Errorf("Expected only the first sale sent to central, got %d", got)
+	}
+}
+
+func TestSetStockCheckMode_UnknownModeIsOff(t *testing.T) {
+	handler, _ := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+	if handler.stockCheckMode != StockCheckOff {
+		t.Fatalf("Expected the check off by default, got %s", handler.stockCheckMode)
+	}
+
+	handler.SetStockCheckMode("strict")
+	handler.SetStockCheckMode("paranoid")
+	if handler.stockCheckMode != StockCheckOff {
+		t.Errorf("Expected an unknown mode to turn the check off, got %s", handler.stockCheckMode)
+	}
+}
+
+func TestGetProductByBarcode(t *testing.T) {
+	handler, localStorage := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+	cacheProducts(t, localStorage, models.Product{
+		ProductID: "SKU-001", Name: "Widget", Available: 3, Version: 1,
+		Barcode: "7501234567890", BarcodeAliases: []string{"012345678905"},
+	})
+
+	for _, code := range []string{"7501234567890", "012345678905"} {
+		rr := serve(handler, "GET", "/v1/store/inventory/by-barcode/"+code, "")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Barcode %s: expected status 200, got %d: %s", code, rr.Code, rr.Body.String())
+		}
+		var product map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &product)
+		if product["productId"] != "SKU-001" {
+			t.Errorf("Barcode %s: expected SKU-001, got %v", code, product["productId"])
+		}
+	}
+
+	rr := serve(handler, "GET", "/v1/store/inventory/by-barcode/0000000000000", "")
+	if rr.Code != http.StatusNotFound || errorCode(rr) != "product_not_found" {
+		t.Errorf("Expected 404 product_not_found for an unknown barcode, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestGetProduct_NotFoundCache(t *testing.T) {
+	handler, localStorage := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+	notFoundCache := NewNotFoundCache(time.Minute, 10)
+	handler.SetNotFoundCache(notFoundCache)
+
+	rr := serve(handler, "GET", "/v1/store/inventory/SKU-001", "")
+	if rr.Code != http.StatusNotFound || errorCode(rr) != "product_not_found" {
+		t.Fatalf("Expected 404 product_not_found, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !notFoundCache.Contains("SKU-001") {
+		t.Fatal("Expected the miss to be cached")
+	}
+
+	// Cached misses skip local storage until an event changes the product
+	cacheProducts(t, localStorage, models.Product{ProductID: "SKU-001", Available: 3, Version: 1})
+	if rr := serve(handler, "GET", "/v1/store/inventory/SKU-001", ""); rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected the cached miss answered, got %d", rr.Code)
+	}
+
+	notFoundCache.Invalidate([]models.Event{{EventType: models.EventTypeProductCreated, ProductID: "SKU-001"}})
+	if rr := serve(handler, "GET", "/v1/store/inventory/SKU-001", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected the product after its event, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestNotFoundCache_Invalidate(t *testing.T) {
+	notFoundCache := NewNotFoundCache(time.Minute, 10)
+	notFoundCache.Add("SKU-001", "SKU-002", "SKU-003")
+
+	notFoundCache.Invalidate([]models.Event{
+		{EventType: models.EventTypeProductDeleted, ProductID: "SKU-001"},
+		{EventType: models.EventTypeProductUpdated, ProductID: "SKU-002"},
+	})
+	if !notFoundCache.Contains("SKU-001") || notFoundCache.Contains("SKU-002") || !notFoundCache.Contains("SKU-003") {
+		t.Error("Expected only the updated product dropped")
+	}
+
+	// A full sync drops every entry
+	notFoundCache.Invalidate(nil)
+	if notFoundCache.Contains("SKU-001") || notFoundCache.Contains("SKU-003") {
+		t.Error("Expected a full sync to clear the cache")
+	}
+
+	if NewNotFoundCache(0, 10) != nil {
+		t.Error("Expected a zero TTL to disable the cache")
+	}
+}
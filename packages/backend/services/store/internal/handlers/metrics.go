@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GetMetrics handles GET /metrics - the event sync lag and throughput in the
+// Prometheus text format, for scrapers without an OpenTelemetry collector
+func (h *InventoryHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	status := h.syncManager.GetSyncStatus()
+
+	var b strings.Builder
+	gauge := func(name, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s{store_id=%q} %v\n", name, help, name, name, h.storeID, value)
+	}
+	counter := func(name, help string, value interface{}) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n%s{store_id=%q} %v\n", name, help, name, name, h.storeID, value)
+	}
+
+	if metrics := status.Metrics; metrics != nil {
+		fallbackMode := 0
+		if metrics.FallbackMode {
+			fallbackMode = 1
+		}
+		gauge("store_sync_last_applied_offset", "Next central event offset the local cache will apply", metrics.LastAppliedOffset)
+		gauge("store_sync_central_offset", "Next offset the central API will assign, as last reported", metrics.CentralOffset)
+		gauge("store_sync_offset_lag", "Central events not yet applied to the local cache", metrics.OffsetLag)
+		counter("store_sync_events_applied_total", "Central events applied to the local cache", metrics.EventsApplied)
+		gauge("store_sync_events_per_second", "Events applied per second over the last minute", metrics.EventsPerSecond)
+		gauge("store_sync_fallback_mode", "1 while event sync runs in full sync fallback mode", fallbackMode)
+		gauge("store_sync_consecutive_failures", "Event polls that failed in a row", metrics.ConsecutiveFailures)
+		counter("store_sync_event_bytes_received_total", "Event page bytes received from the central API, as sent over the wire", metrics.EventBytesReceived)
+		counter("store_sync_event_bytes_decoded_total", "Event page bytes received from the central API, after decompression", metrics.EventBytesDecoded)
+	}
+	lastSuccess := 0
+	if status.LastSyncSuccess {
+		lastSuccess = 1
+	}
+	gauge("store_sync_last_success", "1 when the last sync succeeded", lastSuccess)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(b.String()))
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// NotFoundCache remembers product IDs the store could not find, so clients
+// polling unknown SKUs get their 404 without a storage lookup or a central
+// round trip. Entries expire after the TTL and are dropped as soon as an event
+// for the product, such as product_created, or a full sync is applied. Once
+// full, new IDs are not remembered until entries expire.
+type NotFoundCache struct {
+	mutex      sync.Mutex
+	entries    map[string]time.Time // Product ID -> expiry
+	ttl        time.Duration
+	maxEntries int
+}
+
+// NewNotFoundCache creates a not-found cache; a zero TTL returns nil, which
+// turns it off
+func NewNotFoundCache(ttl time.Duration, maxEntries int) *NotFoundCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxEntries < 1 {
+		maxEntries = 10000
+	}
+	return &NotFoundCache{
+		entries:    make(map[string]time.Time),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// Contains reports whether productID is known not to exist
+func (c *NotFoundCache) Contains(productID string) bool {
+	if c == nil {
+		return false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	expiresAt, ok := c.entries[productID]
+	if !ok {
+		return false
+	}
+	if !time.Now().Before(expiresAt) {
+		delete(c.entries, productID)
+		return false
+	}
+	return true
+}
+
+// Add remembers product IDs that were not found
+func (c *NotFoundCache) Add(productIDs ...string) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now()
+	for _, productID := range productIDs {
+		if _, ok := c.entries[productID]; !ok && len(c.entries) >= c.maxEntries {
+			for id, expiresAt := range c.entries {
+				if !now.Before(expiresAt) {
+					delete(c.entries, id)
+				}
+			}
+			if len(c.entries) >= c.maxEntries {
+				return
+			}
+		}
+		c.entries[productID] = now.Add(c.ttl)
+	}
+}
+
+// Invalidate drops the products applied events created or changed; nil
+// events, sent after a full sync, drop every entry. It is the sync manager's
+// change listener.
+func (c *NotFoundCache) Invalidate(events []models.Event) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if events == nil {
+		if len(c.entries) > 0 {
+			slog.Debug("Clearing not-found cache after full sync", "entries", len(c.entries))
+		}
+		c.entries = make(map[string]time.Time)
+		return
+	}
+	for _, event := range events {
+		if event.EventType != models.EventTypeProductDeleted {
+			delete(c.entries, event.ProductID)
+		}
+	}
+}
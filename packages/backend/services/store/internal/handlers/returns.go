@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/store/internal/returns"
+)
+
+// returnUpdateReason labels restocks from returns on the central event stream
+const returnUpdateReason = "return"
+
+// ReturnRequest is a customer return to process
+type ReturnRequest struct {
+	ReturnID    string `json:"returnId"`
+	ProductID   string `json:"productId"`
+	Quantity    int    `json:"quantity"`
+	Disposition string `json:"disposition"` // restock or damaged
+	Reason      string `json:"reason,omitempty"`
+}
+
+// SetReturnsLedger enables customer returns processing
+func (h *InventoryHandler) SetReturnsLedger(ledger *returns.Ledger) {
+	h.returnsLedger = ledger
+}
+
+// ProcessReturn handles POST /v1/store/inventory/returns - records a customer
+// return and either restocks the units through the central API or writes them
+// off as damaged. Retrying with the same returnId returns the recorded return.
+func (h *InventoryHandler) ProcessReturn(w http.ResponseWriter, r *http.Request) {
+	var returnReq ReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&returnReq); err != nil {
+		slog.Error("Failed to decode return request", "error", err)
+		h.writeErrorResponse(w, "invalid_request", "Invalid request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if issues := validateReturnRequest(returnReq); len(issues) > 0 {
+		h.writeErrorResponse(w, "invalid_request", "Request validation failed", http.StatusBadRequest, issues)
+		return
+	}
+
+	if recorded, ok := h.returnsLedger.Get(returnReq.ReturnID); ok {
+		if recorded.ProductID != returnReq.ProductID || recorded.Quantity != returnReq.Quantity || recorded.Disposition != returnReq.Disposition {
+			h.writeErrorResponse(w, "return_id_conflict", "Return ID already used for a different return", http.StatusConflict, recorded)
+			return
+		}
+		slog.Info("Return already processed, returning recorded result", "return_id", returnReq.ReturnID)
+		h.writeJSON(w, http.StatusOK, recorded)
+		return
+	}
+
+	record := returns.Return{
+		ReturnID:    returnReq.ReturnID,
+		ProductID:   returnReq.ProductID,
+		Quantity:    returnReq.Quantity,
+		Disposition: returnReq.Disposition,
+		Reason:      returnReq.Reason,
+	}
+
+	if returnReq.Disposition == returns.DispositionRestock {
+		updateResp, err := h.restockReturn(r, returnReq)
+		if err != nil {
+			slog.Error("Failed to restock returned units via central API",
+				"return_id", returnReq.ReturnID,
+				"product_id", returnReq.ProductID,
+				"error", err,
+			)
+			h.handleInventoryUpdateError(w, models.UpdateRequest{ProductID: returnReq.ProductID}, err)
+			return
+		}
+		record.NewVersion = updateResp.NewVersion
+		record.NewQuantity = updateResp.NewQuantity
+	}
+
+	record, err := h.returnsLedger.Record(record)
+	if err != nil {
+		// The return is processed and kept in memory; only the save failed
+		slog.Error("Failed to save returns ledger", "return_id", record.ReturnID, "error", err)
+	}
+
+	slog.Info("Processed customer return",
+		"return_id", record.ReturnID,
+		"product_id", record.ProductID,
+		"quantity", record.Quantity,
+		"disposition", record.Disposition,
+		"new_quantity", record.NewQuantity,
+	)
+
+	h.writeJSON(w, http.StatusCreated, record)
+}
+
+// restockReturn adds the returned units back through the central API, retrying
+// version conflicts, and refreshes the local cache with the result
+func (h *InventoryHandler) restockReturn(r *http.Request, returnReq ReturnRequest) (*models.UpdateResponse, error) {
+	version, err := h.currentVersion(r, returnReq.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := h.inventoryClient.UpdateInventoryWithRetryCtx(r.Context(), models.UpdateRequest{
+		StoreID:        h.storeID,
+		ProductID:      returnReq.ProductID,
+		Delta:          returnReq.Quantity,
+		Version:        version,
+		IdempotencyKey: idempotency.DeriveKey("return", returnReq.ReturnID),
+		Reason:         returnUpdateReason,
+	}, client.DefaultRetryOptions())
+	if outcome.Status != client.OutcomeApplied {
+		return nil, outcome.Err
+	}
+
+	updateResp := outcome.Response
+	if err := h.syncManager.UpdateLocalProduct(updateResp.ProductID, updateResp.NewQuantity, updateResp.NewVersion, time.Now()); err != nil {
+		slog.Warn("Failed to update local cache after restocking a return",
+			"product_id", updateResp.ProductID,
+			"error", err,
+		)
+	}
+	return updateResp, nil
+}
+
+// currentVersion returns the product's version from the local cache, asking
+// the central API when the product is not cached. A product central does not
+// know either is reported as storage.ErrNotFound.
+func (h *InventoryHandler) currentVersion(r *http.Request, productID string) (int, error) {
+	if product, err := h.localStorage.GetProduct(productID); err == nil {
+		return product.Version, nil
+	}
+
+	versions, err := h.inventoryClient.GetProductVersionsCtx(r.Context(), []string{productID})
+	if err != nil {
+		return 0, err
+	}
+	current, ok := versions.Versions[productID]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", storage.ErrNotFound, productID)
+	}
+	return current.Version, nil
+}
+
+// GetReturnStats handles GET /v1/store/inventory/returns/stats - per-product
+// return statistics, optionally for one productId
+func (h *InventoryHandler) GetReturnStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.returnsLedger.Stats(r.URL.Query().Get("productId"))
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"stats": stats,
+		"count": len(stats),
+	})
+}
+
+// validateReturnRequest returns the request's field issues, if any
+func validateReturnRequest(returnReq ReturnRequest) map[string]string {
+	issues := make(map[string]string)
+	if returnReq.ReturnID == "" {
+		issues["returnId"] = "is required"
+	}
+	if returnReq.ProductID == "" {
+		issues["productId"] = "is required"
+	}
+	if returnReq.Quantity < 1 {
+		issues["quantity"] = "must be at least 1"
+	}
+	if returnReq.Disposition != returns.DispositionRestock && returnReq.Disposition != returns.DispositionDamaged {
+		issues["disposition"] = fmt.Sprintf("must be %s or %s", returns.DispositionRestock, returns.DispositionDamaged)
+	}
+	return issues
+}
+
+// writeJSON writes a JSON response with the given status
+func (h *InventoryHandler) writeJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
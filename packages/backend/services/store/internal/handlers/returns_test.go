@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/testutil"
+	"github.com/melibackend/store/internal/returns"
+)
+
+func postReturn(handler *InventoryHandler, returnReq ReturnRequest) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(returnReq)
+	rr := httptest.NewRecorder()
+	handler.ProcessReturn(rr, httptest.NewRequest("POST", "/v1/store/inventory/returns", bytes.NewReader(body)))
+	return rr
+}
+
+func TestProcessReturn_RestocksUncachedProduct(t *testing.T) {
+	api := testutil.StartFakeCentralAPI(t, models.Product{ProductID: "SKU-001", Available: 4, Version: 2})
+	handler, _ := newTestHandler(t, api)
+
+	rr := postReturn(handler, ReturnRequest{ReturnID: "ret-1", ProductID: "SKU-001", Quantity: 2, Disposition: returns.DispositionRestock})
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if product, _ := api.Product("SKU-001"); product.Available != 6 || product.Version != 3 {
+		t.Errorf("Expected central at 6 units, version 3, got %d at %d", product.Available, product.Version)
+	}
+}
+
+func TestProcessReturn_UnknownProduct(t *testing.T) {
+	api := testutil.StartFakeCentralAPI(t)
+	handler, _ := newTestHandler(t, api)
+
+	rr := postReturn(handler, ReturnRequest{ReturnID: "ret-1", ProductID: "SKU-404", Quantity: 1, Disposition: returns.DispositionRestock})
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response StandardizedError
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil || response.ErrorType != "product_not_found" {
+		t.Errorf("Expected product_not_found, got %s", rr.Body.String())
+	}
+	if got := api.Requests("/v1/inventory/updates"); got != 0 {
+		t.Errorf("Expected no update sent for an unknown product, got %d", got)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/melibackend/shared/sync"
+)
+
+// PauseSyncRequest says why sync is paused; the reason shows in sync status
+type PauseSyncRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// PauseSync handles POST /v1/store/sync/pause - stops event polling so the
+// local cache stays as it is during an investigation. The body is optional.
+func (h *InventoryHandler) PauseSync(w http.ResponseWriter, r *http.Request) {
+	var pauseReq PauseSyncRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&pauseReq); err != nil {
+			h.writeErrorResponse(w, "invalid_request", "Invalid request body", http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	pause, err := h.syncManager.Pause(pauseReq.Reason)
+	if errors.Is(err, sync.ErrSyncPaused) {
+		h.writeErrorResponse(w, "sync_paused", "Sync is already paused", http.StatusConflict, pause)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to pause sync", "error", err)
+		h.writeErrorResponse(w, "pause_failed", "Failed to pause sync", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	slog.Info("Sync paused by request", "reason", pauseReq.Reason, "remote_addr", r.RemoteAddr)
+	h.writeJSON(w, http.StatusOK, h.syncManager.GetSyncStatus())
+}
+
+// ResumeSync handles POST /v1/store/sync/resume - restarts event polling from
+// where the cache stopped
+func (h *InventoryHandler) ResumeSync(w http.ResponseWriter, r *http.Request) {
+	if err := h.syncManager.Resume(); err != nil {
+		if errors.Is(err, sync.ErrSyncNotPaused) {
+			h.writeErrorResponse(w, "sync_not_paused", "Sync is not paused", http.StatusConflict, nil)
+			return
+		}
+		slog.Error("Failed to resume sync", "error", err)
+		h.writeErrorResponse(w, "resume_failed", "Failed to resume sync", http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	slog.Info("Sync resumed by request", "remote_addr", r.RemoteAddr)
+	h.writeJSON(w, http.StatusOK, h.syncManager.GetSyncStatus())
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/testutil"
+)
+
+func TestPauseSync(t *testing.T) {
+	handler, _ := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+
+	rr := serve(handler, "POST", "/v1/store/sync/pause", `{"reason":"investigating SKU-001"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var status storage.SyncStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("Failed to decode sync status: %v", err)
+	}
+	if status.Paused == nil || status.Paused.Reason != "investigating SKU-001" {
+		t.Errorf("Expected the status paused with its reason, got %s", rr.Body.String())
+	}
+
+	if rr := serve(handler, "POST", "/v1/store/sync/pause", ""); rr.Code != http.StatusConflict || errorCode(rr) != "sync_paused" {
+		t.Errorf("Expected 409 sync_paused pausing twice, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(handler, "POST", "/v1/store/sync/force", ""); rr.Code != http.StatusConflict || errorCode(rr) != "sync_paused" {
+		t.Errorf("Expected 409 sync_paused forcing a paused sync, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestResumeSync(t *testing.T) {
+	handler, _ := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+
+	if rr := serve(handler, "POST", "/v1/store/sync/resume", ""); rr.Code != http.StatusConflict || errorCode(rr) != "sync_not_paused" {
+		t.Fatalf("Expected 409 sync_not_paused, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	if rr := serve(handler, "POST", "/v1/store/sync/pause", ""); rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 pausing, got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr := serve(handler, "POST", "/v1/store/sync/resume", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 resuming, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var status storage.SyncStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || status.Paused != nil {
+		t.Errorf("Expected the status no longer paused, got %s", rr.Body.String())
+	}
+}
+
+func TestPauseSync_InvalidBody(t *testing.T) {
+	handler, _ := newTestHandler(t, testutil.StartFakeCentralAPI(t))
+
+	if rr := serve(handler, "POST", "/v1/store/sync/pause", `{"reason":`); rr.Code != http.StatusBadRequest || errorCode(rr) != "invalid_request" {
+		t.Errorf("Expected 400 invalid_request, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
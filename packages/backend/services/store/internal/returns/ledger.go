@@ -0,0 +1,164 @@
+// Package returns records customer returns processed by the store and keeps
+// per-product return statistics, saved in a JSON file under the data dir.
+package returns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dispositions decide what happens to returned units
+const (
+	// DispositionRestock puts the units back on sale through the central API
+	DispositionRestock = "restock"
+	// DispositionDamaged writes the units off; stock is not changed
+	DispositionDamaged = "damaged"
+)
+
+// Return is one processed customer return
+type Return struct {
+	ReturnID    string `json:"returnId"`
+	ProductID   string `json:"productId"`
+	Quantity    int    `json:"quantity"`
+	Disposition string `json:"disposition"`
+	Reason      string `json:"reason,omitempty"` // Customer's reason, free text
+	NewVersion  int    `json:"newVersion,omitempty"`
+	NewQuantity int    `json:"newQuantity,omitempty"`
+	ProcessedAt string `json:"processedAt"`
+}
+
+// ProductStats summarizes the returns of one product
+type ProductStats struct {
+	ProductID      string `json:"productId"`
+	Returns        int    `json:"returns"`
+	UnitsReturned  int    `json:"unitsReturned"`
+	UnitsRestocked int    `json:"unitsRestocked"`
+	UnitsDamaged   int    `json:"unitsDamaged"`
+	LastReturnAt   string `json:"lastReturnAt"`
+}
+
+// Ledger is the store's return history, keyed by return ID so a retried
+// request never restocks twice
+type Ledger struct {
+	path    string
+	mutex   sync.RWMutex
+	returns map[string]Return
+}
+
+// NewLedger loads the ledger saved in dataDir; a missing file means no returns
+func NewLedger(dataDir string) (*Ledger, error) {
+	l := &Ledger{
+		path:    filepath.Join(dataDir, "returns.json"),
+		returns: make(map[string]Return),
+	}
+
+	data, err := os.ReadFile(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read returns ledger: %w", err)
+	}
+
+	var saved []Return
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("failed to decode returns ledger: %w", err)
+	}
+	for _, r := range saved {
+		l.returns[r.ReturnID] = r
+	}
+	slog.Info("Returns ledger loaded", "path", l.path, "count", len(l.returns))
+	return l, nil
+}
+
+// Get returns a recorded return by ID
+func (l *Ledger) Get(returnID string) (Return, bool) {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	r, ok := l.returns[returnID]
+	return r, ok
+}
+
+// Record stores a processed return and saves the ledger. The return is kept in
+// memory even when the save fails, so the next save includes it.
+func (l *Ledger) Record(r Return) (Return, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if r.ProcessedAt == "" {
+		r.ProcessedAt = time.Now().UTC().Format(time.RFC3339)
+	}
+	l.returns[r.ReturnID] = r
+	return r, l.saveLocked()
+}
+
+// Stats returns per-product return statistics sorted by product ID, or only
+// the given product's when productID is set
+func (l *Ledger) Stats(productID string) []ProductStats {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	byProduct := make(map[string]*ProductStats)
+	for _, r := range l.returns {
+		if productID != "" && r.ProductID != productID {
+			continue
+		}
+		stats, ok := byProduct[r.ProductID]
+		if !ok {
+			stats = &ProductStats{ProductID: r.ProductID}
+			byProduct[r.ProductID] = stats
+		}
+		stats.Returns++
+		stats.UnitsReturned += r.Quantity
+		switch r.Disposition {
+		case DispositionRestock:
+			stats.UnitsRestocked += r.Quantity
+		case DispositionDamaged:
+			stats.UnitsDamaged += r.Quantity
+		}
+		if r.ProcessedAt > stats.LastReturnAt {
+			stats.LastReturnAt = r.ProcessedAt
+		}
+	}
+
+	result := make([]ProductStats, 0, len(byProduct))
+	for _, stats := range byProduct {
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ProductID < result[j].ProductID })
+	return result
+}
+
+// saveLocked writes the ledger atomically (caller holds the write lock)
+func (l *Ledger) saveLocked() error {
+	saved := make([]Return, 0, len(l.returns))
+	for _, r := range l.returns {
+		saved = append(saved, r)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ProcessedAt < saved[j].ProcessedAt })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode returns ledger: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create returns ledger directory: %w", err)
+	}
+
+	tempPath := l.path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write returns ledger: %w", err)
+	}
+	if err := os.Rename(tempPath, l.path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to store returns ledger: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// BreakerState represents the state of a circuit breaker
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// ErrCircuitOpen is returned when a call is rejected because the breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: central API calls are temporarily suspended")
+
+// CircuitBreakerConfig holds configuration for the circuit breaker
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // Consecutive failures before opening
+	OpenTimeout      time.Duration // Time to stay open before probing
+	HalfOpenMaxCalls int           // Concurrent probe calls allowed while half-open
+}
+
+// DefaultCircuitBreakerConfig returns the default breaker configuration
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// BreakerStats is a point-in-time snapshot of the breaker for health endpoints
+type BreakerStats struct {
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	FailureThreshold    int          `json:"failureThreshold"`
+	OpenedAt            time.Time    `json:"openedAt,omitempty"`
+	TotalRejected       int64        `json:"totalRejected"`
+}
+
+// CircuitBreaker guards calls to the central API with closed/open/half-open states
+type CircuitBreaker struct {
+	mu                  sync.Mutex
+	config              CircuitBreakerConfig
+	state               BreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	halfOpenInFlight    int
+	totalRejected       int64
+	onStateChange       func(from, to BreakerState)
+	// now is the breaker's clock, replaced in tests
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a new circuit breaker
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = 1
+	}
+	if config.HalfOpenMaxCalls < 1 {
+		config.HalfOpenMaxCalls = 1
+	}
+
+	return &CircuitBreaker{
+		config: config,
+		state:  BreakerClosed,
+		now:    time.Now,
+	}
+}
+
+// OnStateChange registers a hook invoked whenever the breaker changes state
+func (cb *CircuitBreaker) OnStateChange(hook func(from, to BreakerState)) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.onStateChange = hook
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen otherwise
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BreakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.config.OpenTimeout {
+			cb.totalRejected++
+			return ErrCircuitOpen
+		}
+		cb.transition(BreakerHalfOpen)
+		cb.halfOpenInFlight = 1
+		return nil
+
+	case BreakerHalfOpen:
+		if cb.halfOpenInFlight >= cb.config.HalfOpenMaxCalls {
+			cb.totalRejected++
+			return ErrCircuitOpen
+		}
+		cb.halfOpenInFlight++
+		return nil
+	}
+
+	return nil
+}
+
+// RecordSuccess records a successful call
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures = 0
+	if cb.state == BreakerHalfOpen {
+		cb.halfOpenInFlight = 0
+		cb.transition(BreakerClosed)
+	}
+}
+
+// RecordFailure records a failed call and opens the breaker when the threshold is reached
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures++
+
+	switch cb.state {
+	case BreakerHalfOpen:
+		cb.halfOpenInFlight = 0
+		cb.openedAt = cb.now()
+		cb.transition(BreakerOpen)
+	case BreakerClosed:
+		if cb.consecutiveFailures >= cb.config.FailureThreshold {
+			cb.openedAt = cb.now()
+			cb.transition(BreakerOpen)
+		}
+	}
+}
+
+// reset closes the breaker and forgets its failures, used when calls move to
+// another central endpoint
+func (cb *CircuitBreaker) reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures = 0
+	cb.halfOpenInFlight = 0
+	cb.transition(BreakerClosed)
+}
+
+// release frees a half-open probe slot without recording an outcome, used when
+// the caller cancelled the request before the central API answered
+func (cb *CircuitBreaker) release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == BreakerHalfOpen && cb.halfOpenInFlight > 0 {
+		cb.halfOpenInFlight--
+	}
+}
+
+// State returns the current breaker state
+func (cb *CircuitBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// Stats returns a snapshot of the breaker
+func (cb *CircuitBreaker) Stats() BreakerStats {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return BreakerStats{
+		State:               cb.state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+		FailureThreshold:    cb.config.FailureThreshold,
+		OpenedAt:            cb.openedAt,
+		TotalRejected:       cb.totalRejected,
+	}
+}
+
+// transition changes state and fires the hook (caller must hold the lock)
+func (cb *CircuitBreaker) transition(to BreakerState) {
+	from := cb.state
+	if from == to {
+		return
+	}
+	cb.state = to
+
+	slog.Info("Circuit breaker state changed",
+		"from", from,
+		"to", to,
+		"consecutive_failures", cb.consecutiveFailures)
+
+	if cb.onStateChange != nil {
+		go cb.onStateChange(from, to)
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker on a manual clock moved with the returned func
+func newTestBreaker(config CircuitBreakerConfig) (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(config)
+	cb.now = func() time.Time { return now }
+	return cb, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreaker_OpensAfterThreshold(t *testing.T) {
+	cb, _ := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 3, OpenTimeout: 30 * time.Second})
+
+	for i := 0; i < 2; i++ {
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("Expected call %d allowed while closed, got %v", i+1, err)
+		}
+		cb.RecordFailure()
+	}
+	if cb.State() != BreakerClosed {
+		t.Fatalf("Expected closed below the threshold, got %s", cb.State())
+	}
+
+	// A success resets the count, so the threshold needs consecutive failures
+	cb.RecordSuccess()
+	for i := 0; i < 2; i++ {
+		cb.RecordFailure()
+	}
+	if cb.State() != BreakerClosed {
+		t.Fatalf("Expected closed after a success reset the count, got %s", cb.State())
+	}
+
+	cb.RecordFailure()
+	if cb.State() != BreakerOpen {
+		t.Fatalf("Expected open after 3 consecutive failures, got %s", cb.State())
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen while open, got %v", err)
+	}
+	if stats := cb.Stats(); stats.TotalRejected != 1 || stats.ConsecutiveFailures != 3 {
+		t.Errorf("Expected 1 rejection after 3 failures, got %+v", stats)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	cb, advance := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 30 * time.Second})
+	cb.RecordFailure()
+
+	advance(29 * time.Second)
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected rejection before the open timeout, got %v", err)
+	}
+
+	advance(time.Second)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("Expected a probe allowed after the open timeout, got %v", err)
+	}
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("Expected half-open while probing, got %s", cb.State())
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a second probe rejected, got %v", err)
+	}
+
+	cb.RecordSuccess()
+	if cb.State() != BreakerClosed {
+		t.Errorf("Expected a successful probe to close the breaker, got %s", cb.State())
+	}
+	if err := cb.Allow(); err != nil {
+		t.Errorf("Expected calls allowed once closed, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_FailedProbeReopens(t *testing.T) {
+	cb, advance := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: 30 * time.Second})
+	cb.RecordFailure()
+	firstOpened := cb.Stats().OpenedAt
+
+	advance(30 * time.Second)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("Expected a probe allowed, got %v", err)
+	}
+	cb.RecordFailure()
+	if cb.State() != BreakerOpen {
+		t.Fatalf("Expected a failed probe to reopen the breaker, got %s", cb.State())
+	}
+	if opened := cb.Stats().OpenedAt; !opened.Equal(firstOpened.Add(30 * time.Second)) {
+		t.Errorf("Expected the open timeout restarted at the failed probe, got %v", opened)
+	}
+
+	advance(29 * time.Second)
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected rejection until a full timeout after the failed probe, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_ReleaseFreesProbe(t *testing.T) {
+	cb, advance := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+	cb.RecordFailure()
+	advance(time.Second)
+
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("Expected a probe allowed, got %v", err)
+	}
+	cb.release()
+	if cb.State() != BreakerHalfOpen {
+		t.Fatalf("Expected a released probe to leave the breaker half-open, got %s", cb.State())
+	}
+	if err := cb.Allow(); err != nil {
+		t.Errorf("Expected the released slot to admit another probe, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_OnStateChange(t *testing.T) {
+	cb, advance := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Second})
+	changes := make(chan [2]BreakerState, 3)
+	cb.OnStateChange(func(from, to BreakerState) { changes <- [2]BreakerState{from, to} })
+
+	cb.RecordFailure()
+	advance(time.Second)
+	cb.Allow()
+	cb.RecordSuccess()
+
+	// The hook runs on its own goroutine, so only the set of changes is fixed
+	want := map[[2]BreakerState]bool{
+		{BreakerClosed, BreakerOpen}:     true,
+		{BreakerOpen, BreakerHalfOpen}:   true,
+		{BreakerHalfOpen, BreakerClosed}: true,
+	}
+	for remaining := len(want); remaining > 0; remaining-- {
+		select {
+		case change := <-changes:
+			if !want[change] {
+				t.Errorf("Unexpected state change %s -> %s", change[0], change[1])
+			}
+			delete(want, change)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for state changes, missing %v", want)
+		}
+	}
+}
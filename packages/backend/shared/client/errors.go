@@ -0,0 +1,163 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Error types reported by the central inventory API
+const (
+	ErrorTypeVersionConflict       = "version_conflict"
+	ErrorTypeInsufficientInventory = "insufficient_inventory"
+	ErrorTypeProductNotFound       = "product_not_found"
+	ErrorTypeInvalidRequest        = "invalid_request"
+	ErrorTypeRateLimited           = "rate_limit_exceeded"
+	ErrorTypeLoadShed              = "load_shed"
+	ErrorTypeStocktakeFrozen       = "stocktake_frozen" // 423: decrement refused while the product is counted
+	ErrorTypeStocktakeHold         = "stocktake_hold"   // 429: retry the decrement after the count
+	ErrorTypeConditionFailed       = "condition_failed" // 412: minAvailable or expectedAvailable did not hold
+	ErrorTypeOffsetGone            = "offset_gone"
+	ErrorTypeServerError           = "server_error"
+)
+
+// ReasonReplayRequested is the reason of a 410 offset_gone sent because an
+// operator asked central to replay the store
+const ReasonReplayRequested = "replay_requested"
+
+// ErrTruncatedSnapshot is returned when a catalog snapshot ends before all of
+// its products arrived. It is retried like a transient failure.
+var ErrTruncatedSnapshot = errors.New("truncated catalog snapshot")
+
+// APIError is returned by InventoryClient when the central API answers with a
+// non-success status. The body is decoded so callers can switch on ErrorType
+// instead of parsing error strings.
+type APIError struct {
+	StatusCode   int
+	ErrorType    string
+	ErrorMessage string
+	ProductID    string
+	NewVersion   int
+	NewQuantity  int
+	LastUpdated  string
+	// OldestAvailableOffset and SnapshotOffset are set on 410 offset_gone
+	// responses from the events endpoint; Reason is replay_requested when an
+	// operator asked for the resync
+	OldestAvailableOffset int64
+	SnapshotOffset        int64
+	Reason                string
+	// RetryAfter is the wait the Retry-After header asked for, if any
+	RetryAfter time.Duration
+	Body       []byte
+}
+
+// Error keeps the legacy "request failed with status N: body" format so code
+// that still scrapes error strings continues to work
+func (e *APIError) Error() string {
+	if e.StatusCode == http.StatusGone {
+		return fmt.Sprintf("offset not found (410 Gone): central system may have restarted: %s", string(e.Body))
+	}
+	return fmt.Sprintf("request failed with status %d: %s", e.StatusCode, string(e.Body))
+}
+
+// newResponseError builds an APIError from a response and its body, including
+// the Retry-After header
+func newResponseError(resp *http.Response, body []byte) *APIError {
+	apiErr := newAPIError(resp.StatusCode, body)
+	apiErr.RetryAfter = parseRetryAfter(resp.Header.Get(RetryAfterHeader), time.Now())
+	return apiErr
+}
+
+// newAPIError builds an APIError from a response status and body. Both the
+// update result format (errorType/errorMessage) and the generic error format
+// (code/message) are understood.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Body:       body,
+	}
+
+	var decoded struct {
+		ProductID    string `json:"productId"`
+		ErrorType    string `json:"errorType"`
+		ErrorMessage string `json:"errorMessage"`
+		NewVersion   int    `json:"newVersion"`
+		NewQuantity  int    `json:"newQuantity"`
+		LastUpdated  string `json:"lastUpdated"`
+		Code         string `json:"code"`
+		Message      string `json:"message"`
+
+		OldestAvailableOffset int64  `json:"oldestAvailableOffset"`
+		SnapshotOffset        int64  `json:"snapshotOffset"`
+		Reason                string `json:"reason"`
+	}
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		apiErr.ProductID = decoded.ProductID
+		apiErr.ErrorType = decoded.ErrorType
+		apiErr.ErrorMessage = decoded.ErrorMessage
+		apiErr.NewVersion = decoded.NewVersion
+		apiErr.NewQuantity = decoded.NewQuantity
+		apiErr.LastUpdated = decoded.LastUpdated
+		apiErr.OldestAvailableOffset = decoded.OldestAvailableOffset
+		apiErr.SnapshotOffset = decoded.SnapshotOffset
+		apiErr.Reason = decoded.Reason
+
+		if apiErr.ErrorType == "" {
+			apiErr.ErrorType = decoded.Code
+		}
+		if apiErr.ErrorMessage == "" {
+			apiErr.ErrorMessage = decoded.Message
+		}
+	}
+
+	// Fall back to a type derived from the status code
+	if apiErr.ErrorType == "" {
+		apiErr.ErrorType = errorTypeFromStatus(statusCode)
+	}
+	if apiErr.ErrorMessage == "" {
+		apiErr.ErrorMessage = http.StatusText(statusCode)
+	}
+
+	return apiErr
+}
+
+// errorTypeFromStatus maps an HTTP status to a default error type
+func errorTypeFromStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusConflict:
+		return ErrorTypeVersionConflict
+	case http.StatusNotFound:
+		return ErrorTypeProductNotFound
+	case http.StatusUnprocessableEntity:
+		return ErrorTypeInsufficientInventory
+	case http.StatusBadRequest:
+		return ErrorTypeInvalidRequest
+	case http.StatusTooManyRequests:
+		return ErrorTypeRateLimited
+	case http.StatusGone:
+		return ErrorTypeOffsetGone
+	case http.StatusLocked:
+		return ErrorTypeStocktakeFrozen
+	case http.StatusPreconditionFailed:
+		return ErrorTypeConditionFailed
+	default:
+		return ErrorTypeServerError
+	}
+}
+
+// AsAPIError extracts an *APIError from an error chain
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// IsErrorType reports whether the error is an APIError of the given type
+func IsErrorType(err error, errorType string) bool {
+	apiErr, ok := AsAPIError(err)
+	return ok && apiErr.ErrorType == errorType
+}
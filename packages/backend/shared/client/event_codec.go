@@ -0,0 +1,285 @@
+package client
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/melibackend/shared/models"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Event encodings the client can request from the central events endpoint
+const (
+	EventEncodingJSON     = "json"
+	EventEncodingProtobuf = "protobuf"
+)
+
+// protobufContentType is the media type of protobuf-encoded events pages. The
+// layout is defined by events.proto in the central API.
+const protobufContentType = "application/x-protobuf"
+
+// protobufAccept asks for protobuf but still accepts JSON from central
+// versions that do not support it
+const protobufAccept = protobufContentType + ", application/json;q=0.5"
+
+// SetEventEncoding selects the encoding requested for event polls: json (the
+// default) or protobuf, which is much smaller for large batches. Responses are
+// decoded by their Content-Type, so an older central answering JSON still works.
+func (c *InventoryClient) SetEventEncoding(encoding string) error {
+	switch encoding {
+	case "", EventEncodingJSON:
+		c.eventEncoding = EventEncodingJSON
+	case EventEncodingProtobuf:
+		c.eventEncoding = EventEncodingProtobuf
+	default:
+		return fmt.Errorf("unknown event encoding %q, expected %s or %s", encoding, EventEncodingJSON, EventEncodingProtobuf)
+	}
+	return nil
+}
+
+// decodeProtobufEvents decodes a protobuf events page, skipping unknown fields
+func decodeProtobufEvents(b []byte) (*models.EventsResponse, error) {
+	response := &models.EventsResponse{Events: []models.Event{}}
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			event, err := decodeProtobufEvent(value)
+			if err != nil {
+				return 0, err
+			}
+			response.Events = append(response.Events, event)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.NextOffset = int64(value)
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.HasMore = value != 0
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.Count = int(int64(value))
+			return n, nil
+		case num == 5 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.Filtered = value != 0
+			return n, nil
+		case num == 6 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			response.CurrentOffset = int64(value)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func decodeProtobufEvent(b []byte) (models.Event, error) {
+	var event models.Event
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			event.Offset = int64(value)
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(b, &event.Timestamp)
+		case num == 3 && typ == protowire.BytesType:
+			return consumeString(b, &event.EventType)
+		case num == 4 && typ == protowire.BytesType:
+			return consumeString(b, &event.ProductID)
+		case num == 5 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			product, err := decodeProtobufProduct(value)
+			event.Data = product
+			return n, err
+		case num == 6 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			event.Version = int(int64(value))
+			return n, nil
+		case num == 7 && typ == protowire.BytesType:
+			return consumeString(b, &event.StoreID)
+		case num == 8 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			event.Delta = int(int64(value))
+			return n, nil
+		case num == 9 && typ == protowire.BytesType:
+			return consumeString(b, &event.ToStoreID)
+		case num == 10 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			event.Quantity = int(int64(value))
+			return n, nil
+		case num == 11 && typ == protowire.BytesType:
+			return consumeString(b, &event.Reason)
+		case num == 12 && typ == protowire.BytesType:
+			return consumeString(b, &event.OrderID)
+		case num == 13 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var category models.Category
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &category.ID)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &category.Name)
+				case num == 3 && typ == protowire.BytesType:
+					return consumeString(b, &category.ParentID)
+				case num == 4 && typ == protowire.BytesType:
+					return consumeString(b, &category.UpdatedAt)
+				case num == 5 && typ == protowire.BytesType:
+					return consumeStockPolicy(b, &category.StockPolicy)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			event.Category = &category
+			return n, err
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	return event, err
+}
+
+func decodeProtobufProduct(b []byte) (models.ProductResponse, error) {
+	var product models.ProductResponse
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return consumeString(b, &product.ProductID)
+		case num == 2 && typ == protowire.BytesType:
+			return consumeString(b, &product.Name)
+		case num == 3 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			product.Available = int(int64(value))
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			product.Version = int(int64(value))
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			return consumeString(b, &product.LastUpdated)
+		case num == 6 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			product.Price = math.Float64frombits(bits)
+			return n, nil
+		case num == 7 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var money models.Money
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.VarintType:
+					amount, n := protowire.ConsumeVarint(b)
+					money.Amount = int64(amount)
+					return n, nil
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &money.Currency)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			product.Prices = append(product.Prices, money)
+			return n, err
+		case num == 8 && typ == protowire.BytesType:
+			return consumeString(b, &product.Category)
+		case num == 9 && typ == protowire.BytesType:
+			return consumeString(b, &product.Barcode)
+		case num == 10 && typ == protowire.BytesType:
+			var alias string
+			n, err := consumeString(b, &alias)
+			product.BarcodeAliases = append(product.BarcodeAliases, alias)
+			return n, err
+		case num == 11 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var asset models.ProductAsset
+			err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.BytesType:
+					return consumeString(b, &asset.Type)
+				case num == 2 && typ == protowire.BytesType:
+					return consumeString(b, &asset.URL)
+				case num == 3 && typ == protowire.BytesType:
+					return consumeString(b, &asset.Checksum)
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			product.Assets = append(product.Assets, asset)
+			return n, err
+		case num == 12 && typ == protowire.BytesType:
+			return consumeStockPolicy(b, &product.StockPolicy)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	return product, err
+}
+
+// consumeStockPolicy decodes the backorder fields of a stock policy; the
+// stock bounds only matter to the central API
+func consumeStockPolicy(b []byte, target **models.StockPolicy) (int, error) {
+	value, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+	var policy models.StockPolicy
+	err := consumeFields(value, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 3 && typ == protowire.VarintType:
+			allow, n := protowire.ConsumeVarint(b)
+			policy.AllowBackorder = allow != 0
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			maxBackorder, n := protowire.ConsumeVarint(b)
+			policy.MaxBackorder = int(int64(maxBackorder))
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+	*target = &policy
+	return n, err
+}
+
+// consumeFields walks the fields of one message. field consumes the value of
+// each field and returns its length, or a negative protowire error code.
+func consumeFields(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("invalid protobuf field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeString(b []byte, target *string) (int, error) {
+	value, n := protowire.ConsumeString(b)
+	*target = value
+	return n, nil
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/melibackend/shared/models"
+)
+
+// fixtureEventsResponse is the page in testdata/events.pb, written by the
+// central API's encoder (TestProtobuf_MatchesStoreClientFixture), without the
+// fields the store does not model: stock bounds, allocations, backorders and
+// previous categories
+func fixtureEventsResponse() *models.EventsResponse {
+	events := make([]models.Event, 0, 54)
+	for i := 0; i < 50; i++ {
+		events = append(events, models.Event{
+			Offset:    int64(1000 + i),
+			Timestamp: "2025-11-28T23:00:00Z",
+			EventType: models.EventTypeProductUpdated,
+			ProductID: "SKU-001",
+			Data: models.ProductResponse{
+				ProductID:      "SKU-001",
+				Name:           "iPhone 15 Pro",
+				Available:      40 - i,
+				Version:        20 + i,
+				LastUpdated:    "2025-11-28T23:00:00Z",
+				Price:          1299.99,
+				Prices:         []models.Money{{Amount: 129999, Currency: "USD"}, {Amount: 119999, Currency: "EUR"}},
+				Category:       "phones",
+				Barcode:        "0194253401234",
+				BarcodeAliases: []string{"194253401234"},
+			},
+			Version: 20 + i,
+			StoreID: "store-001",
+			Delta:   -1,
+			Reason:  "sale",
+		})
+	}
+	events[0].Data.Available = -3
+	events[0].Data.Assets = []models.ProductAsset{{
+		Type:     "image",
+		URL:      "https://cdn.example.com/products/iphone.jpg",
+		Checksum: "sha256:" + strings.Repeat("ab", 32),
+	}}
+	events[0].Data.StockPolicy = &models.StockPolicy{} // Bounds only, no backorders
+	events = append(events,
+		models.Event{Offset: 1050, EventType: models.EventTypeSystemRestored},
+		models.Event{
+			Offset:    1051,
+			EventType: models.EventTypeStockTransferred,
+			ProductID: "SKU-001",
+			Version:   71,
+			StoreID:   "store-001",
+			ToStoreID: "store-002",
+			Quantity:  5,
+		},
+		models.Event{
+			Offset:    1052,
+			EventType: models.EventTypeCategoryUpdated,
+			Category: &models.Category{ID: "smartphones", Name: "Smartphones", ParentID: "phones", UpdatedAt: "2025-11-28T23:00:00Z",
+				StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 5}},
+		},
+		models.Event{
+			Offset:    1053,
+			EventType: models.EventTypeBackorderFulfilled,
+			ProductID: "SKU-001",
+			Version:   72,
+			StoreID:   "store-001",
+			Quantity:  2,
+		},
+	)
+	return &models.EventsResponse{Events: events, NextOffset: 1054, HasMore: true, Count: len(events), Filtered: true, CurrentOffset: 1060}
+}
+
+func TestDecodeProtobufEvents_CentralFixture(t *testing.T) {
+	encoded, err := os.ReadFile("testdata/events.pb")
+	if err != nil {
+		t.Fatalf("Failed to read the fixture: %v", err)
+	}
+
+	decoded, err := decodeProtobufEvents(encoded)
+	if err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	want := fixtureEventsResponse()
+	if len(decoded.Events) != len(want.Events) {
+		t.Fatalf("Expected %d events, got %d", len(want.Events), len(decoded.Events))
+	}
+	for i := range want.Events {
+		if !reflect.DeepEqual(decoded.Events[i], want.Events[i]) {
+			t.Errorf("Event %d:\n got %+v\nwant %+v", i, decoded.Events[i], want.Events[i])
+		}
+	}
+	decoded.Events, want.Events = nil, nil
+	if !reflect.DeepEqual(decoded, want) {
+		t.Errorf("Page:\n got %+v\nwant %+v", decoded, want)
+	}
+}
+
+func TestDecodeProtobufEvents_Truncated(t *testing.T) {
+	encoded, err := os.ReadFile("testdata/events.pb")
+	if err != nil {
+		t.Fatalf("Failed to read the fixture: %v", err)
+	}
+	if _, err := decodeProtobufEvents(encoded[:len(encoded)/2]); err == nil {
+		t.Error("Expected an error for a truncated page")
+	}
+}
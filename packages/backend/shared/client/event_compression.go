@@ -0,0 +1,140 @@
+package client
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Event compressions the client can request on event polls
+const (
+	EventCompressionZstd = "zstd"
+	EventCompressionGzip = "gzip"
+	EventCompressionNone = "none"
+)
+
+// maxEventWindow caps the zstd window a central response may use, so a bad
+// response cannot make the decoder allocate hundreds of megabytes
+const maxEventWindow = 8 << 20
+
+// eventAcceptEncodings is the Accept-Encoding sent for each compression. zstd
+// still accepts gzip from central versions that do not support zstd.
+var eventAcceptEncodings = map[string]string{
+	EventCompressionZstd: "zstd, gzip;q=0.5",
+	EventCompressionGzip: "gzip",
+	EventCompressionNone: "identity",
+}
+
+// EventTransferStats counts the event page bytes received over the wire and
+// after decompression
+type EventTransferStats struct {
+	Pages        int64
+	WireBytes    int64
+	DecodedBytes int64
+}
+
+// eventTransferCounters accumulates EventTransferStats
+type eventTransferCounters struct {
+	pages        atomic.Int64
+	wireBytes    atomic.Int64
+	decodedBytes atomic.Int64
+}
+
+// SetEventCompression selects the compression requested for event polls: zstd
+// (the default), gzip or none. Responses are decoded by their
+// Content-Encoding, so a central API that only gzips, or does not compress at
+// all, still works.
+func (c *InventoryClient) SetEventCompression(compression string) error {
+	switch compression {
+	case "":
+		c.eventCompression = EventCompressionZstd
+	case EventCompressionZstd, EventCompressionGzip, EventCompressionNone:
+		c.eventCompression = compression
+	default:
+		return fmt.Errorf("unknown event compression %q, expected %s, %s or %s",
+			compression, EventCompressionZstd, EventCompressionGzip, EventCompressionNone)
+	}
+	return nil
+}
+
+// EventTransferStats returns the event page bytes received since the client
+// was created
+func (c *InventoryClient) EventTransferStats() EventTransferStats {
+	return EventTransferStats{
+		Pages:        c.eventTransfer.pages.Load(),
+		WireBytes:    c.eventTransfer.wireBytes.Load(),
+		DecodedBytes: c.eventTransfer.decodedBytes.Load(),
+	}
+}
+
+// setEventAcceptEncoding asks for the configured compression. Setting the
+// header turns off the transport's transparent gzip, so readEventBody
+// decodes the body and sees its size on the wire.
+func (c *InventoryClient) setEventAcceptEncoding(req *http.Request) {
+	req.Header.Set("Accept-Encoding", eventAcceptEncodings[c.eventCompression])
+}
+
+// readEventBody reads and decompresses an event page, counting its size
+// before and after decompression
+func (c *InventoryClient) readEventBody(resp *http.Response) ([]byte, error) {
+	body, wireBytes, encoding, err := decodeResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	c.eventTransfer.pages.Add(1)
+	c.eventTransfer.wireBytes.Add(wireBytes)
+	c.eventTransfer.decodedBytes.Add(int64(len(body)))
+	slog.Debug("Event page received",
+		"encoding", encoding,
+		"wire_bytes", wireBytes,
+		"decoded_bytes", len(body))
+	return body, nil
+}
+
+// decodeResponseBody reads a body by its Content-Encoding and returns it with
+// its size on the wire and the encoding
+func decodeResponseBody(resp *http.Response) ([]byte, int64, string, error) {
+	wire := &countingReader{r: resp.Body}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	var body []byte
+	var err error
+	switch encoding {
+	case "", "identity":
+		body, err = io.ReadAll(wire)
+	case "gzip", "x-gzip":
+		var reader *gzip.Reader
+		if reader, err = gzip.NewReader(wire); err == nil {
+			body, err = io.ReadAll(reader)
+			reader.Close()
+		}
+	case "zstd":
+		var decoder *zstd.Decoder
+		if decoder, err = zstd.NewReader(wire, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxEventWindow)); err == nil {
+			body, err = io.ReadAll(decoder)
+			decoder.Close()
+		}
+	default:
+		return nil, wire.n, encoding, fmt.Errorf("unsupported response encoding %q", encoding)
+	}
+	return body, wire.n, encoding, err
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
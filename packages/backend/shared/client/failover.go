@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FailoverOptions controls how the client moves between central endpoints
+type FailoverOptions struct {
+	HealthInterval   time.Duration // How often every endpoint is probed
+	ProbeTimeout     time.Duration // Longest a single health probe may take
+	FailureThreshold int           // Failed probes before leaving the active endpoint
+	FailbackAfter    int           // Healthy probes before returning to a preferred endpoint
+}
+
+// DefaultFailoverOptions returns the default failover configuration
+func DefaultFailoverOptions() FailoverOptions {
+	return FailoverOptions{
+		HealthInterval:   5 * time.Second,
+		ProbeTimeout:     2 * time.Second,
+		FailureThreshold: 2,
+		FailbackAfter:    3,
+	}
+}
+
+// EndpointStatus is one central endpoint as the last health probes saw it
+type EndpointStatus struct {
+	URL                  string    `json:"url"`
+	Priority             int       `json:"priority"` // 0 is the most preferred
+	Active               bool      `json:"active"`
+	Healthy              bool      `json:"healthy"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+	LastChecked          time.Time `json:"lastChecked,omitempty"`
+	LastError            string    `json:"lastError,omitempty"`
+}
+
+// endpointSet is the prioritized list of central endpoints and the one calls
+// currently go to
+type endpointSet struct {
+	mu        sync.RWMutex
+	endpoints []EndpointStatus
+	active    int
+}
+
+// newEndpointSet creates a set of endpoints in priority order, all assumed
+// healthy until probed
+func newEndpointSet(urls []string) *endpointSet {
+	endpoints := make([]EndpointStatus, len(urls))
+	for i, url := range urls {
+		endpoints[i] = EndpointStatus{URL: url, Priority: i, Healthy: true}
+	}
+	return &endpointSet{endpoints: endpoints}
+}
+
+// activeURL returns the URL calls currently go to
+func (s *endpointSet) activeURL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.endpoints[s.active].URL
+}
+
+// snapshot returns every endpoint's status in priority order
+func (s *endpointSet) snapshot() []EndpointStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	endpoints := append([]EndpointStatus(nil), s.endpoints...)
+	endpoints[s.active].Active = true
+	return endpoints
+}
+
+// SetEndpoints replaces the central API URL with a prioritized list of
+// central endpoints, the first being preferred. Calls go to the first one
+// until StartFailover's health probes move them.
+func (c *InventoryClient) SetEndpoints(urls []string) {
+	var trimmed []string
+	for _, url := range urls {
+		if url = strings.TrimSpace(url); url != "" {
+			trimmed = append(trimmed, url)
+		}
+	}
+	if len(trimmed) == 0 {
+		return
+	}
+	c.endpoints = newEndpointSet(trimmed)
+}
+
+// ActiveEndpoint returns the URL of the central endpoint calls currently go to
+func (c *InventoryClient) ActiveEndpoint() string {
+	return c.baseURL()
+}
+
+// Endpoints returns the central endpoints in priority order with their health
+func (c *InventoryClient) Endpoints() []EndpointStatus {
+	return c.endpoints.snapshot()
+}
+
+// StartFailover probes every central endpoint now, switching to the first
+// healthy one, and then every HealthInterval until ctx is done. Calls move to
+// the most preferred healthy endpoint once the active one fails
+// FailureThreshold probes in a row, and back to a more preferred endpoint
+// once it passes FailbackAfter probes in a row. With a single endpoint there
+// is nothing to fail over to and no probes run.
+func (c *InventoryClient) StartFailover(ctx context.Context, options FailoverOptions) {
+	if len(c.endpoints.snapshot()) < 2 {
+		return
+	}
+	defaults := DefaultFailoverOptions()
+	if options.HealthInterval <= 0 {
+		options.HealthInterval = defaults.HealthInterval
+	}
+	if options.ProbeTimeout <= 0 {
+		options.ProbeTimeout = defaults.ProbeTimeout
+	}
+	if options.FailureThreshold < 1 {
+		options.FailureThreshold = defaults.FailureThreshold
+	}
+	if options.FailbackAfter < 1 {
+		options.FailbackAfter = defaults.FailbackAfter
+	}
+
+	c.checkEndpoints(ctx, options, true)
+
+	go func() {
+		ticker := time.NewTicker(options.HealthInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.checkEndpoints(ctx, options, false)
+			}
+		}
+	}()
+}
+
+// checkEndpoints probes every endpoint concurrently and moves calls to
+// another endpoint when needed. On the first check calls move to the first
+// healthy endpoint straight away.
+func (c *InventoryClient) checkEndpoints(ctx context.Context, options FailoverOptions, initial bool) {
+	endpoints := c.endpoints.snapshot()
+	results := make([]error, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			probeCtx, cancel := context.WithTimeout(ctx, options.ProbeTimeout)
+			defer cancel()
+			_, results[i] = c.healthCheck(probeCtx, url)
+		}(i, endpoint.URL)
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	s := c.endpoints
+	s.mu.Lock()
+	now := time.Now()
+	for i, err := range results {
+		endpoint := &s.endpoints[i]
+		endpoint.LastChecked = now
+		if err != nil {
+			endpoint.Healthy = false
+			endpoint.ConsecutiveFailures++
+			endpoint.ConsecutiveSuccesses = 0
+			endpoint.LastError = err.Error()
+			continue
+		}
+		endpoint.Healthy = true
+		endpoint.ConsecutiveFailures = 0
+		endpoint.ConsecutiveSuccesses++
+		endpoint.LastError = ""
+	}
+
+	next := s.active
+	reason := ""
+	if active := s.endpoints[s.active]; !active.Healthy && (initial || active.ConsecutiveFailures >= options.FailureThreshold) {
+		for i, endpoint := range s.endpoints {
+			if endpoint.Healthy {
+				next, reason = i, "failover"
+				break
+			}
+		}
+	} else {
+		for i := 0; i < s.active; i++ {
+			if endpoint := s.endpoints[i]; endpoint.Healthy && (initial || endpoint.ConsecutiveSuccesses >= options.FailbackAfter) {
+				next, reason = i, "failback"
+				break
+			}
+		}
+	}
+	from := s.endpoints[s.active]
+	s.active = next
+	s.mu.Unlock()
+
+	if next == from.Priority {
+		return
+	}
+
+	// The breaker judged the endpoint calls are leaving, not the new one
+	if c.breaker != nil {
+		c.breaker.reset()
+	}
+	slog.Warn("Central API endpoint switched",
+		"reason", reason,
+		"from", from.URL,
+		"to", endpoints[next].URL,
+		"from_error", from.LastError)
+}
@@ -2,38 +2,139 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/requestid"
 )
 
 // InventoryClient provides methods to interact with the central inventory API
 type InventoryClient struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	// endpoints are the central API instances in priority order (see SetEndpoints)
+	endpoints   *endpointSet
+	apiKey      string
+	storeID     string
+	httpClient  *http.Client
+	breaker     *CircuitBreaker
+	retryPolicy RetryOptions
+	// eventEncoding is requested on event polls (see SetEventEncoding)
+	eventEncoding string
+	// eventCompression is requested on event polls (see SetEventCompression)
+	eventCompression string
+	eventTransfer    eventTransferCounters
+	// eventFilter names the central event filter polls subscribe to
+	eventFilter string
+	// Quota reported by the central API and how calls react to it
+	rateLimits       *rateLimitTracker
+	rateLimitOptions RateLimitOptions
+	// Maintenance mode reported by the central API
+	maintenance *maintenanceTracker
+	// after times the backoff between retries, replaced in tests
+	after func(time.Duration) <-chan time.Time
 }
 
 // NewInventoryClient creates a new inventory client
 func NewInventoryClient(baseURL, apiKey string) *InventoryClient {
-	return &InventoryClient{
-		baseURL: baseURL,
-		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	c := &InventoryClient{
+		endpoints:        newEndpointSet([]string{baseURL}),
+		apiKey:           apiKey,
+		breaker:          NewCircuitBreaker(DefaultCircuitBreakerConfig()),
+		retryPolicy:      DefaultIdempotentRetryPolicy(),
+		eventEncoding:    EventEncodingJSON,
+		eventCompression: EventCompressionZstd,
+		rateLimits:       &rateLimitTracker{},
+		rateLimitOptions: DefaultRateLimitOptions(),
+		maintenance:      &maintenanceTracker{},
+		after:            time.After,
+	}
+	c.httpClient = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: c.newTransport(nil),
+	}
+	return c
+}
+
+// newTransport wraps base with tracing and rate limit and maintenance header
+// tracking
+func (c *InventoryClient) newTransport(base http.RoundTripper) http.RoundTripper {
+	tracked := &rateLimitTransport{base: orDefaultTransport(base), tracker: c.rateLimits}
+	return newTracingTransport(&maintenanceTransport{base: tracked, tracker: c.maintenance})
+}
+
+// baseURL returns the URL of the central endpoint calls currently go to
+func (c *InventoryClient) baseURL() string {
+	return c.endpoints.activeURL()
+}
+
+// orDefaultTransport returns base, or http.DefaultTransport when nil
+func orDefaultTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		return http.DefaultTransport
 	}
+	return base
+}
+
+// SetEventFilter subscribes event polls to a named filter defined on the
+// central API, so only events for the products it covers are delivered. An
+// empty ID receives every event, or the filter allocated to the store ID.
+func (c *InventoryClient) SetEventFilter(filterID string) {
+	c.eventFilter = filterID
+}
+
+// SetStoreID identifies the calling store to the central API, which uses it
+// for per-store rate limiting
+func (c *InventoryClient) SetStoreID(storeID string) {
+	c.storeID = storeID
 }
 
-// HealthCheck checks the health of the central inventory API
+// SetTLSConfig makes calls to the central API use tlsConfig, for a private CA
+// or a client certificate when the central API requires mutual TLS
+func (c *InventoryClient) SetTLSConfig(tlsConfig *tls.Config) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	c.httpClient.Transport = c.newTransport(transport)
+}
+
+// setAuthHeaders adds the API key and, when configured, the store ID and the
+// request ID and update priority carried by the request context
+func (c *InventoryClient) setAuthHeaders(req *http.Request) {
+	req.Header.Set("X-API-Key", c.apiKey)
+	if c.storeID != "" {
+		req.Header.Set("X-Store-ID", c.storeID)
+	}
+	if id := requestid.FromContext(req.Context()); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	if priority := updatePriorityFromContext(req.Context()); priority != "" {
+		req.Header.Set(UpdatePriorityHeader, priority)
+	}
+}
+
+// HealthCheck checks the health of the central inventory API using a background context
 func (c *InventoryClient) HealthCheck() (*models.HealthResponse, error) {
-	url := fmt.Sprintf("%s/health", c.baseURL)
+	return c.HealthCheckCtx(context.Background())
+}
+
+// HealthCheckCtx checks the health of the central inventory API
+func (c *InventoryClient) HealthCheckCtx(ctx context.Context) (*models.HealthResponse, error) {
+	return c.healthCheck(ctx, c.baseURL())
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// healthCheck checks the health of the central endpoint at baseURL
+func (c *InventoryClient) healthCheck(ctx context.Context, baseURL string) (*models.HealthResponse, error) {
+	url := fmt.Sprintf("%s/health", baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -56,16 +157,21 @@ func (c *InventoryClient) HealthCheck() (*models.HealthResponse, error) {
 	return &health, nil
 }
 
-// GetProduct retrieves a product from the central inventory API
+// GetProduct retrieves a product from the central inventory API using a background context
 func (c *InventoryClient) GetProduct(productID string) (*models.Product, error) {
-	url := fmt.Sprintf("%s/v1/inventory/%s", c.baseURL, productID)
+	return c.GetProductCtx(context.Background(), productID)
+}
+
+// getProduct performs a single GetProduct request without retries or breaker checks
+func (c *InventoryClient) getProduct(ctx context.Context, productID string) (*models.Product, error) {
+	url := fmt.Sprintf("%s/v1/inventory/%s", c.baseURL(), productID)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("X-API-Key", c.apiKey)
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -73,13 +179,14 @@ func (c *InventoryClient) GetProduct(productID string) (*models.Product, error)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("product not found: %s", productID)
-	}
-
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		apiErr := newResponseError(resp, body)
+		if resp.StatusCode == http.StatusNotFound {
+			apiErr.ErrorType = ErrorTypeProductNotFound
+			apiErr.ProductID = productID
+		}
+		return nil, apiErr
 	}
 
 	var product models.Product
@@ -90,22 +197,131 @@ func (c *InventoryClient) GetProduct(productID string) (*models.Product, error)
 	return &product, nil
 }
 
-// UpdateInventory sends an inventory update to the central API
+// BatchGetProducts retrieves several products from the central inventory API using a background context
+func (c *InventoryClient) BatchGetProducts(productIDs []string) (*models.BatchGetResponse, error) {
+	return c.BatchGetProductsCtx(context.Background(), productIDs)
+}
+
+// batchGetProducts performs a single BatchGetProducts request without retries or breaker checks
+func (c *InventoryClient) batchGetProducts(ctx context.Context, productIDs []string) (*models.BatchGetResponse, error) {
+	url := fmt.Sprintf("%s/v1/inventory/batch-get", c.baseURL())
+
+	jsonData, err := json.Marshal(models.BatchGetRequest{ProductIDs: productIDs})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newResponseError(resp, body)
+	}
+
+	var batchResp models.BatchGetResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &batchResp, nil
+}
+
+// GetProductVersions retrieves the current version and stock of several products using a background context
+func (c *InventoryClient) GetProductVersions(productIDs []string) (*models.VersionsResponse, error) {
+	return c.GetProductVersionsCtx(context.Background(), productIDs)
+}
+
+// getProductVersions performs a single GetProductVersions request without retries or breaker checks
+func (c *InventoryClient) getProductVersions(ctx context.Context, productIDs []string) (*models.VersionsResponse, error) {
+	endpoint := fmt.Sprintf("%s/v1/inventory/versions?ids=%s", c.baseURL(), url.QueryEscape(strings.Join(productIDs, ",")))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newResponseError(resp, body)
+	}
+
+	var versionsResp models.VersionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&versionsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &versionsResp, nil
+}
+
+// getCategories performs a single GetCategories request without retries or breaker checks
+func (c *InventoryClient) getCategories(ctx context.Context) ([]models.Category, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL()+"/v1/categories", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newResponseError(resp, body)
+	}
+
+	var categoriesResp models.CategoryListResponse
+	if err := json.NewDecoder(resp.Body).Decode(&categoriesResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return categoriesResp.Categories, nil
+}
+
+// UpdateInventory sends an inventory update to the central API using a background context
 func (c *InventoryClient) UpdateInventory(update models.UpdateRequest) (*models.UpdateResponse, error) {
-	url := fmt.Sprintf("%s/v1/inventory/updates", c.baseURL)
+	return c.UpdateInventoryCtx(context.Background(), update)
+}
+
+// updateInventory performs a single UpdateInventory request without retries or breaker checks
+func (c *InventoryClient) updateInventory(ctx context.Context, update models.UpdateRequest) (*models.UpdateResponse, error) {
+	url := fmt.Sprintf("%s/v1/inventory/updates", c.baseURL())
 
 	jsonData, err := json.Marshal(update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -119,7 +335,7 @@ func (c *InventoryClient) UpdateInventory(update models.UpdateRequest) (*models.
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newResponseError(resp, body)
 	}
 
 	var updateResp models.UpdateResponse
@@ -127,25 +343,40 @@ func (c *InventoryClient) UpdateInventory(update models.UpdateRequest) (*models.
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	// The central API only answers 200 for applied updates; treat anything else
+	// in the envelope as a failure so callers never mistake it for success
+	if !updateResp.Applied {
+		apiErr := newResponseError(resp, body)
+		if apiErr.ErrorType == ErrorTypeServerError {
+			apiErr.ErrorType = ErrorTypeInvalidRequest
+		}
+		return nil, apiErr
+	}
+
 	return &updateResp, nil
 }
 
-// BatchUpdateInventory sends a batch inventory update to the central API
+// BatchUpdateInventory sends a batch inventory update to the central API using a background context
 func (c *InventoryClient) BatchUpdateInventory(batchUpdate models.BatchUpdateRequest) (*models.BatchUpdateResponse, error) {
-	url := fmt.Sprintf("%s/v1/inventory/updates", c.baseURL)
+	return c.BatchUpdateInventoryCtx(context.Background(), batchUpdate)
+}
+
+// batchUpdateInventory performs a single BatchUpdateInventory request without retries or breaker checks
+func (c *InventoryClient) batchUpdateInventory(ctx context.Context, batchUpdate models.BatchUpdateRequest) (*models.BatchUpdateResponse, error) {
+	url := fmt.Sprintf("%s/v1/inventory/updates", c.baseURL())
 
 	jsonData, err := json.Marshal(batchUpdate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -159,7 +390,7 @@ func (c *InventoryClient) BatchUpdateInventory(batchUpdate models.BatchUpdateReq
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newResponseError(resp, body)
 	}
 
 	var batchResp models.BatchUpdateResponse
@@ -170,16 +401,27 @@ func (c *InventoryClient) BatchUpdateInventory(batchUpdate models.BatchUpdateReq
 	return &batchResp, nil
 }
 
-// GetAllProducts retrieves all products from the central inventory API
-func (c *InventoryClient) GetAllProducts() ([]models.Product, error) {
-	url := fmt.Sprintf("%s/v1/inventory", c.baseURL)
+// TransferStock moves units of a product between two stores' allocations using a background context
+func (c *InventoryClient) TransferStock(transfer models.TransferRequest) (*models.StockTransfer, error) {
+	return c.TransferStockCtx(context.Background(), transfer)
+}
+
+// transferStock performs a single transfer request without retries or breaker checks
+func (c *InventoryClient) transferStock(ctx context.Context, transfer models.TransferRequest) (*models.StockTransfer, error) {
+	url := fmt.Sprintf("%s/v1/inventory/transfers", c.baseURL())
+
+	jsonData, err := json.Marshal(transfer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -187,127 +429,252 @@ func (c *InventoryClient) GetAllProducts() ([]models.Product, error) {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	// Read the response body first to debug the format
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Log the response for debugging
-	fmt.Printf("DEBUG: Central API Response: %s\n", string(body))
+	if resp.StatusCode != http.StatusCreated {
+		return nil, newResponseError(resp, body)
+	}
 
-	// Try to parse as the new pagination format: {"products": [...], "pagination": {...}}
-	var paginationResponse struct {
-		Products   []models.Product `json:"products"`
-		Pagination struct {
-			Offset     int  `json:"offset"`
-			Limit      int  `json:"limit"`
-			TotalCount int  `json:"total_count"`
-			HasMore    bool `json:"has_more"`
-		} `json:"pagination"`
+	var stockTransfer models.StockTransfer
+	if err := json.Unmarshal(body, &stockTransfer); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &stockTransfer, nil
+}
+
+// ListTransfers retrieves transfer history, newest first, using a background context.
+// Empty productID or storeID match every transfer; limit 0 uses the server default.
+func (c *InventoryClient) ListTransfers(productID, storeID string, limit int) (*models.TransfersResponse, error) {
+	return c.ListTransfersCtx(context.Background(), productID, storeID, limit)
+}
+
+// listTransfers performs a single transfer history request without retries or breaker checks
+func (c *InventoryClient) listTransfers(ctx context.Context, productID, storeID string, limit int) (*models.TransfersResponse, error) {
+	query := url.Values{}
+	if productID != "" {
+		query.Set("productId", productID)
+	}
+	if storeID != "" {
+		query.Set("storeId", storeID)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	endpoint := fmt.Sprintf("%s/v1/inventory/transfers", c.baseURL())
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
 	}
-	if err := json.Unmarshal(body, &paginationResponse); err == nil {
-		// Successfully parsed as pagination format
-		return paginationResponse.Products, nil
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Try to parse as the legacy format: {"items": [...], "nextCursor": ""}
-	var legacyResponse struct {
-		Items      []models.Product `json:"items"`
-		NextCursor string           `json:"nextCursor"`
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
-	if err := json.Unmarshal(body, &legacyResponse); err == nil {
-		// Successfully parsed as legacy format
-		return legacyResponse.Items, nil
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, newResponseError(resp, body)
 	}
 
-	// If both fail, try to parse as a direct array
-	var directProducts []models.Product
-	if err := json.Unmarshal(body, &directProducts); err != nil {
-		return nil, fmt.Errorf("failed to decode response as pagination, legacy, or direct array format: %w", err)
+	var transfersResp models.TransfersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&transfersResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
-	return directProducts, nil
+
+	return &transfersResp, nil
 }
 
-// GetAllProductsWithMetadata retrieves all products with metadata including event offset
+// GetAllProducts retrieves all products from the central inventory API using a background context
+func (c *InventoryClient) GetAllProducts() ([]models.Product, error) {
+	return c.GetAllProductsCtx(context.Background())
+}
+
+// GetAllProductsWithMetadata retrieves all products with metadata including event offset using a background context
 func (c *InventoryClient) GetAllProductsWithMetadata() ([]models.Product, int64, error) {
-	url := fmt.Sprintf("%s/v1/inventory", c.baseURL)
+	return c.GetAllProductsWithMetadataCtx(context.Background())
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// productPageSize is the largest page the central API serves for product listings
+const productPageSize = 200
+
+// productPage is one page of the central API's product listing
+type productPage struct {
+	Products   []models.Product `json:"products"`
+	Pagination struct {
+		TotalCount int  `json:"total_count"`
+		HasMore    bool `json:"has_more"`
+	} `json:"pagination"`
+	EventOffset int64 `json:"eventOffset,omitempty"`
+}
+
+// getProductPage performs a single paginated product list request without
+// retries or breaker checks
+func (c *InventoryClient) getProductPage(ctx context.Context, offset, limit int) (*productPage, error) {
+	url := fmt.Sprintf("%s/v1/inventory?offset=%d&limit=%d", c.baseURL(), offset, limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("X-API-Key", c.apiKey)
+	c.setAuthHeaders(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to make request: %w", err)
+		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, 0, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newResponseError(resp, body)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	var page productPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &page, nil
+}
+
+// catalogSnapshotProgressEvery is how many products are decoded between
+// progress reports while reading a catalog snapshot
+const catalogSnapshotProgressEvery = 500
+
+// getCatalogSnapshot performs a single catalog snapshot request without retries
+// or breaker checks, decoding products as they stream in
+func (c *InventoryClient) getCatalogSnapshot(ctx context.Context, progress ProductListProgress) ([]models.Product, int64, error) {
+	url := fmt.Sprintf("%s/v1/inventory/snapshot", c.baseURL())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to read response body: %w", err)
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Try to parse as the new pagination format first
-	var paginationResponse struct {
-		Products   []models.Product `json:"products"`
-		Pagination struct {
-			Offset     int  `json:"offset"`
-			Limit      int  `json:"limit"`
-			TotalCount int  `json:"total_count"`
-			HasMore    bool `json:"has_more"`
-		} `json:"pagination"`
-		EventOffset int64 `json:"eventOffset,omitempty"`
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to make request: %w", err)
 	}
-	if err := json.Unmarshal(body, &paginationResponse); err == nil {
-		// Successfully parsed as pagination format
-		// For now, return 0 as eventOffset since the new format doesn't include it
-		return paginationResponse.Products, 0, nil
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, 0, newResponseError(resp, body)
+	}
+
+	// The body is chunked, so a connection dropped mid-way shows up as a
+	// decode failure or as fewer products than announced
+	var eventOffset int64
+	productCount := -1
+	var products []models.Product
+	decoder := json.NewDecoder(resp.Body)
+	if err := expectJSONDelim(decoder, '{'); err != nil {
+		return nil, 0, err
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrTruncatedSnapshot, err)
+		}
+		switch token {
+		case "eventOffset":
+			err = decoder.Decode(&eventOffset)
+		case "productCount":
+			err = decoder.Decode(&productCount)
+			if err == nil {
+				products = make([]models.Product, 0, productCount)
+			}
+		case "products":
+			if err = expectJSONDelim(decoder, '['); err != nil {
+				return nil, 0, err
+			}
+			for err == nil && decoder.More() {
+				var product models.Product
+				if err = decoder.Decode(&product); err == nil {
+					products = append(products, product)
+					if progress != nil && len(products)%catalogSnapshotProgressEvery == 0 {
+						progress(len(products), productCount)
+					}
+				}
+			}
+			if err == nil {
+				err = expectJSONDelim(decoder, ']')
+			}
+		default:
+			var skip json.RawMessage
+			err = decoder.Decode(&skip)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrTruncatedSnapshot, err)
+		}
 	}
 
-	// Parse response with legacy metadata format
-	var legacyResponse struct {
-		Items       []models.Product `json:"items"`
-		NextCursor  string           `json:"nextCursor"`
-		EventOffset int64            `json:"eventOffset"`
+	if len(products) != productCount {
+		return nil, 0, fmt.Errorf("%w: got %d of %d products", ErrTruncatedSnapshot, len(products), productCount)
 	}
-	if err := json.Unmarshal(body, &legacyResponse); err != nil {
-		return nil, 0, fmt.Errorf("failed to decode response: %w", err)
+	if progress != nil {
+		progress(len(products), productCount)
 	}
+	return products, eventOffset, nil
+}
 
-	return legacyResponse.Items, legacyResponse.EventOffset, nil
+// expectJSONDelim reads the next snapshot token and checks it is delim
+func expectJSONDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTruncatedSnapshot, err)
+	}
+	if token != delim {
+		return fmt.Errorf("%w: expected %q, got %v", ErrTruncatedSnapshot, delim, token)
+	}
+	return nil
 }
 
-// GetEvents retrieves events from the central inventory API
+// GetEvents retrieves events from the central inventory API using a background context
 func (c *InventoryClient) GetEvents(offset int64, limit int, waitSeconds int) (*models.EventsResponse, error) {
-	url := fmt.Sprintf("%s/v1/inventory/events?offset=%d&limit=%d&wait=%d",
-		c.baseURL, offset, limit, waitSeconds)
+	return c.GetEventsCtx(context.Background(), offset, limit, waitSeconds)
+}
+
+// getEvents performs a single GetEvents request without retries or breaker checks
+func (c *InventoryClient) getEvents(ctx context.Context, offset int64, limit int, waitSeconds int) (*models.EventsResponse, error) {
+	filterParam := ""
+	if c.eventFilter != "" {
+		filterParam = "&filter=" + url.QueryEscape(c.eventFilter)
+	}
+	url := fmt.Sprintf("%s/v1/inventory/events?offset=%d&limit=%d&wait=%d%s",
+		c.baseURL(), offset, limit, waitSeconds, filterParam)
 
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("X-API-Key", c.apiKey)
+	c.setAuthHeaders(req)
+	if c.eventEncoding == EventEncodingProtobuf {
+		req.Header.Set("Accept", protobufAccept)
+	}
+	c.setEventAcceptEncoding(req)
 
 	// Use a longer timeout for long polling requests
 	client := c.httpClient
 	if waitSeconds > 0 {
 		client = &http.Client{
-			Timeout: time.Duration(waitSeconds+10) * time.Second,
+			Timeout:   time.Duration(waitSeconds+10) * time.Second,
+			Transport: c.httpClient.Transport,
 		}
 	}
 
@@ -317,20 +684,24 @@ func (c *InventoryClient) GetEvents(offset int64, limit int, waitSeconds int) (*
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusGone {
-		return nil, fmt.Errorf("offset not found (410 Gone): central system may have restarted")
-	}
-
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+		body, _, _, _ := decodeResponseBody(resp)
+		return nil, newResponseError(resp, body)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.readEventBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == protobufContentType {
+		eventsResponse, err := decodeProtobufEvents(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode protobuf events response: %w", err)
+		}
+		return eventsResponse, nil
+	}
+
 	var eventsResponse models.EventsResponse
 	if err := json.Unmarshal(body, &eventsResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode events response: %w", err)
@@ -338,3 +709,34 @@ func (c *InventoryClient) GetEvents(offset int64, limit int, waitSeconds int) (*
 
 	return &eventsResponse, nil
 }
+
+// commitEventOffset performs a single offset commit without retries or breaker checks
+func (c *InventoryClient) commitEventOffset(ctx context.Context, offset int64) error {
+	url := fmt.Sprintf("%s/v1/inventory/events/commit", c.baseURL())
+
+	jsonData, err := json.Marshal(models.EventCommitRequest{Offset: offset})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	c.setAuthHeaders(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return newResponseError(resp, body)
+	}
+
+	return nil
+}
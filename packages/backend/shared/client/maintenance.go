@@ -0,0 +1,74 @@
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MaintenanceHeader is set by the central API on every response while it is
+// in read-only maintenance mode
+const MaintenanceHeader = "X-Maintenance-Mode"
+
+// CodeMaintenanceMode is the error code of writes refused during maintenance
+const CodeMaintenanceMode = "maintenance_mode"
+
+// MaintenanceStatus is the central API's maintenance mode as seen on the last
+// response
+type MaintenanceStatus struct {
+	Active     bool      `json:"active"`
+	Mode       string    `json:"mode,omitempty"`  // "read-only"
+	Since      time.Time `json:"since,omitempty"` // First response seen in the current maintenance
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// MaintenanceStatus returns the central API's maintenance mode as last seen
+func (c *InventoryClient) MaintenanceStatus() MaintenanceStatus {
+	return c.maintenance.snapshot()
+}
+
+// maintenanceTracker keeps the maintenance mode reported by the latest response
+type maintenanceTracker struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// observe records the maintenance header of a response, or its absence
+func (t *maintenanceTracker) observe(header http.Header, now time.Time) {
+	mode := header.Get(MaintenanceHeader)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if mode == "" {
+		t.status = MaintenanceStatus{ObservedAt: now}
+		return
+	}
+	if !t.status.Active {
+		t.status.Since = now
+	}
+	t.status.Active = true
+	t.status.Mode = mode
+	t.status.ObservedAt = now
+}
+
+func (t *maintenanceTracker) snapshot() MaintenanceStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status
+}
+
+// maintenanceTransport feeds the maintenance header of every central API
+// response, long polls included, into the client's tracker
+type maintenanceTransport struct {
+	base    http.RoundTripper
+	tracker *maintenanceTracker
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *maintenanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.tracker.observe(resp.Header, time.Now())
+	}
+	return resp, err
+}
@@ -0,0 +1,28 @@
+package client
+
+import "context"
+
+// UpdatePriorityHeader tells the central API which lane an update belongs to.
+// Under pressure it sheds bulk, then sync updates before checkout decrements.
+const UpdatePriorityHeader = "X-Update-Priority"
+
+// Update priorities understood by the central API
+const (
+	UpdatePriorityCheckout = "checkout"
+	UpdatePrioritySync     = "sync"
+	UpdatePriorityBulk     = "bulk"
+)
+
+type updatePriorityKey struct{}
+
+// WithUpdatePriority returns a context whose inventory updates are sent with
+// the given priority. Updates without one are treated as checkout traffic.
+func WithUpdatePriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, updatePriorityKey{}, priority)
+}
+
+// updatePriorityFromContext returns the priority set on ctx, if any
+func updatePriorityFromContext(ctx context.Context) string {
+	priority, _ := ctx.Value(updatePriorityKey{}).(string)
+	return priority
+}
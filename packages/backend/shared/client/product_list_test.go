@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// fakeCatalog serves the central product listing for products SKU-0000
+// onwards, paged as JSON or, when streams is set, as NDJSON
+type fakeCatalog struct {
+	products    []models.Product
+	streams     bool
+	eventOffset int64
+
+	mu          sync.Mutex
+	requests    []string // Raw query of every listing request
+	truncations int      // Streams still to cut short, halfway through
+}
+
+func newFakeCatalog(t *testing.T, count int, streams bool) (*fakeCatalog, *InventoryClient) {
+	t.Helper()
+
+	catalog := &fakeCatalog{streams: streams, eventOffset: 42}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("SKU-%04d", i)
+		catalog.products = append(catalog.products, models.Product{ProductID: id, Name: id, Available: i, Version: 1})
+	}
+	server := httptest.NewServer(catalog)
+	t.Cleanup(server.Close)
+
+	c := NewInventoryClient(server.URL, "key")
+	c.SetRetryPolicy(RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	return catalog, c
+}
+
+func (f *fakeCatalog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/inventory" {
+		http.NotFound(w, r)
+		return
+	}
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	offset = min(offset, len(f.products))
+
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RawQuery)
+	truncate := f.truncations > 0
+	if truncate {
+		f.truncations--
+	}
+	f.mu.Unlock()
+
+	if f.streams && strings.Contains(r.Header.Get("Accept"), ProductStreamContentType) {
+		products := f.products[offset:]
+		if truncate {
+			products = products[:len(products)/2]
+		}
+		w.Header().Set("Content-Type", ProductStreamContentType)
+		w.Header().Set(productStreamTotalHeader, strconv.Itoa(len(f.products)))
+		w.Header().Set("Trailer", productStreamCountHeader)
+		encoder := json.NewEncoder(w)
+		for _, product := range products {
+			encoder.Encode(product)
+		}
+		if !truncate {
+			w.Header().Set(productStreamCountHeader, strconv.Itoa(len(products)))
+		}
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > productPageSize {
+		limit = productPageSize
+	}
+	end := min(offset+limit, len(f.products))
+	var page productPage
+	page.Products = f.products[offset:end]
+	page.Pagination.TotalCount = len(f.products)
+	page.Pagination.HasMore = end < len(f.products)
+	page.EventOffset = f.eventOffset + int64(offset) // Later pages would be torn
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// expectCatalog checks products is the whole catalog, in order
+func expectCatalog(t *testing.T, catalog *fakeCatalog, products []models.Product) {
+	t.Helper()
+
+	if len(products) != len(catalog.products) {
+		t.Fatalf("expected %d products, got %d", len(catalog.products), len(products))
+	}
+	for i, product := range products {
+		if !reflect.DeepEqual(product, catalog.products[i]) {
+			t.Fatalf("product %d: expected %+v, got %+v", i, catalog.products[i], product)
+		}
+	}
+}
+
+func TestGetAllProductsWithProgress_PagesJSONListing(t *testing.T) {
+	catalog, c := newFakeCatalog(t, 450, false)
+
+	var reports []string
+	products, eventOffset, err := c.GetAllProductsWithProgressCtx(context.Background(), func(fetched, total int) {
+		reports = append(reports, fmt.Sprintf("%d/%d", fetched, total))
+	})
+	if err != nil {
+		t.Fatalf("GetAllProductsWithProgressCtx failed: %v", err)
+	}
+
+	expectCatalog(t, catalog, products)
+	if eventOffset != 42 {
+		t.Errorf("expected the first page's event offset 42, got %d", eventOffset)
+	}
+	if got := strings.Join(reports, " "); got != "200/450 400/450 450/450" {
+		t.Errorf("expected progress after every page, got %s", got)
+	}
+	// The first request asks for a stream, then the listing is paged
+	want := []string{"offset=0", "offset=0&limit=200", "offset=200&limit=200", "offset=400&limit=200"}
+	if got := strings.Join(catalog.requests, " "); got != strings.Join(want, " ") {
+		t.Errorf("expected requests %v, got %v", want, catalog.requests)
+	}
+}
+
+func TestGetAllProducts_EmptyCatalog(t *testing.T) {
+	_, c := newFakeCatalog(t, 0, false)
+
+	products, err := c.GetAllProductsCtx(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllProductsCtx failed: %v", err)
+	}
+	if len(products) != 0 {
+		t.Errorf("expected no products, got %d", len(products))
+	}
+}
+
+func TestStreamProducts_ResumesTruncatedStream(t *testing.T) {
+	catalog, c := newFakeCatalog(t, 1200, true)
+	catalog.truncations = 1
+
+	var products []models.Product
+	delivered, err := c.StreamProductsCtx(context.Background(), func(product models.Product) error {
+		products = append(products, product)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamProductsCtx failed: %v", err)
+	}
+
+	if delivered != 1200 {
+		t.Errorf("expected 1200 products delivered, got %d", delivered)
+	}
+	expectCatalog(t, catalog, products)
+	if got := strings.Join(catalog.requests, " "); got != "offset=0 offset=600" {
+		t.Errorf("expected the stream to resume after the last product, got %s", got)
+	}
+}
+
+func TestStreamProducts_StopsOnCallbackError(t *testing.T) {
+	_, c := newFakeCatalog(t, 10, true)
+
+	stop := errors.New("stop")
+	delivered, err := c.StreamProductsCtx(context.Background(), func(product models.Product) error {
+		if product.ProductID == "SKU-0003" {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("expected the callback's error, got %v", err)
+	}
+	if delivered != 3 {
+		t.Errorf("expected 3 products delivered, got %d", delivered)
+	}
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/melibackend/shared/models"
+)
+
+// ProductStreamContentType is requested for product listings streamed one
+// product per line
+const ProductStreamContentType = "application/x-ndjson"
+
+// Streamed listing headers. The product count arrives as a trailer, after
+// the last product, so a stream cut short is told apart from a finished one.
+const (
+	productStreamTotalHeader = "X-Total-Count"
+	productStreamCountHeader = "X-Product-Count"
+)
+
+// productStreamProgressEvery is how many products are delivered between
+// progress reports while reading a streamed listing
+const productStreamProgressEvery = 500
+
+// ErrTruncatedStream is returned when a streamed product listing ends before
+// the central API confirmed its product count. The stream resumes after the
+// last product delivered, like a transient failure.
+var ErrTruncatedStream = errors.New("truncated product stream")
+
+// errNotStreamed is returned when the central API answers a streamed listing
+// request with a JSON page, as central APIs without NDJSON listings do
+var errNotStreamed = errors.New("central API does not stream product listings")
+
+// StreamProductsCtx calls fn with every product of the central catalog, in
+// product ID order, as each one arrives, so large catalogs are processed
+// without holding them whole. A stream cut short is resumed after the last
+// product delivered, so fn sees each product once; against a central API
+// without NDJSON listings the listing is paged through instead. An error
+// from fn stops the stream and is returned. It returns the number of
+// products delivered.
+func (c *InventoryClient) StreamProductsCtx(ctx context.Context, fn func(models.Product) error) (int, error) {
+	delivered := 0
+	_, err := c.streamProducts(ctx, nil, func(product models.Product) error {
+		if err := fn(product); err != nil {
+			return err
+		}
+		delivered++
+		return nil
+	})
+	return delivered, err
+}
+
+// streamProducts is StreamProductsCtx reporting progress. It returns the event
+// offset a paged listing reported on its first page; streams report none.
+func (c *InventoryClient) streamProducts(ctx context.Context, progress ProductListProgress, fn func(models.Product) error) (int64, error) {
+	delivered := 0
+	var fnErr error
+	deliver := func(product models.Product, total int) error {
+		if fnErr = fn(product); fnErr != nil {
+			return fnErr
+		}
+		delivered++
+		if progress != nil && delivered%productStreamProgressEvery == 0 {
+			progress(delivered, total)
+		}
+		return nil
+	}
+
+	total := 0
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		total, err = c.getProductStream(ctx, delivered, deliver)
+		return err
+	})
+	if fnErr != nil {
+		return 0, fnErr
+	}
+	if errors.Is(err, errNotStreamed) {
+		return c.pageProducts(ctx, progress, fn)
+	}
+	if err == nil && progress != nil {
+		progress(delivered, total)
+	}
+	return 0, err
+}
+
+// getProductStream performs a single streamed listing request from offset
+// without retries or breaker checks, passing each product to deliver with the
+// catalog size. It returns the catalog size.
+func (c *InventoryClient) getProductStream(ctx context.Context, offset int, deliver func(models.Product, int) error) (int, error) {
+	url := fmt.Sprintf("%s/v1/inventory?offset=%d", c.baseURL(), offset)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	c.setAuthHeaders(req)
+	req.Header.Set("Accept", ProductStreamContentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, newResponseError(resp, body)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != ProductStreamContentType {
+		return 0, errNotStreamed
+	}
+
+	total, _ := strconv.Atoi(resp.Header.Get(productStreamTotalHeader))
+	streamed := 0
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var product models.Product
+		if err := decoder.Decode(&product); err == io.EOF {
+			break
+		} else if err != nil {
+			return total, fmt.Errorf("%w: %v", ErrTruncatedStream, err)
+		}
+		if err := deliver(product, total); err != nil {
+			return total, err
+		}
+		streamed++
+	}
+
+	// The trailer is only read once the body hit EOF, and only sent when the
+	// central API reached the end of the listing
+	if count := resp.Trailer.Get(productStreamCountHeader); count != strconv.Itoa(streamed) {
+		return total, fmt.Errorf("%w: got %d products, central API reported %q", ErrTruncatedStream, streamed, count)
+	}
+	return total, nil
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit headers sent by the central API
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset" // Unix seconds
+	RetryAfterHeader         = "Retry-After"       // Seconds or an HTTP date
+)
+
+// RateLimitOptions configures how the client reacts to the central API's rate
+// limiting. Only idempotent calls wait; updates are never delayed.
+type RateLimitOptions struct {
+	// HonorRetryAfter makes retries wait at least the Retry-After of a 429 or
+	// 503 answer instead of only the exponential backoff
+	HonorRetryAfter bool
+	// WaitWhenExhausted delays calls while the last answer reported no
+	// remaining quota, until the quota resets
+	WaitWhenExhausted bool
+	// MaxWait caps any single rate limit wait. A Retry-After above it is not
+	// retried; the error is returned to the caller instead.
+	MaxWait time.Duration
+}
+
+// DefaultRateLimitOptions honors Retry-After and exhausted quotas for up to 10s
+func DefaultRateLimitOptions() RateLimitOptions {
+	return RateLimitOptions{
+		HonorRetryAfter:   true,
+		WaitWhenExhausted: true,
+		MaxWait:           10 * time.Second,
+	}
+}
+
+// RateLimitStatus is the central API quota as reported by the last response
+// that carried rate limit headers
+type RateLimitStatus struct {
+	Known      bool      `json:"known"`
+	Limit      int       `json:"limit"`
+	Remaining  int       `json:"remaining"`
+	Reset      time.Time `json:"reset"`
+	ObservedAt time.Time `json:"observedAt"`
+}
+
+// RemainingFraction returns the share of the quota left, or 1 when unknown
+func (s RateLimitStatus) RemainingFraction() float64 {
+	if !s.Known || s.Limit <= 0 {
+		return 1
+	}
+	return float64(s.Remaining) / float64(s.Limit)
+}
+
+// SetRateLimitOptions changes how the client reacts to rate limiting
+func (c *InventoryClient) SetRateLimitOptions(opts RateLimitOptions) {
+	c.rateLimitOptions = opts
+}
+
+// RateLimitStatus returns the quota the central API last reported
+func (c *InventoryClient) RateLimitStatus() RateLimitStatus {
+	return c.rateLimits.snapshot()
+}
+
+// waitForQuota delays an idempotent call while the quota is exhausted, for at
+// most MaxWait
+func (c *InventoryClient) waitForQuota(ctx context.Context) error {
+	if !c.rateLimitOptions.WaitWhenExhausted {
+		return nil
+	}
+	wait := c.rateLimits.exhaustedWait(time.Now())
+	if wait <= 0 {
+		return nil
+	}
+	if wait > c.rateLimitOptions.MaxWait {
+		wait = c.rateLimitOptions.MaxWait
+	}
+
+	slog.Debug("Rate limit quota exhausted, delaying central API call", "wait", wait)
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// retryDelay returns how long to wait before retrying err: the backoff, or the
+// answer's Retry-After when longer. ok is false when Retry-After exceeds MaxWait.
+func (c *InventoryClient) retryDelay(err error, backoff time.Duration) (delay time.Duration, ok bool) {
+	if !c.rateLimitOptions.HonorRetryAfter {
+		return backoff, true
+	}
+	apiErr, isAPIErr := AsAPIError(err)
+	if !isAPIErr || apiErr.RetryAfter <= backoff {
+		return backoff, true
+	}
+	if apiErr.RetryAfter > c.rateLimitOptions.MaxWait {
+		return 0, false
+	}
+	return apiErr.RetryAfter, true
+}
+
+// rateLimitTracker keeps the quota reported by the latest response
+type rateLimitTracker struct {
+	mu     sync.RWMutex
+	status RateLimitStatus
+}
+
+// observe records the quota headers of a response, if it has them
+func (t *rateLimitTracker) observe(header http.Header, now time.Time) {
+	limit, err := strconv.Atoi(header.Get(RateLimitLimitHeader))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(header.Get(RateLimitRemainingHeader))
+	if err != nil {
+		return
+	}
+	status := RateLimitStatus{Known: true, Limit: limit, Remaining: remaining, ObservedAt: now}
+	if reset, err := strconv.ParseInt(header.Get(RateLimitResetHeader), 10, 64); err == nil {
+		status.Reset = time.Unix(reset, 0)
+	}
+
+	t.mu.Lock()
+	t.status = status
+	t.mu.Unlock()
+}
+
+func (t *rateLimitTracker) snapshot() RateLimitStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.status
+}
+
+// exhaustedWait returns how long until an exhausted quota resets, or 0
+func (t *rateLimitTracker) exhaustedWait(now time.Time) time.Duration {
+	status := t.snapshot()
+	if !status.Known || status.Remaining > 0 || status.Reset.IsZero() {
+		return 0
+	}
+	return status.Reset.Sub(now)
+}
+
+// rateLimitTransport feeds the quota headers of every central API response,
+// long polls included, into the client's tracker
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	tracker *rateLimitTracker
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		t.tracker.observe(resp.Header, time.Now())
+	}
+	return resp, err
+}
+
+// parseRetryAfter reads a Retry-After value given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
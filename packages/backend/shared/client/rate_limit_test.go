@@ -0,0 +1,98 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{"empty", "", 0},
+		{"seconds", "30", 30 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-5", 0},
+		{"future date", now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{"past date", now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"garbage", "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitTracker_ExhaustedWait(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	header := func(limit, remaining, reset string) http.Header {
+		h := http.Header{}
+		h.Set(RateLimitLimitHeader, limit)
+		h.Set(RateLimitRemainingHeader, remaining)
+		h.Set(RateLimitResetHeader, reset)
+		return h
+	}
+
+	var tracker rateLimitTracker
+	if wait := tracker.exhaustedWait(now); wait != 0 {
+		t.Errorf("expected no wait before any response, got %v", wait)
+	}
+
+	tracker.observe(header("100", "0", "1700000020"), now)
+	if wait := tracker.exhaustedWait(now); wait != 20*time.Second {
+		t.Errorf("expected a wait until the reset, got %v", wait)
+	}
+	if fraction := tracker.snapshot().RemainingFraction(); fraction != 0 {
+		t.Errorf("expected no quota left, got %v", fraction)
+	}
+
+	// A response without quota headers keeps the last known quota
+	tracker.observe(http.Header{}, now)
+	if !tracker.snapshot().Known {
+		t.Error("expected the quota to stay known")
+	}
+
+	tracker.observe(header("100", "40", "1700000020"), now)
+	if wait := tracker.exhaustedWait(now); wait != 0 {
+		t.Errorf("expected no wait with quota left, got %v", wait)
+	}
+	if fraction := tracker.snapshot().RemainingFraction(); fraction != 0.4 {
+		t.Errorf("expected 0.4 of the quota left, got %v", fraction)
+	}
+}
+
+func TestInventoryClient_RetryDelay(t *testing.T) {
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 5 * time.Second}
+	tests := []struct {
+		name      string
+		options   RateLimitOptions
+		err       error
+		backoff   time.Duration
+		wantDelay time.Duration
+		wantOK    bool
+	}{
+		{"longer Retry-After wins", DefaultRateLimitOptions(), limited, time.Second, 5 * time.Second, true},
+		{"longer backoff wins", DefaultRateLimitOptions(), limited, 8 * time.Second, 8 * time.Second, true},
+		{"not an API error", DefaultRateLimitOptions(), errors.New("connection reset"), time.Second, time.Second, true},
+		{"Retry-After past MaxWait", RateLimitOptions{HonorRetryAfter: true, MaxWait: 2 * time.Second}, limited, time.Second, 0, false},
+		{"Retry-After ignored", RateLimitOptions{MaxWait: 2 * time.Second}, limited, time.Second, time.Second, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewInventoryClient("http://central.invalid", "key")
+			c.SetRateLimitOptions(tt.options)
+			delay, ok := c.retryDelay(tt.err, tt.backoff)
+			if delay != tt.wantDelay || ok != tt.wantOK {
+				t.Errorf("retryDelay = %v, %v; want %v, %v", delay, ok, tt.wantDelay, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// DefaultIdempotentRetryPolicy returns the retry policy used for read-only calls
+func DefaultIdempotentRetryPolicy() RetryOptions {
+	return RetryOptions{
+		MaxAttempts: 3,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}
+}
+
+// SetCircuitBreaker replaces the client's circuit breaker (nil disables it)
+func (c *InventoryClient) SetCircuitBreaker(breaker *CircuitBreaker) {
+	c.breaker = breaker
+}
+
+// CircuitBreaker returns the client's circuit breaker so services can expose its state
+func (c *InventoryClient) CircuitBreaker() *CircuitBreaker {
+	return c.breaker
+}
+
+// SetRetryPolicy sets the exponential-backoff policy for idempotent calls
+func (c *InventoryClient) SetRetryPolicy(policy RetryOptions) {
+	c.retryPolicy = policy
+}
+
+// GetProductCtx retrieves a product from the central inventory API
+func (c *InventoryClient) GetProductCtx(ctx context.Context, productID string) (*models.Product, error) {
+	var product *models.Product
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		product, err = c.getProduct(ctx, productID)
+		return err
+	})
+	return product, err
+}
+
+// BatchGetProductsCtx retrieves several products from the central inventory
+// API in one round trip. It is a read, so it is retried like GetProductCtx.
+func (c *InventoryClient) BatchGetProductsCtx(ctx context.Context, productIDs []string) (*models.BatchGetResponse, error) {
+	var batchResp *models.BatchGetResponse
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		batchResp, err = c.batchGetProducts(ctx, productIDs)
+		return err
+	})
+	return batchResp, err
+}
+
+// GetProductVersionsCtx retrieves only the current version and stock of
+// several products, the cheap read behind OCC conflict retries
+func (c *InventoryClient) GetProductVersionsCtx(ctx context.Context, productIDs []string) (*models.VersionsResponse, error) {
+	var versionsResp *models.VersionsResponse
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		versionsResp, err = c.getProductVersions(ctx, productIDs)
+		return err
+	})
+	return versionsResp, err
+}
+
+// GetCategoriesCtx retrieves the central category tree. Against a central API
+// without categories it returns no categories.
+func (c *InventoryClient) GetCategoriesCtx(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		categories, err = c.getCategories(ctx)
+		return err
+	})
+	if apiErr, ok := AsAPIError(err); ok && apiErr.StatusCode == http.StatusNotFound {
+		slog.Warn("Central API has no categories endpoint, syncing without categories")
+		return nil, nil
+	}
+	return categories, err
+}
+
+// ProductListProgress is called after each page of a product listing with the
+// number of products fetched so far and the catalog size the central API reported
+type ProductListProgress func(fetched, total int)
+
+// GetAllProductsCtx retrieves every product from the central inventory API,
+// following pagination to the last page
+func (c *InventoryClient) GetAllProductsCtx(ctx context.Context) ([]models.Product, error) {
+	products, _, err := c.listAllProducts(ctx, nil)
+	return products, err
+}
+
+// ListAllProductsCtx retrieves every product from the central inventory API,
+// following pagination to the last page. Each page is retried on its own.
+func (c *InventoryClient) ListAllProductsCtx(ctx context.Context) ([]models.Product, error) {
+	products, _, err := c.listAllProducts(ctx, nil)
+	return products, err
+}
+
+// GetAllProductsWithMetadataCtx retrieves every product along with the event
+// offset the central API reported on the first page
+func (c *InventoryClient) GetAllProductsWithMetadataCtx(ctx context.Context) ([]models.Product, int64, error) {
+	return c.listAllProducts(ctx, nil)
+}
+
+// GetAllProductsWithProgressCtx is GetAllProductsWithMetadataCtx reporting
+// progress after every page, for syncing large catalogs
+func (c *InventoryClient) GetAllProductsWithProgressCtx(ctx context.Context, progress ProductListProgress) ([]models.Product, int64, error) {
+	return c.listAllProducts(ctx, progress)
+}
+
+// GetCatalogSnapshotCtx streams the whole catalog as the central API saw it at
+// one point in time, with the event offset to poll from afterwards. Against a
+// central API without the snapshot endpoint it falls back to paging through
+// the listing, whose offset may be torn.
+func (c *InventoryClient) GetCatalogSnapshotCtx(ctx context.Context, progress ProductListProgress) ([]models.Product, int64, error) {
+	var products []models.Product
+	var eventOffset int64
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		products, eventOffset, err = c.getCatalogSnapshot(ctx, progress)
+		return err
+	})
+	if apiErr, ok := AsAPIError(err); ok && apiErr.StatusCode == http.StatusNotFound {
+		slog.Warn("Central API has no catalog snapshot endpoint, paging through the listing")
+		return c.listAllProducts(ctx, progress)
+	}
+	return products, eventOffset, err
+}
+
+// listAllProducts reads the whole product listing, streamed when the central
+// API supports it and paged otherwise. Each page, and each resumed stream, is
+// retried on its own, so a failure late in a big catalog does not restart the
+// listing.
+func (c *InventoryClient) listAllProducts(ctx context.Context, progress ProductListProgress) ([]models.Product, int64, error) {
+	var products []models.Product
+	collect := func(product models.Product) error {
+		products = append(products, product)
+		return nil
+	}
+
+	eventOffset, err := c.streamProducts(ctx, progress, collect)
+	if err != nil {
+		return nil, 0, err
+	}
+	return products, eventOffset, nil
+}
+
+// pageProducts pages through the product listing until the central API
+// reports no more, passing each product to fn, and returns the event offset
+// reported on the first page
+func (c *InventoryClient) pageProducts(ctx context.Context, progress ProductListProgress, fn func(models.Product) error) (int64, error) {
+	var eventOffset int64
+	fetched := 0
+	for offset := 0; ; {
+		var page *productPage
+		err := c.callIdempotent(ctx, func() error {
+			var err error
+			page, err = c.getProductPage(ctx, offset, productPageSize)
+			return err
+		})
+		if err != nil {
+			return 0, err
+		}
+		if offset == 0 {
+			eventOffset = page.EventOffset
+		}
+
+		for _, product := range page.Products {
+			if err := fn(product); err != nil {
+				return 0, err
+			}
+		}
+		fetched += len(page.Products)
+		offset += len(page.Products)
+		if progress != nil {
+			progress(fetched, page.Pagination.TotalCount)
+		}
+		if !page.Pagination.HasMore || len(page.Products) == 0 {
+			return eventOffset, nil
+		}
+	}
+}
+
+// GetEventsCtx retrieves events from the central inventory API
+func (c *InventoryClient) GetEventsCtx(ctx context.Context, offset int64, limit int, waitSeconds int) (*models.EventsResponse, error) {
+	var events *models.EventsResponse
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		events, err = c.getEvents(ctx, offset, limit, waitSeconds)
+		return err
+	})
+	return events, err
+}
+
+// CommitEventOffsetCtx tells the central API that this store (SetStoreID) has
+// applied every event before offset, so those events may be rotated away.
+// Commits only move forward, so they are retried like reads. Without a store
+// ID there is nothing to commit against and the call is a no-op.
+func (c *InventoryClient) CommitEventOffsetCtx(ctx context.Context, offset int64) error {
+	if c.storeID == "" {
+		return nil
+	}
+	return c.callIdempotent(ctx, func() error {
+		return c.commitEventOffset(ctx, offset)
+	})
+}
+
+// UpdateInventoryCtx sends an inventory update to the central API. Updates are
+// not retried here (see UpdateInventoryWithRetry) but fail fast while the breaker is open.
+func (c *InventoryClient) UpdateInventoryCtx(ctx context.Context, update models.UpdateRequest) (*models.UpdateResponse, error) {
+	var resp *models.UpdateResponse
+	err := c.callWithBreaker(ctx, func() error {
+		var err error
+		resp, err = c.updateInventory(ctx, update)
+		return err
+	})
+	return resp, err
+}
+
+// BatchUpdateInventoryCtx sends a batch inventory update to the central API
+func (c *InventoryClient) BatchUpdateInventoryCtx(ctx context.Context, batchUpdate models.BatchUpdateRequest) (*models.BatchUpdateResponse, error) {
+	var resp *models.BatchUpdateResponse
+	err := c.callWithBreaker(ctx, func() error {
+		var err error
+		resp, err = c.batchUpdateInventory(ctx, batchUpdate)
+		return err
+	})
+	return resp, err
+}
+
+// TransferStockCtx moves units of a product between two stores. Transfers carry
+// an idempotency key, so transient failures are retried like reads.
+func (c *InventoryClient) TransferStockCtx(ctx context.Context, transfer models.TransferRequest) (*models.StockTransfer, error) {
+	var resp *models.StockTransfer
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		resp, err = c.transferStock(ctx, transfer)
+		return err
+	})
+	return resp, err
+}
+
+// ListTransfersCtx retrieves transfer history with retries and circuit breaking
+func (c *InventoryClient) ListTransfersCtx(ctx context.Context, productID, storeID string, limit int) (*models.TransfersResponse, error) {
+	var resp *models.TransfersResponse
+	err := c.callIdempotent(ctx, func() error {
+		var err error
+		resp, err = c.listTransfers(ctx, productID, storeID, limit)
+		return err
+	})
+	return resp, err
+}
+
+// callIdempotent runs fn through the breaker and retries transient failures
+// with backoff, honoring the central API's rate limit headers
+func (c *InventoryClient) callIdempotent(ctx context.Context, fn func() error) error {
+	attempts := c.retryPolicy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err := c.waitForQuota(ctx); err != nil {
+			return err
+		}
+		err = c.callWithBreaker(ctx, fn)
+		if err == nil || !isRetryable(ctx, err) || attempt == attempts {
+			return err
+		}
+
+		delay, ok := c.retryDelay(err, backoffWithJitter(c.retryPolicy, attempt))
+		if !ok {
+			// The central API asked for a longer pause than we are willing to wait
+			return err
+		}
+		select {
+		case <-c.after(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return err
+}
+
+// callWithBreaker runs fn if the breaker allows it and records the outcome
+func (c *InventoryClient) callWithBreaker(ctx context.Context, fn func() error) error {
+	if c.breaker == nil {
+		return fn()
+	}
+
+	if err := c.breaker.Allow(); err != nil {
+		return err
+	}
+
+	err := fn()
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Caller gave up; this says nothing about the central API's health
+		c.breaker.release()
+	case isBreakerFailure(err):
+		c.breaker.RecordFailure()
+	default:
+		c.breaker.RecordSuccess()
+	}
+
+	return err
+}
+
+// isBreakerFailure reports whether err indicates the central API is unhealthy.
+// Client errors (4xx) are valid answers and do not count as failures.
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+// isRetryable reports whether an idempotent call should be retried
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	if apiErr, ok := AsAPIError(err); ok {
+		return apiErr.StatusCode >= http.StatusInternalServerError ||
+			apiErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	if errors.Is(err, ErrTruncatedSnapshot) || errors.Is(err, ErrTruncatedStream) {
+		return true
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// flakyCentral answers each request with the next of statuses, then with 200
+type flakyCentral struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	requests   int
+}
+
+func (f *flakyCentral) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+
+	status := http.StatusOK
+	if len(f.statuses) > 0 {
+		status, f.statuses = f.statuses[0], f.statuses[1:]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if status != http.StatusOK {
+		if f.retryAfter != "" {
+			w.Header().Set(RetryAfterHeader, f.retryAfter)
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": http.StatusText(status)})
+		return
+	}
+	if r.Method == http.MethodPost {
+		json.NewEncoder(w).Encode(models.UpdateResponse{ProductID: "SKU-001", Applied: true})
+		return
+	}
+	json.NewEncoder(w).Encode(models.Product{ProductID: "SKU-001", Available: 5, Version: 1})
+}
+
+// newFlakyClient returns a client of central whose backoff waits are recorded
+// instead of slept
+func newFlakyClient(t *testing.T, central *flakyCentral, policy RetryOptions) (*InventoryClient, *[]time.Duration) {
+	t.Helper()
+	server := httptest.NewServer(central)
+	t.Cleanup(server.Close)
+
+	c := NewInventoryClient(server.URL, "key")
+	c.SetRetryPolicy(policy)
+	var waits []time.Duration
+	c.after = func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		fired := make(chan time.Time, 1)
+		fired <- time.Time{}
+		return fired
+	}
+	return c, &waits
+}
+
+func TestIsRetryable(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"server error", context.Background(), &APIError{StatusCode: http.StatusInternalServerError}, true},
+		{"unavailable", context.Background(), &APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"rate limited", context.Background(), &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"not found", context.Background(), &APIError{StatusCode: http.StatusNotFound}, false},
+		{"version conflict", context.Background(), &APIError{StatusCode: http.StatusConflict}, false},
+		{"network error", context.Background(), &url.Error{Op: "Get", URL: "http://central", Err: errors.New("connection refused")}, true},
+		{"truncated snapshot", context.Background(), fmt.Errorf("reading page: %w", ErrTruncatedSnapshot), true},
+		{"truncated stream", context.Background(), ErrTruncatedStream, true},
+		{"circuit open", context.Background(), ErrCircuitOpen, false},
+		{"decode error", context.Background(), errors.New("failed to decode response"), false},
+		{"cancelled caller", cancelled, &APIError{StatusCode: http.StatusServiceUnavailable}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBreakerFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"success", nil, false},
+		{"server error", &APIError{StatusCode: http.StatusInternalServerError}, true},
+		{"bad gateway", &APIError{StatusCode: http.StatusBadGateway}, true},
+		{"not found", &APIError{StatusCode: http.StatusNotFound}, false},
+		{"rate limited", &APIError{StatusCode: http.StatusTooManyRequests}, false},
+		{"insufficient inventory", &APIError{StatusCode: http.StatusUnprocessableEntity}, false},
+		{"network error", &url.Error{Op: "Get", URL: "http://central", Err: errors.New("connection refused")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isBreakerFailure(tt.err); got != tt.want {
+				t.Errorf("isBreakerFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallIdempotent_BacksOffBetweenRetries(t *testing.T) {
+	central := &flakyCentral{statuses: []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusServiceUnavailable}}
+	c, waits := newFlakyClient(t, central, RetryOptions{MaxAttempts: 4, BaseDelay: 100 * time.Millisecond, MaxDelay: 150 * time.Millisecond})
+
+	product, err := c.GetProductCtx(context.Background(), "SKU-001")
+	if err != nil {
+		t.Fatalf("Expected the read to succeed on the fourth attempt, got %v", err)
+	}
+	if product.Available != 5 || central.requests != 4 {
+		t.Errorf("Expected 4 requests and the product, got %d requests and %+v", central.requests, product)
+	}
+
+	// Full jitter: each wait is at most the exponential delay, capped at MaxDelay
+	limits := []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 150 * time.Millisecond}
+	if len(*waits) != len(limits) {
+		t.Fatalf("Expected %d backoff waits, got %v", len(limits), *waits)
+	}
+	for i, wait := range *waits {
+		if wait < 0 || wait > limits[i] {
+			t.Errorf("Wait %d: expected between 0 and %v, got %v", i+1, limits[i], wait)
+		}
+	}
+}
+
+func TestCallIdempotent_GivesUpAfterMaxAttempts(t *testing.T) {
+	central := &flakyCentral{statuses: []int{500, 500, 500, 500}}
+	c, waits := newFlakyClient(t, central, RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := c.GetProductCtx(context.Background(), "SKU-001")
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected the last 500, got %v", err)
+	}
+	if central.requests != 3 || len(*waits) != 2 {
+		t.Errorf("Expected 3 requests with 2 waits between them, got %d and %d", central.requests, len(*waits))
+	}
+}
+
+func TestCallIdempotent_DoesNotRetryClientErrors(t *testing.T) {
+	central := &flakyCentral{statuses: []int{http.StatusNotFound}}
+	c, waits := newFlakyClient(t, central, RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := c.GetProductCtx(context.Background(), "SKU-001")
+	if !IsErrorType(err, ErrorTypeProductNotFound) {
+		t.Fatalf("Expected product_not_found, got %v", err)
+	}
+	if central.requests != 1 || len(*waits) != 0 {
+		t.Errorf("Expected a single request, got %d with %d waits", central.requests, len(*waits))
+	}
+	if c.CircuitBreaker().Stats().ConsecutiveFailures != 0 {
+		t.Error("Expected a 404 not to count as a breaker failure")
+	}
+}
+
+func TestCallIdempotent_HonorsRetryAfter(t *testing.T) {
+	central := &flakyCentral{statuses: []int{http.StatusTooManyRequests}, retryAfter: "2"}
+	c, waits := newFlakyClient(t, central, RetryOptions{MaxAttempts: 2, BaseDelay: time.Millisecond})
+
+	if _, err := c.GetProductCtx(context.Background(), "SKU-001"); err != nil {
+		t.Fatalf("Expected the retry to succeed, got %v", err)
+	}
+	if len(*waits) != 1 || (*waits)[0] != 2*time.Second {
+		t.Errorf("Expected one wait of the 2s Retry-After, got %v", *waits)
+	}
+
+	// A Retry-After beyond MaxWait is returned instead of waited out
+	central.statuses, central.retryAfter = []int{http.StatusTooManyRequests}, "60"
+	*waits = nil
+	_, err := c.GetProductCtx(context.Background(), "SKU-001")
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected the 429 returned, got %v", err)
+	}
+	if len(*waits) != 0 {
+		t.Errorf("Expected no wait beyond MaxWait, got %v", *waits)
+	}
+}
+
+func TestCallIdempotent_StopsWhenBreakerOpens(t *testing.T) {
+	central := &flakyCentral{statuses: []int{500, 500, 500, 500}}
+	c, _ := newFlakyClient(t, central, RetryOptions{MaxAttempts: 4, BaseDelay: time.Millisecond})
+	breaker, _ := newTestBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	c.SetCircuitBreaker(breaker)
+
+	_, err := c.GetProductCtx(context.Background(), "SKU-001")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen once the breaker opened, got %v", err)
+	}
+	if central.requests != 2 {
+		t.Errorf("Expected the open breaker to stop the retries after 2 requests, got %d", central.requests)
+	}
+	if breaker.State() != BreakerOpen {
+		t.Errorf("Expected the breaker open, got %s", breaker.State())
+	}
+}
+
+func TestUpdateInventoryCtx_NeverRetried(t *testing.T) {
+	central := &flakyCentral{statuses: []int{http.StatusServiceUnavailable}}
+	c, waits := newFlakyClient(t, central, RetryOptions{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	update := models.UpdateRequest{StoreID: "store-001", ProductID: "SKU-001", Delta: -1, Version: 1, IdempotencyKey: "key-1"}
+	_, err := c.UpdateInventoryCtx(context.Background(), update)
+	if apiErr, ok := AsAPIError(err); !ok || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the 503 returned, got %v", err)
+	}
+	if central.requests != 1 || len(*waits) != 0 {
+		t.Errorf("Expected the POST sent once without waiting, got %d requests and %d waits", central.requests, len(*waits))
+	}
+	if c.CircuitBreaker().Stats().ConsecutiveFailures != 1 {
+		t.Error("Expected the 503 to count as a breaker failure")
+	}
+
+	central.statuses = []int{http.StatusServiceUnavailable}
+	central.requests = 0
+	_, err = c.BatchUpdateInventoryCtx(context.Background(), models.BatchUpdateRequest{StoreID: "store-001", Updates: []models.UpdateRequest{update}})
+	if err == nil || central.requests != 1 {
+		t.Errorf("Expected the batch POST sent once and failed, got %d requests and %v", central.requests, err)
+	}
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+)
+
+// Update outcome statuses returned by UpdateInventoryWithRetry
+const (
+	OutcomeApplied               = "applied"
+	OutcomeInsufficientInventory = "insufficient_inventory"
+	OutcomeConflictExhausted     = "conflict_exhausted"
+	OutcomeFailed                = "failed"
+)
+
+// RetryOptions configures the optimistic-concurrency retry loop
+type RetryOptions struct {
+	MaxAttempts int           // Total attempts including the first one
+	BaseDelay   time.Duration // Base backoff delay before jitter
+	MaxDelay    time.Duration // Upper bound for a single backoff delay
+}
+
+// DefaultRetryOptions returns sensible defaults for store-side update retries
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts: 3,
+		BaseDelay:   50 * time.Millisecond,
+		MaxDelay:    time.Second,
+	}
+}
+
+// UpdateOutcome describes the final result of an update with conflict retries
+type UpdateOutcome struct {
+	Status       string                 `json:"status"`
+	Response     *models.UpdateResponse `json:"response,omitempty"`
+	Attempts     int                    `json:"attempts"`
+	Conflicts    int                    `json:"conflicts"`
+	FinalVersion int                    `json:"finalVersion"`
+	Available    int                    `json:"available"`
+	Err          error                  `json:"-"`
+}
+
+// UpdateInventoryWithRetry sends an inventory update and, on version_conflict,
+// re-reads the product's version and stock via the versions endpoint, checks
+// the delta still applies and retries with the fresh version. Each retry uses
+// a derived idempotency key because the central API caches the conflict
+// result under the original key.
+func (c *InventoryClient) UpdateInventoryWithRetry(update models.UpdateRequest, opts RetryOptions) *UpdateOutcome {
+	return c.UpdateInventoryWithRetryCtx(context.Background(), update, opts)
+}
+
+// UpdateInventoryWithRetryCtx is UpdateInventoryWithRetry bound to ctx; the
+// retry loop stops early when ctx is cancelled.
+func (c *InventoryClient) UpdateInventoryWithRetryCtx(ctx context.Context, update models.UpdateRequest, opts RetryOptions) *UpdateOutcome {
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 1
+	}
+
+	outcome := &UpdateOutcome{}
+	baseKey := update.IdempotencyKey
+
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		outcome.Attempts = attempt
+		if attempt > 1 {
+			update.IdempotencyKey = idempotency.DeriveKey(baseKey, "retry", strconv.Itoa(attempt-1))
+		}
+
+		resp, err := c.UpdateInventoryCtx(ctx, update)
+		if err == nil {
+			outcome.Status = OutcomeApplied
+			outcome.Err = nil // Drop the conflicts of earlier attempts
+			outcome.Response = resp
+			outcome.FinalVersion = resp.NewVersion
+			outcome.Available = resp.NewQuantity
+			return outcome
+		}
+
+		if !isVersionConflict(err) {
+			outcome.Status = OutcomeFailed
+			if isInsufficientInventory(err) {
+				outcome.Status = OutcomeInsufficientInventory
+				// The 422 envelope carries the current stock and version
+				if apiErr, ok := AsAPIError(err); ok && apiErr.NewVersion > 0 {
+					outcome.FinalVersion = apiErr.NewVersion
+					outcome.Available = apiErr.NewQuantity
+				}
+			}
+			outcome.Err = err
+			return outcome
+		}
+
+		outcome.Conflicts++
+		outcome.Err = err
+
+		if attempt == opts.MaxAttempts {
+			break
+		}
+
+		// Re-read only the latest version and stock, not the full product
+		versions, getErr := c.GetProductVersionsCtx(ctx, []string{update.ProductID})
+		if getErr != nil {
+			outcome.Status = OutcomeFailed
+			outcome.Err = fmt.Errorf("failed to refresh product after conflict: %w", getErr)
+			return outcome
+		}
+		current, found := versions.Versions[update.ProductID]
+		if !found {
+			outcome.Status = OutcomeFailed
+			outcome.Err = fmt.Errorf("product %s no longer exists after conflict", update.ProductID)
+			return outcome
+		}
+
+		outcome.FinalVersion = current.Version
+		outcome.Available = current.Available
+
+		// The sale no longer fits in the current stock, retrying cannot succeed
+		if current.Available+update.Delta < 0 {
+			outcome.Status = OutcomeInsufficientInventory
+			outcome.Err = fmt.Errorf("insufficient inventory: current %d, delta %d", current.Available, update.Delta)
+			return outcome
+		}
+
+		update.Version = current.Version
+
+		delay := backoffWithJitter(opts, attempt)
+		slog.Debug("Retrying inventory update after version conflict",
+			"product_id", update.ProductID,
+			"attempt", attempt,
+			"next_version", update.Version,
+			"delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			outcome.Status = OutcomeFailed
+			outcome.Err = ctx.Err()
+			return outcome
+		}
+	}
+
+	outcome.Status = OutcomeConflictExhausted
+	return outcome
+}
+
+// backoffWithJitter returns an exponential backoff delay with full jitter
+func backoffWithJitter(opts RetryOptions, attempt int) time.Duration {
+	if opts.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := opts.BaseDelay << (attempt - 1)
+	if opts.MaxDelay > 0 && delay > opts.MaxDelay {
+		delay = opts.MaxDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+// isVersionConflict reports whether the error is an OCC version conflict
+func isVersionConflict(err error) bool {
+	return IsErrorType(err, ErrorTypeVersionConflict)
+}
+
+// isInsufficientInventory reports whether the error is an insufficient stock rejection
+func isInsufficientInventory(err error) bool {
+	return IsErrorType(err, ErrorTypeInsufficientInventory)
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+)
+
+// fakeOCC is a central API holding one product. It answers each update
+// with the next of answers, then applies updates at the current version.
+type fakeOCC struct {
+	mu        sync.Mutex
+	version   int
+	available int
+	answers   []int // Status for each update before any is applied
+	updates   []models.UpdateRequest
+}
+
+func (f *fakeOCC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/v1/inventory/versions":
+		json.NewEncoder(w).Encode(models.VersionsResponse{
+			Versions: map[string]models.ProductVersion{"SKU-001": {Version: f.version, Available: f.available}},
+		})
+	case "/v1/inventory/updates":
+		var update models.UpdateRequest
+		json.NewDecoder(r.Body).Decode(&update)
+		f.updates = append(f.updates, update)
+
+		status := http.StatusOK
+		if len(f.answers) > 0 {
+			status, f.answers = f.answers[0], f.answers[1:]
+		}
+		if status == http.StatusOK && update.Version != f.version {
+			status = http.StatusConflict
+		}
+		if status != http.StatusOK {
+			errorType := map[int]string{
+				http.StatusConflict:            ErrorTypeVersionConflict,
+				http.StatusUnprocessableEntity: ErrorTypeInsufficientInventory,
+			}[status]
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]any{
+				"productId": update.ProductID, "applied": false, "errorType": errorType,
+				"errorMessage": errorType, "newVersion": f.version, "newQuantity": f.available,
+			})
+			return
+		}
+
+		f.available += update.Delta
+		f.version++
+		json.NewEncoder(w).Encode(models.UpdateResponse{
+			ProductID: update.ProductID, NewQuantity: f.available, NewVersion: f.version,
+			Delta: update.Delta, IdempotencyKey: update.IdempotencyKey, Applied: true,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newRetryClient returns a client of central with the transport retries off,
+// so every update the retry loop sends reaches central once
+func newRetryClient(t *testing.T, central *fakeOCC) *InventoryClient {
+	t.Helper()
+	server := httptest.NewServer(central)
+	t.Cleanup(server.Close)
+	c := NewInventoryClient(server.URL, "key")
+	c.SetRetryPolicy(RetryOptions{MaxAttempts: 1})
+	return c
+}
+
+// retryOptions retries conflicts up to attempts times without waiting long
+func retryOptions(attempts int) RetryOptions {
+	return RetryOptions{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+}
+
+const baseKey = "01J0000000000000000000KEY1"
+
+func saleUpdate(version, units int) models.UpdateRequest {
+	return models.UpdateRequest{StoreID: "store-001", ProductID: "SKU-001", Delta: -units, Version: version, IdempotencyKey: baseKey}
+}
+
+func TestUpdateInventoryWithRetry_ConflictThenSuccess(t *testing.T) {
+	central := &fakeOCC{version: 5, available: 10}
+	c := newRetryClient(t, central)
+
+	outcome := c.UpdateInventoryWithRetryCtx(context.Background(), saleUpdate(4, 2), retryOptions(3))
+	if outcome.Status != OutcomeApplied || outcome.Err != nil {
+		t.Fatalf("Expected the update applied, got %s: %v", outcome.Status, outcome.Err)
+	}
+	if outcome.Attempts != 2 || outcome.Conflicts != 1 {
+		t.Errorf("Expected 2 attempts with 1 conflict, got %d with %d", outcome.Attempts, outcome.Conflicts)
+	}
+	if outcome.FinalVersion != 6 || outcome.Available != 8 {
+		t.Errorf("Expected 8 units at version 6, got %d at %d", outcome.Available, outcome.FinalVersion)
+	}
+
+	if len(central.updates) != 2 {
+		t.Fatalf("Expected 2 updates sent, got %d", len(central.updates))
+	}
+	if central.updates[1].Version != 5 {
+		t.Errorf("Expected the retry at the re-read version 5, got %d", central.updates[1].Version)
+	}
+	if central.updates[1].Delta != -2 {
+		t.Errorf("Expected the retry to keep the delta, got %d", central.updates[1].Delta)
+	}
+}
+
+func TestUpdateInventoryWithRetry_DerivesRetryKeys(t *testing.T) {
+	central := &fakeOCC{version: 5, available: 10, answers: []int{http.StatusConflict, http.StatusConflict, http.StatusConflict}}
+	c := newRetryClient(t, central)
+
+	outcome := c.UpdateInventoryWithRetry(saleUpdate(5, 1), retryOptions(3))
+	if outcome.Status != OutcomeConflictExhausted {
+		t.Fatalf("Expected conflicts to exhaust the attempts, got %s", outcome.Status)
+	}
+	if outcome.Attempts != 3 || outcome.Conflicts != 3 {
+		t.Errorf("Expected 3 attempts, all conflicts, got %d with %d", outcome.Attempts, outcome.Conflicts)
+	}
+	if !isVersionConflict(outcome.Err) {
+		t.Errorf("Expected the last conflict as the error, got %v", outcome.Err)
+	}
+
+	// The first attempt keeps the caller's key; each retry derives its own,
+	// the same way every time, so a resent retry is still deduplicated
+	want := []string{baseKey}
+	for retry := 1; retry < 3; retry++ {
+		want = append(want, idempotency.DeriveKey(baseKey, "retry", strconv.Itoa(retry)))
+	}
+	for i, update := range central.updates {
+		if update.IdempotencyKey != want[i] {
+			t.Errorf("Attempt %d: expected key %s, got %s", i+1, want[i], update.IdempotencyKey)
+		}
+	}
+	if want[1] == want[2] {
+		t.Error("Expected every retry to get a distinct key")
+	}
+}
+
+func TestUpdateInventoryWithRetry_InsufficientInventory(t *testing.T) {
+	central := &fakeOCC{version: 7, available: 1, answers: []int{http.StatusUnprocessableEntity}}
+	c := newRetryClient(t, central)
+
+	outcome := c.UpdateInventoryWithRetry(saleUpdate(7, 3), retryOptions(3))
+	if outcome.Status != OutcomeInsufficientInventory {
+		t.Fatalf("Expected insufficient_inventory, got %s", outcome.Status)
+	}
+	if outcome.Attempts != 1 || len(central.updates) != 1 {
+		t.Errorf("Expected no retry, got %d attempts and %d updates", outcome.Attempts, len(central.updates))
+	}
+	if outcome.FinalVersion != 7 || outcome.Available != 1 {
+		t.Errorf("Expected the central stock 1 at version 7, got %d at %d", outcome.Available, outcome.FinalVersion)
+	}
+	if !isInsufficientInventory(outcome.Err) {
+		t.Errorf("Expected the 422 as the error, got %v", outcome.Err)
+	}
+}
+
+func TestUpdateInventoryWithRetry_StopsWhenConflictLeavesTooLittleStock(t *testing.T) {
+	central := &fakeOCC{version: 9, available: 1}
+	c := newRetryClient(t, central)
+
+	outcome := c.UpdateInventoryWithRetry(saleUpdate(8, 3), retryOptions(3))
+	if outcome.Status != OutcomeInsufficientInventory {
+		t.Fatalf("Expected insufficient_inventory after the re-read, got %s", outcome.Status)
+	}
+	if len(central.updates) != 1 {
+		t.Errorf("Expected no retry of a sale that cannot fit, got %d updates", len(central.updates))
+	}
+	if outcome.FinalVersion != 9 || outcome.Available != 1 {
+		t.Errorf("Expected the re-read stock 1 at version 9, got %d at %d", outcome.Available, outcome.FinalVersion)
+	}
+}
+
+func TestUpdateInventoryWithRetry_StopsOnCancelledContext(t *testing.T) {
+	central := &fakeOCC{version: 5, available: 10, answers: []int{http.StatusConflict}}
+	c := newRetryClient(t, central)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outcome := c.UpdateInventoryWithRetryCtx(ctx, saleUpdate(5, 1), RetryOptions{MaxAttempts: 3, BaseDelay: time.Hour, MaxDelay: time.Hour})
+	if outcome.Status != OutcomeFailed {
+		t.Fatalf("Expected the cancelled loop to fail, got %s", outcome.Status)
+	}
+	if len(central.updates) > 1 {
+		t.Errorf("Expected no retry after cancellation, got %d updates", len(central.updates))
+	}
+}
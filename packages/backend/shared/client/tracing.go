@@ -0,0 +1,56 @@
+package client
+
+import (
+	"net/http"
+
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingTransport starts a client span for each central API call and injects
+// the trace context headers so the central service continues the same trace
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// newTracingTransport wraps base, defaulting to http.DefaultTransport
+func newTracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), req.Method+" central-api",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.path", req.URL.Path),
+			attribute.String("server.address", req.URL.Host),
+		),
+	)
+	defer span.End()
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	return resp, nil
+}
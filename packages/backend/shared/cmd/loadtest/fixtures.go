@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+)
+
+// Fixture scenarios mirroring PerformanceTestData in the central API's unit
+// test fixtures (tests/unit/testutils/fixtures)
+const (
+	fixtureConcurrent = "concurrent"
+	fixtureHighVolume = "high-volume"
+)
+
+// concurrentProductID is the single product every concurrent fixture update targets
+const concurrentProductID = "PROD-CONCURRENT"
+
+// largeInventorySize is the number of PROD-NNNN products in the fixtures
+const largeInventorySize = 1000
+
+// fixtureUpdates returns the updates of a PerformanceTestData scenario. Every
+// fixture update carries version 1, so replaying them measures how the central
+// API resolves contention; run IDs keep the idempotency keys fresh.
+func fixtureUpdates(scenario, storeID, runID string) ([]models.UpdateRequest, error) {
+	var updates []models.UpdateRequest
+	switch scenario {
+	case fixtureConcurrent:
+		updates = make([]models.UpdateRequest, 100)
+		for i := range updates {
+			updates[i] = models.UpdateRequest{
+				StoreID:        storeID,
+				ProductID:      concurrentProductID,
+				Delta:          -1,
+				Version:        1,
+				IdempotencyKey: idempotency.DeriveKey("concurrent", runID, strconv.Itoa(i)),
+			}
+		}
+	case fixtureHighVolume:
+		updates = make([]models.UpdateRequest, 10000)
+		for i := range updates {
+			updates[i] = models.UpdateRequest{
+				StoreID:        storeID,
+				ProductID:      fmt.Sprintf("PROD-%04d", i%largeInventorySize),
+				Delta:          -1,
+				Version:        1,
+				IdempotencyKey: idempotency.DeriveKey("high-volume", runID, strconv.Itoa(i)),
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown fixture %q, expected %s or %s", scenario, fixtureConcurrent, fixtureHighVolume)
+	}
+	return updates, nil
+}
+
+// ReplayFixture sends a fixture's updates with the given concurrency and
+// records each one as an update
+func ReplayFixture(ctx context.Context, inventoryClient *client.InventoryClient, updates []models.UpdateRequest, concurrency int, recorder *Recorder) {
+	queue := make(chan models.UpdateRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for update := range queue {
+				start := time.Now()
+				_, err := inventoryClient.UpdateInventoryCtx(ctx, update)
+				if ctx.Err() != nil {
+					return
+				}
+				recorder.Record(opUpdate, time.Since(start), err)
+			}
+		}()
+	}
+
+	for _, update := range updates {
+		select {
+		case queue <- update:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// seedProduct is the admin create payload for one fixture product
+type seedProduct struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	Available int     `json:"available"`
+	Price     float64 `json:"price"`
+}
+
+// SeedFixtureProducts creates the fixture products (PROD-CONCURRENT and the
+// large inventory) through the admin API. Products that already exist are
+// left as they are, so the fixtures' version 1 only matches on a fresh catalog.
+func SeedFixtureProducts(ctx context.Context, baseURL, adminKey string) error {
+	products := make([]seedProduct, 0, largeInventorySize+1)
+	products = append(products, seedProduct{
+		ProductID: concurrentProductID,
+		Name:      "Concurrent Test Product",
+		Available: 1000,
+		Price:     9.99,
+	})
+	for i := 0; i < largeInventorySize; i++ {
+		products = append(products, seedProduct{
+			ProductID: fmt.Sprintf("PROD-%04d", i),
+			Name:      fmt.Sprintf("Load Test Product %04d", i),
+			Available: 100,
+			Price:     9.99,
+		})
+	}
+
+	// The admin API accepts a limited number of products per request
+	const chunkSize = 500
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	for start := 0; start < len(products); start += chunkSize {
+		end := start + chunkSize
+		if end > len(products) {
+			end = len(products)
+		}
+
+		body, err := json.Marshal(map[string]interface{}{"products": products[start:end]})
+		if err != nil {
+			return fmt.Errorf("failed to marshal products: %w", err)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/v1/admin/products/create", bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", adminKey)
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to seed products: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("seeding products failed with status %d", resp.StatusCode)
+		}
+	}
+	return nil
+}
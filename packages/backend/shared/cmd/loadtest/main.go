@@ -0,0 +1,176 @@
+// Command loadtest drives configurable mixes of reads, single updates and
+// batch updates against a running central inventory API through the shared
+// client, then reports latency percentiles and conflict rates. It can also
+// replay the PerformanceTestData fixtures of the central API's unit tests.
+//
+//	go run ./cmd/loadtest -url http://localhost:8081 -mix read:70,update:25,batch:5 -duration 30s
+//	go run ./cmd/loadtest -fixture concurrent -seed -admin-key admin-demo
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+)
+
+func main() {
+	baseURL := flag.String("url", envOrDefault("CENTRAL_API_URL", "http://localhost:8081"), "central inventory API base URL")
+	apiKey := flag.String("api-key", envOrDefault("CENTRAL_API_KEY", "demo"), "API key for reads and updates")
+	adminKey := flag.String("admin-key", os.Getenv("ADMIN_API_KEY"), "admin API key, needed by -seed")
+	storeID := flag.String("store-id", "loadtest", "store ID sent with updates")
+	mixFlag := flag.String("mix", "read:70,update:25,batch:5", "operation weights for the mixed workload")
+	products := flag.String("products", "", "comma-separated product IDs (default: every product in the catalog)")
+	concurrency := flag.Int("concurrency", 10, "number of concurrent workers")
+	requests := flag.Int("requests", 0, "total calls to make (0 runs for -duration)")
+	duration := flag.Duration("duration", 30*time.Second, "how long to run when -requests is 0")
+	delta := flag.Int("delta", -1, "stock change sent with each update")
+	batchSize := flag.Int("batch-size", 10, "updates per batch call")
+	priority := flag.String("priority", "", "X-Update-Priority for updates: checkout, sync or bulk")
+	fixture := flag.String("fixture", "", "replay a PerformanceTestData scenario instead: concurrent or high-volume")
+	seed := flag.Bool("seed", false, "create the fixture products through the admin API before replaying")
+	jsonOutput := flag.Bool("json", false, "print the report as JSON")
+	maxP99 := flag.Duration("max-p99", 0, "exit with status 1 when any operation's p99 exceeds this (0 disables)")
+	maxErrorRate := flag.Float64("max-error-rate", 0, "exit with status 1 when the unexpected error rate exceeds this fraction (0 disables)")
+	flag.Parse()
+
+	if *concurrency < 1 {
+		fail("concurrency must be at least 1")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Measure the API, not the client's resilience: no retries, no breaker
+	inventoryClient := client.NewInventoryClient(*baseURL, *apiKey)
+	inventoryClient.SetStoreID(*storeID)
+	inventoryClient.SetCircuitBreaker(nil)
+	inventoryClient.SetRetryPolicy(client.RetryOptions{MaxAttempts: 1})
+	switch *priority {
+	case "":
+	case client.UpdatePriorityCheckout, client.UpdatePrioritySync, client.UpdatePriorityBulk:
+		ctx = client.WithUpdatePriority(ctx, *priority)
+	default:
+		fail("priority must be checkout, sync or bulk")
+	}
+
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	var recorder *Recorder
+	var scenario string
+
+	if *fixture != "" {
+		updates, err := fixtureUpdates(*fixture, *storeID, runID)
+		if err != nil {
+			fail(err.Error())
+		}
+		if *seed {
+			if *adminKey == "" {
+				fail("-seed needs -admin-key or ADMIN_API_KEY")
+			}
+			if err := SeedFixtureProducts(ctx, *baseURL, *adminKey); err != nil {
+				fail(err.Error())
+			}
+		}
+
+		scenario = "fixture:" + *fixture
+		slog.Info("Replaying fixture", "fixture", *fixture, "updates", len(updates), "concurrency", *concurrency)
+		recorder = NewRecorder()
+		ReplayFixture(ctx, inventoryClient, updates, *concurrency, recorder)
+	} else {
+		mix, err := ParseMix(*mixFlag)
+		if err != nil {
+			fail(err.Error())
+		}
+
+		catalog, err := inventoryClient.ListAllProductsCtx(ctx)
+		if err != nil {
+			fail(fmt.Sprintf("failed to list products: %v", err))
+		}
+		productIDs := selectProducts(catalog, *products)
+		if len(productIDs) == 0 {
+			fail("no products to drive load against")
+		}
+
+		workload := &MixedWorkload{
+			Client:      inventoryClient,
+			StoreID:     *storeID,
+			ProductIDs:  productIDs,
+			Mix:         mix,
+			Delta:       *delta,
+			BatchSize:   *batchSize,
+			Concurrency: *concurrency,
+			Requests:    *requests,
+			Duration:    *duration,
+			RunID:       runID,
+		}
+
+		scenario = "mix:" + *mixFlag
+		slog.Info("Running mixed workload",
+			"mix", *mixFlag,
+			"products", len(productIDs),
+			"concurrency", *concurrency,
+			"requests", *requests,
+			"duration", duration.String())
+		recorder = NewRecorder()
+		workload.Run(ctx, recorder, catalog)
+	}
+	recorder.Finish()
+
+	report := recorder.Report(scenario)
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		report.WriteText(os.Stdout)
+	}
+
+	// Thresholds make the run usable as a release gate
+	if *maxP99 > 0 && report.MaxP99() > *maxP99 {
+		fmt.Fprintf(os.Stderr, "p99 latency %s exceeds %s\n", report.MaxP99(), *maxP99)
+		os.Exit(1)
+	}
+	if *maxErrorRate > 0 && report.ErrorRate() > *maxErrorRate {
+		fmt.Fprintf(os.Stderr, "error rate %.3f exceeds %.3f\n", report.ErrorRate(), *maxErrorRate)
+		os.Exit(1)
+	}
+}
+
+// selectProducts returns the requested product IDs, or every product in the catalog
+func selectProducts(catalog []models.Product, requested string) []string {
+	var productIDs []string
+	if requested != "" {
+		for _, productID := range strings.Split(requested, ",") {
+			if productID = strings.TrimSpace(productID); productID != "" {
+				productIDs = append(productIDs, productID)
+			}
+		}
+		return productIDs
+	}
+	for _, product := range catalog {
+		productIDs = append(productIDs, product.ProductID)
+	}
+	return productIDs
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, "loadtest:", message)
+	os.Exit(2)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/client"
+)
+
+// Operation names used in mixes and reports
+const (
+	opRead   = "read"
+	opUpdate = "update"
+	opBatch  = "batch"
+)
+
+// Outcome labels for recorded calls
+const (
+	outcomeOK           = "ok"
+	outcomeConflict     = "version_conflict"
+	outcomeInsufficient = "insufficient_inventory"
+	outcomeNotFound     = "product_not_found"
+	outcomeShed         = "load_shed"
+	outcomeRateLimited  = "rate_limited"
+	outcomeSaturated    = "queue_saturated"
+	outcomePartial      = "partial"
+	outcomeError        = "error"
+)
+
+// Recorder collects latencies and outcomes per operation. It is safe for
+// concurrent use by the load workers.
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	outcomes  map[string]map[string]int
+	started   time.Time
+	finished  time.Time
+}
+
+// NewRecorder creates an empty recorder and starts its clock
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies: make(map[string][]time.Duration),
+		outcomes:  make(map[string]map[string]int),
+		started:   time.Now(),
+	}
+}
+
+// Record stores one call's latency under its operation and the outcome
+// derived from its error
+func (r *Recorder) Record(op string, latency time.Duration, err error) {
+	r.RecordOutcome(op, latency, classify(err))
+}
+
+// RecordOutcome stores one call's latency and an explicit outcome label
+func (r *Recorder) RecordOutcome(op string, latency time.Duration, outcome string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.latencies[op] = append(r.latencies[op], latency)
+	if r.outcomes[op] == nil {
+		r.outcomes[op] = make(map[string]int)
+	}
+	r.outcomes[op][outcome]++
+}
+
+// Finish stops the recorder's clock, used for throughput
+func (r *Recorder) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = time.Now()
+}
+
+// classify maps a client error to an outcome label
+func classify(err error) string {
+	if err == nil {
+		return outcomeOK
+	}
+	apiErr, ok := client.AsAPIError(err)
+	if !ok {
+		return outcomeError
+	}
+	switch apiErr.ErrorType {
+	case client.ErrorTypeVersionConflict:
+		return outcomeConflict
+	case client.ErrorTypeInsufficientInventory:
+		return outcomeInsufficient
+	case client.ErrorTypeProductNotFound:
+		return outcomeNotFound
+	case client.ErrorTypeLoadShed:
+		return outcomeShed
+	case client.ErrorTypeRateLimited:
+		return outcomeRateLimited
+	case outcomeSaturated:
+		return outcomeSaturated
+	default:
+		return outcomeError
+	}
+}
+
+// OperationReport summarizes one operation type
+type OperationReport struct {
+	Operation    string         `json:"operation"`
+	Count        int            `json:"count"`
+	P50Ms        float64        `json:"p50Ms"`
+	P90Ms        float64        `json:"p90Ms"`
+	P99Ms        float64        `json:"p99Ms"`
+	MaxMs        float64        `json:"maxMs"`
+	Outcomes     map[string]int `json:"outcomes"`
+	ConflictRate float64        `json:"conflictRate"`
+	ErrorRate    float64        `json:"errorRate"`
+}
+
+// Report is the result of a load-test run
+type Report struct {
+	Scenario          string            `json:"scenario"`
+	DurationSeconds   float64           `json:"durationSeconds"`
+	TotalRequests     int               `json:"totalRequests"`
+	RequestsPerSecond float64           `json:"requestsPerSecond"`
+	Operations        []OperationReport `json:"operations"`
+}
+
+// Report computes percentiles and rates from everything recorded so far
+func (r *Recorder) Report(scenario string) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	finished := r.finished
+	if finished.IsZero() {
+		finished = time.Now()
+	}
+	duration := finished.Sub(r.started)
+
+	report := Report{
+		Scenario:        scenario,
+		DurationSeconds: duration.Seconds(),
+	}
+
+	ops := make([]string, 0, len(r.latencies))
+	for op := range r.latencies {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		latencies := append([]time.Duration(nil), r.latencies[op]...)
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+		outcomes := make(map[string]int, len(r.outcomes[op]))
+		for outcome, count := range r.outcomes[op] {
+			outcomes[outcome] = count
+		}
+
+		count := len(latencies)
+		opReport := OperationReport{
+			Operation: op,
+			Count:     count,
+			P50Ms:     milliseconds(percentile(latencies, 50)),
+			P90Ms:     milliseconds(percentile(latencies, 90)),
+			P99Ms:     milliseconds(percentile(latencies, 99)),
+			MaxMs:     milliseconds(percentile(latencies, 100)),
+			Outcomes:  outcomes,
+		}
+		if count > 0 {
+			opReport.ConflictRate = float64(outcomes[outcomeConflict]) / float64(count)
+			opReport.ErrorRate = float64(outcomes[outcomeError]) / float64(count)
+		}
+
+		report.TotalRequests += count
+		report.Operations = append(report.Operations, opReport)
+	}
+
+	if duration > 0 {
+		report.RequestsPerSecond = float64(report.TotalRequests) / duration.Seconds()
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// MaxP99 returns the highest p99 latency across operations
+func (report Report) MaxP99() time.Duration {
+	var max float64
+	for _, op := range report.Operations {
+		if op.P99Ms > max {
+			max = op.P99Ms
+		}
+	}
+	return time.Duration(max * float64(time.Millisecond))
+}
+
+// ErrorRate returns the share of all requests that failed with an unexpected error
+func (report Report) ErrorRate() float64 {
+	if report.TotalRequests == 0 {
+		return 0
+	}
+	errorsTotal := 0
+	for _, op := range report.Operations {
+		errorsTotal += op.Outcomes[outcomeError]
+	}
+	return float64(errorsTotal) / float64(report.TotalRequests)
+}
+
+// WriteText prints the report as a table
+func (report Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Scenario: %s\n", report.Scenario)
+	fmt.Fprintf(w, "Duration: %.2fs  Requests: %d  Throughput: %.1f req/s\n\n",
+		report.DurationSeconds, report.TotalRequests, report.RequestsPerSecond)
+
+	fmt.Fprintf(w, "%-8s %8s %9s %9s %9s %9s %9s %9s\n",
+		"op", "count", "p50(ms)", "p90(ms)", "p99(ms)", "max(ms)", "conflict", "errors")
+	for _, op := range report.Operations {
+		fmt.Fprintf(w, "%-8s %8d %9.2f %9.2f %9.2f %9.2f %8.1f%% %8.1f%%\n",
+			op.Operation, op.Count, op.P50Ms, op.P90Ms, op.P99Ms, op.MaxMs,
+			op.ConflictRate*100, op.ErrorRate*100)
+	}
+
+	fmt.Fprintln(w)
+	for _, op := range report.Operations {
+		outcomes := make([]string, 0, len(op.Outcomes))
+		for outcome := range op.Outcomes {
+			outcomes = append(outcomes, outcome)
+		}
+		sort.Strings(outcomes)
+		fmt.Fprintf(w, "%s outcomes:", op.Operation)
+		for _, outcome := range outcomes {
+			fmt.Fprintf(w, " %s=%d", outcome, op.Outcomes[outcome])
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+)
+
+// Mix is the relative weight of each operation in a mixed workload
+type Mix struct {
+	Read   int
+	Update int
+	Batch  int
+}
+
+// ParseMix parses "read:70,update:25,batch:5"; omitted operations get weight 0
+func ParseMix(value string) (Mix, error) {
+	var mix Mix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		op, weightStr, found := strings.Cut(entry, ":")
+		if !found {
+			return Mix{}, fmt.Errorf("invalid mix entry %q, expected op:weight", entry)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil || weight < 0 {
+			return Mix{}, fmt.Errorf("invalid weight %q for %s", weightStr, op)
+		}
+		switch strings.TrimSpace(op) {
+		case opRead:
+			mix.Read = weight
+		case opUpdate:
+			mix.Update = weight
+		case opBatch:
+			mix.Batch = weight
+		default:
+			return Mix{}, fmt.Errorf("unknown operation %q, expected read, update or batch", op)
+		}
+	}
+	if mix.Read+mix.Update+mix.Batch == 0 {
+		return Mix{}, fmt.Errorf("mix %q has no operations", value)
+	}
+	return mix, nil
+}
+
+// pick chooses an operation according to the weights
+func (m Mix) pick(rng *rand.Rand) string {
+	n := rng.Intn(m.Read + m.Update + m.Batch)
+	switch {
+	case n < m.Read:
+		return opRead
+	case n < m.Read+m.Update:
+		return opUpdate
+	default:
+		return opBatch
+	}
+}
+
+// versionTracker remembers the last version seen per product so updates are
+// sent with realistic, mostly current versions; stale ones show up as conflicts
+type versionTracker struct {
+	mu       sync.Mutex
+	versions map[string]int
+}
+
+func newVersionTracker(products []models.Product) *versionTracker {
+	versions := make(map[string]int, len(products))
+	for _, product := range products {
+		versions[product.ProductID] = product.Version
+	}
+	return &versionTracker{versions: versions}
+}
+
+func (t *versionTracker) get(productID string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if version, ok := t.versions[productID]; ok {
+		return version
+	}
+	return 1
+}
+
+// observe records a version unless a newer one is already known
+func (t *versionTracker) observe(productID string, version int) {
+	if version <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if version > t.versions[productID] {
+		t.versions[productID] = version
+	}
+}
+
+// MixedWorkload drives reads, single updates and batches against a set of products
+type MixedWorkload struct {
+	Client      *client.InventoryClient
+	StoreID     string
+	ProductIDs  []string
+	Mix         Mix
+	Delta       int
+	BatchSize   int
+	Concurrency int
+	Requests    int           // Total calls; 0 runs until Duration elapses
+	Duration    time.Duration // Ignored when Requests is set
+	RunID       string        // Keeps idempotency keys unique across runs
+
+	versions *versionTracker
+	sequence atomic.Int64
+}
+
+// Run executes the workload and records every call
+func (w *MixedWorkload) Run(ctx context.Context, recorder *Recorder, products []models.Product) {
+	w.versions = newVersionTracker(products)
+
+	if w.Requests == 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Duration)
+		defer cancel()
+	}
+
+	var remaining atomic.Int64
+	remaining.Store(int64(w.Requests))
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(time.Now().UnixNano() + int64(worker)))
+			for ctx.Err() == nil {
+				if w.Requests > 0 && remaining.Add(-1) < 0 {
+					return
+				}
+				w.runOne(ctx, rng, recorder)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// runOne performs one randomly chosen operation
+func (w *MixedWorkload) runOne(ctx context.Context, rng *rand.Rand, recorder *Recorder) {
+	productID := w.ProductIDs[rng.Intn(len(w.ProductIDs))]
+
+	switch w.Mix.pick(rng) {
+	case opRead:
+		start := time.Now()
+		product, err := w.Client.GetProductCtx(ctx, productID)
+		if ctx.Err() != nil {
+			return
+		}
+		recorder.Record(opRead, time.Since(start), err)
+		if err == nil {
+			w.versions.observe(productID, product.Version)
+		}
+
+	case opUpdate:
+		update := w.update(productID)
+		start := time.Now()
+		resp, err := w.Client.UpdateInventoryCtx(ctx, update)
+		if ctx.Err() != nil {
+			return
+		}
+		recorder.Record(opUpdate, time.Since(start), err)
+		w.observeUpdate(productID, resp, err)
+
+	case opBatch:
+		updates := make([]models.UpdateRequest, 0, w.BatchSize)
+		seen := make(map[string]bool, w.BatchSize)
+		for len(updates) < w.BatchSize && len(seen) < len(w.ProductIDs) {
+			id := w.ProductIDs[rng.Intn(len(w.ProductIDs))]
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			updates = append(updates, w.update(id))
+		}
+
+		start := time.Now()
+		resp, err := w.Client.BatchUpdateInventoryCtx(ctx, models.BatchUpdateRequest{
+			StoreID: w.StoreID,
+			Updates: updates,
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			recorder.Record(opBatch, time.Since(start), err)
+			return
+		}
+
+		outcome := outcomeOK
+		for _, result := range resp.Results {
+			if !result.Applied {
+				outcome = outcomePartial
+				continue
+			}
+			w.versions.observe(result.ProductID, result.NewVersion)
+		}
+		recorder.RecordOutcome(opBatch, time.Since(start), outcome)
+	}
+}
+
+// update builds a single update with the last version seen for the product
+func (w *MixedWorkload) update(productID string) models.UpdateRequest {
+	return models.UpdateRequest{
+		StoreID:        w.StoreID,
+		ProductID:      productID,
+		Delta:          w.Delta,
+		Version:        w.versions.get(productID),
+		IdempotencyKey: idempotency.DeriveKey("loadtest", w.RunID, strconv.FormatInt(w.sequence.Add(1), 10)),
+	}
+}
+
+// observeUpdate learns the product's current version from an update's answer,
+// including the version reported by a conflict
+func (w *MixedWorkload) observeUpdate(productID string, resp *models.UpdateResponse, err error) {
+	if err == nil {
+		w.versions.observe(productID, resp.NewVersion)
+		return
+	}
+	if apiErr, ok := client.AsAPIError(err); ok {
+		w.versions.observe(productID, apiErr.NewVersion)
+	}
+}
@@ -0,0 +1,83 @@
+// Package idempotency generates and checks the idempotency keys sent with
+// inventory updates. The central API accepts a UUIDv4 in canonical form or a
+// ULID and deduplicates keys per store, so keys no longer need a store prefix.
+package idempotency
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// ReplayedHeader is set to "true" on responses answered from an idempotency
+// cache instead of being applied again
+const ReplayedHeader = "Idempotency-Replayed"
+
+// NewKey generates a random UUIDv4 key
+func NewKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms; a derived key still
+		// has the right format
+		return DeriveKey("fallback", err.Error())
+	}
+	return formatUUIDv4(b)
+}
+
+// DeriveKey returns a UUIDv4-formatted key determined by parts, for updates
+// that must dedupe across restarts, such as a return identified by its
+// return ID or the nth retry of another update
+func DeriveKey(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return formatUUIDv4(sum[:16])
+}
+
+// Valid reports whether key is a UUIDv4 or a ULID, the formats the central
+// API accepts
+func Valid(key string) bool {
+	return isUUIDv4(key) || isULID(key)
+}
+
+func formatUUIDv4(b []byte) string {
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+func isUUIDv4(key string) bool {
+	if len(key) != 36 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if key[i] != '-' {
+				return false
+			}
+		default:
+			if strings.IndexByte("0123456789abcdefABCDEF", key[i]) < 0 {
+				return false
+			}
+		}
+	}
+	return key[14] == '4' && strings.IndexByte("89abAB", key[19]) >= 0
+}
+
+// isULID checks 26 Crockford base32 characters, the first no higher than 7
+func isULID(key string) bool {
+	if len(key) != 26 || key[0] < '0' || key[0] > '7' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		// Crockford base32 leaves out I, L, O and U
+		if strings.IndexByte("0123456789ABCDEFGHJKMNPQRSTVWXYZ", c) < 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// CompressionMiddleware gzips responses for clients that accept it. The body
+// is buffered until minBytes are written, so small responses such as single
+// products and errors go out unchanged, while large listings are compressed.
+func CompressionMiddleware(minBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, minBytes: minBytes}
+			defer cw.finish()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter holds back the status and the first minBytes of the body,
+// then decides once whether the response is worth compressing
+type compressWriter struct {
+	http.ResponseWriter
+	minBytes int
+	status   int
+	buf      []byte
+	started  bool
+	gz       *gzip.Writer
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.started || cw.status != 0 {
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.started {
+		if len(cw.buf)+len(p) < cw.minBytes {
+			cw.buf = append(cw.buf, p...)
+			return len(p), nil
+		}
+		cw.buf = append(cw.buf, p...)
+		if err := cw.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	if cw.gz != nil {
+		return cw.gz.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what is buffered; a response flushed before reaching the
+// threshold stays uncompressed
+func (cw *compressWriter) Flush() {
+	if !cw.started {
+		cw.start(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Flush()
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start writes the header, compressed if allowed and worthwhile, followed by
+// the buffered body
+func (cw *compressWriter) start(compress bool) error {
+	cw.started = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	header := cw.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		// Sniff before compressing, net/http would otherwise sniff gzip bytes
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	if compress && cw.compressible(header) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		cw.gz = gzipWriterPool.Get().(*gzip.Writer)
+		cw.gz.Reset(cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buffered := cw.buf
+	cw.buf = nil
+	if len(buffered) == 0 {
+		return nil
+	}
+	if cw.gz != nil {
+		_, err := cw.gz.Write(buffered)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buffered)
+	return err
+}
+
+// compressible reports whether the response may carry a gzip body
+func (cw *compressWriter) compressible(header http.Header) bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(header.Get("Content-Type"), ";")[0]))
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") ||
+		strings.HasSuffix(mediaType, "javascript")
+}
+
+// finish flushes a response that never reached the threshold and closes the gzip stream
+func (cw *compressWriter) finish() {
+	if !cw.started {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return // Nothing written, let net/http send its default response
+		}
+		cw.start(false)
+	}
+	if cw.gz != nil {
+		cw.gz.Close()
+		gzipWriterPool.Put(cw.gz)
+		cw.gz = nil
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, honoring
+// q=0 and the * wildcard
+func acceptsGzip(acceptEncoding string) bool {
+	gzipQ, wildcardQ := -1.0, -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch coding {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "*":
+			wildcardQ = q
+		}
+	}
+
+	if gzipQ >= 0 {
+		return gzipQ > 0
+	}
+	return wildcardQ > 0
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/melibackend/shared/requestid"
+)
+
+// RequestIDMiddleware reuses the caller's X-Request-ID (or generates one),
+// echoes it in the response and stores it in the request context so calls to
+// the central API made while serving the request forward the same ID. The
+// request header is rewritten too, so chi's middleware.RequestID reuses it.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+			r.Header.Set(requestid.Header, id)
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithRequestID(r.Context(), id)))
+	})
+}
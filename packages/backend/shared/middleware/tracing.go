@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span for each request, continuing the
+// caller's trace when a traceparent header is present. routePattern, when set,
+// is called after the handler to name the span by route template instead of
+// the raw path (e.g. chi's RoutePattern).
+func TracingMiddleware(routePattern func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(recorder, r)
+
+			if routePattern != nil {
+				if route := routePattern(r); route != "" {
+					span.SetName(r.Method + " " + route)
+					span.SetAttributes(attribute.String("http.route", route))
+				}
+			}
+
+			span.SetAttributes(attribute.Int("http.response.status_code", recorder.statusCode))
+			if recorder.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.statusCode))
+			}
+		})
+	}
+}
+
+// statusRecorder captures the response status code for the span
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (w *statusRecorder) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
@@ -10,6 +10,61 @@ type Product struct {
 	Version     int       `json:"version"`
 	LastUpdated time.Time `json:"lastUpdated"`
 	Price       float64   `json:"price"`
+	Prices      []Money   `json:"prices,omitempty"`   // Per-currency price list
+	Category    string    `json:"category,omitempty"` // Category the product is listed under
+	// Barcode and BarcodeAliases are the codes POS scanners read for the product
+	Barcode        string   `json:"barcode,omitempty"`
+	BarcodeAliases []string `json:"barcodeAliases,omitempty"`
+	// Images and other files store displays show with the product
+	Assets []ProductAsset `json:"assets,omitempty"`
+	// The product's own stock policy; without one its category's applies
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"`
+}
+
+// StockPolicy is the part of a central stock policy stores act on: with
+// AllowBackorder, sales may take available below zero, down to -MaxBackorder
+type StockPolicy struct {
+	AllowBackorder bool `json:"allowBackorder,omitempty"`
+	MaxBackorder   int  `json:"maxBackorder,omitempty"`
+}
+
+// StockFloor returns the lowest available stock a sale may leave
+func (p StockPolicy) StockFloor() int {
+	if !p.AllowBackorder {
+		return 0
+	}
+	return -p.MaxBackorder
+}
+
+// ProductAsset is a typed URL to an image or other file for the product.
+// Checksum ("sha256:<hex>") lets displays verify a downloaded or cached copy.
+type ProductAsset struct {
+	Type     string `json:"type"` // image, thumbnail, video or document
+	URL      string `json:"url"`
+	Checksum string `json:"checksum"`
+}
+
+// Category is a node of the central category tree; ParentID is empty for a
+// top-level category
+type Category struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	ParentID  string `json:"parentId,omitempty"`
+	UpdatedAt string `json:"updatedAt"`
+	// Applies to the category's products without a policy of their own,
+	// and to its subcategories without one
+	StockPolicy *StockPolicy `json:"stockPolicy,omitempty"`
+}
+
+// CategoryListResponse lists the category tree flat, sorted by ID
+type CategoryListResponse struct {
+	Categories []Category `json:"categories"`
+}
+
+// Money represents a currency-aware amount stored as integer minor units
+type Money struct {
+	Amount   int64  `json:"amount"`   // Minor units (e.g. cents)
+	Currency string `json:"currency"` // ISO 4217 currency code
 }
 
 // UpdateRequest represents a single inventory update request
@@ -19,6 +74,13 @@ type UpdateRequest struct {
 	Delta          int    `json:"delta" validate:"required"`
 	Version        int    `json:"version" validate:"required,min=1"`
 	IdempotencyKey string `json:"idempotencyKey" validate:"required"`
+	Reason         string `json:"reason,omitempty"` // Recorded on the central event, e.g. "return"
+
+	// Optional compare-and-set on the product's stock, checked by the central
+	// API under the product lock; with either set, Version may be 0 to skip the
+	// version check
+	MinAvailable      *int `json:"minAvailable,omitempty"`
+	ExpectedAvailable *int `json:"expectedAvailable,omitempty"`
 }
 
 // BatchUpdateRequest represents a batch of inventory updates
@@ -35,6 +97,7 @@ type UpdateResponse struct {
 	Delta          int    `json:"delta"`
 	IdempotencyKey string `json:"idempotencyKey"`
 	Applied        bool   `json:"applied"`
+	Replayed       bool   `json:"replayed,omitempty"` // Answered from the central idempotency cache
 }
 
 // BatchUpdateResponse represents the response for a batch update
@@ -55,10 +118,11 @@ type ErrorResponse struct {
 
 // HealthResponse represents a health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Service   string    `json:"service,omitempty"`
-	Version   string    `json:"version,omitempty"`
-	Timestamp time.Time `json:"timestamp,omitempty"`
+	Status    string            `json:"status"`
+	Service   string            `json:"service,omitempty"`
+	Version   string            `json:"version,omitempty"`
+	Timestamp time.Time         `json:"timestamp,omitempty"`
+	Checks    map[string]string `json:"checks,omitempty"`
 }
 
 // ReplicationResponse represents inventory data for replication
@@ -91,16 +155,55 @@ type Event struct {
 	ProductID string          `json:"productId"`
 	Data      ProductResponse `json:"data"`
 	Version   int             `json:"version"`
+	StoreID   string          `json:"storeId,omitempty"`   // Store that sent the update, for store updates
+	Delta     int             `json:"delta,omitempty"`     // Applied stock change, for store updates
+	ToStoreID string          `json:"toStoreId,omitempty"` // Destination store, for stock transfers
+	Quantity  int             `json:"quantity,omitempty"`  // Units moved, for stock transfers
+	Reason    string          `json:"reason,omitempty"`    // Why the store changed stock, e.g. "return"
+	OrderID   string          `json:"orderId,omitempty"`   // Order that reserved, sold or released the units
+	Category  *Category       `json:"category,omitempty"`  // The category, for category events
 }
 
 // ProductResponse represents product data in events
 type ProductResponse struct {
-	ProductID   string  `json:"productId"`
-	Name        string  `json:"name"`
-	Available   int     `json:"available"`
-	Version     int     `json:"version"`
-	LastUpdated string  `json:"lastUpdated"`
-	Price       float64 `json:"price"`
+	ProductID      string         `json:"productId"`
+	Name           string         `json:"name"`
+	Available      int            `json:"available"`
+	Version        int            `json:"version"`
+	LastUpdated    string         `json:"lastUpdated"`
+	Price          float64        `json:"price"`
+	Prices         []Money        `json:"prices,omitempty"`
+	Category       string         `json:"category,omitempty"`
+	Barcode        string         `json:"barcode,omitempty"`
+	BarcodeAliases []string       `json:"barcodeAliases,omitempty"`
+	Assets         []ProductAsset `json:"assets,omitempty"`
+	StockPolicy    *StockPolicy   `json:"stockPolicy,omitempty"`
+}
+
+// BatchGetRequest asks for several products in one round trip
+type BatchGetRequest struct {
+	ProductIDs []string `json:"productIds"`
+}
+
+// BatchGetResponse lists the found products in request order and the
+// requested IDs that do not exist. Unavailable is only set by the store API:
+// IDs missing from its cache that could not be looked up centrally.
+type BatchGetResponse struct {
+	Products    []Product `json:"products"`
+	Missing     []string  `json:"missing"`
+	Unavailable []string  `json:"unavailable,omitempty"`
+}
+
+// ProductVersion is the current version and stock of a product
+type ProductVersion struct {
+	Version   int `json:"version"`
+	Available int `json:"available"`
+}
+
+// VersionsResponse maps found product IDs to their current version and stock
+type VersionsResponse struct {
+	Versions map[string]ProductVersion `json:"versions"`
+	Missing  []string                  `json:"missing"`
 }
 
 // EventsResponse represents the response for the events endpoint
@@ -109,6 +212,16 @@ type EventsResponse struct {
 	NextOffset int64   `json:"nextOffset"`
 	HasMore    bool    `json:"hasMore"`
 	Count      int     `json:"count"`
+	Filtered   bool    `json:"filtered,omitempty"` // Offsets have gaps where events were filtered out
+
+	// CurrentOffset is the next offset the central API will assign, so
+	// CurrentOffset - NextOffset is how many events the reader trails by
+	CurrentOffset int64 `json:"currentOffset,omitempty"`
+}
+
+// EventCommitRequest commits that the store applied every event before Offset
+type EventCommitRequest struct {
+	Offset int64 `json:"offset"`
 }
 
 // EventType constants
@@ -116,4 +229,55 @@ const (
 	EventTypeProductUpdated = "product_updated"
 	EventTypeProductCreated = "product_created"
 	EventTypeProductDeleted = "product_deleted"
+	EventTypeSystemRestored = "system_restored" // Central replaced its whole inventory; resync fully
+	// EventTypeStockTransferred moves units between two stores' allocations;
+	// available stock is unchanged but the product's version advances
+	EventTypeStockTransferred = "stock_transferred"
+	// Order events change the product like an update: a reservation takes
+	// units out of available stock, a cancellation returns them and a commit
+	// only advances the version
+	EventTypeOrderReserved  = "order_reserved"
+	EventTypeOrderCommitted = "order_committed"
+	EventTypeOrderCancelled = "order_cancelled"
+	// Category events carry no product: Event.Category is the saved or
+	// deleted category
+	EventTypeCategoryUpdated = "category_updated"
+	EventTypeCategoryDeleted = "category_deleted"
+	// EventTypeBackorderFulfilled reports restocked units filling a backorder;
+	// it carries the product at the version of the restock published before it
+	EventTypeBackorderFulfilled = "backorder_fulfilled"
 )
+
+// TransferRequest moves Quantity units of a product from one store's
+// allocation to another's through POST /v1/inventory/transfers
+type TransferRequest struct {
+	ProductID      string `json:"productId"`
+	FromStoreID    string `json:"fromStoreId"`
+	ToStoreID      string `json:"toStoreId"`
+	Quantity       int    `json:"quantity"`
+	Version        int    `json:"version,omitempty"` // Optional OCC check; 0 skips it
+	IdempotencyKey string `json:"idempotencyKey"`
+}
+
+// StockTransfer is a completed transfer
+type StockTransfer struct {
+	TransferID     string `json:"transferId"`
+	ProductID      string `json:"productId"`
+	FromStoreID    string `json:"fromStoreId"`
+	ToStoreID      string `json:"toStoreId"`
+	Quantity       int    `json:"quantity"`
+	NewVersion     int    `json:"newVersion"`
+	Available      int    `json:"available"`
+	FromAllocation int    `json:"fromAllocation"` // Units left allocated to the source store
+	ToAllocation   int    `json:"toAllocation"`   // Units now allocated to the destination store
+	IdempotencyKey string `json:"idempotencyKey"`
+	EventOffset    int64  `json:"eventOffset"`
+	CreatedAt      string `json:"createdAt"`
+	Replayed       bool   `json:"replayed,omitempty"` // Answered from the central idempotency cache
+}
+
+// TransfersResponse lists transfer history, newest first
+type TransfersResponse struct {
+	Transfers []StockTransfer `json:"transfers"`
+	Count     int             `json:"count"`
+}
@@ -0,0 +1,51 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header carries the request ID between clients, stores and the central API
+const Header = "X-Request-ID"
+
+// maxLength bounds caller-supplied IDs so they cannot bloat logs
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a random 16-byte hex request ID
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether a caller-supplied ID can be reused: non-empty, at most
+// 128 characters and printable ASCII without spaces
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// WithRequestID returns a copy of ctx carrying id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID in ctx, or "" when there is none
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(contextKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
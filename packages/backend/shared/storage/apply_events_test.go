@@ -0,0 +1,289 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/melibackend/shared/models"
+)
+
+// deleteEvent returns a product_deleted event, carrying the deleted
+// product's version plus one
+func deleteEvent(offset int64, productID string, version int) models.Event {
+	return models.Event{
+		Offset:    offset,
+		EventType: models.EventTypeProductDeleted,
+		ProductID: productID,
+		Version:   version,
+		Data:      models.ProductResponse{ProductID: productID, Name: productID},
+	}
+}
+
+// stock is the expected stock and version of a product
+type stock struct {
+	available, version int
+}
+
+// testApplyRules runs the ApplyEvents skip rules of the LocalStorage contract
+// against fresh storages made by newStorage
+func testApplyRules(t *testing.T, newStorage func(t *testing.T) LocalStorage) {
+	tests := []struct {
+		name       string
+		applied    []models.Event // Applied before the batch, one call each
+		batch      []models.Event
+		wantOffset int64
+		want       map[string]*stock // nil means not cached
+	}{
+		{
+			name:       "newer version is applied",
+			batch:      []models.Event{updateEvent(0, "SKU-001", 2, 10)},
+			wantOffset: 1,
+			want:       map[string]*stock{"SKU-001": {10, 2}},
+		},
+		{
+			name:       "event below the recorded offset is skipped",
+			applied:    []models.Event{updateEvent(4, "SKU-001", 2, 10)},
+			batch:      []models.Event{updateEvent(3, "SKU-001", 9, 1)},
+			wantOffset: 5,
+			want:       map[string]*stock{"SKU-001": {10, 2}},
+		},
+		{
+			name:       "older version is skipped but its offset consumed",
+			applied:    []models.Event{updateEvent(0, "SKU-001", 5, 10)},
+			batch:      []models.Event{updateEvent(1, "SKU-001", 4, 3)},
+			wantOffset: 2,
+			want:       map[string]*stock{"SKU-001": {10, 5}},
+		},
+		{
+			name:       "same version is skipped",
+			applied:    []models.Event{updateEvent(0, "SKU-001", 5, 10)},
+			batch:      []models.Event{updateEvent(1, "SKU-001", 5, 3)},
+			wantOffset: 2,
+			want:       map[string]*stock{"SKU-001": {10, 5}},
+		},
+		{
+			name:       "event delivered twice in a batch is applied once",
+			batch:      []models.Event{updateEvent(0, "SKU-001", 2, 10), updateEvent(0, "SKU-001", 2, 10)},
+			wantOffset: 1,
+			want:       map[string]*stock{"SKU-001": {10, 2}},
+		},
+		{
+			name:       "deletion removes the product",
+			applied:    []models.Event{updateEvent(0, "SKU-001", 2, 10)},
+			batch:      []models.Event{deleteEvent(1, "SKU-001", 3)},
+			wantOffset: 2,
+			want:       map[string]*stock{"SKU-001": nil},
+		},
+		{
+			name:       "replayed batch does not bring a deleted product back",
+			applied:    []models.Event{updateEvent(0, "SKU-001", 2, 10), deleteEvent(1, "SKU-001", 3)},
+			batch:      []models.Event{updateEvent(0, "SKU-001", 2, 10), deleteEvent(1, "SKU-001", 3)},
+			wantOffset: 2,
+			want:       map[string]*stock{"SKU-001": nil},
+		},
+		{
+			name:       "deletion older than the cached product is skipped",
+			applied:    []models.Event{updateEvent(0, "SKU-001", 5, 10)},
+			batch:      []models.Event{deleteEvent(1, "SKU-001", 4)},
+			wantOffset: 2,
+			want:       map[string]*stock{"SKU-001": {10, 5}},
+		},
+		{
+			name:       "deletion of an uncached product is a no-op",
+			batch:      []models.Event{deleteEvent(0, "SKU-002", 2)},
+			wantOffset: 1,
+			want:       map[string]*stock{"SKU-002": nil},
+		},
+		{
+			name:       "unknown event type consumes its offset",
+			batch:      []models.Event{{Offset: 0, EventType: "product_renamed", ProductID: "SKU-001", Version: 2}},
+			wantOffset: 1,
+			want:       map[string]*stock{"SKU-001": nil},
+		},
+		{
+			name: "products are applied in stream order",
+			batch: []models.Event{
+				updateEvent(0, "SKU-001", 2, 10),
+				updateEvent(1, "SKU-002", 2, 4),
+				updateEvent(2, "SKU-001", 3, 9),
+				deleteEvent(3, "SKU-002", 3),
+				updateEvent(4, "SKU-001", 4, 8),
+			},
+			wantOffset: 5,
+			want:       map[string]*stock{"SKU-001": {8, 4}, "SKU-002": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newStorage(t)
+			for _, event := range tt.applied {
+				if err := s.ApplyEvents([]models.Event{event}); err != nil {
+					t.Fatalf("ApplyEvents failed: %v", err)
+				}
+			}
+			if err := s.ApplyEvents(tt.batch); err != nil {
+				t.Fatalf("ApplyEvents failed: %v", err)
+			}
+
+			if offset, err := s.GetLastEventOffset(); err != nil || offset != tt.wantOffset {
+				t.Errorf("expected offset %d, got %d (%v)", tt.wantOffset, offset, err)
+			}
+			for productID, want := range tt.want {
+				if want != nil {
+					expectProduct(t, s, productID, want.available, want.version)
+					continue
+				}
+				if product, err := s.GetProduct(productID); !errors.Is(err, ErrNotFound) {
+					t.Errorf("expected %s not to be cached, got %+v (%v)", productID, product, err)
+				}
+			}
+		})
+	}
+
+	t.Run("category events", func(t *testing.T) {
+		s := newStorage(t)
+		phones := models.Category{ID: "phones", Name: "Phones", UpdatedAt: "2026-01-01T00:00:00Z"}
+		tablets := models.Category{ID: "tablets", Name: "Tablets", UpdatedAt: "2026-01-01T00:00:00Z"}
+		err := s.ApplyEvents([]models.Event{
+			{Offset: 0, EventType: models.EventTypeCategoryUpdated, Category: &phones},
+			{Offset: 1, EventType: models.EventTypeCategoryUpdated, Category: &tablets},
+			{Offset: 2, EventType: models.EventTypeCategoryDeleted, Category: &models.Category{ID: "phones"}},
+		})
+		if err != nil {
+			t.Fatalf("ApplyEvents failed: %v", err)
+		}
+
+		categories, err := s.GetCategories()
+		if err != nil {
+			t.Fatalf("GetCategories failed: %v", err)
+		}
+		if len(categories) != 1 || categories[0] != tablets {
+			t.Errorf("expected only %+v, got %+v", tablets, categories)
+		}
+		if offset, _ := s.GetLastEventOffset(); offset != 3 {
+			t.Errorf("expected offset 3, got %d", offset)
+		}
+	})
+}
+
+func TestMemoryStorage_ApplyRules(t *testing.T) {
+	testApplyRules(t, func(t *testing.T) LocalStorage { return newTestMemoryStorage(t) })
+}
+
+func TestMemoryStorage_PersistsOffsetWithProducts(t *testing.T) {
+	dir := t.TempDir()
+	ms := NewMemoryStorage(dir)
+	if err := ms.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := ms.ApplyEvents([]models.Event{updateEvent(7, "SKU-001", 2, 10), deleteEvent(8, "SKU-002", 2)}); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+
+	reopened := NewMemoryStorage(dir)
+	if err := reopened.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if offset, _ := reopened.GetLastEventOffset(); offset != 9 {
+		t.Errorf("expected offset 9 after reopening, got %d", offset)
+	}
+	expectProduct(t, reopened, "SKU-001", 10, 2)
+	if window := reopened.recentEvents.list(); len(window) != 2 || window[0] != "7:SKU-001" || window[1] != "8:SKU-002" {
+		t.Errorf("expected the dedupe window to be reloaded, got %v", window)
+	}
+}
+
+// TestMemoryStorage_ParallelApplyMatchesSerial tests that a batch large enough
+// to be applied in parallel partitions ends in the same state as the same
+// events applied in small serial batches
+func TestMemoryStorage_ParallelApplyMatchesSerial(t *testing.T) {
+	const productCount = 25
+	var events []models.Event
+	versions := make([]int, productCount)
+	for offset := int64(0); offset < 3*parallelApplyThreshold; offset++ {
+		i := int(offset*7) % productCount
+		productID := fmt.Sprintf("SKU-%03d", i)
+		switch {
+		case offset%97 == 0:
+			// A stale event, older than the product's last one
+			events = append(events, updateEvent(offset, productID, versions[i], 0))
+		case offset%89 == 0:
+			versions[i]++
+			events = append(events, deleteEvent(offset, productID, versions[i]))
+		default:
+			versions[i]++
+			events = append(events, updateEvent(offset, productID, versions[i], int(offset)))
+		}
+	}
+
+	parallel := newTestMemoryStorage(t)
+	if err := parallel.ApplyEvents(events); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+	serial := newTestMemoryStorage(t)
+	for start := 0; start < len(events); start += 100 {
+		if err := serial.ApplyEvents(events[start:min(start+100, len(events))]); err != nil {
+			t.Fatalf("ApplyEvents failed: %v", err)
+		}
+	}
+
+	got, want := stockByProduct(t, parallel), stockByProduct(t, serial)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d products, got %d", len(want), len(got))
+	}
+	for productID, stock := range want {
+		if got[productID] != stock {
+			t.Errorf("%s: expected %+v, got %+v", productID, stock, got[productID])
+		}
+	}
+	if offset, _ := parallel.GetLastEventOffset(); offset != int64(len(events)) {
+		t.Errorf("expected offset %d, got %d", len(events), offset)
+	}
+}
+
+// stockByProduct returns the stock and version of every cached product
+func stockByProduct(t *testing.T, s LocalStorage) map[string]stock {
+	t.Helper()
+
+	products, err := s.GetAllProducts()
+	if err != nil {
+		t.Fatalf("GetAllProducts failed: %v", err)
+	}
+	stocks := make(map[string]stock, len(products))
+	for _, product := range products {
+		stocks[product.ProductID] = stock{product.Available, product.Version}
+	}
+	return stocks
+}
+
+func TestEventWindow_ForgetsOldest(t *testing.T) {
+	w := newEventWindow(nil)
+	for i := 0; i <= dedupeWindowSize; i++ {
+		w.add(strconv.Itoa(i) + ":SKU-001")
+	}
+	w.add("5:SKU-001") // Already remembered; must not move
+
+	if w.contains("0:SKU-001") {
+		t.Error("expected the oldest key to be forgotten")
+	}
+	if !w.contains("1:SKU-001") || !w.contains(strconv.Itoa(dedupeWindowSize)+":SKU-001") {
+		t.Error("expected the newest keys to be remembered")
+	}
+	keys := w.list()
+	if len(keys) != dedupeWindowSize || !sort.SliceIsSorted(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i][:len(keys[i])-len(":SKU-001")])
+		b, _ := strconv.Atoi(keys[j][:len(keys[j])-len(":SKU-001")])
+		return a < b
+	}) {
+		t.Errorf("expected %d keys oldest first, got %d", dedupeWindowSize, len(keys))
+	}
+
+	reloaded := newEventWindow(keys)
+	if !reloaded.contains("1:SKU-001") || len(reloaded.list()) != dedupeWindowSize {
+		t.Error("expected a window rebuilt from its keys to remember them")
+	}
+}
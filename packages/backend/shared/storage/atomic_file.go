@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// backupSuffix names the previous generation of a file written with a backup
+	backupSuffix = ".bak"
+	// corruptSuffix names a file that could not be decoded, kept for inspection
+	corruptSuffix = ".corrupt"
+)
+
+// writeFileAtomic replaces path with data so that a crash leaves either the
+// old or the new content, never a partial file: data goes to a temp file in
+// the same directory, is synced to disk and renamed over path. With backup
+// set, the replaced generation stays readable as path+".bak".
+func writeFileAtomic(path string, data []byte, backup bool) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmpPath)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to set temp file mode: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		cleanup()
+		return fmt.Errorf("failed to sync temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if backup {
+		keepBackup(path)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// keepBackup hard-links the current generation of path to path+".bak" before
+// it is replaced, so path itself never goes missing. Failures only cost the
+// backup and are logged.
+func keepBackup(path string) {
+	if _, err := os.Stat(path); err != nil {
+		// Nothing to back up; keep the existing backup
+		return
+	}
+	backupPath := path + backupSuffix
+	if err := os.Remove(backupPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("Failed to remove old backup", "file", backupPath, "error", err)
+		return
+	}
+	if err := os.Link(path, backupPath); err != nil {
+		slog.Warn("Failed to keep backup of previous generation", "file", backupPath, "error", err)
+	}
+}
+
+// syncDir flushes a directory entry so a completed rename survives a crash.
+// Not every platform supports syncing directories; errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/melibackend/shared/models"
+)
+
+// productCodes returns every code a product is scanned by
+func productCodes(product models.Product) []string {
+	codes := make([]string, 0, len(product.BarcodeAliases)+1)
+	if product.Barcode != "" {
+		codes = append(codes, product.Barcode)
+	}
+	return append(codes, product.BarcodeAliases...)
+}
+
+// hasCode reports whether a product is scanned by code
+func hasCode(product models.Product, code string) bool {
+	for _, productCode := range productCodes(product) {
+		if productCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeBarcode trims what a scanner sent; an empty code matches nothing
+func normalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", fmt.Errorf("%w: empty barcode", ErrNotFound)
+	}
+	return code, nil
+}
+
+// barcodeIndex maps every scanned code to its product ID
+type barcodeIndex map[string]string
+
+// add indexes the codes of product
+func (idx barcodeIndex) add(product models.Product) {
+	for _, code := range productCodes(product) {
+		idx[code] = product.ProductID
+	}
+}
+
+// remove drops the codes of product that still point at it
+func (idx barcodeIndex) remove(product models.Product) {
+	for _, code := range productCodes(product) {
+		if idx[code] == product.ProductID {
+			delete(idx, code)
+		}
+	}
+}
+
+// newBarcodeIndex indexes every product
+func newBarcodeIndex(products map[string]models.Product) barcodeIndex {
+	idx := make(barcodeIndex, len(products))
+	for _, product := range products {
+		idx.add(product)
+	}
+	return idx
+}
@@ -0,0 +1,12 @@
+package storage
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the product is not in the local cache.
+	// Callers check it with errors.Is; the message keeps the product ID.
+	ErrNotFound = errors.New("product not found")
+
+	// ErrCorrupted is returned when cached data exists but cannot be decoded
+	ErrCorrupted = errors.New("local storage data is corrupted")
+)
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// productFromEvent builds a product from the full snapshot carried by an event
+func productFromEvent(event models.Event) models.Product {
+	product := models.Product{
+		ProductID:      event.Data.ProductID,
+		Name:           event.Data.Name,
+		Available:      event.Data.Available,
+		Version:        event.Data.Version,
+		Price:          event.Data.Price,
+		Prices:         event.Data.Prices,
+		Category:       event.Data.Category,
+		Barcode:        event.Data.Barcode,
+		BarcodeAliases: event.Data.BarcodeAliases,
+		Assets:         event.Data.Assets,
+		StockPolicy:    event.Data.StockPolicy,
+	}
+	if product.ProductID == "" {
+		product.ProductID = event.ProductID
+	}
+
+	if lastUpdated, err := time.Parse(time.RFC3339, event.Data.LastUpdated); err == nil {
+		product.LastUpdated = lastUpdated
+	} else {
+		product.LastUpdated = time.Now().UTC()
+	}
+	return product
+}
+
+// eventVersion returns the product version an event carries. Deletions carry
+// the deleted product's version plus one, so the same "newer than local" rule
+// applies to every event type.
+func eventVersion(event models.Event) int {
+	if event.Version > 0 {
+		return event.Version
+	}
+	return event.Data.Version
+}
+
+// dedupeWindowSize is how many recently applied events a storage remembers by
+// offset and product, so an event delivered again is skipped instead of
+// applied twice. Offsets only identify an event within one run of the central
+// event log, which starts again at 0 after a reset, so the window is cleared
+// by a full sync and whenever the offset is moved back; events replayed after
+// a rewind are dropped by the version check instead.
+const dedupeWindowSize = 1024
+
+// eventKey identifies an event in the dedupe window
+func eventKey(event models.Event) string {
+	return strconv.FormatInt(event.Offset, 10) + ":" + event.ProductID
+}
+
+// eventWindow remembers the keys of the last dedupeWindowSize applied events
+type eventWindow struct {
+	keys  []string // Oldest first
+	index map[string]struct{}
+}
+
+// newEventWindow creates a window holding the given keys, oldest first
+func newEventWindow(keys []string) *eventWindow {
+	w := &eventWindow{index: make(map[string]struct{}, dedupeWindowSize)}
+	for _, key := range keys {
+		w.add(key)
+	}
+	return w
+}
+
+// contains reports whether the event with key was applied recently
+func (w *eventWindow) contains(key string) bool {
+	_, ok := w.index[key]
+	return ok
+}
+
+// add records key, forgetting the oldest key once the window is full
+func (w *eventWindow) add(key string) {
+	if w.contains(key) {
+		return
+	}
+	if len(w.keys) >= dedupeWindowSize {
+		delete(w.index, w.keys[0])
+		w.keys = w.keys[1:]
+	}
+	w.keys = append(w.keys, key)
+	w.index[key] = struct{}{}
+}
+
+// list returns the remembered keys, oldest first
+func (w *eventWindow) list() []string {
+	return append([]string(nil), w.keys...)
+}
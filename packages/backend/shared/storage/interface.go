@@ -19,16 +19,32 @@ type LocalStorage interface {
 	GetLastSyncTime() (time.Time, error)
 	SetLastSyncTime(t time.Time) error
 
-	// Event offset tracking
+	// Event offset tracking. Moving the offset back clears the recently
+	// applied events, as offsets restart when the central event log is reset.
 	GetLastEventOffset() (int64, error)
 	SetLastEventOffset(offset int64) error
 
-	// Event-driven sync operations
+	// Event-driven sync operations. ApplyEvents applies each event exactly
+	// once: events below the last event offset, events applied recently under
+	// the same offset and product ID, and events not newer than the stored
+	// product version are skipped. The new offset and the recently applied
+	// events are recorded in the same write as the product changes.
 	ApplyEvents(events []models.Event) error
 
+	// Category tree, replaced on full syncs and kept current by category
+	// events in ApplyEvents
+	SyncCategories(categories []models.Category) error
+	GetCategories() ([]models.Category, error)
+
 	// Product operations
 	GetProduct(productID string) (*models.Product, error)
+	// GetProductByBarcode finds the product scanned by a barcode or alias,
+	// kept in a local index as products sync and events arrive
+	GetProductByBarcode(code string) (*models.Product, error)
 	GetAllProducts() ([]models.Product, error)
+	// GetRawProduct returns a product exactly as the backend holds it, for
+	// support engineers comparing the cache with the central API
+	GetRawProduct(productID string) (*RawProduct, error)
 	UpsertProduct(product models.Product) error
 	UpdateProduct(productID string, available int, version int, lastUpdated time.Time) error
 	DeleteProduct(productID string) error
@@ -41,6 +57,14 @@ type LocalStorage interface {
 	GetStorageStats() (*StorageStats, error)
 }
 
+// RawProduct is a cached product as the storage backend holds it: the stored
+// struct for memory, the hash fields under Key for redis
+type RawProduct struct {
+	Backend string      `json:"backend"`
+	Key     string      `json:"key"`
+	Value   interface{} `json:"value"`
+}
+
 // StorageStats provides information about the local storage
 type StorageStats struct {
 	ProductCount   int       `json:"productCount"`
@@ -59,4 +83,94 @@ type SyncStatus struct {
 	ProductCount    int           `json:"productCount"`
 	SyncDuration    time.Duration `json:"syncDuration"`
 	ErrorMessage    string        `json:"errorMessage,omitempty"`
+
+	// While a full sync pages through the catalog, ProductCount counts the
+	// products fetched so far and ProductsTotal the catalog size
+	ProductsTotal int `json:"productsTotal,omitempty"`
+
+	// Scheduled full resync; both are omitted while it is disabled
+	NextFullResync *time.Time        `json:"nextFullResync,omitempty"`
+	LastFullResync *FullResyncResult `json:"lastFullResync,omitempty"`
+
+	// Central API quota; omitted until the central API reports one
+	RateLimit *RateLimitQuota `json:"rateLimit,omitempty"`
+
+	// Central API maintenance mode; omitted while the central API accepts writes
+	CentralMaintenance *CentralMaintenance `json:"centralMaintenance,omitempty"`
+
+	// Operator pause of event polling; omitted while syncing
+	Paused *SyncPause `json:"paused,omitempty"`
+
+	// Event sync lag and throughput, also exported on /metrics
+	Metrics *SyncMetrics `json:"metrics,omitempty"`
+
+	// Paced draining of an event backlog; omitted while polling keeps up
+	CatchUp *CatchUpStatus `json:"catchUp,omitempty"`
+}
+
+// CatchUpStatus is the progress of draining an event backlog, such as the one
+// left by downtime. Batches are paced so catch-up does not starve POS traffic.
+type CatchUpStatus struct {
+	StartedAt       time.Time     `json:"startedAt"`
+	StartOffset     int64         `json:"startOffset"`
+	TargetOffset    int64         `json:"targetOffset"` // Central offset last reported, 0 when unknown
+	AppliedOffset   int64         `json:"appliedOffset"`
+	ProgressPercent float64       `json:"progressPercent"`
+	EventsApplied   int64         `json:"eventsApplied"`
+	LastPause       time.Duration `json:"lastPause"` // Wait before the next batch
+}
+
+// SyncMetrics is how far the store's cache trails the central event stream.
+// Offsets are next offsets: the first event not applied yet and the first
+// event the central API has not assigned yet.
+type SyncMetrics struct {
+	LastAppliedOffset   int64   `json:"lastAppliedOffset"`
+	CentralOffset       int64   `json:"centralOffset"` // 0 until a poll reported it
+	OffsetLag           int64   `json:"offsetLag"`     // Events the central API has that the cache lacks
+	EventsApplied       int64   `json:"eventsApplied"` // Since the store started
+	EventsPerSecond     float64 `json:"eventsPerSecond"`
+	FallbackMode        bool    `json:"fallbackMode"`
+	ConsecutiveFailures int64   `json:"consecutiveFailures"`
+
+	// Event page bytes received from the central API since the store started,
+	// as sent over the wire and after decompression
+	EventBytesReceived int64 `json:"eventBytesReceived"`
+	EventBytesDecoded  int64 `json:"eventBytesDecoded"`
+}
+
+// SyncPause is an operator's pause of synchronization, freezing the local
+// cache while it is investigated
+type SyncPause struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// CentralMaintenance is the central API's read-only maintenance as the store
+// last saw it. Reads and event polling go on; writes are refused by the
+// central API and queued offline.
+type CentralMaintenance struct {
+	Mode  string    `json:"mode"`
+	Since time.Time `json:"since"` // When the store first saw it
+}
+
+// RateLimitQuota is the central API quota the sync manager last saw and, while
+// it runs low, when polling resumes
+type RateLimitQuota struct {
+	Limit              int        `json:"limit"`
+	Remaining          int        `json:"remaining"`
+	Reset              time.Time  `json:"reset"`
+	PollingSlowedUntil *time.Time `json:"pollingSlowedUntil,omitempty"`
+}
+
+// FullResyncResult summarizes a scheduled full resync. Summary counts the
+// differences found per kind, as reported by sync verification.
+type FullResyncResult struct {
+	StartedAt      time.Time      `json:"startedAt"`
+	Duration       time.Duration  `json:"duration"`
+	Success        bool           `json:"success"`
+	ProductCount   int            `json:"productCount"`
+	Differences    int            `json:"differences"`
+	Summary        map[string]int `json:"summary,omitempty"`
+	SkippedPending int            `json:"skippedPending,omitempty"` // Products with offline writes awaiting replay
+	ErrorMessage   string         `json:"errorMessage,omitempty"`
 }
@@ -1,12 +1,17 @@
 package storage
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
 
@@ -17,8 +22,11 @@ import (
 type MemoryStorage struct {
 	mu              sync.RWMutex
 	products        map[string]models.Product
+	categories      map[string]models.Category // Central category tree by ID
 	lastSyncTime    time.Time
 	lastEventOffset int64
+	recentEvents    *eventWindow // Recently applied events, saved with the products
+	barcodes        barcodeIndex // Scanned codes to product IDs, rebuilt on load
 	initializedAt   time.Time
 	dataFile        string
 	metaFile        string
@@ -32,6 +40,16 @@ type StorageMetadata struct {
 	ProductCount    int       `json:"productCount"`
 }
 
+// productsFile is the on-disk layout of the products file. The applied event
+// offset and dedupe window are stored with the products so all are written in
+// a single file write; files written before them are a bare product map.
+type productsFile struct {
+	LastEventOffset int64                      `json:"lastEventOffset"`
+	RecentEvents    []string                   `json:"recentEvents,omitempty"`
+	Categories      map[string]models.Category `json:"categories,omitempty"`
+	Products        map[string]models.Product  `json:"products"`
+}
+
 // NewMemoryStorage creates a new in-memory storage instance
 func NewMemoryStorage(dataDir string) *MemoryStorage {
 	if err := os.MkdirAll(dataDir, 0755); err != nil {
@@ -41,7 +59,10 @@ func NewMemoryStorage(dataDir string) *MemoryStorage {
 
 	return &MemoryStorage{
 		products:      make(map[string]models.Product),
-		initializedAt: time.Now(),
+		categories:    make(map[string]models.Category),
+		recentEvents:  newEventWindow(nil),
+		barcodes:      barcodeIndex{},
+		initializedAt: time.Now().UTC(),
 		dataFile:      filepath.Join(dataDir, "local_inventory.json"),
 		metaFile:      filepath.Join(dataDir, "storage_metadata.json"),
 	}
@@ -58,12 +79,27 @@ func (ms *MemoryStorage) Initialize() error {
 
 	// Try to load existing data
 	if err := ms.loadFromFile(); err != nil {
-		// If loading fails, start with empty storage
+		// If loading fails, start with empty storage; the first sync refills it
 		ms.products = make(map[string]models.Product)
+		ms.categories = make(map[string]models.Category)
+		ms.recentEvents = newEventWindow(nil)
 		ms.lastSyncTime = time.Time{}
-		slog.Info("🆕 Created new empty database - no existing data found",
-			"error", err.Error(),
-			"data_file", ms.dataFile)
+		ms.lastEventOffset = 0
+		if errors.Is(err, ErrCorrupted) {
+			// Keep the unreadable file for inspection instead of overwriting it
+			corruptFile := ms.dataFile + corruptSuffix
+			if renameErr := os.Rename(ms.dataFile, corruptFile); renameErr != nil {
+				slog.Warn("Failed to move corrupted products file aside", "file", ms.dataFile, "error", renameErr)
+			}
+			slog.Error("❌ Local database is corrupted, starting empty and resyncing from the central API",
+				"error", err.Error(),
+				"data_file", ms.dataFile,
+				"moved_to", corruptFile)
+		} else {
+			slog.Info("🆕 Created new empty database - no existing data found",
+				"error", err.Error(),
+				"data_file", ms.dataFile)
+		}
 	} else {
 		slog.Info("📂 Loaded existing database from local files",
 			"product_count", len(ms.products),
@@ -94,15 +130,18 @@ func (ms *MemoryStorage) SyncAllProducts(products []models.Product) error {
 		"old_product_count", oldProductCount,
 		"new_product_count", len(products))
 
-	// Clear existing products
+	// Clear existing products; the window only holds events of the stream
+	// the replaced products came from
 	ms.products = make(map[string]models.Product)
+	ms.barcodes = barcodeIndex{}
+	ms.recentEvents = newEventWindow(nil)
 
 	// Add all new products
 	for _, product := range products {
-		ms.products[product.ProductID] = product
+		ms.putProduct(product)
 	}
 
-	ms.lastSyncTime = time.Now()
+	ms.lastSyncTime = time.Now().UTC()
 
 	slog.Info("✅ Full database synchronization completed",
 		"products_replaced", oldProductCount,
@@ -126,118 +165,83 @@ func (ms *MemoryStorage) SetLastSyncTime(t time.Time) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	ms.lastSyncTime = t
+	ms.lastSyncTime = t.UTC()
 	return ms.saveMetadata()
 }
 
-// GetLastEventOffset returns the last processed event offset by reading from the metadata file
+// GetLastEventOffset returns the last processed event offset
 func (ms *MemoryStorage) GetLastEventOffset() (int64, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-
-	// Always read from the metadata file to get the most current value
-	if data, err := os.ReadFile(ms.metaFile); err == nil {
-		var meta StorageMetadata
-		if err := json.Unmarshal(data, &meta); err == nil {
-			// Update the in-memory variable with the value from file
-			ms.lastEventOffset = meta.LastEventOffset
-			return meta.LastEventOffset, nil
-		}
-	}
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
 
-	// If file doesn't exist or can't be read, return the in-memory value (fallback)
 	return ms.lastEventOffset, nil
 }
 
-// SetLastEventOffset sets the last processed event offset
+// SetLastEventOffset sets the last processed event offset. Unlike ApplyEvents
+// this may move the offset backwards, which full-sync fallbacks rely on; the
+// dedupe window is then cleared, see dedupeWindowSize.
 func (ms *MemoryStorage) SetLastEventOffset(offset int64) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
+	if offset < ms.lastEventOffset {
+		ms.recentEvents = newEventWindow(nil)
+	}
 	ms.lastEventOffset = offset
-	return ms.saveMetadata()
+	return ms.saveToFile()
 }
 
-// ApplyEvents applies a batch of events to the local storage
+// ApplyEvents applies a batch of events exactly once. Events below the
+// recorded offset or in the dedupe window were already applied and are
+// skipped, as are events whose version is not newer than the stored product's.
+// Large batches are split by product and applied in parallel, keeping each
+// product's events in order. The products, the new offset and the window are
+// persisted together in a single file write.
 func (ms *MemoryStorage) ApplyEvents(events []models.Event) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
 	slog.Debug("Applying events to local storage", "event_count", len(events))
 
-	eventsProcessed := 0
-	eventsSkipped := 0
+	pending, eventsSkipped := ms.consumeEvents(events)
+	pending, categoriesProcessed := ms.applyCategoryEvents(pending)
 
-	for _, event := range events {
-		// Since events now contain complete product information,
-		// we can create the product directly from event data
-		product := models.Product{
-			ProductID: event.Data.ProductID,
-			Name:      event.Data.Name,
-			Available: event.Data.Available,
-			Version:   event.Data.Version,
-			Price:     event.Data.Price,
+	partitions := 1
+	if len(pending) >= parallelApplyThreshold {
+		partitions = runtime.GOMAXPROCS(0)
+	}
+	results := make([]partitionResult, partitions)
+	if partitions == 1 {
+		results[0] = ms.applyPartition(pending)
+	} else {
+		byPartition := make([][]models.Event, partitions)
+		for _, event := range pending {
+			i := productPartition(event.ProductID, partitions)
+			byPartition[i] = append(byPartition[i], event)
 		}
-
-		// Parse the timestamp
-		if lastUpdated, err := time.Parse(time.RFC3339, event.Data.LastUpdated); err == nil {
-			product.LastUpdated = lastUpdated
-		} else {
-			product.LastUpdated = time.Now()
+		// Workers only read ms.products; the results are merged below
+		var wg sync.WaitGroup
+		for i := range byPartition {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i] = ms.applyPartition(byPartition[i])
+			}(i)
 		}
+		wg.Wait()
+	}
 
-		// Apply the event based on type
-		switch event.EventType {
-		case models.EventTypeProductUpdated:
-			ms.products[event.ProductID] = product
-			eventsProcessed++
-			slog.Debug("Product updated in local storage",
-				"product_id", event.ProductID,
-				"name", product.Name,
-				"available", product.Available,
-				"version", product.Version,
-				"offset", event.Offset)
-
-		case models.EventTypeProductCreated:
-			ms.products[event.ProductID] = product
-			eventsProcessed++
-			slog.Info("Product created in local storage",
-				"product_id", event.ProductID,
-				"name", product.Name,
-				"available", product.Available,
-				"price", product.Price,
-				"version", product.Version,
-				"offset", event.Offset)
-
-		case models.EventTypeProductDeleted:
-			// Check if product exists before deletion
-			if _, exists := ms.products[event.ProductID]; exists {
-				delete(ms.products, event.ProductID)
-				eventsProcessed++
-				slog.Info("Product deleted from local storage",
-					"product_id", event.ProductID,
-					"name", event.Data.Name,
-					"offset", event.Offset)
+	eventsProcessed := categoriesProcessed
+	for _, result := range results {
+		for productID, state := range result.states {
+			if state.exists {
+				ms.putProduct(state.product)
 			} else {
-				eventsSkipped++
-				slog.Warn("Attempted to delete non-existent product",
-					"product_id", event.ProductID,
-					"offset", event.Offset)
+				ms.removeProduct(productID)
 			}
-
-		default:
-			eventsSkipped++
-			slog.Warn("Unknown event type, skipping",
-				"event_type", event.EventType,
-				"product_id", event.ProductID,
-				"offset", event.Offset)
-			continue
-		}
-
-		// Update the last processed offset
-		if event.Offset >= ms.lastEventOffset {
-			ms.lastEventOffset = event.Offset + 1
 		}
+		eventsProcessed += result.processed
+		eventsSkipped += result.skipped
 	}
 
 	// Log summary of applied events
@@ -246,6 +250,7 @@ func (ms *MemoryStorage) ApplyEvents(events []models.Event) error {
 			"events_received", len(events),
 			"events_processed", eventsProcessed,
 			"events_skipped", eventsSkipped,
+			"partitions", partitions,
 			"total_products", len(ms.products),
 			"last_offset", ms.lastEventOffset)
 	}
@@ -254,6 +259,198 @@ func (ms *MemoryStorage) ApplyEvents(events []models.Event) error {
 	return ms.saveToFile()
 }
 
+// parallelApplyThreshold is the batch size from which ApplyEvents splits the
+// events by product and applies the partitions in parallel
+const parallelApplyThreshold = 1000
+
+// productState is a product as seen while applying a partition of events
+type productState struct {
+	product models.Product
+	exists  bool
+}
+
+// partitionResult is the final state of every product a partition changed
+type partitionResult struct {
+	states    map[string]productState
+	processed int
+	skipped   int
+}
+
+// consumeEvents advances the offset and dedupe window over the batch in stream
+// order and returns the events still to apply, with the number skipped as
+// already applied (caller holds the write lock)
+func (ms *MemoryStorage) consumeEvents(events []models.Event) ([]models.Event, int) {
+	pending := make([]models.Event, 0, len(events))
+	skipped := 0
+	for _, event := range events {
+		if event.Offset < ms.lastEventOffset {
+			skipped++
+			slog.Debug("Event already applied, skipping",
+				"product_id", event.ProductID,
+				"offset", event.Offset,
+				"last_event_offset", ms.lastEventOffset)
+			continue
+		}
+
+		// Every event at or past the recorded offset is consumed, even when
+		// it turns out to be stale
+		ms.lastEventOffset = event.Offset + 1
+
+		key := eventKey(event)
+		if ms.recentEvents.contains(key) {
+			skipped++
+			slog.Debug("Event already applied, skipping",
+				"product_id", event.ProductID,
+				"offset", event.Offset)
+			continue
+		}
+		ms.recentEvents.add(key)
+		pending = append(pending, event)
+	}
+	return pending, skipped
+}
+
+// applyPartition applies events in order on top of the stored products
+// without modifying them, and returns the resulting product states. Every
+// product's events must be in the same partition. Safe to run concurrently
+// while the caller holds the write lock.
+func (ms *MemoryStorage) applyPartition(events []models.Event) partitionResult {
+	result := partitionResult{states: make(map[string]productState)}
+	for _, event := range events {
+		state, seen := result.states[event.ProductID]
+		if !seen {
+			state.product, state.exists = ms.products[event.ProductID]
+		}
+		next, changed := applyEventToProduct(state, event)
+		if !changed {
+			result.skipped++
+			continue
+		}
+		result.states[event.ProductID] = next
+		result.processed++
+	}
+	return result
+}
+
+// applyEventToProduct returns the product state after event and whether the
+// event changed it
+func applyEventToProduct(state productState, event models.Event) (productState, bool) {
+	if state.exists && eventVersion(event) <= state.product.Version {
+		slog.Debug("Stale event, local product is newer",
+			"product_id", event.ProductID,
+			"event_version", eventVersion(event),
+			"local_version", state.product.Version,
+			"offset", event.Offset)
+		return state, false
+	}
+
+	// Since events now contain complete product information,
+	// we can create the product directly from event data
+	product := productFromEvent(event)
+
+	// Apply the event based on type
+	switch event.EventType {
+	case models.EventTypeProductUpdated, models.EventTypeStockTransferred, models.EventTypeBackorderFulfilled,
+		models.EventTypeOrderReserved, models.EventTypeOrderCommitted, models.EventTypeOrderCancelled:
+		slog.Debug("Product updated in local storage",
+			"product_id", event.ProductID,
+			"name", product.Name,
+			"available", product.Available,
+			"version", product.Version,
+			"offset", event.Offset)
+		return productState{product: product, exists: true}, true
+
+	case models.EventTypeProductCreated:
+		slog.Info("Product created in local storage",
+			"product_id", event.ProductID,
+			"name", product.Name,
+			"available", product.Available,
+			"price", product.Price,
+			"version", product.Version,
+			"offset", event.Offset)
+		return productState{product: product, exists: true}, true
+
+	case models.EventTypeProductDeleted:
+		// Check if product exists before deletion
+		if !state.exists {
+			slog.Warn("Attempted to delete non-existent product",
+				"product_id", event.ProductID,
+				"offset", event.Offset)
+			return state, false
+		}
+		slog.Info("Product deleted from local storage",
+			"product_id", event.ProductID,
+			"name", event.Data.Name,
+			"offset", event.Offset)
+		return productState{}, true
+
+	default:
+		slog.Warn("Unknown event type, skipping",
+			"event_type", event.EventType,
+			"product_id", event.ProductID,
+			"offset", event.Offset)
+		return state, false
+	}
+}
+
+// applyCategoryEvents applies the category events of a batch in stream order
+// and returns the product events left to apply with the number of category
+// events applied (caller holds the write lock)
+func (ms *MemoryStorage) applyCategoryEvents(events []models.Event) ([]models.Event, int) {
+	productEvents := events[:0]
+	applied := 0
+	for _, event := range events {
+		switch {
+		case event.EventType == models.EventTypeCategoryUpdated && event.Category != nil:
+			ms.categories[event.Category.ID] = *event.Category
+		case event.EventType == models.EventTypeCategoryDeleted && event.Category != nil:
+			delete(ms.categories, event.Category.ID)
+		default:
+			productEvents = append(productEvents, event)
+			continue
+		}
+		applied++
+		slog.Debug("Category changed in local storage",
+			"event_type", event.EventType,
+			"category_id", event.Category.ID,
+			"offset", event.Offset)
+	}
+	return productEvents, applied
+}
+
+// productPartition routes a product to one of n partitions, so all of its
+// events are applied in order by the same worker
+func productPartition(productID string, n int) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(productID))
+	return int(hash.Sum32() % uint32(n))
+}
+
+// SyncCategories replaces the category tree with the provided list
+func (ms *MemoryStorage) SyncCategories(categories []models.Category) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.categories = make(map[string]models.Category, len(categories))
+	for _, category := range categories {
+		ms.categories[category.ID] = category
+	}
+	return ms.saveToFile()
+}
+
+// GetCategories returns the category tree sorted by ID
+func (ms *MemoryStorage) GetCategories() ([]models.Category, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	categories := make([]models.Category, 0, len(ms.categories))
+	for _, category := range ms.categories {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
 // GetProduct retrieves a single product by ID
 func (ms *MemoryStorage) GetProduct(productID string) (*models.Product, error) {
 	ms.mu.RLock()
@@ -261,7 +458,38 @@ func (ms *MemoryStorage) GetProduct(productID string) (*models.Product, error) {
 
 	product, exists := ms.products[productID]
 	if !exists {
-		return nil, fmt.Errorf("product not found: %s", productID)
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	return &product, nil
+}
+
+// GetRawProduct returns the product struct held in memory
+func (ms *MemoryStorage) GetRawProduct(productID string) (*RawProduct, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	product, exists := ms.products[productID]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	return &RawProduct{Backend: "memory", Key: productID, Value: product}, nil
+}
+
+// GetProductByBarcode finds the product scanned by a barcode or alias
+func (ms *MemoryStorage) GetProductByBarcode(code string) (*models.Product, error) {
+	code, err := normalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	product, exists := ms.products[ms.barcodes[code]]
+	if !exists {
+		return nil, fmt.Errorf("%w: barcode %s", ErrNotFound, code)
 	}
 
 	return &product, nil
@@ -285,7 +513,7 @@ func (ms *MemoryStorage) UpsertProduct(product models.Product) error {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	ms.products[product.ProductID] = product
+	ms.putProduct(product)
 	return nil
 }
 
@@ -296,7 +524,7 @@ func (ms *MemoryStorage) UpdateProduct(productID string, available int, version
 
 	product, exists := ms.products[productID]
 	if !exists {
-		return fmt.Errorf("product not found: %s", productID)
+		return fmt.Errorf("%w: %s", ErrNotFound, productID)
 	}
 
 	product.Available = available
@@ -313,10 +541,10 @@ func (ms *MemoryStorage) DeleteProduct(productID string) error {
 	defer ms.mu.Unlock()
 
 	if _, exists := ms.products[productID]; !exists {
-		return fmt.Errorf("product not found: %s", productID)
+		return fmt.Errorf("%w: %s", ErrNotFound, productID)
 	}
 
-	delete(ms.products, productID)
+	ms.removeProduct(productID)
 	return nil
 }
 
@@ -326,12 +554,29 @@ func (ms *MemoryStorage) BatchUpsertProducts(products []models.Product) error {
 	defer ms.mu.Unlock()
 
 	for _, product := range products {
-		ms.products[product.ProductID] = product
+		ms.putProduct(product)
 	}
 
 	return nil
 }
 
+// putProduct stores a product and re-indexes its codes. The caller holds ms.mu.
+func (ms *MemoryStorage) putProduct(product models.Product) {
+	if previous, exists := ms.products[product.ProductID]; exists {
+		ms.barcodes.remove(previous)
+	}
+	ms.products[product.ProductID] = product
+	ms.barcodes.add(product)
+}
+
+// removeProduct deletes a product and its codes. The caller holds ms.mu.
+func (ms *MemoryStorage) removeProduct(productID string) {
+	if previous, exists := ms.products[productID]; exists {
+		ms.barcodes.remove(previous)
+	}
+	delete(ms.products, productID)
+}
+
 // GetProductCount returns the number of products in storage
 func (ms *MemoryStorage) GetProductCount() (int, error) {
 	ms.mu.RLock()
@@ -371,20 +616,46 @@ func (ms *MemoryStorage) loadFromFile() error {
 	var productsLoaded, metadataLoaded bool
 	var productCount int
 
-	// Load products
-	if data, err := os.ReadFile(ms.dataFile); err == nil {
-		if err := json.Unmarshal(data, &ms.products); err != nil {
-			slog.Error("❌ Failed to parse products file",
-				"file", ms.dataFile,
-				"error", err.Error())
-			return fmt.Errorf("failed to unmarshal products: %w", err)
+	// Load products, falling back to the previous generation when the
+	// current file cannot be decoded
+	var productsOffset int64
+	offsetInProducts := false
+	file, withOffset, err := readProductsFile(ms.dataFile)
+	if errors.Is(err, ErrCorrupted) {
+		slog.Error("❌ Failed to parse products file, trying the backup",
+			"file", ms.dataFile,
+			"error", err.Error())
+		backupFile, backupWithOffset, backupErr := readProductsFile(ms.dataFile + backupSuffix)
+		if backupErr != nil {
+			slog.Error("❌ Products backup is not usable",
+				"file", ms.dataFile+backupSuffix,
+				"error", backupErr.Error())
+			return err
+		}
+		// Move the unreadable file aside so the next save cannot turn it into the backup
+		if renameErr := os.Rename(ms.dataFile, ms.dataFile+corruptSuffix); renameErr != nil {
+			slog.Warn("Failed to move corrupted products file aside", "file", ms.dataFile, "error", renameErr)
 		}
+		slog.Warn("♻️ Recovered products from the previous generation; newer events will be replayed",
+			"file", ms.dataFile+backupSuffix,
+			"last_event_offset", backupFile.LastEventOffset)
+		file, withOffset, err = backupFile, backupWithOffset, nil
+	}
+	if err == nil {
+		ms.products = file.Products
+		ms.categories = file.Categories
+		if ms.categories == nil {
+			ms.categories = make(map[string]models.Category)
+		}
+		ms.barcodes = newBarcodeIndex(file.Products)
+		ms.recentEvents = newEventWindow(file.RecentEvents)
+		productsOffset = file.LastEventOffset
+		offsetInProducts = withOffset
 		productsLoaded = true
 		productCount = len(ms.products)
 		slog.Debug("✅ Products file loaded successfully",
 			"file", ms.dataFile,
-			"product_count", productCount,
-			"file_size_bytes", len(data))
+			"product_count", productCount)
 	} else {
 		slog.Debug("📄 Products file not found",
 			"file", ms.dataFile,
@@ -421,19 +692,137 @@ func (ms *MemoryStorage) loadFromFile() error {
 		return fmt.Errorf("no existing data files found")
 	}
 
+	// The offset written with the products is authoritative; the metadata
+	// copy may lag if the process stopped between the two writes
+	if offsetInProducts {
+		ms.lastEventOffset = productsOffset
+	}
+
+	return nil
+}
+
+// readProductsFile decodes a products file in the current layout or as the
+// older bare product map; withOffset reports the current layout. The file is
+// streamed product by product, so a large cache is never held in memory twice.
+// A file that exists but cannot be decoded, e.g. after a torn write, is
+// ErrCorrupted.
+func readProductsFile(path string) (file productsFile, withOffset bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return productsFile{}, false, err
+	}
+	defer f.Close()
+
+	file, withOffset, err = decodeProductsStream(bufio.NewReaderSize(f, loadBufferSize))
+	if err != nil {
+		return productsFile{}, false, fmt.Errorf("%w: failed to decode %s: %v", ErrCorrupted, filepath.Base(path), err)
+	}
+	return file, withOffset, nil
+}
+
+// loadBufferSize is the read buffer used while streaming the products file
+const loadBufferSize = 1 << 20
+
+// decodeProductsStream reads the top-level object token by token. Known keys
+// belong to the current layout; any other key is a product of the bare map
+// layout.
+func decodeProductsStream(r io.Reader) (file productsFile, withOffset bool, err error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return productsFile{}, false, err
+	}
+
+	file.Products = make(map[string]models.Product)
+	for dec.More() {
+		key, err := objectKey(dec)
+		if err != nil {
+			return productsFile{}, false, err
+		}
+		switch key {
+		case "lastEventOffset":
+			err = dec.Decode(&file.LastEventOffset)
+		case "recentEvents":
+			err = dec.Decode(&file.RecentEvents)
+		case "categories":
+			err = dec.Decode(&file.Categories)
+		case "products":
+			withOffset = true
+			err = decodeProductMap(dec, file.Products)
+		default:
+			var product models.Product
+			err = dec.Decode(&product)
+			file.Products[key] = product
+		}
+		if err != nil {
+			return productsFile{}, false, fmt.Errorf("field %q: %w", key, err)
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return productsFile{}, false, err
+	}
+	return file, withOffset, nil
+}
+
+// decodeProductMap streams a {"<productId>": product, ...} object into products
+func decodeProductMap(dec *json.Decoder, products map[string]models.Product) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		productID, err := objectKey(dec)
+		if err != nil {
+			return err
+		}
+		var product models.Product
+		if err := dec.Decode(&product); err != nil {
+			return fmt.Errorf("product %q: %w", productID, err)
+		}
+		products[productID] = product
+	}
+	return expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks it is the given delimiter
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected %q, got %v", want, token)
+	}
 	return nil
 }
 
+// objectKey reads the next object key
+func objectKey(dec *json.Decoder) (string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected an object key, got %v", token)
+	}
+	return key, nil
+}
+
 // saveToFile saves products and metadata to files
 func (ms *MemoryStorage) saveToFile() error {
-	// Save products
-	data, err := json.MarshalIndent(ms.products, "", "  ")
+	// Save products together with the applied event offset and dedupe window
+	data, err := json.MarshalIndent(productsFile{
+		LastEventOffset: ms.lastEventOffset,
+		RecentEvents:    ms.recentEvents.list(),
+		Categories:      ms.categories,
+		Products:        ms.products,
+	}, "", "  ")
 	if err != nil {
 		slog.Error("❌ Failed to marshal products for saving", "error", err.Error())
 		return fmt.Errorf("failed to marshal products: %w", err)
 	}
 
-	if err := os.WriteFile(ms.dataFile, data, 0644); err != nil {
+	// Replace the file atomically and keep the previous generation as a backup
+	if err := writeFileAtomic(ms.dataFile, data, true); err != nil {
 		slog.Error("❌ Failed to write products file",
 			"file", ms.dataFile,
 			"error", err.Error())
@@ -464,7 +853,7 @@ func (ms *MemoryStorage) saveMetadata() error {
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	if err := os.WriteFile(ms.metaFile, data, 0644); err != nil {
+	if err := writeFileAtomic(ms.metaFile, data, false); err != nil {
 		slog.Error("❌ Failed to write metadata file",
 			"file", ms.metaFile,
 			"error", err.Error())
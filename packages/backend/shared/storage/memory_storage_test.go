@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/melibackend/shared/models"
+)
+
+// newTestMemoryStorage returns an initialized storage in a temp directory
+func newTestMemoryStorage(t *testing.T) *MemoryStorage {
+	t.Helper()
+
+	ms := NewMemoryStorage(t.TempDir())
+	if err := ms.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	return ms
+}
+
+// updateEvent returns a product_updated event setting a product's stock
+func updateEvent(offset int64, productID string, version, available int) models.Event {
+	return models.Event{
+		Offset:    offset,
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Version:   version,
+		Data: models.ProductResponse{
+			ProductID:   productID,
+			Name:        productID,
+			Available:   available,
+			Version:     version,
+			LastUpdated: "2026-01-01T00:00:00Z",
+		},
+	}
+}
+
+// expectProduct fails the test unless the stored product has the stock and version
+func expectProduct(t *testing.T, storage LocalStorage, productID string, available, version int) {
+	t.Helper()
+
+	product, err := storage.GetProduct(productID)
+	if err != nil {
+		t.Fatalf("GetProduct(%s) failed: %v", productID, err)
+	}
+	if product.Available != available || product.Version != version {
+		t.Errorf("expected %s at %d units, version %d, got %d units, version %d",
+			productID, available, version, product.Available, product.Version)
+	}
+}
+
+func TestMemoryStorage_RewindClearsDedupeWindow(t *testing.T) {
+	tests := []struct {
+		name   string
+		rewind func(t *testing.T, ms *MemoryStorage)
+	}{
+		{
+			name: "offset moved back",
+			rewind: func(t *testing.T, ms *MemoryStorage) {
+				if err := ms.SetLastEventOffset(0); err != nil {
+					t.Fatalf("SetLastEventOffset failed: %v", err)
+				}
+			},
+		},
+		{
+			name: "full sync",
+			rewind: func(t *testing.T, ms *MemoryStorage) {
+				err := ms.SyncAllProducts([]models.Product{{ProductID: "SKU-001", Name: "SKU-001", Available: 10, Version: 2}})
+				if err != nil {
+					t.Fatalf("SyncAllProducts failed: %v", err)
+				}
+				if window := ms.recentEvents.list(); len(window) != 0 {
+					t.Errorf("expected the full sync to clear the dedupe window, got %v", window)
+				}
+				if err := ms.SetLastEventOffset(0); err != nil {
+					t.Fatalf("SetLastEventOffset failed: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ms := newTestMemoryStorage(t)
+			if err := ms.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 2, 10)}); err != nil {
+				t.Fatalf("ApplyEvents failed: %v", err)
+			}
+
+			// The central event log restarts at 0 after a reset
+			tt.rewind(t, ms)
+
+			// A new event reusing the offset of an old one is applied
+			if err := ms.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 3, 7)}); err != nil {
+				t.Fatalf("ApplyEvents failed: %v", err)
+			}
+			expectProduct(t, ms, "SKU-001", 7, 3)
+
+			// A replay of the old stream is dropped by the version check
+			if err := ms.SetLastEventOffset(0); err != nil {
+				t.Fatalf("SetLastEventOffset failed: %v", err)
+			}
+			if err := ms.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 2, 10)}); err != nil {
+				t.Fatalf("ApplyEvents failed: %v", err)
+			}
+			expectProduct(t, ms, "SKU-001", 7, 3)
+		})
+	}
+}
@@ -0,0 +1,701 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/melibackend/shared/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisOpTimeout bounds every Redis round trip since LocalStorage has no context parameter
+const redisOpTimeout = 5 * time.Second
+
+// applyEventBatch applies a batch of events and records the new offset in one
+// atomic script. The offset only moves forward so that several store instances
+// applying the same events never rewind it. KEYS[1] is the offset key, KEYS[2]
+// the product index, KEYS[3] the last update time key and KEYS[4] the dedupe
+// window, a sorted set of "<offset>:<productId>" scored by offset, KEYS[5] the
+// barcode hash; KEYS[5+i] is the product hash of the i-th event, or the
+// category hash for category events. ARGV[1] is the update time, ARGV[2] the
+// window size and ARGV[2+i] the JSON-encoded event ({offset, op, productId,
+// version, fields, codes, categoryId, category}). Events below the stored
+// offset or in the window were already applied; events not newer than the
+// cached product are stale. Category events have no version and always apply.
+// Returns {applied, skipped, offset}.
+var applyEventBatch = redis.NewScript(`
+local offset = tonumber(redis.call('GET', KEYS[1]) or '0')
+local applied, skipped = 0, 0
+for i = 3, #ARGV do
+	local event = cjson.decode(ARGV[i])
+	local key = KEYS[i + 3]
+	local member = string.format('%d:%s', event.offset, event.productId)
+	if event.offset < offset then
+		skipped = skipped + 1
+	elseif redis.call('ZSCORE', KEYS[4], member) then
+		offset = event.offset + 1
+		skipped = skipped + 1
+	else
+		offset = event.offset + 1
+		redis.call('ZADD', KEYS[4], event.offset, member)
+		if event.op == 'category_put' then
+			redis.call('HSET', key, event.categoryId, event.category)
+			applied = applied + 1
+		elseif event.op == 'category_delete' then
+			redis.call('HDEL', key, event.categoryId)
+			applied = applied + 1
+		else
+			local current = tonumber(redis.call('HGET', key, 'version') or '-1')
+			if current >= event.version then
+				skipped = skipped + 1
+			elseif event.op == 'upsert' then
+				redis.call('DEL', key)
+				redis.call('HSET', key, unpack(event.fields))
+				redis.call('SADD', KEYS[2], event.productId)
+				for _, code in ipairs(event.codes or {}) do
+					redis.call('HSET', KEYS[5], code, event.productId)
+				end
+				applied = applied + 1
+			elseif event.op == 'delete' and current >= 0 then
+				redis.call('DEL', key)
+				redis.call('SREM', KEYS[2], event.productId)
+				applied = applied + 1
+			else
+				skipped = skipped + 1
+			end
+		end
+	end
+end
+redis.call('ZREMRANGEBYRANK', KEYS[4], 0, -(tonumber(ARGV[2]) + 1))
+redis.call('SET', KEYS[1], string.format('%d', offset))
+redis.call('SET', KEYS[3], ARGV[1])
+return {applied, skipped, offset}
+`)
+
+// scriptEvent is the JSON form of an event passed to applyEventBatch
+type scriptEvent struct {
+	Offset    int64    `json:"offset"`
+	Op        string   `json:"op"`
+	ProductID string   `json:"productId"`
+	Version   int      `json:"version"`
+	Fields    []string `json:"fields,omitempty"`
+	Codes     []string `json:"codes,omitempty"`
+	// CategoryID and the JSON-encoded Category, for category events
+	CategoryID string `json:"categoryId,omitempty"`
+	Category   string `json:"category,omitempty"`
+}
+
+// setEventOffset sets the offset in KEYS[1] to ARGV[1], clearing the dedupe
+// window in KEYS[2] when the offset moves back (see dedupeWindowSize)
+var setEventOffset = redis.NewScript(`
+local offset = tonumber(redis.call('GET', KEYS[1]) or '0')
+if tonumber(ARGV[1]) < offset then
+	redis.call('DEL', KEYS[2])
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+// updateIfExists updates stock fields only for products already in the cache
+var updateIfExists = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'available', ARGV[1], 'version', ARGV[2], 'lastUpdated', ARGV[3])
+return 1
+`)
+
+// RedisStorage implements LocalStorage on Redis so several store API instances
+// can share one cache. Each product is a hash under <prefix>product:<id>, the
+// set <prefix>products indexes product IDs, the hash <prefix>barcodes maps
+// scanned codes to product IDs, the hash <prefix>categories holds the category
+// tree as JSON by category ID and sync metadata lives in plain <prefix>meta:*
+// keys.
+type RedisStorage struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisStorage creates a Redis-backed storage from a redis:// URL
+func NewRedisStorage(redisURL, keyPrefix string) (*RedisStorage, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis url: %w", err)
+	}
+
+	return &RedisStorage{
+		client:    redis.NewClient(opts),
+		keyPrefix: keyPrefix,
+	}, nil
+}
+
+// Initialize verifies connectivity and records the initialization time once
+func (rs *RedisStorage) Initialize() error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	if err := rs.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	if err := rs.client.SetNX(ctx, rs.metaKey("initializedAt"), time.Now().UTC().Format(time.RFC3339Nano), 0).Err(); err != nil {
+		return fmt.Errorf("failed to initialize redis metadata: %w", err)
+	}
+
+	count, _ := rs.client.SCard(ctx, rs.indexKey()).Result()
+	slog.Info("Connected to Redis storage",
+		"key_prefix", rs.keyPrefix,
+		"product_count", count)
+
+	return nil
+}
+
+// Close closes the Redis connection; data stays in Redis
+func (rs *RedisStorage) Close() error {
+	return rs.client.Close()
+}
+
+// SyncAllProducts atomically replaces all products with the provided list
+func (rs *RedisStorage) SyncAllProducts(products []models.Product) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	existingIDs, err := rs.client.SMembers(ctx, rs.indexKey()).Result()
+	if err != nil {
+		return fmt.Errorf("failed to list cached products: %w", err)
+	}
+
+	slog.Info("🔄 Starting full Redis synchronization",
+		"old_product_count", len(existingIDs),
+		"new_product_count", len(products))
+
+	_, err = rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, productID := range existingIDs {
+			pipe.Del(ctx, rs.productKey(productID))
+		}
+		pipe.Del(ctx, rs.indexKey())
+		pipe.Del(ctx, rs.barcodeKey())
+		pipe.Del(ctx, rs.metaKey("recentEvents"))
+
+		for _, product := range products {
+			rs.writeProduct(ctx, pipe, product)
+		}
+
+		pipe.Set(ctx, rs.metaKey("lastSyncTime"), time.Now().UTC().Format(time.RFC3339Nano), 0)
+		pipe.Set(ctx, rs.metaKey("lastUpdateTime"), time.Now().UTC().Format(time.RFC3339Nano), 0)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync products to redis: %w", err)
+	}
+
+	slog.Info("✅ Full Redis synchronization completed", "products_loaded", len(products))
+	return nil
+}
+
+// SyncCategories atomically replaces the category tree
+func (rs *RedisStorage) SyncCategories(categories []models.Category) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	_, err := rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, rs.categoryKey())
+		for _, category := range categories {
+			encoded, err := json.Marshal(category)
+			if err != nil {
+				return err
+			}
+			pipe.HSet(ctx, rs.categoryKey(), category.ID, string(encoded))
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sync categories to redis: %w", err)
+	}
+	return nil
+}
+
+// GetCategories returns the category tree sorted by ID
+func (rs *RedisStorage) GetCategories() ([]models.Category, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	fields, err := rs.client.HGetAll(ctx, rs.categoryKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read categories: %w", err)
+	}
+
+	categories := make([]models.Category, 0, len(fields))
+	for id, encoded := range fields {
+		var category models.Category
+		if err := json.Unmarshal([]byte(encoded), &category); err != nil {
+			return nil, fmt.Errorf("%w: category %s", ErrCorrupted, id)
+		}
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	return categories, nil
+}
+
+// GetLastSyncTime returns the last synchronization time
+func (rs *RedisStorage) GetLastSyncTime() (time.Time, error) {
+	return rs.getTime("lastSyncTime")
+}
+
+// SetLastSyncTime sets the last synchronization time
+func (rs *RedisStorage) SetLastSyncTime(t time.Time) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	return rs.client.Set(ctx, rs.metaKey("lastSyncTime"), t.UTC().Format(time.RFC3339Nano), 0).Err()
+}
+
+// GetLastEventOffset returns the last processed event offset
+func (rs *RedisStorage) GetLastEventOffset() (int64, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	value, err := rs.client.Get(ctx, rs.metaKey("lastEventOffset")).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read event offset: %w", err)
+	}
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: event offset %q", ErrCorrupted, value)
+	}
+	return offset, nil
+}
+
+// SetLastEventOffset sets the last processed event offset. Unlike ApplyEvents
+// this may move the offset backwards, which full-sync fallbacks rely on; the
+// dedupe window is then cleared.
+func (rs *RedisStorage) SetLastEventOffset(offset int64) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	keys := []string{rs.metaKey("lastEventOffset"), rs.metaKey("recentEvents")}
+	return setEventOffset.Run(ctx, rs.client, keys, offset).Err()
+}
+
+// ApplyEvents applies a batch of events exactly once: the product mutations,
+// the new offset and the dedupe window are written by a single script. Events
+// below the stored offset or in the window are skipped and so are events not
+// newer than the cached product.
+func (rs *RedisStorage) ApplyEvents(events []models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	keys := []string{rs.metaKey("lastEventOffset"), rs.indexKey(), rs.metaKey("lastUpdateTime"), rs.metaKey("recentEvents"), rs.barcodeKey()}
+	args := []interface{}{time.Now().UTC().Format(time.RFC3339Nano), dedupeWindowSize}
+
+	for _, event := range events {
+		scripted := scriptEvent{
+			Offset:    event.Offset,
+			ProductID: event.ProductID,
+			Version:   eventVersion(event),
+		}
+		key := rs.productKey(event.ProductID)
+
+		switch event.EventType {
+		case models.EventTypeProductUpdated, models.EventTypeProductCreated, models.EventTypeStockTransferred,
+			models.EventTypeBackorderFulfilled, models.EventTypeOrderReserved, models.EventTypeOrderCommitted,
+			models.EventTypeOrderCancelled:
+			scripted.Op = "upsert"
+			product := productFromEvent(event)
+			scripted.Fields = productFields(product)
+			scripted.Codes = productCodes(product)
+		case models.EventTypeProductDeleted:
+			scripted.Op = "delete"
+		case models.EventTypeCategoryUpdated, models.EventTypeCategoryDeleted:
+			if event.Category == nil {
+				scripted.Op = "unknown"
+				slog.Warn("Category event without a category, skipping", "offset", event.Offset)
+				break
+			}
+			key = rs.categoryKey()
+			scripted.CategoryID = event.Category.ID
+			scripted.Op = "category_delete"
+			if event.EventType == models.EventTypeCategoryUpdated {
+				category, _ := json.Marshal(event.Category)
+				scripted.Op = "category_put"
+				scripted.Category = string(category)
+			}
+		default:
+			// Still passed to the script so its offset is consumed
+			scripted.Op = "unknown"
+			slog.Warn("Unknown event type, skipping",
+				"event_type", event.EventType,
+				"product_id", event.ProductID,
+				"offset", event.Offset)
+		}
+
+		encoded, err := json.Marshal(scripted)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %d: %w", event.Offset, err)
+		}
+		keys = append(keys, key)
+		args = append(args, string(encoded))
+	}
+
+	result, err := applyEventBatch.Run(ctx, rs.client, keys, args...).Int64Slice()
+	if err != nil {
+		return fmt.Errorf("failed to apply events to redis: %w", err)
+	}
+
+	slog.Info("Successfully applied events to Redis storage",
+		"events_received", len(events),
+		"events_applied", result[0],
+		"events_skipped", result[1],
+		"last_offset", result[2])
+
+	return nil
+}
+
+// GetProduct retrieves a single product by ID
+func (rs *RedisStorage) GetProduct(productID string) (*models.Product, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	fields, err := rs.client.HGetAll(ctx, rs.productKey(productID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read product from redis: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	product, err := productFromHash(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetRawProduct returns the product's hash fields as stored, without parsing
+// them, so fields a product could not be read from are visible too
+func (rs *RedisStorage) GetRawProduct(productID string) (*RawProduct, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	key := rs.productKey(productID)
+	fields, err := rs.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read product from redis: %w", err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	return &RawProduct{Backend: "redis", Key: key, Value: fields}, nil
+}
+
+// GetProductByBarcode finds the product scanned by a barcode or alias. Codes
+// are never unindexed, so an entry whose product no longer carries the code is
+// treated as a miss.
+func (rs *RedisStorage) GetProductByBarcode(code string) (*models.Product, error) {
+	code, err := normalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	productID, err := rs.client.HGet(ctx, rs.barcodeKey(), code).Result()
+	if err == redis.Nil {
+		return nil, fmt.Errorf("%w: barcode %s", ErrNotFound, code)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read barcode from redis: %w", err)
+	}
+
+	product, err := rs.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if !hasCode(*product, code) {
+		return nil, fmt.Errorf("%w: barcode %s", ErrNotFound, code)
+	}
+	return product, nil
+}
+
+// GetAllProducts returns all products
+func (rs *RedisStorage) GetAllProducts() ([]models.Product, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	productIDs, err := rs.client.SMembers(ctx, rs.indexKey()).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cached products: %w", err)
+	}
+
+	pipe := rs.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(productIDs))
+	for i, productID := range productIDs {
+		cmds[i] = pipe.HGetAll(ctx, rs.productKey(productID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("failed to read products from redis: %w", err)
+	}
+
+	products := make([]models.Product, 0, len(productIDs))
+	for _, cmd := range cmds {
+		fields, err := cmd.Result()
+		if err != nil || len(fields) == 0 {
+			// Deleted between SMEMBERS and HGETALL
+			continue
+		}
+		product, err := productFromHash(fields)
+		if err != nil {
+			slog.Warn("Skipping unreadable cached product", "error", err)
+			continue
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+// UpsertProduct inserts or updates a product
+func (rs *RedisStorage) UpsertProduct(product models.Product) error {
+	return rs.BatchUpsertProducts([]models.Product{product})
+}
+
+// UpdateProduct updates specific fields of a product
+func (rs *RedisStorage) UpdateProduct(productID string, available int, version int, lastUpdated time.Time) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	updated, err := updateIfExists.Run(ctx, rs.client, []string{rs.productKey(productID)},
+		available, version, lastUpdated.Format(time.RFC3339Nano)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to update product in redis: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	return nil
+}
+
+// DeleteProduct removes a product from storage
+func (rs *RedisStorage) DeleteProduct(productID string) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	removed, err := rs.client.SRem(ctx, rs.indexKey(), productID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete product from redis: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, productID)
+	}
+
+	return rs.client.Del(ctx, rs.productKey(productID)).Err()
+}
+
+// BatchUpsertProducts inserts or updates multiple products
+func (rs *RedisStorage) BatchUpsertProducts(products []models.Product) error {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	_, err := rs.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, product := range products {
+			rs.writeProduct(ctx, pipe, product)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert products to redis: %w", err)
+	}
+	return nil
+}
+
+// GetProductCount returns the number of products in storage
+func (rs *RedisStorage) GetProductCount() (int, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	count, err := rs.client.SCard(ctx, rs.indexKey()).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count products: %w", err)
+	}
+	return int(count), nil
+}
+
+// GetStorageStats returns storage statistics
+func (rs *RedisStorage) GetStorageStats() (*StorageStats, error) {
+	count, err := rs.GetProductCount()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &StorageStats{
+		ProductCount:   count,
+		LastUpdateTime: time.Now(),
+	}
+	stats.LastSyncTime, _ = rs.getTime("lastSyncTime")
+	stats.InitializedAt, _ = rs.getTime("initializedAt")
+
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	// Redis reports memory for the whole instance, which may be shared
+	if info, err := rs.client.Info(ctx, "memory").Result(); err == nil {
+		stats.MemoryUsage = parseInfoInt(info, "used_memory")
+		stats.StorageSize = stats.MemoryUsage
+	}
+
+	return stats, nil
+}
+
+// writeProduct queues the commands that store a product hash and index it
+func (rs *RedisStorage) writeProduct(ctx context.Context, pipe redis.Pipeliner, product models.Product) {
+	key := rs.productKey(product.ProductID)
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, productFields(product))
+	pipe.SAdd(ctx, rs.indexKey(), product.ProductID)
+	for _, code := range productCodes(product) {
+		pipe.HSet(ctx, rs.barcodeKey(), code, product.ProductID)
+	}
+}
+
+// productFields flattens a product into hash field/value pairs
+func productFields(product models.Product) []string {
+	fields := []string{
+		"productId", product.ProductID,
+		"name", product.Name,
+		"available", strconv.Itoa(product.Available),
+		"version", strconv.Itoa(product.Version),
+		"lastUpdated", product.LastUpdated.Format(time.RFC3339Nano),
+		"price", strconv.FormatFloat(product.Price, 'f', -1, 64),
+	}
+	if len(product.Prices) > 0 {
+		if prices, err := json.Marshal(product.Prices); err == nil {
+			fields = append(fields, "prices", string(prices))
+		}
+	}
+	if product.Category != "" {
+		fields = append(fields, "category", product.Category)
+	}
+	if product.Barcode != "" {
+		fields = append(fields, "barcode", product.Barcode)
+	}
+	if len(product.BarcodeAliases) > 0 {
+		if aliases, err := json.Marshal(product.BarcodeAliases); err == nil {
+			fields = append(fields, "barcodeAliases", string(aliases))
+		}
+	}
+	if len(product.Assets) > 0 {
+		if assets, err := json.Marshal(product.Assets); err == nil {
+			fields = append(fields, "assets", string(assets))
+		}
+	}
+	if product.StockPolicy != nil {
+		if policy, err := json.Marshal(product.StockPolicy); err == nil {
+			fields = append(fields, "stockPolicy", string(policy))
+		}
+	}
+	return fields
+}
+
+// getTime reads an RFC3339 metadata key, returning the zero time when unset
+func (rs *RedisStorage) getTime(name string) (time.Time, error) {
+	ctx, cancel := rs.opContext()
+	defer cancel()
+
+	value, err := rs.client.Get(ctx, rs.metaKey(name)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s %q", ErrCorrupted, name, value)
+	}
+	return parsed, nil
+}
+
+func (rs *RedisStorage) opContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), redisOpTimeout)
+}
+
+func (rs *RedisStorage) productKey(productID string) string {
+	return rs.keyPrefix + "product:" + productID
+}
+
+func (rs *RedisStorage) indexKey() string {
+	return rs.keyPrefix + "products"
+}
+
+func (rs *RedisStorage) barcodeKey() string {
+	return rs.keyPrefix + "barcodes"
+}
+
+func (rs *RedisStorage) categoryKey() string {
+	return rs.keyPrefix + "categories"
+}
+
+func (rs *RedisStorage) metaKey(name string) string {
+	return rs.keyPrefix + "meta:" + name
+}
+
+// productFromHash decodes a product hash. Stock and version must decode; the
+// other fields fall back to their zero values.
+func productFromHash(fields map[string]string) (models.Product, error) {
+	product := models.Product{
+		ProductID: fields["productId"],
+		Name:      fields["name"],
+	}
+	var err error
+	if product.Available, err = strconv.Atoi(fields["available"]); err != nil {
+		return product, fmt.Errorf("%w: product %s has available %q", ErrCorrupted, product.ProductID, fields["available"])
+	}
+	if product.Version, err = strconv.Atoi(fields["version"]); err != nil {
+		return product, fmt.Errorf("%w: product %s has version %q", ErrCorrupted, product.ProductID, fields["version"])
+	}
+	product.Price, _ = strconv.ParseFloat(fields["price"], 64)
+	product.LastUpdated, _ = time.Parse(time.RFC3339Nano, fields["lastUpdated"])
+	if prices := fields["prices"]; prices != "" {
+		json.Unmarshal([]byte(prices), &product.Prices)
+	}
+	product.Category = fields["category"]
+	product.Barcode = fields["barcode"]
+	if aliases := fields["barcodeAliases"]; aliases != "" {
+		json.Unmarshal([]byte(aliases), &product.BarcodeAliases)
+	}
+	if assets := fields["assets"]; assets != "" {
+		json.Unmarshal([]byte(assets), &product.Assets)
+	}
+	if policy := fields["stockPolicy"]; policy != "" {
+		product.StockPolicy = &models.StockPolicy{}
+		json.Unmarshal([]byte(policy), product.StockPolicy)
+	}
+	return product, nil
+}
+
+// parseInfoInt extracts an integer field from INFO output
+func parseInfoInt(info, field string) int64 {
+	for _, line := range strings.Split(info, "\r\n") {
+		if value, ok := strings.CutPrefix(line, field+":"); ok {
+			n, _ := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			return n
+		}
+	}
+	return 0
+}
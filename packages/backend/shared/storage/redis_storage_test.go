@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/models"
+)
+
+// newTestRedisStorage returns a storage on the Redis server at REDIS_TEST_URL
+// under a key prefix of its own, skipping the test when no server is set
+func newTestRedisStorage(t *testing.T) *RedisStorage {
+	t.Helper()
+
+	url := os.Getenv("REDIS_TEST_URL")
+	if url == "" {
+		t.Skip("REDIS_TEST_URL not set")
+	}
+	rs, err := NewRedisStorage(url, fmt.Sprintf("test:%s:%d:", t.Name(), time.Now().UnixNano()))
+	if err != nil {
+		t.Fatalf("NewRedisStorage failed: %v", err)
+	}
+	if err := rs.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	t.Cleanup(func() {
+		ctx := context.Background()
+		keys, _ := rs.client.Keys(ctx, rs.keyPrefix+"*").Result()
+		if len(keys) > 0 {
+			rs.client.Del(ctx, keys...)
+		}
+		rs.Close()
+	})
+	return rs
+}
+
+func TestRedisStorage_RewindClearsDedupeWindow(t *testing.T) {
+	rs := newTestRedisStorage(t)
+	if err := rs.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 2, 10)}); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+
+	// The central event log restarts at 0 after a reset
+	if err := rs.SetLastEventOffset(0); err != nil {
+		t.Fatalf("SetLastEventOffset failed: %v", err)
+	}
+	if err := rs.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 3, 7)}); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+	expectProduct(t, rs, "SKU-001", 7, 3)
+
+	// A replay of the old stream is dropped by the version check
+	if err := rs.SetLastEventOffset(0); err != nil {
+		t.Fatalf("SetLastEventOffset failed: %v", err)
+	}
+	if err := rs.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 2, 10)}); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+	expectProduct(t, rs, "SKU-001", 7, 3)
+
+	// A full sync forgets the window as well
+	if err := rs.SyncAllProducts([]models.Product{{ProductID: "SKU-001", Name: "SKU-001", Available: 5, Version: 4}}); err != nil {
+		t.Fatalf("SyncAllProducts failed: %v", err)
+	}
+	if n, _ := rs.client.ZCard(context.Background(), rs.metaKey("recentEvents")).Result(); n != 0 {
+		t.Errorf("expected the full sync to clear the dedupe window, got %d entries", n)
+	}
+}
+
+func TestRedisStorage_ApplyRules(t *testing.T) {
+	testApplyRules(t, func(t *testing.T) LocalStorage { return newTestRedisStorage(t) })
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"strings"
+
+	"github.com/melibackend/shared/models"
+)
+
+// StockPolicyFor returns the policy governing a cached product the way the
+// central API resolves it: the product's own, or else the policy of its
+// category or the nearest ancestor category with one. The category tree is
+// only read for products without a policy of their own.
+func StockPolicyFor(s LocalStorage, product *models.Product) models.StockPolicy {
+	if product.StockPolicy != nil {
+		return *product.StockPolicy
+	}
+	if product.Category == "" {
+		return models.StockPolicy{}
+	}
+
+	list, err := s.GetCategories()
+	if err != nil {
+		return models.StockPolicy{}
+	}
+	categories := make(map[string]models.Category, len(list))
+	for _, category := range list {
+		categories[category.ID] = category
+	}
+
+	// The depth bound guards against a cycle in the cached tree
+	categoryID := strings.ToLower(strings.TrimSpace(product.Category))
+	for depth := 0; categoryID != "" && depth <= len(categories); depth++ {
+		category, exists := categories[categoryID]
+		if !exists {
+			break
+		}
+		if category.StockPolicy != nil {
+			return *category.StockPolicy
+		}
+		categoryID = category.ParentID
+	}
+	return models.StockPolicy{}
+}
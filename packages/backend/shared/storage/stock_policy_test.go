@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/melibackend/shared/models"
+)
+
+func TestStockPolicyFor_ResolvesLikeCentral(t *testing.T) {
+	ms := NewMemoryStorage(t.TempDir())
+	backorders := &models.StockPolicy{AllowBackorder: true, MaxBackorder: 5}
+	err := ms.SyncCategories([]models.Category{
+		{ID: "phones", Name: "Phones", StockPolicy: backorders},
+		{ID: "smartphones", Name: "Smartphones", ParentID: "phones"},
+		{ID: "cases", Name: "Cases", ParentID: "phones", StockPolicy: &models.StockPolicy{}},
+		{ID: "loop-a", Name: "Loop A", ParentID: "loop-b"},
+		{ID: "loop-b", Name: "Loop B", ParentID: "loop-a"},
+	})
+	if err != nil {
+		t.Fatalf("SyncCategories failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		product models.Product
+		want    models.StockPolicy
+	}{
+		{"own policy wins", models.Product{Category: "phones", StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 2}}, models.StockPolicy{AllowBackorder: true, MaxBackorder: 2}},
+		{"category policy", models.Product{Category: "phones"}, *backorders},
+		{"nearest ancestor's policy", models.Product{Category: " Smartphones "}, *backorders},
+		{"empty category policy stops the walk", models.Product{Category: "cases"}, models.StockPolicy{}},
+		{"unknown category", models.Product{Category: "tablets"}, models.StockPolicy{}},
+		{"no category", models.Product{}, models.StockPolicy{}},
+		{"cycle in the tree", models.Product{Category: "loop-a"}, models.StockPolicy{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StockPolicyFor(ms, &tt.product); got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestStockPolicy_StockFloor(t *testing.T) {
+	if got := (models.StockPolicy{}).StockFloor(); got != 0 {
+		t.Errorf("Expected no backorders to floor at 0, got %d", got)
+	}
+	if got := (models.StockPolicy{AllowBackorder: true, MaxBackorder: 5}).StockFloor(); got != -5 {
+		t.Errorf("Expected a backorder limit of 5 to floor at -5, got %d", got)
+	}
+}
+
+func TestProductFromHash_RoundTripsStockPolicy(t *testing.T) {
+	product := models.Product{
+		ProductID:   "SKU-001",
+		Name:        "Phone",
+		Available:   -2,
+		Version:     3,
+		StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 5},
+	}
+
+	fields := map[string]string{}
+	flat := productFields(product)
+	for i := 0; i < len(flat); i += 2 {
+		fields[flat[i]] = flat[i+1]
+	}
+	got, err := productFromHash(fields)
+	if err != nil {
+		t.Fatalf("productFromHash failed: %v", err)
+	}
+	if !reflect.DeepEqual(got.StockPolicy, product.StockPolicy) {
+		t.Errorf("Expected policy %+v, got %+v", product.StockPolicy, got.StockPolicy)
+	}
+}
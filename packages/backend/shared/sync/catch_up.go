@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+)
+
+// maxCatchUpPause caps the wait between catch-up batches, so pacing slows the
+// backlog down without stalling it
+const maxCatchUpPause = 10 * time.Second
+
+// catchUpState tracks a backlog being drained. It is written by the polling
+// goroutine and read by status requests.
+type catchUpState struct {
+	mutex         sync.Mutex
+	active        bool
+	startedAt     time.Time
+	startOffset   int64
+	targetOffset  int64
+	appliedOffset int64
+	eventsApplied int64
+	lastPause     time.Duration
+
+	// The batch in flight, measured to pace the next one
+	batchStart  time.Time
+	batchCPU    time.Duration
+	batchCPUOK  bool
+	batchEvents int
+}
+
+// beginBatch notes when a poll started and the CPU time used so far
+func (s *catchUpState) beginBatch(now time.Time) {
+	cpu, ok := processCPUTime()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.batchStart = now
+	s.batchCPU = cpu
+	s.batchCPUOK = ok
+	s.batchEvents = 0
+}
+
+// isActive reports whether a backlog is being drained
+func (s *catchUpState) isActive() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.active
+}
+
+// observePoll starts, advances or finishes catch-up after a successful poll
+// from fromOffset. A page with more events behind it starts catch-up.
+func (m *EventSyncManager) observePoll(fromOffset int64, response *models.EventsResponse, now time.Time) {
+	s := &m.catchUp
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.batchEvents = len(response.Events)
+	applied := m.metrics.appliedOffset.Load()
+
+	if !s.active {
+		if !response.HasMore {
+			return
+		}
+		s.active = true
+		s.startedAt = now
+		s.startOffset = fromOffset
+		s.targetOffset = 0
+		s.eventsApplied = 0
+		s.lastPause = 0
+		slog.Info("Catching up on central events",
+			"from_offset", fromOffset,
+			"central_offset", response.CurrentOffset,
+			"max_events_per_second", m.catchUpMaxEventsPerSecond,
+			"max_cpu_percent", m.catchUpMaxCPUPercent)
+	}
+
+	s.appliedOffset = applied
+	s.eventsApplied += int64(len(response.Events))
+	if response.CurrentOffset > s.targetOffset {
+		s.targetOffset = response.CurrentOffset
+	}
+
+	if !response.HasMore {
+		s.active = false
+		slog.Info("Caught up on central events",
+			"events_applied", s.eventsApplied,
+			"offset", applied,
+			"duration", now.Sub(s.startedAt))
+	}
+}
+
+// catchUpPause is the wait before the next catch-up batch: long enough to keep
+// under catchUpMaxEventsPerSecond, and long enough for the process to average
+// catchUpMaxCPUPercent of its CPUs over the batch and the pause. The CPU the
+// store spends serving requests counts too, so a busy store catches up slower.
+func (m *EventSyncManager) catchUpPause(now time.Time) time.Duration {
+	s := &m.catchUp
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	elapsed := now.Sub(s.batchStart)
+	var pause time.Duration
+	if m.catchUpMaxEventsPerSecond > 0 {
+		pause = time.Duration(s.batchEvents)*time.Second/time.Duration(m.catchUpMaxEventsPerSecond) - elapsed
+	}
+	if m.catchUpMaxCPUPercent > 0 && s.batchCPUOK && elapsed > 0 {
+		if cpu, ok := processCPUTime(); ok {
+			used := float64(cpu-s.batchCPU) / (float64(elapsed) * float64(runtime.GOMAXPROCS(0)))
+			limit := float64(m.catchUpMaxCPUPercent) / 100
+			if used > limit {
+				pause = max(pause, time.Duration(float64(elapsed)*(used/limit-1)))
+			}
+		}
+	}
+	pause = min(max(pause, 0), maxCatchUpPause)
+	s.lastPause = pause
+	return pause
+}
+
+// drainBacklog keeps polling while the central API reports more events,
+// pacing the batches, instead of waiting a full interval per batch. It hands
+// back to the polling loop when caught up, on a failed poll, when the quota
+// runs low, or when the loop is stopping.
+func (m *EventSyncManager) drainBacklog(ctx context.Context) {
+	for m.catchUp.isActive() {
+		if pause := m.catchUpPause(time.Now()); pause > 0 {
+			timer := time.NewTimer(pause)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-m.stopChan:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+		if m.pollThrottled(time.Now()) {
+			return
+		}
+		if !m.poll(ctx) {
+			return
+		}
+		m.slowPollingIfQuotaLow(time.Now())
+	}
+}
+
+// catchUpStatus returns catch-up progress for the sync status, or nil when
+// polling keeps up
+func (m *EventSyncManager) catchUpStatus() *storage.CatchUpStatus {
+	s := &m.catchUp
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.active {
+		return nil
+	}
+
+	status := &storage.CatchUpStatus{
+		StartedAt:     s.startedAt,
+		StartOffset:   s.startOffset,
+		TargetOffset:  s.targetOffset,
+		AppliedOffset: s.appliedOffset,
+		EventsApplied: s.eventsApplied,
+		LastPause:     s.lastPause,
+	}
+	if total := s.targetOffset - s.startOffset; total > 0 {
+		done := min(max(s.appliedOffset-s.startOffset, 0), total)
+		status.ProgressPercent = float64(done*1000/total) / 10
+	}
+	return status
+}
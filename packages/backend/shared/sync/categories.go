@@ -0,0 +1,23 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/storage"
+)
+
+// syncCategories replaces the local category tree with the central one. Event
+// syncs call it after loading the catalog snapshot: category events from the
+// snapshot offset on then replay any change made in between.
+func syncCategories(ctx context.Context, inventoryClient *client.InventoryClient, localStorage storage.LocalStorage) (int, error) {
+	categories, err := inventoryClient.GetCategoriesCtx(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get categories from central API: %w", err)
+	}
+	if err := localStorage.SyncCategories(categories); err != nil {
+		return 0, fmt.Errorf("failed to sync categories to local storage: %w", err)
+	}
+	return len(categories), nil
+}
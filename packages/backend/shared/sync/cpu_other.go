@@ -0,0 +1,10 @@
+//go:build !unix
+
+package sync
+
+import "time"
+
+// processCPUTime is not available here, so catch-up is paced by rate only
+func processCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/melibackend/shared/client"
 	"github.com/melibackend/shared/models"
 	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // EventSyncManager handles event-driven synchronization between central API and local storage
@@ -24,10 +28,38 @@ type EventSyncManager struct {
 	status                  *storage.SyncStatus
 	statusMutex             sync.RWMutex
 
+	// The polling loop runs under its own context so Pause can stop it
+	loopMutex  sync.Mutex
+	loopCtx    context.Context
+	pollCancel context.CancelFunc
+	pollDone   chan struct{}
+
 	// Circuit breaker for fallback to full sync
-	consecutiveFailures    int
+	consecutiveFailures    atomic.Int64
 	maxConsecutiveFailures int
-	fallbackMode           bool
+	fallbackMode           atomic.Bool
+
+	// Offsets and throughput reported by Metrics
+	metrics syncMetrics
+
+	// Scheduled full resync
+	fullResyncInterval time.Duration
+	fullResyncDaily    bool
+	fullResyncAt       time.Time // Only the clock time is used
+	fullResyncJitter   time.Duration
+	writeQueue         *WriteQueue
+
+	// Told about every change applied to local storage
+	changeListener func(events []models.Event)
+
+	// Polling slows down while the central API quota runs low
+	rateLimitLowPercent int
+	pollNotBefore       time.Time
+
+	// Pacing while a backlog of events is drained
+	catchUpMaxEventsPerSecond int
+	catchUpMaxCPUPercent      int
+	catchUp                   catchUpState
 }
 
 // EventSyncConfig holds configuration for the event sync manager
@@ -36,11 +68,30 @@ type EventSyncConfig struct {
 	EventWaitTimeoutSeconds int
 	EventBatchLimit         int
 	MaxConsecutiveFailures  int
+
+	// Scheduled full resync: daily at FullResyncAt ("HH:MM", UTC) or,
+	// without it, every FullResyncInterval. Each run is delayed by a random
+	// amount up to FullResyncJitter so stores do not resync at the same moment.
+	// Both empty disables the schedule.
+	FullResyncInterval time.Duration
+	FullResyncAt       string
+	FullResyncJitter   time.Duration
+
+	// RateLimitLowPercent slows polling once the remaining central API quota
+	// drops below this share of the limit, spreading what is left until the
+	// quota resets. Zero disables it.
+	RateLimitLowPercent int
+
+	// While a backlog is drained, batches are polled back to back but paced to
+	// at most CatchUpMaxEventsPerSecond and to the process using at most
+	// CatchUpMaxCPUPercent of its CPUs. Zero disables either limit.
+	CatchUpMaxEventsPerSecond int
+	CatchUpMaxCPUPercent      int
 }
 
 // NewEventSyncManager creates a new event-driven sync manager
 func NewEventSyncManager(client *client.InventoryClient, localStorage storage.LocalStorage, config EventSyncConfig) *EventSyncManager {
-	return &EventSyncManager{
+	m := &EventSyncManager{
 		client:                  client,
 		localStorage:            localStorage,
 		syncIntervalSeconds:     config.SyncIntervalSeconds,
@@ -54,6 +105,44 @@ func NewEventSyncManager(client *client.InventoryClient, localStorage storage.Lo
 			ErrorMessage:    "",
 		},
 		maxConsecutiveFailures: config.MaxConsecutiveFailures,
+		fullResyncInterval:     config.FullResyncInterval,
+		fullResyncJitter:       config.FullResyncJitter,
+		rateLimitLowPercent:    config.RateLimitLowPercent,
+
+		catchUpMaxEventsPerSecond: config.CatchUpMaxEventsPerSecond,
+		catchUpMaxCPUPercent:      config.CatchUpMaxCPUPercent,
+	}
+
+	if config.FullResyncAt != "" {
+		at, err := time.Parse("15:04", config.FullResyncAt)
+		if err != nil {
+			slog.Warn("Invalid full resync time, expected HH:MM", "full_resync_at", config.FullResyncAt, "error", err)
+		} else {
+			m.fullResyncDaily = true
+			m.fullResyncAt = at
+		}
+	}
+
+	return m
+}
+
+// SetWriteQueue makes the scheduled full resync skip products with pending
+// offline writes, whose cached stock intentionally runs ahead of the central API
+func (m *EventSyncManager) SetWriteQueue(writeQueue *WriteQueue) {
+	m.writeQueue = writeQueue
+}
+
+// SetChangeListener registers fn to be called after local storage changed:
+// with the events of each applied batch, and with nil after a full sync may
+// have changed any product. Call it before Start.
+func (m *EventSyncManager) SetChangeListener(fn func(events []models.Event)) {
+	m.changeListener = fn
+}
+
+// notifyChange passes applied events, or nil for a full sync, to the listener
+func (m *EventSyncManager) notifyChange(events []models.Event) {
+	if m.changeListener != nil {
+		m.changeListener(events)
 	}
 }
 
@@ -77,7 +166,12 @@ func (m *EventSyncManager) Start(ctx context.Context) error {
 	}
 
 	// Start periodic event polling in background
-	go m.eventPollingLoop(ctx)
+	m.loopMutex.Lock()
+	m.loopCtx = ctx
+	if m.currentPause() == nil {
+		m.startPollingLocked()
+	}
+	m.loopMutex.Unlock()
 
 	return nil
 }
@@ -93,18 +187,29 @@ func (m *EventSyncManager) InitialSync(ctx context.Context) error {
 	m.syncMutex.Lock()
 	defer m.syncMutex.Unlock()
 
+	ctx, span := tracing.Tracer().Start(ctx, "sync.initial")
+	defer span.End()
+
 	slog.Info("Starting initial sync with event offset setup")
 	startTime := time.Now()
 
 	m.updateSyncStatus(true, false, 0, "", time.Time{})
 
-	// Get all products with metadata including current event offset
-	products, eventOffset, err := m.client.GetAllProductsWithMetadata()
+	// Load a snapshot of the catalog pinned to the event offset it was taken at,
+	// so polling from that offset catches every later change
+	products, eventOffset, err := m.client.GetCatalogSnapshotCtx(ctx, m.reportListProgress)
 	if err != nil {
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to get products from central API: %w", err)
 	}
 
+	span.SetAttributes(
+		attribute.Int("sync.product_count", len(products)),
+		attribute.Int64("sync.event_offset", eventOffset),
+	)
+
 	slog.Info("Retrieved products from central API",
 		"count", len(products),
 		"event_offset", eventOffset)
@@ -112,8 +217,18 @@ func (m *EventSyncManager) InitialSync(ctx context.Context) error {
 	// Sync all products to local storage
 	if err := m.localStorage.SyncAllProducts(products); err != nil {
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return fmt.Errorf("failed to sync products to local storage: %w", err)
 	}
+	categoryCount, err := syncCategories(ctx, m.client, m.localStorage)
+	if err != nil {
+		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	m.notifyChange(nil)
 
 	// Set the event offset as our starting point for future event polling
 	if err := m.localStorage.SetLastEventOffset(eventOffset); err != nil {
@@ -131,13 +246,17 @@ func (m *EventSyncManager) InitialSync(ctx context.Context) error {
 
 	slog.Info("Initial sync completed successfully",
 		"products_synced", len(products),
+		"categories_synced", categoryCount,
 		"event_offset", eventOffset,
 		"duration", duration,
 	)
 
+	m.metrics.appliedOffset.Store(eventOffset)
+	m.metrics.observeCentralOffset(eventOffset)
+
 	// Reset failure counters after successful sync
-	m.consecutiveFailures = 0
-	m.fallbackMode = false
+	m.consecutiveFailures.Store(0)
+	m.fallbackMode.Store(false)
 
 	return nil
 }
@@ -152,6 +271,16 @@ func (m *EventSyncManager) eventPollingLoop(ctx context.Context) {
 		"wait_timeout_seconds", m.eventWaitTimeoutSeconds,
 		"batch_limit", m.eventBatchLimit)
 
+	// The scheduled full resync runs on this goroutine so it never overlaps
+	// with applying events
+	var fullResyncTimer *time.Timer
+	var fullResyncC <-chan time.Time
+	if m.fullResyncEnabled() {
+		fullResyncTimer = time.NewTimer(m.scheduleFullResync(time.Now()))
+		defer fullResyncTimer.Stop()
+		fullResyncC = fullResyncTimer.C
+	}
+
 	tickCount := 0
 	for {
 		select {
@@ -161,35 +290,59 @@ func (m *EventSyncManager) eventPollingLoop(ctx context.Context) {
 		case <-m.stopChan:
 			slog.Info("Event polling loop stopped", "total_ticks", tickCount)
 			return
+		case <-fullResyncC:
+			m.runScheduledFullResync(ctx)
+			fullResyncTimer.Reset(m.scheduleFullResync(time.Now()))
 		case <-ticker.C:
 			tickCount++
 			slog.Debug("Ticker fired", "tick_count", tickCount)
+			if m.pollThrottled(time.Now()) {
+				continue
+			}
 
-			// Handle polling with error recovery (synchronously to avoid goroutine issues)
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						slog.Error("Panic in polling", "tick", tickCount, "panic", r)
-					}
-				}()
-
-				if err := m.pollForEvents(ctx); err != nil {
-					slog.Error("Polling failed", "tick", tickCount, "error", err)
-					m.handleSyncError(err)
-				} else {
-					slog.Debug("Polling completed successfully", "tick", tickCount)
-				}
-			}()
+			m.poll(ctx)
+			m.slowPollingIfQuotaLow(time.Now())
+			m.drainBacklog(ctx)
 		}
 	}
 }
 
+// poll polls once, recovering from panics and handling errors, and reports
+// whether the poll succeeded
+func (m *EventSyncManager) poll(ctx context.Context) (ok bool) {
+	// Handle polling with error recovery (synchronously to avoid goroutine issues)
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Panic in polling", "panic", r)
+			ok = false
+		}
+	}()
+
+	if err := m.pollForEvents(ctx); err != nil {
+		if ctx.Err() != nil {
+			// Paused or stopping; the poll was abandoned, not failed
+			return false
+		}
+		slog.Error("Polling failed", "error", err)
+		m.handleSyncError(ctx, err)
+		return false
+	}
+	slog.Debug("Polling completed successfully")
+	return true
+}
+
 // pollForEvents polls for new events and applies them
 func (m *EventSyncManager) pollForEvents(ctx context.Context) error {
+	ctx, span := tracing.Tracer().Start(ctx, "sync.poll_events")
+	defer span.End()
+
 	lastOffset, err := m.localStorage.GetLastEventOffset()
 	if err != nil {
 		return fmt.Errorf("failed to get last event offset: %w", err)
 	}
+	span.SetAttributes(attribute.Int64("sync.from_offset", lastOffset))
+	m.metrics.appliedOffset.Store(lastOffset)
+	m.catchUp.beginBatch(time.Now())
 
 	slog.Debug("Polling for events",
 		"from_offset", lastOffset,
@@ -197,19 +350,44 @@ func (m *EventSyncManager) pollForEvents(ctx context.Context) error {
 		"wait_timeout", m.eventWaitTimeoutSeconds)
 
 	// Get events from the central API
-	eventsResponse, err := m.client.GetEvents(lastOffset, m.eventBatchLimit, m.eventWaitTimeoutSeconds)
+	eventsResponse, err := m.client.GetEventsCtx(ctx, lastOffset, m.eventBatchLimit, m.eventWaitTimeoutSeconds)
 	if err != nil {
-		return m.handleEventError(err, lastOffset)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return m.handleEventError(ctx, err, lastOffset)
 	}
+	span.SetAttributes(attribute.Int("sync.event_count", len(eventsResponse.Events)))
+	m.metrics.observeCentralOffset(eventsResponse.CurrentOffset)
 
 	// Validate response
 	if err := m.validateEventsResponse(eventsResponse, lastOffset); err != nil {
 		return err
 	}
 
+	// A restore replaced the central inventory: apply the events before it,
+	// then resync fully and resume after the restore event
+	if i := restoreEventIndex(eventsResponse.Events); i >= 0 {
+		if i > 0 {
+			if err := m.applyEvents(eventsResponse.Events[:i]); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+				return fmt.Errorf("failed to apply events: %w", err)
+			}
+		}
+		restoreOffset := eventsResponse.Events[i].Offset
+		slog.Warn("Central inventory was restored", "restore_offset", restoreOffset)
+		if err := m.resyncFrom(ctx, "system_restored", restoreOffset+1); err != nil {
+			return err
+		}
+		m.consecutiveFailures.Store(0)
+		return nil
+	}
+
 	// Apply events if any
 	if len(eventsResponse.Events) > 0 {
 		if err := m.applyEvents(eventsResponse.Events); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
 			return fmt.Errorf("failed to apply events: %w", err)
 		}
 
@@ -217,35 +395,51 @@ func (m *EventSyncManager) pollForEvents(ctx context.Context) error {
 			"count", len(eventsResponse.Events),
 			"from_offset", lastOffset,
 			"to_offset", eventsResponse.NextOffset)
+
+		m.commitOffset(ctx, eventsResponse.Events[len(eventsResponse.Events)-1].Offset+1)
 	} else {
 		slog.Debug("No new events available")
 	}
 
+	// A filtered page skips events the store does not carry; move past them
+	// so the next poll does not scan them again
+	if eventsResponse.Filtered && eventsResponse.NextOffset > lastOffset &&
+		(len(eventsResponse.Events) == 0 || eventsResponse.NextOffset > eventsResponse.Events[len(eventsResponse.Events)-1].Offset+1) {
+		if err := m.localStorage.SetLastEventOffset(eventsResponse.NextOffset); err != nil {
+			return fmt.Errorf("failed to advance filtered event offset: %w", err)
+		}
+		m.metrics.appliedOffset.Store(eventsResponse.NextOffset)
+		m.commitOffset(ctx, eventsResponse.NextOffset)
+	}
+
+	m.observePoll(lastOffset, eventsResponse, time.Now())
+
 	// Reset failure counter on successful poll
-	m.consecutiveFailures = 0
-	if m.fallbackMode {
+	m.consecutiveFailures.Store(0)
+	if m.fallbackMode.CompareAndSwap(true, false) {
 		slog.Info("Exiting fallback mode after successful event sync")
-		m.fallbackMode = false
 	}
 
 	return nil
 }
 
-// applyEvents applies a batch of events to local storage
+// applyEvents applies a batch of events to local storage. The storage records
+// the applied offset in the same write as the product changes.
 func (m *EventSyncManager) applyEvents(events []models.Event) error {
 	if err := m.localStorage.ApplyEvents(events); err != nil {
 		return err
 	}
+	m.metrics.recordApplied(events[len(events)-1].Offset+1, len(events), time.Now())
+	m.notifyChange(events)
+	return nil
+}
 
-	// Update last processed offset
-	if len(events) > 0 {
-		lastEvent := events[len(events)-1]
-		if err := m.localStorage.SetLastEventOffset(lastEvent.Offset + 1); err != nil {
-			slog.Warn("Failed to update last event offset", "error", err, "offset", lastEvent.Offset+1)
-		}
+// commitOffset reports the applied offset to the central API so it can rotate
+// consumed events. Failures are only logged: the next poll commits implicitly.
+func (m *EventSyncManager) commitOffset(ctx context.Context, offset int64) {
+	if err := m.client.CommitEventOffsetCtx(ctx, offset); err != nil {
+		slog.Warn("Failed to commit event offset", "offset", offset, "error", err)
 	}
-
-	return nil
 }
 
 // validateEventsResponse validates the events response for consistency
@@ -256,6 +450,11 @@ func (m *EventSyncManager) validateEventsResponse(response *models.EventsRespons
 			response.NextOffset, expectedOffset)
 	}
 
+	// Filtered pages have gaps where events were left out by design
+	if response.Filtered {
+		return nil
+	}
+
 	// Check for large gaps (possible data loss)
 	if len(response.Events) > 0 {
 		firstEventOffset := response.Events[0].Offset
@@ -278,14 +477,23 @@ func (m *EventSyncManager) validateEventsResponse(response *models.EventsRespons
 }
 
 // handleEventError handles specific event-related errors
-func (m *EventSyncManager) handleEventError(err error, lastOffset int64) error {
+func (m *EventSyncManager) handleEventError(ctx context.Context, err error, lastOffset int64) error {
 	errorMsg := err.Error()
 
-	// Handle 410 Gone - offset not found
-	if contains(errorMsg, "410 Gone") || contains(errorMsg, "offset not found") {
-		slog.Warn("Offset not found, central system may have restarted",
-			"last_offset", lastOffset, "error", err)
-		return m.triggerFullSyncFallback("offset_not_found")
+	// Handle 410 Gone - the offset was rotated away or an operator asked for a
+	// replay; resync and resume from the snapshot offset
+	if apiErr, ok := client.AsAPIError(err); ok && apiErr.ErrorType == client.ErrorTypeOffsetGone {
+		if apiErr.Reason == client.ReasonReplayRequested {
+			slog.Warn("Central API requested a replay of this store",
+				"last_offset", lastOffset,
+				"snapshot_offset", apiErr.SnapshotOffset)
+			return m.resyncFrom(ctx, client.ReasonReplayRequested, apiErr.SnapshotOffset)
+		}
+		slog.Warn("Offset no longer available on central API",
+			"last_offset", lastOffset,
+			"oldest_available_offset", apiErr.OldestAvailableOffset,
+			"snapshot_offset", apiErr.SnapshotOffset)
+		return m.resyncFromSnapshot(ctx, apiErr.SnapshotOffset)
 	}
 
 	// Handle other specific errors that should trigger fallback
@@ -293,50 +501,87 @@ func (m *EventSyncManager) handleEventError(err error, lastOffset int64) error {
 		contains(errorMsg, "possible data loss") ||
 		contains(errorMsg, "event sequence gap") {
 		slog.Warn("Data consistency issue detected", "error", err)
-		return m.triggerFullSyncFallback("data_consistency_issue")
+		return m.triggerFullSyncFallback(ctx, "data_consistency_issue")
 	}
 
 	// For other errors, just return them to be handled by the general error handler
 	return err
 }
 
+// resyncFromSnapshot runs a full sync after a 410 offset_gone and makes sure
+// polling resumes no earlier than snapshotOffset. The snapshot offset was
+// taken before the products were fetched, so no event is skipped; the full
+// sync alone may leave the offset at 0 and hit 410 again on the next poll.
+func (m *EventSyncManager) resyncFromSnapshot(ctx context.Context, snapshotOffset int64) error {
+	return m.resyncFrom(ctx, "offset_gone", snapshotOffset)
+}
+
+// resyncFrom runs a full sync and makes sure polling resumes no earlier than
+// resumeOffset
+func (m *EventSyncManager) resyncFrom(ctx context.Context, reason string, resumeOffset int64) error {
+	if err := m.triggerFullSyncFallback(ctx, reason); err != nil {
+		return err
+	}
+
+	currentOffset, err := m.localStorage.GetLastEventOffset()
+	if err != nil {
+		return fmt.Errorf("failed to get last event offset: %w", err)
+	}
+	if currentOffset < resumeOffset {
+		if err := m.localStorage.SetLastEventOffset(resumeOffset); err != nil {
+			return fmt.Errorf("failed to set resume event offset: %w", err)
+		}
+		slog.Info("Resuming event sync after full resync", "reason", reason, "offset", resumeOffset)
+	}
+
+	return nil
+}
+
+// restoreEventIndex returns the index of the first system_restored event, or -1
+func restoreEventIndex(events []models.Event) int {
+	for i, event := range events {
+		if event.EventType == models.EventTypeSystemRestored {
+			return i
+		}
+	}
+	return -1
+}
+
 // triggerFullSyncFallback triggers a full sync as fallback
-func (m *EventSyncManager) triggerFullSyncFallback(reason string) error {
+func (m *EventSyncManager) triggerFullSyncFallback(ctx context.Context, reason string) error {
 	slog.Warn("Triggering full sync fallback", "reason", reason)
 
-	ctx := context.Background()
 	if err := m.InitialSync(ctx); err != nil {
 		return fmt.Errorf("fallback full sync failed: %w", err)
 	}
 
-	m.fallbackMode = true
+	m.fallbackMode.Store(true)
 
 	return nil
 }
 
 // handleSyncError handles general sync errors with circuit breaker logic
-func (m *EventSyncManager) handleSyncError(err error) {
-	m.consecutiveFailures++
+func (m *EventSyncManager) handleSyncError(ctx context.Context, err error) {
+	failures := m.consecutiveFailures.Add(1)
 	slog.Error("Event sync failed",
 		"error", err,
-		"consecutive_failures", m.consecutiveFailures,
+		"consecutive_failures", failures,
 		"max_failures", m.maxConsecutiveFailures)
 
 	// Update sync status
 	m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
 
 	// Check if we should enter fallback mode
-	if m.consecutiveFailures >= m.maxConsecutiveFailures && !m.fallbackMode {
+	if failures >= int64(m.maxConsecutiveFailures) && !m.fallbackMode.Load() {
 		slog.Warn("Too many consecutive failures, entering fallback mode",
-			"failures", m.consecutiveFailures)
+			"failures", failures)
 
 		// Try full sync as fallback in a separate goroutine to avoid blocking
 		go func() {
-			ctx := context.Background()
 			if fallbackErr := m.InitialSync(ctx); fallbackErr != nil {
 				slog.Error("Fallback full sync also failed", "error", fallbackErr)
 			} else {
-				m.fallbackMode = true
+				m.fallbackMode.Store(true)
 				slog.Info("Fallback full sync completed successfully")
 			}
 		}()
@@ -352,11 +597,24 @@ func (m *EventSyncManager) updateSyncStatus(inProgress, success bool, productCou
 	m.status.LastSyncSuccess = success
 	m.status.ProductCount = productCount
 	m.status.ErrorMessage = errorMessage
+	if !inProgress {
+		m.status.ProductsTotal = 0
+	}
 	if !syncTime.IsZero() {
 		m.status.LastSyncTime = syncTime
 	}
 }
 
+// reportListProgress publishes how far a full sync has paged through the catalog
+func (m *EventSyncManager) reportListProgress(fetched, total int) {
+	m.statusMutex.Lock()
+	m.status.ProductCount = fetched
+	m.status.ProductsTotal = total
+	m.statusMutex.Unlock()
+
+	slog.Debug("Fetched product page from central API", "fetched", fetched, "total", total)
+}
+
 // GetSyncStatus returns the current sync status
 func (m *EventSyncManager) GetSyncStatus() *storage.SyncStatus {
 	m.statusMutex.RLock()
@@ -364,12 +622,22 @@ func (m *EventSyncManager) GetSyncStatus() *storage.SyncStatus {
 
 	// Return a copy to avoid race conditions
 	status := *m.status
+	status.RateLimit = m.rateLimitQuota()
+	status.CentralMaintenance = m.centralMaintenance()
+	metrics := m.Metrics()
+	status.Metrics = &metrics
+	status.CatchUp = m.catchUpStatus()
 	return &status
 }
 
-// ForceSync forces an immediate full synchronization
-func (m *EventSyncManager) ForceSync(ctx context.Context) error {
-	slog.Info("Force sync requested")
+// ForceSync forces an immediate full synchronization. While paused it fails
+// with ErrSyncPaused unless overridePause is set; the forced sync then
+// refreshes the cache once and polling stays paused.
+func (m *EventSyncManager) ForceSync(ctx context.Context, overridePause bool) error {
+	slog.Info("Force sync requested", "override_pause", overridePause)
+	if !overridePause && m.currentPause() != nil {
+		return ErrSyncPaused
+	}
 	return m.InitialSync(ctx)
 }
 
@@ -0,0 +1,201 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// fullResyncEnabled reports whether a full resync schedule is configured
+func (m *EventSyncManager) fullResyncEnabled() bool {
+	return m.fullResyncDaily || m.fullResyncInterval > 0
+}
+
+// scheduleFullResync picks the delay until the next full resync, including
+// jitter, and publishes the planned time in the sync status. FullResyncAt is
+// a UTC time, so every store resyncs at the same moment whatever its zone.
+func (m *EventSyncManager) scheduleFullResync(now time.Time) time.Duration {
+	now = now.UTC()
+	delay := m.fullResyncInterval
+	if m.fullResyncDaily {
+		next := time.Date(now.Year(), now.Month(), now.Day(),
+			m.fullResyncAt.Hour(), m.fullResyncAt.Minute(), 0, 0, time.UTC)
+		if !next.After(now) {
+			next = next.AddDate(0, 0, 1)
+		}
+		delay = next.Sub(now)
+	}
+	if m.fullResyncJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(m.fullResyncJitter)))
+	}
+
+	nextRun := now.Add(delay)
+	m.statusMutex.Lock()
+	m.status.NextFullResync = &nextRun
+	m.statusMutex.Unlock()
+
+	slog.Info("Scheduled next full resync", "at", nextRun, "delay", delay)
+	return delay
+}
+
+// runScheduledFullResync runs a full resync and records the result in the sync status
+func (m *EventSyncManager) runScheduledFullResync(ctx context.Context) {
+	result := &storage.FullResyncResult{StartedAt: time.Now()}
+
+	m.statusMutex.Lock()
+	m.status.InProgress = true
+	m.statusMutex.Unlock()
+
+	err := m.fullResync(ctx, result)
+	result.Duration = time.Since(result.StartedAt)
+	result.Success = err == nil
+	if err != nil {
+		result.ErrorMessage = err.Error()
+		slog.Error("Scheduled full resync failed", "error", err, "duration", result.Duration)
+	} else if result.Differences > 0 {
+		slog.Warn("Scheduled full resync corrected drift from central state",
+			"differences", result.Differences,
+			"summary", result.Summary,
+			"product_count", result.ProductCount,
+			"duration", result.Duration)
+	} else {
+		slog.Info("Scheduled full resync found no drift",
+			"product_count", result.ProductCount,
+			"duration", result.Duration)
+	}
+
+	// A failed resync leaves the last sync outcome alone; the error is in LastFullResync
+	m.statusMutex.Lock()
+	m.status.InProgress = false
+	m.status.LastFullResync = result
+	m.statusMutex.Unlock()
+}
+
+// fullResync compares every cached product with the central listing and
+// replaces only the ones that differ. Unlike InitialSync it keeps the event
+// offset: products are never rolled back to an older version, and events
+// newer than the listing are still applied by the next poll.
+func (m *EventSyncManager) fullResync(ctx context.Context, result *storage.FullResyncResult) error {
+	m.syncMutex.Lock()
+	defer m.syncMutex.Unlock()
+
+	ctx, span := tracing.Tracer().Start(ctx, "sync.full_resync")
+	defer span.End()
+
+	slog.Info("Starting scheduled full resync")
+
+	centralProducts, err := m.client.ListAllProductsCtx(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to list products from central API: %w", err)
+	}
+	localProducts, err := m.localStorage.GetAllProducts()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to read local products: %w", err)
+	}
+	result.ProductCount = len(centralProducts)
+
+	pending := map[string]bool{}
+	if m.writeQueue != nil {
+		pending = m.writeQueue.PendingProductIDs()
+	}
+
+	central := make(map[string]*models.Product, len(centralProducts))
+	for i := range centralProducts {
+		central[centralProducts[i].ProductID] = &centralProducts[i]
+	}
+	local := make(map[string]*models.Product, len(localProducts))
+	for i := range localProducts {
+		local[localProducts[i].ProductID] = &localProducts[i]
+	}
+
+	result.Summary = map[string]int{}
+	resync := func(productID string) error {
+		if pending[productID] {
+			result.SkippedPending++
+			return nil
+		}
+		divergence := compareProduct(local[productID], central[productID])
+		if divergence == nil {
+			return nil
+		}
+		result.Summary[divergence.Kind]++
+		result.Differences++
+		return m.resyncProduct(divergence.Kind, productID, central[productID])
+	}
+
+	for productID := range local {
+		if err := resync(productID); err != nil {
+			return err
+		}
+	}
+	for productID := range central {
+		if _, ok := local[productID]; ok {
+			continue
+		}
+		if err := resync(productID); err != nil {
+			return err
+		}
+	}
+	if result.Differences > 0 {
+		m.notifyChange(nil)
+	}
+	if _, err := syncCategories(ctx, m.client, m.localStorage); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	syncTime := time.Now()
+	if err := m.localStorage.SetLastSyncTime(syncTime); err != nil {
+		slog.Warn("Failed to update last sync time", "error", err)
+	}
+	m.updateSyncStatus(false, true, len(centralProducts), "", syncTime)
+
+	span.SetAttributes(
+		attribute.Int("sync.product_count", len(centralProducts)),
+		attribute.Int("sync.differences", result.Differences),
+	)
+	return nil
+}
+
+// resyncProduct brings one diverged product in line with the central listing
+// unless a newer version reached the cache in the meantime
+func (m *EventSyncManager) resyncProduct(kind, productID string, central *models.Product) error {
+	current, err := m.localStorage.GetProduct(productID)
+	if errors.Is(err, storage.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read product %s: %w", productID, err)
+	}
+
+	if kind == DivergenceMissingCentrally {
+		if current == nil {
+			return nil
+		}
+		if err := m.localStorage.DeleteProduct(productID); err != nil {
+			return fmt.Errorf("failed to delete product %s: %w", productID, err)
+		}
+		return nil
+	}
+
+	if current != nil && current.Version > central.Version {
+		return nil
+	}
+	if err := m.localStorage.UpsertProduct(*central); err != nil {
+		return fmt.Errorf("failed to update product %s: %w", productID, err)
+	}
+	return nil
+}
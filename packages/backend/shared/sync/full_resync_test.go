@@ -0,0 +1,113 @@
+package sync
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+)
+
+func TestFullResync_ReplacesOnlyDivergedProducts(t *testing.T) {
+	centralProducts, localProducts := divergedCatalog()
+	centralProducts = append(centralProducts, product("SKU-F", 5, 4))
+	localProducts = append(localProducts, product("SKU-F", 8, 2)) // An event newer than the listing
+	central, c := newFakeCentral(t, centralProducts...)
+	central.categories = []models.Category{{ID: "phones", Name: "Phones", UpdatedAt: "2026-01-01T00:00:00Z"}}
+	localStorage := newTestStorage(t, localProducts...)
+	if err := localStorage.SetLastEventOffset(77); err != nil {
+		t.Fatalf("SetLastEventOffset failed: %v", err)
+	}
+
+	m := NewEventSyncManager(c, localStorage, EventSyncConfig{})
+	result := &storage.FullResyncResult{}
+	if err := m.fullResync(context.Background(), result); err != nil {
+		t.Fatalf("fullResync failed: %v", err)
+	}
+
+	wantSummary := map[string]int{
+		DivergenceStaleVersion:     2,
+		DivergenceQuantityMismatch: 1,
+		DivergenceMissingCentrally: 1,
+		DivergenceMissingLocally:   1,
+	}
+	if result.ProductCount != 5 || result.Differences != 5 || !reflect.DeepEqual(result.Summary, wantSummary) {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	want := map[string]string{"SKU-A": "2/10", "SKU-B": "3/5", "SKU-C": "1/7", "SKU-E": "4/1", "SKU-F": "8/2"}
+	if got := cachedStock(t, localStorage); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if offset, _ := localStorage.GetLastEventOffset(); offset != 77 {
+		t.Errorf("expected the event offset to be kept, got %d", offset)
+	}
+	if categories, _ := localStorage.GetCategories(); !reflect.DeepEqual(categories, central.categories) {
+		t.Errorf("expected the categories to be synced, got %+v", categories)
+	}
+	if status := m.GetSyncStatus(); !status.LastSyncSuccess || status.ProductCount != 5 {
+		t.Errorf("expected a successful sync in the status, got %+v", status)
+	}
+}
+
+func TestFullResync_NoDrift(t *testing.T) {
+	_, c := newFakeCentral(t, product("SKU-A", 2, 10))
+	localStorage := newTestStorage(t, product("SKU-A", 2, 10))
+
+	changes := 0
+	m := NewEventSyncManager(c, localStorage, EventSyncConfig{})
+	m.SetChangeListener(func([]models.Event) { changes++ })
+	result := &storage.FullResyncResult{}
+	if err := m.fullResync(context.Background(), result); err != nil {
+		t.Fatalf("fullResync failed: %v", err)
+	}
+
+	if result.Differences != 0 || len(result.Summary) != 0 {
+		t.Errorf("expected no differences, got %+v", result)
+	}
+	if changes != 0 {
+		t.Errorf("expected no change notification, got %d", changes)
+	}
+}
+
+func TestScheduleFullResync(t *testing.T) {
+	now := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		config   EventSyncConfig
+		min, max time.Duration
+	}{
+		{"interval", EventSyncConfig{FullResyncInterval: 6 * time.Hour}, 6 * time.Hour, 6 * time.Hour},
+		{"daily later today", EventSyncConfig{FullResyncAt: "03:30"}, 90 * time.Minute, 90 * time.Minute},
+		{"daily tomorrow", EventSyncConfig{FullResyncAt: "01:00"}, 23 * time.Hour, 23 * time.Hour},
+		{"daily at the scheduled minute", EventSyncConfig{FullResyncAt: "02:00"}, 24 * time.Hour, 24 * time.Hour},
+		{"daily wins over interval", EventSyncConfig{FullResyncAt: "03:30", FullResyncInterval: time.Hour}, 90 * time.Minute, 90 * time.Minute},
+		{"jitter", EventSyncConfig{FullResyncAt: "03:30", FullResyncJitter: 10 * time.Minute}, 90 * time.Minute, 100*time.Minute - 1},
+	}
+	c := client.NewInventoryClient("http://central.invalid", "key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewEventSyncManager(c, nil, tt.config)
+			delay := m.scheduleFullResync(now)
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("expected a delay in [%v, %v], got %v", tt.min, tt.max, delay)
+			}
+			if next := m.GetSyncStatus().NextFullResync; next == nil || !next.Equal(now.Add(delay)) {
+				t.Errorf("expected the next run in the status, got %v", next)
+			}
+		})
+	}
+
+	if m := NewEventSyncManager(c, nil, EventSyncConfig{FullResyncAt: "25:00"}); m.fullResyncEnabled() {
+		t.Error("expected an invalid time to leave the schedule disabled")
+	}
+
+	// 02:00 at UTC+5 is 21:00 UTC the day before
+	local := time.Date(2026, 3, 1, 2, 0, 0, 0, time.FixedZone("UTC+5", 5*60*60))
+	m := NewEventSyncManager(c, nil, EventSyncConfig{FullResyncAt: "03:30"})
+	if delay := m.scheduleFullResync(local); delay != 6*time.Hour+30*time.Minute {
+		t.Errorf("expected FULL_RESYNC_AT to be read as UTC, got a delay of %v", delay)
+	}
+}
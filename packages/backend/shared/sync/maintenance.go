@@ -0,0 +1,16 @@
+package sync
+
+import "github.com/melibackend/shared/storage"
+
+// centralMaintenance returns the central API's maintenance mode for the sync
+// status, or nil while the central API accepts writes
+func (m *EventSyncManager) centralMaintenance() *storage.CentralMaintenance {
+	maintenance := m.client.MaintenanceStatus()
+	if !maintenance.Active {
+		return nil
+	}
+	return &storage.CentralMaintenance{
+		Mode:  maintenance.Mode,
+		Since: maintenance.Since,
+	}
+}
@@ -16,7 +16,12 @@ type SyncManager interface {
 	Start(ctx context.Context) error
 	Stop()
 	InitialSync(ctx context.Context) error
-	ForceSync(ctx context.Context) error
+	// ForceSync runs a full sync now; while paused it fails with
+	// ErrSyncPaused unless overridePause is set
+	ForceSync(ctx context.Context, overridePause bool) error
+	// Pause stops synchronization until Resume, keeping the local cache as is
+	Pause(reason string) (*storage.SyncPause, error)
+	Resume() error
 	GetSyncStatus() *storage.SyncStatus
 	UpdateLocalProduct(productID string, available int, version int, lastUpdated time.Time) error
 }
@@ -86,7 +91,7 @@ func (m *Manager) InitialSync(ctx context.Context) error {
 
 	// Get all products from central API
 	m.logger.Info("Attempting to get products from central API")
-	products, err := m.client.GetAllProducts()
+	products, err := m.client.GetAllProductsCtx(ctx)
 	if err != nil {
 		m.logger.Error("Failed to get products from central API", "error", err)
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
@@ -108,6 +113,10 @@ func (m *Manager) InitialSync(ctx context.Context) error {
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
 		return fmt.Errorf("failed to sync products to local storage: %w", err)
 	}
+	if _, err := syncCategories(ctx, m.client, m.localStorage); err != nil {
+		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
+		return err
+	}
 
 	// Update sync time
 	syncTime := time.Now()
@@ -140,8 +149,11 @@ func (m *Manager) IncrementalSync(ctx context.Context) error {
 }
 
 // ForceSync forces an immediate full synchronization
-func (m *Manager) ForceSync(ctx context.Context) error {
-	m.logger.Info("Force sync requested")
+func (m *Manager) ForceSync(ctx context.Context, overridePause bool) error {
+	m.logger.Info("Force sync requested", "override_pause", overridePause)
+	if !overridePause && m.paused() {
+		return ErrSyncPaused
+	}
 	return m.InitialSync(ctx)
 }
 
@@ -180,6 +192,9 @@ func (m *Manager) periodicSync(ctx context.Context) {
 			m.logger.Info("Periodic sync stopped")
 			return
 		case <-ticker.C:
+			if m.paused() {
+				continue
+			}
 			if err := m.IncrementalSync(ctx); err != nil {
 				m.logger.Error("Periodic sync failed", "error", err)
 			}
@@ -192,7 +207,7 @@ func (m *Manager) performFullSync(ctx context.Context, startTime time.Time) erro
 	m.updateSyncStatus(true, false, 0, "", time.Time{})
 
 	// Get all products from central API
-	products, err := m.client.GetAllProducts()
+	products, err := m.client.GetAllProductsCtx(ctx)
 	if err != nil {
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
 		return fmt.Errorf("failed to get products from central API: %w", err)
@@ -203,6 +218,10 @@ func (m *Manager) performFullSync(ctx context.Context, startTime time.Time) erro
 		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
 		return fmt.Errorf("failed to sync products to local storage: %w", err)
 	}
+	if _, err := syncCategories(ctx, m.client, m.localStorage); err != nil {
+		m.updateSyncStatus(false, false, 0, err.Error(), time.Time{})
+		return err
+	}
 
 	// Update sync time
 	syncTime := time.Now()
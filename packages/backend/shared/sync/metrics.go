@@ -0,0 +1,166 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/melibackend/shared/storage"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// eventRateWindow is the span events applied per second is averaged over
+const eventRateWindow = 60
+
+// syncMetrics tracks the event sync position and throughput. Offsets are
+// stored as the sync goroutine moves them, so status reads never wait on it.
+type syncMetrics struct {
+	appliedOffset atomic.Int64
+	centralOffset atomic.Int64
+	eventsApplied atomic.Int64
+
+	// Events applied per second over the last minute, bucketed by Unix second
+	rateMutex sync.Mutex
+	counts    [eventRateWindow]int64
+	seconds   [eventRateWindow]int64
+}
+
+// recordApplied moves the applied offset after a batch and counts its events
+func (s *syncMetrics) recordApplied(nextOffset int64, count int, now time.Time) {
+	s.appliedOffset.Store(nextOffset)
+	s.observeCentralOffset(nextOffset)
+	s.eventsApplied.Add(int64(count))
+
+	s.rateMutex.Lock()
+	defer s.rateMutex.Unlock()
+	second := now.Unix()
+	i := second % eventRateWindow
+	if s.seconds[i] != second {
+		s.seconds[i] = second
+		s.counts[i] = 0
+	}
+	s.counts[i] += int64(count)
+}
+
+// observeCentralOffset keeps the highest central offset seen; 0 means the
+// central API did not report one
+func (s *syncMetrics) observeCentralOffset(offset int64) {
+	for {
+		current := s.centralOffset.Load()
+		if offset <= current || s.centralOffset.CompareAndSwap(current, offset) {
+			return
+		}
+	}
+}
+
+// eventsPerSecond averages the events applied over the last minute
+func (s *syncMetrics) eventsPerSecond(now time.Time) float64 {
+	s.rateMutex.Lock()
+	defer s.rateMutex.Unlock()
+	second := now.Unix()
+	var total int64
+	for i := range s.counts {
+		if second-s.seconds[i] < eventRateWindow {
+			total += s.counts[i]
+		}
+	}
+	return float64(total) / eventRateWindow
+}
+
+// Metrics returns how far the local cache trails the central event stream
+func (m *EventSyncManager) Metrics() storage.SyncMetrics {
+	applied := m.metrics.appliedOffset.Load()
+	central := m.metrics.centralOffset.Load()
+	lag := central - applied
+	if central == 0 || lag < 0 {
+		lag = 0
+	}
+	transfer := m.client.EventTransferStats()
+	return storage.SyncMetrics{
+		LastAppliedOffset:   applied,
+		CentralOffset:       central,
+		OffsetLag:           lag,
+		EventsApplied:       m.metrics.eventsApplied.Load(),
+		EventsPerSecond:     m.metrics.eventsPerSecond(time.Now()),
+		FallbackMode:        m.fallbackMode.Load(),
+		ConsecutiveFailures: m.consecutiveFailures.Load(),
+		EventBytesReceived:  transfer.WireBytes,
+		EventBytesDecoded:   transfer.DecodedBytes,
+	}
+}
+
+// RegisterMetrics exports the sync metrics as OpenTelemetry instruments on
+// meter, read from Metrics at each collection
+func (m *EventSyncManager) RegisterMetrics(meter metric.Meter) error {
+	appliedOffset, err := meter.Int64ObservableGauge("store_sync_last_applied_offset",
+		metric.WithDescription("Next central event offset the local cache will apply"))
+	if err != nil {
+		return fmt.Errorf("failed to create applied offset gauge: %w", err)
+	}
+	centralOffset, err := meter.Int64ObservableGauge("store_sync_central_offset",
+		metric.WithDescription("Next offset the central API will assign, as last reported"))
+	if err != nil {
+		return fmt.Errorf("failed to create central offset gauge: %w", err)
+	}
+	offsetLag, err := meter.Int64ObservableGauge("store_sync_offset_lag",
+		metric.WithDescription("Central events not yet applied to the local cache"))
+	if err != nil {
+		return fmt.Errorf("failed to create offset lag gauge: %w", err)
+	}
+	eventsApplied, err := meter.Int64ObservableCounter("store_sync_events_applied_total",
+		metric.WithDescription("Central events applied to the local cache"))
+	if err != nil {
+		return fmt.Errorf("failed to create events applied counter: %w", err)
+	}
+	eventsPerSecond, err := meter.Float64ObservableGauge("store_sync_events_per_second",
+		metric.WithDescription("Events applied per second over the last minute"))
+	if err != nil {
+		return fmt.Errorf("failed to create events rate gauge: %w", err)
+	}
+	fallbackMode, err := meter.Int64ObservableGauge("store_sync_fallback_mode",
+		metric.WithDescription("1 while event sync runs in full sync fallback mode"))
+	if err != nil {
+		return fmt.Errorf("failed to create fallback mode gauge: %w", err)
+	}
+	failures, err := meter.Int64ObservableGauge("store_sync_consecutive_failures",
+		metric.WithDescription("Event polls that failed in a row"))
+	if err != nil {
+		return fmt.Errorf("failed to create consecutive failures gauge: %w", err)
+	}
+	bytesReceived, err := meter.Int64ObservableCounter("store_sync_event_bytes_received_total",
+		metric.WithDescription("Event page bytes received from the central API, as sent over the wire"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return fmt.Errorf("failed to create event bytes received counter: %w", err)
+	}
+	bytesDecoded, err := meter.Int64ObservableCounter("store_sync_event_bytes_decoded_total",
+		metric.WithDescription("Event page bytes received from the central API, after decompression"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return fmt.Errorf("failed to create event bytes decoded counter: %w", err)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		metrics := m.Metrics()
+		observer.ObserveInt64(appliedOffset, metrics.LastAppliedOffset)
+		observer.ObserveInt64(centralOffset, metrics.CentralOffset)
+		observer.ObserveInt64(offsetLag, metrics.OffsetLag)
+		observer.ObserveInt64(eventsApplied, metrics.EventsApplied)
+		observer.ObserveFloat64(eventsPerSecond, metrics.EventsPerSecond)
+		if metrics.FallbackMode {
+			observer.ObserveInt64(fallbackMode, 1)
+		} else {
+			observer.ObserveInt64(fallbackMode, 0)
+		}
+		observer.ObserveInt64(failures, metrics.ConsecutiveFailures)
+		observer.ObserveInt64(bytesReceived, metrics.EventBytesReceived)
+		observer.ObserveInt64(bytesDecoded, metrics.EventBytesDecoded)
+		return nil
+	}, appliedOffset, centralOffset, offsetLag, eventsApplied, eventsPerSecond, fallbackMode, failures, bytesReceived, bytesDecoded)
+	if err != nil {
+		return fmt.Errorf("failed to register sync metrics callback: %w", err)
+	}
+	return nil
+}
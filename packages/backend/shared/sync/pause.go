@@ -0,0 +1,131 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/melibackend/shared/storage"
+)
+
+// ErrSyncPaused is returned when pausing a paused sync, or forcing a sync
+// while paused without overriding the pause
+var ErrSyncPaused = errors.New("sync is paused")
+
+// ErrSyncNotPaused is returned when resuming a sync that is not paused
+var ErrSyncNotPaused = errors.New("sync is not paused")
+
+// Pause stops event polling, and with it the scheduled full resync, so the
+// local cache stays as it is while it is investigated. An in-flight poll is
+// abandoned and Pause returns once the polling loop has exited, so no event
+// is applied after it. The pause lasts until Resume or a restart.
+func (m *EventSyncManager) Pause(reason string) (*storage.SyncPause, error) {
+	m.loopMutex.Lock()
+	defer m.loopMutex.Unlock()
+
+	if pause := m.currentPause(); pause != nil {
+		return pause, ErrSyncPaused
+	}
+
+	m.stopPollingLocked()
+
+	pause := &storage.SyncPause{Since: time.Now(), Reason: reason}
+	m.statusMutex.Lock()
+	m.status.Paused = pause
+	m.statusMutex.Unlock()
+
+	offset, _ := m.localStorage.GetLastEventOffset()
+	slog.Warn("Event sync paused", "reason", reason, "event_offset", offset)
+	return pause, nil
+}
+
+// Resume restarts event polling from the offset the cache stopped at
+func (m *EventSyncManager) Resume() error {
+	m.loopMutex.Lock()
+	defer m.loopMutex.Unlock()
+
+	pause := m.currentPause()
+	if pause == nil {
+		return ErrSyncNotPaused
+	}
+
+	m.statusMutex.Lock()
+	m.status.Paused = nil
+	m.statusMutex.Unlock()
+
+	m.startPollingLocked()
+
+	slog.Info("Event sync resumed", "paused_for", time.Since(pause.Since).Round(time.Second))
+	return nil
+}
+
+// currentPause returns the active pause, or nil while syncing
+func (m *EventSyncManager) currentPause() *storage.SyncPause {
+	m.statusMutex.RLock()
+	defer m.statusMutex.RUnlock()
+	return m.status.Paused
+}
+
+// startPollingLocked starts the polling loop under its own context, so a pause
+// can stop it without stopping the manager. Before Start there is nothing to
+// start. The caller holds loopMutex.
+func (m *EventSyncManager) startPollingLocked() {
+	if m.loopCtx == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(m.loopCtx)
+	done := make(chan struct{})
+	m.pollCancel = cancel
+	m.pollDone = done
+
+	go func() {
+		defer close(done)
+		m.eventPollingLoop(ctx)
+	}()
+}
+
+// stopPollingLocked stops the polling loop and waits for it to exit; the
+// caller holds loopMutex
+func (m *EventSyncManager) stopPollingLocked() {
+	if m.pollCancel == nil {
+		return
+	}
+	m.pollCancel()
+	<-m.pollDone
+	m.pollCancel = nil
+	m.pollDone = nil
+}
+
+// Pause stops the periodic sync until Resume
+func (m *Manager) Pause(reason string) (*storage.SyncPause, error) {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+
+	if m.status.Paused != nil {
+		return m.status.Paused, ErrSyncPaused
+	}
+	m.status.Paused = &storage.SyncPause{Since: time.Now(), Reason: reason}
+	m.logger.Warn("Periodic sync paused", "reason", reason)
+	return m.status.Paused, nil
+}
+
+// Resume restarts the periodic sync
+func (m *Manager) Resume() error {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+
+	if m.status.Paused == nil {
+		return ErrSyncNotPaused
+	}
+	m.status.Paused = nil
+	m.logger.Info("Periodic sync resumed")
+	return nil
+}
+
+// paused reports whether the periodic sync is paused
+func (m *Manager) paused() bool {
+	m.statusMutex.RLock()
+	defer m.statusMutex.RUnlock()
+	return m.status.Paused != nil
+}
@@ -0,0 +1,63 @@
+package sync
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/melibackend/shared/storage"
+)
+
+// pollThrottled reports whether a tick is skipped because polling was slowed
+// to spare the central API quota
+func (m *EventSyncManager) pollThrottled(now time.Time) bool {
+	m.statusMutex.RLock()
+	defer m.statusMutex.RUnlock()
+	return now.Before(m.pollNotBefore)
+}
+
+// slowPollingIfQuotaLow spreads the remaining quota over the rest of the rate
+// limit window once it drops below rateLimitLowPercent, so the store never
+// polls itself into a 429
+func (m *EventSyncManager) slowPollingIfQuotaLow(now time.Time) {
+	if m.rateLimitLowPercent <= 0 {
+		return
+	}
+	quota := m.client.RateLimitStatus()
+	if !quota.Known || quota.RemainingFraction()*100 >= float64(m.rateLimitLowPercent) || !quota.Reset.After(now) {
+		return
+	}
+
+	interval := quota.Reset.Sub(now) / time.Duration(quota.Remaining+1)
+	if minInterval := time.Duration(m.syncIntervalSeconds) * time.Second; interval <= minInterval {
+		return
+	}
+
+	m.statusMutex.Lock()
+	m.pollNotBefore = now.Add(interval)
+	m.statusMutex.Unlock()
+
+	slog.Warn("Central API quota running low, slowing event polling",
+		"remaining", quota.Remaining,
+		"limit", quota.Limit,
+		"reset", quota.Reset,
+		"next_poll_in", interval)
+}
+
+// rateLimitQuota returns the quota for the sync status, or nil when unknown.
+// The caller holds statusMutex.
+func (m *EventSyncManager) rateLimitQuota() *storage.RateLimitQuota {
+	quota := m.client.RateLimitStatus()
+	if !quota.Known {
+		return nil
+	}
+	result := &storage.RateLimitQuota{
+		Limit:     quota.Limit,
+		Remaining: quota.Remaining,
+		Reset:     quota.Reset,
+	}
+	if time.Now().Before(m.pollNotBefore) {
+		slowedUntil := m.pollNotBefore
+		result.PollingSlowedUntil = &slowedUntil
+	}
+	return result
+}
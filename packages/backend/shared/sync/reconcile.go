@@ -0,0 +1,432 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Divergence kinds reported by the reconciler
+const (
+	// DivergenceMissingLocally is a central product absent from the local cache (full mode only)
+	DivergenceMissingLocally = "missing_locally"
+	// DivergenceMissingCentrally is a cached product the central API no longer knows
+	DivergenceMissingCentrally = "missing_centrally"
+	// DivergenceStaleVersion is a cached product whose version differs from the central one
+	DivergenceStaleVersion = "stale_version"
+	// DivergenceQuantityMismatch is a cached product with the central version but other stock
+	DivergenceQuantityMismatch = "quantity_mismatch"
+)
+
+// Reconcile modes
+const (
+	ReconcileModeFull   = "full"
+	ReconcileModeSample = "sample"
+)
+
+// maxReportedDivergences bounds the divergences listed in a report; the
+// summary still counts all of them
+const maxReportedDivergences = 500
+
+// ErrReconcileInProgress is returned when a verification is already running
+var ErrReconcileInProgress = errors.New("reconciliation already in progress")
+
+// errCacheAdvanced means events updated the cache past the central snapshot
+// used for healing
+var errCacheAdvanced = errors.New("cached product is newer than central state")
+
+// Divergence describes one product whose cached state differs from the central API
+type Divergence struct {
+	ProductID        string `json:"productId"`
+	Kind             string `json:"kind"`
+	LocalVersion     int    `json:"localVersion"`
+	CentralVersion   int    `json:"centralVersion"`
+	LocalAvailable   int    `json:"localAvailable"`
+	CentralAvailable int    `json:"centralAvailable"`
+	Healed           bool   `json:"healed"`
+}
+
+// ReconcileOptions selects how a verification compares the cache.
+// A SampleSize of 0 compares every product.
+type ReconcileOptions struct {
+	SampleSize int  `json:"sampleSize"`
+	AutoHeal   bool `json:"autoHeal"`
+}
+
+// ReconcileReport is the divergence report of one verification run
+type ReconcileReport struct {
+	Mode            string         `json:"mode"`
+	StartedAt       time.Time      `json:"startedAt"`
+	Duration        time.Duration  `json:"duration"`
+	LocalProducts   int            `json:"localProducts"`
+	CentralProducts int            `json:"centralProducts,omitempty"` // Full mode only
+	CheckedProducts int            `json:"checkedProducts"`
+	SkippedPending  int            `json:"skippedPending"` // Products with offline writes awaiting replay
+	AutoHeal        bool           `json:"autoHeal"`
+	HealedCount     int            `json:"healedCount"`
+	Summary         map[string]int `json:"summary"`
+	Divergences     []Divergence   `json:"divergences"`
+	Truncated       bool           `json:"truncated"`
+}
+
+// ReconcilerConfig holds configuration for the periodic reconciliation job.
+// An Interval of 0 disables the job; verifications can still run on demand.
+type ReconcilerConfig struct {
+	Interval   time.Duration
+	SampleSize int
+	AutoHeal   bool
+}
+
+// Reconciler compares the local product cache against the central API and
+// reports missing products, stale versions and quantity mismatches. With
+// auto-heal the cached product is replaced by the central one, but only if
+// the cache did not change while the comparison ran.
+type Reconciler struct {
+	client       *client.InventoryClient
+	localStorage storage.LocalStorage
+	writeQueue   *WriteQueue
+	config       ReconcilerConfig
+	runMutex     sync.Mutex
+	reportMutex  sync.RWMutex
+	lastReport   *ReconcileReport
+	stopChan     chan struct{}
+}
+
+// NewReconciler creates a reconciler for the given local storage
+func NewReconciler(inventoryClient *client.InventoryClient, localStorage storage.LocalStorage, config ReconcilerConfig) *Reconciler {
+	return &Reconciler{
+		client:       inventoryClient,
+		localStorage: localStorage,
+		config:       config,
+		stopChan:     make(chan struct{}),
+	}
+}
+
+// SetWriteQueue excludes products with pending offline writes, whose cached
+// stock intentionally runs ahead of the central API
+func (r *Reconciler) SetWriteQueue(writeQueue *WriteQueue) {
+	r.writeQueue = writeQueue
+}
+
+// Start begins periodic verification in the background when an interval is configured
+func (r *Reconciler) Start(ctx context.Context) {
+	if r.config.Interval <= 0 {
+		return
+	}
+	go r.reconcileLoop(ctx)
+}
+
+// Stop stops the periodic verification loop
+func (r *Reconciler) Stop() {
+	close(r.stopChan)
+}
+
+// LastReport returns the report of the most recent verification, or nil
+func (r *Reconciler) LastReport() *ReconcileReport {
+	r.reportMutex.RLock()
+	defer r.reportMutex.RUnlock()
+	return r.lastReport
+}
+
+// reconcileLoop runs scheduled verifications until stopped
+func (r *Reconciler) reconcileLoop(ctx context.Context) {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+
+	slog.Info("Reconciliation loop started",
+		"interval", r.config.Interval,
+		"sample_size", r.config.SampleSize,
+		"auto_heal", r.config.AutoHeal)
+
+	options := ReconcileOptions{SampleSize: r.config.SampleSize, AutoHeal: r.config.AutoHeal}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.stopChan:
+			slog.Info("Reconciliation loop stopped")
+			return
+		case <-ticker.C:
+			if _, err := r.Verify(ctx, options); err != nil && !errors.Is(err, ErrReconcileInProgress) {
+				slog.Error("Scheduled reconciliation failed", "error", err)
+			}
+		}
+	}
+}
+
+// Verify compares the local cache against the central API and returns the
+// divergence report. Only one verification runs at a time.
+func (r *Reconciler) Verify(ctx context.Context, options ReconcileOptions) (*ReconcileReport, error) {
+	if !r.runMutex.TryLock() {
+		return nil, ErrReconcileInProgress
+	}
+	defer r.runMutex.Unlock()
+
+	ctx, span := tracing.Tracer().Start(ctx, "sync.reconcile")
+	defer span.End()
+
+	report := &ReconcileReport{
+		Mode:        ReconcileModeFull,
+		StartedAt:   time.Now(),
+		AutoHeal:    options.AutoHeal,
+		Summary:     map[string]int{},
+		Divergences: []Divergence{},
+	}
+	if options.SampleSize > 0 {
+		report.Mode = ReconcileModeSample
+	}
+
+	localProducts, err := r.localStorage.GetAllProducts()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, fmt.Errorf("failed to read local products: %w", err)
+	}
+	report.LocalProducts = len(localProducts)
+
+	pending := map[string]bool{}
+	if r.writeQueue != nil {
+		pending = r.writeQueue.PendingProductIDs()
+	}
+
+	if report.Mode == ReconcileModeFull {
+		err = r.compareFull(ctx, report, localProducts, pending)
+	} else {
+		err = r.compareSample(ctx, report, localProducts, pending, options.SampleSize)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	sort.Slice(report.Divergences, func(i, j int) bool {
+		return report.Divergences[i].ProductID < report.Divergences[j].ProductID
+	})
+	if options.AutoHeal {
+		for i := range report.Divergences {
+			if r.heal(ctx, &report.Divergences[i]) {
+				report.HealedCount++
+			}
+		}
+	}
+	if len(report.Divergences) > maxReportedDivergences {
+		report.Divergences = report.Divergences[:maxReportedDivergences]
+		report.Truncated = true
+	}
+	report.Duration = time.Since(report.StartedAt)
+
+	span.SetAttributes(
+		attribute.String("reconcile.mode", report.Mode),
+		attribute.Int("reconcile.checked", report.CheckedProducts),
+		attribute.Int("reconcile.divergences", divergenceCount(report)),
+		attribute.Int("reconcile.healed", report.HealedCount),
+	)
+
+	logArgs := []any{
+		"mode", report.Mode,
+		"checked", report.CheckedProducts,
+		"divergences", divergenceCount(report),
+		"healed", report.HealedCount,
+		"duration", report.Duration,
+	}
+	if divergenceCount(report) > 0 {
+		slog.Warn("Reconciliation found divergences from central state", append(logArgs, "summary", report.Summary)...)
+	} else {
+		slog.Info("Reconciliation found no divergences", logArgs...)
+	}
+
+	r.reportMutex.Lock()
+	r.lastReport = report
+	r.reportMutex.Unlock()
+
+	return report, nil
+}
+
+// compareFull compares every cached product against the full central listing
+func (r *Reconciler) compareFull(ctx context.Context, report *ReconcileReport, localProducts []models.Product, pending map[string]bool) error {
+	centralProducts, err := r.client.ListAllProductsCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list products from central API: %w", err)
+	}
+	report.CentralProducts = len(centralProducts)
+
+	central := make(map[string]*models.Product, len(centralProducts))
+	for i := range centralProducts {
+		central[centralProducts[i].ProductID] = &centralProducts[i]
+	}
+
+	seen := make(map[string]bool, len(localProducts))
+	for i := range localProducts {
+		local := &localProducts[i]
+		seen[local.ProductID] = true
+		if pending[local.ProductID] {
+			report.SkippedPending++
+			continue
+		}
+		report.CheckedProducts++
+		recordDivergence(report, compareProduct(local, central[local.ProductID]))
+	}
+
+	for _, product := range central {
+		if seen[product.ProductID] {
+			continue
+		}
+		report.CheckedProducts++
+		recordDivergence(report, compareProduct(nil, product))
+	}
+	return nil
+}
+
+// compareSample compares a random sample of cached products one by one.
+// Products missing locally cannot be detected from a sample.
+func (r *Reconciler) compareSample(ctx context.Context, report *ReconcileReport, localProducts []models.Product, pending map[string]bool, sampleSize int) error {
+	candidates := make([]*models.Product, 0, len(localProducts))
+	for i := range localProducts {
+		if pending[localProducts[i].ProductID] {
+			report.SkippedPending++
+			continue
+		}
+		candidates = append(candidates, &localProducts[i])
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if len(candidates) > sampleSize {
+		candidates = candidates[:sampleSize]
+	}
+
+	for _, local := range candidates {
+		product, err := r.client.GetProductCtx(ctx, local.ProductID)
+		if err != nil && !client.IsErrorType(err, client.ErrorTypeProductNotFound) {
+			return fmt.Errorf("failed to get product %s from central API: %w", local.ProductID, err)
+		}
+		report.CheckedProducts++
+		recordDivergence(report, compareProduct(local, product))
+	}
+	return nil
+}
+
+// compareProduct returns the divergence between a cached and a central
+// product, either of which may be nil, or nil when they agree
+func compareProduct(local, central *models.Product) *Divergence {
+	divergence := &Divergence{}
+	switch {
+	case local == nil:
+		divergence.Kind = DivergenceMissingLocally
+	case central == nil:
+		divergence.Kind = DivergenceMissingCentrally
+	case local.Version != central.Version:
+		divergence.Kind = DivergenceStaleVersion
+	case local.Available != central.Available:
+		divergence.Kind = DivergenceQuantityMismatch
+	default:
+		return nil
+	}
+
+	if local != nil {
+		divergence.ProductID = local.ProductID
+		divergence.LocalVersion = local.Version
+		divergence.LocalAvailable = local.Available
+	}
+	if central != nil {
+		divergence.ProductID = central.ProductID
+		divergence.CentralVersion = central.Version
+		divergence.CentralAvailable = central.Available
+	}
+	return divergence
+}
+
+// recordDivergence adds a divergence to the report and its summary
+func recordDivergence(report *ReconcileReport, divergence *Divergence) {
+	if divergence == nil {
+		return
+	}
+	report.Summary[divergence.Kind]++
+	report.Divergences = append(report.Divergences, *divergence)
+}
+
+// divergenceCount returns the number of divergences found, including any
+// dropped from a truncated list
+func divergenceCount(report *ReconcileReport) int {
+	total := 0
+	for _, count := range report.Summary {
+		total += count
+	}
+	return total
+}
+
+// heal replaces the cached product with the central state. The cache is
+// re-read first and left alone if events changed it since the comparison.
+func (r *Reconciler) heal(ctx context.Context, divergence *Divergence) bool {
+	current, err := r.localStorage.GetProduct(divergence.ProductID)
+	if errors.Is(err, storage.ErrNotFound) {
+		current = nil
+	} else if err != nil {
+		slog.Warn("Failed to read cached product before healing",
+			"product_id", divergence.ProductID,
+			"error", err)
+		return false
+	}
+
+	switch divergence.Kind {
+	case DivergenceMissingLocally:
+		if current != nil {
+			return false
+		}
+	default:
+		if current == nil || current.Version != divergence.LocalVersion || current.Available != divergence.LocalAvailable {
+			return false
+		}
+	}
+
+	if divergence.Kind == DivergenceMissingCentrally {
+		err = r.localStorage.DeleteProduct(divergence.ProductID)
+	} else {
+		err = r.healFromCentral(ctx, divergence.ProductID)
+	}
+	if errors.Is(err, errCacheAdvanced) {
+		return false
+	}
+	if err != nil {
+		slog.Warn("Failed to heal diverged product",
+			"product_id", divergence.ProductID,
+			"kind", divergence.Kind,
+			"error", err)
+		return false
+	}
+
+	divergence.Healed = true
+	slog.Info("Healed diverged product from central state",
+		"product_id", divergence.ProductID,
+		"kind", divergence.Kind,
+		"local_version", divergence.LocalVersion,
+		"central_version", divergence.CentralVersion)
+	return true
+}
+
+// healFromCentral fetches the product again and stores it unless the cache
+// already holds a newer version
+func (r *Reconciler) healFromCentral(ctx context.Context, productID string) error {
+	product, err := r.client.GetProductCtx(ctx, productID)
+	if err != nil {
+		return err
+	}
+	if current, err := r.localStorage.GetProduct(productID); err == nil && current.Version > product.Version {
+		return errCacheAdvanced
+	}
+	return r.localStorage.UpsertProduct(*product)
+}
@@ -0,0 +1,280 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+)
+
+// fakeCentral serves the product and category reads of the central API
+type fakeCentral struct {
+	mu         sync.Mutex
+	products   map[string]models.Product
+	categories []models.Category
+	// onGet runs before a single product read is answered
+	onGet func(productID string)
+}
+
+func newFakeCentral(t *testing.T, products ...models.Product) (*fakeCentral, *client.InventoryClient) {
+	t.Helper()
+
+	central := &fakeCentral{products: map[string]models.Product{}}
+	for _, product := range products {
+		central.products[product.ProductID] = product
+	}
+	server := httptest.NewServer(central)
+	t.Cleanup(server.Close)
+
+	c := client.NewInventoryClient(server.URL, "key")
+	c.SetRetryPolicy(client.RetryOptions{MaxAttempts: 1})
+	return central, c
+}
+
+func (f *fakeCentral) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.URL.Path == "/v1/categories":
+		json.NewEncoder(w).Encode(models.CategoryListResponse{Categories: f.categories})
+	case r.URL.Path == "/v1/inventory":
+		// One JSON page; the client falls back to paging
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		products := make([]models.Product, 0, len(f.products))
+		for _, product := range f.products {
+			products = append(products, product)
+		}
+		sort.Slice(products, func(i, j int) bool { return products[i].ProductID < products[j].ProductID })
+		json.NewEncoder(w).Encode(map[string]any{
+			"products":   products[min(offset, len(products)):],
+			"pagination": map[string]any{"total_count": len(products), "has_more": false},
+		})
+	case strings.HasPrefix(r.URL.Path, "/v1/inventory/"):
+		productID := strings.TrimPrefix(r.URL.Path, "/v1/inventory/")
+		if f.onGet != nil {
+			f.onGet(productID)
+		}
+		product, ok := f.products[productID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"code": client.ErrorTypeProductNotFound, "message": "product not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(product)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestStorage returns an initialized memory storage caching products
+func newTestStorage(t *testing.T, products ...models.Product) storage.LocalStorage {
+	t.Helper()
+
+	localStorage := storage.NewMemoryStorage(t.TempDir())
+	if err := localStorage.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if err := localStorage.SyncAllProducts(products); err != nil {
+		t.Fatalf("SyncAllProducts failed: %v", err)
+	}
+	return localStorage
+}
+
+func product(productID string, version, available int) models.Product {
+	return models.Product{ProductID: productID, Name: productID, Version: version, Available: available}
+}
+
+// cachedStock returns "version/available" of every cached product
+func cachedStock(t *testing.T, localStorage storage.LocalStorage) map[string]string {
+	t.Helper()
+
+	products, err := localStorage.GetAllProducts()
+	if err != nil {
+		t.Fatalf("GetAllProducts failed: %v", err)
+	}
+	stock := make(map[string]string, len(products))
+	for _, p := range products {
+		stock[p.ProductID] = strconv.Itoa(p.Version) + "/" + strconv.Itoa(p.Available)
+	}
+	return stock
+}
+
+// divergedCatalog returns a central catalog and a cache differing from it in
+// every way the reconciler reports
+func divergedCatalog() (central, local []models.Product) {
+	central = []models.Product{
+		product("SKU-A", 2, 10), // Agrees
+		product("SKU-B", 3, 5),  // Stale locally
+		product("SKU-C", 1, 7),  // Other stock locally
+		product("SKU-E", 4, 1),  // Missing locally
+	}
+	local = []models.Product{
+		product("SKU-A", 2, 10),
+		product("SKU-B", 2, 5),
+		product("SKU-C", 1, 6),
+		product("SKU-D", 1, 3), // Missing centrally
+	}
+	return central, local
+}
+
+func TestReconciler_ReportsEachDivergenceKind(t *testing.T) {
+	centralProducts, localProducts := divergedCatalog()
+	_, c := newFakeCentral(t, centralProducts...)
+	localStorage := newTestStorage(t, localProducts...)
+	reconciler := NewReconciler(c, localStorage, ReconcilerConfig{})
+
+	report, err := reconciler.Verify(context.Background(), ReconcileOptions{})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	if report.Mode != ReconcileModeFull || report.CheckedProducts != 5 || report.CentralProducts != 4 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	wantSummary := map[string]int{
+		DivergenceStaleVersion:     1,
+		DivergenceQuantityMismatch: 1,
+		DivergenceMissingCentrally: 1,
+		DivergenceMissingLocally:   1,
+	}
+	if !reflect.DeepEqual(report.Summary, wantSummary) {
+		t.Errorf("expected summary %v, got %v", wantSummary, report.Summary)
+	}
+	wantDivergences := []Divergence{
+		{ProductID: "SKU-B", Kind: DivergenceStaleVersion, LocalVersion: 2, CentralVersion: 3, LocalAvailable: 5, CentralAvailable: 5},
+		{ProductID: "SKU-C", Kind: DivergenceQuantityMismatch, LocalVersion: 1, CentralVersion: 1, LocalAvailable: 6, CentralAvailable: 7},
+		{ProductID: "SKU-D", Kind: DivergenceMissingCentrally, LocalVersion: 1, LocalAvailable: 3},
+		{ProductID: "SKU-E", Kind: DivergenceMissingLocally, CentralVersion: 4, CentralAvailable: 1},
+	}
+	if !reflect.DeepEqual(report.Divergences, wantDivergences) {
+		t.Errorf("expected divergences %+v, got %+v", wantDivergences, report.Divergences)
+	}
+	if report.HealedCount != 0 || len(cachedStock(t, localStorage)) != 4 {
+		t.Error("expected a verification without auto-heal to leave the cache alone")
+	}
+	if reconciler.LastReport() != report {
+		t.Error("expected the report to be kept as the last report")
+	}
+}
+
+func TestReconciler_AutoHealMatchesCentral(t *testing.T) {
+	centralProducts, localProducts := divergedCatalog()
+	_, c := newFakeCentral(t, centralProducts...)
+	localStorage := newTestStorage(t, localProducts...)
+
+	report, err := NewReconciler(c, localStorage, ReconcilerConfig{}).Verify(context.Background(), ReconcileOptions{AutoHeal: true})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	if report.HealedCount != 4 {
+		t.Errorf("expected 4 healed products, got %d", report.HealedCount)
+	}
+	for _, divergence := range report.Divergences {
+		if !divergence.Healed {
+			t.Errorf("expected %s to be healed", divergence.ProductID)
+		}
+	}
+	want := map[string]string{"SKU-A": "2/10", "SKU-B": "3/5", "SKU-C": "1/7", "SKU-E": "4/1"}
+	if got := cachedStock(t, localStorage); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the cache to match central, got %v", got)
+	}
+}
+
+func TestReconciler_AutoHealKeepsNewerCache(t *testing.T) {
+	centralProducts, localProducts := divergedCatalog()
+	central, c := newFakeCentral(t, centralProducts...)
+	localStorage := newTestStorage(t, localProducts...)
+
+	// An event reaches the cache while SKU-B is fetched for healing
+	central.onGet = func(productID string) {
+		if productID == "SKU-B" {
+			if err := localStorage.UpsertProduct(product("SKU-B", 9, 2)); err != nil {
+				t.Errorf("UpsertProduct failed: %v", err)
+			}
+		}
+	}
+
+	report, err := NewReconciler(c, localStorage, ReconcilerConfig{}).Verify(context.Background(), ReconcileOptions{AutoHeal: true})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	if report.HealedCount != 3 || report.Divergences[0].Healed {
+		t.Errorf("expected every product but SKU-B healed, got %+v", report.Divergences)
+	}
+	if got := cachedStock(t, localStorage)["SKU-B"]; got != "9/2" {
+		t.Errorf("expected the newer cached SKU-B to be kept, got %s", got)
+	}
+}
+
+func TestReconciler_SampleReadsProductsOneByOne(t *testing.T) {
+	centralProducts, localProducts := divergedCatalog()
+	central, c := newFakeCentral(t, centralProducts...)
+	localStorage := newTestStorage(t, localProducts...)
+
+	var fetched []string
+	central.onGet = func(productID string) { fetched = append(fetched, productID) }
+
+	report, err := NewReconciler(c, localStorage, ReconcilerConfig{}).Verify(context.Background(), ReconcileOptions{SampleSize: 10})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+
+	if report.Mode != ReconcileModeSample || report.CheckedProducts != 4 || len(fetched) != 4 {
+		t.Errorf("expected every cached product sampled, got %+v after %v", report, fetched)
+	}
+	// A sample cannot see products missing locally
+	wantSummary := map[string]int{
+		DivergenceStaleVersion:     1,
+		DivergenceQuantityMismatch: 1,
+		DivergenceMissingCentrally: 1,
+	}
+	if !reflect.DeepEqual(report.Summary, wantSummary) {
+		t.Errorf("expected summary %v, got %v", wantSummary, report.Summary)
+	}
+}
+
+func TestReconciler_OneVerificationAtATime(t *testing.T) {
+	central, c := newFakeCentral(t, product("SKU-A", 1, 1))
+	localStorage := newTestStorage(t, product("SKU-A", 1, 1))
+	reconciler := NewReconciler(c, localStorage, ReconcilerConfig{})
+
+	started, release := make(chan struct{}), make(chan struct{})
+	central.onGet = func(string) {
+		close(started)
+		<-release
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := reconciler.Verify(context.Background(), ReconcileOptions{SampleSize: 1})
+		done <- err
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("verification did not start")
+	}
+	if _, err := reconciler.Verify(context.Background(), ReconcileOptions{}); !errors.Is(err, ErrReconcileInProgress) {
+		t.Errorf("expected ErrReconcileInProgress, got %v", err)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Verify failed: %v", err)
+	}
+}
@@ -0,0 +1,418 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+	"github.com/melibackend/shared/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRecordedConflicts bounds the conflict history kept for reporting
+const maxRecordedConflicts = 100
+
+// ErrInsufficientLocalStock is returned when an offline sale would drive the
+// locally cached stock below zero, or past the backorder limit of a product
+// whose stock policy allows backorders
+var ErrInsufficientLocalStock = errors.New("insufficient inventory in local cache")
+
+// PendingWrite is an update accepted while the central API was unreachable
+type PendingWrite struct {
+	Update    models.UpdateRequest `json:"update"`
+	QueuedAt  time.Time            `json:"queuedAt"`
+	Attempts  int                  `json:"attempts"`
+	LastError string               `json:"lastError,omitempty"`
+}
+
+// WriteConflict records a queued write the central API refused on replay
+type WriteConflict struct {
+	Update          models.UpdateRequest `json:"update"`
+	QueuedAt        time.Time            `json:"queuedAt"`
+	ReportedAt      time.Time            `json:"reportedAt"`
+	Outcome         string               `json:"outcome"`
+	ErrorMessage    string               `json:"errorMessage"`
+	CentralVersion  int                  `json:"centralVersion"`
+	CentralQuantity int                  `json:"centralQuantity"`
+}
+
+// WriteQueueStatus is a snapshot of the offline write queue
+type WriteQueueStatus struct {
+	Pending         []PendingWrite  `json:"pending"`
+	PendingCount    int             `json:"pendingCount"`
+	ReplayedCount   int64           `json:"replayedCount"`
+	ConflictCount   int64           `json:"conflictCount"`
+	LastReplayAt    time.Time       `json:"lastReplayAt,omitempty"`
+	RecentConflicts []WriteConflict `json:"recentConflicts"`
+}
+
+// WriteQueue buffers store updates while the central API is down. Accepted
+// writes are applied to local storage optimistically, journaled to disk and
+// replayed in order with their original idempotency keys once the central
+// API is reachable again.
+type WriteQueue struct {
+	mu             sync.Mutex
+	replayMutex    sync.Mutex
+	client         *client.InventoryClient
+	localStorage   storage.LocalStorage
+	journalFile    string
+	replayInterval time.Duration
+	retryOptions   client.RetryOptions
+	pending        []PendingWrite
+	conflicts      []WriteConflict
+	replayedCount  int64
+	conflictCount  int64
+	lastReplayAt   time.Time
+	stopChan       chan struct{}
+}
+
+// NewWriteQueue creates a write queue journaled under dataDir
+func NewWriteQueue(inventoryClient *client.InventoryClient, localStorage storage.LocalStorage, dataDir string, replayInterval time.Duration) *WriteQueue {
+	if replayInterval <= 0 {
+		replayInterval = 10 * time.Second
+	}
+
+	return &WriteQueue{
+		client:         inventoryClient,
+		localStorage:   localStorage,
+		journalFile:    filepath.Join(dataDir, "pending_writes.json"),
+		replayInterval: replayInterval,
+		retryOptions:   client.DefaultRetryOptions(),
+		pending:        []PendingWrite{},
+		conflicts:      []WriteConflict{},
+		stopChan:       make(chan struct{}),
+	}
+}
+
+// Start loads the journal and begins replaying pending writes in the background
+func (q *WriteQueue) Start(ctx context.Context) error {
+	q.mu.Lock()
+	err := q.loadJournal()
+	pendingCount := len(q.pending)
+	q.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if pendingCount > 0 {
+		slog.Info("Loaded pending offline writes from journal",
+			"pending_count", pendingCount,
+			"journal_file", q.journalFile)
+	}
+
+	go q.replayLoop(ctx)
+	return nil
+}
+
+// Stop stops the background replay loop
+func (q *WriteQueue) Stop() {
+	slog.Info("Stopping offline write queue")
+	close(q.stopChan)
+}
+
+// Enqueue accepts an update for later delivery. The delta is applied to the
+// local cache immediately so reads reflect the sale; the version is left
+// untouched because only the central API assigns versions.
+func (q *WriteQueue) Enqueue(update models.UpdateRequest) (*models.Product, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	product, err := q.localStorage.GetProduct(update.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	newAvailable := product.Available + update.Delta
+	if update.Delta < 0 && newAvailable < 0 && newAvailable < storage.StockPolicyFor(q.localStorage, product).StockFloor() {
+		return product, ErrInsufficientLocalStock
+	}
+
+	q.pending = append(q.pending, PendingWrite{
+		Update:   update,
+		QueuedAt: time.Now(),
+	})
+	if err := q.saveJournal(); err != nil {
+		q.pending = q.pending[:len(q.pending)-1]
+		return nil, err
+	}
+
+	if err := q.localStorage.UpdateProduct(update.ProductID, newAvailable, product.Version, time.Now()); err != nil {
+		slog.Warn("Failed to apply offline write to local cache",
+			"product_id", update.ProductID,
+			"error", err)
+	}
+	product.Available = newAvailable
+
+	slog.Info("Queued inventory update for replay",
+		"product_id", update.ProductID,
+		"delta", update.Delta,
+		"idempotency_key", update.IdempotencyKey,
+		"pending_count", len(q.pending))
+
+	return product, nil
+}
+
+// PendingCount returns the number of writes waiting for replay
+func (q *WriteQueue) PendingCount() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// PendingProductIDs returns the products with writes waiting for replay; their
+// cached stock includes optimistic deltas the central API has not seen yet
+func (q *WriteQueue) PendingProductIDs() map[string]bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ids := make(map[string]bool, len(q.pending))
+	for _, entry := range q.pending {
+		ids[entry.Update.ProductID] = true
+	}
+	return ids
+}
+
+// Status returns a snapshot of pending writes and recent conflicts
+func (q *WriteQueue) Status() *WriteQueueStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	status := &WriteQueueStatus{
+		Pending:         append([]PendingWrite{}, q.pending...),
+		PendingCount:    len(q.pending),
+		ReplayedCount:   q.replayedCount,
+		ConflictCount:   q.conflictCount,
+		LastReplayAt:    q.lastReplayAt,
+		RecentConflicts: append([]WriteConflict{}, q.conflicts...),
+	}
+	return status
+}
+
+// Replay sends pending writes to the central API in order. It stops at the
+// first write that fails for connectivity reasons so ordering is preserved;
+// writes the central API rejects are dropped and reported as conflicts.
+func (q *WriteQueue) Replay(ctx context.Context) error {
+	q.replayMutex.Lock()
+	defer q.replayMutex.Unlock()
+
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return nil
+		}
+		entry := q.pending[0]
+		q.mu.Unlock()
+
+		// Conflicts are resolved by the retry helper, which re-reads the product
+		// and retries with a fresh version; the first attempt keeps the original key.
+		// Replays use the sync lane so they yield to live checkouts under load.
+		replayCtx, span := tracing.Tracer().Start(client.WithUpdatePriority(ctx, client.UpdatePrioritySync), "sync.replay_update",
+			trace.WithAttributes(
+				attribute.String("product.id", entry.Update.ProductID),
+				attribute.Int("replay.attempts", entry.Attempts),
+			))
+		outcome := q.client.UpdateInventoryWithRetryCtx(replayCtx, entry.Update, q.retryOptions)
+		span.SetAttributes(attribute.String("replay.outcome", outcome.Status))
+		span.End()
+
+		// A cancelled replay or an unreachable central API leaves the write
+		// queued; only the central API's answer takes it off the queue
+		if outcome.Status == client.OutcomeFailed && (ctx.Err() != nil || isConnectivityError(outcome.Err)) {
+			q.mu.Lock()
+			if len(q.pending) > 0 {
+				q.pending[0].Attempts++
+				q.pending[0].LastError = outcome.Err.Error()
+				q.saveJournal()
+			}
+			q.mu.Unlock()
+			return outcome.Err
+		}
+
+		q.mu.Lock()
+		q.pending = q.pending[1:]
+		q.lastReplayAt = time.Now()
+		if outcome.Status == client.OutcomeApplied {
+			q.replayedCount++
+			slog.Info("Replayed offline inventory update",
+				"product_id", entry.Update.ProductID,
+				"idempotency_key", entry.Update.IdempotencyKey,
+				"new_version", outcome.FinalVersion,
+				"attempts", outcome.Attempts)
+		} else {
+			q.recordConflict(entry, outcome)
+		}
+		if err := q.saveJournal(); err != nil {
+			slog.Error("Failed to persist offline write journal", "error", err)
+		}
+		q.reconcileLocal(entry.Update, outcome)
+		q.mu.Unlock()
+	}
+}
+
+// reconcileLocal brings the cached product in line with the central API's
+// answer, keeping the optimistic deltas of writes still waiting in the queue
+// (caller must hold the lock)
+func (q *WriteQueue) reconcileLocal(update models.UpdateRequest, outcome *client.UpdateOutcome) {
+	product, err := q.localStorage.GetProduct(update.ProductID)
+	if err != nil {
+		return
+	}
+
+	available, version := product.Available, product.Version
+	if outcome.FinalVersion > 0 {
+		// An event applied while the write was in flight is newer than the
+		// answer and already includes the write
+		if product.Version > outcome.FinalVersion {
+			return
+		}
+		available, version = outcome.Available, outcome.FinalVersion
+		for _, entry := range q.pending {
+			if entry.Update.ProductID == update.ProductID {
+				available += entry.Update.Delta
+			}
+		}
+	} else if outcome.Status != client.OutcomeApplied {
+		// Undo the optimistic delta of the rejected write
+		available -= update.Delta
+	}
+	if available < 0 {
+		available = 0
+	}
+
+	if err := q.localStorage.UpdateProduct(update.ProductID, available, version, time.Now()); err != nil {
+		slog.Warn("Failed to refresh local cache after replay",
+			"product_id", update.ProductID,
+			"error", err)
+	}
+}
+
+// replayLoop periodically replays pending writes
+func (q *WriteQueue) replayLoop(ctx context.Context) {
+	ticker := time.NewTicker(q.replayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.stopChan:
+			return
+		case <-ticker.C:
+			if q.PendingCount() == 0 {
+				continue
+			}
+			if err := q.Replay(ctx); err != nil {
+				slog.Debug("Offline write replay deferred, central API still unavailable", "error", err)
+			}
+		}
+	}
+}
+
+// recordConflict stores a rejected write for reporting (caller must hold the lock)
+func (q *WriteQueue) recordConflict(entry PendingWrite, outcome *client.UpdateOutcome) {
+	conflict := WriteConflict{
+		Update:          entry.Update,
+		QueuedAt:        entry.QueuedAt,
+		ReportedAt:      time.Now(),
+		Outcome:         outcome.Status,
+		CentralVersion:  outcome.FinalVersion,
+		CentralQuantity: outcome.Available,
+	}
+	if outcome.Err != nil {
+		conflict.ErrorMessage = outcome.Err.Error()
+	}
+	if apiErr, ok := client.AsAPIError(outcome.Err); ok && conflict.CentralVersion == 0 {
+		conflict.CentralVersion = apiErr.NewVersion
+		conflict.CentralQuantity = apiErr.NewQuantity
+	}
+
+	q.conflictCount++
+	q.conflicts = append(q.conflicts, conflict)
+	if len(q.conflicts) > maxRecordedConflicts {
+		q.conflicts = q.conflicts[len(q.conflicts)-maxRecordedConflicts:]
+	}
+
+	slog.Warn("Offline inventory update rejected on replay",
+		"product_id", entry.Update.ProductID,
+		"delta", entry.Update.Delta,
+		"idempotency_key", entry.Update.IdempotencyKey,
+		"outcome", outcome.Status,
+		"error", conflict.ErrorMessage)
+}
+
+// loadJournal reads pending writes from disk (caller must hold the lock)
+func (q *WriteQueue) loadJournal() error {
+	data, err := os.ReadFile(q.journalFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read write journal: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &q.pending); err != nil {
+		return fmt.Errorf("failed to unmarshal write journal: %w", err)
+	}
+	return nil
+}
+
+// saveJournal writes pending writes to disk atomically (caller must hold the lock)
+func (q *WriteQueue) saveJournal() error {
+	data, err := json.MarshalIndent(q.pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal write journal: %w", err)
+	}
+
+	tmpFile := q.journalFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal file: %w", err)
+	}
+	return os.Rename(tmpFile, q.journalFile)
+}
+
+// isConnectivityError reports whether err means the central API could not be
+// reached, as opposed to the central API rejecting the write: a network or
+// transport failure, the open circuit breaker, a gateway or unavailable
+// answer (502, 503, 504) or load shedding (429). Anything else, including
+// errors this package does not recognise, is treated as a rejection.
+func isConnectivityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, client.ErrCircuitOpen) {
+		return true
+	}
+	if apiErr, ok := client.AsAPIError(err); ok {
+		switch apiErr.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusTooManyRequests:
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// IsOfflineError reports whether a failed central API call should fall back
+// to the offline write queue
+func IsOfflineError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return isConnectivityError(err)
+}
@@ -0,0 +1,323 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+	"github.com/melibackend/shared/storage"
+)
+
+// fakeUpdates answers the central API's update endpoint with answer, called
+// once per update in the order they arrive
+type fakeUpdates struct {
+	mu       sync.Mutex
+	received []models.UpdateRequest
+	answer   func(update models.UpdateRequest) (int, any)
+}
+
+func (f *fakeUpdates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/inventory/updates" {
+		http.NotFound(w, r)
+		return
+	}
+	var update models.UpdateRequest
+	json.NewDecoder(r.Body).Decode(&update)
+	f.mu.Lock()
+	f.received = append(f.received, update)
+	f.mu.Unlock()
+
+	status, body := f.answer(update)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// applied is the central API's answer to an applied update
+func applied(update models.UpdateRequest, quantity, version int) (int, any) {
+	return http.StatusOK, models.UpdateResponse{
+		ProductID: update.ProductID, NewQuantity: quantity, NewVersion: version,
+		Delta: update.Delta, IdempotencyKey: update.IdempotencyKey, Applied: true,
+	}
+}
+
+// refused is the central API's answer to an update it did not apply
+func refused(update models.UpdateRequest, status int, errorType string, quantity, version int) (int, any) {
+	return status, map[string]any{
+		"productId": update.ProductID, "applied": false, "errorType": errorType,
+		"errorMessage": errorType, "newQuantity": quantity, "newVersion": version,
+	}
+}
+
+// newReplayQueue returns a queue over a local cache holding products, which
+// replays to a fake central API answering with answer
+func newReplayQueue(t *testing.T, products []models.Product, answer func(models.UpdateRequest) (int, any)) (*WriteQueue, storage.LocalStorage, *fakeUpdates) {
+	t.Helper()
+
+	local := storage.NewMemoryStorage(t.TempDir())
+	if err := local.SyncAllProducts(products); err != nil {
+		t.Fatalf("SyncAllProducts failed: %v", err)
+	}
+	central := &fakeUpdates{answer: answer}
+	server := httptest.NewServer(central)
+	t.Cleanup(server.Close)
+
+	q := NewWriteQueue(client.NewInventoryClient(server.URL, "key"), local, t.TempDir(), 0)
+	q.retryOptions = client.RetryOptions{MaxAttempts: 1}
+	return q, local, central
+}
+
+// productStock returns the cached stock and version of productID
+func productStock(t *testing.T, local storage.LocalStorage, productID string) (int, int) {
+	t.Helper()
+	product, err := local.GetProduct(productID)
+	if err != nil {
+		t.Fatalf("GetProduct failed: %v", err)
+	}
+	return product.Available, product.Version
+}
+
+// sale returns a queued update selling units of productID
+func sale(productID string, units int, key string) models.UpdateRequest {
+	return models.UpdateRequest{StoreID: "store-001", ProductID: productID, Delta: -units, Version: 1, IdempotencyKey: key}
+}
+
+func TestWriteQueue_EnqueueHonoursBackorderPolicy(t *testing.T) {
+	local := storage.NewMemoryStorage(t.TempDir())
+	err := local.SyncCategories([]models.Category{
+		{ID: "phones", Name: "Phones", StockPolicy: &models.StockPolicy{AllowBackorder: true, MaxBackorder: 3}},
+	})
+	if err != nil {
+		t.Fatalf("SyncCategories failed: %v", err)
+	}
+	err = local.SyncAllProducts([]models.Product{
+		{ProductID: "SKU-PLAIN", Available: 1, Version: 1},
+		{ProductID: "SKU-PHONE", Available: 1, Version: 1, Category: "phones"},
+	})
+	if err != nil {
+		t.Fatalf("SyncAllProducts failed: %v", err)
+	}
+	q := NewWriteQueue(nil, local, t.TempDir(), 0)
+
+	if _, err := q.Enqueue(sale("SKU-PLAIN", 2, "plain-1")); !errors.Is(err, ErrInsufficientLocalStock) {
+		t.Errorf("Expected a sale past zero to be refused, got %v", err)
+	}
+
+	product, err := q.Enqueue(sale("SKU-PHONE", 3, "phone-1"))
+	if err != nil {
+		t.Fatalf("Expected a backorder sale within the limit to be queued, got %v", err)
+	}
+	if product.Available != -2 {
+		t.Errorf("Expected the cache to show -2 after the backorder, got %d", product.Available)
+	}
+	if _, err := q.Enqueue(sale("SKU-PHONE", 2, "phone-2")); !errors.Is(err, ErrInsufficientLocalStock) {
+		t.Errorf("Expected a sale past the backorder limit to be refused, got %v", err)
+	}
+
+	// A restock is queued even while the stock is still negative
+	restock := sale("SKU-PHONE", -1, "phone-3")
+	if _, err := q.Enqueue(restock); err != nil {
+		t.Errorf("Expected a restock of backordered stock to be queued, got %v", err)
+	}
+	if got := q.PendingCount(); got != 2 {
+		t.Errorf("Expected 2 queued writes, got %d", got)
+	}
+}
+
+func TestIsConnectivityError(t *testing.T) {
+	apiErr := func(status int) error { return &client.APIError{StatusCode: status} }
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"open breaker", fmt.Errorf("update: %w", client.ErrCircuitOpen), true},
+		{"url error", &url.Error{Op: "Post", URL: "http://central", Err: errors.New("connection refused")}, true},
+		{"net error", fmt.Errorf("failed to make request: %w", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")}), true},
+		{"bad gateway", apiErr(http.StatusBadGateway), true},
+		{"unavailable", apiErr(http.StatusServiceUnavailable), true},
+		{"gateway timeout", apiErr(http.StatusGatewayTimeout), true},
+		{"load shed", apiErr(http.StatusTooManyRequests), true},
+		{"internal error", apiErr(http.StatusInternalServerError), false},
+		{"conflict", apiErr(http.StatusConflict), false},
+		{"insufficient inventory", apiErr(http.StatusUnprocessableEntity), false},
+		{"not found", apiErr(http.StatusNotFound), false},
+		{"decode failure", errors.New("failed to decode response"), false},
+		{"cancelled", context.Canceled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectivityError(tt.err); got != tt.want {
+				t.Errorf("isConnectivityError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteQueue_JournalSurvivesRestart(t *testing.T) {
+	local := storage.NewMemoryStorage(t.TempDir())
+	if err := local.SyncAllProducts([]models.Product{{ProductID: "SKU-001", Available: 10, Version: 1}}); err != nil {
+		t.Fatalf("SyncAllProducts failed: %v", err)
+	}
+	dataDir := t.TempDir()
+
+	first := NewWriteQueue(nil, local, dataDir, 0)
+	for i := 1; i <= 2; i++ {
+		if _, err := first.Enqueue(sale("SKU-001", i, fmt.Sprintf("key-%d", i))); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	second := NewWriteQueue(nil, local, dataDir, 0)
+	if err := second.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer second.Stop()
+
+	second.mu.Lock()
+	pending := append([]PendingWrite(nil), second.pending...)
+	second.mu.Unlock()
+	if len(pending) != 2 {
+		t.Fatalf("Expected 2 writes loaded from the journal, got %d", len(pending))
+	}
+	for i, entry := range pending {
+		want := sale("SKU-001", i+1, fmt.Sprintf("key-%d", i+1))
+		if entry.Update != want {
+			t.Errorf("Write %d: expected %+v, got %+v", i, want, entry.Update)
+		}
+		if entry.QueuedAt.IsZero() {
+			t.Errorf("Write %d lost its queue time", i)
+		}
+	}
+}
+
+func TestWriteQueue_ReplayReportsConflictsAndStopsWhenOffline(t *testing.T) {
+	products := []models.Product{
+		{ProductID: "SKU-001", Available: 10, Version: 1},
+		{ProductID: "SKU-002", Available: 5, Version: 1},
+		{ProductID: "SKU-003", Available: 5, Version: 1},
+	}
+	q, local, central := newReplayQueue(t, products, func(update models.UpdateRequest) (int, any) {
+		switch update.ProductID {
+		case "SKU-001":
+			return applied(update, 7, 4)
+		case "SKU-002":
+			return refused(update, http.StatusUnprocessableEntity, client.ErrorTypeInsufficientInventory, 1, 6)
+		}
+		return refused(update, http.StatusServiceUnavailable, "service_unavailable", 0, 0)
+	})
+
+	for i, update := range []models.UpdateRequest{sale("SKU-001", 2, "a"), sale("SKU-002", 3, "b"), sale("SKU-003", 1, "c")} {
+		if _, err := q.Enqueue(update); err != nil {
+			t.Fatalf("Enqueue %d failed: %v", i, err)
+		}
+	}
+
+	if err := q.Replay(context.Background()); err == nil {
+		t.Fatal("Expected the replay to stop at the unavailable central API")
+	}
+
+	status := q.Status()
+	if status.PendingCount != 1 || status.ReplayedCount != 1 || status.ConflictCount != 1 {
+		t.Fatalf("Expected 1 pending, 1 replayed and 1 conflict, got %+v", status)
+	}
+	conflict := status.RecentConflicts[0]
+	if conflict.Update.ProductID != "SKU-002" || conflict.Outcome != client.OutcomeInsufficientInventory {
+		t.Errorf("Expected SKU-002 reported as insufficient, got %+v", conflict)
+	}
+	if conflict.CentralVersion != 6 || conflict.CentralQuantity != 1 {
+		t.Errorf("Expected the central stock 1 at version 6, got %d at %d", conflict.CentralQuantity, conflict.CentralVersion)
+	}
+
+	// The cache follows the central answers; the offline write stays applied
+	for _, want := range []struct {
+		productID          string
+		available, version int
+	}{{"SKU-001", 7, 4}, {"SKU-002", 1, 6}, {"SKU-003", 4, 1}} {
+		if available, version := productStock(t, local, want.productID); available != want.available || version != want.version {
+			t.Errorf("%s: expected %d at version %d, got %d at %d", want.productID, want.available, want.version, available, version)
+		}
+	}
+
+	q.mu.Lock()
+	entry := q.pending[0]
+	q.mu.Unlock()
+	if entry.Update.ProductID != "SKU-003" || entry.Attempts != 1 || entry.LastError == "" {
+		t.Errorf("Expected SKU-003 kept with one failed attempt, got %+v", entry)
+	}
+	if len(central.received) != 3 {
+		t.Errorf("Expected 3 updates sent, got %d", len(central.received))
+	}
+}
+
+func TestWriteQueue_ReplayKeepsPendingDeltasOverEvents(t *testing.T) {
+	q, local, _ := newReplayQueue(t, []models.Product{{ProductID: "SKU-001", Available: 10, Version: 1}},
+		func(update models.UpdateRequest) (int, any) {
+			if update.IdempotencyKey == "key-1" {
+				return applied(update, 8, 3)
+			}
+			return refused(update, http.StatusServiceUnavailable, "service_unavailable", 0, 0)
+		})
+
+	for i := 1; i <= 2; i++ {
+		if _, err := q.Enqueue(sale("SKU-001", i, fmt.Sprintf("key-%d", i))); err != nil {
+			t.Fatalf("Enqueue failed: %v", err)
+		}
+	}
+
+	// Another store's sale arrives as an event and replaces the optimistic stock
+	if err := local.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 2, 9)}); err != nil {
+		t.Fatalf("ApplyEvents failed: %v", err)
+	}
+
+	// The first write is applied; the second is still owed to the central API
+	q.Replay(context.Background())
+	if available, version := productStock(t, local, "SKU-001"); available != 6 || version != 3 {
+		t.Errorf("Expected the central stock 8 less the pending sale of 2 at version 3, got %d at %d", available, version)
+	}
+}
+
+func TestWriteQueue_ReplayDoesNotRewindNewerEvent(t *testing.T) {
+	var local storage.LocalStorage
+	q, local, _ := newReplayQueue(t, []models.Product{{ProductID: "SKU-001", Available: 10, Version: 1}},
+		func(update models.UpdateRequest) (int, any) {
+			// A later change reaches the store while the answer is in flight
+			if err := local.ApplyEvents([]models.Event{updateEvent(0, "SKU-001", 5, 20)}); err != nil {
+				t.Errorf("ApplyEvents failed: %v", err)
+			}
+			return applied(update, 9, 2)
+		})
+
+	if _, err := q.Enqueue(sale("SKU-001", 1, "key-1")); err != nil {
+		t.Fatalf("Enqueue failed: %v", err)
+	}
+	if err := q.Replay(context.Background()); err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if available, version := productStock(t, local, "SKU-001"); available != 20 || version != 5 {
+		t.Errorf("Expected the newer event's stock 20 at version 5 to stay, got %d at %d", available, version)
+	}
+}
+
+// updateEvent returns a product_updated event carrying the product's stock
+func updateEvent(offset int64, productID string, version, available int) models.Event {
+	return models.Event{
+		Offset:    offset,
+		EventType: models.EventTypeProductUpdated,
+		ProductID: productID,
+		Version:   version,
+		Data:      models.ProductResponse{ProductID: productID, Available: available, Version: version},
+	}
+}
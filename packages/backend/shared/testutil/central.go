@@ -0,0 +1,229 @@
+// Package testutil runs central inventory APIs, and store services syncing
+// from them, for client, store and end-to-end tests.
+//
+// StartTestCentralAPI and StartTestStore run the real services as child
+// processes of the test, so end-to-end tests exercise the same routes,
+// middleware and persistence as production and can crash and restart a
+// service on the data it left behind. They cannot run in-process: the
+// central API lives in its own module and keeps its router in internal
+// packages, which Go does not let other modules import, and its container
+// build only sees its own directory, so it cannot depend on this module to
+// host the harness either. Each server is built from source once per test
+// binary, then every test gets its own processes on free ports with all
+// their data files in temp dirs.
+//
+// StartFakeCentralAPI is the in-process alternative for store and client
+// unit tests: an httptest server answering the routes the shared client
+// calls the way the central API does, with no build and no child process.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+)
+
+// Keys accepted by every test central API
+const (
+	TestAPIKey   = "test-key"
+	TestAdminKey = "test-admin-key"
+)
+
+// CentralKey returns the central API key bound to storeID by WithStores
+func CentralKey(storeID string) string {
+	return storeID + "-central-key"
+}
+
+// TestCentralAPI is a central inventory API running for one test
+type TestCentralAPI struct {
+	*Process
+	APIKey   string
+	AdminKey string
+	DataDir  string // Products, events, snapshots and reports live here
+
+	stores []string
+}
+
+// Option customizes a test central API before it starts
+type Option func(*options)
+
+type options struct {
+	env      map[string]string
+	products []models.Product
+	stores   []string
+}
+
+// WithEnv sets a central API environment variable, e.g. CHAOS_ENABLED
+func WithEnv(key, value string) Option {
+	return func(o *options) { o.env[key] = value }
+}
+
+// WithProducts starts the server with these products already in its data file.
+// Unlike SeedProducts it keeps their versions, so tests can set up conflicts.
+func WithProducts(products ...models.Product) Option {
+	return func(o *options) { o.products = append(o.products, products...) }
+}
+
+// WithStores gives each store its own central API key, CentralKey(storeID),
+// bound to the store as event consumers must be. StartTestStore only starts
+// stores bound this way.
+func WithStores(storeIDs ...string) Option {
+	return func(o *options) { o.stores = append(o.stores, storeIDs...) }
+}
+
+// StartTestCentralAPI builds and starts a central API with temp-file
+// persistence and stops it when the test ends. Set CENTRAL_API_BINARY to reuse
+// a prebuilt server, or CENTRAL_API_DIR when the central sources are not next
+// to this module. The test is skipped when neither is available.
+func StartTestCentralAPI(t testing.TB, opts ...Option) *TestCentralAPI {
+	t.Helper()
+
+	o := &options{env: map[string]string{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	binary := centralBuild.binary(t)
+	dataDir := t.TempDir()
+	// The server loads products from data/inventory_test_data.json under its
+	// working directory and persists them to DATA_PATH; point both at one file
+	dataPath := filepath.Join(dataDir, "data", "inventory_test_data.json")
+	if err := writeProductsFile(dataPath, o.products); err != nil {
+		t.Fatalf("failed to write products file: %v", err)
+	}
+
+	keys := []string{TestAPIKey}
+	var bindings []string
+	for _, storeID := range o.stores {
+		keys = append(keys, CentralKey(storeID))
+		bindings = append(bindings, CentralKey(storeID)+":"+storeID)
+	}
+
+	env := map[string]string{
+		"DATA_PATH":               dataPath,
+		"EVENTS_FILE_PATH":        filepath.Join(dataDir, "events.json"),
+		"SNAPSHOTS_DIR":           filepath.Join(dataDir, "snapshots"),
+		"EVENT_FILTERS_FILE_PATH": filepath.Join(dataDir, "event_filters.json"),
+		"REPORTS_DIR":             filepath.Join(dataDir, "reports"),
+		"ARCHIVE_DIR":             filepath.Join(dataDir, "archive"),
+		"API_KEYS":                strings.Join(keys, ","),
+		"ADMIN_API_KEYS":          TestAdminKey,
+		"API_KEY_STORES":          strings.Join(bindings, ","),
+		"ENVIRONMENT":             "test",
+		"LOG_LEVEL":               "warn",
+		"RATE_LIMIT_ENABLED":      "false",
+		// Flush persistence quickly so tests can inspect the data files
+		"PERSISTENCE_FLUSH_INTERVAL": "10ms",
+	}
+	for key, value := range o.env {
+		env[key] = value
+	}
+
+	api := &TestCentralAPI{
+		Process:  newProcess(t, "central API", binary, dataDir, env),
+		APIKey:   TestAPIKey,
+		AdminKey: TestAdminKey,
+		DataDir:  dataDir,
+		stores:   o.stores,
+	}
+	api.Start(t)
+	return api
+}
+
+// Client returns an inventory client authenticated with the test API key
+func (api *TestCentralAPI) Client() *client.InventoryClient {
+	return client.NewInventoryClient(api.BaseURL, api.APIKey)
+}
+
+// SeedProducts creates products through the admin API; they start at version 1
+func (api *TestCentralAPI) SeedProducts(t testing.TB, products ...models.Product) {
+	t.Helper()
+
+	type createProduct struct {
+		ProductID string         `json:"productId"`
+		Name      string         `json:"name"`
+		Available int            `json:"available"`
+		Price     float64        `json:"price"`
+		Prices    []models.Money `json:"prices,omitempty"`
+	}
+	create := make([]createProduct, len(products))
+	for i, product := range products {
+		create[i] = createProduct{
+			ProductID: product.ProductID,
+			Name:      product.Name,
+			Available: product.Available,
+			Price:     product.Price,
+			Prices:    product.Prices,
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"products": create})
+	if err != nil {
+		t.Fatalf("failed to marshal products: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, api.BaseURL+"/v1/admin/products/create", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", api.AdminKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to seed products: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		t.Fatalf("seeding products failed with status %d", resp.StatusCode)
+	}
+}
+
+// Events returns the events from offset on, without waiting for new ones
+func (api *TestCentralAPI) Events(t testing.TB, offset int64) []models.Event {
+	t.Helper()
+
+	response, err := api.Client().GetEvents(offset, 1000, 0)
+	if err != nil {
+		t.Fatalf("failed to read events: %v", err)
+	}
+	return response.Events
+}
+
+// writeProductsFile writes the central API's products file
+func writeProductsFile(path string, products []models.Product) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	byID := make(map[string]models.Product, len(products))
+	for _, product := range products {
+		if product.Version == 0 {
+			product.Version = 1
+		}
+		if product.LastUpdated.IsZero() {
+			product.LastUpdated = now
+		}
+		byID[product.ProductID] = product
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"products": byID,
+		"metadata": map[string]interface{}{
+			"lastOffset":    0,
+			"totalProducts": len(byID),
+			"lastUpdated":   now.Format(time.RFC3339),
+		},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,98 @@
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/idempotency"
+	"github.com/melibackend/shared/models"
+)
+
+func TestStartTestCentralAPI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the central API")
+	}
+
+	api := StartTestCentralAPI(t, WithProducts(models.Product{
+		ProductID: "SKU-001",
+		Name:      "Test Product",
+		Available: 10,
+		Version:   3,
+		Price:     9.99,
+	}))
+	api.SeedProducts(t, models.Product{ProductID: "SKU-002", Name: "Seeded Product", Available: 5, Price: 1.5})
+
+	inventoryClient := api.Client()
+	product, err := inventoryClient.GetProduct("SKU-001")
+	if err != nil {
+		t.Fatalf("GetProduct failed: %v", err)
+	}
+	if product.Version != 3 || product.Available != 10 {
+		t.Errorf("expected SKU-001 at version 3 with 10 units, got version %d with %d", product.Version, product.Available)
+	}
+
+	resp, err := inventoryClient.UpdateInventory(models.UpdateRequest{
+		StoreID:        "store-test",
+		ProductID:      "SKU-002",
+		Delta:          -2,
+		Version:        1,
+		IdempotencyKey: idempotency.NewKey(),
+	})
+	if err != nil {
+		t.Fatalf("UpdateInventory failed: %v", err)
+	}
+	if resp.NewQuantity != 3 {
+		t.Errorf("expected 3 units left, got %d", resp.NewQuantity)
+	}
+
+	found := false
+	for _, event := range api.Events(t, 0) {
+		if event.ProductID == "SKU-002" && event.StoreID == "store-test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a store update event for SKU-002")
+	}
+}
+
+func TestStartTestStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs the central API and a store")
+	}
+
+	central := StartTestCentralAPI(t, WithStores("store-t1"), WithProducts(models.Product{
+		ProductID: "SKU-001",
+		Name:      "Test Product",
+		Available: 10,
+		Version:   3,
+	}))
+	store := StartTestStore(t, central, "store-t1")
+
+	// A store restarted after a crash comes back on the same address
+	store.Crash(t)
+	store.Start(t)
+
+	var product models.Product
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		req, _ := http.NewRequest(http.MethodGet, store.BaseURL+"/v1/store/inventory/SKU-001", nil)
+		req.Header.Set("X-API-Key", store.APIKey)
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			if resp.StatusCode == http.StatusOK {
+				err = json.NewDecoder(resp.Body).Decode(&product)
+			}
+			resp.Body.Close()
+		}
+		if product.ProductID != "" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if product.Version != 3 || product.Available != 10 {
+		t.Errorf("expected the store to sync SKU-001 at version 3 with 10 units, got %+v", product)
+	}
+}
@@ -0,0 +1,479 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+)
+
+// FakeCentralAPI is an in-process central inventory API for store and client
+// unit tests, which need a central API answering over HTTP but not its
+// middleware, persistence or a built binary. It serves the routes the shared
+// client calls with the central API's status codes and envelopes:
+//
+//   - GET /health
+//   - GET /v1/inventory/{id}, POST /v1/inventory/batch-get and
+//     GET /v1/inventory/versions
+//   - GET /v1/inventory (JSON pages) and GET /v1/inventory/snapshot
+//   - POST /v1/inventory/updates, single and batch, with version checks,
+//     minAvailable/expectedAvailable conditions, the product's own backorder
+//     policy and per-store idempotency
+//   - GET /v1/inventory/events, answered at once without long polling, and
+//     POST /v1/inventory/events/commit
+//   - GET /v1/categories
+//
+// Anything else answers 404, and API keys are not checked. Tests that need
+// the real routes or a restartable process use StartTestCentralAPI.
+type FakeCentralAPI struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	products    map[string]models.Product
+	categories  map[string]models.Category
+	events      []models.Event
+	idempotency map[string]updateResult // Answers by store ID and key
+	committed   map[string]int64        // Committed event offsets by store ID
+	unavailable bool
+	requests    map[string]int // Requests by path
+}
+
+// updateResult is one update's answer in the central API's envelope
+type updateResult struct {
+	models.UpdateResponse
+	ErrorType    string `json:"errorType,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	status       int
+}
+
+// StartFakeCentralAPI starts a fake central API holding products, at version 1
+// unless they set one, and closes it when the test ends
+func StartFakeCentralAPI(t testing.TB, products ...models.Product) *FakeCentralAPI {
+	t.Helper()
+
+	f := &FakeCentralAPI{
+		products:    map[string]models.Product{},
+		categories:  map[string]models.Category{},
+		idempotency: map[string]updateResult{},
+		committed:   map[string]int64{},
+		requests:    map[string]int{},
+	}
+	for _, product := range products {
+		f.products[product.ProductID] = withDefaults(product)
+	}
+	f.Server = httptest.NewServer(f)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// withDefaults gives a product the version and timestamp the central API would
+func withDefaults(product models.Product) models.Product {
+	if product.Version == 0 {
+		product.Version = 1
+	}
+	if product.LastUpdated.IsZero() {
+		product.LastUpdated = time.Now().UTC()
+	}
+	return product
+}
+
+// Client returns an inventory client for the fake, with retries off so tests
+// see each answer as sent
+func (f *FakeCentralAPI) Client() *client.InventoryClient {
+	c := client.NewInventoryClient(f.URL, TestAPIKey)
+	c.SetRetryPolicy(client.RetryOptions{MaxAttempts: 1})
+	return c
+}
+
+// SeedProducts adds or replaces products, recording a product_updated event
+// for each as an admin change would
+func (f *FakeCentralAPI) SeedProducts(products ...models.Product) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, product := range products {
+		if existing, exists := f.products[product.ProductID]; exists && product.Version <= existing.Version {
+			product.Version = existing.Version + 1
+		}
+		product = withDefaults(product)
+		f.products[product.ProductID] = product
+		f.recordProductEvent(models.EventTypeProductUpdated, product, models.Event{})
+	}
+}
+
+// SetCategories adds or replaces categories, recording category_updated events
+func (f *FakeCentralAPI) SetCategories(categories ...models.Category) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, category := range categories {
+		f.categories[category.ID] = category
+		f.events = append(f.events, models.Event{
+			Offset:    int64(len(f.events)),
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			EventType: models.EventTypeCategoryUpdated,
+			Category:  &category,
+		})
+	}
+}
+
+// Product returns the fake's copy of a product
+func (f *FakeCentralAPI) Product(productID string) (models.Product, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	product, exists := f.products[productID]
+	return product, exists
+}
+
+// Events returns every event recorded so far
+func (f *FakeCentralAPI) Events() []models.Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.events)
+}
+
+// CommittedOffset returns the last event offset storeID committed
+func (f *FakeCentralAPI) CommittedOffset(storeID string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.committed[storeID]
+}
+
+// Requests returns how many requests were made to path
+func (f *FakeCentralAPI) Requests(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+// SetUnavailable makes every route answer 503, as a central API that is
+// down behind its load balancer would
+func (f *FakeCentralAPI) SetUnavailable(unavailable bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unavailable = unavailable
+}
+
+func (f *FakeCentralAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests[r.URL.Path]++
+	if f.unavailable {
+		writeFakeJSON(w, http.StatusServiceUnavailable, map[string]string{"code": "service_unavailable", "message": "central API unavailable"})
+		return
+	}
+
+	route := r.Method + " " + r.URL.Path
+	switch {
+	case route == "GET /health":
+		writeFakeJSON(w, http.StatusOK, models.HealthResponse{Status: "healthy", Service: "fake-central"})
+	case route == "GET /v1/inventory":
+		f.serveProductPage(w, r)
+	case route == "GET /v1/inventory/snapshot":
+		f.serveSnapshot(w)
+	case route == "GET /v1/inventory/versions":
+		f.serveVersions(w, r)
+	case route == "POST /v1/inventory/batch-get":
+		f.serveBatchGet(w, r)
+	case route == "POST /v1/inventory/updates":
+		f.serveUpdates(w, r)
+	case route == "GET /v1/inventory/events":
+		f.serveEvents(w, r)
+	case route == "POST /v1/inventory/events/commit":
+		var commit models.EventCommitRequest
+		json.NewDecoder(r.Body).Decode(&commit)
+		f.committed[r.Header.Get("X-Store-ID")] = commit.Offset
+		writeFakeJSON(w, http.StatusOK, commit)
+	case route == "GET /v1/categories":
+		f.serveCategories(w)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v1/inventory/"):
+		f.serveProduct(w, strings.TrimPrefix(r.URL.Path, "/v1/inventory/"))
+	default:
+		writeFakeJSON(w, http.StatusNotFound, map[string]string{"code": "not_found", "message": "route not served by the fake central API"})
+	}
+}
+
+// sortedProducts returns the catalog in product ID order, as listings page it
+func (f *FakeCentralAPI) sortedProducts() []models.Product {
+	products := make([]models.Product, 0, len(f.products))
+	for _, product := range f.products {
+		products = append(products, product)
+	}
+	slices.SortFunc(products, func(a, b models.Product) int { return strings.Compare(a.ProductID, b.ProductID) })
+	return products
+}
+
+func (f *FakeCentralAPI) serveProduct(w http.ResponseWriter, productID string) {
+	product, exists := f.products[productID]
+	if !exists {
+		writeFakeJSON(w, http.StatusNotFound, map[string]any{
+			"productId": productID, "errorType": client.ErrorTypeProductNotFound,
+			"errorMessage": "product not found: " + productID, "newQuantity": -1,
+		})
+		return
+	}
+	writeFakeJSON(w, http.StatusOK, product)
+}
+
+func (f *FakeCentralAPI) serveProductPage(w http.ResponseWriter, r *http.Request) {
+	products := f.sortedProducts()
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	offset = min(max(offset, 0), len(products))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 200
+	}
+	end := min(offset+limit, len(products))
+
+	page := map[string]any{
+		"products":    products[offset:end],
+		"pagination":  map[string]any{"total_count": len(products), "has_more": end < len(products)},
+		"eventOffset": len(f.events),
+	}
+	writeFakeJSON(w, http.StatusOK, page)
+}
+
+func (f *FakeCentralAPI) serveSnapshot(w http.ResponseWriter) {
+	products := f.sortedProducts()
+	writeFakeJSON(w, http.StatusOK, map[string]any{
+		"eventOffset":  len(f.events),
+		"productCount": len(products),
+		"products":     products,
+	})
+}
+
+func (f *FakeCentralAPI) serveVersions(w http.ResponseWriter, r *http.Request) {
+	response := models.VersionsResponse{Versions: map[string]models.ProductVersion{}, Missing: []string{}}
+	for _, productID := range strings.Split(r.URL.Query().Get("ids"), ",") {
+		if productID == "" {
+			continue
+		}
+		if product, exists := f.products[productID]; exists {
+			response.Versions[productID] = models.ProductVersion{Version: product.Version, Available: product.Available}
+		} else {
+			response.Missing = append(response.Missing, productID)
+		}
+	}
+	writeFakeJSON(w, http.StatusOK, response)
+}
+
+func (f *FakeCentralAPI) serveBatchGet(w http.ResponseWriter, r *http.Request) {
+	var request models.BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"code": client.ErrorTypeInvalidRequest, "message": err.Error()})
+		return
+	}
+	response := models.BatchGetResponse{Products: []models.Product{}, Missing: []string{}}
+	for _, productID := range request.ProductIDs {
+		if product, exists := f.products[productID]; exists {
+			response.Products = append(response.Products, product)
+		} else {
+			response.Missing = append(response.Missing, productID)
+		}
+	}
+	writeFakeJSON(w, http.StatusOK, response)
+}
+
+func (f *FakeCentralAPI) serveCategories(w http.ResponseWriter) {
+	categories := make([]models.Category, 0, len(f.categories))
+	for _, category := range f.categories {
+		categories = append(categories, category)
+	}
+	slices.SortFunc(categories, func(a, b models.Category) int { return strings.Compare(a.ID, b.ID) })
+	writeFakeJSON(w, http.StatusOK, models.CategoryListResponse{Categories: categories})
+}
+
+func (f *FakeCentralAPI) serveEvents(w http.ResponseWriter, r *http.Request) {
+	offset, _ := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	offset = min(max(offset, 0), int64(len(f.events)))
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	end := min(offset+int64(limit), int64(len(f.events)))
+
+	events := slices.Clone(f.events[offset:end])
+	writeFakeJSON(w, http.StatusOK, models.EventsResponse{
+		Events:        events,
+		NextOffset:    end,
+		HasMore:       end < int64(len(f.events)),
+		Count:         len(events),
+		CurrentOffset: int64(len(f.events)),
+	})
+}
+
+// serveUpdates answers a single update with its own status, or a batch with
+// 200 and a result per update, as the central API does
+func (f *FakeCentralAPI) serveUpdates(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		models.UpdateRequest
+		Updates []models.UpdateRequest `json:"updates"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, map[string]string{"code": client.ErrorTypeInvalidRequest, "message": err.Error()})
+		return
+	}
+	storeID := r.Header.Get("X-Store-ID")
+
+	if body.Updates == nil {
+		if body.StoreID == "" {
+			body.StoreID = storeID
+		}
+		result := f.applyUpdate(body.UpdateRequest)
+		writeFakeJSON(w, result.status, result)
+		return
+	}
+
+	if body.StoreID == "" {
+		body.StoreID = storeID
+	}
+	response := struct {
+		StoreID      string         `json:"storeId"`
+		Results      []updateResult `json:"results"`
+		TotalCount   int            `json:"totalCount"`
+		SuccessCount int            `json:"successCount"`
+		FailureCount int            `json:"failureCount"`
+	}{StoreID: body.StoreID, Results: []updateResult{}, TotalCount: len(body.Updates)}
+	for _, update := range body.Updates {
+		if update.StoreID == "" {
+			update.StoreID = body.StoreID
+		}
+		result := f.applyUpdate(update)
+		if result.Applied {
+			response.SuccessCount++
+		} else {
+			response.FailureCount++
+		}
+		response.Results = append(response.Results, result)
+	}
+	writeFakeJSON(w, http.StatusOK, response)
+}
+
+// applyUpdate checks and applies one update (caller holds the lock)
+func (f *FakeCentralAPI) applyUpdate(update models.UpdateRequest) updateResult {
+	cacheKey := update.StoreID + "\x00" + update.IdempotencyKey
+	if cached, exists := f.idempotency[cacheKey]; exists && update.IdempotencyKey != "" {
+		cached.Replayed = true
+		return cached
+	}
+
+	result := f.checkUpdate(update)
+	if update.IdempotencyKey != "" {
+		f.idempotency[cacheKey] = result
+	}
+	return result
+}
+
+// checkUpdate applies one update or returns why it cannot be applied
+func (f *FakeCentralAPI) checkUpdate(update models.UpdateRequest) updateResult {
+	product, exists := f.products[update.ProductID]
+	if !exists {
+		return updateFailure(update, http.StatusNotFound, client.ErrorTypeProductNotFound,
+			"product not found: "+update.ProductID, models.Product{Available: -1})
+	}
+
+	conditional := update.MinAvailable != nil || update.ExpectedAvailable != nil
+	if update.Version != product.Version && !(conditional && update.Version == 0) {
+		return updateFailure(update, http.StatusConflict, client.ErrorTypeVersionConflict,
+			fmt.Sprintf("version conflict: expected %d, got %d", product.Version, update.Version), product)
+	}
+	if update.ExpectedAvailable != nil && product.Available != *update.ExpectedAvailable {
+		return updateFailure(update, http.StatusPreconditionFailed, client.ErrorTypeConditionFailed,
+			fmt.Sprintf("condition failed: expected available %d, got %d", *update.ExpectedAvailable, product.Available), product)
+	}
+	if update.MinAvailable != nil && product.Available < *update.MinAvailable {
+		return updateFailure(update, http.StatusPreconditionFailed, client.ErrorTypeConditionFailed,
+			fmt.Sprintf("condition failed: available %d is below minAvailable %d", product.Available, *update.MinAvailable), product)
+	}
+
+	newQuantity := product.Available + update.Delta
+	floor := 0
+	if product.StockPolicy != nil {
+		floor = product.StockPolicy.StockFloor()
+	}
+	if update.Delta < 0 && newQuantity < floor {
+		return updateFailure(update, http.StatusUnprocessableEntity, client.ErrorTypeInsufficientInventory,
+			fmt.Sprintf("insufficient inventory: current %d, delta %d", product.Available, update.Delta), product)
+	}
+
+	product.Available = newQuantity
+	product.Version++
+	product.LastUpdated = time.Now().UTC()
+	f.products[product.ProductID] = product
+	f.recordProductEvent(models.EventTypeProductUpdated, product, models.Event{
+		StoreID: update.StoreID,
+		Delta:   update.Delta,
+		Reason:  update.Reason,
+	})
+
+	return updateResult{
+		UpdateResponse: models.UpdateResponse{
+			ProductID:      product.ProductID,
+			NewQuantity:    product.Available,
+			NewVersion:     product.Version,
+			Delta:          update.Delta,
+			IdempotencyKey: update.IdempotencyKey,
+			Applied:        true,
+		},
+		status: http.StatusOK,
+	}
+}
+
+// updateFailure is the answer to an update that was not applied, carrying
+// the product's current stock and version
+func updateFailure(update models.UpdateRequest, status int, errorType, message string, product models.Product) updateResult {
+	return updateResult{
+		UpdateResponse: models.UpdateResponse{
+			ProductID:      update.ProductID,
+			NewQuantity:    product.Available,
+			NewVersion:     product.Version,
+			Delta:          update.Delta,
+			IdempotencyKey: update.IdempotencyKey,
+		},
+		ErrorType:    errorType,
+		ErrorMessage: message,
+		status:       status,
+	}
+}
+
+// recordProductEvent appends an event carrying the product's new state, with
+// the store fields of extra (caller holds the lock)
+func (f *FakeCentralAPI) recordProductEvent(eventType string, product models.Product, extra models.Event) {
+	event := extra
+	event.Offset = int64(len(f.events))
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	event.EventType = eventType
+	event.ProductID = product.ProductID
+	event.Version = product.Version
+	event.Data = models.ProductResponse{
+		ProductID:      product.ProductID,
+		Name:           product.Name,
+		Available:      product.Available,
+		Version:        product.Version,
+		LastUpdated:    product.LastUpdated.Format(time.RFC3339),
+		Price:          product.Price,
+		Prices:         product.Prices,
+		Category:       product.Category,
+		Barcode:        product.Barcode,
+		BarcodeAliases: product.BarcodeAliases,
+		Assets:         product.Assets,
+		StockPolicy:    product.StockPolicy,
+	}
+	f.events = append(f.events, event)
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,111 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/melibackend/shared/client"
+	"github.com/melibackend/shared/models"
+)
+
+func TestFakeCentralAPI_ServesClient(t *testing.T) {
+	api := StartFakeCentralAPI(t,
+		models.Product{ProductID: "SKU-001", Name: "Phone", Available: 10, Version: 3},
+		models.Product{ProductID: "SKU-002", Name: "Case", Available: 1},
+	)
+	api.SetCategories(models.Category{ID: "phones", Name: "Phones"})
+	c := api.Client()
+	ctx := context.Background()
+
+	product, err := c.GetProductCtx(ctx, "SKU-001")
+	if err != nil {
+		t.Fatalf("GetProductCtx failed: %v", err)
+	}
+	if product.Version != 3 || product.Available != 10 {
+		t.Errorf("Expected SKU-001 at version 3 with 10 units, got version %d with %d", product.Version, product.Available)
+	}
+	if _, err := c.GetProductCtx(ctx, "SKU-404"); !client.IsErrorType(err, client.ErrorTypeProductNotFound) {
+		t.Errorf("Expected product_not_found, got %v", err)
+	}
+
+	products, err := c.GetAllProductsCtx(ctx)
+	if err != nil {
+		t.Fatalf("GetAllProductsCtx failed: %v", err)
+	}
+	if len(products) != 2 || products[0].ProductID != "SKU-001" || products[1].Version != 1 {
+		t.Errorf("Expected both products in ID order, got %+v", products)
+	}
+
+	categories, err := c.GetCategoriesCtx(ctx)
+	if err != nil {
+		t.Fatalf("GetCategoriesCtx failed: %v", err)
+	}
+	if len(categories) != 1 || categories[0].ID != "phones" {
+		t.Errorf("Expected the phones category, got %+v", categories)
+	}
+}
+
+func TestFakeCentralAPI_UpdatesLikeCentral(t *testing.T) {
+	api := StartFakeCentralAPI(t, models.Product{ProductID: "SKU-001", Available: 2, Version: 1})
+	c := api.Client()
+	c.SetStoreID("store-001")
+	ctx := context.Background()
+	update := models.UpdateRequest{StoreID: "store-001", ProductID: "SKU-001", Delta: -1, Version: 1, IdempotencyKey: "key-1"}
+
+	resp, err := c.UpdateInventoryCtx(ctx, update)
+	if err != nil {
+		t.Fatalf("UpdateInventoryCtx failed: %v", err)
+	}
+	if resp.NewQuantity != 1 || resp.NewVersion != 2 {
+		t.Errorf("Expected 1 unit at version 2, got %d at %d", resp.NewQuantity, resp.NewVersion)
+	}
+
+	// The same key is answered from the idempotency cache
+	replayed, err := c.UpdateInventoryCtx(ctx, update)
+	if err != nil || !replayed.Replayed || replayed.NewVersion != 2 {
+		t.Errorf("Expected the cached answer replayed, got %+v, %v", replayed, err)
+	}
+
+	update.IdempotencyKey = "key-2"
+	_, err = c.UpdateInventoryCtx(ctx, update)
+	apiErr, ok := client.AsAPIError(err)
+	if !ok || apiErr.StatusCode != http.StatusConflict || apiErr.NewVersion != 2 {
+		t.Errorf("Expected a 409 version conflict at version 2, got %v", err)
+	}
+
+	update.Version, update.Delta, update.IdempotencyKey = 2, -5, "key-3"
+	_, err = c.UpdateInventoryCtx(ctx, update)
+	if apiErr, ok := client.AsAPIError(err); !ok || apiErr.ErrorType != client.ErrorTypeInsufficientInventory || apiErr.NewQuantity != 1 {
+		t.Errorf("Expected insufficient_inventory with 1 unit left, got %v", err)
+	}
+
+	events, err := c.GetEventsCtx(ctx, 0, 10, 0)
+	if err != nil {
+		t.Fatalf("GetEventsCtx failed: %v", err)
+	}
+	if events.Count != 1 || events.Events[0].StoreID != "store-001" || events.Events[0].Data.Available != 1 {
+		t.Errorf("Expected one store update event, got %+v", events.Events)
+	}
+	if err := c.CommitEventOffsetCtx(ctx, events.NextOffset); err != nil {
+		t.Fatalf("CommitEventOffsetCtx failed: %v", err)
+	}
+	if got := api.CommittedOffset("store-001"); got != 1 {
+		t.Errorf("Expected offset 1 committed, got %d", got)
+	}
+}
+
+func TestFakeCentralAPI_Unavailable(t *testing.T) {
+	api := StartFakeCentralAPI(t, models.Product{ProductID: "SKU-001", Available: 2})
+	api.SetUnavailable(true)
+
+	_, err := api.Client().GetProductCtx(context.Background(), "SKU-001")
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected a 503, got %v", err)
+	}
+	if got := api.Requests("/v1/inventory/SKU-001"); got != 1 {
+		t.Errorf("Expected one request with retries off, got %d", got)
+	}
+}
@@ -0,0 +1,291 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startTimeout bounds how long a service may take to answer /health
+const startTimeout = 30 * time.Second
+
+// stopTimeout bounds a graceful shutdown before the process is killed
+const stopTimeout = 10 * time.Second
+
+// logTailLines is how much of a service's output a failed test prints
+const logTailLines = 40
+
+// Process is a service binary running as a child of the test, listening on a
+// loopback port with its data in a temp dir. It is stopped when the test ends.
+type Process struct {
+	Name    string
+	BaseURL string
+	Dir     string // Working directory holding the service's data
+
+	binary string
+	env    []string
+	port   string
+
+	cmd    *exec.Cmd
+	exited chan struct{}
+	logs   *syncBuffer
+}
+
+// newProcess prepares a service on a free port; Start runs it
+func newProcess(t testing.TB, name, binary, dir string, env map[string]string) *Process {
+	t.Helper()
+
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	p := &Process{
+		Name:    name,
+		BaseURL: "http://127.0.0.1:" + port,
+		Dir:     dir,
+		binary:  binary,
+		port:    port,
+		logs:    &syncBuffer{},
+	}
+	for key, value := range env {
+		p.env = append(p.env, key+"="+value)
+	}
+	p.env = append(p.env, "PORT="+port)
+
+	t.Cleanup(func() {
+		p.Stop()
+		if t.Failed() {
+			t.Logf("%s output:\n%s", p.Name, p.logTail())
+		}
+	})
+	return p
+}
+
+// Start runs the service and waits until it answers /health. A service
+// started again after Stop or Crash keeps its port and its data, so clients
+// and other services reach it where they did before.
+func (p *Process) Start(t testing.TB) {
+	t.Helper()
+
+	if p.cmd != nil {
+		t.Fatalf("%s is already running", p.Name)
+	}
+
+	// Run from the data dir so the service finds its files there and does
+	// not pick up a developer's .env
+	p.cmd = exec.Command(p.binary)
+	p.cmd.Dir = p.Dir
+	p.cmd.Env = append(os.Environ(), p.env...)
+	p.cmd.Stdout = p.logs
+	p.cmd.Stderr = p.logs
+	if err := p.cmd.Start(); err != nil {
+		p.cmd = nil
+		t.Fatalf("failed to start %s: %v", p.Name, err)
+	}
+	exited := make(chan struct{})
+	p.exited = exited
+	cmd := p.cmd
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	if err := p.waitHealthy(); err != nil {
+		t.Fatalf("%s did not start: %v\n%s", p.Name, err, p.logTail())
+	}
+}
+
+// Stop shuts the service down gracefully, killing it if it does not exit in time
+func (p *Process) Stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.exited:
+	case <-time.After(stopTimeout):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+	p.cmd = nil
+}
+
+// Crash kills the service without letting it shut down
+func (p *Process) Crash(t testing.TB) {
+	t.Helper()
+
+	if p.cmd == nil {
+		t.Fatalf("%s is not running", p.Name)
+	}
+	if err := p.cmd.Process.Kill(); err != nil {
+		t.Fatalf("failed to kill %s: %v", p.Name, err)
+	}
+	<-p.exited
+	p.cmd = nil
+}
+
+// Logs returns everything the service wrote to stdout and stderr
+func (p *Process) Logs() string {
+	return p.logs.String()
+}
+
+// logTail returns the last lines of the service's output
+func (p *Process) logTail() string {
+	lines := strings.Split(strings.TrimSpace(p.logs.String()), "\n")
+	if len(lines) > logTailLines {
+		lines = lines[len(lines)-logTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// waitHealthy polls /health until the service answers or exits
+func (p *Process) waitHealthy() error {
+	httpClient := &http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(startTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-p.exited:
+			p.cmd = nil
+			return fmt.Errorf("process exited")
+		default:
+		}
+
+		resp, err := httpClient.Get(p.BaseURL + "/health")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("no healthy response within %s", startTimeout)
+}
+
+// freePort asks the kernel for an unused TCP port
+func freePort() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return fmt.Sprint(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// serviceBuild locates and builds one service's server binary
+type serviceBuild struct {
+	name      string // Binary name under the shared build dir
+	binaryEnv string // Prebuilt binary to use instead of building
+	dirEnv    string // Sources to build when they are not at sourceDir
+	sourceDir string // Default sources, relative to this module's parent
+	label     string // Name in skip and error messages
+
+	once sync.Once
+	path string
+	err  error
+	skip string
+}
+
+var (
+	centralBuild = &serviceBuild{
+		name:      "central-api",
+		binaryEnv: "CENTRAL_API_BINARY",
+		dirEnv:    "CENTRAL_API_DIR",
+		sourceDir: filepath.Join("services", "inventory-management-system"),
+		label:     "central API",
+	}
+	storeBuild = &serviceBuild{
+		name:      "store-api",
+		binaryEnv: "STORE_API_BINARY",
+		dirEnv:    "STORE_API_DIR",
+		sourceDir: filepath.Join("services", "store"),
+		label:     "store API",
+	}
+)
+
+// binary returns a path to the service binary, building it the first time
+// in this test binary. The test is skipped when the sources are not found.
+func (b *serviceBuild) binary(t testing.TB) string {
+	t.Helper()
+
+	if binary := os.Getenv(b.binaryEnv); binary != "" {
+		return binary
+	}
+
+	b.once.Do(func() {
+		sourceDir := os.Getenv(b.dirEnv)
+		if sourceDir == "" {
+			_, file, _, _ := runtime.Caller(0)
+			sourceDir = filepath.Join(filepath.Dir(file), "..", "..", b.sourceDir)
+		}
+		if _, err := os.Stat(filepath.Join(sourceDir, "cmd", "server")); err != nil {
+			b.skip = fmt.Sprintf("%s sources not found at %s; set %s or %s", b.label, sourceDir, b.dirEnv, b.binaryEnv)
+			return
+		}
+		b.path, b.err = b.build(sourceDir)
+	})
+
+	if b.skip != "" {
+		t.Skip(b.skip)
+	}
+	if b.err != nil {
+		t.Fatalf("failed to build %s: %v", b.label, b.err)
+	}
+	return b.path
+}
+
+// build compiles the server into a temp file and renames it into place, so
+// test binaries of other packages building at the same time never see a
+// partial file
+func (b *serviceBuild) build(sourceDir string) (string, error) {
+	binDir := filepath.Join(os.TempDir(), "melibackend-test-services")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(binDir, b.name+"-build-*")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+
+	cmd := exec.Command("go", "build", "-o", tmp.Name(), "./cmd/server")
+	cmd.Dir = sourceDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("go build failed: %v\n%s", err, output)
+	}
+
+	binary := filepath.Join(binDir, b.name)
+	if err := os.Rename(tmp.Name(), binary); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return binary, nil
+}
+
+// syncBuffer collects output written from a process's pipes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
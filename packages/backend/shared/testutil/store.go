@@ -0,0 +1,55 @@
+package testutil
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestStoreAPI is a store service syncing from a test central API
+type TestStoreAPI struct {
+	*Process
+	StoreID string
+	APIKey  string // Accepted by the store's own API
+}
+
+// StartTestStore builds and starts a store service that syncs from central
+// every second, with its data in a temp dir, and stops it when the test ends.
+// central must have been started WithStores(storeID). Only WithEnv options
+// apply, setting store environment variables. Set STORE_API_BINARY to reuse a
+// prebuilt server, or STORE_API_DIR when the store sources are not next to
+// this module.
+func StartTestStore(t testing.TB, central *TestCentralAPI, storeID string, opts ...Option) *TestStoreAPI {
+	t.Helper()
+
+	if !slices.Contains(central.stores, storeID) {
+		t.Fatalf("the central API has no key bound to %s; start it WithStores(%q)", storeID, storeID)
+	}
+	o := &options{env: map[string]string{}}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	binary := storeBuild.binary(t)
+	dataDir := t.TempDir()
+	env := map[string]string{
+		"STORE_ID":                   storeID,
+		"DATA_DIR":                   dataDir,
+		"API_KEYS":                   TestAPIKey,
+		"CENTRAL_API_URL":            central.BaseURL,
+		"CENTRAL_API_KEY":            CentralKey(storeID),
+		"EVENT_WAIT_TIMEOUT_SECONDS": "1",
+		"SYNC_INTERVAL_SECONDS":      "1",
+		"LOG_LEVEL":                  "warn",
+	}
+	for key, value := range o.env {
+		env[key] = value
+	}
+
+	store := &TestStoreAPI{
+		Process: newProcess(t, storeID, binary, dataDir, env),
+		StoreID: storeID,
+		APIKey:  TestAPIKey,
+	}
+	store.Start(t)
+	return store
+}
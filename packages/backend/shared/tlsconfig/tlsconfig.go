@@ -0,0 +1,171 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader serves a certificate/key pair from disk and picks up rotated
+// files without a restart. Files are checked for changes at most once per
+// interval, on the next handshake; a pair that fails to load is logged and the
+// previous certificate stays in use.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	certMod   time.Time
+	keyMod    time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader loads the pair once; interval 0 disables reloading
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	reloader := &CertReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.current(), nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.current(), nil
+}
+
+// current returns the certificate, reloading it first when the files changed
+func (r *CertReloader) current() *tls.Certificate {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.interval > 0 && time.Since(r.lastCheck) >= r.interval {
+		r.lastCheck = time.Now()
+		if r.changed() {
+			if err := r.loadLocked(); err != nil {
+				slog.Warn("Failed to reload TLS certificate, keeping the previous one",
+					"cert_file", r.certFile, "error", err)
+			} else {
+				slog.Info("Reloaded rotated TLS certificate", "cert_file", r.certFile)
+			}
+		}
+	}
+	return r.cert
+}
+
+// changed reports whether either file was modified since the last load
+func (r *CertReloader) changed() bool {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false
+	}
+	return !certInfo.ModTime().Equal(r.certMod) || !keyInfo.ModTime().Equal(r.keyMod)
+}
+
+func (r *CertReloader) load() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastCheck = time.Now()
+	return r.loadLocked()
+}
+
+func (r *CertReloader) loadLocked() error {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat key: %w", err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate pair: %w", err)
+	}
+
+	r.cert = &cert
+	r.certMod = certInfo.ModTime()
+	r.keyMod = keyInfo.ModTime()
+	return nil
+}
+
+// ServerConfig returns the TLS configuration for serving HTTPS with the given
+// pair, or nil when no certificate is configured
+func ServerConfig(certFile, keyFile string, reloadInterval time.Duration) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and a key file are required")
+	}
+
+	reloader, err := NewCertReloader(certFile, keyFile, reloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}, nil
+}
+
+// ClientConfig returns the TLS configuration for calling an HTTPS server: caFile
+// replaces the system roots, and a certificate/key pair enables mutual TLS.
+// It returns nil when nothing is configured.
+func ClientConfig(caFile, certFile, keyFile string, reloadInterval time.Duration) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pool, err := LoadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and a key file are required")
+		}
+		reloader, err := NewCertReloader(certFile, keyFile, reloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
+	}
+
+	return tlsConfig, nil
+}
+
+// LoadCertPool reads PEM certificates into a pool
+func LoadCertPool(caFile string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+	return pool, nil
+}
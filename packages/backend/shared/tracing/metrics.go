@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// InitMetrics exports metrics over OTLP gRPC to
+// OTEL_EXPORTER_OTLP_METRICS_ENDPOINT (default localhost:4317) when
+// METRICS_EXPORTER is "otlp". Otherwise metrics stay local and the returned
+// meter is nil. The returned function flushes and stops the provider.
+func InitMetrics(ctx context.Context, serviceName string) (metric.Meter, func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+
+	if os.Getenv("METRICS_EXPORTER") != "otlp" {
+		return nil, noop, nil
+	}
+
+	exporter, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return nil, noop, err
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("Creating metrics resource, using default", "error", err)
+		res = resource.Default()
+	}
+
+	provider := sdkmetric.NewMeterProvider(
+		// The push interval follows OTEL_METRIC_EXPORT_INTERVAL (default 60s)
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(res),
+	)
+	otel.SetMeterProvider(provider)
+
+	slog.Info("Metrics export enabled", "exporter", "otlp")
+	return provider.Meter(TracerName), provider.Shutdown, nil
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for spans created by shared packages
+const TracerName = "github.com/melibackend/shared"
+
+// Tracer returns the shared tracer. It resolves through the global provider,
+// so spans are dropped until Init has run.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Init configures W3C trace context propagation and, unless TRACES_EXPORTER is
+// "none", an OTLP gRPC exporter sending to OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// (default localhost:4317). The returned function flushes and stops the provider.
+func Init(ctx context.Context, serviceName string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	noop := func(context.Context) error { return nil }
+
+	tracesExporter := os.Getenv("TRACES_EXPORTER")
+	if tracesExporter == "none" {
+		slog.Info("Tracing export disabled")
+		return noop, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return noop, err
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the default name
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("Creating trace resource, using default", "error", err)
+		res = resource.Default()
+	}
+
+	// Sampling follows OTEL_TRACES_SAMPLER / OTEL_TRACES_SAMPLER_ARG
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Starting tracing with grpc exporter", "service", serviceName)
+
+	return provider.Shutdown, nil
+}
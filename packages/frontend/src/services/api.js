@@ -74,7 +74,7 @@ export const updateInventory = async (productId, delta, idempotencyKey = null) =
     const body = {
       product_id: productId,
       delta: delta,
-      idempotency_key: idempotencyKey || `frontend-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`
+      idempotency_key: idempotencyKey || crypto.randomUUID()
     };
 
     return await apiRequest('/inventory', {